@@ -0,0 +1,78 @@
+// Command anonymize scrambles the personal data of a database, typically a
+// restored production dump, so it can be used in staging:
+//
+//	go run ./cmd/anonymize -database-url postgres://... -keep admin@example.com -yes
+//
+// Names, emails, addresses, phone and licence numbers are replaced,
+// locations are moved up to -jitter meters and free-text notes are cleared
+// (see package anonymize). It changes the database in place, so it refuses
+// to run without -yes.
+package main
+
+import (
+	"flag"
+	"log"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/anonymize"
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	cfg := config.Load()
+
+	var (
+		databaseURL = flag.String("database-url", cfg.DatabaseURL, "database connection string")
+		seed        = flag.Int64("seed", time.Now().UnixNano(), "random seed")
+		jitter      = flag.Float64("jitter", anonymize.DefaultJitterMeters, "largest distance in meters locations are moved")
+		keep        = flag.String("keep", "", "comma-separated emails of users to leave as they are")
+		keepNotes   = flag.Bool("keep-notes", false, "leave free-text notes and route messages")
+		confirmed   = flag.Bool("yes", false, "confirm the database is to be changed in place")
+	)
+	flag.Parse()
+	if !*confirmed {
+		log.Fatal("anonymize rewrites the database in place; run it on a copy and pass -yes")
+	}
+
+	db, err := database.Connect(*databaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get underlying sql.DB: %v", err)
+	}
+	defer sqlDB.Close()
+	db = db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Warn)})
+
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	var keepEmails []string
+	if *keep != "" {
+		keepEmails = strings.Split(*keep, ",")
+	}
+	started := time.Now()
+	report, err := anonymize.Run(db, anonymize.Config{
+		Seed:         *seed,
+		JitterMeters: *jitter,
+		KeepEmails:   keepEmails,
+		KeepNotes:    *keepNotes,
+	})
+	if err != nil {
+		log.Fatalf("Failed to anonymize database: %v", err)
+	}
+	log.Printf("Anonymized %d users, %d warehouses, %d customers, %d places, %d drivers, %d GPS tracks, %d recipients, %d security events and %d notes in %v",
+		report.Users, report.Warehouses, report.Customers, report.Places, report.Drivers,
+		report.Tracks, report.Recipients, report.SecurityEvents, report.Notes, time.Since(started))
+}
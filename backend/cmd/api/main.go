@@ -1,19 +1,38 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"log"
 	"os"
+	"time"
 
+	"LogiTrackPro/backend/internal/alerts"
 	"LogiTrackPro/backend/internal/config"
 	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/demand"
+	"LogiTrackPro/backend/internal/doctor"
 	"LogiTrackPro/backend/internal/handlers"
+	"LogiTrackPro/backend/internal/jobs"
 	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/planschedule"
+	"LogiTrackPro/backend/internal/push"
+	"LogiTrackPro/backend/internal/rolling"
+	"LogiTrackPro/backend/internal/securitylog"
+	"LogiTrackPro/backend/internal/snapshots"
+	"LogiTrackPro/backend/internal/storage"
+	"LogiTrackPro/backend/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"gorm.io/gorm/logger"
 )
 
 func main() {
+	doctorOnly := flag.Bool("doctor", false, "check the dependencies, print the report as JSON and exit (1 on failures) without migrating or serving")
+	flag.Parse()
+
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
@@ -35,16 +54,89 @@ func main() {
 	}
 	defer sqlDB.Close()
 
+	// Initialize optimizer client
+	optimizerClient, err := optimizer.New(cfg.OptimizerProtocol, cfg.OptimizerURL, cfg.OptimizerGRPCAddr)
+	if err != nil {
+		log.Fatalf("Failed to create optimizer client: %v", err)
+	}
+
+	// Initialize handlers
+	h := handlers.New(db, optimizerClient, cfg)
+
+	// The doctor checks the schema as it is, so it runs before migrations.
+	// Its report is the only output on stdout, where GORM logs queries.
+	if *doctorOnly {
+		db.Logger = db.Logger.LogMode(logger.Silent)
+		report := h.Doctor(context.Background())
+		out, _ := json.MarshalIndent(report, "", "  ")
+		os.Stdout.Write(append(out, '\n'))
+		sqlDB.Close()
+		if report.Status == doctor.Fail {
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Run migrations
 	if err := database.RunMigrations(db); err != nil {
 		log.Fatalf("Failed to run migrations: %v", err)
 	}
 
-	// Initialize optimizer client
-	optimizerClient := optimizer.NewClient(cfg.OptimizerURL)
-
-	// Initialize handlers
-	h := handlers.New(db, optimizerClient, cfg)
+	// Background jobs: recurring plan templates, rolling plans, daily
+	// inventory snapshots, low-stock alerts, export expiry, security event
+	// forwarding, push notifications, webhook deliveries, idempotency key
+	// expiry and resetting plans left optimizing
+	if cfg.JobPollInterval > 0 {
+		runner := jobs.NewRunner(db, time.Duration(cfg.JobPollInterval)*time.Second)
+		if cfg.PlanSchedulerInterval > 0 {
+			runner.Handle(planschedule.JobType, planschedule.New(db).RunJob)
+			runner.Every(planschedule.JobType, time.Duration(cfg.PlanSchedulerInterval)*time.Minute)
+		}
+		if cfg.PlanRollInterval > 0 {
+			runner.Handle(rolling.JobType, rolling.New(db, h.ReoptimizeRolledPlan).RunJob)
+			runner.Every(rolling.JobType, time.Duration(cfg.PlanRollInterval)*time.Minute)
+		}
+		if cfg.DailySnapshotTime != "off" {
+			schedule, err := snapshots.ParseSchedule(cfg.DailySnapshotTime, cfg.DailySnapshotTimezone)
+			if err != nil {
+				log.Fatalf("Invalid daily snapshot schedule: %v", err)
+			}
+			runner.Handle(snapshots.JobType, snapshots.New(db, schedule).RunJob)
+			runner.Schedule(snapshots.JobType, schedule.Next)
+		}
+		runner.Handle(alerts.JobType, alerts.New(db, cfg.AlertStockoutDays).RunJob)
+		if cfg.AlertScanInterval > 0 {
+			runner.Every(alerts.JobType, time.Duration(cfg.AlertScanInterval)*time.Minute)
+		}
+		if cfg.DemandEstimateInterval > 0 {
+			estimator := demand.New(db, cfg.DemandEstimateWindow, cfg.DemandSmoothingAlpha)
+			estimator.SetAutoApply(cfg.DemandAutoApply)
+			runner.Handle(demand.JobType, estimator.RunJob)
+			runner.Every(demand.JobType, time.Duration(cfg.DemandEstimateInterval)*time.Hour)
+		}
+		if artifacts := h.Artifacts(); artifacts != nil && cfg.StorageExportRetention > 0 {
+			rules := []storage.Rule{{Prefix: storage.ExportsPrefix, MaxAge: time.Duration(cfg.StorageExportRetention) * time.Hour}}
+			runner.Handle(storage.CleanupJobType, storage.CleanupJob(artifacts, rules))
+			runner.Every(storage.CleanupJobType, time.Hour)
+		}
+		if cfg.SecurityWebhookURL != "" {
+			runner.Handle(securitylog.ForwardJobType, securitylog.NewForwarder(db, cfg.SecurityWebhookURL, cfg.SecurityWebhookSecret).RunJob)
+		}
+		if cfg.PushGatewayURL != "" {
+			runner.Handle(push.JobType, push.NewSender(cfg.PushGatewayURL, cfg.PushGatewaySecret).RunJob)
+		}
+		deliverer := webhooks.NewDeliverer(db)
+		if cfg.WebhookAllowPrivateAddresses {
+			deliverer.AllowPrivateAddresses()
+		}
+		runner.Handle(webhooks.JobType, deliverer.RunJob)
+		runner.Handle(handlers.IdempotencyCleanupJobType, h.PurgeIdempotencyKeys)
+		runner.Every(handlers.IdempotencyCleanupJobType, time.Hour)
+		runner.Handle(handlers.StaleOptimizationJobType, h.ResetStaleOptimizations)
+		runner.Every(handlers.StaleOptimizationJobType, 10*time.Minute)
+		runner.PauseWhen(h.InMaintenance)
+		go runner.Run(context.Background())
+	}
 
 	// Setup router
 	router := setupRouter(h, cfg)
@@ -67,38 +159,89 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 
 	router := gin.Default()
 
+	// Client IPs, which rate limits are kept by, come from X-Forwarded-For
+	// only when the request is from a trusted proxy
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Printf("WARNING: invalid TRUSTED_PROXIES, trusting no proxy: %v", err)
+		router.SetTrustedProxies(nil)
+	}
+
 	// CORS middleware
 	router.Use(corsMiddleware())
 
+	// Compress responses for clients that accept gzip
+	router.Use(h.GzipMiddleware())
+
 	// Health check
 	router.GET("/health", h.HealthCheck)
 
-	// API v1 routes
-	v1 := router.Group("/api/v1")
+	// API v1 routes, rate limited per client IP and, once signed in, per user
+	v1 := router.Group(handlers.APIBasePath)
+	v1.Use(h.APIVersionMiddleware(1), h.IPRateLimitMiddleware())
 	{
 		// Auth routes (public)
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", h.Register)
+			auth.POST("/register", h.MaintenanceMiddleware(), h.Register)
 			auth.POST("/login", h.Login)
 			auth.POST("/refresh", h.RefreshToken)
 		}
 
+		// Signed artifact downloads (local storage driver)
+		v1.GET("/files/*key", h.ServeFile)
+
+		// API documentation (public)
+		v1.GET("/openapi.json", h.GetOpenAPI)
+		v1.GET("/docs", h.SwaggerUI)
+
 		// Protected routes
 		protected := v1.Group("")
-		protected.Use(h.AuthMiddleware())
+		protected.Use(protectedMiddleware(h)...)
 		{
 			// User routes
 			protected.GET("/me", h.GetCurrentUser)
+			protected.PUT("/me/password", h.ChangePassword)
+			protected.POST("/me/revoke-tokens", h.RevokeTokens)
+			protected.GET("/usage", h.GetUsage)
+			protected.GET("/onboarding", h.GetOnboarding)
 
 			// Warehouse routes
 			warehouses := protected.Group("/warehouses")
 			{
 				warehouses.GET("", h.ListWarehouses)
 				warehouses.POST("", h.CreateWarehouse)
+				warehouses.POST("/import", h.RoleMiddleware("admin", "manager"), h.ImportWarehouses)
+				warehouses.GET("/by-ref/:ref", h.GetWarehouseByRef)
+				warehouses.PUT("/by-ref/:ref", h.UpsertWarehouse)
 				warehouses.GET("/:id", h.GetWarehouse)
 				warehouses.PUT("/:id", h.UpdateWarehouse)
+				warehouses.PATCH("/:id", h.PatchWarehouse)
 				warehouses.DELETE("/:id", h.DeleteWarehouse)
+				warehouses.GET("/:id/day", h.GetWarehouseDay)
+				warehouses.GET("/:id/stock", h.GetWarehouseStock)
+				warehouses.GET("/:id/stock/movements", h.ListStockMovements)
+				warehouses.POST("/:id/stock/receipts", h.RoleMiddleware("admin", "manager"), h.ReceiveStock)
+				warehouses.POST("/:id/stock/adjustments", h.RoleMiddleware("admin", "manager"), h.AdjustStock)
+				warehouses.POST("/:id/stock/transfers", h.RoleMiddleware("admin", "manager"), h.TransferStock)
+			}
+
+			// Stock transfers between warehouses
+			transfers := protected.Group("/stock-transfers")
+			{
+				transfers.GET("", h.ListStockTransfers)
+				transfers.POST("", h.RoleMiddleware("admin", "manager"), h.CreateStockTransfer)
+				transfers.GET("/:id", h.GetStockTransfer)
+				transfers.POST("/:id/ship", h.RoleMiddleware("admin", "manager"), h.ShipStockTransfer)
+				transfers.POST("/:id/receive", h.RoleMiddleware("admin", "manager"), h.ReceiveStockTransfer)
+				transfers.POST("/:id/cancel", h.RoleMiddleware("admin", "manager"), h.CancelStockTransfer)
+			}
+
+			// Stocktakes of customers and warehouses
+			stocktakes := protected.Group("/stocktakes")
+			{
+				stocktakes.GET("", h.ListStocktakes)
+				stocktakes.POST("", h.RoleMiddleware("admin", "manager"), h.CreateStocktake)
+				stocktakes.GET("/:id", h.GetStocktake)
 			}
 
 			// Customer routes
@@ -106,9 +249,47 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 			{
 				customers.GET("", h.ListCustomers)
 				customers.POST("", h.CreateCustomer)
+				customers.POST("/import", h.RoleMiddleware("admin", "manager"), h.ImportCustomers)
+				customers.POST("/bulk-delete", h.RoleMiddleware("admin", "manager"), h.BulkDeleteCustomers)
+				customers.POST("/bulk-update", h.RoleMiddleware("admin", "manager"), h.BulkUpdateCustomers)
+				customers.GET("/by-ref/:ref", h.GetCustomerByRef)
+				customers.PUT("/by-ref/:ref", h.UpsertCustomer)
 				customers.GET("/:id", h.GetCustomer)
 				customers.PUT("/:id", h.UpdateCustomer)
+				customers.PATCH("/:id", h.PatchCustomer)
 				customers.DELETE("/:id", h.DeleteCustomer)
+				customers.PUT("/:id/tags", h.SetCustomerTags)
+				customers.GET("/:id/inventory-forecast", h.GetInventoryForecast)
+				customers.GET("/:id/inventory-adjustments", h.ListCustomerInventoryAdjustments)
+				customers.POST("/:id/inventory-adjustments", h.RoleMiddleware("admin", "manager"), h.AdjustCustomerInventory)
+				customers.GET("/:id/demand-estimate", h.GetDemandEstimate)
+				customers.POST("/:id/demand-estimate", h.RoleMiddleware("admin", "manager"), h.EstimateDemand)
+				customers.POST("/:id/demand-estimate/apply", h.RoleMiddleware("admin", "manager"), h.ApplyDemandEstimate)
+				customers.GET("/:id/demand-estimates", h.ListDemandEstimates)
+				customers.GET("/:id/products", h.ListCustomerProducts)
+				customers.PUT("/:id/products/:product_id", h.SetCustomerProduct)
+				customers.DELETE("/:id/products/:product_id", h.DeleteCustomerProduct)
+			}
+
+			// Low-stock alerts
+			alerts := protected.Group("/alerts")
+			{
+				alerts.GET("", h.ListAlerts)
+				alerts.GET("/:id", h.GetAlert)
+				alerts.POST("/:id/acknowledge", h.RoleMiddleware("admin", "manager", "user"), h.AcknowledgeAlert)
+				alerts.POST("/:id/resolve", h.RoleMiddleware("admin", "manager", "user"), h.ResolveAlert)
+			}
+
+			// Products for multi-product planning
+			products := protected.Group("/products")
+			{
+				products.GET("", h.ListProducts)
+				products.POST("", h.CreateProduct)
+				products.GET("/by-ref/:ref", h.GetProductByRef)
+				products.PUT("/by-ref/:ref", h.UpsertProduct)
+				products.GET("/:id", h.GetProduct)
+				products.PUT("/:id", h.UpdateProduct)
+				products.DELETE("/:id", h.DeleteProduct)
 			}
 
 			// Vehicle routes
@@ -116,37 +297,196 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 			{
 				vehicles.GET("", h.ListVehicles)
 				vehicles.POST("", h.CreateVehicle)
+				vehicles.POST("/import", h.RoleMiddleware("admin", "manager"), h.ImportVehicles)
+				vehicles.POST("/bulk-delete", h.RoleMiddleware("admin", "manager"), h.BulkDeleteVehicles)
+				vehicles.POST("/bulk-update", h.RoleMiddleware("admin", "manager"), h.BulkUpdateVehicles)
+				vehicles.GET("/by-ref/:ref", h.GetVehicleByRef)
+				vehicles.PUT("/by-ref/:ref", h.UpsertVehicle)
 				vehicles.GET("/:id", h.GetVehicle)
 				vehicles.PUT("/:id", h.UpdateVehicle)
+				vehicles.PATCH("/:id", h.PatchVehicle)
 				vehicles.DELETE("/:id", h.DeleteVehicle)
+				vehicles.PUT("/:id/tags", h.SetVehicleTags)
+				vehicles.GET("/:id/history", h.GetVehicleHistory)
+				vehicles.GET("/:id/maintenance", h.ListVehicleMaintenance)
+				vehicles.POST("/:id/maintenance", h.RoleMiddleware("admin", "manager"), h.RecordVehicleMaintenance)
+			}
+
+			// Drivers
+			drivers := protected.Group("/drivers")
+			{
+				drivers.GET("", h.ListDrivers)
+				drivers.POST("", h.CreateDriver)
+				drivers.GET("/:id", h.GetDriver)
+				drivers.PUT("/:id", h.UpdateDriver)
+				drivers.DELETE("/:id", h.DeleteDriver)
+				drivers.GET("/:id/absences", h.ListDriverAbsences)
+				drivers.POST("/:id/absences", h.CreateDriverAbsence)
+			}
+
+			// Address book of non-customer places
+			places := protected.Group("/places")
+			{
+				places.GET("", h.ListPlaces)
+				places.POST("", h.CreatePlace)
+				places.GET("/:id", h.GetPlace)
+				places.PUT("/:id", h.UpdatePlace)
+				places.DELETE("/:id", h.DeletePlace)
+			}
+
+			// Driver rosters
+			rosters := protected.Group("/rosters")
+			{
+				rosters.GET("", h.ListRoster)
+				rosters.POST("", h.CreateRosterEntries)
+				rosters.GET("/conflicts", h.GetRosterConflicts)
+				rosters.DELETE("/:id", h.DeleteRosterEntry)
+			}
+
+			// Driver absences
+			absences := protected.Group("/absences")
+			{
+				absences.GET("", h.ListAbsences)
+				absences.POST("/:id/approve", h.RoleMiddleware("admin", "manager"), h.ApproveAbsence)
+				absences.POST("/:id/reject", h.RoleMiddleware("admin", "manager"), h.RejectAbsence)
+				absences.DELETE("/:id", h.RoleMiddleware("admin", "manager"), h.DeleteAbsence)
 			}
 
 			// Plan routes
-			plans := protected.Group("/plans")
+			plans := protected.Group("/plans", h.ConditionalGetMiddleware())
 			{
 				plans.GET("", h.ListPlans)
 				plans.POST("", h.CreatePlan)
 				plans.GET("/:id", h.GetPlan)
 				plans.DELETE("/:id", h.DeletePlan)
+				plans.POST("/:id/clone", h.ClonePlan)
+				plans.POST("/:id/template", h.CreatePlanTemplateFromPlan)
 				plans.POST("/:id/optimize", h.OptimizePlan)
+				plans.POST("/:id/reoptimize", h.ReoptimizePlan)
+				plans.PUT("/:id/rolling", h.SetPlanRolling)
+				plans.PUT("/:id/objective", h.SetPlanObjective)
+				plans.PUT("/:id/notes", h.RoleMiddleware("admin", "manager", "user"), h.SetPlanNotes)
+				plans.POST("/:id/approve", h.RoleMiddleware("admin", "manager"), h.ApprovePlan)
+				plans.POST("/:id/execute", h.RoleMiddleware("admin", "manager", "user"), h.ExecutePlan)
+				plans.POST("/:id/complete", h.RoleMiddleware("admin", "manager", "user"), h.CompletePlan)
+				plans.POST("/:id/cancel", h.RoleMiddleware("admin", "manager", "user"), h.CancelPlan)
+				plans.POST("/:id/archive", h.RoleMiddleware("admin", "manager", "user"), h.ArchivePlan)
+				plans.GET("/:id/optimization-progress", h.GetOptimizationProgress)
+				plans.GET("/:id/stream", h.StreamPlan)
+				plans.GET("/:id/unrouted", h.ListUnroutedCustomers)
+				plans.POST("/:id/unrouted/force", h.ForceUnroutedCustomers)
+				plans.GET("/:id/redeliveries", h.ListRedeliveries)
+				plans.GET("/:id/summary", h.GetPlanSummary)
+				plans.GET("/:id/dispatch-check", h.GetDispatchCheck)
+				plans.GET("/:id/solutions", h.ListPlanSolutions)
+				plans.GET("/:id/solutions/:version", h.GetPlanSolution)
+				plans.POST("/:id/solutions/:version/rollback", h.RollbackPlanSolution)
 				plans.GET("/:id/routes", h.GetPlanRoutes)
+				plans.GET("/:id/routes.geojson", h.GetPlanRoutesGeoJSON)
+				plans.GET("/:id/timeline", h.GetPlanTimeline)
+				plans.GET("/:id/costs", h.GetPlanCosts)
+				plans.GET("/:id/load-check", h.GetPlanLoadCheck)
+				plans.GET("/:id/export", h.ExportPlan)
+				plans.POST("/:id/replay-inputs", h.ReplayPlanInputs)
 				plans.GET("/:id/execution-stats", h.GetPlanExecutionStats)
+				plans.GET("/:id/deviation-report", h.GetDeviationReport)
+				plans.POST("/:id/scenarios", h.CreateScenario)
+				plans.GET("/:id/scenarios", h.ListPlanScenarios)
+			}
+
+			// Recurring plan template routes
+			planTemplates := protected.Group("/plan-templates")
+			{
+				planTemplates.GET("", h.ListPlanTemplates)
+				planTemplates.POST("", h.CreatePlanTemplate)
+				planTemplates.GET("/:id", h.GetPlanTemplate)
+				planTemplates.PUT("/:id", h.UpdatePlanTemplate)
+				planTemplates.DELETE("/:id", h.DeletePlanTemplate)
+				planTemplates.POST("/:id/instantiate", h.InstantiatePlanTemplate)
+			}
+
+			// Scenario (what-if) routes
+			scenarios := protected.Group("/scenarios")
+			{
+				scenarios.GET("/compare", h.CompareScenarios)
+				scenarios.GET("/:id", h.GetScenario)
+				scenarios.DELETE("/:id", h.DeleteScenario)
+				scenarios.POST("/:id/optimize", h.OptimizeScenario)
+			}
+
+			// Daily dispatch across plans
+			dispatch := protected.Group("/dispatch")
+			{
+				dispatch.GET("/export", h.ExportDispatch)
 			}
 
 			// Route execution routes
-			routes := protected.Group("/routes")
+			routes := protected.Group("/routes", h.ConditionalGetMiddleware())
 			{
 				routes.POST("/:id/executions", h.CreateRouteExecution)
 				routes.GET("/:id/executions", h.GetRouteExecutions)
+				routes.GET("/:id/messages", h.GetRouteMessages)
+				routes.POST("/:id/messages", h.SendRouteMessage)
+				routes.POST("/:id/messages/read", h.MarkRouteMessagesRead)
+				routes.PUT("/:id/lock", h.LockRoute)
+				routes.GET("/:id/explain", h.ExplainRoute)
+				routes.GET("/:id/load-plan", h.GetRouteLoadPlan)
+				routes.GET("/:id/stops", h.GetRouteStops)
+				routes.POST("/:id/stops", h.InsertStop)
+			}
+
+			// Stop routes
+			stops := protected.Group("/stops")
+			{
+				stops.PATCH("/:id", h.UpdateStop)
+				stops.DELETE("/:id", h.DeleteStop)
+				stops.GET("/:id/products", h.ListStopProducts)
+				stops.PUT("/:id/products", h.SetStopProducts)
 			}
 
 			// Execution routes
 			executions := protected.Group("/executions")
 			{
+				executions.POST("/bulk", h.RoleMiddleware("admin", "manager", "user"), h.BulkUpdateExecutions)
+				executions.GET("/export", h.RoleMiddleware("admin", "manager"), h.ExportExecutions)
 				executions.GET("/:id", h.GetRouteExecution)
 				executions.PUT("/:id", h.UpdateRouteExecution)
 				executions.POST("/:id/start", h.StartRouteExecution)
 				executions.POST("/:id/complete", h.CompleteRouteExecution)
+				executions.GET("/:id/stops", h.ListStopExecutions)
+				executions.PUT("/:id/stops/:stop_execution_id", h.UpdateStopExecution)
+				executions.POST("/:id/locations", h.RecordLocations)
+				executions.GET("/:id/etas", h.GetExecutionETAs)
+				executions.GET("/:id/timeline", h.GetExecutionTimeline)
+				executions.PUT("/:id/shift", h.RecordExecutionShift)
+				executions.POST("/:id/incidents", h.ReportIncident)
+			}
+
+			// Incidents, for the safety team
+			incidents := protected.Group("/incidents", h.RoleMiddleware("admin", "manager"))
+			{
+				incidents.GET("", h.ListIncidents)
+				incidents.GET("/:id", h.GetIncident)
+			}
+
+			// Proof of delivery
+			stopExecutions := protected.Group("/stop-executions")
+			{
+				stopExecutions.POST("/:id/pod", h.UploadProofOfDelivery)
+				stopExecutions.GET("/:id/pod", h.GetProofOfDelivery)
+			}
+
+			// Redeliveries of short and failed stops
+			redeliveries := protected.Group("/redeliveries")
+			{
+				redeliveries.POST("/:id/cancel", h.RoleMiddleware("admin", "manager", "user"), h.CancelRedelivery)
+			}
+
+			// Driver app
+			driver := protected.Group("/driver", h.RoleMiddleware("driver"))
+			{
+				driver.GET("/routes", h.GetDriverRoutes)
+				driver.POST("/stops/:id/events", h.PostDriverStopEvent)
 			}
 
 			// Inventory snapshot routes
@@ -155,20 +495,121 @@ func setupRouter(h *handlers.Handler, cfg *config.Config) *gin.Engine {
 				inventory.POST("/snapshots", h.CreateInventorySnapshot)
 				inventory.GET("/snapshots", h.GetInventorySnapshots)
 				inventory.GET("/history", h.GetInventoryHistory)
+				inventory.GET("/history/aggregate", h.GetInventoryHistoryAggregate)
 			}
 
 			// Analytics routes
-			analytics := protected.Group("/analytics")
+			analytics := protected.Group("/analytics", h.ConditionalGetMiddleware())
 			{
 				analytics.GET("/dashboard", h.GetDashboard)
 				analytics.GET("/summary", h.GetSummary)
+				analytics.GET("/customer-portfolio", h.GetCustomerPortfolio)
+				analytics.GET("/plan-accuracy", h.GetPlanAccuracy)
+				analytics.GET("/driver-hours", h.GetDriverHours)
+				analytics.GET("/vehicle-efficiency", h.GetVehicleEfficiency)
 			}
+
+			// Tags for customers and vehicles
+			tags := protected.Group("/tags")
+			{
+				tags.GET("", h.ListTags)
+				tags.POST("", h.RoleMiddleware("admin", "manager"), h.CreateTag)
+				tags.PUT("/:id", h.RoleMiddleware("admin", "manager"), h.UpdateTag)
+				tags.DELETE("/:id", h.RoleMiddleware("admin", "manager"), h.DeleteTag)
+			}
+
+			// Webhook subscriptions and their delivery log
+			hooks := protected.Group("/webhooks", h.RoleMiddleware("admin", "manager"))
+			{
+				hooks.GET("", h.ListWebhooks)
+				hooks.POST("", h.CreateWebhook)
+				hooks.GET("/:id", h.GetWebhook)
+				hooks.PUT("/:id", h.UpdateWebhook)
+				hooks.DELETE("/:id", h.DeleteWebhook)
+				hooks.POST("/:id/ping", h.PingWebhook)
+				hooks.GET("/:id/deliveries", h.ListWebhookDeliveries)
+				hooks.GET("/:id/deliveries/:delivery_id", h.GetWebhookDelivery)
+				hooks.POST("/:id/deliveries/:delivery_id/redeliver", h.RedeliverWebhook)
+			}
+
+			// Security log (admins only)
+			protected.GET("/security-events", h.AdminMiddleware(), h.ListSecurityEvents)
+
+			// Admin routes
+			admin := protected.Group("/admin")
+			admin.Use(h.AdminMiddleware())
+			{
+				admin.GET("/optimization-runs", h.ListOptimizationRuns)
+				admin.GET("/optimization-runs/:id", h.GetOptimizationRun)
+				admin.GET("/optimization-runs/:id/download", h.DownloadOptimizationRun)
+				admin.DELETE("/plans/:id", h.PurgePlan)
+				admin.POST("/inventory-snapshots/daily", h.TakeDailySnapshots)
+				admin.GET("/jobs", h.ListJobs)
+				admin.GET("/jobs/:id", h.GetJob)
+				admin.POST("/jobs/:id/retry", h.RetryJob)
+				admin.POST("/jobs/:id/cancel", h.CancelJob)
+				admin.PUT("/users/:id/role", h.UpdateUserRole)
+				admin.PUT("/users/:id/organization", h.UpdateUserOrganization)
+				admin.POST("/users/:id/revoke-tokens", h.RevokeUserTokens)
+				admin.GET("/organizations", h.ListOrganizations)
+				admin.POST("/organizations", h.CreateOrganization)
+				admin.PUT("/organizations/:id", h.UpdateOrganization)
+				admin.GET("/organizations/:id/usage", h.GetOrganizationUsage)
+				admin.GET("/maintenance", h.GetMaintenance)
+				admin.PUT("/maintenance", h.SetMaintenance)
+				admin.GET("/doctor", h.GetDoctor)
+			}
+		}
+	}
+
+	// API v2 routes: the v1 handlers with the v2 response shapes, starting
+	// with the list endpoints and their paginated envelope
+	v2 := router.Group(handlers.APIV2BasePath)
+	v2.Use(h.APIVersionMiddleware(2), h.IPRateLimitMiddleware())
+	{
+		v2.GET("/openapi.json", h.GetOpenAPI)
+		v2.GET("/docs", h.SwaggerUI)
+
+		protected := v2.Group("")
+		protected.Use(protectedMiddleware(h)...)
+		{
+			protected.GET("/warehouses/:id/stock/movements", h.ListStockMovements)
+			protected.GET("/stock-transfers", h.ListStockTransfers)
+			protected.GET("/stocktakes", h.ListStocktakes)
+			protected.GET("/customers", h.ListCustomers)
+			protected.GET("/customers/:id/inventory-adjustments", h.ListCustomerInventoryAdjustments)
+			protected.GET("/customers/:id/demand-estimates", h.ListDemandEstimates)
+			protected.GET("/alerts", h.ListAlerts)
+
+			plans := protected.Group("/plans", h.ConditionalGetMiddleware())
+			{
+				plans.GET("", h.ListPlans)
+				plans.GET("/:id/routes", h.GetPlanRoutes)
+			}
+
+			protected.GET("/incidents", h.RoleMiddleware("admin", "manager"), h.ListIncidents)
+			protected.GET("/webhooks/:id/deliveries", h.RoleMiddleware("admin", "manager"), h.ListWebhookDeliveries)
+			protected.GET("/security-events", h.AdminMiddleware(), h.ListSecurityEvents)
 		}
 	}
 
+	h.SetReplayHandler(router)
 	return router
 }
 
+// protectedMiddleware is the chain of every version's signed-in routes
+func protectedMiddleware(h *handlers.Handler) []gin.HandlerFunc {
+	return []gin.HandlerFunc{h.AuthMiddleware(), h.UserRateLimitMiddleware(), h.UsageMiddleware(), h.MaintenanceMiddleware(), h.IdempotencyMiddleware()}
+}
+
+// CORS lists of the methods and request headers browsers may use and the
+// response headers their scripts may read
+const (
+	corsAllowMethods  = "GET, POST, PUT, PATCH, DELETE, OPTIONS"
+	corsAllowHeaders  = "Origin, Content-Type, Authorization, If-Match, Idempotency-Key, If-None-Match"
+	corsExposeHeaders = "Content-Length, ETag, Retry-After, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, X-Quota-Limit, X-Quota-Remaining"
+)
+
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Whitelist of allowed origins
@@ -181,14 +622,14 @@ func corsMiddleware() gin.HandlerFunc {
 		if allowedOrigins[origin] {
 			c.Header("Access-Control-Allow-Origin", origin)
 			c.Header("Access-Control-Allow-Credentials", "true")
-			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
-			c.Header("Access-Control-Expose-Headers", "Content-Length")
+			c.Header("Access-Control-Allow-Methods", corsAllowMethods)
+			c.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+			c.Header("Access-Control-Expose-Headers", corsExposeHeaders)
 		} else if origin == "" {
 			c.Header("Access-Control-Allow-Origin", "*")
-			c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Authorization")
-			c.Header("Access-Control-Expose-Headers", "Content-Length")
+			c.Header("Access-Control-Allow-Methods", corsAllowMethods)
+			c.Header("Access-Control-Allow-Headers", corsAllowHeaders)
+			c.Header("Access-Control-Expose-Headers", corsExposeHeaders)
 		}
 
 		if c.Request.Method == "OPTIONS" {
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/handlers"
+	"LogiTrackPro/backend/internal/openapi"
+	"LogiTrackPro/backend/internal/testkit"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testRouter(t *testing.T) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWTSecret: "test-secret-key", JWTExpiry: 24}
+	return setupRouter(handlers.New(testkit.DB(t), nil, cfg), cfg)
+}
+
+// TestOpenAPIMatchesRoutes keeps the documented operations and the
+// registered routes of each API version in step
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	router := testRouter(t)
+
+	for _, version := range []struct {
+		basePath   string
+		operations []openapi.Operation
+		doc        *openapi.Document
+	}{
+		{handlers.APIBasePath, handlers.Operations, handlers.OpenAPIDocument()},
+		{handlers.APIV2BasePath, handlers.V2Operations, handlers.V2OpenAPIDocument()},
+	} {
+		registered := make(map[string]bool)
+		for _, r := range router.Routes() {
+			if !strings.HasPrefix(r.Path, version.basePath+"/") {
+				continue
+			}
+			registered[r.Method+" "+strings.TrimPrefix(r.Path, version.basePath)] = true
+		}
+		documented := make(map[string]bool)
+		for _, op := range version.operations {
+			key := op.Method + " " + op.Path
+			if documented[key] {
+				t.Errorf("%s: %s is documented twice", version.basePath, key)
+			}
+			documented[key] = true
+			if !registered[key] {
+				t.Errorf("%s: %s is documented but not registered", version.basePath, key)
+			}
+		}
+		for key := range registered {
+			if !documented[key] {
+				t.Errorf("%s: %s is registered but not documented", version.basePath, key)
+			}
+		}
+
+		for _, op := range version.operations {
+			if version.doc.Paths[openapi.Path(op.Path)][strings.ToLower(op.Method)] == nil {
+				t.Errorf("%s: %s %s is missing from the document", version.basePath, op.Method, op.Path)
+			}
+		}
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	router := testRouter(t)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("openapi.json status = %d, want 200 without a token", w.Code)
+	}
+	var doc openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("openapi.json is not a document: %v", err)
+	}
+	if doc.OpenAPI != openapi.Version || doc.Servers[0].URL != "/api/v1" {
+		t.Errorf("document = %s at %+v, want %s at /api/v1", doc.OpenAPI, doc.Servers, openapi.Version)
+	}
+	create := doc.Paths["/customers"]["post"]
+	if create == nil || create.RequestBody == nil || len(create.Security) == 0 {
+		t.Fatalf("POST /customers = %+v, want a secured operation with a body", create)
+	}
+	if _, ok := create.Responses["201"]; !ok {
+		t.Errorf("POST /customers responses = %v, want 201", create.Responses)
+	}
+	if customer := doc.Components.Schemas["CustomerRequest"]; customer == nil || !contains(customer.Required, "name") {
+		t.Errorf("CustomerRequest schema = %+v, want name required", customer)
+	}
+	if params := doc.Paths["/plans/{id}/solutions/{version}"]["get"].Parameters; len(params) != 3 || params[1].Name != "version" || params[2].Name != "fields" {
+		t.Errorf("solution parameters = %+v, want id, version and fields", params)
+	}
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/docs", nil))
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "openapi.json") {
+		t.Errorf("docs status = %d, want 200 with Swagger UI pointed at openapi.json", w.Code)
+	}
+
+	// Version 2 has a document of its own, with its page envelope
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v2/openapi.json", nil))
+	var v2 openapi.Document
+	if err := json.Unmarshal(w.Body.Bytes(), &v2); err != nil || v2.Servers[0].URL != "/api/v2" {
+		t.Fatalf("v2 openapi.json status = %d, want a document at /api/v2", w.Code)
+	}
+	list := v2.Paths["/customers"]["get"]
+	if list == nil || v2.Paths["/customers"]["post"] != nil {
+		t.Fatalf("v2 /customers = %v, want only the list", v2.Paths["/customers"])
+	}
+	if envelope := list.Responses["200"].Content["application/json"].Schema; envelope.Properties["links"] == nil || envelope.Properties["pagination"] != nil {
+		t.Errorf("v2 list envelope = %+v, want data, meta and links", envelope)
+	}
+}
+
+// TestForwardedForNeedsTrustedProxy tests that a client cannot get a fresh
+// IP rate limit bucket by sending another X-Forwarded-For, unless it comes
+// through a trusted proxy
+func TestForwardedForNeedsTrustedProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	db := testkit.DB(t)
+
+	login := func(router *gin.Engine, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader("{}"))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Forwarded-For", forwardedFor)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	newRouter := func(proxies []string) *gin.Engine {
+		cfg := &config.Config{JWTSecret: "test-secret-key", JWTExpiry: 24, RateLimitIPPerMinute: 1, RateLimitIPBurst: 1, TrustedProxies: proxies}
+		return setupRouter(handlers.New(db, nil, cfg), cfg)
+	}
+
+	t.Run("untrusted peer", func(t *testing.T) {
+		router := newRouter(nil)
+		login(router, "203.0.113.1")
+		if code := login(router, "203.0.113.2"); code != http.StatusTooManyRequests {
+			t.Errorf("spoofed X-Forwarded-For status = %d, want 429 from the peer's bucket", code)
+		}
+	})
+	t.Run("trusted proxy", func(t *testing.T) {
+		// httptest requests come from 192.0.2.1
+		router := newRouter([]string{"192.0.2.0/24"})
+		login(router, "203.0.113.1")
+		if code := login(router, "203.0.113.2"); code == http.StatusTooManyRequests {
+			t.Errorf("another client behind the proxy was limited")
+		}
+		if code := login(router, "203.0.113.1"); code != http.StatusTooManyRequests {
+			t.Errorf("same client behind the proxy status = %d, want 429", code)
+		}
+	})
+}
+
+// TestCORSPreflight tests that the frontend's preflights allow the methods
+// and headers the API uses and expose the headers it answers with
+func TestCORSPreflight(t *testing.T) {
+	router := testRouter(t)
+
+	req := httptest.NewRequest(http.MethodOptions, "/api/v1/customers/1", nil)
+	req.Header.Set("Origin", "http://localhost:3000")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPatch)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusNoContent || w.Header().Get("Access-Control-Allow-Origin") != "http://localhost:3000" {
+		t.Fatalf("preflight status = %d, headers %v, want 204 for the frontend", w.Code, w.Header())
+	}
+	for header, want := range map[string][]string{
+		"Access-Control-Allow-Methods":  {"PATCH"},
+		"Access-Control-Allow-Headers":  {"If-Match", "Idempotency-Key", "If-None-Match"},
+		"Access-Control-Expose-Headers": {"ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "X-Quota-Limit", "X-Quota-Remaining"},
+	} {
+		list := strings.Split(w.Header().Get(header), ", ")
+		for _, name := range want {
+			if !contains(list, name) {
+				t.Errorf("%s = %v, want %s in it", header, list, name)
+			}
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
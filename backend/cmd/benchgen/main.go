@@ -0,0 +1,84 @@
+// Command benchgen fills a database with a synthetic dataset of configurable
+// size for load tests:
+//
+//	go run ./cmd/benchgen -customers 5000 -vehicles 200 -days 14 -routes
+//
+// It connects to DATABASE_URL (or -database-url) and runs migrations first.
+package main
+
+import (
+	"flag"
+	"log"
+	"time"
+
+	"LogiTrackPro/backend/internal/benchdata"
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+
+	"github.com/joho/godotenv"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using environment variables")
+	}
+	cfg := config.Load()
+
+	var (
+		databaseURL = flag.String("database-url", cfg.DatabaseURL, "database connection string")
+		customers   = flag.Int("customers", 1000, "number of customers")
+		vehicles    = flag.Int("vehicles", 50, "number of vehicles")
+		days        = flag.Int("days", 7, "planning horizon in days")
+		seed        = flag.Int64("seed", 1, "random seed")
+		startDate   = flag.String("start", "", "plan start date (YYYY-MM-DD, default next Monday)")
+		withRoutes  = flag.Bool("routes", false, "also store a synthetic solution as the plan's routes")
+	)
+	flag.Parse()
+
+	genCfg := benchdata.Config{Customers: *customers, Vehicles: *vehicles, Days: *days, Seed: *seed}
+	if *startDate != "" {
+		start, err := time.Parse("2006-01-02", *startDate)
+		if err != nil {
+			log.Fatalf("Invalid start date %q (use YYYY-MM-DD)", *startDate)
+		}
+		genCfg.StartDate = start
+	}
+	if err := genCfg.Validate(); err != nil {
+		log.Fatal(err)
+	}
+
+	db, err := database.Connect(*databaseURL)
+	if err != nil {
+		log.Fatalf("Failed to connect to database: %v", err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		log.Fatalf("Failed to get underlying sql.DB: %v", err)
+	}
+	defer sqlDB.Close()
+	db = db.Session(&gorm.Session{Logger: db.Logger.LogMode(logger.Warn)})
+
+	if err := database.RunMigrations(db); err != nil {
+		log.Fatalf("Failed to run migrations: %v", err)
+	}
+
+	started := time.Now()
+	ds, err := benchdata.Generate(db, genCfg)
+	if err != nil {
+		log.Fatalf("Failed to generate dataset: %v", err)
+	}
+	log.Printf("Created warehouse %d, %d customers, %d vehicles and plan %d (%s to %s) in %v",
+		ds.Warehouse.ID, len(ds.Customers), len(ds.Vehicles), ds.Plan.ID,
+		ds.Plan.StartDate.Format("2006-01-02"), ds.Plan.EndDate.Format("2006-01-02"), time.Since(started))
+
+	if *withRoutes {
+		started = time.Now()
+		resp := benchdata.Solve(ds.Request())
+		if err := benchdata.SaveSolution(db, ds.Plan, resp); err != nil {
+			log.Fatalf("Failed to store routes: %v", err)
+		}
+		log.Printf("Stored %d routes for plan %d in %v", len(resp.Routes), ds.Plan.ID, time.Since(started))
+	}
+}
@@ -0,0 +1,284 @@
+// Package alerts scans customers and warehouses for low stock: inventory
+// below its minimum now, or projected to run out within a number of days.
+// Each condition found is kept as one alert until it clears, and its
+// opening is published to inventory.low_stock webhooks.
+package alerts
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/forecast"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/webhooks"
+
+	"gorm.io/gorm"
+)
+
+// JobType is the job that runs a scan, periodically and after inventory
+// snapshots are taken
+const JobType = "alerts.scan"
+
+// Scope is the payload of a scan job. It limits the scan to one entity
+// type, and to one entity when EntityID is set; the zero Scope scans all
+// customers and warehouses.
+type Scope struct {
+	EntityType string `json:"entity_type,omitempty"`
+	EntityID   int64  `json:"entity_id,omitempty"`
+}
+
+// Scanner opens, updates and resolves alerts
+type Scanner struct {
+	db    *gorm.DB
+	days  int
+	clock clock.Clock
+}
+
+// New returns a scanner that alerts on stockouts projected within days
+func New(db *gorm.DB, days int) *Scanner {
+	return &Scanner{db: db, days: days, clock: clock.Real}
+}
+
+// SetClock replaces the clock RunJob reads the current time from
+func (s *Scanner) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// RunJob runs a scan of the Scope in the payload as a background job
+func (s *Scanner) RunJob(ctx context.Context, payload json.RawMessage) error {
+	var scope Scope
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &scope); err != nil {
+			return fmt.Errorf("decode scope: %w", err)
+		}
+	}
+	result, err := s.Scan(scope, s.clock.Now())
+	if result != nil && (result.Opened > 0 || result.Resolved > 0) {
+		log.Printf("Alert scan: opened %d, resolved %d alerts", result.Opened, result.Resolved)
+	}
+	return err
+}
+
+// Scan finds the low-stock conditions of the entities in scope and brings
+// their alerts in line: a new condition opens an alert, one already alerted
+// on updates its alert, acknowledged or not, and alerts whose condition
+// cleared are resolved.
+func (s *Scanner) Scan(scope Scope, now time.Time) (*models.AlertScan, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+	var entityID *int64
+	if scope.EntityID != 0 {
+		entityID = &scope.EntityID
+	}
+
+	var found []models.Alert
+	if scope.EntityType == "" || scope.EntityType == "customer" {
+		customerAlerts, err := s.scanCustomers(entityID, today)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, customerAlerts...)
+	}
+	if scope.EntityType == "" || scope.EntityType == "warehouse" {
+		warehouseAlerts, err := s.scanWarehouses(entityID, today)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, warehouseAlerts...)
+	}
+
+	result := &models.AlertScan{}
+	var opened []*models.Alert
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		existing, err := database.ListUnresolvedAlertsTx(tx, scope.EntityType, entityID)
+		if err != nil {
+			return err
+		}
+		open := make(map[string]*models.Alert, len(existing))
+		for i := range existing {
+			open[key(&existing[i])] = &existing[i]
+		}
+		for i := range found {
+			alert := &found[i]
+			if current, ok := open[key(alert)]; ok {
+				current.EntityName = alert.EntityName
+				current.Severity = alert.Severity
+				current.Message = alert.Message
+				current.InventoryLevel = alert.InventoryLevel
+				current.Threshold = alert.Threshold
+				current.StockoutDate = alert.StockoutDate
+				current.LastSeenAt = now
+				if err := database.SaveAlertTx(tx, current); err != nil {
+					return err
+				}
+				delete(open, key(alert))
+				result.Updated++
+				continue
+			}
+			alert.Status = models.AlertOpen
+			alert.DetectedAt = now
+			alert.LastSeenAt = now
+			if err := database.SaveAlertTx(tx, alert); err != nil {
+				return err
+			}
+			opened = append(opened, alert)
+			result.Opened++
+		}
+		for _, alert := range existing {
+			if _, cleared := open[key(&alert)]; !cleared {
+				continue
+			}
+			if err := database.ResolveAlert(tx, alert.ID, nil, now); err != nil {
+				return err
+			}
+			result.Resolved++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscribers hear of a condition once, when its alert opens
+	for _, alert := range opened {
+		if _, err := webhooks.Publish(s.db, webhooks.InventoryLowStock, alert, now); err != nil {
+			log.Printf("Alert scan: failed to queue webhooks of alert %d: %v", alert.ID, err)
+		}
+	}
+	return result, nil
+}
+
+func key(a *models.Alert) string {
+	return fmt.Sprintf("%s/%d/%s", a.EntityType, a.EntityID, a.Kind)
+}
+
+func (s *Scanner) scanCustomers(id *int64, today time.Time) ([]models.Alert, error) {
+	var customers []models.Customer
+	if id != nil {
+		customer, err := database.GetCustomer(s.db, *id)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			return nil, fmt.Errorf("get customer: %w", err)
+		}
+		if customer != nil {
+			customers = append(customers, *customer)
+		}
+	} else {
+		var err error
+		if customers, err = database.ListCustomers(s.db); err != nil {
+			return nil, fmt.Errorf("list customers: %w", err)
+		}
+	}
+
+	stops, err := database.GetPlannedDeliveries(s.db, database.PlannedDeliveryFilter{
+		CustomerID: id,
+		From:       today,
+		To:         today.AddDate(0, 0, s.days),
+		Statuses:   forecast.Statuses,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("get planned deliveries: %w", err)
+	}
+	deliveries := make(map[int64][]models.Stop)
+	for _, stop := range stops {
+		if stop.CustomerID != nil {
+			deliveries[*stop.CustomerID] = append(deliveries[*stop.CustomerID], stop)
+		}
+	}
+
+	var found []models.Alert
+	for i := range customers {
+		c := &customers[i]
+		if c.CurrentInventory < c.MinInventory {
+			found = append(found, belowMinimum("customer", c.ID, c.Name, c.CurrentInventory, c.MinInventory))
+		}
+		projected := forecast.Customer(c, deliveries[c.ID], today, s.days)
+		if projected.StockoutDate != nil {
+			date, _ := time.Parse("2006-01-02", *projected.StockoutDate)
+			found = append(found, projectedStockout("customer", c.ID, c.Name, c.CurrentInventory, c.MinInventory, date, today))
+		}
+	}
+	return found, nil
+}
+
+func (s *Scanner) scanWarehouses(id *int64, today time.Time) ([]models.Alert, error) {
+	var warehouses []models.Warehouse
+	if id != nil {
+		warehouse, err := database.GetWarehouse(s.db, *id)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			return nil, fmt.Errorf("get warehouse: %w", err)
+		}
+		if warehouse != nil {
+			warehouses = append(warehouses, *warehouse)
+		}
+	} else {
+		var err error
+		if warehouses, err = database.ListWarehouses(s.db); err != nil {
+			return nil, fmt.Errorf("list warehouses: %w", err)
+		}
+	}
+
+	var found []models.Alert
+	for i := range warehouses {
+		w := &warehouses[i]
+		if w.CurrentStock < w.MinStock {
+			found = append(found, belowMinimum("warehouse", w.ID, w.Name, w.CurrentStock, w.MinStock))
+		}
+		stops, err := database.GetPlannedDeliveries(s.db, database.PlannedDeliveryFilter{
+			WarehouseID: &w.ID,
+			From:        today,
+			To:          today.AddDate(0, 0, s.days),
+			Statuses:    forecast.Statuses,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("get planned deliveries: %w", err)
+		}
+		if date := forecast.WarehouseStockout(w, stops, today, s.days); date != nil {
+			found = append(found, projectedStockout("warehouse", w.ID, w.Name, w.CurrentStock, w.MinStock, *date, today))
+		}
+	}
+	return found, nil
+}
+
+// belowMinimum is the alert of inventory below its minimum, critical once
+// it is gone
+func belowMinimum(entityType string, id int64, name string, level, minimum float64) models.Alert {
+	severity := models.AlertWarning
+	if level <= 0 {
+		severity = models.AlertCritical
+	}
+	return models.Alert{
+		EntityType:     entityType,
+		EntityID:       id,
+		EntityName:     name,
+		Kind:           models.AlertBelowMinimum,
+		Severity:       severity,
+		Message:        fmt.Sprintf("Inventory %.1f is below the minimum %.1f", level, minimum),
+		InventoryLevel: level,
+		Threshold:      minimum,
+	}
+}
+
+// projectedStockout is the alert of a projected stockout, critical when it
+// is today or tomorrow
+func projectedStockout(entityType string, id int64, name string, level, minimum float64, date, today time.Time) models.Alert {
+	severity := models.AlertWarning
+	if date.Before(today.AddDate(0, 0, 2)) {
+		severity = models.AlertCritical
+	}
+	return models.Alert{
+		EntityType:     entityType,
+		EntityID:       id,
+		EntityName:     name,
+		Kind:           models.AlertProjectedStockout,
+		Severity:       severity,
+		Message:        fmt.Sprintf("Projected to run out of stock on %s", date.Format("2006-01-02")),
+		InventoryLevel: level,
+		Threshold:      minimum,
+		StockoutDate:   &date,
+	}
+}
@@ -0,0 +1,81 @@
+package alerts
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestScan tests that scans open alerts for low stock, keep one alert per
+// condition and resolve alerts whose condition cleared
+func TestScan(t *testing.T) {
+	db := testkit.DB(t)
+	fx := testkit.NewFixtures(t, db)
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	// 100 in stock, 60 going out on 6 March and 50 on 7 March
+	warehouse := fx.Warehouse(func(w *models.Warehouse) { w.CurrentStock = 100; w.MinStock = 20 })
+	plan := fx.Plan(warehouse, start, 5, testkit.WithStatus("approved"))
+	big := func(c *models.Customer) { c.CurrentInventory = 200; c.MaxInventory = 300 }
+	route := fx.Route(plan, fx.Vehicle(warehouse), 3, fx.Customer(big))
+	db.Model(&models.Stop{}).Where("route_id = ?", route.ID).Update("quantity", 60)
+	route = fx.Route(plan, fx.Vehicle(warehouse), 4, fx.Customer(big))
+	db.Model(&models.Stop{}).Where("route_id = ?", route.ID).Update("quantity", 50)
+	// 15 on hand at 10 a day, below the minimum of 20 and out tomorrow
+	low := fx.Customer(func(c *models.Customer) { c.CurrentInventory = 15 })
+	// 50 on hand at 10 a day runs out on 8 March, after the 3 days scanned
+	fx.Customer()
+
+	scanner := New(db, 3)
+	result, err := scanner.Scan(Scope{}, now)
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if result.Opened != 2 {
+		t.Errorf("Scan() = %+v, want 2 alerts opened", result)
+	}
+	alerts, _, _ := database.ListAlerts(db, database.AlertFilter{EntityType: "customer", EntityID: &low.ID}, 0, 10)
+	if len(alerts) != 2 {
+		t.Fatalf("low customer alerts = %+v, want below minimum and projected stockout", alerts)
+	}
+	for _, alert := range alerts {
+		if alert.Kind == models.AlertProjectedStockout && (alert.Severity != models.AlertCritical || alert.StockoutDate.Format("2006-01-02") != "2024-03-05") {
+			t.Errorf("stockout alert = %+v, want critical for 5 March", alert)
+		}
+		if alert.Kind == models.AlertBelowMinimum && alert.Severity != models.AlertWarning {
+			t.Errorf("below minimum alert = %+v, want a warning", alert)
+		}
+	}
+
+	// over 5 days the warehouse runs out on 7 March
+	scanner = New(db, 5)
+	result, err = scanner.Scan(Scope{}, now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if result.Opened != 2 || result.Updated != 2 || result.Resolved != 0 {
+		t.Errorf("wider Scan() = %+v, want 2 opened and 2 updated", result)
+	}
+	warehouseAlerts, _, _ := database.ListAlerts(db, database.AlertFilter{EntityType: "warehouse"}, 0, 10)
+	if len(warehouseAlerts) != 1 || warehouseAlerts[0].StockoutDate.Format("2006-01-02") != "2024-03-07" {
+		t.Errorf("warehouse alerts = %+v, want a stockout on 7 March", warehouseAlerts)
+	}
+
+	// a delivery brings the low customer back up
+	db.Model(low).Update("current_inventory", 80)
+	result, err = scanner.Scan(Scope{EntityType: "customer", EntityID: low.ID}, now.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("Scan() error = %v", err)
+	}
+	if result.Resolved != 2 || result.Opened != 0 {
+		t.Errorf("customer Scan() = %+v, want its 2 alerts resolved", result)
+	}
+	unresolved, total, _ := database.ListAlerts(db, database.AlertFilter{Statuses: []string{models.AlertOpen}}, 0, 10)
+	if total != 2 || unresolved[0].ResolvedBy != nil {
+		t.Errorf("open alerts = %d, want the other customer's and the warehouse's", total)
+	}
+}
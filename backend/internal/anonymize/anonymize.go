@@ -0,0 +1,333 @@
+// Package anonymize scrambles the personal data in a database so a copy of
+// production can be used in staging. Names, emails, addresses, phone and
+// licence numbers are replaced, locations are moved a short random distance
+// and free-text notes are cleared. Quantities, times, distances and the
+// layout of customers around their warehouses are left alone, so plans
+// optimize and routes look much as they did in production.
+package anonymize
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// DefaultJitterMeters is how far locations are moved at most when no
+// jitter is configured
+const DefaultJitterMeters = 250
+
+// metersPerDegree is the length of a degree of latitude
+const metersPerDegree = 111320
+
+// Config controls what is scrambled
+type Config struct {
+	Seed         int64
+	JitterMeters float64  // largest distance a location is moved; 0 means DefaultJitterMeters
+	KeepEmails   []string // users left as they are, such as the staging admins
+	KeepNotes    bool     // leave free-text notes and route messages
+}
+
+// Report counts the rows Run scrambled
+type Report struct {
+	Users          int
+	Warehouses     int
+	Customers      int
+	Drivers        int
+	Places         int
+	Tracks         int // route executions whose GPS pings were moved
+	Recipients     int
+	SecurityEvents int
+	Notes          int
+}
+
+type anonymizer struct {
+	rng    *rand.Rand
+	jitter float64
+}
+
+// Run scrambles the database in one transaction. The same seed moves
+// locations and rewrites numbers the same way. Soft-deleted rows are
+// scrambled too.
+func Run(db *gorm.DB, cfg Config) (*Report, error) {
+	a := &anonymizer{rng: rand.New(rand.NewSource(cfg.Seed)), jitter: cfg.JitterMeters}
+	if a.jitter <= 0 {
+		a.jitter = DefaultJitterMeters
+	}
+	keep := make(map[string]bool, len(cfg.KeepEmails))
+	for _, email := range cfg.KeepEmails {
+		keep[strings.ToLower(strings.TrimSpace(email))] = true
+	}
+
+	report := &Report{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		var err error
+		if report.Users, err = a.users(tx, keep); err != nil {
+			return fmt.Errorf("users: %w", err)
+		}
+		if report.SecurityEvents, err = a.securityEvents(tx, keep); err != nil {
+			return fmt.Errorf("security events: %w", err)
+		}
+		if report.Warehouses, err = a.warehouses(tx); err != nil {
+			return fmt.Errorf("warehouses: %w", err)
+		}
+		if report.Customers, err = a.customers(tx); err != nil {
+			return fmt.Errorf("customers: %w", err)
+		}
+		if report.Places, err = a.places(tx); err != nil {
+			return fmt.Errorf("places: %w", err)
+		}
+		if report.Drivers, err = a.drivers(tx); err != nil {
+			return fmt.Errorf("drivers: %w", err)
+		}
+		if report.Tracks, err = a.tracks(tx); err != nil {
+			return fmt.Errorf("location pings: %w", err)
+		}
+		if report.Recipients, err = a.recipients(tx); err != nil {
+			return fmt.Errorf("recipients: %w", err)
+		}
+		if !cfg.KeepNotes {
+			if report.Notes, err = a.notes(tx); err != nil {
+				return fmt.Errorf("notes: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// users renames every user not kept and gives them an email made from
+// their ID, so emails stay unique
+func (a *anonymizer) users(tx *gorm.DB, keep map[string]bool) (int, error) {
+	var users []models.User
+	if err := tx.Select("id", "email").Order("id").Find(&users).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, u := range users {
+		if keep[strings.ToLower(u.Email)] {
+			continue
+		}
+		err := tx.Model(&models.User{}).Where("id = ?", u.ID).UpdateColumns(map[string]interface{}{
+			"name":  fmt.Sprintf("User %d", u.ID),
+			"email": userEmail(u.ID),
+		}).Error
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func userEmail(id int64) string {
+	return fmt.Sprintf("user%d@anonymized.example.com", id)
+}
+
+// securityEvents replaces the emails of the security log with those of
+// the scrambled users, or clears them for unknown accounts, and clears the
+// IP addresses
+func (a *anonymizer) securityEvents(tx *gorm.DB, keep map[string]bool) (int, error) {
+	var events []models.SecurityEvent
+	if err := tx.Select("id", "user_id", "email").Order("id").Find(&events).Error; err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range events {
+		email := ""
+		if keep[strings.ToLower(e.Email)] {
+			email = e.Email
+		} else if e.UserID != nil {
+			email = userEmail(*e.UserID)
+		}
+		err := tx.Model(&models.SecurityEvent{}).Where("id = ?", e.ID).
+			UpdateColumns(map[string]interface{}{"email": email, "ip": ""}).Error
+		if err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func (a *anonymizer) warehouses(tx *gorm.DB) (int, error) {
+	var warehouses []models.Warehouse
+	if err := tx.Select("id", "latitude", "longitude").Order("id").Find(&warehouses).Error; err != nil {
+		return 0, err
+	}
+	for i, w := range warehouses {
+		lat, lon := a.move(w.Latitude, w.Longitude)
+		err := tx.Model(&models.Warehouse{}).Where("id = ?", w.ID).UpdateColumns(map[string]interface{}{
+			"name":      fmt.Sprintf("Warehouse %d", w.ID),
+			"address":   address(w.ID, "Depot Road"),
+			"latitude":  lat,
+			"longitude": lon,
+			"version":   gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(warehouses), nil
+}
+
+func (a *anonymizer) customers(tx *gorm.DB) (int, error) {
+	var customers []models.Customer
+	if err := tx.Select("id", "latitude", "longitude").Order("id").Find(&customers).Error; err != nil {
+		return 0, err
+	}
+	for i, c := range customers {
+		lat, lon := a.move(c.Latitude, c.Longitude)
+		err := tx.Model(&models.Customer{}).Where("id = ?", c.ID).UpdateColumns(map[string]interface{}{
+			"name":      fmt.Sprintf("Customer %d", c.ID),
+			"address":   address(c.ID, "Market Street"),
+			"latitude":  lat,
+			"longitude": lon,
+			"version":   gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(customers), nil
+}
+
+func (a *anonymizer) places(tx *gorm.DB) (int, error) {
+	var places []models.Place
+	if err := tx.Select("id", "latitude", "longitude").Order("id").Find(&places).Error; err != nil {
+		return 0, err
+	}
+	for i, p := range places {
+		lat, lon := a.move(p.Latitude, p.Longitude)
+		err := tx.Model(&models.Place{}).Where("id = ?", p.ID).UpdateColumns(map[string]interface{}{
+			"name":      fmt.Sprintf("Place %d", p.ID),
+			"address":   address(p.ID, "Ring Road"),
+			"latitude":  lat,
+			"longitude": lon,
+			"version":   gorm.Expr("version + 1"),
+		}).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(places), nil
+}
+
+func (a *anonymizer) drivers(tx *gorm.DB) (int, error) {
+	var drivers []models.Driver
+	if err := tx.Select("id", "phone", "license_number").Order("id").Find(&drivers).Error; err != nil {
+		return 0, err
+	}
+	for i, d := range drivers {
+		err := tx.Model(&models.Driver{}).Where("id = ?", d.ID).UpdateColumns(map[string]interface{}{
+			"name":           fmt.Sprintf("Driver %d", d.ID),
+			"phone":          a.scramble(d.Phone),
+			"license_number": a.scramble(d.LicenseNumber),
+		}).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(drivers), nil
+}
+
+// tracks moves each execution's GPS pings together by one offset, so the
+// track keeps its shape and length and stays near the moved stops
+func (a *anonymizer) tracks(tx *gorm.DB) (int, error) {
+	var executionIDs []int64
+	err := tx.Model(&models.LocationPing{}).Distinct().Order("route_execution_id").
+		Pluck("route_execution_id", &executionIDs).Error
+	if err != nil {
+		return 0, err
+	}
+	for i, id := range executionIDs {
+		var first models.LocationPing
+		if err := tx.Where("route_execution_id = ?", id).Order("recorded_at").First(&first).Error; err != nil {
+			return i, err
+		}
+		lat, lon := a.move(first.Latitude, first.Longitude)
+		err := tx.Model(&models.LocationPing{}).Where("route_execution_id = ?", id).UpdateColumns(map[string]interface{}{
+			"latitude":  gorm.Expr("latitude + ?", lat-first.Latitude),
+			"longitude": gorm.Expr("longitude + ?", lon-first.Longitude),
+		}).Error
+		if err != nil {
+			return i, err
+		}
+	}
+	return len(executionIDs), nil
+}
+
+// recipients replaces the names given at proof of delivery
+func (a *anonymizer) recipients(tx *gorm.DB) (int, error) {
+	result := tx.Model(&models.StopExecution{}).Where("recipient_name <> ''").
+		UpdateColumn("recipient_name", "Recipient")
+	return int(result.RowsAffected), result.Error
+}
+
+// notes clears the free text drivers, planners and dispatchers wrote
+func (a *anonymizer) notes(tx *gorm.DB) (int, error) {
+	columns := []struct {
+		model  interface{}
+		column string
+		value  string
+	}{
+		{&models.StopExecution{}, "notes", ""},
+		{&models.RouteExecution{}, "driver_notes", ""},
+		{&models.RouteExecution{}, "deviation_reason", ""},
+		{&models.DriverStopEvent{}, "notes", ""},
+		{&models.RosterEntry{}, "notes", ""},
+		{&models.Place{}, "notes", ""},
+		{&models.RouteMessage{}, "body", "Message removed"},
+	}
+	n := 0
+	for _, c := range columns {
+		result := tx.Model(c.model).Where(c.column+" <> ?", c.value).UpdateColumn(c.column, c.value)
+		if result.Error != nil {
+			return n, result.Error
+		}
+		n += int(result.RowsAffected)
+	}
+	return n, nil
+}
+
+// move returns a point at a random distance of up to the jitter from
+// latitude, longitude, uniformly spread over that disc
+func (a *anonymizer) move(latitude, longitude float64) (float64, float64) {
+	distance := a.jitter * math.Sqrt(a.rng.Float64())
+	bearing := 2 * math.Pi * a.rng.Float64()
+	dLat := distance * math.Cos(bearing) / metersPerDegree
+	dLon := 0.0
+	if c := math.Cos(latitude * math.Pi / 180); c > 1e-6 {
+		dLon = distance * math.Sin(bearing) / (metersPerDegree * c)
+	}
+	return latitude + dLat, longitude + dLon
+}
+
+// scramble replaces the digits and letters of s with random ones, keeping
+// its length, case and punctuation so numbers keep their format
+func (a *anonymizer) scramble(s string) string {
+	out := []rune(s)
+	for i, r := range out {
+		switch {
+		case r >= '0' && r <= '9':
+			out[i] = rune('0' + a.rng.Intn(10))
+		case r >= 'A' && r <= 'Z':
+			out[i] = rune('A' + a.rng.Intn(26))
+		case r >= 'a' && r <= 'z':
+			out[i] = rune('a' + a.rng.Intn(26))
+		}
+	}
+	return string(out)
+}
+
+func address(id int64, street string) string {
+	return fmt.Sprintf("%d %s", id, street)
+}
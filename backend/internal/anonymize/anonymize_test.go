@@ -0,0 +1,93 @@
+package anonymize
+
+import (
+	"math"
+	"regexp"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestRun tests that personal data is replaced while locations stay close
+// by, GPS tracks keep their shape and kept users are untouched
+func TestRun(t *testing.T) {
+	db := testkit.DB(t)
+	fx := testkit.NewFixtures(t, db)
+	admin, planner := fx.User("admin"), fx.User("manager")
+	warehouse := fx.Warehouse()
+	customer := fx.Customer()
+	driver := &models.Driver{Name: "Jan Jansen", Phone: "+31 6-1234 5678", LicenseNumber: "NL-AB123"}
+	if err := db.Create(driver).Error; err != nil {
+		t.Fatal(err)
+	}
+	plan := fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1)
+	route := fx.Route(plan, fx.Vehicle(warehouse), 1, customer)
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "completed", DriverNotes: "spoke to Mrs Smith",
+		StopExecutions: []models.StopExecution{{StopID: route.Stops[0].ID, Status: "completed", RecipientName: "A. Smith", Notes: "left at door 4"}}}
+	if err := database.CreateRouteExecution(db, execution); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	pings := []models.LocationPing{
+		{RouteExecutionID: execution.ID, Latitude: 52.0, Longitude: 4.0, RecordedAt: start},
+		{RouteExecutionID: execution.ID, Latitude: 52.1, Longitude: 4.2, RecordedAt: start.Add(time.Hour)},
+	}
+	if _, err := database.CreateLocationPings(db, pings); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := Run(db, Config{Seed: 3, KeepEmails: []string{admin.Email}})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.Users != 1 || report.Customers != 1 || report.Drivers != 1 || report.Tracks != 1 || report.Notes != 2 {
+		t.Errorf("report = %+v", report)
+	}
+
+	var users []models.User
+	db.Order("id").Find(&users)
+	if users[0].Email != admin.Email || users[0].Name != admin.Name {
+		t.Errorf("kept user = %+v, want unchanged", users[0])
+	}
+	if users[1].Email == planner.Email || users[1].Email != userEmail(planner.ID) {
+		t.Errorf("scrambled user = %+v", users[1])
+	}
+
+	var c models.Customer
+	db.First(&c, customer.ID)
+	if c.Name == customer.Name || c.Address == customer.Address {
+		t.Errorf("customer = %q at %q, want renamed", c.Name, c.Address)
+	}
+	if d := distanceMeters(c.Latitude, c.Longitude, customer.Latitude, customer.Longitude); d == 0 || d > DefaultJitterMeters+1 {
+		t.Errorf("customer moved %.0f m, want within %d m", d, DefaultJitterMeters)
+	}
+
+	var d models.Driver
+	db.First(&d, driver.ID)
+	if d.Name == driver.Name || d.Phone == driver.Phone || !regexp.MustCompile(`^\+\d\d \d-\d{4} \d{4}$`).MatchString(d.Phone) {
+		t.Errorf("driver = %q with phone %q, want renamed with the phone's format kept", d.Name, d.Phone)
+	}
+
+	track, _ := database.GetLocationTrack(db, execution.ID)
+	dLat, dLon := track[0].Latitude-pings[0].Latitude, track[0].Longitude-pings[0].Longitude
+	if dLat == 0 || math.Abs(track[1].Latitude-pings[1].Latitude-dLat) > 1e-9 || math.Abs(track[1].Longitude-pings[1].Longitude-dLon) > 1e-9 {
+		t.Errorf("track = %+v, want both pings moved by one offset", track)
+	}
+
+	stored, _ := database.GetRouteExecution(db, execution.ID)
+	if stored.DriverNotes != "" || stored.StopExecutions[0].Notes != "" || stored.StopExecutions[0].RecipientName != "Recipient" {
+		t.Errorf("execution = %+v, want notes and recipient cleared", stored)
+	}
+}
+
+// distanceMeters is the haversine distance between two points
+func distanceMeters(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadius = 6371000
+	rad := math.Pi / 180
+	dLat, dLon := (lat2-lat1)*rad, (lon2-lon1)*rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,299 @@
+// Package benchdata generates synthetic datasets for benchmarks and load
+// tests: one warehouse with customers scattered around it, a vehicle fleet
+// and a draft plan over a number of days.
+package benchdata
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"gorm.io/gorm"
+)
+
+// batchSize is the number of rows per INSERT
+const batchSize = 500
+
+// Config sizes a dataset
+type Config struct {
+	Customers int
+	Vehicles  int
+	Days      int
+	Seed      int64
+	StartDate time.Time // zero means the next Monday
+}
+
+// Dataset is what Generate stored
+type Dataset struct {
+	Warehouse *models.Warehouse
+	Customers []models.Customer
+	Vehicles  []models.Vehicle
+	Plan      *models.Plan
+}
+
+// Validate checks the dataset size is usable
+func (c Config) Validate() error {
+	if c.Customers < 1 || c.Vehicles < 1 || c.Days < 1 {
+		return fmt.Errorf("customers, vehicles and days must be at least 1 (got %d, %d, %d)", c.Customers, c.Vehicles, c.Days)
+	}
+	return nil
+}
+
+// Generate stores a synthetic warehouse, customers, vehicles and a draft
+// plan. The same seed produces the same locations and quantities; names
+// get a unique suffix so several datasets can live in one database.
+func Generate(db *gorm.DB, cfg Config) (*Dataset, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	start := cfg.StartDate
+	if start.IsZero() {
+		start = nextMonday(time.Now().UTC())
+	}
+	suffix := fmt.Sprintf("%d-%d", cfg.Seed, time.Now().UnixNano())
+
+	ds := &Dataset{
+		Warehouse: &models.Warehouse{
+			Name:         "Bench depot " + suffix,
+			Address:      "Synthetic",
+			Latitude:     52.52,
+			Longitude:    13.405,
+			Capacity:     float64(cfg.Customers) * 1000,
+			CurrentStock: float64(cfg.Customers) * 500,
+		},
+		Customers: make([]models.Customer, cfg.Customers),
+		Vehicles:  make([]models.Vehicle, cfg.Vehicles),
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ds.Warehouse).Error; err != nil {
+			return err
+		}
+
+		for i := range ds.Customers {
+			// Within roughly 50 km of the depot
+			maxInventory := float64(200 + rng.Intn(800))
+			ds.Customers[i] = models.Customer{
+				Name:             fmt.Sprintf("Bench customer %d %s", i+1, suffix),
+				Latitude:         ds.Warehouse.Latitude + (rng.Float64()-0.5)*0.9,
+				Longitude:        ds.Warehouse.Longitude + (rng.Float64()-0.5)*1.4,
+				DemandRate:       math.Round(maxInventory*(0.05+rng.Float64()*0.15)*100) / 100,
+				MaxInventory:     maxInventory,
+				CurrentInventory: math.Round(maxInventory*rng.Float64()*100) / 100,
+				MinInventory:     math.Round(maxInventory*0.1*100) / 100,
+				HoldingCost:      0.1,
+				Priority:         1 + rng.Intn(3),
+			}
+		}
+		if err := tx.CreateInBatches(ds.Customers, batchSize).Error; err != nil {
+			return err
+		}
+
+		for i := range ds.Vehicles {
+			ds.Vehicles[i] = models.Vehicle{
+				Name:        fmt.Sprintf("Bench truck %d %s", i+1, suffix),
+				Capacity:    float64(2000 + 500*rng.Intn(7)),
+				CostPerKm:   0.8 + rng.Float64(),
+				FixedCost:   float64(50 + rng.Intn(100)),
+				MaxDistance: 400,
+				Available:   true,
+				WarehouseID: &ds.Warehouse.ID,
+			}
+		}
+		if err := tx.CreateInBatches(ds.Vehicles, batchSize).Error; err != nil {
+			return err
+		}
+
+		ds.Plan = &models.Plan{
+			Name:        "Bench plan " + suffix,
+			StartDate:   start,
+			EndDate:     start.AddDate(0, 0, cfg.Days-1),
+			Status:      "draft",
+			WarehouseID: &ds.Warehouse.ID,
+		}
+		return tx.Create(ds.Plan).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return ds, nil
+}
+
+// Solve builds a feasible optimizer response for a request without running
+// the optimizer: customers are dealt round-robin over the days and filled
+// onto vehicles up to their capacity. It stands in for the optimizer service
+// when benchmarking the backend.
+func Solve(req *optimizer.OptimizeRequest) *optimizer.OptimizeResponse {
+	resp := &optimizer.OptimizeResponse{Success: true, Message: "synthetic solution"}
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil || len(req.Vehicles) == 0 || req.PlanningHorizon < 1 {
+		resp.Success = false
+		resp.Message = "invalid request"
+		return resp
+	}
+
+	for day := 1; day <= req.PlanningHorizon; day++ {
+		vehicle := 0
+		route := newRoute(day, start, req.Vehicles[vehicle])
+		for i := day - 1; i < len(req.Customers); i += req.PlanningHorizon {
+			c := req.Customers[i]
+			quantity := math.Min(math.Max(c.MaxInventory-c.CurrentInventory, c.DemandRate), c.MaxInventory)
+			if quantity <= 0 {
+				continue
+			}
+			if route.TotalLoad+quantity > req.Vehicles[vehicle].Capacity {
+				if len(route.Stops) > 0 {
+					appendRoute(resp, route)
+				}
+				vehicle++
+				if vehicle == len(req.Vehicles) {
+					break
+				}
+				route = newRoute(day, start, req.Vehicles[vehicle])
+				if quantity > req.Vehicles[vehicle].Capacity {
+					continue
+				}
+			}
+			// Distances are nominal; benchmarks measure persistence, not routing
+			route.TotalDistance += 5
+			route.TotalCost += 5 * req.Vehicles[vehicle].CostPerKm
+			route.TotalLoad += quantity
+			route.Stops = append(route.Stops, optimizer.StopResult{
+				CustomerID:  c.ID,
+				Sequence:    len(route.Stops) + 1,
+				Quantity:    quantity,
+				ArrivalTime: fmt.Sprintf("%02d:%02d", 8+len(route.Stops)/4, (len(route.Stops)%4)*15),
+			})
+		}
+		if vehicle < len(req.Vehicles) && len(route.Stops) > 0 {
+			appendRoute(resp, route)
+		}
+	}
+	return resp
+}
+
+func newRoute(day int, start time.Time, v optimizer.VehicleData) optimizer.RouteResult {
+	return optimizer.RouteResult{
+		Day:       day,
+		Date:      start.AddDate(0, 0, day-1).Format("2006-01-02"),
+		VehicleID: v.ID,
+		TotalCost: v.FixedCost,
+	}
+}
+
+func appendRoute(resp *optimizer.OptimizeResponse, route optimizer.RouteResult) {
+	resp.Routes = append(resp.Routes, route)
+	resp.TotalCost += route.TotalCost
+	resp.TotalDistance += route.TotalDistance
+}
+
+// nextMonday returns the first Monday after t, at midnight
+func nextMonday(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (8 - int(day.Weekday())) % 7
+	if offset == 0 {
+		offset = 7
+	}
+	return day.AddDate(0, 0, offset)
+}
+
+// SaveSolution stores a response's routes and stops as the plan's routes and
+// marks the plan optimized, giving list and dashboard queries data to read
+func SaveSolution(db *gorm.DB, plan *models.Plan, resp *optimizer.OptimizeResponse) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		routes := make([]models.Route, len(resp.Routes))
+		for i, r := range resp.Routes {
+			date, err := time.Parse("2006-01-02", r.Date)
+			if err != nil {
+				return err
+			}
+			vehicleID := r.VehicleID
+			routes[i] = models.Route{
+				PlanID:        plan.ID,
+				VehicleID:     &vehicleID,
+				Day:           r.Day,
+				Date:          date,
+				TotalDistance: r.TotalDistance,
+				TotalCost:     r.TotalCost,
+				TotalLoad:     r.TotalLoad,
+			}
+		}
+		if len(routes) > 0 {
+			if err := tx.CreateInBatches(routes, batchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		var stops []models.Stop
+		for i, r := range resp.Routes {
+			for _, s := range r.Stops {
+				customerID := s.CustomerID
+				stops = append(stops, models.Stop{
+					RouteID:     routes[i].ID,
+					CustomerID:  &customerID,
+					Sequence:    s.Sequence,
+					Quantity:    s.Quantity,
+					ArrivalTime: s.ArrivalTime,
+				})
+			}
+		}
+		if len(stops) > 0 {
+			if err := tx.CreateInBatches(stops, batchSize).Error; err != nil {
+				return err
+			}
+		}
+
+		plan.Status = "optimized"
+		plan.TotalCost = resp.TotalCost
+		plan.TotalDistance = resp.TotalDistance
+		return tx.Model(plan).Updates(map[string]interface{}{
+			"status":         plan.Status,
+			"total_cost":     plan.TotalCost,
+			"total_distance": plan.TotalDistance,
+			"version":        gorm.Expr("version + 1"),
+		}).Error
+	})
+}
+
+// Request builds the optimizer request for a generated dataset
+func (ds *Dataset) Request() *optimizer.OptimizeRequest {
+	req := &optimizer.OptimizeRequest{
+		Warehouse: optimizer.WarehouseData{
+			ID:        ds.Warehouse.ID,
+			Latitude:  ds.Warehouse.Latitude,
+			Longitude: ds.Warehouse.Longitude,
+			Stock:     ds.Warehouse.CurrentStock,
+		},
+		Customers:       make([]optimizer.CustomerData, len(ds.Customers)),
+		Vehicles:        make([]optimizer.VehicleData, len(ds.Vehicles)),
+		PlanningHorizon: int(ds.Plan.EndDate.Sub(ds.Plan.StartDate).Hours()/24) + 1,
+		StartDate:       ds.Plan.StartDate.Format("2006-01-02"),
+	}
+	for i, c := range ds.Customers {
+		req.Customers[i] = optimizer.CustomerData{
+			ID:               c.ID,
+			Latitude:         c.Latitude,
+			Longitude:        c.Longitude,
+			DemandRate:       c.DemandRate,
+			MaxInventory:     c.MaxInventory,
+			CurrentInventory: c.CurrentInventory,
+			MinInventory:     c.MinInventory,
+			Priority:         c.Priority,
+		}
+	}
+	for i, v := range ds.Vehicles {
+		req.Vehicles[i] = optimizer.VehicleData{
+			ID:          v.ID,
+			Capacity:    v.Capacity,
+			CostPerKm:   v.CostPerKm,
+			FixedCost:   v.FixedCost,
+			MaxDistance: v.MaxDistance,
+		}
+	}
+	return req
+}
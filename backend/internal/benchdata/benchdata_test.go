@@ -0,0 +1,56 @@
+package benchdata
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// TestGenerateAndSolve tests that a generated dataset has the requested size
+// and that its synthetic solution is feasible and can be stored
+func TestGenerateAndSolve(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ds, err := Generate(db, Config{Customers: 60, Vehicles: 3, Days: 5, Seed: 7, StartDate: start})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if len(ds.Customers) != 60 || len(ds.Vehicles) != 3 || !ds.Plan.EndDate.Equal(start.AddDate(0, 0, 4)) {
+		t.Fatalf("dataset = %d customers, %d vehicles, plan to %v", len(ds.Customers), len(ds.Vehicles), ds.Plan.EndDate)
+	}
+
+	req := ds.Request()
+	resp := Solve(req)
+	if !resp.Success || len(resp.Routes) == 0 {
+		t.Fatalf("Solve() = %+v, want routes", resp)
+	}
+	if violations := optimizer.ValidateResponse(req, resp); len(violations) > 0 {
+		t.Errorf("Solve() violations = %+v", violations)
+	}
+
+	if err := SaveSolution(db, ds.Plan, resp); err != nil {
+		t.Fatalf("SaveSolution() error = %v", err)
+	}
+	var routes int64
+	db.Model(&models.Route{}).Where("plan_id = ?", ds.Plan.ID).Count(&routes)
+	if int(routes) != len(resp.Routes) {
+		t.Errorf("stored %d routes, want %d", routes, len(resp.Routes))
+	}
+
+	if _, err := Generate(db, Config{Customers: 0, Vehicles: 1, Days: 1}); err == nil {
+		t.Error("Generate() with no customers succeeded, want error")
+	}
+}
@@ -0,0 +1,20 @@
+// Package clock abstracts the current time so that time-dependent behavior
+// can be tested with a frozen or stepped clock (see testkit.Clock).
+package clock
+
+import "time"
+
+// Clock tells the current time
+type Clock interface {
+	Now() time.Time
+}
+
+// Real is the system clock. It reads UTC so that times compared, stored
+// and serialized do not depend on the server's time zone.
+var Real Clock = realClock{}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now().UTC()
+}
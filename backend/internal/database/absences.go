@@ -0,0 +1,109 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListAbsences retrieves absences overlapping a date range, optionally
+// filtered by driver and status. Zero from/to leave the range open.
+func ListAbsences(db *gorm.DB, driverID *int64, status string, from, to time.Time) ([]models.DriverAbsence, error) {
+	var absences []models.DriverAbsence
+	query := db
+	if !to.IsZero() {
+		query = query.Where("start_date <= ?", to)
+	}
+	if !from.IsZero() {
+		query = query.Where("end_date >= ?", from)
+	}
+	if driverID != nil {
+		query = query.Where("driver_id = ?", *driverID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Preload("Driver").Order("start_date, id").Find(&absences).Error
+	return absences, err
+}
+
+// GetApprovedAbsences retrieves approved absences of the given drivers that
+// overlap a date range, grouped by driver ID
+func GetApprovedAbsences(db *gorm.DB, driverIDs []int64, from, to time.Time) (map[int64][]models.DriverAbsence, error) {
+	var absences []models.DriverAbsence
+	err := db.Where("driver_id IN ? AND status = ? AND start_date <= ? AND end_date >= ?", driverIDs, "approved", to, from).
+		Find(&absences).Error
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[int64][]models.DriverAbsence)
+	for _, a := range absences {
+		result[a.DriverID] = append(result[a.DriverID], a)
+	}
+	return result, nil
+}
+
+func GetAbsence(db *gorm.DB, id int64) (*models.DriverAbsence, error) {
+	a := &models.DriverAbsence{}
+	err := db.First(a, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return a, nil
+}
+
+func CreateAbsence(db *gorm.DB, a *models.DriverAbsence) error {
+	return db.Create(a).Error
+}
+
+// ReviewAbsenceTx records an approval decision on a pending absence
+func ReviewAbsenceTx(tx *gorm.DB, a *models.DriverAbsence) error {
+	result := tx.Model(&models.DriverAbsence{}).
+		Where("id = ? AND status = ?", a.ID, "pending").
+		Updates(map[string]interface{}{
+			"status":      a.Status,
+			"reviewed_by": a.ReviewedBy,
+			"reviewed_at": a.ReviewedAt,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func DeleteAbsence(db *gorm.DB, id int64) error {
+	result := db.Delete(&models.DriverAbsence{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// FlagRoutesForReassignmentTx marks a driver's routes within a date range as
+// needing a new driver and returns them
+func FlagRoutesForReassignmentTx(tx *gorm.DB, driverID int64, from, to time.Time) ([]models.Route, error) {
+	err := tx.Model(&models.Route{}).
+		Where("driver_id = ? AND date >= ? AND date <= ?", driverID, from, to).
+		Update("needs_reassignment", true).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var routes []models.Route
+	err = tx.Where("driver_id = ? AND date >= ? AND date <= ?", driverID, from, to).
+		Order("date, id").
+		Find(&routes).Error
+	return routes, err
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AlertFilter selects alerts. Empty fields match all.
+type AlertFilter struct {
+	Statuses   []string
+	Severity   string
+	Kind       string
+	EntityType string
+	EntityID   *int64
+}
+
+// GetAlert retrieves an alert by ID
+func GetAlert(db *gorm.DB, id int64) (*models.Alert, error) {
+	alert := &models.Alert{}
+	if err := db.First(alert, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return alert, nil
+}
+
+// ListAlerts retrieves one page of the alerts matching f, latest detected
+// first, and the number of matching alerts
+func ListAlerts(db *gorm.DB, f AlertFilter, offset, limit int) ([]models.Alert, int64, error) {
+	query := db.Model(&models.Alert{})
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+	if f.Severity != "" {
+		query = query.Where("severity = ?", f.Severity)
+	}
+	if f.Kind != "" {
+		query = query.Where("kind = ?", f.Kind)
+	}
+	if f.EntityType != "" {
+		query = query.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != nil {
+		query = query.Where("entity_id = ?", *f.EntityID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var alerts []models.Alert
+	err := query.Order("detected_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&alerts).Error
+	return alerts, total, err
+}
+
+// ListUnresolvedAlertsTx retrieves the alerts not resolved yet, of one
+// entity type when entityType is set and of one entity when entityID is
+func ListUnresolvedAlertsTx(tx *gorm.DB, entityType string, entityID *int64) ([]models.Alert, error) {
+	query := tx.Where("status <> ?", models.AlertResolved)
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID != nil {
+		query = query.Where("entity_id = ?", *entityID)
+	}
+	var alerts []models.Alert
+	err := query.Order("id").Find(&alerts).Error
+	return alerts, err
+}
+
+// SaveAlertTx creates an alert or stores the changes to one
+func SaveAlertTx(tx *gorm.DB, alert *models.Alert) error {
+	return tx.Save(alert).Error
+}
+
+// AcknowledgeAlert marks an open alert acknowledged by a user. It returns
+// ErrNotFound when there is no open alert with the ID.
+func AcknowledgeAlert(db *gorm.DB, id int64, userID *int64, now time.Time) error {
+	result := db.Model(&models.Alert{}).
+		Where("id = ? AND status = ?", id, models.AlertOpen).
+		Updates(map[string]interface{}{
+			"status":          models.AlertAcknowledged,
+			"acknowledged_at": now,
+			"acknowledged_by": userID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResolveAlert marks an unresolved alert resolved, by a user or by the scan
+// when userID is nil. It returns ErrNotFound when there is no unresolved
+// alert with the ID.
+func ResolveAlert(db *gorm.DB, id int64, userID *int64, now time.Time) error {
+	result := db.Model(&models.Alert{}).
+		Where("id = ? AND status <> ?", id, models.AlertResolved).
+		Updates(map[string]interface{}{
+			"status":      models.AlertResolved,
+			"resolved_at": now,
+			"resolved_by": userID,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"errors"
+	"strings"
 
 	"LogiTrackPro/backend/internal/models"
 
@@ -10,13 +11,74 @@ import (
 
 func ListCustomers(db *gorm.DB) ([]models.Customer, error) {
 	var customers []models.Customer
-	err := db.Order("name").Find(&customers).Error
+	err := db.Preload("Tags").Order("name").Find(&customers).Error
 	return customers, err
 }
 
+// CustomerFilter selects customers to list. Search matches the name or
+// address, ignoring case. A bounding box whose MinLongitude is greater than
+// its MaxLongitude crosses the antimeridian. Tags match customers with any
+// of the named tags.
+type CustomerFilter struct {
+	Search      string
+	MinPriority *int
+	MaxPriority *int
+	Box         *BoundingBox
+	Tags        []string
+	Order       string
+}
+
+// BoundingBox is an area between two latitudes and two longitudes
+type BoundingBox struct {
+	MinLongitude, MinLatitude, MaxLongitude, MaxLatitude float64
+}
+
+// ListCustomersPage retrieves one page of the customers matching f and the
+// number of matching customers
+func ListCustomersPage(db *gorm.DB, f CustomerFilter, offset, limit int) ([]models.Customer, int64, error) {
+	query := db.Model(&models.Customer{})
+	if f.Search != "" {
+		pattern := "%" + escapeLike(strings.ToLower(f.Search)) + "%"
+		query = query.Where(`LOWER(name) LIKE ? ESCAPE '\' OR LOWER(address) LIKE ? ESCAPE '\'`, pattern, pattern)
+	}
+	if f.MinPriority != nil {
+		query = query.Where("priority >= ?", *f.MinPriority)
+	}
+	if f.MaxPriority != nil {
+		query = query.Where("priority <= ?", *f.MaxPriority)
+	}
+	if b := f.Box; b != nil {
+		query = query.Where("latitude BETWEEN ? AND ?", b.MinLatitude, b.MaxLatitude)
+		if b.MinLongitude <= b.MaxLongitude {
+			query = query.Where("longitude BETWEEN ? AND ?", b.MinLongitude, b.MaxLongitude)
+		} else {
+			query = query.Where("longitude >= ? OR longitude <= ?", b.MinLongitude, b.MaxLongitude)
+		}
+	}
+	query = withAnyTag(query, "customer_tags", "customer_id", f.Tags)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := f.Order
+	if order == "" {
+		order = "name ASC"
+	}
+	var customers []models.Customer
+	err := query.Preload("Tags").Order(order).Order("id").Offset(offset).Limit(limit).Find(&customers).Error
+	return customers, total, err
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func GetCustomer(db *gorm.DB, id int64) (*models.Customer, error) {
 	c := &models.Customer{}
-	err := db.First(c, id).Error
+	err := db.Preload("Tags").First(c, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
@@ -31,27 +93,62 @@ func CreateCustomer(db *gorm.DB, c *models.Customer) error {
 }
 
 func UpdateCustomer(db *gorm.DB, c *models.Customer) error {
-	result := db.Model(c).Updates(models.Customer{
-		Name:             c.Name,
-		Address:          c.Address,
-		Latitude:         c.Latitude,
-		Longitude:        c.Longitude,
-		DemandRate:       c.DemandRate,
-		MaxInventory:     c.MaxInventory,
-		CurrentInventory: c.CurrentInventory,
-		MinInventory:     c.MinInventory,
-		HoldingCost:      c.HoldingCost,
-		Priority:         c.Priority,
+	version, err := updateVersion(db, &models.Customer{}, c.ID, c.Version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(c).Updates(models.Customer{
+			Name:             c.Name,
+			Address:          c.Address,
+			Latitude:         c.Latitude,
+			Longitude:        c.Longitude,
+			DemandRate:       c.DemandRate,
+			MaxInventory:     c.MaxInventory,
+			CurrentInventory: c.CurrentInventory,
+			MinInventory:     c.MinInventory,
+			HoldingCost:      c.HoldingCost,
+			Priority:         c.Priority,
+			ProductID:        c.ProductID,
+			ServiceTags:      c.ServiceTags,
+			MinDropSize:      c.MinDropSize,
+			WarehouseID:      c.WarehouseID,
+			ExternalRef:      c.ExternalRef,
+		})
 	})
-	if result.Error != nil {
-		return result.Error
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+	c.Version = version
+	return nil
+}
+
+// UpdateCustomerColumns writes the given columns of a customer, zero values
+// included
+func UpdateCustomerColumns(db *gorm.DB, c *models.Customer, columns []string) error {
+	version, err := updateVersion(db, &models.Customer{}, c.ID, c.Version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(c).Select(columns).Updates(c)
+	})
+	if err != nil {
+		return err
 	}
+	c.Version = version
 	return nil
 }
 
+// GetCustomersByExternalRef retrieves the customers with any of the
+// external references, by reference
+func GetCustomersByExternalRef(db *gorm.DB, refs []string) (map[string]*models.Customer, error) {
+	result := make(map[string]*models.Customer)
+	if len(refs) == 0 {
+		return result, nil
+	}
+	var customers []models.Customer
+	if err := db.Where("external_ref IN ?", refs).Find(&customers).Error; err != nil {
+		return nil, err
+	}
+	for i := range customers {
+		result[customers[i].ExternalRef] = &customers[i]
+	}
+	return result, nil
+}
+
 func DeleteCustomer(db *gorm.DB, id int64) error {
 	result := db.Delete(&models.Customer{}, id)
 	if result.Error != nil {
@@ -2,7 +2,9 @@ package database
 
 import (
 	"fmt"
+	"time"
 
+	"LogiTrackPro/backend/internal/clock"
 	"LogiTrackPro/backend/internal/models"
 
 	"gorm.io/driver/postgres"
@@ -33,30 +35,90 @@ func Connect(databaseURL string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
+	UseClock(db, clock.Real)
 	return db, nil
 }
 
-func RunMigrations(db *gorm.DB) error {
-	// AutoMigrate will create tables, missing columns, missing indexes, etc.
-	// It will NOT delete unused columns to protect your data.
-	err := db.AutoMigrate(
+// UseClock makes GORM read c for the created_at and updated_at timestamps
+// it sets, in UTC
+func UseClock(db *gorm.DB, c clock.Clock) {
+	db.Config.NowFunc = func() time.Time {
+		return c.Now().UTC()
+	}
+}
+
+// withDeleted is a preload condition that keeps soft-deleted records, so
+// routes and other history still show the customers, vehicles and
+// warehouses they were planned with
+func withDeleted(db *gorm.DB) *gorm.DB {
+	return db.Unscoped()
+}
+
+// migratedModels are the models RunMigrations creates tables for with
+// AutoMigrate; location pings are migrated separately
+func migratedModels() []interface{} {
+	return []interface{}{
 		&models.User{},
 		&models.Warehouse{},
 		&models.Customer{},
 		&models.Vehicle{},
+		&models.Tag{},
+		&models.Driver{},
+		&models.Place{},
+		&models.RosterEntry{},
+		&models.DriverAbsence{},
 		&models.Plan{},
+		&models.PlanTemplate{},
 		&models.Route{},
 		&models.Stop{},
 		&models.RouteExecution{},
 		&models.StopExecution{},
+		&models.VehicleLog{},
+		&models.MaintenanceRecord{},
+		&models.Incident{},
 		&models.InventorySnapshot{},
+		&models.InventoryAdjustment{},
+		&models.DemandEstimate{},
+		&models.StockTransfer{},
+		&models.Stocktake{},
+		&models.StocktakeLine{},
+		&models.Alert{},
 		&models.Product{},
 		&models.CustomerProductInventory{},
 		&models.StopProductQuantity{},
-	)
+		&models.WarehouseProductStock{},
+		&models.StockMovement{},
+		&models.StopExplanation{},
+		&models.Scenario{},
+		&models.ScenarioRoute{},
+		&models.PlanSolution{},
+		&models.SolutionRoute{},
+		&models.UnroutedCustomer{},
+		&models.Redelivery{},
+		&models.OptimizationRun{},
+		&models.Job{},
+		&models.Organization{},
+		&models.UsageCounter{},
+		&models.SecurityEvent{},
+		&models.RouteMessage{},
+		&models.RouteMessageRead{},
+		&models.DriverStopEvent{},
+		&models.IdempotencyKey{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+	}
+}
+
+func RunMigrations(db *gorm.DB) error {
+	// AutoMigrate will create tables, missing columns, missing indexes, etc.
+	// It will NOT delete unused columns to protect your data.
+	err := db.AutoMigrate(migratedModels()...)
 	if err != nil {
 		return fmt.Errorf("migration failed: %w", err)
 	}
+	if err := migrateLocationPings(db); err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
 
 	return nil
 }
@@ -0,0 +1,119 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CustomerDelivery is a quantity delivered to a customer by a completed stop
+type CustomerDelivery struct {
+	CustomerID  int64
+	Quantity    float64
+	DeliveredAt time.Time
+}
+
+// ListCustomerSnapshots retrieves the inventory snapshots of customers
+// taken from from up to to, of one customer when customerID is set, by
+// customer and in the order they were taken
+func ListCustomerSnapshots(db *gorm.DB, customerID *int64, from, to time.Time) ([]models.InventorySnapshot, error) {
+	query := db.Where("entity_type = ? AND snapshot_time >= ? AND snapshot_time <= ?", "customer", from, to)
+	if customerID != nil {
+		query = query.Where("entity_id = ?", *customerID)
+	}
+	var snapshots []models.InventorySnapshot
+	err := query.Order("entity_id, snapshot_time, id").Find(&snapshots).Error
+	return snapshots, err
+}
+
+// ListCustomerDeliveries retrieves the quantities completed stops delivered
+// after from up to to, to one customer when customerID is set
+func ListCustomerDeliveries(db *gorm.DB, customerID *int64, from, to time.Time) ([]CustomerDelivery, error) {
+	query := db.Model(&models.StopExecution{}).
+		Select("stops.customer_id AS customer_id, stop_executions.actual_quantity AS quantity, stop_executions.actual_departure_time AS delivered_at").
+		Joins("JOIN stops ON stop_executions.stop_id = stops.id").
+		Where("stop_executions.status = ? AND stops.customer_id IS NOT NULL", "completed").
+		Where("stop_executions.actual_departure_time > ? AND stop_executions.actual_departure_time <= ?", from, to)
+	if customerID != nil {
+		query = query.Where("stops.customer_id = ?", *customerID)
+	}
+	var deliveries []CustomerDelivery
+	err := query.Order("stop_executions.actual_departure_time").Scan(&deliveries).Error
+	return deliveries, err
+}
+
+// GetAdjustmentDeltas returns the deltas of the inventory adjustments
+// recorded by the given snapshots, by snapshot ID
+func GetAdjustmentDeltas(db *gorm.DB, snapshotIDs []int64) (map[int64]float64, error) {
+	deltas := make(map[int64]float64)
+	if len(snapshotIDs) == 0 {
+		return deltas, nil
+	}
+	var adjustments []models.InventoryAdjustment
+	if err := db.Where("snapshot_id IN ?", snapshotIDs).Find(&adjustments).Error; err != nil {
+		return nil, err
+	}
+	for _, a := range adjustments {
+		deltas[*a.SnapshotID] += a.Delta
+	}
+	return deltas, nil
+}
+
+// CreateDemandEstimates stores estimates, all or none
+func CreateDemandEstimates(db *gorm.DB, estimates []models.DemandEstimate) error {
+	if len(estimates) == 0 {
+		return nil
+	}
+	return db.Create(&estimates).Error
+}
+
+// GetLatestDemandEstimate retrieves a customer's latest demand estimate
+func GetLatestDemandEstimate(db *gorm.DB, customerID int64) (*models.DemandEstimate, error) {
+	estimate := &models.DemandEstimate{}
+	err := db.Where("customer_id = ?", customerID).Order("estimated_at DESC, id DESC").First(estimate).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// ListDemandEstimates retrieves one page of a customer's demand estimates,
+// latest first, and the number of estimates
+func ListDemandEstimates(db *gorm.DB, customerID int64, offset, limit int) ([]models.DemandEstimate, int64, error) {
+	query := db.Model(&models.DemandEstimate{}).Where("customer_id = ?", customerID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var estimates []models.DemandEstimate
+	err := query.Order("estimated_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&estimates).Error
+	return estimates, total, err
+}
+
+// ApplyDemandEstimate sets the customer's demand rate to the estimate by
+// method and records who applied it, all or none
+func ApplyDemandEstimate(db *gorm.DB, e *models.DemandEstimate, method string, userID *int64, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Customer{}).Where("id = ?", e.CustomerID).Updates(map[string]interface{}{
+			"demand_rate": e.Rate(method),
+			"version":     nextVersion,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		e.AppliedMethod, e.AppliedBy, e.AppliedAt = method, userID, &now
+		return tx.Model(e).Select("applied_method", "applied_by", "applied_at").Updates(e).Error
+	})
+}
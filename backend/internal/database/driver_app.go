@@ -0,0 +1,112 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// GetDriverByUserID retrieves the driver linked to a user account
+func GetDriverByUserID(db *gorm.DB, userID int64) (*models.Driver, error) {
+	d := &models.Driver{}
+	err := db.Where("user_id = ?", userID).Order("id").First(d).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetDriverRoutes retrieves a driver's routes on a date from plans in any
+// of the given statuses, with plan, vehicle and stops in delivery order.
+// Customers only carry what a driver needs to find them.
+func GetDriverRoutes(db *gorm.DB, driverID int64, date time.Time, statuses []string) ([]models.Route, error) {
+	var routes []models.Route
+	err := db.Joins("JOIN plans ON routes.plan_id = plans.id").
+		Where("routes.driver_id = ? AND routes.date >= ? AND routes.date < ? AND plans.status IN ? AND plans.deleted_at IS NULL",
+			driverID, date, date.AddDate(0, 0, 1), statuses).
+		Preload("Plan").
+		Preload("Vehicle", withDeleted).
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer", func(db *gorm.DB) *gorm.DB {
+			return db.Unscoped().Select("id", "name", "address", "latitude", "longitude")
+		}).
+		Preload("Stops.Place", withDeleted).
+		Order("routes.planned_start, routes.id").
+		Find(&routes).Error
+	return routes, err
+}
+
+// GetExecutionsForRoutes retrieves the executions of routes with their stop
+// executions, newest first
+func GetExecutionsForRoutes(db *gorm.DB, routeIDs []int64) ([]models.RouteExecution, error) {
+	var executions []models.RouteExecution
+	if len(routeIDs) == 0 {
+		return executions, nil
+	}
+	err := db.Where("route_id IN ?", routeIDs).
+		Preload("StopExecutions").
+		Order("created_at DESC, id DESC").
+		Find(&executions).Error
+	return executions, err
+}
+
+// GetDriverRosterNotes returns the notes of a driver's roster entries on a
+// date
+func GetDriverRosterNotes(db *gorm.DB, driverID int64, date time.Time) ([]string, error) {
+	var notes []string
+	err := db.Model(&models.RosterEntry{}).
+		Where("driver_id = ? AND date >= ? AND date < ? AND notes <> ''", driverID, date, date.AddDate(0, 0, 1)).
+		Order("id").
+		Pluck("notes", &notes).Error
+	return notes, err
+}
+
+// GetDriverStopEvent retrieves an uploaded stop event by its client ID
+func GetDriverStopEvent(db *gorm.DB, clientEventID string) (*models.DriverStopEvent, error) {
+	event := &models.DriverStopEvent{}
+	err := db.Where("client_event_id = ?", clientEventID).First(event).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+// GetDriverStopEventsByExecution returns the stop events uploaded for a
+// route execution's stops, in the order they were received
+func GetDriverStopEventsByExecution(db *gorm.DB, executionID int64) ([]models.DriverStopEvent, error) {
+	var events []models.DriverStopEvent
+	err := db.Joins("JOIN stop_executions ON stop_executions.id = driver_stop_events.stop_execution_id").
+		Where("stop_executions.route_execution_id = ?", executionID).
+		Order("driver_stop_events.id").
+		Find(&events).Error
+	return events, err
+}
+
+// CreateDriverStopEventTx records an applied stop event
+func CreateDriverStopEventTx(tx *gorm.DB, event *models.DriverStopEvent) error {
+	return tx.Create(event).Error
+}
+
+// GetPlanDriverUserIDs returns the user accounts of the drivers assigned to
+// a plan's routes on or after a date
+func GetPlanDriverUserIDs(db *gorm.DB, planID int64, from time.Time) ([]int64, error) {
+	var ids []int64
+	err := db.Model(&models.Route{}).
+		Joins("JOIN drivers ON routes.driver_id = drivers.id").
+		Where("routes.plan_id = ? AND routes.date >= ? AND drivers.user_id IS NOT NULL", planID, from).
+		Distinct().
+		Order("drivers.user_id").
+		Pluck("drivers.user_id", &ids).Error
+	return ids, err
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"errors"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func ListDrivers(db *gorm.DB) ([]models.Driver, error) {
+	var drivers []models.Driver
+	err := db.Order("name").Find(&drivers).Error
+	return drivers, err
+}
+
+func GetDriver(db *gorm.DB, id int64) (*models.Driver, error) {
+	d := &models.Driver{}
+	err := db.First(d, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// GetDriversByIDs returns the drivers keyed by ID; unknown IDs are omitted
+func GetDriversByIDs(db *gorm.DB, ids []int64) (map[int64]models.Driver, error) {
+	var drivers []models.Driver
+	if err := db.Where("id IN ?", ids).Find(&drivers).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int64]models.Driver, len(drivers))
+	for _, d := range drivers {
+		result[d.ID] = d
+	}
+	return result, nil
+}
+
+func CreateDriver(db *gorm.DB, d *models.Driver) error {
+	return db.Create(d).Error
+}
+
+func UpdateDriver(db *gorm.DB, d *models.Driver) error {
+	result := db.Model(d).Select("name", "phone", "license_number", "status", "warehouse_id", "user_id", "updated_at").Updates(d)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func DeleteDriver(db *gorm.DB, id int64) error {
+	result := db.Delete(&models.Driver{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
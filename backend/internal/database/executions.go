@@ -2,6 +2,7 @@ package database
 
 import (
 	"errors"
+	"math"
 	"time"
 
 	"LogiTrackPro/backend/internal/models"
@@ -11,13 +12,14 @@ import (
 
 // CreateRouteExecution creates a new route execution record
 func CreateRouteExecution(db *gorm.DB, execution *models.RouteExecution) error {
+	execution.Progress = executionProgress(execution)
 	return db.Create(execution).Error
 }
 
 // GetRouteExecution retrieves a route execution by ID
 func GetRouteExecution(db *gorm.DB, id int64) (*models.RouteExecution, error) {
 	execution := &models.RouteExecution{}
-	err := db.Preload("Route").Preload("StopExecutions.Stop").
+	err := db.Preload("Route").Preload("StopExecutions.Stop").Preload("VehicleLog").
 		First(execution, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -38,6 +40,50 @@ func GetRouteExecutionsByRoute(db *gorm.DB, routeID int64) ([]models.RouteExecut
 	return executions, err
 }
 
+// GetRouteExecutionsByPlan retrieves all executions of a plan's routes with
+// their stop executions
+func GetRouteExecutionsByPlan(db *gorm.DB, planID int64) ([]models.RouteExecution, error) {
+	var executions []models.RouteExecution
+	err := db.Joins("JOIN routes ON route_executions.route_id = routes.id").
+		Where("routes.plan_id = ?", planID).
+		Preload("StopExecutions").
+		Order("route_executions.created_at, route_executions.id").
+		Find(&executions).Error
+	return executions, err
+}
+
+// AccuracyFilter selects the plans scored for accuracy: those starting
+// between From and To, optionally of one warehouse or planner
+type AccuracyFilter struct {
+	From        time.Time
+	To          time.Time
+	WarehouseID *int64
+	CreatedBy   *int64
+}
+
+// GetCompletedExecutionsForAccuracy retrieves the completed route executions
+// of the plans f selects, with their stop executions and their route's plan,
+// warehouse and creator
+func GetCompletedExecutionsForAccuracy(db *gorm.DB, f AccuracyFilter) ([]models.RouteExecution, error) {
+	query := db.Joins("JOIN routes ON route_executions.route_id = routes.id").
+		Joins("JOIN plans ON routes.plan_id = plans.id AND plans.deleted_at IS NULL").
+		Where("route_executions.status = ? AND plans.start_date BETWEEN ? AND ?", "completed", f.From, f.To)
+	if f.WarehouseID != nil {
+		query = query.Where("plans.warehouse_id = ?", *f.WarehouseID)
+	}
+	if f.CreatedBy != nil {
+		query = query.Where("plans.created_by = ?", *f.CreatedBy)
+	}
+
+	var executions []models.RouteExecution
+	err := query.Preload("StopExecutions").
+		Preload("Route.Plan.Warehouse", withDeleted).
+		Preload("Route.Plan.User").
+		Order("plans.start_date, plans.id, route_executions.id").
+		Find(&executions).Error
+	return executions, err
+}
+
 // UpdateRouteExecution updates a route execution
 func UpdateRouteExecution(db *gorm.DB, execution *models.RouteExecution) error {
 	result := db.Model(execution).Updates(models.RouteExecution{
@@ -56,17 +102,96 @@ func UpdateRouteExecution(db *gorm.DB, execution *models.RouteExecution) error {
 	if result.RowsAffected == 0 {
 		return ErrNotFound
 	}
+	return refreshExecutionProgress(db, execution.ID)
+}
+
+// SetExecutionShift records the driver's shift and breaks on a route
+// execution; a nil end leaves the shift open
+func SetExecutionShift(db *gorm.DB, id int64, start time.Time, end *time.Time, breaks []models.ShiftBreak) error {
+	result := db.Model(&models.RouteExecution{ID: id}).
+		Select("shift_start", "shift_end", "breaks").
+		Updates(&models.RouteExecution{ShiftStart: &start, ShiftEnd: end, Breaks: breaks})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
 	return nil
 }
 
-// StartRouteExecution marks a route execution as in progress
-func StartRouteExecution(db *gorm.DB, executionID int64) error {
-	now := time.Now()
+// GetDriverShifts retrieves the route executions of routes with a driver
+// whose shift, or actual start without one, falls between from and to,
+// optionally of some drivers only, with their stop executions and route
+func GetDriverShifts(db *gorm.DB, driverIDs []int64, from, to time.Time) ([]models.RouteExecution, error) {
+	query := db.Joins("JOIN routes ON route_executions.route_id = routes.id").
+		Where("routes.driver_id IS NOT NULL").
+		Where("COALESCE(route_executions.shift_start, route_executions.actual_start_time) BETWEEN ? AND ?", from, to)
+	if len(driverIDs) > 0 {
+		query = query.Where("routes.driver_id IN ?", driverIDs)
+	}
+
+	var executions []models.RouteExecution
+	err := query.Preload("StopExecutions").
+		Preload("Route").
+		Order("route_executions.id").
+		Find(&executions).Error
+	return executions, err
+}
+
+// GetCompletedExecutionsForExport retrieves the route executions completed
+// between from and to, optionally of one driver's routes, with their stop
+// executions and their route's plan, vehicle and driver, ordered by driver
+// and completion
+func GetCompletedExecutionsForExport(db *gorm.DB, from, to time.Time, driverID *int64) ([]models.RouteExecution, error) {
+	query := db.Joins("JOIN routes ON route_executions.route_id = routes.id").
+		Where("route_executions.status = ? AND route_executions.actual_end_time BETWEEN ? AND ?", "completed", from, to)
+	if driverID != nil {
+		query = query.Where("routes.driver_id = ?", *driverID)
+	}
+
+	var executions []models.RouteExecution
+	err := query.Preload("StopExecutions").
+		Preload("Route.Plan", withDeleted).
+		Preload("Route.Vehicle", withDeleted).
+		Preload("Route.Driver").
+		Order("routes.driver_id, route_executions.actual_end_time, route_executions.id").
+		Find(&executions).Error
+	return executions, err
+}
+
+// GetRoutesWithoutExecutionsTx retrieves the routes of a plan that have no
+// execution record, with their stops in delivery order
+func GetRoutesWithoutExecutionsTx(tx *gorm.DB, planID int64) ([]models.Route, error) {
+	var routes []models.Route
+	err := tx.Where("plan_id = ? AND id NOT IN (?)", planID, tx.Model(&models.RouteExecution{}).Select("route_id")).
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Order("day, id").
+		Find(&routes).Error
+	return routes, err
+}
+
+// CreateRouteExecutionsTx creates route executions with their stop
+// executions
+func CreateRouteExecutionsTx(tx *gorm.DB, executions []models.RouteExecution) error {
+	if len(executions) == 0 {
+		return nil
+	}
+	for i := range executions {
+		executions[i].Progress = executionProgress(&executions[i])
+	}
+	return tx.Create(&executions).Error
+}
+
+// StartRouteExecution marks a route execution as in progress from startTime
+func StartRouteExecution(db *gorm.DB, executionID int64, startTime time.Time) error {
 	result := db.Model(&models.RouteExecution{}).
 		Where("id = ?", executionID).
 		Updates(map[string]interface{}{
 			"status":            "in_progress",
-			"actual_start_time": now,
+			"actual_start_time": startTime,
 		})
 	if result.Error != nil {
 		return result.Error
@@ -74,12 +199,11 @@ func StartRouteExecution(db *gorm.DB, executionID int64) error {
 	if result.RowsAffected == 0 {
 		return ErrNotFound
 	}
-	return nil
+	return refreshExecutionProgress(db, executionID)
 }
 
-// CompleteRouteExecution marks a route execution as completed
-func CompleteRouteExecution(db *gorm.DB, executionID int64, actualDistance, actualCost, actualLoad float64) error {
-	now := time.Now()
+// CompleteRouteExecution marks a route execution as completed at endTime
+func CompleteRouteExecution(db *gorm.DB, executionID int64, actualDistance, actualCost, actualLoad float64, endTime time.Time) error {
 	result := db.Model(&models.RouteExecution{}).
 		Where("id = ?", executionID).
 		Updates(map[string]interface{}{
@@ -87,7 +211,7 @@ func CompleteRouteExecution(db *gorm.DB, executionID int64, actualDistance, actu
 			"actual_distance": actualDistance,
 			"actual_cost":     actualCost,
 			"actual_load":     actualLoad,
-			"actual_end_time": now,
+			"actual_end_time": endTime,
 		})
 	if result.Error != nil {
 		return result.Error
@@ -95,7 +219,26 @@ func CompleteRouteExecution(db *gorm.DB, executionID int64, actualDistance, actu
 	if result.RowsAffected == 0 {
 		return ErrNotFound
 	}
-	return nil
+	return refreshExecutionProgress(db, executionID)
+}
+
+// CountStartedExecutionsFromDay counts executions that have left the pending
+// state for a plan's routes on or after the given day
+func CountStartedExecutionsFromDay(db *gorm.DB, planID int64, fromDay int) (int, error) {
+	var count int64
+	err := db.Model(&models.RouteExecution{}).
+		Joins("JOIN routes ON route_executions.route_id = routes.id").
+		Where("routes.plan_id = ? AND routes.day >= ? AND route_executions.status NOT IN ?",
+			planID, fromDay, []string{"pending", "cancelled"}).
+		Count(&count).Error
+	return int(count), err
+}
+
+// CountCompletedExecutions counts route executions that were completed
+func CountCompletedExecutions(db *gorm.DB) (int, error) {
+	var count int64
+	err := db.Model(&models.RouteExecution{}).Where("status = ?", "completed").Count(&count).Error
+	return int(count), err
 }
 
 // CreateStopExecution creates a new stop execution record
@@ -103,16 +246,47 @@ func CreateStopExecution(db *gorm.DB, execution *models.StopExecution) error {
 	return db.Create(execution).Error
 }
 
-// GetStopExecutionsByRouteExecution retrieves all stop executions for a route execution
+// GetStopExecutionsByRouteExecution retrieves all stop executions for a
+// route execution in stop sequence
 func GetStopExecutionsByRouteExecution(db *gorm.DB, routeExecutionID int64) ([]models.StopExecution, error) {
 	var executions []models.StopExecution
-	err := db.Where("route_execution_id = ?", routeExecutionID).
-		Preload("Stop").
-		Order("sequence").
+	err := db.Joins("JOIN stops ON stops.id = stop_executions.stop_id").
+		Where("stop_executions.route_execution_id = ?", routeExecutionID).
+		Preload("Stop.Customer", withDeleted).
+		Order("stops.sequence").
 		Find(&executions).Error
 	return executions, err
 }
 
+// GetStopExecution retrieves a stop execution with its route execution and
+// route
+func GetStopExecution(db *gorm.DB, id int64) (*models.StopExecution, error) {
+	execution := &models.StopExecution{}
+	err := db.Preload("RouteExecution.Route").First(execution, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return execution, nil
+}
+
+// SaveProofOfDelivery stores the recipient, signature and photos captured at
+// a stop
+func SaveProofOfDelivery(db *gorm.DB, execution *models.StopExecution) error {
+	result := db.Model(execution).
+		Select("recipient_name", "pod_signature", "pod_photos", "pod_captured_at").
+		Updates(execution)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 // UpdateStopExecution updates a stop execution
 func UpdateStopExecution(db *gorm.DB, execution *models.StopExecution) error {
 	result := db.Model(execution).Updates(models.StopExecution{
@@ -132,6 +306,203 @@ func UpdateStopExecution(db *gorm.DB, execution *models.StopExecution) error {
 	return nil
 }
 
+// RecordStopOutcomeTx stores what happened at a stop. Unlike
+// UpdateStopExecution it also writes zero quantities and durations.
+func RecordStopOutcomeTx(tx *gorm.DB, execution *models.StopExecution) error {
+	result := tx.Model(execution).
+		Select("status", "actual_quantity", "actual_arrival_time", "actual_departure_time", "service_duration", "notes",
+			"arrival_detected", "departure_detected").
+		Updates(execution)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RecordDeliveryTx moves the quantity delivered at a completed stop from
+// the stock of its plan's warehouse to its customer's inventory, and
+// snapshots both at the departure with reason delivery. The warehouse's
+// side is booked to its stock ledger, against its stock of the customer's
+// product when it keeps one. Stops left without delivering, and stops
+// without a customer, leave inventory alone.
+func RecordDeliveryTx(tx *gorm.DB, execution *models.StopExecution) error {
+	if execution.Status != "completed" || execution.ActualQuantity <= 0 || execution.ActualDepartureTime == nil {
+		return nil
+	}
+	stop := &models.Stop{}
+	if err := tx.Preload("Route.Plan").First(stop, execution.StopID).Error; err != nil {
+		return err
+	}
+	if stop.CustomerID == nil || stop.Route == nil || stop.Route.Plan == nil {
+		return nil
+	}
+	at := *execution.ActualDepartureTime
+
+	customer := &models.Customer{}
+	err := tx.Unscoped().Model(customer).Where("id = ?", *stop.CustomerID).
+		UpdateColumns(map[string]interface{}{
+			"current_inventory": gorm.Expr("current_inventory + ?", execution.ActualQuantity),
+			"version":           nextVersion,
+		}).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Unscoped().First(customer, *stop.CustomerID).Error; err != nil {
+		return err
+	}
+	snapshots := []models.InventorySnapshot{{
+		EntityType:     "customer",
+		EntityID:       customer.ID,
+		InventoryLevel: customer.CurrentInventory,
+		DemandRate:     customer.DemandRate,
+		MinInventory:   customer.MinInventory,
+		MaxInventory:   customer.MaxInventory,
+	}}
+
+	if warehouseID := stop.Route.Plan.WarehouseID; warehouseID != nil {
+		movement := &models.StockMovement{
+			WarehouseID:     *warehouseID,
+			Kind:            models.StockDelivery,
+			Quantity:        -execution.ActualQuantity,
+			PlanID:          &stop.Route.PlanID,
+			RouteID:         &stop.RouteID,
+			StopExecutionID: &execution.ID,
+			OccurredAt:      at,
+		}
+		if customer.ProductID != nil {
+			var kept int64
+			err := tx.Model(&models.WarehouseProductStock{}).
+				Where("warehouse_id = ? AND product_id = ?", *warehouseID, *customer.ProductID).
+				Count(&kept).Error
+			if err != nil {
+				return err
+			}
+			if kept > 0 {
+				movement.ProductID = customer.ProductID
+			}
+		}
+		if err := RecordStockMovementTx(tx, movement); err != nil {
+			return err
+		}
+		snapshots = append(snapshots, models.InventorySnapshot{
+			EntityType:     "warehouse",
+			EntityID:       *warehouseID,
+			InventoryLevel: movement.Balance,
+		})
+	}
+	for i := range snapshots {
+		snapshots[i].SnapshotDate = at.UTC().Truncate(24 * time.Hour)
+		snapshots[i].SnapshotTime = at
+		snapshots[i].SnapshotReason = "delivery"
+		snapshots[i].PlanID = &stop.Route.PlanID
+		snapshots[i].RouteID = &stop.RouteID
+	}
+	return tx.Create(&snapshots).Error
+}
+
+// RollUpStopExecutionsTx recalculates a route execution's actual load from
+// its completed stops and refreshes its progress. A pending execution moves
+// to in progress, and one without a start time starts at its first stop
+// arrival.
+func RollUpStopExecutionsTx(tx *gorm.DB, routeExecutionID int64) error {
+	execution, err := getExecutionWithStops(tx, routeExecutionID)
+	if err != nil {
+		return err
+	}
+
+	execution.ActualLoad = 0
+	var firstArrival *time.Time
+	for _, s := range execution.StopExecutions {
+		if s.Status == "completed" {
+			execution.ActualLoad += s.ActualQuantity
+		}
+		if s.ActualArrivalTime != nil && (firstArrival == nil || s.ActualArrivalTime.Before(*firstArrival)) {
+			firstArrival = s.ActualArrivalTime
+		}
+	}
+	if execution.Status == "pending" {
+		execution.Status = "in_progress"
+	}
+	if execution.ActualStartTime == nil {
+		execution.ActualStartTime = firstArrival
+	}
+	execution.Progress = executionProgress(execution)
+
+	columns := append([]string{"status", "actual_load", "actual_start_time"}, progressColumns...)
+	return tx.Model(execution).Select(columns).Updates(execution).Error
+}
+
+// progressColumns are the columns of models.ExecutionProgress
+var progressColumns = []string{
+	"progress_total_stops", "progress_completed_stops", "progress_stops_percent", "progress_delivered_percent",
+	"progress_planned_minutes", "progress_elapsed_minutes", "progress_time_percent",
+}
+
+// refreshExecutionProgress recomputes and stores a route execution's
+// progress after its actuals changed
+func refreshExecutionProgress(db *gorm.DB, routeExecutionID int64) error {
+	execution, err := getExecutionWithStops(db, routeExecutionID)
+	if err != nil {
+		return err
+	}
+	execution.Progress = executionProgress(execution)
+	return db.Model(execution).Select(progressColumns).Updates(execution).Error
+}
+
+func getExecutionWithStops(db *gorm.DB, id int64) (*models.RouteExecution, error) {
+	execution := &models.RouteExecution{}
+	if err := db.Preload("StopExecutions").First(execution, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return execution, nil
+}
+
+// executionProgress computes a route execution's progress from its fields
+// and stop executions
+func executionProgress(e *models.RouteExecution) models.ExecutionProgress {
+	p := models.ExecutionProgress{TotalStops: len(e.StopExecutions)}
+	last := e.ActualEndTime
+	for _, s := range e.StopExecutions {
+		if s.Status == "completed" {
+			p.CompletedStops++
+		}
+		if e.ActualEndTime != nil {
+			continue
+		}
+		for _, t := range []*time.Time{s.ActualArrivalTime, s.ActualDepartureTime} {
+			if t != nil && (last == nil || t.After(*last)) {
+				last = t
+			}
+		}
+	}
+
+	if e.PlannedStartTime != nil && e.PlannedEndTime != nil && e.PlannedEndTime.After(*e.PlannedStartTime) {
+		p.PlannedMinutes = int(e.PlannedEndTime.Sub(*e.PlannedStartTime).Minutes())
+	}
+	if e.ActualStartTime != nil && last != nil && last.After(*e.ActualStartTime) {
+		p.ElapsedMinutes = int(last.Sub(*e.ActualStartTime).Minutes())
+	}
+	p.StopsPercent = percentOf(float64(p.CompletedStops), float64(p.TotalStops))
+	p.DeliveredPercent = percentOf(e.ActualLoad, e.PlannedLoad)
+	p.TimePercent = percentOf(float64(p.ElapsedMinutes), float64(p.PlannedMinutes))
+	return p
+}
+
+// percentOf is part as a percentage of whole rounded to one decimal, or 0
+// when whole is not positive
+func percentOf(part, whole float64) float64 {
+	if whole <= 0 {
+		return 0
+	}
+	return math.Round(part/whole*1000) / 10
+}
+
 // GetExecutionStats calculates execution statistics for a plan
 func GetExecutionStats(db *gorm.DB, planID int64) (map[string]interface{}, error) {
 	var stats struct {
@@ -141,6 +512,10 @@ func GetExecutionStats(db *gorm.DB, planID int64) (map[string]interface{}, error
 		TotalActualCost      float64
 		TotalPlannedDistance float64
 		TotalActualDistance  float64
+		TotalStops           int64
+		CompletedStops       int64
+		TotalPlannedLoad     float64
+		TotalActualLoad      float64
 	}
 
 	err := db.Table("route_executions").
@@ -150,7 +525,11 @@ func GetExecutionStats(db *gorm.DB, planID int64) (map[string]interface{}, error
 			COALESCE(SUM(planned_cost), 0) as total_planned_cost,
 			COALESCE(SUM(actual_cost), 0) as total_actual_cost,
 			COALESCE(SUM(planned_distance), 0) as total_planned_distance,
-			COALESCE(SUM(actual_distance), 0) as total_actual_distance
+			COALESCE(SUM(actual_distance), 0) as total_actual_distance,
+			COALESCE(SUM(progress_total_stops), 0) as total_stops,
+			COALESCE(SUM(progress_completed_stops), 0) as completed_stops,
+			COALESCE(SUM(planned_load), 0) as total_planned_load,
+			COALESCE(SUM(actual_load), 0) as total_actual_load
 		`).
 		Joins("JOIN routes ON route_executions.route_id = routes.id").
 		Where("routes.plan_id = ?", planID).
@@ -167,6 +546,10 @@ func GetExecutionStats(db *gorm.DB, planID int64) (map[string]interface{}, error
 		"total_actual_cost":      stats.TotalActualCost,
 		"total_planned_distance": stats.TotalPlannedDistance,
 		"total_actual_distance":  stats.TotalActualDistance,
+		"total_stops":            stats.TotalStops,
+		"completed_stops":        stats.CompletedStops,
+		"stops_percent":          percentOf(float64(stats.CompletedStops), float64(stats.TotalStops)),
+		"delivered_percent":      percentOf(stats.TotalActualLoad, stats.TotalPlannedLoad),
 	}
 
 	if stats.TotalPlannedCost > 0 {
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ExternalRefOwner returns the ID of the record of model's kind, e.g.
+// &models.Vehicle{}, with an external reference
+func ExternalRefOwner(db *gorm.DB, model interface{}, ref string) (int64, error) {
+	var id int64
+	err := db.Model(model).Where("external_ref = ?", ref).Select("id").Order("id").Limit(1).Scan(&id).Error
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, ErrNotFound
+	}
+	return id, nil
+}
+
+// GetByExternalRef retrieves the record with an external reference into
+// dest, e.g. a *models.Vehicle
+func GetByExternalRef(db *gorm.DB, dest interface{}, ref string) error {
+	err := db.Where("external_ref = ?", ref).Order("id").First(dest).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,58 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ClaimIdempotencyKey records a request under a user's idempotency key.
+// Keys created before since have expired and are replaced. When the key is
+// taken it returns the request holding it and ErrDuplicate.
+func ClaimIdempotencyKey(db *gorm.DB, k *models.IdempotencyKey, since time.Time) (*models.IdempotencyKey, error) {
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND key = ? AND created_at < ?", k.UserID, k.Key, since).
+			Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(k).Error
+	})
+	if err == nil || !isUniqueViolation(err) {
+		return nil, err
+	}
+	// the failed insert aborted the transaction, so the holder is read
+	// outside of it
+	existing := &models.IdempotencyKey{}
+	if err := db.Where("user_id = ? AND key = ?", k.UserID, k.Key).First(existing).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return existing, ErrDuplicate
+}
+
+// CompleteIdempotencyKey stores the response of the request holding a key
+func CompleteIdempotencyKey(db *gorm.DB, id int64, status int, contentType string, body []byte, at time.Time) error {
+	return db.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":       status,
+		"content_type": contentType,
+		"body":         body,
+		"completed_at": at,
+	}).Error
+}
+
+// ReleaseIdempotencyKey frees a key, so a retry runs the request again
+func ReleaseIdempotencyKey(db *gorm.DB, id int64) error {
+	return db.Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// DeleteIdempotencyKeysBefore deletes the keys created before t and returns
+// how many there were
+func DeleteIdempotencyKeysBefore(db *gorm.DB, t time.Time) (int64, error) {
+	result := db.Where("created_at < ?", t).Delete(&models.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// IncidentFilter selects incidents by category, when they occurred and
+// the execution, plan, vehicle or driver they concern
+type IncidentFilter struct {
+	Categories  []string
+	ExecutionID *int64
+	PlanID      *int64
+	VehicleID   *int64
+	DriverID    *int64
+	Since       *time.Time
+	Before      *time.Time
+}
+
+// CreateIncidentTx stores a reported incident
+func CreateIncidentTx(tx *gorm.DB, incident *models.Incident) error {
+	return tx.Create(incident).Error
+}
+
+// GetIncidentsByVehicle retrieves the incidents of a vehicle that occurred
+// on the days from to to, oldest first
+func GetIncidentsByVehicle(db *gorm.DB, vehicleID int64, from, to time.Time) ([]models.Incident, error) {
+	var incidents []models.Incident
+	err := db.Where("vehicle_id = ? AND occurred_at >= ? AND occurred_at < ?", vehicleID, from, to.AddDate(0, 0, 1)).
+		Order("occurred_at, id").
+		Find(&incidents).Error
+	return incidents, err
+}
+
+// GetIncident retrieves an incident by ID
+func GetIncident(db *gorm.DB, id int64) (*models.Incident, error) {
+	incident := &models.Incident{}
+	if err := db.First(incident, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return incident, nil
+}
+
+// ListIncidents retrieves one page of the incidents matching f, latest
+// first, and the number of matching incidents
+func ListIncidents(db *gorm.DB, f IncidentFilter, offset, limit int) ([]models.Incident, int64, error) {
+	query := db.Model(&models.Incident{})
+	if len(f.Categories) > 0 {
+		query = query.Where("category IN ?", f.Categories)
+	}
+	if f.ExecutionID != nil {
+		query = query.Where("route_execution_id = ?", *f.ExecutionID)
+	}
+	if f.PlanID != nil {
+		query = query.Where("plan_id = ?", *f.PlanID)
+	}
+	if f.VehicleID != nil {
+		query = query.Where("vehicle_id = ?", *f.VehicleID)
+	}
+	if f.DriverID != nil {
+		query = query.Where("driver_id = ?", *f.DriverID)
+	}
+	if f.Since != nil {
+		query = query.Where("occurred_at >= ?", *f.Since)
+	}
+	if f.Before != nil {
+		query = query.Where("occurred_at < ?", *f.Before)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var incidents []models.Incident
+	err := query.Order("occurred_at DESC").Order("id DESC").Offset(offset).Limit(limit).Find(&incidents).Error
+	return incidents, total, err
+}
@@ -0,0 +1,79 @@
+package database
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// AdjustCustomerInventory changes a customer's current inventory by an
+// adjustment's delta at now, records the inventory after it as a snapshot
+// and stores the adjustment, all or none. Adjustments that would take the
+// inventory below zero are refused with ErrInsufficientStock.
+func AdjustCustomerInventory(db *gorm.DB, adj *models.InventoryAdjustment, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		return AdjustCustomerInventoryTx(tx, adj, now)
+	})
+}
+
+// AdjustCustomerInventoryTx is AdjustCustomerInventory within a transaction.
+// The snapshot of a stocktake adjustment has the stocktake reason.
+func AdjustCustomerInventoryTx(tx *gorm.DB, adj *models.InventoryAdjustment, now time.Time) error {
+	result := tx.Model(&models.Customer{}).Where("id = ?", adj.CustomerID).
+		UpdateColumns(map[string]interface{}{
+			"current_inventory": gorm.Expr("current_inventory + ?", adj.Delta),
+			"version":           nextVersion,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	customer := &models.Customer{}
+	if err := tx.First(customer, adj.CustomerID).Error; err != nil {
+		return err
+	}
+	if customer.CurrentInventory < 0 {
+		return ErrInsufficientStock
+	}
+	adj.Balance = customer.CurrentInventory
+
+	snapshot := &models.InventorySnapshot{
+		EntityType:     "customer",
+		EntityID:       customer.ID,
+		SnapshotDate:   now.UTC().Truncate(24 * time.Hour),
+		SnapshotTime:   now,
+		InventoryLevel: customer.CurrentInventory,
+		DemandRate:     customer.DemandRate,
+		MinInventory:   customer.MinInventory,
+		MaxInventory:   customer.MaxInventory,
+		SnapshotReason: "adjustment",
+	}
+	if adj.Reason == models.StocktakeReason {
+		snapshot.SnapshotReason = models.StocktakeReason
+	}
+	if err := tx.Create(snapshot).Error; err != nil {
+		return err
+	}
+	adj.SnapshotID = &snapshot.ID
+	return tx.Create(adj).Error
+}
+
+// ListInventoryAdjustments retrieves one page of a customer's inventory
+// adjustments, latest first, and the number of adjustments
+func ListInventoryAdjustments(db *gorm.DB, customerID int64, offset, limit int) ([]models.InventoryAdjustment, int64, error) {
+	query := db.Model(&models.InventoryAdjustment{}).Where("customer_id = ?", customerID)
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var adjustments []models.InventoryAdjustment
+	err := query.Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&adjustments).Error
+	return adjustments, total, err
+}
@@ -61,58 +61,79 @@ func GetLatestInventorySnapshot(db *gorm.DB, entityType string, entityID int64)
 	return snapshot, nil
 }
 
-// CreateDailyInventorySnapshots creates snapshots for all customers/warehouses for a date
-func CreateDailyInventorySnapshots(db *gorm.DB, snapshotDate time.Time, reason string) error {
-	// Create snapshots for all customers
-	var customers []models.Customer
-	if err := db.Find(&customers).Error; err != nil {
-		return err
-	}
+// HasDailyInventorySnapshots reports whether the daily snapshots of a date
+// were already taken
+func HasDailyInventorySnapshots(db *gorm.DB, snapshotDate time.Time) (bool, error) {
+	var count int64
+	err := db.Model(&models.InventorySnapshot{}).
+		Where("snapshot_reason = ? AND snapshot_date >= ? AND snapshot_date < ?",
+			"daily", snapshotDate, snapshotDate.AddDate(0, 0, 1)).
+		Count(&count).Error
+	return count > 0, err
+}
 
-	now := time.Now()
-	for _, customer := range customers {
-		snapshot := &models.InventorySnapshot{
-			EntityType:     "customer",
-			EntityID:       customer.ID,
-			SnapshotDate:   snapshotDate,
-			SnapshotTime:   now,
-			InventoryLevel: customer.CurrentInventory,
-			DemandRate:     customer.DemandRate,
-			MinInventory:   customer.MinInventory,
-			MaxInventory:   customer.MaxInventory,
-			SnapshotReason: reason,
-		}
-		if err := db.Create(snapshot).Error; err != nil {
+// CreateDailyInventorySnapshots creates snapshots for all customers/warehouses
+// for a date, taken at now, and returns how many were created. Either all of
+// them are created or none.
+func CreateDailyInventorySnapshots(db *gorm.DB, snapshotDate time.Time, reason string, now time.Time) (int, error) {
+	created := 0
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// Create snapshots for all customers
+		var customers []models.Customer
+		if err := tx.Find(&customers).Error; err != nil {
 			return err
 		}
-	}
 
-	// Create snapshots for all warehouses
-	var warehouses []models.Warehouse
-	if err := db.Find(&warehouses).Error; err != nil {
-		return err
-	}
-
-	for _, warehouse := range warehouses {
-		snapshot := &models.InventorySnapshot{
-			EntityType:     "warehouse",
-			EntityID:       warehouse.ID,
-			SnapshotDate:   snapshotDate,
-			SnapshotTime:   now,
-			InventoryLevel: warehouse.CurrentStock,
-			SnapshotReason: reason,
+		for _, customer := range customers {
+			snapshot := &models.InventorySnapshot{
+				EntityType:     "customer",
+				EntityID:       customer.ID,
+				SnapshotDate:   snapshotDate,
+				SnapshotTime:   now,
+				InventoryLevel: customer.CurrentInventory,
+				DemandRate:     customer.DemandRate,
+				MinInventory:   customer.MinInventory,
+				MaxInventory:   customer.MaxInventory,
+				SnapshotReason: reason,
+			}
+			if err := tx.Create(snapshot).Error; err != nil {
+				return err
+			}
+			created++
 		}
-		if err := db.Create(snapshot).Error; err != nil {
+
+		// Create snapshots for all warehouses
+		var warehouses []models.Warehouse
+		if err := tx.Find(&warehouses).Error; err != nil {
 			return err
 		}
-	}
 
-	return nil
+		for _, warehouse := range warehouses {
+			snapshot := &models.InventorySnapshot{
+				EntityType:     "warehouse",
+				EntityID:       warehouse.ID,
+				SnapshotDate:   snapshotDate,
+				SnapshotTime:   now,
+				InventoryLevel: warehouse.CurrentStock,
+				SnapshotReason: reason,
+			}
+			if err := tx.Create(snapshot).Error; err != nil {
+				return err
+			}
+			created++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
 }
 
-// GetInventoryHistory retrieves inventory history for analytics
-func GetInventoryHistory(db *gorm.DB, entityType string, entityID int64, days int) ([]models.InventorySnapshot, error) {
-	startDate := time.Now().AddDate(0, 0, -days)
+// GetInventoryHistory retrieves the inventory history of the days before now
+// for analytics
+func GetInventoryHistory(db *gorm.DB, entityType string, entityID int64, days int, now time.Time) ([]models.InventorySnapshot, error) {
+	startDate := now.AddDate(0, 0, -days)
 	var snapshots []models.InventorySnapshot
 	err := db.Where("entity_type = ? AND entity_id = ? AND snapshot_date >= ?",
 		entityType, entityID, startDate).
@@ -121,6 +142,19 @@ func GetInventoryHistory(db *gorm.DB, entityType string, entityID int64, days in
 	return snapshots, err
 }
 
+// ListInventorySnapshotsByDate retrieves the snapshots of an entity type
+// dated from from to to, of one entity when entityID is set, by entity and
+// date and in the order they were taken
+func ListInventorySnapshotsByDate(db *gorm.DB, entityType string, entityID *int64, from, to time.Time) ([]models.InventorySnapshot, error) {
+	query := db.Where("entity_type = ? AND snapshot_date >= ? AND snapshot_date <= ?", entityType, from, to)
+	if entityID != nil {
+		query = query.Where("entity_id = ?", *entityID)
+	}
+	var snapshots []models.InventorySnapshot
+	err := query.Order("entity_id, snapshot_date, snapshot_time, id").Find(&snapshots).Error
+	return snapshots, err
+}
+
 // GetInventorySnapshotsByPlan retrieves snapshots associated with a plan
 func GetInventorySnapshotsByPlan(db *gorm.DB, planID int64) ([]models.InventorySnapshot, error) {
 	var snapshots []models.InventorySnapshot
@@ -129,3 +163,32 @@ func GetInventorySnapshotsByPlan(db *gorm.DB, planID int64) ([]models.InventoryS
 		Find(&snapshots).Error
 	return snapshots, err
 }
+
+// GetLatestInventorySnapshots retrieves the most recent snapshot of each
+// given entity, keyed by entity ID
+func GetLatestInventorySnapshots(db *gorm.DB, entityType string, entityIDs []int64) (map[int64]models.InventorySnapshot, error) {
+	return GetLatestInventorySnapshotsBefore(db, entityType, entityIDs, nil)
+}
+
+// GetLatestInventorySnapshotsBefore is GetLatestInventorySnapshots limited to
+// snapshots taken at or before a time
+func GetLatestInventorySnapshotsBefore(db *gorm.DB, entityType string, entityIDs []int64, before *time.Time) (map[int64]models.InventorySnapshot, error) {
+	latest := make(map[int64]models.InventorySnapshot, len(entityIDs))
+	if len(entityIDs) == 0 {
+		return latest, nil
+	}
+	query := db.Where("entity_type = ? AND entity_id IN ?", entityType, entityIDs)
+	if before != nil {
+		query = query.Where("snapshot_time <= ?", *before)
+	}
+	var snapshots []models.InventorySnapshot
+	err := query.Order("snapshot_time ASC").
+		Find(&snapshots).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range snapshots {
+		latest[s.EntityID] = s
+	}
+	return latest, nil
+}
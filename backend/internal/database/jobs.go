@@ -0,0 +1,161 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// claimAttempts bounds how often ClaimNextJob retries when other workers
+// claim the jobs it picked first
+const claimAttempts = 5
+
+func CreateJob(db *gorm.DB, job *models.Job) error {
+	return db.Create(job).Error
+}
+
+func GetJob(db *gorm.DB, id int64) (*models.Job, error) {
+	job := &models.Job{}
+	err := db.First(job, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return job, nil
+}
+
+// ListJobs retrieves jobs, newest first. An empty status or jobType matches
+// all jobs.
+func ListJobs(db *gorm.DB, status, jobType string, limit int) ([]models.Job, error) {
+	var jobs []models.Job
+	query := db.Order("created_at DESC, id DESC").Limit(limit)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+	err := query.Find(&jobs).Error
+	return jobs, err
+}
+
+// HasOpenJob reports whether a job of the type is pending or running
+func HasOpenJob(db *gorm.DB, jobType string) (bool, error) {
+	var count int64
+	err := db.Model(&models.Job{}).
+		Where("type = ? AND status IN ?", jobType, []string{"pending", "running"}).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// ClaimNextJob marks the oldest due pending job of one of the types as
+// running for worker and returns it, or nil when no job is due. The claim is
+// conditional on the job still being pending, so concurrent workers never
+// run the same job.
+func ClaimNextJob(db *gorm.DB, types []string, worker string, now time.Time) (*models.Job, error) {
+	for i := 0; i < claimAttempts; i++ {
+		var due []models.Job
+		err := db.Where("status = ? AND run_at <= ? AND type IN ?", "pending", now, types).
+			Order("run_at, id").
+			Limit(1).
+			Find(&due).Error
+		if err != nil {
+			return nil, err
+		}
+		if len(due) == 0 {
+			return nil, nil
+		}
+		job := due[0]
+
+		result := db.Model(&models.Job{}).
+			Where("id = ? AND status = ?", job.ID, "pending").
+			Updates(map[string]interface{}{
+				"status":    "running",
+				"attempts":  gorm.Expr("attempts + 1"),
+				"locked_at": now,
+				"locked_by": worker,
+			})
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 1 {
+			return GetJob(db, job.ID)
+		}
+	}
+	return nil, nil
+}
+
+// FinishJob records the outcome of a running job: succeeded, or failed with
+// errMsg. A failed job with a retryAt goes back to pending until then;
+// without one it is dead.
+func FinishJob(db *gorm.DB, id int64, errMsg string, retryAt *time.Time, now time.Time) error {
+	updates := map[string]interface{}{
+		"locked_at":  nil,
+		"locked_by":  "",
+		"last_error": errMsg,
+	}
+	switch {
+	case errMsg == "":
+		updates["status"] = "succeeded"
+		updates["finished_at"] = now
+	case retryAt != nil:
+		updates["status"] = "pending"
+		updates["run_at"] = *retryAt
+	default:
+		updates["status"] = "dead"
+		updates["finished_at"] = now
+	}
+	return transitionJob(db, id, []string{"running"}, updates)
+}
+
+// RetryJob queues a dead or cancelled job again with a fresh set of attempts
+func RetryJob(db *gorm.DB, id int64, now time.Time) error {
+	return transitionJob(db, id, []string{"dead", "cancelled"}, map[string]interface{}{
+		"status":      "pending",
+		"attempts":    0,
+		"run_at":      now,
+		"finished_at": nil,
+	})
+}
+
+// CancelJob stops a pending job from running
+func CancelJob(db *gorm.DB, id int64, now time.Time) error {
+	return transitionJob(db, id, []string{"pending"}, map[string]interface{}{
+		"status":      "cancelled",
+		"finished_at": now,
+	})
+}
+
+// RequeueStaleJobs returns running jobs locked before cutoff to pending. A
+// worker that died mid-job leaves it running; the attempt still counts.
+func RequeueStaleJobs(db *gorm.DB, cutoff time.Time) (int64, error) {
+	result := db.Model(&models.Job{}).
+		Where("status = ? AND locked_at < ?", "running", cutoff).
+		Updates(map[string]interface{}{
+			"status":     "pending",
+			"locked_at":  nil,
+			"locked_by":  "",
+			"last_error": "worker stopped responding",
+		})
+	return result.RowsAffected, result.Error
+}
+
+// transitionJob updates a job that is in one of the from statuses. It returns
+// ErrNotFound when the job does not exist or is in another status.
+func transitionJob(db *gorm.DB, id int64, from []string, updates map[string]interface{}) error {
+	result := db.Model(&models.Job{}).
+		Where("id = ? AND status IN ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
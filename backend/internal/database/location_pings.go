@@ -0,0 +1,121 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// migrateLocationPings creates the location_pings table. On PostgreSQL it
+// is partitioned by day of recorded_at, which AutoMigrate cannot declare;
+// the partitions are added as pings arrive (see CreateLocationPings) and old
+// days can be dropped as whole tables.
+func migrateLocationPings(db *gorm.DB) error {
+	if db.Dialector.Name() != "postgres" {
+		return db.AutoMigrate(&models.LocationPing{})
+	}
+	err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS location_pings (
+			id bigserial,
+			route_execution_id integer NOT NULL,
+			latitude double precision NOT NULL,
+			longitude double precision NOT NULL,
+			speed double precision,
+			recorded_at timestamp NOT NULL,
+			created_at timestamptz,
+			PRIMARY KEY (id, recorded_at)
+		) PARTITION BY RANGE (recorded_at)
+	`).Error
+	if err != nil {
+		return err
+	}
+	return db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_location_pings_track ON location_pings (route_execution_id, recorded_at)").Error
+}
+
+// ensureLocationPingPartitions creates the daily partitions of
+// location_pings holding the given times, on PostgreSQL
+func ensureLocationPingPartitions(db *gorm.DB, times []time.Time) error {
+	if db.Dialector.Name() != "postgres" {
+		return nil
+	}
+	days := make(map[time.Time]bool)
+	for _, t := range times {
+		day := t.UTC().Truncate(24 * time.Hour)
+		if days[day] {
+			continue
+		}
+		days[day] = true
+		err := db.Exec(fmt.Sprintf(
+			"CREATE TABLE IF NOT EXISTS location_pings_%s PARTITION OF location_pings FOR VALUES FROM ('%s') TO ('%s')",
+			day.Format("20060102"), day.Format("2006-01-02"), day.AddDate(0, 0, 1).Format("2006-01-02"),
+		)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateLocationPings stores a batch of pings of a route execution and
+// returns how many were new. Pings repeating an execution's timestamp, e.g.
+// from a batch uploaded again, are skipped.
+func CreateLocationPings(db *gorm.DB, pings []models.LocationPing) (int, error) {
+	if len(pings) == 0 {
+		return 0, nil
+	}
+	times := make([]time.Time, len(pings))
+	for i := range pings {
+		pings[i].RecordedAt = pings[i].RecordedAt.UTC()
+		times[i] = pings[i].RecordedAt
+	}
+	if err := ensureLocationPingPartitions(db, times); err != nil {
+		return 0, err
+	}
+	result := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&pings)
+	return int(result.RowsAffected), result.Error
+}
+
+// GetLocationTrack returns the pings of a route execution in the order
+// they were recorded
+func GetLocationTrack(db *gorm.DB, executionID int64) ([]models.LocationPing, error) {
+	var pings []models.LocationPing
+	err := db.Where("route_execution_id = ?", executionID).
+		Order("recorded_at").
+		Find(&pings).Error
+	return pings, err
+}
+
+// GetLatestLocation returns the last ping recorded for a route execution
+func GetLatestLocation(db *gorm.DB, executionID int64) (*models.LocationPing, error) {
+	ping := &models.LocationPing{}
+	err := db.Where("route_execution_id = ?", executionID).
+		Order("recorded_at DESC").
+		First(ping).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return ping, nil
+}
+
+// SetExecutionActualDistance stores the actual distance of a route
+// execution
+func SetExecutionActualDistance(db *gorm.DB, executionID int64, distance float64) error {
+	result := db.Model(&models.RouteExecution{}).
+		Where("id = ?", executionID).
+		Update("actual_distance", distance)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
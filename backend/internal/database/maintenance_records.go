@@ -0,0 +1,29 @@
+package database
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// CreateMaintenanceRecord stores maintenance done on a vehicle
+func CreateMaintenanceRecord(db *gorm.DB, r *models.MaintenanceRecord) error {
+	return db.Create(r).Error
+}
+
+// ListMaintenanceRecords retrieves a vehicle's maintenance dated within a
+// date range, oldest first. Zero from/to leave the range open.
+func ListMaintenanceRecords(db *gorm.DB, vehicleID int64, from, to time.Time) ([]models.MaintenanceRecord, error) {
+	query := db.Where("vehicle_id = ?", vehicleID)
+	if !from.IsZero() {
+		query = query.Where("date >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where("date <= ?", to)
+	}
+	var records []models.MaintenanceRecord
+	err := query.Order("date, id").Find(&records).Error
+	return records, err
+}
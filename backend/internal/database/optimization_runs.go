@@ -0,0 +1,73 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// runSummaryColumns are the optimization run columns without the raw payloads
+var runSummaryColumns = []string{
+	"id", "plan_id", "scenario_id", "kind", "status", "protocol", "duration_ms",
+	"planning_horizon", "first_day", "customer_count", "vehicle_count", "route_count",
+	"total_cost", "total_distance", "solver_message", "error", "created_by", "created_at",
+}
+
+// ListOptimizationRuns retrieves run metadata, newest first. A nil planID
+// or empty status matches all runs.
+func ListOptimizationRuns(db *gorm.DB, planID *int64, status string, limit int) ([]models.OptimizationRun, error) {
+	var runs []models.OptimizationRun
+	query := db.Select(runSummaryColumns).Order("created_at DESC, id DESC").Limit(limit)
+	if planID != nil {
+		query = query.Where("plan_id = ?", *planID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&runs).Error
+	return runs, err
+}
+
+// GetOptimizationRun retrieves a run including its raw request and response
+func GetOptimizationRun(db *gorm.DB, id int64) (*models.OptimizationRun, error) {
+	run := &models.OptimizationRun{}
+	err := db.First(run, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return run, nil
+}
+
+func CreateOptimizationRun(db *gorm.DB, run *models.OptimizationRun) error {
+	return db.Create(run).Error
+}
+
+// MarkOptimizationRunInfeasible records the violations found in a run's result
+func MarkOptimizationRunInfeasible(db *gorm.DB, id int64, violations string) error {
+	return db.Model(&models.OptimizationRun{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"status": "infeasible", "violations": violations}).Error
+}
+
+// GetLatestOptimizationRunBefore retrieves a plan's most recent run of a
+// kind created at or before a time, including its raw payloads
+func GetLatestOptimizationRunBefore(db *gorm.DB, planID int64, kind string, before time.Time) (*models.OptimizationRun, error) {
+	var runs []models.OptimizationRun
+	err := db.Where("plan_id = ? AND kind = ? AND created_at <= ?", planID, kind, before).
+		Order("created_at DESC, id DESC").
+		Limit(1).
+		Find(&runs).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrNotFound
+	}
+	return &runs[0], nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"errors"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func ListOrganizations(db *gorm.DB) ([]models.Organization, error) {
+	var orgs []models.Organization
+	err := db.Order("name").Find(&orgs).Error
+	return orgs, err
+}
+
+func GetOrganization(db *gorm.DB, id int64) (*models.Organization, error) {
+	org := &models.Organization{}
+	err := db.First(org, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return org, nil
+}
+
+func CreateOrganization(db *gorm.DB, org *models.Organization) error {
+	return db.Create(org).Error
+}
+
+// UpdateOrganization saves an organization's name and quotas. The columns
+// are selected so that cleared quotas are written too.
+func UpdateOrganization(db *gorm.DB, org *models.Organization) error {
+	result := db.Model(org).
+		Select("name", "max_optimizations_per_month", "max_customers", "max_api_calls_per_day").
+		Updates(org)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// InOrganization scopes a query of customers to an organization's, or to
+// the customers of no organization when orgID is nil
+func InOrganization(db *gorm.DB, orgID *int64) *gorm.DB {
+	if orgID == nil {
+		return db.Where("organization_id IS NULL")
+	}
+	return db.Where("organization_id = ?", *orgID)
+}
+
+// CountOrganizationCustomers counts the customers created by an
+// organization's users
+func CountOrganizationCustomers(db *gorm.DB, orgID int64) (int64, error) {
+	var count int64
+	err := db.Model(&models.Customer{}).Where("organization_id = ?", orgID).Count(&count).Error
+	return count, err
+}
+
+// GetUsage retrieves an organization's count of metric in period
+func GetUsage(db *gorm.DB, orgID int64, metric, period string) (int64, error) {
+	var counter models.UsageCounter
+	err := db.Where("organization_id = ? AND metric = ? AND period = ?", orgID, metric, period).
+		Limit(1).
+		Find(&counter).Error
+	return counter.Count, err
+}
+
+// IncrementUsage adds n to an organization's count of metric in period and
+// returns the new count
+func IncrementUsage(db *gorm.DB, orgID int64, metric, period string, n int64) (int64, error) {
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "organization_id"}, {Name: "metric"}, {Name: "period"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"count": gorm.Expr("usage_counters.count + ?", n), "updated_at": gorm.Expr("CURRENT_TIMESTAMP")}),
+	}).Create(&models.UsageCounter{OrganizationID: orgID, Metric: metric, Period: period, Count: n}).Error
+	if err != nil {
+		return 0, err
+	}
+	return GetUsage(db, orgID, metric, period)
+}
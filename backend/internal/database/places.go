@@ -0,0 +1,83 @@
+package database
+
+import (
+	"errors"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListPlaces returns the address book by name, only places of a kind when
+// kind is set
+func ListPlaces(db *gorm.DB, kind string) ([]models.Place, error) {
+	var places []models.Place
+	q := db.Order("name")
+	if kind != "" {
+		q = q.Where("kind = ?", kind)
+	}
+	err := q.Find(&places).Error
+	return places, err
+}
+
+func GetPlace(db *gorm.DB, id int64) (*models.Place, error) {
+	p := &models.Place{}
+	err := db.First(p, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return p, nil
+}
+
+func CreatePlace(db *gorm.DB, p *models.Place) error {
+	return db.Create(p).Error
+}
+
+func UpdatePlace(db *gorm.DB, p *models.Place) error {
+	result := db.Model(p).Select("name", "kind", "address", "latitude", "longitude", "notes", "updated_at").Updates(p)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeletePlace soft-deletes a place; stops already planned there keep it
+func DeletePlace(db *gorm.DB, id int64) error {
+	result := db.Delete(&models.Place{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// WarehouseDepot returns the depot place at a warehouse's coordinates,
+// creating one named after the warehouse when there is none, so routes can
+// stop at the warehouse
+func WarehouseDepot(db *gorm.DB, w *models.Warehouse) (*models.Place, error) {
+	p := &models.Place{}
+	err := db.Where("kind = ? AND latitude = ? AND longitude = ?", "depot", w.Latitude, w.Longitude).
+		Order("id").First(p).Error
+	if err == nil {
+		return p, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	p = &models.Place{
+		Name:      w.Name,
+		Kind:      "depot",
+		Address:   w.Address,
+		Latitude:  w.Latitude,
+		Longitude: w.Longitude,
+	}
+	return p, db.Create(p).Error
+}
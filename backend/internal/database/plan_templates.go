@@ -0,0 +1,93 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func ListPlanTemplates(db *gorm.DB) ([]models.PlanTemplate, error) {
+	var templates []models.PlanTemplate
+	err := db.Order("name").Find(&templates).Error
+	return templates, err
+}
+
+func GetPlanTemplate(db *gorm.DB, id int64) (*models.PlanTemplate, error) {
+	t := &models.PlanTemplate{}
+	err := db.First(t, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+func CreatePlanTemplate(db *gorm.DB, t *models.PlanTemplate) error {
+	return db.Create(t).Error
+}
+
+// UpdatePlanTemplate saves a template's configuration and schedule. The
+// columns are selected so that inactive templates and cleared lists are
+// written too.
+func UpdatePlanTemplate(db *gorm.DB, t *models.PlanTemplate) error {
+	result := db.Model(t).
+		Select("name", "warehouse_id", "customer_ids", "vehicle_ids", "horizon_days", "recurrence", "next_start_date", "lead_days", "active").
+		Updates(t)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func DeletePlanTemplate(db *gorm.DB, id int64) error {
+	result := db.Delete(&models.PlanTemplate{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ListScheduledPlanTemplates retrieves active recurring templates that have
+// a next start date
+func ListScheduledPlanTemplates(db *gorm.DB) ([]models.PlanTemplate, error) {
+	var templates []models.PlanTemplate
+	err := db.Where("active = ? AND recurrence <> ? AND next_start_date IS NOT NULL", true, "none").
+		Order("next_start_date, id").
+		Find(&templates).Error
+	return templates, err
+}
+
+// AdvancePlanTemplateTx moves a template's schedule from one start date to
+// the next and records the plan created for it. It returns ErrNotFound when
+// the schedule was already advanced, so concurrent schedulers never create
+// the same plan twice.
+func AdvancePlanTemplateTx(tx *gorm.DB, id int64, from, next time.Time, planID *int64, runAt time.Time) error {
+	updates := map[string]interface{}{
+		"next_start_date": next,
+		"last_run_at":     runAt,
+	}
+	if planID != nil {
+		updates["last_plan_id"] = *planID
+	}
+	result := tx.Model(&models.PlanTemplate{}).
+		Where("id = ? AND next_start_date = ?", id, from).
+		Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -2,8 +2,10 @@ package database
 
 import (
 	"errors"
+	"time"
 
 	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
 
 	"gorm.io/gorm"
 )
@@ -14,6 +16,55 @@ func ListPlans(db *gorm.DB) ([]models.Plan, error) {
 	return plans, err
 }
 
+// PlanFilter selects and orders plans. Nil and empty fields match all
+// plans; From and To match plans overlapping the range.
+type PlanFilter struct {
+	Statuses        []string
+	ExcludeStatuses []string
+	WarehouseID     *int64
+	CreatedBy       *int64
+	From            *time.Time
+	To              *time.Time
+	Order           string
+}
+
+// ListPlansPage retrieves one page of the plans matching f and the number
+// of matching plans
+func ListPlansPage(db *gorm.DB, f PlanFilter, offset, limit int) ([]models.Plan, int64, error) {
+	query := db.Model(&models.Plan{})
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+	if len(f.ExcludeStatuses) > 0 {
+		query = query.Where("status NOT IN ?", f.ExcludeStatuses)
+	}
+	if f.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *f.WarehouseID)
+	}
+	if f.CreatedBy != nil {
+		query = query.Where("created_by = ?", *f.CreatedBy)
+	}
+	if f.From != nil {
+		query = query.Where("end_date >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("start_date <= ?", *f.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := f.Order
+	if order == "" {
+		order = "created_at DESC"
+	}
+	var plans []models.Plan
+	err := query.Order(order).Order("id").Offset(offset).Limit(limit).Find(&plans).Error
+	return plans, total, err
+}
+
 func GetPlan(db *gorm.DB, id int64) (*models.Plan, error) {
 	p := &models.Plan{}
 	err := db.First(p, id).Error
@@ -35,6 +86,7 @@ func UpdatePlanStatus(db *gorm.DB, id int64, status string, totalCost, totalDist
 		"status":         status,
 		"total_cost":     totalCost,
 		"total_distance": totalDistance,
+		"version":        nextVersion,
 	})
 	if result.Error != nil {
 		return result.Error
@@ -50,6 +102,7 @@ func UpdatePlanStatusTx(tx *gorm.DB, id int64, status string, totalCost, totalDi
 		"status":         status,
 		"total_cost":     totalCost,
 		"total_distance": totalDistance,
+		"version":        nextVersion,
 	})
 	if result.Error != nil {
 		return result.Error
@@ -60,6 +113,59 @@ func UpdatePlanStatusTx(tx *gorm.DB, id int64, status string, totalCost, totalDi
 	return nil
 }
 
+// TransitionPlanStatus moves a plan from one status to another, also setting
+// any extra columns. It returns ErrNotFound when the plan is no longer in the
+// from status, so concurrent transitions cannot both succeed.
+func TransitionPlanStatus(db *gorm.DB, id int64, from, to string, extra map[string]interface{}) error {
+	updates := map[string]interface{}{"status": to, "version": nextVersion}
+	for column, value := range extra {
+		updates[column] = value
+	}
+	result := db.Model(&models.Plan{}).Where("id = ? AND status = ?", id, from).Updates(updates)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResetStaleOptimizingPlans resets plans optimizing since before cutoff,
+// whose optimization crashed or was lost to a restart: to optimized when
+// they have routes and to draft when not. It returns how many were reset.
+func ResetStaleOptimizingPlans(db *gorm.DB, cutoff time.Time) (int64, error) {
+	var reset int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		routes := tx.Model(&models.Route{}).Select("1").Where("routes.plan_id = plans.id")
+		for _, to := range []struct {
+			status string
+			where  string
+		}{
+			{planstate.Optimized, "EXISTS (?)"},
+			{planstate.Draft, "NOT EXISTS (?)"},
+		} {
+			result := tx.Model(&models.Plan{}).
+				Where("status = ? AND updated_at < ?", planstate.Optimizing, cutoff).
+				Where(to.where, routes).
+				Updates(map[string]interface{}{"status": to.status, "version": nextVersion})
+			if result.Error != nil {
+				return result.Error
+			}
+			reset += result.RowsAffected
+		}
+		return nil
+	})
+	return reset, err
+}
+
+// GetPlanWithDeleted retrieves a plan even when it was soft-deleted
+func GetPlanWithDeleted(db *gorm.DB, id int64) (*models.Plan, error) {
+	return GetPlan(db.Unscoped(), id)
+}
+
+// DeletePlan soft-deletes a plan; its routes and history are kept until the
+// plan is purged
 func DeletePlan(db *gorm.DB, id int64) error {
 	result := db.Delete(&models.Plan{}, id)
 	if result.Error != nil {
@@ -74,7 +180,17 @@ func DeletePlan(db *gorm.DB, id int64) error {
 func CountActivePlans(db *gorm.DB) (int, error) {
 	var count int64
 	err := db.Model(&models.Plan{}).
-		Where("status IN ?", []string{"draft", "optimizing", "optimized"}).
+		Where("status IN ?", planstate.ActiveStatuses()).
+		Count(&count).Error
+	return int(count), err
+}
+
+// CountOptimizedPlans counts plans that have been optimized at least once,
+// including those released since
+func CountOptimizedPlans(db *gorm.DB) (int, error) {
+	var count int64
+	err := db.Model(&models.Plan{}).
+		Where("status IN ?", append([]string{planstate.Optimized}, planstate.ReleasedStatuses()...)).
 		Count(&count).Error
 	return int(count), err
 }
@@ -85,3 +201,160 @@ func GetRecentPlans(db *gorm.DB, limit int) ([]models.Plan, error) {
 	return plans, err
 }
 
+// GetPreviousPlan returns the most recent optimized, approved or executed
+// plan for the same warehouse that starts before the given plan
+func GetPreviousPlan(db *gorm.DB, p *models.Plan) (*models.Plan, error) {
+	prev := &models.Plan{}
+	statuses := append([]string{planstate.Optimized}, planstate.ReleasedStatuses()...)
+	query := db.Where("id <> ? AND start_date < ? AND status IN ?", p.ID, p.StartDate, statuses)
+	if p.WarehouseID != nil {
+		query = query.Where("warehouse_id = ?", *p.WarehouseID)
+	}
+	err := query.Order("start_date DESC, id DESC").First(prev).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return prev, nil
+}
+
+// ListRollingPlans retrieves the rolling plans in any of the given statuses
+func ListRollingPlans(db *gorm.DB, statuses []string) ([]models.Plan, error) {
+	var plans []models.Plan
+	err := db.Where("rolling = ? AND status IN ?", true, statuses).
+		Order("id").
+		Find(&plans).Error
+	return plans, err
+}
+
+// SetPlanRolling turns a plan's rolling horizon on or off. Unless version
+// is 0 it only changes that version of the plan; it returns the new one.
+func SetPlanRolling(db *gorm.DB, id, version int64, rolling bool) (int64, error) {
+	return updateVersion(db, &models.Plan{}, id, version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&models.Plan{}).Where("id = ?", id).Update("rolling", rolling)
+	})
+}
+
+// SetPlanObjective sets what a plan is optimized for and, for carbon, the
+// cost increase allowed over the cheapest routes. Like SetPlanRolling it
+// checks and returns the plan's version.
+func SetPlanObjective(db *gorm.DB, id, version int64, objective string, maxCostIncreasePct float64) (int64, error) {
+	return updateVersion(db, &models.Plan{}, id, version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&models.Plan{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"objective":             objective,
+			"max_cost_increase_pct": maxCostIncreasePct,
+		})
+	})
+}
+
+// SetPlanObjectiveTradeoffTx records the emissions traded against cost by
+// a plan's last optimization, nil when it was optimized for cost
+func SetPlanObjectiveTradeoffTx(tx *gorm.DB, id int64, tradeoff *models.ObjectiveTradeoff) error {
+	err := tx.Model(&models.Plan{}).Where("id = ?", id).Select("objective_tradeoff").
+		Updates(&models.Plan{ObjectiveTradeoff: tradeoff}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Plan{}).Where("id = ?", id).UpdateColumn("version", nextVersion).Error
+}
+
+// SetPlanNotes replaces a plan's driver notes. Like SetPlanRolling it
+// checks and returns the plan's version.
+func SetPlanNotes(db *gorm.DB, id, version int64, notes string) (int64, error) {
+	return updateVersion(db, &models.Plan{}, id, version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(&models.Plan{}).Where("id = ?", id).Update("notes", notes)
+	})
+}
+
+// RollPlanTx moves a plan's horizon forward by shift days. Routes are
+// renumbered to the new start date; routes on the days that fell off are
+// deleted unless they have execution records, which keep them as history
+// with a day number of 0 or less. It returns ErrNotFound when the plan no
+// longer starts on start, so concurrent rollers never shift a plan twice.
+func RollPlanTx(tx *gorm.DB, id int64, start, end time.Time, shift int) error {
+	result := tx.Model(&models.Plan{}).
+		Where("id = ? AND start_date = ?", id, start).
+		Updates(map[string]interface{}{
+			"start_date": start.AddDate(0, 0, shift),
+			"end_date":   end.AddDate(0, 0, shift),
+			"version":    nextVersion,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+
+	executed := tx.Model(&models.RouteExecution{}).Select("route_id")
+	err := tx.Where("plan_id = ? AND day <= ? AND id NOT IN (?)", id, shift, executed).
+		Delete(&models.Route{}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Route{}).Where("plan_id = ?", id).
+		Update("day", gorm.Expr("day - ?", shift)).Error
+}
+
+// PurgePlan permanently removes a plan with its routes, stops, executions
+// and their vehicle logs and incidents, solutions, scenarios, unrouted
+// customers and optimization runs. Inventory snapshots and templates that refer to it
+// are kept without the reference.
+func PurgePlan(db *gorm.DB, id int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		tx = tx.Unscoped().Session(&gorm.Session{})
+		routes := tx.Model(&models.Route{}).Select("id").Where("plan_id = ?", id)
+		stops := tx.Model(&models.Stop{}).Select("id").Where("route_id IN (?)", routes)
+		solutions := tx.Model(&models.PlanSolution{}).Select("id").Where("plan_id = ?", id)
+		scenarios := tx.Model(&models.Scenario{}).Select("id").Where("plan_id = ?", id)
+		executions := tx.Model(&models.RouteExecution{}).Select("id").Where("route_id IN (?)", routes)
+
+		// Children first, so foreign keys never point at removed rows
+		deletes := []struct {
+			model interface{}
+			where []interface{}
+		}{
+			{&models.StopExecution{}, []interface{}{"stop_id IN (?)", stops}},
+			{&models.StopProductQuantity{}, []interface{}{"stop_id IN (?)", stops}},
+			{&models.StopExplanation{}, []interface{}{"stop_id IN (?)", stops}},
+			{&models.VehicleLog{}, []interface{}{"route_execution_id IN (?)", executions}},
+			{&models.Incident{}, []interface{}{"plan_id = ?", id}},
+			{&models.RouteExecution{}, []interface{}{"route_id IN (?)", routes}},
+			{&models.Stop{}, []interface{}{"route_id IN (?)", routes}},
+			{&models.SolutionRoute{}, []interface{}{"solution_id IN (?)", solutions}},
+			{&models.ScenarioRoute{}, []interface{}{"scenario_id IN (?)", scenarios}},
+			{&models.OptimizationRun{}, []interface{}{"plan_id = ? OR scenario_id IN (?)", id, scenarios}},
+			{&models.Route{}, []interface{}{"plan_id = ?", id}},
+			{&models.PlanSolution{}, []interface{}{"plan_id = ?", id}},
+			{&models.Scenario{}, []interface{}{"plan_id = ?", id}},
+			{&models.UnroutedCustomer{}, []interface{}{"plan_id = ?", id}},
+			{&models.Redelivery{}, []interface{}{"plan_id = ?", id}},
+		}
+		for _, d := range deletes {
+			if err := tx.Where(d.where[0], d.where[1:]...).Delete(d.model).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Model(&models.InventorySnapshot{}).Where("plan_id = ?", id).
+			Updates(map[string]interface{}{"plan_id": nil, "route_id": nil}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&models.PlanTemplate{}).Where("last_plan_id = ?", id).Update("last_plan_id", nil).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Delete(&models.Plan{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
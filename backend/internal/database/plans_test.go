@@ -0,0 +1,108 @@
+package database
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupPlanTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := RunMigrations(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+// TestDeletePlanKeepsHistory tests that deleted plans disappear from
+// queries but keep their routes, and that routes keep deleted customers
+func TestDeletePlanKeepsHistory(t *testing.T) {
+	db := setupPlanTestDB(t)
+
+	customer := &models.Customer{Name: "Gone", Latitude: 1, Longitude: 1}
+	CreateCustomer(db, customer)
+	plan := &models.Plan{Name: "Old", StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	CreatePlan(db, plan)
+	route := &models.Route{PlanID: plan.ID, Day: 1, Date: plan.StartDate, Stops: []models.Stop{{CustomerID: &customer.ID, Sequence: 1}}}
+	CreateRoute(db, route)
+
+	if err := DeleteCustomer(db, customer.ID); err != nil {
+		t.Fatalf("DeleteCustomer() error = %v", err)
+	}
+	stored, err := GetRouteByID(db, route.ID)
+	if err != nil || stored.Stops[0].Customer == nil || stored.Stops[0].Customer.Name != "Gone" {
+		t.Errorf("GetRouteByID() = %+v, %v, want the deleted customer on the stop", stored, err)
+	}
+
+	if err := DeletePlan(db, plan.ID); err != nil {
+		t.Fatalf("DeletePlan() error = %v", err)
+	}
+	if _, err := GetPlan(db, plan.ID); err != ErrNotFound {
+		t.Errorf("GetPlan() after delete error = %v, want ErrNotFound", err)
+	}
+	if p, err := GetPlanWithDeleted(db, plan.ID); err != nil || !p.DeletedAt.Valid {
+		t.Errorf("GetPlanWithDeleted() = %+v, %v, want the deleted plan", p, err)
+	}
+	if routes, _ := GetRoutesByPlan(db, plan.ID); len(routes) != 1 {
+		t.Errorf("deleted plan has %d routes, want 1 kept", len(routes))
+	}
+	if err := DeletePlan(db, plan.ID); err != ErrNotFound {
+		t.Errorf("second DeletePlan() error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestPurgePlan tests that purging removes a plan with its routes and
+// history and detaches snapshots
+func TestPurgePlan(t *testing.T) {
+	db := setupPlanTestDB(t)
+
+	plan := &models.Plan{Name: "Old", StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)}
+	CreatePlan(db, plan)
+	route := &models.Route{PlanID: plan.ID, Day: 1, Date: plan.StartDate, Stops: []models.Stop{{Sequence: 1}}}
+	CreateRoute(db, route)
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "completed"}
+	db.Create(execution)
+	db.Create(&models.StopExecution{RouteExecutionID: execution.ID, StopID: route.Stops[0].ID})
+	db.Create(&models.PlanSolution{PlanID: plan.ID, Version: 1, Source: "optimize", Routes: []models.SolutionRoute{{Day: 1, Date: plan.StartDate}}})
+	db.Create(&models.OptimizationRun{PlanID: &plan.ID, Kind: "optimize", Status: "success"})
+	snapshot := &models.InventorySnapshot{EntityType: "customer", EntityID: 1, SnapshotTime: plan.StartDate, PlanID: &plan.ID, RouteID: &route.ID}
+	db.Create(snapshot)
+	other := &models.Plan{Name: "Other", StartDate: plan.StartDate, EndDate: plan.EndDate}
+	CreatePlan(db, other)
+	CreateRoute(db, &models.Route{PlanID: other.ID, Day: 1, Date: other.StartDate})
+
+	DeletePlan(db, plan.ID)
+	if err := PurgePlan(db, plan.ID); err != nil {
+		t.Fatalf("PurgePlan() error = %v", err)
+	}
+
+	for _, model := range []interface{}{&models.Stop{}, &models.RouteExecution{}, &models.StopExecution{}, &models.PlanSolution{}, &models.SolutionRoute{}, &models.OptimizationRun{}} {
+		var count int64
+		db.Model(model).Count(&count)
+		if count != 0 {
+			t.Errorf("%T rows after purge = %d, want 0", model, count)
+		}
+	}
+	var routes int64
+	db.Model(&models.Route{}).Count(&routes)
+	if routes != 1 {
+		t.Errorf("routes after purge = %d, want only the other plan's", routes)
+	}
+	if _, err := GetPlanWithDeleted(db, plan.ID); err != ErrNotFound {
+		t.Errorf("GetPlanWithDeleted() after purge error = %v, want ErrNotFound", err)
+	}
+	db.First(snapshot, snapshot.ID)
+	if snapshot.PlanID != nil || snapshot.RouteID != nil {
+		t.Errorf("snapshot plan %v route %v, want both cleared", snapshot.PlanID, snapshot.RouteID)
+	}
+	if err := PurgePlan(db, plan.ID); err != ErrNotFound {
+		t.Errorf("second PurgePlan() error = %v, want ErrNotFound", err)
+	}
+}
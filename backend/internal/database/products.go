@@ -28,22 +28,26 @@ func GetProduct(db *gorm.DB, id int64) (*models.Product, error) {
 	return product, nil
 }
 
-// CreateProduct creates a new product
+// CreateProduct creates a new product; a SKU already taken is ErrDuplicate
 func CreateProduct(db *gorm.DB, product *models.Product) error {
-	return db.Create(product).Error
+	if err := db.Create(product).Error; err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		return err
+	}
+	return nil
 }
 
-// UpdateProduct updates a product
+// UpdateProduct updates a product; a SKU already taken is ErrDuplicate
 func UpdateProduct(db *gorm.DB, product *models.Product) error {
-	result := db.Model(product).Updates(models.Product{
-		Name:        product.Name,
-		SKU:         product.SKU,
-		Description: product.Description,
-		Unit:        product.Unit,
-		Weight:      product.Weight,
-		Volume:      product.Volume,
-	})
+	result := db.Model(product).
+		Select("name", "sku", "description", "unit", "weight", "volume", "quantity_step", "rounding_mode", "external_ref", "updated_at").
+		Updates(product)
 	if result.Error != nil {
+		if isUniqueViolation(result.Error) {
+			return ErrDuplicate
+		}
 		return result.Error
 	}
 	if result.RowsAffected == 0 {
@@ -73,18 +77,45 @@ func GetCustomerProductInventory(db *gorm.DB, customerID int64) ([]models.Custom
 	return inventory, err
 }
 
-// UpdateCustomerProductInventory updates product inventory for a customer
+// GetCustomerProductInventories retrieves the product inventory of the
+// given customers, by customer ID and product ID
+func GetCustomerProductInventories(db *gorm.DB, customerIDs []int64) (map[int64][]models.CustomerProductInventory, error) {
+	result := make(map[int64][]models.CustomerProductInventory)
+	if len(customerIDs) == 0 {
+		return result, nil
+	}
+	var inventory []models.CustomerProductInventory
+	if err := db.Where("customer_id IN ?", customerIDs).Order("customer_id, product_id").Find(&inventory).Error; err != nil {
+		return nil, err
+	}
+	for _, p := range inventory {
+		result[p.CustomerID] = append(result[p.CustomerID], p)
+	}
+	return result, nil
+}
+
+// GetCustomerProduct retrieves a customer's inventory of one product
+func GetCustomerProduct(db *gorm.DB, customerID, productID int64) (*models.CustomerProductInventory, error) {
+	inventory := &models.CustomerProductInventory{}
+	err := db.Where("customer_id = ? AND product_id = ?", customerID, productID).
+		Preload("Product").
+		First(inventory).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return inventory, nil
+}
+
+// UpdateCustomerProductInventory sets a customer's inventory of a product,
+// creating it when the customer has none yet
 func UpdateCustomerProductInventory(db *gorm.DB, inventory *models.CustomerProductInventory) error {
-	result := db.Model(inventory).
+	result := db.Model(&models.CustomerProductInventory{}).
 		Where("customer_id = ? AND product_id = ?", inventory.CustomerID, inventory.ProductID).
-		Updates(models.CustomerProductInventory{
-			CurrentInventory: inventory.CurrentInventory,
-			MaxInventory:     inventory.MaxInventory,
-			MinInventory:     inventory.MinInventory,
-			DemandRate:       inventory.DemandRate,
-			HoldingCost:      inventory.HoldingCost,
-			Priority:         inventory.Priority,
-		})
+		Select("current_inventory", "max_inventory", "min_inventory", "demand_rate", "holding_cost", "priority", "reorder_point", "order_quantity", "updated_at").
+		Updates(inventory)
 	if result.Error != nil {
 		return result.Error
 	}
@@ -94,3 +125,69 @@ func UpdateCustomerProductInventory(db *gorm.DB, inventory *models.CustomerProdu
 	}
 	return nil
 }
+
+// DeleteCustomerProduct removes a customer's inventory of a product
+func DeleteCustomerProduct(db *gorm.DB, customerID, productID int64) error {
+	result := db.Where("customer_id = ? AND product_id = ?", customerID, productID).
+		Delete(&models.CustomerProductInventory{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ProductInUse tells whether customers, deleted ones included, plan their
+// inventory in a product, keep inventory of it or stops carry it
+func ProductInUse(db *gorm.DB, id int64) (bool, error) {
+	uses := []struct {
+		model interface{}
+		query string
+	}{
+		{&models.Customer{}, "product_id = ?"},
+		{&models.CustomerProductInventory{}, "product_id = ?"},
+		{&models.StopProductQuantity{}, "product_id = ?"},
+	}
+	for _, use := range uses {
+		var count int64
+		if err := db.Unscoped().Model(use.model).Where(use.query, id).Count(&count).Error; err != nil {
+			return false, err
+		}
+		if count > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetStopProductQuantities retrieves the product quantities of a stop with
+// their products
+func GetStopProductQuantities(db *gorm.DB, stopID int64) ([]models.StopProductQuantity, error) {
+	var quantities []models.StopProductQuantity
+	err := db.Where("stop_id = ?", stopID).
+		Preload("Product").
+		Order("id").
+		Find(&quantities).Error
+	return quantities, err
+}
+
+// ReplaceStopProductQuantitiesTx replaces the product quantities of a stop
+// and sets the stop's quantity, and its route's load, to their sum
+func ReplaceStopProductQuantitiesTx(tx *gorm.DB, stop *models.Stop, quantities []models.StopProductQuantity) error {
+	if err := tx.Where("stop_id = ?", stop.ID).Delete(&models.StopProductQuantity{}).Error; err != nil {
+		return err
+	}
+	var total float64
+	for i := range quantities {
+		quantities[i].StopID = stop.ID
+		total += quantities[i].Quantity
+	}
+	if len(quantities) > 0 {
+		if err := tx.Create(&quantities).Error; err != nil {
+			return err
+		}
+	}
+	return UpdateStopQuantityTx(tx, stop, total)
+}
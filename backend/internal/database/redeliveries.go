@@ -0,0 +1,164 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListRedeliveriesByPlan retrieves the redeliveries of a plan's stops,
+// newest first
+func ListRedeliveriesByPlan(db *gorm.DB, planID int64) ([]models.Redelivery, error) {
+	var entries []models.Redelivery
+	err := db.Preload("Customer", withDeleted).
+		Where("plan_id = ?", planID).
+		Order("id DESC").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetRedelivery retrieves a redelivery by ID
+func GetRedelivery(db *gorm.DB, id int64) (*models.Redelivery, error) {
+	entry := &models.Redelivery{}
+	if err := db.First(entry, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return entry, nil
+}
+
+// CreateRedeliveryTx records a redelivery
+func CreateRedeliveryTx(tx *gorm.DB, entry *models.Redelivery) error {
+	return tx.Create(entry).Error
+}
+
+// GetRedeliveryRoutesTx retrieves the routes of a plan after the given day
+// that can still take another stop: those not yet started, whose execution
+// records are all pending. Routes come earliest first with their plan and
+// warehouse, vehicle and stops like GetRouteWithStops.
+func GetRedeliveryRoutesTx(tx *gorm.DB, planID int64, afterDay int) ([]models.Route, error) {
+	var routes []models.Route
+	err := unstartedRoutes(tx).
+		Where("plan_id = ? AND day > ?", planID, afterDay).
+		Order("day, id").
+		Find(&routes).Error
+	return routes, err
+}
+
+// GetUnstartedRouteTx retrieves a route of a plan, loaded like
+// GetRedeliveryRoutesTx, if it has not started. It returns ErrNotFound
+// for routes of other plans and routes under way.
+func GetUnstartedRouteTx(tx *gorm.DB, planID, routeID int64) (*models.Route, error) {
+	var routes []models.Route
+	err := unstartedRoutes(tx).Where("plan_id = ? AND id = ?", planID, routeID).Find(&routes).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, ErrNotFound
+	}
+	return &routes[0], nil
+}
+
+// unstartedRoutes selects the routes whose execution records are all
+// pending, with what redelivery planning needs loaded
+func unstartedRoutes(tx *gorm.DB) *gorm.DB {
+	started := tx.Model(&models.RouteExecution{}).Select("route_id").Where("status <> ?", "pending")
+	return tx.Preload("Plan.Warehouse", withDeleted).
+		Preload("Vehicle", withDeleted).
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer", withDeleted).
+		Preload("Stops.Place", withDeleted).
+		Where("id NOT IN (?)", started)
+}
+
+// AppendRedeliveryStopTx adds a stop to the end of its route, with a
+// pending stop execution on each pending execution of the route, and
+// recomputes the route's load
+func AppendRedeliveryStopTx(tx *gorm.DB, stop *models.Stop) error {
+	if err := InsertStopTx(tx, stop); err != nil {
+		return err
+	}
+	if err := updateRouteLoadTx(tx, stop.RouteID); err != nil {
+		return err
+	}
+
+	var executions []models.RouteExecution
+	if err := tx.Where("route_id = ? AND status = ?", stop.RouteID, "pending").Find(&executions).Error; err != nil {
+		return err
+	}
+	for _, e := range executions {
+		execution := &models.StopExecution{
+			RouteExecutionID: e.ID,
+			StopID:           stop.ID,
+			Status:           "pending",
+			PlannedQuantity:  stop.Quantity,
+		}
+		if err := tx.Create(execution).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&models.RouteExecution{}).Where("id = ?", e.ID).
+			Update("planned_load", gorm.Expr("planned_load + ?", stop.Quantity)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ResolveRedeliveryStopTx closes the scheduled redelivery delivered by a
+// stop, if any, with the given status at now
+func ResolveRedeliveryStopTx(tx *gorm.DB, stopID int64, status string, now time.Time) error {
+	return tx.Model(&models.Redelivery{}).
+		Where("stop_id = ? AND status = ?", stopID, "scheduled").
+		Updates(map[string]interface{}{
+			"status":      status,
+			"resolved_at": now,
+		}).Error
+}
+
+// GetQueuedRedeliveries retrieves redeliveries waiting for an optimization
+func GetQueuedRedeliveries(db *gorm.DB) ([]models.Redelivery, error) {
+	var entries []models.Redelivery
+	err := db.Where("status = ?", "queued").Find(&entries).Error
+	return entries, err
+}
+
+// ConsumeRedeliveriesTx marks queued redeliveries as taken up by a plan's
+// optimization at now
+func ConsumeRedeliveriesTx(tx *gorm.DB, ids []int64, planID int64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Redelivery{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"status":           "consumed",
+			"consumed_plan_id": planID,
+			"resolved_at":      now,
+		}).Error
+}
+
+// CancelRedelivery cancels a queued redelivery at now
+func CancelRedelivery(db *gorm.DB, id int64, now time.Time) error {
+	result := db.Model(&models.Redelivery{}).
+		Where("id = ? AND status = ?", id, "queued").
+		Updates(map[string]interface{}{
+			"status":      "cancelled",
+			"resolved_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListRosterEntries retrieves roster entries within a date range, optionally
+// limited to vehicles of one warehouse
+func ListRosterEntries(db *gorm.DB, from, to time.Time, warehouseID *int64) ([]models.RosterEntry, error) {
+	var entries []models.RosterEntry
+	query := db.Where("driver_rosters.date >= ? AND driver_rosters.date <= ?", from, to)
+	if warehouseID != nil {
+		query = query.Joins("JOIN vehicles ON vehicles.id = driver_rosters.vehicle_id").
+			Where("vehicles.warehouse_id = ?", *warehouseID)
+	}
+	err := query.Preload("Driver").
+		Preload("Vehicle", withDeleted).
+		Order("driver_rosters.date, driver_rosters.vehicle_id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetRosterEntriesForVehicles retrieves entries for the given vehicles within a date range
+func GetRosterEntriesForVehicles(db *gorm.DB, vehicleIDs []int64, from, to time.Time) ([]models.RosterEntry, error) {
+	var entries []models.RosterEntry
+	err := db.Where("vehicle_id IN ? AND date >= ? AND date <= ?", vehicleIDs, from, to).
+		Preload("Driver").
+		Order("date, vehicle_id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetRosterEntriesForDates retrieves all entries on the given dates, used to
+// check new assignments for double-booking
+func GetRosterEntriesForDates(db *gorm.DB, dates []time.Time) ([]models.RosterEntry, error) {
+	var entries []models.RosterEntry
+	err := db.Where("date IN ?", dates).Find(&entries).Error
+	return entries, err
+}
+
+func CreateRosterEntriesTx(tx *gorm.DB, entries []models.RosterEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.Create(&entries).Error
+}
+
+func DeleteRosterEntry(db *gorm.DB, id int64) error {
+	result := db.Delete(&models.RosterEntry{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// AssignRosteredDriversTx sets each route's driver from the roster entry for
+// its vehicle and date
+func AssignRosteredDriversTx(tx *gorm.DB, planID int64) error {
+	return tx.Exec(`UPDATE routes SET driver_id = (
+			SELECT driver_rosters.driver_id FROM driver_rosters
+			WHERE driver_rosters.vehicle_id = routes.vehicle_id AND driver_rosters.date = routes.date
+		) WHERE plan_id = ?`, planID).Error
+}
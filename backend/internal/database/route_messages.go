@@ -0,0 +1,81 @@
+package database
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateRouteMessage stores a message in a route's thread
+func CreateRouteMessage(db *gorm.DB, message *models.RouteMessage) error {
+	return db.Create(message).Error
+}
+
+// ListRouteMessages returns up to limit messages of a route's thread after
+// afterID, oldest first, with their senders
+func ListRouteMessages(db *gorm.DB, routeID, afterID int64, limit int) ([]models.RouteMessage, error) {
+	var messages []models.RouteMessage
+	err := db.Where("route_id = ? AND id > ?", routeID, afterID).
+		Preload("Sender").
+		Order("id").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// GetRouteMessageSenders returns the IDs of the users who posted in a
+// route's thread
+func GetRouteMessageSenders(db *gorm.DB, routeID int64) ([]int64, error) {
+	var ids []int64
+	err := db.Model(&models.RouteMessage{}).
+		Where("route_id = ?", routeID).
+		Distinct().
+		Pluck("sender_id", &ids).Error
+	return ids, err
+}
+
+// LatestRouteMessageID returns the ID of the newest message of a route's
+// thread, or 0 when it has none
+func LatestRouteMessageID(db *gorm.DB, routeID int64) (int64, error) {
+	var id int64
+	err := db.Model(&models.RouteMessage{}).
+		Where("route_id = ?", routeID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&id).Error
+	return id, err
+}
+
+// MarkRouteMessagesRead moves a user's read receipt of a route's thread
+// forward to messageID; receipts never move back
+func MarkRouteMessagesRead(db *gorm.DB, routeID, userID, messageID int64, at time.Time) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "route_id"}, {Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"last_read_message_id": gorm.Expr("CASE WHEN excluded.last_read_message_id > route_message_reads.last_read_message_id THEN excluded.last_read_message_id ELSE route_message_reads.last_read_message_id END"),
+			"read_at":              gorm.Expr("CASE WHEN excluded.last_read_message_id > route_message_reads.last_read_message_id THEN excluded.read_at ELSE route_message_reads.read_at END"),
+		}),
+	}).Create(&models.RouteMessageRead{RouteID: routeID, UserID: userID, LastReadMessageID: messageID, ReadAt: at}).Error
+}
+
+// GetRouteMessageReads returns the read receipts of a route's thread
+func GetRouteMessageReads(db *gorm.DB, routeID int64) ([]models.RouteMessageRead, error) {
+	var reads []models.RouteMessageRead
+	err := db.Where("route_id = ?", routeID).Order("user_id").Find(&reads).Error
+	return reads, err
+}
+
+// CountUnreadRouteMessages counts the messages of a route's thread from
+// other users after the user's read receipt
+func CountUnreadRouteMessages(db *gorm.DB, routeID, userID int64) (int, error) {
+	var count int64
+	err := db.Model(&models.RouteMessage{}).
+		Where("route_id = ? AND sender_id <> ?", routeID, userID).
+		Where("id > COALESCE((?), 0)", db.Model(&models.RouteMessageRead{}).
+			Select("last_read_message_id").
+			Where("route_id = ? AND user_id = ?", routeID, userID)).
+		Count(&count).Error
+	return int(count), err
+}
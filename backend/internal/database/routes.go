@@ -2,25 +2,199 @@ package database
 
 import (
 	"errors"
+	"time"
 
 	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
 
 	"gorm.io/gorm"
 )
 
+// RouteEmbed selects the associations loaded with routes
+type RouteEmbed struct {
+	Vehicle   bool
+	Driver    bool
+	Stops     bool
+	Customers bool // the stops' customers; implies Stops
+	Places    bool // the stops' places; implies Stops
+}
+
+// DefaultRouteEmbed is what route listings load unless told otherwise
+var DefaultRouteEmbed = RouteEmbed{Vehicle: true, Stops: true, Customers: true, Places: true}
+
+func (e RouteEmbed) preload(query *gorm.DB) *gorm.DB {
+	if e.Vehicle {
+		query = query.Preload("Vehicle", withDeleted)
+	}
+	if e.Driver {
+		query = query.Preload("Driver")
+	}
+	if e.Stops && !e.Customers && !e.Places {
+		query = query.Preload("Stops")
+	}
+	if e.Customers {
+		query = query.Preload("Stops.Customer", withDeleted)
+	}
+	if e.Places {
+		query = query.Preload("Stops.Place", withDeleted)
+	}
+	return query
+}
+
 func GetRoutesByPlan(db *gorm.DB, planID int64) ([]models.Route, error) {
+	return GetRoutesByPlanWith(db, planID, DefaultRouteEmbed)
+}
+
+// GetRoutesByPlanWith retrieves a plan's routes with the associations of
+// embed
+func GetRoutesByPlanWith(db *gorm.DB, planID int64, embed RouteEmbed) ([]models.Route, error) {
 	var routes []models.Route
-	err := db.Where("plan_id = ?", planID).
-		Preload("Vehicle").
-		Preload("Stops.Customer").
+	err := embed.preload(db.Where("plan_id = ?", planID)).
 		Order("day, id").
 		Find(&routes).Error
 	return routes, err
 }
 
+// GetLoadSheetRoutes retrieves a plan's routes by day and vehicle with
+// vehicle, driver and stops in delivery order
+func GetLoadSheetRoutes(db *gorm.DB, planID int64) ([]models.Route, error) {
+	var routes []models.Route
+	err := db.Where("plan_id = ?", planID).
+		Preload("Vehicle", withDeleted).
+		Preload("Driver").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer", withDeleted).
+		Preload("Stops.Place", withDeleted).
+		Order("day, vehicle_id, id").
+		Find(&routes).Error
+	return routes, err
+}
+
+// GetDispatchRoutes retrieves the routes departing on a date from plans in
+// any of the given statuses, grouped by warehouse and vehicle, with plan,
+// warehouse, vehicle, driver and stops in delivery order
+func GetDispatchRoutes(db *gorm.DB, date time.Time, statuses []string) ([]models.Route, error) {
+	var routes []models.Route
+	err := db.Joins("JOIN plans ON routes.plan_id = plans.id").
+		Where("routes.date >= ? AND routes.date < ? AND plans.status IN ? AND plans.deleted_at IS NULL", date, date.AddDate(0, 0, 1), statuses).
+		Preload("Plan.Warehouse", withDeleted).
+		Preload("Vehicle", withDeleted).
+		Preload("Driver").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer", withDeleted).
+		Preload("Stops.Place", withDeleted).
+		Order("plans.warehouse_id, routes.vehicle_id, routes.planned_start, routes.id").
+		Find(&routes).Error
+	return routes, err
+}
+
+// PlannedDeliveryFilter selects planned deliveries. Nil fields match all.
+type PlannedDeliveryFilter struct {
+	CustomerID  *int64
+	WarehouseID *int64 // the plan's warehouse
+	From, To    time.Time
+	Statuses    []string // plan statuses
+}
+
+// GetPlannedDeliveries retrieves the delivery stops on routes dated from up
+// to but excluding to, of plans in any of the given statuses, that are not
+// delivered yet, with their route, in date order
+func GetPlannedDeliveries(db *gorm.DB, filter PlannedDeliveryFilter) ([]models.Stop, error) {
+	finished := db.Model(&models.StopExecution{}).Select("stop_id").Where("status NOT IN ?", []string{"pending", "arrived"})
+	query := db.Joins("JOIN routes ON stops.route_id = routes.id").
+		Joins("JOIN plans ON routes.plan_id = plans.id").
+		Where("stops.type = ? AND routes.date >= ? AND routes.date < ? AND plans.status IN ? AND plans.deleted_at IS NULL",
+			"delivery", filter.From, filter.To, filter.Statuses).
+		Where("stops.id NOT IN (?)", finished)
+	if filter.CustomerID != nil {
+		query = query.Where("stops.customer_id = ?", *filter.CustomerID)
+	}
+	if filter.WarehouseID != nil {
+		query = query.Where("plans.warehouse_id = ?", *filter.WarehouseID)
+	}
+	var stops []models.Stop
+	err := query.Preload("Route").
+		Order("routes.date, stops.id").
+		Find(&stops).Error
+	return stops, err
+}
+
+// RouteFilter selects and orders a plan's routes. Nil fields match all
+// routes.
+type RouteFilter struct {
+	PlanID    int64
+	Day       *int
+	VehicleID *int64
+	DriverID  *int64
+	From      *time.Time
+	To        *time.Time
+	Order     string
+	Embed     RouteEmbed
+}
+
+// ListRoutesPage retrieves one page of the routes matching f, with the
+// associations of f.Embed, and the number of matching routes
+func ListRoutesPage(db *gorm.DB, f RouteFilter, offset, limit int) ([]models.Route, int64, error) {
+	query := db.Model(&models.Route{}).Where("plan_id = ?", f.PlanID)
+	if f.Day != nil {
+		query = query.Where("day = ?", *f.Day)
+	}
+	if f.VehicleID != nil {
+		query = query.Where("vehicle_id = ?", *f.VehicleID)
+	}
+	if f.DriverID != nil {
+		query = query.Where("driver_id = ?", *f.DriverID)
+	}
+	if f.From != nil {
+		query = query.Where("date >= ?", *f.From)
+	}
+	if f.To != nil {
+		query = query.Where("date <= ?", *f.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	order := f.Order
+	if order == "" {
+		order = "day ASC"
+	}
+	var routes []models.Route
+	err := f.Embed.preload(query).
+		Order(order).Order("id").
+		Offset(offset).Limit(limit).
+		Find(&routes).Error
+	return routes, total, err
+}
+
+// GetRoutesByWarehouse retrieves routes departing a warehouse within a date
+// range. Only routes of optimized, approved and executing plans are included;
+// draft, cancelled and finished plans send nothing out.
+func GetRoutesByWarehouse(db *gorm.DB, warehouseID int64, from, to time.Time) ([]models.Route, error) {
+	var routes []models.Route
+	err := db.Joins("JOIN plans ON routes.plan_id = plans.id").
+		Where("plans.warehouse_id = ? AND routes.date >= ? AND routes.date <= ? AND plans.deleted_at IS NULL", warehouseID, from, to).
+		Where("plans.status IN ?", planstate.ScheduledStatuses()).
+		Preload("Vehicle", withDeleted).
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer", withDeleted).
+		Preload("Stops.Place", withDeleted).
+		Order("routes.date, routes.id").
+		Find(&routes).Error
+	return routes, err
+}
+
 func GetRouteByID(db *gorm.DB, id int64) (*models.Route, error) {
 	route := &models.Route{}
-	err := db.Preload("Plan").Preload("Vehicle").Preload("Stops.Customer").
+	err := db.Preload("Plan").Preload("Vehicle", withDeleted).Preload("Stops.Customer", withDeleted).Preload("Stops.Place", withDeleted).
 		First(route, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -31,6 +205,82 @@ func GetRouteByID(db *gorm.DB, id int64) (*models.Route, error) {
 	return route, nil
 }
 
+// GetRouteWithStops retrieves a route with its plan and warehouse, its
+// vehicle and its stops in delivery order with their customers and places
+func GetRouteWithStops(db *gorm.DB, id int64) (*models.Route, error) {
+	route := &models.Route{}
+	err := db.Preload("Plan.Warehouse", withDeleted).
+		Preload("Vehicle", withDeleted).
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer", withDeleted).
+		Preload("Stops.Place", withDeleted).
+		First(route, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return route, nil
+}
+
+// GetRouteWithExplanations retrieves a route with its plan and its stops in
+// delivery order, with customers and the explanations captured when the
+// route was optimized
+func GetRouteWithExplanations(db *gorm.DB, id int64) (*models.Route, error) {
+	route := &models.Route{}
+	err := db.Preload("Plan").
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer", withDeleted).
+		Preload("Stops.Place", withDeleted).
+		Preload("Stops.Explanation").
+		First(route, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return route, nil
+}
+
+// GetCargoRoutes retrieves a plan's routes with vehicle and stops in
+// delivery order, with the products and quantities needed to weigh them
+func GetCargoRoutes(db *gorm.DB, planID int64) ([]models.Route, error) {
+	var routes []models.Route
+	err := preloadCargo(db.Where("plan_id = ?", planID)).
+		Order("day, vehicle_id, id").
+		Find(&routes).Error
+	return routes, err
+}
+
+// GetCargoRoute retrieves a route with its plan, vehicle and cargo like
+// GetCargoRoutes
+func GetCargoRoute(db *gorm.DB, id int64) (*models.Route, error) {
+	route := &models.Route{}
+	err := preloadCargo(db.Preload("Plan")).First(route, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return route, nil
+}
+
+func preloadCargo(db *gorm.DB) *gorm.DB {
+	return db.Preload("Vehicle", withDeleted).
+		Preload("Stops", func(db *gorm.DB) *gorm.DB {
+			return db.Order("sequence")
+		}).
+		Preload("Stops.Customer.Product", withDeleted).
+		Preload("Stops.ProductQuantities.Product")
+}
+
 func CreateRoute(db *gorm.DB, r *models.Route) error {
 	return db.Create(r).Error
 }
@@ -47,10 +297,41 @@ func DeleteRoutesByPlanTx(tx *gorm.DB, planID int64) error {
 	return tx.Where("plan_id = ?", planID).Delete(&models.Route{}).Error
 }
 
+// DeleteUnlockedRoutesFromDayTx deletes a plan's routes on or after the given
+// day, except locked ones
+func DeleteUnlockedRoutesFromDayTx(tx *gorm.DB, planID int64, fromDay int) error {
+	return tx.Where("plan_id = ? AND day >= ? AND locked = ?", planID, fromDay, false).Delete(&models.Route{}).Error
+}
+
+// SetRouteLocked pins or unpins a route
+func SetRouteLocked(db *gorm.DB, id int64, locked bool) error {
+	result := db.Model(&models.Route{}).Where("id = ?", id).Update("locked", locked)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GetPlanRouteTotals sums cost and distance over all of a plan's routes
+func GetPlanRouteTotals(db *gorm.DB, planID int64) (float64, float64, error) {
+	var result struct {
+		TotalDistance float64
+		TotalCost     float64
+	}
+	err := db.Model(&models.Route{}).
+		Select("COALESCE(SUM(total_distance), 0) as total_distance, COALESCE(SUM(total_cost), 0) as total_cost").
+		Where("plan_id = ?", planID).
+		Scan(&result).Error
+	return result.TotalCost, result.TotalDistance, err
+}
+
 func GetStopsByRoute(db *gorm.DB, routeID int64) ([]models.Stop, error) {
 	var stops []models.Stop
 	err := db.Where("route_id = ?", routeID).
-		Preload("Customer").
+		Preload("Customer", withDeleted).
 		Order("sequence").
 		Find(&stops).Error
 	return stops, err
@@ -66,7 +347,7 @@ func CreateStopTx(tx *gorm.DB, s *models.Stop) error {
 
 func CountTotalDeliveries(db *gorm.DB) (int, error) {
 	var count int64
-	err := db.Model(&models.Stop{}).Count(&count).Error
+	err := db.Model(&models.Stop{}).Where("type = ?", "delivery").Count(&count).Error
 	return int(count), err
 }
 
@@ -80,3 +361,156 @@ func GetTotalDistanceAndCost(db *gorm.DB) (float64, float64, error) {
 		Scan(&result).Error
 	return result.TotalDistance, result.TotalCost, err
 }
+
+// CustomerDeliveryTotals aggregates planned stops per customer
+type CustomerDeliveryTotals struct {
+	CustomerID int64
+	Visits     int
+	Quantity   float64
+}
+
+// GetCustomerDeliveryTotals counts planned visits and quantity per customer within a date range
+func GetCustomerDeliveryTotals(db *gorm.DB, from, to time.Time) ([]CustomerDeliveryTotals, error) {
+	var totals []CustomerDeliveryTotals
+	err := db.Table("stops").
+		Select("stops.customer_id, COUNT(*) as visits, COALESCE(SUM(stops.quantity), 0) as quantity").
+		Joins("JOIN routes ON stops.route_id = routes.id").
+		Where("stops.customer_id IS NOT NULL AND routes.date >= ? AND routes.date <= ?", from, to).
+		Group("stops.customer_id").
+		Scan(&totals).Error
+	return totals, err
+}
+
+// GetStop retrieves a stop with its customer and the customer's product, or
+// its place
+func GetStop(db *gorm.DB, id int64) (*models.Stop, error) {
+	stop := &models.Stop{}
+	err := db.Preload("Customer.Product", withDeleted).Preload("Place", withDeleted).First(stop, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return stop, nil
+}
+
+// GetStopWithRouteTx retrieves a stop with its route
+func GetStopWithRouteTx(tx *gorm.DB, id int64) (*models.Stop, error) {
+	stop := &models.Stop{}
+	if err := tx.Preload("Route").First(stop, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return stop, nil
+}
+
+// UpdateStopQuantityTx sets a stop's quantity and recomputes its route's load
+func UpdateStopQuantityTx(tx *gorm.DB, stop *models.Stop, quantity float64) error {
+	result := tx.Model(&models.Stop{}).Where("id = ?", stop.ID).Update("quantity", quantity)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	stop.Quantity = quantity
+	return updateRouteLoadTx(tx, stop.RouteID)
+}
+
+// UpdateStopArrivalTimeTx sets a stop's arrival time
+func UpdateStopArrivalTimeTx(tx *gorm.DB, stop *models.Stop, arrival string) error {
+	result := tx.Model(&models.Stop{}).Where("id = ?", stop.ID).Update("arrival_time", arrival)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	stop.ArrivalTime = arrival
+	return nil
+}
+
+// CountStopExecutions counts the execution records of a stop
+func CountStopExecutions(db *gorm.DB, stopID int64) (int, error) {
+	var count int64
+	err := db.Model(&models.StopExecution{}).Where("stop_id = ?", stopID).Count(&count).Error
+	return int(count), err
+}
+
+// DeleteStopTx deletes a stop with its explanation and product quantities,
+// closes the gap it leaves in the route's sequence and recomputes the
+// route's load
+func DeleteStopTx(tx *gorm.DB, stop *models.Stop) error {
+	if err := tx.Where("stop_id = ?", stop.ID).Delete(&models.StopExplanation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("stop_id = ?", stop.ID).Delete(&models.StopProductQuantity{}).Error; err != nil {
+		return err
+	}
+	result := tx.Delete(&models.Stop{}, stop.ID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	err := tx.Model(&models.Stop{}).
+		Where("route_id = ? AND sequence > ?", stop.RouteID, stop.Sequence).
+		Update("sequence", gorm.Expr("sequence - 1")).Error
+	if err != nil {
+		return err
+	}
+	return updateRouteLoadTx(tx, stop.RouteID)
+}
+
+// InsertStopTx inserts a stop at its sequence in the route, moving the
+// stops from there on one place back
+func InsertStopTx(tx *gorm.DB, stop *models.Stop) error {
+	err := tx.Model(&models.Stop{}).
+		Where("route_id = ? AND sequence >= ?", stop.RouteID, stop.Sequence).
+		Update("sequence", gorm.Expr("sequence + 1")).Error
+	if err != nil {
+		return err
+	}
+	return tx.Create(stop).Error
+}
+
+// updateRouteLoadTx sets a route's load to the sum of its stop quantities
+func updateRouteLoadTx(tx *gorm.DB, routeID int64) error {
+	return tx.Model(&models.Route{}).
+		Where("id = ?", routeID).
+		Update("total_load", tx.Model(&models.Stop{}).
+			Select("COALESCE(SUM(quantity), 0)").
+			Where("route_id = ?", routeID)).Error
+}
+
+// UpdateRouteCostsTx stores a route's distance, cost, cost breakdown and
+// planned end after its stops were edited
+func UpdateRouteCostsTx(tx *gorm.DB, route *models.Route) error {
+	return tx.Model(&models.Route{}).Where("id = ?", route.ID).Updates(map[string]interface{}{
+		"planned_end":    route.PlannedEnd,
+		"total_distance": route.TotalDistance,
+		"total_cost":     route.TotalCost,
+		"fixed_cost":     route.FixedCost,
+		"distance_cost":  route.DistanceCost,
+		"holding_cost":   route.HoldingCost,
+		"penalty_cost":   route.PenaltyCost,
+	}).Error
+}
+
+// UpdatePlanTotalsTx sets a plan's cost and distance to the sums over its
+// routes
+func UpdatePlanTotalsTx(tx *gorm.DB, planID int64) error {
+	totalCost, totalDistance, err := GetPlanRouteTotals(tx, planID)
+	if err != nil {
+		return err
+	}
+	return tx.Model(&models.Plan{}).Where("id = ?", planID).Updates(map[string]interface{}{
+		"total_cost":     totalCost,
+		"total_distance": totalDistance,
+		"version":        nextVersion,
+	}).Error
+}
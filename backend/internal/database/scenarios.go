@@ -0,0 +1,107 @@
+package database
+
+import (
+	"errors"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListScenariosByPlan retrieves all scenarios cloned from a plan
+func ListScenariosByPlan(db *gorm.DB, planID int64) ([]models.Scenario, error) {
+	var scenarios []models.Scenario
+	err := db.Where("plan_id = ?", planID).Order("created_at DESC").Find(&scenarios).Error
+	return scenarios, err
+}
+
+// GetScenario retrieves a scenario with its routes
+func GetScenario(db *gorm.DB, id int64) (*models.Scenario, error) {
+	scenario := &models.Scenario{}
+	err := db.Preload("Routes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("day, id")
+	}).First(scenario, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return scenario, nil
+}
+
+// GetScenariosByIDs retrieves scenarios in the order of the given IDs
+func GetScenariosByIDs(db *gorm.DB, ids []int64) ([]models.Scenario, error) {
+	var scenarios []models.Scenario
+	if err := db.Where("id IN ?", ids).Find(&scenarios).Error; err != nil {
+		return nil, err
+	}
+
+	byID := make(map[int64]models.Scenario, len(scenarios))
+	for _, s := range scenarios {
+		byID[s.ID] = s
+	}
+	ordered := make([]models.Scenario, 0, len(ids))
+	for _, id := range ids {
+		s, ok := byID[id]
+		if !ok {
+			return nil, ErrNotFound
+		}
+		ordered = append(ordered, s)
+	}
+	return ordered, nil
+}
+
+// CreateScenario creates a new scenario
+func CreateScenario(db *gorm.DB, scenario *models.Scenario) error {
+	return db.Create(scenario).Error
+}
+
+// SaveScenarioResultTx replaces a scenario's routes and stores its totals
+func SaveScenarioResultTx(tx *gorm.DB, scenario *models.Scenario, routes []models.ScenarioRoute) error {
+	if err := tx.Where("scenario_id = ?", scenario.ID).Delete(&models.ScenarioRoute{}).Error; err != nil {
+		return err
+	}
+	for i := range routes {
+		routes[i].ScenarioID = scenario.ID
+		if err := tx.Create(&routes[i]).Error; err != nil {
+			return err
+		}
+	}
+	return UpdateScenarioStatus(tx, scenario)
+}
+
+// UpdateScenarioStatus stores a scenario's status and result totals
+func UpdateScenarioStatus(db *gorm.DB, scenario *models.Scenario) error {
+	result := db.Model(&models.Scenario{}).Where("id = ?", scenario.ID).Updates(map[string]interface{}{
+		"status":         scenario.Status,
+		"total_cost":     scenario.TotalCost,
+		"total_distance": scenario.TotalDistance,
+		"vehicles_used":  scenario.VehiclesUsed,
+		"message":        scenario.Message,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteScenario deletes a scenario and its routes
+func DeleteScenario(db *gorm.DB, id int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("scenario_id = ?", id).Delete(&models.ScenarioRoute{}).Error; err != nil {
+			return err
+		}
+		result := tx.Delete(&models.Scenario{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
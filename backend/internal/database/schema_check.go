@@ -0,0 +1,72 @@
+package database
+
+import (
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+// checkedModels are the models whose tables MissingColumns and
+// MissingIndexes compare the database with
+func checkedModels() []interface{} {
+	return append(migratedModels(), &models.LocationPing{})
+}
+
+func parseModel(db *gorm.DB, model interface{}) (*schema.Schema, error) {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(model); err != nil {
+		return nil, err
+	}
+	return stmt.Schema, nil
+}
+
+// MissingColumns returns the tables ("table") and columns ("table.column")
+// of the models that are not in the database, i.e. migrations that have
+// not been applied
+func MissingColumns(db *gorm.DB) ([]string, error) {
+	migrator := db.Migrator()
+	var missing []string
+	for _, model := range checkedModels() {
+		s, err := parseModel(db, model)
+		if err != nil {
+			return nil, err
+		}
+		if !migrator.HasTable(model) {
+			missing = append(missing, s.Table)
+			continue
+		}
+		for _, field := range s.Fields {
+			if field.DBName == "" || field.IgnoreMigration {
+				continue
+			}
+			if !migrator.HasColumn(model, field.DBName) {
+				missing = append(missing, s.Table+"."+field.DBName)
+			}
+		}
+	}
+	return missing, nil
+}
+
+// MissingIndexes returns the indexes ("table.index") the models declare
+// that are not in the database. Tables that are missing altogether are
+// left to MissingColumns.
+func MissingIndexes(db *gorm.DB) ([]string, error) {
+	migrator := db.Migrator()
+	var missing []string
+	for _, model := range checkedModels() {
+		s, err := parseModel(db, model)
+		if err != nil {
+			return nil, err
+		}
+		if !migrator.HasTable(model) {
+			continue
+		}
+		for _, index := range s.ParseIndexes() {
+			if !migrator.HasIndex(model, index.Name) {
+				missing = append(missing, s.Table+"."+index.Name)
+			}
+		}
+	}
+	return missing, nil
+}
@@ -0,0 +1,61 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func CreateSecurityEvent(db *gorm.DB, event *models.SecurityEvent) error {
+	return db.Create(event).Error
+}
+
+func GetSecurityEvent(db *gorm.DB, id int64) (*models.SecurityEvent, error) {
+	event := &models.SecurityEvent{}
+	err := db.First(event, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return event, nil
+}
+
+// SecurityEventFilter selects security events. Nil and empty fields match
+// all events; Before is exclusive.
+type SecurityEventFilter struct {
+	Types  []string
+	UserID *int64
+	Since  *time.Time
+	Before *time.Time
+}
+
+// ListSecurityEvents retrieves one page of the events matching f, newest
+// first, and the number of matching events
+func ListSecurityEvents(db *gorm.DB, f SecurityEventFilter, offset, limit int) ([]models.SecurityEvent, int64, error) {
+	query := db.Model(&models.SecurityEvent{})
+	if len(f.Types) > 0 {
+		query = query.Where("type IN ?", f.Types)
+	}
+	if f.UserID != nil {
+		query = query.Where("user_id = ? OR actor_id = ?", *f.UserID, *f.UserID)
+	}
+	if f.Since != nil {
+		query = query.Where("created_at >= ?", *f.Since)
+	}
+	if f.Before != nil {
+		query = query.Where("created_at < ?", *f.Before)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var events []models.SecurityEvent
+	err := query.Order("created_at DESC").Order("id DESC").Offset(offset).Limit(limit).Find(&events).Error
+	return events, total, err
+}
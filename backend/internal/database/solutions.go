@@ -0,0 +1,71 @@
+package database
+
+import (
+	"errors"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListPlanSolutions retrieves a plan's solution versions without their routes
+func ListPlanSolutions(db *gorm.DB, planID int64) ([]models.PlanSolution, error) {
+	var solutions []models.PlanSolution
+	err := db.Where("plan_id = ?", planID).Order("version DESC").Find(&solutions).Error
+	return solutions, err
+}
+
+// GetPlanSolution retrieves one solution version with its routes
+func GetPlanSolution(db *gorm.DB, planID int64, version int) (*models.PlanSolution, error) {
+	solution := &models.PlanSolution{}
+	err := db.Preload("Routes", func(db *gorm.DB) *gorm.DB {
+		return db.Order("day, id")
+	}).Where("plan_id = ? AND version = ?", planID, version).First(solution).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return solution, nil
+}
+
+// GetCurrentPlanSolution retrieves the solution version the plan's routes
+// were last replaced with
+func GetCurrentPlanSolution(db *gorm.DB, planID int64) (*models.PlanSolution, error) {
+	solution := &models.PlanSolution{}
+	err := db.Preload("Routes").
+		Where("plan_id = ? AND is_current = ?", planID, true).
+		First(solution).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return solution, nil
+}
+
+// CreatePlanSolutionTx stores a solution as the plan's next version and marks
+// it current. Routes on the solution are created with it.
+func CreatePlanSolutionTx(tx *gorm.DB, solution *models.PlanSolution) error {
+	var latest int
+	err := tx.Model(&models.PlanSolution{}).
+		Where("plan_id = ?", solution.PlanID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error
+	if err != nil {
+		return err
+	}
+
+	err = tx.Model(&models.PlanSolution{}).
+		Where("plan_id = ? AND is_current = ?", solution.PlanID, true).
+		Update("is_current", false).Error
+	if err != nil {
+		return err
+	}
+
+	solution.Version = latest + 1
+	solution.IsCurrent = true
+	return tx.Create(solution).Error
+}
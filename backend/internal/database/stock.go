@@ -0,0 +1,143 @@
+package database
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrInsufficientStock is returned when a movement would take a warehouse's
+// stock, or its stock of a product, below zero, and when an adjustment would
+// take a customer's inventory below zero
+var ErrInsufficientStock = errors.New("not enough stock")
+
+// StockMovementFilter selects stock movements of a warehouse by product,
+// kind and when they occurred
+type StockMovementFilter struct {
+	WarehouseID int64
+	ProductID   *int64
+	Kinds       []string
+	Since       *time.Time
+	Before      *time.Time
+}
+
+// RecordStockMovementTx books a movement to the stock ledger: it changes
+// the warehouse's current stock, and its stock of the movement's product,
+// by the movement's quantity and stores the movement with the balances
+// after it. Only deliveries may take stock below zero, as deliveries are
+// recorded after the goods have left; other movements that would are
+// refused with ErrInsufficientStock. The stock after an adjustment is also
+// recorded as a snapshot, with the stocktake reason for a stocktake's.
+func RecordStockMovementTx(tx *gorm.DB, m *models.StockMovement) error {
+	result := tx.Unscoped().Model(&models.Warehouse{}).Where("id = ?", m.WarehouseID).
+		UpdateColumns(map[string]interface{}{
+			"current_stock": gorm.Expr("current_stock + ?", m.Quantity),
+			"version":       nextVersion,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	warehouse := &models.Warehouse{}
+	if err := tx.Unscoped().First(warehouse, m.WarehouseID).Error; err != nil {
+		return err
+	}
+	m.Balance = warehouse.CurrentStock
+	if m.Balance < 0 && m.Kind != models.StockDelivery {
+		return ErrInsufficientStock
+	}
+
+	if m.ProductID != nil {
+		stock, err := addProductStockTx(tx, m.WarehouseID, *m.ProductID, m.Quantity)
+		if err != nil {
+			return err
+		}
+		if stock.Quantity < 0 && m.Kind != models.StockDelivery {
+			return ErrInsufficientStock
+		}
+		m.ProductBalance = &stock.Quantity
+	}
+	if m.Kind == models.StockAdjustment {
+		snapshot := &models.InventorySnapshot{
+			EntityType:     "warehouse",
+			EntityID:       m.WarehouseID,
+			SnapshotDate:   m.OccurredAt.UTC().Truncate(24 * time.Hour),
+			SnapshotTime:   m.OccurredAt,
+			InventoryLevel: m.Balance,
+			SnapshotReason: "adjustment",
+		}
+		if m.Reason == models.StocktakeReason {
+			snapshot.SnapshotReason = models.StocktakeReason
+		}
+		if err := tx.Create(snapshot).Error; err != nil {
+			return err
+		}
+	}
+	return tx.Create(m).Error
+}
+
+// addProductStockTx changes a warehouse's stock of a product by quantity,
+// creating the stock record on the product's first movement
+func addProductStockTx(tx *gorm.DB, warehouseID, productID int64, quantity float64) (*models.WarehouseProductStock, error) {
+	result := tx.Model(&models.WarehouseProductStock{}).
+		Where("warehouse_id = ? AND product_id = ?", warehouseID, productID).
+		Update("quantity", gorm.Expr("quantity + ?", quantity))
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	stock := &models.WarehouseProductStock{WarehouseID: warehouseID, ProductID: productID, Quantity: quantity}
+	if result.RowsAffected == 0 {
+		return stock, tx.Create(stock).Error
+	}
+	err := tx.Where("warehouse_id = ? AND product_id = ?", warehouseID, productID).First(stock).Error
+	return stock, err
+}
+
+// GetWarehouseProductStock retrieves a warehouse's stock of each product
+// with the products, by product name
+func GetWarehouseProductStock(db *gorm.DB, warehouseID int64) ([]models.WarehouseProductStock, error) {
+	var stock []models.WarehouseProductStock
+	if err := db.Where("warehouse_id = ?", warehouseID).Preload("Product").Find(&stock).Error; err != nil {
+		return nil, err
+	}
+	sort.Slice(stock, func(i, j int) bool {
+		a, b := stock[i].Product, stock[j].Product
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return a.Name < b.Name
+	})
+	return stock, nil
+}
+
+// ListStockMovements retrieves one page of the stock movements matching f,
+// latest first, and the number of matching movements
+func ListStockMovements(db *gorm.DB, f StockMovementFilter, offset, limit int) ([]models.StockMovement, int64, error) {
+	query := db.Model(&models.StockMovement{}).Where("warehouse_id = ?", f.WarehouseID)
+	if f.ProductID != nil {
+		query = query.Where("product_id = ?", *f.ProductID)
+	}
+	if len(f.Kinds) > 0 {
+		query = query.Where("kind IN ?", f.Kinds)
+	}
+	if f.Since != nil {
+		query = query.Where("occurred_at >= ?", *f.Since)
+	}
+	if f.Before != nil {
+		query = query.Where("occurred_at < ?", *f.Before)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var movements []models.StockMovement
+	err := query.Preload("Product").Order("occurred_at DESC").Order("id DESC").Offset(offset).Limit(limit).Find(&movements).Error
+	return movements, total, err
+}
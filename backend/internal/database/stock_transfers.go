@@ -0,0 +1,198 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StockTransferFilter selects stock transfers. Empty fields match all.
+type StockTransferFilter struct {
+	// WarehouseID matches transfers from or to the warehouse
+	WarehouseID *int64
+	Statuses    []string
+}
+
+// CreateStockTransfer stores a requested transfer, with the route carrying
+// it when route is set, all or none. The route's plan totals are updated.
+func CreateStockTransfer(db *gorm.DB, t *models.StockTransfer, route *models.Route) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if route != nil {
+			if err := CreateRouteTx(tx, route); err != nil {
+				return err
+			}
+			if err := UpdatePlanTotalsTx(tx, route.PlanID); err != nil {
+				return err
+			}
+			t.PlanID, t.RouteID = &route.PlanID, &route.ID
+		}
+		return tx.Create(t).Error
+	})
+}
+
+// GetStockTransfer retrieves a stock transfer by ID with its warehouses and
+// product
+func GetStockTransfer(db *gorm.DB, id int64) (*models.StockTransfer, error) {
+	t := &models.StockTransfer{}
+	err := db.Preload("FromWarehouse", withDeleted).
+		Preload("ToWarehouse", withDeleted).
+		Preload("Product").
+		First(t, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// ListStockTransfers retrieves one page of the stock transfers matching f,
+// latest first, and the number of matching transfers
+func ListStockTransfers(db *gorm.DB, f StockTransferFilter, offset, limit int) ([]models.StockTransfer, int64, error) {
+	query := db.Model(&models.StockTransfer{})
+	if f.WarehouseID != nil {
+		query = query.Where("from_warehouse_id = ? OR to_warehouse_id = ?", *f.WarehouseID, *f.WarehouseID)
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var transfers []models.StockTransfer
+	err := query.Order("created_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&transfers).Error
+	return transfers, total, err
+}
+
+// ShipStockTransfer marks a requested transfer in transit at now and books
+// its quantity out of the source warehouse, all or none. It returns
+// ErrNotFound when the transfer is not requested, and ErrInsufficientStock
+// when the source warehouse does not have the stock.
+func ShipStockTransfer(db *gorm.DB, id int64, userID *int64, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		t, err := advanceStockTransferTx(tx, id, models.TransferRequested, map[string]interface{}{
+			"status":     models.TransferInTransit,
+			"shipped_by": userID,
+			"shipped_at": now,
+		})
+		if err != nil {
+			return err
+		}
+		out := &models.StockMovement{
+			WarehouseID:            t.FromWarehouseID,
+			ProductID:              t.ProductID,
+			Kind:                   models.StockTransferOut,
+			Quantity:               -t.Quantity,
+			CounterpartWarehouseID: &t.ToWarehouseID,
+			Reference:              transferReference(t),
+			Notes:                  t.Notes,
+			PlanID:                 t.PlanID,
+			RouteID:                t.RouteID,
+			CreatedBy:              userID,
+			OccurredAt:             now,
+		}
+		if err := RecordStockMovementTx(tx, out); err != nil {
+			return err
+		}
+		return tx.Model(t).Update("out_movement_id", out.ID).Error
+	})
+}
+
+// ReceiveStockTransfer marks a transfer in transit received at now and
+// books its quantity in at the destination warehouse, all or none. It
+// returns ErrNotFound when the transfer is not in transit.
+func ReceiveStockTransfer(db *gorm.DB, id int64, userID *int64, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		t, err := advanceStockTransferTx(tx, id, models.TransferInTransit, map[string]interface{}{
+			"status":      models.TransferReceived,
+			"received_by": userID,
+			"received_at": now,
+		})
+		if err != nil {
+			return err
+		}
+		in := &models.StockMovement{
+			WarehouseID:            t.ToWarehouseID,
+			ProductID:              t.ProductID,
+			Kind:                   models.StockTransferIn,
+			Quantity:               t.Quantity,
+			CounterpartWarehouseID: &t.FromWarehouseID,
+			Reference:              transferReference(t),
+			Notes:                  t.Notes,
+			PlanID:                 t.PlanID,
+			RouteID:                t.RouteID,
+			CreatedBy:              userID,
+			OccurredAt:             now,
+		}
+		if err := RecordStockMovementTx(tx, in); err != nil {
+			return err
+		}
+		return tx.Model(t).Update("in_movement_id", in.ID).Error
+	})
+}
+
+// CancelStockTransfer cancels a requested transfer at now. Its route is
+// removed from the plan unless it has execution records. It returns
+// ErrNotFound when the transfer is not requested.
+func CancelStockTransfer(db *gorm.DB, id int64, userID *int64, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		t, err := advanceStockTransferTx(tx, id, models.TransferRequested, map[string]interface{}{
+			"status":       models.TransferCancelled,
+			"cancelled_by": userID,
+			"cancelled_at": now,
+		})
+		if err != nil || t.RouteID == nil {
+			return err
+		}
+		var executions int64
+		if err := tx.Model(&models.RouteExecution{}).Where("route_id = ?", *t.RouteID).Count(&executions).Error; err != nil {
+			return err
+		}
+		if executions > 0 {
+			return nil
+		}
+		if err := tx.Where("route_id = ?", *t.RouteID).Delete(&models.Stop{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&models.Route{}, *t.RouteID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(t).Update("route_id", nil).Error; err != nil {
+			return err
+		}
+		return UpdatePlanTotalsTx(tx, *t.PlanID)
+	})
+}
+
+// advanceStockTransferTx applies changes to a transfer in status from and
+// returns the changed transfer, or ErrNotFound when it is in another status
+func advanceStockTransferTx(tx *gorm.DB, id int64, from string, changes map[string]interface{}) (*models.StockTransfer, error) {
+	result := tx.Model(&models.StockTransfer{}).Where("id = ? AND status = ?", id, from).Updates(changes)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotFound
+	}
+	t := &models.StockTransfer{}
+	return t, tx.First(t, id).Error
+}
+
+// transferReference is the reference of a transfer's ledger entries: its
+// own, or its number
+func transferReference(t *models.StockTransfer) string {
+	if t.Reference != "" {
+		return t.Reference
+	}
+	return fmt.Sprintf("Transfer #%d", t.ID)
+}
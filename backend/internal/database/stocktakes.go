@@ -0,0 +1,174 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// StocktakeFilter selects stocktakes by what was counted. Empty fields
+// match all.
+type StocktakeFilter struct {
+	EntityType string
+	EntityID   *int64
+}
+
+// RecordStocktake reconciles a stocktake's counted quantities with the
+// books: it sets each line's book quantity and variance, applies the
+// variances as adjustments with the stocktake reason and stores the
+// stocktake with its lines, all or none. A customer is counted as a whole,
+// by a line without product. It returns ErrNotFound when the customer or
+// warehouse does not exist.
+func RecordStocktake(db *gorm.DB, s *models.Stocktake) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		lines := s.Lines
+		s.Lines, s.Variance = nil, 0
+		if err := tx.Create(s).Error; err != nil {
+			return err
+		}
+		for i := range lines {
+			line := &lines[i]
+			line.StocktakeID = s.ID
+			var err error
+			if s.EntityType == "customer" {
+				err = reconcileCustomerTx(tx, s, line)
+			} else {
+				err = reconcileWarehouseTx(tx, s, line)
+			}
+			if err != nil {
+				return err
+			}
+			if err := tx.Create(line).Error; err != nil {
+				return err
+			}
+			s.Variance += line.Variance
+		}
+		s.Lines = lines
+		return tx.Model(s).Update("variance", s.Variance).Error
+	})
+}
+
+// reconcileCustomerTx compares a line's count with the customer's current
+// inventory and adjusts the inventory by the variance
+func reconcileCustomerTx(tx *gorm.DB, s *models.Stocktake, line *models.StocktakeLine) error {
+	customer := &models.Customer{}
+	if err := tx.First(customer, s.EntityID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	line.BookQuantity = customer.CurrentInventory
+	line.Variance = line.CountedQuantity - line.BookQuantity
+	if line.Variance == 0 {
+		return nil
+	}
+	adj := &models.InventoryAdjustment{
+		CustomerID: customer.ID,
+		Delta:      line.Variance,
+		Reason:     models.StocktakeReason,
+		Notes:      stocktakeNotes(s),
+		CreatedBy:  s.CountedBy,
+	}
+	if err := AdjustCustomerInventoryTx(tx, adj, s.CountedAt); err != nil {
+		return err
+	}
+	line.AdjustmentID = &adj.ID
+	return nil
+}
+
+// reconcileWarehouseTx compares a line's count with the warehouse's stock
+// of the product, or its stock not booked to a product, and books the
+// variance as an adjustment
+func reconcileWarehouseTx(tx *gorm.DB, s *models.Stocktake, line *models.StocktakeLine) error {
+	warehouse := &models.Warehouse{}
+	if err := tx.First(warehouse, s.EntityID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrNotFound
+		}
+		return err
+	}
+	stock, err := GetWarehouseProductStock(tx, warehouse.ID)
+	if err != nil {
+		return err
+	}
+	line.BookQuantity = warehouse.CurrentStock
+	if line.ProductID != nil {
+		line.BookQuantity = 0
+	}
+	for _, p := range stock {
+		switch {
+		case line.ProductID == nil:
+			line.BookQuantity -= p.Quantity
+		case p.ProductID == *line.ProductID:
+			line.BookQuantity = p.Quantity
+		}
+	}
+	line.Variance = line.CountedQuantity - line.BookQuantity
+	if line.Variance == 0 {
+		return nil
+	}
+	movement := &models.StockMovement{
+		WarehouseID: warehouse.ID,
+		ProductID:   line.ProductID,
+		Kind:        models.StockAdjustment,
+		Reason:      models.StocktakeReason,
+		Quantity:    line.Variance,
+		Reference:   s.Reference,
+		Notes:       stocktakeNotes(s),
+		CreatedBy:   s.CountedBy,
+		OccurredAt:  s.CountedAt,
+	}
+	if err := RecordStockMovementTx(tx, movement); err != nil {
+		return err
+	}
+	line.MovementID = &movement.ID
+	return nil
+}
+
+// stocktakeNotes are the notes of the adjustments a stocktake applies
+func stocktakeNotes(s *models.Stocktake) string {
+	return fmt.Sprintf("Stocktake %d", s.ID)
+}
+
+// GetStocktake retrieves a stocktake by ID with its lines and their
+// products
+func GetStocktake(db *gorm.DB, id int64) (*models.Stocktake, error) {
+	s := &models.Stocktake{}
+	err := db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id")
+	}).Preload("Lines.Product").First(s, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListStocktakes retrieves one page of the stocktakes matching f, latest
+// first, without their lines, and the number of matching stocktakes
+func ListStocktakes(db *gorm.DB, f StocktakeFilter, offset, limit int) ([]models.Stocktake, int64, error) {
+	query := db.Model(&models.Stocktake{})
+	if f.EntityType != "" {
+		query = query.Where("entity_type = ?", f.EntityType)
+	}
+	if f.EntityID != nil {
+		query = query.Where("entity_id = ?", *f.EntityID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var stocktakes []models.Stocktake
+	err := query.Order("counted_at DESC, id DESC").
+		Offset(offset).
+		Limit(limit).
+		Find(&stocktakes).Error
+	return stocktakes, total, err
+}
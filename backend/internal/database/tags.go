@@ -0,0 +1,128 @@
+package database
+
+import (
+	"errors"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func ListTags(db *gorm.DB) ([]models.Tag, error) {
+	var tags []models.Tag
+	err := db.Order("name").Find(&tags).Error
+	return tags, err
+}
+
+func GetTag(db *gorm.DB, id int64) (*models.Tag, error) {
+	t := &models.Tag{}
+	err := db.First(t, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return t, nil
+}
+
+// GetTagsByName retrieves the tags with any of the names, by name
+func GetTagsByName(db *gorm.DB, names []string) (map[string]models.Tag, error) {
+	result := make(map[string]models.Tag)
+	if len(names) == 0 {
+		return result, nil
+	}
+	var tags []models.Tag
+	if err := db.Where("name IN ?", names).Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	for _, t := range tags {
+		result[t.Name] = t
+	}
+	return result, nil
+}
+
+func CreateTag(db *gorm.DB, t *models.Tag) error {
+	return db.Create(t).Error
+}
+
+func UpdateTag(db *gorm.DB, t *models.Tag) error {
+	result := db.Model(t).Select("name", "description", "updated_at").Updates(t)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteTag deletes a tag and detaches it from its customers and vehicles
+func DeleteTag(db *gorm.DB, id int64) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, table := range []string{"customer_tags", "vehicle_tags"} {
+			if err := tx.Exec("DELETE FROM "+table+" WHERE tag_id = ?", id).Error; err != nil {
+				return err
+			}
+		}
+		result := tx.Delete(&models.Tag{}, id)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotFound
+		}
+		return nil
+	})
+}
+
+// SetCustomerTags replaces the tags of a customer, a new version of it
+func SetCustomerTags(db *gorm.DB, c *models.Customer, tags []models.Tag) error {
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(c).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		return bumpVersion(tx, &models.Customer{}, c.ID, &c.Version)
+	})
+	if err != nil {
+		return err
+	}
+	c.Tags = tags
+	return nil
+}
+
+// SetVehicleTags replaces the tags of a vehicle, a new version of it
+func SetVehicleTags(db *gorm.DB, v *models.Vehicle, tags []models.Tag) error {
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(v).Association("Tags").Replace(tags); err != nil {
+			return err
+		}
+		return bumpVersion(tx, &models.Vehicle{}, v.ID, &v.Version)
+	})
+	if err != nil {
+		return err
+	}
+	v.Tags = tags
+	return nil
+}
+
+// withAnyTag narrows a query of customers or vehicles to those with any of
+// the named tags. joinTable and column are the attachments' table and the
+// column of the tagged record's ID in it.
+func withAnyTag(query *gorm.DB, joinTable, column string, names []string) *gorm.DB {
+	if len(names) == 0 {
+		return query
+	}
+	tagged := query.Session(&gorm.Session{NewDB: true}).
+		Table(joinTable).
+		Select(joinTable+"."+column).
+		Joins("JOIN tags ON tags.id = "+joinTable+".tag_id").
+		Where("tags.name IN ?", names)
+	return query.Where("id IN (?)", tagged)
+}
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ListUnroutedCustomers retrieves the unrouted customer report of a plan,
+// largest shortfall first
+func ListUnroutedCustomers(db *gorm.DB, planID int64) ([]models.UnroutedCustomer, error) {
+	var entries []models.UnroutedCustomer
+	err := db.Preload("Customer", withDeleted).
+		Where("plan_id = ?", planID).
+		Order("shortfall DESC, id").
+		Find(&entries).Error
+	return entries, err
+}
+
+// ReplaceUnroutedCustomersTx replaces a plan's unrouted customer report
+func ReplaceUnroutedCustomersTx(tx *gorm.DB, planID int64, entries []models.UnroutedCustomer) error {
+	if err := tx.Where("plan_id = ?", planID).Delete(&models.UnroutedCustomer{}).Error; err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return tx.Create(&entries).Error
+}
+
+// ForceUnroutedCustomers flags a plan's unrouted customers for the next
+// optimization at now. An empty customerIDs flags every customer in the
+// report.
+func ForceUnroutedCustomers(db *gorm.DB, planID int64, customerIDs []int64, userID int64, now time.Time) (int64, error) {
+	query := db.Model(&models.UnroutedCustomer{}).
+		Where("plan_id = ? AND forced_at IS NULL", planID)
+	if len(customerIDs) > 0 {
+		query = query.Where("customer_id IN ?", customerIDs)
+	}
+	result := query.Updates(map[string]interface{}{
+		"forced_at": now,
+		"forced_by": userID,
+	})
+	return result.RowsAffected, result.Error
+}
+
+// GetPendingForcedCustomers retrieves forced entries not yet used by an optimization
+func GetPendingForcedCustomers(db *gorm.DB) ([]models.UnroutedCustomer, error) {
+	var entries []models.UnroutedCustomer
+	err := db.Where("forced_at IS NOT NULL AND consumed_at IS NULL").Find(&entries).Error
+	return entries, err
+}
+
+// ConsumeForcedCustomersTx marks forced entries as used by a plan's
+// optimization at now
+func ConsumeForcedCustomersTx(tx *gorm.DB, ids []int64, planID int64, now time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&models.UnroutedCustomer{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{
+			"consumed_at":      now,
+			"consumed_plan_id": planID,
+		}).Error
+}
@@ -2,6 +2,8 @@ package database
 
 import (
 	"errors"
+	"strings"
+	"time"
 
 	"LogiTrackPro/backend/internal/models"
 
@@ -35,6 +37,30 @@ func GetUserByID(db *gorm.DB, id int64) (*models.User, error) {
 	return user, nil
 }
 
+func UpdateUserRole(db *gorm.DB, id int64, role string) error {
+	result := db.Model(&models.User{}).Where("id = ?", id).Update("role", role)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateUserOrganization moves a user to an organization, or out of any
+// with a nil orgID
+func UpdateUserOrganization(db *gorm.DB, id int64, orgID *int64) error {
+	result := db.Model(&models.User{}).Where("id = ?", id).Update("organization_id", orgID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
 func CreateUser(db *gorm.DB, user *models.User) error {
 	err := db.Create(user).Error
 	if err != nil {
@@ -49,12 +75,14 @@ func CreateUser(db *gorm.DB, user *models.User) error {
 
 func isUniqueViolation(err error) bool {
 	// GORM wraps PostgreSQL errors, check for unique constraint violations
-	return err != nil && (
-		errors.Is(err, gorm.ErrDuplicatedKey) ||
-		contains(err.Error(), "unique") ||
-		contains(err.Error(), "duplicate") ||
-		contains(err.Error(), "violates unique constraint"),
-	)
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	return errors.Is(err, gorm.ErrDuplicatedKey) ||
+		contains(msg, "unique") ||
+		contains(msg, "duplicate") ||
+		contains(msg, "violates unique constraint")
 }
 
 func contains(s, substr string) bool {
@@ -70,3 +98,31 @@ func containsHelper(s, substr string) bool {
 	return false
 }
 
+
+// RevokeUserTokens rejects every token issued to the user before at
+func RevokeUserTokens(db *gorm.DB, id int64, at time.Time) error {
+	result := db.Model(&models.User{}).Where("id = ?", id).Update("tokens_revoked_at", at)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UpdateUserPassword stores a new password hash and revokes the user's
+// existing tokens
+func UpdateUserPassword(db *gorm.DB, id int64, hash string, at time.Time) error {
+	result := db.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash":     hash,
+		"tokens_revoked_at": at,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package database
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SaveVehicleLog stores the vehicle log of a route execution, replacing
+// the one reported when the execution was completed before
+func SaveVehicleLog(db *gorm.DB, log *models.VehicleLog) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "route_execution_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"vehicle_id", "date", "odometer_start", "odometer_end", "fuel_purchased", "fuel_cost", "updated_at"}),
+	}).Create(log).Error
+}
+
+// GetVehicleLogs retrieves the vehicle logs dated between from and to, of
+// one vehicle or all of them, ordered by vehicle and date
+func GetVehicleLogs(db *gorm.DB, from, to time.Time, vehicleID *int64) ([]models.VehicleLog, error) {
+	query := db.Where("date BETWEEN ? AND ?", from, to)
+	if vehicleID != nil {
+		query = query.Where("vehicle_id = ?", *vehicleID)
+	}
+	var logs []models.VehicleLog
+	err := query.Order("vehicle_id, date, id").Find(&logs).Error
+	return logs, err
+}
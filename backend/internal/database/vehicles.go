@@ -2,21 +2,28 @@ package database
 
 import (
 	"errors"
+	"time"
 
 	"LogiTrackPro/backend/internal/models"
 
 	"gorm.io/gorm"
 )
 
-func ListVehicles(db *gorm.DB) ([]models.Vehicle, error) {
+// ListVehicles retrieves the vehicles with any of the named tags, or all
+// vehicles without tags
+func ListVehicles(db *gorm.DB, tags []string) ([]models.Vehicle, error) {
 	var vehicles []models.Vehicle
-	err := db.Order("name").Find(&vehicles).Error
+	err := withAnyTag(db.Model(&models.Vehicle{}), "vehicle_tags", "vehicle_id", tags).
+		Preload("Tags").
+		Order("name").
+		Find(&vehicles).Error
 	return vehicles, err
 }
 
 func ListAvailableVehiclesByWarehouse(db *gorm.DB, warehouseID int64) ([]models.Vehicle, error) {
 	var vehicles []models.Vehicle
 	err := db.Where("warehouse_id = ? AND available = ?", warehouseID, true).
+		Preload("Tags").
 		Order("name").
 		Find(&vehicles).Error
 	return vehicles, err
@@ -24,7 +31,7 @@ func ListAvailableVehiclesByWarehouse(db *gorm.DB, warehouseID int64) ([]models.
 
 func GetVehicle(db *gorm.DB, id int64) (*models.Vehicle, error) {
 	v := &models.Vehicle{}
-	err := db.First(v, id).Error
+	err := db.Preload("Tags").First(v, id).Error
 	if err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, ErrNotFound
@@ -34,29 +41,78 @@ func GetVehicle(db *gorm.DB, id int64) (*models.Vehicle, error) {
 	return v, nil
 }
 
+// GetVehicleWithDeleted retrieves a vehicle even when it was retired
+func GetVehicleWithDeleted(db *gorm.DB, id int64) (*models.Vehicle, error) {
+	return GetVehicle(db.Unscoped(), id)
+}
+
 func CreateVehicle(db *gorm.DB, v *models.Vehicle) error {
 	return db.Create(v).Error
 }
 
 func UpdateVehicle(db *gorm.DB, v *models.Vehicle) error {
-	result := db.Model(v).Updates(models.Vehicle{
-		Name:        v.Name,
-		Capacity:    v.Capacity,
-		CostPerKm:   v.CostPerKm,
-		FixedCost:   v.FixedCost,
-		MaxDistance: v.MaxDistance,
-		Available:   v.Available,
-		WarehouseID: v.WarehouseID,
+	version, err := updateVersion(db, &models.Vehicle{}, v.ID, v.Version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(v).Updates(models.Vehicle{
+			Name:             v.Name,
+			Capacity:         v.Capacity,
+			CostPerKm:        v.CostPerKm,
+			FixedCost:        v.FixedCost,
+			MaxDistance:      v.MaxDistance,
+			RangeKm:          v.RangeKm,
+			Electric:         v.Electric,
+			ChargeMinutes:    v.ChargeMinutes,
+			ConsumptionPerKm: v.ConsumptionPerKm,
+			CO2PerKm:         v.CO2PerKm,
+			Available:        v.Available,
+			WarehouseID:      v.WarehouseID,
+			MaxWorkingHours:  v.MaxWorkingHours,
+			AverageSpeed:     v.AverageSpeed,
+			ShiftStart:       v.ShiftStart,
+			ShiftEnd:         v.ShiftEnd,
+			AllowedTags:      v.AllowedTags,
+			MaxPayloadWeight: v.MaxPayloadWeight,
+			MaxFrontAxleLoad: v.MaxFrontAxleLoad,
+			MaxRearAxleLoad:  v.MaxRearAxleLoad,
+			ExternalRef:      v.ExternalRef,
+		})
 	})
-	if result.Error != nil {
-		return result.Error
+	if err != nil {
+		return err
 	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+	v.Version = version
+	return nil
+}
+
+// UpdateVehicleColumns writes the given columns of a vehicle, zero values
+// included
+func UpdateVehicleColumns(db *gorm.DB, v *models.Vehicle, columns []string) error {
+	version, err := updateVersion(db, &models.Vehicle{}, v.ID, v.Version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(v).Select(columns).Updates(v)
+	})
+	if err != nil {
+		return err
 	}
+	v.Version = version
 	return nil
 }
 
+// GetVehiclesByExternalRef retrieves the vehicles with any of the external
+// references, by reference
+func GetVehiclesByExternalRef(db *gorm.DB, refs []string) (map[string]*models.Vehicle, error) {
+	result := make(map[string]*models.Vehicle)
+	if len(refs) == 0 {
+		return result, nil
+	}
+	var vehicles []models.Vehicle
+	if err := db.Where("external_ref IN ?", refs).Find(&vehicles).Error; err != nil {
+		return nil, err
+	}
+	for i := range vehicles {
+		result[vehicles[i].ExternalRef] = &vehicles[i]
+	}
+	return result, nil
+}
+
 func DeleteVehicle(db *gorm.DB, id int64) error {
 	result := db.Delete(&models.Vehicle{}, id)
 	if result.Error != nil {
@@ -74,3 +130,34 @@ func CountVehicles(db *gorm.DB) (int, error) {
 	return int(count), err
 }
 
+// GetRoutesByVehicle retrieves routes driven by a vehicle within a date range
+func GetRoutesByVehicle(db *gorm.DB, vehicleID int64, from, to time.Time) ([]models.Route, error) {
+	var routes []models.Route
+	err := db.Where("vehicle_id = ? AND date >= ? AND date <= ?", vehicleID, from, to).
+		Order("date, id").
+		Find(&routes).Error
+	return routes, err
+}
+
+// GetRouteExecutionsByVehicle retrieves executions of a vehicle's routes within a date range
+func GetRouteExecutionsByVehicle(db *gorm.DB, vehicleID int64, from, to time.Time) ([]models.RouteExecution, error) {
+	var executions []models.RouteExecution
+	err := db.Joins("JOIN routes ON route_executions.route_id = routes.id").
+		Where("routes.vehicle_id = ? AND routes.date >= ? AND routes.date <= ?", vehicleID, from, to).
+		Order("route_executions.created_at").
+		Find(&executions).Error
+	return executions, err
+}
+
+// GetVehiclesByIDs returns the vehicles keyed by ID; unknown IDs are omitted
+func GetVehiclesByIDs(db *gorm.DB, ids []int64) (map[int64]models.Vehicle, error) {
+	var vehicles []models.Vehicle
+	if err := db.Where("id IN ?", ids).Find(&vehicles).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[int64]models.Vehicle, len(vehicles))
+	for _, v := range vehicles {
+		result[v.ID] = v
+	}
+	return result, nil
+}
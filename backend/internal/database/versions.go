@@ -0,0 +1,58 @@
+package database
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrVersionConflict is returned when an edit is based on another version
+// of a record than the stored one
+var ErrVersionConflict = errors.New("record was changed since the version edited")
+
+// nextVersion increments a record's version in the statement that sets it.
+// Writers that change a versioned record outside updateVersion, such as a
+// delivery adding to a customer's inventory, set it too, so that an edit
+// based on the version before theirs conflicts instead of undoing them.
+var nextVersion = gorm.Expr("version + 1")
+
+// bumpVersion increments the version of the record of model's kind with id
+// for a change made to it elsewhere, such as to its associations, and reads
+// the new version into version
+func bumpVersion(tx *gorm.DB, model interface{}, id int64, version *int64) error {
+	if err := tx.Model(model).Where("id = ?", id).UpdateColumn("version", nextVersion).Error; err != nil {
+		return err
+	}
+	return tx.Model(model).Where("id = ?", id).Select("version").Scan(version).Error
+}
+
+// updateVersion runs update, which edits the record of model's kind with
+// id, and increments the record's version. Unless expected is 0 the edit
+// only applies to that version of the record. It returns the new version,
+// ErrVersionConflict when the record has another version and ErrNotFound
+// when there is none.
+func updateVersion(db *gorm.DB, model interface{}, id, expected int64, update func(tx *gorm.DB) *gorm.DB) (int64, error) {
+	var version int64
+	err := db.Transaction(func(tx *gorm.DB) error {
+		scoped := tx
+		if expected > 0 {
+			scoped = tx.Where("version = ?", expected)
+		}
+		result := update(scoped)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			var count int64
+			if err := tx.Model(model).Where("id = ?", id).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				return ErrVersionConflict
+			}
+			return ErrNotFound
+		}
+		return bumpVersion(tx, model, id, &version)
+	})
+	return version, err
+}
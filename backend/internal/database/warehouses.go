@@ -26,31 +26,81 @@ func GetWarehouse(db *gorm.DB, id int64) (*models.Warehouse, error) {
 	return w, nil
 }
 
+// CreateWarehouse creates a warehouse; its current stock is booked to the
+// stock ledger as an opening balance
 func CreateWarehouse(db *gorm.DB, w *models.Warehouse) error {
-	return db.Create(w).Error
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(w).Error; err != nil {
+			return err
+		}
+		if w.CurrentStock == 0 {
+			return nil
+		}
+		return tx.Create(&models.StockMovement{
+			WarehouseID: w.ID,
+			Kind:        models.StockReceipt,
+			Reason:      "opening_balance",
+			Quantity:    w.CurrentStock,
+			Balance:     w.CurrentStock,
+			OccurredAt:  w.CreatedAt,
+		}).Error
+	})
 }
 
+// UpdateWarehouse updates a warehouse. Its current stock only changes
+// through stock movements.
 func UpdateWarehouse(db *gorm.DB, w *models.Warehouse) error {
-	result := db.Model(w).Updates(models.Warehouse{
-		Name:             w.Name,
-		Address:          w.Address,
-		Latitude:         w.Latitude,
-		Longitude:        w.Longitude,
-		Capacity:         w.Capacity,
-		CurrentStock:     w.CurrentStock,
-		HoldingCost:      w.HoldingCost,
-		ReplenishmentQty: w.ReplenishmentQty,
+	version, err := updateVersion(db, &models.Warehouse{}, w.ID, w.Version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(w).Updates(models.Warehouse{
+			Name:             w.Name,
+			Address:          w.Address,
+			Latitude:         w.Latitude,
+			Longitude:        w.Longitude,
+			Capacity:         w.Capacity,
+			HoldingCost:      w.HoldingCost,
+			ReplenishmentQty: w.ReplenishmentQty,
+			MinStock:         w.MinStock,
+			ExternalRef:      w.ExternalRef,
+		})
 	})
-	if result.Error != nil {
-		return result.Error
-	}
-	if result.RowsAffected == 0 {
-		return ErrNotFound
+	if err != nil {
+		return err
 	}
+	w.Version = version
 	// Reload to get updated_at
 	return db.First(w, w.ID).Error
 }
 
+// UpdateWarehouseColumns writes the given columns of a warehouse, zero
+// values included. Like UpdateWarehouse it leaves current_stock alone.
+func UpdateWarehouseColumns(db *gorm.DB, w *models.Warehouse, columns []string) error {
+	version, err := updateVersion(db, &models.Warehouse{}, w.ID, w.Version, func(tx *gorm.DB) *gorm.DB {
+		return tx.Model(w).Select(columns).Omit("current_stock").Updates(w)
+	})
+	if err != nil {
+		return err
+	}
+	w.Version = version
+	return nil
+}
+
+// GetWarehousesByExternalRef retrieves the warehouses with any of the
+// external references, by reference
+func GetWarehousesByExternalRef(db *gorm.DB, refs []string) (map[string]*models.Warehouse, error) {
+	result := make(map[string]*models.Warehouse)
+	if len(refs) == 0 {
+		return result, nil
+	}
+	var warehouses []models.Warehouse
+	if err := db.Where("external_ref IN ?", refs).Find(&warehouses).Error; err != nil {
+		return nil, err
+	}
+	for i := range warehouses {
+		result[warehouses[i].ExternalRef] = &warehouses[i]
+	}
+	return result, nil
+}
+
 func DeleteWarehouse(db *gorm.DB, id int64) error {
 	result := db.Delete(&models.Warehouse{}, id)
 	if result.Error != nil {
@@ -0,0 +1,121 @@
+package database
+
+import (
+	"errors"
+	"slices"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+func ListWebhookSubscriptions(db *gorm.DB) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	err := db.Order("id").Find(&subs).Error
+	return subs, err
+}
+
+// ListWebhookSubscriptionsForEvent returns the active subscriptions to an
+// event
+func ListWebhookSubscriptionsForEvent(db *gorm.DB, event string) ([]models.WebhookSubscription, error) {
+	var active []models.WebhookSubscription
+	if err := db.Where("active = ?", true).Order("id").Find(&active).Error; err != nil {
+		return nil, err
+	}
+	var subs []models.WebhookSubscription
+	for _, s := range active {
+		if slices.Contains(s.Events, event) {
+			subs = append(subs, s)
+		}
+	}
+	return subs, nil
+}
+
+func GetWebhookSubscription(db *gorm.DB, id int64) (*models.WebhookSubscription, error) {
+	s := &models.WebhookSubscription{}
+	err := db.First(s, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+func CreateWebhookSubscription(db *gorm.DB, s *models.WebhookSubscription) error {
+	return db.Create(s).Error
+}
+
+func UpdateWebhookSubscription(db *gorm.DB, s *models.WebhookSubscription) error {
+	result := db.Model(s).Select("url", "description", "events", "secret", "active", "updated_at").Updates(s)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteWebhookSubscription soft-deletes a subscription; its deliveries
+// stay in the log and pending ones are cancelled when they come up
+func DeleteWebhookSubscription(db *gorm.DB, id int64) error {
+	result := db.Delete(&models.WebhookSubscription{}, id)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func CreateWebhookDelivery(db *gorm.DB, d *models.WebhookDelivery) error {
+	return db.Create(d).Error
+}
+
+func GetWebhookDelivery(db *gorm.DB, id int64) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	err := db.First(d, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	return d, nil
+}
+
+// SaveWebhookDelivery stores the outcome of a delivery attempt
+func SaveWebhookDelivery(db *gorm.DB, d *models.WebhookDelivery) error {
+	return db.Save(d).Error
+}
+
+// WebhookDeliveryFilter selects the deliveries of a subscription. Empty
+// fields match all deliveries.
+type WebhookDeliveryFilter struct {
+	SubscriptionID int64
+	Statuses       []string
+	Event          string
+}
+
+// ListWebhookDeliveries retrieves one page of the deliveries matching f,
+// newest first, and the number of matching deliveries
+func ListWebhookDeliveries(db *gorm.DB, f WebhookDeliveryFilter, offset, limit int) ([]models.WebhookDelivery, int64, error) {
+	query := db.Model(&models.WebhookDelivery{}).Where("subscription_id = ?", f.SubscriptionID)
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+	if f.Event != "" {
+		query = query.Where("event = ?", f.Event)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	var deliveries []models.WebhookDelivery
+	err := query.Order("created_at DESC").Order("id DESC").Offset(offset).Limit(limit).Find(&deliveries).Error
+	return deliveries, total, err
+}
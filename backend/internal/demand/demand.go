@@ -0,0 +1,250 @@
+// Package demand estimates customers' daily demand from their history: the
+// inventory they used between snapshots, given what was delivered and
+// adjusted in between, averaged per day over a rolling window.
+package demand
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// JobType is the job that estimates demand, periodically
+const JobType = "demand.estimate"
+
+// Scope is the payload of an estimate job. The zero Scope estimates all
+// customers.
+type Scope struct {
+	CustomerID int64 `json:"customer_id,omitempty"`
+}
+
+// Estimator estimates and stores customers' demand
+type Estimator struct {
+	db        *gorm.DB
+	window    int
+	alpha     float64
+	autoApply bool
+	clock     clock.Clock
+}
+
+// New returns an estimator over a window of days, smoothing with alpha
+func New(db *gorm.DB, window int, alpha float64) *Estimator {
+	return &Estimator{db: db, window: window, alpha: alpha, clock: clock.Real}
+}
+
+// SetClock replaces the clock RunJob reads the current time from
+func (e *Estimator) SetClock(c clock.Clock) {
+	e.clock = c
+}
+
+// SetAutoApply makes RunJob apply the smoothed estimates to the customers'
+// demand rates
+func (e *Estimator) SetAutoApply(apply bool) {
+	e.autoApply = apply
+}
+
+// RunJob estimates the demand of the customers in the payload's Scope as a
+// background job
+func (e *Estimator) RunJob(ctx context.Context, payload json.RawMessage) error {
+	var scope Scope
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &scope); err != nil {
+			return fmt.Errorf("decode scope: %w", err)
+		}
+	}
+	now := e.clock.Now()
+	estimates, err := e.Run(scope, now)
+	if err != nil {
+		return err
+	}
+	applied := 0
+	for i := range estimates {
+		if !e.autoApply || estimates[i].Smoothed == estimates[i].CurrentRate {
+			continue
+		}
+		err := database.ApplyDemandEstimate(e.db, &estimates[i], models.DemandExponentialSmoothing, nil, now)
+		switch {
+		case err == nil:
+			applied++
+		case !errors.Is(err, database.ErrNotFound):
+			return fmt.Errorf("apply estimate of customer %d: %w", estimates[i].CustomerID, err)
+		}
+	}
+	log.Printf("Demand estimation: %d customers estimated, %d rates applied", len(estimates), applied)
+	return nil
+}
+
+// Run estimates the demand of the customers in scope over the window up to
+// now and stores the estimates. Customers without history in the window
+// get none.
+func (e *Estimator) Run(scope Scope, now time.Time) ([]models.DemandEstimate, error) {
+	var customers []models.Customer
+	var customerID *int64
+	if scope.CustomerID != 0 {
+		customerID = &scope.CustomerID
+		customer, err := database.GetCustomer(e.db, scope.CustomerID)
+		if err != nil {
+			return nil, fmt.Errorf("get customer: %w", err)
+		}
+		customers = append(customers, *customer)
+	} else {
+		var err error
+		if customers, err = database.ListCustomers(e.db); err != nil {
+			return nil, fmt.Errorf("list customers: %w", err)
+		}
+	}
+
+	from := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -e.window)
+	snapshots, err := database.ListCustomerSnapshots(e.db, customerID, from, now)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+	deliveries, err := database.ListCustomerDeliveries(e.db, customerID, from, now)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	ids := make([]int64, len(snapshots))
+	for i, s := range snapshots {
+		ids[i] = s.ID
+	}
+	adjustments, err := database.GetAdjustmentDeltas(e.db, ids)
+	if err != nil {
+		return nil, fmt.Errorf("get adjustments: %w", err)
+	}
+
+	bySnapshot := make(map[int64][]models.InventorySnapshot)
+	for _, s := range snapshots {
+		bySnapshot[s.EntityID] = append(bySnapshot[s.EntityID], s)
+	}
+	byDelivery := make(map[int64][]database.CustomerDelivery)
+	for _, d := range deliveries {
+		byDelivery[d.CustomerID] = append(byDelivery[d.CustomerID], d)
+	}
+
+	var estimates []models.DemandEstimate
+	for _, c := range customers {
+		daily := Daily(bySnapshot[c.ID], byDelivery[c.ID], adjustments)
+		if len(daily) == 0 {
+			continue
+		}
+		estimate := models.DemandEstimate{
+			CustomerID:    c.ID,
+			WindowFrom:    from,
+			WindowTo:      now,
+			ObservedDays:  len(daily),
+			MovingAverage: round(MovingAverage(daily)),
+			Smoothed:      round(Smooth(daily, e.alpha)),
+			Alpha:         e.alpha,
+			CurrentRate:   c.DemandRate,
+			EstimatedAt:   now,
+		}
+		for _, d := range daily {
+			estimate.Consumed += d.Used
+		}
+		estimate.Consumed = round(estimate.Consumed)
+		estimates = append(estimates, estimate)
+	}
+	if err := database.CreateDemandEstimates(e.db, estimates); err != nil {
+		return nil, fmt.Errorf("store estimates: %w", err)
+	}
+	return estimates, nil
+}
+
+// Day is the inventory a customer used on a day. Covered is the part of the
+// day the history accounts for; Rate scales Used up to a whole day.
+type Day struct {
+	Date    time.Time
+	Used    float64
+	Covered float64
+}
+
+// Rate is the day's demand
+func (d Day) Rate() float64 {
+	return d.Used / d.Covered
+}
+
+// Daily spreads the inventory used between consecutive snapshots evenly
+// over the time between them and sums it per day, oldest day first. Used is
+// the level before, plus deliveries after it, plus the adjustment recorded
+// by the later snapshot, less the level after. Periods in which inventory
+// grew without explanation, e.g. an edit of the customer, are left out.
+// snapshots must be in the order they were taken.
+func Daily(snapshots []models.InventorySnapshot, deliveries []database.CustomerDelivery, adjustments map[int64]float64) []Day {
+	var days []Day
+	index := make(map[time.Time]int)
+	for i := 1; i < len(snapshots); i++ {
+		prev, cur := snapshots[i-1], snapshots[i]
+		start, end := prev.SnapshotTime, cur.SnapshotTime
+		if !end.After(start) {
+			continue
+		}
+		used := prev.InventoryLevel - cur.InventoryLevel + adjustments[cur.ID]
+		for _, d := range deliveries {
+			if d.DeliveredAt.After(start) && !d.DeliveredAt.After(end) {
+				used += d.Quantity
+			}
+		}
+		if used < 0 {
+			continue
+		}
+
+		span := end.Sub(start)
+		for t := start; t.Before(end); {
+			date := t.UTC().Truncate(24 * time.Hour)
+			next := date.Add(24 * time.Hour)
+			if next.After(end) {
+				next = end
+			}
+			part := float64(next.Sub(t)) / float64(span)
+			j, ok := index[date]
+			if !ok {
+				j = len(days)
+				index[date] = j
+				days = append(days, Day{Date: date})
+			}
+			days[j].Used += used * part
+			days[j].Covered += float64(next.Sub(t)) / float64(24*time.Hour)
+			t = next
+		}
+	}
+	return days
+}
+
+// MovingAverage is the mean daily demand
+func MovingAverage(days []Day) float64 {
+	if len(days) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, d := range days {
+		sum += d.Rate()
+	}
+	return sum / float64(len(days))
+}
+
+// Smooth is the exponentially smoothed daily demand: each day weighs alpha
+// against the smoothed demand of the days before it
+func Smooth(days []Day, alpha float64) float64 {
+	if len(days) == 0 {
+		return 0
+	}
+	smoothed := days[0].Rate()
+	for _, d := range days[1:] {
+		smoothed = alpha*d.Rate() + (1-alpha)*smoothed
+	}
+	return smoothed
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
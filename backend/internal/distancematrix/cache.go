@@ -0,0 +1,71 @@
+package distancematrix
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Cache memoizes matrices per coordinate set so repeated optimizations of
+// the same customers don't hit the (usually metered) provider again.
+type Cache struct {
+	provider Provider
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	matrix    *Matrix
+	expiresAt time.Time
+}
+
+func NewCache(provider Provider, ttl time.Duration) *Cache {
+	return &Cache{
+		provider: provider,
+		ttl:      ttl,
+		entries:  make(map[string]cacheEntry),
+	}
+}
+
+// Compute returns a cached matrix for the points or fetches a fresh one
+func (c *Cache) Compute(points []Point) (*Matrix, error) {
+	key := cacheKey(points)
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, ok := c.entries[key]
+	c.mu.Unlock()
+	if ok && now.Before(entry.expiresAt) {
+		return entry.matrix, nil
+	}
+
+	matrix, err := c.provider.Compute(points)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	for k, e := range c.entries {
+		if now.After(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cacheEntry{matrix: matrix, expiresAt: now.Add(c.ttl)}
+	c.mu.Unlock()
+
+	return matrix, nil
+}
+
+// cacheKey hashes the ordered coordinate list; order matters because the
+// matrix is indexed by position
+func cacheKey(points []Point) string {
+	h := sha256.New()
+	for _, p := range points {
+		fmt.Fprintf(h, "%.6f,%.6f;", p.Latitude, p.Longitude)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
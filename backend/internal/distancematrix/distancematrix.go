@@ -0,0 +1,183 @@
+package distancematrix
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Supported provider names for DISTANCE_PROVIDER
+const (
+	ProviderNone   = ""
+	ProviderOSRM   = "osrm"
+	ProviderGoogle = "google"
+	ProviderMapbox = "mapbox"
+)
+
+var ErrEmptyInput = errors.New("at least two points are required")
+
+// Point is a geographic coordinate
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Matrix holds pairwise road distances (km) and travel times (minutes).
+// Distances[i][j] is the distance from point i to point j.
+type Matrix struct {
+	Distances [][]float64
+	Durations [][]float64
+}
+
+// Provider computes a distance matrix for a set of points
+type Provider interface {
+	Compute(points []Point) (*Matrix, error)
+}
+
+// New builds a cached provider by name. It returns a nil provider when name
+// is empty so callers can fall back to straight-line distances.
+func New(name, baseURL, apiKey string, cacheTTL time.Duration) (Provider, error) {
+	var provider Provider
+	switch strings.ToLower(name) {
+	case ProviderNone:
+		return nil, nil
+	case ProviderOSRM:
+		provider = NewOSRMProvider(baseURL)
+	case ProviderGoogle:
+		if apiKey == "" {
+			return nil, fmt.Errorf("google distance matrix requires an API key")
+		}
+		provider = NewGoogleProvider(baseURL, apiKey)
+	case ProviderMapbox:
+		if apiKey == "" {
+			return nil, fmt.Errorf("mapbox distance matrix requires an access token")
+		}
+		provider = NewMapboxProvider(baseURL, apiKey)
+	default:
+		return nil, fmt.Errorf("unknown distance provider %q", name)
+	}
+	return NewCache(provider, cacheTTL), nil
+}
+
+func newHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: 60 * time.Second,
+	}
+}
+
+func newMatrix(n int) *Matrix {
+	return newBlock(n, n)
+}
+
+// newBlock is a matrix of rows origins by cols destinations
+func newBlock(rows, cols int) *Matrix {
+	m := &Matrix{
+		Distances: make([][]float64, rows),
+		Durations: make([][]float64, rows),
+	}
+	for i := 0; i < rows; i++ {
+		m.Distances[i] = make([]float64, cols)
+		m.Durations[i] = make([]float64, cols)
+	}
+	return m
+}
+
+// tiles computes the matrix of points in blocks of at most rows origins by
+// cols destinations, for providers that cap the size of a request, and
+// merges them
+func tiles(points []Point, rows, cols int, block func(origins, destinations []Point) (*Matrix, error)) (*Matrix, error) {
+	n := len(points)
+	m := newMatrix(n)
+	for i := 0; i < n; i += rows {
+		origins := points[i:min(i+rows, n)]
+		for j := 0; j < n; j += cols {
+			b, err := block(origins, points[j:min(j+cols, n)])
+			if err != nil {
+				return nil, err
+			}
+			for k := range origins {
+				copy(m.Distances[i+k][j:], b.Distances[k])
+				copy(m.Durations[i+k][j:], b.Durations[k])
+			}
+		}
+	}
+	return m, nil
+}
+
+// lonLatPath renders points as "lon,lat;lon,lat" used by OSRM and Mapbox
+func lonLatPath(points []Point) string {
+	parts := make([]string, len(points))
+	for i, p := range points {
+		parts[i] = fmt.Sprintf("%f,%f", p.Longitude, p.Latitude)
+	}
+	return strings.Join(parts, ";")
+}
+
+// tableResponse is the table format shared by OSRM and Mapbox (meters, seconds)
+type tableResponse struct {
+	Code      string       `json:"code"`
+	Message   string       `json:"message"`
+	Distances [][]*float64 `json:"distances"`
+	Durations [][]*float64 `json:"durations"`
+}
+
+// tableMatrix computes the matrix of points through an OSRM-style table
+// service that takes at most maxCoordinates coordinates a request: in one
+// request when they fit, else in blocks whose origins and destinations fit
+// together, picked out by the sources and destinations parameters. fetch
+// requests the table of coordinates with params appended to the query.
+func tableMatrix(points []Point, maxCoordinates int, fetch func(coordinates []Point, params string) (*tableResponse, error)) (*Matrix, error) {
+	if len(points) <= maxCoordinates {
+		t, err := fetch(points, "")
+		if err != nil {
+			return nil, err
+		}
+		return t.toMatrix(len(points), len(points))
+	}
+	size := maxCoordinates / 2
+	return tiles(points, size, size, func(origins, destinations []Point) (*Matrix, error) {
+		params := "&sources=" + indexList(0, len(origins)) + "&destinations=" + indexList(len(origins), len(destinations))
+		t, err := fetch(append(slices.Clone(origins), destinations...), params)
+		if err != nil {
+			return nil, err
+		}
+		return t.toMatrix(len(origins), len(destinations))
+	})
+}
+
+// indexList renders the n indexes from first as "3;4;5"
+func indexList(first, n int) string {
+	parts := make([]string, n)
+	for i := range parts {
+		parts[i] = strconv.Itoa(first + i)
+	}
+	return strings.Join(parts, ";")
+}
+
+func (t *tableResponse) toMatrix(rows, cols int) (*Matrix, error) {
+	if !strings.EqualFold(t.Code, "ok") {
+		return nil, fmt.Errorf("provider returned code %q: %s", t.Code, t.Message)
+	}
+	if len(t.Distances) != rows || len(t.Durations) != rows {
+		return nil, fmt.Errorf("provider returned %d rows, want %d", len(t.Distances), rows)
+	}
+
+	m := newBlock(rows, cols)
+	for i := 0; i < rows; i++ {
+		if len(t.Distances[i]) != cols || len(t.Durations[i]) != cols {
+			return nil, fmt.Errorf("provider returned malformed row %d", i)
+		}
+		for j := 0; j < cols; j++ {
+			if t.Distances[i][j] == nil || t.Durations[i][j] == nil {
+				return nil, fmt.Errorf("no route between points %d and %d", i, j)
+			}
+			m.Distances[i][j] = *t.Distances[i][j] / 1000
+			m.Durations[i][j] = *t.Durations[i][j] / 60
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,289 @@
+package distancematrix
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testPoints = []Point{
+	{Latitude: 40.7128, Longitude: -74.0060},
+	{Latitude: 40.7580, Longitude: -73.9855},
+}
+
+// TestOSRMProvider tests matrix decoding and unit conversion
+func TestOSRMProvider(t *testing.T) {
+	tests := []struct {
+		name           string
+		serverStatus   int
+		serverResponse string
+		wantErr        bool
+	}{
+		{
+			name:           "valid table",
+			serverStatus:   http.StatusOK,
+			serverResponse: `{"code":"Ok","distances":[[0,5200],[5400,0]],"durations":[[0,600],[660,0]]}`,
+			wantErr:        false,
+		},
+		{
+			name:           "unroutable pair",
+			serverStatus:   http.StatusOK,
+			serverResponse: `{"code":"Ok","distances":[[0,null],[5400,0]],"durations":[[0,null],[660,0]]}`,
+			wantErr:        true,
+		},
+		{
+			name:           "provider error code",
+			serverStatus:   http.StatusOK,
+			serverResponse: `{"code":"InvalidQuery","message":"bad coordinates"}`,
+			wantErr:        true,
+		},
+		{
+			name:           "server error",
+			serverStatus:   http.StatusInternalServerError,
+			serverResponse: ``,
+			wantErr:        true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if !strings.HasPrefix(r.URL.Path, "/table/v1/driving/") {
+					t.Errorf("unexpected path %s", r.URL.Path)
+				}
+				w.WriteHeader(tt.serverStatus)
+				w.Write([]byte(tt.serverResponse))
+			}))
+			defer server.Close()
+
+			m, err := NewOSRMProvider(server.URL).Compute(testPoints)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if m.Distances[0][1] != 5.2 {
+				t.Errorf("Distances[0][1] = %v, want 5.2", m.Distances[0][1])
+			}
+			if m.Durations[1][0] != 11 {
+				t.Errorf("Durations[1][0] = %v, want 11", m.Durations[1][0])
+			}
+		})
+	}
+}
+
+// TestGoogleProvider tests decoding of the Google response format
+func TestGoogleProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "test-key" {
+			t.Errorf("API key not sent")
+		}
+		w.Write([]byte(`{"status":"OK","rows":[
+			{"elements":[{"status":"OK","distance":{"value":0},"duration":{"value":0}},{"status":"OK","distance":{"value":3000},"duration":{"value":300}}]},
+			{"elements":[{"status":"OK","distance":{"value":3100},"duration":{"value":360}},{"status":"OK","distance":{"value":0},"duration":{"value":0}}]}
+		]}`))
+	}))
+	defer server.Close()
+
+	m, err := NewGoogleProvider(server.URL, "test-key").Compute(testPoints)
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	if m.Distances[1][0] != 3.1 || m.Durations[0][1] != 5 {
+		t.Errorf("unexpected matrix %+v", m)
+	}
+}
+
+// linePoints are n points along a parallel, point i at longitude i, so that
+// fake providers can tell them apart
+func linePoints(n int) []Point {
+	points := make([]Point, n)
+	for i := range points {
+		points[i] = Point{Latitude: 40, Longitude: float64(i)}
+	}
+	return points
+}
+
+// fakeMeters is the fake distance from the point at longitude a to that at
+// b, and the fake duration in seconds
+func fakeMeters(a, b float64) float64 {
+	return a*100 + b
+}
+
+// checkLineMatrix checks a matrix of linePoints against fakeMeters
+func checkLineMatrix(t *testing.T, m *Matrix, n int) {
+	t.Helper()
+	if len(m.Distances) != n {
+		t.Fatalf("matrix has %d rows, want %d", len(m.Distances), n)
+	}
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			want := fakeMeters(float64(i), float64(j))
+			if i != j && (m.Distances[i][j] != want/1000 || m.Durations[i][j] != want/60) {
+				t.Fatalf("[%d][%d] = %v km, %v min, want %v m", i, j, m.Distances[i][j], m.Durations[i][j], want)
+			}
+		}
+	}
+}
+
+// TestTiledTables tests that OSRM and Mapbox matrices of more points than a
+// request takes are fetched in blocks within the cap and merged
+func TestTiledTables(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		path := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		var lons []float64
+		for _, coord := range strings.Split(path, ";") {
+			lon, _ := strconv.ParseFloat(strings.Split(coord, ",")[0], 64)
+			lons = append(lons, lon)
+		}
+		if len(lons) > mapboxMaxCoordinates {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"code":"InvalidInput","message":"Too many coordinates"}`))
+			return
+		}
+		// the index lists are separated by semicolons, which url.Query drops
+		params := make(map[string]string)
+		for _, pair := range strings.Split(r.URL.RawQuery, "&") {
+			key, value, _ := strings.Cut(pair, "=")
+			params[key] = value
+		}
+		indexes := func(param string) []int {
+			var list []int
+			if params[param] == "" {
+				for i := range lons {
+					list = append(list, i)
+				}
+				return list
+			}
+			for _, s := range strings.Split(params[param], ";") {
+				i, _ := strconv.Atoi(s)
+				list = append(list, i)
+			}
+			return list
+		}
+		var table tableResponse
+		table.Code = "Ok"
+		for _, i := range indexes("sources") {
+			var row []*float64
+			for _, j := range indexes("destinations") {
+				v := fakeMeters(lons[i], lons[j])
+				row = append(row, &v)
+			}
+			table.Distances = append(table.Distances, row)
+			table.Durations = append(table.Durations, row)
+		}
+		json.NewEncoder(w).Encode(table)
+	}))
+	defer server.Close()
+
+	m, err := NewMapboxProvider(server.URL, "token").Compute(linePoints(30))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	checkLineMatrix(t, m, 30)
+	// blocks of 12 by 12
+	if requests != 9 {
+		t.Errorf("requests = %d, want 9", requests)
+	}
+
+	requests = 0
+	if _, err := NewMapboxProvider(server.URL, "token").Compute(linePoints(20)); err != nil || requests != 1 {
+		t.Errorf("20 points took %d requests, %v, want 1", requests, err)
+	}
+}
+
+// TestGoogleBlocks tests that Google matrices are fetched in blocks within
+// its caps on origins, destinations and elements and merged
+func TestGoogleBlocks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		parse := func(param string) []float64 {
+			var lons []float64
+			for _, coord := range strings.Split(r.URL.Query().Get(param), "|") {
+				lon, _ := strconv.ParseFloat(strings.Split(coord, ",")[1], 64)
+				lons = append(lons, lon)
+			}
+			return lons
+		}
+		origins, destinations := parse("origins"), parse("destinations")
+		if len(origins) > 25 || len(destinations) > 25 || len(origins)*len(destinations) > 100 {
+			w.Write([]byte(`{"status":"MAX_ELEMENTS_EXCEEDED","rows":[]}`))
+			return
+		}
+		type value struct {
+			Value float64 `json:"value"`
+		}
+		type element struct {
+			Status   string `json:"status"`
+			Distance value  `json:"distance"`
+			Duration value  `json:"duration"`
+		}
+		var rows []map[string][]element
+		for _, a := range origins {
+			var elements []element
+			for _, b := range destinations {
+				v := value{fakeMeters(a, b)}
+				elements = append(elements, element{Status: "OK", Distance: v, Duration: v})
+			}
+			rows = append(rows, map[string][]element{"elements": elements})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "OK", "rows": rows})
+	}))
+	defer server.Close()
+
+	m, err := NewGoogleProvider(server.URL, "test-key").Compute(linePoints(30))
+	if err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	checkLineMatrix(t, m, 30)
+}
+
+// TestCache tests that repeated coordinate sets are served from cache
+func TestCache(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Write([]byte(`{"code":"Ok","distances":[[0,1000],[1000,0]],"durations":[[0,60],[60,0]]}`))
+	}))
+	defer server.Close()
+
+	cache := NewCache(NewMapboxProvider(server.URL, "token"), time.Hour)
+	for i := 0; i < 3; i++ {
+		if _, err := cache.Compute(testPoints); err != nil {
+			t.Fatalf("Compute() error = %v", err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("provider called %d times, want 1", calls)
+	}
+
+	reversed := []Point{testPoints[1], testPoints[0]}
+	if _, err := cache.Compute(reversed); err != nil {
+		t.Fatalf("Compute() error = %v", err)
+	}
+	if calls != 2 {
+		t.Errorf("provider called %d times after new point order, want 2", calls)
+	}
+}
+
+// TestNew tests provider selection by name
+func TestNew(t *testing.T) {
+	if p, err := New("", "", "", time.Hour); p != nil || err != nil {
+		t.Errorf("New(\"\") = %v, %v, want nil, nil", p, err)
+	}
+	if _, err := New("google", "", "", time.Hour); err == nil {
+		t.Error("New(google) without key should fail")
+	}
+	if _, err := New("here", "", "", time.Hour); err == nil {
+		t.Error("New(here) should fail for unknown provider")
+	}
+	if p, err := New("OSRM", "", "", time.Hour); p == nil || err != nil {
+		t.Errorf("New(OSRM) = %v, %v", p, err)
+	}
+}
@@ -0,0 +1,118 @@
+package distancematrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultGoogleURL = "https://maps.googleapis.com"
+
+// googleBlockSize is the most origins and destinations of a request, 10 by
+// 10 being the largest square within Google's 100 elements
+const googleBlockSize = 10
+
+// GoogleProvider queries the Google Distance Matrix API
+type GoogleProvider struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+func NewGoogleProvider(baseURL, apiKey string) *GoogleProvider {
+	if baseURL == "" {
+		baseURL = defaultGoogleURL
+	}
+	return &GoogleProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     apiKey,
+		httpClient: newHTTPClient(),
+	}
+}
+
+type googleResponse struct {
+	Status       string `json:"status"`
+	ErrorMessage string `json:"error_message"`
+	Rows         []struct {
+		Elements []struct {
+			Status   string `json:"status"`
+			Distance struct {
+				Value float64 `json:"value"`
+			} `json:"distance"`
+			Duration struct {
+				Value float64 `json:"value"`
+			} `json:"duration"`
+		} `json:"elements"`
+	} `json:"rows"`
+}
+
+// Compute fetches the driving distance matrix from Google in blocks of
+// googleBlockSize origins by googleBlockSize destinations, which keeps each
+// request within Google's 25 origins, 25 destinations and 100 elements
+func (p *GoogleProvider) Compute(points []Point) (*Matrix, error) {
+	if len(points) < 2 {
+		return nil, ErrEmptyInput
+	}
+	return tiles(points, googleBlockSize, googleBlockSize, p.block)
+}
+
+// block fetches the matrix of origins by destinations
+func (p *GoogleProvider) block(origins, destinations []Point) (*Matrix, error) {
+	query := url.Values{}
+	query.Set("origins", latLngList(origins))
+	query.Set("destinations", latLngList(destinations))
+	query.Set("mode", "driving")
+	query.Set("key", p.apiKey)
+
+	resp, err := p.httpClient.Get(p.baseURL + "/maps/api/distancematrix/json?" + query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Google: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Google returned status %d", resp.StatusCode)
+	}
+
+	var result googleResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Google response: %w", err)
+	}
+
+	if result.Status != "OK" {
+		return nil, fmt.Errorf("Google returned status %q: %s", result.Status, result.ErrorMessage)
+	}
+
+	if len(result.Rows) != len(origins) {
+		return nil, fmt.Errorf("Google returned %d rows, want %d", len(result.Rows), len(origins))
+	}
+
+	m := newBlock(len(origins), len(destinations))
+	for i, row := range result.Rows {
+		if len(row.Elements) != len(destinations) {
+			return nil, fmt.Errorf("Google returned malformed row %d", i)
+		}
+		for j, el := range row.Elements {
+			if origins[i] == destinations[j] {
+				continue
+			}
+			if el.Status != "OK" {
+				return nil, fmt.Errorf("no route between points %v and %v: %s", origins[i], destinations[j], el.Status)
+			}
+			m.Distances[i][j] = el.Distance.Value / 1000
+			m.Durations[i][j] = el.Duration.Value / 60
+		}
+	}
+	return m, nil
+}
+
+// latLngList renders points as "lat,lng|lat,lng"
+func latLngList(points []Point) string {
+	coords := make([]string, len(points))
+	for i, pt := range points {
+		coords[i] = fmt.Sprintf("%f,%f", pt.Latitude, pt.Longitude)
+	}
+	return strings.Join(coords, "|")
+}
@@ -0,0 +1,63 @@
+package distancematrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const defaultMapboxURL = "https://api.mapbox.com"
+
+// mapboxMaxCoordinates is the most coordinates of a Matrix API request for
+// the driving profile
+const mapboxMaxCoordinates = 25
+
+// MapboxProvider queries the Mapbox Matrix API
+type MapboxProvider struct {
+	baseURL     string
+	accessToken string
+	httpClient  *http.Client
+}
+
+func NewMapboxProvider(baseURL, accessToken string) *MapboxProvider {
+	if baseURL == "" {
+		baseURL = defaultMapboxURL
+	}
+	return &MapboxProvider{
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		accessToken: accessToken,
+		httpClient:  newHTTPClient(),
+	}
+}
+
+// Compute fetches the driving distance matrix from Mapbox, in blocks when
+// there are more points than a matrix request takes
+func (p *MapboxProvider) Compute(points []Point) (*Matrix, error) {
+	if len(points) < 2 {
+		return nil, ErrEmptyInput
+	}
+	return tableMatrix(points, mapboxMaxCoordinates, p.table)
+}
+
+// table requests the matrix of coordinates
+func (p *MapboxProvider) table(coordinates []Point, params string) (*tableResponse, error) {
+	endpoint := fmt.Sprintf("%s/directions-matrix/v1/mapbox/driving/%s?annotations=distance,duration&access_token=%s%s",
+		p.baseURL, lonLatPath(coordinates), url.QueryEscape(p.accessToken), params)
+	resp, err := p.httpClient.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call Mapbox: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Mapbox returned status %d", resp.StatusCode)
+	}
+
+	var result tableResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Mapbox response: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,59 @@
+package distancematrix
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const defaultOSRMURL = "https://router.project-osrm.org"
+
+// osrmMaxCoordinates is the most coordinates of a table request, osrm-routed's
+// default --max-table-size
+const osrmMaxCoordinates = 100
+
+// OSRMProvider queries the OSRM table service
+type OSRMProvider struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+func NewOSRMProvider(baseURL string) *OSRMProvider {
+	if baseURL == "" {
+		baseURL = defaultOSRMURL
+	}
+	return &OSRMProvider{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: newHTTPClient(),
+	}
+}
+
+// Compute fetches the driving distance matrix from OSRM, in blocks when
+// there are more points than a table request takes
+func (p *OSRMProvider) Compute(points []Point) (*Matrix, error) {
+	if len(points) < 2 {
+		return nil, ErrEmptyInput
+	}
+	return tableMatrix(points, osrmMaxCoordinates, p.table)
+}
+
+// table requests the table of coordinates
+func (p *OSRMProvider) table(coordinates []Point, params string) (*tableResponse, error) {
+	url := fmt.Sprintf("%s/table/v1/driving/%s?annotations=distance,duration%s", p.baseURL, lonLatPath(coordinates), params)
+	resp, err := p.httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to call OSRM: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("OSRM returned status %d", resp.StatusCode)
+	}
+
+	var result tableResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode OSRM response: %w", err)
+	}
+	return &result, nil
+}
@@ -0,0 +1,244 @@
+// Package doctor runs deep checks of the API's dependencies for ops: the
+// database schema, the optimizer service, artifact storage, the clock and
+// the webhook endpoints, reporting each as pass, warn or fail.
+package doctor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/storage"
+
+	"gorm.io/gorm"
+)
+
+// Check statuses, from best to worst
+const (
+	Pass = "pass"
+	Warn = "warn"
+	Fail = "fail"
+)
+
+// Clock skew against the database beyond which the clock check warns or
+// fails. Tokens, signed URLs and planned times are all compared with it.
+const (
+	SkewWarn = 5 * time.Second
+	SkewFail = time.Minute
+)
+
+// webhookTimeout bounds each webhook reachability probe
+const webhookTimeout = 5 * time.Second
+
+// Webhook is an outgoing endpoint the API delivers to
+type Webhook struct {
+	Name string
+	URL  string
+}
+
+// Deps are the dependencies the doctor checks. Optimizer and Storage may
+// be nil when they are not configured.
+type Deps struct {
+	DB        *gorm.DB
+	Optimizer *optimizer.Client
+	Storage   storage.Storage
+	Clock     clock.Clock
+	Webhooks  []Webhook
+	// HTTPClient probes the webhooks, http.DefaultClient with a timeout if nil
+	HTTPClient *http.Client
+}
+
+// Run runs all checks in order and returns their report
+func Run(ctx context.Context, deps Deps) *models.DoctorReport {
+	if deps.Clock == nil {
+		deps.Clock = clock.Real
+	}
+	if deps.HTTPClient == nil {
+		deps.HTTPClient = &http.Client{Timeout: webhookTimeout}
+	}
+
+	report := &models.DoctorReport{Status: Pass, CheckedAt: deps.Clock.Now()}
+	run := func(name string, check func() (string, string)) {
+		start := time.Now()
+		status, detail := check()
+		report.Checks = append(report.Checks, models.DoctorCheck{
+			Name:       name,
+			Status:     status,
+			Detail:     detail,
+			DurationMs: time.Since(start).Milliseconds(),
+		})
+		if rank(status) > rank(report.Status) {
+			report.Status = status
+		}
+	}
+
+	run("database", func() (string, string) { return checkDatabase(deps.DB) })
+	run("migrations", func() (string, string) { return checkMigrations(deps.DB) })
+	run("indexes", func() (string, string) { return checkIndexes(deps.DB) })
+	run("optimizer", func() (string, string) { return checkOptimizer(deps.Optimizer) })
+	run("storage", func() (string, string) { return checkStorage(ctx, deps.Storage, deps.Clock) })
+	run("clock_skew", func() (string, string) { return checkClock(deps.DB, deps.Clock) })
+	for _, webhook := range deps.Webhooks {
+		run("webhook:"+webhook.Name, func() (string, string) { return checkWebhook(ctx, deps.HTTPClient, webhook.URL) })
+	}
+	return report
+}
+
+func rank(status string) int {
+	switch status {
+	case Fail:
+		return 2
+	case Warn:
+		return 1
+	}
+	return 0
+}
+
+func checkDatabase(db *gorm.DB) (string, string) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return Fail, err.Error()
+	}
+	if err := sqlDB.Ping(); err != nil {
+		return Fail, "database unreachable: " + err.Error()
+	}
+	return Pass, "connected to " + db.Dialector.Name()
+}
+
+func checkMigrations(db *gorm.DB) (string, string) {
+	missing, err := database.MissingColumns(db)
+	if err != nil {
+		return Fail, "failed to inspect the schema: " + err.Error()
+	}
+	if len(missing) > 0 {
+		return Fail, "not migrated: " + strings.Join(missing, ", ")
+	}
+	return Pass, "all tables and columns present"
+}
+
+func checkIndexes(db *gorm.DB) (string, string) {
+	missing, err := database.MissingIndexes(db)
+	if err != nil {
+		return Fail, "failed to inspect the schema: " + err.Error()
+	}
+	if len(missing) > 0 {
+		return Fail, "missing indexes: " + strings.Join(missing, ", ")
+	}
+	return Pass, "all indexes present"
+}
+
+func checkOptimizer(client *optimizer.Client) (string, string) {
+	if client == nil {
+		return Fail, "optimizer client not configured"
+	}
+	version, err := client.Version()
+	if err != nil {
+		return Fail, err.Error()
+	}
+	if version == "" {
+		return Warn, fmt.Sprintf("reachable over %s but reports no version; expected %d.x", client.Protocol(), optimizer.CompatibleMajor)
+	}
+	if !optimizer.Compatible(version) {
+		return Fail, fmt.Sprintf("version %s is incompatible; expected %d.x", version, optimizer.CompatibleMajor)
+	}
+	return Pass, fmt.Sprintf("version %s over %s", version, client.Protocol())
+}
+
+// checkStorage writes, reads back and deletes a probe object
+func checkStorage(ctx context.Context, store storage.Storage, c clock.Clock) (string, string) {
+	if store == nil {
+		return Warn, "not configured; exports, proofs of delivery and backups are unavailable"
+	}
+	key := fmt.Sprintf("doctor/probe-%d", c.Now().UnixNano())
+	body := []byte("doctor probe")
+	if err := store.Put(ctx, key, bytes.NewReader(body), int64(len(body)), "text/plain"); err != nil {
+		return Fail, "not writable: " + err.Error()
+	}
+
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		store.Delete(ctx, key)
+		return Fail, "written probe not readable: " + err.Error()
+	}
+	got, err := io.ReadAll(r)
+	r.Close()
+	if err != nil || !bytes.Equal(got, body) {
+		store.Delete(ctx, key)
+		return Fail, "written probe read back differently"
+	}
+	if err := store.Delete(ctx, key); err != nil {
+		return Warn, "writable but the probe could not be deleted: " + err.Error()
+	}
+	return Pass, "writable"
+}
+
+// checkClock compares the clock with the database server's, allowing for
+// the query's round trip
+func checkClock(db *gorm.DB, c clock.Clock) (string, string) {
+	before := c.Now()
+	var value interface{}
+	if err := db.Raw("SELECT CURRENT_TIMESTAMP").Row().Scan(&value); err != nil {
+		return Fail, "failed to read the database time: " + err.Error()
+	}
+	after := c.Now()
+	dbTime, err := parseDBTime(value)
+	if err != nil {
+		return Fail, err.Error()
+	}
+
+	skew := before.Add(after.Sub(before) / 2).Sub(dbTime)
+	if skew < 0 {
+		skew = -skew
+	}
+	detail := fmt.Sprintf("%s from the database clock", skew.Round(time.Millisecond))
+	switch {
+	case skew > SkewFail:
+		return Fail, detail
+	case skew > SkewWarn:
+		return Warn, detail
+	}
+	return Pass, detail
+}
+
+// parseDBTime reads CURRENT_TIMESTAMP, which SQLite returns as UTC text
+func parseDBTime(value interface{}) (time.Time, error) {
+	switch v := value.(type) {
+	case time.Time:
+		return v, nil
+	case []byte:
+		return parseDBTime(string(v))
+	case string:
+		for _, layout := range []string{"2006-01-02 15:04:05", time.RFC3339Nano} {
+			if t, err := time.Parse(layout, v); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized database time %v", value)
+}
+
+// checkWebhook probes that a webhook's host answers. Any response below
+// 500 counts, since the endpoints only accept signed POSTs.
+func checkWebhook(ctx context.Context, client *http.Client, url string) (string, string) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return Fail, "invalid URL: " + err.Error()
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Fail, "unreachable: " + err.Error()
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return Warn, fmt.Sprintf("reachable but answered %d", resp.StatusCode)
+	}
+	return Pass, fmt.Sprintf("reachable (%d)", resp.StatusCode)
+}
@@ -0,0 +1,110 @@
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/storage"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// statuses returns the status of each check by name
+func statuses(report *models.DoctorReport) map[string]string {
+	byName := make(map[string]string)
+	for _, check := range report.Checks {
+		byName[check.Name] = check.Status
+	}
+	return byName
+}
+
+// TestRun tests a healthy setup passes and a missing migration, a missing
+// index and an unreachable webhook fail
+func TestRun(t *testing.T) {
+	db := testkit.DB(t)
+	store, err := storage.NewLocal(t.TempDir(), "", []byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}))
+	defer webhook.Close()
+
+	deps := Deps{
+		DB:        db,
+		Optimizer: testkit.NewOptimizer(t).Client(),
+		Storage:   store,
+		Clock:     clock.Real,
+		Webhooks:  []Webhook{{Name: "security", URL: webhook.URL}},
+	}
+	report := Run(context.Background(), deps)
+	if report.Status != Pass {
+		t.Fatalf("report = %+v, want all checks passing", report)
+	}
+	want := []string{"database", "migrations", "indexes", "optimizer", "storage", "clock_skew", "webhook:security"}
+	for _, name := range want {
+		if statuses(report)[name] != Pass {
+			t.Errorf("check %s = %q, want pass", name, statuses(report)[name])
+		}
+	}
+	if objects, _ := store.List(context.Background(), "doctor/"); len(objects) != 0 {
+		t.Errorf("storage probe left %d objects behind", len(objects))
+	}
+
+	deps.Storage = nil
+	if report := Run(context.Background(), deps); report.Status != Warn || statuses(report)["storage"] != Warn {
+		t.Errorf("without storage status = %s, want warn", report.Status)
+	}
+
+	if err := db.Migrator().DropTable(&models.RouteMessageRead{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Migrator().DropIndex(&models.RosterEntry{}, "idx_roster_driver_date"); err != nil {
+		t.Fatal(err)
+	}
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	deps.Webhooks = []Webhook{{Name: "push_gateway", URL: dead.URL}}
+
+	report = Run(context.Background(), deps)
+	if report.Status != Fail {
+		t.Errorf("status = %s, want fail", report.Status)
+	}
+	for _, check := range report.Checks {
+		switch check.Name {
+		case "migrations":
+			if check.Status != Fail || !strings.Contains(check.Detail, "route_message_reads") {
+				t.Errorf("migrations = %+v, want the dropped table", check)
+			}
+		case "indexes":
+			if check.Status != Fail || !strings.Contains(check.Detail, "driver_rosters.idx_roster_driver_date") {
+				t.Errorf("indexes = %+v, want the dropped index", check)
+			}
+		case "webhook:push_gateway":
+			if check.Status != Fail {
+				t.Errorf("unreachable webhook = %+v, want fail", check)
+			}
+		}
+	}
+}
+
+// TestOptimizerVersion tests the optimizer's version must have the
+// client's major version
+func TestOptimizerVersion(t *testing.T) {
+	if status, detail := checkOptimizer(testkit.NewOptimizer(t).Client()); status != Pass || !strings.Contains(detail, "1.0.0") {
+		t.Errorf("checkOptimizer() = %s, %q, want version 1.0.0 passing", status, detail)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"status":"healthy","version":"2.1.0"}`))
+	}))
+	defer server.Close()
+	if status, detail := checkOptimizer(optimizer.NewClient(server.URL)); status != Fail {
+		t.Errorf("checkOptimizer() of 2.1.0 = %s, %q, want fail", status, detail)
+	}
+}
@@ -0,0 +1,91 @@
+// Package eta recomputes the expected arrival at the stops left on a route
+// from the vehicle's current position. Legs are driven for the travel times
+// of the distance provider when one is configured, and otherwise over the
+// straight-line distance at the vehicle's average speed.
+package eta
+
+import (
+	"log"
+	"time"
+
+	"LogiTrackPro/backend/internal/distancematrix"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// Sources of the leg travel times
+const (
+	SourceRoad         = "road"
+	SourceStraightLine = "straight_line"
+)
+
+// Stop is a stop still to be visited
+type Stop struct {
+	ID             int64 // passed through to its Arrival
+	Point          distancematrix.Point
+	ServiceMinutes int // time spent at the stop before driving on
+}
+
+// Arrival is the expected arrival at a stop
+type Arrival struct {
+	ID         int64
+	ETA        time.Time
+	DistanceKm float64 // driven from the position to get there
+}
+
+// Estimate returns the arrival at each of stops, visited in order, leaving
+// from at the time at. speed is the vehicle's average speed in km/h, and
+// the default when 0. Travel times come from provider, which may be nil;
+// when it fails the straight-line estimate is used and logged. The source
+// of the travel times is returned with the arrivals.
+func Estimate(provider distancematrix.Provider, from distancematrix.Point, at time.Time, speed float64, stops []Stop) ([]Arrival, string) {
+	arrivals := make([]Arrival, 0, len(stops))
+	if len(stops) == 0 {
+		return arrivals, SourceStraightLine
+	}
+	if speed <= 0 {
+		speed = optimizer.DefaultAverageSpeed
+	}
+
+	points := make([]distancematrix.Point, 0, len(stops)+1)
+	points = append(points, from)
+	for _, s := range stops {
+		points = append(points, s.Point)
+	}
+	legs, source := legTimes(provider, points, speed)
+
+	var km float64
+	for i, s := range stops {
+		at = at.Add(time.Duration(legs[i].minutes * float64(time.Minute)))
+		km += legs[i].km
+		arrivals = append(arrivals, Arrival{ID: s.ID, ETA: at, DistanceKm: km})
+		at = at.Add(time.Duration(s.ServiceMinutes) * time.Minute)
+	}
+	return arrivals, source
+}
+
+type leg struct {
+	km      float64
+	minutes float64
+}
+
+// legTimes returns the distance and travel time of the legs between
+// consecutive points, and their source
+func legTimes(provider distancematrix.Provider, points []distancematrix.Point, speed float64) ([]leg, string) {
+	legs := make([]leg, len(points)-1)
+	if provider != nil {
+		matrix, err := provider.Compute(points)
+		if err == nil {
+			for i := range legs {
+				legs[i] = leg{km: matrix.Distances[i][i+1], minutes: matrix.Durations[i][i+1]}
+			}
+			return legs, SourceRoad
+		}
+		log.Printf("WARNING: distance matrix unavailable, using straight-line ETAs: %v", err)
+	}
+	for i := range legs {
+		a, b := points[i], points[i+1]
+		km := optimizer.HaversineKm(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+		legs[i] = leg{km: km, minutes: km / speed * 60}
+	}
+	return legs, SourceStraightLine
+}
@@ -0,0 +1,51 @@
+package eta
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/distancematrix"
+)
+
+type stubProvider struct {
+	matrix *distancematrix.Matrix
+	err    error
+}
+
+func (p stubProvider) Compute([]distancematrix.Point) (*distancematrix.Matrix, error) {
+	return p.matrix, p.err
+}
+
+// TestEstimate tests that arrivals add up leg travel times and service
+// times, by road or straight-line when the provider fails
+func TestEstimate(t *testing.T) {
+	start := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	stops := []Stop{
+		{ID: 1, Point: distancematrix.Point{Latitude: 0.1}, ServiceMinutes: 15},
+		{ID: 2, Point: distancematrix.Point{Latitude: 0.2}, ServiceMinutes: 15},
+	}
+	road := stubProvider{matrix: &distancematrix.Matrix{
+		Distances: [][]float64{{0, 12, 0}, {0, 0, 14}, {0, 0, 0}},
+		Durations: [][]float64{{0, 20, 0}, {0, 0, 25}, {0, 0, 0}},
+	}}
+
+	arrivals, source := Estimate(road, distancematrix.Point{}, start, 60, stops)
+	if source != SourceRoad || len(arrivals) != 2 ||
+		!arrivals[0].ETA.Equal(start.Add(20*time.Minute)) || arrivals[0].DistanceKm != 12 ||
+		!arrivals[1].ETA.Equal(start.Add(60*time.Minute)) || arrivals[1].DistanceKm != 26 {
+		t.Errorf("road arrivals = %+v (%s), want 08:20 and 09:00", arrivals, source)
+	}
+
+	// 0.1 degrees of latitude is about 11.1 km, 11 minutes at 60 km/h
+	arrivals, source = Estimate(stubProvider{err: errors.New("down")}, distancematrix.Point{}, start, 60, stops)
+	if source != SourceStraightLine || len(arrivals) != 2 ||
+		arrivals[0].ETA.Sub(start).Round(time.Minute) != 11*time.Minute ||
+		arrivals[1].ETA.Sub(start).Round(time.Minute) != 37*time.Minute {
+		t.Errorf("straight-line arrivals = %+v (%s), want about 08:11 and 08:37", arrivals, source)
+	}
+
+	if arrivals, _ := Estimate(nil, distancematrix.Point{}, start, 0, nil); len(arrivals) != 0 {
+		t.Errorf("arrivals without stops = %+v", arrivals)
+	}
+}
@@ -0,0 +1,213 @@
+// Package export writes tabular reports as CSV or Excel workbooks. Rows are
+// written straight to the output as they are produced, so large reports are
+// never held in memory.
+package export
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Formats
+const (
+	CSV  = "csv"
+	XLSX = "xlsx"
+)
+
+// Writer writes a report made of sheets. Sheet starts a sheet with a header
+// row; Row appends to the current sheet. Close must be called to complete
+// the output.
+type Writer interface {
+	Sheet(name string, header ...string) error
+	Row(values ...interface{}) error
+	Close() error
+}
+
+// New returns a writer for format
+func New(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case CSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	case XLSX:
+		return &xlsxWriter{zip: zip.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown export format %q (use csv or xlsx)", format)
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	if format == XLSX {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// formatValue renders a cell for CSV output
+func formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// csvWriter writes the sheets one after another, each preceded by its name
+// and separated by an empty line
+type csvWriter struct {
+	w      *csv.Writer
+	sheets int
+}
+
+func (w *csvWriter) Sheet(name string, header ...string) error {
+	if w.sheets > 0 {
+		if err := w.w.Write([]string{""}); err != nil {
+			return err
+		}
+	}
+	w.sheets++
+	if err := w.w.Write([]string{name}); err != nil {
+		return err
+	}
+	return w.w.Write(header)
+}
+
+func (w *csvWriter) Row(values ...interface{}) error {
+	record := make([]string, len(values))
+	for i, v := range values {
+		record[i] = formatValue(v)
+	}
+	return w.w.Write(record)
+}
+
+func (w *csvWriter) Close() error {
+	w.w.Flush()
+	return w.w.Error()
+}
+
+// xlsxWriter writes a minimal SpreadsheetML package. Every sheet is a zip
+// entry written as rows arrive; the workbook parts listing the sheets are
+// added on Close. Strings are stored inline, so no shared string table has
+// to be built up front.
+type xlsxWriter struct {
+	zip    *zip.Writer
+	sheet  *bufio.Writer
+	sheets []string
+}
+
+const (
+	xlsxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+	xlsxMainNS = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	xlsxRelNS  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	xlsxPkgNS  = "http://schemas.openxmlformats.org/package/2006/relationships"
+)
+
+func (w *xlsxWriter) Sheet(name string, header ...string) error {
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+	w.sheets = append(w.sheets, name)
+	entry, err := w.zip.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", len(w.sheets)))
+	if err != nil {
+		return err
+	}
+	w.sheet = bufio.NewWriter(entry)
+	fmt.Fprintf(w.sheet, `%s<worksheet xmlns="%s"><sheetData>`, xlsxHeader, xlsxMainNS)
+
+	values := make([]interface{}, len(header))
+	for i, h := range header {
+		values[i] = h
+	}
+	return w.Row(values...)
+}
+
+func (w *xlsxWriter) Row(values ...interface{}) error {
+	if w.sheet == nil {
+		return errors.New("export: row written before the first sheet")
+	}
+	w.sheet.WriteString("<row>")
+	for _, v := range values {
+		switch v := v.(type) {
+		case nil:
+			w.sheet.WriteString("<c/>")
+		case int, int64, float64:
+			fmt.Fprintf(w.sheet, "<c><v>%s</v></c>", formatValue(v))
+		default:
+			w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`)
+			if err := xml.EscapeText(w.sheet, []byte(formatValue(v))); err != nil {
+				return err
+			}
+			w.sheet.WriteString("</t></is></c>")
+		}
+	}
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+// endSheet closes the sheet being written, if any
+func (w *xlsxWriter) endSheet() error {
+	if w.sheet == nil {
+		return nil
+	}
+	w.sheet.WriteString("</sheetData></worksheet>")
+	err := w.sheet.Flush()
+	w.sheet = nil
+	return err
+}
+
+func (w *xlsxWriter) Close() error {
+	if err := w.endSheet(); err != nil {
+		return err
+	}
+
+	contentTypes := `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`
+	workbook := fmt.Sprintf(`<workbook xmlns="%s" xmlns:r="%s"><sheets>`, xlsxMainNS, xlsxRelNS)
+	workbookRels := fmt.Sprintf(`<Relationships xmlns="%s">`, xlsxPkgNS)
+	for i, name := range w.sheets {
+		n := i + 1
+		contentTypes += fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		workbook += fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeAttr(name), n, n)
+		workbookRels += fmt.Sprintf(`<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, n, xlsxRelNS, n)
+	}
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes + `</Types>`},
+		{"_rels/.rels", fmt.Sprintf(`<Relationships xmlns="%s"><Relationship Id="rId1" Type="%s/officeDocument" Target="xl/workbook.xml"/></Relationships>`, xlsxPkgNS, xlsxRelNS)},
+		{"xl/workbook.xml", workbook + `</sheets></workbook>`},
+		{"xl/_rels/workbook.xml.rels", workbookRels + `</Relationships>`},
+	}
+	for _, p := range parts {
+		entry, err := w.zip.Create(p.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(entry, xlsxHeader+p.body); err != nil {
+			return err
+		}
+	}
+	return w.zip.Close()
+}
+
+// escapeAttr escapes s for use in an XML attribute
+func escapeAttr(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
@@ -0,0 +1,85 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func writeReport(t *testing.T, format string) []byte {
+	var buf bytes.Buffer
+	w, err := New(format, &buf)
+	if err != nil {
+		t.Fatalf("New(%q) error = %v", format, err)
+	}
+	w.Sheet("Routes", "ID", "Name")
+	w.Row(1, "North <A&B>")
+	w.Row(int64(2), nil)
+	w.Sheet("Stops", "Quantity")
+	w.Row(12.5)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+// TestCSV tests that sheets are written as sections
+func TestCSV(t *testing.T) {
+	got := string(writeReport(t, CSV))
+	want := "Routes\nID,Name\n1,North <A&B>\n2,\n\nStops\nQuantity\n12.5\n"
+	if got != want {
+		t.Errorf("CSV output = %q, want %q", got, want)
+	}
+}
+
+// TestXLSX tests that the workbook is a well-formed package with one
+// worksheet per sheet
+func TestXLSX(t *testing.T) {
+	data := writeReport(t, XLSX)
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("zip.NewReader() error = %v", err)
+	}
+
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		body, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(body)
+
+		dec := xml.NewDecoder(bytes.NewReader(body))
+		for {
+			if _, err := dec.Token(); err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("%s is not well-formed: %v", f.Name, err)
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/worksheets/sheet1.xml", "xl/worksheets/sheet2.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("package is missing %s", name)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `<sheet name="Stops" sheetId="2" r:id="rId2"/>`) {
+		t.Errorf("workbook.xml = %s, want the Stops sheet", parts["xl/workbook.xml"])
+	}
+	if !strings.Contains(parts["xl/worksheets/sheet1.xml"], "<row><c><v>1</v></c><c t=\"inlineStr\"><is><t xml:space=\"preserve\">North &lt;A&amp;B&gt;</t></is></c></row>") {
+		t.Errorf("sheet1.xml = %s, want a numeric and an escaped string cell", parts["xl/worksheets/sheet1.xml"])
+	}
+	if !strings.Contains(parts["xl/worksheets/sheet2.xml"], "<c><v>12.5</v></c>") {
+		t.Errorf("sheet2.xml = %s, want the quantity", parts["xl/worksheets/sheet2.xml"])
+	}
+}
+
+// TestNewUnknownFormat tests that unsupported formats are rejected
+func TestNewUnknownFormat(t *testing.T) {
+	if _, err := New("pdf", io.Discard); err == nil {
+		t.Error("New(pdf) error = nil, want an error")
+	}
+}
@@ -0,0 +1,82 @@
+// Package forecast projects inventory forward from demand, replenishment
+// and the deliveries planned on routes that are not delivered yet.
+package forecast
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+)
+
+// Statuses are the statuses of plans whose deliveries a forecast counts on
+var Statuses = []string{planstate.Optimized, planstate.Approved, planstate.Executing}
+
+// Customer projects a customer's inventory over days from today with its
+// planned delivery stops, which come with their route. Inventory does not
+// go below zero: demand that cannot be met is lost.
+func Customer(customer *models.Customer, stops []models.Stop, today time.Time, days int) *models.InventoryForecast {
+	forecast := &models.InventoryForecast{
+		CustomerID:       customer.ID,
+		CurrentInventory: customer.CurrentInventory,
+		DemandRate:       customer.DemandRate,
+		MinInventory:     customer.MinInventory,
+		MaxInventory:     customer.MaxInventory,
+		Deliveries:       []models.PlannedDelivery{},
+		Days:             make([]models.InventoryForecastDay, 0, days),
+	}
+	delivered := make(map[string]float64)
+	for _, stop := range stops {
+		date := stop.Route.Date.Format("2006-01-02")
+		delivered[date] += stop.Quantity
+		forecast.Deliveries = append(forecast.Deliveries, models.PlannedDelivery{
+			Date:     date,
+			PlanID:   stop.Route.PlanID,
+			RouteID:  stop.RouteID,
+			StopID:   stop.ID,
+			Quantity: stop.Quantity,
+		})
+	}
+
+	inventory := customer.CurrentInventory
+	for i := 0; i < days; i++ {
+		date := today.AddDate(0, 0, i).Format("2006-01-02")
+		day := models.InventoryForecastDay{
+			Date:      date,
+			Opening:   inventory,
+			Delivered: delivered[date],
+			Demand:    customer.DemandRate,
+		}
+		available := day.Opening + day.Delivered
+		day.Closing = max(available-customer.DemandRate, 0)
+		if forecast.StockoutDate == nil && customer.DemandRate > 0 && available <= customer.DemandRate {
+			forecast.StockoutDate = &day.Date
+		}
+		if forecast.BelowMinimumDate == nil && min(day.Opening, day.Closing) < customer.MinInventory {
+			forecast.BelowMinimumDate = &day.Date
+		}
+		forecast.Days = append(forecast.Days, day)
+		inventory = day.Closing
+	}
+	return forecast
+}
+
+// WarehouseStockout returns the first day over days from today on which a
+// warehouse's projected stock goes below zero, or nil. Its daily
+// replenishment arrives each day and the planned delivery stops of its
+// plans, which come with their route, leave on their route's date.
+func WarehouseStockout(warehouse *models.Warehouse, stops []models.Stop, today time.Time, days int) *time.Time {
+	outbound := make(map[string]float64)
+	for _, stop := range stops {
+		outbound[stop.Route.Date.Format("2006-01-02")] += stop.Quantity
+	}
+	stock := warehouse.CurrentStock
+	for i := 0; i < days; i++ {
+		date := today.AddDate(0, 0, i)
+		stock += warehouse.ReplenishmentQty - outbound[date.Format("2006-01-02")]
+		if stock < 0 {
+			return &date
+		}
+	}
+	return nil
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type AbsenceRequest struct {
+	Type      string `json:"type" binding:"required,oneof=vacation sick other"`
+	StartDate string `json:"start_date" binding:"required"`
+	EndDate   string `json:"end_date" binding:"required"`
+	Reason    string `json:"reason"`
+}
+
+// ListDriverAbsences handles GET /api/v1/drivers/:id/absences
+func (h *Handler) ListDriverAbsences(c *gin.Context) {
+	driverID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	absences, err := database.ListAbsences(h.db, &driverID, c.Query("status"), time.Time{}, time.Time{})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch absences")
+		return
+	}
+	if absences == nil {
+		absences = []models.DriverAbsence{}
+	}
+	successResponse(c, absences)
+}
+
+// CreateDriverAbsence handles POST /api/v1/drivers/:id/absences
+func (h *Handler) CreateDriverAbsence(c *gin.Context) {
+	driverID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	var req AbsenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid start date format (use YYYY-MM-DD)")
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid end date format (use YYYY-MM-DD)")
+		return
+	}
+	if endDate.Before(startDate) {
+		errorResponse(c, http.StatusBadRequest, "End date must be after start date")
+		return
+	}
+
+	if _, err := database.GetDriver(h.db, driverID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Driver")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver")
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	absence := &models.DriverAbsence{
+		DriverID:    driverID,
+		Type:        req.Type,
+		StartDate:   startDate,
+		EndDate:     endDate,
+		Status:      "pending",
+		Reason:      req.Reason,
+		RequestedBy: &userID,
+	}
+
+	if err := database.CreateAbsence(h.db, absence); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create absence")
+		return
+	}
+	createdResponse(c, absence)
+}
+
+// ListAbsences handles GET /api/v1/absences?status=&from=&to=
+func (h *Handler) ListAbsences(c *gin.Context) {
+	from, to, ok := h.parseRosterRange(c)
+	if !ok {
+		return
+	}
+
+	absences, err := database.ListAbsences(h.db, nil, c.Query("status"), from, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch absences")
+		return
+	}
+	if absences == nil {
+		absences = []models.DriverAbsence{}
+	}
+	successResponse(c, absences)
+}
+
+// ApproveAbsence handles POST /api/v1/absences/:id/approve
+// Routes already assigned to the driver during the absence are flagged for
+// reassignment and returned.
+func (h *Handler) ApproveAbsence(c *gin.Context) {
+	h.reviewAbsence(c, "approved")
+}
+
+// RejectAbsence handles POST /api/v1/absences/:id/reject
+func (h *Handler) RejectAbsence(c *gin.Context) {
+	h.reviewAbsence(c, "rejected")
+}
+
+func (h *Handler) reviewAbsence(c *gin.Context, status string) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid absence ID")
+		return
+	}
+
+	absence, err := database.GetAbsence(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Absence")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch absence")
+		return
+	}
+	if absence.Status != "pending" {
+		errorResponse(c, http.StatusConflict, "Absence has already been "+absence.Status)
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	now := h.clock.Now()
+	absence.Status = status
+	absence.ReviewedBy = &userID
+	absence.ReviewedAt = &now
+
+	affected := []models.Route{}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.ReviewAbsenceTx(tx, absence); err != nil {
+			return err
+		}
+		if status != "approved" {
+			return nil
+		}
+		routes, err := database.FlagRoutesForReassignmentTx(tx, absence.DriverID, absence.StartDate, absence.EndDate)
+		if err != nil {
+			return err
+		}
+		affected = append(affected, routes...)
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusConflict, "Absence was reviewed concurrently")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to review absence")
+		return
+	}
+
+	successResponse(c, gin.H{
+		"absence":                     absence,
+		"routes_needing_reassignment": affected,
+	})
+}
+
+// DeleteAbsence handles DELETE /api/v1/absences/:id
+func (h *Handler) DeleteAbsence(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid absence ID")
+		return
+	}
+
+	if err := database.DeleteAbsence(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Absence")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete absence")
+		return
+	}
+	successResponse(c, gin.H{"message": "Absence deleted successfully"})
+}
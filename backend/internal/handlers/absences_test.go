@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestReviewAbsences tests that only managers approve, reject and delete
+// absences and that approving one flags the driver's routes
+func TestReviewAbsences(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/absences/:id/approve", s.h.RoleMiddleware("admin", "manager"), s.h.ApproveAbsence)
+	s.api.POST("/absences/:id/reject", s.h.RoleMiddleware("admin", "manager"), s.h.RejectAbsence)
+	s.api.DELETE("/absences/:id", s.h.RoleMiddleware("admin", "manager"), s.h.DeleteAbsence)
+	manager := s.login(t, "manager")
+	driverToken := s.login(t, "driver")
+
+	warehouse := s.fx.Warehouse()
+	driver := s.fx.Driver(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 5)
+	vehicle := s.fx.Vehicle(warehouse)
+	during := s.fx.Route(plan, vehicle, 2, s.fx.Customer())
+	after := s.fx.Route(plan, vehicle, 5, s.fx.Customer())
+	for _, route := range []*models.Route{during, after} {
+		if err := s.db.Model(route).Update("driver_id", driver.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	absence := func(t *testing.T) *models.DriverAbsence {
+		t.Helper()
+		a := &models.DriverAbsence{
+			DriverID:  driver.ID,
+			Type:      "vacation",
+			StartDate: time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC),
+			EndDate:   time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC),
+			Status:    "pending",
+		}
+		if err := database.CreateAbsence(s.db, a); err != nil {
+			t.Fatal(err)
+		}
+		return a
+	}
+	path := func(a *models.DriverAbsence) string {
+		return fmt.Sprintf("/api/v1/absences/%d", a.ID)
+	}
+	stored := func(t *testing.T, a *models.DriverAbsence) string {
+		t.Helper()
+		got, err := database.GetAbsence(s.db, a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.Status
+	}
+
+	t.Run("drivers cannot review", func(t *testing.T) {
+		a := absence(t)
+		for _, req := range []struct{ method, path string }{
+			{"POST", path(a) + "/approve"},
+			{"POST", path(a) + "/reject"},
+			{"DELETE", path(a)},
+		} {
+			if w := s.do(t, req.method, req.path, driverToken, nil); w.Code != http.StatusForbidden {
+				t.Errorf("%s %s as driver status = %d, want 403", req.method, req.path, w.Code)
+			}
+		}
+		if got := stored(t, a); got != "pending" {
+			t.Errorf("absence status = %s, want pending", got)
+		}
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		a := absence(t)
+		w := s.do(t, "POST", path(a)+"/approve", manager, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("approve status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data struct {
+				Absence  models.DriverAbsence `json:"absence"`
+				Affected []models.Route       `json:"routes_needing_reassignment"`
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Data.Absence.Status != "approved" || resp.Data.Absence.ReviewedBy == nil {
+			t.Errorf("absence = %+v, want approved with its reviewer", resp.Data.Absence)
+		}
+		if len(resp.Data.Affected) != 1 || resp.Data.Affected[0].ID != during.ID || !resp.Data.Affected[0].NeedsReassignment {
+			t.Errorf("routes needing reassignment = %+v, want the route during the absence", resp.Data.Affected)
+		}
+		if w := s.do(t, "POST", path(a)+"/reject", manager, nil); w.Code != http.StatusConflict {
+			t.Errorf("reject after approval status = %d, want 409", w.Code)
+		}
+	})
+
+	t.Run("reject", func(t *testing.T) {
+		a := absence(t)
+		if w := s.do(t, "POST", path(a)+"/reject", manager, nil); w.Code != http.StatusOK {
+			t.Fatalf("reject status = %d: %s", w.Code, w.Body.String())
+		}
+		if got := stored(t, a); got != "rejected" {
+			t.Errorf("absence status = %s, want rejected", got)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		a := absence(t)
+		if w := s.do(t, "DELETE", path(a), manager, nil); w.Code != http.StatusOK {
+			t.Fatalf("delete status = %d: %s", w.Code, w.Body.String())
+		}
+		if _, err := database.GetAbsence(s.db, a.ID); !errors.Is(err, database.ErrNotFound) {
+			t.Errorf("GetAbsence() after delete error = %v, want ErrNotFound", err)
+		}
+		if w := s.do(t, "DELETE", path(a), manager, nil); w.Code != http.StatusNotFound {
+			t.Errorf("delete again status = %d, want 404", w.Code)
+		}
+	})
+}
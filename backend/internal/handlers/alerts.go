@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/alerts"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var alertStatuses = []string{models.AlertOpen, models.AlertAcknowledged, models.AlertResolved}
+
+// ListAlerts handles GET /api/v1/alerts?status=&severity=&kind=&entity_type=&entity_id=&page=&limit=
+// Low-stock alerts, latest detected first. status takes a comma-separated
+// list, e.g. open,acknowledged for the alerts not resolved yet.
+func (h *Handler) ListAlerts(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.AlertFilter{
+		Severity:   c.Query("severity"),
+		Kind:       c.Query("kind"),
+		EntityType: c.Query("entity_type"),
+	}
+	if raw := c.Query("status"); raw != "" {
+		filter.Statuses = strings.Split(raw, ",")
+		for _, status := range filter.Statuses {
+			if !slices.Contains(alertStatuses, status) {
+				errorResponse(c, http.StatusBadRequest, "status must be one of "+strings.Join(alertStatuses, ", "))
+				return
+			}
+		}
+	}
+	if filter.Severity != "" && filter.Severity != models.AlertWarning && filter.Severity != models.AlertCritical {
+		errorResponse(c, http.StatusBadRequest, "severity must be warning or critical")
+		return
+	}
+	if filter.Kind != "" && filter.Kind != models.AlertBelowMinimum && filter.Kind != models.AlertProjectedStockout {
+		errorResponse(c, http.StatusBadRequest, "kind must be below_minimum or projected_stockout")
+		return
+	}
+	if filter.EntityType != "" && filter.EntityType != "customer" && filter.EntityType != "warehouse" {
+		errorResponse(c, http.StatusBadRequest, "entity_type must be customer or warehouse")
+		return
+	}
+	if filter.EntityID, err = parseIDQuery(c, "entity_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	list, total, err := database.ListAlerts(h.db, filter, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch alerts")
+		return
+	}
+	if list == nil {
+		list = []models.Alert{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, list, page)
+}
+
+// GetAlert handles GET /api/v1/alerts/:id
+func (h *Handler) GetAlert(c *gin.Context) {
+	alert, ok := h.alert(c)
+	if !ok {
+		return
+	}
+	successResponse(c, alert)
+}
+
+// AcknowledgeAlert handles POST /api/v1/alerts/:id/acknowledge
+// An acknowledged alert stays until it is resolved by hand or its condition
+// clears; scans keep updating it meanwhile.
+func (h *Handler) AcknowledgeAlert(c *gin.Context) {
+	alert, ok := h.alert(c)
+	if !ok {
+		return
+	}
+	if alert.Status != models.AlertOpen {
+		errorResponse(c, http.StatusConflict, "Alert is "+alert.Status)
+		return
+	}
+	h.changeAlert(c, alert.ID, database.AcknowledgeAlert)
+}
+
+// ResolveAlert handles POST /api/v1/alerts/:id/resolve
+// A condition that still holds opens a new alert at the next scan.
+func (h *Handler) ResolveAlert(c *gin.Context) {
+	alert, ok := h.alert(c)
+	if !ok {
+		return
+	}
+	if alert.Status == models.AlertResolved {
+		errorResponse(c, http.StatusConflict, "Alert is already resolved")
+		return
+	}
+	h.changeAlert(c, alert.ID, database.ResolveAlert)
+}
+
+// alert fetches the alert of the request's :id. It writes the error
+// response and returns false when there is none.
+func (h *Handler) alert(c *gin.Context) (*models.Alert, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid alert ID")
+		return nil, false
+	}
+	alert, err := database.GetAlert(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Alert")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch alert")
+		return nil, false
+	}
+	return alert, true
+}
+
+// changeAlert applies an acknowledgement or resolution by the current user
+// and responds with the changed alert. A scan that changed the alert's
+// status in the meantime is reported as a conflict.
+func (h *Handler) changeAlert(c *gin.Context, id int64, change func(db *gorm.DB, id int64, userID *int64, now time.Time) error) {
+	var userID *int64
+	if uid := c.GetInt64("userID"); uid != 0 {
+		userID = &uid
+	}
+	if err := change(h.db, id, userID, h.clock.Now()); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusConflict, "Alert changed status, try again")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update alert")
+		return
+	}
+	alert, err := database.GetAlert(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch alert")
+		return
+	}
+	successResponse(c, alert)
+}
+
+// queueAlertScan queues a low-stock alert scan of a customer or warehouse
+// whose inventory was recorded
+func (h *Handler) queueAlertScan(entityType string, entityID int64) {
+	scope := alerts.Scope{EntityType: entityType, EntityID: entityID}
+	if _, err := jobs.Enqueue(h.db, alerts.JobType, scope, jobs.Options{RunAt: h.clock.Now()}); err != nil {
+		log.Printf("Failed to queue alert scan of %s %d: %v", entityType, entityID, err)
+	}
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/alerts"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestAlerts tests a snapshot queueing an alert scan and alerts being
+// listed, acknowledged and resolved
+func TestAlerts(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	s.h.SetClock(testkit.NewClock(now))
+	s.api.POST("/inventory/snapshots", s.h.CreateInventorySnapshot)
+	s.api.GET("/alerts", s.h.ListAlerts)
+	s.api.POST("/alerts/:id/acknowledge", s.h.RoleMiddleware("admin", "manager", "user"), s.h.AcknowledgeAlert)
+	s.api.POST("/alerts/:id/resolve", s.h.RoleMiddleware("admin", "manager", "user"), s.h.ResolveAlert)
+
+	token := s.login(t, "manager")
+	customer := s.fx.Customer(func(c *models.Customer) { c.CurrentInventory = 5 })
+
+	w := s.do(t, "POST", "/api/v1/inventory/snapshots", token, CreateInventorySnapshotRequest{EntityType: "customer", EntityID: customer.ID, SnapshotDate: "2024-03-04"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("snapshot status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	var job models.Job
+	if err := s.db.Where("type = ?", alerts.JobType).First(&job).Error; err != nil || job.Payload != fmt.Sprintf(`{"entity_type":"customer","entity_id":%d}`, customer.ID) {
+		t.Fatalf("queued scan = %+v, %v, want one of the customer", job, err)
+	}
+	scanner := alerts.New(s.db, 7)
+	scanner.SetClock(testkit.NewClock(now))
+	if err := scanner.RunJob(context.Background(), json.RawMessage(job.Payload)); err != nil {
+		t.Fatal(err)
+	}
+	var alert models.Alert
+	if err := s.db.First(&alert).Error; err != nil {
+		t.Fatalf("scan raised no alert: %v", err)
+	}
+
+	t.Run("list", func(t *testing.T) {
+		var list struct {
+			Data []models.Alert
+		}
+		w := s.do(t, "GET", "/api/v1/alerts?status=open&severity=critical", token, nil)
+		json.Unmarshal(w.Body.Bytes(), &list)
+		if w.Code != http.StatusOK || len(list.Data) != 1 || list.Data[0].Kind != models.AlertProjectedStockout {
+			t.Errorf("critical alerts = %d %+v, want the stockout tomorrow", w.Code, list.Data)
+		}
+		if w := s.do(t, "GET", "/api/v1/alerts?status=closed", token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("unknown status = %d, want 400", w.Code)
+		}
+	})
+
+	type response struct{ Data models.Alert }
+	path := fmt.Sprintf("/api/v1/alerts/%d", alert.ID)
+	t.Run("acknowledge", func(t *testing.T) {
+		w := s.do(t, "POST", path+"/acknowledge", token, nil)
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || resp.Data.Status != models.AlertAcknowledged || resp.Data.AcknowledgedBy == nil {
+			t.Errorf("acknowledge = %d %+v, want acknowledged by the manager", w.Code, resp.Data)
+		}
+		if w := s.do(t, "POST", path+"/acknowledge", token, nil); w.Code != http.StatusConflict {
+			t.Errorf("second acknowledge status = %d, want 409", w.Code)
+		}
+	})
+
+	t.Run("resolve", func(t *testing.T) {
+		w := s.do(t, "POST", path+"/resolve", token, nil)
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || resp.Data.Status != models.AlertResolved || resp.Data.ResolvedBy == nil {
+			t.Errorf("resolve = %d %+v, want resolved by the manager", w.Code, resp.Data)
+		}
+		if w := s.do(t, "POST", path+"/resolve", token, nil); w.Code != http.StatusConflict {
+			t.Errorf("second resolve status = %d, want 409", w.Code)
+		}
+		if alert, _ := database.GetAlert(s.db, alert.ID); alert.Status != models.AlertResolved {
+			t.Errorf("stored alert status = %s, want resolved", alert.Status)
+		}
+	})
+}
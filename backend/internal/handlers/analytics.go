@@ -2,6 +2,9 @@ package handlers
 
 import (
 	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
@@ -53,3 +56,133 @@ func (h *Handler) GetSummary(c *gin.Context) {
 	})
 }
 
+// Portfolio thresholds: A covers the top 80% of volume, B the next 15%.
+// Frequency bands are measured in visits per week.
+const (
+	portfolioClassAShare = 0.80
+	portfolioClassBShare = 0.95
+	portfolioHighFreq    = 2.0
+	portfolioMediumFreq  = 0.5
+)
+
+// GetCustomerPortfolio handles GET /api/v1/analytics/customer-portfolio?days=90
+func (h *Handler) GetCustomerPortfolio(c *gin.Context) {
+	days := 90
+	if raw := c.Query("days"); raw != "" {
+		val, err := strconv.Atoi(raw)
+		if err != nil || val < 1 || val > 365 {
+			errorResponse(c, http.StatusBadRequest, "days must be between 1 and 365")
+			return
+		}
+		days = val
+	}
+
+	to := h.clock.Now().Truncate(24 * time.Hour)
+	from := to.AddDate(0, 0, -days)
+
+	customers, err := h.customers.List()
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return
+	}
+	totals, err := database.GetCustomerDeliveryTotals(h.db, from, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch delivery totals")
+		return
+	}
+
+	report := buildCustomerPortfolio(customers, totals, days)
+	report.From = from.Format("2006-01-02")
+	report.To = to.Format("2006-01-02")
+
+	successResponse(c, report)
+}
+
+// buildCustomerPortfolio runs the ABC classification and frequency banding
+func buildCustomerPortfolio(customers []models.Customer, totals []database.CustomerDeliveryTotals, days int) *models.CustomerPortfolioReport {
+	byCustomer := make(map[int64]database.CustomerDeliveryTotals, len(totals))
+	var totalVolume float64
+	for _, t := range totals {
+		byCustomer[t.CustomerID] = t
+		totalVolume += t.Quantity
+	}
+
+	weeks := float64(days) / 7
+	entries := make([]models.CustomerPortfolioEntry, len(customers))
+	for i, cust := range customers {
+		t := byCustomer[cust.ID]
+		entry := models.CustomerPortfolioEntry{
+			CustomerID:    cust.ID,
+			Name:          cust.Name,
+			Visits:        t.Visits,
+			VisitsPerWeek: float64(t.Visits) / weeks,
+			Volume:        t.Quantity,
+		}
+		if t.Visits > 0 {
+			entry.AvgDropSize = t.Quantity / float64(t.Visits)
+		}
+		if totalVolume > 0 {
+			entry.VolumeShare = t.Quantity / totalVolume
+		}
+		entries[i] = entry
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Volume > entries[j].Volume
+	})
+
+	report := &models.CustomerPortfolioReport{
+		TotalVolume: totalVolume,
+		ClassCounts: map[string]int{"A": 0, "B": 0, "C": 0},
+		BandCounts:  map[string]int{"high": 0, "medium": 0, "low": 0, "none": 0},
+		Customers:   entries,
+	}
+
+	var cumulative float64
+	for i := range entries {
+		e := &entries[i]
+
+		// Classify by the share accumulated before this customer so the
+		// customer crossing a threshold still lands in the higher class
+		switch {
+		case e.Volume > 0 && cumulative < portfolioClassAShare:
+			e.VolumeClass = "A"
+		case e.Volume > 0 && cumulative < portfolioClassBShare:
+			e.VolumeClass = "B"
+		default:
+			e.VolumeClass = "C"
+		}
+		cumulative += e.VolumeShare
+
+		switch {
+		case e.Visits == 0:
+			e.FrequencyBand = "none"
+		case e.VisitsPerWeek >= portfolioHighFreq:
+			e.FrequencyBand = "high"
+		case e.VisitsPerWeek >= portfolioMediumFreq:
+			e.FrequencyBand = "medium"
+		default:
+			e.FrequencyBand = "low"
+		}
+
+		e.SuggestedFrequency, e.Reason = suggestFrequency(e.VolumeClass, e.FrequencyBand)
+
+		report.ClassCounts[e.VolumeClass]++
+		report.BandCounts[e.FrequencyBand]++
+	}
+
+	return report
+}
+
+func suggestFrequency(volumeClass, band string) (string, string) {
+	switch {
+	case volumeClass == "A" && (band == "low" || band == "none"):
+		return "increase", "high-volume customer visited infrequently; risk of stockouts"
+	case volumeClass == "C" && band == "high":
+		return "decrease", "low-volume customer visited often; consolidate into fewer, larger drops"
+	case volumeClass == "B" && band == "high":
+		return "decrease", "medium-volume customer visited more than needed"
+	default:
+		return "keep", ""
+	}
+}
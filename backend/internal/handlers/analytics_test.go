@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestBuildCustomerPortfolio tests ABC classes, frequency bands and suggestions
+func TestBuildCustomerPortfolio(t *testing.T) {
+	customers := []models.Customer{
+		{ID: 1, Name: "Big, rarely visited"},
+		{ID: 2, Name: "Medium"},
+		{ID: 3, Name: "Small, visited often"},
+		{ID: 4, Name: "Never visited"},
+	}
+	totals := []database.CustomerDeliveryTotals{
+		{CustomerID: 1, Visits: 2, Quantity: 800},
+		{CustomerID: 2, Visits: 10, Quantity: 150},
+		{CustomerID: 3, Visits: 40, Quantity: 50},
+	}
+
+	report := buildCustomerPortfolio(customers, totals, 70)
+
+	want := map[int64]struct {
+		class, band, suggestion string
+	}{
+		1: {"A", "low", "increase"},
+		2: {"B", "medium", "keep"},
+		3: {"C", "high", "decrease"},
+		4: {"C", "none", "keep"},
+	}
+
+	if report.TotalVolume != 1000 {
+		t.Errorf("TotalVolume = %v, want 1000", report.TotalVolume)
+	}
+	if report.Customers[0].CustomerID != 1 {
+		t.Errorf("first customer = %d, want highest volume customer 1", report.Customers[0].CustomerID)
+	}
+	for _, e := range report.Customers {
+		w := want[e.CustomerID]
+		if e.VolumeClass != w.class || e.FrequencyBand != w.band || e.SuggestedFrequency != w.suggestion {
+			t.Errorf("customer %d = (%s, %s, %s), want (%s, %s, %s)",
+				e.CustomerID, e.VolumeClass, e.FrequencyBand, e.SuggestedFrequency, w.class, w.band, w.suggestion)
+		}
+	}
+}
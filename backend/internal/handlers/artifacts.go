@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultSignedURLTTL applies when STORAGE_SIGNED_URL_TTL_MINUTES is unset
+const defaultSignedURLTTL = 15 * time.Minute
+
+// storeArtifact saves a generated file and returns a signed link to it. All
+// features producing files go through here so they share one storage
+// backend and its lifecycle rules.
+func (h *Handler) storeArtifact(ctx context.Context, key, contentType string, body []byte) (*models.ArtifactLink, error) {
+	if h.artifacts == nil {
+		return nil, errors.New("artifact storage is not configured")
+	}
+	if err := h.artifacts.Put(ctx, key, bytes.NewReader(body), int64(len(body)), contentType); err != nil {
+		return nil, err
+	}
+	return h.artifactLink(ctx, key, int64(len(body)))
+}
+
+// artifactLink signs a link to a stored file
+func (h *Handler) artifactLink(ctx context.Context, key string, size int64) (*models.ArtifactLink, error) {
+	if h.artifacts == nil {
+		return nil, errors.New("artifact storage is not configured")
+	}
+	ttl := time.Duration(h.config.StorageSignedURLTTL) * time.Minute
+	if ttl <= 0 {
+		ttl = defaultSignedURLTTL
+	}
+	url, err := h.artifacts.SignedURL(ctx, key, ttl)
+	if err != nil {
+		return nil, err
+	}
+	return &models.ArtifactLink{Key: key, URL: url, Size: size, ExpiresAt: h.clock.Now().Add(ttl)}, nil
+}
+
+// ServeFile handles GET /api/v1/files/*key?expires=&signature=
+// It serves signed URLs of the local storage driver. The signature replaces
+// authentication, so the route is public.
+func (h *Handler) ServeFile(c *gin.Context) {
+	local, ok := h.artifacts.(*storage.Local)
+	if !ok {
+		notFoundResponse(c, "File")
+		return
+	}
+
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if err := local.VerifySignature(key, c.Query("expires"), c.Query("signature"), h.clock.Now()); err != nil {
+		errorResponse(c, http.StatusForbidden, "Invalid or expired file link")
+		return
+	}
+
+	body, err := local.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			notFoundResponse(c, "File")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to read file")
+		return
+	}
+	defer body.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	c.Header("Content-Disposition", `attachment; filename="`+path.Base(key)+`"`)
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", contentType)
+	io.Copy(c.Writer, body)
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/storage"
+)
+
+// TestServeFile tests that file links are signed with the storage signing
+// secret and that links signed with anything else are refused
+func TestServeFile(t *testing.T) {
+	dir := t.TempDir()
+	s := newTestServer(t, func(cfg *config.Config) { cfg.StorageLocalDir = dir })
+	s.router.GET("/api/v1/files/*key", s.h.ServeFile)
+	ctx := context.Background()
+
+	link, err := s.h.storeArtifact(ctx, "exports/report.csv", "text/csv", []byte("id,name\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(t *testing.T, url string) int {
+		t.Helper()
+		return s.do(t, "GET", strings.TrimPrefix(url, "http://localhost:8080"), "", nil).Code
+	}
+
+	t.Run("signed link", func(t *testing.T) {
+		if code := get(t, link.URL); code != http.StatusOK {
+			t.Errorf("signed link status = %d, want 200", code)
+		}
+	})
+
+	t.Run("signed with the JWT secret", func(t *testing.T) {
+		forger, err := storage.NewLocal(dir, "", []byte(s.h.config.JWTSecret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged, err := forger.SignedURL(ctx, "exports/report.csv", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code := get(t, forged); code != http.StatusForbidden {
+			t.Errorf("link signed with the JWT secret status = %d, want 403", code)
+		}
+	})
+
+	t.Run("tampered key", func(t *testing.T) {
+		if code := get(t, strings.Replace(link.URL, "report.csv", "other.csv", 1)); code != http.StatusForbidden {
+			t.Errorf("tampered link status = %d, want 403", code)
+		}
+	})
+}
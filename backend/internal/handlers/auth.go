@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"strings"
@@ -9,6 +10,7 @@ import (
 
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/securitylog"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -36,7 +38,7 @@ type AuthResponse struct {
 func (h *Handler) Register(c *gin.Context) {
 	var req RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
 
@@ -51,7 +53,7 @@ func (h *Handler) Register(c *gin.Context) {
 		Email:    req.Email,
 		Password: string(hashedPassword),
 		Name:     req.Name,
-		Role:     "user",
+		Role:     roleUser,
 	}
 
 	if err := database.CreateUser(h.db, user); err != nil {
@@ -81,13 +83,14 @@ func (h *Handler) Register(c *gin.Context) {
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
 
 	user, err := database.GetUserByEmail(h.db, req.Email)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
+			h.recordSecurityEvent(c, securitylog.LoginFailed, &models.User{Email: req.Email}, "unknown email")
 			errorResponse(c, http.StatusUnauthorized, "Invalid credentials")
 			return
 		}
@@ -96,6 +99,7 @@ func (h *Handler) Login(c *gin.Context) {
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+		h.recordSecurityEvent(c, securitylog.LoginFailed, user, "wrong password")
 		errorResponse(c, http.StatusUnauthorized, "Invalid credentials")
 		return
 	}
@@ -105,6 +109,7 @@ func (h *Handler) Login(c *gin.Context) {
 		errorResponse(c, http.StatusInternalServerError, "Failed to generate token")
 		return
 	}
+	h.recordSecurityEvent(c, securitylog.Login, user, "")
 
 	successResponse(c, AuthResponse{
 		Token:     token,
@@ -128,15 +133,9 @@ func (h *Handler) RefreshToken(c *gin.Context) {
 		return
 	}
 
-	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
-	if err != nil {
-		errorResponse(c, http.StatusUnauthorized, "Invalid token")
-		return
-	}
-
-	user, err := database.GetUserByID(h.db, userID)
+	user, err := h.tokenUser(claims)
 	if err != nil {
-		errorResponse(c, http.StatusUnauthorized, "User not found")
+		h.tokenError(c, err)
 		return
 	}
 
@@ -158,7 +157,7 @@ func (h *Handler) GetCurrentUser(c *gin.Context) {
 	userID := c.GetInt64("userID")
 	user, err := database.GetUserByID(h.db, userID)
 	if err != nil {
-		errorResponse(c, http.StatusNotFound, "User not found")
+		notFoundResponse(c, "User")
 		return
 	}
 	successResponse(c, user)
@@ -182,25 +181,175 @@ func (h *Handler) AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+		user, err := h.tokenUser(claims)
 		if err != nil {
-			errorResponse(c, http.StatusUnauthorized, "Invalid token")
+			h.tokenError(c, err)
 			c.Abort()
 			return
 		}
 
-		c.Set("userID", userID)
+		c.Set("userID", user.ID)
+		c.Set("userRole", user.Role)
 		c.Next()
 	}
 }
 
+var (
+	errInvalidToken = errors.New("invalid token")
+	errTokenRevoked = errors.New("token revoked")
+)
+
+// tokenUser loads the user a token was issued to and rejects tokens issued
+// before the user's tokens were last revoked. Tokens carry their issue time
+// in whole seconds, so one issued in the same second as the revocation
+// stays valid.
+func (h *Handler) tokenUser(claims *jwt.RegisteredClaims) (*models.User, error) {
+	userID, err := strconv.ParseInt(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, errInvalidToken
+	}
+	user, err := database.GetUserByID(h.db, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TokensRevokedAt != nil {
+		if claims.IssuedAt == nil || claims.IssuedAt.Before(user.TokensRevokedAt.Truncate(time.Second)) {
+			return nil, errTokenRevoked
+		}
+	}
+	return user, nil
+}
+
+// tokenError responds to a tokenUser error
+func (h *Handler) tokenError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, errTokenRevoked):
+		errorResponse(c, http.StatusUnauthorized, "Token has been revoked")
+	case errors.Is(err, database.ErrNotFound):
+		errorResponse(c, http.StatusUnauthorized, "User not found")
+	case errors.Is(err, errInvalidToken):
+		errorResponse(c, http.StatusUnauthorized, "Invalid token")
+	default:
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+	}
+}
+
+// User roles. Managers approve plans; drivers only see approved plans.
+const (
+	roleAdmin   = "admin"
+	roleManager = "manager"
+	roleUser    = "user"
+	roleDriver  = "driver"
+)
+
+type UpdateUserRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=admin manager user driver"`
+}
+
+// AdminMiddleware restricts a route group to users with the admin role. It
+// must run after AuthMiddleware.
+func (h *Handler) AdminMiddleware() gin.HandlerFunc {
+	return h.requireRole("Admin access required", roleAdmin)
+}
+
+// RoleMiddleware restricts a route to users with one of the given roles. It
+// must run after AuthMiddleware.
+func (h *Handler) RoleMiddleware(roles ...string) gin.HandlerFunc {
+	return h.requireRole("Insufficient role for this action", roles...)
+}
+
+func (h *Handler) requireRole(message string, roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := database.GetUserByID(h.db, c.GetInt64("userID"))
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				errorResponse(c, http.StatusUnauthorized, "User not found")
+			} else {
+				errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+			}
+			c.Abort()
+			return
+		}
+		for _, role := range roles {
+			if user.Role == role {
+				c.Set("userRole", user.Role)
+				c.Next()
+				return
+			}
+		}
+		errorResponse(c, http.StatusForbidden, message)
+		c.Abort()
+	}
+}
+
+// isDriver reports whether the current user has the driver role
+func (h *Handler) isDriver(c *gin.Context) (bool, error) {
+	if role, ok := c.Get("userRole"); ok {
+		return role == roleDriver, nil
+	}
+	user, err := database.GetUserByID(h.db, c.GetInt64("userID"))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	c.Set("userRole", user.Role)
+	return user.Role == roleDriver, nil
+}
+
+// UpdateUserRole handles PUT /api/v1/admin/users/:id/role
+func (h *Handler) UpdateUserRole(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req UpdateUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	user, err := database.GetUserByID(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "User")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	previous := user.Role
+
+	if err := database.UpdateUserRole(h.db, id, req.Role); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "User")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update user role")
+		return
+	}
+
+	user, err = database.GetUserByID(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if previous != user.Role {
+		h.recordSecurityEvent(c, securitylog.RoleChanged, user, fmt.Sprintf("role changed from %s to %s", previous, user.Role))
+	}
+	successResponse(c, user)
+}
+
 func (h *Handler) generateToken(user *models.User) (string, time.Time, error) {
-	expiresAt := time.Now().Add(time.Duration(h.config.JWTExpiry) * time.Hour)
+	expiresAt := h.clock.Now().Add(time.Duration(h.config.JWTExpiry) * time.Hour)
 	
 	claims := jwt.RegisteredClaims{
 		Subject:   strconv.FormatInt(user.ID, 10),
 		ExpiresAt: jwt.NewNumericDate(expiresAt),
-		IssuedAt:  jwt.NewNumericDate(time.Now()),
+		IssuedAt:  jwt.NewNumericDate(h.clock.Now()),
 		Issuer:    "LogiTrackPro",
 	}
 
@@ -216,14 +365,14 @@ func (h *Handler) generateToken(user *models.User) (string, time.Time, error) {
 func (h *Handler) parseToken(tokenString string) (*jwt.RegisteredClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &jwt.RegisteredClaims{}, func(token *jwt.Token) (interface{}, error) {
 		return []byte(h.config.JWTSecret), nil
-	})
+	}, jwt.WithTimeFunc(h.clock.Now))
 	if err != nil {
 		return nil, err
 	}
 
 	claims, ok := token.Claims.(*jwt.RegisteredClaims)
 	if !ok || !token.Valid {
-		return nil, errors.New("invalid token")
+		return nil, errInvalidToken
 	}
 
 	return claims, nil
@@ -147,8 +147,10 @@ func TestRegister(t *testing.T) {
 				router.ServeHTTP(w, req)
 
 				// Second registration with same email
+				req2 := httptest.NewRequest("POST", "/api/v1/auth/register", bytes.NewBuffer(body))
+				req2.Header.Set("Content-Type", "application/json")
 				w2 := httptest.NewRecorder()
-				router.ServeHTTP(w2, req)
+				router.ServeHTTP(w2, req2)
 				if w2.Code != http.StatusConflict {
 					t.Errorf("Register() status = %d, want %d", w2.Code, http.StatusConflict)
 				}
@@ -306,7 +308,7 @@ func TestAuthMiddleware(t *testing.T) {
 	loginW := httptest.NewRecorder()
 	router := gin.New()
 	router.POST("/api/v1/auth/login", h.Login)
-	router.ServeHTTP(loginW, loginW)
+	router.ServeHTTP(loginW, loginReq)
 
 	var loginResponse struct {
 		Success bool
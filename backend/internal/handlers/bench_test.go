@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"LogiTrackPro/backend/internal/benchdata"
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// benchSizes are the dataset sizes every benchmark runs at
+var benchSizes = []benchdata.Config{
+	{Customers: 100, Vehicles: 10, Days: 7, Seed: 1},
+	{Customers: 1000, Vehicles: 50, Days: 7, Seed: 1},
+}
+
+// setupBenchHandler creates a handler over a generated dataset whose
+// optimizer answers with benchdata.Solve
+func setupBenchHandler(b *testing.B, size benchdata.Config) (*Handler, *benchdata.Dataset) {
+	b.Helper()
+	gin.SetMode(gin.TestMode)
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		b.Fatalf("Failed to connect to test database: %v", err)
+	}
+	// Every connection to :memory: is a separate database
+	sqlDB, _ := db.DB()
+	sqlDB.SetMaxOpenConns(1)
+	if err := database.RunMigrations(db); err != nil {
+		b.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	ds, err := benchdata.Generate(db, size)
+	if err != nil {
+		b.Fatalf("Generate() error = %v", err)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req optimizer.OptimizeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(benchdata.Solve(&req))
+	}))
+	b.Cleanup(server.Close)
+
+	cfg := &config.Config{JWTSecret: "bench-secret", JWTExpiry: 24, OptimizerURL: server.URL}
+	return New(db, optimizer.NewClient(cfg.OptimizerURL), cfg), ds
+}
+
+// benchName labels a sub-benchmark with its dataset size
+func benchName(size benchdata.Config) string {
+	return fmt.Sprintf("customers=%d/vehicles=%d/days=%d", size.Customers, size.Vehicles, size.Days)
+}
+
+// runBenchRequest serves the same request b.N times and fails on a non-200
+func runBenchRequest(b *testing.B, router *gin.Engine, method, path string) {
+	b.Helper()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(method, path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			b.Fatalf("%s %s status = %d, body = %s", method, path, w.Code, w.Body.String())
+		}
+	}
+}
+
+// BenchmarkOptimizePlan measures a full optimization round trip: building the
+// request, validating the response and persisting routes, stops and the
+// solution snapshot
+func BenchmarkOptimizePlan(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(benchName(size), func(b *testing.B) {
+			h, ds := setupBenchHandler(b, size)
+			router := gin.New()
+			router.POST("/api/v1/plans/:id/optimize", h.OptimizePlan)
+			runBenchRequest(b, router, "POST", "/api/v1/plans/"+strconv.FormatInt(ds.Plan.ID, 10)+"/optimize")
+		})
+	}
+}
+
+// BenchmarkListEndpoints measures the list endpoints over an optimized plan
+func BenchmarkListEndpoints(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(benchName(size), func(b *testing.B) {
+			h, ds := setupBenchHandler(b, size)
+			if err := benchdata.SaveSolution(h.db, ds.Plan, benchdata.Solve(ds.Request())); err != nil {
+				b.Fatalf("SaveSolution() error = %v", err)
+			}
+			router := gin.New()
+			router.GET("/api/v1/customers", h.ListCustomers)
+			router.GET("/api/v1/vehicles", h.ListVehicles)
+			router.GET("/api/v1/plans", h.ListPlans)
+			router.GET("/api/v1/plans/:id/routes", h.GetPlanRoutes)
+
+			paths := []string{
+				"/api/v1/customers",
+				"/api/v1/vehicles",
+				"/api/v1/plans",
+				"/api/v1/plans/" + strconv.FormatInt(ds.Plan.ID, 10) + "/routes",
+			}
+			for _, path := range paths {
+				b.Run(path, func(b *testing.B) {
+					runBenchRequest(b, router, "GET", path)
+				})
+			}
+		})
+	}
+}
+
+// BenchmarkGetDashboard measures dashboard aggregation over an optimized plan
+func BenchmarkGetDashboard(b *testing.B) {
+	for _, size := range benchSizes {
+		b.Run(benchName(size), func(b *testing.B) {
+			h, ds := setupBenchHandler(b, size)
+			if err := benchdata.SaveSolution(h.db, ds.Plan, benchdata.Solve(ds.Request())); err != nil {
+				b.Fatalf("SaveSolution() error = %v", err)
+			}
+			router := gin.New()
+			router.GET("/api/v1/analytics/dashboard", h.GetDashboard)
+			runBenchRequest(b, router, "GET", "/api/v1/analytics/dashboard")
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// breakRules returns the rules break and refuel stops are planned against
+// on new routes of the vehicles: the configured driving limit, the
+// vehicles' range and the places of the address book. Fuel stations and
+// truck stops take refuel stops, charging stations the charging stops of
+// electric vehicles; any place takes breaks.
+func (h *Handler) breakRules(vehicles []models.Vehicle) (optimizer.BreakRules, error) {
+	rules := optimizer.BreakRules{
+		MaxDrivingMinutes: h.config.BreakAfterDriving,
+		BreakMinutes:      h.config.BreakDuration,
+		RefuelMinutes:     h.config.RefuelDuration,
+		RangeKm:           make(map[int64]float64),
+		ChargeMinutes:     make(map[int64]int),
+	}
+	for _, v := range vehicles {
+		if v.RangeKm > 0 {
+			rules.RangeKm[v.ID] = v.RangeKm
+		}
+		if v.Electric {
+			rules.ChargeMinutes[v.ID] = v.ChargeMinutes
+		}
+	}
+	if rules.MaxDrivingMinutes <= 0 && len(rules.RangeKm) == 0 {
+		return rules, nil
+	}
+
+	places, err := database.ListPlaces(h.db, "")
+	if err != nil {
+		return rules, err
+	}
+	for _, p := range places {
+		rules.Places = append(rules.Places, optimizer.RestPlace{
+			ID:        p.ID,
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+			Fuel:      p.Kind == "fuel_station" || p.Kind == "truck_stop",
+			Charging:  p.Kind == "charging_station",
+		})
+	}
+	return rules, nil
+}
+
+// mandatoryStopMinutes is the time a break, refuel or charging stop adds to a route:
+// its own and the driving time of its detour at speed km/h
+func mandatoryStopMinutes(m optimizer.MandatoryStop, speed float64) float64 {
+	if speed <= 0 {
+		speed = optimizer.DefaultAverageSpeed
+	}
+	return float64(m.Minutes) + m.DetourKm/speed*60
+}
+
+// mandatoryStop builds the stop of a planned break, refuel or charging stop
+func mandatoryStop(routeID int64, sequence int, m optimizer.MandatoryStop) *models.Stop {
+	stop := &models.Stop{RouteID: routeID, Type: m.Type, Sequence: sequence, DurationMinutes: m.Minutes}
+	if m.PlaceID != 0 {
+		placeID := m.PlaceID
+		stop.PlaceID = &placeID
+	}
+	return stop
+}
+
+// shiftClock moves an HH:MM time by minutes; other values are kept as is
+func shiftClock(clock string, minutes int) string {
+	at, err := optimizer.ParseClock(clock)
+	if err != nil || minutes == 0 {
+		return clock
+	}
+	return optimizer.FormatClock(at + minutes)
+}
+
+// addMinutes moves an optional time by minutes
+func addMinutes(t *time.Time, minutes int) *time.Time {
+	if t == nil {
+		return nil
+	}
+	moved := t.Add(time.Duration(minutes) * time.Minute)
+	return &moved
+}
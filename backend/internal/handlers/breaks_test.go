@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestOptimizeInsertsBreaks tests that optimized routes get break and
+// refuel stops at a truck stop from the driving limit and vehicle range
+func TestOptimizeInsertsBreaks(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.BreakAfterDriving, cfg.BreakDuration, cfg.RefuelDuration = 60, 30, 10
+	})
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+
+	warehouse := s.fx.Warehouse()
+	// about 88 km north of the warehouse, with a truck stop half way
+	s.fx.Customer(func(c *models.Customer) { c.Latitude, c.Longitude = 41.5, -74.006 })
+	s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.RangeKm = 150 })
+	truckStop := &models.Place{Name: "Truck stop", Kind: "truck_stop", Latitude: 41.1, Longitude: -74.006}
+	database.CreatePlace(s.db, truckStop)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1)
+
+	token := s.login(t, "manager")
+	if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID), token, nil); w.Code != http.StatusOK {
+		t.Fatalf("optimize status = %d: %s", w.Code, w.Body.String())
+	}
+
+	routes, err := database.GetRoutesByPlan(s.db, plan.ID)
+	if err != nil || len(routes) != 1 {
+		t.Fatalf("routes = %v, %v; want one", routes, err)
+	}
+	route := routes[0]
+	// an hour's drive is over before the customer and again on the way
+	// back, where the tank also runs low
+	want := []struct {
+		typ     string
+		arrival string
+	}{{"rest_break", ""}, {"delivery", "09:00"}, {"refuel", ""}, {"rest_break", ""}}
+	if len(route.Stops) != len(want) {
+		t.Fatalf("stops = %+v, want %d", route.Stops, len(want))
+	}
+	for i, w := range want {
+		stop := route.Stops[i]
+		if stop.Type != w.typ || stop.Sequence != i+1 || stop.ArrivalTime != w.arrival {
+			t.Errorf("stop %d = %s #%d at %q, want %s #%d at %q", i, stop.Type, stop.Sequence, stop.ArrivalTime, w.typ, i+1, w.arrival)
+		}
+		if stop.Type != "delivery" && (stop.PlaceID == nil || *stop.PlaceID != truckStop.ID || stop.Place == nil) {
+			t.Errorf("stop %d = %+v, want it at the truck stop", i, stop)
+		}
+	}
+	if route.TotalDistance < 10 || route.TotalDistance > 10.1 {
+		t.Errorf("route distance = %v, want the optimizer's 10 km and next to no detour", route.TotalDistance)
+	}
+	// 08:00 start and 10 km at the default speed plus a stop, then the
+	// break, refuel and break
+	if route.PlannedEnd == nil || route.PlannedEnd.Format("15:04") != "09:37" {
+		t.Errorf("planned end = %v, want 09:37", route.PlannedEnd)
+	}
+}
+
+// TestElectricVehicleRange tests that electric vehicles charge at charging
+// stations on optimized routes and that manual edits warn when a route is
+// left beyond the vehicle's range
+func TestElectricVehicleRange(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.RefuelDuration = 10 })
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.POST("/routes/:id/stops", s.h.InsertStop)
+	s.api.DELETE("/stops/:id", s.h.DeleteStop)
+
+	warehouse := s.fx.Warehouse()
+	// about 88 km north of the warehouse: 175 km there and back
+	s.fx.Customer(func(c *models.Customer) { c.Latitude, c.Longitude = 41.5, -74.006 })
+	s.fx.Vehicle(warehouse, func(v *models.Vehicle) {
+		v.RangeKm, v.Electric, v.ChargeMinutes, v.ConsumptionPerKm = 150, true, 60, 1.2
+	})
+	fuel := &models.Place{Name: "Fuel", Kind: "fuel_station", Latitude: 41.1, Longitude: -74.006}
+	charger := &models.Place{Name: "Charger", Kind: "charging_station", Latitude: 41.1, Longitude: -74.01}
+	database.CreatePlace(s.db, fuel)
+	database.CreatePlace(s.db, charger)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1)
+	token := s.login(t, "manager")
+
+	w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID), token, nil)
+	var optimized struct{ Warnings []string }
+	json.Unmarshal(w.Body.Bytes(), &optimized)
+	if w.Code != http.StatusOK || len(optimized.Warnings) != 0 {
+		t.Fatalf("optimize = %d %v: %s", w.Code, optimized.Warnings, w.Body.String())
+	}
+	routes, _ := database.GetRoutesByPlan(s.db, plan.ID)
+	route, err := database.GetRouteWithStops(s.db, routes[0].ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the battery runs low on the way back, 88 of 150 km into the charge
+	if len(route.Stops) != 2 || route.Stops[1].Type != "charging" || route.Stops[1].PlaceID == nil ||
+		*route.Stops[1].PlaceID != charger.ID || route.Stops[1].DurationMinutes != 36 {
+		t.Fatalf("stops = %+v, want a delivery then 36 minutes at the charger", route.Stops)
+	}
+
+	warnings := func(t *testing.T, method, path string, body interface{}, status int) []string {
+		t.Helper()
+		w := s.do(t, method, path, token, body)
+		var edited struct{ Warnings []string }
+		json.Unmarshal(w.Body.Bytes(), &edited)
+		if w.Code != status {
+			t.Errorf("%s %s status = %d, want %d: %s", method, path, w.Code, status, w.Body.String())
+		}
+		return edited.Warnings
+	}
+	stops := fmt.Sprintf("/api/v1/routes/%d/stops", route.ID)
+
+	t.Run("without the charging stop", func(t *testing.T) {
+		got := warnings(t, "DELETE", fmt.Sprintf("/api/v1/stops/%d", route.Stops[1].ID), nil, http.StatusOK)
+		if len(got) != 1 || !strings.Contains(got[0], "175 km without charging") {
+			t.Errorf("delete charging stop warnings = %v, want the way back out of range", got)
+		}
+	})
+
+	t.Run("refuelling does not charge", func(t *testing.T) {
+		got := warnings(t, "POST", stops, InsertStopRequest{PlaceID: fuel.ID, Type: "refuel", DurationMinutes: 10}, http.StatusCreated)
+		if len(got) != 1 {
+			t.Errorf("insert refuel stop warnings = %v, want a range warning", got)
+		}
+	})
+
+	t.Run("charging again", func(t *testing.T) {
+		got := warnings(t, "POST", stops, InsertStopRequest{PlaceID: charger.ID, Type: "charging", Sequence: 2, DurationMinutes: 30}, http.StatusCreated)
+		if len(got) != 0 {
+			t.Errorf("insert charging stop warnings = %v, want none", got)
+		}
+	})
+}
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"LogiTrackPro/backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BulkDeleteRequest deletes the records of ids, at most 500
+type BulkDeleteRequest struct {
+	IDs    []int64 `json:"ids" binding:"required,min=1,max=500,unique,dive,gt=0"`
+	Atomic bool    `json:"atomic"`
+}
+
+// BulkCustomerUpdateRequest applies the same changes to every customer of
+// ids. Versions are not checked; external_ref can only be cleared since it
+// is unique.
+type BulkCustomerUpdateRequest struct {
+	IDs     []int64       `json:"ids" binding:"required,min=1,max=500,unique,dive,gt=0"`
+	Changes CustomerPatch `json:"changes"`
+	Atomic  bool          `json:"atomic"`
+}
+
+// BulkVehicleUpdateRequest is BulkCustomerUpdateRequest for vehicles
+type BulkVehicleUpdateRequest struct {
+	IDs     []int64      `json:"ids" binding:"required,min=1,max=500,unique,dive,gt=0"`
+	Changes VehiclePatch `json:"changes"`
+	Atomic  bool         `json:"atomic"`
+}
+
+// BulkResult is the outcome of one item of a bulk request, with the status
+// code it would have had as a request of its own
+type BulkResult struct {
+	ID      int64       `json:"id"`
+	Success bool        `json:"success"`
+	Status  int         `json:"status"`
+	Error   string      `json:"error,omitempty"`
+	Record  interface{} `json:"record,omitempty"`
+}
+
+// bulkItem changes one record within a bulk request's transaction,
+// returning the record changed, the status code of the outcome and, when it
+// failed, the error message
+type bulkItem func(tx *gorm.DB, id int64) (interface{}, int, string)
+
+// runBulk applies item to each ID, in order, within one transaction and
+// responds with the outcome of each. An item that fails is rolled back on
+// its own and reported while the others are kept, unless atomic is set, in
+// which case the first failure rolls back every item, as in
+// BulkUpdateExecutions.
+func (h *Handler) runBulk(c *gin.Context, ids []int64, atomic bool, item bulkItem, failure string) {
+	results := make([]BulkResult, len(ids))
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			var record interface{}
+			status, message := http.StatusOK, ""
+			err := tx.Transaction(func(itemTx *gorm.DB) error {
+				if record, status, message = item(itemTx, id); message != "" {
+					return errors.New(message)
+				}
+				return nil
+			})
+			if err == nil {
+				results[i] = BulkResult{ID: id, Success: true, Status: status, Record: record}
+				continue
+			}
+			if message == "" {
+				status, message = http.StatusInternalServerError, failure
+			}
+			results[i] = BulkResult{ID: id, Status: status, Error: message}
+			if atomic {
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkAborted) {
+		errorResponse(c, http.StatusInternalServerError, failure)
+		return
+	}
+
+	succeeded := 0
+	for i := range results {
+		r := &results[i]
+		if r.Success && errors.Is(err, errBulkAborted) {
+			*r = BulkResult{ID: r.ID, Status: http.StatusConflict, Error: "Rolled back: another item failed"}
+		}
+		if r.ID == 0 {
+			// not reached after an atomic request failed
+			*r = BulkResult{ID: ids[i], Status: http.StatusConflict, Error: "Rolled back: another item failed"}
+		}
+		if r.Success {
+			succeeded++
+		}
+	}
+	successResponse(c, gin.H{
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
+
+// BulkDeleteCustomers handles POST /api/v1/customers/bulk-delete
+func (h *Handler) BulkDeleteCustomers(c *gin.Context) {
+	var req BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	h.runBulk(c, req.IDs, req.Atomic, func(tx *gorm.DB, id int64) (interface{}, int, string) {
+		if err := database.DeleteCustomer(tx, id); err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				return nil, http.StatusNotFound, "Customer not found"
+			}
+			return nil, http.StatusInternalServerError, "Failed to delete customer"
+		}
+		return nil, http.StatusOK, ""
+	}, "Failed to delete customers")
+}
+
+// BulkUpdateCustomers handles POST /api/v1/customers/bulk-update
+// Changes the fields in changes, like PatchCustomer, on every customer of
+// ids, e.g. to move them to another warehouse or reprioritize them.
+func (h *Handler) BulkUpdateCustomers(c *gin.Context) {
+	var req BulkCustomerUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.Changes.ExternalRef != nil && *req.Changes.ExternalRef != "" {
+		errorResponse(c, http.StatusBadRequest, "external_ref is unique and can only be cleared in bulk")
+		return
+	}
+	if !h.bulkWarehouseExists(c, req.Changes.WarehouseID) {
+		return
+	}
+	h.runBulk(c, req.IDs, req.Atomic, func(tx *gorm.DB, id int64) (interface{}, int, string) {
+		customer, err := database.GetCustomer(tx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				return nil, http.StatusNotFound, "Customer not found"
+			}
+			return nil, http.StatusInternalServerError, "Failed to fetch customer"
+		}
+		customer.Version = 0
+		if columns := req.Changes.apply(customer); len(columns) > 0 {
+			if err := database.UpdateCustomerColumns(tx, customer, append(columns, "updated_at")); err != nil {
+				return nil, http.StatusInternalServerError, "Failed to update customer"
+			}
+		}
+		return customer, http.StatusOK, ""
+	}, "Failed to update customers")
+}
+
+// BulkDeleteVehicles handles POST /api/v1/vehicles/bulk-delete
+func (h *Handler) BulkDeleteVehicles(c *gin.Context) {
+	var req BulkDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	h.runBulk(c, req.IDs, req.Atomic, func(tx *gorm.DB, id int64) (interface{}, int, string) {
+		if err := database.DeleteVehicle(tx, id); err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				return nil, http.StatusNotFound, "Vehicle not found"
+			}
+			return nil, http.StatusInternalServerError, "Failed to delete vehicle"
+		}
+		return nil, http.StatusOK, ""
+	}, "Failed to delete vehicles")
+}
+
+// BulkUpdateVehicles handles POST /api/v1/vehicles/bulk-update
+// Changes the fields in changes, like PatchVehicle, on every vehicle of
+// ids, e.g. to take them out of service. Each vehicle's shift is checked
+// as the changes leave it.
+func (h *Handler) BulkUpdateVehicles(c *gin.Context) {
+	var req BulkVehicleUpdateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.Changes.ExternalRef != nil && *req.Changes.ExternalRef != "" {
+		errorResponse(c, http.StatusBadRequest, "external_ref is unique and can only be cleared in bulk")
+		return
+	}
+	if !h.bulkWarehouseExists(c, req.Changes.WarehouseID) {
+		return
+	}
+	h.runBulk(c, req.IDs, req.Atomic, func(tx *gorm.DB, id int64) (interface{}, int, string) {
+		vehicle, err := database.GetVehicle(tx, id)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				return nil, http.StatusNotFound, "Vehicle not found"
+			}
+			return nil, http.StatusInternalServerError, "Failed to fetch vehicle"
+		}
+		vehicle.Version = 0
+		columns := req.Changes.apply(vehicle)
+		shift := VehicleRequest{ShiftStart: vehicle.ShiftStart, ShiftEnd: vehicle.ShiftEnd}
+		if err := shift.validateShift(); err != nil {
+			return nil, http.StatusBadRequest, "Invalid request: " + err.Error()
+		}
+		if len(columns) > 0 {
+			if err := database.UpdateVehicleColumns(tx, vehicle, append(columns, "updated_at")); err != nil {
+				return nil, http.StatusInternalServerError, "Failed to update vehicle"
+			}
+		}
+		return vehicle, http.StatusOK, ""
+	}, "Failed to update vehicles")
+}
+
+// bulkWarehouseExists checks the warehouse a bulk update assigns exists,
+// responding 400 when it does not. nil and 0, which clears it, pass.
+func (h *Handler) bulkWarehouseExists(c *gin.Context, id *int64) bool {
+	if id == nil || *id == 0 {
+		return true
+	}
+	if _, err := database.GetWarehouse(h.db, *id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusBadRequest, "Warehouse not found")
+			return false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return false
+	}
+	return true
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+type bulkResponse struct {
+	Data struct {
+		Succeeded int
+		Failed    int
+		Results   []BulkResult
+	}
+}
+
+// TestBulkCustomers tests bulk updates and deletes of customers with
+// per-item results, and that atomic requests roll back on a failure
+func TestBulkCustomers(t *testing.T) {
+	s := newTestServer(t)
+	api := s.api.Group("", s.h.RoleMiddleware("admin", "manager"))
+	api.POST("/customers/bulk-delete", s.h.BulkDeleteCustomers)
+	api.POST("/customers/bulk-update", s.h.BulkUpdateCustomers)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	a, b, c := s.fx.Customer(), s.fx.Customer(), s.fx.Customer()
+
+	do := func(t *testing.T, path string, body interface{}) bulkResponse {
+		t.Helper()
+		w := s.do(t, "POST", path, token, body)
+		if w.Code != http.StatusOK {
+			t.Fatalf("POST %s status = %d: %s", path, w.Code, w.Body.String())
+		}
+		var resp bulkResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	t.Run("update", func(t *testing.T) {
+		priority := 5
+		resp := do(t, "/api/v1/customers/bulk-update", BulkCustomerUpdateRequest{
+			IDs:     []int64{a.ID, 9999, b.ID},
+			Changes: CustomerPatch{Priority: &priority, WarehouseID: &warehouse.ID},
+		})
+		if resp.Data.Succeeded != 2 || resp.Data.Failed != 1 || resp.Data.Results[1].Status != http.StatusNotFound || !resp.Data.Results[2].Success {
+			t.Fatalf("bulk update = %+v, want two updated and the unknown customer not found", resp.Data)
+		}
+		for _, id := range []int64{a.ID, b.ID} {
+			if got, _ := database.GetCustomer(s.db, id); got.Priority != 5 || got.WarehouseID == nil || *got.WarehouseID != warehouse.ID || got.Name == "" {
+				t.Errorf("customer %d = %+v, want priority 5 at the warehouse with the rest kept", id, got)
+			}
+		}
+		if got, _ := database.GetCustomer(s.db, c.ID); got.Priority == 5 {
+			t.Errorf("customer not in the request was updated")
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		missing := int64(9999)
+		if w := s.do(t, "POST", "/api/v1/customers/bulk-update", token, BulkCustomerUpdateRequest{
+			IDs: []int64{a.ID}, Changes: CustomerPatch{WarehouseID: &missing},
+		}); w.Code != http.StatusBadRequest {
+			t.Errorf("bulk update to an unknown warehouse status = %d, want 400", w.Code)
+		}
+		ref := "ERP-1"
+		if w := s.do(t, "POST", "/api/v1/customers/bulk-update", token, BulkCustomerUpdateRequest{
+			IDs: []int64{a.ID, b.ID}, Changes: CustomerPatch{ExternalRef: &ref},
+		}); w.Code != http.StatusBadRequest {
+			t.Errorf("bulk update setting external_ref status = %d, want 400", w.Code)
+		}
+		if w := s.do(t, "POST", "/api/v1/customers/bulk-delete", token, BulkDeleteRequest{IDs: []int64{a.ID, a.ID}}); w.Code != http.StatusBadRequest {
+			t.Errorf("bulk delete with duplicate IDs status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("atomic delete rolls back", func(t *testing.T) {
+		resp := do(t, "/api/v1/customers/bulk-delete", BulkDeleteRequest{IDs: []int64{a.ID, 9999, b.ID}, Atomic: true})
+		if resp.Data.Succeeded != 0 || resp.Data.Failed != 3 || resp.Data.Results[0].Status != http.StatusConflict || resp.Data.Results[2].ID != b.ID {
+			t.Fatalf("atomic bulk delete = %+v, want every item rolled back", resp.Data)
+		}
+		if _, err := database.GetCustomer(s.db, a.ID); err != nil {
+			t.Errorf("customer of a rolled back delete: %v", err)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := do(t, "/api/v1/customers/bulk-delete", BulkDeleteRequest{IDs: []int64{a.ID, 9999, b.ID}})
+		if resp.Data.Succeeded != 2 || resp.Data.Failed != 1 {
+			t.Fatalf("bulk delete = %+v, want two deleted", resp.Data)
+		}
+		var left []models.Customer
+		s.db.Find(&left)
+		if len(left) != 1 || left[0].ID != c.ID {
+			t.Errorf("customers left = %+v, want only the one not deleted", left)
+		}
+	})
+}
+
+// TestBulkVehicles tests that bulk vehicle updates check each vehicle's
+// shift as the changes leave it
+func TestBulkVehicles(t *testing.T) {
+	s := newTestServer(t)
+	api := s.api.Group("", s.h.RoleMiddleware("admin", "manager"))
+	api.POST("/vehicles/bulk-delete", s.h.BulkDeleteVehicles)
+	api.POST("/vehicles/bulk-update", s.h.BulkUpdateVehicles)
+
+	token := s.login(t, "admin")
+	warehouse := s.fx.Warehouse()
+	early := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.ShiftStart = "06:00" })
+	late := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.ShiftStart = "14:00" })
+
+	t.Run("update", func(t *testing.T) {
+		end := "12:00"
+		unavailable := false
+		w := s.do(t, "POST", "/api/v1/vehicles/bulk-update", token, BulkVehicleUpdateRequest{
+			IDs:     []int64{early.ID, late.ID},
+			Changes: VehiclePatch{ShiftEnd: &end, Available: &unavailable},
+		})
+		var resp bulkResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || resp.Data.Succeeded != 1 || resp.Data.Results[1].Status != http.StatusBadRequest {
+			t.Fatalf("bulk update status = %d with %+v, want the late vehicle's shift refused", w.Code, resp.Data)
+		}
+		if got, _ := database.GetVehicle(s.db, early.ID); got.Available || got.ShiftEnd != end || got.Version != early.Version+1 {
+			t.Errorf("early vehicle = %+v, want unavailable until noon in a new version", got)
+		}
+		if got, _ := database.GetVehicle(s.db, late.ID); !got.Available {
+			t.Errorf("late vehicle was changed despite failing")
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		w := s.do(t, "POST", "/api/v1/vehicles/bulk-delete", token, BulkDeleteRequest{IDs: []int64{early.ID, late.ID}})
+		var resp bulkResponse
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Data.Succeeded != 2 {
+			t.Errorf("bulk delete = %+v, want both deleted", resp.Data)
+		}
+	})
+}
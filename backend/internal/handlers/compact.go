@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// compactView reports whether a request asks for ?view=compact (see
+// models.CompactRoute). view may also be "full", the default.
+func compactView(c *gin.Context) (bool, error) {
+	switch view := c.Query("view"); view {
+	case "", "full":
+		return false, nil
+	case "compact":
+		return true, nil
+	default:
+		return false, fmt.Errorf("invalid view %q (use full or compact)", view)
+	}
+}
+
+// unixTime is t in Unix seconds, or 0 for no time
+func unixTime(t *time.Time) int64 {
+	if t == nil {
+		return 0
+	}
+	return t.Unix()
+}
+
+func compactRoute(r models.DriverRoute) models.CompactRoute {
+	route := models.CompactRoute{
+		ID:      r.RouteID,
+		PlanID:  r.PlanID,
+		Date:    r.Date.Unix(),
+		Vehicle: r.VehicleName,
+		Start:   unixTime(r.PlannedStart),
+		End:     unixTime(r.PlannedEnd),
+		Load:    r.TotalLoad,
+		Status:  r.ExecutionStatus,
+		Notes:   r.Notes,
+		Stops:   make([]models.CompactStop, len(r.Stops)),
+	}
+	if r.ExecutionID != nil {
+		route.ExecutionID = *r.ExecutionID
+	}
+	for i, s := range r.Stops {
+		stop := models.CompactStop{
+			ID:          s.StopID,
+			Sequence:    s.Sequence,
+			Name:        s.CustomerName,
+			Address:     s.Address,
+			Latitude:    s.Latitude,
+			Longitude:   s.Longitude,
+			Quantity:    s.Quantity,
+			ArrivalTime: s.ArrivalTime,
+			Status:      s.Status,
+			Delivered:   s.ActualQuantity,
+		}
+		if s.StopExecutionID != nil {
+			stop.ExecutionID = *s.StopExecutionID
+		}
+		route.Stops[i] = stop
+	}
+	return route
+}
+
+func compactExecution(e *models.RouteExecution) models.CompactExecution {
+	return models.CompactExecution{
+		ID:             e.ID,
+		RouteID:        e.RouteID,
+		Status:         e.Status,
+		Started:        unixTime(e.ActualStartTime),
+		Ended:          unixTime(e.ActualEndTime),
+		Distance:       e.ActualDistance,
+		Load:           e.ActualLoad,
+		CompletedStops: e.Progress.CompletedStops,
+		TotalStops:     e.Progress.TotalStops,
+		Stops:          compactStopExecutions(e.StopExecutions),
+	}
+}
+
+func compactStopExecutions(stops []models.StopExecution) []models.CompactStopExecution {
+	compact := make([]models.CompactStopExecution, len(stops))
+	for i := range stops {
+		compact[i] = compactStopExecution(&stops[i])
+	}
+	return compact
+}
+
+func compactStopExecution(s *models.StopExecution) models.CompactStopExecution {
+	return models.CompactStopExecution{
+		ID:        s.ID,
+		StopID:    s.StopID,
+		Status:    s.Status,
+		Planned:   s.PlannedQuantity,
+		Delivered: s.ActualQuantity,
+		Arrived:   unixTime(s.ActualArrivalTime),
+		Departed:  unixTime(s.ActualDepartureTime),
+		Notes:     s.Notes,
+	}
+}
@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ConditionalGetMiddleware lets clients that poll re-fetch responses only
+// when they change. Successful JSON GETs get a weak ETag hashing the body,
+// after the record version when the handler set one (W/"3-…", which
+// If-Match accepts as version 3), and a GET whose If-None-Match has the ETag
+// is answered 304 without a body. There is no Last-Modified: a response
+// such as a plan with its routes has no single update time to give, and the
+// hash holds whichever instance serves it. Event streams, downloads and
+// other non-JSON responses are passed through as they are written.
+func (h *Handler) ConditionalGetMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+		w := &bufferedWriter{ResponseWriter: c.Writer, status: http.StatusOK}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		if w.passthrough {
+			return
+		}
+
+		if w.status != http.StatusOK {
+			w.flush()
+			return
+		}
+		header := w.Header()
+		sum := sha256.Sum256(w.body.Bytes())
+		tag := hex.EncodeToString(sum[:10])
+		if version := strings.Trim(header.Get("ETag"), `"`); version != "" {
+			tag = version + "-" + tag
+		}
+		etag := `W/"` + tag + `"`
+		header.Set("ETag", etag)
+		header.Set("Cache-Control", "private, no-cache")
+
+		if noneMatch(c.Request, etag) {
+			header.Del("Content-Type")
+			header.Del("Content-Length")
+			w.status = http.StatusNotModified
+			w.body.Reset()
+		}
+		w.flush()
+	}
+}
+
+// noneMatch reports whether a GET's If-None-Match has etag, so that the
+// response is not modified
+func noneMatch(r *http.Request, etag string) bool {
+	for _, candidate := range strings.Split(r.Header.Get("If-None-Match"), ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate != "" && strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// bufferedWriter holds a JSON response back until it is complete. Anything
+// else, such as an event stream or a file download, which may be large or
+// never end, is written through as it comes.
+type bufferedWriter struct {
+	gin.ResponseWriter
+	status      int
+	body        bytes.Buffer
+	decided     bool
+	passthrough bool
+}
+
+func (w *bufferedWriter) decide() {
+	if w.decided {
+		return
+	}
+	w.decided = true
+	header := w.Header()
+	if !strings.HasPrefix(header.Get("Content-Type"), "application/json") || header.Get("Content-Disposition") != "" {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+}
+
+func (w *bufferedWriter) WriteHeader(code int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	w.status = code
+}
+
+func (w *bufferedWriter) WriteHeaderNow() {
+	w.decide()
+	if w.passthrough {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *bufferedWriter) Write(b []byte) (int, error) {
+	w.decide()
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.body.Write(b)
+}
+
+func (w *bufferedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *bufferedWriter) Flush() {
+	w.decide()
+	if w.passthrough {
+		w.ResponseWriter.Flush()
+	}
+}
+
+func (w *bufferedWriter) Status() int {
+	if w.passthrough {
+		return w.ResponseWriter.Status()
+	}
+	return w.status
+}
+
+func (w *bufferedWriter) Size() int {
+	if w.passthrough {
+		return w.ResponseWriter.Size()
+	}
+	return w.body.Len()
+}
+
+func (w *bufferedWriter) Written() bool {
+	return w.decided || w.body.Len() > 0
+}
+
+// flush writes the held response
+func (w *bufferedWriter) flush() {
+	w.ResponseWriter.WriteHeader(w.status)
+	if w.body.Len() == 0 {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.ResponseWriter.Write(w.body.Bytes())
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestConditionalGet tests that unchanged plans and analytics are answered
+// 304 by ETag, that downloads stream through unbuffered, and that responses
+// are gzipped for clients that accept it
+func TestConditionalGet(t *testing.T) {
+	s := newTestServer(t)
+	clock := testkit.NewClock(time.Now().UTC().Truncate(time.Second))
+	s.h.SetClock(clock)
+	// groups copy the engine's middleware when made, so this one is made
+	// after gzip is in
+	s.router.Use(s.h.GzipMiddleware())
+	api := s.router.Group("/api/v1", s.h.AuthMiddleware())
+	plans := api.Group("/plans", s.h.ConditionalGetMiddleware())
+	plans.GET("/:id", s.h.GetPlan)
+	plans.PUT("/:id/notes", s.h.SetPlanNotes)
+	plans.GET("/:id/export", s.h.ExportPlan)
+	api.Group("/analytics", s.h.ConditionalGetMiddleware()).GET("/dashboard", s.h.GetDashboard)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 7)
+	s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer(), s.fx.Customer())
+	planPath := fmt.Sprintf("/api/v1/plans/%d", plan.ID)
+
+	get := func(path string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := get(planPath, nil)
+	etag := w.Header().Get("ETag")
+	if w.Code != http.StatusOK || !strings.HasPrefix(etag, `W/"1-`) || w.Header().Get("Last-Modified") != "" || w.Header().Get("Content-Encoding") != "" {
+		t.Fatalf("GET plan status = %d, ETag %q, want 200 uncompressed with an ETag only", w.Code, etag)
+	}
+
+	t.Run("unchanged", func(t *testing.T) {
+		if again := get(planPath, nil); again.Header().Get("ETag") != etag {
+			t.Errorf("ETag of the unchanged plan = %q, want %q", again.Header().Get("ETag"), etag)
+		}
+		clock.Advance(time.Minute)
+		if w := get(planPath, map[string]string{"If-None-Match": `"other", ` + etag}); w.Code != http.StatusNotModified || w.Body.Len() != 0 || w.Header().Get("ETag") != etag {
+			t.Errorf("If-None-Match status = %d with %d bytes, want 304 without a body", w.Code, w.Body.Len())
+		}
+		if w := get(planPath+"?fields=id", map[string]string{"If-None-Match": etag}); w.Code != http.StatusOK {
+			t.Errorf("other fields status = %d, want 200", w.Code)
+		}
+	})
+
+	t.Run("edit changes the ETag", func(t *testing.T) {
+		req := httptest.NewRequest("PUT", planPath+"/notes", strings.NewReader(`{"notes":"Gate code 1234"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("If-Match", etag)
+		edit := httptest.NewRecorder()
+		s.router.ServeHTTP(edit, req)
+		if edit.Code != http.StatusOK {
+			t.Fatalf("edit with the GET's ETag status = %d: %s", edit.Code, edit.Body.String())
+		}
+		w := get(planPath, map[string]string{"If-None-Match": etag})
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("ETag"), `W/"2-`) {
+			t.Errorf("GET of the edited plan status = %d, ETag %q, want 200 with a new ETag", w.Code, w.Header().Get("ETag"))
+		}
+	})
+
+	t.Run("gzip", func(t *testing.T) {
+		w := get(planPath, map[string]string{"Accept-Encoding": "gzip, deflate"})
+		if w.Header().Get("Content-Encoding") != "gzip" || !strings.Contains(w.Header().Get("Vary"), "Accept-Encoding") {
+			t.Fatalf("Content-Encoding = %q, Vary = %q, want gzip varying by Accept-Encoding", w.Header().Get("Content-Encoding"), w.Header().Get("Vary"))
+		}
+		gz, err := gzip.NewReader(w.Body)
+		if err != nil {
+			t.Fatalf("gzip reader: %v", err)
+		}
+		body, _ := io.ReadAll(gz)
+		var resp struct{ Data struct{ Notes string } }
+		if err := json.Unmarshal(body, &resp); err != nil || resp.Data.Notes != "Gate code 1234" {
+			t.Errorf("decompressed plan = %s, want the edited notes", body)
+		}
+		notModified := get(planPath, map[string]string{"If-None-Match": w.Header().Get("ETag"), "Accept-Encoding": "gzip"})
+		if notModified.Code != http.StatusNotModified || notModified.Header().Get("Content-Encoding") != "" {
+			t.Errorf("gzip 304 status = %d, Content-Encoding %q, want 304 unencoded", notModified.Code, notModified.Header().Get("Content-Encoding"))
+		}
+		if w := get(planPath, map[string]string{"Accept-Encoding": "gzip;q=0"}); w.Header().Get("Content-Encoding") != "" {
+			t.Errorf("gzip;q=0 was gzipped")
+		}
+	})
+
+	t.Run("downloads pass through", func(t *testing.T) {
+		if w := get(planPath+"/export?format=csv", nil); w.Code != http.StatusOK || w.Header().Get("Content-Disposition") == "" || w.Header().Get("ETag") != "" {
+			t.Errorf("export status = %d, headers %v, want a download passed through", w.Code, w.Header())
+		}
+	})
+
+	t.Run("analytics", func(t *testing.T) {
+		// computed, and still unchanged until their data is
+		w := get("/api/v1/analytics/dashboard", nil)
+		if w := get("/api/v1/analytics/dashboard", map[string]string{"If-None-Match": w.Header().Get("ETag")}); w.Code != http.StatusNotModified {
+			t.Errorf("unchanged dashboard status = %d, want 304", w.Code)
+		}
+		s.fx.Customer()
+		if w := get("/api/v1/analytics/dashboard", map[string]string{"If-None-Match": w.Header().Get("ETag")}); w.Code != http.StatusOK {
+			t.Errorf("dashboard after a new customer status = %d, want 200", w.Code)
+		}
+	})
+}
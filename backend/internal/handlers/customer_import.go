@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/usage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// customerImportColumns are the customer fields an import sets
+var customerImportColumns = []string{
+	"external_ref", "name", "address", "latitude", "longitude", "demand_rate", "max_inventory", "current_inventory",
+	"min_inventory", "holding_cost", "priority", "min_drop_size", "service_tags", "warehouse_id",
+}
+
+// ImportCustomers handles POST /api/v1/customers/import
+// Creates and updates customers from a CSV or XLSX file (see readImport),
+// matched by external_ref: rows of known customers update them, others
+// create one. Empty cells leave a field as it is, or at its default. Rows
+// with errors are reported and skipped while the others are imported in
+// one transaction; with dry_run nothing is stored.
+func (h *Handler) ImportCustomers(c *gin.Context) {
+	file, ok := h.readImport(c, customerImportColumns)
+	if !ok {
+		return
+	}
+	existing, err := database.GetCustomersByExternalRef(h.customerScope(c), file.refs())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return
+	}
+	warehouseIDs, err := h.importWarehouseIDs()
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouses")
+		return
+	}
+
+	orgID, inOrg := organizationID(c)
+	imp := &importer[models.Customer]{
+		columns:  customerImportColumns,
+		required: []string{"name", "latitude", "longitude"},
+		existing: existing,
+		set: func(r *importCells, cu *models.Customer, field, value string) {
+			setCustomerField(r, cu, field, value, warehouseIDs)
+		},
+		check: func(r *importCells, cu *models.Customer) {
+			if cu.MaxInventory > 0 && cu.CurrentInventory > cu.MaxInventory {
+				r.fail("current_inventory", "must not exceed max_inventory")
+			}
+		},
+		id: func(cu *models.Customer) int64 { return cu.ID },
+		create: func(tx *gorm.DB, cu *models.Customer) error {
+			if inOrg {
+				cu.OrganizationID = &orgID
+			}
+			return database.CreateCustomer(tx, cu)
+		},
+		update: database.UpdateCustomerColumns,
+	}
+	result, records := planImport(file, imp)
+	if !h.customerImportQuota(c, result.Created) {
+		return
+	}
+	h.runImport(c, file, result, func(tx *gorm.DB) error {
+		return writeImport(tx, result, records, imp)
+	})
+}
+
+// setCustomerField sets a field of an imported customer from a cell
+func setCustomerField(r *importCells, cu *models.Customer, field, value string, warehouseIDs map[int64]bool) {
+	switch field {
+	case "external_ref":
+		cu.ExternalRef = value
+	case "name":
+		cu.Name = value
+	case "address":
+		cu.Address = value
+	case "latitude":
+		r.number(field, value, &cu.Latitude, -90, 90)
+	case "longitude":
+		r.number(field, value, &cu.Longitude, -180, 180)
+	case "demand_rate":
+		r.quantity(field, value, &cu.DemandRate)
+	case "max_inventory":
+		r.quantity(field, value, &cu.MaxInventory)
+	case "current_inventory":
+		r.quantity(field, value, &cu.CurrentInventory)
+	case "min_inventory":
+		r.quantity(field, value, &cu.MinInventory)
+	case "holding_cost":
+		r.quantity(field, value, &cu.HoldingCost)
+	case "min_drop_size":
+		r.quantity(field, value, &cu.MinDropSize)
+	case "priority":
+		r.integer(field, value, &cu.Priority)
+	case "service_tags":
+		cu.ServiceTags = r.list(value)
+	case "warehouse_id":
+		r.warehouse(field, value, warehouseIDs, &cu.WarehouseID)
+	}
+}
+
+// customerImportQuota checks the current organization's customer quota
+// leaves room for the customers an import creates. It responds with 402
+// and returns false when it does not.
+func (h *Handler) customerImportQuota(c *gin.Context, creates int) bool {
+	orgID, ok := organizationID(c)
+	if !ok || creates == 0 {
+		return true
+	}
+	limits, err := h.organizationLimits(orgID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch organization")
+		return false
+	}
+	current, err := h.metricUsage(orgID, usage.Customers, limits, h.clock.Now())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch usage")
+		return false
+	}
+	if current.Limit > 0 && current.Used+int64(creates) > int64(current.Limit) {
+		errorCodeResponse(c, usage.ExceededStatus(usage.Customers), codeQuotaExceeded, fmt.Sprintf("Importing %d new customers would exceed the quota of %d customers (%d used), upgrade your plan or contact support", creates, current.Limit, current.Used))
+		return false
+	}
+	return true
+}
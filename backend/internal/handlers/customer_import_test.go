@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/export"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestImportCustomers tests importing customers from CSV and Excel files:
+// column mapping, row errors, dry runs and upserts by external reference
+func TestImportCustomers(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/customers/import", s.h.RoleMiddleware("admin", "manager"), s.h.ImportCustomers)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	known := s.fx.Customer(func(c *models.Customer) { c.ExternalRef = "ERP-2" })
+
+	upload := func(t *testing.T, filename string, data []byte, fields map[string]string) (int, ImportResult) {
+		t.Helper()
+		return importUpload(t, s.router, "/api/v1/customers/import", token, filename, data, fields)
+	}
+
+	csv := []byte("Ref,Customer,Lat,Lon,demand_rate,service_tags,warehouse_id\n" +
+		"ERP-1,Acme,52.1,4.3,12,reefer; adr," + fmt.Sprint(warehouse.ID) + "\n" +
+		"ERP-2,,,,0,,\n" +
+		"ERP-3,Broken,95,4.3,-1,,999\n" +
+		"ERP-1,Again,52,4,,,\n" +
+		",Nameless,52,4,,,\n")
+	mapping := map[string]string{"mapping": `{"external_ref":"Ref","name":"Customer","latitude":"Lat","longitude":"Lon"}`}
+
+	t.Run("invalid uploads", func(t *testing.T) {
+		if code, _ := upload(t, "customers.csv", csv, nil); code != http.StatusBadRequest {
+			t.Errorf("unmapped external_ref status = %d, want 400", code)
+		}
+		if code, _ := upload(t, "customers.txt", csv, mapping); code != http.StatusBadRequest {
+			t.Errorf("unknown extension status = %d, want 400", code)
+		}
+	})
+
+	t.Run("dry run", func(t *testing.T) {
+		code, result := upload(t, "customers.csv", csv, map[string]string{"mapping": mapping["mapping"], "dry_run": "true"})
+		if code != http.StatusOK || !result.DryRun || result.Rows != 5 || result.Created != 1 || result.Updated != 1 || result.Failed != 3 {
+			t.Fatalf("dry run = %d %+v, want 1 created, 1 updated and 3 failed", code, result)
+		}
+		if r := result.Results[2]; r.Row != 4 || r.Action != importError || len(r.Errors) != 3 {
+			t.Errorf("row 4 = %+v, want latitude, demand rate and warehouse errors", r)
+		}
+		if r := result.Results[3]; r.Errors[0].Column != "external_ref" || r.Errors[0].Message != "also in row 2" {
+			t.Errorf("row 5 = %+v, want the repeated external_ref", r)
+		}
+		if count, _ := database.CountCustomers(s.db); count != 1 {
+			t.Errorf("customers after the dry run = %d, want 1", count)
+		}
+	})
+
+	t.Run("csv", func(t *testing.T) {
+		code, result := upload(t, "customers.csv", csv, mapping)
+		if code != http.StatusOK || result.DryRun || result.Created != 1 || result.Updated != 1 || result.Results[0].ID == nil {
+			t.Fatalf("import = %d %+v, want 1 created and 1 updated", code, result)
+		}
+		created, _ := database.GetCustomer(s.db, *result.Results[0].ID)
+		if created.Name != "Acme" || created.DemandRate != 12 || len(created.ServiceTags) != 2 || created.WarehouseID == nil || created.ExternalRef != "ERP-1" {
+			t.Errorf("created = %+v, want Acme with its tags and warehouse", created)
+		}
+		// only the cells given change, zeroes included
+		updated, _ := database.GetCustomer(s.db, known.ID)
+		if updated.Name != known.Name || updated.DemandRate != 0 || updated.CurrentInventory != known.CurrentInventory {
+			t.Errorf("updated = %+v, want only the demand rate zeroed", updated)
+		}
+	})
+
+	t.Run("xlsx updates", func(t *testing.T) {
+		var buf bytes.Buffer
+		w, _ := export.New(export.XLSX, &buf)
+		w.Sheet("Customers", "external_ref", "name", "latitude", "longitude")
+		w.Row("ERP-1", "Acme Fuels", 52.2, 4.4)
+		w.Close()
+		code, result := upload(t, "customers.xlsx", buf.Bytes(), nil)
+		if code != http.StatusOK || result.Updated != 1 || result.Created != 0 {
+			t.Fatalf("xlsx import = %d %+v, want 1 updated", code, result)
+		}
+		var renamed models.Customer
+		if s.db.Where("external_ref = ?", "ERP-1").First(&renamed); renamed.Name != "Acme Fuels" || renamed.Latitude != 52.2 {
+			t.Errorf("renamed = %+v, want Acme Fuels moved", renamed)
+		}
+	})
+}
+
+// importUpload posts a file to an import endpoint and decodes the result
+func importUpload(t *testing.T, router *gin.Engine, path, token, filename string, data []byte, fields map[string]string) (int, ImportResult) {
+	t.Helper()
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		mw.WriteField(name, value)
+	}
+	fw, _ := mw.CreateFormFile("file", filename)
+	fw.Write(data)
+	mw.Close()
+	req := httptest.NewRequest("POST", path, &buf)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	var resp struct{ Data ImportResult }
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.Data
+}
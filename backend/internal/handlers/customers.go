@@ -2,31 +2,144 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/usage"
 
 	"github.com/gin-gonic/gin"
 )
 
 type CustomerRequest struct {
-	Name             string  `json:"name" binding:"required"`
-	Address          string  `json:"address"`
-	Latitude         float64 `json:"latitude" binding:"required"`
-	Longitude        float64 `json:"longitude" binding:"required"`
-	DemandRate       float64 `json:"demand_rate"`
-	MaxInventory     float64 `json:"max_inventory"`
-	CurrentInventory float64 `json:"current_inventory"`
-	MinInventory     float64 `json:"min_inventory"`
-	HoldingCost      float64 `json:"holding_cost"`
-	Priority         int     `json:"priority"`
-}
-
-// ListCustomers handles GET /api/v1/customers
+	Name             string   `json:"name" binding:"required"`
+	Address          string   `json:"address"`
+	Latitude         float64  `json:"latitude" binding:"required"`
+	Longitude        float64  `json:"longitude" binding:"required"`
+	DemandRate       float64  `json:"demand_rate"`
+	MaxInventory     float64  `json:"max_inventory"`
+	CurrentInventory float64  `json:"current_inventory"`
+	MinInventory     float64  `json:"min_inventory"`
+	HoldingCost      float64  `json:"holding_cost"`
+	Priority         int      `json:"priority"`
+	ProductID        *int64   `json:"product_id"`
+	ServiceTags      []string `json:"service_tags"`
+	MinDropSize      float64  `json:"min_drop_size" binding:"gte=0"`
+	WarehouseID      *int64   `json:"warehouse_id"`
+	ExternalRef      string   `json:"external_ref" binding:"max=100"`
+	// Version is the version of the customer edited; see expectedVersion
+	Version int64 `json:"version"`
+}
+
+// CustomerPatch changes the fields of a customer it has. product_id and
+// warehouse_id 0 clear them.
+type CustomerPatch struct {
+	Name             *string   `json:"name" binding:"omitempty,min=1"`
+	Address          *string   `json:"address"`
+	Latitude         *float64  `json:"latitude" binding:"omitempty,gte=-90,lte=90"`
+	Longitude        *float64  `json:"longitude" binding:"omitempty,gte=-180,lte=180"`
+	DemandRate       *float64  `json:"demand_rate"`
+	MaxInventory     *float64  `json:"max_inventory"`
+	CurrentInventory *float64  `json:"current_inventory"`
+	MinInventory     *float64  `json:"min_inventory"`
+	HoldingCost      *float64  `json:"holding_cost"`
+	Priority         *int      `json:"priority"`
+	ProductID        *int64    `json:"product_id"`
+	ServiceTags      *[]string `json:"service_tags"`
+	MinDropSize      *float64  `json:"min_drop_size" binding:"omitempty,gte=0"`
+	WarehouseID      *int64    `json:"warehouse_id"`
+	ExternalRef      *string   `json:"external_ref" binding:"omitempty,max=100"`
+	Version          int64     `json:"version"`
+}
+
+// apply applies the patch to a customer and returns the columns it changes
+func (p CustomerPatch) apply(cu *models.Customer) []string {
+	var columns []string
+	patchField(&columns, "name", &cu.Name, p.Name)
+	patchField(&columns, "address", &cu.Address, p.Address)
+	patchField(&columns, "latitude", &cu.Latitude, p.Latitude)
+	patchField(&columns, "longitude", &cu.Longitude, p.Longitude)
+	patchField(&columns, "demand_rate", &cu.DemandRate, p.DemandRate)
+	patchField(&columns, "max_inventory", &cu.MaxInventory, p.MaxInventory)
+	patchField(&columns, "current_inventory", &cu.CurrentInventory, p.CurrentInventory)
+	patchField(&columns, "min_inventory", &cu.MinInventory, p.MinInventory)
+	patchField(&columns, "holding_cost", &cu.HoldingCost, p.HoldingCost)
+	patchField(&columns, "priority", &cu.Priority, p.Priority)
+	patchID(&columns, "product_id", &cu.ProductID, p.ProductID)
+	patchField(&columns, "service_tags", &cu.ServiceTags, p.ServiceTags)
+	patchField(&columns, "min_drop_size", &cu.MinDropSize, p.MinDropSize)
+	patchID(&columns, "warehouse_id", &cu.WarehouseID, p.WarehouseID)
+	patchField(&columns, "external_ref", &cu.ExternalRef, p.ExternalRef)
+	return columns
+}
+
+// toCustomer builds the customer model from a request
+func (r CustomerRequest) toCustomer(id int64) *models.Customer {
+	return &models.Customer{
+		ID:               id,
+		Name:             r.Name,
+		Address:          r.Address,
+		Latitude:         r.Latitude,
+		Longitude:        r.Longitude,
+		DemandRate:       r.DemandRate,
+		MaxInventory:     r.MaxInventory,
+		CurrentInventory: r.CurrentInventory,
+		MinInventory:     r.MinInventory,
+		HoldingCost:      r.HoldingCost,
+		Priority:         r.Priority,
+		ProductID:        r.ProductID,
+		ServiceTags:      r.ServiceTags,
+		MinDropSize:      r.MinDropSize,
+		WarehouseID:      r.WarehouseID,
+		ExternalRef:      r.ExternalRef,
+	}
+}
+
+// customerSortColumns are the fields customers can be sorted by
+var customerSortColumns = map[string]string{
+	"name":              "name",
+	"priority":          "priority",
+	"demand_rate":       "demand_rate",
+	"current_inventory": "current_inventory",
+	"created_at":        "created_at",
+	"updated_at":        "updated_at",
+}
+
+// ListCustomers handles GET /api/v1/customers?page=&limit=&q=&min_priority=&max_priority=&bbox=&tag=&sort=
+// q searches names and addresses; bbox is min_lon,min_lat,max_lon,max_lat;
+// tag is a comma-separated list of tag names, any of which matches.
+// Customers are sorted by name unless sort says otherwise.
 func (h *Handler) ListCustomers(c *gin.Context) {
-	customers, err := database.ListCustomers(h.db)
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.CustomerFilter{Search: strings.TrimSpace(c.Query("q")), Tags: parseTagQuery(c)}
+	if filter.MinPriority, err = parseIntQuery(c, "min_priority"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.MaxPriority, err = parseIntQuery(c, "max_priority"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Box, err = parseBoundingBox(c.Query("bbox")); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Order, err = parseSort(c, customerSortColumns, "name"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	customers, total, err := h.customers.Page(filter, page.Offset(), page.Limit)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customers")
 		return
@@ -34,7 +147,36 @@ func (h *Handler) ListCustomers(c *gin.Context) {
 	if customers == nil {
 		customers = []models.Customer{}
 	}
-	successResponse(c, customers)
+	page.SetTotal(total)
+	paginatedResponse(c, customers, page)
+}
+
+// parseBoundingBox reads a bounding box as min_lon,min_lat,max_lon,max_lat.
+// A min_lon east of max_lon crosses the antimeridian.
+func parseBoundingBox(raw string) (*database.BoundingBox, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	parts := strings.Split(raw, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("bbox must be min_lon,min_lat,max_lon,max_lat")
+	}
+	var v [4]float64
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, errors.New("bbox must be min_lon,min_lat,max_lon,max_lat")
+		}
+		v[i] = f
+	}
+	box := &database.BoundingBox{MinLongitude: v[0], MinLatitude: v[1], MaxLongitude: v[2], MaxLatitude: v[3]}
+	switch {
+	case box.MinLatitude < -90 || box.MaxLatitude > 90 || math.Abs(box.MinLongitude) > 180 || math.Abs(box.MaxLongitude) > 180:
+		return nil, errors.New("bbox is outside -180..180 longitude and -90..90 latitude")
+	case box.MinLatitude > box.MaxLatitude:
+		return nil, errors.New("bbox min_lat must not be above max_lat")
+	}
+	return box, nil
 }
 
 // GetCustomer handles GET /api/v1/customers/:id
@@ -45,44 +187,52 @@ func (h *Handler) GetCustomer(c *gin.Context) {
 		return
 	}
 
-	customer, err := database.GetCustomer(h.db, id)
+	customer, err := h.customers.Get(id)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Customer not found")
+			notFoundResponse(c, "Customer")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
 		return
 	}
+	setETag(c, customer.Version)
 	successResponse(c, customer)
 }
 
 // CreateCustomer handles POST /api/v1/customers
+// Customers out of reach of the vehicles are stored with a warning (see
+// customerReachWarnings).
 func (h *Handler) CreateCustomer(c *gin.Context) {
 	var req CustomerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
+	h.createCustomer(c, req.toCustomer(0))
+}
 
-	customer := &models.Customer{
-		Name:             req.Name,
-		Address:          req.Address,
-		Latitude:         req.Latitude,
-		Longitude:        req.Longitude,
-		DemandRate:       req.DemandRate,
-		MaxInventory:     req.MaxInventory,
-		CurrentInventory: req.CurrentInventory,
-		MinInventory:     req.MinInventory,
-		HoldingCost:      req.HoldingCost,
-		Priority:         req.Priority,
+// createCustomer stores a new customer and responds with it
+func (h *Handler) createCustomer(c *gin.Context, customer *models.Customer) {
+	if !h.uniqueExternalRef(c, "customer", h.customerScope(c), &models.Customer{}, customer.ID, customer.ExternalRef) {
+		return
+	}
+	warnings, ok := h.customerReachWarnings(c, customer)
+	if !ok {
+		return
+	}
+	if !h.reserveUsage(c, usage.Customers) {
+		return
+	}
+	if orgID, ok := organizationID(c); ok {
+		customer.OrganizationID = &orgID
 	}
 
-	if err := database.CreateCustomer(h.db, customer); err != nil {
+	if err := h.customers.Create(customer); err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to create customer")
 		return
 	}
-	createdResponse(c, customer)
+	createdWarningResponse(c, customer, warnings)
 }
 
 // UpdateCustomer handles PUT /api/v1/customers/:id
@@ -95,33 +245,133 @@ func (h *Handler) UpdateCustomer(c *gin.Context) {
 
 	var req CustomerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
 		return
 	}
+	customer := req.toCustomer(id)
+	customer.Version = version
+	h.updateCustomer(c, customer)
+}
 
-	customer := &models.Customer{
-		ID:               id,
-		Name:             req.Name,
-		Address:          req.Address,
-		Latitude:         req.Latitude,
-		Longitude:        req.Longitude,
-		DemandRate:       req.DemandRate,
-		MaxInventory:     req.MaxInventory,
-		CurrentInventory: req.CurrentInventory,
-		MinInventory:     req.MinInventory,
-		HoldingCost:      req.HoldingCost,
-		Priority:         req.Priority,
-	}
-
-	if err := database.UpdateCustomer(h.db, customer); err != nil {
+// updateCustomer stores a customer's changes and responds with it. Unless
+// the customer's Version is 0 it must be the stored one.
+func (h *Handler) updateCustomer(c *gin.Context, customer *models.Customer) {
+	if !h.uniqueExternalRef(c, "customer", h.customerScope(c), &models.Customer{}, customer.ID, customer.ExternalRef) {
+		return
+	}
+	warnings, ok := h.customerReachWarnings(c, customer)
+	if !ok {
+		return
+	}
+
+	if err := h.customers.Update(customer); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Customer not found")
+			notFoundResponse(c, "Customer")
+			return
+		}
+		if versionConflict(c, "Customer", err) {
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to update customer")
 		return
 	}
-	successResponse(c, customer)
+	setETag(c, customer.Version)
+	warningResponse(c, customer, warnings)
+}
+
+// GetCustomerByRef handles GET /api/v1/customers/by-ref/:ref
+// External references are unique within the user's organization.
+func (h *Handler) GetCustomerByRef(c *gin.Context) {
+	h.getByExternalRef(c, "customer", h.customerScope(c), &models.Customer{})
+}
+
+// UpsertCustomer handles PUT /api/v1/customers/by-ref/:ref
+// Updates the customer with the external reference, or creates it (201)
+// when there is none, like UpdateCustomer and CreateCustomer. The version
+// only applies to updates.
+func (h *Handler) UpsertCustomer(c *gin.Context) {
+	var req CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+	id, ok := h.upsertTarget(c, "customer", h.customerScope(c), &models.Customer{}, &req.ExternalRef)
+	if !ok {
+		return
+	}
+	if id == 0 {
+		h.createCustomer(c, req.toCustomer(0))
+		return
+	}
+	customer := req.toCustomer(id)
+	customer.Version = version
+	h.updateCustomer(c, customer)
+}
+
+// PatchCustomer handles PATCH /api/v1/customers/:id
+// Changes only the fields in the request, unlike UpdateCustomer, which
+// replaces the customer.
+func (h *Handler) PatchCustomer(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+
+	var req CustomerPatch
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	customer, err := h.customers.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
+		return
+	}
+	if staleVersion(c, "Customer", version, customer.Version) {
+		return
+	}
+	customer.Version = version
+	columns := req.apply(customer)
+	if !h.uniqueExternalRef(c, "customer", h.customerScope(c), &models.Customer{}, customer.ID, customer.ExternalRef) {
+		return
+	}
+	warnings, ok := h.customerReachWarnings(c, customer)
+	if !ok {
+		return
+	}
+	if len(columns) > 0 {
+		if err := h.customers.Patch(customer, append(columns, "updated_at")); err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				notFoundResponse(c, "Customer")
+				return
+			}
+			if versionConflict(c, "Customer", err) {
+				return
+			}
+			errorResponse(c, http.StatusInternalServerError, "Failed to update customer")
+			return
+		}
+	}
+	setETag(c, customer.Version)
+	warningResponse(c, customer, warnings)
 }
 
 // DeleteCustomer handles DELETE /api/v1/customers/:id
@@ -132,9 +382,9 @@ func (h *Handler) DeleteCustomer(c *gin.Context) {
 		return
 	}
 
-	if err := database.DeleteCustomer(h.db, id); err != nil {
+	if err := h.customers.Delete(id); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Customer not found")
+			notFoundResponse(c, "Customer")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to delete customer")
@@ -143,3 +393,55 @@ func (h *Handler) DeleteCustomer(c *gin.Context) {
 	successResponse(c, gin.H{"message": "Customer deleted successfully"})
 }
 
+// customerReachWarnings warns when no available vehicle of the customer's
+// warehouse can make the round trip to it within its distance limit, or,
+// for customers without a warehouse, when no warehouse has such a vehicle.
+// Warehouses without available vehicles are not checked. It writes the
+// error response and returns false when the warehouse does not exist.
+func (h *Handler) customerReachWarnings(c *gin.Context, customer *models.Customer) ([]string, bool) {
+	if h.db == nil {
+		// handlers wired to repositories only cannot look up the fleet
+		return nil, true
+	}
+	var warehouses []models.Warehouse
+	if customer.WarehouseID != nil {
+		warehouse, err := database.GetWarehouse(h.db, *customer.WarehouseID)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				errorResponse(c, http.StatusBadRequest, "Warehouse not found")
+				return nil, false
+			}
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+			return nil, false
+		}
+		warehouses = []models.Warehouse{*warehouse}
+	} else {
+		var err error
+		if warehouses, err = database.ListWarehouses(h.db); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouses")
+			return nil, false
+		}
+	}
+
+	var warnings []string
+	for _, w := range warehouses {
+		vehicles, err := database.ListAvailableVehiclesByWarehouse(h.db, w.ID)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
+			return nil, false
+		}
+		if len(vehicles) == 0 {
+			continue
+		}
+		roundTrip := 2 * optimizer.HaversineKm(w.Latitude, w.Longitude, customer.Latitude, customer.Longitude)
+		longest := 0.0
+		for _, v := range vehicles {
+			if v.MaxDistance <= 0 || roundTrip <= v.MaxDistance {
+				return nil, true
+			}
+			longest = max(longest, v.MaxDistance)
+		}
+		warnings = append(warnings, fmt.Sprintf("Round trip of %.1f km from warehouse %s exceeds the max distance of every available vehicle there (at most %.1f km)", roundTrip, w.Name, longest))
+	}
+	return warnings, true
+}
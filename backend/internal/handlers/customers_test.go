@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeCustomers is an in-memory repository.Customers
+type fakeCustomers struct {
+	byID   map[int64]*models.Customer
+	nextID int64
+	err    error
+}
+
+func newFakeCustomers() *fakeCustomers {
+	return &fakeCustomers{byID: make(map[int64]*models.Customer)}
+}
+
+func (f *fakeCustomers) List() ([]models.Customer, error) {
+	customers := []models.Customer{}
+	for _, c := range f.byID {
+		customers = append(customers, *c)
+	}
+	return customers, f.err
+}
+
+func (f *fakeCustomers) Page(filter database.CustomerFilter, offset, limit int) ([]models.Customer, int64, error) {
+	customers, err := f.List()
+	sort.Slice(customers, func(i, j int) bool { return customers[i].ID < customers[j].ID })
+	total := int64(len(customers))
+	customers = customers[min(offset, len(customers)):]
+	return customers[:min(limit, len(customers))], total, err
+}
+
+func (f *fakeCustomers) Get(id int64) (*models.Customer, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	c, ok := f.byID[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return c, nil
+}
+
+func (f *fakeCustomers) Create(c *models.Customer) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.nextID++
+	c.ID = f.nextID
+	f.byID[c.ID] = c
+	return nil
+}
+
+func (f *fakeCustomers) Update(c *models.Customer) error {
+	if _, err := f.Get(c.ID); err != nil {
+		return err
+	}
+	f.byID[c.ID] = c
+	return nil
+}
+
+func (f *fakeCustomers) Patch(c *models.Customer, columns []string) error {
+	return f.Update(c)
+}
+
+func (f *fakeCustomers) Delete(id int64) error {
+	if _, err := f.Get(id); err != nil {
+		return err
+	}
+	delete(f.byID, id)
+	return nil
+}
+
+// TestCustomerHandlersWithFakeRepository tests the customer handlers against
+// an in-memory repository, without a database
+func TestCustomerHandlersWithFakeRepository(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	customers := newFakeCustomers()
+	h := New(nil, nil, &config.Config{JWTSecret: "test-secret-key"})
+	h.SetRepositories(repository.Repositories{Customers: customers})
+
+	router := gin.New()
+	router.GET("/customers/:id", h.GetCustomer)
+	router.POST("/customers", h.CreateCustomer)
+	router.PUT("/customers/:id", h.UpdateCustomer)
+	router.DELETE("/customers/:id", h.DeleteCustomer)
+
+	shop := CustomerRequest{Name: "Corner Shop", Latitude: 52.5, Longitude: 13.4, MaxInventory: 100}
+	w := e2eRequest(t, router, "POST", "/customers", "", shop)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201: %s", w.Code, w.Body.String())
+	}
+	if stored, ok := customers.byID[1]; !ok || stored.Name != "Corner Shop" {
+		t.Fatalf("repository holds %+v, want the created customer as ID 1", customers.byID)
+	}
+
+	w = e2eRequest(t, router, "GET", "/customers/1", "", nil)
+	var got struct{ Data models.Customer }
+	json.Unmarshal(w.Body.Bytes(), &got)
+	if w.Code != http.StatusOK || got.Data.Name != "Corner Shop" {
+		t.Errorf("get = %d %+v, want the created customer", w.Code, got.Data)
+	}
+
+	if w := e2eRequest(t, router, "PUT", "/customers/2", "", shop); w.Code != http.StatusNotFound {
+		t.Errorf("update missing status = %d, want 404", w.Code)
+	}
+	if w := e2eRequest(t, router, "DELETE", "/customers/1", "", nil); w.Code != http.StatusOK {
+		t.Errorf("delete status = %d, want 200", w.Code)
+	}
+	if w := e2eRequest(t, router, "GET", "/customers/1", "", nil); w.Code != http.StatusNotFound {
+		t.Errorf("get deleted status = %d, want 404", w.Code)
+	}
+
+	customers.err = errors.New("backend unavailable")
+	if w := e2eRequest(t, router, "GET", "/customers/1", "", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("get with failing repository status = %d, want 500", w.Code)
+	}
+}
+
+// TestCustomerReachWarnings tests the warning for customers no available
+// vehicle can reach within its distance limit
+func TestCustomerReachWarnings(t *testing.T) {
+	s := newTestServer(t)
+	s.router.POST("/customers", s.h.CreateCustomer)
+	s.router.PUT("/customers/:id", s.h.UpdateCustomer)
+
+	newYork := s.fx.Warehouse()
+	s.fx.Vehicle(newYork, func(v *models.Vehicle) { v.MaxDistance = 100 })
+	parked := s.fx.Vehicle(newYork)
+	s.db.Model(parked).Update("available", false)
+	philadelphia := s.fx.Warehouse(func(w *models.Warehouse) { w.Latitude, w.Longitude = 39.9526, -75.1652 })
+
+	create := func(t *testing.T, req CustomerRequest) (int, []string) {
+		t.Helper()
+		w := s.do(t, "POST", "/customers", "", req)
+		var resp struct{ Warnings []string }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Warnings
+	}
+
+	t.Run("assigned warehouse", func(t *testing.T) {
+		near := CustomerRequest{Name: "Midtown", Latitude: 40.7549, Longitude: -73.9840, WarehouseID: &newYork.ID}
+		if code, warnings := create(t, near); code != http.StatusCreated || len(warnings) != 0 {
+			t.Errorf("near customer = %d %v, want created without warnings", code, warnings)
+		}
+		far := CustomerRequest{Name: "Center City", Latitude: 39.9500, Longitude: -75.1600, WarehouseID: &newYork.ID}
+		code, warnings := create(t, far)
+		if code != http.StatusCreated || len(warnings) != 1 || !strings.Contains(warnings[0], newYork.Name) {
+			t.Errorf("far customer = %d %v, want created with a warning about %s", code, warnings, newYork.Name)
+		}
+	})
+
+	t.Run("any warehouse", func(t *testing.T) {
+		// without a warehouse, one that reaches it is enough; Philadelphia
+		// has no vehicles yet
+		far := CustomerRequest{Name: "Center City", Latitude: 39.9500, Longitude: -75.1600}
+		if code, warnings := create(t, far); code != http.StatusCreated || len(warnings) != 1 {
+			t.Errorf("far customer without warehouse = %d %v, want one warning", code, warnings)
+		}
+		s.fx.Vehicle(philadelphia)
+		if code, warnings := create(t, far); code != http.StatusCreated || len(warnings) != 0 {
+			t.Errorf("far customer without warehouse = %d %v, want no warning once Philadelphia has a vehicle", code, warnings)
+		}
+	})
+
+	t.Run("unknown warehouse", func(t *testing.T) {
+		missing := int64(999)
+		far := CustomerRequest{Name: "Center City", Latitude: 39.9500, Longitude: -75.1600, WarehouseID: &missing}
+		if code, _ := create(t, far); code != http.StatusBadRequest {
+			t.Errorf("unknown warehouse status = %d, want 400", code)
+		}
+	})
+}
+
+// TestListCustomersFilters tests paging, searching, filtering and sorting
+// the customer list
+func TestListCustomersFilters(t *testing.T) {
+	s := newTestServer(t)
+	s.router.GET("/customers", s.h.ListCustomers)
+
+	s.fx.Customer(func(c *models.Customer) { c.Name, c.Address, c.Priority = "Harbor Fuels", "1 Pier Road", 3 })
+	s.fx.Customer(func(c *models.Customer) { c.Name, c.Address, c.Priority = "Acme", "9 Harbor Street", 1 })
+	s.fx.Customer(func(c *models.Customer) { c.Name, c.Priority = "100% Diesel", 2 })
+	s.fx.Customer(func(c *models.Customer) { c.Name, c.Latitude, c.Longitude = "Fiji Depot", -17.8, 178.4 })
+
+	list := func(t *testing.T, query string) (int, []models.Customer, models.Pagination) {
+		t.Helper()
+		w := s.do(t, "GET", "/customers"+query, "", nil)
+		var resp struct {
+			Data       []models.Customer
+			Pagination models.Pagination
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data, resp.Pagination
+	}
+	names := func(customers []models.Customer) string {
+		var names []string
+		for _, c := range customers {
+			names = append(names, c.Name)
+		}
+		return strings.Join(names, ", ")
+	}
+
+	tests := []struct {
+		query string
+		want  string
+		total int64
+	}{
+		{"?limit=2", "100% Diesel, Acme", 4},
+		{"?limit=2&page=2", "Fiji Depot, Harbor Fuels", 4},
+		{"?q=HARBOR", "Acme, Harbor Fuels", 2},
+		{"?q=%25", "100% Diesel", 1},
+		{"?q=harbor&min_priority=2", "Harbor Fuels", 1},
+		{"?max_priority=2&sort=-priority", "100% Diesel, Acme, Fiji Depot", 3},
+		{"?bbox=-75,40,-73,41", "100% Diesel, Acme, Harbor Fuels", 3},
+		{"?bbox=170,-20,-170,-10", "Fiji Depot", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			code, customers, page := list(t, tt.query)
+			if code != http.StatusOK || names(customers) != tt.want || page.Total != tt.total {
+				t.Errorf("list%s = %d [%s] of %d, want [%s] of %d", tt.query, code, names(customers), page.Total, tt.want, tt.total)
+			}
+		})
+	}
+	for _, query := range []string{"?sort=address", "?bbox=1,2,3", "?bbox=0,50,10,40", "?min_priority=high"} {
+		t.Run(query, func(t *testing.T) {
+			if code, _, _ := list(t, query); code != http.StatusBadRequest {
+				t.Errorf("list%s status = %d, want 400", query, code)
+			}
+		})
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/demand"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ApplyDemandEstimateRequest struct {
+	Method string `json:"method" binding:"omitempty,oneof=moving_average exponential_smoothing"` // defaults to exponential_smoothing
+}
+
+// GetDemandEstimate handles GET /api/v1/customers/:id/demand-estimate
+// The customer's latest demand estimate.
+func (h *Handler) GetDemandEstimate(c *gin.Context) {
+	id, ok := h.demandCustomer(c)
+	if !ok {
+		return
+	}
+	estimate, err := database.GetLatestDemandEstimate(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorCodeResponse(c, http.StatusNotFound, "DEMAND_ESTIMATE_NOT_FOUND", "Customer has no demand estimate")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch demand estimate")
+		return
+	}
+	successResponse(c, estimate)
+}
+
+// ListDemandEstimates handles GET /api/v1/customers/:id/demand-estimates?page=&limit=
+// The customer's demand estimates, latest first.
+func (h *Handler) ListDemandEstimates(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	id, ok := h.demandCustomer(c)
+	if !ok {
+		return
+	}
+
+	estimates, total, err := database.ListDemandEstimates(h.db, id, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch demand estimates")
+		return
+	}
+	if estimates == nil {
+		estimates = []models.DemandEstimate{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, estimates, page)
+}
+
+// EstimateDemand handles POST /api/v1/customers/:id/demand-estimate
+// Estimates the customer's demand from its history now, without waiting
+// for the estimation job. The customer's demand rate is left as is. A
+// customer without history in the window cannot be estimated (422).
+func (h *Handler) EstimateDemand(c *gin.Context) {
+	id, ok := h.demandCustomer(c)
+	if !ok {
+		return
+	}
+	estimator := demand.New(h.db, h.config.DemandEstimateWindow, h.config.DemandSmoothingAlpha)
+	estimates, err := estimator.Run(demand.Scope{CustomerID: id}, h.clock.Now())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to estimate demand")
+		return
+	}
+	if len(estimates) == 0 {
+		errorResponse(c, http.StatusUnprocessableEntity, "Not enough inventory history to estimate demand")
+		return
+	}
+	createdResponse(c, estimates[0])
+}
+
+// ApplyDemandEstimate handles POST /api/v1/customers/:id/demand-estimate/apply
+// Sets the customer's demand rate to its latest estimate, by method.
+func (h *Handler) ApplyDemandEstimate(c *gin.Context) {
+	var req ApplyDemandEstimateRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.Method == "" {
+		req.Method = models.DemandExponentialSmoothing
+	}
+	id, ok := h.demandCustomer(c)
+	if !ok {
+		return
+	}
+	estimate, err := database.GetLatestDemandEstimate(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorCodeResponse(c, http.StatusNotFound, "DEMAND_ESTIMATE_NOT_FOUND", "Customer has no demand estimate")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch demand estimate")
+		return
+	}
+
+	var userID *int64
+	if uid := c.GetInt64("userID"); uid != 0 {
+		userID = &uid
+	}
+	if err := database.ApplyDemandEstimate(h.db, estimate, req.Method, userID, h.clock.Now()); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to apply demand estimate")
+		return
+	}
+	h.queueAlertScan("customer", id)
+	successResponse(c, estimate)
+}
+
+// demandCustomer checks the customer of the request's :id exists and
+// returns its ID. It writes the error response and returns false when it
+// does not.
+func (h *Handler) demandCustomer(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return 0, false
+	}
+	if _, err := h.customers.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return 0, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,92 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestDemandEstimates tests estimating a customer's demand on request and
+// applying the estimate to its demand rate
+func TestDemandEstimates(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.DemandEstimateWindow, cfg.DemandSmoothingAlpha = 7, 0.5
+	})
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.api.GET("/customers/:id/demand-estimate", s.h.GetDemandEstimate)
+	s.api.POST("/customers/:id/demand-estimate", s.h.EstimateDemand)
+	s.api.POST("/customers/:id/demand-estimate/apply", s.h.ApplyDemandEstimate)
+	s.api.GET("/customers/:id/demand-estimates", s.h.ListDemandEstimates)
+
+	token := s.login(t, "manager")
+	customer := s.fx.Customer()
+	for i, level := range []float64{100, 88, 80} {
+		at := time.Date(2024, 3, 1+i, 0, 0, 0, 0, time.UTC)
+		database.CreateInventorySnapshot(s.db, &models.InventorySnapshot{EntityType: "customer", EntityID: customer.ID, SnapshotDate: at, SnapshotTime: at, InventoryLevel: level})
+	}
+	path := fmt.Sprintf("/api/v1/customers/%d/demand-estimate", customer.ID)
+
+	t.Run("before estimating", func(t *testing.T) {
+		if w := s.do(t, "GET", path, token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("estimate before estimating status = %d, want 404", w.Code)
+		}
+		if w := s.do(t, "POST", path+"/apply", token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("apply before estimating status = %d, want 404", w.Code)
+		}
+	})
+
+	t.Run("estimate", func(t *testing.T) {
+		var estimate models.DemandEstimate
+		w := s.do(t, "POST", path, token, nil)
+		json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{&estimate})
+		// 12 used on 1 March and 8 on 2 March
+		if w.Code != http.StatusCreated || estimate.MovingAverage != 10 || estimate.Smoothed != 10 || estimate.AppliedAt != nil {
+			t.Fatalf("estimate = %d %+v, want 10 a day, not applied", w.Code, estimate)
+		}
+		if got, _ := database.GetCustomer(s.db, customer.ID); got.DemandRate != 10 {
+			t.Errorf("demand rate after estimating = %g, want unchanged", got.DemandRate)
+		}
+	})
+
+	t.Run("apply", func(t *testing.T) {
+		at := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+		database.CreateInventorySnapshot(s.db, &models.InventorySnapshot{EntityType: "customer", EntityID: customer.ID, SnapshotDate: at, SnapshotTime: at, InventoryLevel: 64})
+		s.do(t, "POST", path, token, nil)
+		var estimate models.DemandEstimate
+		w := s.do(t, "POST", path+"/apply", token, ApplyDemandEstimateRequest{Method: models.DemandMovingAverage})
+		json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{&estimate})
+		// 12, 8 and 16 a day
+		if w.Code != http.StatusOK || estimate.AppliedMethod != models.DemandMovingAverage || estimate.AppliedBy == nil {
+			t.Errorf("apply = %d %+v, want the moving average applied by the manager", w.Code, estimate)
+		}
+		if got, _ := database.GetCustomer(s.db, customer.ID); got.DemandRate != 12 {
+			t.Errorf("demand rate = %g, want the moving average of 12", got.DemandRate)
+		}
+		if w := s.do(t, "POST", path+"/apply", token, ApplyDemandEstimateRequest{Method: "median"}); w.Code != http.StatusBadRequest {
+			t.Errorf("unknown method status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		var list []models.DemandEstimate
+		w := s.do(t, "GET", path+"s", token, nil)
+		json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{&list})
+		if w.Code != http.StatusOK || len(list) != 2 || list[0].AppliedAt == nil {
+			t.Errorf("estimates = %d %+v, want both, the applied one first", w.Code, list)
+		}
+	})
+
+	t.Run("without history", func(t *testing.T) {
+		other := s.fx.Customer()
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/customers/%d/demand-estimate", other.ID), token, nil); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("estimate without history status = %d, want 422", w.Code)
+		}
+	})
+}
@@ -0,0 +1,335 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deviation causes, from most to least specific
+const (
+	causeFailedStops    = "failed_stops"
+	causeManualEdit     = "manual_edit"
+	causeTraffic        = "traffic"
+	causeStaleInventory = "inventory_staleness"
+	causeUnexplained    = "unexplained"
+)
+
+// Thresholds for attributing deviations
+const (
+	deviationTolerance       = 0.01           // ignore cost and quantity differences below this
+	trafficDistanceOverrun   = 0.10           // actual distance share above planned
+	trafficDurationOverrun   = 0.15           // actual duration share above planned
+	staleInventoryAge        = 48 * time.Hour // inventory reading age at planning time
+	deviationReportItemLimit = 50             // items listed per cause
+)
+
+// deviationInput is everything the root cause analysis looks at
+type deviationInput struct {
+	Routes     []models.Route
+	Executions []models.RouteExecution
+	Solution   *models.PlanSolution               // optimized quantities; nil when unknown
+	Snapshots  map[int64]models.InventorySnapshot // latest customer reading before planning
+	PlannedAt  *time.Time
+}
+
+// GetDeviationReport handles GET /api/v1/plans/:id/deviation-report
+// Deviations between completed route executions and the optimized plan are
+// attributed to failed stops, manual edits, traffic or stale inventory data
+// and ranked by their share of the plan's total deviation.
+func (h *Handler) GetDeviationReport(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	if _, err := h.plans.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	executions, err := database.GetRouteExecutionsByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route executions")
+		return
+	}
+	completed := false
+	for _, e := range executions {
+		if e.Status == "completed" {
+			completed = true
+			break
+		}
+	}
+	if !completed {
+		errorResponse(c, http.StatusConflict, "Plan has no completed route executions")
+		return
+	}
+
+	routes, err := database.GetRoutesByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan routes")
+		return
+	}
+
+	input := deviationInput{Routes: routes, Executions: executions}
+	solution, err := database.GetCurrentPlanSolution(h.db, id)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan solution")
+		return
+	}
+	if solution != nil {
+		input.Solution = solution
+		input.PlannedAt = &solution.CreatedAt
+	}
+
+	customerIDs := make([]int64, 0)
+	for _, r := range routes {
+		for _, s := range r.Stops {
+			if s.CustomerID != nil {
+				customerIDs = append(customerIDs, *s.CustomerID)
+			}
+		}
+	}
+	input.Snapshots, err = database.GetLatestInventorySnapshotsBefore(h.db, "customer", customerIDs, input.PlannedAt)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch inventory snapshots")
+		return
+	}
+
+	report := analyzeDeviations(input)
+	report.PlanID = id
+	successResponse(c, report)
+}
+
+// analyzeDeviations attributes each completed route's cost deviation and each
+// stop's quantity deviation to a cause. A stop's deviation is measured from
+// the optimized quantity, so a manual edit and an execution shortfall on the
+// same stop are reported separately.
+func analyzeDeviations(in deviationInput) *models.DeviationReport {
+	report := &models.DeviationReport{PlannedAt: in.PlannedAt, Causes: []models.DeviationCause{}}
+	causes := make(map[string]*models.DeviationCause)
+	absCost := make(map[string]float64)
+	absQuantity := make(map[string]float64)
+	add := func(cause string, item models.DeviationItem) {
+		dc, ok := causes[cause]
+		if !ok {
+			dc = &models.DeviationCause{Cause: cause, Items: []models.DeviationItem{}}
+			causes[cause] = dc
+		}
+		dc.Occurrences++
+		dc.CostImpact += item.CostImpact
+		dc.QuantityImpact += item.QuantityImpact
+		absCost[cause] += math.Abs(item.CostImpact)
+		absQuantity[cause] += math.Abs(item.QuantityImpact)
+		if len(dc.Items) < deviationReportItemLimit {
+			dc.Items = append(dc.Items, item)
+		}
+	}
+
+	routes := make(map[int64]models.Route, len(in.Routes))
+	for _, r := range in.Routes {
+		routes[r.ID] = r
+	}
+	optimized := optimizedQuantities(in.Solution)
+
+	// The latest completed execution of each route counts
+	latest := make(map[int64]models.RouteExecution)
+	for _, e := range in.Executions {
+		if e.Status == "completed" {
+			latest[e.RouteID] = e
+		}
+	}
+	routeIDs := make([]int64, 0, len(latest))
+	for id := range latest {
+		routeIDs = append(routeIDs, id)
+	}
+	sort.Slice(routeIDs, func(i, j int) bool { return routeIDs[i] < routeIDs[j] })
+
+	for _, routeID := range routeIDs {
+		exec := latest[routeID]
+		route, ok := routes[routeID]
+		if !ok {
+			continue
+		}
+		report.RoutesAnalyzed++
+		report.PlannedCost += exec.PlannedCost
+		report.ActualCost += exec.ActualCost
+
+		stops := make(map[int64]models.Stop, len(route.Stops))
+		for _, s := range route.Stops {
+			stops[s.ID] = s
+		}
+
+		failed := 0
+		edited := 0
+		for _, se := range exec.StopExecutions {
+			stop, ok := stops[se.StopID]
+			if !ok {
+				continue
+			}
+			stopID := stop.ID
+			item := models.DeviationItem{RouteID: route.ID, StopID: &stopID, CustomerID: stop.CustomerID, Day: route.Day}
+
+			planned := se.PlannedQuantity
+			if planned == 0 {
+				planned = stop.Quantity
+			}
+			baseline := planned
+			if q, ok := optimized[solutionStopKey(route.Day, route.VehicleID, stop.CustomerID)]; ok {
+				baseline = q
+			}
+			report.PlannedQuantity += baseline
+
+			if edit := planned - baseline; math.Abs(edit) > deviationTolerance {
+				edited++
+				item.QuantityImpact = edit
+				item.Detail = fmt.Sprintf("quantity edited from %.2f to %.2f after optimization", baseline, planned)
+				add(causeManualEdit, item)
+			}
+
+			if se.Status == "failed" || se.Status == "skipped" {
+				failed++
+				item.QuantityImpact = -planned
+				item.Detail = fmt.Sprintf("stop %s, %.2f not delivered", se.Status, planned)
+				add(causeFailedStops, item)
+				continue
+			}
+			report.DeliveredQuantity += se.ActualQuantity
+
+			diff := se.ActualQuantity - planned
+			if se.Status != "completed" || math.Abs(diff) <= deviationTolerance {
+				continue
+			}
+			item.QuantityImpact = diff
+			if stale, detail := staleInventory(stop.CustomerID, in.Snapshots, in.PlannedAt); stale {
+				item.Detail = fmt.Sprintf("delivered %.2f instead of %.2f; %s", se.ActualQuantity, planned, detail)
+				add(causeStaleInventory, item)
+			} else {
+				item.Detail = fmt.Sprintf("delivered %.2f instead of %.2f", se.ActualQuantity, planned)
+				add(causeUnexplained, item)
+			}
+		}
+
+		costDiff := exec.ActualCost - exec.PlannedCost
+		if math.Abs(costDiff) <= deviationTolerance {
+			continue
+		}
+		item := models.DeviationItem{RouteID: route.ID, Day: route.Day, CostImpact: costDiff}
+		if detail := trafficOverrun(exec); detail != "" {
+			item.Detail = detail
+			add(causeTraffic, item)
+		} else if failed > 0 {
+			item.Detail = fmt.Sprintf("%d failed or skipped stops on the route", failed)
+			add(causeFailedStops, item)
+		} else if edited > 0 {
+			item.Detail = fmt.Sprintf("%d stops edited after optimization", edited)
+			add(causeManualEdit, item)
+		} else {
+			item.Detail = fmt.Sprintf("cost %.2f instead of %.2f", exec.ActualCost, exec.PlannedCost)
+			add(causeUnexplained, item)
+		}
+	}
+
+	report.CostDeviation = report.ActualCost - report.PlannedCost
+	report.QuantityDeviation = report.DeliveredQuantity - report.PlannedQuantity
+
+	var totalCost, totalQuantity float64
+	for cause := range causes {
+		totalCost += absCost[cause]
+		totalQuantity += absQuantity[cause]
+	}
+	for cause, dc := range causes {
+		if totalCost > 0 {
+			dc.Share = absCost[cause] / totalCost
+		}
+		if totalQuantity > 0 {
+			dc.Share = math.Max(dc.Share, absQuantity[cause]/totalQuantity)
+		}
+		report.Causes = append(report.Causes, *dc)
+	}
+	sort.Slice(report.Causes, func(i, j int) bool {
+		if report.Causes[i].Share != report.Causes[j].Share {
+			return report.Causes[i].Share > report.Causes[j].Share
+		}
+		return report.Causes[i].Cause < report.Causes[j].Cause
+	})
+	for i := range report.Causes {
+		report.Causes[i].Rank = i + 1
+	}
+	return report
+}
+
+// solutionStopKey identifies a stop across solution snapshots
+func solutionStopKey(day int, vehicleID, customerID *int64) string {
+	var vehicle, customer int64
+	if vehicleID != nil {
+		vehicle = *vehicleID
+	}
+	if customerID != nil {
+		customer = *customerID
+	}
+	return fmt.Sprintf("%d/%d/%d", day, vehicle, customer)
+}
+
+// optimizedQuantities maps each stop in a solution to the quantity the
+// optimizer planned
+func optimizedQuantities(solution *models.PlanSolution) map[string]float64 {
+	quantities := make(map[string]float64)
+	if solution == nil {
+		return quantities
+	}
+	for _, r := range solution.Routes {
+		for _, s := range r.Stops {
+			customerID := s.CustomerID
+			quantities[solutionStopKey(r.Day, r.VehicleID, &customerID)] = s.Quantity
+		}
+	}
+	return quantities
+}
+
+// staleInventory reports whether the customer's inventory reading was older
+// than staleInventoryAge when the plan was optimized
+func staleInventory(customerID *int64, snapshots map[int64]models.InventorySnapshot, plannedAt *time.Time) (bool, string) {
+	if customerID == nil || plannedAt == nil {
+		return false, ""
+	}
+	snapshot, ok := snapshots[*customerID]
+	if !ok {
+		return true, "no inventory reading before planning"
+	}
+	if age := plannedAt.Sub(snapshot.SnapshotTime); age > staleInventoryAge {
+		return true, fmt.Sprintf("inventory reading was %.0f hours old at planning", age.Hours())
+	}
+	return false, ""
+}
+
+// trafficOverrun describes a route's distance or duration overrun, or
+// returns "" when the route ran close to plan
+func trafficOverrun(exec models.RouteExecution) string {
+	if exec.PlannedDistance > 0 && exec.ActualDistance > exec.PlannedDistance*(1+trafficDistanceOverrun) {
+		return fmt.Sprintf("drove %.1f km instead of %.1f km", exec.ActualDistance, exec.PlannedDistance)
+	}
+	if exec.PlannedStartTime == nil || exec.PlannedEndTime == nil || exec.ActualStartTime == nil || exec.ActualEndTime == nil {
+		return ""
+	}
+	planned := exec.PlannedEndTime.Sub(*exec.PlannedStartTime)
+	actual := exec.ActualEndTime.Sub(*exec.ActualStartTime)
+	if planned > 0 && float64(actual) > float64(planned)*(1+trafficDurationOverrun) {
+		return fmt.Sprintf("took %.0f minutes instead of %.0f", actual.Minutes(), planned.Minutes())
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestAnalyzeDeviations tests that deviations are attributed to their causes
+// and ranked by share
+func TestAnalyzeDeviations(t *testing.T) {
+	plannedAt := time.Date(2024, 1, 1, 6, 0, 0, 0, time.UTC)
+	vehicleID := int64(10)
+	customers := []int64{1, 2, 3}
+	routes := []models.Route{
+		{ID: 1, Day: 1, VehicleID: &vehicleID, Stops: []models.Stop{
+			{ID: 11, CustomerID: &customers[0], Quantity: 40},
+			{ID: 12, CustomerID: &customers[1], Quantity: 30},
+		}},
+		{ID: 2, Day: 2, VehicleID: &vehicleID, Stops: []models.Stop{
+			{ID: 21, CustomerID: &customers[2], Quantity: 20},
+		}},
+	}
+	executions := []models.RouteExecution{
+		{RouteID: 1, Status: "completed", PlannedCost: 100, ActualCost: 130, PlannedDistance: 50, ActualDistance: 60, StopExecutions: []models.StopExecution{
+			{StopID: 11, Status: "completed", ActualQuantity: 40},
+			{StopID: 12, Status: "failed"},
+		}},
+		{RouteID: 2, Status: "completed", PlannedCost: 80, ActualCost: 80, StopExecutions: []models.StopExecution{
+			{StopID: 21, Status: "completed", ActualQuantity: 15},
+		}},
+	}
+	solution := &models.PlanSolution{Routes: []models.SolutionRoute{
+		{Day: 1, VehicleID: &vehicleID, Stops: []models.ScenarioStop{{CustomerID: 1, Quantity: 50}, {CustomerID: 2, Quantity: 30}}},
+		{Day: 2, VehicleID: &vehicleID, Stops: []models.ScenarioStop{{CustomerID: 3, Quantity: 20}}},
+	}}
+	snapshots := map[int64]models.InventorySnapshot{
+		1: {EntityID: 1, SnapshotTime: plannedAt.Add(-time.Hour)},
+		2: {EntityID: 2, SnapshotTime: plannedAt.Add(-time.Hour)},
+		3: {EntityID: 3, SnapshotTime: plannedAt.Add(-72 * time.Hour)},
+	}
+
+	report := analyzeDeviations(deviationInput{
+		Routes:     routes,
+		Executions: executions,
+		Solution:   solution,
+		Snapshots:  snapshots,
+		PlannedAt:  &plannedAt,
+	})
+
+	if report.RoutesAnalyzed != 2 || report.CostDeviation != 30 {
+		t.Errorf("routes analyzed = %d, cost deviation = %v, want 2 and 30", report.RoutesAnalyzed, report.CostDeviation)
+	}
+	if report.PlannedQuantity != 100 || report.DeliveredQuantity != 55 {
+		t.Errorf("planned/delivered quantity = %v/%v, want 100/55", report.PlannedQuantity, report.DeliveredQuantity)
+	}
+
+	want := []struct {
+		cause    string
+		cost     float64
+		quantity float64
+	}{
+		{causeTraffic, 30, 0},
+		{causeFailedStops, 0, -30},
+		{causeManualEdit, 0, -10},
+		{causeStaleInventory, 0, -5},
+	}
+	if len(report.Causes) != len(want) {
+		t.Fatalf("causes = %+v, want %d causes", report.Causes, len(want))
+	}
+	for i, w := range want {
+		got := report.Causes[i]
+		if got.Cause != w.cause || got.Rank != i+1 || got.CostImpact != w.cost || got.QuantityImpact != w.quantity {
+			t.Errorf("cause %d = %s (rank %d, cost %v, quantity %v), want %s (cost %v, quantity %v)",
+				i, got.Cause, got.Rank, got.CostImpact, got.QuantityImpact, w.cause, w.cost, w.quantity)
+		}
+	}
+}
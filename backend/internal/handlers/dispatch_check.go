@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// overDeliveryTolerance ignores excesses caused by rounding
+const overDeliveryTolerance = 1e-6
+
+// inventoryBaseline is the last known inventory level of a customer
+type inventoryBaseline struct {
+	Level  float64
+	AsOf   time.Time
+	Source string
+}
+
+// GetDispatchCheck handles GET /api/v1/plans/:id/dispatch-check
+// Each stop's quantity is compared against the customer's projected free
+// capacity on the delivery date, starting from the freshest inventory
+// reading and applying daily demand and earlier deliveries in the plan.
+func (h *Handler) GetDispatchCheck(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	if _, err := h.plans.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	routes, err := database.GetRoutesByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan routes")
+		return
+	}
+
+	customerIDs := make([]int64, 0)
+	seen := make(map[int64]bool)
+	for _, r := range routes {
+		for _, s := range r.Stops {
+			if s.CustomerID != nil && !seen[*s.CustomerID] {
+				seen[*s.CustomerID] = true
+				customerIDs = append(customerIDs, *s.CustomerID)
+			}
+		}
+	}
+	snapshots, err := database.GetLatestInventorySnapshots(h.db, "customer", customerIDs)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch inventory snapshots")
+		return
+	}
+
+	successResponse(c, checkOverDeliveries(id, routes, snapshots))
+}
+
+// checkOverDeliveries walks a plan's stops in delivery order and flags those
+// planning more than the customer's projected free capacity
+func checkOverDeliveries(planID int64, routes []models.Route, snapshots map[int64]models.InventorySnapshot) *models.DispatchCheck {
+	type plannedStop struct {
+		route models.Route
+		stop  models.Stop
+	}
+	var stops []plannedStop
+	for _, r := range routes {
+		for _, s := range r.Stops {
+			if s.Customer != nil {
+				stops = append(stops, plannedStop{r, s})
+			}
+		}
+	}
+	sort.SliceStable(stops, func(i, j int) bool {
+		a, b := stops[i], stops[j]
+		if !a.route.Date.Equal(b.route.Date) {
+			return a.route.Date.Before(b.route.Date)
+		}
+		if a.route.ID != b.route.ID {
+			return a.route.ID < b.route.ID
+		}
+		return a.stop.Sequence < b.stop.Sequence
+	})
+
+	check := &models.DispatchCheck{
+		PlanID:         planID,
+		StopsChecked:   len(stops),
+		OverDeliveries: []models.OverDelivery{},
+	}
+	// Running projection per customer: level at the start of day `at`
+	type projection struct {
+		baseline inventoryBaseline
+		level    float64
+		at       time.Time
+	}
+	projections := make(map[int64]*projection)
+
+	for _, ps := range stops {
+		customer := ps.stop.Customer
+		p, ok := projections[customer.ID]
+		if !ok {
+			baseline := customerBaseline(customer, snapshots)
+			p = &projection{baseline: baseline, level: baseline.Level, at: baseline.AsOf.Truncate(24 * time.Hour)}
+			projections[customer.ID] = p
+		}
+
+		deliveryDay := ps.route.Date.Truncate(24 * time.Hour)
+		if days := deliveryDay.Sub(p.at).Hours() / 24; days > 0 {
+			p.level = math.Max(0, p.level-customer.DemandRate*days)
+			p.at = deliveryDay
+		}
+
+		if customer.MaxInventory > 0 {
+			free := math.Max(0, customer.MaxInventory-p.level)
+			if excess := ps.stop.Quantity - free; excess > overDeliveryTolerance {
+				check.OverDeliveries = append(check.OverDeliveries, models.OverDelivery{
+					StopID:             ps.stop.ID,
+					RouteID:            ps.route.ID,
+					CustomerID:         customer.ID,
+					CustomerName:       customer.Name,
+					Day:                ps.route.Day,
+					Date:               ps.route.Date.Format("2006-01-02"),
+					PlannedQuantity:    ps.stop.Quantity,
+					ProjectedInventory: p.level,
+					MaxInventory:       customer.MaxInventory,
+					FreeCapacity:       free,
+					Excess:             excess,
+					InventoryAsOf:      p.baseline.AsOf,
+					InventorySource:    p.baseline.Source,
+				})
+			}
+		}
+		p.level += ps.stop.Quantity
+	}
+	return check
+}
+
+// customerBaseline picks the freshest inventory reading for a customer:
+// the latest snapshot when it is newer than the customer record
+func customerBaseline(customer *models.Customer, snapshots map[int64]models.InventorySnapshot) inventoryBaseline {
+	baseline := inventoryBaseline{Level: customer.CurrentInventory, AsOf: customer.UpdatedAt, Source: "customer"}
+	if s, ok := snapshots[customer.ID]; ok && s.SnapshotTime.After(customer.UpdatedAt) {
+		baseline = inventoryBaseline{Level: s.InventoryLevel, AsOf: s.SnapshotTime, Source: "snapshot"}
+	}
+	return baseline
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestCheckOverDeliveries tests that stops are flagged when they exceed the
+// projected free capacity on the delivery date
+func TestCheckOverDeliveries(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2024, 1, d, 0, 0, 0, 0, time.UTC) }
+	customer := &models.Customer{ID: 1, Name: "Customer", CurrentInventory: 50, MaxInventory: 100, DemandRate: 10, UpdatedAt: day(1).Add(9 * time.Hour)}
+	routes := []models.Route{
+		{ID: 1, Day: 3, Date: day(3), Stops: []models.Stop{{ID: 11, Customer: customer, Quantity: 75}}},
+		{ID: 2, Day: 4, Date: day(4), Stops: []models.Stop{{ID: 21, Customer: customer, Quantity: 10}}},
+	}
+
+	tests := []struct {
+		name      string
+		snapshots map[int64]models.InventorySnapshot
+		wantStops []int64
+		wantFirst float64 // excess of the first flagged stop
+	}{
+		{
+			// 50 - 2 days * 10 leaves room for 70; the day 3 delivery then
+			// leaves 95 after a day of demand
+			name:      "customer inventory",
+			wantStops: []int64{11, 21},
+			wantFirst: 5,
+		},
+		{
+			name:      "newer empty snapshot",
+			snapshots: map[int64]models.InventorySnapshot{1: {EntityID: 1, InventoryLevel: 0, SnapshotTime: day(2)}},
+			wantStops: nil,
+		},
+		{
+			name:      "older snapshot ignored",
+			snapshots: map[int64]models.InventorySnapshot{1: {EntityID: 1, InventoryLevel: 0, SnapshotTime: day(1)}},
+			wantStops: []int64{11, 21},
+			wantFirst: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := checkOverDeliveries(1, routes, tt.snapshots)
+			if check.StopsChecked != 2 {
+				t.Errorf("StopsChecked = %d, want 2", check.StopsChecked)
+			}
+			if len(check.OverDeliveries) != len(tt.wantStops) {
+				t.Fatalf("OverDeliveries = %+v, want stops %v", check.OverDeliveries, tt.wantStops)
+			}
+			for i, o := range check.OverDeliveries {
+				if o.StopID != tt.wantStops[i] {
+					t.Errorf("OverDeliveries[%d].StopID = %d, want %d", i, o.StopID, tt.wantStops[i])
+				}
+			}
+			if len(tt.wantStops) > 0 && check.OverDeliveries[0].Excess != tt.wantFirst {
+				t.Errorf("first excess = %v, want %v", check.OverDeliveries[0].Excess, tt.wantFirst)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/export"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+	"LogiTrackPro/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// dispatchStatuses are the plan statuses whose routes are dispatched
+var dispatchStatuses = []string{planstate.Optimized, planstate.Approved, planstate.Executing}
+
+// ExportDispatch handles GET /api/v1/dispatch/export?date=&format=xlsx|csv&link=true
+// One file with every route departing on the date (default today) across
+// all optimized, approved and executing plans, grouped by warehouse and
+// vehicle. Drivers only get routes of approved plans. With link=true the
+// file is stored and a download link returned instead.
+func (h *Handler) ExportDispatch(c *gin.Context) {
+	today := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	date, err := parseDateQuery(c, "date", today)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+
+	format := c.DefaultQuery("format", export.XLSX)
+	if format != export.XLSX && format != export.CSV {
+		errorResponse(c, http.StatusBadRequest, "format must be xlsx or csv")
+		return
+	}
+
+	statuses := dispatchStatuses
+	driver, err := h.isDriver(c)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if driver {
+		statuses = []string{planstate.Approved, planstate.Executing}
+	}
+
+	routes, err := database.GetDispatchRoutes(h.db, date, statuses)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+
+	filename := fmt.Sprintf("dispatch-%s.%s", date.Format("2006-01-02"), format)
+	if c.Query("link") == "true" {
+		var buf bytes.Buffer
+		w, _ := export.New(format, &buf)
+		if err := writeDispatchSheets(w, routes); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to generate export")
+			return
+		}
+		link, err := h.storeArtifact(c.Request.Context(), storage.ExportsPrefix+"dispatch/"+filename, export.ContentType(format), buf.Bytes())
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to store export: "+err.Error())
+			return
+		}
+		successResponse(c, link)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", export.ContentType(format))
+	c.Status(http.StatusOK)
+
+	w, _ := export.New(format, c.Writer)
+	if err := writeDispatchSheets(w, routes); err != nil {
+		// The status is already sent; all that is left is to cut the file short
+		log.Printf("Dispatch export for %s failed: %v", date.Format("2006-01-02"), err)
+		c.Abort()
+	}
+}
+
+// writeDispatchSheets writes the routes and stops sheets of a dispatch
+// export
+func writeDispatchSheets(w export.Writer, routes []models.Route) error {
+	err := w.Sheet("Routes", "Warehouse", "Vehicle", "Plan", "Route ID", "Driver", "Start", "End", "Stops", "Load", "Distance (km)")
+	if err != nil {
+		return err
+	}
+	for _, r := range routes {
+		warehouse, plan := dispatchPlanNames(r)
+		err := w.Row(warehouse, vehicleName(r.Vehicle), plan, r.ID, driverName(r.Driver),
+			clockOf(r.PlannedStart), clockOf(r.PlannedEnd), len(r.Stops), r.TotalLoad, r.TotalDistance)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.Sheet("Stops", "Warehouse", "Vehicle", "Plan", "Route ID", "Sequence", "Type", "Customer", "Address", "Quantity", "ETA")
+	if err != nil {
+		return err
+	}
+	for _, r := range routes {
+		warehouse, plan := dispatchPlanNames(r)
+		for _, s := range r.Stops {
+			name, address := stopLocation(s)
+			err := w.Row(warehouse, vehicleName(r.Vehicle), plan, r.ID, s.Sequence, s.Type, name, address, s.Quantity, s.ArrivalTime)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return w.Close()
+}
+
+func dispatchPlanNames(r models.Route) (warehouse, plan string) {
+	if r.Plan == nil {
+		return "", ""
+	}
+	if r.Plan.Warehouse != nil {
+		warehouse = r.Plan.Warehouse.Name
+	}
+	return warehouse, r.Plan.Name
+}
+
+// clockOf formats a planned time as HH:MM, or empty when unset
+func clockOf(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("15:04")
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestExportDispatch tests that one day's routes of all active plans are
+// exported grouped by warehouse and vehicle
+func TestExportDispatch(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/dispatch/export", s.h.ExportDispatch)
+	planner := s.login(t, "user")
+	driver := s.login(t, "driver")
+
+	north := &models.Warehouse{Name: "North", Latitude: 40.7, Longitude: -74.0}
+	south := &models.Warehouse{Name: "South", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, north)
+	database.CreateWarehouse(s.db, south)
+	truck := &models.Vehicle{Name: "Truck", Capacity: 100, Available: true}
+	van := &models.Vehicle{Name: "Van", Capacity: 100, Available: true}
+	database.CreateVehicle(s.db, truck)
+	database.CreateVehicle(s.db, van)
+	customer := &models.Customer{Name: "Acme", Address: "1 Main St", Latitude: 40.7, Longitude: -74.0}
+	database.CreateCustomer(s.db, customer)
+
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	newPlan := func(name, status string, warehouse *models.Warehouse) *models.Plan {
+		plan := &models.Plan{Name: name, StartDate: day, EndDate: day.AddDate(0, 0, 1), Status: status, WarehouseID: &warehouse.ID}
+		database.CreatePlan(s.db, plan)
+		return plan
+	}
+	newRoute := func(plan *models.Plan, vehicle *models.Vehicle, date time.Time, start string) {
+		planned, _ := time.Parse("2006-01-02 15:04", date.Format("2006-01-02")+" "+start)
+		route := &models.Route{PlanID: plan.ID, VehicleID: &vehicle.ID, Day: 1, Date: date, PlannedStart: &planned, TotalLoad: 10}
+		database.CreateRoute(s.db, route)
+		s.db.Create(&models.Stop{RouteID: route.ID, CustomerID: &customer.ID, Sequence: 1, Quantity: 10, ArrivalTime: start})
+	}
+
+	fuel := newPlan("Fuel", "approved", south)
+	lube := newPlan("Lube", "optimized", north)
+	draft := newPlan("Draft", "draft", north)
+	newRoute(fuel, truck, day, "07:00")
+	newRoute(lube, van, day, "06:00")
+	newRoute(lube, truck, day, "09:00")
+	newRoute(lube, truck, day.AddDate(0, 0, 1), "08:00")
+	newRoute(draft, truck, day, "05:00")
+
+	get := func(t *testing.T, token, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		return s.do(t, "GET", "/api/v1/dispatch/export"+query, token, nil)
+	}
+
+	t.Run("planner", func(t *testing.T) {
+		w := get(t, planner, "?date=2024-05-06&format=csv")
+		if w.Code != http.StatusOK {
+			t.Fatalf("ExportDispatch() status = %d, body = %s", w.Code, w.Body.String())
+		}
+		if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="dispatch-2024-05-06.csv"` {
+			t.Errorf("Content-Disposition = %q", got)
+		}
+		want := "Routes\n" +
+			"Warehouse,Vehicle,Plan,Route ID,Driver,Start,End,Stops,Load,Distance (km)\n" +
+			"North,Truck,Lube,3,,09:00,,1,10,0\n" +
+			"North,Van,Lube,2,,06:00,,1,10,0\n" +
+			"South,Truck,Fuel,1,,07:00,,1,10,0\n" +
+			"\n" +
+			"Stops\n" +
+			"Warehouse,Vehicle,Plan,Route ID,Sequence,Type,Customer,Address,Quantity,ETA\n" +
+			"North,Truck,Lube,3,1,delivery,Acme,1 Main St,10,09:00\n" +
+			"North,Van,Lube,2,1,delivery,Acme,1 Main St,10,06:00\n" +
+			"South,Truck,Fuel,1,1,delivery,Acme,1 Main St,10,07:00\n"
+		if got := w.Body.String(); got != want {
+			t.Errorf("ExportDispatch() body = %q, want %q", got, want)
+		}
+	})
+
+	t.Run("driver", func(t *testing.T) {
+		w := get(t, driver, "?date=2024-05-06&format=csv")
+		if got := w.Body.String(); w.Code != http.StatusOK || strings.Count(got, "Lube") != 0 || strings.Count(got, "Fuel") != 2 {
+			t.Errorf("ExportDispatch() as driver = %q, want only the approved plan's route", got)
+		}
+	})
+
+	t.Run("bad date", func(t *testing.T) {
+		if w := get(t, planner, "?date=06/05/2024"); w.Code != http.StatusBadRequest {
+			t.Errorf("ExportDispatch(bad date) status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+
+	"LogiTrackPro/backend/internal/doctor"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Doctor runs the dependency checks against the handler's database,
+// optimizer, storage, clock and configured webhooks
+func (h *Handler) Doctor(ctx context.Context) *models.DoctorReport {
+	deps := doctor.Deps{
+		DB:        h.db,
+		Optimizer: h.optimizer,
+		Storage:   h.artifacts,
+		Clock:     h.clock,
+	}
+	if h.config != nil {
+		if h.config.SecurityWebhookURL != "" {
+			deps.Webhooks = append(deps.Webhooks, doctor.Webhook{Name: "security", URL: h.config.SecurityWebhookURL})
+		}
+		if h.config.PushGatewayURL != "" {
+			deps.Webhooks = append(deps.Webhooks, doctor.Webhook{Name: "push_gateway", URL: h.config.PushGatewayURL})
+		}
+	}
+	return doctor.Run(ctx, deps)
+}
+
+// GetDoctor handles GET /api/v1/admin/doctor
+// Runs deep checks of the API's dependencies: migrations applied, indexes
+// present, optimizer version, storage writable, clock skew against the
+// database and webhook endpoints reachable, each reported as pass, warn or
+// fail with the worst as the overall status.
+func (h *Handler) GetDoctor(c *gin.Context) {
+	successResponse(c, h.Doctor(c.Request.Context()))
+}
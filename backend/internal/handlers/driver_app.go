@@ -0,0 +1,339 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+	"LogiTrackPro/backend/internal/push"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type DriverStopEventRequest struct {
+	ClientEventID  string     `json:"client_event_id" binding:"required,uuid"`
+	Status         string     `json:"status" binding:"required,oneof=arrived completed skipped failed"`
+	ActualQuantity *float64   `json:"actual_quantity" binding:"omitempty,gte=0"`
+	Time           *time.Time `json:"time"`
+	Notes          string     `json:"notes"`
+}
+
+type SetPlanNotesRequest struct {
+	Notes   string `json:"notes" binding:"max=4000"`
+	Version int64  `json:"version"`
+}
+
+// driverRouteStatuses are the plan statuses whose routes drivers see
+var driverRouteStatuses = []string{planstate.Approved, planstate.Executing}
+
+// GetDriverRoutes handles GET /api/v1/driver/routes?date=&view=
+// The signed-in driver's routes of approved and executing plans on the date
+// (default today), each with its stops, their execution state and the
+// notes that apply. view=compact returns models.CompactRoute.
+func (h *Handler) GetDriverRoutes(c *gin.Context) {
+	driver, ok := h.currentDriver(c)
+	if !ok {
+		return
+	}
+	today := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	date, err := parseDateQuery(c, "date", today)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+	compact, err := compactView(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	routes, err := database.GetDriverRoutes(h.db, driver.ID, date, driverRouteStatuses)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+	routeIDs := make([]int64, len(routes))
+	for i, r := range routes {
+		routeIDs[i] = r.ID
+	}
+	executions, err := database.GetExecutionsForRoutes(h.db, routeIDs)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route executions")
+		return
+	}
+	rosterNotes, err := database.GetDriverRosterNotes(h.db, driver.ID, date)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch roster")
+		return
+	}
+
+	// executions come newest first; a route shows its latest one
+	latest := make(map[int64]*models.RouteExecution, len(executions))
+	for i := range executions {
+		if _, ok := latest[executions[i].RouteID]; !ok {
+			latest[executions[i].RouteID] = &executions[i]
+		}
+	}
+	result := make([]models.DriverRoute, len(routes))
+	for i, r := range routes {
+		result[i] = driverRoute(r, latest[r.ID], rosterNotes)
+	}
+	if compact {
+		routes := make([]models.CompactRoute, len(result))
+		for i, r := range result {
+			routes[i] = compactRoute(r)
+		}
+		successResponse(c, routes)
+		return
+	}
+	successResponse(c, result)
+}
+
+// driverRoute builds the driver app view of a route and its execution
+func driverRoute(r models.Route, execution *models.RouteExecution, rosterNotes []string) models.DriverRoute {
+	route := models.DriverRoute{
+		RouteID:      r.ID,
+		PlanID:       r.PlanID,
+		Date:         r.Date,
+		VehicleID:    r.VehicleID,
+		VehicleName:  vehicleName(r.Vehicle),
+		PlannedStart: r.PlannedStart,
+		PlannedEnd:   r.PlannedEnd,
+		TotalLoad:    r.TotalLoad,
+		Notes:        []string{},
+		Stops:        make([]models.DriverStop, len(r.Stops)),
+	}
+	if r.Plan != nil && r.Plan.Notes != "" {
+		route.Notes = append(route.Notes, r.Plan.Notes)
+	}
+	route.Notes = append(route.Notes, rosterNotes...)
+
+	stopExecutions := make(map[int64]models.StopExecution)
+	if execution != nil {
+		route.ExecutionID = &execution.ID
+		route.ExecutionStatus = execution.Status
+		for _, se := range execution.StopExecutions {
+			stopExecutions[se.StopID] = se
+		}
+	}
+	for i, s := range r.Stops {
+		stop := models.DriverStop{
+			StopID:      s.ID,
+			Sequence:    s.Sequence,
+			Type:        s.Type,
+			CustomerID:  s.CustomerID,
+			PlaceID:     s.PlaceID,
+			Quantity:    s.Quantity,
+			ArrivalTime: s.ArrivalTime,
+			Status:      "pending",
+		}
+		if s.Customer != nil {
+			stop.CustomerName = s.Customer.Name
+			stop.Address = s.Customer.Address
+			stop.Latitude = s.Customer.Latitude
+			stop.Longitude = s.Customer.Longitude
+		}
+		if s.Place != nil {
+			stop.PlaceName = s.Place.Name
+			stop.Address = s.Place.Address
+			stop.Latitude = s.Place.Latitude
+			stop.Longitude = s.Place.Longitude
+		}
+		if se, ok := stopExecutions[s.ID]; ok {
+			stop.StopExecutionID = &se.ID
+			stop.Status = se.Status
+			stop.ActualQuantity = se.ActualQuantity
+		}
+		route.Stops[i] = stop
+	}
+	return route
+}
+
+// PostDriverStopEvent handles POST /api/v1/driver/stops/:id/events?view=
+// Reports a stop execution of one of the driver's routes as arrived,
+// completed, skipped or failed (see UpdateStopExecution), at the device
+// time when given. Events are idempotent by client_event_id: an event that
+// was already applied is answered with the stop's current state and
+// "replayed": true, so the app can retry uploads after losing connectivity.
+// view=compact returns the stop as models.CompactStopExecution.
+func (h *Handler) PostDriverStopEvent(c *gin.Context) {
+	driver, ok := h.currentDriver(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stop execution ID")
+		return
+	}
+	var req DriverStopEventRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	compact, err := compactView(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stop, err := database.GetStopExecution(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stop execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop execution")
+		return
+	}
+	if stop.RouteExecution == nil || stop.RouteExecution.Route == nil ||
+		stop.RouteExecution.Route.DriverID == nil || *stop.RouteExecution.Route.DriverID != driver.ID {
+		errorResponse(c, http.StatusForbidden, "Stop is not on one of your routes")
+		return
+	}
+
+	applied, err := database.GetDriverStopEvent(h.db, req.ClientEventID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop event")
+		return
+	}
+	if applied != nil {
+		if applied.StopExecutionID != id {
+			errorResponse(c, http.StatusConflict, "client_event_id was already used for another stop")
+			return
+		}
+		stop.RouteExecution = nil
+		respondWithStopEvent(c, stop, true, compact)
+		return
+	}
+
+	execution, err := h.executions.Get(stop.RouteExecutionID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	event := &models.DriverStopEvent{
+		ClientEventID:   req.ClientEventID,
+		StopExecutionID: id,
+		UserID:          c.GetInt64("userID"),
+		Status:          req.Status,
+		ActualQuantity:  req.ActualQuantity,
+		Notes:           req.Notes,
+		OccurredAt:      req.Time,
+	}
+	outcome := UpdateStopExecutionRequest{Status: req.Status, ActualQuantity: req.ActualQuantity, Time: req.Time, Notes: req.Notes}
+	updated, ok := h.reportStop(c, execution, id, outcome, func(tx *gorm.DB) error {
+		return database.CreateDriverStopEventTx(tx, event)
+	})
+	if !ok {
+		return
+	}
+	respondWithStopEvent(c, updated, false, compact)
+}
+
+// respondWithStopEvent responds with the state of a stop after a driver
+// stop event, as {"stop_execution", "replayed"} or compact {"se", "r"}
+func respondWithStopEvent(c *gin.Context, stop *models.StopExecution, replayed, compact bool) {
+	if compact {
+		successResponse(c, gin.H{"se": compactStopExecution(stop), "r": replayed})
+		return
+	}
+	successResponse(c, gin.H{"stop_execution": stop, "replayed": replayed})
+}
+
+// currentDriver loads the driver linked to the signed-in user, writing a
+// 404 when there is none
+func (h *Handler) currentDriver(c *gin.Context) (*models.Driver, bool) {
+	driver, err := database.GetDriverByUserID(h.db, c.GetInt64("userID"))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorCodeResponse(c, http.StatusNotFound, "DRIVER_NOT_FOUND", "No driver is linked to this user")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver")
+		return nil, false
+	}
+	return driver, true
+}
+
+// SetPlanNotes handles PUT /api/v1/plans/:id/notes
+// Notes are shown to the drivers of the plan's routes. Changed notes of an
+// approved or executing plan are pushed to the drivers with routes from
+// today on.
+func (h *Handler) SetPlanNotes(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+	var req SetPlanNotesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if staleVersion(c, "Plan", version, plan.Version) {
+		return
+	}
+	changed := plan.Notes != req.Notes
+	plan.Version, err = database.SetPlanNotes(h.db, id, version, req.Notes)
+	if err != nil {
+		if versionConflict(c, "Plan", err) {
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update plan")
+		return
+	}
+	plan.Notes = req.Notes
+	if changed && req.Notes != "" && (plan.Status == planstate.Approved || plan.Status == planstate.Executing) {
+		h.notifyPlanNotes(plan)
+	}
+	setETag(c, plan.Version)
+	successResponse(c, plan)
+}
+
+// notifyPlanNotes queues a push notification of a plan's notes for the
+// drivers of its upcoming routes. Failures are logged since the notes are
+// already saved.
+func (h *Handler) notifyPlanNotes(plan *models.Plan) {
+	if h.config == nil || h.config.PushGatewayURL == "" {
+		return
+	}
+	today := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	userIDs, err := database.GetPlanDriverUserIDs(h.db, plan.ID, today)
+	if err != nil {
+		log.Printf("Failed to find drivers of plan %d: %v", plan.ID, err)
+		return
+	}
+	for _, userID := range userIDs {
+		notification := push.Notification{
+			UserID: userID,
+			Title:  "Notes for " + plan.Name,
+			Body:   truncate(plan.Notes, 200),
+			Data:   map[string]string{"plan_id": strconv.FormatInt(plan.ID, 10)},
+		}
+		if _, err := jobs.Enqueue(h.db, push.JobType, notification, jobs.Options{RunAt: h.clock.Now()}); err != nil {
+			log.Printf("Failed to queue plan %d notes push to user %d: %v", plan.ID, userID, err)
+		}
+	}
+}
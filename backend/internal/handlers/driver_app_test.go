@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/push"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestDriverApp tests the driver's routes of the day, idempotent stop
+// events and the push of plan notes
+func TestDriverApp(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.PushGatewayURL = "http://push.example.com/notify" })
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.api.GET("/driver/routes", s.h.RoleMiddleware("driver"), s.h.GetDriverRoutes)
+	s.api.POST("/driver/stops/:id/events", s.h.RoleMiddleware("driver"), s.h.PostDriverStopEvent)
+	s.api.PUT("/plans/:id/notes", s.h.SetPlanNotes)
+
+	manager := s.fx.User("manager")
+	driverUser := s.fx.User("driver")
+	otherUser := s.fx.User("driver")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 2, testkit.WithStatus("approved"))
+	today := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer(), s.fx.Customer())
+	tomorrow := s.fx.Route(plan, s.fx.Vehicle(warehouse), 2, s.fx.Customer())
+	for _, u := range []*models.User{driverUser, otherUser} {
+		driver := &models.Driver{Name: u.Name, UserID: &u.ID}
+		if err := database.CreateDriver(s.db, driver); err != nil {
+			t.Fatal(err)
+		}
+		if u == driverUser {
+			s.db.Model(today).Update("driver_id", driver.ID)
+			s.db.Model(tomorrow).Update("driver_id", driver.ID)
+		}
+	}
+
+	execution := &models.RouteExecution{RouteID: today.ID, Status: "pending", PlannedLoad: today.TotalLoad}
+	for _, stop := range today.Stops {
+		execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{StopID: stop.ID, Status: "pending", PlannedQuantity: stop.Quantity})
+	}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+	first := execution.StopExecutions[0].ID
+
+	token := e2eLogin(t, s.router, driverUser).Token
+	events := fmt.Sprintf("/api/v1/driver/stops/%d/events", first)
+	completed := DriverStopEventRequest{ClientEventID: "0b5c5c0e-3d5e-4a8a-9a57-0d6f2c1b9e11", Status: "completed"}
+	post := func(t *testing.T, token string, req DriverStopEventRequest) (int, bool) {
+		t.Helper()
+		w := s.do(t, "POST", events, token, req)
+		var resp struct {
+			Data struct{ Replayed bool }
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data.Replayed
+	}
+	// the full view's size, to compare the compact one with
+	var full int
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"plan notes pushed", func(t *testing.T) {
+			if w := s.do(t, "PUT", fmt.Sprintf("/api/v1/plans/%d/notes", plan.ID), e2eLogin(t, s.router, manager).Token,
+				SetPlanNotesRequest{Notes: "Gate B is closed, use the side entrance"}); w.Code != http.StatusOK {
+				t.Fatalf("set notes status = %d: %s", w.Code, w.Body.String())
+			}
+			queued, err := database.ListJobs(s.db, "", push.JobType, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(queued) != 1 {
+				t.Fatalf("queued %d pushes, want one for the plan's driver", len(queued))
+			}
+			var n push.Notification
+			json.Unmarshal([]byte(queued[0].Payload), &n)
+			if n.UserID != driverUser.ID || n.Data["plan_id"] != fmt.Sprint(plan.ID) {
+				t.Errorf("push = %+v, want the plan notes for user %d", n, driverUser.ID)
+			}
+		}},
+		{"routes of the day", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/driver/routes", token, nil)
+			var routes struct{ Data []models.DriverRoute }
+			json.Unmarshal(w.Body.Bytes(), &routes)
+			if w.Code != http.StatusOK || len(routes.Data) != 1 || routes.Data[0].RouteID != today.ID {
+				t.Fatalf("routes = %d %+v, want today's route only", w.Code, routes.Data)
+			}
+			full = w.Body.Len()
+			got := routes.Data[0]
+			if got.ExecutionID == nil || *got.ExecutionID != execution.ID || len(got.Stops) != 2 || got.Stops[0].StopExecutionID == nil ||
+				*got.Stops[0].StopExecutionID != first || got.Stops[0].CustomerName == "" || len(got.Notes) != 1 {
+				t.Errorf("route = %+v, want the execution, both stops with customers and the plan notes", got)
+			}
+			if w := s.do(t, "GET", "/api/v1/driver/routes?date=2024-03-05", token, nil); w.Code != http.StatusOK {
+				t.Errorf("tomorrow status = %d", w.Code)
+			}
+		}},
+		{"compact view", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/driver/routes?view=compact", token, nil)
+			var compact struct{ Data []models.CompactRoute }
+			json.Unmarshal(w.Body.Bytes(), &compact)
+			if w.Code != http.StatusOK || len(compact.Data) != 1 || compact.Data[0].ID != today.ID || compact.Data[0].Date != today.Date.Unix() ||
+				compact.Data[0].ExecutionID != execution.ID || len(compact.Data[0].Stops) != 2 || compact.Data[0].Stops[0].ExecutionID != first {
+				t.Errorf("compact routes = %d %+v", w.Code, compact.Data)
+			}
+			if w.Body.Len() >= full {
+				t.Errorf("compact payload is %d bytes, want less than the full %d", w.Body.Len(), full)
+			}
+			if w := s.do(t, "GET", "/api/v1/driver/routes?view=tiny", token, nil); w.Code != http.StatusBadRequest {
+				t.Errorf("unknown view status = %d, want 400", w.Code)
+			}
+		}},
+		{"stop events", func(t *testing.T) {
+			if code, _ := post(t, e2eLogin(t, s.router, otherUser).Token, completed); code != http.StatusForbidden {
+				t.Errorf("other driver status = %d, want 403", code)
+			}
+			if code, _ := post(t, token, DriverStopEventRequest{ClientEventID: "not-a-uuid", Status: "completed"}); code != http.StatusBadRequest {
+				t.Errorf("invalid client_event_id status = %d, want 400", code)
+			}
+			for i, want := range []bool{false, true} {
+				if code, replayed := post(t, token, completed); code != http.StatusOK || replayed != want {
+					t.Fatalf("upload %d = %d replayed %v, want 200 replayed %v", i+1, code, replayed, want)
+				}
+			}
+			stored, err := s.h.executions.Get(execution.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if stored.ActualLoad != 10 {
+				t.Errorf("actual load = %v after a replayed event, want 10", stored.ActualLoad)
+			}
+		}},
+		{"reused client event", func(t *testing.T) {
+			reused := fmt.Sprintf("/api/v1/driver/stops/%d/events", execution.StopExecutions[1].ID)
+			if w := s.do(t, "POST", reused, token, completed); w.Code != http.StatusConflict {
+				t.Errorf("reused client_event_id status = %d, want 409", w.Code)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
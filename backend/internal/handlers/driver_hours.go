@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/hos"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ExecutionShiftRequest struct {
+	ShiftStart time.Time           `json:"shift_start" binding:"required"`
+	ShiftEnd   *time.Time          `json:"shift_end"`
+	Breaks     []models.ShiftBreak `json:"breaks" binding:"max=50"`
+}
+
+// hosRules returns the configured hours-of-service rules
+func (h *Handler) hosRules() hos.Rules {
+	return hos.Rules{
+		MaxDailyDriving:   time.Duration(h.config.MaxDailyDriving) * time.Minute,
+		MaxWeeklyDriving:  time.Duration(h.config.MaxWeeklyDriving) * time.Minute,
+		BreakAfterDriving: time.Duration(h.config.BreakAfterDriving) * time.Minute,
+		BreakDuration:     time.Duration(h.config.BreakDuration) * time.Minute,
+	}
+}
+
+// RecordExecutionShift handles PUT /api/v1/executions/:id/shift
+// Records when the driver started and ended work on the route and the
+// breaks they took, which must fall within the shift and not overlap. The
+// shift may be left open while the route is under way. Hours-of-service
+// rules the driver breaches on the shift's day or week are returned as
+// warnings.
+func (h *Handler) RecordExecutionShift(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
+		return
+	}
+
+	var req ExecutionShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if err := validateShift(req); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	if !h.canReportExecution(c, execution) {
+		return
+	}
+
+	if err := database.SetExecutionShift(h.db, id, req.ShiftStart, req.ShiftEnd, req.Breaks); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to record shift")
+		return
+	}
+	execution, err = h.executions.Get(id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	h.publishExecution(execution)
+
+	var warnings []string
+	if execution.Route != nil && execution.Route.DriverID != nil {
+		driverID := *execution.Route.DriverID
+		week := hos.WeekStart(req.ShiftStart)
+		_, violations, err := h.driverHours([]int64{driverID}, week, week.AddDate(0, 0, 7))
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to check hours of service")
+			return
+		}
+		year, isoWeek := req.ShiftStart.ISOWeek()
+		periods := map[string]bool{
+			req.ShiftStart.Format("2006-01-02"):    true,
+			fmt.Sprintf("%d-W%02d", year, isoWeek): true,
+		}
+		for _, v := range violations {
+			if v.Rule == hos.ContinuousDriving && (v.ExecutionID == nil || *v.ExecutionID != id) || !periods[v.Period] {
+				continue
+			}
+			warnings = append(warnings, fmt.Sprintf("Driver exceeds %s on %s: %d minutes against a limit of %d",
+				v.Rule, v.Period, v.ActualMinutes, v.LimitMinutes))
+		}
+	}
+	warningResponse(c, execution, warnings)
+}
+
+// validateShift checks a shift ends after it starts and its breaks fall
+// within it without overlapping
+func validateShift(req ExecutionShiftRequest) error {
+	if req.ShiftEnd != nil && !req.ShiftEnd.After(req.ShiftStart) {
+		return errors.New("shift_end must be after shift_start")
+	}
+	breaks := append([]models.ShiftBreak(nil), req.Breaks...)
+	sort.Slice(breaks, func(i, j int) bool { return breaks[i].Start.Before(breaks[j].Start) })
+	for i, b := range breaks {
+		if !b.End.After(b.Start) {
+			return errors.New("breaks must end after they start")
+		}
+		if b.Start.Before(req.ShiftStart) || (req.ShiftEnd != nil && b.End.After(*req.ShiftEnd)) {
+			return errors.New("breaks must fall within the shift")
+		}
+		if i > 0 && b.Start.Before(breaks[i-1].End) {
+			return errors.New("breaks must not overlap")
+		}
+	}
+	return nil
+}
+
+// driverHours checks the shifts starting between from and to, of some
+// drivers or all of them
+func (h *Handler) driverHours(driverIDs []int64, from, to time.Time) ([]models.DriverDay, []models.HoursViolation, error) {
+	executions, err := database.GetDriverShifts(h.db, driverIDs, from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+	days, violations := h.checkShifts(executions)
+	return days, violations, nil
+}
+
+// checkShifts checks the shifts of route executions against the
+// hours-of-service rules
+func (h *Handler) checkShifts(executions []models.RouteExecution) ([]models.DriverDay, []models.HoursViolation) {
+	now := h.clock.Now()
+	shifts := make([]hos.Shift, 0, len(executions))
+	for i := range executions {
+		if shift, ok := hos.FromExecution(&executions[i], now); ok {
+			shifts = append(shifts, shift)
+		}
+	}
+	return hos.Check(h.hosRules(), shifts)
+}
+
+// GetDriverHours handles GET /api/v1/analytics/driver-hours?from=&to=&driver_id=
+// Drivers' working, driving and break time by day from the shifts recorded
+// on route executions (their actual start and end without one), by default
+// this week, with the hours-of-service rules breached. Weekly driving is
+// counted from the Monday of from's week.
+func (h *Handler) GetDriverHours(c *gin.Context) {
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	driverID, err := parseIDQuery(c, "driver_id")
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	today := h.clock.Now().Truncate(24 * time.Hour)
+	if to == nil {
+		to = &today
+	}
+	if from == nil {
+		start := hos.WeekStart(*to)
+		from = &start
+	}
+	if to.Before(*from) {
+		errorResponse(c, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	var driverIDs []int64
+	if driverID != nil {
+		driverIDs = []int64{*driverID}
+	}
+	days, violations, err := h.driverHours(driverIDs, hos.WeekStart(*from), to.AddDate(0, 0, 1).Add(-time.Nanosecond))
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver shifts")
+		return
+	}
+
+	// earlier days of from's week only count towards its weekly driving
+	first := from.Format("2006-01-02")
+	report := &models.DriverHoursReport{
+		From:                    first,
+		To:                      to.Format("2006-01-02"),
+		MaxDailyDrivingMinutes:  h.config.MaxDailyDriving,
+		MaxWeeklyDrivingMinutes: h.config.MaxWeeklyDriving,
+		BreakAfterMinutes:       h.config.BreakAfterDriving,
+		Days:                    []models.DriverDay{},
+		Violations:              []models.HoursViolation{},
+	}
+	for _, day := range days {
+		if day.Date >= first {
+			report.Days = append(report.Days, day)
+		}
+	}
+	for _, v := range violations {
+		if v.Rule == hos.WeeklyDriving || v.Period >= first {
+			report.Violations = append(report.Violations, v)
+		}
+	}
+	successResponse(c, report)
+}
+
+// planHoursViolations returns the hours-of-service rules breached by the
+// drivers of a plan's routes on the plan's days and in its weeks
+func (h *Handler) planHoursViolations(plan *models.Plan) ([]models.HoursViolation, error) {
+	from := hos.WeekStart(plan.StartDate)
+	to := hos.WeekStart(plan.EndDate).AddDate(0, 0, 7)
+	executions, err := database.GetDriverShifts(h.db, nil, from, to)
+	if err != nil {
+		return nil, err
+	}
+	drivers := make(map[int64]bool)
+	for _, e := range executions {
+		if e.Route.PlanID == plan.ID {
+			drivers[*e.Route.DriverID] = true
+		}
+	}
+	var shifts []models.RouteExecution
+	for _, e := range executions {
+		if drivers[*e.Route.DriverID] {
+			shifts = append(shifts, e)
+		}
+	}
+	_, violations := h.checkShifts(shifts)
+
+	first, last := plan.StartDate.Format("2006-01-02"), plan.EndDate.Format("2006-01-02")
+	firstYear, firstWeek := plan.StartDate.ISOWeek()
+	lastYear, lastWeek := plan.EndDate.ISOWeek()
+	firstWeekKey, lastWeekKey := fmt.Sprintf("%d-W%02d", firstYear, firstWeek), fmt.Sprintf("%d-W%02d", lastYear, lastWeek)
+	inPlan := []models.HoursViolation{}
+	for _, v := range violations {
+		if v.Rule == hos.WeeklyDriving && v.Period >= firstWeekKey && v.Period <= lastWeekKey ||
+			v.Rule != hos.WeeklyDriving && v.Period >= first && v.Period <= last {
+			inPlan = append(inPlan, v)
+		}
+	}
+	return inPlan, nil
+}
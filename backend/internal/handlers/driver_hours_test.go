@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestDriverHours tests that recorded shifts are checked against the
+// hours-of-service rules when recorded, in the driver hours report and in
+// the plan's execution stats
+func TestDriverHours(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.MaxDailyDriving, cfg.MaxWeeklyDriving, cfg.BreakAfterDriving, cfg.BreakDuration = 540, 3360, 270, 45
+	})
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 6, 12, 0, 0, 0, time.UTC)))
+	s.api.PUT("/executions/:id/shift", s.h.RecordExecutionShift)
+	s.api.GET("/analytics/driver-hours", s.h.GetDriverHours)
+	s.api.GET("/plans/:id/execution-stats", s.h.GetPlanExecutionStats)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	driver := s.fx.Driver(warehouse)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	plan := s.fx.Plan(warehouse, day, 2, testkit.WithStatus("executing"))
+	vehicle := s.fx.Vehicle(warehouse)
+	execute := func(planDay int) *models.RouteExecution {
+		route := s.fx.Route(plan, vehicle, planDay, s.fx.Customer())
+		if err := s.db.Model(route).Update("driver_id", driver.ID).Error; err != nil {
+			t.Fatal(err)
+		}
+		execution := &models.RouteExecution{RouteID: route.ID, Status: "completed"}
+		if err := database.CreateRouteExecution(s.db, execution); err != nil {
+			t.Fatal(err)
+		}
+		return execution
+	}
+	monday, tuesday := execute(1), execute(2)
+	at := func(days, hour, minute int) time.Time {
+		return day.AddDate(0, 0, days).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+
+	type response struct {
+		Data     models.RouteExecution
+		Warnings []string
+	}
+	record := func(t *testing.T, execution *models.RouteExecution, req ExecutionShiftRequest) response {
+		t.Helper()
+		w := s.do(t, "PUT", fmt.Sprintf("/api/v1/executions/%d/shift", execution.ID), token, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("record shift status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	t.Run("compliant shift", func(t *testing.T) {
+		// 8 hours with a break after 4 is within the rules
+		end := at(0, 16, 45)
+		resp := record(t, monday, ExecutionShiftRequest{ShiftStart: at(0, 8, 0), ShiftEnd: &end,
+			Breaks: []models.ShiftBreak{{Start: at(0, 12, 0), End: at(0, 12, 45)}}})
+		if len(resp.Warnings) != 0 || resp.Data.ShiftStart == nil || len(resp.Data.Breaks) != 1 {
+			t.Errorf("compliant shift = %+v with warnings %v, want it recorded without warnings", resp.Data, resp.Warnings)
+		}
+	})
+
+	t.Run("violating shift", func(t *testing.T) {
+		// 10 hours without a break breaks the daily and continuous limits
+		end := at(1, 16, 0)
+		resp := record(t, tuesday, ExecutionShiftRequest{ShiftStart: at(1, 6, 0), ShiftEnd: &end})
+		if len(resp.Warnings) != 2 {
+			t.Errorf("warnings = %v, want daily and continuous driving", resp.Warnings)
+		}
+	})
+
+	for name, req := range map[string]ExecutionShiftRequest{
+		"end before start":     {ShiftStart: at(1, 6, 0), ShiftEnd: &[]time.Time{at(1, 5, 0)}[0]},
+		"break outside shift":  {ShiftStart: at(1, 6, 0), Breaks: []models.ShiftBreak{{Start: at(1, 5, 0), End: at(1, 5, 30)}}},
+		"overlapping breaks":   {ShiftStart: at(1, 6, 0), Breaks: []models.ShiftBreak{{Start: at(1, 9, 0), End: at(1, 10, 0)}, {Start: at(1, 9, 30), End: at(1, 10, 30)}}},
+		"break ending earlier": {ShiftStart: at(1, 6, 0), Breaks: []models.ShiftBreak{{Start: at(1, 9, 0), End: at(1, 8, 0)}}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := s.do(t, "PUT", fmt.Sprintf("/api/v1/executions/%d/shift", tuesday.ID), token, req)
+			if w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+		})
+	}
+
+	t.Run("driver hours report", func(t *testing.T) {
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/analytics/driver-hours?driver_id=%d", driver.ID), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("driver hours status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var hours struct{ Data models.DriverHoursReport }
+		json.Unmarshal(w.Body.Bytes(), &hours)
+		report := hours.Data
+		if report.From != "2024-03-04" || len(report.Days) != 2 || report.Days[0].DrivingMinutes != 480 || report.Days[1].DrivingMinutes != 600 {
+			t.Errorf("days = %+v from %s, want 480 then 600 minutes this week", report.Days, report.From)
+		}
+		if len(report.Violations) != 2 || report.Violations[0].Period != "2024-03-05" {
+			t.Errorf("violations = %+v, want two on Tuesday", report.Violations)
+		}
+	})
+
+	t.Run("execution stats", func(t *testing.T) {
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/plans/%d/execution-stats", plan.ID), token, nil)
+		var stats struct {
+			Data struct {
+				Violations []models.HoursViolation `json:"hours_of_service_violations"`
+			}
+		}
+		json.Unmarshal(w.Body.Bytes(), &stats)
+		if len(stats.Data.Violations) != 2 || stats.Data.Violations[0].DriverID != driver.ID {
+			t.Errorf("execution stats violations = %+v, want the driver's two", stats.Data.Violations)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DriverRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Phone         string `json:"phone"`
+	LicenseNumber string `json:"license_number"`
+	Status        string `json:"status" binding:"omitempty,oneof=active on_leave inactive"`
+	WarehouseID   int64  `json:"warehouse_id"`
+	UserID        *int64 `json:"user_id"`
+}
+
+func (r *DriverRequest) toModel() *models.Driver {
+	status := r.Status
+	if status == "" {
+		status = "active"
+	}
+	return &models.Driver{
+		Name:          r.Name,
+		Phone:         r.Phone,
+		LicenseNumber: r.LicenseNumber,
+		Status:        status,
+		WarehouseID:   warehouseIDPtr(r.WarehouseID),
+		UserID:        r.UserID,
+	}
+}
+
+// ListDrivers handles GET /api/v1/drivers
+func (h *Handler) ListDrivers(c *gin.Context) {
+	drivers, err := database.ListDrivers(h.db)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch drivers")
+		return
+	}
+	if drivers == nil {
+		drivers = []models.Driver{}
+	}
+	successResponse(c, drivers)
+}
+
+// GetDriver handles GET /api/v1/drivers/:id
+func (h *Handler) GetDriver(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	driver, err := database.GetDriver(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Driver")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver")
+		return
+	}
+	successResponse(c, driver)
+}
+
+// CreateDriver handles POST /api/v1/drivers
+func (h *Handler) CreateDriver(c *gin.Context) {
+	var req DriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	driver := req.toModel()
+	if err := database.CreateDriver(h.db, driver); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create driver")
+		return
+	}
+	createdResponse(c, driver)
+}
+
+// UpdateDriver handles PUT /api/v1/drivers/:id
+func (h *Handler) UpdateDriver(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	var req DriverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	driver := req.toModel()
+	driver.ID = id
+	if err := database.UpdateDriver(h.db, driver); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Driver")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update driver")
+		return
+	}
+	successResponse(c, driver)
+}
+
+// DeleteDriver handles DELETE /api/v1/drivers/:id
+func (h *Handler) DeleteDriver(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	if err := database.DeleteDriver(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Driver")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete driver")
+		return
+	}
+	successResponse(c, gin.H{"message": "Driver deleted successfully"})
+}
@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestEndToEndPlanLifecycle drives a plan from draft to an executing route
+// against the fake optimizer with a frozen clock
+func TestEndToEndPlanLifecycle(t *testing.T) {
+	s := newTestServer(t)
+	clock := testkit.NewClock(time.Date(2024, 3, 4, 6, 30, 0, 0, time.UTC))
+	s.h.SetClock(clock)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.POST("/plans/:id/approve", s.h.ApprovePlan)
+	s.api.POST("/plans/:id/execute", s.h.ExecutePlan)
+	s.api.GET("/routes/:id/executions", s.h.GetRouteExecutions)
+	s.api.POST("/executions/:id/start", s.h.StartRouteExecution)
+	s.api.POST("/executions/:id/complete", s.h.CompleteRouteExecution)
+
+	manager := s.fx.User("manager")
+	warehouse := s.fx.Warehouse()
+	s.fx.Customer()
+	s.fx.Customer()
+	s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3)
+
+	login := e2eLogin(t, s.router, manager)
+	if !login.ExpiresAt.Equal(clock.Now().Add(24 * time.Hour)) {
+		t.Errorf("token expires at %v, want 24h after the frozen clock", login.ExpiresAt)
+	}
+	token := login.Token
+
+	// each step needs the one before it, so the lifecycle stops at the
+	// first that fails
+	var (
+		approvedAt time.Time
+		routes     []models.Route
+		execution  models.RouteExecution
+	)
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"optimize", func(t *testing.T) {
+			// The first optimizer call fails, the retry gets the default answer
+			s.opt.Then(testkit.Fail())
+			optimize := fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID)
+			if w := s.do(t, "POST", optimize, token, nil); w.Code != http.StatusInternalServerError {
+				t.Fatalf("optimize with failing optimizer status = %d, want 500: %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "POST", optimize, token, nil); w.Code != http.StatusOK {
+				t.Fatalf("optimize status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			if got := len(s.opt.Requests()); got != 2 {
+				t.Errorf("optimizer received %d requests, want 2", got)
+			}
+			if req := s.opt.LastRequest(); req.StartDate != "2024-03-04" || len(req.Customers) != 2 {
+				t.Errorf("last optimizer request = %+v, want both customers from 2024-03-04", req)
+			}
+		}},
+		{"approve", func(t *testing.T) {
+			approvedAt = clock.Advance(90 * time.Minute)
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/approve", plan.ID), token, nil); w.Code != http.StatusOK {
+				t.Fatalf("approve status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			stored, _ := database.GetPlan(s.db, plan.ID)
+			if stored.ApprovedAt == nil || !stored.ApprovedAt.Equal(approvedAt) {
+				t.Errorf("approved at %v, want %v", stored.ApprovedAt, approvedAt)
+			}
+			routes, _ = database.GetRoutesByPlan(s.db, plan.ID)
+			if len(routes) != 1 {
+				t.Fatalf("plan has %d routes, want 1", len(routes))
+			}
+		}},
+		{"execute", func(t *testing.T) {
+			// Approval created the execution records; executing adds no more
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/execute", plan.ID), token, nil); w.Code != http.StatusOK {
+				t.Fatalf("execute status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			w := s.do(t, "GET", fmt.Sprintf("/api/v1/routes/%d/executions", routes[0].ID), token, nil)
+			var listed struct{ Data []models.RouteExecution }
+			json.Unmarshal(w.Body.Bytes(), &listed)
+			if len(listed.Data) != 1 {
+				t.Fatalf("route has %d executions, want 1 created on approval", len(listed.Data))
+			}
+			execution = listed.Data[0]
+			if !execution.CreatedAt.Equal(approvedAt) || execution.Status != "pending" || execution.PlannedLoad != routes[0].TotalLoad {
+				t.Errorf("execution = %+v, want a pending record of the route created at %v", execution, approvedAt)
+			}
+			stops, _ := database.GetStopExecutionsByRouteExecution(s.db, execution.ID)
+			if len(stops) != len(routes[0].Stops) || stops[0].PlannedQuantity != routes[0].Stops[0].Quantity || stops[0].PlannedArrivalTime == nil || !stops[0].PlannedArrivalTime.Equal(time.Date(2024, 3, 4, 8, 30, 0, 0, time.UTC)) {
+				t.Errorf("stop executions = %+v, want one per stop with planned quantity and arrival at 08:30", stops)
+			}
+		}},
+		{"start", func(t *testing.T) {
+			startedAt := clock.Advance(30 * time.Minute)
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/start", execution.ID), token, StartRouteExecutionRequest{}); w.Code != http.StatusOK {
+				t.Fatalf("start execution status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			started, _ := database.GetRouteExecution(s.db, execution.ID)
+			if started.ActualStartTime == nil || !started.ActualStartTime.Equal(startedAt) {
+				t.Errorf("execution started at %v, want %v", started.ActualStartTime, startedAt)
+			}
+		}},
+		{"complete", func(t *testing.T) {
+			completedAt := clock.Advance(5 * time.Hour)
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/complete", execution.ID), token, CompleteRouteExecutionRequest{ActualDistance: 42}); w.Code != http.StatusOK {
+				t.Fatalf("complete execution status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			completed, _ := database.GetRouteExecution(s.db, execution.ID)
+			if completed.ActualEndTime == nil || !completed.ActualEndTime.Equal(completedAt) {
+				t.Errorf("execution completed at %v, want %v", completed.ActualEndTime, completedAt)
+			}
+			if !completed.UpdatedAt.Equal(completedAt) {
+				t.Errorf("execution updated at %v, want %v", completed.UpdatedAt, completedAt)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
+
+// e2eLogin logs a testkit user in through a router serving
+// POST /api/v1/auth/login
+func e2eLogin(t *testing.T, router http.Handler, user *models.User) AuthResponse {
+	t.Helper()
+	w := e2eRequest(t, router, "POST", "/api/v1/auth/login", "", LoginRequest{Email: user.Email, Password: testkit.Password})
+	var login struct{ Data AuthResponse }
+	if err := json.Unmarshal(w.Body.Bytes(), &login); err != nil || login.Data.Token == "" {
+		t.Fatalf("login as %s failed: %s", user.Email, w.Body.String())
+	}
+	return login.Data
+}
+
+func e2eRequest(t *testing.T, router http.Handler, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var buf bytes.Buffer
+	if body != nil {
+		json.NewEncoder(&buf).Encode(body)
+	}
+	req := httptest.NewRequest(method, path, &buf)
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
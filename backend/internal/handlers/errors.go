@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// Error codes with a meaning of their own. Other errors have a code per
+// status (see statusCode) or per missing record (see notFoundResponse).
+const (
+	codeValidation         = "VALIDATION_ERROR"
+	codeVersionConflict    = "VERSION_CONFLICT"
+	codeIdempotencyReused  = "IDEMPOTENCY_KEY_REUSED"
+	codeIdempotencyRunning = "IDEMPOTENCY_KEY_IN_PROGRESS"
+	codeExternalRefTaken   = "EXTERNAL_REF_TAKEN"
+	codeQuotaExceeded      = "QUOTA_EXCEEDED"
+	codeRateLimited        = "RATE_LIMITED"
+	codeMaintenance        = "MAINTENANCE"
+	codeRosterConflict     = "ROSTER_CONFLICT"
+	codeInfeasibleSolution = "INFEASIBLE_SOLUTION"
+)
+
+// FieldError is a field of a request that failed validation. Field is its
+// JSON path, e.g. items[0].quantity.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ErrorResponse is the body of an error response
+type ErrorResponse struct {
+	Success bool         `json:"success"`
+	Error   string       `json:"error"`
+	Code    string       `json:"code"`
+	Details []FieldError `json:"details,omitempty"`
+}
+
+func init() {
+	// validation errors name fields as requests do
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
+	}
+}
+
+// jsonFieldName is the name of a struct field in JSON
+func jsonFieldName(f reflect.StructField) string {
+	name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+// errorResponse responds with an error: a message for people and the
+// status's code, e.g. BAD_REQUEST, for clients
+func errorResponse(c *gin.Context, status int, message string) {
+	errorCodeResponse(c, status, statusCode(status), message)
+}
+
+// errorCodeResponse is errorResponse with a code of its own
+func errorCodeResponse(c *gin.Context, status int, code, message string) {
+	c.JSON(status, ErrorResponse{Error: message, Code: code})
+}
+
+// notFoundResponse responds 404 to a missing record of kind, e.g. "Route
+// execution", with code ROUTE_EXECUTION_NOT_FOUND
+func notFoundResponse(c *gin.Context, kind string) {
+	code := strings.ToUpper(strings.ReplaceAll(kind, " ", "_")) + "_NOT_FOUND"
+	errorCodeResponse(c, http.StatusNotFound, code, kind+" not found")
+}
+
+// validationErrorResponse responds 400 to a request that failed binding or
+// validation, with the fields at fault as details
+func validationErrorResponse(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, ErrorResponse{
+		Error:   "Invalid request: " + err.Error(),
+		Code:    codeValidation,
+		Details: fieldErrors(err),
+	})
+}
+
+// statusCode is the error code of a status, e.g. INTERNAL_SERVER_ERROR
+func statusCode(status int) string {
+	text := http.StatusText(status)
+	if text == "" {
+		return "ERROR"
+	}
+	return strings.ToUpper(strings.NewReplacer(" ", "_", "-", "_", "'", "").Replace(text))
+}
+
+// fieldErrors lists the fields a binding error is about
+func fieldErrors(err error) []FieldError {
+	var invalid validator.ValidationErrors
+	if errors.As(err, &invalid) {
+		details := make([]FieldError, 0, len(invalid))
+		for _, fe := range invalid {
+			field := fe.Namespace()
+			// the namespace starts with the request type
+			if i := strings.IndexByte(field, '.'); i >= 0 {
+				field = field[i+1:]
+			}
+			details = append(details, FieldError{Field: field, Rule: fe.Tag(), Message: field + " " + ruleMessage(fe)})
+		}
+		return details
+	}
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return []FieldError{{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("%s must be %s", typeErr.Field, jsonTypeName(typeErr.Type)),
+		}}
+	}
+	return nil
+}
+
+// ruleMessage describes the validation rule a field broke
+func ruleMessage(fe validator.FieldError) string {
+	param := fe.Param()
+	counted := fe.Kind() == reflect.String || fe.Kind() == reflect.Slice || fe.Kind() == reflect.Map
+	unit := "characters"
+	if fe.Kind() != reflect.String {
+		unit = "items"
+	}
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min", "gte":
+		if counted {
+			return fmt.Sprintf("must have at least %s %s", param, unit)
+		}
+		return "must be at least " + param
+	case "max", "lte":
+		if counted {
+			return fmt.Sprintf("must have at most %s %s", param, unit)
+		}
+		return "must be at most " + param
+	case "gt":
+		return "must be greater than " + param
+	case "lt":
+		return "must be less than " + param
+	case "len":
+		return fmt.Sprintf("must have %s %s", param, unit)
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(param), ", ")
+	case "email":
+		return "must be an email address"
+	case "uuid":
+		return "must be a UUID"
+	}
+	return fmt.Sprintf("fails the %s rule", fe.Tag())
+}
+
+// jsonTypeName describes the JSON value a Go type is decoded from
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestErrorEnvelope tests that errors carry a code and validation errors
+// the fields at fault by their JSON names
+func TestErrorEnvelope(t *testing.T) {
+	s := newTestServer(t)
+	s.router.POST("/customers", s.h.CreateCustomer)
+	s.router.GET("/plans/:id", s.h.GetPlan)
+	s.router.GET("/route-executions/:id", s.h.GetRouteExecution)
+
+	type envelope struct {
+		Success bool
+		Error   string
+		Code    string
+		Details []FieldError
+	}
+	send := func(t *testing.T, method, path string, body interface{}) (int, envelope) {
+		t.Helper()
+		w := s.do(t, method, path, "", body)
+		var resp envelope
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("%s %s body %q: %v", method, path, w.Body.String(), err)
+		}
+		return w.Code, resp
+	}
+
+	t.Run("missing and invalid fields", func(t *testing.T) {
+		status, resp := send(t, "POST", "/customers", gin.H{"address": "Main St 1", "min_drop_size": -1})
+		if status != http.StatusBadRequest || resp.Success || resp.Code != "VALIDATION_ERROR" || resp.Error == "" {
+			t.Fatalf("invalid customer = %d %+v, want a 400 VALIDATION_ERROR", status, resp)
+		}
+		rules := map[string]string{}
+		for _, d := range resp.Details {
+			rules[d.Field] = d.Rule
+		}
+		want := map[string]string{"name": "required", "latitude": "required", "longitude": "required", "min_drop_size": "gte"}
+		for field, rule := range want {
+			if rules[field] != rule {
+				t.Errorf("details = %+v, want %s failing %s", resp.Details, field, rule)
+			}
+		}
+	})
+
+	t.Run("mistyped field", func(t *testing.T) {
+		status, resp := send(t, "POST", "/customers", gin.H{"name": "Depot", "latitude": "north", "longitude": 4})
+		if status != http.StatusBadRequest || len(resp.Details) != 1 || resp.Details[0].Field != "latitude" || resp.Details[0].Message != "latitude must be a number" {
+			t.Errorf("mistyped latitude = %d %+v, want a detail on latitude", status, resp)
+		}
+	})
+
+	tests := []struct {
+		path   string
+		status int
+		code   string
+	}{
+		{"/plans/999", http.StatusNotFound, "PLAN_NOT_FOUND"},
+		{"/plans/abc", http.StatusBadRequest, "BAD_REQUEST"},
+		{"/route-executions/999", http.StatusNotFound, "ROUTE_EXECUTION_NOT_FOUND"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if status, resp := send(t, "GET", tt.path, nil); status != tt.status || resp.Code != tt.code {
+				t.Errorf("GET %s = %d %q, want %d %q", tt.path, status, resp.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Plan event types
+const (
+	eventExecution = "execution"
+	eventLocation  = "location"
+	eventETA       = "eta"
+)
+
+const (
+	// eventBuffer is how many events a subscriber may fall behind before
+	// further events to it are dropped
+	eventBuffer = 64
+	// streamHeartbeat is how often an idle stream sends a comment, so
+	// proxies keep the connection open
+	streamHeartbeat = 15 * time.Second
+)
+
+// eventBus fans plan events out to the streams subscribed to the plan. It
+// lives in one API process: events published by another instance are not
+// seen.
+type eventBus struct {
+	mu   sync.Mutex
+	subs map[int64]map[chan models.PlanEvent]struct{}
+}
+
+func newEventBus() *eventBus {
+	return &eventBus{subs: make(map[int64]map[chan models.PlanEvent]struct{})}
+}
+
+// subscribe returns a channel receiving the events of a plan and a
+// function ending the subscription
+func (b *eventBus) subscribe(planID int64) (<-chan models.PlanEvent, func()) {
+	ch := make(chan models.PlanEvent, eventBuffer)
+	b.mu.Lock()
+	if b.subs[planID] == nil {
+		b.subs[planID] = make(map[chan models.PlanEvent]struct{})
+	}
+	b.subs[planID][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		delete(b.subs[planID], ch)
+		if len(b.subs[planID]) == 0 {
+			delete(b.subs, planID)
+		}
+		b.mu.Unlock()
+	}
+}
+
+// watched reports whether any stream is subscribed to a plan, so callers
+// can skip building events nobody receives
+func (b *eventBus) watched(planID int64) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs[planID]) > 0
+}
+
+// publish sends an event to the plan's subscribers without blocking; a
+// subscriber whose buffer is full misses it
+func (b *eventBus) publish(e models.PlanEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs[e.PlanID] {
+		select {
+		case ch <- e:
+		default:
+		}
+	}
+}
+
+// publishExecution streams the state of a route execution and, while it is
+// under way, the ETA of its remaining stops. execution needs its route and
+// stop executions loaded.
+func (h *Handler) publishExecution(execution *models.RouteExecution) {
+	if execution.Route == nil || !h.events.watched(execution.Route.PlanID) {
+		return
+	}
+	event := models.PlanEvent{
+		Type:        eventExecution,
+		PlanID:      execution.Route.PlanID,
+		RouteID:     execution.RouteID,
+		ExecutionID: execution.ID,
+		At:          h.clock.Now(),
+		Data:        executionUpdate(execution),
+	}
+	h.events.publish(event)
+	if execution.Status == "in_progress" {
+		event.Type = eventETA
+		event.Data = executionETA(execution)
+		h.events.publish(event)
+	}
+}
+
+// publishExecutionChange reloads a route execution after a change and
+// streams it (see publishExecution). Failures are logged since the change
+// is already stored.
+func (h *Handler) publishExecutionChange(execution *models.RouteExecution) {
+	if execution.Route == nil || !h.events.watched(execution.Route.PlanID) {
+		return
+	}
+	updated, err := h.executions.Get(execution.ID)
+	if err != nil {
+		log.Printf("Failed to load route execution %d for streaming: %v", execution.ID, err)
+		return
+	}
+	h.publishExecution(updated)
+}
+
+// publishLocation streams the latest position of a route execution
+func (h *Handler) publishLocation(execution *models.RouteExecution, ping models.LocationPing) {
+	if execution.Route == nil {
+		return
+	}
+	h.events.publish(models.PlanEvent{
+		Type:        eventLocation,
+		PlanID:      execution.Route.PlanID,
+		RouteID:     execution.RouteID,
+		ExecutionID: execution.ID,
+		At:          h.clock.Now(),
+		Data:        ping,
+	})
+}
+
+func executionUpdate(execution *models.RouteExecution) models.ExecutionUpdate {
+	return models.ExecutionUpdate{
+		Status:          execution.Status,
+		Progress:        execution.Progress,
+		ActualStartTime: execution.ActualStartTime,
+		ActualEndTime:   execution.ActualEndTime,
+		ActualDistance:  execution.ActualDistance,
+		ActualLoad:      execution.ActualLoad,
+	}
+}
+
+// executionETA shifts the planned arrival of the stops still pending by the
+// delay of the latest start, arrival or departure reported, in stop order
+func executionETA(execution *models.RouteExecution) models.ExecutionETA {
+	stops := append([]models.StopExecution(nil), execution.StopExecutions...)
+	sort.SliceStable(stops, func(i, j int) bool {
+		if stops[i].Stop != nil && stops[j].Stop != nil {
+			return stops[i].Stop.Sequence < stops[j].Stop.Sequence
+		}
+		return stops[i].ID < stops[j].ID
+	})
+
+	var delay time.Duration
+	if execution.ActualStartTime != nil && execution.PlannedStartTime != nil {
+		delay = execution.ActualStartTime.Sub(*execution.PlannedStartTime)
+	}
+	for _, s := range stops {
+		switch {
+		case s.ActualDepartureTime != nil && s.PlannedDepartureTime != nil:
+			delay = s.ActualDepartureTime.Sub(*s.PlannedDepartureTime)
+		case s.ActualArrivalTime != nil && s.PlannedArrivalTime != nil:
+			delay = s.ActualArrivalTime.Sub(*s.PlannedArrivalTime)
+		}
+	}
+
+	eta := models.ExecutionETA{DelayMinutes: int(delay.Minutes()), Stops: []models.StopETA{}}
+	for _, s := range stops {
+		if s.Status != "pending" || s.PlannedArrivalTime == nil {
+			continue
+		}
+		eta.Stops = append(eta.Stops, models.StopETA{
+			StopExecutionID: s.ID,
+			StopID:          s.StopID,
+			ETA:             s.PlannedArrivalTime.Add(delay),
+		})
+	}
+	return eta
+}
+
+// StreamPlan handles GET /api/v1/plans/:id/stream
+// Server-sent events for dispatch dashboards: an "execution" event with the
+// current state of each of the plan's route executions, then "execution"
+// events on status and progress changes, "location" events with new GPS
+// positions and "eta" events with the estimated arrival at the stops of
+// executions under way. Each event's data is a models.PlanEvent.
+func (h *Handler) StreamPlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+	if _, err := h.plans.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	// subscribe before reading the current state so no change in between
+	// is missed
+	events, unsubscribe := h.events.subscribe(id)
+	defer unsubscribe()
+	executions, err := database.GetRouteExecutionsByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route executions")
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	for _, e := range executions {
+		c.SSEvent(eventExecution, models.PlanEvent{
+			Type:        eventExecution,
+			PlanID:      id,
+			RouteID:     e.RouteID,
+			ExecutionID: e.ID,
+			At:          h.clock.Now(),
+			Data:        executionUpdate(&e),
+		})
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case <-c.Request.Context().Done():
+			return false
+		case e := <-events:
+			c.SSEvent(e.Type, e)
+		case <-heartbeat.C:
+			io.WriteString(w, ": ping\n\n")
+		}
+		return true
+	})
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestStreamPlan tests the server-sent events of a plan: the current state
+// on connect, then execution changes, ETAs and GPS positions
+func TestStreamPlan(t *testing.T) {
+	s := newTestServer(t)
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.api.GET("/plans/:id/stream", s.h.StreamPlan)
+	s.api.POST("/executions/:id/start", s.h.StartRouteExecution)
+	s.api.POST("/executions/:id/locations", s.h.RecordLocations)
+	server := httptest.NewServer(s.router)
+	defer server.Close()
+
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer())
+	plannedStart, plannedArrival := clk.Now(), clk.Now().Add(time.Hour)
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "pending", PlannedStartTime: &plannedStart}
+	execution.StopExecutions = []models.StopExecution{{StopID: route.Stops[0].ID, Status: "pending", PlannedArrivalTime: &plannedArrival}}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+	token := s.login(t, "manager")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/api/v1/plans/%d/stream", server.URL, plan.ID), nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		t.Fatalf("stream = %d %s, want an event stream", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	type received struct {
+		name  string
+		event models.PlanEvent
+		data  json.RawMessage
+	}
+	events := make(chan received, 16)
+	go func() {
+		scanner := bufio.NewScanner(resp.Body)
+		var name string
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "event:"):
+				name = strings.TrimPrefix(line, "event:")
+			case strings.HasPrefix(line, "data:"):
+				var r received
+				r.name = name
+				payload := []byte(strings.TrimPrefix(line, "data:"))
+				json.Unmarshal(payload, &r.event)
+				var raw struct{ Data json.RawMessage }
+				json.Unmarshal(payload, &raw)
+				r.data = raw.Data
+				events <- r
+			}
+		}
+	}()
+	next := func(t *testing.T, want string) received {
+		t.Helper()
+		select {
+		case r := <-events:
+			if r.name != want || r.event.PlanID != plan.ID || r.event.ExecutionID != execution.ID {
+				t.Fatalf("event = %s %+v, want %s of execution %d", r.name, r.event, want, execution.ID)
+			}
+			return r
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no %s event", want)
+			return received{}
+		}
+	}
+
+	t.Run("current state on connect", func(t *testing.T) {
+		var update models.ExecutionUpdate
+		json.Unmarshal(next(t, "execution").data, &update)
+		if update.Status != "pending" {
+			t.Errorf("initial state = %+v, want pending", update)
+		}
+	})
+
+	startedAt := clk.Advance(20 * time.Minute)
+	t.Run("start", func(t *testing.T) {
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/start", execution.ID), token, StartRouteExecutionRequest{}); w.Code != http.StatusOK {
+			t.Fatalf("start status = %d: %s", w.Code, w.Body.String())
+		}
+		var update models.ExecutionUpdate
+		json.Unmarshal(next(t, "execution").data, &update)
+		if update.Status != "in_progress" || update.ActualStartTime == nil || !update.ActualStartTime.Equal(startedAt) {
+			t.Errorf("started = %+v, want in progress from %v", update, startedAt)
+		}
+		var eta models.ExecutionETA
+		json.Unmarshal(next(t, "eta").data, &eta)
+		if eta.DelayMinutes != 20 || len(eta.Stops) != 1 || !eta.Stops[0].ETA.Equal(plannedArrival.Add(20*time.Minute)) {
+			t.Errorf("eta = %+v, want the stop 20 minutes late", eta)
+		}
+	})
+
+	t.Run("locations", func(t *testing.T) {
+		pings := RecordLocationsRequest{Pings: []LocationPingRequest{
+			{Latitude: 40.72, Longitude: -74.00, Timestamp: startedAt},
+			{Latitude: 40.73, Longitude: -74.00, Timestamp: startedAt.Add(time.Minute)},
+		}}
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/locations", execution.ID), token, pings); w.Code != http.StatusOK {
+			t.Fatalf("locations status = %d: %s", w.Code, w.Body.String())
+		}
+		var ping models.LocationPing
+		json.Unmarshal(next(t, "location").data, &ping)
+		if ping.Latitude != 40.73 {
+			t.Errorf("location = %+v, want the latest ping", ping)
+		}
+		var update models.ExecutionUpdate
+		json.Unmarshal(next(t, "execution").data, &update)
+		if update.ActualDistance == 0 {
+			t.Errorf("execution after pings = %+v, want the tracked distance", update)
+		}
+	})
+}
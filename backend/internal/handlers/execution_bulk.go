@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// errBulkAborted rolls back an atomic bulk update after an item failed
+var errBulkAborted = errors.New("bulk update aborted")
+
+type BulkExecutionUpdate struct {
+	ID              int64      `json:"id" binding:"required"`
+	Status          string     `json:"status" binding:"required,oneof=in_progress completed cancelled"`
+	ActualDistance  float64    `json:"actual_distance" binding:"gte=0"`
+	ActualCost      float64    `json:"actual_cost" binding:"gte=0"`
+	ActualLoad      float64    `json:"actual_load" binding:"gte=0"`
+	ActualStartTime *time.Time `json:"actual_start_time"`
+	ActualEndTime   *time.Time `json:"actual_end_time"`
+	DriverNotes     string     `json:"driver_notes"`
+	DeviationReason string     `json:"deviation_reason"`
+}
+
+type BulkUpdateExecutionsRequest struct {
+	Executions []BulkExecutionUpdate `json:"executions" binding:"required,min=1,max=500,dive"`
+	Atomic     bool                  `json:"atomic"`
+}
+
+// BulkExecutionResult is the outcome of one item of a bulk update, with
+// the status code it would have had as a request of its own
+type BulkExecutionResult struct {
+	ID        int64                  `json:"id"`
+	Success   bool                   `json:"success"`
+	Status    int                    `json:"status"`
+	Error     string                 `json:"error,omitempty"`
+	Execution *models.RouteExecution `json:"execution,omitempty"`
+}
+
+// BulkUpdateExecutions handles POST /api/v1/executions/bulk
+// Each item starts (in_progress), completes or cancels an execution, in
+// order, within one transaction. An item that fails is rolled back on its
+// own and reported while the others are kept, unless atomic is set, in
+// which case the first failure rolls back every item. Completing without a
+// distance or load uses the GPS track and the load delivered at the stops.
+// Executions already completed or cancelled are not changed (409).
+func (h *Handler) BulkUpdateExecutions(c *gin.Context) {
+	var req BulkUpdateExecutionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	results := make([]BulkExecutionResult, len(req.Executions))
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for i, item := range req.Executions {
+			results[i] = BulkExecutionResult{ID: item.ID, Success: true, Status: http.StatusOK}
+			status, message := http.StatusOK, ""
+			err := tx.Transaction(func(itemTx *gorm.DB) error {
+				if status, message = h.bulkUpdateExecutionTx(itemTx, item); message != "" {
+					return errors.New(message)
+				}
+				return nil
+			})
+			if err == nil {
+				continue
+			}
+			if message == "" {
+				status, message = http.StatusInternalServerError, "Failed to update route execution"
+			}
+			results[i] = BulkExecutionResult{ID: item.ID, Status: status, Error: message}
+			if req.Atomic {
+				return errBulkAborted
+			}
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errBulkAborted) {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update route executions")
+		return
+	}
+
+	succeeded := 0
+	for i := range results {
+		r := &results[i]
+		if r.Success && errors.Is(err, errBulkAborted) {
+			*r = BulkExecutionResult{ID: r.ID, Status: http.StatusConflict, Error: "Rolled back: another update failed"}
+		}
+		if !r.Success {
+			continue
+		}
+		succeeded++
+		if execution, getErr := h.executions.Get(r.ID); getErr == nil {
+			h.publishExecution(execution)
+			if execution.Status == "completed" {
+				h.publishWebhook(webhooks.ExecutionCompleted, execution)
+			}
+			r.Execution = execution
+		}
+	}
+	successResponse(c, gin.H{
+		"succeeded": succeeded,
+		"failed":    len(results) - succeeded,
+		"results":   results,
+	})
+}
+
+// bulkUpdateExecutionTx applies one item of a bulk update, returning the
+// status code of the outcome and, when it failed, the error message
+func (h *Handler) bulkUpdateExecutionTx(tx *gorm.DB, item BulkExecutionUpdate) (int, string) {
+	execution, err := database.GetRouteExecution(tx, item.ID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return http.StatusNotFound, "Route execution not found"
+		}
+		return http.StatusInternalServerError, "Failed to fetch route execution"
+	}
+	if execution.Status == "completed" || execution.Status == "cancelled" || execution.Status == item.Status {
+		return http.StatusConflict, "Route execution is already " + execution.Status
+	}
+	now := h.clock.Now()
+
+	switch item.Status {
+	case "in_progress":
+		if item.ActualStartTime == nil {
+			item.ActualStartTime = &now
+		}
+		err = database.StartRouteExecution(tx, item.ID, *item.ActualStartTime)
+	case "completed":
+		if item.ActualEndTime == nil {
+			item.ActualEndTime = &now
+		}
+		if item.ActualDistance == 0 {
+			track, err := database.GetLocationTrack(tx, item.ID)
+			if err != nil {
+				return http.StatusInternalServerError, "Failed to compute distance"
+			}
+			if len(track) >= 2 {
+				item.ActualDistance = trackDistanceKm(track)
+			}
+		}
+		if item.ActualLoad == 0 {
+			item.ActualLoad = execution.ActualLoad
+		}
+		err = database.CompleteRouteExecution(tx, item.ID, item.ActualDistance, item.ActualCost, item.ActualLoad, *item.ActualEndTime)
+	case "cancelled":
+		err = database.UpdateRouteExecution(tx, &models.RouteExecution{ID: item.ID, Status: item.Status})
+	}
+	if err != nil {
+		return http.StatusInternalServerError, "Failed to update route execution"
+	}
+
+	if item.DriverNotes != "" || item.DeviationReason != "" {
+		err := database.UpdateRouteExecution(tx, &models.RouteExecution{
+			ID:              item.ID,
+			DriverNotes:     item.DriverNotes,
+			DeviationReason: item.DeviationReason,
+		})
+		if err != nil {
+			return http.StatusInternalServerError, "Failed to update route execution"
+		}
+	}
+	return http.StatusOK, ""
+}
@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestBulkUpdateExecutions tests that bulk updates keep the items that
+// succeed and report the others, or roll back every item when atomic
+func TestBulkUpdateExecutions(t *testing.T) {
+	s := newTestServer(t)
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 18, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+
+	s.api.POST("/executions/bulk", s.h.BulkUpdateExecutions)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+	execution := func(status string, load float64) *models.RouteExecution {
+		e := &models.RouteExecution{RouteID: s.fx.Route(plan, vehicle, 1, s.fx.Customer()).ID, Status: status, ActualLoad: load}
+		if err := database.CreateRouteExecution(s.db, e); err != nil {
+			t.Fatal(err)
+		}
+		return e
+	}
+	running, pending, done := execution("in_progress", 8), execution("pending", 0), execution("completed", 10)
+
+	type response struct {
+		Data struct {
+			Succeeded int
+			Failed    int
+			Results   []BulkExecutionResult
+		}
+	}
+	bulk := func(t *testing.T, req BulkUpdateExecutionsRequest) response {
+		t.Helper()
+		w := s.do(t, "POST", "/api/v1/executions/bulk", token, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("bulk status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp
+	}
+
+	t.Run("atomic rolls back", func(t *testing.T) {
+		// an atomic request with a failing item changes nothing
+		resp := bulk(t, BulkUpdateExecutionsRequest{Atomic: true, Executions: []BulkExecutionUpdate{
+			{ID: running.ID, Status: "completed"},
+			{ID: done.ID, Status: "completed"},
+		}})
+		if resp.Data.Succeeded != 0 || resp.Data.Results[0].Status != http.StatusConflict || resp.Data.Results[1].Status != http.StatusConflict {
+			t.Errorf("atomic results = %+v, want both rolled back", resp.Data)
+		}
+		if stored, _ := database.GetRouteExecution(s.db, running.ID); stored.Status != "in_progress" {
+			t.Errorf("execution after atomic failure = %s, want in_progress", stored.Status)
+		}
+	})
+
+	t.Run("partial", func(t *testing.T) {
+		resp := bulk(t, BulkUpdateExecutionsRequest{Executions: []BulkExecutionUpdate{
+			{ID: running.ID, Status: "completed", ActualDistance: 42, DriverNotes: "all delivered"},
+			{ID: done.ID, Status: "completed"},
+			{ID: 999, Status: "cancelled"},
+			{ID: pending.ID, Status: "cancelled", DeviationReason: "vehicle breakdown"},
+		}})
+		want := []int{http.StatusOK, http.StatusConflict, http.StatusNotFound, http.StatusOK}
+		if resp.Data.Succeeded != 2 || resp.Data.Failed != 2 || len(resp.Data.Results) != 4 {
+			t.Fatalf("bulk = %+v, want 2 succeeded and 2 failed", resp.Data)
+		}
+		for i, r := range resp.Data.Results {
+			if r.Status != want[i] || r.Success != (want[i] == http.StatusOK) {
+				t.Errorf("result %d = %+v, want status %d", i, r, want[i])
+			}
+		}
+
+		stored, _ := database.GetRouteExecution(s.db, running.ID)
+		if stored.Status != "completed" || stored.ActualDistance != 42 || stored.ActualLoad != 8 || stored.DriverNotes != "all delivered" || !stored.ActualEndTime.Equal(clk.Now()) {
+			t.Errorf("completed execution = %+v, want 42 km with the delivered load of 8 now", stored)
+		}
+		if stored, _ := database.GetRouteExecution(s.db, pending.ID); stored.Status != "cancelled" || stored.DeviationReason != "vehicle breakdown" {
+			t.Errorf("cancelled execution = %+v", stored)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		if w := s.do(t, "POST", "/api/v1/executions/bulk", token, BulkUpdateExecutionsRequest{}); w.Code != http.StatusBadRequest {
+			t.Errorf("empty bulk status = %d, want 400", w.Code)
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/distancematrix"
+	"LogiTrackPro/backend/internal/eta"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetExecutionETAs handles GET /api/v1/executions/:id/etas
+// Recomputes the arrival at the stops still pending, in route order, from
+// where the vehicle is: the stop it has arrived at and not left, leaving
+// once the stop's service time is up, else its latest GPS ping, else the
+// warehouse at the planned start. Legs take the distance provider's travel
+// times, or the straight-line distance at the vehicle's average speed
+// without one (see eta.Estimate). Nothing leaves before now.
+func (h *Handler) GetExecutionETAs(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
+		return
+	}
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	if !h.canReportExecution(c, execution) {
+		return
+	}
+	if execution.Status == "completed" || execution.Status == "cancelled" {
+		errorResponse(c, http.StatusConflict, "Route execution is "+execution.Status)
+		return
+	}
+
+	route, err := database.GetRouteWithStops(h.db, execution.RouteID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return
+	}
+	points := make(map[int64]distancematrix.Point, len(route.Stops))
+	minutes := make(map[int64]int, len(route.Stops))
+	for i, s := range route.Stops {
+		if p, ok := routePoint(route, i); ok {
+			points[s.ID] = p
+		}
+		minutes[s.ID] = optimizer.ServiceMinutes
+		if s.Type != "" && s.Type != "delivery" {
+			minutes[s.ID] = s.DurationMinutes
+		}
+	}
+
+	stops := append([]models.StopExecution(nil), execution.StopExecutions...)
+	sort.SliceStable(stops, func(i, j int) bool {
+		if stops[i].Stop != nil && stops[j].Stop != nil {
+			return stops[i].Stop.Sequence < stops[j].Stop.Sequence
+		}
+		return stops[i].ID < stops[j].ID
+	})
+
+	now := h.clock.Now()
+	etas := models.RouteETAs{ExecutionID: id, Stops: []models.StopETA{}}
+	var origin distancematrix.Point
+	var departure time.Time
+	for _, s := range stops {
+		p, ok := points[s.StopID]
+		if s.Status == "arrived" && s.ActualArrivalTime != nil && s.ActualDepartureTime == nil && ok {
+			etas.Origin, origin = "stop", p
+			departure = s.ActualArrivalTime.Add(time.Duration(minutes[s.StopID]) * time.Minute)
+		}
+	}
+	if etas.Origin == "" {
+		ping, err := database.GetLatestLocation(h.db, id)
+		switch {
+		case err == nil:
+			etas.Origin = "gps"
+			origin = distancematrix.Point{Latitude: ping.Latitude, Longitude: ping.Longitude}
+		case errors.Is(err, database.ErrNotFound):
+			etas.Origin = "warehouse"
+			origin, _ = routePoint(route, -1)
+			if execution.PlannedStartTime != nil {
+				departure = *execution.PlannedStartTime
+			}
+		default:
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch locations")
+			return
+		}
+	}
+	if departure.Before(now) {
+		departure = now
+	}
+	etas.Latitude, etas.Longitude, etas.DepartureAt = origin.Latitude, origin.Longitude, departure
+
+	var remaining []eta.Stop
+	planned := make(map[int64]*time.Time)
+	for _, s := range stops {
+		p, ok := points[s.StopID]
+		if s.Status != "pending" || !ok {
+			continue
+		}
+		remaining = append(remaining, eta.Stop{ID: s.ID, Point: p, ServiceMinutes: minutes[s.StopID]})
+		planned[s.ID] = s.PlannedArrivalTime
+	}
+	var speed float64
+	if route.Vehicle != nil {
+		speed = route.Vehicle.AverageSpeed
+	}
+	arrivals, source := eta.Estimate(h.distances, origin, departure, speed, remaining)
+	etas.Source = source
+
+	stopIDs := make(map[int64]int64, len(stops))
+	for _, s := range stops {
+		stopIDs[s.ID] = s.StopID
+	}
+	for _, a := range arrivals {
+		stop := models.StopETA{
+			StopExecutionID: a.ID,
+			StopID:          stopIDs[a.ID],
+			ETA:             a.ETA,
+			DistanceKm:      math.Round(a.DistanceKm*10) / 10,
+		}
+		if p := planned[a.ID]; p != nil {
+			stop.DelayMinutes = int(math.Round(a.ETA.Sub(*p).Minutes()))
+		}
+		etas.Stops = append(etas.Stops, stop)
+	}
+	successResponse(c, etas)
+}
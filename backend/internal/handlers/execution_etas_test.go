@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestExecutionETAs tests that arrivals are recomputed from the warehouse,
+// the latest GPS ping and the stop being served
+func TestExecutionETAs(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	s.h.SetClock(testkit.NewClock(now))
+
+	s.api.GET("/executions/:id/etas", s.h.GetExecutionETAs)
+
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+	at := func(lat float64) func(*models.Customer) {
+		return func(c *models.Customer) { c.Latitude, c.Longitude = lat, -74.006 }
+	}
+	vehicle := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.AverageSpeed = 60 })
+	route := s.fx.Route(plan, vehicle, 1, s.fx.Customer(at(40.75)), s.fx.Customer(at(40.80)))
+	planned := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "pending", PlannedStartTime: &planned}
+	for _, stop := range route.Stops {
+		arrival := planned.Add(30 * time.Minute)
+		execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{StopID: stop.ID, Status: "pending", PlannedArrivalTime: &arrival})
+	}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+	token := s.login(t, "manager")
+
+	etas := func(t *testing.T) models.RouteETAs {
+		t.Helper()
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/executions/%d/etas", execution.ID), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("etas status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.RouteETAs }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	// minutes at 60 km/h between two latitudes on the customers' meridian
+	drive := func(from, to float64) time.Duration {
+		return time.Duration(optimizer.HaversineKm(from, -74.006, to, -74.006) * float64(time.Minute))
+	}
+	check := func(t *testing.T, got models.RouteETAs, origin string, want ...time.Time) {
+		t.Helper()
+		if got.Origin != origin || got.Source != "straight_line" || len(got.Stops) != len(want) {
+			t.Fatalf("etas = %+v, want %d stops from the %s", got, len(want), origin)
+		}
+		for i, w := range want {
+			if d := got.Stops[i].ETA.Sub(w); d < -time.Second || d > time.Second {
+				t.Errorf("stop %d eta = %v, want %v", i, got.Stops[i].ETA, w)
+			}
+		}
+	}
+
+	t.Run("not started", func(t *testing.T) {
+		// late: from the warehouse, now, with a stop between
+		first := now.Add(drive(40.7128, 40.75))
+		got := etas(t)
+		check(t, got, "warehouse", first, first.Add(optimizer.ServiceMinutes*time.Minute+drive(40.75, 40.80)))
+		if got.Stops[0].DelayMinutes != int(first.Sub(planned.Add(30*time.Minute)).Round(time.Minute).Minutes()) || got.Stops[0].DistanceKm < 4.1 || got.Stops[0].DistanceKm > 4.2 {
+			t.Errorf("first stop = %+v, want its delay and distance", got.Stops[0])
+		}
+	})
+
+	t.Run("under way", func(t *testing.T) {
+		// from the latest ping
+		database.CreateLocationPings(s.db, []models.LocationPing{
+			{RouteExecutionID: execution.ID, Latitude: 40.72, Longitude: -74.006, RecordedAt: now.Add(-10 * time.Minute)},
+			{RouteExecutionID: execution.ID, Latitude: 40.74, Longitude: -74.006, RecordedAt: now.Add(-time.Minute)},
+		})
+		first := now.Add(drive(40.74, 40.75))
+		check(t, etas(t), "gps", first, first.Add(optimizer.ServiceMinutes*time.Minute+drive(40.75, 40.80)))
+	})
+
+	t.Run("at a stop", func(t *testing.T) {
+		// at the first customer since 08:55: leaves once served
+		arrived := now.Add(-5 * time.Minute)
+		stop := execution.StopExecutions[0]
+		stop.Status, stop.ActualArrivalTime = "arrived", &arrived
+		if err := s.db.Save(&stop).Error; err != nil {
+			t.Fatal(err)
+		}
+		check(t, etas(t), "stop", arrived.Add(optimizer.ServiceMinutes*time.Minute+drive(40.75, 40.80)))
+	})
+
+	t.Run("completed", func(t *testing.T) {
+		s.db.Model(execution).Update("status", "completed")
+		if w := s.do(t, "GET", fmt.Sprintf("/api/v1/executions/%d/etas", execution.ID), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("completed execution etas status = %d, want 409", w.Code)
+		}
+	})
+}
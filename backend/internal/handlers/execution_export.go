@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/export"
+	"LogiTrackPro/backend/internal/hos"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportExecutions handles GET /api/v1/executions/export?from=&to=&driver_id=&format=csv|xlsx
+// Completed route executions for payroll and settlement: one row per route
+// completed between from and to (by default from the first of to's month
+// until today), with the driver's working, break and driving hours, the
+// distance and the stop outcomes, then the totals per driver. The file is
+// written to the response as it is generated.
+func (h *Handler) ExportExecutions(c *gin.Context) {
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	driverID, err := parseIDQuery(c, "driver_id")
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	format := c.DefaultQuery("format", export.CSV)
+	if format != export.XLSX && format != export.CSV {
+		errorResponse(c, http.StatusBadRequest, "format must be csv or xlsx")
+		return
+	}
+	today := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	if to == nil {
+		to = &today
+	}
+	if from == nil {
+		start := time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+		from = &start
+	}
+	if to.Before(*from) {
+		errorResponse(c, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	executions, err := database.GetCompletedExecutionsForExport(h.db, *from, to.AddDate(0, 0, 1).Add(-time.Nanosecond), driverID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route executions")
+		return
+	}
+
+	filename := fmt.Sprintf("executions-%s-%s.%s", from.Format("2006-01-02"), to.Format("2006-01-02"), format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Header("Content-Type", export.ContentType(format))
+	c.Status(http.StatusOK)
+
+	w, _ := export.New(format, c.Writer)
+	if err := h.writeExecutionSheets(w, executions); err != nil {
+		// The status is already sent; all that is left is to cut the file short
+		log.Printf("Execution export for %s to %s failed: %v", from.Format("2006-01-02"), to.Format("2006-01-02"), err)
+		c.Abort()
+	}
+}
+
+// executionTotals are the figures of an execution export row, summed per
+// driver on the drivers sheet
+type executionTotals struct {
+	routes                            int
+	working, breaks, driving          time.Duration
+	distance, load                    float64
+	stops, completed, skipped, failed int
+}
+
+func (t *executionTotals) add(o executionTotals) {
+	t.routes += o.routes
+	t.working += o.working
+	t.breaks += o.breaks
+	t.driving += o.driving
+	t.distance += o.distance
+	t.load += o.load
+	t.stops += o.stops
+	t.completed += o.completed
+	t.skipped += o.skipped
+	t.failed += o.failed
+}
+
+// writeExecutionSheets writes the routes and drivers sheets of an execution
+// export. Executions come ordered by driver.
+func (h *Handler) writeExecutionSheets(w export.Writer, executions []models.RouteExecution) error {
+	err := w.Sheet("Routes", "Execution ID", "Route ID", "Plan", "Date", "Driver ID", "Driver", "Vehicle", "Start", "End",
+		"Working hours", "Break hours", "Driving hours", "Distance (km)", "Stops", "Completed", "Skipped", "Failed", "Delivered load")
+	if err != nil {
+		return err
+	}
+
+	type driverTotals struct {
+		id   *int64
+		name string
+		executionTotals
+	}
+	var drivers []driverTotals
+	for i := range executions {
+		e := &executions[i]
+		row := executionTotals{routes: 1, distance: e.ActualDistance, load: e.ActualLoad, stops: len(e.StopExecutions)}
+		for _, s := range e.StopExecutions {
+			switch s.Status {
+			case "completed":
+				row.completed++
+			case "skipped":
+				row.skipped++
+			case "failed":
+				row.failed++
+			}
+		}
+		if shift, ok := hos.FromExecution(e, h.clock.Now()); ok {
+			row.working, row.breaks, row.driving = shift.Working(), shift.BreakTime(), shift.Driving()
+		}
+
+		var plan, vehicle, driver string
+		var driverID interface{}
+		if e.Route != nil {
+			if e.Route.Plan != nil {
+				plan = e.Route.Plan.Name
+			}
+			vehicle, driver = vehicleName(e.Route.Vehicle), driverName(e.Route.Driver)
+			if e.Route.DriverID != nil {
+				driverID = *e.Route.DriverID
+			}
+		}
+		start, end := e.ShiftStart, e.ShiftEnd
+		if start == nil {
+			start = e.ActualStartTime
+		}
+		if end == nil {
+			end = e.ActualEndTime
+		}
+		err := w.Row(e.ID, e.RouteID, plan, e.ActualEndTime.UTC().Format("2006-01-02"), driverID, driver, vehicle,
+			timestampOf(start), timestampOf(end), hoursOf(row.working), hoursOf(row.breaks), hoursOf(row.driving),
+			row.distance, row.stops, row.completed, row.skipped, row.failed, row.load)
+		if err != nil {
+			return err
+		}
+
+		var id *int64
+		if e.Route != nil {
+			id = e.Route.DriverID
+		}
+		if n := len(drivers); n == 0 || !sameID(drivers[n-1].id, id) {
+			drivers = append(drivers, driverTotals{id: id, name: driver})
+		}
+		drivers[len(drivers)-1].add(row)
+	}
+
+	err = w.Sheet("Drivers", "Driver ID", "Driver", "Routes", "Working hours", "Break hours", "Driving hours",
+		"Distance (km)", "Stops", "Completed", "Skipped", "Failed", "Delivered load")
+	if err != nil {
+		return err
+	}
+	for _, d := range drivers {
+		var id interface{}
+		if d.id != nil {
+			id = *d.id
+		}
+		err := w.Row(id, d.name, d.routes, hoursOf(d.working), hoursOf(d.breaks), hoursOf(d.driving),
+			d.distance, d.stops, d.completed, d.skipped, d.failed, d.load)
+		if err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// sameID tells whether two optional IDs are equal
+func sameID(a, b *int64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// hoursOf renders a duration as hours to two decimals
+func hoursOf(d time.Duration) float64 {
+	return math.Round(d.Hours()*100) / 100
+}
+
+// timestampOf formats a time in UTC as RFC 3339, or empty when unset
+func timestampOf(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
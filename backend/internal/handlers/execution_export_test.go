@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestExportExecutions tests completed routes are exported with their hours,
+// distance and stop outcomes and totalled per driver
+func TestExportExecutions(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 20, 12, 0, 0, 0, time.UTC)))
+
+	s.api.GET("/executions/export", s.h.RoleMiddleware("admin", "manager"), s.h.ExportExecutions)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+	alice, bob := s.fx.Driver(warehouse), s.fx.Driver(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 5, testkit.WithStatus("executing"))
+	at := func(day, hour, minute int) *time.Time {
+		t := time.Date(2024, 3, 3+day, hour, minute, 0, 0, time.UTC)
+		return &t
+	}
+	complete := func(driver *models.Driver, day int, statuses ...string) *models.RouteExecution {
+		var customers []*models.Customer
+		for range statuses {
+			customers = append(customers, s.fx.Customer())
+		}
+		route := s.fx.Route(plan, vehicle, day, customers...)
+		s.db.Model(route).Update("driver_id", driver.ID)
+		execution := &models.RouteExecution{RouteID: route.ID, Status: "completed", ActualStartTime: at(day, 8, 0), ActualEndTime: at(day, 16, 30),
+			ActualDistance: 120, ActualLoad: 20, Breaks: []models.ShiftBreak{{Start: *at(day, 12, 0), End: *at(day, 12, 30)}}}
+		for i, stop := range route.Stops {
+			execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{StopID: stop.ID, Status: statuses[i], PlannedQuantity: 10})
+		}
+		if err := database.CreateRouteExecution(s.db, execution); err != nil {
+			t.Fatal(err)
+		}
+		return execution
+	}
+	complete(alice, 1, "completed", "completed")
+	complete(alice, 2, "completed", "failed", "skipped")
+	complete(bob, 1, "completed")
+	// in progress and outside the range
+	database.CreateRouteExecution(s.db, &models.RouteExecution{RouteID: s.fx.Route(plan, vehicle, 3, s.fx.Customer()).ID, Status: "in_progress", ActualStartTime: at(3, 8, 0)})
+	complete(bob, 5)
+
+	sheets := func(t *testing.T, query string) map[string][][]string {
+		t.Helper()
+		w := s.do(t, "GET", "/api/v1/executions/export"+query, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("export status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		r := csv.NewReader(strings.NewReader(w.Body.String()))
+		r.FieldsPerRecord = -1
+		records, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		result := make(map[string][][]string)
+		var sheet string
+		// sheets start with a line of their name
+		for _, record := range records {
+			if len(record) == 1 {
+				sheet = record[0]
+				continue
+			}
+			result[sheet] = append(result[sheet], record)
+		}
+		return result
+	}
+
+	t.Run("all drivers", func(t *testing.T) {
+		got := sheets(t, "?from=2024-03-04&to=2024-03-07")
+		if routes := got["Routes"]; len(routes) != 4 {
+			t.Fatalf("routes sheet = %v, want the header and 3 completed routes", routes)
+		}
+		// 8.5 hours with a half hour break
+		if row := got["Routes"][2]; row[3] != "2024-03-05" || row[9] != "8" || row[10] != "0.5" || row[13] != "3" || row[14] != "1" || row[15] != "1" || row[16] != "1" {
+			t.Errorf("second route of the first driver = %v, want 8 working hours and one stop of each outcome", row)
+		}
+		drivers := got["Drivers"]
+		if len(drivers) != 3 || drivers[1][1] != alice.Name || drivers[1][2] != "2" || drivers[1][3] != "16" || drivers[1][6] != "240" || drivers[2][1] != bob.Name {
+			t.Errorf("drivers sheet = %v, want two routes and 16 hours for the first driver", drivers)
+		}
+	})
+
+	t.Run("one driver", func(t *testing.T) {
+		if got := sheets(t, fmt.Sprintf("?from=2024-03-04&to=2024-03-31&driver_id=%d", bob.ID)); len(got["Routes"]) != 3 || len(got["Drivers"]) != 2 {
+			t.Errorf("one driver's export = %v, want their 2 routes", got)
+		}
+	})
+
+	t.Run("reversed range", func(t *testing.T) {
+		if w := s.do(t, "GET", "/api/v1/executions/export?from=2024-03-07&to=2024-03-04", token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("reversed range status = %d, want 400", w.Code)
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// timelineLateAfter is how far behind its planned arrival a stop may be
+// reached before the timeline raises a late_arrival alert
+const timelineLateAfter = 15 * time.Minute
+
+// timelineGPSGap is the longest silence between two GPS pings before the
+// timeline raises a gps_gap alert
+const timelineGPSGap = 15 * time.Minute
+
+// GetExecutionTimeline handles GET /api/v1/executions/:id/timeline?pings=false
+// Merges what was recorded on a route execution into one list, oldest
+// first: the route's start and end, arrivals and outcomes at the stops
+// (from GPS or as reported), proofs of delivery, driver app updates, GPS
+// pings, the route's messages and the driver's notes. Alerts are raised for
+// arrivals more than 15 minutes behind plan and for gaps of more than 15
+// minutes in the GPS track. pings=false leaves the GPS pings out.
+func (h *Handler) GetExecutionTimeline(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
+		return
+	}
+	withPings := c.DefaultQuery("pings", "true") != "false"
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	if !h.canReportExecution(c, execution) {
+		return
+	}
+
+	track, err := database.GetLocationTrack(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch location track")
+		return
+	}
+	updates, err := database.GetDriverStopEventsByExecution(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver updates")
+		return
+	}
+	// a limit of -1 returns the whole thread
+	messages, err := database.ListRouteMessages(h.db, execution.RouteID, 0, -1)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route messages")
+		return
+	}
+
+	successResponse(c, buildExecutionTimeline(execution, track, updates, messages, withPings))
+}
+
+// buildExecutionTimeline lists the events of an execution. Events are added
+// in the order they happen along the route and then sorted stably by time,
+// so a stop's arrival stays ahead of its outcome when both were recorded at
+// once.
+func buildExecutionTimeline(execution *models.RouteExecution, track []models.LocationPing, updates []models.DriverStopEvent, messages []models.RouteMessage, withPings bool) *models.ExecutionTimeline {
+	timeline := &models.ExecutionTimeline{
+		ExecutionID: execution.ID,
+		RouteID:     execution.RouteID,
+		Status:      execution.Status,
+		Events:      []models.ExecutionEvent{},
+	}
+	add := func(event models.ExecutionEvent) {
+		if event.Type == "alert" {
+			timeline.Alerts++
+		}
+		timeline.Events = append(timeline.Events, event)
+	}
+
+	if execution.ActualStartTime != nil {
+		add(models.ExecutionEvent{At: *execution.ActualStartTime, Type: "started"})
+	}
+
+	stops := append([]models.StopExecution(nil), execution.StopExecutions...)
+	sort.SliceStable(stops, func(i, j int) bool {
+		if stops[i].Stop != nil && stops[j].Stop != nil {
+			return stops[i].Stop.Sequence < stops[j].Stop.Sequence
+		}
+		return stops[i].ID < stops[j].ID
+	})
+	for i := range stops {
+		s := &stops[i]
+		stop := func(event models.ExecutionEvent) {
+			event.StopExecutionID, event.StopID = &s.ID, &s.StopID
+			add(event)
+		}
+		if s.ActualArrivalTime != nil {
+			stop(models.ExecutionEvent{At: *s.ActualArrivalTime, Type: "arrived", Source: timelineSource(s.ArrivalDetected)})
+			if s.PlannedArrivalTime != nil {
+				if late := s.ActualArrivalTime.Sub(*s.PlannedArrivalTime); late > timelineLateAfter {
+					stop(models.ExecutionEvent{
+						At:     *s.ActualArrivalTime,
+						Type:   "alert",
+						Alert:  "late_arrival",
+						Detail: fmt.Sprintf("Arrived %d minutes behind plan", int(late.Minutes())),
+					})
+				}
+			}
+		}
+		if s.PODCapturedAt != nil {
+			stop(models.ExecutionEvent{At: *s.PODCapturedAt, Type: "proof_of_delivery", Detail: s.RecipientName})
+		}
+		if s.Status == "pending" || s.Status == "arrived" {
+			continue
+		}
+		// failed and skipped stops may never have been reached, leaving
+		// only the time they were reported
+		outcome := models.ExecutionEvent{At: s.UpdatedAt, Type: s.Status, Source: timelineSource(s.DepartureDetected), Detail: s.Notes}
+		if s.ActualDepartureTime != nil {
+			outcome.At = *s.ActualDepartureTime
+		}
+		if s.Status == "completed" {
+			outcome.Detail = fmt.Sprintf("Delivered %g of %g", s.ActualQuantity, s.PlannedQuantity)
+			if s.Notes != "" {
+				outcome.Detail += ": " + s.Notes
+			}
+		}
+		stop(outcome)
+	}
+
+	for i := range updates {
+		u := &updates[i]
+		event := models.ExecutionEvent{
+			At:              u.CreatedAt,
+			Type:            "driver_update",
+			StopExecutionID: &u.StopExecutionID,
+			UserID:          &u.UserID,
+			Detail:          u.Status,
+		}
+		if u.OccurredAt != nil {
+			event.At = *u.OccurredAt
+		}
+		if u.Notes != "" {
+			event.Detail += ": " + u.Notes
+		}
+		add(event)
+	}
+
+	for i := range track {
+		p := &track[i]
+		if withPings {
+			add(models.ExecutionEvent{At: p.RecordedAt, Type: "location", Latitude: &p.Latitude, Longitude: &p.Longitude, Speed: p.Speed})
+		}
+		if i > 0 {
+			if gap := p.RecordedAt.Sub(track[i-1].RecordedAt); gap > timelineGPSGap {
+				add(models.ExecutionEvent{
+					At:        p.RecordedAt,
+					Type:      "alert",
+					Alert:     "gps_gap",
+					Detail:    fmt.Sprintf("No GPS for %d minutes", int(gap.Minutes())),
+					Latitude:  &p.Latitude,
+					Longitude: &p.Longitude,
+				})
+			}
+		}
+	}
+
+	for i := range messages {
+		m := &messages[i]
+		add(models.ExecutionEvent{At: m.CreatedAt, Type: "message", UserID: &m.SenderID, Detail: m.Body})
+	}
+
+	// the route's own notes and end come last among events at the same time
+	end := execution.UpdatedAt
+	if execution.ActualEndTime != nil {
+		end = *execution.ActualEndTime
+	}
+	if execution.DriverNotes != "" {
+		add(models.ExecutionEvent{At: end, Type: "note", Detail: execution.DriverNotes})
+	}
+	if execution.DeviationReason != "" {
+		add(models.ExecutionEvent{At: end, Type: "note", Detail: "Deviation: " + execution.DeviationReason})
+	}
+	switch execution.Status {
+	case "completed":
+		add(models.ExecutionEvent{At: end, Type: "route_completed"})
+	case "cancelled":
+		add(models.ExecutionEvent{At: end, Type: "route_cancelled"})
+	}
+
+	sort.SliceStable(timeline.Events, func(i, j int) bool {
+		return timeline.Events[i].At.Before(timeline.Events[j].At)
+	})
+	return timeline
+}
+
+// timelineSource tells whether a stop time was taken from GPS or reported
+func timelineSource(detected bool) string {
+	if detected {
+		return "gps"
+	}
+	return "reported"
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestGetExecutionTimeline tests that stops, driver updates, pings,
+// messages and notes are merged in time order with late arrivals and GPS
+// gaps flagged
+func TestGetExecutionTimeline(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/executions/:id/timeline", s.h.GetExecutionTimeline)
+
+	manager := s.fx.User("manager")
+	token := e2eLogin(t, s.router, manager).Token
+	warehouse := s.fx.Warehouse()
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	plan := s.fx.Plan(warehouse, day, 1, testkit.WithStatus("executing"))
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer(), s.fx.Customer())
+	at := func(hour, minute int) *time.Time {
+		t := day.Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+		return &t
+	}
+
+	execution := &models.RouteExecution{
+		RouteID: route.ID, Status: "completed",
+		ActualStartTime: at(8, 0), ActualEndTime: at(11, 0), DriverNotes: "gate code changed",
+		StopExecutions: []models.StopExecution{
+			// 40 minutes late, arrival picked up by GPS
+			{StopID: route.Stops[0].ID, Status: "completed", PlannedQuantity: 10, ActualQuantity: 8,
+				PlannedArrivalTime: at(9, 0), ActualArrivalTime: at(9, 40), ArrivalDetected: true, ActualDepartureTime: at(9, 55)},
+			{StopID: route.Stops[1].ID, Status: "failed", PlannedQuantity: 10, Notes: "closed",
+				PlannedArrivalTime: at(10, 0), ActualArrivalTime: at(10, 5), ActualDepartureTime: at(10, 10)},
+		},
+	}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+	pings := []models.LocationPing{
+		{RouteExecutionID: execution.ID, Latitude: 52.1, Longitude: 4.1, RecordedAt: *at(8, 30)},
+		{RouteExecutionID: execution.ID, Latitude: 52.2, Longitude: 4.2, RecordedAt: *at(9, 30)},
+	}
+	if _, err := database.CreateLocationPings(s.db, pings); err != nil {
+		t.Fatal(err)
+	}
+	event := &models.DriverStopEvent{ClientEventID: "evt-1", StopExecutionID: execution.StopExecutions[1].ID, UserID: manager.ID, Status: "failed", Notes: "closed", OccurredAt: at(10, 9)}
+	if err := database.CreateDriverStopEventTx(s.db, event); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.CreateRouteMessage(s.db, &models.RouteMessage{RouteID: route.ID, SenderID: manager.ID, Body: "call ahead", CreatedAt: *at(9, 45)}); err != nil {
+		t.Fatal(err)
+	}
+
+	get := func(t *testing.T, query string) models.ExecutionTimeline {
+		t.Helper()
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/executions/%d/timeline%s", execution.ID, query), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("timeline status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.ExecutionTimeline }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+
+	t.Run("all events", func(t *testing.T) {
+		timeline := get(t, "")
+		want := []string{
+			"started", "location", "location", "alert", "arrived", "alert", "message", "completed",
+			"arrived", "driver_update", "failed", "note", "route_completed",
+		}
+		if len(timeline.Events) != len(want) {
+			t.Fatalf("events = %+v, want %v", timeline.Events, want)
+		}
+		for i, e := range timeline.Events {
+			if e.Type != want[i] {
+				t.Errorf("event %d = %s at %s, want %s", i, e.Type, e.At, want[i])
+			}
+			if i > 0 && e.At.Before(timeline.Events[i-1].At) {
+				t.Errorf("event %d at %s is before the one ahead of it", i, e.At)
+			}
+		}
+		if timeline.Alerts != 2 || timeline.Events[3].Alert != "gps_gap" || timeline.Events[5].Alert != "late_arrival" {
+			t.Errorf("alerts = %d (%+v, %+v), want a GPS gap and a late arrival", timeline.Alerts, timeline.Events[3], timeline.Events[5])
+		}
+		if arrived := timeline.Events[4]; arrived.Source != "gps" || arrived.StopID == nil || *arrived.StopID != route.Stops[0].ID {
+			t.Errorf("first arrival = %+v, want detected by GPS at the first stop", arrived)
+		}
+		if done := timeline.Events[7]; done.Detail != "Delivered 8 of 10" {
+			t.Errorf("completed stop detail = %q", done.Detail)
+		}
+	})
+
+	t.Run("without pings", func(t *testing.T) {
+		// the gap is still flagged
+		if timeline := get(t, "?pings=false"); len(timeline.Events) != 11 || timeline.Alerts != 2 {
+			t.Errorf("events without pings = %+v, want 11 with both alerts", timeline.Events)
+		}
+	})
+
+	t.Run("unknown execution", func(t *testing.T) {
+		if w := s.do(t, "GET", "/api/v1/executions/999/timeline", token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("unknown execution status = %d, want 404", w.Code)
+		}
+	})
+}
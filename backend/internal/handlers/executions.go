@@ -8,6 +8,7 @@ import (
 
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
 )
@@ -38,6 +39,12 @@ type CompleteRouteExecutionRequest struct {
 	ActualEndTime   *time.Time `json:"actual_end_time"`
 	DriverNotes     string     `json:"driver_notes"`
 	DeviationReason string     `json:"deviation_reason"`
+	// Odometer readings (km) and fuel bought on the route, kept in the
+	// vehicle's log (see GetVehicleEfficiency)
+	OdometerStart *float64 `json:"odometer_start" binding:"omitempty,gte=0"`
+	OdometerEnd   *float64 `json:"odometer_end" binding:"omitempty,gte=0"`
+	FuelPurchased float64  `json:"fuel_purchased" binding:"gte=0"`
+	FuelCost      float64  `json:"fuel_cost" binding:"gte=0"`
 }
 
 // CreateRouteExecution handles POST /api/v1/routes/:id/executions
@@ -52,7 +59,7 @@ func (h *Handler) CreateRouteExecution(c *gin.Context) {
 	route, err := database.GetRouteByID(h.db, routeID)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Route not found")
+			notFoundResponse(c, "Route")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
@@ -68,7 +75,7 @@ func (h *Handler) CreateRouteExecution(c *gin.Context) {
 		PlannedLoad:     route.TotalLoad,
 	}
 
-	if err := database.CreateRouteExecution(h.db, execution); err != nil {
+	if err := h.executions.Create(execution); err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to create route execution")
 		return
 	}
@@ -76,24 +83,33 @@ func (h *Handler) CreateRouteExecution(c *gin.Context) {
 	createdResponse(c, execution)
 }
 
-// GetRouteExecution handles GET /api/v1/executions/:id
+// GetRouteExecution handles GET /api/v1/executions/:id?view=
 func (h *Handler) GetRouteExecution(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
 		return
 	}
+	compact, err := compactView(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	execution, err := database.GetRouteExecution(h.db, id)
+	execution, err := h.executions.Get(id)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Route execution not found")
+			notFoundResponse(c, "Route execution")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
 		return
 	}
 
+	if compact {
+		successResponse(c, compactExecution(execution))
+		return
+	}
 	successResponse(c, execution)
 }
 
@@ -105,7 +121,7 @@ func (h *Handler) GetRouteExecutions(c *gin.Context) {
 		return
 	}
 
-	executions, err := database.GetRouteExecutionsByRoute(h.db, routeID)
+	executions, err := h.executions.ListByRoute(routeID)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route executions")
 		return
@@ -128,7 +144,7 @@ func (h *Handler) StartRouteExecution(c *gin.Context) {
 
 	var req StartRouteExecutionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
 
@@ -139,23 +155,25 @@ func (h *Handler) StartRouteExecution(c *gin.Context) {
 	}
 
 	if execution.ActualStartTime == nil {
-		now := time.Now()
+		now := h.clock.Now()
 		execution.ActualStartTime = &now
 	}
 
-	if err := database.UpdateRouteExecution(h.db, execution); err != nil {
+	if err := h.executions.Update(execution); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Route execution not found")
+			notFoundResponse(c, "Route execution")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to start route execution")
 		return
 	}
 
-	successResponse(c, execution)
+	h.respondWithExecution(c, id)
 }
 
 // CompleteRouteExecution handles POST /api/v1/executions/:id/complete
+// Odometer readings and fuel purchased, when reported, are stored in the
+// vehicle log of the execution.
 func (h *Handler) CompleteRouteExecution(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -165,19 +183,44 @@ func (h *Handler) CompleteRouteExecution(c *gin.Context) {
 
 	var req CompleteRouteExecutionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
 
+	if req.OdometerStart != nil && req.OdometerEnd != nil && *req.OdometerEnd < *req.OdometerStart {
+		errorResponse(c, http.StatusBadRequest, "odometer_end must not be below odometer_start")
+		return
+	}
+	var vehicleID *int64
+	if req.reportsVehicleLog() {
+		execution, err := h.executions.Get(id)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				notFoundResponse(c, "Route execution")
+				return
+			}
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+			return
+		}
+		if execution.Route == nil || execution.Route.VehicleID == nil {
+			errorResponse(c, http.StatusBadRequest, "The route has no vehicle to log odometer and fuel against")
+			return
+		}
+		vehicleID = execution.Route.VehicleID
+	}
+
 	if req.ActualEndTime == nil {
-		now := time.Now()
+		now := h.clock.Now()
 		req.ActualEndTime = &now
 	}
+	if !h.useTrackDistance(c, id, &req.ActualDistance) {
+		return
+	}
 
-	err = database.CompleteRouteExecution(h.db, id, req.ActualDistance, req.ActualCost, req.ActualLoad)
+	err = h.executions.Complete(id, req.ActualDistance, req.ActualCost, req.ActualLoad, *req.ActualEndTime)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Route execution not found")
+			notFoundResponse(c, "Route execution")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to complete route execution")
@@ -192,10 +235,60 @@ func (h *Handler) CompleteRouteExecution(c *gin.Context) {
 			DeviationReason: req.DeviationReason,
 			ActualEndTime:   req.ActualEndTime,
 		}
-		database.UpdateRouteExecution(h.db, execution)
+		h.executions.Update(execution)
+	}
+
+	if vehicleID != nil {
+		entry := &models.VehicleLog{
+			VehicleID:        *vehicleID,
+			RouteExecutionID: id,
+			Date:             req.ActualEndTime.UTC().Truncate(24 * time.Hour),
+			OdometerStart:    req.OdometerStart,
+			OdometerEnd:      req.OdometerEnd,
+			FuelPurchased:    req.FuelPurchased,
+			FuelCost:         req.FuelCost,
+		}
+		if err := database.SaveVehicleLog(h.db, entry); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to save vehicle log")
+			return
+		}
+	}
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	h.publishExecution(execution)
+	h.publishWebhook(webhooks.ExecutionCompleted, execution)
+	successResponse(c, execution)
+}
+
+// useTrackDistance replaces a reported distance with the one driven along
+// the execution's GPS track, when it has one (see RecordLocations). It
+// writes a 500 and returns false when the track cannot be read.
+func (h *Handler) useTrackDistance(c *gin.Context, executionID int64, distance *float64) bool {
+	tracked, ok, err := h.trackDistance(executionID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to compute distance")
+		return false
+	}
+	if ok {
+		*distance = tracked
 	}
+	return true
+}
 
-	execution, _ := database.GetRouteExecution(h.db, id)
+// respondWithExecution responds with a route execution as stored after an
+// update, including its recomputed progress, and streams the change to the
+// plan's watchers
+func (h *Handler) respondWithExecution(c *gin.Context, id int64) {
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	h.publishExecution(execution)
 	successResponse(c, execution)
 }
 
@@ -209,7 +302,11 @@ func (h *Handler) UpdateRouteExecution(c *gin.Context) {
 
 	var req UpdateRouteExecutionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if !h.useTrackDistance(c, id, &req.ActualDistance) {
 		return
 	}
 
@@ -225,19 +322,21 @@ func (h *Handler) UpdateRouteExecution(c *gin.Context) {
 		DeviationReason: req.DeviationReason,
 	}
 
-	if err := database.UpdateRouteExecution(h.db, execution); err != nil {
+	if err := h.executions.Update(execution); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Route execution not found")
+			notFoundResponse(c, "Route execution")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to update route execution")
 		return
 	}
 
-	successResponse(c, execution)
+	h.respondWithExecution(c, id)
 }
 
 // GetPlanExecutionStats handles GET /api/v1/plans/:id/execution-stats
+// Includes the hours-of-service rules breached by the drivers of the plan's
+// routes on its days and in its weeks (see GetDriverHours).
 func (h *Handler) GetPlanExecutionStats(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -245,11 +344,23 @@ func (h *Handler) GetPlanExecutionStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := database.GetExecutionStats(h.db, id)
+	stats, err := h.executions.Stats(id)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch execution statistics")
 		return
 	}
 
+	violations := []models.HoursViolation{}
+	if plan, err := h.plans.Get(id); err == nil {
+		if violations, err = h.planHoursViolations(plan); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to check hours of service")
+			return
+		}
+	} else if !errors.Is(err, database.ErrNotFound) {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	stats["hours_of_service_violations"] = violations
+
 	successResponse(c, stats)
 }
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// maxExternalRefLength is the longest external reference stored
+const maxExternalRefLength = 100
+
+// customerScope scopes a query of customers to the current user's
+// organization, within which customer external references are unique
+func (h *Handler) customerScope(c *gin.Context) *gorm.DB {
+	if h.db == nil {
+		return nil
+	}
+	if orgID, ok := organizationID(c); ok {
+		return database.InOrganization(h.db, &orgID)
+	}
+	return database.InOrganization(h.db, nil)
+}
+
+// uniqueExternalRef checks no other record of model's kind in scope has an
+// external reference. It writes a 409 and returns false when one has.
+func (h *Handler) uniqueExternalRef(c *gin.Context, kind string, scope *gorm.DB, model interface{}, id int64, ref string) bool {
+	if scope == nil || ref == "" {
+		return true
+	}
+	other, err := database.ExternalRefOwner(scope, model, ref)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return true
+	case err != nil:
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch "+kind)
+		return false
+	case other != id:
+		errorCodeResponse(c, http.StatusConflict, codeExternalRefTaken, fmt.Sprintf("external_ref is already used by %s %d", kind, other))
+		return false
+	}
+	return true
+}
+
+// getByExternalRef responds with the record of the :ref path parameter in
+// scope, read into dest
+func (h *Handler) getByExternalRef(c *gin.Context, kind string, scope *gorm.DB, dest interface{}) {
+	err := database.GetByExternalRef(scope, dest, c.Param("ref"))
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorCodeResponse(c, http.StatusNotFound, strings.ToUpper(kind)+"_NOT_FOUND", fmt.Sprintf("No %s has external_ref %s", kind, c.Param("ref")))
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch "+kind)
+		return
+	}
+	successResponse(c, dest)
+}
+
+// upsertTarget finds the record an upsert by the :ref path parameter
+// writes: the ID of the record of model's kind in scope with the
+// reference, or 0 when a new one is created. The request's external_ref,
+// if given, must be the same and is set to it. It writes the error
+// response and returns false when the upsert cannot go ahead.
+func (h *Handler) upsertTarget(c *gin.Context, kind string, scope *gorm.DB, model interface{}, bodyRef *string) (int64, bool) {
+	ref := c.Param("ref")
+	if len(ref) > maxExternalRefLength {
+		errorResponse(c, http.StatusBadRequest, fmt.Sprintf("external_ref must be at most %d characters", maxExternalRefLength))
+		return 0, false
+	}
+	if *bodyRef != "" && *bodyRef != ref {
+		errorResponse(c, http.StatusBadRequest, "external_ref in the body must match the path")
+		return 0, false
+	}
+	*bodyRef = ref
+	id, err := database.ExternalRefOwner(scope, model, ref)
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return 0, true
+	case err != nil:
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch "+kind)
+		return 0, false
+	}
+	return id, true
+}
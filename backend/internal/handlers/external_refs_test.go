@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestExternalRefs tests looking up and upserting records by external
+// reference, and that references are unique
+func TestExternalRefs(t *testing.T) {
+	s := newTestServer(t)
+	api := s.api.Group("", s.h.UsageMiddleware())
+	api.POST("/products", s.h.CreateProduct)
+	api.GET("/products/by-ref/:ref", s.h.GetProductByRef)
+	api.PUT("/products/by-ref/:ref", s.h.UpsertProduct)
+	api.GET("/products/:id", s.h.GetProduct)
+	api.GET("/vehicles/by-ref/:ref", s.h.GetVehicleByRef)
+	api.PUT("/vehicles/by-ref/:ref", s.h.UpsertVehicle)
+	api.PUT("/warehouses/by-ref/:ref", s.h.UpsertWarehouse)
+	api.GET("/customers/by-ref/:ref", s.h.GetCustomerByRef)
+	api.PUT("/customers/by-ref/:ref", s.h.UpsertCustomer)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse(func(w *models.Warehouse) { w.ExternalRef = "DC-1" })
+	s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.ExternalRef = "VAN-1" })
+
+	decode := func(w *httptest.ResponseRecorder, v interface{}) {
+		json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{v})
+	}
+
+	t.Run("products", func(t *testing.T) {
+		var product models.Product
+		body := gin.H{"name": "Diesel", "sku": "DSL", "unit": "liters"}
+		w := s.do(t, "PUT", "/api/v1/products/by-ref/ERP-7", token, body)
+		decode(w, &product)
+		if w.Code != http.StatusCreated || product.ExternalRef != "ERP-7" {
+			t.Fatalf("upsert new product = %d %+v, want it created with the reference", w.Code, product)
+		}
+		body["name"] = "Diesel B7"
+		if w := s.do(t, "PUT", "/api/v1/products/by-ref/ERP-7", token, body); w.Code != http.StatusOK {
+			t.Fatalf("upsert known product status = %d, want 200", w.Code)
+		}
+		var found models.Product
+		w = s.do(t, "GET", "/api/v1/products/by-ref/ERP-7", token, nil)
+		decode(w, &found)
+		if w.Code != http.StatusOK || found.ID != product.ID || found.Name != "Diesel B7" {
+			t.Errorf("lookup = %d %+v, want the renamed product", w.Code, found)
+		}
+		if w := s.do(t, "GET", "/api/v1/products/by-ref/ERP-8", token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("unknown reference status = %d, want 404", w.Code)
+		}
+		if w := s.do(t, "GET", "/api/v1/products/"+fmt.Sprint(product.ID), token, nil); w.Code != http.StatusOK {
+			t.Errorf("lookup by ID status = %d, want 200 beside the by-ref route", w.Code)
+		}
+		body["external_ref"] = "ERP-9"
+		if w := s.do(t, "PUT", "/api/v1/products/by-ref/ERP-7", token, body); w.Code != http.StatusBadRequest {
+			t.Errorf("mismatched body reference status = %d, want 400", w.Code)
+		}
+		if w := s.do(t, "POST", "/api/v1/products", token, gin.H{"name": "Petrol", "sku": "PTR", "external_ref": "ERP-7"}); w.Code != http.StatusConflict {
+			t.Errorf("duplicate reference status = %d, want 409", w.Code)
+		}
+	})
+
+	t.Run("vehicles and warehouses", func(t *testing.T) {
+		var vehicle models.Vehicle
+		w := s.do(t, "PUT", "/api/v1/vehicles/by-ref/VAN-1", token, gin.H{"name": "Van 1", "capacity": 900, "shift_start": "17:00", "shift_end": "08:00"})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("invalid shift status = %d, want 400", w.Code)
+		}
+		w = s.do(t, "PUT", "/api/v1/vehicles/by-ref/VAN-1", token, gin.H{"name": "Van 1", "capacity": 900, "warehouse_id": warehouse.ID})
+		decode(w, &vehicle)
+		if count, _ := database.CountVehicles(s.db); w.Code != http.StatusOK || vehicle.Capacity != 900 || count != 1 {
+			t.Errorf("upsert known vehicle = %d %+v with %d vehicles, want it updated", w.Code, vehicle, count)
+		}
+		if w := s.do(t, "PUT", "/api/v1/warehouses/by-ref/DC-1", token, gin.H{"name": "North", "latitude": 53, "longitude": 6, "current_stock": 1}); w.Code != http.StatusOK {
+			t.Errorf("upsert known warehouse status = %d, want 200", w.Code)
+		}
+		if stocked, _ := database.GetWarehouse(s.db, warehouse.ID); stocked.Name != "North" || stocked.CurrentStock != warehouse.CurrentStock {
+			t.Errorf("warehouse = %+v, want renamed with its stock kept", stocked)
+		}
+	})
+
+	t.Run("customers per organization", func(t *testing.T) {
+		// customer references are unique within an organization
+		org := &models.Organization{Name: "Acme"}
+		database.CreateOrganization(s.db, org)
+		other := s.fx.Customer(func(c *models.Customer) { c.ExternalRef = "C-1" })
+		member := s.fx.User("manager", func(u *models.User) { u.OrganizationID = &org.ID })
+		orgToken := e2eLogin(t, s.router, member).Token
+		if w := s.do(t, "GET", "/api/v1/customers/by-ref/C-1", orgToken, nil); w.Code != http.StatusNotFound {
+			t.Errorf("other organization's customer status = %d, want 404", w.Code)
+		}
+		var customer models.Customer
+		w := s.do(t, "PUT", "/api/v1/customers/by-ref/C-1", orgToken, gin.H{"name": "Acme Shop", "latitude": 52, "longitude": 4})
+		decode(w, &customer)
+		if w.Code != http.StatusCreated || customer.ID == other.ID || customer.OrganizationID == nil || *customer.OrganizationID != org.ID {
+			t.Errorf("upsert in organization = %d %+v, want a new customer of the organization", w.Code, customer)
+		}
+		w = s.do(t, "GET", "/api/v1/customers/by-ref/C-1", token, nil)
+		decode(w, &customer)
+		if w.Code != http.StatusOK || customer.ID != other.ID {
+			t.Errorf("lookup without organization = %d %+v, want customer %d", w.Code, customer, other.ID)
+		}
+	})
+}
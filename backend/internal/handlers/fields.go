@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// routeEmbeds are the associations ?embed= can load with routes
+var routeEmbeds = []string{"vehicle", "driver", "stops", "stops.customer", "stops.place"}
+
+// defaultRouteEmbeds are loaded when ?embed= is left out
+var defaultRouteEmbeds = []string{"vehicle", "stops", "stops.customer", "stops.place"}
+
+// parseEmbed reads the embed query parameter, a comma-separated list of
+// the associations to load out of allowed, e.g. embed=vehicle,stops.customer.
+// Embedding a nested association embeds its parents. Leaving the parameter
+// out embeds defaults; embed= or embed=none embeds nothing.
+func parseEmbed(c *gin.Context, allowed, defaults []string) (map[string]bool, error) {
+	raw, ok := c.GetQuery("embed")
+	if !ok {
+		raw = strings.Join(defaults, ",")
+	}
+	embed := map[string]bool{}
+	if raw == "none" {
+		return embed, nil
+	}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("embed must be a comma-separated list of %s, or none", strings.Join(allowed, ", "))
+		}
+		for i, r := range name {
+			if r == '.' {
+				embed[name[:i]] = true
+			}
+		}
+		embed[name] = true
+	}
+	return embed, nil
+}
+
+// routeEmbed converts parsed embeds to the route associations to load.
+// prefix is the path of the routes in the response, e.g. "routes." for a
+// plan's routes.
+func routeEmbed(embed map[string]bool, prefix string) database.RouteEmbed {
+	return database.RouteEmbed{
+		Vehicle:   embed[prefix+"vehicle"],
+		Driver:    embed[prefix+"driver"],
+		Stops:     embed[prefix+"stops"],
+		Customers: embed[prefix+"stops.customer"],
+		Places:    embed[prefix+"stops.place"],
+	}
+}
+
+// withPrefix returns names with prefix prepended to each
+func withPrefix(prefix string, names []string) []string {
+	prefixed := make([]string, len(names))
+	for i, name := range names {
+		prefixed[i] = prefix + name
+	}
+	return prefixed
+}
+
+// fieldTree is the set of fields ?fields= keeps, by JSON key. A field
+// without subfields keeps its whole value.
+type fieldTree map[string]fieldTree
+
+// parseFields reads the fields query parameter, a comma-separated list of
+// JSON keys of the response data, e.g. fields=id,name,routes.stops.customer_id.
+// Dotted keys select fields of nested objects and of every element of
+// nested lists. A key selected whole keeps its whole value even when fields
+// of it are selected too, e.g. fields=routes,routes.stops keeps all of
+// routes. It returns nil when the parameter is left out.
+func parseFields(c *gin.Context) fieldTree {
+	raw := c.Query("fields")
+	if raw == "" {
+		return nil
+	}
+	var paths [][]string
+	whole := map[string]bool{}
+	for _, field := range strings.Split(raw, ",") {
+		var path []string
+		for _, key := range strings.Split(strings.TrimSpace(field), ".") {
+			if key == "" {
+				break
+			}
+			path = append(path, key)
+		}
+		if len(path) > 0 {
+			paths = append(paths, path)
+			whole[strings.Join(path, ".")] = true
+		}
+	}
+	tree := fieldTree{}
+	for _, path := range paths {
+		if selectedWhole(path, whole) {
+			continue
+		}
+		node := tree
+		for _, key := range path {
+			next, ok := node[key]
+			if !ok {
+				next = fieldTree{}
+				node[key] = next
+			}
+			node = next
+		}
+	}
+	if len(tree) == 0 {
+		return nil
+	}
+	return tree
+}
+
+// selectedWhole reports whether a parent of path is selected whole
+func selectedWhole(path []string, whole map[string]bool) bool {
+	for i := 1; i < len(path); i++ {
+		if whole[strings.Join(path[:i], ".")] {
+			return true
+		}
+	}
+	return false
+}
+
+// selectFields trims response data to the fields requested with ?fields=,
+// applied to each element when data is a list. Requested fields the data
+// does not have are ignored, so one list of fields works for every
+// response of an endpoint. Data is returned unchanged without ?fields=.
+func selectFields(c *gin.Context, data interface{}) interface{} {
+	tree := parseFields(c)
+	if tree == nil || data == nil {
+		return data
+	}
+	body, err := json.Marshal(data)
+	if err != nil {
+		// c.JSON reports the error on the original data
+		return data
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return data
+	}
+	return tree.apply(value)
+}
+
+func (t fieldTree) apply(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		selected := make(map[string]interface{}, len(t))
+		for key, sub := range t {
+			field, ok := v[key]
+			if !ok {
+				continue
+			}
+			if len(sub) > 0 {
+				field = sub.apply(field)
+			}
+			selected[key] = field
+		}
+		return selected
+	case []interface{}:
+		for i, element := range v {
+			v[i] = t.apply(element)
+		}
+		return v
+	default:
+		return value
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// TestFieldsAndEmbed tests trimming responses with ?fields= and choosing
+// the associations loaded with ?embed=
+func TestFieldsAndEmbed(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/plans/:id", s.h.GetPlan)
+	s.api.GET("/plans/:id/routes", s.h.GetPlanRoutes)
+
+	token := s.login(t, "admin")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 7)
+	s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer(), s.fx.Customer())
+	planPath := fmt.Sprintf("/api/v1/plans/%d", plan.ID)
+
+	get := func(t *testing.T, path string) map[string]interface{} {
+		t.Helper()
+		w := s.do(t, "GET", path, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET %s status = %d: %s", path, w.Code, w.Body.String())
+		}
+		var body map[string]interface{}
+		json.Unmarshal(w.Body.Bytes(), &body)
+		return body
+	}
+	route := func(t *testing.T, plan map[string]interface{}) map[string]interface{} {
+		t.Helper()
+		routes, _ := plan["routes"].([]interface{})
+		if len(routes) != 1 {
+			t.Fatalf("plan routes = %v, want one route", plan["routes"])
+		}
+		return routes[0].(map[string]interface{})
+	}
+
+	t.Run("default", func(t *testing.T) {
+		// the plan comes with everything
+		full := route(t, get(t, planPath)["data"].(map[string]interface{}))
+		stops, _ := full["stops"].([]interface{})
+		if full["vehicle"] == nil || len(stops) != 2 || stops[0].(map[string]interface{})["customer"] == nil {
+			t.Errorf("default route = %v, want the vehicle and stops with customers", full)
+		}
+	})
+
+	t.Run("embed", func(t *testing.T) {
+		embedded := get(t, planPath+"?embed=routes.vehicle")["data"].(map[string]interface{})
+		if r := route(t, embedded); r["vehicle"] == nil || r["stops"] != nil {
+			t.Errorf("embed=routes.vehicle route = %v, want the vehicle without stops", r)
+		}
+		if data := get(t, planPath+"?embed=none")["data"].(map[string]interface{}); data["routes"] != nil {
+			t.Errorf("embed=none routes = %v, want none", data["routes"])
+		}
+		if w := s.do(t, "GET", planPath+"?embed=warehouse", token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("unknown embed status = %d, want 400", w.Code)
+		}
+	})
+
+	t.Run("fields", func(t *testing.T) {
+		// select keys, nested ones through lists
+		trimmed := get(t, planPath+"?fields=id,name,routes.day,routes.stops.customer.name,unknown")["data"].(map[string]interface{})
+		if len(trimmed) != 3 || trimmed["id"] != float64(plan.ID) || trimmed["name"] != plan.Name {
+			t.Errorf("trimmed plan = %v, want id, name and routes", trimmed)
+		}
+		r := route(t, trimmed)
+		stops, _ := r["stops"].([]interface{})
+		if len(r) != 2 || r["day"] != float64(1) || len(stops) != 2 {
+			t.Fatalf("trimmed route = %v, want day and stops", r)
+		}
+		stop := stops[0].(map[string]interface{})
+		customer, _ := stop["customer"].(map[string]interface{})
+		if len(stop) != 1 || len(customer) != 1 || customer["name"] == "" {
+			t.Errorf("trimmed stop = %v, want only the customer's name", stop)
+		}
+	})
+
+	t.Run("whole and nested", func(t *testing.T) {
+		// a key selected whole wins over fields of it, in either order
+		for _, fields := range []string{"routes,routes.stops", "routes.stops,routes"} {
+			r := route(t, get(t, planPath+"?fields="+fields)["data"].(map[string]interface{}))
+			if r["day"] == nil || r["vehicle"] == nil || r["stops"] == nil {
+				t.Errorf("fields=%s route = %v, want the whole route", fields, r)
+			}
+		}
+	})
+
+	t.Run("lists", func(t *testing.T) {
+		// trim each element and keep their pagination
+		page := get(t, planPath+"/routes?fields=id,vehicle_id&embed=")
+		items, _ := page["data"].([]interface{})
+		if len(items) != 1 || len(items[0].(map[string]interface{})) != 2 || page["pagination"] == nil {
+			t.Errorf("trimmed routes page = %v, want one route with two fields and the pagination", page)
+		}
+		if w := s.do(t, "GET", planPath+"/routes?embed=stops.customer", token, nil); w.Code != http.StatusOK {
+			t.Errorf("embed=stops.customer status = %d, want 200", w.Code)
+		} else {
+			var body struct {
+				Data []map[string]interface{}
+			}
+			json.Unmarshal(w.Body.Bytes(), &body)
+			if body.Data[0]["vehicle"] != nil || body.Data[0]["stops"] == nil {
+				t.Errorf("embed=stops.customer route = %v, want stops without the vehicle", body.Data[0])
+			}
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"net/http"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// vehicleImportColumns are the vehicle fields an import sets
+var vehicleImportColumns = []string{
+	"external_ref", "name", "capacity", "cost_per_km", "fixed_cost", "max_distance", "range_km", "electric",
+	"charge_minutes", "consumption_per_km", "co2_per_km", "available", "warehouse_id", "max_working_hours",
+	"average_speed", "shift_start", "shift_end", "allowed_tags", "max_payload_weight", "max_front_axle_load",
+	"max_rear_axle_load",
+}
+
+// warehouseImportColumns are the warehouse fields an import sets
+var warehouseImportColumns = []string{
+	"external_ref", "name", "address", "latitude", "longitude", "capacity", "current_stock", "holding_cost",
+	"replenishment_qty", "min_stock",
+}
+
+// ImportVehicles handles POST /api/v1/vehicles/import
+// Creates and updates vehicles from a CSV or XLSX file, matched by
+// external_ref, like ImportCustomers. New vehicles are available unless
+// the file says otherwise.
+func (h *Handler) ImportVehicles(c *gin.Context) {
+	file, ok := h.readImport(c, vehicleImportColumns)
+	if !ok {
+		return
+	}
+	existing, err := database.GetVehiclesByExternalRef(h.db, file.refs())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
+		return
+	}
+	warehouseIDs, err := h.importWarehouseIDs()
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouses")
+		return
+	}
+
+	imp := &importer[models.Vehicle]{
+		columns:  vehicleImportColumns,
+		required: []string{"name", "capacity"},
+		existing: existing,
+		defaults: func(v *models.Vehicle) { v.Available = true },
+		set: func(r *importCells, v *models.Vehicle, field, value string) {
+			setVehicleField(r, v, field, value, warehouseIDs)
+		},
+		check: func(r *importCells, v *models.Vehicle) {
+			if v.Capacity <= 0 {
+				r.fail("capacity", "must be greater than 0")
+			}
+			shift := VehicleRequest{ShiftStart: v.ShiftStart, ShiftEnd: v.ShiftEnd}
+			if err := shift.validateShift(); err != nil {
+				r.fail("shift_end", err.Error())
+			}
+		},
+		id: func(v *models.Vehicle) int64 { return v.ID },
+		create: func(tx *gorm.DB, v *models.Vehicle) error {
+			available := v.Available
+			if err := database.CreateVehicle(tx, v); err != nil || available {
+				return err
+			}
+			// the column's default turns a false available into true on insert
+			v.Available = false
+			return database.UpdateVehicleColumns(tx, v, []string{"available"})
+		},
+		update: database.UpdateVehicleColumns,
+	}
+	result, records := planImport(file, imp)
+	h.runImport(c, file, result, func(tx *gorm.DB) error {
+		return writeImport(tx, result, records, imp)
+	})
+}
+
+// setVehicleField sets a field of an imported vehicle from a cell
+func setVehicleField(r *importCells, v *models.Vehicle, field, value string, warehouseIDs map[int64]bool) {
+	switch field {
+	case "external_ref":
+		v.ExternalRef = value
+	case "name":
+		v.Name = value
+	case "capacity":
+		r.quantity(field, value, &v.Capacity)
+	case "cost_per_km":
+		r.quantity(field, value, &v.CostPerKm)
+	case "fixed_cost":
+		r.quantity(field, value, &v.FixedCost)
+	case "max_distance":
+		r.quantity(field, value, &v.MaxDistance)
+	case "range_km":
+		r.quantity(field, value, &v.RangeKm)
+	case "electric":
+		r.boolean(field, value, &v.Electric)
+	case "charge_minutes":
+		r.integer(field, value, &v.ChargeMinutes)
+	case "consumption_per_km":
+		r.quantity(field, value, &v.ConsumptionPerKm)
+	case "co2_per_km":
+		r.quantity(field, value, &v.CO2PerKm)
+	case "available":
+		r.boolean(field, value, &v.Available)
+	case "warehouse_id":
+		r.warehouse(field, value, warehouseIDs, &v.WarehouseID)
+	case "max_working_hours":
+		r.quantity(field, value, &v.MaxWorkingHours)
+	case "average_speed":
+		r.quantity(field, value, &v.AverageSpeed)
+	case "shift_start":
+		r.clock(field, value, &v.ShiftStart)
+	case "shift_end":
+		r.clock(field, value, &v.ShiftEnd)
+	case "allowed_tags":
+		v.AllowedTags = r.list(value)
+	case "max_payload_weight":
+		r.quantity(field, value, &v.MaxPayloadWeight)
+	case "max_front_axle_load":
+		r.quantity(field, value, &v.MaxFrontAxleLoad)
+	case "max_rear_axle_load":
+		r.quantity(field, value, &v.MaxRearAxleLoad)
+	}
+}
+
+// ImportWarehouses handles POST /api/v1/warehouses/import
+// Creates and updates warehouses from a CSV or XLSX file, matched by
+// external_ref, like ImportCustomers. current_stock is the opening stock
+// of new warehouses; rows of known warehouses cannot set it, as stock only
+// changes through stock movements.
+func (h *Handler) ImportWarehouses(c *gin.Context) {
+	file, ok := h.readImport(c, warehouseImportColumns)
+	if !ok {
+		return
+	}
+	existing, err := database.GetWarehousesByExternalRef(h.db, file.refs())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouses")
+		return
+	}
+
+	imp := &importer[models.Warehouse]{
+		columns:  warehouseImportColumns,
+		required: []string{"name", "latitude", "longitude"},
+		existing: existing,
+		set: func(r *importCells, w *models.Warehouse, field, value string) {
+			if field == "current_stock" && existing[w.ExternalRef] != nil {
+				r.fail(field, "can only be set for a new warehouse; book a stock adjustment instead")
+				return
+			}
+			setWarehouseField(r, w, field, value)
+		},
+		check: func(r *importCells, w *models.Warehouse) {
+			if existing[w.ExternalRef] == nil && w.Capacity > 0 && w.CurrentStock > w.Capacity {
+				r.fail("current_stock", "must not exceed capacity")
+			}
+		},
+		id:     func(w *models.Warehouse) int64 { return w.ID },
+		create: database.CreateWarehouse,
+		update: database.UpdateWarehouseColumns,
+	}
+	result, records := planImport(file, imp)
+	h.runImport(c, file, result, func(tx *gorm.DB) error {
+		return writeImport(tx, result, records, imp)
+	})
+}
+
+// setWarehouseField sets a field of an imported warehouse from a cell
+func setWarehouseField(r *importCells, w *models.Warehouse, field, value string) {
+	switch field {
+	case "external_ref":
+		w.ExternalRef = value
+	case "name":
+		w.Name = value
+	case "address":
+		w.Address = value
+	case "latitude":
+		r.number(field, value, &w.Latitude, -90, 90)
+	case "longitude":
+		r.number(field, value, &w.Longitude, -180, 180)
+	case "capacity":
+		r.quantity(field, value, &w.Capacity)
+	case "current_stock":
+		r.quantity(field, value, &w.CurrentStock)
+	case "holding_cost":
+		r.quantity(field, value, &w.HoldingCost)
+	case "replenishment_qty":
+		r.quantity(field, value, &w.ReplenishmentQty)
+	case "min_stock":
+		r.quantity(field, value, &w.MinStock)
+	}
+}
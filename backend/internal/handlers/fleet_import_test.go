@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestImportVehicles tests importing a fleet: validation, defaults of new
+// vehicles and updates by external reference
+func TestImportVehicles(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/vehicles/import", s.h.RoleMiddleware("admin", "manager"), s.h.ImportVehicles)
+
+	warehouse := s.fx.Warehouse()
+	known := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.ExternalRef = "VAN-2" })
+	t.Run("drivers cannot import", func(t *testing.T) {
+		if code, _ := importUpload(t, s.router, "/api/v1/vehicles/import", s.login(t, "driver"), "fleet.csv", []byte("external_ref\nVAN-1\n"), nil); code != http.StatusForbidden {
+			t.Errorf("driver import status = %d, want 403", code)
+		}
+	})
+	token := s.login(t, "manager")
+
+	csv := []byte("external_ref,name,capacity,electric,available,shift_start,shift_end,allowed_tags,warehouse_id\n" +
+		"VAN-1,Van 1,800,yes,no,07:00,15:30,reefer," + fmt.Sprint(warehouse.ID) + "\n" +
+		"VAN-2,,,,false,,,,\n" +
+		"VAN-3,Van 3,0,maybe,,18:00,06:00,,\n" +
+		"VAN-4,Van 4,500,,,,,,\n")
+	code, result := importUpload(t, s.router, "/api/v1/vehicles/import", token, "fleet.csv", csv, nil)
+	if code != http.StatusOK || result.Created != 2 || result.Updated != 1 || result.Failed != 1 {
+		t.Fatalf("import = %d %+v, want 2 created, 1 updated and 1 failed", code, result)
+	}
+
+	t.Run("row errors", func(t *testing.T) {
+		if r := result.Results[2]; r.Action != importError || len(r.Errors) != 3 {
+			t.Errorf("row 4 = %+v, want capacity, electric and shift errors", r)
+		}
+	})
+
+	t.Run("created", func(t *testing.T) {
+		van1, _ := database.GetVehicle(s.db, *result.Results[0].ID)
+		if van1.Available || !van1.Electric || van1.ShiftEnd != "15:30" || len(van1.AllowedTags) != 1 || van1.WarehouseID == nil || *van1.WarehouseID != warehouse.ID {
+			t.Errorf("VAN-1 = %+v, want an unavailable electric reefer van at the warehouse", van1)
+		}
+		if van4, _ := database.GetVehicle(s.db, *result.Results[3].ID); !van4.Available {
+			t.Errorf("VAN-4 = %+v, want available by default", van4)
+		}
+	})
+
+	t.Run("updated", func(t *testing.T) {
+		if updated, _ := database.GetVehicle(s.db, known.ID); updated.Available || updated.Name != known.Name || updated.Capacity != known.Capacity {
+			t.Errorf("VAN-2 = %+v, want only taken out of service", updated)
+		}
+	})
+}
+
+// TestImportWarehouses tests importing depots: opening stock of new
+// warehouses and updates that cannot change stock
+func TestImportWarehouses(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/warehouses/import", s.h.RoleMiddleware("admin", "manager"), s.h.ImportWarehouses)
+
+	token := s.login(t, "admin")
+	known := s.fx.Warehouse(func(w *models.Warehouse) { w.ExternalRef = "DC-2" })
+
+	csv := []byte("external_ref,name,latitude,longitude,capacity,current_stock,min_stock\n" +
+		"DC-1,North,53.2,6.5,2000,400,100\n" +
+		"DC-2,,,,,,250\n" +
+		"DC-2,,,,,,\n" +
+		"DC-3,South,,4.4,100,200,\n" +
+		"DC-4,West,51.9,4.4,,0,\n")
+	t.Run("stock of known warehouses", func(t *testing.T) {
+		// is never imported
+		if _, result := importUpload(t, s.router, "/api/v1/warehouses/import", token, "depots.csv", []byte("external_ref,current_stock\nDC-2,1\n"), map[string]string{"dry_run": "true"}); result.Failed != 1 {
+			t.Errorf("stock update = %+v, want it refused", result)
+		}
+	})
+
+	code, result := importUpload(t, s.router, "/api/v1/warehouses/import", token, "depots.csv", csv, nil)
+	if code != http.StatusOK || result.Created != 2 || result.Updated != 1 || result.Failed != 2 {
+		t.Fatalf("import = %d %+v, want 2 created, 1 updated and 2 failed", code, result)
+	}
+
+	t.Run("row errors", func(t *testing.T) {
+		if r := result.Results[3]; len(r.Errors) != 2 {
+			t.Errorf("row 5 = %+v, want the missing latitude and the stock over capacity", r)
+		}
+	})
+
+	t.Run("opening stock", func(t *testing.T) {
+		north, _ := database.GetWarehouse(s.db, *result.Results[0].ID)
+		if north.CurrentStock != 400 || north.MinStock != 100 || north.ExternalRef != "DC-1" {
+			t.Errorf("DC-1 = %+v, want 400 in stock", north)
+		}
+		movements, _, _ := database.ListStockMovements(s.db, database.StockMovementFilter{WarehouseID: north.ID}, 0, 10)
+		if len(movements) != 1 || movements[0].Reason != "opening_balance" {
+			t.Errorf("DC-1 movements = %+v, want its opening balance", movements)
+		}
+	})
+
+	t.Run("updated", func(t *testing.T) {
+		if updated, _ := database.GetWarehouse(s.db, known.ID); updated.MinStock != 250 || updated.CurrentStock != known.CurrentStock || updated.Name != known.Name {
+			t.Errorf("DC-2 = %+v, want only the min stock changed", updated)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"gorm.io/gorm"
+)
+
+// geofenceExitFactor widens the radius a vehicle has to leave before a
+// departure is detected, so GPS jitter at the edge does not end a visit
+const geofenceExitFactor = 1.5
+
+// applyGeofences marks the stops of a route execution arrived and departed
+// from its GPS track (see detectStopVisits) and rolls them up into the
+// execution. It returns the number of arrivals and departures detected.
+func (h *Handler) applyGeofences(execution *models.RouteExecution, track []models.LocationPing) (int, int, error) {
+	if h.config == nil || h.config.GeofenceRadius <= 0 || len(track) == 0 {
+		return 0, 0, nil
+	}
+	if execution.Status != "pending" && execution.Status != "in_progress" {
+		return 0, 0, nil
+	}
+	stops, err := database.GetStopExecutionsByRouteExecution(h.db, execution.ID)
+	if err != nil {
+		return 0, 0, err
+	}
+	changed, arrivals, departures := detectStopVisits(stops, track, float64(h.config.GeofenceRadius)/1000)
+	if len(changed) == 0 {
+		return 0, 0, nil
+	}
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		for _, stop := range changed {
+			if err := database.RecordStopOutcomeTx(tx, stop); err != nil {
+				return err
+			}
+		}
+		return database.RollUpStopExecutionsTx(tx, execution.ID)
+	})
+	return arrivals, departures, err
+}
+
+// detectStopVisits walks a track in recorded order and returns the stops it
+// changed. A stop without an arrival is arrived at the first ping within
+// radiusKm of its customer; an arrived stop without a departure is departed
+// at the first ping beyond geofenceExitFactor times the radius, after the
+// vehicle was seen within the radius since the arrival. Times the driver
+// reported are kept, and finished stops are left alone.
+func detectStopVisits(stops []models.StopExecution, track []models.LocationPing, radiusKm float64) ([]*models.StopExecution, int, int) {
+	var changed []*models.StopExecution
+	arrivals, departures := 0, 0
+	for i := range stops {
+		stop := &stops[i]
+		if stop.Status != "pending" && stop.Status != "arrived" {
+			continue
+		}
+		if stop.ActualDepartureTime != nil || stop.Stop == nil || stop.Stop.Customer == nil {
+			continue
+		}
+		customer := stop.Stop.Customer
+
+		inside, detected := false, false
+		for _, p := range track {
+			if stop.ActualArrivalTime != nil && p.RecordedAt.Before(*stop.ActualArrivalTime) {
+				continue
+			}
+			km := optimizer.HaversineKm(p.Latitude, p.Longitude, customer.Latitude, customer.Longitude)
+			if stop.ActualArrivalTime == nil {
+				if km <= radiusKm {
+					at := p.RecordedAt
+					stop.ActualArrivalTime = &at
+					stop.ArrivalDetected = true
+					stop.Status = "arrived"
+					inside, detected = true, true
+					arrivals++
+				}
+				continue
+			}
+			if km <= radiusKm {
+				inside = true
+			} else if inside && km > radiusKm*geofenceExitFactor {
+				at := p.RecordedAt
+				stop.ActualDepartureTime = &at
+				stop.DepartureDetected = true
+				stop.ServiceDuration = max(int(at.Sub(*stop.ActualArrivalTime).Minutes()), 0)
+				detected = true
+				departures++
+				break
+			}
+		}
+		if detected {
+			changed = append(changed, stop)
+		}
+	}
+	return changed, arrivals, departures
+}
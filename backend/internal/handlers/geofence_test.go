@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestGeofenceStopVisits tests that GPS pings mark stops arrived and
+// departed around their customers, and that reported times win
+func TestGeofenceStopVisits(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.GeofenceRadius = 150 })
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.api.POST("/executions/:id/locations", s.h.RecordLocations)
+	s.api.PUT("/executions/:id/stops/:stop_execution_id", s.h.UpdateStopExecution)
+
+	manager := s.fx.User("manager")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+	at := func(lat float64) func(*models.Customer) {
+		return func(c *models.Customer) { c.Latitude, c.Longitude = lat, -74.0 }
+	}
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer(at(40.75)), s.fx.Customer(at(40.80)))
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "pending"}
+	for _, stop := range route.Stops {
+		execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{StopID: stop.ID, Status: "pending"})
+	}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+	first, second := execution.StopExecutions[0].ID, execution.StopExecutions[1].ID
+	token := e2eLogin(t, s.router, manager).Token
+
+	start := time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)
+	record := func(t *testing.T, pings ...LocationPingRequest) models.LocationIngest {
+		t.Helper()
+		w := s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/locations", execution.ID), token, RecordLocationsRequest{Pings: pings})
+		var resp struct{ Data models.LocationIngest }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK {
+			t.Fatalf("locations status = %d: %s", w.Code, w.Body.String())
+		}
+		return resp.Data
+	}
+	stops := func(t *testing.T) map[int64]models.StopExecution {
+		t.Helper()
+		stored, err := s.h.executions.Get(execution.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byID := make(map[int64]models.StopExecution)
+		for _, stop := range stored.StopExecutions {
+			byID[stop.ID] = stop
+		}
+		return byID
+	}
+
+	t.Run("arrival detected", func(t *testing.T) {
+		// approach and enter the first customer; 40.7495 is about 55m away
+		ingest := record(t,
+			LocationPingRequest{Latitude: 40.72, Longitude: -74.0, Timestamp: start},
+			LocationPingRequest{Latitude: 40.7495, Longitude: -74.0, Timestamp: start.Add(10 * time.Minute)},
+		)
+		if ingest.Arrivals != 1 || ingest.Departures != 0 {
+			t.Errorf("ingest = %+v, want one arrival", ingest)
+		}
+		got := stops(t)[first]
+		if got.Status != "arrived" || !got.ArrivalDetected || got.ActualArrivalTime == nil || !got.ActualArrivalTime.Equal(start.Add(10*time.Minute)) {
+			t.Errorf("first stop = %+v, want arrived at the first ping inside", got)
+		}
+		stored, _ := s.h.executions.Get(execution.ID)
+		if stored.Status != "in_progress" {
+			t.Errorf("execution status = %s, want in_progress", stored.Status)
+		}
+	})
+
+	reported := start.Add(8 * time.Minute)
+	early := start.Add(40 * time.Minute)
+	t.Run("reported arrivals", func(t *testing.T) {
+		// the driver reports the arrival a bit earlier, replacing the
+		// detected one
+		arrived := UpdateStopExecutionRequest{Status: "arrived", Time: &reported}
+		if w := s.do(t, "PUT", fmt.Sprintf("/api/v1/executions/%d/stops/%d", execution.ID, first), token, arrived); w.Code != http.StatusOK {
+			t.Fatalf("report over detected arrival status = %d: %s", w.Code, w.Body.String())
+		}
+		if w := s.do(t, "PUT", fmt.Sprintf("/api/v1/executions/%d/stops/%d", execution.ID, first), token, arrived); w.Code != http.StatusConflict {
+			t.Errorf("second reported arrival status = %d, want 409", w.Code)
+		}
+		// and reports the second customer before its pings arrive
+		if w := s.do(t, "PUT", fmt.Sprintf("/api/v1/executions/%d/stops/%d", execution.ID, second), token,
+			UpdateStopExecutionRequest{Status: "arrived", Time: &early}); w.Code != http.StatusOK {
+			t.Fatalf("report second arrival status = %d: %s", w.Code, w.Body.String())
+		}
+	})
+
+	t.Run("departures detected", func(t *testing.T) {
+		// stay at the first customer, jitter just outside the radius, leave
+		// it, then pass the second
+		ingest := record(t,
+			LocationPingRequest{Latitude: 40.7502, Longitude: -74.0, Timestamp: start.Add(15 * time.Minute)},
+			LocationPingRequest{Latitude: 40.7516, Longitude: -74.0, Timestamp: start.Add(20 * time.Minute)},
+			LocationPingRequest{Latitude: 40.76, Longitude: -74.0, Timestamp: start.Add(30 * time.Minute)},
+			LocationPingRequest{Latitude: 40.8001, Longitude: -74.0, Timestamp: start.Add(45 * time.Minute)},
+			LocationPingRequest{Latitude: 40.81, Longitude: -74.0, Timestamp: start.Add(60 * time.Minute)},
+		)
+		if ingest.Arrivals != 0 || ingest.Departures != 2 {
+			t.Errorf("ingest = %+v, want two departures", ingest)
+		}
+		all := stops(t)
+		got := all[first]
+		if got.ArrivalDetected || !got.ActualArrivalTime.Equal(reported) || !got.DepartureDetected ||
+			got.ActualDepartureTime == nil || !got.ActualDepartureTime.Equal(start.Add(30*time.Minute)) || got.ServiceDuration != 22 {
+			t.Errorf("first stop = %+v, want the reported arrival and the detected departure", got)
+		}
+		got = all[second]
+		if got.ArrivalDetected || !got.ActualArrivalTime.Equal(early) || got.ActualDepartureTime == nil || !got.ActualDepartureTime.Equal(start.Add(60*time.Minute)) {
+			t.Errorf("second stop = %+v, want the reported arrival kept", got)
+		}
+	})
+
+	t.Run("completing", func(t *testing.T) {
+		// without a time keeps the detected departure
+		if w := s.do(t, "PUT", fmt.Sprintf("/api/v1/executions/%d/stops/%d", execution.ID, first), token,
+			UpdateStopExecutionRequest{Status: "completed"}); w.Code != http.StatusOK {
+			t.Fatalf("complete status = %d: %s", w.Code, w.Body.String())
+		}
+		got := stops(t)[first]
+		if got.Status != "completed" || !got.DepartureDetected || !got.ActualDepartureTime.Equal(start.Add(30*time.Minute)) {
+			t.Errorf("completed stop = %+v, want the detected departure kept", got)
+		}
+	})
+}
@@ -1,10 +1,25 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"LogiTrackPro/backend/internal/clock"
 	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/distancematrix"
+	"LogiTrackPro/backend/internal/models"
 	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/ratelimit"
+	"LogiTrackPro/backend/internal/repository"
+	"LogiTrackPro/backend/internal/storage"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -14,14 +29,111 @@ type Handler struct {
 	db        *gorm.DB
 	optimizer *optimizer.Client
 	config    *config.Config
+	distances distancematrix.Provider
+	artifacts storage.Storage
+	clock     clock.Clock
+	// customers, plans and executions are the repositories of those
+	// aggregates; other storage goes through the database package
+	customers  repository.Customers
+	plans      repository.Plans
+	executions repository.Executions
+	// progress holds the latest optimizer.Progress per plan ID while optimizing
+	progress sync.Map
+	// events streams execution changes to the plans' watchers
+	events *eventBus
+	// maintenance is the read-only mode toggle and its outbox
+	maintenance *maintenance
+	// rateLimiter keeps the per-user and per-IP token buckets
+	rateLimiter ratelimit.Store
 }
 
 func New(db *gorm.DB, optimizerClient *optimizer.Client, cfg *config.Config) *Handler {
-	return &Handler{
+	distances, err := distancematrix.New(
+		cfg.DistanceProvider,
+		cfg.DistanceProviderURL,
+		cfg.DistanceAPIKey,
+		time.Duration(cfg.DistanceCacheTTL)*time.Hour,
+	)
+	if err != nil {
+		log.Printf("WARNING: distance provider disabled, using straight-line distances: %v", err)
+	}
+
+	artifacts, err := storage.New(storage.Config{
+		Driver:        cfg.StorageDriver,
+		LocalDir:      cfg.StorageLocalDir,
+		PublicURL:     cfg.StoragePublicURL,
+		SigningSecret: cfg.StorageSigningSecret,
+		Bucket:        cfg.StorageBucket,
+		Region:        cfg.StorageRegion,
+		Endpoint:      cfg.StorageEndpoint,
+		AccessKey:     cfg.StorageAccessKey,
+		SecretKey:     cfg.StorageSecretKey,
+	})
+	if err != nil {
+		log.Printf("WARNING: artifact storage disabled: %v", err)
+		// the drivers return typed nil pointers, which would not be nil here
+		artifacts = nil
+	}
+
+	var rateLimiter ratelimit.Store = ratelimit.NewMemory()
+	if cfg.RateLimitRedisURL != "" {
+		redis, err := ratelimit.NewRedis(cfg.RateLimitRedisURL)
+		if err != nil {
+			log.Printf("WARNING: Redis rate limiting disabled, keeping limits in memory: %v", err)
+		} else {
+			rateLimiter = redis
+		}
+	}
+
+	h := &Handler{
 		db:        db,
 		optimizer: optimizerClient,
 		config:    cfg,
+		distances: distances,
+		artifacts: artifacts,
+		clock:     clock.Real,
+		events:    newEventBus(),
+		maintenance: &maintenance{
+			enabled:    cfg.MaintenanceMode,
+			retryAfter: cfg.MaintenanceRetryAfter,
+		},
+		rateLimiter: rateLimiter,
+	}
+	if h.maintenance.retryAfter <= 0 {
+		h.maintenance.retryAfter = defaultRetryAfter
+	}
+	if h.maintenance.enabled {
+		h.maintenance.since = h.clock.Now()
 	}
+	h.SetRepositories(repository.NewGorm(db))
+	return h
+}
+
+// SetRepositories replaces the repositories handlers read and write
+// customers, plans and executions through, e.g. with in-memory fakes
+func (h *Handler) SetRepositories(r repository.Repositories) {
+	h.customers = r.Customers
+	h.plans = r.Plans
+	h.executions = r.Executions
+}
+
+// SetClock replaces the clock handlers read the current time from, so
+// tests can freeze or step time. Timestamps GORM sets on the handler's
+// database and the expiry of local signed URLs follow the same clock.
+func (h *Handler) SetClock(c clock.Clock) {
+	h.clock = c
+	if h.db != nil {
+		database.UseClock(h.db, c)
+	}
+	if local, ok := h.artifacts.(*storage.Local); ok {
+		local.SetClock(c)
+	}
+}
+
+// Artifacts returns the storage for generated files, or nil when it is not
+// configured
+func (h *Handler) Artifacts() storage.Storage {
+	return h.artifacts
 }
 
 // HealthCheck handles GET /health
@@ -49,25 +161,154 @@ func (h *Handler) HealthCheck(c *gin.Context) {
 	})
 }
 
-// Response helpers
+// Response helpers. Data is trimmed to the fields asked for with ?fields=
+// (see selectFields).
 func successResponse(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
-		"data":    data,
+		"data":    selectFields(c, data),
 	})
 }
 
 func createdResponse(c *gin.Context, data interface{}) {
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"data":    data,
+		"data":    selectFields(c, data),
 	})
 }
 
-func errorResponse(c *gin.Context, status int, message string) {
-	c.JSON(status, gin.H{
-		"success": false,
-		"error":   message,
+// warningResponse is a success response carrying warnings the client should
+// show, such as a manual edit that is allowed but uneconomical
+func warningResponse(c *gin.Context, data interface{}, warnings []string) {
+	if len(warnings) == 0 {
+		successResponse(c, data)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"data":     selectFields(c, data),
+		"warnings": warnings,
 	})
 }
 
+// createdWarningResponse is createdResponse carrying warnings (see
+// warningResponse)
+func createdWarningResponse(c *gin.Context, data interface{}, warnings []string) {
+	if len(warnings) == 0 {
+		createdResponse(c, data)
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"data":     selectFields(c, data),
+		"warnings": warnings,
+	})
+}
+
+// paginatedResponse is a success response for one page of a list, in the
+// envelope of the request's API version
+func paginatedResponse(c *gin.Context, data interface{}, page models.Pagination) {
+	c.JSON(http.StatusOK, serializerFor(c).page(c, selectFields(c, data), page))
+}
+
+// parseDateQuery reads an optional YYYY-MM-DD query parameter
+func parseDateQuery(c *gin.Context, key string, defaultValue time.Time) (time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// parseDateRangeQuery reads the optional from and to query parameters
+func parseDateRangeQuery(c *gin.Context) (from, to *time.Time, err error) {
+	for _, p := range []struct {
+		key  string
+		dest **time.Time
+	}{{"from", &from}, {"to", &to}} {
+		if c.Query(p.key) == "" {
+			continue
+		}
+		date, err := parseDateQuery(c, p.key, time.Time{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid %s date format (use YYYY-MM-DD)", p.key)
+		}
+		*p.dest = &date
+	}
+	if from != nil && to != nil && to.Before(*from) {
+		return nil, nil, errors.New("to must not be before from")
+	}
+	return from, to, nil
+}
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// parsePage reads the page and limit query parameters
+func parsePage(c *gin.Context) (models.Pagination, error) {
+	page := models.Pagination{Page: 1, Limit: defaultPageLimit}
+	if raw := c.Query("page"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			return page, errors.New("page must be a positive integer")
+		}
+		page.Page = n
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = n
+	}
+	return page, nil
+}
+
+// parseSort reads the sort query parameter, a field name with an optional
+// "-" prefix for descending order, and returns the ORDER BY clause. columns
+// maps the sortable fields to their columns.
+func parseSort(c *gin.Context, columns map[string]string, defaultSort string) (string, error) {
+	sort := c.DefaultQuery("sort", defaultSort)
+	direction := "ASC"
+	if strings.HasPrefix(sort, "-") {
+		sort, direction = sort[1:], "DESC"
+	}
+	column, ok := columns[sort]
+	if !ok {
+		fields := make([]string, 0, len(columns))
+		for field := range columns {
+			fields = append(fields, field)
+		}
+		slices.Sort(fields)
+		return "", fmt.Errorf("sort must be one of %s, optionally prefixed with -", strings.Join(fields, ", "))
+	}
+	return column + " " + direction, nil
+}
+
+// parseIDQuery reads an optional ID query parameter
+func parseIDQuery(c *gin.Context, key string) (*int64, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	return &id, nil
+}
+
+// parseIntQuery reads an optional whole number query parameter
+func parseIntQuery(c *gin.Context, key string) (*int, error) {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a whole number", key)
+	}
+	return &n, nil
+}
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IdempotencyCleanupJobType is the job deleting expired idempotency keys
+const IdempotencyCleanupJobType = "idempotency.cleanup"
+
+// idempotencyTTL is how long a response is replayed to retries with the
+// same Idempotency-Key
+const idempotencyTTL = 24 * time.Hour
+
+// maxIdempotencyKeyLength is the longest Idempotency-Key accepted
+const maxIdempotencyKeyLength = 255
+
+// IdempotencyMiddleware makes POST requests sent with an Idempotency-Key
+// header safe to retry. The first request with a key runs and its response
+// is stored; for 24 hours retries with the key get that response again
+// (with Idempotent-Replayed: true) instead of running the request twice.
+// A key reused for another request is refused with 422 and a retry while
+// the first request still runs with 409. Responses with a 5xx status are
+// not stored, so a retry runs the request again. It must run after
+// AuthMiddleware.
+func (h *Handler) IdempotencyMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			errorResponse(c, http.StatusBadRequest, "Idempotency-Key is too long")
+			c.Abort()
+			return
+		}
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		now := h.clock.Now()
+		record := &models.IdempotencyKey{
+			UserID:      c.GetInt64("userID"),
+			Key:         key,
+			RequestHash: requestHash(c.Request.Method, c.Request.URL.RequestURI(), body),
+			CreatedAt:   now,
+		}
+		held, err := database.ClaimIdempotencyKey(h.db, record, now.Add(-idempotencyTTL))
+		if errors.Is(err, database.ErrDuplicate) {
+			replayIdempotent(c, held, record.RequestHash)
+			return
+		}
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to record idempotency key")
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		c.Next()
+
+		if writer.Status() >= http.StatusInternalServerError {
+			err = database.ReleaseIdempotencyKey(h.db, record.ID)
+		} else {
+			err = database.CompleteIdempotencyKey(h.db, record.ID, writer.Status(),
+				writer.Header().Get("Content-Type"), writer.body.Bytes(), h.clock.Now())
+		}
+		if err != nil {
+			log.Printf("WARNING: failed to store the response for idempotency key %q: %v", key, err)
+		}
+	}
+}
+
+// replayIdempotent answers a request whose idempotency key is held by the
+// earlier request held
+func replayIdempotent(c *gin.Context, held *models.IdempotencyKey, hash string) {
+	switch {
+	case held.RequestHash != hash:
+		errorCodeResponse(c, http.StatusUnprocessableEntity, codeIdempotencyReused, "Idempotency-Key was already used for a different request")
+	case held.CompletedAt == nil:
+		errorCodeResponse(c, http.StatusConflict, codeIdempotencyRunning, "A request with this Idempotency-Key is still in progress")
+	default:
+		c.Header("Idempotent-Replayed", "true")
+		c.Data(held.Status, held.ContentType, held.Body)
+	}
+	c.Abort()
+}
+
+// requestHash identifies a request by its method, path, query and body
+func requestHash(method, uri string, body []byte) string {
+	sum := sha256.New()
+	sum.Write([]byte(method + " " + uri + "\n"))
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// recordingWriter keeps a copy of the response body it writes
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// PurgeIdempotencyKeys is the IdempotencyCleanupJobType job. It deletes the
+// keys whose responses are no longer replayed.
+func (h *Handler) PurgeIdempotencyKeys(ctx context.Context, _ json.RawMessage) error {
+	deleted, err := database.DeleteIdempotencyKeysBefore(h.db.WithContext(ctx), h.clock.Now().Add(-idempotencyTTL))
+	if deleted > 0 {
+		log.Printf("Idempotency cleanup: deleted %d expired keys", deleted)
+	}
+	return err
+}
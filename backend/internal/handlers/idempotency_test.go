@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestIdempotencyMiddleware tests that retries with the same key replay
+// the first response instead of creating the record again
+func TestIdempotencyMiddleware(t *testing.T) {
+	s := newTestServer(t)
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.router.Use(func(c *gin.Context) {
+		c.Set("userID", int64(1))
+		if c.GetHeader("X-Test-User") == "2" {
+			c.Set("userID", int64(2))
+		}
+		c.Next()
+	}, s.h.IdempotencyMiddleware())
+	s.router.POST("/customers", s.h.CreateCustomer)
+
+	post := func(t *testing.T, key, user string, body interface{}) *httptest.ResponseRecorder {
+		t.Helper()
+		data, _ := json.Marshal(body)
+		req := httptest.NewRequest("POST", "/customers", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Idempotency-Key", key)
+		req.Header.Set("X-Test-User", user)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		return w
+	}
+	countCustomers := func(t *testing.T) int {
+		t.Helper()
+		n, err := database.CountCustomers(s.db)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	depot := CustomerRequest{Name: "Depot", Latitude: 52, Longitude: 4}
+	t.Run("retry replays", func(t *testing.T) {
+		first := post(t, "key-1", "1", depot)
+		if first.Code != http.StatusCreated {
+			t.Fatalf("first request status = %d: %s", first.Code, first.Body.String())
+		}
+		retry := post(t, "key-1", "1", depot)
+		if retry.Code != http.StatusCreated || retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("retry = %d %s (replayed %q), want the first response replayed", retry.Code, retry.Body.String(), retry.Header().Get("Idempotent-Replayed"))
+		}
+		if n := countCustomers(t); n != 1 {
+			t.Errorf("customers after a retry = %d, want 1", n)
+		}
+	})
+
+	t.Run("key reuse", func(t *testing.T) {
+		if w := post(t, "key-1", "1", CustomerRequest{Name: "Other", Latitude: 52, Longitude: 4}); w.Code != http.StatusUnprocessableEntity {
+			t.Errorf("key reused for another request status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+		}
+		if w := post(t, "key-1", "2", depot); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("same key of another user status = %d, want a new customer", w.Code)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		// are replayed too, as retrying them cannot help
+		if w := post(t, "key-2", "1", gin.H{"name": "No location"}); w.Code != http.StatusBadRequest {
+			t.Fatalf("invalid request status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+		if w := post(t, "key-2", "1", gin.H{"name": "No location"}); w.Code != http.StatusBadRequest || w.Header().Get("Idempotent-Replayed") != "true" {
+			t.Errorf("retried invalid request status = %d, want the 400 replayed", w.Code)
+		}
+	})
+
+	t.Run("running request", func(t *testing.T) {
+		// its key is still held
+		if err := s.db.Create(&models.IdempotencyKey{UserID: 1, Key: "key-3", RequestHash: requestHash("POST", "/customers", []byte("null")), CreatedAt: clk.Now()}).Error; err != nil {
+			t.Fatal(err)
+		}
+		if w := post(t, "key-3", "1", nil); w.Code != http.StatusConflict {
+			t.Errorf("retry while the request runs status = %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		clk.Advance(25 * time.Hour)
+		if w := post(t, "key-1", "1", depot); w.Code != http.StatusCreated || w.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("expired key status = %d (replayed %q), want the request run again", w.Code, w.Header().Get("Idempotent-Replayed"))
+		}
+		if err := s.h.PurgeIdempotencyKeys(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+		var left int64
+		s.db.Model(&models.IdempotencyKey{}).Count(&left)
+		if left != 1 {
+			t.Errorf("keys after the cleanup = %d, want only the one just used", left)
+		}
+	})
+}
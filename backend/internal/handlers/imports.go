@@ -0,0 +1,428 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/export"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/spreadsheet"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const (
+	// maxImportBytes bounds an uploaded import file
+	maxImportBytes = 10 << 20
+	// maxImportRows bounds the records imported at once
+	maxImportRows = 5000
+)
+
+// Actions of an imported row
+const (
+	importCreate = "create"
+	importUpdate = "update"
+	importError  = "error"
+)
+
+// ImportError is what is wrong with a cell, or with the row when Column is
+// empty
+type ImportError struct {
+	Column  string `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportRow is the outcome of one row of an import
+type ImportRow struct {
+	Row         int           `json:"row"` // as the spreadsheet numbers it
+	ExternalRef string        `json:"external_ref"`
+	Action      string        `json:"action"`       // create, update or error
+	ID          *int64        `json:"id,omitempty"` // of the record created or updated
+	Errors      []ImportError `json:"errors,omitempty"`
+}
+
+// ImportResult is the outcome of an import
+type ImportResult struct {
+	DryRun  bool        `json:"dry_run"`
+	Rows    int         `json:"rows"`
+	Created int         `json:"created"`
+	Updated int         `json:"updated"`
+	Failed  int         `json:"failed"`
+	Results []ImportRow `json:"results"`
+}
+
+// importFile is an uploaded table with the column of each field
+type importFile struct {
+	rows   []spreadsheet.Row // below the header row
+	index  map[string]int
+	dryRun bool
+}
+
+// importer imports records of one kind from a file, matching them to the
+// stored ones by external reference
+type importer[T any] struct {
+	// columns are the fields a file can set, by their JSON names
+	columns []string
+	// required are the fields a new record needs
+	required []string
+	// existing are the stored records of the file's references
+	existing map[string]*T
+	// defaults sets the fields of a new record a file does not, if any
+	defaults func(record *T)
+	// set sets a field of a record from a cell that is not empty
+	set func(r *importCells, record *T, field, value string)
+	// check validates a record once its fields are set
+	check  func(r *importCells, record *T)
+	id     func(record *T) int64
+	create func(tx *gorm.DB, record *T) error
+	// update writes the columns set of a stored record
+	update func(tx *gorm.DB, record *T, columns []string) error
+}
+
+// importRecord is a row read into the record it creates or updates, with
+// the columns it sets
+type importRecord[T any] struct {
+	record  *T
+	columns []string
+}
+
+// readImport reads an uploaded import: a multipart form with a CSV or XLSX
+// file whose first row names the columns, a mapping JSON object naming the
+// column of each field that is not named like the field, an optional
+// format overriding the file's extension and dry_run. The file needs an
+// external_ref column. It writes the error response and returns false when
+// the upload cannot be read.
+func (h *Handler) readImport(c *gin.Context, columns []string) (*importFile, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBytes)
+	upload, err := c.FormFile("file")
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Upload the records as the file field of a multipart form")
+		return nil, false
+	}
+	format := c.PostForm("format")
+	if format == "" {
+		var ok bool
+		if format, ok = spreadsheet.FormatOf(upload.Filename); !ok {
+			errorResponse(c, http.StatusBadRequest, "Upload a .csv or .xlsx file, or set format")
+			return nil, false
+		}
+	}
+	if format != export.CSV && format != export.XLSX {
+		errorResponse(c, http.StatusBadRequest, "format must be csv or xlsx")
+		return nil, false
+	}
+	file := &importFile{}
+	if raw := c.PostForm("dry_run"); raw != "" {
+		if file.dryRun, err = strconv.ParseBool(raw); err != nil {
+			errorResponse(c, http.StatusBadRequest, "dry_run must be true or false")
+			return nil, false
+		}
+	}
+	mapping := make(map[string]string)
+	if raw := c.PostForm("mapping"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			errorResponse(c, http.StatusBadRequest, "mapping must be a JSON object of column names by field")
+			return nil, false
+		}
+		for field := range mapping {
+			if !slices.Contains(columns, field) {
+				errorResponse(c, http.StatusBadRequest, "mapping names an unknown field "+field)
+				return nil, false
+			}
+		}
+	}
+
+	f, err := upload.Open()
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Failed to read the file")
+		return nil, false
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Failed to read the file")
+		return nil, false
+	}
+	rows, err := spreadsheet.Read(format, data)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid "+format+" file: "+err.Error())
+		return nil, false
+	}
+	if len(rows) < 2 {
+		errorResponse(c, http.StatusBadRequest, "The file has no records below its header row")
+		return nil, false
+	}
+	if len(rows)-1 > maxImportRows {
+		errorResponse(c, http.StatusBadRequest, fmt.Sprintf("At most %d records can be imported at once", maxImportRows))
+		return nil, false
+	}
+	if file.index, err = importIndex(rows[0].Cells, columns, mapping); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return nil, false
+	}
+	file.rows = rows[1:]
+	return file, true
+}
+
+// importIndex finds the column of each field in the header row: the
+// column mapping names, or else the column named like the field, ignoring
+// case
+func importIndex(header, fields []string, mapping map[string]string) (map[string]int, error) {
+	columns := make(map[string]int)
+	for i, name := range header {
+		key := strings.ToLower(strings.TrimSpace(name))
+		if _, ok := columns[key]; !ok {
+			columns[key] = i
+		}
+	}
+	index := make(map[string]int)
+	for _, field := range fields {
+		name, mapped := mapping[field]
+		if !mapped {
+			name = field
+		}
+		i, ok := columns[strings.ToLower(strings.TrimSpace(name))]
+		switch {
+		case ok:
+			index[field] = i
+		case mapped:
+			return nil, fmt.Errorf("column %q mapped to %s is not in the header row", name, field)
+		case field == "external_ref":
+			return nil, fmt.Errorf("the file needs an external_ref column identifying each record")
+		}
+	}
+	return index, nil
+}
+
+// cell is a row's trimmed value of a field, empty when the file has no
+// column for it
+func (f *importFile) cell(row spreadsheet.Row, field string) string {
+	i, ok := f.index[field]
+	if !ok || i >= len(row.Cells) {
+		return ""
+	}
+	return strings.TrimSpace(row.Cells[i])
+}
+
+// refs are the external references of the file's rows
+func (f *importFile) refs() []string {
+	refs := make([]string, 0, len(f.rows))
+	for _, row := range f.rows {
+		if ref := f.cell(row, "external_ref"); ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// planImport reads each row of a file into the record it creates or
+// updates and reports the rows. Rows with errors get no record.
+func planImport[T any](f *importFile, imp *importer[T]) (*ImportResult, []*importRecord[T]) {
+	result := &ImportResult{DryRun: f.dryRun, Rows: len(f.rows), Results: make([]ImportRow, len(f.rows))}
+	records := make([]*importRecord[T], len(f.rows))
+	seen := make(map[string]int)
+	for i, row := range f.rows {
+		outcome := &result.Results[i]
+		outcome.Row = row.Number
+		outcome.ExternalRef = f.cell(row, "external_ref")
+		cells := &importCells{}
+		switch first, ok := seen[outcome.ExternalRef]; {
+		case outcome.ExternalRef == "":
+			cells.fail("external_ref", "is required")
+		case len(outcome.ExternalRef) > 100:
+			cells.fail("external_ref", "must be at most 100 characters")
+		case ok:
+			cells.fail("external_ref", fmt.Sprintf("also in row %d", first))
+		default:
+			seen[outcome.ExternalRef] = row.Number
+		}
+
+		record := &importRecord[T]{record: new(T)}
+		existing := imp.existing[outcome.ExternalRef]
+		if existing != nil {
+			copied := *existing
+			record.record = &copied
+		} else if imp.defaults != nil {
+			imp.defaults(record.record)
+		}
+		for _, field := range imp.columns {
+			value := f.cell(row, field)
+			if value == "" {
+				continue
+			}
+			record.columns = append(record.columns, field)
+			imp.set(cells, record.record, field, value)
+		}
+		if existing == nil {
+			for _, field := range imp.required {
+				if !slices.Contains(record.columns, field) {
+					cells.fail(field, "is required for a new record")
+				}
+			}
+		}
+		if imp.check != nil {
+			imp.check(cells, record.record)
+		}
+
+		outcome.Errors = cells.errors
+		switch {
+		case len(outcome.Errors) > 0:
+			outcome.Action = importError
+			result.Failed++
+		case existing != nil:
+			id := imp.id(existing)
+			outcome.Action, outcome.ID = importUpdate, &id
+			result.Updated++
+			records[i] = record
+		default:
+			outcome.Action = importCreate
+			result.Created++
+			records[i] = record
+		}
+	}
+	return result, records
+}
+
+// writeImport creates and updates the records of an import, setting the
+// IDs of the records created in the result
+func writeImport[T any](tx *gorm.DB, result *ImportResult, records []*importRecord[T], imp *importer[T]) error {
+	for i, r := range records {
+		switch {
+		case r == nil:
+		case result.Results[i].Action == importUpdate:
+			if err := imp.update(tx, r.record, append(r.columns, "updated_at")); err != nil {
+				return err
+			}
+		default:
+			if err := imp.create(tx, r.record); err != nil {
+				return err
+			}
+			id := imp.id(r.record)
+			result.Results[i].ID = &id
+		}
+	}
+	return nil
+}
+
+// runImport stores an import unless it is a dry run and responds with its
+// result
+func (h *Handler) runImport(c *gin.Context, f *importFile, result *ImportResult, write func(tx *gorm.DB) error) {
+	if !f.dryRun {
+		if err := h.db.Transaction(write); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to import records")
+			return
+		}
+	}
+	successResponse(c, result)
+}
+
+// importWarehouseIDs returns the IDs of the warehouses import rows can
+// refer to
+func (h *Handler) importWarehouseIDs() (map[int64]bool, error) {
+	warehouses, err := database.ListWarehouses(h.db)
+	if err != nil {
+		return nil, err
+	}
+	ids := make(map[int64]bool)
+	for _, w := range warehouses {
+		ids[w.ID] = true
+	}
+	return ids, nil
+}
+
+// importCells reads the cells of a row, collecting what is wrong with them
+type importCells struct {
+	errors []ImportError
+}
+
+func (r *importCells) fail(field, message string) {
+	r.errors = append(r.errors, ImportError{Column: field, Message: message})
+}
+
+// number reads a number between min and max
+func (r *importCells) number(field, value string, dst *float64, min, max float64) {
+	v, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil:
+		r.fail(field, "must be a number")
+	case v < min || v > max:
+		r.fail(field, fmt.Sprintf("must be between %g and %g", min, max))
+	default:
+		*dst = v
+	}
+}
+
+// quantity reads a number that is not negative
+func (r *importCells) quantity(field, value string, dst *float64) {
+	v, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err != nil:
+		r.fail(field, "must be a number")
+	case v < 0:
+		r.fail(field, "must not be negative")
+	default:
+		*dst = v
+	}
+}
+
+// integer reads a whole number that is not negative
+func (r *importCells) integer(field, value string, dst *int) {
+	v, err := strconv.Atoi(value)
+	switch {
+	case err != nil:
+		r.fail(field, "must be a whole number")
+	case v < 0:
+		r.fail(field, "must not be negative")
+	default:
+		*dst = v
+	}
+}
+
+// boolean reads true or false, also as yes/no or 1/0
+func (r *importCells) boolean(field, value string, dst *bool) {
+	switch strings.ToLower(value) {
+	case "true", "yes", "y", "1":
+		*dst = true
+	case "false", "no", "n", "0":
+		*dst = false
+	default:
+		r.fail(field, "must be true or false")
+	}
+}
+
+// clock reads a time of day as HH:MM
+func (r *importCells) clock(field, value string, dst *string) {
+	if _, err := optimizer.ParseClock(value); err != nil {
+		r.fail(field, err.Error())
+		return
+	}
+	*dst = value
+}
+
+// warehouse reads the ID of a warehouse in ids
+func (r *importCells) warehouse(field, value string, ids map[int64]bool, dst **int64) {
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || !ids[id] {
+		r.fail(field, "is not a warehouse ID")
+		return
+	}
+	*dst = &id
+}
+
+// list reads values separated by semicolons
+func (r *importCells) list(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ";") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
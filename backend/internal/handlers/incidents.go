@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// incidentCategories are the kinds of incident drivers report
+var incidentCategories = []string{"breakdown", "accident", "refused_delivery", "damaged_goods"}
+
+type ReportIncidentRequest struct {
+	Category        string   `json:"category" form:"category" binding:"required,oneof=breakdown accident refused_delivery damaged_goods"`
+	Description     string   `json:"description" form:"description" binding:"required,max=5000"`
+	StopExecutionID *int64   `json:"stop_execution_id" form:"stop_execution_id"`
+	Effect          string   `json:"effect" form:"effect" binding:"omitempty,oneof=none cancel_remaining reassign"`
+	ReassignRouteID *int64   `json:"reassign_route_id" form:"reassign_route_id"`
+	Latitude        *float64 `json:"latitude" form:"latitude" binding:"omitempty,gte=-90,lte=90"`
+	Longitude       *float64 `json:"longitude" form:"longitude" binding:"omitempty,gte=-180,lte=180"`
+	// OccurredAt defaults to now
+	OccurredAt *time.Time `json:"occurred_at" form:"occurred_at"`
+}
+
+// ReportIncident handles POST /api/v1/executions/:id/incidents
+// JSON, or a multipart form with the same fields and photo files (PNG,
+// JPEG or WebP, up to 10 MB each and 10 per incident). The effect decides
+// what happens to the stops not yet finished: none leaves them to the
+// driver, cancel_remaining skips them, and reassign fails them so their
+// deliveries are redelivered, on reassign_route_id when given (an unstarted
+// route of the same plan with room left) or like other failed stops on the
+// earliest later route or at the next optimization.
+func (h *Handler) ReportIncident(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
+		return
+	}
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	if !h.canReportExecution(c, execution) {
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPODUploadBytes)
+	var req ReportIncidentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	var photos []*multipart.FileHeader
+	if form, err := c.MultipartForm(); err == nil {
+		photos = form.File["photo"]
+	}
+	if len(photos) > maxPODPhotos {
+		errorResponse(c, http.StatusBadRequest, fmt.Sprintf("An incident keeps at most %d photos", maxPODPhotos))
+		return
+	}
+	if len(photos) > 0 && h.artifacts == nil {
+		errorResponse(c, http.StatusServiceUnavailable, "Artifact storage is not configured")
+		return
+	}
+	if req.Effect == "" {
+		req.Effect = "none"
+	}
+	if status, msg := h.validateIncident(execution, req); msg != "" {
+		errorResponse(c, status, msg)
+		return
+	}
+
+	now := h.clock.Now()
+	at := now
+	if req.OccurredAt != nil {
+		at = *req.OccurredAt
+	}
+	incident := &models.Incident{
+		RouteExecutionID: execution.ID,
+		RouteID:          execution.RouteID,
+		StopExecutionID:  req.StopExecutionID,
+		Category:         req.Category,
+		Description:      req.Description,
+		Latitude:         req.Latitude,
+		Longitude:        req.Longitude,
+		OccurredAt:       at,
+		Effect:           req.Effect,
+		ReassignRouteID:  req.ReassignRouteID,
+		AffectedStops:    []int64{},
+	}
+	if userID := c.GetInt64("userID"); userID != 0 {
+		incident.ReportedBy = &userID
+	}
+	if execution.Route != nil {
+		incident.PlanID = execution.Route.PlanID
+		incident.VehicleID = execution.Route.VehicleID
+		incident.DriverID = execution.Route.DriverID
+	}
+
+	// stops left on the route, which the effect skips or fails
+	var affected []*models.StopExecution
+	if req.Effect != "none" {
+		for i := range execution.StopExecutions {
+			stop := &execution.StopExecutions[i]
+			if stop.Status == "pending" || stop.Status == "arrived" {
+				affected = append(affected, stop)
+				incident.AffectedStops = append(incident.AffectedStops, stop.ID)
+			}
+		}
+	}
+
+	prefix := fmt.Sprintf("%sexecutions/%d/", storage.IncidentsPrefix, execution.ID)
+	stamp := now.UTC().Format("20060102T150405Z")
+	// discard removes what this request stored when it does not complete
+	discard := func() {
+		for _, f := range incident.PhotoFiles {
+			h.artifacts.Delete(c.Request.Context(), f.Key)
+		}
+	}
+	for i, fh := range photos {
+		file, status, err := h.storePODImage(c, fh, fmt.Sprintf("%sphoto-%s-%d", prefix, stamp, i+1))
+		if err != nil {
+			discard()
+			errorResponse(c, status, err.Error())
+			return
+		}
+		incident.PhotoFiles = append(incident.PhotoFiles, *file)
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.CreateIncidentTx(tx, incident); err != nil {
+			return err
+		}
+		if len(affected) == 0 {
+			return nil
+		}
+		status, verb := "skipped", "Cancelled"
+		if req.Effect == "reassign" {
+			status, verb = "failed", "Reassigned"
+		}
+		outcome := UpdateStopExecutionRequest{
+			Status: status,
+			Notes:  fmt.Sprintf("%s after %s (incident #%d)", verb, strings.ReplaceAll(req.Category, "_", " "), incident.ID),
+		}
+		for _, stop := range affected {
+			applyStopOutcome(stop, outcome, at)
+			if err := database.RecordStopOutcomeTx(tx, stop); err != nil {
+				return err
+			}
+			if err := h.planRedeliveryOnTx(tx, stop, req.ReassignRouteID); err != nil {
+				return err
+			}
+		}
+		return database.RollUpStopExecutionsTx(tx, execution.ID)
+	})
+	if err != nil {
+		discard()
+		errorResponse(c, http.StatusInternalServerError, "Failed to report incident")
+		return
+	}
+	if len(affected) > 0 {
+		h.publishExecutionChange(execution)
+	}
+
+	if !h.signIncidentPhotos(c, incident) {
+		return
+	}
+	createdResponse(c, incident)
+}
+
+// validateIncident returns the status and message of why an incident
+// cannot be reported on an execution, or "" when it can
+func (h *Handler) validateIncident(execution *models.RouteExecution, req ReportIncidentRequest) (int, string) {
+	if req.StopExecutionID != nil && !slices.ContainsFunc(execution.StopExecutions, func(s models.StopExecution) bool {
+		return s.ID == *req.StopExecutionID
+	}) {
+		return http.StatusBadRequest, "stop_execution_id is not a stop of this route execution"
+	}
+	if req.Effect != "none" && (execution.Status == "completed" || execution.Status == "cancelled") {
+		return http.StatusConflict, "Route execution is " + execution.Status
+	}
+	if req.ReassignRouteID == nil {
+		return 0, ""
+	}
+	if req.Effect != "reassign" {
+		return http.StatusBadRequest, "reassign_route_id is only used with the reassign effect"
+	}
+	if *req.ReassignRouteID == execution.RouteID || execution.Route == nil {
+		return http.StatusBadRequest, "reassign_route_id must be another route of the plan"
+	}
+	if _, err := database.GetUnstartedRouteTx(h.db, execution.Route.PlanID, *req.ReassignRouteID); err != nil {
+		if !errors.Is(err, database.ErrNotFound) {
+			return http.StatusInternalServerError, "Failed to fetch route"
+		}
+		return http.StatusBadRequest, "reassign_route_id must be a route of the same plan that has not started"
+	}
+	return 0, ""
+}
+
+// signIncidentPhotos fills in signed download links for an incident's
+// photos. It writes a 500 and returns false when they cannot be signed.
+func (h *Handler) signIncidentPhotos(c *gin.Context, incident *models.Incident) bool {
+	incident.Photos = []models.ArtifactLink{}
+	for _, photo := range incident.PhotoFiles {
+		link, err := h.artifactLink(c.Request.Context(), photo.Key, photo.Size)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to sign photo link")
+			return false
+		}
+		incident.Photos = append(incident.Photos, *link)
+	}
+	return true
+}
+
+// GetIncident handles GET /api/v1/incidents/:id
+// Photos come as signed, expiring download links.
+func (h *Handler) GetIncident(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid incident ID")
+		return
+	}
+	incident, err := database.GetIncident(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Incident")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch incident")
+		return
+	}
+	if !h.signIncidentPhotos(c, incident) {
+		return
+	}
+	successResponse(c, incident)
+}
+
+// ListIncidents handles GET /api/v1/incidents?category=&execution_id=&plan_id=&vehicle_id=&driver_id=&from=&to=&page=&limit=
+// Incidents latest first, for the safety team. category takes a
+// comma-separated list; from and to are dates of occurrence. Photos are
+// left out; GetIncident signs them.
+func (h *Handler) ListIncidents(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.IncidentFilter{}
+	if raw := c.Query("category"); raw != "" {
+		filter.Categories = strings.Split(raw, ",")
+		for _, category := range filter.Categories {
+			if !slices.Contains(incidentCategories, category) {
+				errorResponse(c, http.StatusBadRequest, "category must be one of "+strings.Join(incidentCategories, ", "))
+				return
+			}
+		}
+	}
+	if filter.ExecutionID, err = parseIDQuery(c, "execution_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.PlanID, err = parseIDQuery(c, "plan_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.VehicleID, err = parseIDQuery(c, "vehicle_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.DriverID, err = parseIDQuery(c, "driver_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Since, filter.Before, err = parseDateRangeQuery(c); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Before != nil {
+		before := filter.Before.AddDate(0, 0, 1)
+		filter.Before = &before
+	}
+
+	incidents, total, err := database.ListIncidents(h.db, filter, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch incidents")
+		return
+	}
+	if incidents == nil {
+		incidents = []models.Incident{}
+	}
+	for i := range incidents {
+		incidents[i].Photos = []models.ArtifactLink{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, incidents, page)
+}
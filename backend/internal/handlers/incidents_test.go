@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestIncidents tests reporting incidents that reassign or cancel the
+// stops left on a route, with photos, and listing them for the safety team
+func TestIncidents(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.StorageLocalDir = t.TempDir() })
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC)))
+	s.api.POST("/executions/:id/incidents", s.h.ReportIncident)
+	s.api.GET("/incidents", s.h.RoleMiddleware("admin", "manager"), s.h.ListIncidents)
+	s.api.GET("/incidents/:id", s.h.RoleMiddleware("admin", "manager"), s.h.GetIncident)
+
+	token := s.login(t, "manager")
+	driverToken := s.login(t, "driver")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+	execute := func(route *models.Route, firstDone bool) *models.RouteExecution {
+		execution := &models.RouteExecution{RouteID: route.ID, Status: "in_progress"}
+		for i, stop := range route.Stops {
+			status := "pending"
+			if firstDone && i == 0 {
+				status = "completed"
+			}
+			execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{StopID: stop.ID, Status: status, PlannedQuantity: 10})
+		}
+		if err := database.CreateRouteExecution(s.db, execution); err != nil {
+			t.Fatal(err)
+		}
+		return execution
+	}
+	brokenDown := execute(s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer(), s.fx.Customer(), s.fx.Customer()), true)
+	spare := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer())
+
+	type response struct{ Data models.Incident }
+	report := func(t *testing.T, execution *models.RouteExecution, req ReportIncidentRequest) (int, models.Incident) {
+		t.Helper()
+		w := s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/incidents", execution.ID), driverToken, req)
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	t.Run("invalid reports", func(t *testing.T) {
+		if code, _ := report(t, brokenDown, ReportIncidentRequest{Category: "flat_tyre", Description: "x"}); code != http.StatusBadRequest {
+			t.Errorf("unknown category status = %d, want 400", code)
+		}
+		ownRoute := brokenDown.RouteID
+		if code, _ := report(t, brokenDown, ReportIncidentRequest{Category: "breakdown", Description: "x", Effect: "reassign", ReassignRouteID: &ownRoute}); code != http.StatusBadRequest {
+			t.Errorf("reassign to own route status = %d, want 400", code)
+		}
+	})
+
+	var breakdown, damagedGoods models.Incident
+	t.Run("reassign", func(t *testing.T) {
+		// the two stops left move to the spare vehicle's route
+		code, incident := report(t, brokenDown, ReportIncidentRequest{Category: "breakdown", Description: "Gearbox failure", Effect: "reassign", ReassignRouteID: &spare.ID})
+		if code != http.StatusCreated {
+			t.Fatalf("report status = %d, want 201", code)
+		}
+		breakdown = incident
+		if len(incident.AffectedStops) != 2 || incident.PlanID != plan.ID || incident.VehicleID == nil {
+			t.Errorf("incident = %+v, want the two remaining stops affected", incident)
+		}
+		stored, _ := database.GetRouteExecution(s.db, brokenDown.ID)
+		for _, stop := range stored.StopExecutions[1:] {
+			if stop.Status != "failed" || stop.Notes != fmt.Sprintf("Reassigned after breakdown (incident #%d)", incident.ID) {
+				t.Errorf("remaining stop = %s %q, want failed with the incident in its notes", stop.Status, stop.Notes)
+			}
+		}
+		var scheduled int64
+		s.db.Model(&models.Redelivery{}).Where("route_id = ? AND status = ?", spare.ID, "scheduled").Count(&scheduled)
+		if route, _ := database.GetRouteByID(s.db, spare.ID); scheduled != 2 || len(route.Stops) != 3 {
+			t.Errorf("spare route has %d stops and %d scheduled redeliveries, want 3 and 2", len(route.Stops), scheduled)
+		}
+	})
+
+	t.Run("photo and cancel remaining", func(t *testing.T) {
+		// damaged goods with a photo, cancelling the rest of the route
+		damaged := execute(s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer()), false)
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		mw.WriteField("category", "damaged_goods")
+		mw.WriteField("description", "Pallet crushed in transit")
+		mw.WriteField("effect", "cancel_remaining")
+		fw, _ := mw.CreateFormFile("photo", "pallet.png")
+		fw.Write(testPNG)
+		mw.Close()
+		req := httptest.NewRequest("POST", fmt.Sprintf("/api/v1/executions/%d/incidents", damaged.ID), &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+driverToken)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("multipart report status = %d, want 201: %s", w.Code, w.Body.String())
+		}
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		damagedGoods = resp.Data
+		if len(resp.Data.Photos) != 1 || resp.Data.Photos[0].URL == "" {
+			t.Errorf("photos = %+v, want one signed link", resp.Data.Photos)
+		}
+		if stored, _ := database.GetRouteExecution(s.db, damaged.ID); stored.StopExecutions[0].Status != "skipped" {
+			t.Errorf("remaining stop = %s, want skipped", stored.StopExecutions[0].Status)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := s.do(t, "GET", "/api/v1/incidents?category=breakdown,accident", token, nil)
+		var list struct{ Data []models.Incident }
+		json.Unmarshal(w.Body.Bytes(), &list)
+		if w.Code != http.StatusOK || len(list.Data) != 1 || list.Data[0].ID != breakdown.ID {
+			t.Errorf("breakdowns = %d %+v, want the one breakdown", w.Code, list.Data)
+		}
+		if w := s.do(t, "GET", "/api/v1/incidents", driverToken, nil); w.Code != http.StatusForbidden {
+			t.Errorf("driver listing status = %d, want 403", w.Code)
+		}
+	})
+
+	t.Run("get", func(t *testing.T) {
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/incidents/%d", damagedGoods.ID), token, nil)
+		var got response
+		json.Unmarshal(w.Body.Bytes(), &got)
+		if got.Data.Category != "damaged_goods" || len(got.Data.Photos) != 1 {
+			t.Errorf("incident = %+v, want the damaged goods with its photo", got.Data)
+		}
+	})
+}
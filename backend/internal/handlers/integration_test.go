@@ -31,9 +31,18 @@ func setupIntegrationTestDB(t *testing.T) *gorm.DB {
 		&models.Warehouse{},
 		&models.Customer{},
 		&models.Vehicle{},
+		&models.Driver{},
+		&models.RosterEntry{},
+		&models.DriverAbsence{},
 		&models.Plan{},
 		&models.Route{},
 		&models.Stop{},
+		&models.StopExplanation{},
+		&models.PlanSolution{},
+		&models.SolutionRoute{},
+		&models.UnroutedCustomer{},
+		&models.Redelivery{},
+		&models.OptimizationRun{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
@@ -92,7 +101,7 @@ func getAuthToken(t *testing.T, h *Handler) string {
 
 // TestCustomerCRUDIntegration tests complete CRUD flow for customers
 func TestCustomerCRUDIntegration(t *testing.T) {
-	h, db := setupIntegrationHandler(t)
+	h, _ := setupIntegrationHandler(t)
 	token := getAuthToken(t, h)
 
 	router := gin.New()
@@ -204,7 +213,7 @@ func TestCustomerCRUDIntegration(t *testing.T) {
 
 // TestPlanCreationFlow tests plan creation with warehouse
 func TestPlanCreationFlow(t *testing.T) {
-	h, db := setupIntegrationHandler(t)
+	h, _ := setupIntegrationHandler(t)
 	token := getAuthToken(t, h)
 
 	// Create warehouse first
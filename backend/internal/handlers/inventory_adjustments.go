@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryAdjustmentRequest struct {
+	// Delta is the change, negative to take inventory out
+	Delta  float64 `json:"delta" binding:"required,ne=0"`
+	Reason string  `json:"reason" binding:"required,oneof=count_correction damaged expired lost found returned other"`
+	Notes  string  `json:"notes"`
+}
+
+// AdjustCustomerInventory handles POST /api/v1/customers/:id/inventory-adjustments
+// Corrects the customer's current inventory by a signed delta with a reason
+// code, keeping who made the change and why. The inventory after it is
+// recorded as a snapshot, and it cannot go below zero (409).
+func (h *Handler) AdjustCustomerInventory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+	var req InventoryAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	adjustment := &models.InventoryAdjustment{
+		CustomerID: id,
+		Delta:      req.Delta,
+		Reason:     req.Reason,
+		Notes:      req.Notes,
+	}
+	if userID := c.GetInt64("userID"); userID != 0 {
+		adjustment.CreatedBy = &userID
+	}
+	if err := database.AdjustCustomerInventory(h.db, adjustment, h.clock.Now()); err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			notFoundResponse(c, "Customer")
+		case errors.Is(err, database.ErrInsufficientStock):
+			errorResponse(c, http.StatusConflict, "Inventory cannot go below zero")
+		default:
+			errorResponse(c, http.StatusInternalServerError, "Failed to adjust inventory")
+		}
+		return
+	}
+	h.queueAlertScan("customer", id)
+	createdResponse(c, adjustment)
+}
+
+// ListCustomerInventoryAdjustments handles GET /api/v1/customers/:id/inventory-adjustments?page=&limit=
+// The customer's inventory adjustments, latest first.
+func (h *Handler) ListCustomerInventoryAdjustments(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if _, err := h.customers.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
+		return
+	}
+
+	adjustments, total, err := database.ListInventoryAdjustments(h.db, id, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch inventory adjustments")
+		return
+	}
+	if adjustments == nil {
+		adjustments = []models.InventoryAdjustment{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, adjustments, page)
+}
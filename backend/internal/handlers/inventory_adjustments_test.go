@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestInventoryAdjustments tests customer inventory corrections keeping
+// their reason and user, with a snapshot of the inventory after them
+func TestInventoryAdjustments(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.api.GET("/customers/:id/inventory-adjustments", s.h.ListCustomerInventoryAdjustments)
+	s.api.POST("/customers/:id/inventory-adjustments", s.h.RoleMiddleware("admin", "manager"), s.h.AdjustCustomerInventory)
+
+	manager := s.fx.User("manager")
+	token := e2eLogin(t, s.router, manager).Token
+	// 50 on hand
+	customer := s.fx.Customer()
+	path := fmt.Sprintf("/api/v1/customers/%d/inventory-adjustments", customer.ID)
+
+	t.Run("refused", func(t *testing.T) {
+		if w := s.do(t, "POST", path, token, InventoryAdjustmentRequest{Delta: -5, Reason: "spilled"}); w.Code != http.StatusBadRequest {
+			t.Errorf("unknown reason status = %d, want 400", w.Code)
+		}
+		if w := s.do(t, "POST", path, s.login(t, "driver"), InventoryAdjustmentRequest{Delta: -5, Reason: "damaged"}); w.Code != http.StatusForbidden {
+			t.Errorf("driver status = %d, want 403", w.Code)
+		}
+		if w := s.do(t, "POST", "/api/v1/customers/999/inventory-adjustments", token, InventoryAdjustmentRequest{Delta: 5, Reason: "found"}); w.Code != http.StatusNotFound {
+			t.Errorf("unknown customer status = %d, want 404", w.Code)
+		}
+	})
+
+	t.Run("adjust", func(t *testing.T) {
+		w := s.do(t, "POST", path, token, InventoryAdjustmentRequest{Delta: -12, Reason: "count_correction", Notes: "Tank dip at the weekly visit"})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("adjust status = %d, want 201: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.InventoryAdjustment }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Data.Balance != 38 || resp.Data.SnapshotID == nil || resp.Data.CreatedBy == nil || *resp.Data.CreatedBy != manager.ID {
+			t.Errorf("adjustment = %+v, want 38 left with a snapshot by the manager", resp.Data)
+		}
+		snapshot, err := database.GetLatestInventorySnapshot(s.db, "customer", customer.ID)
+		if err != nil || snapshot.InventoryLevel != 38 || snapshot.SnapshotReason != "adjustment" {
+			t.Errorf("snapshot = %+v, %v, want 38 for the adjustment", snapshot, err)
+		}
+	})
+
+	t.Run("below zero", func(t *testing.T) {
+		if w := s.do(t, "POST", path, token, InventoryAdjustmentRequest{Delta: -40, Reason: "lost"}); w.Code != http.StatusConflict {
+			t.Errorf("adjustment below zero status = %d, want 409", w.Code)
+		}
+		if stored, _ := database.GetCustomer(s.db, customer.ID); stored.CurrentInventory != 38 {
+			t.Errorf("current inventory = %v, want 38 after the refused adjustment", stored.CurrentInventory)
+		}
+	})
+
+	t.Run("list", func(t *testing.T) {
+		w := s.do(t, "GET", path, token, nil)
+		var list struct{ Data []models.InventoryAdjustment }
+		json.Unmarshal(w.Body.Bytes(), &list)
+		if w.Code != http.StatusOK || len(list.Data) != 1 || list.Data[0].Notes != "Tank dip at the weekly visit" {
+			t.Errorf("adjustments = %d %+v, want the one booked", w.Code, list.Data)
+		}
+	})
+}
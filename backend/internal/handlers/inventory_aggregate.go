@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/hos"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// aggregateDefaultDays is the range aggregated when no from date is given
+	aggregateDefaultDays = 30
+	// aggregateMaxDays is the longest range aggregated at once
+	aggregateMaxDays = 366
+)
+
+// aggregateEntity is what a snapshot's level is compared to
+type aggregateEntity struct {
+	name     string
+	min      float64
+	capacity float64
+}
+
+// GetInventoryHistoryAggregate handles GET /api/v1/inventory/history/aggregate?entity_type=&entity_id=&from=&to=&bucket=day
+// Buckets the snapshots of one customer or warehouse, or of all of them
+// when entity_id is left out, by day or week with the minimum, average and
+// maximum inventory, the days below the minimum and the fill rate. The
+// range defaults to the last 30 days and spans at most 366.
+func (h *Handler) GetInventoryHistoryAggregate(c *gin.Context) {
+	entityType := c.Query("entity_type")
+	if entityType != "customer" && entityType != "warehouse" {
+		errorResponse(c, http.StatusBadRequest, "entity_type must be customer or warehouse")
+		return
+	}
+	bucket := c.DefaultQuery("bucket", "day")
+	if bucket != "day" && bucket != "week" {
+		errorResponse(c, http.StatusBadRequest, "bucket must be day or week")
+		return
+	}
+	entityID, err := parseIDQuery(c, "entity_id")
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	end := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	if to != nil {
+		end = *to
+	}
+	start := end.AddDate(0, 0, 1-aggregateDefaultDays)
+	if from != nil {
+		start = *from
+	}
+	if end.Before(start) {
+		errorResponse(c, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+	if end.Sub(start) >= aggregateMaxDays*24*time.Hour {
+		errorResponse(c, http.StatusBadRequest, "range must not span more than 366 days")
+		return
+	}
+
+	entities, ok := h.aggregateEntities(c, entityType, entityID)
+	if !ok {
+		return
+	}
+	snapshots, err := database.ListInventorySnapshotsByDate(h.db, entityType, entityID, start, end)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch inventory snapshots")
+		return
+	}
+
+	result := &models.InventoryHistoryAggregate{
+		From:     start.Format("2006-01-02"),
+		To:       end.Format("2006-01-02"),
+		Bucket:   bucket,
+		Entities: buildInventoryAggregates(entityType, entities, snapshots, bucket),
+	}
+	if entityID != nil && len(result.Entities) == 0 {
+		e := entities[*entityID]
+		result.Entities = []models.InventoryAggregate{{EntityType: entityType, EntityID: *entityID, Name: e.name, Capacity: e.capacity, Buckets: []models.InventoryBucket{}}}
+	}
+	successResponse(c, result)
+}
+
+// aggregateEntities fetches the customers or warehouses to aggregate, one
+// when id is set, by ID. It writes the error response and returns false
+// when they cannot be fetched.
+func (h *Handler) aggregateEntities(c *gin.Context, entityType string, id *int64) (map[int64]aggregateEntity, bool) {
+	entities := make(map[int64]aggregateEntity)
+	var err error
+	if entityType == "customer" {
+		var customers []models.Customer
+		if id != nil {
+			var customer *models.Customer
+			if customer, err = h.customers.Get(*id); err == nil {
+				customers = append(customers, *customer)
+			}
+		} else {
+			customers, err = database.ListCustomers(h.db)
+		}
+		for _, cu := range customers {
+			entities[cu.ID] = aggregateEntity{name: cu.Name, min: cu.MinInventory, capacity: cu.MaxInventory}
+		}
+	} else {
+		var warehouses []models.Warehouse
+		if id != nil {
+			var warehouse *models.Warehouse
+			if warehouse, err = database.GetWarehouse(h.db, *id); err == nil {
+				warehouses = append(warehouses, *warehouse)
+			}
+		} else {
+			warehouses, err = database.ListWarehouses(h.db)
+		}
+		for _, w := range warehouses {
+			entities[w.ID] = aggregateEntity{name: w.Name, min: w.MinStock, capacity: w.Capacity}
+		}
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Entity")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch "+entityType+"s")
+		return nil, false
+	}
+	return entities, true
+}
+
+// buildInventoryAggregates buckets snapshots, which come by entity and
+// date, for each entity with snapshots. A snapshot is
+// below the minimum it recorded, or the entity's when it recorded none.
+func buildInventoryAggregates(entityType string, entities map[int64]aggregateEntity, snapshots []models.InventorySnapshot, bucket string) []models.InventoryAggregate {
+	result := []models.InventoryAggregate{}
+	for i := 0; i < len(snapshots); {
+		id := snapshots[i].EntityID
+		j := i
+		for j < len(snapshots) && snapshots[j].EntityID == id {
+			j++
+		}
+		entity, ok := entities[id]
+		if !ok {
+			i = j
+			continue
+		}
+
+		total := &inventoryTotals{}
+		buckets := make(map[string]*inventoryTotals)
+		var order []string
+		for _, s := range snapshots[i:j] {
+			date := s.SnapshotDate.UTC()
+			key := date.Format("2006-01-02")
+			if bucket == "week" {
+				key = hos.WeekStart(date).Format("2006-01-02")
+			}
+			if buckets[key] == nil {
+				buckets[key] = &inventoryTotals{}
+				order = append(order, key)
+			}
+			threshold := entity.min
+			if s.MinInventory > 0 {
+				threshold = s.MinInventory
+			}
+			below := s.InventoryLevel < threshold
+			total.add(date, s.InventoryLevel, below)
+			buckets[key].add(date, s.InventoryLevel, below)
+		}
+
+		aggregate := models.InventoryAggregate{
+			EntityType:     entityType,
+			EntityID:       id,
+			Name:           entity.name,
+			Capacity:       entity.capacity,
+			InventoryStats: total.stats(entity.capacity),
+			Buckets:        make([]models.InventoryBucket, len(order)),
+		}
+		for k, key := range order {
+			aggregate.Buckets[k] = models.InventoryBucket{Start: key, InventoryStats: buckets[key].stats(entity.capacity)}
+		}
+		result = append(result, aggregate)
+		i = j
+	}
+	return result
+}
+
+// inventoryTotals accumulates snapshot levels and the days they were taken
+type inventoryTotals struct {
+	count         int
+	min, max, sum float64
+	days          map[time.Time]bool // whether a snapshot of the day was below the minimum
+}
+
+func (t *inventoryTotals) add(date time.Time, level float64, below bool) {
+	if t.count == 0 {
+		t.min, t.max = level, level
+		t.days = make(map[time.Time]bool)
+	}
+	t.count++
+	t.min = math.Min(t.min, level)
+	t.max = math.Max(t.max, level)
+	t.sum += level
+	t.days[date] = t.days[date] || below
+}
+
+func (t *inventoryTotals) stats(capacity float64) models.InventoryStats {
+	stats := models.InventoryStats{
+		Snapshots:    t.count,
+		Min:          t.min,
+		Avg:          math.Round(t.sum/float64(t.count)*100) / 100,
+		Max:          t.max,
+		DaysObserved: len(t.days),
+	}
+	for _, below := range t.days {
+		if below {
+			stats.DaysBelowMin++
+		}
+	}
+	if capacity > 0 {
+		rate := math.Round(t.sum/float64(t.count)/capacity*1000) / 1000
+		stats.FillRate = &rate
+	}
+	return stats
+}
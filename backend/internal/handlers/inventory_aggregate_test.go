@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestInventoryHistoryAggregate tests snapshots bucketed by day and week
+// with their days below the minimum and fill rates
+func TestInventoryHistoryAggregate(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 20, 9, 0, 0, 0, time.UTC)))
+	s.api.GET("/inventory/history/aggregate", s.h.GetInventoryHistoryAggregate)
+
+	token := s.login(t, "manager")
+	// minimum 20, maximum 100
+	customer := s.fx.Customer()
+	s.fx.Customer()
+	for _, snap := range []struct {
+		day   int
+		level float64
+		min   float64
+	}{{4, 50, 0}, {4, 30, 0}, {5, 15, 0}, {11, 60, 70}} {
+		date := time.Date(2024, 3, snap.day, 0, 0, 0, 0, time.UTC)
+		database.CreateInventorySnapshot(s.db, &models.InventorySnapshot{EntityType: "customer", EntityID: customer.ID, SnapshotDate: date, SnapshotTime: date.Add(time.Duration(snap.level) * time.Minute), InventoryLevel: snap.level, MinInventory: snap.min})
+	}
+	get := func(t *testing.T, query string) (int, models.InventoryHistoryAggregate) {
+		t.Helper()
+		var result models.InventoryHistoryAggregate
+		w := s.do(t, "GET", "/api/v1/inventory/history/aggregate?"+query, token, nil)
+		json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{&result})
+		return w.Code, result
+	}
+
+	t.Run("daily", func(t *testing.T) {
+		code, result := get(t, fmt.Sprintf("entity_type=customer&entity_id=%d&from=2024-03-01&to=2024-03-31", customer.ID))
+		if code != http.StatusOK || len(result.Entities) != 1 {
+			t.Fatalf("daily aggregate = %d %+v, want the customer", code, result)
+		}
+		total := result.Entities[0]
+		if total.Snapshots != 4 || total.Min != 15 || total.Max != 60 || total.Avg != 38.75 || total.DaysObserved != 3 || total.DaysBelowMin != 2 || total.FillRate == nil || *total.FillRate != 0.388 {
+			t.Errorf("totals = %+v, want 4 snapshots from 15 to 60, 2 of 3 days below the minimum", total.InventoryStats)
+		}
+		if len(total.Buckets) != 3 || total.Buckets[0].Start != "2024-03-04" || total.Buckets[0].Avg != 40 || total.Buckets[0].DaysBelowMin != 0 || total.Buckets[2].DaysBelowMin != 1 {
+			t.Errorf("daily buckets = %+v, want 4, 5 and 11 March, the last below its recorded minimum", total.Buckets)
+		}
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		code, result := get(t, "entity_type=customer&from=2024-03-01&to=2024-03-31&bucket=week")
+		if code != http.StatusOK || len(result.Entities) != 1 {
+			t.Fatalf("weekly aggregate = %d %+v, want only the customer with snapshots", code, result)
+		}
+		if buckets := result.Entities[0].Buckets; len(buckets) != 2 || buckets[0].Start != "2024-03-04" || buckets[0].Snapshots != 3 || buckets[0].DaysObserved != 2 || buckets[1].Start != "2024-03-11" {
+			t.Errorf("weekly buckets = %+v, want the weeks of 4 and 11 March", buckets)
+		}
+	})
+
+	t.Run("default range", func(t *testing.T) {
+		if code, result := get(t, fmt.Sprintf("entity_type=customer&entity_id=%d", customer.ID)); code != http.StatusOK || result.From != "2024-02-20" || len(result.Entities) != 1 || len(result.Entities[0].Buckets) != 3 {
+			t.Errorf("default range = %d %+v, want the last 30 days", code, result)
+		}
+	})
+
+	t.Run("invalid queries", func(t *testing.T) {
+		for _, query := range []string{"entity_type=route", "entity_type=customer&bucket=month", "entity_type=customer&from=2023-01-01&to=2024-03-01"} {
+			if code, _ := get(t, query); code != http.StatusBadRequest {
+				t.Errorf("%s status = %d, want 400", query, code)
+			}
+		}
+		if code, _ := get(t, "entity_type=warehouse&entity_id=9999"); code != http.StatusNotFound {
+			t.Errorf("unknown warehouse status = %d, want 404", code)
+		}
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/forecast"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Inventory forecast horizon in days
+const (
+	forecastDefaultDays = 14
+	forecastMaxDays     = 90
+)
+
+// GetInventoryForecast handles GET /api/v1/customers/:id/inventory-forecast?days=14
+// Projects the customer's inventory day by day from today, taking its
+// demand rate each day and adding the deliveries planned on routes of
+// optimized, approved and executing plans that are not delivered yet.
+// Inventory does not go below zero: demand that cannot be met is lost.
+func (h *Handler) GetInventoryForecast(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+	days := forecastDefaultDays
+	if raw := c.Query("days"); raw != "" {
+		days, err = strconv.Atoi(raw)
+		if err != nil || days < 1 || days > forecastMaxDays {
+			errorResponse(c, http.StatusBadRequest, fmt.Sprintf("days must be between 1 and %d", forecastMaxDays))
+			return
+		}
+	}
+
+	customer, err := h.customers.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
+		return
+	}
+
+	today := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	stops, err := database.GetPlannedDeliveries(h.db, database.PlannedDeliveryFilter{
+		CustomerID: &id,
+		From:       today,
+		To:         today.AddDate(0, 0, days),
+		Statuses:   forecast.Statuses,
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch planned deliveries")
+		return
+	}
+	successResponse(c, forecast.Customer(customer, stops, today, days))
+}
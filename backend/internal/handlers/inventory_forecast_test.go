@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestInventoryForecast tests projecting a customer's inventory with the
+// deliveries still to come on optimized routes
+func TestInventoryForecast(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.api.GET("/customers/:id/inventory-forecast", s.h.GetInventoryForecast)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+	// 50 on hand, 10 a day, minimum 20
+	customer := s.fx.Customer()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	s.fx.Route(s.fx.Plan(warehouse, start, 5, testkit.WithStatus("optimized")), vehicle, 3, customer)
+	// not counted: a draft plan, and a delivery already made
+	s.fx.Route(s.fx.Plan(warehouse, start, 5), vehicle, 2, customer)
+	done := s.fx.Route(s.fx.Plan(warehouse, start, 5, testkit.WithStatus("executing")), vehicle, 1, customer)
+	execution := &models.RouteExecution{RouteID: done.ID, Status: "in_progress", StopExecutions: []models.StopExecution{{StopID: done.Stops[0].ID, Status: "completed"}}}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("forecast", func(t *testing.T) {
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/customers/%d/inventory-forecast?days=7", customer.ID), token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("forecast status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.InventoryForecast }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		forecast := resp.Data
+		if len(forecast.Days) != 7 || len(forecast.Deliveries) != 1 || forecast.Deliveries[0].Date != "2024-03-06" {
+			t.Fatalf("forecast = %+v, want 7 days and the delivery on 6 March", forecast)
+		}
+		// 50, 40, 30+10, 30, 20, 10, 0
+		if day := forecast.Days[2]; day.Opening != 30 || day.Delivered != 10 || day.Closing != 30 {
+			t.Errorf("6 March = %+v, want 30 on hand, 10 delivered and 30 left", day)
+		}
+		if forecast.BelowMinimumDate == nil || *forecast.BelowMinimumDate != "2024-03-08" {
+			t.Errorf("below minimum date = %v, want 2024-03-08", forecast.BelowMinimumDate)
+		}
+		if forecast.StockoutDate == nil || *forecast.StockoutDate != "2024-03-09" {
+			t.Errorf("stockout date = %v, want 2024-03-09", forecast.StockoutDate)
+		}
+		if last := forecast.Days[6]; last.Opening != 0 || last.Closing != 0 {
+			t.Errorf("last day = %+v, want inventory kept at zero", last)
+		}
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		if w := s.do(t, "GET", fmt.Sprintf("/api/v1/customers/%d/inventory-forecast?days=0", customer.ID), token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("days=0 status = %d, want 400", w.Code)
+		}
+		if w := s.do(t, "GET", "/api/v1/customers/999/inventory-forecast", token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("unknown customer status = %d, want 404", w.Code)
+		}
+	})
+}
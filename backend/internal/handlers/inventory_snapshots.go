@@ -2,12 +2,14 @@ package handlers
 
 import (
 	"errors"
+	"io"
 	"net/http"
 	"strconv"
 	"time"
 
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/snapshots"
 
 	"github.com/gin-gonic/gin"
 )
@@ -27,11 +29,16 @@ type GetInventoryHistoryRequest struct {
 	Days       int    `form:"days" binding:"min=1,max=365"`
 }
 
+type TakeDailySnapshotsRequest struct {
+	// Date defaults to today in the configured snapshot timezone
+	Date string `json:"date"`
+}
+
 // CreateInventorySnapshot handles POST /api/v1/inventory-snapshots
 func (h *Handler) CreateInventorySnapshot(c *gin.Context) {
 	var req CreateInventorySnapshotRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
 
@@ -44,10 +51,10 @@ func (h *Handler) CreateInventorySnapshot(c *gin.Context) {
 	// Get current inventory level based on entity type
 	var inventoryLevel float64
 	if req.EntityType == "customer" {
-		customer, err := database.GetCustomer(h.db, req.EntityID)
+		customer, err := h.customers.Get(req.EntityID)
 		if err != nil {
 			if errors.Is(err, database.ErrNotFound) {
-				errorResponse(c, http.StatusNotFound, "Customer not found")
+				notFoundResponse(c, "Customer")
 				return
 			}
 			errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
@@ -58,7 +65,7 @@ func (h *Handler) CreateInventorySnapshot(c *gin.Context) {
 		warehouse, err := database.GetWarehouse(h.db, req.EntityID)
 		if err != nil {
 			if errors.Is(err, database.ErrNotFound) {
-				errorResponse(c, http.StatusNotFound, "Warehouse not found")
+				notFoundResponse(c, "Warehouse")
 				return
 			}
 			errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
@@ -71,7 +78,7 @@ func (h *Handler) CreateInventorySnapshot(c *gin.Context) {
 		EntityType:     req.EntityType,
 		EntityID:       req.EntityID,
 		SnapshotDate:   snapshotDate,
-		SnapshotTime:   time.Now(),
+		SnapshotTime:   h.clock.Now(),
 		InventoryLevel: inventoryLevel,
 		SnapshotReason: req.SnapshotReason,
 		PlanID:         req.PlanID,
@@ -82,6 +89,7 @@ func (h *Handler) CreateInventorySnapshot(c *gin.Context) {
 		errorResponse(c, http.StatusInternalServerError, "Failed to create inventory snapshot")
 		return
 	}
+	h.queueAlertScan(snapshot.EntityType, snapshot.EntityID)
 
 	createdResponse(c, snapshot)
 }
@@ -90,7 +98,7 @@ func (h *Handler) CreateInventorySnapshot(c *gin.Context) {
 func (h *Handler) GetInventoryHistory(c *gin.Context) {
 	var req GetInventoryHistoryRequest
 	if err := c.ShouldBindQuery(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid query parameters: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
 
@@ -98,7 +106,7 @@ func (h *Handler) GetInventoryHistory(c *gin.Context) {
 		req.Days = 30 // Default to 30 days
 	}
 
-	snapshots, err := database.GetInventoryHistory(h.db, req.EntityType, req.EntityID, req.Days)
+	snapshots, err := database.GetInventoryHistory(h.db, req.EntityType, req.EntityID, req.Days, h.clock.Now())
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch inventory history")
 		return
@@ -159,3 +167,45 @@ func (h *Handler) GetInventorySnapshots(c *gin.Context) {
 
 	successResponse(c, snapshots)
 }
+
+// TakeDailySnapshots handles POST /api/v1/admin/inventory-snapshots/daily
+// Takes the daily snapshots of all customers and warehouses now instead of
+// waiting for the scheduler, e.g. after it was down. A date that already has
+// its daily snapshots is left as it is and reported with created false.
+func (h *Handler) TakeDailySnapshots(c *gin.Context) {
+	var req TakeDailySnapshotsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	now := h.clock.Now()
+	var date time.Time
+	if req.Date != "" {
+		parsed, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+			return
+		}
+		date = parsed
+	} else {
+		location, err := time.LoadLocation(h.config.DailySnapshotTimezone)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Invalid daily snapshot timezone")
+			return
+		}
+		local := now.In(location)
+		date = time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	result, err := snapshots.Take(h.db, date, now)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to take inventory snapshots")
+		return
+	}
+	if result.Created {
+		createdResponse(c, result)
+		return
+	}
+	successResponse(c, result)
+}
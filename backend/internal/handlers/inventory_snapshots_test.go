@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestTakeDailySnapshots tests admins taking the daily snapshots by hand,
+// by default for today in the configured timezone, once per date
+func TestTakeDailySnapshots(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.DailySnapshotTimezone = "America/New_York" })
+	// still 3 March in New York
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 2, 0, 0, 0, time.UTC)))
+	s.api.POST("/admin/inventory-snapshots/daily", s.h.AdminMiddleware(), s.h.TakeDailySnapshots)
+
+	token := s.login(t, "admin")
+	s.fx.Warehouse()
+	s.fx.Customer()
+
+	take := func(t *testing.T, token string, body any) (int, models.DailySnapshots) {
+		t.Helper()
+		w := s.do(t, "POST", "/api/v1/admin/inventory-snapshots/daily", token, body)
+		var resp struct{ Data models.DailySnapshots }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	t.Run("admin only", func(t *testing.T) {
+		if code, _ := take(t, s.login(t, "manager"), nil); code != http.StatusForbidden {
+			t.Errorf("manager status = %d, want 403", code)
+		}
+	})
+
+	t.Run("today once", func(t *testing.T) {
+		code, result := take(t, token, nil)
+		if code != http.StatusCreated || result.Date != "2024-03-03" || result.Snapshots != 2 {
+			t.Errorf("take = %d %+v, want 2 snapshots for 2024-03-03", code, result)
+		}
+		if code, result := take(t, token, nil); code != http.StatusOK || result.Created {
+			t.Errorf("second take = %d %+v, want the date skipped", code, result)
+		}
+	})
+
+	t.Run("given date", func(t *testing.T) {
+		if code, result := take(t, token, TakeDailySnapshotsRequest{Date: "2024-03-04"}); code != http.StatusCreated || result.Date != "2024-03-04" {
+			t.Errorf("take for a date = %d %+v, want snapshots for 2024-03-04", code, result)
+		}
+		if code, _ := take(t, token, TakeDailySnapshotsRequest{Date: "4 March"}); code != http.StatusBadRequest {
+			t.Errorf("invalid date status = %d, want 400", code)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultJobListLimit = 50
+	maxJobListLimit     = 500
+)
+
+// ListJobs handles GET /api/v1/admin/jobs?status=&type=&limit=
+// status=dead lists the dead-letter queue.
+func (h *Handler) ListJobs(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", jobs.Pending, jobs.Running, jobs.Succeeded, jobs.Dead, jobs.Cancelled:
+	default:
+		errorResponse(c, http.StatusBadRequest, "status must be pending, running, succeeded, dead or cancelled")
+		return
+	}
+
+	limit := defaultJobListLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxJobListLimit {
+			errorResponse(c, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxJobListLimit))
+			return
+		}
+		limit = n
+	}
+
+	list, err := database.ListJobs(h.db, status, c.Query("type"), limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch jobs")
+		return
+	}
+	if list == nil {
+		list = []models.Job{}
+	}
+	successResponse(c, list)
+}
+
+// GetJob handles GET /api/v1/admin/jobs/:id
+func (h *Handler) GetJob(c *gin.Context) {
+	job, ok := h.fetchJob(c)
+	if !ok {
+		return
+	}
+	successResponse(c, job)
+}
+
+// RetryJob handles POST /api/v1/admin/jobs/:id/retry
+// Queues a dead or cancelled job again with a fresh set of attempts.
+func (h *Handler) RetryJob(c *gin.Context) {
+	job, ok := h.fetchJob(c)
+	if !ok {
+		return
+	}
+	if !jobs.Retryable(job.Status) {
+		errorResponse(c, http.StatusConflict, fmt.Sprintf("Cannot retry a %s job", job.Status))
+		return
+	}
+	h.updateJob(c, job.ID, database.RetryJob(h.db, job.ID, h.clock.Now()))
+}
+
+// CancelJob handles POST /api/v1/admin/jobs/:id/cancel
+// Only pending jobs can be cancelled; running jobs finish their attempt.
+func (h *Handler) CancelJob(c *gin.Context) {
+	job, ok := h.fetchJob(c)
+	if !ok {
+		return
+	}
+	if job.Status != jobs.Pending {
+		errorResponse(c, http.StatusConflict, fmt.Sprintf("Cannot cancel a %s job", job.Status))
+		return
+	}
+	h.updateJob(c, job.ID, database.CancelJob(h.db, job.ID, h.clock.Now()))
+}
+
+func (h *Handler) fetchJob(c *gin.Context) (*models.Job, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid job ID")
+		return nil, false
+	}
+
+	job, err := database.GetJob(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Job")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch job")
+		return nil, false
+	}
+	return job, true
+}
+
+// updateJob responds with the job after a status change, or 409 when the
+// runner changed its status first
+func (h *Handler) updateJob(c *gin.Context, id int64, err error) {
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusConflict, "Job status changed concurrently, retry")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update job")
+		return
+	}
+
+	job, err := database.GetJob(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated job")
+		return
+	}
+	successResponse(c, job)
+}
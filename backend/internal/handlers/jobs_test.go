@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestJobManagement tests listing the dead-letter queue and retrying and
+// cancelling jobs
+func TestJobManagement(t *testing.T) {
+	s := newTestServer(t)
+	admin := s.api.Group("/admin", s.h.AdminMiddleware())
+	admin.GET("/jobs", s.h.ListJobs)
+	admin.POST("/jobs/:id/retry", s.h.RetryJob)
+	admin.POST("/jobs/:id/cancel", s.h.CancelJob)
+
+	token := s.login(t, "admin")
+	dead, _ := jobs.Enqueue(s.db, "report", nil, jobs.Options{})
+	s.db.Model(&models.Job{}).Where("id = ?", dead.ID).Updates(map[string]interface{}{"status": jobs.Dead, "attempts": 5, "last_error": "timeout"})
+	pending, _ := jobs.Enqueue(s.db, "report", nil, jobs.Options{RunAt: time.Now().Add(time.Hour)})
+
+	t.Run("list", func(t *testing.T) {
+		w := s.do(t, "GET", "/api/v1/admin/jobs?status=dead", token, nil)
+		var list struct {
+			Data []models.Job
+		}
+		json.Unmarshal(w.Body.Bytes(), &list)
+		if w.Code != http.StatusOK || len(list.Data) != 1 || list.Data[0].ID != dead.ID {
+			t.Fatalf("ListJobs(status=dead) status = %d, body = %s", w.Code, w.Body.String())
+		}
+		if w := s.do(t, "GET", "/api/v1/admin/jobs?status=broken", token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("ListJobs(status=broken) status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("retry", func(t *testing.T) {
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/admin/jobs/%d/retry", dead.ID), token, nil); w.Code != http.StatusOK {
+			t.Fatalf("RetryJob() status = %d, body = %s", w.Code, w.Body.String())
+		}
+		if job, _ := database.GetJob(s.db, dead.ID); job.Status != jobs.Pending || job.Attempts != 0 {
+			t.Errorf("retried job = %+v, want pending with no attempts", job)
+		}
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/admin/jobs/%d/retry", pending.ID), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("RetryJob() on a pending job status = %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/admin/jobs/%d/cancel", pending.ID), token, nil); w.Code != http.StatusOK {
+			t.Fatalf("CancelJob() status = %d, body = %s", w.Code, w.Body.String())
+		}
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/admin/jobs/%d/cancel", pending.ID), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("CancelJob() twice status = %d, want %d", w.Code, http.StatusConflict)
+		}
+		if w := s.do(t, "POST", "/api/v1/admin/jobs/999/cancel", token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("CancelJob() on a missing job status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
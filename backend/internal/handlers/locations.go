@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxTrackSpeedKmh is the speed above which a ping is taken for a GPS jump
+// and left out of the track distance
+const maxTrackSpeedKmh = 200.0
+
+type LocationPingRequest struct {
+	Latitude  float64   `json:"lat" binding:"gte=-90,lte=90"`
+	Longitude float64   `json:"lon" binding:"gte=-180,lte=180"`
+	Timestamp time.Time `json:"timestamp" binding:"required"`
+	Speed     *float64  `json:"speed" binding:"omitempty,gte=0"`
+}
+
+type RecordLocationsRequest struct {
+	Pings []LocationPingRequest `json:"pings" binding:"required,min=1,max=1000,dive"`
+}
+
+// RecordLocations handles POST /api/v1/executions/:id/locations
+// Takes a batch of GPS pings (lat, lon, timestamp, optional speed in km/h)
+// and recomputes the execution's actual distance from its whole track.
+// Stops of a pending or running execution are marked arrived and departed
+// as the track enters and leaves their customers (see detectStopVisits).
+// Pings repeating a timestamp already stored are skipped, so a batch can be
+// uploaded again after a failure. Cancelled executions take no pings.
+func (h *Handler) RecordLocations(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
+		return
+	}
+	var req RecordLocationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	if !h.canReportExecution(c, execution) {
+		return
+	}
+	if execution.Status == "cancelled" {
+		errorResponse(c, http.StatusConflict, "Route execution is cancelled")
+		return
+	}
+
+	pings := make([]models.LocationPing, len(req.Pings))
+	for i, p := range req.Pings {
+		pings[i] = models.LocationPing{
+			RouteExecutionID: id,
+			Latitude:         p.Latitude,
+			Longitude:        p.Longitude,
+			Speed:            p.Speed,
+			RecordedAt:       p.Timestamp,
+		}
+	}
+	stored, err := database.CreateLocationPings(h.db, pings)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to store locations")
+		return
+	}
+
+	result := models.LocationIngest{Received: len(pings), Stored: stored, ActualDistance: execution.ActualDistance}
+	track, err := database.GetLocationTrack(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch track")
+		return
+	}
+	if len(track) >= 2 {
+		result.ActualDistance = trackDistanceKm(track)
+		if err := database.SetExecutionActualDistance(h.db, id, result.ActualDistance); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to update route execution")
+			return
+		}
+	}
+	if stored > 0 {
+		if result.Arrivals, result.Departures, err = h.applyGeofences(execution, track); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to update stop executions")
+			return
+		}
+	}
+	if stored > 0 {
+		h.publishLocation(execution, latestPing(pings))
+		h.publishExecutionChange(execution)
+	}
+	successResponse(c, result)
+}
+
+// latestPing returns the most recently recorded of a batch of pings
+func latestPing(pings []models.LocationPing) models.LocationPing {
+	latest := pings[0]
+	for _, p := range pings[1:] {
+		if p.RecordedAt.After(latest.RecordedAt) {
+			latest = p
+		}
+	}
+	return latest
+}
+
+// trackDistance returns the km driven along a route execution's GPS track,
+// and false when it has fewer than two pings to measure
+func (h *Handler) trackDistance(executionID int64) (float64, bool, error) {
+	track, err := database.GetLocationTrack(h.db, executionID)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(track) < 2 {
+		return 0, false, nil
+	}
+	return trackDistanceKm(track), true, nil
+}
+
+// trackDistanceKm sums the straight-line distances between consecutive
+// pings in recorded order. A ping that could only be reached faster than
+// maxTrackSpeedKmh from the previous one is a GPS jump and is skipped.
+func trackDistanceKm(track []models.LocationPing) float64 {
+	var total float64
+	last := track[0]
+	for _, p := range track[1:] {
+		km := optimizer.HaversineKm(last.Latitude, last.Longitude, p.Latitude, p.Longitude)
+		hours := p.RecordedAt.Sub(last.RecordedAt).Hours()
+		if hours <= 0 || km/hours > maxTrackSpeedKmh {
+			continue
+		}
+		total += km
+		last = p
+	}
+	return math.Round(total*1000) / 1000
+}
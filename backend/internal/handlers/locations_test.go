@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestRecordLocations tests GPS ping ingestion, skipping repeated pings and
+// jumps, and the actual distance taken from the track
+func TestRecordLocations(t *testing.T) {
+	s := newTestServer(t)
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.api.POST("/executions/:id/locations", s.h.RecordLocations)
+	s.api.POST("/executions/:id/complete", s.h.CompleteRouteExecution)
+
+	driverUser := s.fx.User("driver")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer())
+	driver := &models.Driver{Name: "Dana", UserID: &driverUser.ID}
+	if err := database.CreateDriver(s.db, driver); err != nil {
+		t.Fatal(err)
+	}
+	s.db.Model(route).Update("driver_id", driver.ID)
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "in_progress"}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+
+	token := e2eLogin(t, s.router, driverUser).Token
+	locations := fmt.Sprintf("/api/v1/executions/%d/locations", execution.ID)
+	start := clk.Now()
+	// one ping a minute heading north, ~1.1 km apart, with a GPS jump
+	batch := RecordLocationsRequest{}
+	for i := 0; i < 4; i++ {
+		batch.Pings = append(batch.Pings, LocationPingRequest{Latitude: 52 + 0.01*float64(i), Longitude: 13, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	batch.Pings = append(batch.Pings, LocationPingRequest{Latitude: 53, Longitude: 13, Timestamp: start.Add(90 * time.Second)})
+	want := math.Round(optimizer.HaversineKm(52, 13, 52.03, 13)*1000) / 1000
+	record := func(t *testing.T, token string, req RecordLocationsRequest) (int, models.LocationIngest) {
+		t.Helper()
+		w := s.do(t, "POST", locations, token, req)
+		var resp struct{ Data models.LocationIngest }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	t.Run("rejected", func(t *testing.T) {
+		if code, _ := record(t, s.login(t, "driver"), batch); code != http.StatusForbidden {
+			t.Errorf("other driver status = %d, want 403", code)
+		}
+		if code, _ := record(t, token, RecordLocationsRequest{Pings: []LocationPingRequest{{Latitude: 91, Timestamp: start}}}); code != http.StatusBadRequest {
+			t.Errorf("invalid latitude status = %d, want 400", code)
+		}
+	})
+
+	t.Run("record", func(t *testing.T) {
+		code, got := record(t, token, batch)
+		if code != http.StatusOK || got.Received != 5 || got.Stored != 5 || math.Abs(got.ActualDistance-want) > 0.001 {
+			t.Fatalf("record = %d %+v, want 5 stored and %.3f km without the jump", code, got, want)
+		}
+		if code, got := record(t, token, batch); code != http.StatusOK || got.Stored != 0 || math.Abs(got.ActualDistance-want) > 0.001 {
+			t.Errorf("repeated batch = %d %+v, want nothing stored and the same distance", code, got)
+		}
+	})
+
+	t.Run("complete", func(t *testing.T) {
+		w := s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/complete", execution.ID), token, CompleteRouteExecutionRequest{ActualDistance: 99})
+		if w.Code != http.StatusOK {
+			t.Fatalf("complete status = %d: %s", w.Code, w.Body.String())
+		}
+		stored, err := s.h.executions.Get(execution.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(stored.ActualDistance-want) > 0.001 {
+			t.Errorf("completed distance = %v, want the tracked %.3f km over the reported 99", stored.ActualDistance, want)
+		}
+	})
+}
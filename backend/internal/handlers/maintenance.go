@@ -0,0 +1,229 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultRetryAfter is the Retry-After of rejected mutations when none is
+// configured
+const defaultRetryAfter = 300
+
+// outboxLimit is how many submissions are queued during maintenance before
+// further ones are rejected like other mutations
+const outboxLimit = 10000
+
+// outboxRoutes are the driver execution submissions queued during
+// maintenance instead of being rejected
+var outboxRoutes = map[string]bool{
+	"POST /api/v1/executions/:id/start":                   true,
+	"POST /api/v1/executions/:id/complete":                true,
+	"PUT /api/v1/executions/:id/stops/:stop_execution_id": true,
+	"POST /api/v1/executions/:id/locations":               true,
+	"PUT /api/v1/executions/:id/shift":                    true,
+	"POST /api/v1/executions/:id/incidents":               true,
+	"POST /api/v1/driver/stops/:id/events":                true,
+	"POST /api/v1/stop-executions/:id/pod":                true,
+}
+
+// maintenanceExempt are the mutations still served during maintenance
+var maintenanceExempt = map[string]bool{
+	"PUT /api/v1/admin/maintenance": true,
+}
+
+// maintenance is the read-only mode toggle and the outbox of submissions
+// received while it is on. It is kept per instance.
+type maintenance struct {
+	mu         sync.Mutex
+	enabled    bool
+	since      time.Time
+	message    string
+	retryAfter int
+	outbox     []outboxEntry
+	// replay serves the queued submissions when maintenance ends
+	replay http.Handler
+}
+
+// outboxEntry is a submission queued during maintenance
+type outboxEntry struct {
+	method string
+	path   string
+	header http.Header
+	body   []byte
+}
+
+// InMaintenance reports whether read-only mode is on
+func (h *Handler) InMaintenance() bool {
+	h.maintenance.mu.Lock()
+	defer h.maintenance.mu.Unlock()
+	return h.maintenance.enabled
+}
+
+// SetReplayHandler sets the handler submissions queued during maintenance
+// are replayed through when it ends, normally the router
+func (h *Handler) SetReplayHandler(handler http.Handler) {
+	h.maintenance.mu.Lock()
+	defer h.maintenance.mu.Unlock()
+	h.maintenance.replay = handler
+}
+
+// MaintenanceMiddleware rejects mutations with 503 and Retry-After while
+// read-only mode is on. Driver execution submissions are queued instead
+// (202) and replayed in order when it is turned off. Reads go through.
+func (h *Handler) MaintenanceMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		method := c.Request.Method
+		if method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions {
+			c.Next()
+			return
+		}
+		route := method + " " + c.FullPath()
+		m := h.maintenance
+		m.mu.Lock()
+		if !m.enabled || maintenanceExempt[route] {
+			m.mu.Unlock()
+			c.Next()
+			return
+		}
+		retryAfter, message := m.retryAfter, m.message
+		if outboxRoutes[route] && len(m.outbox) < outboxLimit {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				m.mu.Unlock()
+				errorResponse(c, http.StatusBadRequest, "Failed to read request body")
+				c.Abort()
+				return
+			}
+			m.outbox = append(m.outbox, outboxEntry{
+				method: method,
+				path:   c.Request.URL.RequestURI(),
+				header: c.Request.Header.Clone(),
+				body:   body,
+			})
+			position := len(m.outbox)
+			m.mu.Unlock()
+			c.AbortWithStatusJSON(http.StatusAccepted, gin.H{
+				"success": true,
+				"data":    gin.H{"queued": true, "position": position},
+			})
+			return
+		}
+		m.mu.Unlock()
+
+		if message == "" {
+			message = "The service is read-only for maintenance"
+		}
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		errorCodeResponse(c, http.StatusServiceUnavailable, codeMaintenance, message)
+		c.Abort()
+	}
+}
+
+type MaintenanceRequest struct {
+	Enabled           bool   `json:"enabled"`
+	Message           string `json:"message"`
+	RetryAfterSeconds int    `json:"retry_after_seconds" binding:"gte=0"`
+}
+
+// GetMaintenance handles GET /api/v1/admin/maintenance
+func (h *Handler) GetMaintenance(c *gin.Context) {
+	successResponse(c, h.maintenanceStatus())
+}
+
+// SetMaintenance handles PUT /api/v1/admin/maintenance
+// Turns read-only mode on or off on this instance. Turning it off replays
+// the queued driver submissions in the order they were received, with the
+// credentials they were sent with, and reports how many failed.
+func (h *Handler) SetMaintenance(c *gin.Context) {
+	var req MaintenanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	m := h.maintenance
+	m.mu.Lock()
+	if req.Enabled {
+		if !m.enabled {
+			m.since = h.clock.Now()
+		}
+		m.enabled = true
+		m.message = req.Message
+		if req.RetryAfterSeconds > 0 {
+			m.retryAfter = req.RetryAfterSeconds
+		}
+		m.mu.Unlock()
+		successResponse(c, h.maintenanceStatus())
+		return
+	}
+	m.enabled = false
+	m.message = ""
+	queued, replay := m.outbox, m.replay
+	m.outbox = nil
+	m.mu.Unlock()
+
+	replayed, failed := 0, 0
+	for _, entry := range queued {
+		if replay == nil {
+			failed++
+			continue
+		}
+		if status := replayEntry(replay, entry); status >= http.StatusBadRequest {
+			log.Printf("WARNING: queued %s %s failed on replay with status %d", entry.method, entry.path, status)
+			failed++
+			continue
+		}
+		replayed++
+	}
+	status := h.maintenanceStatus()
+	status.Replayed, status.ReplayFailed = replayed, failed
+	successResponse(c, status)
+}
+
+func (h *Handler) maintenanceStatus() models.MaintenanceStatus {
+	m := h.maintenance
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := models.MaintenanceStatus{
+		Enabled:           m.enabled,
+		Message:           m.message,
+		RetryAfterSeconds: m.retryAfter,
+		Queued:            len(m.outbox),
+	}
+	if m.enabled {
+		since := m.since
+		status.Since = &since
+	}
+	return status
+}
+
+// replayEntry serves a queued submission and returns its status code
+func replayEntry(handler http.Handler, entry outboxEntry) int {
+	req, err := http.NewRequest(entry.method, entry.path, bytes.NewReader(entry.body))
+	if err != nil {
+		return http.StatusBadRequest
+	}
+	req.Header = entry.header
+	w := &replayWriter{header: make(http.Header), status: http.StatusOK}
+	handler.ServeHTTP(w, req)
+	return w.status
+}
+
+// replayWriter discards a replayed response, keeping its status code
+type replayWriter struct {
+	header http.Header
+	status int
+}
+
+func (w *replayWriter) Header() http.Header         { return w.header }
+func (w *replayWriter) Write(b []byte) (int, error) { return len(b), nil }
+func (w *replayWriter) WriteHeader(status int)      { w.status = status }
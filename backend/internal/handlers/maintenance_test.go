@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestMaintenanceMode tests that read-only mode rejects mutations, serves
+// reads and queues driver submissions until it is turned off
+func TestMaintenanceMode(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.MaintenanceRetryAfter = 120 })
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	api := s.api.Group("", s.h.UsageMiddleware(), s.h.MaintenanceMiddleware())
+	api.GET("/customers", s.h.ListCustomers)
+	api.POST("/customers", s.h.CreateCustomer)
+	api.PUT("/executions/:id/stops/:stop_execution_id", s.h.UpdateStopExecution)
+	api.GET("/admin/maintenance", s.h.AdminMiddleware(), s.h.GetMaintenance)
+	api.PUT("/admin/maintenance", s.h.AdminMiddleware(), s.h.SetMaintenance)
+	s.h.SetReplayHandler(s.router)
+
+	admin := s.login(t, "admin")
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer())
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "in_progress",
+		StopExecutions: []models.StopExecution{{StopID: route.Stops[0].ID, Status: "pending", PlannedQuantity: 10}}}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+
+	type response struct{ Data models.MaintenanceStatus }
+	toggle := func(t *testing.T, req MaintenanceRequest) models.MaintenanceStatus {
+		t.Helper()
+		w := s.do(t, "PUT", "/api/v1/admin/maintenance", admin, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("toggle status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var resp response
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+
+	stopPath := fmt.Sprintf("/api/v1/executions/%d/stops/%d", execution.ID, execution.StopExecutions[0].ID)
+	for _, step := range []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"enable", func(t *testing.T) {
+			if status := toggle(t, MaintenanceRequest{Enabled: true, Message: "Database upgrade"}); !status.Enabled || status.Since == nil {
+				t.Fatalf("status = %+v, want enabled", status)
+			}
+		}},
+		{"read only", func(t *testing.T) {
+			w := s.do(t, "POST", "/api/v1/customers", token, CustomerRequest{Name: "New", Latitude: 52, Longitude: 4})
+			if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") != "120" {
+				t.Errorf("mutation status = %d with Retry-After %q, want 503 after 120", w.Code, w.Header().Get("Retry-After"))
+			}
+			if w := s.do(t, "GET", "/api/v1/customers", token, nil); w.Code != http.StatusOK {
+				t.Errorf("read status = %d, want 200", w.Code)
+			}
+		}},
+		{"driver submission queued", func(t *testing.T) {
+			if w := s.do(t, "PUT", stopPath, token, UpdateStopExecutionRequest{Status: "completed"}); w.Code != http.StatusAccepted {
+				t.Fatalf("driver submission status = %d, want 202: %s", w.Code, w.Body.String())
+			}
+			if stored, _ := database.GetRouteExecution(s.db, execution.ID); stored.StopExecutions[0].Status != "pending" {
+				t.Errorf("stop during maintenance = %s, want pending until replayed", stored.StopExecutions[0].Status)
+			}
+			w := s.do(t, "GET", "/api/v1/admin/maintenance", admin, nil)
+			var resp response
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if resp.Data.Queued != 1 || resp.Data.Message != "Database upgrade" {
+				t.Errorf("status = %+v, want one queued submission", resp.Data)
+			}
+		}},
+		{"disable replays", func(t *testing.T) {
+			if status := toggle(t, MaintenanceRequest{Enabled: false}); status.Enabled || status.Replayed != 1 || status.ReplayFailed != 0 || status.Queued != 0 {
+				t.Errorf("status after maintenance = %+v, want the submission replayed", status)
+			}
+			if stored, _ := database.GetRouteExecution(s.db, execution.ID); stored.StopExecutions[0].Status != "completed" {
+				t.Errorf("stop after maintenance = %s, want completed", stored.StopExecutions[0].Status)
+			}
+			if w := s.do(t, "POST", "/api/v1/customers", token, CustomerRequest{Name: "New", Latitude: 52, Longitude: 4}); w.Code != http.StatusCreated {
+				t.Errorf("mutation after maintenance status = %d, want 201", w.Code)
+			}
+		}},
+	} {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// onboardingCustomerTarget is how many customers make a useful first plan
+const onboardingCustomerTarget = 5
+
+// GetOnboarding handles GET /api/v1/onboarding
+// The checklist is computed from the stored data, so steps complete by
+// themselves as the setup is done.
+func (h *Handler) GetOnboarding(c *gin.Context) {
+	counts := []struct {
+		key, title string
+		target     int
+		count      func() (int, error)
+	}{
+		{"warehouse", "Create a warehouse", 1, func() (int, error) { return database.CountWarehouses(h.db) }},
+		{"vehicle", "Add a vehicle", 1, func() (int, error) { return database.CountVehicles(h.db) }},
+		{"customers", "Add at least 5 customers", onboardingCustomerTarget, func() (int, error) { return database.CountCustomers(h.db) }},
+		{"plan_optimized", "Optimize your first plan", 1, func() (int, error) { return database.CountOptimizedPlans(h.db) }},
+		{"execution_completed", "Complete your first route execution", 1, func() (int, error) { return database.CountCompletedExecutions(h.db) }},
+	}
+
+	onboarding := &models.Onboarding{Steps: make([]models.OnboardingStep, 0, len(counts))}
+	for _, step := range counts {
+		n, err := step.count()
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to compute onboarding status")
+			return
+		}
+		done := n >= step.target
+		if n > step.target {
+			n = step.target
+		}
+		onboarding.Steps = append(onboarding.Steps, models.OnboardingStep{
+			Key:       step.key,
+			Title:     step.title,
+			Completed: done,
+			Current:   n,
+			Target:    step.target,
+		})
+		if done {
+			onboarding.CompletedSteps++
+		} else if onboarding.NextStep == "" {
+			onboarding.NextStep = step.key
+		}
+	}
+	onboarding.TotalSteps = len(onboarding.Steps)
+	onboarding.Complete = onboarding.CompletedSteps == onboarding.TotalSteps
+
+	successResponse(c, onboarding)
+}
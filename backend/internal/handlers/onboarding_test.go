@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestGetOnboarding tests that setup steps complete as data is added
+func TestGetOnboarding(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/onboarding", s.h.GetOnboarding)
+
+	token := s.login(t, "admin")
+	get := func(t *testing.T) models.Onboarding {
+		t.Helper()
+		w := s.do(t, "GET", "/api/v1/onboarding", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetOnboarding() status = %d, body = %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data models.Onboarding
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+
+	var warehouse *models.Warehouse
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"empty installation", func(t *testing.T) {
+			got := get(t)
+			if got.CompletedSteps != 0 || got.TotalSteps != 5 || got.NextStep != "warehouse" || got.Complete {
+				t.Errorf("empty installation onboarding = %+v, want nothing done and warehouse next", got)
+			}
+		}},
+		{"some customers", func(t *testing.T) {
+			warehouse = &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+			database.CreateWarehouse(s.db, warehouse)
+			database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+			for i := 0; i < 3; i++ {
+				database.CreateCustomer(s.db, &models.Customer{Name: "Customer " + strconv.Itoa(i), Latitude: 40.7, Longitude: -74.0})
+			}
+
+			got := get(t)
+			customers := got.Steps[2]
+			if got.CompletedSteps != 2 || got.NextStep != "customers" || customers.Current != 3 || customers.Target != 5 || customers.Completed {
+				t.Errorf("onboarding = %+v, want 2 steps done and customers at 3 of 5", got)
+			}
+		}},
+		{"complete", func(t *testing.T) {
+			for i := 3; i < 6; i++ {
+				database.CreateCustomer(s.db, &models.Customer{Name: "Customer " + strconv.Itoa(i), Latitude: 40.7, Longitude: -74.0})
+			}
+			start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start, Status: "approved", WarehouseID: &warehouse.ID}
+			database.CreatePlan(s.db, plan)
+			route := &models.Route{PlanID: plan.ID, Day: 1, Date: start}
+			database.CreateRoute(s.db, route)
+			s.db.Create(&models.RouteExecution{RouteID: route.ID, Status: "completed"})
+
+			got := get(t)
+			if !got.Complete || got.CompletedSteps != 5 || got.NextStep != "" || got.Steps[2].Current != 5 {
+				t.Errorf("onboarding = %+v, want all steps complete with customers capped at 5", got)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"sync"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/openapi"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIBasePath is the path the API's operations are below
+const APIBasePath = "/api/v1"
+
+// Operations documents every endpoint below APIBasePath. A route registered
+// without an entry here, or an entry without a route, fails the router's
+// tests, so add both together.
+var Operations = []openapi.Operation{
+	{Method: http.MethodGet, Path: "/openapi.json", Summary: "Get the OpenAPI document", Public: true, ContentType: "application/json"},
+	{Method: http.MethodGet, Path: "/docs", Summary: "Browse the API with Swagger UI", Public: true, ContentType: "text/html"},
+	{Method: http.MethodPost, Path: "/auth/register", Summary: "Register", Public: true, Body: RegisterRequest{}, Response: AuthResponse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/auth/login", Summary: "Login", Public: true, Body: LoginRequest{}, Response: AuthResponse{}},
+	{Method: http.MethodPost, Path: "/auth/refresh", Summary: "Refresh token", Public: true, Response: AuthResponse{}},
+	{Method: http.MethodGet, Path: "/files/*key", Summary: "Download a signed artifact", Public: true, Query: []string{"expires", "signature"}, ContentType: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/me", Summary: "Get the current user", Response: models.User{}},
+	{Method: http.MethodPut, Path: "/me/password", Summary: "Change password", Body: ChangePasswordRequest{}, Response: AuthResponse{}},
+	{Method: http.MethodPost, Path: "/me/revoke-tokens", Summary: "Revoke tokens"},
+	{Method: http.MethodGet, Path: "/usage", Summary: "Get usage against quotas"},
+	{Method: http.MethodGet, Path: "/onboarding", Summary: "Get the onboarding checklist", Response: models.Onboarding{}},
+	{Method: http.MethodGet, Path: "/warehouses", Summary: "List warehouses", Response: []models.Warehouse{}},
+	{Method: http.MethodPost, Path: "/warehouses", Summary: "Create warehouse", Body: WarehouseRequest{}, Response: models.Warehouse{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/warehouses/import", Summary: "Import warehouses", Files: []string{"file"}, Fields: []string{"format", "dry_run", "mapping"}, Response: ImportResult{}},
+	{Method: http.MethodGet, Path: "/warehouses/by-ref/:ref", Summary: "Get warehouse by ref", Response: models.Warehouse{}},
+	{Method: http.MethodPut, Path: "/warehouses/by-ref/:ref", Summary: "Upsert warehouse", Body: WarehouseRequest{}, Response: models.Warehouse{}},
+	{Method: http.MethodGet, Path: "/warehouses/:id", Summary: "Get warehouse", Response: models.Warehouse{}},
+	{Method: http.MethodPut, Path: "/warehouses/:id", Summary: "Update warehouse", Body: WarehouseRequest{}, Response: models.Warehouse{}},
+	{Method: http.MethodPatch, Path: "/warehouses/:id", Summary: "Patch warehouse", Body: WarehousePatch{}, Response: models.Warehouse{}},
+	{Method: http.MethodDelete, Path: "/warehouses/:id", Summary: "Delete warehouse"},
+	{Method: http.MethodGet, Path: "/warehouses/:id/day", Summary: "Get warehouse day", Query: []string{"date"}, Response: models.WarehouseDayView{}},
+	{Method: http.MethodGet, Path: "/warehouses/:id/stock", Summary: "Get warehouse stock", Response: models.WarehouseStock{}},
+	{Method: http.MethodGet, Path: "/warehouses/:id/stock/movements", Summary: "List stock movements", Query: []string{"product_id", "kind", "from", "to", "page", "limit"}, Response: []models.StockMovement{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/warehouses/:id/stock/receipts", Summary: "Receive stock", Body: StockReceiptRequest{}, Response: models.StockMovement{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/warehouses/:id/stock/adjustments", Summary: "Adjust stock", Body: StockAdjustmentRequest{}, Response: models.StockMovement{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/warehouses/:id/stock/transfers", Summary: "Transfer stock", Body: StockTransferRequest{}, Response: []models.StockMovement{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/stock-transfers", Summary: "List stock transfers", Query: []string{"warehouse_id", "status", "page", "limit"}, Response: []models.StockTransfer{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/stock-transfers", Summary: "Create stock transfer", Body: CreateStockTransferRequest{}, Response: models.StockTransfer{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/stock-transfers/:id", Summary: "Get stock transfer", Response: models.StockTransfer{}},
+	{Method: http.MethodPost, Path: "/stock-transfers/:id/ship", Summary: "Ship stock transfer", Response: models.StockTransfer{}},
+	{Method: http.MethodPost, Path: "/stock-transfers/:id/receive", Summary: "Receive stock transfer", Response: models.StockTransfer{}},
+	{Method: http.MethodPost, Path: "/stock-transfers/:id/cancel", Summary: "Cancel stock transfer", Response: models.StockTransfer{}},
+	{Method: http.MethodGet, Path: "/stocktakes", Summary: "List stocktakes", Query: []string{"entity_type", "entity_id", "page", "limit"}, Response: []models.Stocktake{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/stocktakes", Summary: "Create stocktake", Body: StocktakeRequest{}, Response: models.Stocktake{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/stocktakes/:id", Summary: "Get stocktake", Response: models.Stocktake{}},
+	{Method: http.MethodGet, Path: "/customers", Summary: "List customers", Query: []string{"page", "limit", "q", "min_priority", "max_priority", "bbox", "tag", "sort"}, Response: []models.Customer{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/customers", Summary: "Create customer", Body: CustomerRequest{}, Response: models.Customer{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/customers/bulk-delete", Summary: "Bulk delete customers", Body: BulkDeleteRequest{}},
+	{Method: http.MethodPost, Path: "/customers/bulk-update", Summary: "Bulk update customers", Body: BulkCustomerUpdateRequest{}},
+	{Method: http.MethodPost, Path: "/customers/import", Summary: "Import customers", Files: []string{"file"}, Fields: []string{"format", "dry_run", "mapping"}, Response: ImportResult{}},
+	{Method: http.MethodGet, Path: "/customers/by-ref/:ref", Summary: "Get customer by ref", Response: models.Customer{}},
+	{Method: http.MethodPut, Path: "/customers/by-ref/:ref", Summary: "Upsert customer", Body: CustomerRequest{}, Response: models.Customer{}},
+	{Method: http.MethodGet, Path: "/customers/:id", Summary: "Get customer", Response: models.Customer{}},
+	{Method: http.MethodPut, Path: "/customers/:id", Summary: "Update customer", Body: CustomerRequest{}, Response: models.Customer{}},
+	{Method: http.MethodPatch, Path: "/customers/:id", Summary: "Patch customer", Body: CustomerPatch{}, Response: models.Customer{}},
+	{Method: http.MethodDelete, Path: "/customers/:id", Summary: "Delete customer"},
+	{Method: http.MethodPut, Path: "/customers/:id/tags", Summary: "Set customer tags", Body: SetTagsRequest{}, Response: models.Customer{}},
+	{Method: http.MethodGet, Path: "/customers/:id/inventory-forecast", Summary: "Get inventory forecast", Query: []string{"days"}, Response: models.InventoryForecast{}},
+	{Method: http.MethodGet, Path: "/customers/:id/inventory-adjustments", Summary: "List customer inventory adjustments", Query: []string{"page", "limit"}, Response: []models.InventoryAdjustment{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/customers/:id/inventory-adjustments", Summary: "Adjust customer inventory", Body: InventoryAdjustmentRequest{}, Response: models.InventoryAdjustment{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/customers/:id/demand-estimate", Summary: "Get demand estimate", Response: models.DemandEstimate{}},
+	{Method: http.MethodPost, Path: "/customers/:id/demand-estimate", Summary: "Estimate demand", Response: models.DemandEstimate{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/customers/:id/demand-estimate/apply", Summary: "Apply demand estimate", Body: ApplyDemandEstimateRequest{}, BodyOptional: true, Response: models.DemandEstimate{}},
+	{Method: http.MethodGet, Path: "/customers/:id/demand-estimates", Summary: "List demand estimates", Query: []string{"page", "limit"}, Response: []models.DemandEstimate{}, Paginated: true},
+	{Method: http.MethodGet, Path: "/customers/:id/products", Summary: "List customer products", Response: []models.CustomerProductInventory{}},
+	{Method: http.MethodPut, Path: "/customers/:id/products/:product_id", Summary: "Set customer product", Body: CustomerProductRequest{}, Response: models.CustomerProductInventory{}},
+	{Method: http.MethodDelete, Path: "/customers/:id/products/:product_id", Summary: "Delete customer product"},
+	{Method: http.MethodGet, Path: "/alerts", Summary: "List alerts", Query: []string{"status", "severity", "kind", "entity_type", "entity_id", "page", "limit"}, Response: []models.Alert{}, Paginated: true},
+	{Method: http.MethodGet, Path: "/alerts/:id", Summary: "Get alert", Response: models.Alert{}},
+	{Method: http.MethodPost, Path: "/alerts/:id/acknowledge", Summary: "Acknowledge alert", Response: models.Alert{}},
+	{Method: http.MethodPost, Path: "/alerts/:id/resolve", Summary: "Resolve alert", Response: models.Alert{}},
+	{Method: http.MethodGet, Path: "/products", Summary: "List products", Response: []models.Product{}},
+	{Method: http.MethodPost, Path: "/products", Summary: "Create product", Body: ProductRequest{}, Response: models.Product{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/products/by-ref/:ref", Summary: "Get product by ref", Response: models.Product{}},
+	{Method: http.MethodPut, Path: "/products/by-ref/:ref", Summary: "Upsert product", Body: ProductRequest{}, Response: models.Product{}},
+	{Method: http.MethodGet, Path: "/products/:id", Summary: "Get product", Response: models.Product{}},
+	{Method: http.MethodPut, Path: "/products/:id", Summary: "Update product", Body: ProductRequest{}, Response: models.Product{}},
+	{Method: http.MethodDelete, Path: "/products/:id", Summary: "Delete product"},
+	{Method: http.MethodGet, Path: "/vehicles", Summary: "List vehicles", Query: []string{"tag"}, Response: []models.Vehicle{}},
+	{Method: http.MethodPost, Path: "/vehicles", Summary: "Create vehicle", Body: VehicleRequest{}, Response: models.Vehicle{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/vehicles/bulk-delete", Summary: "Bulk delete vehicles", Body: BulkDeleteRequest{}},
+	{Method: http.MethodPost, Path: "/vehicles/bulk-update", Summary: "Bulk update vehicles", Body: BulkVehicleUpdateRequest{}},
+	{Method: http.MethodPost, Path: "/vehicles/import", Summary: "Import vehicles", Files: []string{"file"}, Fields: []string{"format", "dry_run", "mapping"}, Response: ImportResult{}},
+	{Method: http.MethodGet, Path: "/vehicles/by-ref/:ref", Summary: "Get vehicle by ref", Response: models.Vehicle{}},
+	{Method: http.MethodPut, Path: "/vehicles/by-ref/:ref", Summary: "Upsert vehicle", Body: VehicleRequest{}, Response: models.Vehicle{}},
+	{Method: http.MethodGet, Path: "/vehicles/:id", Summary: "Get vehicle", Response: models.Vehicle{}},
+	{Method: http.MethodPut, Path: "/vehicles/:id", Summary: "Update vehicle", Body: VehicleRequest{}, Response: models.Vehicle{}},
+	{Method: http.MethodPatch, Path: "/vehicles/:id", Summary: "Patch vehicle", Body: VehiclePatch{}, Response: models.Vehicle{}},
+	{Method: http.MethodDelete, Path: "/vehicles/:id", Summary: "Delete vehicle"},
+	{Method: http.MethodPut, Path: "/vehicles/:id/tags", Summary: "Set vehicle tags", Body: SetTagsRequest{}, Response: models.Vehicle{}},
+	{Method: http.MethodGet, Path: "/vehicles/:id/history", Summary: "Get vehicle history", Query: []string{"from", "to"}, Response: models.VehicleHistory{}},
+	{Method: http.MethodGet, Path: "/vehicles/:id/maintenance", Summary: "List vehicle maintenance", Query: []string{"from", "to"}, Response: []models.MaintenanceRecord{}},
+	{Method: http.MethodPost, Path: "/vehicles/:id/maintenance", Summary: "Record vehicle maintenance", Body: MaintenanceRecordRequest{}, Response: models.MaintenanceRecord{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/drivers", Summary: "List drivers", Response: []models.Driver{}},
+	{Method: http.MethodPost, Path: "/drivers", Summary: "Create driver", Body: DriverRequest{}, Response: models.Driver{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/drivers/:id", Summary: "Get driver", Response: models.Driver{}},
+	{Method: http.MethodPut, Path: "/drivers/:id", Summary: "Update driver", Body: DriverRequest{}, Response: models.Driver{}},
+	{Method: http.MethodDelete, Path: "/drivers/:id", Summary: "Delete driver"},
+	{Method: http.MethodGet, Path: "/drivers/:id/absences", Summary: "List driver absences", Response: []models.DriverAbsence{}},
+	{Method: http.MethodPost, Path: "/drivers/:id/absences", Summary: "Create driver absence", Body: AbsenceRequest{}, Response: models.DriverAbsence{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/places", Summary: "List places", Query: []string{"kind"}, Response: []models.Place{}},
+	{Method: http.MethodPost, Path: "/places", Summary: "Create place", Body: PlaceRequest{}, Response: models.Place{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/places/:id", Summary: "Get place", Response: models.Place{}},
+	{Method: http.MethodPut, Path: "/places/:id", Summary: "Update place", Body: PlaceRequest{}, Response: models.Place{}},
+	{Method: http.MethodDelete, Path: "/places/:id", Summary: "Delete place"},
+	{Method: http.MethodGet, Path: "/rosters", Summary: "List roster entries", Query: []string{"from", "to", "warehouse_id"}, Response: []models.RosterEntry{}},
+	{Method: http.MethodPost, Path: "/rosters", Summary: "Create roster entries", Body: RosterRequest{}, Response: []models.RosterEntry{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/rosters/conflicts", Summary: "Get roster conflicts", Query: []string{"from", "to"}},
+	{Method: http.MethodDelete, Path: "/rosters/:id", Summary: "Delete roster entry"},
+	{Method: http.MethodGet, Path: "/absences", Summary: "List absences", Query: []string{"status", "from", "to"}, Response: []models.DriverAbsence{}},
+	{Method: http.MethodPost, Path: "/absences/:id/approve", Summary: "Approve absence"},
+	{Method: http.MethodPost, Path: "/absences/:id/reject", Summary: "Reject absence"},
+	{Method: http.MethodDelete, Path: "/absences/:id", Summary: "Delete absence"},
+	{Method: http.MethodGet, Path: "/plans", Summary: "List plans", Query: []string{"page", "limit", "status", "warehouse_id", "created_by", "from", "to", "sort", "include_archived"}, Response: []models.Plan{}, Paginated: true},
+	{Method: http.MethodPost, Path: "/plans", Summary: "Create plan", Body: PlanRequest{}, Response: models.Plan{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/plans/:id", Summary: "Get plan", Query: []string{"embed"}, Response: models.Plan{}},
+	{Method: http.MethodDelete, Path: "/plans/:id", Summary: "Delete plan"},
+	{Method: http.MethodPost, Path: "/plans/:id/clone", Summary: "Clone plan", Body: ClonePlanRequest{}, Response: models.Plan{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/plans/:id/template", Summary: "Create plan template from plan", Body: PlanTemplateFromPlanRequest{}, Response: models.PlanTemplate{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/plans/:id/optimize", Summary: "Optimize plan"},
+	{Method: http.MethodPost, Path: "/plans/:id/reoptimize", Summary: "Reoptimize plan", Query: []string{"from_day"}},
+	{Method: http.MethodPut, Path: "/plans/:id/rolling", Summary: "Set plan rolling", Body: SetPlanRollingRequest{}, Response: models.Plan{}},
+	{Method: http.MethodPut, Path: "/plans/:id/objective", Summary: "Set plan objective", Body: SetPlanObjectiveRequest{}, Response: models.Plan{}},
+	{Method: http.MethodPut, Path: "/plans/:id/notes", Summary: "Set plan notes", Body: SetPlanNotesRequest{}, Response: models.Plan{}},
+	{Method: http.MethodPost, Path: "/plans/:id/approve", Summary: "Approve plan"},
+	{Method: http.MethodPost, Path: "/plans/:id/execute", Summary: "Execute plan"},
+	{Method: http.MethodPost, Path: "/plans/:id/complete", Summary: "Complete plan"},
+	{Method: http.MethodPost, Path: "/plans/:id/cancel", Summary: "Cancel plan", Response: models.Plan{}},
+	{Method: http.MethodPost, Path: "/plans/:id/archive", Summary: "Archive plan"},
+	{Method: http.MethodGet, Path: "/plans/:id/optimization-progress", Summary: "Get optimization progress"},
+	{Method: http.MethodGet, Path: "/plans/:id/stream", Summary: "Stream plan events", ContentType: "text/event-stream"},
+	{Method: http.MethodGet, Path: "/plans/:id/unrouted", Summary: "List unrouted customers", Response: []models.UnroutedCustomer{}},
+	{Method: http.MethodPost, Path: "/plans/:id/unrouted/force", Summary: "Force unrouted customers", Body: ForceUnroutedRequest{}, BodyOptional: true},
+	{Method: http.MethodGet, Path: "/plans/:id/redeliveries", Summary: "List redeliveries", Response: []models.Redelivery{}},
+	{Method: http.MethodGet, Path: "/plans/:id/summary", Summary: "Get plan summary", Response: models.PlanSummary{}},
+	{Method: http.MethodGet, Path: "/plans/:id/dispatch-check", Summary: "Get dispatch check", Response: models.DispatchCheck{}},
+	{Method: http.MethodGet, Path: "/plans/:id/solutions", Summary: "List plan solutions", Response: []models.PlanSolution{}},
+	{Method: http.MethodGet, Path: "/plans/:id/solutions/:version", Summary: "Get plan solution", Response: models.PlanSolution{}},
+	{Method: http.MethodPost, Path: "/plans/:id/solutions/:version/rollback", Summary: "Rollback plan solution", Response: models.Plan{}},
+	{Method: http.MethodGet, Path: "/plans/:id/routes", Summary: "Get plan routes", Query: []string{"page", "limit", "day", "vehicle_id", "driver_id", "from", "to", "sort", "embed"}, Response: []models.Route{}, Paginated: true},
+	{Method: http.MethodGet, Path: "/plans/:id/routes.geojson", Summary: "Get plan routes as GeoJSON", ContentType: "application/geo+json"},
+	{Method: http.MethodGet, Path: "/plans/:id/timeline", Summary: "Get plan timeline", Response: models.PlanTimeline{}},
+	{Method: http.MethodGet, Path: "/plans/:id/costs", Summary: "Get plan costs", Response: models.PlanCosts{}},
+	{Method: http.MethodGet, Path: "/plans/:id/load-check", Summary: "Get plan load check", Response: models.PlanLoadCheck{}},
+	{Method: http.MethodGet, Path: "/plans/:id/export", Summary: "Export plan", Query: []string{"format"}, ContentType: "application/octet-stream"},
+	{Method: http.MethodPost, Path: "/plans/:id/replay-inputs", Summary: "Replay plan inputs", Query: []string{"link"}, Body: ReplayInputsRequest{}, BodyOptional: true, Response: models.ReplayInputs{}},
+	{Method: http.MethodGet, Path: "/plans/:id/execution-stats", Summary: "Get plan execution stats"},
+	{Method: http.MethodGet, Path: "/plans/:id/deviation-report", Summary: "Get deviation report", Response: models.DeviationReport{}},
+	{Method: http.MethodPost, Path: "/plans/:id/scenarios", Summary: "Create scenario", Body: ScenarioRequest{}, Response: models.Scenario{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/plans/:id/scenarios", Summary: "List plan scenarios", Response: []models.Scenario{}},
+	{Method: http.MethodGet, Path: "/plan-templates", Summary: "List plan templates", Response: []models.PlanTemplate{}},
+	{Method: http.MethodPost, Path: "/plan-templates", Summary: "Create plan template", Body: PlanTemplateRequest{}, Response: models.PlanTemplate{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/plan-templates/:id", Summary: "Get plan template", Response: models.PlanTemplate{}},
+	{Method: http.MethodPut, Path: "/plan-templates/:id", Summary: "Update plan template", Body: PlanTemplateRequest{}, Response: models.PlanTemplate{}},
+	{Method: http.MethodDelete, Path: "/plan-templates/:id", Summary: "Delete plan template"},
+	{Method: http.MethodPost, Path: "/plan-templates/:id/instantiate", Summary: "Instantiate plan template", Body: InstantiatePlanTemplateRequest{}, Response: models.Plan{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/scenarios/compare", Summary: "Compare scenarios", Query: []string{"ids"}},
+	{Method: http.MethodGet, Path: "/scenarios/:id", Summary: "Get scenario", Response: models.Scenario{}},
+	{Method: http.MethodDelete, Path: "/scenarios/:id", Summary: "Delete scenario"},
+	{Method: http.MethodPost, Path: "/scenarios/:id/optimize", Summary: "Optimize scenario", Response: models.Scenario{}},
+	{Method: http.MethodGet, Path: "/dispatch/export", Summary: "Export dispatch", Query: []string{"date", "format", "link"}, ContentType: "application/octet-stream"},
+	{Method: http.MethodPost, Path: "/routes/:id/executions", Summary: "Create route execution", Response: models.RouteExecution{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/routes/:id/executions", Summary: "Get route executions", Response: []models.RouteExecution{}},
+	{Method: http.MethodGet, Path: "/routes/:id/messages", Summary: "Get route messages", Query: []string{"after_id", "limit"}, Response: models.RouteThread{}},
+	{Method: http.MethodPost, Path: "/routes/:id/messages", Summary: "Send route message", Body: SendRouteMessageRequest{}, Response: models.RouteMessage{}, Status: http.StatusCreated},
+	{Method: http.MethodPost, Path: "/routes/:id/messages/read", Summary: "Mark route messages read", Body: MarkRouteMessagesReadRequest{}, BodyOptional: true, Response: []models.RouteMessageRead{}},
+	{Method: http.MethodPut, Path: "/routes/:id/lock", Summary: "Lock route", Body: LockRouteRequest{}, Response: models.Route{}},
+	{Method: http.MethodGet, Path: "/routes/:id/explain", Summary: "Explain a route", Response: models.RouteExplanation{}},
+	{Method: http.MethodGet, Path: "/routes/:id/load-plan", Summary: "Get route load plan", Response: models.RouteLoadPlan{}},
+	{Method: http.MethodGet, Path: "/routes/:id/stops", Summary: "Get route stops", Response: []models.Stop{}},
+	{Method: http.MethodPost, Path: "/routes/:id/stops", Summary: "Insert stop", Body: InsertStopRequest{}, Response: models.Stop{}, Status: http.StatusCreated},
+	{Method: http.MethodPatch, Path: "/stops/:id", Summary: "Update stop", Body: UpdateStopRequest{}, Response: models.Stop{}},
+	{Method: http.MethodDelete, Path: "/stops/:id", Summary: "Delete stop"},
+	{Method: http.MethodGet, Path: "/stops/:id/products", Summary: "List stop products", Response: []models.StopProductQuantity{}},
+	{Method: http.MethodPut, Path: "/stops/:id/products", Summary: "Set stop products", Body: StopProductsRequest{}, Response: models.Stop{}},
+	{Method: http.MethodPost, Path: "/executions/bulk", Summary: "Bulk update executions", Body: BulkUpdateExecutionsRequest{}},
+	{Method: http.MethodGet, Path: "/executions/export", Summary: "Export executions", Query: []string{"from", "to", "driver_id", "format"}, ContentType: "application/octet-stream"},
+	{Method: http.MethodGet, Path: "/executions/:id", Summary: "Get route execution", Query: []string{"view"}, Response: models.RouteExecution{}},
+	{Method: http.MethodPut, Path: "/executions/:id", Summary: "Update route execution", Body: UpdateRouteExecutionRequest{}, Response: models.RouteExecution{}},
+	{Method: http.MethodPost, Path: "/executions/:id/start", Summary: "Start route execution", Body: StartRouteExecutionRequest{}, Response: models.RouteExecution{}},
+	{Method: http.MethodPost, Path: "/executions/:id/complete", Summary: "Complete route execution", Body: CompleteRouteExecutionRequest{}, Response: models.RouteExecution{}},
+	{Method: http.MethodGet, Path: "/executions/:id/stops", Summary: "List stop executions", Query: []string{"view"}, Response: []models.StopExecution{}},
+	{Method: http.MethodPut, Path: "/executions/:id/stops/:stop_execution_id", Summary: "Update stop execution", Body: UpdateStopExecutionRequest{}, Response: models.StopExecution{}},
+	{Method: http.MethodPost, Path: "/executions/:id/locations", Summary: "Record location pings", Body: RecordLocationsRequest{}, Response: models.LocationIngest{}},
+	{Method: http.MethodGet, Path: "/executions/:id/etas", Summary: "Get execution ETAs", Response: models.RouteETAs{}},
+	{Method: http.MethodGet, Path: "/executions/:id/timeline", Summary: "Get execution timeline", Query: []string{"pings"}, Response: models.ExecutionTimeline{}},
+	{Method: http.MethodPut, Path: "/executions/:id/shift", Summary: "Record an execution shift", Body: ExecutionShiftRequest{}},
+	{Method: http.MethodPost, Path: "/executions/:id/incidents", Summary: "Report an incident", Body: ReportIncidentRequest{}, Response: models.Incident{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/incidents", Summary: "List incidents", Query: []string{"category", "execution_id", "plan_id", "vehicle_id", "driver_id", "from", "to", "page", "limit"}, Response: []models.Incident{}, Paginated: true},
+	{Method: http.MethodGet, Path: "/incidents/:id", Summary: "Get incident", Response: models.Incident{}},
+	{Method: http.MethodPost, Path: "/stop-executions/:id/pod", Summary: "Upload proof of delivery", Files: []string{"signature", "photo"}, Fields: []string{"recipient_name"}, Response: models.ProofOfDelivery{}},
+	{Method: http.MethodGet, Path: "/stop-executions/:id/pod", Summary: "Get proof of delivery", Response: models.ProofOfDelivery{}},
+	{Method: http.MethodPost, Path: "/redeliveries/:id/cancel", Summary: "Cancel redelivery", Response: models.Redelivery{}},
+	{Method: http.MethodGet, Path: "/driver/routes", Summary: "Get driver routes", Query: []string{"date", "view"}, Response: []models.DriverRoute{}},
+	{Method: http.MethodPost, Path: "/driver/stops/:id/events", Summary: "Post driver stop event", Query: []string{"view"}, Body: DriverStopEventRequest{}},
+	{Method: http.MethodPost, Path: "/inventory/snapshots", Summary: "Create inventory snapshot", Body: CreateInventorySnapshotRequest{}, Response: models.InventorySnapshot{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/inventory/snapshots", Summary: "Get inventory snapshots", Query: []string{"entity_type", "entity_id", "start_date", "end_date"}, Response: []models.InventorySnapshot{}},
+	{Method: http.MethodGet, Path: "/inventory/history", Summary: "Get inventory history", Query: []string{"entity_type", "entity_id", "days"}, Response: []models.InventorySnapshot{}},
+	{Method: http.MethodGet, Path: "/inventory/history/aggregate", Summary: "Get inventory history aggregate", Query: []string{"entity_type", "entity_id", "from", "to", "bucket"}, Response: models.InventoryHistoryAggregate{}},
+	{Method: http.MethodGet, Path: "/analytics/dashboard", Summary: "Get the dashboard", Response: models.Dashboard{}},
+	{Method: http.MethodGet, Path: "/analytics/summary", Summary: "Get the summary"},
+	{Method: http.MethodGet, Path: "/analytics/customer-portfolio", Summary: "Get customer portfolio", Query: []string{"days"}, Response: models.CustomerPortfolioReport{}},
+	{Method: http.MethodGet, Path: "/analytics/plan-accuracy", Summary: "Get plan accuracy", Query: []string{"from", "to", "period", "warehouse_id", "created_by"}, Response: models.PlanAccuracyReport{}},
+	{Method: http.MethodGet, Path: "/analytics/driver-hours", Summary: "Get driver hours", Query: []string{"from", "to", "driver_id"}, Response: models.DriverHoursReport{}},
+	{Method: http.MethodGet, Path: "/analytics/vehicle-efficiency", Summary: "Get vehicle efficiency", Query: []string{"from", "to", "vehicle_id"}, Response: models.VehicleEfficiencyReport{}},
+	{Method: http.MethodGet, Path: "/tags", Summary: "List tags", Response: []models.Tag{}},
+	{Method: http.MethodPost, Path: "/tags", Summary: "Create tag", Body: TagRequest{}, Response: models.Tag{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: "/tags/:id", Summary: "Update tag", Body: TagRequest{}, Response: models.Tag{}},
+	{Method: http.MethodDelete, Path: "/tags/:id", Summary: "Delete tag"},
+	{Method: http.MethodGet, Path: "/webhooks", Summary: "List webhooks", Response: []models.WebhookSubscription{}},
+	{Method: http.MethodPost, Path: "/webhooks", Summary: "Create webhook", Body: WebhookRequest{}, Response: CreatedWebhook{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/webhooks/:id", Summary: "Get webhook", Response: models.WebhookSubscription{}},
+	{Method: http.MethodPut, Path: "/webhooks/:id", Summary: "Update webhook", Body: WebhookRequest{}, Response: models.WebhookSubscription{}},
+	{Method: http.MethodDelete, Path: "/webhooks/:id", Summary: "Delete webhook"},
+	{Method: http.MethodPost, Path: "/webhooks/:id/ping", Summary: "Ping webhook", Response: models.WebhookDelivery{}},
+	{Method: http.MethodGet, Path: "/webhooks/:id/deliveries", Summary: "List webhook deliveries", Query: []string{"status", "event", "page", "limit"}, Response: []models.WebhookDelivery{}, Paginated: true},
+	{Method: http.MethodGet, Path: "/webhooks/:id/deliveries/:delivery_id", Summary: "Get webhook delivery", Response: models.WebhookDelivery{}},
+	{Method: http.MethodPost, Path: "/webhooks/:id/deliveries/:delivery_id/redeliver", Summary: "Redeliver webhook delivery", Response: models.WebhookDelivery{}, Status: http.StatusCreated},
+	{Method: http.MethodGet, Path: "/security-events", Summary: "List security events", Query: []string{"type", "user_id", "from", "to", "page", "limit"}, Response: []models.SecurityEvent{}, Paginated: true},
+	{Method: http.MethodGet, Path: "/admin/optimization-runs", Summary: "List optimization runs", Query: []string{"plan_id", "status", "limit"}, Response: []models.OptimizationRun{}},
+	{Method: http.MethodGet, Path: "/admin/optimization-runs/:id", Summary: "Get optimization run"},
+	{Method: http.MethodGet, Path: "/admin/optimization-runs/:id/download", Summary: "Download optimization run", Query: []string{"part", "link"}, ContentType: "application/json"},
+	{Method: http.MethodDelete, Path: "/admin/plans/:id", Summary: "Purge plan"},
+	{Method: http.MethodPost, Path: "/admin/inventory-snapshots/daily", Summary: "Take daily snapshots", Body: TakeDailySnapshotsRequest{}, BodyOptional: true, Response: models.DailySnapshots{}},
+	{Method: http.MethodGet, Path: "/admin/jobs", Summary: "List jobs", Query: []string{"status", "type", "limit"}, Response: []models.Job{}},
+	{Method: http.MethodGet, Path: "/admin/jobs/:id", Summary: "Get job", Response: models.Job{}},
+	{Method: http.MethodPost, Path: "/admin/jobs/:id/retry", Summary: "Retry job", Response: models.Job{}},
+	{Method: http.MethodPost, Path: "/admin/jobs/:id/cancel", Summary: "Cancel job", Response: models.Job{}},
+	{Method: http.MethodPut, Path: "/admin/users/:id/role", Summary: "Update user role", Body: UpdateUserRoleRequest{}, Response: models.User{}},
+	{Method: http.MethodPut, Path: "/admin/users/:id/organization", Summary: "Update user organization", Body: UpdateUserOrganizationRequest{}, Response: models.User{}},
+	{Method: http.MethodPost, Path: "/admin/users/:id/revoke-tokens", Summary: "Revoke user tokens"},
+	{Method: http.MethodGet, Path: "/admin/organizations", Summary: "List organizations", Response: []models.Organization{}},
+	{Method: http.MethodPost, Path: "/admin/organizations", Summary: "Create organization", Body: OrganizationRequest{}, Response: models.Organization{}, Status: http.StatusCreated},
+	{Method: http.MethodPut, Path: "/admin/organizations/:id", Summary: "Update organization", Body: OrganizationRequest{}, Response: models.Organization{}},
+	{Method: http.MethodGet, Path: "/admin/organizations/:id/usage", Summary: "Get organization usage"},
+	{Method: http.MethodGet, Path: "/admin/maintenance", Summary: "Get the maintenance mode", Response: models.MaintenanceStatus{}},
+	{Method: http.MethodPut, Path: "/admin/maintenance", Summary: "Set the maintenance mode", Body: MaintenanceRequest{}, Response: models.MaintenanceStatus{}},
+	{Method: http.MethodGet, Path: "/admin/doctor", Summary: "Check the dependencies", Response: models.DoctorReport{}},
+}
+
+// V2Operations documents the endpoints below APIV2BasePath. Version 2
+// starts with the list endpoints, whose pages come in the v2 envelope, and
+// the documentation itself; the router's tests keep it in step with the v2
+// routes as they do Operations with v1's.
+var V2Operations = slices.DeleteFunc(slices.Clone(Operations), func(op openapi.Operation) bool {
+	return !op.Paginated && op.Path != "/openapi.json" && op.Path != "/docs"
+})
+
+var (
+	openAPIOnce       sync.Once
+	openAPIDocument   *openapi.Document
+	openAPIV2Once     sync.Once
+	openAPIV2Document *openapi.Document
+)
+
+// openAPIConfig describes the API at version 1
+var openAPIConfig = openapi.Config{
+	Title:       "LogiTrackPro API",
+	Version:     "1.0",
+	Description: "Inventory routing: warehouses, customers, fleets, optimized plans and their execution.",
+	BasePath:    APIBasePath,
+	ErrorBody:   ErrorResponse{},
+	Pagination:  models.Pagination{},
+	ReadQuery:   []string{"fields"},
+}
+
+// OpenAPIDocument is the OpenAPI document of Operations, built on first use
+func OpenAPIDocument() *openapi.Document {
+	openAPIOnce.Do(func() {
+		openAPIDocument = openapi.Build(openAPIConfig, Operations)
+	})
+	return openAPIDocument
+}
+
+// V2OpenAPIDocument is the OpenAPI document of V2Operations, built on first
+// use
+func V2OpenAPIDocument() *openapi.Document {
+	openAPIV2Once.Do(func() {
+		cfg := openAPIConfig
+		cfg.Version = "2.0"
+		cfg.BasePath = APIV2BasePath
+		cfg.PageProperties = map[string]interface{}{"meta": PageMeta{}, "links": PageLinks{}}
+		openAPIV2Document = openapi.Build(cfg, V2Operations)
+	})
+	return openAPIV2Document
+}
+
+// GetOpenAPI handles GET /api/v1/openapi.json and GET /api/v2/openapi.json,
+// serving the document of the request's version
+func (h *Handler) GetOpenAPI(c *gin.Context) {
+	if apiVersion(c) == 2 {
+		c.JSON(http.StatusOK, V2OpenAPIDocument())
+		return
+	}
+	c.JSON(http.StatusOK, OpenAPIDocument())
+}
+
+// swaggerUIPage loads Swagger UI from its CDN, pointed at the document
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>LogiTrackPro API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({url: "openapi.json", dom_id: "#swagger-ui"});
+  </script>
+</body>
+</html>
+`
+
+// SwaggerUI handles GET /api/v1/docs and GET /api/v2/docs; the page loads
+// the document next to it
+func (h *Handler) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
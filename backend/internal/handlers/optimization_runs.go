@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultRunListLimit = 50
+	maxRunListLimit     = 500
+)
+
+// ListOptimizationRuns handles GET /api/v1/admin/optimization-runs
+func (h *Handler) ListOptimizationRuns(c *gin.Context) {
+	var planID *int64
+	if raw := c.Query("plan_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+			return
+		}
+		planID = &id
+	}
+
+	limit := defaultRunListLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxRunListLimit {
+			errorResponse(c, http.StatusBadRequest, fmt.Sprintf("limit must be between 1 and %d", maxRunListLimit))
+			return
+		}
+		limit = n
+	}
+
+	runs, err := database.ListOptimizationRuns(h.db, planID, c.Query("status"), limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch optimization runs")
+		return
+	}
+	if runs == nil {
+		runs = []models.OptimizationRun{}
+	}
+	successResponse(c, runs)
+}
+
+// GetOptimizationRun handles GET /api/v1/admin/optimization-runs/:id
+func (h *Handler) GetOptimizationRun(c *gin.Context) {
+	run, ok := h.fetchOptimizationRun(c)
+	if !ok {
+		return
+	}
+	successResponse(c, gin.H{"run": run, "violations": rawJSON(run.Violations)})
+}
+
+// DownloadOptimizationRun handles GET /api/v1/admin/optimization-runs/:id/download?part=request|response&link=true
+// With link=true the file is stored in artifact storage and a signed URL is
+// returned instead of the file itself.
+// Without part the request and response are returned together.
+func (h *Handler) DownloadOptimizationRun(c *gin.Context) {
+	run, ok := h.fetchOptimizationRun(c)
+	if !ok {
+		return
+	}
+
+	var body []byte
+	part := c.Query("part")
+	switch part {
+	case "request":
+		body = []byte(run.Request)
+	case "response":
+		body = []byte(run.Response)
+	case "":
+		part = "run"
+		body, _ = json.MarshalIndent(gin.H{
+			"run":        run,
+			"request":    rawJSON(run.Request),
+			"response":   rawJSON(run.Response),
+			"violations": rawJSON(run.Violations),
+		}, "", "  ")
+	default:
+		errorResponse(c, http.StatusBadRequest, "part must be request or response")
+		return
+	}
+
+	if c.Query("link") == "true" {
+		key := fmt.Sprintf("%soptimization-runs/%d-%s.json", storage.ExportsPrefix, run.ID, part)
+		link, err := h.storeArtifact(c.Request.Context(), key, "application/json", body)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to store download: "+err.Error())
+			return
+		}
+		successResponse(c, link)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="optimization-run-%d-%s.json"`, run.ID, part))
+	c.Data(http.StatusOK, "application/json", body)
+}
+
+func (h *Handler) fetchOptimizationRun(c *gin.Context) (*models.OptimizationRun, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid optimization run ID")
+		return nil, false
+	}
+
+	run, err := database.GetOptimizationRun(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Optimization run")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch optimization run")
+		return nil, false
+	}
+	return run, true
+}
+
+// rawJSON embeds a stored JSON document as-is, or null when empty
+func rawJSON(s string) json.RawMessage {
+	if s == "" {
+		return json.RawMessage("null")
+	}
+	return json.RawMessage(s)
+}
+
+// callOptimizer runs the optimizer and archives the request, response and
+// timing as an optimization run. run carries the plan, scenario and kind;
+// archive failures are logged and never fail the optimization.
+func (h *Handler) callOptimizer(run *models.OptimizationRun, optReq *optimizer.OptimizeRequest, onProgress func(optimizer.Progress)) (*optimizer.OptimizeResponse, error) {
+	started := h.clock.Now()
+	optResp, err := h.optimizer.OptimizeWithProgress(optReq, onProgress)
+	run.DurationMs = h.clock.Now().Sub(started).Milliseconds()
+
+	run.Protocol = h.optimizer.Protocol()
+	run.PlanningHorizon = optReq.PlanningHorizon
+	run.CustomerCount = len(optReq.Customers)
+	run.VehicleCount = len(optReq.Vehicles)
+	if data, marshalErr := json.Marshal(optReq); marshalErr == nil {
+		run.Request = string(data)
+	}
+
+	switch {
+	case err != nil:
+		run.Status = "error"
+		run.Error = err.Error()
+	case !optResp.Success:
+		run.Status = "failed"
+	default:
+		run.Status = "success"
+	}
+	if optResp != nil {
+		run.SolverMessage = optResp.Message
+		run.RouteCount = len(optResp.Routes)
+		run.TotalCost = optResp.TotalCost
+		run.TotalDistance = optResp.TotalDistance
+		if data, marshalErr := json.Marshal(optResp); marshalErr == nil {
+			run.Response = string(data)
+		}
+	}
+
+	if archiveErr := database.CreateOptimizationRun(h.db, run); archiveErr != nil {
+		log.Printf("WARNING: failed to archive optimization run: %v", archiveErr)
+	}
+	return optResp, err
+}
+
+// markRunInfeasible records validation violations on an archived run
+func (h *Handler) markRunInfeasible(run *models.OptimizationRun, violations []optimizer.Violation) {
+	if run.ID == 0 {
+		return
+	}
+	data, _ := json.Marshal(violations)
+	if err := database.MarkOptimizationRunInfeasible(h.db, run.ID, string(data)); err != nil {
+		log.Printf("WARNING: failed to mark optimization run %d infeasible: %v", run.ID, err)
+	}
+}
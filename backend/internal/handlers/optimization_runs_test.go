@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/storage"
+)
+
+// TestOptimizationRunArchive tests that optimizer calls are archived and only
+// admins can download them
+func TestOptimizationRunArchive(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	database.CreateCustomer(s.db, &models.Customer{Name: "Customer", Latitude: 40.7, Longitude: -74.0, DemandRate: 10})
+	database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+
+	artifacts, err := storage.NewLocal(t.TempDir(), "http://example.com/api/v1/files", []byte("test-secret"))
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	s.h.artifacts = artifacts
+
+	s.router.GET("/api/v1/files/*key", s.h.ServeFile)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	admin := s.api.Group("/admin", s.h.AdminMiddleware())
+	admin.GET("/optimization-runs", s.h.ListOptimizationRuns)
+	admin.GET("/optimization-runs/:id/download", s.h.DownloadOptimizationRun)
+	token := s.login(t, "user")
+	adminToken := s.login(t, "admin")
+
+	var run models.OptimizationRun
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"optimize", func(t *testing.T) {
+			if w := s.do(t, "POST", "/api/v1/plans/"+strconv.FormatInt(plan.ID, 10)+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+		}},
+		{"admins only", func(t *testing.T) {
+			if w := s.do(t, "GET", "/api/v1/admin/optimization-runs", token, nil); w.Code != http.StatusForbidden {
+				t.Fatalf("ListOptimizationRuns() as non-admin status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		}},
+		{"list", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/admin/optimization-runs?plan_id="+strconv.FormatInt(plan.ID, 10), adminToken, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("ListOptimizationRuns() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var list struct {
+				Data []models.OptimizationRun
+			}
+			json.Unmarshal(w.Body.Bytes(), &list)
+			if len(list.Data) != 1 {
+				t.Fatalf("ListOptimizationRuns() returned %d runs, want 1", len(list.Data))
+			}
+			run = list.Data[0]
+			if run.Kind != "optimize" || run.Status != "success" || run.Protocol != "http" || run.RouteCount != 1 || run.VehicleCount != 1 {
+				t.Errorf("run = %+v, want a successful optimize run with 1 route", run)
+			}
+		}},
+		{"download", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/admin/optimization-runs/"+strconv.FormatInt(run.ID, 10)+"/download?part=request", adminToken, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("DownloadOptimizationRun() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var archived optimizer.OptimizeRequest
+			if err := json.Unmarshal(w.Body.Bytes(), &archived); err != nil {
+				t.Fatalf("downloaded request is not valid JSON: %v", err)
+			}
+			if archived.StartDate != "2024-01-01" || len(archived.Customers) != 1 {
+				t.Errorf("downloaded request = %+v, want the request sent to the optimizer", archived)
+			}
+		}},
+		{"signed link", func(t *testing.T) {
+			// A signed link serves the same file without a token, until tampered with
+			w := s.do(t, "GET", "/api/v1/admin/optimization-runs/"+strconv.FormatInt(run.ID, 10)+"/download?part=request&link=true", adminToken, nil)
+			var linkResp struct {
+				Data models.ArtifactLink
+			}
+			json.Unmarshal(w.Body.Bytes(), &linkResp)
+			if w.Code != http.StatusOK || linkResp.Data.URL == "" {
+				t.Fatalf("DownloadOptimizationRun(link=true) status = %d, body = %s", w.Code, w.Body.String())
+			}
+			link, err := url.Parse(linkResp.Data.URL)
+			if err != nil {
+				t.Fatalf("invalid link %q: %v", linkResp.Data.URL, err)
+			}
+			fetch := func(t *testing.T, rawQuery string) *httptest.ResponseRecorder {
+				t.Helper()
+				return s.do(t, "GET", link.Path+"?"+rawQuery, "", nil)
+			}
+			if w := fetch(t, link.RawQuery); w.Code != http.StatusOK || !json.Valid(w.Body.Bytes()) {
+				t.Errorf("ServeFile() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			query := link.Query()
+			query.Set("signature", query.Get("signature")+"00")
+			if w := fetch(t, query.Encode()); w.Code != http.StatusForbidden {
+				t.Errorf("ServeFile() with a bad signature status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// segmentRequest splits an optimizer request into the windows it is solved
+// in under the configured optimizer limits. It fails when the request is
+// too large and cannot or may not be chunked.
+func (h *Handler) segmentRequest(optReq *optimizer.OptimizeRequest) ([]optimizer.Window, error) {
+	if h.config == nil {
+		return []optimizer.Window{{FirstDay: 1, Days: optReq.PlanningHorizon}}, nil
+	}
+	limits := optimizer.Limits{
+		MaxHorizonDays:  h.config.OptimizerMaxHorizonDays,
+		MaxCustomers:    h.config.OptimizerMaxCustomers,
+		MaxCustomerDays: h.config.OptimizerMaxCustomerDays,
+	}
+	return limits.Segment(optReq, h.config.OptimizerChunking)
+}
+
+// optimizeWindows calls the optimizer once per window, carrying inventories
+// forward from the routes of earlier windows, and stitches the results into
+// one response over the full request. Every call is archived as its own run
+// copied from run; the runs are returned so an infeasible result can be
+// marked on all of them. A window that fails ends the optimization with its
+// error or unsuccessful response.
+func (h *Handler) optimizeWindows(run *models.OptimizationRun, optReq *optimizer.OptimizeRequest, windows []optimizer.Window, onProgress func(optimizer.Progress)) (*optimizer.OptimizeResponse, []*models.OptimizationRun, error) {
+	if len(windows) == 1 {
+		optResp, err := h.callOptimizer(run, optReq, onProgress)
+		return optResp, []*models.OptimizationRun{run}, err
+	}
+
+	total := &optimizer.OptimizeResponse{Success: true, Routes: []optimizer.RouteResult{}}
+	runs := make([]*models.OptimizationRun, 0, len(windows))
+	for _, w := range windows {
+		windowReq, err := optimizer.WindowRequest(optReq, w, total.Routes)
+		if err != nil {
+			return nil, runs, err
+		}
+
+		var progress func(optimizer.Progress)
+		if onProgress != nil {
+			done := *total
+			progress = func(p optimizer.Progress) {
+				p.Day += w.FirstDay - 1
+				p.TotalDays = optReq.PlanningHorizon
+				p.Routes += len(done.Routes)
+				p.Cost += done.TotalCost
+				p.Distance += done.TotalDistance
+				onProgress(p)
+			}
+		}
+
+		windowRun := *run
+		windowRun.FirstDay = w.FirstDay
+		runs = append(runs, &windowRun)
+		part, err := h.callOptimizer(&windowRun, windowReq, progress)
+		if err != nil {
+			return nil, runs, fmt.Errorf("days %d-%d: %w", w.FirstDay, w.LastDay(), err)
+		}
+		if !part.Success {
+			return &optimizer.OptimizeResponse{Message: fmt.Sprintf("days %d-%d: %s", w.FirstDay, w.LastDay(), part.Message)}, runs, nil
+		}
+		optimizer.StitchWindow(total, w, part)
+	}
+	return total, runs, nil
+}
+
+// markRunsInfeasible records validation violations on every run of an
+// optimization
+func (h *Handler) markRunsInfeasible(runs []*models.OptimizationRun, violations []optimizer.Violation) {
+	for _, run := range runs {
+		h.markRunInfeasible(run, violations)
+	}
+}
+
+// solutionWindows converts the windows of a chunked optimization to plan
+// days; a single window is not reported
+func solutionWindows(windows []optimizer.Window, dayOffset int) []models.SolutionWindow {
+	if len(windows) < 2 {
+		return nil
+	}
+	result := make([]models.SolutionWindow, len(windows))
+	for i, w := range windows {
+		result[i] = models.SolutionWindow{FirstDay: w.FirstDay + dayOffset, LastDay: w.LastDay() + dayOffset}
+	}
+	return result
+}
+
+// segmentationWarning tells the client a plan was optimized in rolling
+// windows rather than as a whole
+func segmentationWarning(windows []models.SolutionWindow) []string {
+	if len(windows) == 0 {
+		return nil
+	}
+	days := make([]string, len(windows))
+	for i, w := range windows {
+		days[i] = fmt.Sprintf("%d-%d", w.FirstDay, w.LastDay)
+	}
+	return []string{fmt.Sprintf("The plan exceeds the optimizer limits and was optimized in %d rolling windows (days %s); deliveries near window boundaries may be less economical than in a single solve",
+		len(windows), strings.Join(days, ", "))}
+}
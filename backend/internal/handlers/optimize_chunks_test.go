@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestOptimizeInWindows tests that plans over the optimizer limits are
+// rejected without chunking and solved in rolling windows with it
+func TestOptimizeInWindows(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.OptimizerMaxHorizonDays = 4 })
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	s.fx.Customer(func(c *models.Customer) { c.CurrentInventory, c.DemandRate = 100, 10 })
+	s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 10)
+	path := fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID)
+
+	t.Run("without chunking", func(t *testing.T) {
+		w := s.do(t, "POST", path, token, nil)
+		if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "10-day horizon exceeds the optimizer limit of 4 days") {
+			t.Fatalf("optimize without chunking = %d %s, want 400 naming the limit", w.Code, w.Body.String())
+		}
+		if len(s.opt.Requests()) != 0 {
+			t.Fatalf("optimizer called %d times for a rejected plan", len(s.opt.Requests()))
+		}
+	})
+
+	t.Run("with chunking", func(t *testing.T) {
+		s.h.config.OptimizerChunking = true
+		w := s.do(t, "POST", path, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("optimize with chunking status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data     models.Plan
+			Warnings []string
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Warnings) != 1 || !strings.Contains(resp.Warnings[0], "3 rolling windows (days 1-4, 5-8, 9-10)") {
+			t.Errorf("warnings = %v, want the segmentation", resp.Warnings)
+		}
+
+		requests := s.opt.Requests()
+		if len(requests) != 3 {
+			t.Fatalf("optimizer called %d times, want 3", len(requests))
+		}
+		// The fake delivers 5 units on the first day of each window, which
+		// the customer has no room for at its maximum of 100
+		if r := requests[1]; r.StartDate != "2024-03-08" || r.PlanningHorizon != 4 || r.Customers[0].CurrentInventory != 60 {
+			t.Errorf("second window starts %s for %d days with inventory %v, want 2024-03-08, 4 and 60", r.StartDate, r.PlanningHorizon, r.Customers[0].CurrentInventory)
+		}
+
+		var days []int
+		for _, r := range resp.Data.Routes {
+			days = append(days, r.Day)
+		}
+		if fmt.Sprint(days) != "[1 5 9]" || resp.Data.TotalCost != 300 {
+			t.Errorf("route days = %v costing %v, want [1 5 9] costing 300", days, resp.Data.TotalCost)
+		}
+	})
+
+	t.Run("archived windows", func(t *testing.T) {
+		solutions, err := database.ListPlanSolutions(s.db, plan.ID)
+		if err != nil || len(solutions) != 1 {
+			t.Fatalf("solutions = %d, %v", len(solutions), err)
+		}
+		if got := fmt.Sprint(solutions[0].Parameters.Windows); got != "[{1 4} {5 8} {9 10}]" {
+			t.Errorf("solution windows = %s", got)
+		}
+		runs, _ := database.ListOptimizationRuns(s.db, &plan.ID, "", 10)
+		var firstDays []int
+		for _, run := range runs {
+			firstDays = append(firstDays, run.FirstDay)
+		}
+		if fmt.Sprint(firstDays) != "[9 5 1]" {
+			t.Errorf("archived runs start on days %v, want [9 5 1]", firstDays)
+		}
+	})
+}
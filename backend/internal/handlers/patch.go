@@ -0,0 +1,21 @@
+package handlers
+
+// patchField sets *dst to the value of a field of a PATCH request when the
+// request has it, adding its column to columns
+func patchField[T any](columns *[]string, column string, dst *T, src *T) {
+	if src == nil {
+		return
+	}
+	*dst = *src
+	*columns = append(*columns, column)
+}
+
+// patchID sets an optional reference from a field of a PATCH request when
+// the request has it; 0 clears it
+func patchID(columns *[]string, column string, dst **int64, src *int64) {
+	if src == nil {
+		return
+	}
+	*dst = warehouseIDPtr(*src)
+	*columns = append(*columns, column)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestPatchHandlers tests that PATCH changes only the fields sent, zero
+// values included
+func TestPatchHandlers(t *testing.T) {
+	s := newTestServer(t)
+	s.api.PATCH("/customers/:id", s.h.PatchCustomer)
+	s.api.PATCH("/vehicles/:id", s.h.PatchVehicle)
+	s.api.PATCH("/warehouses/:id", s.h.PatchWarehouse)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	customer := s.fx.Customer(func(c *models.Customer) { c.WarehouseID = &warehouse.ID; c.ServiceTags = []string{"reefer"} })
+	vehicle := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.ShiftStart, v.ShiftEnd = "06:00", "14:00" })
+
+	t.Run("customer", func(t *testing.T) {
+		if w := s.do(t, "PATCH", "/api/v1/customers/"+fmt.Sprint(customer.ID), token, gin.H{"demand_rate": 0, "warehouse_id": 0, "service_tags": []string{}}); w.Code != http.StatusOK {
+			t.Fatalf("patch customer status = %d: %s", w.Code, w.Body.String())
+		}
+		patched, _ := database.GetCustomer(s.db, customer.ID)
+		if patched.DemandRate != 0 || patched.WarehouseID != nil || len(patched.ServiceTags) != 0 {
+			t.Errorf("patched customer = %+v, want demand, warehouse and tags cleared", patched)
+		}
+		if patched.Name != customer.Name || patched.CurrentInventory != customer.CurrentInventory || patched.Latitude != customer.Latitude {
+			t.Errorf("patched customer = %+v, want the other fields kept", patched)
+		}
+	})
+
+	t.Run("vehicle", func(t *testing.T) {
+		if w := s.do(t, "PATCH", "/api/v1/vehicles/"+fmt.Sprint(vehicle.ID), token, gin.H{"available": false, "shift_end": "15:30"}); w.Code != http.StatusOK {
+			t.Fatalf("patch vehicle status = %d: %s", w.Code, w.Body.String())
+		}
+		parked, _ := database.GetVehicle(s.db, vehicle.ID)
+		if parked.Available || parked.ShiftStart != "06:00" || parked.ShiftEnd != "15:30" || parked.Capacity != vehicle.Capacity {
+			t.Errorf("patched vehicle = %+v, want it unavailable until 15:30 and the rest kept", parked)
+		}
+	})
+
+	t.Run("warehouse", func(t *testing.T) {
+		if w := s.do(t, "PATCH", "/api/v1/warehouses/"+fmt.Sprint(warehouse.ID), token, gin.H{"min_stock": 250}); w.Code != http.StatusOK {
+			t.Fatalf("patch warehouse status = %d: %s", w.Code, w.Body.String())
+		}
+		if depot, _ := database.GetWarehouse(s.db, warehouse.ID); depot.MinStock != 250 || depot.Name != warehouse.Name || depot.CurrentStock != warehouse.CurrentStock {
+			t.Errorf("patched warehouse = %+v, want only the min stock changed", depot)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		invalid := []struct {
+			path string
+			body gin.H
+			want int
+		}{
+			{"/api/v1/customers/" + fmt.Sprint(customer.ID), gin.H{"name": ""}, http.StatusBadRequest},
+			{"/api/v1/customers/" + fmt.Sprint(customer.ID), gin.H{"latitude": 91}, http.StatusBadRequest},
+			{"/api/v1/vehicles/" + fmt.Sprint(vehicle.ID), gin.H{"capacity": 0}, http.StatusBadRequest},
+			{"/api/v1/vehicles/" + fmt.Sprint(vehicle.ID), gin.H{"shift_start": "16:00"}, http.StatusBadRequest},
+			{"/api/v1/warehouses/999", gin.H{"name": "Nowhere"}, http.StatusNotFound},
+		}
+		for _, tt := range invalid {
+			if w := s.do(t, "PATCH", tt.path, token, tt.body); w.Code != tt.want {
+				t.Errorf("PATCH %s %v status = %d, want %d", tt.path, tt.body, w.Code, tt.want)
+			}
+		}
+	})
+}
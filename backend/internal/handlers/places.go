@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlaceRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Kind      string   `json:"kind" binding:"omitempty,oneof=fuel_station truck_stop charging_station depot other"`
+	Address   string   `json:"address"`
+	Latitude  *float64 `json:"latitude" binding:"required,gte=-90,lte=90"`
+	Longitude *float64 `json:"longitude" binding:"required,gte=-180,lte=180"`
+	Notes     string   `json:"notes"`
+}
+
+func (r *PlaceRequest) toModel() *models.Place {
+	kind := r.Kind
+	if kind == "" {
+		kind = "other"
+	}
+	return &models.Place{
+		Name:      r.Name,
+		Kind:      kind,
+		Address:   r.Address,
+		Latitude:  *r.Latitude,
+		Longitude: *r.Longitude,
+		Notes:     r.Notes,
+	}
+}
+
+// ListPlaces handles GET /api/v1/places?kind=
+func (h *Handler) ListPlaces(c *gin.Context) {
+	places, err := database.ListPlaces(h.db, c.Query("kind"))
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch places")
+		return
+	}
+	if places == nil {
+		places = []models.Place{}
+	}
+	successResponse(c, places)
+}
+
+// GetPlace handles GET /api/v1/places/:id
+func (h *Handler) GetPlace(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid place ID")
+		return
+	}
+
+	place, err := database.GetPlace(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Place")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch place")
+		return
+	}
+	successResponse(c, place)
+}
+
+// CreatePlace handles POST /api/v1/places
+func (h *Handler) CreatePlace(c *gin.Context) {
+	var req PlaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	place := req.toModel()
+	if err := database.CreatePlace(h.db, place); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create place")
+		return
+	}
+	createdResponse(c, place)
+}
+
+// UpdatePlace handles PUT /api/v1/places/:id
+func (h *Handler) UpdatePlace(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid place ID")
+		return
+	}
+
+	var req PlaceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	place := req.toModel()
+	place.ID = id
+	if err := database.UpdatePlace(h.db, place); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Place")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update place")
+		return
+	}
+	successResponse(c, place)
+}
+
+// DeletePlace handles DELETE /api/v1/places/:id
+// Stops already planned at the place keep it; it can no longer be added to
+// routes.
+func (h *Handler) DeletePlace(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid place ID")
+		return
+	}
+
+	if err := database.DeletePlace(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Place")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete place")
+		return
+	}
+	successResponse(c, gin.H{"message": "Place deleted successfully"})
+}
@@ -0,0 +1,275 @@
+package handlers
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// accuracyOnTime is how far from its planned arrival a stop may be reached
+// and still count as on time
+const accuracyOnTime = 30 * time.Minute
+
+// accuracyDefaultDays is the range scored when no from date is given
+const accuracyDefaultDays = 180
+
+// GetPlanAccuracy handles GET /api/v1/analytics/plan-accuracy?from=&to=&period=month&warehouse_id=&created_by=
+// Plans starting in the range, by default the last 180 days, are scored on
+// their completed routes: delivered against planned quantities at the
+// finished stops, arrivals within 30 minutes of plan, and actual against
+// planned route costs. Scores are trended by week or month overall and for
+// each warehouse and planner.
+func (h *Handler) GetPlanAccuracy(c *gin.Context) {
+	period := c.DefaultQuery("period", "month")
+	if period != "week" && period != "month" {
+		errorResponse(c, http.StatusBadRequest, "period must be week or month")
+		return
+	}
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := database.AccuracyFilter{To: h.clock.Now().Truncate(24 * time.Hour)}
+	if to != nil {
+		filter.To = *to
+	}
+	filter.From = filter.To.AddDate(0, 0, -accuracyDefaultDays)
+	if from != nil {
+		filter.From = *from
+	}
+	if filter.To.Before(filter.From) {
+		errorResponse(c, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+	if filter.WarehouseID, err = parseIDQuery(c, "warehouse_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.CreatedBy, err = parseIDQuery(c, "created_by"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	executions, err := database.GetCompletedExecutionsForAccuracy(h.db, filter)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route executions")
+		return
+	}
+
+	report := buildPlanAccuracy(executions, period)
+	report.From = filter.From.Format("2006-01-02")
+	report.To = filter.To.Format("2006-01-02")
+	successResponse(c, report)
+}
+
+// buildPlanAccuracy scores completed executions, which come ordered by
+// their plan's start date, overall, by period, by warehouse, by planner and
+// by plan
+func buildPlanAccuracy(executions []models.RouteExecution, period string) *models.PlanAccuracyReport {
+	overall := newAccuracyTotals()
+	trend := newAccuracyTrend()
+	warehouses := make(map[int64]*accuracyGroup)
+	planners := make(map[int64]*accuracyGroup)
+	plans := make(map[int64]*accuracyTotals)
+	var planOrder []*models.Plan
+
+	for i := range executions {
+		e := &executions[i]
+		if e.Route == nil || e.Route.Plan == nil {
+			continue
+		}
+		plan := e.Route.Plan
+		key := accuracyPeriod(plan.StartDate, period)
+		overall.add(e)
+		trend.add(key, e)
+		if plan.WarehouseID != nil {
+			name := ""
+			if plan.Warehouse != nil {
+				name = plan.Warehouse.Name
+			}
+			groupFor(warehouses, *plan.WarehouseID, name).add(key, e)
+		}
+		if plan.CreatedBy != nil {
+			name := ""
+			if plan.User != nil {
+				name = plan.User.Name
+			}
+			groupFor(planners, *plan.CreatedBy, name).add(key, e)
+		}
+		if plans[plan.ID] == nil {
+			plans[plan.ID] = newAccuracyTotals()
+			planOrder = append(planOrder, plan)
+		}
+		plans[plan.ID].add(e)
+	}
+
+	report := &models.PlanAccuracyReport{
+		Period:     period,
+		Overall:    overall.score(),
+		Trend:      trend.periods(),
+		Warehouses: accuracyGroups(warehouses),
+		Planners:   accuracyGroups(planners),
+		Plans:      make([]models.PlanAccuracy, len(planOrder)),
+	}
+	for i, plan := range planOrder {
+		report.Plans[i] = models.PlanAccuracy{
+			PlanID:        plan.ID,
+			Name:          plan.Name,
+			StartDate:     plan.StartDate.Format("2006-01-02"),
+			WarehouseID:   plan.WarehouseID,
+			CreatedBy:     plan.CreatedBy,
+			AccuracyScore: plans[plan.ID].score(),
+		}
+	}
+	return report
+}
+
+// accuracyPeriod names the week or month of a plan's start date
+func accuracyPeriod(date time.Time, period string) string {
+	if period == "week" {
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return date.Format("2006-01")
+}
+
+// accuracyTotals sums the planned and actual outcomes of executions
+type accuracyTotals struct {
+	plans           map[int64]bool
+	routes          int
+	stops           int
+	plannedQuantity float64
+	quantityError   float64
+	timedStops      int
+	onTimeStops     int
+	plannedCost     float64
+	costError       float64
+}
+
+func newAccuracyTotals() *accuracyTotals {
+	return &accuracyTotals{plans: make(map[int64]bool)}
+}
+
+// add counts a completed execution. Stops still pending or arrived are
+// left out, as are costs of routes planned without one.
+func (t *accuracyTotals) add(e *models.RouteExecution) {
+	t.plans[e.Route.PlanID] = true
+	t.routes++
+	if e.PlannedCost > 0 {
+		t.plannedCost += e.PlannedCost
+		t.costError += math.Abs(e.ActualCost - e.PlannedCost)
+	}
+	for _, s := range e.StopExecutions {
+		if s.Status == "pending" || s.Status == "arrived" {
+			continue
+		}
+		t.stops++
+		t.plannedQuantity += s.PlannedQuantity
+		t.quantityError += math.Abs(s.ActualQuantity - s.PlannedQuantity)
+		if s.PlannedArrivalTime != nil && s.ActualArrivalTime != nil {
+			t.timedStops++
+			if s.ActualArrivalTime.Sub(*s.PlannedArrivalTime).Abs() <= accuracyOnTime {
+				t.onTimeStops++
+			}
+		}
+	}
+}
+
+func (t *accuracyTotals) score() models.AccuracyScore {
+	score := models.AccuracyScore{Plans: len(t.plans), Routes: t.routes, Stops: t.stops}
+	var sum float64
+	var measured int
+	if t.plannedQuantity > 0 {
+		score.QuantityAccuracy = accuracyPercent(1 - t.quantityError/t.plannedQuantity)
+		sum += score.QuantityAccuracy
+		measured++
+	}
+	if t.timedStops > 0 {
+		score.TimingAccuracy = accuracyPercent(float64(t.onTimeStops) / float64(t.timedStops))
+		sum += score.TimingAccuracy
+		measured++
+	}
+	if t.plannedCost > 0 {
+		score.CostAccuracy = accuracyPercent(1 - t.costError/t.plannedCost)
+		sum += score.CostAccuracy
+		measured++
+	}
+	if measured > 0 {
+		score.Score = math.Round(sum/float64(measured)*10) / 10
+	}
+	return score
+}
+
+// accuracyPercent turns a share into a percentage rounded to one decimal,
+// floored at 0 for errors larger than the plan
+func accuracyPercent(share float64) float64 {
+	return math.Round(math.Max(share, 0)*1000) / 10
+}
+
+// accuracyTrend sums executions per period, keeping periods in the order
+// they were first seen
+type accuracyTrend struct {
+	keys   []string
+	totals map[string]*accuracyTotals
+}
+
+func newAccuracyTrend() *accuracyTrend {
+	return &accuracyTrend{totals: make(map[string]*accuracyTotals)}
+}
+
+func (tr *accuracyTrend) add(key string, e *models.RouteExecution) {
+	if tr.totals[key] == nil {
+		tr.totals[key] = newAccuracyTotals()
+		tr.keys = append(tr.keys, key)
+	}
+	tr.totals[key].add(e)
+}
+
+func (tr *accuracyTrend) periods() []models.AccuracyPeriod {
+	periods := make([]models.AccuracyPeriod, len(tr.keys))
+	for i, key := range tr.keys {
+		periods[i] = models.AccuracyPeriod{Period: key, AccuracyScore: tr.totals[key].score()}
+	}
+	return periods
+}
+
+// accuracyGroup sums the executions of one warehouse's or planner's plans
+type accuracyGroup struct {
+	name  string
+	total *accuracyTotals
+	trend *accuracyTrend
+}
+
+func groupFor(groups map[int64]*accuracyGroup, id int64, name string) *accuracyGroup {
+	if groups[id] == nil {
+		groups[id] = &accuracyGroup{name: name, total: newAccuracyTotals(), trend: newAccuracyTrend()}
+	}
+	return groups[id]
+}
+
+func (g *accuracyGroup) add(key string, e *models.RouteExecution) {
+	g.total.add(e)
+	g.trend.add(key, e)
+}
+
+// accuracyGroups scores groups in ID order
+func accuracyGroups(groups map[int64]*accuracyGroup) []models.AccuracyGroup {
+	scored := make([]models.AccuracyGroup, 0, len(groups))
+	for id, g := range groups {
+		group := models.AccuracyGroup{ID: id, Name: g.name, Trend: g.trend.periods(), AccuracyScore: g.total.score()}
+		if n := len(group.Trend); n > 1 {
+			group.Change = math.Round((group.Trend[n-1].Score-group.Trend[0].Score)*10) / 10
+		}
+		scored = append(scored, group)
+	}
+	sort.Slice(scored, func(i, j int) bool { return scored[i].ID < scored[j].ID })
+	return scored
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestGetPlanAccuracy tests quantity, timing and cost accuracy of past
+// plans and their trend by month for warehouses and planners
+func TestGetPlanAccuracy(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)))
+	s.api.GET("/analytics/plan-accuracy", s.h.GetPlanAccuracy)
+
+	planner := s.fx.User("manager")
+	token := e2eLogin(t, s.router, planner).Token
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+
+	// run completes a route of a plan starting on start with 10 units
+	// planned at each of two stops, delivering delivered and arriving late
+	// minutes after plan, for actualCost against a planned cost of 100
+	run := func(start time.Time, delivered [2]float64, late [2]int, actualCost float64) *models.Plan {
+		plan := s.fx.Plan(warehouse, start, 1, testkit.WithStatus("completed"), func(p *models.Plan) { p.CreatedBy = &planner.ID })
+		route := s.fx.Route(plan, vehicle, 1, s.fx.Customer(), s.fx.Customer())
+		execution := &models.RouteExecution{RouteID: route.ID, Status: "completed", PlannedCost: 100, ActualCost: actualCost}
+		for i, stop := range route.Stops {
+			planned := start.Add(time.Duration(9+i) * time.Hour)
+			actual := planned.Add(time.Duration(late[i]) * time.Minute)
+			execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{
+				StopID: stop.ID, Status: "completed", PlannedQuantity: 10, ActualQuantity: delivered[i],
+				PlannedArrivalTime: &planned, ActualArrivalTime: &actual,
+			})
+		}
+		if err := database.CreateRouteExecution(s.db, execution); err != nil {
+			t.Fatal(err)
+		}
+		return plan
+	}
+	march := run(time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), [2]float64{6, 10}, [2]int{45, 10}, 150)
+	run(time.Date(2024, 4, 8, 0, 0, 0, 0, time.UTC), [2]float64{10, 9}, [2]int{-5, 20}, 110)
+	// a plan outside the range is not scored
+	run(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), [2]float64{0, 0}, [2]int{300, 300}, 500)
+
+	t.Run("monthly", func(t *testing.T) {
+		w := s.do(t, "GET", "/api/v1/analytics/plan-accuracy?from=2024-01-01", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.PlanAccuracyReport }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		report := resp.Data
+
+		// March: 4 of 20 units short, 1 of 2 stops on time, 50 over 100 in cost
+		wantMarch := models.AccuracyScore{Plans: 1, Routes: 1, Stops: 2, QuantityAccuracy: 80, TimingAccuracy: 50, CostAccuracy: 50, Score: 60}
+		if len(report.Trend) != 2 || report.Trend[0].Period != "2024-03" || report.Trend[0].AccuracyScore != wantMarch {
+			t.Fatalf("trend = %+v, want March scored %+v first", report.Trend, wantMarch)
+		}
+		if april := report.Trend[1]; april.Period != "2024-04" || april.Score != 95 {
+			t.Errorf("April = %+v, want a score of 95", april)
+		}
+		if report.Overall.Plans != 2 || report.Overall.QuantityAccuracy != 87.5 || report.Overall.CostAccuracy != 70 {
+			t.Errorf("overall = %+v, want 2 plans at 87.5%% quantity and 70%% cost accuracy", report.Overall)
+		}
+		if len(report.Warehouses) != 1 || report.Warehouses[0].ID != warehouse.ID || report.Warehouses[0].Change != 35 {
+			t.Errorf("warehouses = %+v, want one improving by 35", report.Warehouses)
+		}
+		if len(report.Planners) != 1 || report.Planners[0].ID != planner.ID || report.Planners[0].Name != planner.Name {
+			t.Errorf("planners = %+v, want the planner", report.Planners)
+		}
+		if len(report.Plans) != 2 || report.Plans[0].PlanID != march.ID {
+			t.Errorf("plans = %+v, want March then April", report.Plans)
+		}
+	})
+
+	t.Run("weekly", func(t *testing.T) {
+		w := s.do(t, "GET", "/api/v1/analytics/plan-accuracy?from=2024-01-01&period=week", token, nil)
+		var resp struct{ Data models.PlanAccuracyReport }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Data.Trend) != 2 || resp.Data.Trend[0].Period != "2024-W10" {
+			t.Errorf("weekly trend = %+v, want 2024-W10 first", resp.Data.Trend)
+		}
+	})
+
+	t.Run("invalid period", func(t *testing.T) {
+		if w := s.do(t, "GET", "/api/v1/analytics/plan-accuracy?period=day", token, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("period=day status = %d, want 400", w.Code)
+		}
+	})
+}
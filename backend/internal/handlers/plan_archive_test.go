@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestArchiveAndPurgePlan tests archiving, hiding archived plans from lists
+// and purging deleted or archived plans
+func TestArchiveAndPurgePlan(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/plans", s.h.ListPlans)
+	s.api.DELETE("/plans/:id", s.h.DeletePlan)
+	s.api.POST("/plans/:id/archive", s.h.ArchivePlan)
+	s.api.DELETE("/admin/plans/:id", s.h.AdminMiddleware(), s.h.PurgePlan)
+
+	token := s.login(t, "admin")
+	warehouse := s.fx.Warehouse()
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	completed := s.fx.Plan(warehouse, start, 7, testkit.WithStatus(planstate.Completed))
+	draft := s.fx.Plan(warehouse, start, 7)
+	s.fx.Route(completed, s.fx.Vehicle(warehouse), 1, s.fx.Customer())
+
+	listed := func(t *testing.T, query string) int {
+		t.Helper()
+		w := s.do(t, "GET", "/api/v1/plans"+query, token, nil)
+		var resp struct{ Data []models.Plan }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return len(resp.Data)
+	}
+	path := func(format string, id int64) string { return fmt.Sprintf(format, id) }
+
+	t.Run("only finished plans", func(t *testing.T) {
+		if w := s.do(t, "POST", path("/api/v1/plans/%d/archive", draft.ID), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("archive draft status = %d, want %d", w.Code, http.StatusConflict)
+		}
+		if w := s.do(t, "DELETE", path("/api/v1/admin/plans/%d", completed.ID), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("purge completed plan status = %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("archive", func(t *testing.T) {
+		if w := s.do(t, "POST", path("/api/v1/plans/%d/archive", completed.ID), token, nil); w.Code != http.StatusOK {
+			t.Fatalf("archive status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		if n := listed(t, ""); n != 1 {
+			t.Errorf("listed %d plans, want 1 without archived", n)
+		}
+		if n := listed(t, "?include_archived=true"); n != 2 {
+			t.Errorf("listed %d plans with include_archived, want 2", n)
+		}
+		if n := listed(t, "?status=archived"); n != 1 {
+			t.Errorf("listed %d archived plans, want 1", n)
+		}
+	})
+
+	t.Run("purge archived", func(t *testing.T) {
+		if w := s.do(t, "DELETE", path("/api/v1/admin/plans/%d", completed.ID), token, nil); w.Code != http.StatusOK {
+			t.Fatalf("purge archived plan status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		if routes, _ := database.GetRoutesByPlan(s.db, completed.ID); len(routes) != 0 {
+			t.Errorf("purged plan has %d routes, want 0", len(routes))
+		}
+	})
+
+	t.Run("purge deleted", func(t *testing.T) {
+		s.do(t, "DELETE", path("/api/v1/plans/%d", draft.ID), token, nil)
+		if n := listed(t, "?include_archived=true"); n != 0 {
+			t.Errorf("listed %d plans after delete, want 0", n)
+		}
+		if w := s.do(t, "DELETE", path("/api/v1/admin/plans/%d", draft.ID), token, nil); w.Code != http.StatusOK {
+			t.Errorf("purge deleted plan status = %d, want 200", w.Code)
+		}
+		if w := s.do(t, "DELETE", path("/api/v1/admin/plans/%d", draft.ID), token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("purge purged plan status = %d, want 404", w.Code)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ClonePlanRequest struct {
+	Name          string `json:"name"`
+	StartDate     string `json:"start_date" binding:"required"`
+	IncludeRoutes bool   `json:"include_routes"`
+}
+
+// ClonePlan handles POST /api/v1/plans/:id/clone
+// The copy keeps the source plan's length and warehouse. With include_routes
+// its routes and stops are copied too, shifted by the same number of days,
+// and drivers are re-assigned from the roster on the new dates.
+func (h *Handler) ClonePlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	var req ClonePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid start date format (use YYYY-MM-DD)")
+		return
+	}
+
+	source, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	offset := startDate.Sub(source.StartDate)
+	userID := c.GetInt64("userID")
+	clone := &models.Plan{
+		Name:               req.Name,
+		StartDate:          startDate,
+		EndDate:            source.EndDate.Add(offset),
+		Status:             planstate.Draft,
+		WarehouseID:        source.WarehouseID,
+		CustomerIDs:        source.CustomerIDs,
+		VehicleIDs:         source.VehicleIDs,
+		CustomerTags:       source.CustomerTags,
+		VehicleTags:        source.VehicleTags,
+		Objective:          source.Objective,
+		MaxCostIncreasePct: source.MaxCostIncreasePct,
+		CreatedBy:          &userID,
+	}
+	if clone.Name == "" {
+		clone.Name = source.Name + " (copy)"
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.CreatePlan(tx, clone); err != nil {
+			return err
+		}
+		if !req.IncludeRoutes {
+			return nil
+		}
+
+		routes, err := database.GetRoutesByPlan(tx, source.ID)
+		if err != nil {
+			return err
+		}
+		if len(routes) == 0 {
+			return nil
+		}
+		for _, r := range routes {
+			route := &models.Route{
+				PlanID:        clone.ID,
+				VehicleID:     r.VehicleID,
+				Day:           r.Day,
+				Date:          r.Date.Add(offset),
+				PlannedStart:  shiftTime(r.PlannedStart, offset),
+				PlannedEnd:    shiftTime(r.PlannedEnd, offset),
+				TotalDistance: r.TotalDistance,
+				TotalCost:     r.TotalCost,
+				TotalLoad:     r.TotalLoad,
+				CostBreakdown: r.CostBreakdown,
+			}
+			if err := database.CreateRouteTx(tx, route); err != nil {
+				return err
+			}
+			for _, s := range r.Stops {
+				stop := &models.Stop{
+					RouteID:         route.ID,
+					CustomerID:      s.CustomerID,
+					PlaceID:         s.PlaceID,
+					Type:            s.Type,
+					Sequence:        s.Sequence,
+					DurationMinutes: s.DurationMinutes,
+					Quantity:        s.Quantity,
+					ArrivalTime:     s.ArrivalTime,
+				}
+				if err := database.CreateStopTx(tx, stop); err != nil {
+					return err
+				}
+			}
+		}
+		if err := database.AssignRosteredDriversTx(tx, clone.ID); err != nil {
+			return err
+		}
+
+		clone.Status = planstate.Optimized
+		clone.TotalCost = source.TotalCost
+		clone.TotalDistance = source.TotalDistance
+		if err := database.UpdatePlanStatusTx(tx, clone.ID, clone.Status, clone.TotalCost, clone.TotalDistance); err != nil {
+			return err
+		}
+		params := models.SolutionParameters{
+			StartDate:       clone.StartDate.Format("2006-01-02"),
+			PlanningHorizon: int(clone.EndDate.Sub(clone.StartDate).Hours()/24) + 1,
+		}
+		return snapshotSolutionTx(tx, clone.ID, "clone", nil, params, "Cloned from plan "+strconv.FormatInt(source.ID, 10), userID)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to clone plan")
+		return
+	}
+	createdResponse(c, clone)
+}
+
+// shiftTime moves an optional timestamp by offset
+func shiftTime(t *time.Time, offset time.Duration) *time.Time {
+	if t == nil {
+		return nil
+	}
+	shifted := t.Add(offset)
+	return &shifted
+}
@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestClonePlan tests copying a plan forward with and without its routes
+func TestClonePlan(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	customer := &models.Customer{Name: "Customer", Latitude: 40.7, Longitude: -74.0}
+	database.CreateCustomer(s.db, customer)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Week 1", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "optimized", TotalCost: 150, TotalDistance: 40, WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+	plannedStart := start.AddDate(0, 0, 2).Add(8 * time.Hour)
+	route := &models.Route{PlanID: plan.ID, Day: 3, Date: start.AddDate(0, 0, 2), PlannedStart: &plannedStart, TotalCost: 150, TotalDistance: 40, TotalLoad: 20}
+	database.CreateRoute(s.db, route)
+	database.CreateStop(s.db, &models.Stop{RouteID: route.ID, CustomerID: &customer.ID, Sequence: 1, Quantity: 20, ArrivalTime: "08:30"})
+
+	s.api.POST("/plans/:id/clone", s.h.ClonePlan)
+	token := s.login(t, "user")
+
+	clone := func(t *testing.T, body ClonePlanRequest) (int, models.Plan) {
+		t.Helper()
+		w := s.do(t, "POST", "/api/v1/plans/1/clone", token, body)
+		var response struct {
+			Data models.Plan
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code, response.Data
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"without routes", func(t *testing.T) {
+			status, empty := clone(t, ClonePlanRequest{StartDate: "2024-01-08"})
+			if status != http.StatusCreated {
+				t.Fatalf("ClonePlan() status = %d, want %d", status, http.StatusCreated)
+			}
+			if empty.Name != "Week 1 (copy)" || empty.Status != "draft" || !empty.EndDate.Equal(start.AddDate(0, 0, 13)) {
+				t.Errorf("clone = %+v, want a draft copy ending 2024-01-14", empty)
+			}
+			if routes, _ := database.GetRoutesByPlan(s.db, empty.ID); len(routes) != 0 {
+				t.Errorf("clone without routes has %d routes, want 0", len(routes))
+			}
+		}},
+		{"with routes", func(t *testing.T) {
+			status, full := clone(t, ClonePlanRequest{Name: "Week 2", StartDate: "2024-01-08", IncludeRoutes: true})
+			if status != http.StatusCreated {
+				t.Fatalf("ClonePlan(include_routes) status = %d, want %d", status, http.StatusCreated)
+			}
+			if full.Name != "Week 2" || full.Status != "optimized" || full.TotalCost != 150 {
+				t.Errorf("clone = %+v, want optimized copy named Week 2", full)
+			}
+			routes, _ := database.GetRoutesByPlan(s.db, full.ID)
+			if len(routes) != 1 || len(routes[0].Stops) != 1 {
+				t.Fatalf("cloned routes = %+v, want one route with one stop", routes)
+			}
+			if !routes[0].Date.Equal(start.AddDate(0, 0, 9)) || routes[0].Day != 3 {
+				t.Errorf("cloned route day %d on %v, want day 3 on 2024-01-10", routes[0].Day, routes[0].Date)
+			}
+			if routes[0].PlannedStart == nil || !routes[0].PlannedStart.Equal(plannedStart.AddDate(0, 0, 7)) {
+				t.Errorf("cloned planned start = %v, want shifted by a week", routes[0].PlannedStart)
+			}
+			if routes[0].Stops[0].Quantity != 20 || *routes[0].Stops[0].CustomerID != customer.ID {
+				t.Errorf("cloned stop = %+v, want customer %d with 20", routes[0].Stops[0], customer.ID)
+			}
+			if solutions, _ := database.ListPlanSolutions(s.db, full.ID); len(solutions) != 1 || solutions[0].Source != "clone" {
+				t.Errorf("clone solutions = %+v, want one clone snapshot", solutions)
+			}
+		}},
+		{"bad start date", func(t *testing.T) {
+			if status, _ := clone(t, ClonePlanRequest{StartDate: "next week"}); status != http.StatusBadRequest {
+				t.Errorf("ClonePlan(bad date) status = %d, want %d", status, http.StatusBadRequest)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanCosts handles GET /api/v1/plans/:id/costs
+// Decomposes the plan's cost into fixed vehicle, per-km, holding and penalty
+// cost, in total, per day and per route, from the breakdown stored when the
+// routes were optimized.
+func (h *Handler) GetPlanCosts(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if !h.canSeePlan(c, plan) {
+		return
+	}
+
+	routes, err := database.GetRoutesByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+	successResponse(c, planCosts(plan.ID, routes))
+}
+
+// planCosts sums route breakdowns per day and for the plan; routes come
+// ordered by day
+func planCosts(planID int64, routes []models.Route) models.PlanCosts {
+	costs := models.PlanCosts{PlanID: planID, Days: []models.DayCosts{}, Routes: []models.RouteCosts{}}
+	for _, r := range routes {
+		rc := routeCosts(r)
+		costs.Routes = append(costs.Routes, rc)
+
+		if n := len(costs.Days); n == 0 || costs.Days[n-1].Day != r.Day {
+			costs.Days = append(costs.Days, models.DayCosts{Day: r.Day, Date: r.Date})
+		}
+		day := &costs.Days[len(costs.Days)-1]
+		addCosts(&day.CostBreakdown, rc.CostBreakdown)
+		day.TotalCost += rc.TotalCost
+		day.Routes++
+
+		addCosts(&costs.CostBreakdown, rc.CostBreakdown)
+		costs.TotalCost += rc.TotalCost
+	}
+	return costs
+}
+
+// routeCosts reads a route's stored breakdown. Routes optimized before
+// breakdowns were stored get fixed and distance cost from their vehicle and
+// the rest of their cost as penalty. Electric vehicles' routes also carry
+// the energy their distance takes at the vehicle's consumption.
+func routeCosts(r models.Route) models.RouteCosts {
+	rc := models.RouteCosts{
+		RouteID:       r.ID,
+		Day:           r.Day,
+		Date:          r.Date,
+		VehicleID:     r.VehicleID,
+		VehicleName:   vehicleName(r.Vehicle),
+		CostBreakdown: r.CostBreakdown,
+	}
+	if rc.CostBreakdown == (models.CostBreakdown{}) && r.TotalCost > 0 {
+		rc.Derived = true
+		if r.Vehicle != nil {
+			rc.FixedCost = r.Vehicle.FixedCost
+			rc.DistanceCost = r.TotalDistance * r.Vehicle.CostPerKm
+		}
+		if penalty := r.TotalCost - rc.FixedCost - rc.DistanceCost; penalty > 0.01 {
+			rc.PenaltyCost = penalty
+		}
+	}
+	rc.TotalCost = rc.FixedCost + rc.DistanceCost + rc.HoldingCost + rc.PenaltyCost
+	if r.Vehicle != nil && r.Vehicle.Electric {
+		rc.EnergyKwh = r.TotalDistance * r.Vehicle.ConsumptionPerKm
+	}
+	return rc
+}
+
+func addCosts(sum *models.CostBreakdown, b models.CostBreakdown) {
+	sum.FixedCost += b.FixedCost
+	sum.DistanceCost += b.DistanceCost
+	sum.HoldingCost += b.HoldingCost
+	sum.PenaltyCost += b.PenaltyCost
+}
@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestGetPlanCosts tests that optimization stores route cost breakdowns and
+// that routes stored without one are derived from their vehicle
+func TestGetPlanCosts(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.GET("/plans/:id/costs", s.h.GetPlanCosts)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	s.fx.Customer(func(c *models.Customer) { c.HoldingCost = 2 })
+	vehicle := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.FixedCost = 50 })
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3)
+	legacy := s.fx.Route(plan, vehicle, 2, s.fx.Customer())
+	s.db.Model(legacy).Update("locked", true)
+
+	if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID), token, nil); w.Code != http.StatusOK {
+		t.Fatalf("optimize status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+
+	w := s.do(t, "GET", fmt.Sprintf("/api/v1/plans/%d/costs", plan.ID), token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("costs status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	var resp struct{ Data models.PlanCosts }
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	costs := resp.Data
+
+	if len(costs.Routes) != 2 || len(costs.Days) != 2 {
+		t.Fatalf("costs have %d routes and %d days, want 2 and 2", len(costs.Routes), len(costs.Days))
+	}
+
+	t.Run("optimized route", func(t *testing.T) {
+		// The fake optimizer's route costs 100 for 10 km delivering 5 units,
+		// which last half a day at the customer's demand of 10
+		optimized := costs.Routes[0]
+		if optimized.Derived || optimized.FixedCost != 50 || optimized.DistanceCost != 10 || optimized.PenaltyCost != 40 || optimized.HoldingCost != 2.5 {
+			t.Errorf("optimized route costs = %+v, want fixed 50, distance 10, penalty 40, holding 2.5", optimized)
+		}
+	})
+
+	t.Run("derived route", func(t *testing.T) {
+		if got := costs.Routes[1]; !got.Derived || got.RouteID != legacy.ID || got.FixedCost != 50 || got.PenaltyCost != 40 || got.HoldingCost != 0 {
+			t.Errorf("legacy route costs = %+v, want derived fixed 50 and penalty 40", got)
+		}
+	})
+
+	t.Run("totals", func(t *testing.T) {
+		if costs.Days[0].Day != 1 || costs.Days[0].TotalCost != 102.5 || costs.Days[1].Routes != 1 {
+			t.Errorf("days = %+v, want day 1 at 102.5 and one route on day 2", costs.Days)
+		}
+		if math.Abs(costs.TotalCost-202.5) > 1e-9 || costs.FixedCost != 100 {
+			t.Errorf("plan total = %v with fixed %v, want 202.5 with fixed 100", costs.TotalCost, costs.FixedCost)
+		}
+	})
+}
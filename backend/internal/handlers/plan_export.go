@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/export"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportPlan handles GET /api/v1/plans/:id/export?format=xlsx|csv
+// Produces load sheets: one row per route and one per stop in delivery
+// order. The file is written to the response as it is generated.
+func (h *Handler) ExportPlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	format := c.DefaultQuery("format", export.XLSX)
+	if format != export.XLSX && format != export.CSV {
+		errorResponse(c, http.StatusBadRequest, "format must be xlsx or csv")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if !h.canSeePlan(c, plan) {
+		return
+	}
+
+	routes, err := database.GetLoadSheetRoutes(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="plan-%d-load-sheet.%s"`, plan.ID, format))
+	c.Header("Content-Type", export.ContentType(format))
+	c.Status(http.StatusOK)
+
+	w, _ := export.New(format, c.Writer)
+	if err := writeLoadSheets(w, routes); err != nil {
+		// The status is already sent; all that is left is to cut the file short
+		log.Printf("Export of plan %d failed: %v", plan.ID, err)
+		c.Abort()
+	}
+}
+
+// writeLoadSheets writes the routes and stops sheets of a plan export
+func writeLoadSheets(w export.Writer, routes []models.Route) error {
+	err := w.Sheet("Routes", "Route ID", "Day", "Date", "Vehicle", "Driver", "Stops", "Load", "Distance (km)", "Cost")
+	if err != nil {
+		return err
+	}
+	for _, r := range routes {
+		err := w.Row(r.ID, r.Day, r.Date.Format("2006-01-02"), vehicleName(r.Vehicle), driverName(r.Driver),
+			len(r.Stops), r.TotalLoad, r.TotalDistance, r.TotalCost)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = w.Sheet("Stops", "Route ID", "Day", "Date", "Vehicle", "Sequence", "Type", "Customer", "Address", "Quantity", "ETA")
+	if err != nil {
+		return err
+	}
+	for _, r := range routes {
+		for _, s := range r.Stops {
+			name, address := stopLocation(s)
+			err := w.Row(r.ID, r.Day, r.Date.Format("2006-01-02"), vehicleName(r.Vehicle),
+				s.Sequence, s.Type, name, address, s.Quantity, s.ArrivalTime)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return w.Close()
+}
+
+// stopLocation returns the name and address of a stop's customer, or of
+// its place for break, refuel and other stops
+func stopLocation(s models.Stop) (name, address string) {
+	switch {
+	case s.Customer != nil:
+		return s.Customer.Name, s.Customer.Address
+	case s.Place != nil:
+		return s.Place.Name, s.Place.Address
+	}
+	return "", ""
+}
+
+func vehicleName(v *models.Vehicle) string {
+	if v == nil {
+		return ""
+	}
+	return v.Name
+}
+
+func driverName(d *models.Driver) string {
+	if d == nil {
+		return ""
+	}
+	return d.Name
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestExportPlan tests the CSV load sheet and format validation
+func TestExportPlan(t *testing.T) {
+	s := newTestServer(t)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start, Status: "optimized"}
+	database.CreatePlan(s.db, plan)
+	vehicle := &models.Vehicle{Name: "Truck 1", Capacity: 100, Available: true}
+	database.CreateVehicle(s.db, vehicle)
+	first := &models.Customer{Name: "Acme, Inc.", Address: "1 Main St", Latitude: 40.7, Longitude: -74.0}
+	second := &models.Customer{Name: "Globex", Address: "2 Side St", Latitude: 40.7, Longitude: -74.0}
+	database.CreateCustomer(s.db, first)
+	database.CreateCustomer(s.db, second)
+	route := &models.Route{PlanID: plan.ID, VehicleID: &vehicle.ID, Day: 1, Date: start, TotalLoad: 30, TotalDistance: 12.5, TotalCost: 40}
+	database.CreateRoute(s.db, route)
+	s.db.Create(&models.Stop{RouteID: route.ID, CustomerID: &second.ID, Sequence: 2, Quantity: 10, ArrivalTime: "09:30"})
+	s.db.Create(&models.Stop{RouteID: route.ID, CustomerID: &first.ID, Sequence: 1, Quantity: 20, ArrivalTime: "08:45"})
+	station := &models.Place{Name: "Shell A1", Kind: "fuel_station", Address: "Exit 12", Latitude: 40.8, Longitude: -74.1}
+	database.CreatePlace(s.db, station)
+	s.db.Create(&models.Stop{RouteID: route.ID, PlaceID: &station.ID, Type: "refuel", Sequence: 3, DurationMinutes: 15})
+
+	s.api.GET("/plans/:id/export", s.h.ExportPlan)
+	token := s.login(t, "user")
+	get := func(t *testing.T, query string) *httptest.ResponseRecorder {
+		t.Helper()
+		return s.do(t, "GET", "/api/v1/plans/1/export"+query, token, nil)
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"csv", func(t *testing.T) {
+			w := get(t, "?format=csv")
+			if w.Code != http.StatusOK {
+				t.Fatalf("ExportPlan(csv) status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if got := w.Header().Get("Content-Disposition"); got != `attachment; filename="plan-1-load-sheet.csv"` {
+				t.Errorf("Content-Disposition = %q", got)
+			}
+			want := "Routes\n" +
+				"Route ID,Day,Date,Vehicle,Driver,Stops,Load,Distance (km),Cost\n" +
+				"1,1,2024-01-01,Truck 1,,3,30,12.5,40\n" +
+				"\n" +
+				"Stops\n" +
+				"Route ID,Day,Date,Vehicle,Sequence,Type,Customer,Address,Quantity,ETA\n" +
+				"1,1,2024-01-01,Truck 1,1,delivery,\"Acme, Inc.\",1 Main St,20,08:45\n" +
+				"1,1,2024-01-01,Truck 1,2,delivery,Globex,2 Side St,10,09:30\n" +
+				"1,1,2024-01-01,Truck 1,3,refuel,Shell A1,Exit 12,0,\n"
+			if got := w.Body.String(); got != want {
+				t.Errorf("ExportPlan(csv) body = %q, want %q", got, want)
+			}
+		}},
+		{"xlsx by default", func(t *testing.T) {
+			w := get(t, "")
+			if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "PK") {
+				t.Errorf("ExportPlan() status = %d, want an xlsx (zip) body by default", w.Code)
+			}
+		}},
+		{"unknown format", func(t *testing.T) {
+			if w := get(t, "?format=pdf"); w.Code != http.StatusBadRequest {
+				t.Errorf("ExportPlan(pdf) status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanRoutesGeoJSON handles GET /api/v1/plans/:id/routes.geojson
+// Returns a bare GeoJSON feature collection, without the usual response
+// envelope, so map libraries and GIS tools can load it directly: the
+// warehouse and every stop as Points and every route as a LineString from
+// the warehouse through its stops and back.
+func (h *Handler) GetPlanRoutesGeoJSON(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if !h.canSeePlan(c, plan) {
+		return
+	}
+	if plan.WarehouseID == nil {
+		errorResponse(c, http.StatusBadRequest, "Plan has no warehouse assigned")
+		return
+	}
+	warehouse, err := database.GetWarehouse(h.db, *plan.WarehouseID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return
+	}
+
+	routes, err := database.GetLoadSheetRoutes(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+
+	c.Header("Content-Type", "application/geo+json")
+	c.JSON(http.StatusOK, planGeoJSON(warehouse, routes))
+}
+
+// planGeoJSON builds the feature collection of a plan's routes
+func planGeoJSON(warehouse *models.Warehouse, routes []models.Route) models.GeoJSONFeatureCollection {
+	depot := []float64{warehouse.Longitude, warehouse.Latitude}
+	features := []models.GeoJSONFeature{{
+		Type:     "Feature",
+		Geometry: models.GeoJSONGeometry{Type: "Point", Coordinates: depot},
+		Properties: map[string]interface{}{
+			"kind":         "warehouse",
+			"warehouse_id": warehouse.ID,
+			"name":         warehouse.Name,
+		},
+	}}
+
+	for _, r := range routes {
+		line := [][]float64{depot}
+		var stops []models.GeoJSONFeature
+		for _, s := range r.Stops {
+			if s.Customer == nil {
+				continue
+			}
+			point := []float64{s.Customer.Longitude, s.Customer.Latitude}
+			line = append(line, point)
+			stops = append(stops, models.GeoJSONFeature{
+				Type:     "Feature",
+				Geometry: models.GeoJSONGeometry{Type: "Point", Coordinates: point},
+				Properties: map[string]interface{}{
+					"kind":         "stop",
+					"stop_id":      s.ID,
+					"route_id":     r.ID,
+					"day":          r.Day,
+					"vehicle_id":   r.VehicleID,
+					"sequence":     s.Sequence,
+					"customer_id":  s.CustomerID,
+					"customer":     s.Customer.Name,
+					"quantity":     s.Quantity,
+					"arrival_time": s.ArrivalTime,
+				},
+			})
+		}
+		line = append(line, depot)
+
+		features = append(features, models.GeoJSONFeature{
+			Type:     "Feature",
+			Geometry: models.GeoJSONGeometry{Type: "LineString", Coordinates: line},
+			Properties: map[string]interface{}{
+				"kind":       "route",
+				"route_id":   r.ID,
+				"day":        r.Day,
+				"date":       r.Date.Format("2006-01-02"),
+				"vehicle_id": r.VehicleID,
+				"vehicle":    vehicleName(r.Vehicle),
+				"driver_id":  r.DriverID,
+				"driver":     driverName(r.Driver),
+				"stops":      len(stops),
+				"load":       r.TotalLoad,
+				"distance":   r.TotalDistance,
+				"cost":       r.TotalCost,
+				"locked":     r.Locked,
+			},
+		})
+		features = append(features, stops...)
+	}
+	return models.GeoJSONFeatureCollection{Type: "FeatureCollection", Features: features}
+}
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestGetPlanRoutesGeoJSON tests the warehouse, route and stop features
+func TestGetPlanRoutesGeoJSON(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.0, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	near := &models.Customer{Name: "Near", Latitude: 40.1, Longitude: -74.1}
+	far := &models.Customer{Name: "Far", Latitude: 40.2, Longitude: -74.2}
+	database.CreateCustomer(s.db, near)
+	database.CreateCustomer(s.db, far)
+	vehicle := &models.Vehicle{Name: "Truck", Capacity: 100, Available: true}
+	database.CreateVehicle(s.db, vehicle)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start, Status: "optimized", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+	route := &models.Route{PlanID: plan.ID, VehicleID: &vehicle.ID, Day: 1, Date: start, TotalLoad: 30}
+	database.CreateRoute(s.db, route)
+	s.db.Create(&models.Stop{RouteID: route.ID, CustomerID: &far.ID, Sequence: 2, Quantity: 10})
+	s.db.Create(&models.Stop{RouteID: route.ID, CustomerID: &near.ID, Sequence: 1, Quantity: 20})
+
+	s.api.GET("/plans/:id/routes", s.h.GetPlanRoutes)
+	s.api.GET("/plans/:id/routes.geojson", s.h.GetPlanRoutesGeoJSON)
+	token := s.login(t, "user")
+
+	w := s.do(t, "GET", "/api/v1/plans/1/routes.geojson", token, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GetPlanRoutesGeoJSON() status = %d, body = %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("Content-Type"); got != "application/geo+json" {
+		t.Errorf("Content-Type = %q, want application/geo+json", got)
+	}
+
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates json.RawMessage
+			}
+			Properties map[string]interface{}
+		}
+	}
+	json.Unmarshal(w.Body.Bytes(), &fc)
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 4 {
+		t.Fatalf("GeoJSON = %s, want a warehouse, a route and two stops", w.Body.String())
+	}
+	t.Run("feature kinds", func(t *testing.T) {
+		kinds := []string{"warehouse", "route", "stop", "stop"}
+		for i, f := range fc.Features {
+			if f.Properties["kind"] != kinds[i] {
+				t.Errorf("feature %d kind = %v, want %s", i, f.Properties["kind"], kinds[i])
+			}
+		}
+	})
+
+	t.Run("route line", func(t *testing.T) {
+		line := fc.Features[1]
+		wantLine := "[[-74,40],[-74.1,40.1],[-74.2,40.2],[-74,40]]"
+		if line.Geometry.Type != "LineString" || string(line.Geometry.Coordinates) != wantLine {
+			t.Errorf("route geometry = %s %s, want LineString %s", line.Geometry.Type, line.Geometry.Coordinates, wantLine)
+		}
+		if line.Properties["vehicle"] != "Truck" || line.Properties["load"] != 30.0 || line.Properties["day"] != 1.0 {
+			t.Errorf("route properties = %v, want vehicle Truck, load 30, day 1", line.Properties)
+		}
+		if stop := fc.Features[2]; stop.Properties["customer"] != "Near" || string(stop.Geometry.Coordinates) != "[-74.1,40.1]" {
+			t.Errorf("first stop = %v %s, want Near at [-74.1,40.1]", stop.Properties, stop.Geometry.Coordinates)
+		}
+	})
+}
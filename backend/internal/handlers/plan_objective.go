@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SetPlanObjectiveRequest struct {
+	Objective          string  `json:"objective" binding:"required,oneof=cost carbon"`
+	MaxCostIncreasePct float64 `json:"max_cost_increase_pct" binding:"gte=0"`
+	Version            int64   `json:"version"`
+}
+
+// SetPlanObjective handles PUT /api/v1/plans/:id/objective
+// Plans are optimized for cost unless set to carbon, which keeps the
+// lowest-emission routes costing at most max_cost_increase_pct more than
+// the cheapest (see optimizeForCarbon). The change applies from the next
+// optimization.
+func (h *Handler) SetPlanObjective(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	var req SetPlanObjectiveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	if _, err := database.SetPlanObjective(h.db, id, version, req.Objective, req.MaxCostIncreasePct); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		if versionConflict(c, "Plan", err) {
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update plan")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	setETag(c, plan.Version)
+	successResponse(c, plan)
+}
+
+// co2PerKm returns the kg of CO2 a vehicle emits per km: its own figure,
+// else none for an electric vehicle and the configured default for others
+func (h *Handler) co2PerKm(v *models.Vehicle) float64 {
+	switch {
+	case v.CO2PerKm > 0:
+		return v.CO2PerKm
+	case v.Electric:
+		return 0
+	}
+	return h.config.DefaultCO2PerKm
+}
+
+// vehicleEmissions returns the kg of CO2 per km of vehicles by ID
+func (h *Handler) vehicleEmissions(vehicles []models.Vehicle) map[int64]float64 {
+	co2 := make(map[int64]float64, len(vehicles))
+	for i := range vehicles {
+		co2[vehicles[i].ID] = h.co2PerKm(&vehicles[i])
+	}
+	return co2
+}
+
+// planCO2Kg returns the kg of CO2 a plan's routes emit
+func (h *Handler) planCO2Kg(routes []models.Route) float64 {
+	var kg float64
+	for _, r := range routes {
+		if r.Vehicle != nil {
+			kg += r.TotalDistance * h.co2PerKm(r.Vehicle)
+		}
+	}
+	return math.Round(kg*100) / 100
+}
+
+// optimizeForCarbon looks for routes emitting less CO2 than the cheapest
+// ones, cheapest, solved from optReq. The request is solved again priced
+// on emissions at each of optimizer.CarbonWeights in turn, and the first
+// result emitting less and costing at most maxIncreasePct more than the
+// cheapest routes is returned, repriced at the vehicles' cost. Otherwise,
+// or when those solves fail, the cheapest routes are kept. The tradeoff is
+// returned with the routes, and the runs of every solve with those of
+// cheapest.
+func (h *Handler) optimizeForCarbon(run *models.OptimizationRun, optReq *optimizer.OptimizeRequest, windows []optimizer.Window,
+	cheapest *optimizer.OptimizeResponse, runs []*models.OptimizationRun, co2 map[int64]float64, maxIncreasePct float64,
+) (*optimizer.OptimizeResponse, *models.ObjectiveTradeoff, []*models.OptimizationRun) {
+	cents := func(x float64) float64 { return math.Round(x*100) / 100 }
+	baselineCost := optimizer.OperatingCost(optReq, cheapest.Routes)
+	baselineCO2 := optimizer.Emissions(cheapest.Routes, co2)
+	tradeoff := &models.ObjectiveTradeoff{
+		BaselineCost:  cents(baselineCost),
+		BaselineCO2Kg: cents(baselineCO2),
+		Cost:          cents(baselineCost),
+		CO2Kg:         cents(baselineCO2),
+	}
+
+	for _, weight := range optimizer.CarbonWeights {
+		carbonRun := *run
+		resp, carbonRuns, err := h.optimizeWindows(&carbonRun, optimizer.CarbonRequest(optReq, co2, weight), windows, nil)
+		runs = append(runs, carbonRuns...)
+		if err != nil || !resp.Success {
+			log.Printf("Carbon optimization at weight %g failed, keeping the cheapest routes: %v", weight, err)
+			break
+		}
+		cost, kg := optimizer.OperatingCost(optReq, resp.Routes), optimizer.Emissions(resp.Routes, co2)
+		if kg >= baselineCO2 || cost > baselineCost*(1+maxIncreasePct/100) {
+			continue
+		}
+
+		optimizer.RepriceRoutes(optReq, resp)
+		tradeoff.Cost, tradeoff.CO2Kg, tradeoff.CO2SavedKg = cents(cost), cents(kg), cents(baselineCO2-kg)
+		if baselineCost > 0 {
+			tradeoff.CostIncreasePct = cents((cost/baselineCost - 1) * 100)
+		}
+		tradeoff.CarbonWeight = weight
+		return resp, tradeoff, runs
+	}
+	return cheapest, tradeoff, runs
+}
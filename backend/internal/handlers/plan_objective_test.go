@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestCarbonObjective tests that carbon plans take lower-emission routes
+// within their cost increase limit, keep the cheapest ones beyond it, and
+// report the tradeoff in the summary
+func TestCarbonObjective(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.DefaultCO2PerKm = 0.9 })
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.PUT("/plans/:id/objective", s.h.SetPlanObjective)
+	s.api.GET("/plans/:id/summary", s.h.GetPlanSummary)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	customer := s.fx.Customer()
+	diesel := s.fx.Vehicle(warehouse)
+	electric := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.Electric, v.CostPerKm = true, 1.2 })
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1)
+
+	// Either vehicle drives the customer's 100 km round trip; the diesel
+	// one costs 100 and emits 90 kg, the electric one costs 120
+	on := func(vehicle *models.Vehicle) testkit.Script {
+		return func(req *optimizer.OptimizeRequest) *optimizer.OptimizeResponse {
+			route := optimizer.RouteResult{Day: 1, Date: req.StartDate, VehicleID: vehicle.ID, TotalDistance: 100, TotalCost: 100, TotalLoad: 5,
+				Stops: []optimizer.StopResult{{CustomerID: customer.ID, Sequence: 1, Quantity: 5, ArrivalTime: "08:30"}}}
+			return &optimizer.OptimizeResponse{Success: true, Routes: []optimizer.RouteResult{route}, TotalCost: 100, TotalDistance: 100}
+		}
+	}
+	optimize := func(t *testing.T) {
+		t.Helper()
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID), token, nil); w.Code != http.StatusOK {
+			t.Fatalf("optimize status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+	}
+	summarize := func(t *testing.T) models.PlanSummary {
+		t.Helper()
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/plans/%d/summary", plan.ID), token, nil)
+		var resp struct{ Data models.PlanSummary }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	setObjective := func(t *testing.T, maxPct float64) {
+		t.Helper()
+		w := s.do(t, "PUT", fmt.Sprintf("/api/v1/plans/%d/objective", plan.ID), token,
+			SetPlanObjectiveRequest{Objective: optimizer.ObjectiveCarbon, MaxCostIncreasePct: maxPct})
+		if w.Code != http.StatusOK {
+			t.Fatalf("set objective status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+	}
+
+	t.Run("within the limit", func(t *testing.T) {
+		setObjective(t, 25)
+		s.opt.Then(on(diesel), on(electric))
+		optimize(t)
+		carbonReq := s.opt.LastRequest()
+		for _, v := range carbonReq.Vehicles {
+			if v.ID == electric.ID && v.CostPerKm != 0 || v.ID == diesel.ID && v.CostPerKm <= 0 {
+				t.Errorf("carbon request vehicle %d costs %v per km, want emissions priced", v.ID, v.CostPerKm)
+			}
+		}
+		summary := summarize(t)
+		want := models.ObjectiveTradeoff{BaselineCost: 100, BaselineCO2Kg: 90, Cost: 120, CostIncreasePct: 20, CO2SavedKg: 90, CarbonWeight: 1}
+		if summary.ObjectiveTradeoff == nil || *summary.ObjectiveTradeoff != want || summary.TotalCO2Kg != 0 || summary.TotalCost != 120 {
+			t.Errorf("summary within 25%% = cost %v, %v kg, tradeoff %+v, want the electric route at 120 with %+v",
+				summary.TotalCost, summary.TotalCO2Kg, summary.ObjectiveTradeoff, want)
+		}
+	})
+
+	t.Run("beyond the limit", func(t *testing.T) {
+		// a 10% limit rules out the electric route at both weights
+		setObjective(t, 10)
+		s.opt.Then(on(diesel), on(electric), on(electric))
+		optimize(t)
+		summary := summarize(t)
+		if got := summary.ObjectiveTradeoff; got == nil || got.CarbonWeight != 0 || got.CO2Kg != 90 || summary.TotalCO2Kg != 90 || summary.TotalCost != 100 {
+			t.Errorf("summary within 10%% = cost %v, %v kg, tradeoff %+v, want the diesel route kept", summary.TotalCost, summary.TotalCO2Kg, got)
+		}
+		fallback := false
+		for _, f := range summary.RiskFlags {
+			fallback = fallback || f.Code == "carbon_fallback"
+		}
+		if !fallback {
+			t.Errorf("risk flags = %+v, want carbon_fallback", summary.RiskFlags)
+		}
+	})
+
+	t.Run("unknown objective", func(t *testing.T) {
+		if w := s.do(t, "PUT", fmt.Sprintf("/api/v1/plans/%d/objective", plan.ID), token, map[string]string{"objective": "speed"}); w.Code != http.StatusBadRequest {
+			t.Errorf("unknown objective status = %d, want 400", w.Code)
+		}
+	})
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReplayInputsRequest struct {
+	AsOf string `json:"as_of"` // YYYY-MM-DD or RFC 3339, defaults to the plan's start date
+}
+
+// ReplayPlanInputs handles POST /api/v1/plans/:id/replay-inputs?link=true
+// Rebuilds the optimizer request the plan would have had at as_of. The base
+// is the request archived by the plan's last optimization up to then, or
+// current master data when there is none; inventories, demand rates and
+// inventory bounds are then taken from the latest snapshots at as_of. With
+// link=true the request is also stored and a download link returned.
+func (h *Handler) ReplayPlanInputs(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	var req ReplayInputsRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	asOf := plan.StartDate
+	if req.AsOf != "" {
+		if asOf, err = parseAsOf(req.AsOf); err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid as_of (use YYYY-MM-DD or RFC 3339)")
+			return
+		}
+	}
+
+	replay := models.ReplayInputs{PlanID: plan.ID, AsOf: asOf, Base: "master_data", CustomersWithoutSnapshot: []int64{}}
+	var optReq *optimizer.OptimizeRequest
+	run, err := database.GetLatestOptimizationRunBefore(h.db, plan.ID, "optimize", asOf)
+	switch {
+	case err == nil && run.Request != "":
+		optReq = &optimizer.OptimizeRequest{}
+		if err := json.Unmarshal([]byte(run.Request), optReq); err != nil {
+			errorResponse(c, http.StatusInternalServerError, fmt.Sprintf("Archived request of optimization run %d is unreadable", run.ID))
+			return
+		}
+		replay.Base = "optimization_run"
+		replay.BaseRunID = &run.ID
+	case err == nil || errors.Is(err, database.ErrNotFound):
+		var ok bool
+		if optReq, ok = h.masterDataRequest(c, plan); !ok {
+			return
+		}
+	default:
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch optimization runs")
+		return
+	}
+
+	if err := h.applySnapshots(optReq, asOf, &replay); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch inventory snapshots")
+		return
+	}
+
+	body, err := json.Marshal(optReq)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to encode optimizer request")
+		return
+	}
+	replay.Request = body
+
+	if c.Query("link") == "true" {
+		key := fmt.Sprintf("%sreplays/plan-%d-%s.json", storage.ExportsPrefix, plan.ID, asOf.UTC().Format("20060102T150405Z"))
+		link, err := h.storeArtifact(c.Request.Context(), key, "application/json", body)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to store replay inputs: "+err.Error())
+			return
+		}
+		replay.Link = link
+	}
+	successResponse(c, replay)
+}
+
+// masterDataRequest builds a plan's optimizer request from current master
+// data and the driver roster, as optimizing it now would
+func (h *Handler) masterDataRequest(c *gin.Context, plan *models.Plan) (*optimizer.OptimizeRequest, bool) {
+	if plan.WarehouseID == nil {
+		errorResponse(c, http.StatusBadRequest, "Plan has no warehouse assigned")
+		return nil, false
+	}
+	warehouse, err := database.GetWarehouse(h.db, *plan.WarehouseID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return nil, false
+	}
+	customers, err := h.customers.List()
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return nil, false
+	}
+	vehicles, err := database.ListAvailableVehiclesByWarehouse(h.db, warehouse.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
+		return nil, false
+	}
+
+	optReq := h.buildOptimizeRequest(plan, warehouse, planCustomers(plan, customers), planVehicles(plan, vehicles))
+	if err := h.applyRoster(optReq, plan.StartDate); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver roster")
+		return nil, false
+	}
+	return optReq, true
+}
+
+// applySnapshots replaces the inventory data of a request with the latest
+// snapshots taken at or before asOf. Demand rate and inventory bounds are
+// only taken from snapshots that recorded them.
+func (h *Handler) applySnapshots(optReq *optimizer.OptimizeRequest, asOf time.Time, replay *models.ReplayInputs) error {
+	ids := make([]int64, len(optReq.Customers))
+	for i, cust := range optReq.Customers {
+		ids[i] = cust.ID
+	}
+	snapshots, err := database.GetLatestInventorySnapshotsBefore(h.db, "customer", ids, &asOf)
+	if err != nil {
+		return err
+	}
+	for i := range optReq.Customers {
+		cust := &optReq.Customers[i]
+		s, ok := snapshots[cust.ID]
+		if !ok {
+			replay.CustomersWithoutSnapshot = append(replay.CustomersWithoutSnapshot, cust.ID)
+			continue
+		}
+		cust.CurrentInventory = s.InventoryLevel
+		if s.MaxInventory > 0 {
+			cust.DemandRate = s.DemandRate
+			cust.MinInventory = s.MinInventory
+			cust.MaxInventory = s.MaxInventory
+		}
+		replay.CustomersFromSnapshots++
+	}
+
+	warehouses, err := database.GetLatestInventorySnapshotsBefore(h.db, "warehouse", []int64{optReq.Warehouse.ID}, &asOf)
+	if err != nil {
+		return err
+	}
+	if s, ok := warehouses[optReq.Warehouse.ID]; ok {
+		optReq.Warehouse.Stock = s.InventoryLevel
+		replay.WarehouseFromSnapshot = true
+	}
+	return nil
+}
+
+// parseAsOf reads a date, meaning the start of that day, or a timestamp
+func parseAsOf(value string) (time.Time, error) {
+	if t, err := time.Parse("2006-01-02", value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,121 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// TestReplayPlanInputs tests rebuilding a plan's request from snapshots,
+// first over current master data and then over an archived run
+func TestReplayPlanInputs(t *testing.T) {
+	s := newTestServer(t)
+	if err := s.db.AutoMigrate(&models.InventorySnapshot{}); err != nil {
+		t.Fatalf("Failed to migrate inventory snapshots: %v", err)
+	}
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0, CurrentStock: 900}
+	database.CreateWarehouse(s.db, warehouse)
+	tracked := &models.Customer{Name: "Tracked", Latitude: 40.7, Longitude: -74.0, CurrentInventory: 90, DemandRate: 1, MaxInventory: 200}
+	untracked := &models.Customer{Name: "Untracked", Latitude: 40.7, Longitude: -74.0, CurrentInventory: 40}
+	database.CreateCustomer(s.db, tracked)
+	database.CreateCustomer(s.db, untracked)
+	database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 2), Status: "completed", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+
+	for _, snapshot := range []models.InventorySnapshot{
+		{EntityType: "customer", EntityID: tracked.ID, SnapshotTime: start.Add(-48 * time.Hour), InventoryLevel: 10},
+		{EntityType: "customer", EntityID: tracked.ID, SnapshotTime: start.Add(-time.Hour), InventoryLevel: 30, DemandRate: 5, MinInventory: 10, MaxInventory: 100},
+		{EntityType: "customer", EntityID: tracked.ID, SnapshotTime: start.Add(time.Hour), InventoryLevel: 70},
+		{EntityType: "warehouse", EntityID: warehouse.ID, SnapshotTime: start.Add(-time.Hour), InventoryLevel: 500},
+	} {
+		snapshot.SnapshotDate = snapshot.SnapshotTime.Truncate(24 * time.Hour)
+		database.CreateInventorySnapshot(s.db, &snapshot)
+	}
+
+	s.api.POST("/plans/:id/replay-inputs", s.h.ReplayPlanInputs)
+	token := s.login(t, "user")
+	replay := func(t *testing.T, body interface{}, wantStatus int) (models.ReplayInputs, optimizer.OptimizeRequest) {
+		t.Helper()
+		w := s.do(t, "POST", "/api/v1/plans/"+strconv.FormatInt(plan.ID, 10)+"/replay-inputs", token, body)
+		if w.Code != wantStatus {
+			t.Fatalf("ReplayPlanInputs(%v) status = %d, want %d, body = %s", body, w.Code, wantStatus, w.Body.String())
+		}
+		var resp struct {
+			Data models.ReplayInputs
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		var optReq optimizer.OptimizeRequest
+		json.Unmarshal(resp.Data.Request, &optReq)
+		return resp.Data, optReq
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"master data", func(t *testing.T) {
+			got, optReq := replay(t, nil, http.StatusOK)
+			if got.Base != "master_data" || got.CustomersFromSnapshots != 1 || len(got.CustomersWithoutSnapshot) != 1 || got.CustomersWithoutSnapshot[0] != untracked.ID || !got.WarehouseFromSnapshot {
+				t.Errorf("replay = %+v, want master data with one snapshot customer", got)
+			}
+			if optReq.StartDate != "2024-03-04" || optReq.Warehouse.Stock != 500 || len(optReq.Customers) != 2 {
+				t.Fatalf("request = %+v, want the plan window and snapshot warehouse stock", optReq)
+			}
+			for _, cust := range optReq.Customers {
+				switch cust.ID {
+				case tracked.ID:
+					if cust.CurrentInventory != 30 || cust.DemandRate != 5 || cust.MinInventory != 10 || cust.MaxInventory != 100 {
+						t.Errorf("tracked customer = %+v, want the snapshot before the start date", cust)
+					}
+				case untracked.ID:
+					if cust.CurrentInventory != 40 {
+						t.Errorf("untracked customer inventory = %v, want current 40", cust.CurrentInventory)
+					}
+				}
+			}
+		}},
+		{"older snapshot", func(t *testing.T) {
+			// An older snapshot that recorded only the level keeps the base bounds
+			_, optReq := replay(t, ReplayInputsRequest{AsOf: "2024-03-02T12:00:00Z"}, http.StatusOK)
+			if cust := optReq.Customers[0]; cust.CurrentInventory != 10 || cust.MaxInventory != 200 {
+				t.Errorf("tracked customer as of Mar 2 = %+v, want level 10 with max 200", cust)
+			}
+		}},
+		{"archived run", func(t *testing.T) {
+			archived, _ := json.Marshal(optimizer.OptimizeRequest{
+				Warehouse:       optimizer.WarehouseData{ID: warehouse.ID, Stock: 800},
+				Customers:       []optimizer.CustomerData{{ID: tracked.ID, Latitude: 1.5, CurrentInventory: 99, MaxInventory: 150}},
+				PlanningHorizon: 3,
+				StartDate:       "2024-03-04",
+			})
+			run := &models.OptimizationRun{PlanID: &plan.ID, Kind: "optimize", Status: "success", Request: string(archived)}
+			database.CreateOptimizationRun(s.db, run)
+			s.db.Model(run).Update("created_at", start.Add(-24*time.Hour))
+
+			got, optReq := replay(t, nil, http.StatusOK)
+			if got.Base != "optimization_run" || got.BaseRunID == nil || *got.BaseRunID != run.ID {
+				t.Errorf("replay = %+v, want the archived run as base", got)
+			}
+			if len(optReq.Customers) != 1 || optReq.Customers[0].Latitude != 1.5 || optReq.Customers[0].CurrentInventory != 30 || optReq.Warehouse.Stock != 500 {
+				t.Errorf("request = %+v, want the archived customer with snapshot inventory", optReq)
+			}
+		}},
+		{"bad as_of", func(t *testing.T) {
+			replay(t, ReplayInputsRequest{AsOf: "yesterday"}, http.StatusBadRequest)
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
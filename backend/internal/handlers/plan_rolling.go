@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SetPlanRollingRequest struct {
+	Rolling bool  `json:"rolling"`
+	Version int64 `json:"version"`
+}
+
+// SetPlanRolling handles PUT /api/v1/plans/:id/rolling
+// Rolling plans move forward every night: passed days are dropped, as many
+// days are appended and the open window is re-optimized (see package
+// rolling).
+func (h *Handler) SetPlanRolling(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	var req SetPlanRollingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	if _, err := database.SetPlanRolling(h.db, id, version, req.Rolling); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		if versionConflict(c, "Plan", err) {
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update plan")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	setETag(c, plan.Version)
+	successResponse(c, plan)
+}
+
+// ReoptimizeRolledPlan re-optimizes the open window of a plan moved by the
+// plan roller. Background runs are not counted against quotas.
+func (h *Handler) ReoptimizeRolledPlan(plan *models.Plan, fromDay int) error {
+	return h.reoptimizeWindow(plan, fromDay, 0, "roll", func() bool { return true })
+}
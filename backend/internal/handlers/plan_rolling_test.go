@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestSetPlanRolling tests turning a plan's rolling horizon on
+func TestSetPlanRolling(t *testing.T) {
+	s := newTestServer(t)
+
+	s.api.PUT("/plans/:id/rolling", s.h.SetPlanRolling)
+	token := s.login(t, "user")
+
+	plan := &models.Plan{
+		Name:      "Rolling",
+		StartDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 1, 7, 0, 0, 0, 0, time.UTC),
+		Status:    "optimized",
+	}
+	database.CreatePlan(s.db, plan)
+
+	put := func(t *testing.T, id string) *httptest.ResponseRecorder {
+		t.Helper()
+		return s.do(t, "PUT", "/api/v1/plans/"+id+"/rolling", token, SetPlanRollingRequest{Rolling: true})
+	}
+
+	t.Run("set", func(t *testing.T) {
+		if w := put(t, "1"); w.Code != http.StatusOK {
+			t.Fatalf("SetPlanRolling() status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+		}
+		stored, _ := database.GetPlan(s.db, plan.ID)
+		if !stored.Rolling {
+			t.Error("SetPlanRolling() did not store the flag")
+		}
+	})
+
+	t.Run("missing plan", func(t *testing.T) {
+		if w := put(t, "99"); w.Code != http.StatusNotFound {
+			t.Errorf("SetPlanRolling() for a missing plan status = %d, want %d", w.Code, http.StatusNotFound)
+		}
+	})
+}
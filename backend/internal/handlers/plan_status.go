@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// StaleOptimizationJobType is the job resetting plans left optimizing
+const StaleOptimizationJobType = "plans.reset_stale_optimizations"
+
+// staleOptimizationAge is how long a plan may stay optimizing before it is
+// taken to have been abandoned by a crash or restart. It is well above the
+// optimizer's timeout.
+const staleOptimizationAge = time.Hour
+
+// ResetStaleOptimizations is the StaleOptimizationJobType job. Plans
+// optimizing for longer than staleOptimizationAge are returned to optimized
+// when they have routes and to draft when not, so they can be optimized or
+// cancelled again.
+func (h *Handler) ResetStaleOptimizations(ctx context.Context, _ json.RawMessage) error {
+	reset, err := database.ResetStaleOptimizingPlans(h.db.WithContext(ctx), h.clock.Now().Add(-staleOptimizationAge))
+	if reset > 0 {
+		log.Printf("Stale optimizations: reset %d plans left optimizing", reset)
+	}
+	return err
+}
+
+// ApprovePlan handles POST /api/v1/plans/:id/approve
+// Approval releases an optimized plan to drivers and freezes its routes. It
+// creates the pending execution records of every route and stop.
+func (h *Handler) ApprovePlan(c *gin.Context) {
+	h.transitionPlan(c, planstate.Approved, func(extra map[string]interface{}) {
+		extra["approved_by"] = c.GetInt64("userID")
+		extra["approved_at"] = h.clock.Now()
+	}, createPlannedExecutionsTx)
+}
+
+// ExecutePlan handles POST /api/v1/plans/:id/execute
+// Routes that have no execution record yet get one, as on approval.
+func (h *Handler) ExecutePlan(c *gin.Context) {
+	h.transitionPlan(c, planstate.Executing, nil, createPlannedExecutionsTx)
+}
+
+// CompletePlan handles POST /api/v1/plans/:id/complete
+func (h *Handler) CompletePlan(c *gin.Context) {
+	h.transitionPlan(c, planstate.Completed, nil, nil)
+}
+
+// ArchivePlan handles POST /api/v1/plans/:id/archive
+// Archived plans are left out of plan lists unless include_archived=true.
+func (h *Handler) ArchivePlan(c *gin.Context) {
+	h.transitionPlan(c, planstate.Archived, nil, nil)
+}
+
+// CancelPlan handles POST /api/v1/plans/:id/cancel
+func (h *Handler) CancelPlan(c *gin.Context) {
+	h.transitionPlan(c, planstate.Cancelled, nil, nil)
+}
+
+// transitionPlan moves the plan in the :id parameter to status to, responding
+// with 409 when the lifecycle does not allow it. set can add columns to
+// update; then runs in the same transaction as the status change.
+func (h *Handler) transitionPlan(c *gin.Context, to string, set func(extra map[string]interface{}), then func(tx *gorm.DB, planID int64) error) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	if err := planstate.Check(plan.Status, to); err != nil {
+		errorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	extra := make(map[string]interface{})
+	if set != nil {
+		set(extra)
+	}
+	if then == nil {
+		err = h.plans.Transition(id, plan.Status, to, extra)
+	} else {
+		err = h.db.Transaction(func(tx *gorm.DB) error {
+			if err := database.TransitionPlanStatus(tx, id, plan.Status, to, extra); err != nil {
+				return err
+			}
+			return then(tx, id)
+		})
+	}
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusConflict, "Plan status changed concurrently, retry")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update plan status")
+		return
+	}
+
+	plan, err = h.plans.Get(id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated plan")
+		return
+	}
+	successResponse(c, plan)
+}
+
+// createPlannedExecutionsTx creates a pending execution record for every
+// route of a plan that has none, with a stop execution per stop carrying
+// the planned quantity and times
+func createPlannedExecutionsTx(tx *gorm.DB, planID int64) error {
+	routes, err := database.GetRoutesWithoutExecutionsTx(tx, planID)
+	if err != nil {
+		return err
+	}
+	executions := make([]models.RouteExecution, len(routes))
+	for i, r := range routes {
+		executions[i] = plannedExecution(r)
+	}
+	return database.CreateRouteExecutionsTx(tx, executions)
+}
+
+// plannedExecution is the pending execution record of a route
+func plannedExecution(r models.Route) models.RouteExecution {
+	execution := models.RouteExecution{
+		RouteID:          r.ID,
+		Status:           "pending",
+		PlannedDistance:  r.TotalDistance,
+		PlannedCost:      r.TotalCost,
+		PlannedLoad:      r.TotalLoad,
+		PlannedStartTime: r.PlannedStart,
+		PlannedEndTime:   r.PlannedEnd,
+		StopExecutions:   make([]models.StopExecution, len(r.Stops)),
+	}
+	arrivals := stopArrivals(r)
+	for i, s := range r.Stops {
+		stop := models.StopExecution{
+			StopID:          s.ID,
+			Status:          "pending",
+			PlannedQuantity: s.Quantity,
+			ServiceDuration: optimizer.ServiceMinutes,
+		}
+		if arrival, ok := arrivals[s.ID]; ok {
+			departure := arrival.Add(optimizer.ServiceMinutes * time.Minute)
+			stop.PlannedArrivalTime = &arrival
+			stop.PlannedDepartureTime = &departure
+		}
+		execution.StopExecutions[i] = stop
+	}
+	return execution
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/planstate"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestPlanApprovalWorkflow tests the plan lifecycle from draft to completed,
+// the role checks on approval and execution and what drivers can see
+func TestPlanApprovalWorkflow(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	database.CreateCustomer(s.db, &models.Customer{Name: "Customer", Latitude: 40.7, Longitude: -74.0, DemandRate: 10})
+	database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Released", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+	draft := &models.Plan{Name: "Still planning", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, draft)
+
+	s.api.GET("/plans", s.h.ListPlans)
+	s.api.GET("/plans/:id", s.h.GetPlan)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.POST("/plans/:id/approve", s.h.RoleMiddleware("admin", "manager"), s.h.ApprovePlan)
+	s.api.POST("/plans/:id/execute", s.h.RoleMiddleware("admin", "manager", "user"), s.h.ExecutePlan)
+	s.api.POST("/plans/:id/complete", s.h.RoleMiddleware("admin", "manager", "user"), s.h.CompletePlan)
+	s.api.POST("/plans/:id/cancel", s.h.RoleMiddleware("admin", "manager", "user"), s.h.CancelPlan)
+	user := s.login(t, "user")
+	manager := s.login(t, "manager")
+	driver := s.login(t, "driver")
+
+	planPath := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10)
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"approval needs a manager", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/approve", user, nil); w.Code != http.StatusForbidden {
+				t.Errorf("ApprovePlan() as user status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+			if w := s.do(t, "POST", planPath+"/approve", manager, nil); w.Code != http.StatusConflict {
+				t.Errorf("ApprovePlan() on draft status = %d, want %d", w.Code, http.StatusConflict)
+			}
+		}},
+		{"approve", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/optimize", manager, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			w := s.do(t, "POST", planPath+"/approve", manager, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("ApprovePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var approved struct {
+				Data models.Plan
+			}
+			json.Unmarshal(w.Body.Bytes(), &approved)
+			if approved.Data.Status != "approved" || approved.Data.ApprovedBy == nil || approved.Data.ApprovedAt == nil {
+				t.Errorf("approved plan = %+v, want status approved with approver", approved.Data)
+			}
+			if w := s.do(t, "POST", planPath+"/optimize", manager, nil); w.Code != http.StatusConflict {
+				t.Errorf("OptimizePlan() on approved plan status = %d, want %d", w.Code, http.StatusConflict)
+			}
+		}},
+		{"driver view", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/plans", driver, nil)
+			var list struct {
+				Data []models.Plan
+			}
+			json.Unmarshal(w.Body.Bytes(), &list)
+			if len(list.Data) != 1 || list.Data[0].ID != plan.ID {
+				t.Errorf("ListPlans() as driver = %+v, want only the approved plan", list.Data)
+			}
+			if w := s.do(t, "GET", "/api/v1/plans/"+strconv.FormatInt(draft.ID, 10), driver, nil); w.Code != http.StatusNotFound {
+				t.Errorf("GetPlan() of draft as driver status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+			if w := s.do(t, "GET", planPath, driver, nil); w.Code != http.StatusOK {
+				t.Errorf("GetPlan() of approved plan as driver status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if w := s.do(t, "POST", planPath+"/execute", driver, nil); w.Code != http.StatusForbidden {
+				t.Errorf("ExecutePlan() as driver status = %d, want %d", w.Code, http.StatusForbidden)
+			}
+		}},
+		{"execute and complete", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/execute", user, nil); w.Code != http.StatusOK {
+				t.Fatalf("ExecutePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "POST", planPath+"/complete", user, nil); w.Code != http.StatusOK {
+				t.Fatalf("CompletePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "POST", planPath+"/cancel", user, nil); w.Code != http.StatusConflict {
+				t.Errorf("CancelPlan() on completed plan status = %d, want %d", w.Code, http.StatusConflict)
+			}
+			if stored, _ := database.GetPlan(s.db, plan.ID); stored.Status != "completed" {
+				t.Errorf("plan status = %s, want completed", stored.Status)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
+
+// TestOptimizingPlans tests that a plan being optimized refuses a second
+// optimization, can be cancelled and is reset once left optimizing
+func TestOptimizingPlans(t *testing.T) {
+	s := newTestServer(t)
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	s.h.SetClock(testkit.NewClock(now))
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.POST("/plans/:id/cancel", s.h.CancelPlan)
+	token := s.login(t, "manager")
+
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+	customer := s.fx.Customer()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	optimizing := testkit.WithStatus(planstate.Optimizing)
+	status := func(t *testing.T, plan *models.Plan) string {
+		t.Helper()
+		stored, err := database.GetPlan(s.db, plan.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stored.Status
+	}
+	path := func(plan *models.Plan, action string) string {
+		return fmt.Sprintf("/api/v1/plans/%d/%s", plan.ID, action)
+	}
+
+	t.Run("second optimization", func(t *testing.T) {
+		plan := s.fx.Plan(warehouse, start, 3, optimizing)
+		if w := s.do(t, "POST", path(plan, "optimize"), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("optimize while optimizing status = %d, want 409", w.Code)
+		}
+	})
+
+	t.Run("cancel while optimizing", func(t *testing.T) {
+		plan := s.fx.Plan(warehouse, start, 3, optimizing)
+		if w := s.do(t, "POST", path(plan, "cancel"), token, nil); w.Code != http.StatusOK {
+			t.Fatalf("cancel status = %d: %s", w.Code, w.Body.String())
+		}
+		if got := status(t, plan); got != planstate.Cancelled {
+			t.Errorf("status = %s, want cancelled", got)
+		}
+	})
+
+	t.Run("cancelled during optimization", func(t *testing.T) {
+		plan := s.fx.Plan(warehouse, start, 3)
+		s.opt.Then(func(req *optimizer.OptimizeRequest) *optimizer.OptimizeResponse {
+			if err := database.TransitionPlanStatus(s.db, plan.ID, planstate.Optimizing, planstate.Cancelled, nil); err != nil {
+				t.Errorf("cancel during optimization: %v", err)
+			}
+			return testkit.OneStopPerVehicle(req)
+		})
+		if w := s.do(t, "POST", path(plan, "optimize"), token, nil); w.Code != http.StatusConflict {
+			t.Errorf("optimize status = %d, want 409", w.Code)
+		}
+		if got := status(t, plan); got != planstate.Cancelled {
+			t.Errorf("status = %s, want cancelled", got)
+		}
+		if routes, _ := database.GetRoutesByPlan(s.db, plan.ID); len(routes) != 0 {
+			t.Errorf("cancelled plan has %d routes, want none", len(routes))
+		}
+	})
+
+	t.Run("reset stale optimizations", func(t *testing.T) {
+		withRoutes := s.fx.Plan(warehouse, start, 3, optimizing)
+		s.fx.Route(withRoutes, vehicle, 1, customer)
+		withoutRoutes := s.fx.Plan(warehouse, start, 3, optimizing)
+		recent := s.fx.Plan(warehouse, start, 3, optimizing)
+		for plan, updated := range map[*models.Plan]time.Time{
+			withRoutes:    now.Add(-2 * time.Hour),
+			withoutRoutes: now.Add(-2 * time.Hour),
+			recent:        now.Add(-10 * time.Minute),
+		} {
+			if err := s.db.Exec("UPDATE plans SET updated_at = ? WHERE id = ?", updated, plan.ID).Error; err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if err := s.h.ResetStaleOptimizations(context.Background(), nil); err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range []struct {
+			name string
+			plan *models.Plan
+			want string
+		}{
+			{"with routes", withRoutes, planstate.Optimized},
+			{"without routes", withoutRoutes, planstate.Draft},
+			{"recent", recent, planstate.Optimizing},
+		} {
+			if got := status(t, tt.plan); got != tt.want {
+				t.Errorf("%s plan status = %s, want %s", tt.name, got, tt.want)
+			}
+		}
+	})
+}
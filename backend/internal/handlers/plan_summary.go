@@ -0,0 +1,227 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Thresholds for plan summary risk flags
+const (
+	summaryHighUtilization = 0.95 // route load share of vehicle capacity
+	summaryLowUtilization  = 0.5  // plan-wide average
+	summaryTightDistance   = 0.9  // route distance share of vehicle max distance
+	summaryMaxDeviations   = 5
+)
+
+// GetPlanSummary handles GET /api/v1/plans/:id/summary
+func (h *Handler) GetPlanSummary(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	routes, err := database.GetRoutesByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan routes")
+		return
+	}
+
+	customers, err := h.customers.List()
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return
+	}
+
+	summary := summarizePlan(plan, routes, customers)
+	summary.TotalCO2Kg = h.planCO2Kg(routes)
+
+	previous, err := database.GetPreviousPlan(h.db, plan)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch previous plan")
+		return
+	}
+	if previous != nil {
+		previousRoutes, err := database.GetRoutesByPlan(h.db, previous.ID)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch previous plan routes")
+			return
+		}
+		summary.PreviousPlanID = &previous.ID
+		summary.Deviations = planDeviations(summarizePlan(previous, previousRoutes, nil), summary)
+	}
+
+	successResponse(c, summary)
+}
+
+// summarizePlan computes headline figures and risk flags for a plan. When
+// customers is nil the unserved-customer check is skipped.
+func summarizePlan(plan *models.Plan, routes []models.Route, customers []models.Customer) *models.PlanSummary {
+	days := int(plan.EndDate.Sub(plan.StartDate).Hours()/24) + 1
+	summary := &models.PlanSummary{
+		PlanID:            plan.ID,
+		Name:              plan.Name,
+		Status:            plan.Status,
+		StartDate:         plan.StartDate.Format("2006-01-02"),
+		EndDate:           plan.EndDate.Format("2006-01-02"),
+		Days:              days,
+		RouteCount:        len(routes),
+		RiskFlags:         []models.PlanRiskFlag{},
+		Deviations:        []models.PlanDeviation{},
+		TotalCost:         plan.TotalCost,
+		TotalDistanceKm:   plan.TotalDistance,
+		ObjectiveTradeoff: plan.ObjectiveTradeoff,
+	}
+
+	vehicles := make(map[int64]bool)
+	served := make(map[int64]bool)
+	utilizationSum := 0.0
+	utilizationCount := 0
+	highLoadRoutes := 0
+	tightDistanceRoutes := 0
+
+	for _, r := range routes {
+		if r.VehicleID != nil {
+			vehicles[*r.VehicleID] = true
+		}
+		summary.StopCount += len(r.Stops)
+		for _, s := range r.Stops {
+			summary.TotalDelivered += s.Quantity
+			if s.CustomerID != nil {
+				served[*s.CustomerID] = true
+			}
+		}
+
+		if r.Vehicle == nil {
+			continue
+		}
+		if r.Vehicle.Capacity > 0 {
+			utilization := r.TotalLoad / r.Vehicle.Capacity
+			utilizationSum += utilization
+			utilizationCount++
+			if utilization > summaryHighUtilization {
+				highLoadRoutes++
+			}
+		}
+		if r.Vehicle.MaxDistance > 0 && r.TotalDistance > r.Vehicle.MaxDistance*summaryTightDistance {
+			tightDistanceRoutes++
+		}
+	}
+
+	summary.VehiclesUsed = len(vehicles)
+	summary.CustomersServed = len(served)
+	if utilizationCount > 0 {
+		summary.AvgUtilization = utilizationSum / float64(utilizationCount) * 100
+	}
+	if summary.TotalDelivered > 0 {
+		summary.CostPerUnit = summary.TotalCost / summary.TotalDelivered
+	}
+
+	flag := func(code, severity, message string) {
+		summary.RiskFlags = append(summary.RiskFlags, models.PlanRiskFlag{Code: code, Severity: severity, Message: message})
+	}
+
+	if plan.Status == planstate.Draft || plan.Status == planstate.Optimizing {
+		flag("not_optimized", "critical", "Plan has not been optimized yet")
+	} else if len(routes) == 0 {
+		flag("no_routes", "critical", "Plan has no routes")
+	}
+	if highLoadRoutes > 0 {
+		flag("high_utilization", "warning", fmt.Sprintf("%d routes are loaded above %.0f%% of vehicle capacity", highLoadRoutes, summaryHighUtilization*100))
+	}
+	if tightDistanceRoutes > 0 {
+		flag("tight_distance", "warning", fmt.Sprintf("%d routes use more than %.0f%% of the vehicle's distance limit", tightDistanceRoutes, summaryTightDistance*100))
+	}
+	if t := plan.ObjectiveTradeoff; t != nil && t.CarbonWeight == 0 {
+		flag("carbon_fallback", "info", fmt.Sprintf("No routes emitting less CO2 were found within a %.0f%% cost increase; the cheapest routes were kept", plan.MaxCostIncreasePct))
+	}
+	if utilizationCount > 0 && summary.AvgUtilization < summaryLowUtilization*100 {
+		flag("low_utilization", "info", fmt.Sprintf("Average vehicle utilization is only %.1f%%", summary.AvgUtilization))
+	}
+
+	// Customers projected to fall below minimum inventory within the horizon
+	// but receiving no delivery
+	atRisk := 0
+	for _, cust := range customers {
+		if served[cust.ID] {
+			continue
+		}
+		if cust.CurrentInventory-cust.DemandRate*float64(days) < cust.MinInventory {
+			atRisk++
+		}
+	}
+	if atRisk > 0 && len(routes) > 0 {
+		flag("unserved_customers", "critical", fmt.Sprintf("%d customers are projected to fall below minimum inventory without a delivery", atRisk))
+	}
+
+	summary.Headline = fmt.Sprintf("%s: %d days, %d routes on %d vehicles delivering %.0f units to %d customers for %.2f (%.1f km, %.1f%% average utilization)",
+		plan.Name, days, summary.RouteCount, summary.VehiclesUsed, summary.TotalDelivered,
+		summary.CustomersServed, summary.TotalCost, summary.TotalDistanceKm, summary.AvgUtilization)
+
+	return summary
+}
+
+// planDeviations returns the metrics that changed the most relative to the
+// previous plan, largest relative change first
+func planDeviations(previous, current *models.PlanSummary) []models.PlanDeviation {
+	metrics := []struct {
+		name     string
+		previous float64
+		current  float64
+	}{
+		{"total_cost", previous.TotalCost, current.TotalCost},
+		{"total_distance_km", previous.TotalDistanceKm, current.TotalDistanceKm},
+		{"total_delivered", previous.TotalDelivered, current.TotalDelivered},
+		{"cost_per_unit", previous.CostPerUnit, current.CostPerUnit},
+		{"vehicles_used", float64(previous.VehiclesUsed), float64(current.VehiclesUsed)},
+		{"route_count", float64(previous.RouteCount), float64(current.RouteCount)},
+		{"customers_served", float64(previous.CustomersServed), float64(current.CustomersServed)},
+		{"avg_utilization", previous.AvgUtilization, current.AvgUtilization},
+	}
+
+	deviations := make([]models.PlanDeviation, 0, len(metrics))
+	for _, m := range metrics {
+		delta := m.current - m.previous
+		if delta == 0 {
+			continue
+		}
+		d := models.PlanDeviation{
+			Metric:   m.name,
+			Previous: m.previous,
+			Current:  m.current,
+			Delta:    delta,
+		}
+		if m.previous != 0 {
+			d.DeltaPercent = delta / math.Abs(m.previous) * 100
+		}
+		deviations = append(deviations, d)
+	}
+
+	sort.SliceStable(deviations, func(i, j int) bool {
+		return math.Abs(deviations[i].DeltaPercent) > math.Abs(deviations[j].DeltaPercent)
+	})
+	if len(deviations) > summaryMaxDeviations {
+		deviations = deviations[:summaryMaxDeviations]
+	}
+	return deviations
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestSummarizePlan tests headline figures, risk flags and deviations
+func TestSummarizePlan(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{ID: 2, Name: "Week 2", Status: "optimized", StartDate: start, EndDate: start.AddDate(0, 0, 6), TotalCost: 300, TotalDistance: 120}
+
+	truck := &models.Vehicle{ID: 1, Capacity: 100, MaxDistance: 100}
+	vehicleID := truck.ID
+	served := int64(10)
+	routes := []models.Route{
+		{VehicleID: &vehicleID, Vehicle: truck, Day: 1, TotalLoad: 98, TotalDistance: 95, Stops: []models.Stop{{CustomerID: &served, Quantity: 98}}},
+		{VehicleID: &vehicleID, Vehicle: truck, Day: 2, TotalLoad: 50, TotalDistance: 25, Stops: []models.Stop{{CustomerID: &served, Quantity: 50}}},
+	}
+	customers := []models.Customer{
+		{ID: 10, CurrentInventory: 0, DemandRate: 10},
+		{ID: 11, CurrentInventory: 20, DemandRate: 10, MinInventory: 5},
+		{ID: 12, CurrentInventory: 500, DemandRate: 10},
+	}
+
+	summary := summarizePlan(plan, routes, customers)
+
+	if summary.Days != 7 || summary.VehiclesUsed != 1 || summary.CustomersServed != 1 || summary.TotalDelivered != 148 {
+		t.Errorf("summarizePlan() = %+v", summary)
+	}
+	if summary.AvgUtilization != 74 {
+		t.Errorf("AvgUtilization = %v, want 74", summary.AvgUtilization)
+	}
+
+	flags := make(map[string]bool)
+	for _, f := range summary.RiskFlags {
+		flags[f.Code] = true
+	}
+	for _, code := range []string{"high_utilization", "tight_distance", "unserved_customers"} {
+		if !flags[code] {
+			t.Errorf("missing risk flag %q in %+v", code, summary.RiskFlags)
+		}
+	}
+	if flags["low_utilization"] {
+		t.Error("unexpected low_utilization flag")
+	}
+
+	previous := &models.PlanSummary{TotalCost: 200, TotalDistanceKm: 120, TotalDelivered: 148, VehiclesUsed: 1, RouteCount: 2, CustomersServed: 1, AvgUtilization: 74, CostPerUnit: 200.0 / 148}
+	deviations := planDeviations(previous, summary)
+	if len(deviations) != 2 {
+		t.Fatalf("planDeviations() = %+v, want cost and cost per unit", deviations)
+	}
+	for _, d := range deviations {
+		if math.Abs(d.DeltaPercent-50) > 1e-9 {
+			t.Errorf("%s DeltaPercent = %v, want 50", d.Metric, d.DeltaPercent)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planschedule"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PlanTemplateRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	WarehouseID   int64    `json:"warehouse_id" binding:"required"`
+	CustomerIDs   []int64  `json:"customer_ids"`
+	VehicleIDs    []int64  `json:"vehicle_ids"`
+	CustomerTags  []string `json:"customer_tags" binding:"max=50,dive,max=50"`
+	VehicleTags   []string `json:"vehicle_tags" binding:"max=50,dive,max=50"`
+	HorizonDays   int      `json:"horizon_days" binding:"required,min=1"`
+	Recurrence    string   `json:"recurrence" binding:"omitempty,oneof=none weekly monthly"`
+	NextStartDate string   `json:"next_start_date"`
+	LeadDays      int      `json:"lead_days" binding:"gte=0"`
+	Active        *bool    `json:"active"`
+}
+
+type PlanTemplateFromPlanRequest struct {
+	Name          string `json:"name" binding:"required"`
+	Recurrence    string `json:"recurrence" binding:"omitempty,oneof=none weekly monthly"`
+	NextStartDate string `json:"next_start_date"`
+	LeadDays      int    `json:"lead_days" binding:"gte=0"`
+}
+
+type InstantiatePlanTemplateRequest struct {
+	StartDate string `json:"start_date" binding:"required"`
+}
+
+// ListPlanTemplates handles GET /api/v1/plan-templates
+func (h *Handler) ListPlanTemplates(c *gin.Context) {
+	templates, err := database.ListPlanTemplates(h.db)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan templates")
+		return
+	}
+	if templates == nil {
+		templates = []models.PlanTemplate{}
+	}
+	successResponse(c, templates)
+}
+
+// GetPlanTemplate handles GET /api/v1/plan-templates/:id
+func (h *Handler) GetPlanTemplate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	template, err := database.GetPlanTemplate(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan template")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan template")
+		return
+	}
+	successResponse(c, template)
+}
+
+// CreatePlanTemplate handles POST /api/v1/plan-templates
+func (h *Handler) CreatePlanTemplate(c *gin.Context) {
+	var req PlanTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	template := &models.PlanTemplate{CreatedBy: &userID}
+	if err := req.applyTo(template); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if err := database.CreatePlanTemplate(h.db, template); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create plan template")
+		return
+	}
+	createdResponse(c, template)
+}
+
+// CreatePlanTemplateFromPlan handles POST /api/v1/plans/:id/template
+// The template takes the plan's warehouse, customer and vehicle sets and
+// length. With a recurrence and next_start_date the scheduler keeps
+// creating draft plans from it.
+func (h *Handler) CreatePlanTemplateFromPlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	var req PlanTemplateFromPlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if plan.WarehouseID == nil {
+		errorResponse(c, http.StatusBadRequest, "Plan has no warehouse assigned")
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	template := &models.PlanTemplate{SourcePlanID: &plan.ID, CreatedBy: &userID}
+	full := PlanTemplateRequest{
+		Name:          req.Name,
+		WarehouseID:   *plan.WarehouseID,
+		CustomerIDs:   plan.CustomerIDs,
+		VehicleIDs:    plan.VehicleIDs,
+		CustomerTags:  plan.CustomerTags,
+		VehicleTags:   plan.VehicleTags,
+		HorizonDays:   int(plan.EndDate.Sub(plan.StartDate).Hours()/24) + 1,
+		Recurrence:    req.Recurrence,
+		NextStartDate: req.NextStartDate,
+		LeadDays:      req.LeadDays,
+	}
+	if err := full.applyTo(template); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if err := database.CreatePlanTemplate(h.db, template); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create plan template")
+		return
+	}
+	createdResponse(c, template)
+}
+
+// UpdatePlanTemplate handles PUT /api/v1/plan-templates/:id
+func (h *Handler) UpdatePlanTemplate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	var req PlanTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	template, err := database.GetPlanTemplate(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan template")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan template")
+		return
+	}
+	if err := req.applyTo(template); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if err := database.UpdatePlanTemplate(h.db, template); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update plan template")
+		return
+	}
+	successResponse(c, template)
+}
+
+// DeletePlanTemplate handles DELETE /api/v1/plan-templates/:id
+// Plans already created from the template are kept.
+func (h *Handler) DeletePlanTemplate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	if err := database.DeletePlanTemplate(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan template")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete plan template")
+		return
+	}
+	successResponse(c, gin.H{"message": "Plan template deleted successfully"})
+}
+
+// InstantiatePlanTemplate handles POST /api/v1/plan-templates/:id/instantiate
+// Creates a draft plan from the template right away. The template's schedule
+// is left as it is.
+func (h *Handler) InstantiatePlanTemplate(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan template ID")
+		return
+	}
+
+	var req InstantiatePlanTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid start date format (use YYYY-MM-DD)")
+		return
+	}
+
+	template, err := database.GetPlanTemplate(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan template")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan template")
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	plan := planschedule.NewPlan(*template, startDate, &userID)
+	if err := h.plans.Create(plan); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create plan")
+		return
+	}
+	createdResponse(c, plan)
+}
+
+// applyTo validates the schedule and copies the request onto t
+func (r PlanTemplateRequest) applyTo(t *models.PlanTemplate) error {
+	recurrence := r.Recurrence
+	if recurrence == "" {
+		recurrence = planschedule.None
+	}
+
+	var nextStart *time.Time
+	if r.NextStartDate != "" {
+		date, err := time.Parse("2006-01-02", r.NextStartDate)
+		if err != nil {
+			return errors.New("next_start_date: use YYYY-MM-DD")
+		}
+		nextStart = &date
+	}
+	if recurrence != planschedule.None && nextStart == nil {
+		return fmt.Errorf("next_start_date is required for %s templates", recurrence)
+	}
+	if recurrence == planschedule.Monthly && nextStart.Day() > planschedule.MaxMonthlyDay {
+		return fmt.Errorf("monthly templates must start on day 1-%d of the month", planschedule.MaxMonthlyDay)
+	}
+
+	t.Name = r.Name
+	t.WarehouseID = &r.WarehouseID
+	t.CustomerIDs = r.CustomerIDs
+	t.VehicleIDs = r.VehicleIDs
+	t.CustomerTags = normalizeTags(r.CustomerTags)
+	t.VehicleTags = normalizeTags(r.VehicleTags)
+	t.HorizonDays = r.HorizonDays
+	t.Recurrence = recurrence
+	t.NextStartDate = nextStart
+	t.LeadDays = r.LeadDays
+	t.Active = r.Active == nil || *r.Active
+	return nil
+}
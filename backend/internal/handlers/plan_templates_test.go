@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestPlanTemplates tests saving a plan as a recurring template, creating a
+// plan from it and that the plan's customer and vehicle sets reach the optimizer
+func TestPlanTemplates(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	var customers []int64
+	var vehicles []int64
+	for i := 0; i < 3; i++ {
+		customer := &models.Customer{Name: "Customer " + strconv.Itoa(i), Latitude: 40.7, Longitude: -74.0, DemandRate: 10}
+		database.CreateCustomer(s.db, customer)
+		customers = append(customers, customer.ID)
+		vehicle := &models.Vehicle{Name: "Truck " + strconv.Itoa(i), Capacity: 100, Available: true, WarehouseID: &warehouse.ID}
+		database.CreateVehicle(s.db, vehicle)
+		vehicles = append(vehicles, vehicle.ID)
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{
+		Name: "North", StartDate: start, EndDate: start.AddDate(0, 0, 4), Status: "draft", WarehouseID: &warehouse.ID,
+		CustomerIDs: customers[:2], VehicleIDs: vehicles[1:2],
+	}
+	database.CreatePlan(s.db, plan)
+
+	s.api.POST("/plans/:id/template", s.h.CreatePlanTemplateFromPlan)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.PUT("/plan-templates/:id", s.h.UpdatePlanTemplate)
+	s.api.POST("/plan-templates/:id/instantiate", s.h.InstantiatePlanTemplate)
+	token := s.login(t, "user")
+
+	planPath := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10)
+
+	var (
+		template     models.PlanTemplate
+		templatePath string
+		instance     models.Plan
+	)
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"recurrence validation", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/template", token, PlanTemplateFromPlanRequest{Name: "North weekly", Recurrence: "weekly"}); w.Code != http.StatusBadRequest {
+				t.Errorf("recurring template without next_start_date status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if w := s.do(t, "POST", planPath+"/template", token, PlanTemplateFromPlanRequest{Name: "North monthly", Recurrence: "monthly", NextStartDate: "2024-01-30"}); w.Code != http.StatusBadRequest {
+				t.Errorf("monthly template on the 30th status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		}},
+		{"save plan as template", func(t *testing.T) {
+			w := s.do(t, "POST", planPath+"/template", token, PlanTemplateFromPlanRequest{Name: "North weekly", Recurrence: "weekly", NextStartDate: "2024-01-08", LeadDays: 2})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("CreatePlanTemplateFromPlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var created struct {
+				Data models.PlanTemplate
+			}
+			json.Unmarshal(w.Body.Bytes(), &created)
+			template = created.Data
+			if template.HorizonDays != 5 || len(template.CustomerIDs) != 2 || len(template.VehicleIDs) != 1 || !template.Active {
+				t.Errorf("template = %+v, want 5 days, 2 customers, 1 vehicle, active", template)
+			}
+			if template.SourcePlanID == nil || *template.SourcePlanID != plan.ID {
+				t.Errorf("template source plan = %v, want %d", template.SourcePlanID, plan.ID)
+			}
+		}},
+		{"pause", func(t *testing.T) {
+			templatePath = "/api/v1/plan-templates/" + strconv.FormatInt(template.ID, 10)
+			paused := false
+			w := s.do(t, "PUT", templatePath, token, PlanTemplateRequest{
+				Name: "North weekly", WarehouseID: warehouse.ID, CustomerIDs: customers[:2], VehicleIDs: vehicles[1:2],
+				HorizonDays: 5, Recurrence: "weekly", NextStartDate: "2024-01-08", Active: &paused,
+			})
+			if w.Code != http.StatusOK {
+				t.Fatalf("UpdatePlanTemplate() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if stored, _ := database.GetPlanTemplate(s.db, template.ID); stored.Active {
+				t.Error("paused template is still active")
+			}
+		}},
+		{"instantiate", func(t *testing.T) {
+			w := s.do(t, "POST", templatePath+"/instantiate", token, InstantiatePlanTemplateRequest{StartDate: "2024-02-05"})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("InstantiatePlanTemplate() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Data models.Plan
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			instance = resp.Data
+			if instance.Status != "draft" || !instance.EndDate.Equal(time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("instance = %+v, want draft ending 2024-02-09", instance)
+			}
+			if instance.TemplateID == nil || *instance.TemplateID != template.ID {
+				t.Errorf("instance template = %v, want %d", instance.TemplateID, template.ID)
+			}
+		}},
+		{"optimize instance", func(t *testing.T) {
+			if w := s.do(t, "POST", "/api/v1/plans/"+strconv.FormatInt(instance.ID, 10)+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if len(s.opt.LastRequest().Customers) != 2 || len(s.opt.LastRequest().Vehicles) != 1 || s.opt.LastRequest().Vehicles[0].ID != vehicles[1] {
+				t.Errorf("optimizer got %d customers and vehicles %+v, want the plan's 2 customers and vehicle %d", len(s.opt.LastRequest().Customers), s.opt.LastRequest().Vehicles, vehicles[1])
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPlanTimeline handles GET /api/v1/plans/:id/timeline
+// Routes become bars in one lane per vehicle. Each stop is served from its
+// arrival time for optimizer.ServiceMinutes; a bar runs from the planned
+// start (or first arrival) to the later of the planned end and the last
+// departure.
+func (h *Handler) GetPlanTimeline(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if !h.canSeePlan(c, plan) {
+		return
+	}
+
+	routes, err := database.GetLoadSheetRoutes(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+	successResponse(c, planTimeline(plan.ID, routes))
+}
+
+// planTimeline groups routes into vehicle lanes; routes without any times
+// are listed as unscheduled
+func planTimeline(planID int64, routes []models.Route) models.PlanTimeline {
+	timeline := models.PlanTimeline{PlanID: planID, Lanes: []models.TimelineLane{}, UnscheduledRouteIDs: []int64{}}
+	lanes := make(map[int64]int)
+	for _, r := range routes {
+		bar, ok := timelineBar(r)
+		if !ok {
+			timeline.UnscheduledRouteIDs = append(timeline.UnscheduledRouteIDs, r.ID)
+			continue
+		}
+
+		var key int64 // routes without a vehicle share lane 0
+		if r.VehicleID != nil {
+			key = *r.VehicleID
+		}
+		i, ok := lanes[key]
+		if !ok {
+			i = len(timeline.Lanes)
+			lanes[key] = i
+			timeline.Lanes = append(timeline.Lanes, models.TimelineLane{VehicleID: r.VehicleID, VehicleName: vehicleName(r.Vehicle)})
+		}
+		timeline.Lanes[i].Bars = append(timeline.Lanes[i].Bars, bar)
+	}
+
+	sort.SliceStable(timeline.Lanes, func(i, j int) bool {
+		a, b := timeline.Lanes[i].VehicleID, timeline.Lanes[j].VehicleID
+		if a == nil || b == nil {
+			return b == nil && a != nil
+		}
+		return *a < *b
+	})
+	for _, lane := range timeline.Lanes {
+		sort.SliceStable(lane.Bars, func(i, j int) bool {
+			return lane.Bars[i].Start.Before(lane.Bars[j].Start)
+		})
+	}
+	return timeline
+}
+
+// timelineBar computes a route's bar. Arrival times are anchored to the
+// route date and roll over midnight when they go backwards.
+func timelineBar(r models.Route) (models.TimelineBar, bool) {
+	bar := models.TimelineBar{
+		RouteID:    r.ID,
+		Day:        r.Day,
+		Date:       r.Date,
+		DriverID:   r.DriverID,
+		DriverName: driverName(r.Driver),
+		Load:       r.TotalLoad,
+		Locked:     r.Locked,
+		Stops:      []models.TimelineStop{},
+	}
+	if r.PlannedStart != nil {
+		bar.Start = *r.PlannedStart
+	}
+	if r.PlannedEnd != nil {
+		bar.End = *r.PlannedEnd
+	}
+
+	arrivals := stopArrivals(r)
+	for _, s := range r.Stops {
+		arrival, ok := arrivals[s.ID]
+		if !ok {
+			continue
+		}
+		stop := models.TimelineStop{
+			StopID:     s.ID,
+			Sequence:   s.Sequence,
+			CustomerID: s.CustomerID,
+			Quantity:   s.Quantity,
+			Arrival:    arrival,
+			Departure:  arrival.Add(optimizer.ServiceMinutes * time.Minute),
+		}
+		if s.Customer != nil {
+			stop.CustomerName = s.Customer.Name
+		}
+		bar.Stops = append(bar.Stops, stop)
+
+		if bar.Start.IsZero() {
+			bar.Start = arrival
+		}
+		if stop.Departure.After(bar.End) {
+			bar.End = stop.Departure
+		}
+	}
+	if bar.End.Before(bar.Start) {
+		bar.End = bar.Start
+	}
+	return bar, !bar.Start.IsZero()
+}
+
+// stopArrivals anchors the HH:MM arrival times of a route's stops, in
+// delivery order, to the route date by stop ID. Times roll over midnight
+// when they go backwards from the planned start or the previous stop;
+// stops without a valid time are left out.
+func stopArrivals(r models.Route) map[int64]time.Time {
+	midnight := time.Date(r.Date.Year(), r.Date.Month(), r.Date.Day(), 0, 0, 0, 0, r.Date.Location())
+	previous := -1
+	if r.PlannedStart != nil {
+		previous = int(r.PlannedStart.Sub(midnight).Minutes())
+	}
+	arrivals := make(map[int64]time.Time, len(r.Stops))
+	for _, s := range r.Stops {
+		minutes, err := optimizer.ParseClock(s.ArrivalTime)
+		if err != nil {
+			continue
+		}
+		for minutes < previous {
+			minutes += 1440
+		}
+		previous = minutes
+		arrivals[s.ID] = midnight.Add(time.Duration(minutes) * time.Minute)
+	}
+	return arrivals
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestPlanTimeline tests bar bounds, midnight rollover and lane grouping
+func TestPlanTimeline(t *testing.T) {
+	day1 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+	at := func(day time.Time, clock string) time.Time {
+		d, _ := time.ParseDuration(clock)
+		return day.Add(d)
+	}
+	truck, van := int64(1), int64(2)
+	customerID := int64(7)
+	plannedStart, plannedEnd := at(day1, "8h"), at(day1, "9h30m")
+
+	routes := []models.Route{
+		{ID: 10, VehicleID: &van, Vehicle: &models.Vehicle{Name: "Van"}, Day: 1, Date: day1, PlannedStart: &plannedStart, PlannedEnd: &plannedEnd,
+			Stops: []models.Stop{
+				{ID: 100, Sequence: 1, CustomerID: &customerID, Customer: &models.Customer{Name: "Acme"}, ArrivalTime: "08:30"},
+				{ID: 101, Sequence: 2, ArrivalTime: "09:20"},
+			}},
+		{ID: 11, VehicleID: &truck, Day: 2, Date: day2, Stops: []models.Stop{{ID: 102, Sequence: 1, ArrivalTime: "23:30"}, {ID: 103, Sequence: 2, ArrivalTime: "00:20"}}},
+		{ID: 12, VehicleID: &truck, Day: 1, Date: day1, Stops: []models.Stop{{ID: 104, Sequence: 1, ArrivalTime: "10:00"}}},
+		{ID: 13, VehicleID: &truck, Day: 3, Date: day2.AddDate(0, 0, 1), Stops: []models.Stop{{ID: 105, Sequence: 1}}},
+	}
+
+	got := planTimeline(1, routes)
+	if len(got.UnscheduledRouteIDs) != 1 || got.UnscheduledRouteIDs[0] != 13 {
+		t.Errorf("unscheduled = %v, want [13]", got.UnscheduledRouteIDs)
+	}
+	if len(got.Lanes) != 2 || *got.Lanes[0].VehicleID != truck || got.Lanes[1].VehicleName != "Van" {
+		t.Fatalf("lanes = %+v, want truck then van", got.Lanes)
+	}
+
+	truckBars := got.Lanes[0].Bars
+	if len(truckBars) != 2 || truckBars[0].RouteID != 12 || truckBars[1].RouteID != 11 {
+		t.Fatalf("truck bars = %+v, want routes 12 and 11 in start order", truckBars)
+	}
+	night := truckBars[1]
+	if !night.Start.Equal(at(day2, "23h30m")) || !night.End.Equal(at(day2, "24h35m")) {
+		t.Errorf("overnight bar = %v - %v, want 23:30 to 00:35 the next day", night.Start, night.End)
+	}
+	if !night.Stops[1].Arrival.Equal(at(day2, "24h20m")) {
+		t.Errorf("second overnight arrival = %v, want 00:20 the next day", night.Stops[1].Arrival)
+	}
+
+	van1 := got.Lanes[1].Bars[0]
+	if !van1.Start.Equal(plannedStart) || !van1.End.Equal(at(day1, "9h35m")) {
+		t.Errorf("van bar = %v - %v, want the planned start to the last departure 09:35", van1.Start, van1.End)
+	}
+	if stop := van1.Stops[0]; stop.CustomerName != "Acme" || !stop.Departure.Equal(at(day1, "8h45m")) {
+		t.Errorf("first van stop = %+v, want Acme served until 08:45", stop)
+	}
+}
@@ -2,27 +2,110 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"log"
+	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 	"time"
 
 	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/distancematrix"
 	"LogiTrackPro/backend/internal/models"
 	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/planstate"
+	"LogiTrackPro/backend/internal/usage"
+	"LogiTrackPro/backend/internal/webhooks"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type PlanRequest struct {
-	Name        string `json:"name" binding:"required"`
-	StartDate   string `json:"start_date" binding:"required"`
-	EndDate     string `json:"end_date" binding:"required"`
-	WarehouseID int64  `json:"warehouse_id" binding:"required"`
+	Name        string  `json:"name" binding:"required"`
+	StartDate   string  `json:"start_date" binding:"required"`
+	EndDate     string  `json:"end_date" binding:"required"`
+	WarehouseID int64   `json:"warehouse_id" binding:"required"`
+	CustomerIDs []int64 `json:"customer_ids"`
+	VehicleIDs  []int64 `json:"vehicle_ids"`
+	// CustomerTags and VehicleTags narrow the customers and vehicles
+	// optimized to those with any of the tags
+	CustomerTags       []string `json:"customer_tags" binding:"max=50,dive,max=50"`
+	VehicleTags        []string `json:"vehicle_tags" binding:"max=50,dive,max=50"`
+	Rolling            bool     `json:"rolling"`
+	Notes              string   `json:"notes" binding:"max=4000"`
+	Objective          string   `json:"objective" binding:"omitempty,oneof=cost carbon"`
+	MaxCostIncreasePct float64  `json:"max_cost_increase_pct" binding:"gte=0"`
 }
 
-// ListPlans handles GET /api/v1/plans
+// planSortColumns are the fields plans can be sorted by
+var planSortColumns = map[string]string{
+	"created_at": "created_at",
+	"start_date": "start_date",
+	"end_date":   "end_date",
+	"name":       "name",
+	"status":     "status",
+	"total_cost": "total_cost",
+}
+
+// ListPlans handles GET /api/v1/plans?page=&limit=&status=&warehouse_id=&created_by=&from=&to=&sort=&include_archived=
+// status takes a comma-separated list; from/to match plans overlapping the
+// range. Archived plans are only listed with include_archived=true or when
+// asked for by status. Drivers only see released plans.
 func (h *Handler) ListPlans(c *gin.Context) {
-	plans, err := database.ListPlans(h.db)
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.PlanFilter{}
+	if raw := c.Query("status"); raw != "" {
+		filter.Statuses = strings.Split(raw, ",")
+	}
+	if c.Query("include_archived") != "true" && !slices.Contains(filter.Statuses, planstate.Archived) {
+		filter.ExcludeStatuses = []string{planstate.Archived}
+	}
+	if filter.WarehouseID, err = parseIDQuery(c, "warehouse_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.CreatedBy, err = parseIDQuery(c, "created_by"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.From, filter.To, err = parseDateRangeQuery(c); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Order, err = parseSort(c, planSortColumns, "-created_at"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	driver, err := h.isDriver(c)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if driver {
+		released := planstate.ReleasedStatuses()
+		if len(filter.Statuses) == 0 {
+			filter.Statuses = released
+		} else {
+			filter.Statuses = slices.DeleteFunc(filter.Statuses, func(status string) bool {
+				return !slices.Contains(released, status)
+			})
+			if len(filter.Statuses) == 0 {
+				paginatedResponse(c, []models.Plan{}, page)
+				return
+			}
+		}
+	}
+
+	plans, total, err := h.plans.List(filter, page.Offset(), page.Limit)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plans")
 		return
@@ -30,34 +113,50 @@ func (h *Handler) ListPlans(c *gin.Context) {
 	if plans == nil {
 		plans = []models.Plan{}
 	}
-	successResponse(c, plans)
+	page.SetTotal(total)
+	paginatedResponse(c, plans, page)
 }
 
-// GetPlan handles GET /api/v1/plans/:id
+// planEmbeds are the associations ?embed= can load with a plan
+var planEmbeds = append([]string{"routes"}, withPrefix("routes.", routeEmbeds)...)
+
+// GetPlan handles GET /api/v1/plans/:id?embed=
+// The plan comes with its routes, their vehicles and their stops' customers
+// and places unless embed lists fewer, e.g. embed=routes.vehicle.
 func (h *Handler) GetPlan(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
 		return
 	}
+	embed, err := parseEmbed(c, planEmbeds, withPrefix("routes.", defaultRouteEmbeds))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	plan, err := database.GetPlan(h.db, id)
+	plan, err := h.plans.Get(id)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Plan not found")
+			notFoundResponse(c, "Plan")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
 		return
 	}
-
-	// Load routes
-	routes, err := database.GetRoutesByPlan(h.db, id)
-	if err != nil {
-		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan routes")
+	if !h.canSeePlan(c, plan) {
 		return
 	}
-	plan.Routes = routes
+	setETag(c, plan.Version)
+
+	if embed["routes"] {
+		routes, err := database.GetRoutesByPlanWith(h.db, id, routeEmbed(embed, "routes."))
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan routes")
+			return
+		}
+		plan.Routes = routes
+	}
 
 	successResponse(c, plan)
 }
@@ -66,7 +165,7 @@ func (h *Handler) GetPlan(c *gin.Context) {
 func (h *Handler) CreatePlan(c *gin.Context) {
 	var req PlanRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
 
@@ -87,18 +186,35 @@ func (h *Handler) CreatePlan(c *gin.Context) {
 		return
 	}
 
+	customerTags, ok := h.lookupTags(c, req.CustomerTags)
+	if !ok {
+		return
+	}
+	vehicleTags, ok := h.lookupTags(c, req.VehicleTags)
+	if !ok {
+		return
+	}
+
 	userID := c.GetInt64("userID")
 
 	plan := &models.Plan{
-		Name:        req.Name,
-		StartDate:   startDate,
-		EndDate:     endDate,
-		Status:      "draft",
-		WarehouseID: req.WarehouseID,
-		CreatedBy:   userID,
+		Name:               req.Name,
+		StartDate:          startDate,
+		EndDate:            endDate,
+		Status:             planstate.Draft,
+		WarehouseID:        &req.WarehouseID,
+		CustomerIDs:        req.CustomerIDs,
+		VehicleIDs:         req.VehicleIDs,
+		CustomerTags:       tagNames(customerTags),
+		VehicleTags:        tagNames(vehicleTags),
+		Rolling:            req.Rolling,
+		Notes:              req.Notes,
+		Objective:          req.Objective,
+		MaxCostIncreasePct: req.MaxCostIncreasePct,
+		CreatedBy:          &userID,
 	}
 
-	if err := database.CreatePlan(h.db, plan); err != nil {
+	if err := h.plans.Create(plan); err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to create plan")
 		return
 	}
@@ -113,9 +229,9 @@ func (h *Handler) DeletePlan(c *gin.Context) {
 		return
 	}
 
-	if err := database.DeletePlan(h.db, id); err != nil {
+	if err := h.plans.Delete(id); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Plan not found")
+			notFoundResponse(c, "Plan")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to delete plan")
@@ -124,7 +240,49 @@ func (h *Handler) DeletePlan(c *gin.Context) {
 	successResponse(c, gin.H{"message": "Plan deleted successfully"})
 }
 
-// GetPlanRoutes handles GET /api/v1/plans/:id/routes
+// PurgePlan handles DELETE /api/v1/admin/plans/:id
+// Permanently removes a deleted or archived plan and everything recorded
+// for it.
+func (h *Handler) PurgePlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.GetWithDeleted(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if !plan.DeletedAt.Valid && plan.Status != planstate.Archived {
+		errorResponse(c, http.StatusConflict, "Only deleted or archived plans can be purged")
+		return
+	}
+
+	if err := h.plans.Purge(id); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to purge plan: "+err.Error())
+		return
+	}
+	successResponse(c, gin.H{"message": "Plan purged"})
+}
+
+// routeSortColumns are the fields a plan's routes can be sorted by
+var routeSortColumns = map[string]string{
+	"day":            "day",
+	"date":           "date",
+	"total_cost":     "total_cost",
+	"total_distance": "total_distance",
+	"total_load":     "total_load",
+}
+
+// GetPlanRoutes handles GET /api/v1/plans/:id/routes?page=&limit=&day=&vehicle_id=&driver_id=&from=&to=&sort=&embed=
+// Routes come with their vehicles and their stops' customers and places
+// unless embed lists fewer.
 func (h *Handler) GetPlanRoutes(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -132,7 +290,56 @@ func (h *Handler) GetPlanRoutes(c *gin.Context) {
 		return
 	}
 
-	routes, err := database.GetRoutesByPlan(h.db, id)
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	embed, err := parseEmbed(c, routeEmbeds, defaultRouteEmbeds)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter := database.RouteFilter{PlanID: id, Embed: routeEmbed(embed, "")}
+	if raw := c.Query("day"); raw != "" {
+		day, err := strconv.Atoi(raw)
+		if err != nil || day < 1 {
+			errorResponse(c, http.StatusBadRequest, "day must be a positive integer")
+			return
+		}
+		filter.Day = &day
+	}
+	if filter.VehicleID, err = parseIDQuery(c, "vehicle_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.DriverID, err = parseIDQuery(c, "driver_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.From, filter.To, err = parseDateRangeQuery(c); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Order, err = parseSort(c, routeSortColumns, "day"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if !h.canSeePlan(c, plan) {
+		return
+	}
+
+	routes, total, err := database.ListRoutesPage(h.db, filter, page.Offset(), page.Limit)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
 		return
@@ -140,10 +347,13 @@ func (h *Handler) GetPlanRoutes(c *gin.Context) {
 	if routes == nil {
 		routes = []models.Route{}
 	}
-	successResponse(c, routes)
+	page.SetTotal(total)
+	paginatedResponse(c, routes, page)
 }
 
 // OptimizePlan handles POST /api/v1/plans/:id/optimize
+// Locked routes are kept; their customers and vehicle days are left out of
+// the solve.
 func (h *Handler) OptimizePlan(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -152,29 +362,40 @@ func (h *Handler) OptimizePlan(c *gin.Context) {
 	}
 
 	// Get plan
-	plan, err := database.GetPlan(h.db, id)
+	plan, err := h.plans.Get(id)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Plan not found")
+			notFoundResponse(c, "Plan")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
 		return
 	}
 
+	if err := planstate.Check(plan.Status, planstate.Optimizing); err != nil {
+		errorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	if plan.WarehouseID == nil {
+		errorResponse(c, http.StatusBadRequest, "Plan has no warehouse assigned")
+		return
+	}
+
 	// Get warehouse
-	warehouse, err := database.GetWarehouse(h.db, plan.WarehouseID)
+	warehouse, err := database.GetWarehouse(h.db, *plan.WarehouseID)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
 		return
 	}
 
 	// Get customers
-	customers, err := database.ListCustomers(h.db)
+	customers, err := h.customers.List()
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customers")
 		return
 	}
+	customers = planCustomers(plan, customers)
 
 	if len(customers) == 0 {
 		errorResponse(c, http.StatusBadRequest, "No customers to optimize")
@@ -187,62 +408,69 @@ func (h *Handler) OptimizePlan(c *gin.Context) {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
 		return
 	}
+	vehicles = planVehicles(plan, vehicles)
 
 	if len(vehicles) == 0 {
 		errorResponse(c, http.StatusBadRequest, "No available vehicles for optimization")
 		return
 	}
 
-	// Calculate planning horizon (days)
-	planningHorizon := int(plan.EndDate.Sub(plan.StartDate).Hours()/24) + 1
+	existing, err := database.GetRoutesByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan routes")
+		return
+	}
 
 	// Build optimizer request
-	optReq := &optimizer.OptimizeRequest{
-		Warehouse: optimizer.WarehouseData{
-			ID:        warehouse.ID,
-			Latitude:  warehouse.Latitude,
-			Longitude: warehouse.Longitude,
-			Stock:     warehouse.CurrentStock,
-		},
-		Customers:       make([]optimizer.CustomerData, len(customers)),
-		Vehicles:        make([]optimizer.VehicleData, len(vehicles)),
-		PlanningHorizon: planningHorizon,
-		StartDate:       plan.StartDate.Format("2006-01-02"),
+	optReq := h.buildOptimizeRequest(plan, warehouse, customers, vehicles)
+	if err := h.applyRoster(optReq, plan.StartDate); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver roster")
+		return
 	}
-
-	for i, c := range customers {
-		optReq.Customers[i] = optimizer.CustomerData{
-			ID:               c.ID,
-			Latitude:         c.Latitude,
-			Longitude:        c.Longitude,
-			DemandRate:       c.DemandRate,
-			MaxInventory:     c.MaxInventory,
-			CurrentInventory: c.CurrentInventory,
-			MinInventory:     c.MinInventory,
-			Priority:         c.Priority,
-		}
+	locked := applyLockedRoutes(optReq, existing, 0)
+	if len(optReq.Vehicles) == 0 {
+		errorResponse(c, http.StatusBadRequest, "No vehicles have a rostered driver in the planning window")
+		return
+	}
+	forcedIDs, err := h.applyForcedCustomers(optReq)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch forced customers")
+		return
+	}
+	redeliveryIDs, err := h.applyRedeliveries(optReq)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch queued redeliveries")
+		return
+	}
+	windows, err := h.segmentRequest(optReq)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Plan is too large to optimize: "+err.Error())
+		return
 	}
 
-	for i, v := range vehicles {
-		optReq.Vehicles[i] = optimizer.VehicleData{
-			ID:          v.ID,
-			Capacity:    v.Capacity,
-			CostPerKm:   v.CostPerKm,
-			FixedCost:   v.FixedCost,
-			MaxDistance: v.MaxDistance,
-		}
+	if !h.reserveUsage(c, usage.Optimizations) {
+		return
 	}
 
-	// Update plan status
-	if err := database.UpdatePlanStatus(h.db, id, "optimizing", 0, 0); err != nil {
+	// Update plan status; a concurrent optimization or cancellation wins
+	if err := database.TransitionPlanStatus(h.db, id, plan.Status, planstate.Optimizing, map[string]interface{}{"total_cost": 0, "total_distance": 0}); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusConflict, "Plan status changed concurrently, retry")
+			return
+		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to update plan status: "+err.Error())
 		return
 	}
 
-	// Call optimizer
-	optResp, err := h.optimizer.Optimize(optReq)
+	// Call optimizer, recording progress for GetOptimizationProgress
+	userID := c.GetInt64("userID")
+	run := &models.OptimizationRun{PlanID: &id, Kind: "optimize", CreatedBy: &userID}
+	optResp, runs, err := h.optimizeWindows(run, optReq, windows, func(p optimizer.Progress) {
+		h.progress.Store(id, p)
+	})
+	h.progress.Delete(id)
 	if err != nil {
-		if revertErr := database.UpdatePlanStatus(h.db, id, "draft", 0, 0); revertErr != nil {
+		if revertErr := h.abortOptimization(plan); revertErr != nil {
 			errorResponse(c, http.StatusInternalServerError, "Optimization failed: "+err.Error()+". Revert failed: "+revertErr.Error())
 		} else {
 			errorResponse(c, http.StatusInternalServerError, "Optimization failed: "+err.Error())
@@ -251,7 +479,7 @@ func (h *Handler) OptimizePlan(c *gin.Context) {
 	}
 
 	if !optResp.Success {
-		if revertErr := database.UpdatePlanStatus(h.db, id, "draft", 0, 0); revertErr != nil {
+		if revertErr := h.abortOptimization(plan); revertErr != nil {
 			errorResponse(c, http.StatusInternalServerError, "Optimization failed: "+optResp.Message+". Revert failed: "+revertErr.Error())
 		} else {
 			errorResponse(c, http.StatusInternalServerError, "Optimization failed: "+optResp.Message)
@@ -259,69 +487,100 @@ func (h *Handler) OptimizePlan(c *gin.Context) {
 		return
 	}
 
+	// Carbon plans trade some of the cost for lower emissions
+	var tradeoff *models.ObjectiveTradeoff
+	if plan.Objective == optimizer.ObjectiveCarbon {
+		optResp, tradeoff, runs = h.optimizeForCarbon(run, optReq, windows, optResp, runs, h.vehicleEmissions(vehicles), plan.MaxCostIncreasePct)
+	}
+
+	rules, err := h.quantityRules(customers)
+	if err != nil {
+		if revertErr := h.abortOptimization(plan); revertErr != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch product rounding rules. Revert failed: "+revertErr.Error())
+		} else {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch product rounding rules")
+		}
+		return
+	}
+	roundOptimizerQuantities(optReq, optResp, rules)
+	optimizer.FillRouteTimes(optReq, optResp)
+
+	if violations := optimizer.ValidateResponse(optReq, optResp); len(violations) > 0 {
+		h.markRunsInfeasible(runs, violations)
+		if revertErr := h.abortOptimization(plan); revertErr != nil {
+			errorResponse(c, http.StatusInternalServerError, "Optimization result is infeasible. Revert failed: "+revertErr.Error())
+		} else {
+			infeasibleResultResponse(c, violations)
+		}
+		return
+	}
+	breaks, err := h.breakRules(vehicles)
+	if err != nil {
+		if revertErr := h.abortOptimization(plan); revertErr != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch places. Revert failed: "+revertErr.Error())
+		} else {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch places")
+		}
+		return
+	}
+	params := solutionParameters(optReq, 0)
+	params.Windows = solutionWindows(windows, 0)
+
 	// Begin transaction for atomic route creation
 	err = h.db.Transaction(func(tx *gorm.DB) error {
-		// Delete existing routes
-		if err := database.DeleteRoutesByPlanTx(tx, id); err != nil {
+		// Replace existing routes, keeping locked ones
+		if err := database.DeleteUnlockedRoutesFromDayTx(tx, id, 1); err != nil {
 			return err
 		}
 
 		// Save new routes
-		for _, routeResult := range optResp.Routes {
-			routeDate, err := time.Parse("2006-01-02", routeResult.Date)
-			if err != nil {
-				return err
-			}
-			var vehicleID *int64
-			if routeResult.VehicleID != 0 {
-				vID := routeResult.VehicleID
-				vehicleID = &vID
-			}
-			route := &models.Route{
-				PlanID:        id,
-				VehicleID:     vehicleID,
-				Day:           routeResult.Day,
-				Date:          routeDate,
-				TotalDistance: routeResult.TotalDistance,
-				TotalCost:     routeResult.TotalCost,
-				TotalLoad:     routeResult.TotalLoad,
-			}
+		inserted, err := saveRouteResultsTx(tx, id, optReq, optResp.Routes, 0, breaks)
+		if err != nil {
+			return err
+		}
 
-			if err := database.CreateRouteTx(tx, route); err != nil {
+		// Update plan status within transaction; totals include locked
+		// routes and break and refuel detours
+		totalCost, totalDistance := optResp.TotalCost, optResp.TotalDistance
+		if locked > 0 || inserted > 0 {
+			var err error
+			if totalCost, totalDistance, err = database.GetPlanRouteTotals(tx, id); err != nil {
 				return err
 			}
-
-			// Save stops
-			for _, stopResult := range routeResult.Stops {
-				var customerID *int64
-				if stopResult.CustomerID > 0 {
-					cID := stopResult.CustomerID
-					customerID = &cID
-				}
-				stop := &models.Stop{
-					RouteID:     route.ID,
-					CustomerID:  customerID,
-					Sequence:    stopResult.Sequence,
-					Quantity:    stopResult.Quantity,
-					ArrivalTime: stopResult.ArrivalTime,
-				}
-				if err := database.CreateStopTx(tx, stop); err != nil {
-					return err
-				}
-			}
+		}
+		err = database.TransitionPlanStatus(tx, id, planstate.Optimizing, planstate.Optimized, map[string]interface{}{
+			"total_cost":     totalCost,
+			"total_distance": totalDistance,
+		})
+		if errors.Is(err, database.ErrNotFound) {
+			return errPlanLeftOptimizing
+		}
+		if err != nil {
+			return err
+		}
+		if err := database.SetPlanObjectiveTradeoffTx(tx, id, tradeoff); err != nil {
+			return err
 		}
 
-		// Update plan status within transaction
-		if err := database.UpdatePlanStatusTx(tx, id, "optimized", optResp.TotalCost, optResp.TotalDistance); err != nil {
+		// Report customers the result left without a delivery
+		if err := h.saveUnroutedTx(tx, id, optReq, optResp, forcedIDs); err != nil {
+			return err
+		}
+		if err := database.ConsumeRedeliveriesTx(tx, redeliveryIDs, id, h.clock.Now()); err != nil {
 			return err
 		}
 
-		return nil
+		// Keep this result as a new solution version
+		return snapshotSolutionTx(tx, id, "optimize", nil, params, optResp.Message, c.GetInt64("userID"))
 	})
 
+	if errors.Is(err, errPlanLeftOptimizing) {
+		errorResponse(c, http.StatusConflict, "Plan was cancelled while it was being optimized")
+		return
+	}
 	if err != nil {
 		// Revert plan status on transaction failure
-		if revertErr := database.UpdatePlanStatus(h.db, id, "draft", 0, 0); revertErr != nil {
+		if revertErr := h.abortOptimization(plan); revertErr != nil {
 			errorResponse(c, http.StatusInternalServerError, "Transaction failed: "+err.Error()+". Revert failed: "+revertErr.Error())
 		} else {
 			errorResponse(c, http.StatusInternalServerError, "Transaction failed: "+err.Error())
@@ -330,11 +589,18 @@ func (h *Handler) OptimizePlan(c *gin.Context) {
 	}
 
 	// Get updated plan with routes
-	plan, err = database.GetPlan(h.db, id)
+	plan, err = h.plans.Get(id)
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated plan: "+err.Error())
 		return
 	}
+	h.publishWebhook(webhooks.PlanOptimized, webhooks.PlanOptimizedData{
+		PlanID:        id,
+		Name:          plan.Name,
+		Source:        "optimize",
+		TotalCost:     plan.TotalCost,
+		TotalDistance: plan.TotalDistance,
+	})
 
 	routes, err := database.GetRoutesByPlan(h.db, id)
 	if err != nil {
@@ -343,6 +609,617 @@ func (h *Handler) OptimizePlan(c *gin.Context) {
 	}
 	plan.Routes = routes
 
-	successResponse(c, plan)
+	warningResponse(c, plan, append(segmentationWarning(params.Windows), rangeWarnings(warehouse, routes)...))
 }
 
+// errPlanLeftOptimizing aborts saving an optimization result when the plan
+// was cancelled while the optimizer ran
+var errPlanLeftOptimizing = errors.New("plan is no longer optimizing")
+
+// abortOptimization returns a plan whose optimization failed to the status
+// and totals it had before, which still match its routes. A plan cancelled
+// while the optimizer ran is left cancelled.
+func (h *Handler) abortOptimization(plan *models.Plan) error {
+	err := database.TransitionPlanStatus(h.db, plan.ID, planstate.Optimizing, plan.Status, map[string]interface{}{
+		"total_cost":     plan.TotalCost,
+		"total_distance": plan.TotalDistance,
+	})
+	if errors.Is(err, database.ErrNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ReoptimizePlan handles POST /api/v1/plans/:id/reoptimize?from_day=N
+// Routes before from_day are frozen; days N..end are re-solved from current
+// inventories and replace the plan's existing future routes, except locked
+// ones.
+func (h *Handler) ReoptimizePlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	fromDay, err := strconv.Atoi(c.Query("from_day"))
+	if err != nil || fromDay < 1 {
+		errorResponse(c, http.StatusBadRequest, "from_day must be a positive integer")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	reserved := true
+	err = h.reoptimizeWindow(plan, fromDay, c.GetInt64("userID"), "reoptimize", func() bool {
+		reserved = h.reserveUsage(c, usage.Optimizations)
+		return reserved
+	})
+	if !reserved {
+		return
+	}
+	var reoptErr *reoptimizeError
+	if errors.As(err, &reoptErr) {
+		if len(reoptErr.violations) > 0 {
+			infeasibleResultResponse(c, reoptErr.violations)
+			return
+		}
+		errorResponse(c, reoptErr.status, reoptErr.message)
+		return
+	}
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Transaction failed: "+err.Error())
+		return
+	}
+
+	plan, err = h.plans.Get(id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated plan: "+err.Error())
+		return
+	}
+	routes, err := database.GetRoutesByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated routes: "+err.Error())
+		return
+	}
+	plan.Routes = routes
+	warehouse, err := database.GetWarehouse(h.db, *plan.WarehouseID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return
+	}
+
+	warningResponse(c, plan, rangeWarnings(warehouse, routes))
+}
+
+// reoptimizeError is a re-optimization that was refused or failed, with the
+// status it is reported with
+type reoptimizeError struct {
+	status     int
+	message    string
+	violations []optimizer.Violation // set when the result was infeasible
+}
+
+func (e *reoptimizeError) Error() string {
+	return e.message
+}
+
+func reoptimizeFailed(status int, message string) error {
+	return &reoptimizeError{status: status, message: message}
+}
+
+// reoptimizeWindow re-solves days fromDay..end of an optimized plan and
+// replaces their unlocked routes, recording the result as a solution from
+// source. reserve is called just before the optimizer and aborts the run
+// when it returns false. Refusals and failures are *reoptimizeError; other
+// errors come from saving the result.
+func (h *Handler) reoptimizeWindow(plan *models.Plan, fromDay int, userID int64, source string, reserve func() bool) error {
+	id := plan.ID
+	if plan.Status != planstate.Optimized {
+		return reoptimizeFailed(http.StatusConflict, "Only optimized plans can be re-optimized (status: "+plan.Status+")")
+	}
+	if plan.WarehouseID == nil {
+		return reoptimizeFailed(http.StatusBadRequest, "Plan has no warehouse assigned")
+	}
+
+	horizon := int(plan.EndDate.Sub(plan.StartDate).Hours()/24) + 1
+	if fromDay > horizon {
+		return reoptimizeFailed(http.StatusBadRequest, "from_day is beyond the plan horizon")
+	}
+
+	started, err := database.CountStartedExecutionsFromDay(h.db, id, fromDay)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to check route executions")
+	}
+	if started > 0 {
+		return reoptimizeFailed(http.StatusConflict, "Routes on or after from_day have already started execution")
+	}
+
+	warehouse, err := database.GetWarehouse(h.db, *plan.WarehouseID)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch warehouse")
+	}
+
+	customers, err := h.customers.List()
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch customers")
+	}
+	customers = planCustomers(plan, customers)
+	if len(customers) == 0 {
+		return reoptimizeFailed(http.StatusBadRequest, "No customers to optimize")
+	}
+
+	vehicles, err := database.ListAvailableVehiclesByWarehouse(h.db, warehouse.ID)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch vehicles")
+	}
+	vehicles = planVehicles(plan, vehicles)
+	if len(vehicles) == 0 {
+		return reoptimizeFailed(http.StatusBadRequest, "No available vehicles for optimization")
+	}
+
+	existing, err := database.GetRoutesByPlan(h.db, id)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch plan routes")
+	}
+
+	// Optimize only the open window, starting from current inventories
+	dayOffset := fromDay - 1
+	window := *plan
+	window.StartDate = plan.StartDate.AddDate(0, 0, dayOffset)
+	optReq := h.buildOptimizeRequest(&window, warehouse, customers, vehicles)
+	if err := h.applyRoster(optReq, window.StartDate); err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch driver roster")
+	}
+	applyLockedRoutes(optReq, existing, dayOffset)
+	if len(optReq.Vehicles) == 0 {
+		return reoptimizeFailed(http.StatusBadRequest, "No vehicles have a rostered driver in the planning window")
+	}
+	forcedIDs, err := h.applyForcedCustomers(optReq)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch forced customers")
+	}
+	redeliveryIDs, err := h.applyRedeliveries(optReq)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch queued redeliveries")
+	}
+	for _, r := range existing {
+		// Routes of days dropped from a rolling plan are history, not input.
+		// Numbered before the window, they are context for the solver and
+		// their deliveries are already in current inventories.
+		if r.Day >= 1 && r.Day < fromDay {
+			result := routeToResult(r)
+			result.Day = r.Day - dayOffset
+			optReq.LockedRoutes = append(optReq.LockedRoutes, result)
+		}
+	}
+	windows, err := h.segmentRequest(optReq)
+	if err != nil {
+		return reoptimizeFailed(http.StatusBadRequest, "Plan is too large to optimize: "+err.Error())
+	}
+
+	if !reserve() {
+		return reoptimizeFailed(http.StatusTooManyRequests, "Optimization quota exceeded")
+	}
+
+	run := &models.OptimizationRun{PlanID: &id, Kind: "reoptimize"}
+	if userID != 0 {
+		run.CreatedBy = &userID
+	}
+	optResp, runs, err := h.optimizeWindows(run, optReq, windows, nil)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Optimization failed: "+err.Error())
+	}
+	if !optResp.Success {
+		return reoptimizeFailed(http.StatusInternalServerError, "Optimization failed: "+optResp.Message)
+	}
+	rules, err := h.quantityRules(customers)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch product rounding rules")
+	}
+	roundOptimizerQuantities(optReq, optResp, rules)
+	optimizer.FillRouteTimes(optReq, optResp)
+	if violations := optimizer.ValidateResponse(optReq, optResp); len(violations) > 0 {
+		h.markRunsInfeasible(runs, violations)
+		return &reoptimizeError{status: http.StatusUnprocessableEntity, message: "Optimizer returned an infeasible solution", violations: violations}
+	}
+	breaks, err := h.breakRules(vehicles)
+	if err != nil {
+		return reoptimizeFailed(http.StatusInternalServerError, "Failed to fetch places")
+	}
+
+	var totalCost, totalDistance float64
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.DeleteUnlockedRoutesFromDayTx(tx, id, fromDay); err != nil {
+			return err
+		}
+		if _, err := saveRouteResultsTx(tx, id, optReq, optResp.Routes, dayOffset, breaks); err != nil {
+			return err
+		}
+		var err error
+		if totalCost, totalDistance, err = database.GetPlanRouteTotals(tx, id); err != nil {
+			return err
+		}
+		if err := database.UpdatePlanStatusTx(tx, id, planstate.Optimized, totalCost, totalDistance); err != nil {
+			return err
+		}
+		if err := h.saveUnroutedTx(tx, id, optReq, optResp, forcedIDs); err != nil {
+			return err
+		}
+		if err := database.ConsumeRedeliveriesTx(tx, redeliveryIDs, id, h.clock.Now()); err != nil {
+			return err
+		}
+		params := solutionParameters(optReq, fromDay)
+		params.Windows = solutionWindows(windows, dayOffset)
+		return snapshotSolutionTx(tx, id, source, nil, params, optResp.Message, userID)
+	})
+	if err != nil {
+		return err
+	}
+	h.publishWebhook(webhooks.PlanOptimized, webhooks.PlanOptimizedData{
+		PlanID:        id,
+		Name:          plan.Name,
+		Source:        source,
+		TotalCost:     totalCost,
+		TotalDistance: totalDistance,
+	})
+	return nil
+}
+
+// infeasibleResultResponse rejects an optimizer result that breaks plan
+// constraints and reports each violation
+func infeasibleResultResponse(c *gin.Context, violations []optimizer.Violation) {
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"success":    false,
+		"error":      fmt.Sprintf("Optimizer returned an infeasible solution (%d violations)", len(violations)),
+		"code":       codeInfeasibleSolution,
+		"violations": violations,
+	})
+}
+
+// routeToResult converts a stored route's deliveries back into the
+// optimizer's format
+func routeToResult(r models.Route) optimizer.RouteResult {
+	result := optimizer.RouteResult{
+		Day:           r.Day,
+		Date:          r.Date.Format("2006-01-02"),
+		TotalDistance: r.TotalDistance,
+		TotalCost:     r.TotalCost,
+		TotalLoad:     r.TotalLoad,
+		Stops:         make([]optimizer.StopResult, 0, len(r.Stops)),
+	}
+	if r.VehicleID != nil {
+		result.VehicleID = *r.VehicleID
+	}
+	if r.PlannedStart != nil {
+		result.StartTime = r.PlannedStart.Format("15:04")
+	}
+	if r.PlannedEnd != nil {
+		result.EndTime = r.PlannedEnd.Format("15:04")
+	}
+	for _, s := range r.Stops {
+		// break, refuel and other stops at places are not the optimizer's
+		if s.CustomerID == nil {
+			continue
+		}
+		result.Stops = append(result.Stops, optimizer.StopResult{
+			CustomerID:  *s.CustomerID,
+			Sequence:    s.Sequence,
+			Quantity:    s.Quantity,
+			ArrivalTime: s.ArrivalTime,
+		})
+	}
+	return result
+}
+
+// saveRouteResultsTx persists optimizer routes and their stops for a plan,
+// explains each stop and breaks down each route's cost from the request it
+// was solved from (see optimizer.ExplainStops and optimizer.CostRoutes) and
+// assigns each route the driver rostered on its vehicle that day. Break,
+// refuel and charging stops are inserted as breaks requires (see
+// optimizer.PlanMandatoryStops); their detours and time are added to the
+// route and later arrivals move back. dayOffset shifts the optimizer's
+// 1-based day numbers when only part of the horizon was optimized. It
+// returns the number of break, refuel and charging stops inserted.
+func saveRouteResultsTx(tx *gorm.DB, planID int64, optReq *optimizer.OptimizeRequest, results []optimizer.RouteResult, dayOffset int, breaks optimizer.BreakRules) (int, error) {
+	reasons := optimizer.ExplainStops(optReq, results)
+	costs := optimizer.CostRoutes(optReq, results)
+	mandatory := optimizer.PlanMandatoryStops(optReq, results, breaks)
+	vehicles := make(map[int64]optimizer.VehicleData, len(optReq.Vehicles))
+	for _, v := range optReq.Vehicles {
+		vehicles[v.ID] = v
+	}
+	inserted := 0
+	for i, routeResult := range results {
+		routeDate, err := time.Parse("2006-01-02", routeResult.Date)
+		if err != nil {
+			return 0, err
+		}
+		plannedStart, plannedEnd, err := plannedRouteTimes(routeDate, routeResult)
+		if err != nil {
+			return 0, err
+		}
+		vehicle := vehicles[routeResult.VehicleID]
+		var detourKm, addedMinutes float64
+		for _, m := range mandatory[i] {
+			detourKm += m.DetourKm
+			addedMinutes += mandatoryStopMinutes(m, vehicle.AverageSpeed)
+		}
+		var vehicleID *int64
+		if routeResult.VehicleID != 0 {
+			vID := routeResult.VehicleID
+			vehicleID = &vID
+		}
+		route := &models.Route{
+			PlanID:        planID,
+			VehicleID:     vehicleID,
+			Day:           routeResult.Day + dayOffset,
+			Date:          routeDate,
+			PlannedStart:  plannedStart,
+			PlannedEnd:    plannedEnd,
+			TotalDistance: routeResult.TotalDistance + detourKm,
+			TotalCost:     routeResult.TotalCost + detourKm*vehicle.CostPerKm,
+			TotalLoad:     routeResult.TotalLoad,
+			CostBreakdown: models.CostBreakdown{
+				FixedCost:    costs[i].Fixed,
+				DistanceCost: costs[i].Distance + detourKm*vehicle.CostPerKm,
+				HoldingCost:  costs[i].Holding,
+				PenaltyCost:  costs[i].Penalty,
+			},
+		}
+		if len(mandatory[i]) > 0 {
+			route.PlannedEnd = addMinutes(plannedEnd, int(math.Round(addedMinutes)))
+		}
+
+		if err := database.CreateRouteTx(tx, route); err != nil {
+			return 0, err
+		}
+
+		// Save stops, the break and refuel stops before the deliveries
+		// they come before
+		sequence, next := 0, 0
+		var shift float64
+		addMandatory := func(before int) error {
+			for ; next < len(mandatory[i]) && mandatory[i][next].Before == before; next++ {
+				m := mandatory[i][next]
+				sequence++
+				if err := database.CreateStopTx(tx, mandatoryStop(route.ID, sequence, m)); err != nil {
+					return err
+				}
+				shift += mandatoryStopMinutes(m, vehicle.AverageSpeed)
+				inserted++
+			}
+			return nil
+		}
+		for j, stopResult := range routeResult.Stops {
+			if err := addMandatory(j); err != nil {
+				return 0, err
+			}
+			var customerID *int64
+			if stopResult.CustomerID > 0 {
+				cID := stopResult.CustomerID
+				customerID = &cID
+			}
+			sequence++
+			stop := &models.Stop{
+				RouteID:     route.ID,
+				CustomerID:  customerID,
+				Sequence:    stopResult.Sequence,
+				Quantity:    stopResult.Quantity,
+				ArrivalTime: stopResult.ArrivalTime,
+			}
+			if len(mandatory[i]) > 0 {
+				stop.Sequence = sequence
+				stop.ArrivalTime = shiftClock(stopResult.ArrivalTime, int(math.Round(shift)))
+			}
+			if r := reasons[i][j]; r != nil {
+				stop.Explanation = &models.StopExplanation{
+					Reason:              r.Reason,
+					Detail:              r.Detail,
+					InventoryAtDelivery: r.InventoryAtDelivery,
+					InventoryAfter:      r.InventoryAfter,
+					DaysToStockout:      r.DaysToStockout,
+					DemandRate:          r.DemandRate,
+					MinInventory:        r.MinInventory,
+					MaxInventory:        r.MaxInventory,
+					Priority:            r.Priority,
+				}
+			}
+			if err := database.CreateStopTx(tx, stop); err != nil {
+				return 0, err
+			}
+		}
+		if err := addMandatory(len(routeResult.Stops)); err != nil {
+			return 0, err
+		}
+	}
+	return inserted, database.AssignRosteredDriversTx(tx, planID)
+}
+
+// plannedRouteTimes anchors a route's HH:MM start and end times to its date.
+// Routes without times are stored without them; an end before the start
+// rolls over to the next day.
+func plannedRouteTimes(date time.Time, result optimizer.RouteResult) (*time.Time, *time.Time, error) {
+	if result.StartTime == "" && result.EndTime == "" {
+		return nil, nil, nil
+	}
+	start, end, err := optimizer.RouteWindow(result)
+	if err != nil {
+		return nil, nil, fmt.Errorf("route for vehicle %d on day %d: %w", result.VehicleID, result.Day, err)
+	}
+	plannedStart := date.Add(time.Duration(start) * time.Minute)
+	plannedEnd := date.Add(time.Duration(end) * time.Minute)
+	return &plannedStart, &plannedEnd, nil
+}
+
+// canSeePlan responds with 404 and returns false when a driver asks for a
+// plan that has not been approved yet
+func (h *Handler) canSeePlan(c *gin.Context, plan *models.Plan) bool {
+	driver, err := h.isDriver(c)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return false
+	}
+	if driver && !planstate.Released(plan.Status) {
+		notFoundResponse(c, "Plan")
+		return false
+	}
+	return true
+}
+
+// planCustomers narrows customers to the plan's customer set and customer
+// tags, if it has them
+func planCustomers(plan *models.Plan, customers []models.Customer) []models.Customer {
+	if len(plan.CustomerIDs) == 0 && len(plan.CustomerTags) == 0 {
+		return customers
+	}
+	include := make(map[int64]bool, len(plan.CustomerIDs))
+	for _, id := range plan.CustomerIDs {
+		include[id] = true
+	}
+	result := make([]models.Customer, 0, len(customers))
+	for _, c := range customers {
+		if (len(include) == 0 || include[c.ID]) && hasAnyTag(c.Tags, plan.CustomerTags) {
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+// planVehicles narrows vehicles to the plan's vehicle set and vehicle tags,
+// if it has them
+func planVehicles(plan *models.Plan, vehicles []models.Vehicle) []models.Vehicle {
+	if len(plan.VehicleIDs) == 0 && len(plan.VehicleTags) == 0 {
+		return vehicles
+	}
+	include := make(map[int64]bool, len(plan.VehicleIDs))
+	for _, id := range plan.VehicleIDs {
+		include[id] = true
+	}
+	result := make([]models.Vehicle, 0, len(vehicles))
+	for _, v := range vehicles {
+		if (len(include) == 0 || include[v.ID]) && hasAnyTag(v.Tags, plan.VehicleTags) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// buildOptimizeRequest assembles the optimizer payload for a plan's horizon
+func (h *Handler) buildOptimizeRequest(plan *models.Plan, warehouse *models.Warehouse, customers []models.Customer, vehicles []models.Vehicle) *optimizer.OptimizeRequest {
+	// Calculate planning horizon (days)
+	planningHorizon := int(plan.EndDate.Sub(plan.StartDate).Hours()/24) + 1
+
+	optReq := &optimizer.OptimizeRequest{
+		Warehouse: optimizer.WarehouseData{
+			ID:        warehouse.ID,
+			Latitude:  warehouse.Latitude,
+			Longitude: warehouse.Longitude,
+			Stock:     warehouse.CurrentStock,
+		},
+		Customers:       make([]optimizer.CustomerData, len(customers)),
+		Vehicles:        make([]optimizer.VehicleData, len(vehicles)),
+		PlanningHorizon: planningHorizon,
+		StartDate:       plan.StartDate.Format("2006-01-02"),
+	}
+
+	for i, c := range customers {
+		optReq.Customers[i] = optimizer.CustomerData{
+			ID:               c.ID,
+			Latitude:         c.Latitude,
+			Longitude:        c.Longitude,
+			DemandRate:       c.DemandRate,
+			MaxInventory:     c.MaxInventory,
+			CurrentInventory: c.CurrentInventory,
+			MinInventory:     c.MinInventory,
+			Priority:         c.Priority,
+			ServiceTags:      c.ServiceTags,
+			MinDropSize:      c.MinDropSize,
+			HoldingCost:      c.HoldingCost,
+		}
+	}
+
+	for i, v := range vehicles {
+		optReq.Vehicles[i] = optimizer.VehicleData{
+			ID:              v.ID,
+			Capacity:        v.Capacity,
+			CostPerKm:       v.CostPerKm,
+			FixedCost:       v.FixedCost,
+			MaxDistance:     v.MaxDistance,
+			MaxWorkingHours: v.MaxWorkingHours,
+			AverageSpeed:    v.AverageSpeed,
+			ShiftStart:      v.ShiftStart,
+			ShiftEnd:        v.ShiftEnd,
+			AllowedTags:     v.AllowedTags,
+		}
+	}
+
+	// Plan customers that keep products by their products' reorder policies
+	h.applyProductPolicies(optReq)
+
+	// Attach road distances when a provider is configured
+	optReq.DistanceMatrix = h.buildDistanceMatrix(warehouse, customers)
+
+	return optReq
+}
+
+// buildDistanceMatrix fetches road distances for the warehouse and customers.
+// Provider failures are logged and the optimizer falls back to haversine.
+func (h *Handler) buildDistanceMatrix(warehouse *models.Warehouse, customers []models.Customer) *optimizer.DistanceMatrix {
+	if h.distances == nil {
+		return nil
+	}
+
+	ids := make([]int64, 0, len(customers)+1)
+	points := make([]distancematrix.Point, 0, len(customers)+1)
+	ids = append(ids, 0)
+	points = append(points, distancematrix.Point{Latitude: warehouse.Latitude, Longitude: warehouse.Longitude})
+	for _, c := range customers {
+		ids = append(ids, c.ID)
+		points = append(points, distancematrix.Point{Latitude: c.Latitude, Longitude: c.Longitude})
+	}
+
+	matrix, err := h.distances.Compute(points)
+	if err != nil {
+		log.Printf("WARNING: distance matrix unavailable, using straight-line distances: %v", err)
+		return nil
+	}
+
+	return &optimizer.DistanceMatrix{
+		LocationIDs: ids,
+		Distances:   matrix.Distances,
+		Durations:   matrix.Durations,
+	}
+}
+
+// GetOptimizationProgress handles GET /api/v1/plans/:id/optimization-progress
+func (h *Handler) GetOptimizationProgress(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	response := gin.H{"plan_id": plan.ID, "status": plan.Status, "progress": nil}
+	if p, ok := h.progress.Load(id); ok {
+		response["progress"] = p
+	}
+	successResponse(c, response)
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -12,6 +13,8 @@ import (
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
 	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/planstate"
+	"LogiTrackPro/backend/internal/testkit"
 
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
@@ -31,9 +34,18 @@ func setupPlanTestHandler(t *testing.T) (*Handler, *gorm.DB) {
 		&models.Warehouse{},
 		&models.Customer{},
 		&models.Vehicle{},
+		&models.Driver{},
+		&models.RosterEntry{},
+		&models.DriverAbsence{},
 		&models.Plan{},
 		&models.Route{},
 		&models.Stop{},
+		&models.StopExplanation{},
+		&models.PlanSolution{},
+		&models.SolutionRoute{},
+		&models.UnroutedCustomer{},
+		&models.Redelivery{},
+		&models.OptimizationRun{},
 	)
 	if err != nil {
 		t.Fatalf("Failed to migrate test database: %v", err)
@@ -337,3 +349,311 @@ func TestGetPlanRoutes(t *testing.T) {
 		t.Error("GetPlanRoutes() returned empty routes")
 	}
 }
+
+// TestListPlansPagination tests paging, filtering and sorting plans
+func TestListPlansPagination(t *testing.T) {
+	h, db := setupPlanTestHandler(t)
+	token := getAuthTokenForPlanTests(t, h, db)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(db, warehouse)
+	for i, status := range []string{"draft", "optimized", "draft", "approved", "draft"} {
+		start := time.Date(2024, 1, 1+7*i, 0, 0, 0, 0, time.UTC)
+		plan := &models.Plan{Name: "Plan " + string(rune('A'+i)), StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: status}
+		if i%2 == 0 {
+			plan.WarehouseID = &warehouse.ID
+		}
+		database.CreatePlan(db, plan)
+	}
+
+	router := gin.New()
+	router.Use(h.AuthMiddleware())
+	router.GET("/api/v1/plans", h.ListPlans)
+	list := func(query string, wantStatus int) ([]models.Plan, models.Pagination) {
+		req := httptest.NewRequest("GET", "/api/v1/plans"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != wantStatus {
+			t.Fatalf("ListPlans(%q) status = %d, want %d, body = %s", query, w.Code, wantStatus, w.Body.String())
+		}
+		var resp struct {
+			Data       []models.Plan
+			Pagination models.Pagination
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data, resp.Pagination
+	}
+
+	plans, page := list("?limit=2&page=2&sort=name", http.StatusOK)
+	if len(plans) != 2 || plans[0].Name != "Plan C" || plans[1].Name != "Plan D" {
+		t.Errorf("page 2 by name = %+v, want Plan C and Plan D", plans)
+	}
+	if page.Total != 5 || page.TotalPages != 3 || page.Page != 2 || page.Limit != 2 {
+		t.Errorf("pagination = %+v, want total 5 over 3 pages", page)
+	}
+
+	plans, page = list("?status=draft,approved&sort=-start_date", http.StatusOK)
+	if page.Total != 4 || plans[0].Name != "Plan E" || plans[3].Name != "Plan A" {
+		t.Errorf("draft and approved plans newest first = %+v, want E down to A", plans)
+	}
+
+	plans, _ = list("?warehouse_id="+strconv.FormatInt(warehouse.ID, 10)+"&from=2024-01-10&to=2024-01-20", http.StatusOK)
+	if len(plans) != 1 || plans[0].Name != "Plan C" {
+		t.Errorf("warehouse plans overlapping Jan 10-20 = %+v, want Plan C", plans)
+	}
+
+	for _, query := range []string{"?limit=0", "?page=x", "?sort=password", "?warehouse_id=x", "?from=01/02/2024", "?from=2024-02-01&to=2024-01-01"} {
+		list(query, http.StatusBadRequest)
+	}
+}
+
+// TestGetPlanRoutesFilters tests paging, filtering and sorting a plan's routes
+func TestGetPlanRoutesFilters(t *testing.T) {
+	h, db := setupPlanTestHandler(t)
+	token := getAuthTokenForPlanTests(t, h, db)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 2), Status: "optimized"}
+	database.CreatePlan(db, plan)
+	vehicle := &models.Vehicle{Name: "Truck", Capacity: 100, Available: true}
+	database.CreateVehicle(db, vehicle)
+	for day := 1; day <= 3; day++ {
+		database.CreateRoute(db, &models.Route{PlanID: plan.ID, Day: day, Date: start.AddDate(0, 0, day-1), TotalCost: float64(100 * (4 - day)), VehicleID: &vehicle.ID})
+		database.CreateRoute(db, &models.Route{PlanID: plan.ID, Day: day, Date: start.AddDate(0, 0, day-1), TotalCost: float64(10 * day)})
+	}
+
+	router := gin.New()
+	router.Use(h.AuthMiddleware())
+	router.GET("/api/v1/plans/:id/routes", h.GetPlanRoutes)
+	list := func(query string) ([]models.Route, models.Pagination) {
+		req := httptest.NewRequest("GET", "/api/v1/plans/"+strconv.FormatInt(plan.ID, 10)+"/routes"+query, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("GetPlanRoutes(%q) status = %d, body = %s", query, w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data       []models.Route
+			Pagination models.Pagination
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data, resp.Pagination
+	}
+
+	routes, page := list("?vehicle_id=" + strconv.FormatInt(vehicle.ID, 10) + "&sort=total_cost")
+	if page.Total != 3 || len(routes) != 3 || routes[0].Day != 3 || routes[2].Day != 1 {
+		t.Errorf("vehicle routes by cost = %+v, want days 3, 2, 1", routes)
+	}
+
+	routes, page = list("?day=2")
+	if page.Total != 2 || len(routes) != 2 {
+		t.Errorf("day 2 routes = %d of %d, want 2", len(routes), page.Total)
+	}
+
+	routes, page = list("?from=2024-01-02&limit=1&page=4&sort=-date")
+	if page.Total != 4 || page.TotalPages != 4 || len(routes) != 1 || routes[0].Day != 2 {
+		t.Errorf("last route from Jan 2 = %+v (%+v), want a day 2 route", routes, page)
+	}
+}
+
+// TestOptimizeRejectedResult tests that a failed or infeasible
+// re-optimization leaves an optimized plan with its status, totals and routes
+func TestOptimizeRejectedResult(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	token := s.login(t, "manager")
+
+	warehouse := s.fx.Warehouse()
+	s.fx.Vehicle(warehouse)
+	s.fx.Customer()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3)
+	path := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10) + "/optimize"
+
+	overloaded := func(req *optimizer.OptimizeRequest) *optimizer.OptimizeResponse {
+		resp := testkit.OneStopPerVehicle(req)
+		resp.Routes[0].Stops[0].Quantity = 10000
+		resp.Routes[0].TotalLoad = 10000
+		return resp
+	}
+	s.opt.Then(testkit.Infeasible("no solution"), testkit.OneStopPerVehicle, overloaded, testkit.Fail())
+
+	check := func(t *testing.T, status string, cost float64, routes int) {
+		t.Helper()
+		stored, err := database.GetPlan(s.db, plan.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if stored.Status != status || stored.TotalCost != cost {
+			t.Errorf("plan = %s costing %v, want %s costing %v", stored.Status, stored.TotalCost, status, cost)
+		}
+		if got, _ := database.GetRoutesByPlan(s.db, plan.ID); len(got) != routes {
+			t.Errorf("plan has %d routes, want %d", len(got), routes)
+		}
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"failed first optimization", func(t *testing.T) {
+			if w := s.do(t, "POST", path, token, nil); w.Code != http.StatusInternalServerError {
+				t.Errorf("optimize status = %d, want 500", w.Code)
+			}
+			check(t, "draft", 0, 0)
+		}},
+		{"optimize", func(t *testing.T) {
+			if w := s.do(t, "POST", path, token, nil); w.Code != http.StatusOK {
+				t.Fatalf("optimize status = %d: %s", w.Code, w.Body.String())
+			}
+			check(t, "optimized", 100, 1)
+		}},
+		{"infeasible re-optimization", func(t *testing.T) {
+			if w := s.do(t, "POST", path, token, nil); w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("optimize status = %d, want 422", w.Code)
+			}
+			check(t, "optimized", 100, 1)
+		}},
+		{"failed re-optimization", func(t *testing.T) {
+			if w := s.do(t, "POST", path, token, nil); w.Code != http.StatusInternalServerError {
+				t.Errorf("optimize status = %d, want 500", w.Code)
+			}
+			check(t, "optimized", 100, 1)
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
+
+// TestReoptimizePlan tests that re-optimizing from a day keeps the earlier
+// days and locked routes and is refused once a route in the window started
+func TestReoptimizePlan(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/plans/:id/reoptimize", s.h.ReoptimizePlan)
+	token := s.login(t, "manager")
+
+	first, second, pinned := s.fx.Customer(), s.fx.Customer(), s.fx.Customer()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	optimized := testkit.WithStatus(planstate.Optimized)
+	reoptimize := func(t *testing.T, plan *models.Plan, fromDay string) int {
+		t.Helper()
+		path := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10) + "/reoptimize?from_day=" + fromDay
+		return s.do(t, "POST", path, token, nil).Code
+	}
+	routeIDs := func(t *testing.T, plan *models.Plan) map[int64]int {
+		t.Helper()
+		routes, err := database.GetRoutesByPlan(s.db, plan.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		days := make(map[int64]int, len(routes))
+		for _, r := range routes {
+			days[r.ID] = r.Day
+		}
+		return days
+	}
+
+	t.Run("earlier days and locked routes are kept", func(t *testing.T) {
+		warehouse := s.fx.Warehouse()
+		truck, van := s.fx.Vehicle(warehouse), s.fx.Vehicle(warehouse)
+		plan := s.fx.Plan(warehouse, start, 5, optimized)
+		day1 := s.fx.Route(plan, truck, 1, first)
+		day2 := s.fx.Route(plan, truck, 2, second)
+		locked := s.fx.Route(plan, van, 3, pinned)
+		if err := database.SetRouteLocked(s.db, locked.ID, true); err != nil {
+			t.Fatal(err)
+		}
+		replaced := s.fx.Route(plan, truck, 4, second)
+
+		if code := reoptimize(t, plan, "3"); code != http.StatusOK {
+			t.Fatalf("reoptimize status = %d, want 200", code)
+		}
+
+		req := s.opt.LastRequest()
+		if req.StartDate != "2024-03-06" || req.PlanningHorizon != 3 {
+			t.Errorf("request window = %s for %d days, want 2024-03-06 for 3", req.StartDate, req.PlanningHorizon)
+		}
+		// The locked route is on the window's first day; the frozen days
+		// before it are context numbered up to 0
+		sent := map[int]int64{}
+		for _, r := range req.LockedRoutes {
+			sent[r.Day] = r.Stops[0].CustomerID
+		}
+		if len(sent) != 3 || sent[-1] != first.ID || sent[0] != second.ID || sent[1] != pinned.ID {
+			t.Errorf("request locked routes = %+v, want days -1, 0 and the locked route on day 1", req.LockedRoutes)
+		}
+		for _, v := range req.Vehicles {
+			if v.ID == van.ID && (len(v.AvailableDays) != 2 || v.AvailableDays[0] != 2) {
+				t.Errorf("van available days = %v, want days 2-3", v.AvailableDays)
+			}
+		}
+
+		days := routeIDs(t, plan)
+		for _, kept := range []*models.Route{day1, day2, locked} {
+			if days[kept.ID] != kept.Day {
+				t.Errorf("route on day %d was not kept", kept.Day)
+			}
+		}
+		if _, ok := days[replaced.ID]; ok {
+			t.Error("unlocked route in the window was kept")
+		}
+		// Truck on day 3 and van on day 4, the first days they are free
+		if len(days) != 5 {
+			t.Errorf("plan has %d routes, want the 3 kept and 2 new ones", len(days))
+		}
+		if stored, _ := database.GetPlan(s.db, plan.ID); stored.TotalCost != 500 {
+			t.Errorf("plan total cost = %v, want 500", stored.TotalCost)
+		}
+	})
+
+	t.Run("started execution", func(t *testing.T) {
+		warehouse := s.fx.Warehouse()
+		truck := s.fx.Vehicle(warehouse)
+		plan := s.fx.Plan(warehouse, start, 5, optimized)
+		s.fx.Route(plan, truck, 2, first)
+		started := s.fx.Route(plan, truck, 4, second)
+		execution := &models.RouteExecution{RouteID: started.ID, Status: "in_progress"}
+		if err := s.db.Create(execution).Error; err != nil {
+			t.Fatal(err)
+		}
+		before := routeIDs(t, plan)
+
+		for _, fromDay := range []string{"1", "4"} {
+			if code := reoptimize(t, plan, fromDay); code != http.StatusConflict {
+				t.Errorf("reoptimize from day %s status = %d, want 409", fromDay, code)
+			}
+		}
+		if after := routeIDs(t, plan); len(after) != len(before) {
+			t.Errorf("refused reoptimize left %d routes, want %d", len(after), len(before))
+		}
+		if code := reoptimize(t, plan, "5"); code != http.StatusOK {
+			t.Errorf("reoptimize after the started route status = %d, want 200", code)
+		}
+		if days := routeIDs(t, plan); days[started.ID] != 4 {
+			t.Error("started route was not kept")
+		}
+	})
+
+	t.Run("refused", func(t *testing.T) {
+		warehouse := s.fx.Warehouse()
+		s.fx.Vehicle(warehouse)
+		for _, tt := range []struct {
+			name    string
+			plan    *models.Plan
+			fromDay string
+			want    int
+		}{
+			{"draft plan", s.fx.Plan(warehouse, start, 5), "2", http.StatusConflict},
+			{"day 0", s.fx.Plan(warehouse, start, 5, optimized), "0", http.StatusBadRequest},
+			{"beyond the horizon", s.fx.Plan(warehouse, start, 5, optimized), "6", http.StatusBadRequest},
+		} {
+			if code := reoptimize(t, tt.plan, tt.fromDay); code != tt.want {
+				t.Errorf("%s: reoptimize status = %d, want %d", tt.name, code, tt.want)
+			}
+		}
+	})
+}
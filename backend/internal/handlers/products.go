@@ -0,0 +1,467 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/quantity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProductRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	SKU          string  `json:"sku" binding:"required,max=100"`
+	Description  string  `json:"description"`
+	Unit         string  `json:"unit" binding:"max=50"`
+	Weight       float64 `json:"weight" binding:"gte=0"`
+	Volume       float64 `json:"volume" binding:"gte=0"`
+	QuantityStep float64 `json:"quantity_step" binding:"gte=0"`
+	RoundingMode string  `json:"rounding_mode"`
+	ExternalRef  string  `json:"external_ref" binding:"max=100"`
+}
+
+func (r *ProductRequest) toModel() *models.Product {
+	unit, mode := r.Unit, r.RoundingMode
+	if unit == "" {
+		unit = "kg"
+	}
+	if mode == "" {
+		mode = quantity.RoundNearest
+	}
+	return &models.Product{
+		Name:         r.Name,
+		SKU:          r.SKU,
+		Description:  r.Description,
+		Unit:         unit,
+		Weight:       r.Weight,
+		Volume:       r.Volume,
+		QuantityStep: r.QuantityStep,
+		RoundingMode: mode,
+		ExternalRef:  r.ExternalRef,
+	}
+}
+
+// bindProduct binds and validates a product request, writing a 400 and
+// returning nil when it is invalid
+func bindProduct(c *gin.Context) *models.Product {
+	var req ProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return nil
+	}
+	if !quantity.ValidMode(req.RoundingMode) {
+		errorResponse(c, http.StatusBadRequest, "rounding_mode must be nearest, up or down")
+		return nil
+	}
+	return req.toModel()
+}
+
+// ListProducts handles GET /api/v1/products
+func (h *Handler) ListProducts(c *gin.Context) {
+	products, err := database.ListProducts(h.db)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch products")
+		return
+	}
+	if products == nil {
+		products = []models.Product{}
+	}
+	successResponse(c, products)
+}
+
+// GetProduct handles GET /api/v1/products/:id
+func (h *Handler) GetProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	product, err := database.GetProduct(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Product")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch product")
+		return
+	}
+	successResponse(c, product)
+}
+
+// CreateProduct handles POST /api/v1/products
+func (h *Handler) CreateProduct(c *gin.Context) {
+	product := bindProduct(c)
+	if product == nil {
+		return
+	}
+	h.createProduct(c, product)
+}
+
+// createProduct stores a new product and responds with it
+func (h *Handler) createProduct(c *gin.Context, product *models.Product) {
+	if !h.uniqueExternalRef(c, "product", h.db, &models.Product{}, product.ID, product.ExternalRef) {
+		return
+	}
+	if err := database.CreateProduct(h.db, product); err != nil {
+		if errors.Is(err, database.ErrDuplicate) {
+			errorResponse(c, http.StatusConflict, "A product with this SKU already exists")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to create product")
+		return
+	}
+	createdResponse(c, product)
+}
+
+// UpdateProduct handles PUT /api/v1/products/:id
+// A new quantity step applies to quantities planned from then on; stops
+// already planned keep theirs.
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	product := bindProduct(c)
+	if product == nil {
+		return
+	}
+	product.ID = id
+	h.updateProduct(c, product)
+}
+
+// updateProduct stores a product's changes and responds with it
+func (h *Handler) updateProduct(c *gin.Context, product *models.Product) {
+	if !h.uniqueExternalRef(c, "product", h.db, &models.Product{}, product.ID, product.ExternalRef) {
+		return
+	}
+	if err := database.UpdateProduct(h.db, product); err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			notFoundResponse(c, "Product")
+		case errors.Is(err, database.ErrDuplicate):
+			errorResponse(c, http.StatusConflict, "A product with this SKU already exists")
+		default:
+			errorResponse(c, http.StatusInternalServerError, "Failed to update product")
+		}
+		return
+	}
+
+	product, err := database.GetProduct(h.db, product.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated product")
+		return
+	}
+	successResponse(c, product)
+}
+
+// GetProductByRef handles GET /api/v1/products/by-ref/:ref
+func (h *Handler) GetProductByRef(c *gin.Context) {
+	h.getByExternalRef(c, "product", h.db, &models.Product{})
+}
+
+// UpsertProduct handles PUT /api/v1/products/by-ref/:ref
+// Updates the product with the external reference, or creates it (201)
+// when there is none.
+func (h *Handler) UpsertProduct(c *gin.Context) {
+	product := bindProduct(c)
+	if product == nil {
+		return
+	}
+	id, ok := h.upsertTarget(c, "product", h.db, &models.Product{}, &product.ExternalRef)
+	if !ok {
+		return
+	}
+	if id == 0 {
+		h.createProduct(c, product)
+		return
+	}
+	product.ID = id
+	h.updateProduct(c, product)
+}
+
+// DeleteProduct handles DELETE /api/v1/products/:id
+// Products customers plan in, keep inventory of or that stops carry cannot
+// be deleted.
+func (h *Handler) DeleteProduct(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	inUse, err := database.ProductInUse(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to check product use")
+		return
+	}
+	if inUse {
+		errorResponse(c, http.StatusConflict, "Product is used by customers or stops")
+		return
+	}
+	if err := database.DeleteProduct(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Product")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete product")
+		return
+	}
+	successResponse(c, gin.H{"message": "Product deleted successfully"})
+}
+
+type CustomerProductRequest struct {
+	CurrentInventory float64 `json:"current_inventory" binding:"gte=0"`
+	MaxInventory     float64 `json:"max_inventory" binding:"gte=0"`
+	MinInventory     float64 `json:"min_inventory" binding:"gte=0"`
+	DemandRate       float64 `json:"demand_rate" binding:"gte=0"`
+	HoldingCost      float64 `json:"holding_cost" binding:"gte=0"`
+	Priority         int     `json:"priority" binding:"gte=0"`
+	// ReorderPoint defaults to min_inventory and OrderQuantity to topping
+	// up to max_inventory
+	ReorderPoint  float64 `json:"reorder_point" binding:"gte=0"`
+	OrderQuantity float64 `json:"order_quantity" binding:"gte=0"`
+}
+
+// customerProductIDs parses the customer and product IDs of a customer
+// product route, writing a 400 and returning false when one is invalid
+func customerProductIDs(c *gin.Context) (customerID, productID int64, ok bool) {
+	customerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return 0, 0, false
+	}
+	productID, err = strconv.ParseInt(c.Param("product_id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid product ID")
+		return 0, 0, false
+	}
+	return customerID, productID, true
+}
+
+// ListCustomerProducts handles GET /api/v1/customers/:id/products
+// The customer's inventory of each product, for multi-product planning.
+func (h *Handler) ListCustomerProducts(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+	if _, err := h.customers.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
+		return
+	}
+
+	inventory, err := database.GetCustomerProductInventory(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer products")
+		return
+	}
+	if inventory == nil {
+		inventory = []models.CustomerProductInventory{}
+	}
+	successResponse(c, inventory)
+}
+
+// SetCustomerProduct handles PUT /api/v1/customers/:id/products/:product_id
+// Sets the customer's inventory of a product and its reorder policy, adding
+// the product to the customer when it has none yet.
+func (h *Handler) SetCustomerProduct(c *gin.Context) {
+	customerID, productID, ok := customerProductIDs(c)
+	if !ok {
+		return
+	}
+
+	var req CustomerProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.MaxInventory > 0 && (req.MinInventory > req.MaxInventory || req.CurrentInventory > req.MaxInventory) {
+		errorResponse(c, http.StatusBadRequest, "min_inventory and current_inventory must not exceed max_inventory")
+		return
+	}
+	if req.MaxInventory > 0 && (req.ReorderPoint >= req.MaxInventory || req.OrderQuantity > req.MaxInventory) {
+		errorResponse(c, http.StatusBadRequest, "reorder_point must be below and order_quantity must not exceed max_inventory")
+		return
+	}
+
+	if _, err := h.customers.Get(customerID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
+		return
+	}
+	if _, err := database.GetProduct(h.db, productID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Product")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch product")
+		return
+	}
+
+	err := database.UpdateCustomerProductInventory(h.db, &models.CustomerProductInventory{
+		CustomerID:       customerID,
+		ProductID:        productID,
+		CurrentInventory: req.CurrentInventory,
+		MaxInventory:     req.MaxInventory,
+		MinInventory:     req.MinInventory,
+		DemandRate:       req.DemandRate,
+		HoldingCost:      req.HoldingCost,
+		Priority:         req.Priority,
+		ReorderPoint:     req.ReorderPoint,
+		OrderQuantity:    req.OrderQuantity,
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update customer product")
+		return
+	}
+
+	inventory, err := database.GetCustomerProduct(h.db, customerID, productID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer product")
+		return
+	}
+	successResponse(c, inventory)
+}
+
+// DeleteCustomerProduct handles DELETE /api/v1/customers/:id/products/:product_id
+func (h *Handler) DeleteCustomerProduct(c *gin.Context) {
+	customerID, productID, ok := customerProductIDs(c)
+	if !ok {
+		return
+	}
+
+	if err := database.DeleteCustomerProduct(h.db, customerID, productID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorCodeResponse(c, http.StatusNotFound, "CUSTOMER_PRODUCT_NOT_FOUND", "Customer does not keep this product")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete customer product")
+		return
+	}
+	successResponse(c, gin.H{"message": "Customer product deleted successfully"})
+}
+
+type StopProductsRequest struct {
+	Products []StopProductQuantityRequest `json:"products" binding:"dive"`
+}
+
+type StopProductQuantityRequest struct {
+	ProductID int64   `json:"product_id" binding:"required"`
+	Quantity  float64 `json:"quantity" binding:"gt=0"`
+}
+
+// ListStopProducts handles GET /api/v1/stops/:id/products
+func (h *Handler) ListStopProducts(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stop ID")
+		return
+	}
+	if _, err := database.GetStop(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stop")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop")
+		return
+	}
+
+	quantities, err := database.GetStopProductQuantities(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop products")
+		return
+	}
+	if quantities == nil {
+		quantities = []models.StopProductQuantity{}
+	}
+	successResponse(c, quantities)
+}
+
+// SetStopProducts handles PUT /api/v1/stops/:id/products
+// Replaces what a customer stop delivers of each product; an empty list
+// clears them. Quantities must be multiples of their product's rounding
+// step. The stop's quantity becomes their sum and the route's load is
+// recalculated.
+func (h *Handler) SetStopProducts(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stop ID")
+		return
+	}
+
+	var req StopProductsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	stop, err := database.GetStop(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stop")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop")
+		return
+	}
+	if stop.CustomerID == nil {
+		errorResponse(c, http.StatusBadRequest, "Only customer stops deliver products")
+		return
+	}
+
+	quantities := make([]models.StopProductQuantity, 0, len(req.Products))
+	seen := make(map[int64]bool)
+	for _, p := range req.Products {
+		if seen[p.ProductID] {
+			errorResponse(c, http.StatusBadRequest, fmt.Sprintf("Product %d is listed more than once", p.ProductID))
+			return
+		}
+		seen[p.ProductID] = true
+		product, err := database.GetProduct(h.db, p.ProductID)
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				errorResponse(c, http.StatusBadRequest, fmt.Sprintf("Product %d not found", p.ProductID))
+				return
+			}
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch product")
+			return
+		}
+		if err := quantity.Validate(p.Quantity, productRule(product)); err != nil {
+			errorResponse(c, http.StatusBadRequest, fmt.Sprintf("Invalid quantity of %s: %s", product.Name, err.Error()))
+			return
+		}
+		quantities = append(quantities, models.StopProductQuantity{ProductID: p.ProductID, Quantity: p.Quantity})
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		return database.ReplaceStopProductQuantitiesTx(tx, stop, quantities)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update stop products")
+		return
+	}
+
+	stop.ProductQuantities, err = database.GetStopProductQuantities(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop products")
+		return
+	}
+	successResponse(c, stop)
+}
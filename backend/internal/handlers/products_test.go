@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestProducts tests managing products, a customer's inventory of them and
+// the quantities of each product a stop delivers
+func TestProducts(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/products", s.h.ListProducts)
+	s.api.POST("/products", s.h.CreateProduct)
+	s.api.PUT("/products/:id", s.h.UpdateProduct)
+	s.api.DELETE("/products/:id", s.h.DeleteProduct)
+	s.api.GET("/customers/:id/products", s.h.ListCustomerProducts)
+	s.api.PUT("/customers/:id/products/:product_id", s.h.SetCustomerProduct)
+	s.api.DELETE("/customers/:id/products/:product_id", s.h.DeleteCustomerProduct)
+	s.api.GET("/stops/:id/products", s.h.ListStopProducts)
+	s.api.PUT("/stops/:id/products", s.h.SetStopProducts)
+
+	token := s.login(t, "manager")
+	request := func(t *testing.T, method, path string, body interface{}, out interface{}) int {
+		t.Helper()
+		w := s.do(t, method, path, token, body)
+		if out != nil {
+			json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{out})
+		}
+		return w.Code
+	}
+
+	var pallets, drums models.Product
+	if code := request(t, "POST", "/api/v1/products", ProductRequest{Name: "Pallet", SKU: "PAL", Unit: "pallets", QuantityStep: 2, RoundingMode: "up"}, &pallets); code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201", code)
+	}
+	request(t, "POST", "/api/v1/products", ProductRequest{Name: "Drum", SKU: "DRM", QuantityStep: 0.5}, &drums)
+	customer := s.fx.Customer()
+	path := fmt.Sprintf("/api/v1/customers/%d/products/%d", customer.ID, pallets.ID)
+	warehouse := s.fx.Warehouse()
+	route := s.fx.Route(s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1), s.fx.Vehicle(warehouse), 1, customer, s.fx.Customer())
+	stopPath := fmt.Sprintf("/api/v1/stops/%d/products", route.Stops[0].ID)
+
+	t.Run("products", func(t *testing.T) {
+		if drums.Unit != "kg" || drums.RoundingMode != "nearest" {
+			t.Errorf("drum = %+v, want kg rounded to the nearest step", drums)
+		}
+		if code := request(t, "POST", "/api/v1/products", ProductRequest{Name: "Other pallet", SKU: "PAL"}, nil); code != http.StatusConflict {
+			t.Errorf("duplicate SKU status = %d, want 409", code)
+		}
+		if code := request(t, "POST", "/api/v1/products", ProductRequest{Name: "Crate", SKU: "CRT", RoundingMode: "sideways"}, nil); code != http.StatusBadRequest {
+			t.Errorf("unknown rounding mode status = %d, want 400", code)
+		}
+		// a full update can turn rounding off
+		var updated models.Product
+		if code := request(t, "PUT", fmt.Sprintf("/api/v1/products/%d", drums.ID), ProductRequest{Name: "Drum", SKU: "DRM", Unit: "l"}, &updated); code != http.StatusOK || updated.QuantityStep != 0 || updated.Unit != "l" {
+			t.Errorf("update = %d %+v, want no rounding in litres", code, updated)
+		}
+	})
+
+	t.Run("customer inventory", func(t *testing.T) {
+		if code := request(t, "PUT", path, CustomerProductRequest{CurrentInventory: 50, MaxInventory: 40}, nil); code != http.StatusBadRequest {
+			t.Errorf("inventory above max status = %d, want 400", code)
+		}
+		request(t, "PUT", path, CustomerProductRequest{CurrentInventory: 10, MaxInventory: 40, DemandRate: 5, Priority: 2}, nil)
+		var inventory models.CustomerProductInventory
+		if code := request(t, "PUT", path, CustomerProductRequest{CurrentInventory: 0, MaxInventory: 40, DemandRate: 5}, &inventory); code != http.StatusOK || inventory.CurrentInventory != 0 || inventory.Priority != 0 || inventory.Product == nil {
+			t.Errorf("set inventory = %d %+v, want it emptied", code, inventory)
+		}
+		if code := request(t, "PUT", path, CustomerProductRequest{MaxInventory: 40, ReorderPoint: 40}, nil); code != http.StatusBadRequest {
+			t.Errorf("reorder point at max status = %d, want 400", code)
+		}
+		if code := request(t, "PUT", path, CustomerProductRequest{MaxInventory: 40, ReorderPoint: 10, OrderQuantity: 50}, nil); code != http.StatusBadRequest {
+			t.Errorf("order quantity above max status = %d, want 400", code)
+		}
+		if code := request(t, "PUT", path, CustomerProductRequest{MaxInventory: 40, DemandRate: 5, ReorderPoint: 10, OrderQuantity: 20}, &inventory); code != http.StatusOK || inventory.ReorderPoint != 10 || inventory.OrderQuantity != 20 {
+			t.Errorf("set reorder policy = %d %+v, want reorder at 10 by 20", code, inventory)
+		}
+		var list []models.CustomerProductInventory
+		if request(t, "GET", fmt.Sprintf("/api/v1/customers/%d/products", customer.ID), nil, &list); len(list) != 1 {
+			t.Errorf("customer products = %+v, want one", list)
+		}
+		if code := request(t, "DELETE", fmt.Sprintf("/api/v1/products/%d", pallets.ID), nil, nil); code != http.StatusConflict {
+			t.Errorf("delete of a kept product status = %d, want 409", code)
+		}
+	})
+
+	t.Run("stop products", func(t *testing.T) {
+		if code := request(t, "PUT", stopPath, StopProductsRequest{Products: []StopProductQuantityRequest{{ProductID: pallets.ID, Quantity: 3}}}, nil); code != http.StatusBadRequest {
+			t.Errorf("odd number of pallets status = %d, want 400", code)
+		}
+		var stop models.Stop
+		code := request(t, "PUT", stopPath, StopProductsRequest{Products: []StopProductQuantityRequest{{ProductID: pallets.ID, Quantity: 4}, {ProductID: drums.ID, Quantity: 2.5}}}, &stop)
+		if code != http.StatusOK || len(stop.ProductQuantities) != 2 || stop.Quantity != 6.5 {
+			t.Errorf("set stop products = %d %+v, want two products totalling 6.5", code, stop)
+		}
+		if route, _ := database.GetRouteByID(s.db, route.ID); route.TotalLoad != 16.5 {
+			t.Errorf("route load = %g, want 16.5", route.TotalLoad)
+		}
+		var quantities []models.StopProductQuantity
+		if request(t, "GET", stopPath, nil, &quantities); len(quantities) != 2 || quantities[0].Product == nil {
+			t.Errorf("stop products = %+v, want both with their products", quantities)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		if code := request(t, "DELETE", path, nil, nil); code != http.StatusOK {
+			t.Errorf("delete customer product status = %d, want 200", code)
+		}
+		request(t, "PUT", stopPath, StopProductsRequest{}, nil)
+		if code := request(t, "DELETE", fmt.Sprintf("/api/v1/products/%d", pallets.ID), nil, nil); code != http.StatusOK {
+			t.Errorf("delete of an unused product status = %d, want 200", code)
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/storage"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxPODUploadBytes bounds a whole proof-of-delivery upload
+	maxPODUploadBytes = 32 << 20
+	// maxPODImageBytes bounds a single signature or photo
+	maxPODImageBytes = 10 << 20
+	// maxPODPhotos is how many photos a stop keeps
+	maxPODPhotos = 10
+)
+
+// podImageTypes maps the accepted image types to file extensions
+var podImageTypes = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+	"image/webp": ".webp",
+}
+
+// UploadProofOfDelivery handles POST /api/v1/stop-executions/:id/pod
+// Multipart form with any of recipient_name, a signature file and one or
+// more photo files (PNG, JPEG or WebP, up to 10 MB each). A new signature
+// replaces the previous one; photos are added, up to 10 per stop.
+func (h *Handler) UploadProofOfDelivery(c *gin.Context) {
+	stop, ok := h.podStopExecution(c)
+	if !ok {
+		return
+	}
+	if h.artifacts == nil {
+		errorResponse(c, http.StatusServiceUnavailable, "Artifact storage is not configured")
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxPODUploadBytes)
+	form, err := c.MultipartForm()
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid multipart form: "+err.Error())
+		return
+	}
+	signatures, photos := form.File["signature"], form.File["photo"]
+	recipient := strings.TrimSpace(c.PostForm("recipient_name"))
+	if len(signatures) > 1 {
+		errorResponse(c, http.StatusBadRequest, "Only one signature can be uploaded")
+		return
+	}
+	if len(signatures) == 0 && len(photos) == 0 && recipient == "" {
+		errorResponse(c, http.StatusBadRequest, "Provide a recipient_name, signature or photo")
+		return
+	}
+	if len(stop.PODPhotos)+len(photos) > maxPODPhotos {
+		errorResponse(c, http.StatusBadRequest, fmt.Sprintf("A stop keeps at most %d photos", maxPODPhotos))
+		return
+	}
+
+	now := h.clock.Now()
+	prefix := fmt.Sprintf("%sstop-executions/%d/", storage.PODPrefix, stop.ID)
+	stamp := now.UTC().Format("20060102T150405Z")
+	var stored []models.StoredFile
+	// discard removes what this request stored when it does not complete
+	discard := func() {
+		for _, f := range stored {
+			h.artifacts.Delete(c.Request.Context(), f.Key)
+		}
+	}
+
+	previousSignature := stop.PODSignature
+	for _, fh := range signatures {
+		file, status, err := h.storePODImage(c, fh, prefix+"signature-"+stamp)
+		if err != nil {
+			discard()
+			errorResponse(c, status, err.Error())
+			return
+		}
+		stored = append(stored, *file)
+		stop.PODSignature = file
+	}
+	for i, fh := range photos {
+		file, status, err := h.storePODImage(c, fh, fmt.Sprintf("%sphoto-%s-%d", prefix, stamp, len(stop.PODPhotos)+i+1))
+		if err != nil {
+			discard()
+			errorResponse(c, status, err.Error())
+			return
+		}
+		stored = append(stored, *file)
+	}
+	stop.PODPhotos = append(stop.PODPhotos, stored[len(signatures):]...)
+	if recipient != "" {
+		stop.RecipientName = truncate(recipient, 255)
+	}
+	stop.PODCapturedAt = &now
+
+	if err := database.SaveProofOfDelivery(h.db, stop); err != nil {
+		discard()
+		errorResponse(c, http.StatusInternalServerError, "Failed to save proof of delivery")
+		return
+	}
+	if previousSignature != nil && len(signatures) > 0 && previousSignature.Key != stop.PODSignature.Key {
+		h.artifacts.Delete(c.Request.Context(), previousSignature.Key)
+	}
+
+	h.respondWithProofOfDelivery(c, stop)
+}
+
+// GetProofOfDelivery handles GET /api/v1/stop-executions/:id/pod
+// Signature and photos come as signed, expiring download links.
+func (h *Handler) GetProofOfDelivery(c *gin.Context) {
+	stop, ok := h.podStopExecution(c)
+	if !ok {
+		return
+	}
+	h.respondWithProofOfDelivery(c, stop)
+}
+
+// podStopExecution loads the stop execution of the request and checks that
+// the user may report on it
+func (h *Handler) podStopExecution(c *gin.Context) (*models.StopExecution, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stop execution ID")
+		return nil, false
+	}
+	stop, err := database.GetStopExecution(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stop execution")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop execution")
+		return nil, false
+	}
+	if stop.RouteExecution != nil && !h.canReportExecution(c, stop.RouteExecution) {
+		return nil, false
+	}
+	return stop, true
+}
+
+// storePODImage checks that an uploaded file is a supported image and
+// stores it under key with the extension of its type. The returned status
+// goes with the error.
+func (h *Handler) storePODImage(c *gin.Context, fh *multipart.FileHeader, key string) (*models.StoredFile, int, error) {
+	if fh.Size > maxPODImageBytes {
+		return nil, http.StatusRequestEntityTooLarge, fmt.Errorf("%s is larger than %d MB", fh.Filename, maxPODImageBytes>>20)
+	}
+	f, err := fh.Open()
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read %s", fh.Filename)
+	}
+	defer f.Close()
+	body, err := io.ReadAll(io.LimitReader(f, maxPODImageBytes+1))
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("failed to read %s", fh.Filename)
+	}
+
+	contentType := http.DetectContentType(body)
+	ext, ok := podImageTypes[contentType]
+	if !ok {
+		return nil, http.StatusBadRequest, fmt.Errorf("%s is not a PNG, JPEG or WebP image", fh.Filename)
+	}
+	key += ext
+	if err := h.artifacts.Put(c.Request.Context(), key, bytes.NewReader(body), int64(len(body)), contentType); err != nil {
+		return nil, http.StatusInternalServerError, errors.New("failed to store " + fh.Filename)
+	}
+	return &models.StoredFile{Key: key, Size: int64(len(body)), ContentType: contentType}, 0, nil
+}
+
+func (h *Handler) respondWithProofOfDelivery(c *gin.Context, stop *models.StopExecution) {
+	pod := models.ProofOfDelivery{
+		StopExecutionID: stop.ID,
+		RecipientName:   stop.RecipientName,
+		CapturedAt:      stop.PODCapturedAt,
+		Photos:          []models.ArtifactLink{},
+	}
+	if stop.PODSignature != nil {
+		link, err := h.artifactLink(c.Request.Context(), stop.PODSignature.Key, stop.PODSignature.Size)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to sign signature link")
+			return
+		}
+		pod.Signature = link
+	}
+	for _, photo := range stop.PODPhotos {
+		link, err := h.artifactLink(c.Request.Context(), photo.Key, photo.Size)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to sign photo link")
+			return
+		}
+		pod.Photos = append(pod.Photos, *link)
+	}
+	successResponse(c, pod)
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+var testPNG = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+
+// TestProofOfDelivery tests uploading a recipient, signature and photos for
+// a stop and reading them back as signed links
+func TestProofOfDelivery(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.StorageLocalDir = t.TempDir() })
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.api.POST("/stop-executions/:id/pod", s.h.UploadProofOfDelivery)
+	s.api.GET("/stop-executions/:id/pod", s.h.GetProofOfDelivery)
+
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1)
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer())
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "in_progress", StopExecutions: []models.StopExecution{{StopID: route.Stops[0].ID, Status: "completed"}}}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+	token := s.login(t, "driver")
+	pod := fmt.Sprintf("/api/v1/stop-executions/%d/pod", execution.StopExecutions[0].ID)
+
+	upload := func(t *testing.T, fields map[string]string, files map[string][][]byte) (int, models.ProofOfDelivery) {
+		t.Helper()
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for name, value := range fields {
+			mw.WriteField(name, value)
+		}
+		for name, contents := range files {
+			for i, content := range contents {
+				fw, _ := mw.CreateFormFile(name, fmt.Sprintf("%s-%d.png", name, i))
+				fw.Write(content)
+			}
+		}
+		mw.Close()
+		req := httptest.NewRequest("POST", pod, &buf)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		s.router.ServeHTTP(w, req)
+		var resp struct{ Data models.ProofOfDelivery }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	t.Run("invalid uploads", func(t *testing.T) {
+		if code, _ := upload(t, nil, map[string][][]byte{"photo": {[]byte("not an image")}}); code != http.StatusBadRequest {
+			t.Errorf("non-image upload status = %d, want 400", code)
+		}
+		if code, _ := upload(t, nil, nil); code != http.StatusBadRequest {
+			t.Errorf("empty upload status = %d, want 400", code)
+		}
+	})
+
+	t.Run("upload", func(t *testing.T) {
+		code, got := upload(t, map[string]string{"recipient_name": " Pat Lee "}, map[string][][]byte{"signature": {testPNG}, "photo": {testPNG, testPNG}})
+		if code != http.StatusOK {
+			t.Fatalf("upload status = %d", code)
+		}
+		if got.RecipientName != "Pat Lee" || got.Signature == nil || len(got.Photos) != 2 || got.CapturedAt == nil {
+			t.Fatalf("proof of delivery = %+v", got)
+		}
+		if !strings.HasSuffix(got.Signature.Key, ".png") || got.Signature.URL == "" || got.Signature.Size != int64(len(testPNG)) {
+			t.Errorf("signature link = %+v", got.Signature)
+		}
+	})
+
+	t.Run("read back", func(t *testing.T) {
+		if code, _ := upload(t, nil, map[string][][]byte{"photo": {testPNG}}); code != http.StatusOK {
+			t.Fatalf("second upload status = %d", code)
+		}
+		w := s.do(t, "GET", pod, token, nil)
+		var read struct{ Data models.ProofOfDelivery }
+		json.Unmarshal(w.Body.Bytes(), &read)
+		if w.Code != http.StatusOK || read.Data.RecipientName != "Pat Lee" || read.Data.Signature == nil || len(read.Data.Photos) != 3 {
+			t.Fatalf("get = %d %+v, want the recipient, signature and 3 photos", w.Code, read.Data)
+		}
+		body, err := s.h.Artifacts().Get(context.Background(), read.Data.Photos[2].Key)
+		if err != nil {
+			t.Fatalf("stored photo: %v", err)
+		}
+		defer body.Close()
+		if content, _ := io.ReadAll(body); !bytes.Equal(content, testPNG) {
+			t.Errorf("stored photo content = %q", content)
+		}
+	})
+
+	t.Run("unknown stop execution", func(t *testing.T) {
+		if w := s.do(t, "GET", "/api/v1/stop-executions/999/pod", token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("unknown stop execution status = %d, want 404", w.Code)
+		}
+	})
+}
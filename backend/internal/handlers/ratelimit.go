@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPRateLimitMiddleware limits the requests of each client IP, signed in
+// or not, to RATE_LIMIT_IP_PER_MINUTE
+func (h *Handler) IPRateLimitMiddleware() gin.HandlerFunc {
+	limit := ratelimit.PerMinute(h.config.RateLimitIPPerMinute, h.config.RateLimitIPBurst)
+	return h.rateLimitMiddleware(limit, func(c *gin.Context) string {
+		return "ip:" + c.ClientIP()
+	})
+}
+
+// UserRateLimitMiddleware limits the requests of each user to
+// RATE_LIMIT_USER_PER_MINUTE, however many addresses they come from. It
+// must run after AuthMiddleware.
+func (h *Handler) UserRateLimitMiddleware() gin.HandlerFunc {
+	limit := ratelimit.PerMinute(h.config.RateLimitUserPerMinute, h.config.RateLimitUserBurst)
+	return h.rateLimitMiddleware(limit, func(c *gin.Context) string {
+		return "user:" + strconv.FormatInt(c.GetInt64("userID"), 10)
+	})
+}
+
+// rateLimitMiddleware takes a token from the bucket of the request's key
+// and rejects the request with 429 and Retry-After when there is none.
+// X-RateLimit-Limit, -Remaining and -Reset (seconds until the bucket is
+// full) describe the tightest limit the request was counted against.
+// Requests go through when the limiter fails, so that an unavailable Redis
+// does not take the API down with it.
+func (h *Handler) rateLimitMiddleware(limit ratelimit.Limit, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !limit.Enabled() {
+			c.Next()
+			return
+		}
+		result, err := h.rateLimiter.Take(c.Request.Context(), key(c), limit, h.clock.Now())
+		if err != nil {
+			log.Printf("WARNING: rate limiter unavailable, not limiting: %v", err)
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		if current, err := strconv.Atoi(header.Get("X-RateLimit-Remaining")); err != nil || result.Remaining <= current {
+			header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+		}
+		if !result.Allowed {
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			errorCodeResponse(c, http.StatusTooManyRequests, codeRateLimited, "Rate limit exceeded, retry later")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// ceilSeconds is d in whole seconds, rounded up
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
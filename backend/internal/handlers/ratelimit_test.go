@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/testkit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestRateLimits tests that users and client IPs get buckets of their own
+// that refill over time, with the tightest limit in the headers
+func TestRateLimits(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) {
+		cfg.RateLimitUserPerMinute, cfg.RateLimitUserBurst = 2, 2
+		cfg.RateLimitIPPerMinute, cfg.RateLimitIPBurst = 60, 10
+	})
+	clock := testkit.NewClock(time.Now().UTC())
+	s.h.SetClock(clock)
+	alice := s.login(t, "dispatcher")
+	bob := s.login(t, "dispatcher")
+
+	// the login of the test server is not limited, so the limited routes
+	// get a router of their own
+	router := gin.New()
+	v1 := router.Group("/api/v1", s.h.IPRateLimitMiddleware())
+	v1.POST("/auth/login", s.h.Login)
+	v1.GET("/me", s.h.AuthMiddleware(), s.h.UserRateLimitMiddleware(), s.h.GetCurrentUser)
+
+	t.Run("per user", func(t *testing.T) {
+		for i, want := range []string{"1", "0"} {
+			w := e2eRequest(t, router, "GET", "/api/v1/me", alice, nil)
+			if w.Code != http.StatusOK || w.Header().Get("X-RateLimit-Remaining") != want || w.Header().Get("X-RateLimit-Limit") != "2" {
+				t.Fatalf("request %d status = %d with headers %v, want 200 with the user's %s remaining", i, w.Code, w.Header(), want)
+			}
+		}
+		w := e2eRequest(t, router, "GET", "/api/v1/me", alice, nil)
+		var body ErrorResponse
+		json.Unmarshal(w.Body.Bytes(), &body)
+		if w.Code != http.StatusTooManyRequests || body.Code != codeRateLimited || w.Header().Get("Retry-After") != "30" || w.Header().Get("X-RateLimit-Reset") != "60" {
+			t.Fatalf("over the limit status = %d, code %q, headers %v, want 429 retrying in 30s", w.Code, body.Code, w.Header())
+		}
+		if w := e2eRequest(t, router, "GET", "/api/v1/me", bob, nil); w.Code != http.StatusOK {
+			t.Errorf("another user status = %d, want 200", w.Code)
+		}
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		clock.Advance(30 * time.Second)
+		if w := e2eRequest(t, router, "GET", "/api/v1/me", alice, nil); w.Code != http.StatusOK {
+			t.Errorf("after 30s status = %d, want 200", w.Code)
+		}
+	})
+
+	t.Run("per client IP", func(t *testing.T) {
+		// Client IPs are limited before signing in; the test IP's bucket
+		// refilled in the half minute and has 9 of 10 left
+		statuses := map[int]int{}
+		for i := 0; i < 12; i++ {
+			statuses[e2eRequest(t, router, "POST", "/api/v1/auth/login", "", LoginRequest{}).Code]++
+		}
+		if statuses[http.StatusTooManyRequests] != 3 {
+			t.Errorf("login statuses = %v, want the last 3 of 12 limited", statuses)
+		}
+		req := httptest.NewRequest("POST", "/api/v1/auth/login", nil)
+		req.RemoteAddr = "198.51.100.7:4321"
+		other := httptest.NewRecorder()
+		router.ServeHTTP(other, req)
+		if other.Code == http.StatusTooManyRequests {
+			t.Errorf("another IP was limited")
+		}
+	})
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/distancematrix"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListRedeliveries handles GET /api/v1/plans/:id/redeliveries
+func (h *Handler) ListRedeliveries(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	if _, err := h.plans.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	entries, err := database.ListRedeliveriesByPlan(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch redeliveries")
+		return
+	}
+	if entries == nil {
+		entries = []models.Redelivery{}
+	}
+	successResponse(c, entries)
+}
+
+// CancelRedelivery handles POST /api/v1/redeliveries/:id/cancel
+// Only queued redeliveries can be cancelled; scheduled ones are removed by
+// deleting their stop before the route starts.
+func (h *Handler) CancelRedelivery(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid redelivery ID")
+		return
+	}
+
+	entry, err := database.GetRedelivery(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Redelivery")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch redelivery")
+		return
+	}
+	if entry.Status != "queued" {
+		errorResponse(c, http.StatusConflict, "Redelivery is "+entry.Status)
+		return
+	}
+
+	if err := database.CancelRedelivery(h.db, id, h.clock.Now()); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusConflict, "Redelivery is no longer queued")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to cancel redelivery")
+		return
+	}
+
+	entry, err = database.GetRedelivery(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch redelivery")
+		return
+	}
+	successResponse(c, entry)
+}
+
+// planRedeliveryTx follows up a reported stop. A scheduled redelivery the
+// stop was making is closed as delivered or failed. A stop completed short
+// of its planned quantity, or failed, gets a redelivery of the remainder:
+// appended to the earliest later route of the plan with room left on its
+// vehicle, or queued for the next optimization when there is none.
+func (h *Handler) planRedeliveryTx(tx *gorm.DB, execution *models.StopExecution) error {
+	return h.planRedeliveryOnTx(tx, execution, nil)
+}
+
+// planRedeliveryOnTx is planRedeliveryTx appending the remainder to the
+// given route of the plan instead, when it is set, still unstarted and has
+// room left
+func (h *Handler) planRedeliveryOnTx(tx *gorm.DB, execution *models.StopExecution, routeID *int64) error {
+	reason := "partial"
+	switch execution.Status {
+	case "completed":
+		if err := database.ResolveRedeliveryStopTx(tx, execution.StopID, "delivered", h.clock.Now()); err != nil {
+			return err
+		}
+	case "failed":
+		reason = "failed"
+		if err := database.ResolveRedeliveryStopTx(tx, execution.StopID, "failed", h.clock.Now()); err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+	remainder := execution.PlannedQuantity - execution.ActualQuantity
+	if remainder <= 0 {
+		return nil
+	}
+
+	stop, err := database.GetStopWithRouteTx(tx, execution.StopID)
+	if err != nil {
+		return err
+	}
+	if stop.CustomerID == nil || stop.Route == nil {
+		return nil
+	}
+	customer, err := database.GetCustomer(tx, *stop.CustomerID)
+	if err != nil {
+		return err
+	}
+	entry := &models.Redelivery{
+		PlanID:          stop.Route.PlanID,
+		CustomerID:      customer.ID,
+		StopExecutionID: execution.ID,
+		Reason:          reason,
+		Quantity:        remainder,
+		Status:          "queued",
+	}
+
+	var routes []models.Route
+	if routeID != nil {
+		route, err := database.GetUnstartedRouteTx(tx, stop.Route.PlanID, *routeID)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			return err
+		}
+		if route != nil {
+			routes = []models.Route{*route}
+		}
+	} else if routes, err = database.GetRedeliveryRoutesTx(tx, stop.Route.PlanID, stop.Route.Day); err != nil {
+		return err
+	}
+	for i := range routes {
+		route := &routes[i]
+		if route.Vehicle == nil || route.Vehicle.Capacity > 0 && route.TotalLoad+remainder > route.Vehicle.Capacity {
+			continue
+		}
+
+		appended := &models.Stop{
+			RouteID:    route.ID,
+			CustomerID: &customer.ID,
+			Sequence:   len(route.Stops) + 1,
+			Quantity:   remainder,
+		}
+		point := distancematrix.Point{Latitude: customer.Latitude, Longitude: customer.Longitude}
+		h.insertStopCosts(route, len(route.Stops), point, optimizer.ServiceMinutes)
+		if err := database.AppendRedeliveryStopTx(tx, appended); err != nil {
+			return err
+		}
+		if err := database.UpdateRouteCostsTx(tx, route); err != nil {
+			return err
+		}
+		if err := database.UpdatePlanTotalsTx(tx, route.PlanID); err != nil {
+			return err
+		}
+		entry.Status, entry.RouteID, entry.StopID = "scheduled", &route.ID, &appended.ID
+		break
+	}
+	return database.CreateRedeliveryTx(tx, entry)
+}
+
+// applyRedeliveries makes customers with a queued redelivery due on the
+// first day of the request with the priority of a forced customer. It
+// returns the redeliveries used so they can be consumed with the result.
+func (h *Handler) applyRedeliveries(optReq *optimizer.OptimizeRequest) ([]int64, error) {
+	queued, err := database.GetQueuedRedeliveries(h.db)
+	if err != nil {
+		return nil, err
+	}
+	if len(queued) == 0 {
+		return nil, nil
+	}
+
+	redeliveries := make(map[int64][]int64)
+	for _, entry := range queued {
+		redeliveries[entry.CustomerID] = append(redeliveries[entry.CustomerID], entry.ID)
+	}
+
+	var used []int64
+	for i := range optReq.Customers {
+		cust := &optReq.Customers[i]
+		ids, ok := redeliveries[cust.ID]
+		if !ok {
+			continue
+		}
+		cust.Priority += forcedPriorityBoost
+		if cust.CurrentInventory > cust.MinInventory {
+			cust.CurrentInventory = cust.MinInventory
+		}
+		used = append(used, ids...)
+	}
+	return used, nil
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestRedeliveries tests that short and failed stops are appended to a
+// later route with room, or queued for the next optimization otherwise
+func TestRedeliveries(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC)))
+	s.api.PUT("/executions/:id/stops/:stop_execution_id", s.h.UpdateStopExecution)
+	s.api.GET("/plans/:id/redeliveries", s.h.ListRedeliveries)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.POST("/redeliveries/:id/cancel", s.h.CancelRedelivery)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3, testkit.WithStatus("executing"))
+	short, failed := s.fx.Customer(), s.fx.Customer()
+	today := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, short, failed)
+	// tomorrow's vehicle has room for 6 more units, not 16
+	tomorrow := s.fx.Route(plan, s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.Capacity = 20 }), 2, s.fx.Customer())
+
+	execute := func(route *models.Route) *models.RouteExecution {
+		execution := &models.RouteExecution{RouteID: route.ID, Status: "pending", PlannedLoad: route.TotalLoad}
+		for _, stop := range route.Stops {
+			execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{StopID: stop.ID, Status: "pending", PlannedQuantity: stop.Quantity})
+		}
+		if err := database.CreateRouteExecution(s.db, execution); err != nil {
+			t.Fatal(err)
+		}
+		return execution
+	}
+	mark := func(t *testing.T, execution *models.RouteExecution, stopID int64, req UpdateStopExecutionRequest) {
+		t.Helper()
+		w := s.do(t, "PUT", fmt.Sprintf("/api/v1/executions/%d/stops/%d", execution.ID, stopID), token, req)
+		if w.Code != http.StatusOK {
+			t.Fatalf("report stop status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+	}
+	list := func(t *testing.T) []models.Redelivery {
+		t.Helper()
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/plans/%d/redeliveries", plan.ID), token, nil)
+		var resp struct{ Data []models.Redelivery }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	todayRun, tomorrowRun := execute(today), execute(tomorrow)
+
+	var queued, scheduled models.Redelivery
+	var stops []models.StopExecution
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"report stops", func(t *testing.T) {
+			four := 4.0
+			mark(t, todayRun, todayRun.StopExecutions[0].ID, UpdateStopExecutionRequest{Status: "completed", ActualQuantity: &four})
+			mark(t, todayRun, todayRun.StopExecutions[1].ID, UpdateStopExecutionRequest{Status: "failed", Notes: "closed"})
+
+			entries := list(t)
+			if len(entries) != 2 {
+				t.Fatalf("redeliveries = %+v, want 2", entries)
+			}
+			queued, scheduled = entries[0], entries[1]
+			if scheduled.Reason != "partial" || scheduled.Quantity != 6 || scheduled.Status != "scheduled" || scheduled.RouteID == nil || *scheduled.RouteID != tomorrow.ID {
+				t.Fatalf("partial redelivery = %+v, want 6 units scheduled on tomorrow's route", scheduled)
+			}
+			if queued.Reason != "failed" || queued.Quantity != 10 || queued.Status != "queued" || queued.RouteID != nil {
+				t.Errorf("failed redelivery = %+v, want 10 units queued", queued)
+			}
+		}},
+		{"appended to a later route", func(t *testing.T) {
+			route, _ := database.GetRouteWithStops(s.db, tomorrow.ID)
+			if len(route.Stops) != 2 || route.TotalLoad != 16 || route.Stops[1].ID != *scheduled.StopID || *route.Stops[1].CustomerID != short.ID || route.TotalDistance <= tomorrow.TotalDistance {
+				t.Errorf("tomorrow's route = load %v over %v km with stops %+v, want the remainder appended", route.TotalLoad, route.TotalDistance, route.Stops)
+			}
+			stops, _ = database.GetStopExecutionsByRouteExecution(s.db, tomorrowRun.ID)
+			if len(stops) != 2 || stops[1].StopID != *scheduled.StopID || stops[1].PlannedQuantity != 6 {
+				t.Fatalf("tomorrow's stop executions = %+v, want the redelivery added", stops)
+			}
+		}},
+		{"redelivered", func(t *testing.T) {
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/redeliveries/%d/cancel", scheduled.ID), token, nil); w.Code != http.StatusConflict {
+				t.Errorf("cancel scheduled status = %d, want 409", w.Code)
+			}
+			mark(t, tomorrowRun, stops[1].ID, UpdateStopExecutionRequest{Status: "completed"})
+			if stored, _ := database.GetRedelivery(s.db, scheduled.ID); stored.Status != "delivered" || stored.ResolvedAt == nil {
+				t.Errorf("redelivered stop = %+v, want delivered", stored)
+			}
+		}},
+		{"queued for the next optimization", func(t *testing.T) {
+			// the next optimization serves the queued customer first
+			next := s.fx.Plan(warehouse, time.Date(2024, 3, 7, 0, 0, 0, 0, time.UTC), 3)
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/optimize", next.ID), token, nil); w.Code != http.StatusOK {
+				t.Fatalf("optimize status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			for _, c := range s.opt.LastRequest().Customers {
+				if c.ID == failed.ID && c.Priority < forcedPriorityBoost {
+					t.Errorf("queued customer priority = %d, want boosted", c.Priority)
+				}
+			}
+			if stored, _ := database.GetRedelivery(s.db, queued.ID); stored.Status != "consumed" || stored.ConsumedPlanID == nil || *stored.ConsumedPlanID != next.ID {
+				t.Errorf("queued redelivery after optimization = %+v, want consumed by plan %d", stored, next.ID)
+			}
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/redeliveries/%d/cancel", queued.ID), token, nil); w.Code != http.StatusConflict {
+				t.Errorf("cancel consumed status = %d, want 409", w.Code)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
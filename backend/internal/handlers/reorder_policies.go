@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"log"
+	"math"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// applyProductPolicies plans customers that keep products from their
+// products' reorder policies instead of their aggregate inventory. Without
+// product inventory the customers are planned in aggregate, as before.
+func (h *Handler) applyProductPolicies(optReq *optimizer.OptimizeRequest) {
+	ids := make([]int64, len(optReq.Customers))
+	for i, c := range optReq.Customers {
+		ids[i] = c.ID
+	}
+	inventories, err := database.GetCustomerProductInventories(h.db, ids)
+	if err != nil {
+		log.Printf("WARNING: product inventory unavailable, planning customers in aggregate: %v", err)
+		return
+	}
+	for i := range optReq.Customers {
+		if products := inventories[optReq.Customers[i].ID]; len(products) > 0 {
+			productPolicies(&optReq.Customers[i], products)
+		}
+	}
+}
+
+// productPolicies sets a customer's demand and inventory to the sums over
+// its products and lists the products with what each can take. A product
+// is reordered at its reorder point, or its min inventory, and filled by
+// its order quantity, or up to its max inventory, so the customer's min
+// inventory is the sum of the reorder points and its max inventory the sum
+// of the levels the products are filled to.
+func productPolicies(c *optimizer.CustomerData, products []models.CustomerProductInventory) {
+	c.DemandRate, c.CurrentInventory, c.MinInventory, c.MaxInventory = 0, 0, 0, 0
+	c.Products = make([]optimizer.ProductData, len(products))
+	for i, p := range products {
+		reorder := p.ReorderPoint
+		if reorder == 0 {
+			reorder = p.MinInventory
+		}
+		room := math.Inf(1)
+		if p.MaxInventory > 0 {
+			room = math.Max(p.MaxInventory-p.CurrentInventory, 0)
+		}
+		filled := p.MaxInventory
+		if p.OrderQuantity > 0 && (filled == 0 || reorder+p.OrderQuantity < filled) {
+			filled = reorder + p.OrderQuantity
+		}
+		filled = math.Max(filled, p.CurrentInventory)
+
+		data := optimizer.ProductData{
+			ProductID:        p.ProductID,
+			DemandRate:       p.DemandRate,
+			CurrentInventory: p.CurrentInventory,
+			MaxInventory:     p.MaxInventory,
+			ReorderPoint:     reorder,
+			OrderQuantity:    p.OrderQuantity,
+		}
+		if p.CurrentInventory <= reorder {
+			data.Deliverable = room
+			if p.OrderQuantity > 0 {
+				data.Deliverable = math.Min(p.OrderQuantity, room)
+			}
+			if math.IsInf(data.Deliverable, 1) {
+				data.Deliverable = 0
+			}
+		}
+		if p.DemandRate > 0 {
+			days := math.Max(p.CurrentInventory-reorder, 0) / p.DemandRate
+			data.DaysToReorder = &days
+		}
+		c.Products[i] = data
+
+		c.DemandRate += p.DemandRate
+		c.CurrentInventory += p.CurrentInventory
+		c.MinInventory += reorder
+		c.MaxInventory += filled
+	}
+}
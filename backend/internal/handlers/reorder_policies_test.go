@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"testing"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// TestProductPolicies tests deriving a customer's optimizer data from its
+// products' reorder policies
+func TestProductPolicies(t *testing.T) {
+	customer := optimizer.CustomerData{ID: 1, DemandRate: 99, CurrentInventory: 99, MinInventory: 99, MaxInventory: 999}
+	productPolicies(&customer, []models.CustomerProductInventory{
+		// at its reorder point, filled by its order quantity
+		{ProductID: 1, CurrentInventory: 10, MaxInventory: 100, ReorderPoint: 10, OrderQuantity: 30, DemandRate: 5},
+		// above its min inventory, filled up to its max
+		{ProductID: 2, CurrentInventory: 40, MaxInventory: 60, MinInventory: 20, DemandRate: 4},
+		// below its reorder point, its order quantity capped by its room
+		{ProductID: 3, CurrentInventory: 5, MaxInventory: 20, ReorderPoint: 8, OrderQuantity: 25},
+	})
+
+	if customer.DemandRate != 9 || customer.CurrentInventory != 55 {
+		t.Errorf("demand, inventory = %g, %g, want 9, 55", customer.DemandRate, customer.CurrentInventory)
+	}
+	// reorder points 10 + 20 + 8; filled to 40 + 60 + 20
+	if customer.MinInventory != 38 || customer.MaxInventory != 120 {
+		t.Errorf("min, max = %g, %g, want 38, 120", customer.MinInventory, customer.MaxInventory)
+	}
+	if len(customer.Products) != 3 {
+		t.Fatalf("products = %+v, want 3", customer.Products)
+	}
+	for i, want := range []struct {
+		deliverable float64
+		days        float64 // -1 without demand
+	}{{30, 0}, {0, 5}, {15, -1}} {
+		p := customer.Products[i]
+		if p.Deliverable != want.deliverable {
+			t.Errorf("product %d deliverable = %g, want %g", p.ProductID, p.Deliverable, want.deliverable)
+		}
+		days := -1.0
+		if p.DaysToReorder != nil {
+			days = *p.DaysToReorder
+		}
+		if days != want.days {
+			t.Errorf("product %d days to reorder = %g, want %g", p.ProductID, days, want.days)
+		}
+	}
+}
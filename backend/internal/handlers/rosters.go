@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/roster"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RosterEntryRequest struct {
+	DriverID  int64  `json:"driver_id" binding:"required"`
+	VehicleID int64  `json:"vehicle_id" binding:"required"`
+	Date      string `json:"date" binding:"required"`
+	Notes     string `json:"notes"`
+}
+
+type RosterRequest struct {
+	Entries []RosterEntryRequest `json:"entries" binding:"required,min=1,dive"`
+}
+
+// ListRoster handles GET /api/v1/rosters?from=&to=&warehouse_id=
+func (h *Handler) ListRoster(c *gin.Context) {
+	from, to, ok := h.parseRosterRange(c)
+	if !ok {
+		return
+	}
+
+	var warehouseID *int64
+	if raw := c.Query("warehouse_id"); raw != "" {
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid warehouse ID")
+			return
+		}
+		warehouseID = &id
+	}
+
+	entries, err := database.ListRosterEntries(h.db, from, to, warehouseID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch roster")
+		return
+	}
+	if entries == nil {
+		entries = []models.RosterEntry{}
+	}
+	successResponse(c, entries)
+}
+
+// CreateRosterEntries handles POST /api/v1/rosters
+// All entries are created together, or none if any of them conflicts.
+func (h *Handler) CreateRosterEntries(c *gin.Context) {
+	var req RosterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	entries := make([]models.RosterEntry, len(req.Entries))
+	var driverIDs, vehicleIDs []int64
+	var dates []time.Time
+	for i, e := range req.Entries {
+		date, err := time.Parse("2006-01-02", e.Date)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD): "+e.Date)
+			return
+		}
+		entries[i] = models.RosterEntry{
+			DriverID:  e.DriverID,
+			VehicleID: e.VehicleID,
+			Date:      date,
+			Notes:     e.Notes,
+			CreatedBy: &userID,
+		}
+		driverIDs = append(driverIDs, e.DriverID)
+		vehicleIDs = append(vehicleIDs, e.VehicleID)
+		dates = append(dates, date)
+	}
+
+	drivers, err := database.GetDriversByIDs(h.db, driverIDs)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch drivers")
+		return
+	}
+	vehicles, err := database.GetVehiclesByIDs(h.db, vehicleIDs)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
+		return
+	}
+	for _, e := range entries {
+		if _, ok := drivers[e.DriverID]; !ok {
+			errorResponse(c, http.StatusBadRequest, "Driver not found: "+strconv.FormatInt(e.DriverID, 10))
+			return
+		}
+		if _, ok := vehicles[e.VehicleID]; !ok {
+			errorResponse(c, http.StatusBadRequest, "Vehicle not found: "+strconv.FormatInt(e.VehicleID, 10))
+			return
+		}
+	}
+
+	existing, err := database.GetRosterEntriesForDates(h.db, dates)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch roster")
+		return
+	}
+
+	first, last := dates[0], dates[0]
+	for _, d := range dates {
+		if d.Before(first) {
+			first = d
+		}
+		if d.After(last) {
+			last = d
+		}
+	}
+	absences, err := database.GetApprovedAbsences(h.db, driverIDs, first, last)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver absences")
+		return
+	}
+
+	res := roster.Resources{Drivers: drivers, Vehicles: vehicles, Absences: absences}
+	if conflicts := roster.DetectConflicts(entries, existing, res); len(conflicts) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"success":   false,
+			"error":     "Roster entries conflict with existing assignments",
+			"code":      codeRosterConflict,
+			"conflicts": conflicts,
+		})
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		return database.CreateRosterEntriesTx(tx, entries)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create roster entries")
+		return
+	}
+	createdResponse(c, entries)
+}
+
+// DeleteRosterEntry handles DELETE /api/v1/rosters/:id
+func (h *Handler) DeleteRosterEntry(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid roster entry ID")
+		return
+	}
+
+	if err := database.DeleteRosterEntry(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Roster entry")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete roster entry")
+		return
+	}
+	successResponse(c, gin.H{"message": "Roster entry deleted successfully"})
+}
+
+// GetRosterConflicts handles GET /api/v1/rosters/conflicts?from=&to=
+// Reports stored entries that can no longer be honoured, e.g. because the
+// driver went on leave or the vehicle was taken out of service.
+func (h *Handler) GetRosterConflicts(c *gin.Context) {
+	from, to, ok := h.parseRosterRange(c)
+	if !ok {
+		return
+	}
+
+	entries, err := database.ListRosterEntries(h.db, from, to, nil)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch roster")
+		return
+	}
+
+	res, err := h.rosterResources(entries, from, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver absences")
+		return
+	}
+
+	successResponse(c, roster.CheckEntries(entries, res))
+}
+
+// applyRoster restricts the optimizer's vehicles to the days they have a
+// rostered driver. If no vehicle in the request is rostered in the window the
+// roster is treated as unused and the request is left unchanged.
+func (h *Handler) applyRoster(optReq *optimizer.OptimizeRequest, start time.Time) error {
+	vehicleIDs := make([]int64, len(optReq.Vehicles))
+	for i, v := range optReq.Vehicles {
+		vehicleIDs[i] = v.ID
+	}
+	end := start.AddDate(0, 0, optReq.PlanningHorizon-1)
+
+	entries, err := database.GetRosterEntriesForVehicles(h.db, vehicleIDs, start, end)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+
+	res, err := h.rosterResources(entries, start, end)
+	if err != nil {
+		return err
+	}
+
+	days := roster.VehicleDays(roster.Usable(entries, res), start)
+	vehicles := make([]optimizer.VehicleData, 0, len(optReq.Vehicles))
+	for _, v := range optReq.Vehicles {
+		if len(days[v.ID]) == 0 {
+			continue
+		}
+		v.AvailableDays = days[v.ID]
+		vehicles = append(vehicles, v)
+	}
+	optReq.Vehicles = vehicles
+	return nil
+}
+
+// rosterResources collects the drivers, vehicles and approved absences
+// referenced by preloaded roster entries
+func (h *Handler) rosterResources(entries []models.RosterEntry, from, to time.Time) (roster.Resources, error) {
+	res := roster.Resources{
+		Drivers:  make(map[int64]models.Driver),
+		Vehicles: make(map[int64]models.Vehicle),
+	}
+	var driverIDs []int64
+	for _, e := range entries {
+		if e.Driver != nil {
+			res.Drivers[e.DriverID] = *e.Driver
+		}
+		if e.Vehicle != nil {
+			res.Vehicles[e.VehicleID] = *e.Vehicle
+		}
+		driverIDs = append(driverIDs, e.DriverID)
+	}
+	if len(driverIDs) == 0 {
+		return res, nil
+	}
+
+	absences, err := database.GetApprovedAbsences(h.db, driverIDs, from, to)
+	if err != nil {
+		return res, err
+	}
+	res.Absences = absences
+	return res, nil
+}
+
+// parseRosterRange reads from/to query parameters, defaulting to the next 14 days
+func (h *Handler) parseRosterRange(c *gin.Context) (time.Time, time.Time, bool) {
+	today := h.clock.Now().UTC().Truncate(24 * time.Hour)
+	from, err := parseDateQuery(c, "from", today)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid from date format (use YYYY-MM-DD)")
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := parseDateQuery(c, "to", from.AddDate(0, 0, 13))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid to date format (use YYYY-MM-DD)")
+		return time.Time{}, time.Time{}, false
+	}
+	if to.Before(from) {
+		errorResponse(c, http.StatusBadRequest, "to date must be after from date")
+		return time.Time{}, time.Time{}, false
+	}
+	return from, to, true
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/quantity"
+)
+
+// productRule returns the rounding rule configured on a product
+func productRule(p *models.Product) quantity.Rule {
+	if p == nil {
+		return quantity.Rule{}
+	}
+	return quantity.Rule{Step: p.QuantityStep, Mode: p.RoundingMode, Unit: p.Unit}
+}
+
+// quantityRules maps customer IDs to the rounding rule of the product their
+// inventory is planned in. Customers without a rounded product are omitted.
+func (h *Handler) quantityRules(customers []models.Customer) (map[int64]quantity.Rule, error) {
+	products, err := database.ListProducts(h.db)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*models.Product, len(products))
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+	}
+
+	rules := make(map[int64]quantity.Rule)
+	for _, c := range customers {
+		if c.ProductID == nil {
+			continue
+		}
+		if rule := productRule(byID[*c.ProductID]); rule.Enabled() {
+			rules[c.ID] = rule
+		}
+	}
+	return rules, nil
+}
+
+// roundOptimizerQuantities rounds stop quantities to each customer's product
+// step and recomputes route loads. Stops that would round to zero get one
+// step since the solver decided to visit them, and any rounding up falls back
+// to rounding down when it would overload the vehicle.
+func roundOptimizerQuantities(optReq *optimizer.OptimizeRequest, optResp *optimizer.OptimizeResponse, rules map[int64]quantity.Rule) {
+	if len(rules) == 0 {
+		return
+	}
+
+	capacities := make(map[int64]float64, len(optReq.Vehicles))
+	for _, v := range optReq.Vehicles {
+		capacities[v.ID] = v.Capacity
+	}
+
+	for i := range optResp.Routes {
+		route := &optResp.Routes[i]
+		capacity, hasCapacity := capacities[route.VehicleID]
+
+		load := 0.0
+		for _, s := range route.Stops {
+			load += s.Quantity
+		}
+
+		for j := range route.Stops {
+			stop := &route.Stops[j]
+			rule, ok := rules[stop.CustomerID]
+			if !ok {
+				continue
+			}
+			rounded := quantity.Round(stop.Quantity, rule)
+			if rounded <= 0 {
+				rounded = rule.Step
+			}
+			if hasCapacity && rounded > stop.Quantity && load-stop.Quantity+rounded > capacity {
+				rounded = quantity.Round(stop.Quantity, quantity.Rule{Step: rule.Step, Mode: quantity.RoundDown})
+			}
+			load += rounded - stop.Quantity
+			stop.Quantity = rounded
+		}
+		route.TotalLoad = load
+	}
+}
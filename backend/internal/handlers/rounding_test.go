@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/quantity"
+)
+
+// TestRoundOptimizerQuantities tests rounding and the capacity fallback
+func TestRoundOptimizerQuantities(t *testing.T) {
+	optReq := &optimizer.OptimizeRequest{Vehicles: []optimizer.VehicleData{{ID: 1, Capacity: 11}}}
+	optResp := &optimizer.OptimizeResponse{Routes: []optimizer.RouteResult{{
+		VehicleID: 1,
+		Stops: []optimizer.StopResult{
+			{CustomerID: 1, Quantity: 3.4127},
+			{CustomerID: 2, Quantity: 5.5},
+			{CustomerID: 3, Quantity: 0.2},
+			{CustomerID: 4, Quantity: 1.2345},
+		},
+	}}}
+	rules := map[int64]quantity.Rule{
+		1: {Step: 1, Mode: quantity.RoundUp},
+		2: {Step: 1, Mode: quantity.RoundUp},
+		3: {Step: 1},
+	}
+
+	roundOptimizerQuantities(optReq, optResp, rules)
+
+	route := optResp.Routes[0]
+	// Rounding customers 2 and 3 up would overload the vehicle
+	want := []float64{4, 5, 0, 1.2345}
+	for i, w := range want {
+		if route.Stops[i].Quantity != w {
+			t.Errorf("stop %d quantity = %v, want %v", i, route.Stops[i].Quantity, w)
+		}
+	}
+	if math.Abs(route.TotalLoad-10.2345) > 1e-9 {
+		t.Errorf("TotalLoad = %v, want 10.2345", route.TotalLoad)
+	}
+}
+
+// TestProductRounding tests that stored routes use product steps and manual
+// stop edits are validated against them
+func TestProductRounding(t *testing.T) {
+	s := newTestServer(t)
+
+	pallets := &models.Product{Name: "Pallet", SKU: "PAL", Unit: "pallets", QuantityStep: 2, RoundingMode: quantity.RoundUp}
+	database.CreateProduct(s.db, pallets)
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	database.CreateCustomer(s.db, &models.Customer{Name: "Customer", Latitude: 40.7, Longitude: -74.0, DemandRate: 10, ProductID: &pallets.ID})
+	database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.PATCH("/stops/:id", s.h.UpdateStop)
+	token := s.login(t, "user")
+
+	var routes []models.Route
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"optimize", func(t *testing.T) {
+			// The fake optimizer delivers 5 units; whole double pallets round up to 6
+			if w := s.do(t, "POST", "/api/v1/plans/"+strconv.FormatInt(plan.ID, 10)+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			routes, _ = database.GetRoutesByPlan(s.db, plan.ID)
+			if len(routes) != 1 || routes[0].Stops[0].Quantity != 6 || routes[0].TotalLoad != 6 {
+				t.Fatalf("stored routes = %+v, want one stop of 6", routes)
+			}
+		}},
+		{"edit stop", func(t *testing.T) {
+			stopPath := "/api/v1/stops/" + strconv.FormatInt(routes[0].Stops[0].ID, 10)
+			if w := s.do(t, "PATCH", stopPath, token, UpdateStopRequest{Quantity: func(q float64) *float64 { return &q }(5)}); w.Code != http.StatusBadRequest {
+				t.Errorf("UpdateStop(5) status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if w := s.do(t, "PATCH", stopPath, token, UpdateStopRequest{Quantity: func(q float64) *float64 { return &q }(8)}); w.Code != http.StatusOK {
+				t.Fatalf("UpdateStop(8) status = %d, body = %s", w.Code, w.Body.String())
+			}
+			route, _ := database.GetRouteByID(s.db, routes[0].ID)
+			if route.TotalLoad != 8 {
+				t.Errorf("route load after edit = %v, want 8", route.TotalLoad)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/loadplan"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRouteLoadPlan handles GET /api/v1/routes/:id/load-plan
+// Returns the load order of the route's cargo, last drop first, with the
+// axle loads on every leg checked against the vehicle's limits.
+func (h *Handler) GetRouteLoadPlan(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid route ID")
+		return
+	}
+
+	route, err := database.GetCargoRoute(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return
+	}
+	if route.Plan != nil && !h.canSeePlan(c, route.Plan) {
+		return
+	}
+	successResponse(c, routeLoadPlan(*route))
+}
+
+// GetPlanLoadCheck handles GET /api/v1/plans/:id/load-check
+// Checks every route of the plan and lists the ones that cannot legally be
+// loaded.
+func (h *Handler) GetPlanLoadCheck(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	plan, err := h.plans.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if !h.canSeePlan(c, plan) {
+		return
+	}
+
+	routes, err := database.GetCargoRoutes(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+
+	check := models.PlanLoadCheck{PlanID: plan.ID, RoutesChecked: len(routes), Illegal: []models.RouteLoadPlan{}}
+	for _, r := range routes {
+		if lp := routeLoadPlan(r); !lp.Legal {
+			check.Illegal = append(check.Illegal, lp)
+		}
+	}
+	successResponse(c, check)
+}
+
+// routeLoadPlan weighs a route's stops and plans its load. A route without
+// a vehicle is only sequenced.
+func routeLoadPlan(r models.Route) models.RouteLoadPlan {
+	lp := models.RouteLoadPlan{
+		RouteID:              r.ID,
+		VehicleID:            r.VehicleID,
+		Items:                []models.LoadPlanItem{},
+		Legs:                 []models.LoadPlanLeg{},
+		Violations:           []string{},
+		MissingWeightStopIDs: []int64{},
+	}
+
+	items := make([]loadplan.Item, 0, len(r.Stops))
+	customers := make(map[int64]string, len(r.Stops))
+	for _, s := range r.Stops {
+		// break and refuel stops carry no cargo
+		if s.Type != "" && s.Type != "delivery" {
+			continue
+		}
+		weight, ok := stopWeight(s)
+		if !ok {
+			lp.MissingWeightStopIDs = append(lp.MissingWeightStopIDs, s.ID)
+		}
+		items = append(items, loadplan.Item{StopID: s.ID, Sequence: s.Sequence, Quantity: s.Quantity, Weight: weight})
+		if s.Customer != nil {
+			customers[s.ID] = s.Customer.Name
+		}
+		lp.TotalWeight += weight
+	}
+
+	var limits loadplan.Limits
+	if r.Vehicle != nil {
+		limits = loadplan.Limits{
+			Capacity:     r.Vehicle.Capacity,
+			MaxPayload:   r.Vehicle.MaxPayloadWeight,
+			MaxFrontAxle: r.Vehicle.MaxFrontAxleLoad,
+			MaxRearAxle:  r.Vehicle.MaxRearAxleLoad,
+		}
+	}
+	result := loadplan.Plan(items, limits)
+
+	for _, p := range result.Placements {
+		lp.Items = append(lp.Items, models.LoadPlanItem{
+			LoadOrder:    p.LoadOrder,
+			StopID:       p.StopID,
+			Sequence:     p.Sequence,
+			CustomerName: customers[p.StopID],
+			Quantity:     p.Quantity,
+			Weight:       p.Weight,
+			BedFrom:      p.From,
+			BedTo:        p.To,
+		})
+	}
+	for _, leg := range result.Legs {
+		lp.Legs = append(lp.Legs, models.LoadPlanLeg(leg))
+	}
+	lp.Violations = append(lp.Violations, result.Violations...)
+	lp.Legal = len(lp.Violations) == 0
+	return lp
+}
+
+// stopWeight is the weight of a stop's cargo in kg: its product quantities
+// when split by product, otherwise its quantity of the customer's product.
+// It reports false when any product involved has no weight.
+func stopWeight(s models.Stop) (float64, bool) {
+	if len(s.ProductQuantities) > 0 {
+		total, ok := 0.0, true
+		for _, pq := range s.ProductQuantities {
+			if pq.Product == nil || pq.Product.Weight <= 0 {
+				ok = ok && pq.Quantity == 0
+				continue
+			}
+			total += pq.Quantity * pq.Product.Weight
+		}
+		return total, ok
+	}
+	if s.Customer == nil || s.Customer.Product == nil || s.Customer.Product.Weight <= 0 {
+		return 0, s.Quantity == 0
+	}
+	return s.Quantity * s.Customer.Product.Weight, true
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"testing"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestRouteLoadPlan tests stop weights from products and the legality flag
+func TestRouteLoadPlan(t *testing.T) {
+	pallet := &models.Product{Name: "Pallet", Weight: 20}
+	drum := &models.Product{Name: "Drum", Weight: 50}
+	unweighed := &models.Product{Name: "Crate"}
+	vehicleID := int64(3)
+
+	route := models.Route{
+		ID:        1,
+		VehicleID: &vehicleID,
+		Vehicle:   &models.Vehicle{Capacity: 100, MaxPayloadWeight: 2000, MaxRearAxleLoad: 1000},
+		Stops: []models.Stop{
+			{ID: 10, Sequence: 1, Quantity: 50, Customer: &models.Customer{Name: "Acme", Product: pallet}},
+			{ID: 11, Sequence: 2, Quantity: 30, ProductQuantities: []models.StopProductQuantity{
+				{Quantity: 10, Product: drum},
+				{Quantity: 20, Product: pallet},
+			}},
+			{ID: 12, Sequence: 3, Quantity: 20, Customer: &models.Customer{Name: "Globex", Product: unweighed}},
+		},
+	}
+
+	got := routeLoadPlan(route)
+	if got.TotalWeight != 1000+900 {
+		t.Errorf("total weight = %v, want 1900", got.TotalWeight)
+	}
+	if len(got.MissingWeightStopIDs) != 1 || got.MissingWeightStopIDs[0] != 12 {
+		t.Errorf("missing weights = %v, want [12]", got.MissingWeightStopIDs)
+	}
+	if first := got.Items[0]; first.StopID != 12 || first.CustomerName != "Globex" {
+		t.Errorf("first loaded = %+v, want the last drop", first)
+	}
+	// Acme's 1000 kg sits at the door (centre 0.75), the drums and pallets
+	// of stop 11 in the middle (centre 0.35)
+	if got.Legal || len(got.Violations) != 1 {
+		t.Errorf("load plan = %+v, want an illegal rear axle", got)
+	}
+
+	route.Vehicle.MaxRearAxleLoad = 0
+	if got := routeLoadPlan(route); !got.Legal {
+		t.Errorf("violations = %v, want a legal load without axle limits", got.Violations)
+	}
+}
@@ -0,0 +1,238 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/push"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SendRouteMessageRequest struct {
+	Body string `json:"body" binding:"required,max=4000"`
+}
+
+type MarkRouteMessagesReadRequest struct {
+	MessageID int64 `json:"message_id"` // defaults to the newest message
+}
+
+// GetRouteMessages handles GET /api/v1/routes/:id/messages?after_id=&limit=
+// Messages come oldest first; pass the last ID seen as after_id to poll for
+// new ones. limit defaults to 50, max 200.
+func (h *Handler) GetRouteMessages(c *gin.Context) {
+	route, ok := h.messageRoute(c)
+	if !ok {
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	afterID, err := parseIDQuery(c, "after_id")
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	thread := models.RouteThread{RouteID: route.ID}
+	var after int64
+	if afterID != nil {
+		after = *afterID
+	}
+	if thread.Messages, err = database.ListRouteMessages(h.db, route.ID, after, page.Limit); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch messages")
+		return
+	}
+	if thread.Reads, err = database.GetRouteMessageReads(h.db, route.ID); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch read receipts")
+		return
+	}
+	if thread.Unread, err = database.CountUnreadRouteMessages(h.db, route.ID, c.GetInt64("userID")); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to count unread messages")
+		return
+	}
+	if thread.Messages == nil {
+		thread.Messages = []models.RouteMessage{}
+	}
+	if thread.Reads == nil {
+		thread.Reads = []models.RouteMessageRead{}
+	}
+	successResponse(c, thread)
+}
+
+// SendRouteMessage handles POST /api/v1/routes/:id/messages
+// The other participants of the thread get a push notification: the
+// route's driver, the plan's creator and approver, and whoever posted
+// before.
+func (h *Handler) SendRouteMessage(c *gin.Context) {
+	route, ok := h.messageRoute(c)
+	if !ok {
+		return
+	}
+	var req SendRouteMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	senderID := c.GetInt64("userID")
+	message := &models.RouteMessage{RouteID: route.ID, SenderID: senderID, Body: req.Body}
+	if err := database.CreateRouteMessage(h.db, message); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to send message")
+		return
+	}
+	if err := database.MarkRouteMessagesRead(h.db, route.ID, senderID, message.ID, message.CreatedAt); err != nil {
+		log.Printf("Failed to mark message %d read by its sender: %v", message.ID, err)
+	}
+	if sender, err := database.GetUserByID(h.db, senderID); err == nil {
+		message.Sender = sender
+	}
+	h.notifyRouteMessage(route, message)
+
+	createdResponse(c, message)
+}
+
+// MarkRouteMessagesRead handles POST /api/v1/routes/:id/messages/read
+// Moves the user's read receipt forward to message_id, or to the newest
+// message when it is omitted.
+func (h *Handler) MarkRouteMessagesRead(c *gin.Context) {
+	route, ok := h.messageRoute(c)
+	if !ok {
+		return
+	}
+	var req MarkRouteMessagesReadRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	latest, err := database.LatestRouteMessageID(h.db, route.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch messages")
+		return
+	}
+	if req.MessageID == 0 || req.MessageID > latest {
+		req.MessageID = latest
+	}
+	if req.MessageID > 0 {
+		if err := database.MarkRouteMessagesRead(h.db, route.ID, c.GetInt64("userID"), req.MessageID, h.clock.Now()); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to mark messages read")
+			return
+		}
+	}
+
+	reads, err := database.GetRouteMessageReads(h.db, route.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch read receipts")
+		return
+	}
+	if reads == nil {
+		reads = []models.RouteMessageRead{}
+	}
+	successResponse(c, reads)
+}
+
+// messageRoute loads the route of a messaging request and checks that the
+// user may take part in its thread
+func (h *Handler) messageRoute(c *gin.Context) (*models.Route, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid route ID")
+		return nil, false
+	}
+	route, err := database.GetRouteByID(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return nil, false
+	}
+	if !h.canReportRoute(c, route) {
+		return nil, false
+	}
+	return route, true
+}
+
+// notifyRouteMessage queues a push notification of a new message for every
+// participant of the route's thread but its sender. Failures are logged
+// since the message is already stored.
+func (h *Handler) notifyRouteMessage(route *models.Route, message *models.RouteMessage) {
+	if h.config == nil || h.config.PushGatewayURL == "" {
+		return
+	}
+	recipients, err := h.routeMessageRecipients(route)
+	if err != nil {
+		log.Printf("Failed to find recipients of message %d: %v", message.ID, err)
+		return
+	}
+
+	title := fmt.Sprintf("Route %d, %s", route.ID, route.Date.Format("2006-01-02"))
+	if message.Sender != nil {
+		title = message.Sender.Name + " on " + title
+	}
+	for _, userID := range recipients {
+		if userID == message.SenderID {
+			continue
+		}
+		notification := push.Notification{
+			UserID: userID,
+			Title:  title,
+			Body:   truncate(message.Body, 200),
+			Data: map[string]string{
+				"route_id":   strconv.FormatInt(route.ID, 10),
+				"message_id": strconv.FormatInt(message.ID, 10),
+			},
+		}
+		if _, err := jobs.Enqueue(h.db, push.JobType, notification, jobs.Options{RunAt: h.clock.Now()}); err != nil {
+			log.Printf("Failed to queue push of message %d to user %d: %v", message.ID, userID, err)
+		}
+	}
+}
+
+// routeMessageRecipients returns the users taking part in a route's
+// thread, in a stable order
+func (h *Handler) routeMessageRecipients(route *models.Route) ([]int64, error) {
+	var candidates []int64
+	if route.DriverID != nil {
+		driver, err := database.GetDriver(h.db, *route.DriverID)
+		if err != nil && !errors.Is(err, database.ErrNotFound) {
+			return nil, err
+		}
+		if driver != nil && driver.UserID != nil {
+			candidates = append(candidates, *driver.UserID)
+		}
+	}
+	if route.Plan != nil {
+		for _, id := range []*int64{route.Plan.CreatedBy, route.Plan.ApprovedBy} {
+			if id != nil {
+				candidates = append(candidates, *id)
+			}
+		}
+	}
+	senders, err := database.GetRouteMessageSenders(h.db, route.ID)
+	if err != nil {
+		return nil, err
+	}
+	candidates = append(candidates, senders...)
+
+	seen := make(map[int64]bool, len(candidates))
+	recipients := make([]int64, 0, len(candidates))
+	for _, id := range candidates {
+		if !seen[id] {
+			seen[id] = true
+			recipients = append(recipients, id)
+		}
+	}
+	return recipients, nil
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/push"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestRouteMessages tests a dispatcher-driver thread with read receipts and
+// push notifications to the other participant
+func TestRouteMessages(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.PushGatewayURL = "http://push.example.com/notify" })
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 7, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.api.GET("/routes/:id/messages", s.h.GetRouteMessages)
+	s.api.POST("/routes/:id/messages", s.h.SendRouteMessage)
+	s.api.POST("/routes/:id/messages/read", s.h.MarkRouteMessagesRead)
+
+	manager := s.fx.User("manager")
+	driverUser := s.fx.User("driver")
+	warehouse := s.fx.Warehouse()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1)
+	s.db.Model(plan).Update("created_by", manager.ID)
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, s.fx.Customer())
+	driver := &models.Driver{Name: "Dana", UserID: &driverUser.ID}
+	if err := database.CreateDriver(s.db, driver); err != nil {
+		t.Fatal(err)
+	}
+	s.db.Model(route).Update("driver_id", driver.ID)
+
+	managerToken := e2eLogin(t, s.router, manager).Token
+	driverToken := e2eLogin(t, s.router, driverUser).Token
+	messages := fmt.Sprintf("/api/v1/routes/%d/messages", route.ID)
+	thread := func(t *testing.T, token, query string) models.RouteThread {
+		t.Helper()
+		w := s.do(t, "GET", messages+query, token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get messages status = %d: %s", w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.RouteThread }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	var first struct{ Data models.RouteMessage }
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"rejected", func(t *testing.T) {
+			if w := s.do(t, "GET", messages, s.login(t, "driver"), nil); w.Code != http.StatusForbidden {
+				t.Errorf("other driver status = %d, want 403", w.Code)
+			}
+			if w := s.do(t, "POST", messages, managerToken, SendRouteMessageRequest{}); w.Code != http.StatusBadRequest {
+				t.Errorf("empty message status = %d, want 400", w.Code)
+			}
+		}},
+		{"send", func(t *testing.T) {
+			w := s.do(t, "POST", messages, managerToken, SendRouteMessageRequest{Body: "Customer 2 asks for delivery before 10"})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("send status = %d: %s", w.Code, w.Body.String())
+			}
+			json.Unmarshal(w.Body.Bytes(), &first)
+			got := thread(t, driverToken, "")
+			if len(got.Messages) != 1 || got.Messages[0].Sender == nil || got.Messages[0].Sender.ID != manager.ID || got.Unread != 1 {
+				t.Fatalf("driver thread = %+v, want the manager's message unread", got)
+			}
+		}},
+		{"read receipts", func(t *testing.T) {
+			readAt := clk.Advance(5 * time.Minute)
+			if w := s.do(t, "POST", messages+"/read", driverToken, nil); w.Code != http.StatusOK {
+				t.Fatalf("mark read status = %d: %s", w.Code, w.Body.String())
+			}
+			got := thread(t, driverToken, "")
+			if got.Unread != 0 || len(got.Reads) != 2 {
+				t.Fatalf("driver thread after reading = %+v, want receipts of both users", got)
+			}
+			for _, r := range got.Reads {
+				if r.UserID == driverUser.ID && (r.LastReadMessageID != first.Data.ID || !r.ReadAt.Equal(readAt)) {
+					t.Errorf("driver receipt = %+v, want message %d read at %v", r, first.Data.ID, readAt)
+				}
+			}
+		}},
+		{"reply", func(t *testing.T) {
+			clk.Advance(time.Minute)
+			if w := s.do(t, "POST", messages, driverToken, SendRouteMessageRequest{Body: "OK, doing it first"}); w.Code != http.StatusCreated {
+				t.Fatalf("reply status = %d: %s", w.Code, w.Body.String())
+			}
+			if got := thread(t, managerToken, fmt.Sprintf("?after_id=%d", first.Data.ID)); len(got.Messages) != 1 || got.Messages[0].Body != "OK, doing it first" || got.Unread != 1 {
+				t.Errorf("manager thread after %d = %+v, want the reply unread", first.Data.ID, got)
+			}
+		}},
+		{"push notifications", func(t *testing.T) {
+			queued, err := database.ListJobs(s.db, "", push.JobType, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			recipients := map[int64]int{}
+			for _, job := range queued {
+				var n push.Notification
+				json.Unmarshal([]byte(job.Payload), &n)
+				recipients[n.UserID]++
+			}
+			if len(queued) != 2 || recipients[driverUser.ID] != 1 || recipients[manager.ID] != 1 {
+				t.Errorf("push recipients = %v, want one notification each for the driver and the manager", recipients)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LockRouteRequest struct {
+	Locked *bool `json:"locked" binding:"required"`
+}
+
+// LockRoute handles PUT /api/v1/routes/:id/lock
+// Locked routes, e.g. manually edited or already dispatched ones, survive
+// re-optimization unchanged.
+func (h *Handler) LockRoute(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid route ID")
+		return
+	}
+
+	var req LockRouteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if err := database.SetRouteLocked(h.db, id, *req.Locked); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update route")
+		return
+	}
+
+	route, err := database.GetRouteByID(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return
+	}
+	successResponse(c, route)
+}
+
+// ExplainRoute handles GET /api/v1/routes/:id/explain
+// Reports why each customer is on the route that day, from the inventory,
+// demand and priority the optimizer solved with.
+func (h *Handler) ExplainRoute(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid route ID")
+		return
+	}
+
+	route, err := database.GetRouteWithExplanations(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return
+	}
+	if route.Plan != nil && !h.canSeePlan(c, route.Plan) {
+		return
+	}
+
+	explanation := models.RouteExplanation{
+		RouteID:   route.ID,
+		PlanID:    route.PlanID,
+		Day:       route.Day,
+		Date:      route.Date,
+		VehicleID: route.VehicleID,
+		Stops:     make([]models.StopExplanationItem, len(route.Stops)),
+	}
+	for i, s := range route.Stops {
+		item := models.StopExplanationItem{
+			StopID:      s.ID,
+			Sequence:    s.Sequence,
+			CustomerID:  s.CustomerID,
+			Quantity:    s.Quantity,
+			Explanation: s.Explanation,
+		}
+		if s.Customer != nil {
+			item.CustomerName = s.Customer.Name
+		}
+		explanation.Stops[i] = item
+	}
+	successResponse(c, explanation)
+}
+
+// applyLockedRoutes pins a plan's locked routes in an optimizer request:
+// they are sent as locked routes, which the solver counts as deliveries to
+// their customers on their days without visiting them again that day, and
+// their vehicles are taken on their days. dayOffset converts plan days to
+// request days; locked routes before the request window are skipped. It
+// returns the number of routes applied.
+func applyLockedRoutes(optReq *optimizer.OptimizeRequest, routes []models.Route, dayOffset int) int {
+	busy := make(map[int64]map[int]bool)
+	applied := 0
+	for _, r := range routes {
+		if !r.Locked || r.Day <= dayOffset {
+			continue
+		}
+		result := routeToResult(r)
+		result.Day = r.Day - dayOffset
+		optReq.LockedRoutes = append(optReq.LockedRoutes, result)
+		if r.VehicleID != nil {
+			if busy[*r.VehicleID] == nil {
+				busy[*r.VehicleID] = make(map[int]bool)
+			}
+			busy[*r.VehicleID][result.Day] = true
+		}
+		applied++
+	}
+	if applied == 0 {
+		return 0
+	}
+
+	vehicles := optReq.Vehicles[:0]
+	for _, v := range optReq.Vehicles {
+		if taken := busy[v.ID]; len(taken) > 0 {
+			days := v.AvailableDays
+			if len(days) == 0 {
+				for day := 1; day <= optReq.PlanningHorizon; day++ {
+					days = append(days, day)
+				}
+			}
+			free := make([]int, 0, len(days))
+			for _, day := range days {
+				if !taken[day] {
+					free = append(free, day)
+				}
+			}
+			if len(free) == 0 {
+				continue
+			}
+			v.AvailableDays = free
+		}
+		vehicles = append(vehicles, v)
+	}
+	optReq.Vehicles = vehicles
+	return applied
+}
@@ -0,0 +1,180 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestLockedRoutesSurviveOptimize tests that re-optimizing keeps locked
+// routes and sends them with their vehicle days left out of the solve
+func TestLockedRoutesSurviveOptimize(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	for i := 0; i < 2; i++ {
+		database.CreateCustomer(s.db, &models.Customer{Name: "Customer " + strconv.Itoa(i), Latitude: 40.7, Longitude: -74.0, DemandRate: 10})
+		database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck " + strconv.Itoa(i), Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.PUT("/routes/:id/lock", s.h.LockRoute)
+	token := s.login(t, "user")
+
+	optimizePath := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10) + "/optimize"
+	var (
+		pinned         models.Route
+		pinnedCustomer int64
+	)
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"optimize", func(t *testing.T) {
+			if w := s.do(t, "POST", optimizePath, token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			routes, _ := database.GetRoutesByPlan(s.db, plan.ID)
+			if len(routes) != 2 {
+				t.Fatalf("optimize created %d routes, want 2", len(routes))
+			}
+			pinned = routes[0]
+			pinnedCustomer = *pinned.Stops[0].CustomerID
+		}},
+		{"lock", func(t *testing.T) {
+			if w := s.do(t, "PUT", "/api/v1/routes/999/lock", token, gin.H{"locked": true}); w.Code != http.StatusNotFound {
+				t.Errorf("LockRoute() on a missing route status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+			if w := s.do(t, "PUT", "/api/v1/routes/"+strconv.FormatInt(pinned.ID, 10)+"/lock", token, gin.H{"locked": true}); w.Code != http.StatusOK {
+				t.Fatalf("LockRoute() status = %d, body = %s", w.Code, w.Body.String())
+			}
+		}},
+		{"re-optimize", func(t *testing.T) {
+			if w := s.do(t, "POST", optimizePath, token, nil); w.Code != http.StatusOK {
+				t.Fatalf("second OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			req := s.opt.LastRequest()
+			if len(req.LockedRoutes) != 1 || req.LockedRoutes[0].Day != pinned.Day || req.LockedRoutes[0].Stops[0].CustomerID != pinnedCustomer {
+				t.Errorf("request locked routes = %+v, want the pinned route", req.LockedRoutes)
+			}
+			// The pinned customer is only served by the locked route on its
+			// day and can get deliveries on the others
+			if len(req.Customers) != 2 {
+				t.Errorf("request customers = %+v, want both", req.Customers)
+			}
+			for _, v := range req.Vehicles {
+				if v.ID == *pinned.VehicleID && (len(v.AvailableDays) != 6 || v.AvailableDays[0] != 2) {
+					t.Errorf("pinned vehicle available days = %v, want days 2-7", v.AvailableDays)
+				}
+			}
+
+			routes, _ := database.GetRoutesByPlan(s.db, plan.ID)
+			kept := false
+			for _, r := range routes {
+				if r.ID == pinned.ID {
+					kept = r.Locked
+				}
+			}
+			if !kept || len(routes) != 3 {
+				t.Errorf("after re-optimizing got %d routes, pinned route kept = %v, want 3 routes including the locked one", len(routes), kept)
+			}
+			updated, _ := database.GetPlan(s.db, plan.ID)
+			if updated.TotalCost != 300 {
+				t.Errorf("plan total cost = %v, want 300 including the locked route", updated.TotalCost)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
+
+// TestExplainRoute tests that optimized stops carry the explanation captured
+// from the optimizer request
+func TestExplainRoute(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	customer := &models.Customer{Name: "Acme", Latitude: 40.7, Longitude: -74.0, DemandRate: 10, CurrentInventory: 15, MinInventory: 20, MaxInventory: 100, Priority: 2}
+	database.CreateCustomer(s.db, customer)
+	database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 2), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.GET("/routes/:id/explain", s.h.ExplainRoute)
+	token := s.login(t, "user")
+
+	var routes []models.Route
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"optimize", func(t *testing.T) {
+			if w := s.do(t, "POST", "/api/v1/plans/"+strconv.FormatInt(plan.ID, 10)+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			routes, _ = database.GetRoutesByPlan(s.db, plan.ID)
+			if len(routes) != 1 {
+				t.Fatalf("optimize created %d routes, want 1", len(routes))
+			}
+		}},
+		{"optimized stop", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/routes/"+strconv.FormatInt(routes[0].ID, 10)+"/explain", token, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("ExplainRoute() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var resp struct {
+				Data models.RouteExplanation
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if len(resp.Data.Stops) != 1 || resp.Data.Stops[0].CustomerName != "Acme" {
+				t.Fatalf("ExplainRoute() = %+v, want the Acme stop", resp.Data)
+			}
+			got := resp.Data.Stops[0].Explanation
+			if got == nil || got.Reason != optimizer.ReasonBelowMinimum || got.Priority != 2 || got.DaysToStockout == nil || *got.DaysToStockout != 1.5 {
+				t.Errorf("explanation = %+v, want below_minimum with priority 2 and 1.5 days to stockout", got)
+			}
+		}},
+		{"manual stop", func(t *testing.T) {
+			// Stops not created by the optimizer have no explanation
+			manual := &models.Route{PlanID: plan.ID, Day: 2, Date: start.AddDate(0, 0, 1)}
+			database.CreateRoute(s.db, manual)
+			s.db.Create(&models.Stop{RouteID: manual.ID, CustomerID: &customer.ID, Sequence: 1, Quantity: 5})
+			w := s.do(t, "GET", "/api/v1/routes/"+strconv.FormatInt(manual.ID, 10)+"/explain", token, nil)
+			var resp struct {
+				Data models.RouteExplanation
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if w.Code != http.StatusOK || len(resp.Data.Stops) != 1 || resp.Data.Stops[0].Explanation != nil {
+				t.Errorf("ExplainRoute(manual) status = %d, data = %+v, want a stop without explanation", w.Code, resp.Data)
+			}
+		}},
+		{"missing route", func(t *testing.T) {
+			if w := s.do(t, "GET", "/api/v1/routes/999/explain", token, nil); w.Code != http.StatusNotFound {
+				t.Errorf("ExplainRoute(missing) status = %d, want %d", w.Code, http.StatusNotFound)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
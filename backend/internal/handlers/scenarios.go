@@ -0,0 +1,387 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/usage"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ScenarioRequest struct {
+	Name             string  `json:"name" binding:"required"`
+	VehicleCount     *int    `json:"vehicle_count" binding:"omitempty,min=1"`
+	DemandMultiplier float64 `json:"demand_multiplier" binding:"omitempty,gt=0"`
+	CustomerIDs      []int64 `json:"customer_ids"`
+}
+
+// ScenarioComparison reports a scenario's results relative to the baseline
+// (the first scenario in the comparison)
+type ScenarioComparison struct {
+	ScenarioID           int64   `json:"scenario_id"`
+	Name                 string  `json:"name"`
+	Status               string  `json:"status"`
+	TotalCost            float64 `json:"total_cost"`
+	TotalDistance        float64 `json:"total_distance"`
+	VehiclesUsed         int     `json:"vehicles_used"`
+	CostDelta            float64 `json:"cost_delta"`
+	CostDeltaPercent     float64 `json:"cost_delta_percent"`
+	DistanceDelta        float64 `json:"distance_delta"`
+	DistanceDeltaPercent float64 `json:"distance_delta_percent"`
+	VehiclesUsedDelta    int     `json:"vehicles_used_delta"`
+}
+
+// CreateScenario handles POST /api/v1/plans/:id/scenarios
+func (h *Handler) CreateScenario(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	var req ScenarioRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if _, err := h.plans.Get(planID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	if req.DemandMultiplier == 0 {
+		req.DemandMultiplier = 1
+	}
+	userID := c.GetInt64("userID")
+
+	scenario := &models.Scenario{
+		PlanID:           planID,
+		Name:             req.Name,
+		Status:           "draft",
+		VehicleCount:     req.VehicleCount,
+		DemandMultiplier: req.DemandMultiplier,
+		CustomerIDs:      req.CustomerIDs,
+		CreatedBy:        &userID,
+	}
+
+	if err := database.CreateScenario(h.db, scenario); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create scenario")
+		return
+	}
+	createdResponse(c, scenario)
+}
+
+// ListPlanScenarios handles GET /api/v1/plans/:id/scenarios
+func (h *Handler) ListPlanScenarios(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	scenarios, err := database.ListScenariosByPlan(h.db, planID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch scenarios")
+		return
+	}
+	if scenarios == nil {
+		scenarios = []models.Scenario{}
+	}
+	successResponse(c, scenarios)
+}
+
+// GetScenario handles GET /api/v1/scenarios/:id
+func (h *Handler) GetScenario(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid scenario ID")
+		return
+	}
+
+	scenario, err := database.GetScenario(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Scenario")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch scenario")
+		return
+	}
+	successResponse(c, scenario)
+}
+
+// DeleteScenario handles DELETE /api/v1/scenarios/:id
+func (h *Handler) DeleteScenario(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid scenario ID")
+		return
+	}
+
+	if err := database.DeleteScenario(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Scenario")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete scenario")
+		return
+	}
+	successResponse(c, gin.H{"message": "Scenario deleted successfully"})
+}
+
+// OptimizeScenario handles POST /api/v1/scenarios/:id/optimize
+func (h *Handler) OptimizeScenario(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid scenario ID")
+		return
+	}
+
+	scenario, err := database.GetScenario(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Scenario")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch scenario")
+		return
+	}
+
+	plan, err := h.plans.Get(scenario.PlanID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch source plan")
+		return
+	}
+	if plan.WarehouseID == nil {
+		errorResponse(c, http.StatusBadRequest, "Plan has no warehouse assigned")
+		return
+	}
+
+	warehouse, err := database.GetWarehouse(h.db, *plan.WarehouseID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return
+	}
+
+	customers, err := h.customers.List()
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customers")
+		return
+	}
+	customers = applyScenarioCustomers(scenario, planCustomers(plan, customers))
+	if len(customers) == 0 {
+		errorResponse(c, http.StatusBadRequest, "No customers to optimize")
+		return
+	}
+
+	vehicles, err := database.ListAvailableVehiclesByWarehouse(h.db, warehouse.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
+		return
+	}
+	vehicles = planVehicles(plan, vehicles)
+	// scenarios can shrink the fleet, not add vehicles it does not have
+	if scenario.VehicleCount != nil {
+		if *scenario.VehicleCount > len(vehicles) {
+			errorResponse(c, http.StatusBadRequest, fmt.Sprintf("Scenario uses %d vehicles but only %d are available", *scenario.VehicleCount, len(vehicles)))
+			return
+		}
+		vehicles = vehicles[:*scenario.VehicleCount]
+	}
+	if len(vehicles) == 0 {
+		errorResponse(c, http.StatusBadRequest, "No available vehicles for optimization")
+		return
+	}
+
+	optReq := h.buildOptimizeRequest(plan, warehouse, customers, vehicles)
+	if err := h.applyRoster(optReq, plan.StartDate); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver roster")
+		return
+	}
+	if len(optReq.Vehicles) == 0 {
+		errorResponse(c, http.StatusBadRequest, "No vehicles have a rostered driver in the planning window")
+		return
+	}
+
+	if !h.reserveUsage(c, usage.Optimizations) {
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	run := &models.OptimizationRun{PlanID: &plan.ID, ScenarioID: &scenario.ID, Kind: "scenario", CreatedBy: &userID}
+	optResp, err := h.callOptimizer(run, optReq, nil)
+	if err == nil && !optResp.Success {
+		err = errors.New(optResp.Message)
+	}
+	if err != nil {
+		scenario.Status = "failed"
+		scenario.Message = err.Error()
+		database.UpdateScenarioStatus(h.db, scenario)
+		errorResponse(c, http.StatusInternalServerError, "Optimization failed: "+err.Error())
+		return
+	}
+	rules, err := h.quantityRules(customers)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch product rounding rules")
+		return
+	}
+	roundOptimizerQuantities(optReq, optResp, rules)
+	optimizer.FillRouteTimes(optReq, optResp)
+	if violations := optimizer.ValidateResponse(optReq, optResp); len(violations) > 0 {
+		scenario.Status = "failed"
+		scenario.Message = violations[0].Message
+		database.UpdateScenarioStatus(h.db, scenario)
+		h.markRunInfeasible(run, violations)
+		infeasibleResultResponse(c, violations)
+		return
+	}
+
+	routes := make([]models.ScenarioRoute, 0, len(optResp.Routes))
+	vehiclesUsed := make(map[int64]bool)
+	for _, routeResult := range optResp.Routes {
+		routeDate, err := time.Parse("2006-01-02", routeResult.Date)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Optimizer returned invalid route date: "+routeResult.Date)
+			return
+		}
+		var vehicleID *int64
+		if routeResult.VehicleID != 0 {
+			vID := routeResult.VehicleID
+			vehicleID = &vID
+			vehiclesUsed[vID] = true
+		}
+		stops := make([]models.ScenarioStop, len(routeResult.Stops))
+		for i, s := range routeResult.Stops {
+			stops[i] = models.ScenarioStop{
+				CustomerID:  s.CustomerID,
+				Sequence:    s.Sequence,
+				Quantity:    s.Quantity,
+				ArrivalTime: s.ArrivalTime,
+			}
+		}
+		routes = append(routes, models.ScenarioRoute{
+			VehicleID:     vehicleID,
+			Day:           routeResult.Day,
+			Date:          routeDate,
+			TotalDistance: routeResult.TotalDistance,
+			TotalCost:     routeResult.TotalCost,
+			TotalLoad:     routeResult.TotalLoad,
+			Stops:         stops,
+		})
+	}
+
+	scenario.Status = "optimized"
+	scenario.Message = optResp.Message
+	scenario.TotalCost = optResp.TotalCost
+	scenario.TotalDistance = optResp.TotalDistance
+	scenario.VehiclesUsed = len(vehiclesUsed)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		return database.SaveScenarioResultTx(tx, scenario, routes)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to save scenario result: "+err.Error())
+		return
+	}
+
+	scenario, err = database.GetScenario(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated scenario")
+		return
+	}
+	successResponse(c, scenario)
+}
+
+// CompareScenarios handles GET /api/v1/scenarios/compare?ids=1,2,3
+func (h *Handler) CompareScenarios(c *gin.Context) {
+	var ids []int64
+	for _, raw := range strings.Split(c.Query("ids"), ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid scenario ID: "+raw)
+			return
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) < 2 {
+		errorResponse(c, http.StatusBadRequest, "At least two scenario IDs are required")
+		return
+	}
+
+	scenarios, err := database.GetScenariosByIDs(h.db, ids)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Scenario")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch scenarios")
+		return
+	}
+
+	baseline := scenarios[0]
+	comparisons := make([]ScenarioComparison, len(scenarios))
+	for i, s := range scenarios {
+		comparisons[i] = ScenarioComparison{
+			ScenarioID:        s.ID,
+			Name:              s.Name,
+			Status:            s.Status,
+			TotalCost:         s.TotalCost,
+			TotalDistance:     s.TotalDistance,
+			VehiclesUsed:      s.VehiclesUsed,
+			CostDelta:         s.TotalCost - baseline.TotalCost,
+			DistanceDelta:     s.TotalDistance - baseline.TotalDistance,
+			VehiclesUsedDelta: s.VehiclesUsed - baseline.VehiclesUsed,
+		}
+		if baseline.TotalCost > 0 {
+			comparisons[i].CostDeltaPercent = comparisons[i].CostDelta / baseline.TotalCost * 100
+		}
+		if baseline.TotalDistance > 0 {
+			comparisons[i].DistanceDeltaPercent = comparisons[i].DistanceDelta / baseline.TotalDistance * 100
+		}
+	}
+
+	successResponse(c, gin.H{
+		"baseline_scenario_id": baseline.ID,
+		"scenarios":            comparisons,
+	})
+}
+
+// applyScenarioCustomers narrows customers to the scenario's subset and
+// scales their demand by the scenario multiplier
+func applyScenarioCustomers(scenario *models.Scenario, customers []models.Customer) []models.Customer {
+	var include map[int64]bool
+	if len(scenario.CustomerIDs) > 0 {
+		include = make(map[int64]bool, len(scenario.CustomerIDs))
+		for _, id := range scenario.CustomerIDs {
+			include[id] = true
+		}
+	}
+
+	result := make([]models.Customer, 0, len(customers))
+	for _, c := range customers {
+		if include != nil && !include[c.ID] {
+			continue
+		}
+		c.DemandRate *= scenario.DemandMultiplier
+		result = append(result, c)
+	}
+	return result
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestScenarioLifecycle tests create, optimize and compare without touching the plan
+func TestScenarioLifecycle(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	for i := 0; i < 3; i++ {
+		database.CreateCustomer(s.db, &models.Customer{Name: "Customer " + strconv.Itoa(i), Latitude: 40.7, Longitude: -74.0, DemandRate: 10})
+		database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck " + strconv.Itoa(i), Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+
+	s.api.POST("/plans/:id/scenarios", s.h.CreateScenario)
+	s.api.GET("/scenarios/compare", s.h.CompareScenarios)
+	s.api.GET("/scenarios/:id", s.h.GetScenario)
+	s.api.POST("/scenarios/:id/optimize", s.h.OptimizeScenario)
+	token := s.login(t, "user")
+
+	createScenario := func(t *testing.T, req ScenarioRequest) models.Scenario {
+		t.Helper()
+		w := s.do(t, "POST", "/api/v1/plans/"+strconv.FormatInt(plan.ID, 10)+"/scenarios", token, req)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("CreateScenario() status = %d, body = %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data models.Scenario
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data
+	}
+
+	var full, reduced models.Scenario
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"optimize scenarios", func(t *testing.T) {
+			full = createScenario(t, ScenarioRequest{Name: "All vehicles"})
+			oneVehicle := 1
+			reduced = createScenario(t, ScenarioRequest{Name: "One vehicle, double demand", VehicleCount: &oneVehicle, DemandMultiplier: 2})
+
+			if w := s.do(t, "POST", "/api/v1/scenarios/"+strconv.FormatInt(full.ID, 10)+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizeScenario() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "POST", "/api/v1/scenarios/"+strconv.FormatInt(reduced.ID, 10)+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizeScenario() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if len(s.opt.LastRequest().Vehicles) != 1 {
+				t.Errorf("optimizer received %d vehicles, want 1", len(s.opt.LastRequest().Vehicles))
+			}
+			if s.opt.LastRequest().Customers[0].DemandRate != 20 {
+				t.Errorf("optimizer received demand rate %v, want 20", s.opt.LastRequest().Customers[0].DemandRate)
+			}
+		}},
+		{"plan untouched", func(t *testing.T) {
+			// The source plan must be untouched
+			routes, _ := database.GetRoutesByPlan(s.db, plan.ID)
+			if len(routes) != 0 {
+				t.Errorf("plan has %d routes after scenario optimization, want 0", len(routes))
+			}
+		}},
+		{"compare", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/scenarios/compare?ids="+strconv.FormatInt(full.ID, 10)+","+strconv.FormatInt(reduced.ID, 10), token, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("CompareScenarios() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			var compare struct {
+				Data struct {
+					Scenarios []ScenarioComparison `json:"scenarios"`
+				}
+			}
+			json.Unmarshal(w.Body.Bytes(), &compare)
+			if len(compare.Data.Scenarios) != 2 {
+				t.Fatalf("CompareScenarios() returned %d entries, want 2", len(compare.Data.Scenarios))
+			}
+			got := compare.Data.Scenarios[1]
+			if got.CostDelta != -200 || got.VehiclesUsedDelta != -2 {
+				t.Errorf("CompareScenarios() delta = %+v, want cost -200 and vehicles -2", got)
+			}
+		}},
+		{"more vehicles than the fleet", func(t *testing.T) {
+			fourVehicles := 4
+			larger := createScenario(t, ScenarioRequest{Name: "Four vehicles", VehicleCount: &fourVehicles})
+			if w := s.do(t, "POST", "/api/v1/scenarios/"+strconv.FormatInt(larger.ID, 10)+"/optimize", token, nil); w.Code != http.StatusBadRequest {
+				t.Errorf("OptimizeScenario() with 4 of 3 vehicles status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/securitylog"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+// ListSecurityEvents handles GET /api/v1/security-events?type=&user_id=&from=&to=&page=&limit=
+// Newest first. type takes a comma-separated list; user_id matches events
+// about or by the user; from and to are inclusive dates.
+func (h *Handler) ListSecurityEvents(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.SecurityEventFilter{}
+	if raw := c.Query("type"); raw != "" {
+		filter.Types = strings.Split(raw, ",")
+		for _, t := range filter.Types {
+			if !slices.Contains(securitylog.Types, t) {
+				errorResponse(c, http.StatusBadRequest, fmt.Sprintf("type must be one of %s", strings.Join(securitylog.Types, ", ")))
+				return
+			}
+		}
+	}
+	if filter.UserID, err = parseIDQuery(c, "user_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Since, filter.Before, err = parseDateRangeQuery(c); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Before != nil {
+		before := filter.Before.AddDate(0, 0, 1)
+		filter.Before = &before
+	}
+
+	events, total, err := database.ListSecurityEvents(h.db, filter, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch security events")
+		return
+	}
+	if events == nil {
+		events = []models.SecurityEvent{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, events, page)
+}
+
+// ChangePassword handles PUT /api/v1/me/password
+// Every token issued before the change is revoked, so the response carries
+// a fresh one.
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	user, err := database.GetUserByID(h.db, c.GetInt64("userID"))
+	if err != nil {
+		notFoundResponse(c, "User")
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.CurrentPassword)); err != nil {
+		errorResponse(c, http.StatusUnauthorized, "Current password is incorrect")
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to process password")
+		return
+	}
+	if err := database.UpdateUserPassword(h.db, user.ID, string(hashedPassword), h.clock.Now()); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update password")
+		return
+	}
+	h.recordSecurityEvent(c, securitylog.PasswordChanged, user, "existing tokens revoked")
+
+	token, expiresAt, err := h.generateToken(user)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to generate token")
+		return
+	}
+	successResponse(c, AuthResponse{
+		Token:     token,
+		ExpiresAt: expiresAt,
+		User:      user,
+	})
+}
+
+// RevokeTokens handles POST /api/v1/me/revoke-tokens
+// Signs the current user out everywhere, including this token.
+func (h *Handler) RevokeTokens(c *gin.Context) {
+	h.revokeTokens(c, c.GetInt64("userID"), "revoked by the user")
+}
+
+// RevokeUserTokens handles POST /api/v1/admin/users/:id/revoke-tokens
+func (h *Handler) RevokeUserTokens(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	h.revokeTokens(c, id, "revoked by an admin")
+}
+
+func (h *Handler) revokeTokens(c *gin.Context, userID int64, detail string) {
+	if err := database.RevokeUserTokens(h.db, userID, h.clock.Now()); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "User")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to revoke tokens")
+		return
+	}
+	user, err := database.GetUserByID(h.db, userID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	h.recordSecurityEvent(c, securitylog.TokensRevoked, user, detail)
+	successResponse(c, gin.H{"message": "Tokens revoked"})
+}
+
+// recordSecurityEvent stores a security event about user, which only has an
+// email for unknown accounts, and queues it for the SIEM webhook. The acting user is
+// taken from the request when it is authenticated. Failures are logged
+// rather than returned since the action being recorded already happened.
+func (h *Handler) recordSecurityEvent(c *gin.Context, eventType string, user *models.User, detail string) {
+	event := &models.SecurityEvent{
+		Type:      eventType,
+		IP:        c.ClientIP(),
+		UserAgent: truncate(c.Request.UserAgent(), 255),
+		Detail:    detail,
+	}
+	if user != nil {
+		if user.ID != 0 {
+			event.UserID = &user.ID
+		}
+		event.Email = user.Email
+	}
+	if actorID, ok := c.Get("userID"); ok {
+		id := actorID.(int64)
+		event.ActorID = &id
+	}
+
+	if err := database.CreateSecurityEvent(h.db, event); err != nil {
+		log.Printf("Failed to record %s security event: %v", event.Type, err)
+		return
+	}
+	if h.config == nil || h.config.SecurityWebhookURL == "" {
+		return
+	}
+	payload := securitylog.ForwardPayload{EventID: event.ID}
+	if _, err := jobs.Enqueue(h.db, securitylog.ForwardJobType, payload, jobs.Options{RunAt: h.clock.Now()}); err != nil {
+		log.Printf("Failed to queue security event %d for forwarding: %v", event.ID, err)
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/securitylog"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestSecurityEvents tests that logins, password changes, token
+// revocations and permission changes are logged, that revoked tokens are
+// rejected and that events are queued for the SIEM webhook
+func TestSecurityEvents(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.SecurityWebhookURL = "http://siem.example.com/events" })
+	clk := testkit.NewClock(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.router.POST("/api/v1/auth/refresh", s.h.RefreshToken)
+	s.api.GET("/me", s.h.GetCurrentUser)
+	s.api.PUT("/me/password", s.h.ChangePassword)
+	s.api.POST("/me/revoke-tokens", s.h.RevokeTokens)
+	s.api.GET("/security-events", s.h.AdminMiddleware(), s.h.ListSecurityEvents)
+	s.api.PUT("/admin/users/:id/role", s.h.AdminMiddleware(), s.h.UpdateUserRole)
+
+	admin := s.fx.User("admin")
+	user := s.fx.User("user")
+	adminToken := e2eLogin(t, s.router, admin).Token
+	userToken := e2eLogin(t, s.router, user).Token
+
+	list := func(t *testing.T, token, query string) (int, []models.SecurityEvent) {
+		t.Helper()
+		w := s.do(t, "GET", "/api/v1/security-events"+query, token, nil)
+		var resp struct {
+			Data       []models.SecurityEvent
+			Pagination models.Pagination
+		}
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	var changed struct{ Data AuthResponse }
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"failed logins", func(t *testing.T) {
+			s.do(t, "POST", "/api/v1/auth/login", "", LoginRequest{Email: user.Email, Password: "wrong"})
+			s.do(t, "POST", "/api/v1/auth/login", "", LoginRequest{Email: "nobody@example.com", Password: "wrong"})
+		}},
+		{"password change revokes older tokens", func(t *testing.T) {
+			clk.Advance(time.Minute)
+			w := s.do(t, "PUT", "/api/v1/me/password", userToken, ChangePasswordRequest{CurrentPassword: "wrong", NewPassword: "new-password"})
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("change password with wrong current password status = %d, want 401", w.Code)
+			}
+			w = s.do(t, "PUT", "/api/v1/me/password", userToken, ChangePasswordRequest{CurrentPassword: testkit.Password, NewPassword: "new-password"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("change password status = %d: %s", w.Code, w.Body.String())
+			}
+			json.Unmarshal(w.Body.Bytes(), &changed)
+			if w := s.do(t, "GET", "/api/v1/me", userToken, nil); w.Code != http.StatusUnauthorized {
+				t.Errorf("old token after password change status = %d, want 401", w.Code)
+			}
+			if w := s.do(t, "POST", "/api/v1/auth/refresh", userToken, nil); w.Code != http.StatusUnauthorized {
+				t.Errorf("refresh with old token status = %d, want 401", w.Code)
+			}
+			if w := s.do(t, "GET", "/api/v1/me", changed.Data.Token, nil); w.Code != http.StatusOK {
+				t.Errorf("new token after password change status = %d, want 200", w.Code)
+			}
+		}},
+		{"revoke tokens", func(t *testing.T) {
+			clk.Advance(time.Minute)
+			if w := s.do(t, "POST", "/api/v1/me/revoke-tokens", changed.Data.Token, nil); w.Code != http.StatusOK {
+				t.Fatalf("revoke tokens status = %d: %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "GET", "/api/v1/me", changed.Data.Token, nil); w.Code != http.StatusUnauthorized {
+				t.Errorf("revoked token status = %d, want 401", w.Code)
+			}
+		}},
+		{"role change", func(t *testing.T) {
+			w := s.do(t, "PUT", fmt.Sprintf("/api/v1/admin/users/%d/role", user.ID), adminToken, UpdateUserRoleRequest{Role: "manager"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("update role status = %d: %s", w.Code, w.Body.String())
+			}
+		}},
+		{"list access", func(t *testing.T) {
+			if code, _ := list(t, s.login(t, "user"), ""); code != http.StatusForbidden {
+				t.Errorf("non-admin list status = %d, want 403", code)
+			}
+			if code, _ := list(t, adminToken, "?type=unknown"); code != http.StatusBadRequest {
+				t.Errorf("unknown type status = %d, want 400", code)
+			}
+		}},
+		{"events by user", func(t *testing.T) {
+			code, events := list(t, adminToken, fmt.Sprintf("?user_id=%d", user.ID))
+			if code != http.StatusOK {
+				t.Fatalf("list status = %d", code)
+			}
+			var types []string
+			for _, e := range events {
+				types = append(types, e.Type)
+			}
+			want := []string{securitylog.RoleChanged, securitylog.TokensRevoked, securitylog.PasswordChanged, securitylog.LoginFailed, securitylog.Login}
+			if fmt.Sprint(types) != fmt.Sprint(want) {
+				t.Errorf("event types = %v, want %v", types, want)
+			}
+			if role := events[0]; role.ActorID == nil || *role.ActorID != admin.ID || role.Detail != "role changed from user to manager" {
+				t.Errorf("role change event = %+v", role)
+			}
+			if !events[2].CreatedAt.Equal(clk.Now().Add(-time.Minute)) {
+				t.Errorf("password change logged at %v", events[2].CreatedAt)
+			}
+		}},
+		{"events by type and date", func(t *testing.T) {
+			_, failed := list(t, adminToken, "?type=login_failed")
+			if len(failed) != 2 {
+				t.Fatalf("failed logins = %d, want 2", len(failed))
+			}
+			if unknown := failed[0]; unknown.UserID != nil || unknown.Email != "nobody@example.com" || unknown.Detail != "unknown email" {
+				t.Errorf("unknown email event = %+v", unknown)
+			}
+			if _, none := list(t, adminToken, "?to=2024-02-29"); len(none) != 0 {
+				t.Errorf("events before the first day = %d, want 0", len(none))
+			}
+		}},
+		{"forwarded to the SIEM", func(t *testing.T) {
+			queued, err := database.ListJobs(s.db, "", securitylog.ForwardJobType, 100)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var all int64
+			s.db.Model(&models.SecurityEvent{}).Count(&all)
+			if int64(len(queued)) != all {
+				t.Errorf("queued %d forward jobs for %d events", len(queued), all)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/testkit"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// testServer is a handler on a fresh test database and the fake optimizer,
+// behind a router that logs users in. Tests register the routes they
+// exercise on api and fill the database with fx.
+type testServer struct {
+	h      *Handler
+	db     *gorm.DB
+	opt    *testkit.Optimizer
+	fx     *testkit.Fixtures
+	router *gin.Engine
+	// api is /api/v1 behind AuthMiddleware
+	api *gin.RouterGroup
+}
+
+// newTestServer sets up a test server. configure, when given, changes the
+// test configuration before the handler is built.
+func newTestServer(t *testing.T, configure ...func(*config.Config)) *testServer {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	cfg := &config.Config{JWTSecret: "test-secret-key", JWTExpiry: 24, StorageSigningSecret: "test-storage-secret"}
+	for _, f := range configure {
+		f(cfg)
+	}
+	db := testkit.DB(t)
+	opt := testkit.NewOptimizer(t)
+	h := New(db, opt.Client(), cfg)
+
+	router := gin.New()
+	router.POST("/api/v1/auth/login", h.Login)
+	return &testServer{
+		h:      h,
+		db:     db,
+		opt:    opt,
+		fx:     testkit.NewFixtures(t, db),
+		router: router,
+		api:    router.Group("/api/v1", h.AuthMiddleware()),
+	}
+}
+
+// login creates a user with the role and returns their token
+func (s *testServer) login(t *testing.T, role string) string {
+	t.Helper()
+	return e2eLogin(t, s.router, s.fx.User(role)).Token
+}
+
+// do sends a JSON request to the router as the token's user
+func (s *testServer) do(t *testing.T, method, path, token string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	return e2eRequest(t, s.router, method, path, token, body)
+}
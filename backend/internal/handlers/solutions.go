@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListPlanSolutions handles GET /api/v1/plans/:id/solutions
+func (h *Handler) ListPlanSolutions(c *gin.Context) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	solutions, err := database.ListPlanSolutions(h.db, planID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch solutions")
+		return
+	}
+	if solutions == nil {
+		solutions = []models.PlanSolution{}
+	}
+	successResponse(c, solutions)
+}
+
+// GetPlanSolution handles GET /api/v1/plans/:id/solutions/:version
+func (h *Handler) GetPlanSolution(c *gin.Context) {
+	planID, version, ok := parseSolutionParams(c)
+	if !ok {
+		return
+	}
+
+	solution, err := database.GetPlanSolution(h.db, planID, version)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Solution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch solution")
+		return
+	}
+	successResponse(c, solution)
+}
+
+// RollbackPlanSolution handles POST /api/v1/plans/:id/solutions/:version/rollback
+// The plan's routes are replaced by the stored version, which is recorded as
+// a new version so the history stays append-only.
+func (h *Handler) RollbackPlanSolution(c *gin.Context) {
+	planID, version, ok := parseSolutionParams(c)
+	if !ok {
+		return
+	}
+
+	solution, err := database.GetPlanSolution(h.db, planID, version)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Solution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch solution")
+		return
+	}
+	if solution.IsCurrent {
+		errorResponse(c, http.StatusConflict, "Solution is already the current version")
+		return
+	}
+
+	plan, err := h.plans.Get(planID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+	if err := planstate.Check(plan.Status, planstate.Optimized); err != nil {
+		errorResponse(c, http.StatusConflict, err.Error())
+		return
+	}
+
+	started, err := database.CountStartedExecutionsFromDay(h.db, planID, 1)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to check route executions")
+		return
+	}
+	if started > 0 {
+		errorResponse(c, http.StatusConflict, "Plan routes have already started execution")
+		return
+	}
+
+	userID := c.GetInt64("userID")
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.DeleteRoutesByPlanTx(tx, planID); err != nil {
+			return err
+		}
+		for _, sr := range solution.Routes {
+			route := &models.Route{
+				PlanID:        planID,
+				VehicleID:     sr.VehicleID,
+				DriverID:      sr.DriverID,
+				Day:           sr.Day,
+				Date:          sr.Date,
+				PlannedStart:  sr.PlannedStart,
+				PlannedEnd:    sr.PlannedEnd,
+				TotalDistance: sr.TotalDistance,
+				TotalCost:     sr.TotalCost,
+				TotalLoad:     sr.TotalLoad,
+				CostBreakdown: sr.CostBreakdown,
+			}
+			if err := database.CreateRouteTx(tx, route); err != nil {
+				return err
+			}
+			for _, ss := range sr.Stops {
+				stop := &models.Stop{
+					RouteID:         route.ID,
+					PlaceID:         ss.PlaceID,
+					Type:            ss.Type,
+					Sequence:        ss.Sequence,
+					DurationMinutes: ss.DurationMinutes,
+					Quantity:        ss.Quantity,
+					ArrivalTime:     ss.ArrivalTime,
+				}
+				if ss.CustomerID != 0 {
+					customerID := ss.CustomerID
+					stop.CustomerID = &customerID
+				}
+				if err := database.CreateStopTx(tx, stop); err != nil {
+					return err
+				}
+			}
+		}
+		if err := database.UpdatePlanStatusTx(tx, planID, planstate.Optimized, solution.TotalCost, solution.TotalDistance); err != nil {
+			return err
+		}
+		return snapshotSolutionTx(tx, planID, "rollback", &solution.Version, solution.Parameters, "Rolled back to version "+strconv.Itoa(solution.Version), userID)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Rollback failed: "+err.Error())
+		return
+	}
+
+	plan, err = h.plans.Get(planID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated plan")
+		return
+	}
+	routes, err := database.GetRoutesByPlan(h.db, planID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated routes")
+		return
+	}
+	plan.Routes = routes
+
+	successResponse(c, plan)
+}
+
+// snapshotSolutionTx stores the plan's current routes as a new solution version
+func snapshotSolutionTx(tx *gorm.DB, planID int64, source string, baseVersion *int, params models.SolutionParameters, message string, userID int64) error {
+	routes, err := database.GetRoutesByPlan(tx, planID)
+	if err != nil {
+		return err
+	}
+
+	solution := &models.PlanSolution{
+		PlanID:      planID,
+		Source:      source,
+		BaseVersion: baseVersion,
+		RouteCount:  len(routes),
+		Parameters:  params,
+		Message:     message,
+		Routes:      make([]models.SolutionRoute, 0, len(routes)),
+	}
+	if userID != 0 {
+		solution.CreatedBy = &userID
+	}
+
+	for _, r := range routes {
+		stops := make([]models.ScenarioStop, 0, len(r.Stops))
+		for _, s := range r.Stops {
+			stop := models.ScenarioStop{
+				PlaceID:         s.PlaceID,
+				Sequence:        s.Sequence,
+				DurationMinutes: s.DurationMinutes,
+				Quantity:        s.Quantity,
+				ArrivalTime:     s.ArrivalTime,
+			}
+			switch {
+			case s.CustomerID != nil:
+				stop.CustomerID = *s.CustomerID
+			case s.PlaceID == nil:
+				continue
+			}
+			if s.Type != "delivery" {
+				stop.Type = s.Type
+			}
+			stops = append(stops, stop)
+		}
+		solution.Routes = append(solution.Routes, models.SolutionRoute{
+			VehicleID:     r.VehicleID,
+			DriverID:      r.DriverID,
+			Day:           r.Day,
+			Date:          r.Date,
+			PlannedStart:  r.PlannedStart,
+			PlannedEnd:    r.PlannedEnd,
+			TotalDistance: r.TotalDistance,
+			TotalCost:     r.TotalCost,
+			TotalLoad:     r.TotalLoad,
+			CostBreakdown: r.CostBreakdown,
+			Stops:         stops,
+		})
+		solution.TotalCost += r.TotalCost
+		solution.TotalDistance += r.TotalDistance
+	}
+
+	return database.CreatePlanSolutionTx(tx, solution)
+}
+
+// solutionParameters records the solver inputs of an optimizer request
+func solutionParameters(optReq *optimizer.OptimizeRequest, fromDay int) models.SolutionParameters {
+	params := models.SolutionParameters{
+		StartDate:       optReq.StartDate,
+		PlanningHorizon: optReq.PlanningHorizon,
+		FromDay:         fromDay,
+		CustomerCount:   len(optReq.Customers),
+		LockedRoutes:    len(optReq.LockedRoutes),
+		RoadDistances:   optReq.DistanceMatrix != nil,
+		VehicleIDs:      make([]int64, len(optReq.Vehicles)),
+	}
+	for i, v := range optReq.Vehicles {
+		params.VehicleIDs[i] = v.ID
+	}
+	return params
+}
+
+func parseSolutionParams(c *gin.Context) (int64, int, bool) {
+	planID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return 0, 0, false
+	}
+	version, err := strconv.Atoi(c.Param("version"))
+	if err != nil || version < 1 {
+		errorResponse(c, http.StatusBadRequest, "Invalid solution version")
+		return 0, 0, false
+	}
+	return planID, version, true
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestPlanSolutionVersions tests that each optimization is versioned and a
+// previous version can be restored
+func TestPlanSolutionVersions(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	database.CreateCustomer(s.db, &models.Customer{Name: "Customer", Latitude: 40.7, Longitude: -74.0, DemandRate: 10})
+	truck := &models.Vehicle{Name: "Truck 1", Capacity: 100, Available: true, WarehouseID: &warehouse.ID}
+	database.CreateVehicle(s.db, truck)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+	planPath := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10)
+
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.GET("/plans/:id/solutions", s.h.ListPlanSolutions)
+	s.api.POST("/plans/:id/solutions/:version/rollback", s.h.RollbackPlanSolution)
+	token := s.login(t, "user")
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"first optimization", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+		}},
+		{"second optimization", func(t *testing.T) {
+			// A second vehicle and customer change the next solution
+			database.CreateCustomer(s.db, &models.Customer{Name: "Customer 2", Latitude: 40.7, Longitude: -74.0, DemandRate: 10})
+			database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck 2", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+			if w := s.do(t, "POST", planPath+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if routes, _ := database.GetRoutesByPlan(s.db, plan.ID); len(routes) != 2 {
+				t.Fatalf("plan has %d routes after second optimization, want 2", len(routes))
+			}
+		}},
+		{"rollback", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/solutions/1/rollback", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("RollbackPlanSolution() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			routes, _ := database.GetRoutesByPlan(s.db, plan.ID)
+			if len(routes) != 1 || *routes[0].VehicleID != truck.ID {
+				t.Errorf("plan routes after rollback = %+v, want the single route of version 1", routes)
+			}
+		}},
+		{"list versions", func(t *testing.T) {
+			w := s.do(t, "GET", planPath+"/solutions", token, nil)
+			var response struct {
+				Data []models.PlanSolution
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if len(response.Data) != 3 {
+				t.Fatalf("ListPlanSolutions() returned %d versions, want 3", len(response.Data))
+			}
+			latest := response.Data[0]
+			if latest.Version != 3 || latest.Source != "rollback" || !latest.IsCurrent || latest.BaseVersion == nil || *latest.BaseVersion != 1 {
+				t.Errorf("latest solution = %+v, want current rollback version 3 based on 1", latest)
+			}
+		}},
+		{"rollback to current version", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/solutions/3/rollback", token, nil); w.Code != http.StatusConflict {
+				t.Errorf("rollback to current version status = %d, want %d", w.Code, http.StatusConflict)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// stockKinds are the kinds of stock movement
+var stockKinds = []string{models.StockReceipt, models.StockDelivery, models.StockAdjustment, models.StockTransferIn, models.StockTransferOut}
+
+type StockReceiptRequest struct {
+	ProductID  *int64     `json:"product_id"`
+	Quantity   float64    `json:"quantity" binding:"gt=0"`
+	Reference  string     `json:"reference" binding:"max=255"`
+	Notes      string     `json:"notes"`
+	OccurredAt *time.Time `json:"occurred_at"` // defaults to now
+}
+
+type StockAdjustmentRequest struct {
+	ProductID *int64 `json:"product_id"`
+	// Quantity is the change, negative to take stock out
+	Quantity   float64    `json:"quantity" binding:"required,ne=0"`
+	Reason     string     `json:"reason" binding:"required,oneof=count_correction damaged expired lost found returned other"`
+	Notes      string     `json:"notes"`
+	OccurredAt *time.Time `json:"occurred_at"`
+}
+
+type StockTransferRequest struct {
+	ToWarehouseID int64      `json:"to_warehouse_id" binding:"required"`
+	ProductID     *int64     `json:"product_id"`
+	Quantity      float64    `json:"quantity" binding:"gt=0"`
+	Reference     string     `json:"reference" binding:"max=255"`
+	Notes         string     `json:"notes"`
+	OccurredAt    *time.Time `json:"occurred_at"`
+}
+
+// GetWarehouseStock handles GET /api/v1/warehouses/:id/stock
+// The warehouse's current stock, its stock of each product and the stock
+// not booked to a product.
+func (h *Handler) GetWarehouseStock(c *gin.Context) {
+	warehouse, ok := h.stockWarehouse(c)
+	if !ok {
+		return
+	}
+
+	products, err := database.GetWarehouseProductStock(h.db, warehouse.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch product stock")
+		return
+	}
+	stock := &models.WarehouseStock{
+		WarehouseID:  warehouse.ID,
+		CurrentStock: warehouse.CurrentStock,
+		Unassigned:   warehouse.CurrentStock,
+		Products:     []models.WarehouseProductStock{},
+	}
+	for _, p := range products {
+		stock.Unassigned -= p.Quantity
+		stock.Products = append(stock.Products, p)
+	}
+	successResponse(c, stock)
+}
+
+// ListStockMovements handles GET /api/v1/warehouses/:id/stock/movements?product_id=&kind=&from=&to=&page=&limit=
+// The warehouse's stock ledger, latest first. kind takes a comma-separated
+// list; from and to are dates the movements occurred.
+func (h *Handler) ListStockMovements(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.StockMovementFilter{WarehouseID: id}
+	if raw := c.Query("kind"); raw != "" {
+		filter.Kinds = strings.Split(raw, ",")
+		for _, kind := range filter.Kinds {
+			if !slices.Contains(stockKinds, kind) {
+				errorResponse(c, http.StatusBadRequest, "kind must be one of "+strings.Join(stockKinds, ", "))
+				return
+			}
+		}
+	}
+	if filter.ProductID, err = parseIDQuery(c, "product_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Since, filter.Before, err = parseDateRangeQuery(c); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Before != nil {
+		before := filter.Before.AddDate(0, 0, 1)
+		filter.Before = &before
+	}
+
+	movements, total, err := database.ListStockMovements(h.db, filter, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stock movements")
+		return
+	}
+	if movements == nil {
+		movements = []models.StockMovement{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, movements, page)
+}
+
+// ReceiveStock handles POST /api/v1/warehouses/:id/stock/receipts
+// Books goods received at the warehouse, of a product or unassigned.
+func (h *Handler) ReceiveStock(c *gin.Context) {
+	var req StockReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	warehouse, ok := h.stockWarehouse(c)
+	if !ok || !h.validStockProduct(c, req.ProductID) {
+		return
+	}
+
+	movement := &models.StockMovement{
+		WarehouseID: warehouse.ID,
+		ProductID:   req.ProductID,
+		Kind:        models.StockReceipt,
+		Quantity:    req.Quantity,
+		Reference:   req.Reference,
+		Notes:       req.Notes,
+		OccurredAt:  h.stockTime(req.OccurredAt),
+	}
+	if h.recordStockMovements(c, movement) {
+		createdResponse(c, movement)
+	}
+}
+
+// AdjustStock handles POST /api/v1/warehouses/:id/stock/adjustments
+// Corrects the warehouse's stock by a signed quantity with a reason code,
+// e.g. after a count or for damaged goods. Stock cannot go below zero
+// (409). The stock after it is recorded as a snapshot.
+func (h *Handler) AdjustStock(c *gin.Context) {
+	var req StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	warehouse, ok := h.stockWarehouse(c)
+	if !ok || !h.validStockProduct(c, req.ProductID) {
+		return
+	}
+
+	movement := &models.StockMovement{
+		WarehouseID: warehouse.ID,
+		ProductID:   req.ProductID,
+		Kind:        models.StockAdjustment,
+		Reason:      req.Reason,
+		Quantity:    req.Quantity,
+		Notes:       req.Notes,
+		OccurredAt:  h.stockTime(req.OccurredAt),
+	}
+	if h.recordStockMovements(c, movement) {
+		h.queueAlertScan("warehouse", warehouse.ID)
+		createdResponse(c, movement)
+	}
+}
+
+// TransferStock handles POST /api/v1/warehouses/:id/stock/transfers
+// Moves stock to another warehouse, booked as a transfer out of this
+// warehouse and a transfer in at the other, in one transaction. Responds
+// with both movements.
+func (h *Handler) TransferStock(c *gin.Context) {
+	var req StockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	warehouse, ok := h.stockWarehouse(c)
+	if !ok || !h.validStockProduct(c, req.ProductID) {
+		return
+	}
+	if req.ToWarehouseID == warehouse.ID {
+		errorResponse(c, http.StatusBadRequest, "to_warehouse_id must be another warehouse")
+		return
+	}
+	if _, err := database.GetWarehouse(h.db, req.ToWarehouseID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusBadRequest, "to_warehouse_id not found")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return
+	}
+
+	at := h.stockTime(req.OccurredAt)
+	out := &models.StockMovement{
+		WarehouseID:            warehouse.ID,
+		ProductID:              req.ProductID,
+		Kind:                   models.StockTransferOut,
+		Quantity:               -req.Quantity,
+		CounterpartWarehouseID: &req.ToWarehouseID,
+		Reference:              req.Reference,
+		Notes:                  req.Notes,
+		OccurredAt:             at,
+	}
+	in := *out
+	in.WarehouseID, in.CounterpartWarehouseID = req.ToWarehouseID, &warehouse.ID
+	in.Kind, in.Quantity = models.StockTransferIn, req.Quantity
+	if h.recordStockMovements(c, out, &in) {
+		createdResponse(c, []*models.StockMovement{out, &in})
+	}
+}
+
+// stockWarehouse fetches the warehouse of a stock route. It writes the
+// error response and returns false when there is none.
+func (h *Handler) stockWarehouse(c *gin.Context) (*models.Warehouse, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid warehouse ID")
+		return nil, false
+	}
+	warehouse, err := database.GetWarehouse(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Warehouse")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return nil, false
+	}
+	return warehouse, true
+}
+
+// validStockProduct checks the product of a stock movement exists, writing
+// a 400 and returning false when it does not
+func (h *Handler) validStockProduct(c *gin.Context, productID *int64) bool {
+	if productID == nil {
+		return true
+	}
+	if _, err := database.GetProduct(h.db, *productID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusBadRequest, fmt.Sprintf("Product %d not found", *productID))
+			return false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch product")
+		return false
+	}
+	return true
+}
+
+// stockTime is when a movement occurred: the time given, or now
+func (h *Handler) stockTime(at *time.Time) time.Time {
+	if at != nil {
+		return *at
+	}
+	return h.clock.Now()
+}
+
+// recordStockMovements books movements to the ledger by the current user,
+// all or none. It writes the error response and returns false when they
+// cannot be booked.
+func (h *Handler) recordStockMovements(c *gin.Context, movements ...*models.StockMovement) bool {
+	userID := c.GetInt64("userID")
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		for _, m := range movements {
+			if userID != 0 {
+				m.CreatedBy = &userID
+			}
+			if err := database.RecordStockMovementTx(tx, m); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, database.ErrNotFound):
+		notFoundResponse(c, "Warehouse")
+	case errors.Is(err, database.ErrInsufficientStock):
+		errorResponse(c, http.StatusConflict, "Not enough stock for this movement")
+	default:
+		errorResponse(c, http.StatusInternalServerError, "Failed to record stock movement")
+	}
+	return false
+}
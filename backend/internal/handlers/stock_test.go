@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestStockLedger tests receipts, adjustments, transfers and deliveries
+// are booked to the stock ledger and kept per product
+func TestStockLedger(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.api.GET("/warehouses/:id/stock", s.h.GetWarehouseStock)
+	s.api.GET("/warehouses/:id/stock/movements", s.h.ListStockMovements)
+	s.api.POST("/warehouses/:id/stock/receipts", s.h.ReceiveStock)
+	s.api.POST("/warehouses/:id/stock/adjustments", s.h.AdjustStock)
+	s.api.POST("/warehouses/:id/stock/transfers", s.h.TransferStock)
+
+	token := s.login(t, "manager")
+	request := func(t *testing.T, method, path string, body interface{}, out interface{}) int {
+		t.Helper()
+		w := s.do(t, method, path, token, body)
+		if out != nil {
+			json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{out})
+		}
+		return w.Code
+	}
+
+	north := &models.Warehouse{Name: "North", Latitude: 40.7, Longitude: -74.0, CurrentStock: 500}
+	if err := database.CreateWarehouse(s.db, north); err != nil {
+		t.Fatal(err)
+	}
+	south := s.fx.Warehouse()
+	diesel := &models.Product{Name: "Diesel", SKU: "DSL", Unit: "l"}
+	database.CreateProduct(s.db, diesel)
+	stockPath := fmt.Sprintf("/api/v1/warehouses/%d/stock", north.ID)
+
+	t.Run("receipt", func(t *testing.T) {
+		var receipt models.StockMovement
+		if code := request(t, "POST", stockPath+"/receipts", StockReceiptRequest{ProductID: &diesel.ID, Quantity: 100, Reference: "DN-1"}, &receipt); code != http.StatusCreated {
+			t.Fatalf("receipt status = %d, want 201", code)
+		}
+		if receipt.Balance != 600 || receipt.ProductBalance == nil || *receipt.ProductBalance != 100 || receipt.CreatedBy == nil {
+			t.Errorf("receipt = %+v, want 600 in stock, 100 of diesel", receipt)
+		}
+	})
+
+	t.Run("adjustments", func(t *testing.T) {
+		if code := request(t, "POST", stockPath+"/adjustments", StockAdjustmentRequest{ProductID: &diesel.ID, Quantity: -30, Reason: "spilled"}, nil); code != http.StatusBadRequest {
+			t.Errorf("unknown reason status = %d, want 400", code)
+		}
+		request(t, "POST", stockPath+"/adjustments", StockAdjustmentRequest{ProductID: &diesel.ID, Quantity: -30, Reason: "damaged"}, nil)
+		if code := request(t, "POST", stockPath+"/adjustments", StockAdjustmentRequest{ProductID: &diesel.ID, Quantity: -80, Reason: "lost"}, nil); code != http.StatusConflict {
+			t.Errorf("adjustment below zero status = %d, want 409", code)
+		}
+		var snapshots int64
+		s.db.Model(&models.InventorySnapshot{}).Where("entity_type = ? AND snapshot_reason = ?", "warehouse", "adjustment").Count(&snapshots)
+		if snapshots != 1 {
+			t.Errorf("adjustment snapshots = %d, want 1 for the adjustment booked", snapshots)
+		}
+	})
+
+	t.Run("transfer", func(t *testing.T) {
+		var transfer []models.StockMovement
+		code := request(t, "POST", stockPath+"/transfers", StockTransferRequest{ToWarehouseID: south.ID, ProductID: &diesel.ID, Quantity: 20}, &transfer)
+		if code != http.StatusCreated || len(transfer) != 2 || transfer[0].Quantity != -20 || transfer[1].WarehouseID != south.ID || *transfer[1].CounterpartWarehouseID != north.ID {
+			t.Errorf("transfer = %d %+v, want out of north and into south", code, transfer)
+		}
+	})
+
+	t.Run("delivery", func(t *testing.T) {
+		// the customer's product is delivered from the warehouse's diesel
+		customer := s.fx.Customer(func(c *models.Customer) { c.ProductID = &diesel.ID })
+		plan := s.fx.Plan(north, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1, testkit.WithStatus("executing"))
+		route := s.fx.Route(plan, s.fx.Vehicle(north), 1, customer)
+		execution := &models.RouteExecution{RouteID: route.ID, Status: "in_progress", StopExecutions: []models.StopExecution{{StopID: route.Stops[0].ID, Status: "pending"}}}
+		database.CreateRouteExecution(s.db, execution)
+		departed := time.Date(2024, 3, 4, 11, 0, 0, 0, time.UTC)
+		stop := &execution.StopExecutions[0]
+		stop.Status, stop.ActualQuantity, stop.ActualDepartureTime = "completed", 10, &departed
+		if err := database.RecordDeliveryTx(s.db, stop); err != nil {
+			t.Fatal(err)
+		}
+
+		var stock models.WarehouseStock
+		request(t, "GET", stockPath, nil, &stock)
+		if stock.CurrentStock != 540 || len(stock.Products) != 1 || stock.Products[0].Quantity != 40 || stock.Unassigned != 500 {
+			t.Errorf("stock = %+v, want 540 of which 40 diesel", stock)
+		}
+	})
+
+	t.Run("ledger", func(t *testing.T) {
+		var page struct {
+			Data       []models.StockMovement
+			Pagination models.Pagination
+		}
+		w := s.do(t, "GET", stockPath+"/movements", token, nil)
+		json.Unmarshal(w.Body.Bytes(), &page)
+		if page.Pagination.Total != 5 || page.Data[0].Kind != models.StockDelivery || page.Data[0].StopExecutionID == nil || page.Data[4].Reason != "opening_balance" {
+			t.Errorf("ledger = %d %+v, want 5 movements from the opening balance to the delivery", page.Pagination.Total, page.Data)
+		}
+	})
+
+	t.Run("filtered ledger", func(t *testing.T) {
+		w := s.do(t, "GET", stockPath+"/movements?kind=adjustment,transfer_out", token, nil)
+		var resp struct{ Data []models.StockMovement }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if len(resp.Data) != 2 {
+			t.Errorf("adjustments and transfers out = %+v, want 2", resp.Data)
+		}
+	})
+}
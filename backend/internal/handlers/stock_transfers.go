@@ -0,0 +1,328 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/distancematrix"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// transferStatuses are the statuses of a stock transfer
+var transferStatuses = []string{models.TransferRequested, models.TransferInTransit, models.TransferReceived, models.TransferCancelled}
+
+// transferStopMinutes is the time planned for unloading a transfer at the
+// destination warehouse
+const transferStopMinutes = 30
+
+type CreateStockTransferRequest struct {
+	FromWarehouseID int64                 `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   int64                 `json:"to_warehouse_id" binding:"required"`
+	ProductID       *int64                `json:"product_id"`
+	Quantity        float64               `json:"quantity" binding:"gt=0"`
+	Reference       string                `json:"reference" binding:"max=255"`
+	Notes           string                `json:"notes"`
+	Route           *TransferRouteRequest `json:"route"` // plans a route carrying the transfer
+}
+
+// TransferRouteRequest plans a transfer route in a plan of the source
+// warehouse: the vehicle drives from the warehouse to the destination and
+// back on the date, by default the plan's first day
+type TransferRouteRequest struct {
+	PlanID    int64  `json:"plan_id" binding:"required"`
+	VehicleID int64  `json:"vehicle_id" binding:"required"`
+	Date      string `json:"date"`
+}
+
+// ListStockTransfers handles GET /api/v1/stock-transfers?warehouse_id=&status=&page=&limit=
+// Stock transfers, latest first. warehouse_id matches transfers from or to
+// the warehouse; status takes a comma-separated list.
+func (h *Handler) ListStockTransfers(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var filter database.StockTransferFilter
+	if raw := c.Query("status"); raw != "" {
+		filter.Statuses = strings.Split(raw, ",")
+		for _, status := range filter.Statuses {
+			if !slices.Contains(transferStatuses, status) {
+				errorResponse(c, http.StatusBadRequest, "status must be one of "+strings.Join(transferStatuses, ", "))
+				return
+			}
+		}
+	}
+	if filter.WarehouseID, err = parseIDQuery(c, "warehouse_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	transfers, total, err := database.ListStockTransfers(h.db, filter, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stock transfers")
+		return
+	}
+	if transfers == nil {
+		transfers = []models.StockTransfer{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, transfers, page)
+}
+
+// GetStockTransfer handles GET /api/v1/stock-transfers/:id
+func (h *Handler) GetStockTransfer(c *gin.Context) {
+	transfer, ok := h.stockTransfer(c)
+	if !ok {
+		return
+	}
+	successResponse(c, transfer)
+}
+
+// CreateStockTransfer handles POST /api/v1/stock-transfers
+// Requests a transfer of stock between warehouses. Stock does not move
+// until the transfer is shipped. With route, a locked route carrying the
+// transfer is added to a plan of the source warehouse.
+func (h *Handler) CreateStockTransfer(c *gin.Context) {
+	var req CreateStockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.FromWarehouseID == req.ToWarehouseID {
+		errorResponse(c, http.StatusBadRequest, "to_warehouse_id must be another warehouse")
+		return
+	}
+	from, ok := h.transferWarehouse(c, "from_warehouse_id", req.FromWarehouseID)
+	if !ok {
+		return
+	}
+	to, ok := h.transferWarehouse(c, "to_warehouse_id", req.ToWarehouseID)
+	if !ok || !h.validStockProduct(c, req.ProductID) {
+		return
+	}
+
+	transfer := &models.StockTransfer{
+		FromWarehouseID: from.ID,
+		ToWarehouseID:   to.ID,
+		ProductID:       req.ProductID,
+		Quantity:        req.Quantity,
+		Status:          models.TransferRequested,
+		Reference:       req.Reference,
+		Notes:           req.Notes,
+	}
+	if userID := c.GetInt64("userID"); userID != 0 {
+		transfer.RequestedBy = &userID
+	}
+	var route *models.Route
+	if req.Route != nil {
+		if route, ok = h.transferRoute(c, req.Route, from, to, req.Quantity); !ok {
+			return
+		}
+	}
+
+	if err := database.CreateStockTransfer(h.db, transfer, route); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create stock transfer")
+		return
+	}
+	transfer, err := database.GetStockTransfer(h.db, transfer.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stock transfer")
+		return
+	}
+	createdResponse(c, transfer)
+}
+
+// ShipStockTransfer handles POST /api/v1/stock-transfers/:id/ship
+// Takes the transfer's stock out of the source warehouse; it is in transit
+// until received. The source must have the stock (409).
+func (h *Handler) ShipStockTransfer(c *gin.Context) {
+	h.advanceStockTransfer(c, models.TransferRequested, database.ShipStockTransfer)
+}
+
+// ReceiveStockTransfer handles POST /api/v1/stock-transfers/:id/receive
+// Books the transfer's stock in at the destination warehouse.
+func (h *Handler) ReceiveStockTransfer(c *gin.Context) {
+	h.advanceStockTransfer(c, models.TransferInTransit, database.ReceiveStockTransfer)
+}
+
+// CancelStockTransfer handles POST /api/v1/stock-transfers/:id/cancel
+// Only transfers not shipped yet can be cancelled. Their route is removed
+// from the plan unless it was started.
+func (h *Handler) CancelStockTransfer(c *gin.Context) {
+	h.advanceStockTransfer(c, models.TransferRequested, database.CancelStockTransfer)
+}
+
+// advanceStockTransfer moves the request's transfer on from status by the
+// current user and responds with the changed transfer. A transfer in
+// another status is a conflict.
+func (h *Handler) advanceStockTransfer(c *gin.Context, status string, advance func(db *gorm.DB, id int64, userID *int64, now time.Time) error) {
+	transfer, ok := h.stockTransfer(c)
+	if !ok {
+		return
+	}
+	if transfer.Status != status {
+		errorResponse(c, http.StatusConflict, "Stock transfer is "+transfer.Status)
+		return
+	}
+	var userID *int64
+	if uid := c.GetInt64("userID"); uid != 0 {
+		userID = &uid
+	}
+	if err := advance(h.db, transfer.ID, userID, h.clock.Now()); err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotFound):
+			errorResponse(c, http.StatusConflict, "Stock transfer changed status, try again")
+		case errors.Is(err, database.ErrInsufficientStock):
+			errorResponse(c, http.StatusConflict, "Not enough stock at the source warehouse")
+		default:
+			errorResponse(c, http.StatusInternalServerError, "Failed to update stock transfer")
+		}
+		return
+	}
+	for _, id := range []int64{transfer.FromWarehouseID, transfer.ToWarehouseID} {
+		h.queueAlertScan("warehouse", id)
+	}
+	transfer, err := database.GetStockTransfer(h.db, transfer.ID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stock transfer")
+		return
+	}
+	successResponse(c, transfer)
+}
+
+// stockTransfer fetches the stock transfer of the request's :id. It writes
+// the error response and returns false when there is none.
+func (h *Handler) stockTransfer(c *gin.Context) (*models.StockTransfer, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stock transfer ID")
+		return nil, false
+	}
+	transfer, err := database.GetStockTransfer(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stock transfer")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stock transfer")
+		return nil, false
+	}
+	return transfer, true
+}
+
+// transferWarehouse fetches a warehouse of a transfer request, writing a
+// 400 naming field and returning false when there is none
+func (h *Handler) transferWarehouse(c *gin.Context, field string, id int64) (*models.Warehouse, bool) {
+	warehouse, err := database.GetWarehouse(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusBadRequest, field+" not found")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return nil, false
+	}
+	return warehouse, true
+}
+
+// transferRoute builds the route carrying a transfer: one stop at the
+// destination's depot place, costed as a round trip from the source. The
+// plan must start from the source warehouse and not be finished, the date
+// must fall in it and the vehicle must hold the quantity and be free that
+// day. It writes the error response and returns false when the route
+// cannot be planned.
+func (h *Handler) transferRoute(c *gin.Context, req *TransferRouteRequest, from, to *models.Warehouse, quantity float64) (*models.Route, bool) {
+	plan, err := database.GetPlan(h.db, req.PlanID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusBadRequest, "route.plan_id not found")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return nil, false
+	}
+	if plan.WarehouseID == nil || *plan.WarehouseID != from.ID {
+		errorResponse(c, http.StatusBadRequest, "route.plan_id must be a plan of the source warehouse")
+		return nil, false
+	}
+	if !slices.Contains([]string{planstate.Draft, planstate.Optimized, planstate.Approved, planstate.Executing}, plan.Status) {
+		errorResponse(c, http.StatusConflict, "Plan is "+plan.Status)
+		return nil, false
+	}
+	date := plan.StartDate
+	if req.Date != "" {
+		if date, err = time.Parse("2006-01-02", req.Date); err != nil {
+			errorResponse(c, http.StatusBadRequest, "route.date must be YYYY-MM-DD")
+			return nil, false
+		}
+	}
+	if date.Before(plan.StartDate) || date.After(plan.EndDate) {
+		errorResponse(c, http.StatusBadRequest, "route.date must be within the plan")
+		return nil, false
+	}
+	day := int(date.Sub(plan.StartDate).Hours()/24) + 1
+
+	vehicle, err := database.GetVehicle(h.db, req.VehicleID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			errorResponse(c, http.StatusBadRequest, "route.vehicle_id not found")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle")
+		return nil, false
+	}
+	if vehicle.Capacity < quantity {
+		errorResponse(c, http.StatusBadRequest, fmt.Sprintf("Vehicle capacity %g is below the quantity %g", vehicle.Capacity, quantity))
+		return nil, false
+	}
+	_, busy, err := database.ListRoutesPage(h.db, database.RouteFilter{PlanID: plan.ID, Day: &day, VehicleID: &vehicle.ID}, 0, 1)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return nil, false
+	}
+	if busy > 0 {
+		errorResponse(c, http.StatusConflict, "Vehicle already has a route that day")
+		return nil, false
+	}
+
+	depot, err := database.WarehouseDepot(h.db, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to find the destination's depot")
+		return nil, false
+	}
+	plan.Warehouse = from
+	route := &models.Route{
+		PlanID:    plan.ID,
+		VehicleID: &vehicle.ID,
+		Locked:    true,
+		Day:       day,
+		Date:      date,
+		TotalLoad: quantity,
+		Plan:      plan,
+		Vehicle:   vehicle,
+	}
+	route.TotalCost = vehicle.FixedCost
+	route.FixedCost = vehicle.FixedCost
+	h.insertStopCosts(route, 0, distancematrix.Point{Latitude: to.Latitude, Longitude: to.Longitude}, transferStopMinutes)
+	route.Plan, route.Vehicle = nil, nil
+	route.Stops = []models.Stop{{
+		PlaceID:         &depot.ID,
+		Type:            "other",
+		Sequence:        1,
+		DurationMinutes: transferStopMinutes,
+		Quantity:        quantity,
+	}}
+	return route, true
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestStockTransfers tests a transfer taking stock out of the source when
+// shipped and booking it in at the destination when received, with a route
+// carrying it, and a cancelled transfer removing its route
+func TestStockTransfers(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.api.GET("/stock-transfers", s.h.ListStockTransfers)
+	s.api.POST("/stock-transfers", s.h.CreateStockTransfer)
+	s.api.POST("/stock-transfers/:id/ship", s.h.ShipStockTransfer)
+	s.api.POST("/stock-transfers/:id/receive", s.h.ReceiveStockTransfer)
+	s.api.POST("/stock-transfers/:id/cancel", s.h.CancelStockTransfer)
+
+	token := s.login(t, "manager")
+	request := func(t *testing.T, method, path string, body interface{}, out interface{}) int {
+		t.Helper()
+		w := s.do(t, method, path, token, body)
+		if out != nil {
+			json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{out})
+		}
+		return w.Code
+	}
+	stock := func(w *models.Warehouse) float64 {
+		got, _ := database.GetWarehouse(s.db, w.ID)
+		return got.CurrentStock
+	}
+
+	north := s.fx.Warehouse(func(w *models.Warehouse) { w.CurrentStock = 100 })
+	south := s.fx.Warehouse(func(w *models.Warehouse) { w.Latitude = 40.0; w.CurrentStock = 0 })
+	plan := s.fx.Plan(north, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3)
+	vehicle := s.fx.Vehicle(north)
+
+	var transfer, big models.StockTransfer
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"request with a route", func(t *testing.T) {
+			code := request(t, "POST", "/api/v1/stock-transfers", CreateStockTransferRequest{
+				FromWarehouseID: north.ID, ToWarehouseID: south.ID, Quantity: 80,
+				Route: &TransferRouteRequest{PlanID: plan.ID, VehicleID: vehicle.ID, Date: "2024-03-05"},
+			}, &transfer)
+			if code != http.StatusCreated || transfer.Status != models.TransferRequested || transfer.RouteID == nil {
+				t.Fatalf("create = %d %+v, want a requested transfer with a route", code, transfer)
+			}
+			route, err := database.GetRouteWithStops(s.db, *transfer.RouteID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if route.Day != 2 || !route.Locked || route.TotalLoad != 80 || route.TotalDistance < 150 || len(route.Stops) != 1 || route.Stops[0].Place == nil || route.Stops[0].Place.Kind != "depot" {
+				t.Errorf("route = %+v, want a locked round trip to the south depot on day 2", route)
+			}
+			if stock(north) != 100 {
+				t.Errorf("north stock after request = %g, want 100", stock(north))
+			}
+			if code := request(t, "POST", "/api/v1/stock-transfers", CreateStockTransferRequest{
+				FromWarehouseID: north.ID, ToWarehouseID: south.ID, Quantity: 10,
+				Route: &TransferRouteRequest{PlanID: plan.ID, VehicleID: vehicle.ID, Date: "2024-03-05"},
+			}, nil); code != http.StatusConflict {
+				t.Errorf("second route for the vehicle status = %d, want 409", code)
+			}
+		}},
+		{"ship and receive", func(t *testing.T) {
+			path := fmt.Sprintf("/api/v1/stock-transfers/%d", transfer.ID)
+			if code := request(t, "POST", path+"/receive", nil, nil); code != http.StatusConflict {
+				t.Errorf("receive before shipping status = %d, want 409", code)
+			}
+			if code := request(t, "POST", path+"/ship", nil, &transfer); code != http.StatusOK || transfer.Status != models.TransferInTransit || transfer.OutMovementID == nil {
+				t.Fatalf("ship = %d %+v, want in transit", code, transfer)
+			}
+			if stock(north) != 20 || stock(south) != 0 {
+				t.Errorf("stock in transit = %g/%g, want 20/0", stock(north), stock(south))
+			}
+			if code := request(t, "POST", path+"/cancel", nil, nil); code != http.StatusConflict {
+				t.Errorf("cancel after shipping status = %d, want 409", code)
+			}
+			if code := request(t, "POST", path+"/receive", nil, &transfer); code != http.StatusOK || transfer.Status != models.TransferReceived || transfer.ReceivedBy == nil {
+				t.Fatalf("receive = %d %+v, want received", code, transfer)
+			}
+			if stock(north) != 20 || stock(south) != 80 {
+				t.Errorf("stock after receipt = %g/%g, want 20/80", stock(north), stock(south))
+			}
+			movements, _, _ := database.ListStockMovements(s.db, database.StockMovementFilter{WarehouseID: south.ID}, 0, 10)
+			if len(movements) != 1 || movements[0].Kind != models.StockTransferIn || *movements[0].CounterpartWarehouseID != north.ID {
+				t.Errorf("south movements = %+v, want the transfer in", movements)
+			}
+		}},
+		{"ship without stock", func(t *testing.T) {
+			// more than the north has left cannot ship
+			request(t, "POST", "/api/v1/stock-transfers", CreateStockTransferRequest{FromWarehouseID: north.ID, ToWarehouseID: south.ID, Quantity: 50}, &big)
+			if code := request(t, "POST", fmt.Sprintf("/api/v1/stock-transfers/%d/ship", big.ID), nil, nil); code != http.StatusConflict {
+				t.Errorf("ship without stock status = %d, want 409", code)
+			}
+		}},
+		{"cancel", func(t *testing.T) {
+			var cancelled models.StockTransfer
+			request(t, "POST", "/api/v1/stock-transfers", CreateStockTransferRequest{
+				FromWarehouseID: north.ID, ToWarehouseID: south.ID, Quantity: 10,
+				Route: &TransferRouteRequest{PlanID: plan.ID, VehicleID: vehicle.ID},
+			}, &cancelled)
+			routeID := cancelled.RouteID
+			if code := request(t, "POST", fmt.Sprintf("/api/v1/stock-transfers/%d/cancel", cancelled.ID), nil, &cancelled); code != http.StatusOK || cancelled.Status != models.TransferCancelled || cancelled.RouteID != nil {
+				t.Errorf("cancel = %d %+v, want cancelled without its route", code, cancelled)
+			}
+			if routeID == nil {
+				t.Fatal("cancelled transfer had no route")
+			}
+			if _, err := database.GetRouteWithStops(s.db, *routeID); err != database.ErrNotFound {
+				t.Errorf("cancelled transfer's route error = %v, want it deleted", err)
+			}
+		}},
+		{"list open transfers", func(t *testing.T) {
+			var list []models.StockTransfer
+			if code := request(t, "GET", fmt.Sprintf("/api/v1/stock-transfers?warehouse_id=%d&status=requested,in_transit", south.ID), nil, &list); code != http.StatusOK || len(list) != 1 || list[0].ID != big.ID {
+				t.Errorf("open transfers = %d %+v, want the unshipped one", code, list)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StocktakeRequest struct {
+	EntityType string                 `json:"entity_type" binding:"required,oneof=customer warehouse"`
+	EntityID   int64                  `json:"entity_id" binding:"required"`
+	Reference  string                 `json:"reference" binding:"max=255"`
+	Notes      string                 `json:"notes"`
+	Lines      []StocktakeLineRequest `json:"lines" binding:"required,min=1,dive"`
+}
+
+// StocktakeLineRequest is the counted quantity of a product, or of the
+// stock not booked to a product when product_id is left out
+type StocktakeLineRequest struct {
+	ProductID       *int64  `json:"product_id"`
+	CountedQuantity float64 `json:"counted_quantity" binding:"gte=0"`
+}
+
+// ListStocktakes handles GET /api/v1/stocktakes?entity_type=&entity_id=&page=&limit=
+// Stocktakes without their lines, latest first.
+func (h *Handler) ListStocktakes(c *gin.Context) {
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.StocktakeFilter{EntityType: c.Query("entity_type")}
+	if filter.EntityType != "" && filter.EntityType != "customer" && filter.EntityType != "warehouse" {
+		errorResponse(c, http.StatusBadRequest, "entity_type must be customer or warehouse")
+		return
+	}
+	if filter.EntityID, err = parseIDQuery(c, "entity_id"); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	stocktakes, total, err := database.ListStocktakes(h.db, filter, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stocktakes")
+		return
+	}
+	if stocktakes == nil {
+		stocktakes = []models.Stocktake{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, stocktakes, page)
+}
+
+// GetStocktake handles GET /api/v1/stocktakes/:id
+// A stocktake with its lines: the book and counted quantity of each product
+// and the adjustment that applied the variance.
+func (h *Handler) GetStocktake(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stocktake ID")
+		return
+	}
+	stocktake, err := database.GetStocktake(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stocktake")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stocktake")
+		return
+	}
+	successResponse(c, stocktake)
+}
+
+// CreateStocktake handles POST /api/v1/stocktakes
+// Records counted quantities of a customer or warehouse. Each line's
+// variance against the books is applied right away, as an inventory
+// adjustment of the customer or a stock adjustment of the warehouse, with
+// the stocktake reason; so is the snapshot of the level after it. A
+// customer is counted as a whole, by one line without product_id; a
+// warehouse by product, with a line without product_id for the stock not
+// booked to a product.
+func (h *Handler) CreateStocktake(c *gin.Context) {
+	var req StocktakeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.EntityType == "customer" && (len(req.Lines) != 1 || req.Lines[0].ProductID != nil) {
+		errorResponse(c, http.StatusBadRequest, "A customer is counted by one line without product_id")
+		return
+	}
+	counted := make(map[int64]bool)
+	for _, l := range req.Lines {
+		var key int64
+		if l.ProductID != nil {
+			key = *l.ProductID
+		}
+		if counted[key] {
+			errorResponse(c, http.StatusBadRequest, "Each product may be counted once")
+			return
+		}
+		counted[key] = true
+		if !h.validStockProduct(c, l.ProductID) {
+			return
+		}
+	}
+
+	stocktake := &models.Stocktake{
+		EntityType: req.EntityType,
+		EntityID:   req.EntityID,
+		Reference:  req.Reference,
+		Notes:      req.Notes,
+		CountedAt:  h.clock.Now(),
+		Lines:      make([]models.StocktakeLine, len(req.Lines)),
+	}
+	if userID := c.GetInt64("userID"); userID != 0 {
+		stocktake.CountedBy = &userID
+	}
+	for i, l := range req.Lines {
+		stocktake.Lines[i] = models.StocktakeLine{ProductID: l.ProductID, CountedQuantity: l.CountedQuantity}
+	}
+	if err := database.RecordStocktake(h.db, stocktake); err != nil {
+		switch {
+		case errors.Is(err, database.ErrNotFound) && req.EntityType == "customer":
+			notFoundResponse(c, "Customer")
+		case errors.Is(err, database.ErrNotFound):
+			notFoundResponse(c, "Warehouse")
+		default:
+			errorResponse(c, http.StatusInternalServerError, "Failed to record stocktake")
+		}
+		return
+	}
+	h.queueAlertScan(req.EntityType, req.EntityID)
+	createdResponse(c, stocktake)
+}
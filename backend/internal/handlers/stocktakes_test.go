@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestStocktakes tests reconciling counted customer inventory and warehouse
+// stock with the books, applying the variances as adjustments
+func TestStocktakes(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)))
+	s.api.GET("/stocktakes", s.h.ListStocktakes)
+	s.api.POST("/stocktakes", s.h.RoleMiddleware("admin", "manager"), s.h.CreateStocktake)
+	s.api.GET("/stocktakes/:id", s.h.GetStocktake)
+
+	manager := s.fx.User("manager")
+	token := e2eLogin(t, s.router, manager).Token
+	request := func(t *testing.T, body interface{}, out interface{}) int {
+		t.Helper()
+		w := s.do(t, "POST", "/api/v1/stocktakes", token, body)
+		if out != nil {
+			json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{out})
+		}
+		return w.Code
+	}
+
+	// 50 on hand
+	customer := s.fx.Customer()
+	diesel := &models.Product{Name: "Diesel", SKU: "DSL", Unit: "l"}
+	database.CreateProduct(s.db, diesel)
+	var counted models.Stocktake
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"customer", func(t *testing.T) {
+			if code := request(t, StocktakeRequest{EntityType: "customer", EntityID: customer.ID, Lines: []StocktakeLineRequest{{CountedQuantity: 44}}}, &counted); code != http.StatusCreated {
+				t.Fatalf("customer stocktake status = %d, want 201", code)
+			}
+			if len(counted.Lines) != 1 || counted.Variance != -6 || counted.Lines[0].BookQuantity != 50 || counted.Lines[0].AdjustmentID == nil || counted.CountedBy == nil || *counted.CountedBy != manager.ID {
+				t.Errorf("customer stocktake = %+v, want 6 short of 50, adjusted by the manager", counted)
+			}
+			if stored, _ := database.GetCustomer(s.db, customer.ID); stored.CurrentInventory != 44 {
+				t.Errorf("current inventory = %v, want the 44 counted", stored.CurrentInventory)
+			}
+			snapshot, err := database.GetLatestInventorySnapshot(s.db, "customer", customer.ID)
+			if err != nil || snapshot.InventoryLevel != 44 || snapshot.SnapshotReason != models.StocktakeReason {
+				t.Errorf("snapshot = %+v, %v, want 44 for the stocktake", snapshot, err)
+			}
+			if code := request(t, StocktakeRequest{EntityType: "customer", EntityID: customer.ID, Lines: []StocktakeLineRequest{{ProductID: &diesel.ID, CountedQuantity: 1}}}, nil); code != http.StatusBadRequest {
+				t.Errorf("customer product count status = %d, want 400", code)
+			}
+		}},
+		{"warehouse", func(t *testing.T) {
+			// 5000 not booked to a product and 100 of diesel
+			warehouse := s.fx.Warehouse()
+			database.RecordStockMovementTx(s.db, &models.StockMovement{WarehouseID: warehouse.ID, ProductID: &diesel.ID, Kind: models.StockReceipt, Quantity: 100, OccurredAt: time.Now()})
+			lines := []StocktakeLineRequest{{ProductID: &diesel.ID, CountedQuantity: 90}, {CountedQuantity: 5000}}
+			if code := request(t, StocktakeRequest{EntityType: "warehouse", EntityID: warehouse.ID, Lines: append(lines, lines[0])}, nil); code != http.StatusBadRequest {
+				t.Errorf("product counted twice status = %d, want 400", code)
+			}
+			if code := request(t, StocktakeRequest{EntityType: "warehouse", EntityID: 999, Lines: lines}, nil); code != http.StatusNotFound {
+				t.Errorf("unknown warehouse status = %d, want 404", code)
+			}
+			if code := request(t, StocktakeRequest{EntityType: "warehouse", EntityID: warehouse.ID, Reference: "ST-1", Lines: lines}, &counted); code != http.StatusCreated {
+				t.Fatalf("warehouse stocktake status = %d, want 201", code)
+			}
+			if counted.Variance != -10 || counted.Lines[0].MovementID == nil || counted.Lines[1].MovementID != nil || counted.Lines[1].BookQuantity != 5000 {
+				t.Errorf("warehouse stocktake = %+v, want diesel 10 short and the rest as booked", counted)
+			}
+			movements, _, _ := database.ListStockMovements(s.db, database.StockMovementFilter{WarehouseID: warehouse.ID, Kinds: []string{models.StockAdjustment}}, 0, 10)
+			if len(movements) != 1 || movements[0].Reason != models.StocktakeReason || movements[0].Quantity != -10 || movements[0].Balance != 5090 || movements[0].Reference != "ST-1" {
+				t.Errorf("adjustments = %+v, want diesel 10 down to 5090", movements)
+			}
+		}},
+		{"get", func(t *testing.T) {
+			var got models.Stocktake
+			w := s.do(t, "GET", fmt.Sprintf("/api/v1/stocktakes/%d", counted.ID), token, nil)
+			json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{&got})
+			if w.Code != http.StatusOK || len(got.Lines) != 2 || got.Lines[0].Product == nil {
+				t.Errorf("stocktake = %d %+v, want both lines with diesel", w.Code, got)
+			}
+		}},
+		{"list", func(t *testing.T) {
+			var list []models.Stocktake
+			w := s.do(t, "GET", "/api/v1/stocktakes?entity_type=warehouse", token, nil)
+			json.Unmarshal(w.Body.Bytes(), &struct{ Data interface{} }{&list})
+			if w.Code != http.StatusOK || len(list) != 1 || list[0].ID != counted.ID {
+				t.Errorf("warehouse stocktakes = %d %+v, want the one counted", w.Code, list)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
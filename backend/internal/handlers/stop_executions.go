@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type UpdateStopExecutionRequest struct {
+	Status         string     `json:"status" binding:"required,oneof=arrived completed skipped failed"`
+	ActualQuantity *float64   `json:"actual_quantity" binding:"omitempty,gte=0"`
+	Time           *time.Time `json:"time"`
+	Notes          string     `json:"notes"`
+}
+
+// ListStopExecutions handles GET /api/v1/executions/:id/stops?view=
+func (h *Handler) ListStopExecutions(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
+		return
+	}
+	compact, err := compactView(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	if !h.canReportExecution(c, execution) {
+		return
+	}
+
+	stops, err := database.GetStopExecutionsByRouteExecution(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop executions")
+		return
+	}
+	if compact {
+		successResponse(c, compactStopExecutions(stops))
+		return
+	}
+	if stops == nil {
+		stops = []models.StopExecution{}
+	}
+	successResponse(c, stops)
+}
+
+// UpdateStopExecution handles PUT /api/v1/executions/:id/stops/:stop_execution_id
+// A stop is marked arrived, then completed, skipped or failed; finished
+// stops cannot change. Completed stops deliver the planned quantity unless
+// actual_quantity says otherwise, skipped and failed ones nothing and need
+// notes. time defaults to now. The route execution's actual load is the sum
+// delivered so far, and it starts with the first stop reported. What a
+// completed stop delivers leaves the warehouse's stock for the customer's
+// inventory (see database.RecordDeliveryTx).
+func (h *Handler) UpdateStopExecution(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid execution ID")
+		return
+	}
+	stopExecutionID, err := strconv.ParseInt(c.Param("stop_execution_id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stop execution ID")
+		return
+	}
+
+	var req UpdateStopExecutionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	execution, err := h.executions.Get(id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route execution")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route execution")
+		return
+	}
+	if !h.canReportExecution(c, execution) {
+		return
+	}
+
+	stop, ok := h.reportStop(c, execution, stopExecutionID, req, nil)
+	if !ok {
+		return
+	}
+	successResponse(c, stop)
+}
+
+// reportStop applies an outcome to a stop of a route execution and rolls
+// it up into the execution. record, when set, runs in the same
+// transaction. It writes the error response and returns false when the
+// outcome cannot be applied.
+func (h *Handler) reportStop(c *gin.Context, execution *models.RouteExecution, stopExecutionID int64, req UpdateStopExecutionRequest, record func(tx *gorm.DB) error) (*models.StopExecution, bool) {
+	if (req.Status == "skipped" || req.Status == "failed") && req.Notes == "" {
+		errorResponse(c, http.StatusBadRequest, "Notes are required when a stop is skipped or failed")
+		return nil, false
+	}
+	if execution.Status == "completed" || execution.Status == "cancelled" {
+		errorResponse(c, http.StatusConflict, "Route execution is "+execution.Status)
+		return nil, false
+	}
+
+	var stop *models.StopExecution
+	for i := range execution.StopExecutions {
+		if execution.StopExecutions[i].ID == stopExecutionID {
+			stop = &execution.StopExecutions[i]
+		}
+	}
+	if stop == nil {
+		notFoundResponse(c, "Stop execution")
+		return nil, false
+	}
+	if stop.Status != "pending" && stop.Status != "arrived" {
+		errorResponse(c, http.StatusConflict, "Stop is already "+stop.Status)
+		return nil, false
+	}
+	if req.Status == "arrived" && stop.Status == "arrived" && !stop.ArrivalDetected {
+		errorResponse(c, http.StatusConflict, "Stop is already arrived")
+		return nil, false
+	}
+
+	at := h.clock.Now()
+	if req.Time != nil {
+		at = *req.Time
+	}
+	applyStopOutcome(stop, req, at)
+
+	err := h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.RecordStopOutcomeTx(tx, stop); err != nil {
+			return err
+		}
+		if err := database.RecordDeliveryTx(tx, stop); err != nil {
+			return err
+		}
+		if err := h.planRedeliveryTx(tx, stop); err != nil {
+			return err
+		}
+		if record != nil {
+			if err := record(tx); err != nil {
+				return err
+			}
+		}
+		return database.RollUpStopExecutionsTx(tx, execution.ID)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update stop execution")
+		return nil, false
+	}
+	h.publishExecutionChange(execution)
+	return stop, true
+}
+
+// applyStopOutcome moves a stop execution to the requested status at the
+// given time. Leaving a stop records the departure and, when the arrival
+// was reported, the minutes spent there. Reported times replace those
+// detected by the geofence, except that a detected departure is kept when
+// the report gives no time.
+func applyStopOutcome(stop *models.StopExecution, req UpdateStopExecutionRequest, at time.Time) {
+	stop.Status = req.Status
+	if req.Notes != "" {
+		stop.Notes = req.Notes
+	}
+	switch req.Status {
+	case "arrived":
+		stop.ActualArrivalTime = &at
+		stop.ArrivalDetected = false
+		return
+	case "completed":
+		stop.ActualQuantity = stop.PlannedQuantity
+		if stop.ActualArrivalTime == nil {
+			stop.ActualArrivalTime = &at
+		}
+	default:
+		stop.ActualQuantity = 0
+	}
+	if req.ActualQuantity != nil {
+		stop.ActualQuantity = *req.ActualQuantity
+	}
+	if stop.ActualArrivalTime != nil {
+		// a departure detected by the geofence is more precise than the
+		// time of the report, unless the driver gave one
+		if !stop.DepartureDetected || req.Time != nil {
+			stop.ActualDepartureTime = &at
+			stop.DepartureDetected = false
+		}
+		stop.ServiceDuration = max(int(stop.ActualDepartureTime.Sub(*stop.ActualArrivalTime).Minutes()), 0)
+	}
+}
+
+// canReportExecution writes a 403 and returns false when a driver may not
+// report on a route execution (see canReportRoute)
+func (h *Handler) canReportExecution(c *gin.Context, execution *models.RouteExecution) bool {
+	if execution.Route == nil {
+		return true
+	}
+	return h.canReportRoute(c, execution.Route)
+}
+
+// canReportRoute writes a 403 and returns false when a driver asks for a
+// route assigned to a driver linked to another user. Other roles, and
+// routes without a linked driver, are allowed.
+func (h *Handler) canReportRoute(c *gin.Context, route *models.Route) bool {
+	driver, err := h.isDriver(c)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return false
+	}
+	if !driver || route.DriverID == nil {
+		return true
+	}
+	assigned, err := database.GetDriver(h.db, *route.DriverID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return true
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch driver")
+		return false
+	}
+	if assigned.UserID != nil && *assigned.UserID != c.GetInt64("userID") {
+		errorResponse(c, http.StatusForbidden, "Route is assigned to another driver")
+		return false
+	}
+	return true
+}
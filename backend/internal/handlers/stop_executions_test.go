@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestStopExecutions tests that drivers report stops of their own routes
+// and that the outcomes roll up into the route execution and its progress
+func TestStopExecutions(t *testing.T) {
+	s := newTestServer(t)
+	clk := testkit.NewClock(time.Date(2024, 3, 4, 8, 0, 0, 0, time.UTC))
+	s.h.SetClock(clk)
+	s.api.GET("/executions/:id/stops", s.h.ListStopExecutions)
+	s.api.PUT("/executions/:id/stops/:stop_execution_id", s.h.UpdateStopExecution)
+
+	driverUser := s.fx.User("driver")
+	otherUser := s.fx.User("driver")
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3, testkit.WithStatus("approved"))
+	route := s.fx.Route(plan, vehicle, 1, s.fx.Customer(), s.fx.Customer(), s.fx.Customer())
+
+	driver := &models.Driver{Name: "Dana", UserID: &driverUser.ID}
+	if err := database.CreateDriver(s.db, driver); err != nil {
+		t.Fatal(err)
+	}
+	s.db.Model(route).Update("driver_id", driver.ID)
+
+	plannedStart, plannedEnd := clk.Now(), clk.Now().Add(2*time.Hour)
+	execution := &models.RouteExecution{RouteID: route.ID, Status: "pending", PlannedLoad: route.TotalLoad, PlannedStartTime: &plannedStart, PlannedEndTime: &plannedEnd}
+	for _, stop := range route.Stops {
+		execution.StopExecutions = append(execution.StopExecutions, models.StopExecution{StopID: stop.ID, Status: "pending", PlannedQuantity: stop.Quantity})
+	}
+	if err := database.CreateRouteExecution(s.db, execution); err != nil {
+		t.Fatal(err)
+	}
+	first, second, third := execution.StopExecutions[0].ID, execution.StopExecutions[1].ID, execution.StopExecutions[2].ID
+
+	token := e2eLogin(t, s.router, driverUser).Token
+	stops := fmt.Sprintf("/api/v1/executions/%d/stops", execution.ID)
+	mark := func(t *testing.T, token string, stopID int64, req UpdateStopExecutionRequest) (int, models.StopExecution) {
+		t.Helper()
+		w := s.do(t, "PUT", fmt.Sprintf("%s/%d", stops, stopID), token, req)
+		var resp struct{ Data models.StopExecution }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"list", func(t *testing.T) {
+			if w := s.do(t, "GET", stops, e2eLogin(t, s.router, otherUser).Token, nil); w.Code != http.StatusForbidden {
+				t.Errorf("other driver list status = %d, want 403", w.Code)
+			}
+			w := s.do(t, "GET", stops, token, nil)
+			var listed struct{ Data []models.StopExecution }
+			json.Unmarshal(w.Body.Bytes(), &listed)
+			if w.Code != http.StatusOK || len(listed.Data) != 3 || listed.Data[0].ID != first || listed.Data[0].Stop == nil || listed.Data[0].Stop.Customer == nil {
+				t.Fatalf("list = %d %+v, want 3 stops in sequence with customers", w.Code, listed.Data)
+			}
+		}},
+		{"arrive", func(t *testing.T) {
+			arrivedAt := clk.Advance(30 * time.Minute)
+			if code, stop := mark(t, token, first, UpdateStopExecutionRequest{Status: "arrived"}); code != http.StatusOK || stop.ActualArrivalTime == nil || !stop.ActualArrivalTime.Equal(arrivedAt) {
+				t.Fatalf("arrive = %d %+v", code, stop)
+			}
+			if code, _ := mark(t, token, first, UpdateStopExecutionRequest{Status: "arrived"}); code != http.StatusConflict {
+				t.Errorf("arrive twice status = %d, want 409", code)
+			}
+			stored, _ := database.GetRouteExecution(s.db, execution.ID)
+			if stored.Status != "in_progress" || stored.ActualStartTime == nil || !stored.ActualStartTime.Equal(arrivedAt) {
+				t.Errorf("execution after first arrival = %s started %v, want in_progress at %v", stored.Status, stored.ActualStartTime, arrivedAt)
+			}
+		}},
+		{"complete", func(t *testing.T) {
+			clk.Advance(20 * time.Minute)
+			code, stop := mark(t, token, first, UpdateStopExecutionRequest{Status: "completed", Notes: "left at the back door"})
+			if code != http.StatusOK || stop.ActualQuantity != 10 || stop.ServiceDuration != 20 || stop.Notes != "left at the back door" {
+				t.Errorf("complete = %d %+v, want the planned 10 units after 20 minutes", code, stop)
+			}
+			if code, _ := mark(t, token, first, UpdateStopExecutionRequest{Status: "failed", Notes: "x"}); code != http.StatusConflict {
+				t.Errorf("change completed stop status = %d, want 409", code)
+			}
+		}},
+		{"partial and failed", func(t *testing.T) {
+			partial := 4.0
+			if code, stop := mark(t, token, second, UpdateStopExecutionRequest{Status: "completed", ActualQuantity: &partial}); code != http.StatusOK || stop.ActualQuantity != 4 {
+				t.Errorf("partial delivery = %d %+v", code, stop)
+			}
+			if code, _ := mark(t, token, third, UpdateStopExecutionRequest{Status: "failed"}); code != http.StatusBadRequest {
+				t.Errorf("failed without notes status = %d, want 400", code)
+			}
+			if code, stop := mark(t, token, third, UpdateStopExecutionRequest{Status: "failed", Notes: "closed"}); code != http.StatusOK || stop.ActualQuantity != 0 {
+				t.Errorf("failed stop = %d %+v", code, stop)
+			}
+		}},
+		{"execution rollup", func(t *testing.T) {
+			stored, _ := database.GetRouteExecution(s.db, execution.ID)
+			if stored.ActualLoad != 14 {
+				t.Errorf("execution actual load = %v, want 14 from completed stops", stored.ActualLoad)
+			}
+			want := models.ExecutionProgress{
+				TotalStops:       3,
+				CompletedStops:   2,
+				StopsPercent:     66.7,
+				DeliveredPercent: 46.7,
+				PlannedMinutes:   120,
+				ElapsedMinutes:   20,
+				TimePercent:      16.7,
+			}
+			if stored.Progress != want {
+				t.Errorf("execution progress = %+v, want %+v", stored.Progress, want)
+			}
+		}},
+		{"stock moved", func(t *testing.T) {
+			// deliveries moved stock from the warehouse to the customers
+			for i, level := range []float64{60, 54, 50} {
+				customer, _ := database.GetCustomer(s.db, *route.Stops[i].CustomerID)
+				if customer.CurrentInventory != level {
+					t.Errorf("customer %d inventory = %v, want %v", i, customer.CurrentInventory, level)
+				}
+			}
+			if stocked, _ := database.GetWarehouse(s.db, warehouse.ID); stocked.CurrentStock != 4986 {
+				t.Errorf("warehouse stock = %v, want 4986", stocked.CurrentStock)
+			}
+			snapshots, _ := database.GetInventorySnapshotsByPlan(s.db, plan.ID)
+			if len(snapshots) != 4 {
+				t.Fatalf("snapshots = %+v, want one per customer and warehouse for each delivery", snapshots)
+			}
+			for _, snapshot := range snapshots {
+				if snapshot.SnapshotReason != "delivery" || snapshot.RouteID == nil || *snapshot.RouteID != route.ID {
+					t.Errorf("snapshot = %+v, want a delivery on the route", snapshot)
+				}
+			}
+			if last := snapshots[3]; last.EntityType != "warehouse" || last.InventoryLevel != 4986 || !last.SnapshotTime.Equal(clk.Now()) {
+				t.Errorf("last snapshot = %+v, want the warehouse at 4986 now", last)
+			}
+		}},
+		{"closed stops", func(t *testing.T) {
+			if code, _ := mark(t, token, 999, UpdateStopExecutionRequest{Status: "arrived"}); code != http.StatusNotFound {
+				t.Errorf("unknown stop status = %d, want 404", code)
+			}
+			s.db.Model(execution).Update("status", "completed")
+			if code, _ := mark(t, token, third, UpdateStopExecutionRequest{Status: "arrived"}); code != http.StatusConflict {
+				t.Errorf("stop of completed execution status = %d, want 409", code)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/distancematrix"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+	"LogiTrackPro/backend/internal/quantity"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// UpdateStopRequest corrects a stop; at least one field is required
+type UpdateStopRequest struct {
+	Quantity    *float64 `json:"quantity"`
+	ArrivalTime *string  `json:"arrival_time"` // HH:MM
+}
+
+// InsertStopRequest adds a stop at an address book place to a route
+type InsertStopRequest struct {
+	PlaceID         int64  `json:"place_id" binding:"required"`
+	Type            string `json:"type" binding:"required,oneof=refuel charging rest_break other"`
+	Sequence        int    `json:"sequence" binding:"gte=0"` // position in the route; 0 appends
+	DurationMinutes int    `json:"duration_minutes" binding:"gte=0"`
+}
+
+// GetRouteStops handles GET /api/v1/routes/:id/stops
+func (h *Handler) GetRouteStops(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid route ID")
+		return
+	}
+
+	route, err := database.GetRouteWithStops(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return
+	}
+	if route.Plan != nil && !h.canSeePlan(c, route.Plan) {
+		return
+	}
+
+	if route.Stops == nil {
+		route.Stops = []models.Stop{}
+	}
+	successResponse(c, route.Stops)
+}
+
+// InsertStop handles POST /api/v1/routes/:id/stops
+// Adds a refuel, charging, rest or other stop at a place from the address
+// book. The route's distance and cost grow by the detour to the place, and
+// its planned end by the detour's driving time and the stop's duration; the
+// plan's totals are recalculated. Routes with executions cannot take new
+// stops. The response warns when the route drives further than the
+// vehicle's range between refuelling or charging (see rangeWarning).
+func (h *Handler) InsertStop(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid route ID")
+		return
+	}
+
+	var req InsertStopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	route, err := database.GetRouteWithStops(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Route")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return
+	}
+	if req.Sequence == 0 {
+		req.Sequence = len(route.Stops) + 1
+	}
+	if req.Sequence > len(route.Stops)+1 {
+		errorResponse(c, http.StatusBadRequest, fmt.Sprintf("sequence must be between 1 and %d", len(route.Stops)+1))
+		return
+	}
+	place, err := database.GetPlace(h.db, req.PlaceID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Place")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch place")
+		return
+	}
+	executions, err := database.GetRouteExecutionsByRoute(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to check route executions")
+		return
+	}
+	if len(executions) > 0 {
+		errorResponse(c, http.StatusConflict, "Route has execution records")
+		return
+	}
+
+	stop := &models.Stop{
+		RouteID:         route.ID,
+		PlaceID:         &place.ID,
+		Type:            req.Type,
+		Sequence:        req.Sequence,
+		DurationMinutes: req.DurationMinutes,
+	}
+	h.insertStopCosts(route, req.Sequence-1, distancematrix.Point{Latitude: place.Latitude, Longitude: place.Longitude}, req.DurationMinutes)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.InsertStopTx(tx, stop); err != nil {
+			return err
+		}
+		if err := database.UpdateRouteCostsTx(tx, route); err != nil {
+			return err
+		}
+		return database.UpdatePlanTotalsTx(tx, route.PlanID)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to insert stop")
+		return
+	}
+
+	route, err = database.GetRouteWithStops(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated route")
+		return
+	}
+	createdWarningResponse(c, route, routeRangeWarnings(route))
+}
+
+// UpdateStop handles PATCH /api/v1/stops/:id
+// Manual quantity edits must respect the rounding rule of the customer's product
+// and warn when they fall below the customer's minimum drop size.
+func (h *Handler) UpdateStop(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stop ID")
+		return
+	}
+
+	var req UpdateStopRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.Quantity == nil && req.ArrivalTime == nil {
+		errorResponse(c, http.StatusBadRequest, "quantity or arrival_time is required")
+		return
+	}
+	if req.ArrivalTime != nil {
+		if _, err := optimizer.ParseClock(*req.ArrivalTime); err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid arrival_time (use HH:MM)")
+			return
+		}
+	}
+
+	stop, err := database.GetStop(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stop")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop")
+		return
+	}
+
+	if req.Quantity != nil {
+		rule := quantity.Rule{}
+		if stop.Customer != nil {
+			rule = productRule(stop.Customer.Product)
+		}
+		if err := quantity.Validate(*req.Quantity, rule); err != nil {
+			errorResponse(c, http.StatusBadRequest, "Invalid quantity: "+err.Error())
+			return
+		}
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if req.Quantity != nil {
+			if err := database.UpdateStopQuantityTx(tx, stop, *req.Quantity); err != nil {
+				return err
+			}
+		}
+		if req.ArrivalTime != nil {
+			return database.UpdateStopArrivalTimeTx(tx, stop, *req.ArrivalTime)
+		}
+		return nil
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update stop")
+		return
+	}
+
+	var warnings []string
+	if req.Quantity != nil && stop.Customer != nil && *req.Quantity > 0 && *req.Quantity < stop.Customer.MinDropSize {
+		warnings = append(warnings, fmt.Sprintf("Quantity %g is below the customer's minimum drop size of %g", *req.Quantity, stop.Customer.MinDropSize))
+	}
+	warningResponse(c, stop, warnings)
+}
+
+// DeleteStop handles DELETE /api/v1/stops/:id
+// The route drives straight from the previous to the next stop instead; its
+// load, distance and cost and the plan's totals are recalculated. Stops
+// with execution records cannot be deleted. Deleting a refuel or charging
+// stop warns when the route is left out of its vehicle's range.
+func (h *Handler) DeleteStop(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid stop ID")
+		return
+	}
+
+	stop, err := database.GetStop(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Stop")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch stop")
+		return
+	}
+	executed, err := database.CountStopExecutions(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to check stop executions")
+		return
+	}
+	if executed > 0 {
+		errorResponse(c, http.StatusConflict, "Stop has execution records")
+		return
+	}
+
+	route, err := database.GetRouteWithStops(h.db, stop.RouteID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch route")
+		return
+	}
+	h.removeStopCosts(route, stop.ID)
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := database.DeleteStopTx(tx, stop); err != nil {
+			return err
+		}
+		if err := database.UpdateRouteCostsTx(tx, route); err != nil {
+			return err
+		}
+		return database.UpdatePlanTotalsTx(tx, route.PlanID)
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete stop")
+		return
+	}
+
+	route, err = database.GetRouteWithStops(h.db, stop.RouteID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch updated route")
+		return
+	}
+	warningResponse(c, route, routeRangeWarnings(route))
+}
+
+// removeStopCosts adjusts a route's distance and cost for skipping a stop:
+// the legs to and from it are replaced by one leg from the previous to the
+// next stop (the warehouse at either end), priced at the vehicle's cost per
+// km. A route left without stops costs nothing. Without coordinates for
+// every point the distance is left as is.
+func (h *Handler) removeStopCosts(route *models.Route, stopID int64) {
+	index := -1
+	for i, s := range route.Stops {
+		if s.ID == stopID {
+			index = i
+		}
+	}
+	if index < 0 {
+		return
+	}
+	if len(route.Stops) == 1 {
+		route.TotalDistance, route.TotalCost = 0, 0
+		route.CostBreakdown = models.CostBreakdown{}
+		return
+	}
+	if route.Plan == nil || route.Plan.Warehouse == nil {
+		return
+	}
+
+	prev, ok1 := routePoint(route, index-1)
+	skipped, ok2 := routePoint(route, index)
+	next, ok3 := routePoint(route, index+1)
+	if !ok1 || !ok2 || !ok3 {
+		return
+	}
+
+	toStop, fromStop, direct := h.detourKm(prev, skipped, next)
+	delta := math.Max(direct-toStop-fromStop, -route.TotalDistance)
+	route.TotalDistance += delta
+	if route.Vehicle != nil {
+		route.TotalCost += delta * route.Vehicle.CostPerKm
+		route.DistanceCost = math.Max(route.DistanceCost+delta*route.Vehicle.CostPerKm, 0)
+	}
+}
+
+// insertStopCosts adjusts a route's distance, cost and planned end for a
+// stop at point before the stop at index: the leg from the previous to that
+// stop (the warehouse at either end) is replaced by the legs to and from
+// the point, priced at the vehicle's cost per km and driven at its average
+// speed, and the stop's minutes are added. Without coordinates for the
+// neighbouring stops only the minutes are added.
+func (h *Handler) insertStopCosts(route *models.Route, index int, point distancematrix.Point, minutes int) {
+	var delta float64
+	if route.Plan != nil && route.Plan.Warehouse != nil {
+		prev, ok1 := routePoint(route, index-1)
+		next, ok2 := routePoint(route, index)
+		if ok1 && ok2 {
+			toStop, fromStop, direct := h.detourKm(prev, point, next)
+			delta = math.Max(toStop+fromStop-direct, 0)
+		}
+	}
+	route.TotalDistance += delta
+	speed := optimizer.DefaultAverageSpeed
+	if route.Vehicle != nil {
+		route.TotalCost += delta * route.Vehicle.CostPerKm
+		route.DistanceCost += delta * route.Vehicle.CostPerKm
+		if route.Vehicle.AverageSpeed > 0 {
+			speed = route.Vehicle.AverageSpeed
+		}
+	}
+	if route.PlannedEnd != nil {
+		end := route.PlannedEnd.Add(time.Duration((delta/speed*60 + float64(minutes)) * float64(time.Minute)))
+		route.PlannedEnd = &end
+	}
+}
+
+// routePoint returns the coordinates of the route's stop at index, its
+// customer's or its place's, or the warehouse's for an index before the
+// first or after the last stop. route needs its plan's warehouse loaded.
+func routePoint(route *models.Route, i int) (distancematrix.Point, bool) {
+	if i < 0 || i >= len(route.Stops) {
+		depot := route.Plan.Warehouse
+		return distancematrix.Point{Latitude: depot.Latitude, Longitude: depot.Longitude}, true
+	}
+	switch s := route.Stops[i]; {
+	case s.Customer != nil:
+		return distancematrix.Point{Latitude: s.Customer.Latitude, Longitude: s.Customer.Longitude}, true
+	case s.Place != nil:
+		return distancematrix.Point{Latitude: s.Place.Latitude, Longitude: s.Place.Longitude}, true
+	}
+	return distancematrix.Point{}, false
+}
+
+// detourKm returns the distances a to b, b to c and a to c, by road when a
+// distance provider is configured and straight-line otherwise
+func (h *Handler) detourKm(a, b, c distancematrix.Point) (ab, bc, ac float64) {
+	if h.distances != nil {
+		matrix, err := h.distances.Compute([]distancematrix.Point{a, b, c})
+		if err == nil {
+			return matrix.Distances[0][1], matrix.Distances[1][2], matrix.Distances[0][2]
+		}
+		log.Printf("WARNING: distance matrix unavailable, using straight-line distances: %v", err)
+	}
+	km := func(p, q distancematrix.Point) float64 {
+		return optimizer.HaversineKm(p.Latitude, p.Longitude, q.Latitude, q.Longitude)
+	}
+	return km(a, b), km(b, c), km(a, c)
+}
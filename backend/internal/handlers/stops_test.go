@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestMinDropSize tests that optimizer deliveries below a customer's minimum
+// drop size are rejected and that manual edits below it only warn
+func TestMinDropSize(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.PATCH("/stops/:id", s.h.UpdateStop)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	customer := s.fx.Customer(func(c *models.Customer) { c.MinDropSize = 10 })
+	s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 7)
+
+	t.Run("optimizer deliveries", func(t *testing.T) {
+		// The fake optimizer delivers 5 units, half the minimum drop
+		if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID), token, nil); w.Code != http.StatusUnprocessableEntity {
+			t.Fatalf("OptimizePlan() status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+		}
+		if req := s.opt.LastRequest(); req.Customers[0].MinDropSize != 10 {
+			t.Errorf("optimizer min drop size = %v, want 10", req.Customers[0].MinDropSize)
+		}
+	})
+
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, customer)
+	stopPath := fmt.Sprintf("/api/v1/stops/%d", route.Stops[0].ID)
+
+	tests := []struct {
+		name        string
+		quantity    float64
+		wantWarning bool
+	}{
+		{name: "at minimum", quantity: 10},
+		{name: "below minimum", quantity: 4, wantWarning: true},
+		{name: "skipped", quantity: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(t, "PATCH", stopPath, token, UpdateStopRequest{Quantity: &tt.quantity})
+			if w.Code != http.StatusOK {
+				t.Fatalf("UpdateStop(%v) status = %d, body = %s", tt.quantity, w.Code, w.Body.String())
+			}
+			var response struct {
+				Warnings []string
+			}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			if got := len(response.Warnings) > 0; got != tt.wantWarning {
+				t.Errorf("UpdateStop(%v) warnings = %v, want warning %v", tt.quantity, response.Warnings, tt.wantWarning)
+			}
+		})
+	}
+}
+
+// TestStopEndpoints tests listing, correcting and deleting stops, and that
+// deleting one recalculates route and plan totals
+func TestStopEndpoints(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/routes/:id/stops", s.h.GetRouteStops)
+	s.api.PATCH("/stops/:id", s.h.UpdateStop)
+	s.api.DELETE("/stops/:id", s.h.DeleteStop)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	first := s.fx.Customer()
+	detour := s.fx.Customer(func(c *models.Customer) { c.Longitude = -73.9 })
+	last := s.fx.Customer()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3)
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, first, detour, last)
+	database.UpdatePlanTotalsTx(s.db, plan.ID)
+
+	stopsPath := fmt.Sprintf("/api/v1/routes/%d/stops", route.ID)
+	var listed struct{ Data []models.Stop }
+	w := s.do(t, "GET", stopsPath, token, nil)
+	json.Unmarshal(w.Body.Bytes(), &listed)
+	if w.Code != http.StatusOK || len(listed.Data) != 3 || listed.Data[1].CustomerID == nil || *listed.Data[1].CustomerID != detour.ID {
+		t.Fatalf("GetRouteStops() = %d %+v, want the three stops in sequence", w.Code, listed.Data)
+	}
+	middle := listed.Data[1]
+	stopPath := fmt.Sprintf("/api/v1/stops/%d", middle.ID)
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"edit arrival", func(t *testing.T) {
+			if w := s.do(t, "PATCH", stopPath, token, UpdateStopRequest{}); w.Code != http.StatusBadRequest {
+				t.Errorf("empty UpdateStop() status = %d, want 400", w.Code)
+			}
+			bad := "9am"
+			if w := s.do(t, "PATCH", stopPath, token, UpdateStopRequest{ArrivalTime: &bad}); w.Code != http.StatusBadRequest {
+				t.Errorf("UpdateStop(%q) status = %d, want 400", bad, w.Code)
+			}
+			arrival := "09:45"
+			if w := s.do(t, "PATCH", stopPath, token, UpdateStopRequest{ArrivalTime: &arrival}); w.Code != http.StatusOK {
+				t.Fatalf("UpdateStop(%q) status = %d: %s", arrival, w.Code, w.Body.String())
+			}
+			if stop, _ := database.GetStop(s.db, middle.ID); stop.ArrivalTime != arrival || stop.Quantity != 10 {
+				t.Errorf("stop after arrival edit = %+v, want arrival %s and quantity unchanged", stop, arrival)
+			}
+		}},
+		{"delete", func(t *testing.T) {
+			w := s.do(t, "DELETE", stopPath, token, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("DeleteStop() status = %d: %s", w.Code, w.Body.String())
+			}
+			km := func(a, b *models.Customer) float64 {
+				return optimizer.HaversineKm(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+			}
+			saved := km(first, detour) + km(detour, last) - km(first, last)
+			updated, _ := database.GetRouteWithStops(s.db, route.ID)
+			if len(updated.Stops) != 2 || updated.Stops[1].Sequence != 2 || updated.TotalLoad != 20 {
+				t.Errorf("route after delete has %d stops, last sequence %d, load %v; want 2, 2, 20", len(updated.Stops), updated.Stops[1].Sequence, updated.TotalLoad)
+			}
+			if math.Abs(updated.TotalDistance-(30-saved)) > 1e-6 || math.Abs(updated.TotalCost-(300-saved)) > 1e-6 {
+				t.Errorf("route distance %v and cost %v, want %v and %v", updated.TotalDistance, updated.TotalCost, 30-saved, 300-saved)
+			}
+			if stored, _ := database.GetPlan(s.db, plan.ID); math.Abs(stored.TotalCost-updated.TotalCost) > 1e-6 {
+				t.Errorf("plan cost %v, want the route's %v", stored.TotalCost, updated.TotalCost)
+			}
+			if w := s.do(t, "DELETE", stopPath, token, nil); w.Code != http.StatusNotFound {
+				t.Errorf("second DeleteStop() status = %d, want 404", w.Code)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
+
+// TestInsertPlaceStop tests adding a rest stop from the address book to a
+// route and that the detour and the stop's duration are accounted for
+func TestInsertPlaceStop(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/places", s.h.CreatePlace)
+	s.api.GET("/places", s.h.ListPlaces)
+	s.api.POST("/routes/:id/stops", s.h.InsertStop)
+	s.api.DELETE("/stops/:id", s.h.DeleteStop)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	first, last := s.fx.Customer(), s.fx.Customer()
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 1)
+	route := s.fx.Route(plan, s.fx.Vehicle(warehouse), 1, first, last)
+	end := time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)
+	s.db.Model(route).Update("planned_end", end)
+
+	stopsPath := fmt.Sprintf("/api/v1/routes/%d/stops", route.ID)
+	lat, lon := 40.715, -73.95
+	var created struct{ Data models.Place }
+	var inserted struct{ Data models.Route }
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"create place", func(t *testing.T) {
+			if w := s.do(t, "POST", "/api/v1/places", token, gin.H{"name": "Truck stop", "kind": "truck_stop"}); w.Code != http.StatusBadRequest {
+				t.Errorf("CreatePlace() without coordinates status = %d, want 400", w.Code)
+			}
+			w := s.do(t, "POST", "/api/v1/places", token, PlaceRequest{Name: "Truck stop", Kind: "truck_stop", Latitude: &lat, Longitude: &lon})
+			json.Unmarshal(w.Body.Bytes(), &created)
+			if w.Code != http.StatusCreated || created.Data.ID == 0 {
+				t.Fatalf("CreatePlace() = %d: %s", w.Code, w.Body.String())
+			}
+			var listed struct{ Data []models.Place }
+			json.Unmarshal(s.do(t, "GET", "/api/v1/places?kind=fuel_station", token, nil).Body.Bytes(), &listed)
+			if len(listed.Data) != 0 {
+				t.Errorf("ListPlaces(fuel_station) = %+v, want none", listed.Data)
+			}
+		}},
+		{"insert", func(t *testing.T) {
+			if w := s.do(t, "POST", stopsPath, token, InsertStopRequest{PlaceID: created.Data.ID, Type: "rest_break", Sequence: 4}); w.Code != http.StatusBadRequest {
+				t.Errorf("InsertStop() past the end status = %d, want 400", w.Code)
+			}
+			w := s.do(t, "POST", stopsPath, token, InsertStopRequest{PlaceID: created.Data.ID, Type: "rest_break", Sequence: 2, DurationMinutes: 30})
+			json.Unmarshal(w.Body.Bytes(), &inserted)
+			if w.Code != http.StatusCreated || len(inserted.Data.Stops) != 3 {
+				t.Fatalf("InsertStop() = %d: %s", w.Code, w.Body.String())
+			}
+			if stop := inserted.Data.Stops[1]; stop.Type != "rest_break" || stop.Place == nil || stop.Sequence != 2 || inserted.Data.Stops[2].CustomerID == nil || *inserted.Data.Stops[2].CustomerID != last.ID {
+				t.Errorf("stops = %+v, want the rest break between the customers", inserted.Data.Stops)
+			}
+		}},
+		{"detour", func(t *testing.T) {
+			km := func(a, b *models.Customer) float64 {
+				return optimizer.HaversineKm(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+			}
+			place := &models.Customer{Latitude: lat, Longitude: lon}
+			detour := km(first, place) + km(place, last) - km(first, last)
+			if math.Abs(inserted.Data.TotalDistance-(20+detour)) > 1e-6 || math.Abs(inserted.Data.TotalCost-(200+detour)) > 1e-6 {
+				t.Errorf("route distance %v and cost %v, want %v and %v", inserted.Data.TotalDistance, inserted.Data.TotalCost, 20+detour, 200+detour)
+			}
+			wantEnd := end.Add(30*time.Minute + time.Duration(detour/optimizer.DefaultAverageSpeed*float64(time.Hour)))
+			if inserted.Data.PlannedEnd == nil || inserted.Data.PlannedEnd.Sub(wantEnd).Abs() > time.Second {
+				t.Errorf("planned end = %v, want %v", inserted.Data.PlannedEnd, wantEnd)
+			}
+			if stored, _ := database.GetPlan(s.db, plan.ID); math.Abs(stored.TotalDistance-inserted.Data.TotalDistance) > 1e-6 {
+				t.Errorf("plan distance %v, want the route's %v", stored.TotalDistance, inserted.Data.TotalDistance)
+			}
+		}},
+		{"delete", func(t *testing.T) {
+			if w := s.do(t, "DELETE", fmt.Sprintf("/api/v1/stops/%d", inserted.Data.Stops[1].ID), token, nil); w.Code != http.StatusOK {
+				t.Fatalf("DeleteStop() status = %d: %s", w.Code, w.Body.String())
+			}
+			if updated, _ := database.GetRouteWithStops(s.db, route.ID); math.Abs(updated.TotalDistance-20) > 1e-6 {
+				t.Errorf("route distance after removing the rest break = %v, want 20", updated.TotalDistance)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TagRequest creates or renames a tag
+type TagRequest struct {
+	Name        string `json:"name" binding:"required,max=50"`
+	Description string `json:"description" binding:"max=255"`
+}
+
+// SetTagsRequest replaces the tags of a customer or vehicle, by name
+type SetTagsRequest struct {
+	Tags []string `json:"tags" binding:"max=50,dive,max=50"`
+}
+
+// normalizeTag is a tag name as stored: trimmed and lowercase
+func normalizeTag(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// normalizeTags normalizes tag names, dropping empty ones and duplicates
+func normalizeTags(names []string) []string {
+	var result []string
+	for _, name := range names {
+		if name = normalizeTag(name); name != "" && !slices.Contains(result, name) {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// parseTagQuery reads the tag query parameter, a comma-separated list of
+// tag names
+func parseTagQuery(c *gin.Context) []string {
+	raw := c.Query("tag")
+	if raw == "" {
+		return nil
+	}
+	return normalizeTags(strings.Split(raw, ","))
+}
+
+// ListTags handles GET /api/v1/tags
+func (h *Handler) ListTags(c *gin.Context) {
+	tags, err := database.ListTags(h.db)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch tags")
+		return
+	}
+	if tags == nil {
+		tags = []models.Tag{}
+	}
+	successResponse(c, tags)
+}
+
+// CreateTag handles POST /api/v1/tags
+// Names are stored lowercase and are unique (409).
+func (h *Handler) CreateTag(c *gin.Context) {
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	tag := &models.Tag{Description: req.Description}
+	if !h.validTagName(c, req.Name, 0, &tag.Name) {
+		return
+	}
+	if err := database.CreateTag(h.db, tag); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create tag")
+		return
+	}
+	createdResponse(c, tag)
+}
+
+// UpdateTag handles PUT /api/v1/tags/:id
+// Renaming a tag renames it on every customer and vehicle that has it.
+func (h *Handler) UpdateTag(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+	var req TagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	tag := &models.Tag{ID: id, Description: req.Description}
+	if !h.validTagName(c, req.Name, id, &tag.Name) {
+		return
+	}
+	if err := database.UpdateTag(h.db, tag); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Tag")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update tag")
+		return
+	}
+	tag, err = database.GetTag(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch tag")
+		return
+	}
+	successResponse(c, tag)
+}
+
+// DeleteTag handles DELETE /api/v1/tags/:id
+// The tag is removed from its customers and vehicles. Plans selecting by
+// it keep its name and select nothing by it any more.
+func (h *Handler) DeleteTag(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid tag ID")
+		return
+	}
+	if err := database.DeleteTag(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Tag")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete tag")
+		return
+	}
+	successResponse(c, gin.H{"message": "Tag deleted successfully"})
+}
+
+// SetCustomerTags handles PUT /api/v1/customers/:id/tags
+func (h *Handler) SetCustomerTags(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid customer ID")
+		return
+	}
+	var req SetTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	tags, ok := h.lookupTags(c, req.Tags)
+	if !ok {
+		return
+	}
+	customer, err := database.GetCustomer(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Customer")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch customer")
+		return
+	}
+	if err := database.SetCustomerTags(h.db, customer, tags); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update customer tags")
+		return
+	}
+	successResponse(c, customer)
+}
+
+// SetVehicleTags handles PUT /api/v1/vehicles/:id/tags
+func (h *Handler) SetVehicleTags(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+	var req SetTagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	tags, ok := h.lookupTags(c, req.Tags)
+	if !ok {
+		return
+	}
+	vehicle, err := database.GetVehicle(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Vehicle")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle")
+		return
+	}
+	if err := database.SetVehicleTags(h.db, vehicle, tags); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to update vehicle tags")
+		return
+	}
+	successResponse(c, vehicle)
+}
+
+// validTagName normalizes a tag name into name, responding 400 when it is
+// empty or has a comma and 409 when another tag than id has it
+func (h *Handler) validTagName(c *gin.Context, raw string, id int64, name *string) bool {
+	*name = normalizeTag(raw)
+	if *name == "" || strings.Contains(*name, ",") {
+		errorResponse(c, http.StatusBadRequest, "Tag names must not be blank or contain commas")
+		return false
+	}
+	existing, err := database.GetTagsByName(h.db, []string{*name})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch tags")
+		return false
+	}
+	if t, ok := existing[*name]; ok && t.ID != id {
+		errorResponse(c, http.StatusConflict, "Tag "+*name+" already exists")
+		return false
+	}
+	return true
+}
+
+// lookupTags fetches the tags of the names, responding 400 when one does
+// not exist
+func (h *Handler) lookupTags(c *gin.Context, names []string) ([]models.Tag, bool) {
+	names = normalizeTags(names)
+	byName, err := database.GetTagsByName(h.db, names)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch tags")
+		return nil, false
+	}
+	tags := make([]models.Tag, 0, len(names))
+	for _, name := range names {
+		tag, ok := byName[name]
+		if !ok {
+			errorResponse(c, http.StatusBadRequest, "Unknown tag: "+name)
+			return nil, false
+		}
+		tags = append(tags, tag)
+	}
+	return tags, true
+}
+
+// tagNames returns the names of tags
+func tagNames(tags []models.Tag) []string {
+	names := make([]string, len(tags))
+	for i, t := range tags {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// hasAnyTag reports whether tags has any of the names; no names match all
+func hasAnyTag(tags []models.Tag, names []string) bool {
+	if len(names) == 0 {
+		return true
+	}
+	for _, t := range tags {
+		if slices.Contains(names, t.Name) {
+			return true
+		}
+	}
+	return false
+}
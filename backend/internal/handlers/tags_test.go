@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestTags tests tagging customers and vehicles, filtering lists by tag and
+// optimizing only the tagged customers with the tagged vehicles
+func TestTags(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/tags", s.h.ListTags)
+	s.api.POST("/tags", s.h.CreateTag)
+	s.api.PUT("/tags/:id", s.h.UpdateTag)
+	s.api.DELETE("/tags/:id", s.h.DeleteTag)
+	s.api.GET("/customers", s.h.ListCustomers)
+	s.api.PUT("/customers/:id/tags", s.h.SetCustomerTags)
+	s.api.GET("/vehicles", s.h.ListVehicles)
+	s.api.PUT("/vehicles/:id/tags", s.h.SetVehicleTags)
+	s.api.POST("/plans", s.h.CreatePlan)
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	frozen, dry := s.fx.Customer(), s.fx.Customer()
+	reefer, van := s.fx.Vehicle(warehouse), s.fx.Vehicle(warehouse)
+
+	createTag := func(t *testing.T, name string) models.Tag {
+		t.Helper()
+		w := s.do(t, "POST", "/api/v1/tags", token, TagRequest{Name: name})
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create tag %q status = %d: %s", name, w.Code, w.Body.String())
+		}
+		var resp struct{ Data models.Tag }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return resp.Data
+	}
+	setTags := func(t *testing.T, path string, tags ...string) *struct{ Data struct{ Tags []models.Tag } } {
+		t.Helper()
+		w := s.do(t, "PUT", path, token, SetTagsRequest{Tags: tags})
+		if w.Code != http.StatusOK {
+			t.Fatalf("PUT %s status = %d: %s", path, w.Code, w.Body.String())
+		}
+		var resp struct{ Data struct{ Tags []models.Tag } }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return &resp
+	}
+	var frozenTag models.Tag
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"create tags", func(t *testing.T) {
+			frozenTag = createTag(t, " Frozen-Goods ")
+			createTag(t, "reefer")
+			if frozenTag.Name != "frozen-goods" {
+				t.Errorf("tag name = %q, want it trimmed and lowercase", frozenTag.Name)
+			}
+			if w := s.do(t, "POST", "/api/v1/tags", token, TagRequest{Name: "REEFER"}); w.Code != http.StatusConflict {
+				t.Errorf("duplicate tag status = %d, want 409", w.Code)
+			}
+			if w := s.do(t, "POST", "/api/v1/tags", token, TagRequest{Name: "a,b"}); w.Code != http.StatusBadRequest {
+				t.Errorf("tag with a comma status = %d, want 400", w.Code)
+			}
+		}},
+		{"tag customers and vehicles", func(t *testing.T) {
+			if resp := setTags(t, fmt.Sprintf("/api/v1/customers/%d/tags", frozen.ID), "frozen-goods"); len(resp.Data.Tags) != 1 {
+				t.Errorf("customer tags = %+v, want frozen-goods", resp.Data.Tags)
+			}
+			setTags(t, fmt.Sprintf("/api/v1/vehicles/%d/tags", reefer.ID), "Reefer")
+			if w := s.do(t, "PUT", fmt.Sprintf("/api/v1/customers/%d/tags", dry.ID), token, SetTagsRequest{Tags: []string{"ambient"}}); w.Code != http.StatusBadRequest {
+				t.Errorf("unknown tag status = %d, want 400", w.Code)
+			}
+		}},
+		{"filter lists", func(t *testing.T) {
+			var customers struct{ Data []models.Customer }
+			w := s.do(t, "GET", "/api/v1/customers?tag=frozen-goods,other", token, nil)
+			json.Unmarshal(w.Body.Bytes(), &customers)
+			if len(customers.Data) != 1 || customers.Data[0].ID != frozen.ID || len(customers.Data[0].Tags) != 1 {
+				t.Errorf("customers tagged frozen-goods = %+v, want only the tagged one with its tag", customers.Data)
+			}
+			var vehicles struct{ Data []models.Vehicle }
+			w = s.do(t, "GET", "/api/v1/vehicles?tag=reefer", token, nil)
+			json.Unmarshal(w.Body.Bytes(), &vehicles)
+			if len(vehicles.Data) != 1 || vehicles.Data[0].ID != reefer.ID {
+				t.Errorf("vehicles tagged reefer = %+v, want only the reefer", vehicles.Data)
+			}
+		}},
+		{"optimize tagged", func(t *testing.T) {
+			// Plans optimize only the tagged customers with the tagged vehicles
+			w := s.do(t, "POST", "/api/v1/plans", token, PlanRequest{
+				Name: "Frozen run", StartDate: "2024-03-04", EndDate: "2024-03-06", WarehouseID: warehouse.ID,
+				CustomerTags: []string{"Frozen-Goods"}, VehicleTags: []string{"reefer"},
+			})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create plan status = %d: %s", w.Code, w.Body.String())
+			}
+			var plan struct{ Data models.Plan }
+			json.Unmarshal(w.Body.Bytes(), &plan)
+			if len(plan.Data.CustomerTags) != 1 || plan.Data.CustomerTags[0] != "frozen-goods" {
+				t.Errorf("plan customer tags = %v, want frozen-goods", plan.Data.CustomerTags)
+			}
+			if w := s.do(t, "POST", fmt.Sprintf("/api/v1/plans/%d/optimize", plan.Data.ID), token, nil); w.Code != http.StatusOK {
+				t.Fatalf("optimize status = %d: %s", w.Code, w.Body.String())
+			}
+			req := s.opt.LastRequest()
+			if len(req.Customers) != 1 || req.Customers[0].ID != frozen.ID || len(req.Vehicles) != 1 || req.Vehicles[0].ID != reefer.ID {
+				t.Errorf("optimizer request customers = %+v vehicles = %+v, want only the tagged ones", req.Customers, req.Vehicles)
+			}
+			if w := s.do(t, "POST", "/api/v1/plans", token, PlanRequest{
+				Name: "Unknown", StartDate: "2024-03-04", EndDate: "2024-03-06", WarehouseID: warehouse.ID, VehicleTags: []string{"tanker"},
+			}); w.Code != http.StatusBadRequest {
+				t.Errorf("plan with an unknown tag status = %d, want 400", w.Code)
+			}
+		}},
+		{"delete tag", func(t *testing.T) {
+			if w := s.do(t, "DELETE", fmt.Sprintf("/api/v1/tags/%d", frozenTag.ID), token, nil); w.Code != http.StatusOK {
+				t.Fatalf("delete tag status = %d: %s", w.Code, w.Body.String())
+			}
+			if got, _ := database.GetCustomer(s.db, frozen.ID); len(got.Tags) != 0 {
+				t.Errorf("customer tags after delete = %+v, want none", got.Tags)
+			}
+			if got, _ := database.GetVehicle(s.db, van.ID); len(got.Tags) != 0 {
+				t.Errorf("untagged vehicle tags = %+v, want none", got.Tags)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// forcedPriorityBoost is added to a forced customer's priority so the solver
+// serves it before regular customers
+const forcedPriorityBoost = 10
+
+type ForceUnroutedRequest struct {
+	CustomerIDs []int64 `json:"customer_ids"`
+}
+
+// ListUnroutedCustomers handles GET /api/v1/plans/:id/unrouted
+func (h *Handler) ListUnroutedCustomers(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	if _, err := h.plans.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	entries, err := database.ListUnroutedCustomers(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch unrouted customers")
+		return
+	}
+	if entries == nil {
+		entries = []models.UnroutedCustomer{}
+	}
+	successResponse(c, entries)
+}
+
+// ForceUnroutedCustomers handles POST /api/v1/plans/:id/unrouted/force
+// Flags the plan's unrouted customers (or the given subset) so the next
+// optimization must deliver to them on its first day with elevated priority.
+func (h *Handler) ForceUnroutedCustomers(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid plan ID")
+		return
+	}
+
+	var req ForceUnroutedRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if _, err := h.plans.Get(id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Plan")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch plan")
+		return
+	}
+
+	forced, err := database.ForceUnroutedCustomers(h.db, id, req.CustomerIDs, c.GetInt64("userID"), h.clock.Now())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to force unrouted customers")
+		return
+	}
+	if forced == 0 {
+		errorResponse(c, http.StatusConflict, "No unforced unrouted customers to force")
+		return
+	}
+
+	entries, err := database.ListUnroutedCustomers(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch unrouted customers")
+		return
+	}
+	successResponse(c, gin.H{"forced": forced, "unrouted": entries})
+}
+
+// applyForcedCustomers raises the priority of customers forced from an
+// unrouted report and makes them due on the first day of the request. It
+// returns the forced entries used so they can be consumed with the result.
+func (h *Handler) applyForcedCustomers(optReq *optimizer.OptimizeRequest) ([]int64, error) {
+	pending, err := database.GetPendingForcedCustomers(h.db)
+	if err != nil {
+		return nil, err
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	forced := make(map[int64][]int64)
+	for _, entry := range pending {
+		forced[entry.CustomerID] = append(forced[entry.CustomerID], entry.ID)
+	}
+
+	var used []int64
+	for i := range optReq.Customers {
+		cust := &optReq.Customers[i]
+		ids, ok := forced[cust.ID]
+		if !ok {
+			continue
+		}
+		cust.Priority += forcedPriorityBoost
+		if cust.CurrentInventory > cust.MinInventory {
+			cust.CurrentInventory = cust.MinInventory
+		}
+		used = append(used, ids...)
+	}
+	return used, nil
+}
+
+// saveUnroutedTx stores the unrouted customer report for an optimization
+// result and consumes the forced entries it was built with
+func (h *Handler) saveUnroutedTx(tx *gorm.DB, planID int64, optReq *optimizer.OptimizeRequest, optResp *optimizer.OptimizeResponse, forcedIDs []int64) error {
+	if err := database.ConsumeForcedCustomersTx(tx, forcedIDs, planID, h.clock.Now()); err != nil {
+		return err
+	}
+
+	unrouted := optimizer.FindUnrouted(optReq, optResp)
+	entries := make([]models.UnroutedCustomer, len(unrouted))
+	for i, u := range unrouted {
+		entries[i] = models.UnroutedCustomer{
+			PlanID:     planID,
+			CustomerID: u.CustomerID,
+			Reason:     u.Reason,
+			Detail:     u.Detail,
+			Shortfall:  u.Shortfall,
+		}
+	}
+	return database.ReplaceUnroutedCustomersTx(tx, planID, entries)
+}
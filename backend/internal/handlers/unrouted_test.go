@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// TestForceUnroutedCustomers tests the unrouted report and that forcing a
+// customer boosts it in the next optimization
+func TestForceUnroutedCustomers(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	database.CreateCustomer(s.db, &models.Customer{Name: "Customer A", Latitude: 40.7, Longitude: -74.0, DemandRate: 10})
+	missed := &models.Customer{Name: "Customer B", Latitude: 40.7, Longitude: -74.0, DemandRate: 10, MaxInventory: 50, CurrentInventory: 20}
+	database.CreateCustomer(s.db, missed)
+	database.CreateVehicle(s.db, &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID})
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+	planPath := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10)
+
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	s.api.GET("/plans/:id/unrouted", s.h.ListUnroutedCustomers)
+	s.api.POST("/plans/:id/unrouted/force", s.h.ForceUnroutedCustomers)
+	token := s.login(t, "user")
+
+	unrouted := func(t *testing.T) []models.UnroutedCustomer {
+		t.Helper()
+		w := s.do(t, "GET", planPath+"/unrouted", token, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("ListUnroutedCustomers() status = %d, body = %s", w.Code, w.Body.String())
+		}
+		var response struct {
+			Data []models.UnroutedCustomer
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response.Data
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"report", func(t *testing.T) {
+			// The fake optimizer gives the single vehicle's stop to the first customer
+			if w := s.do(t, "POST", planPath+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			report := unrouted(t)
+			if len(report) != 1 || report[0].CustomerID != missed.ID || report[0].Reason != optimizer.UnroutedBlocked {
+				t.Fatalf("unrouted report = %+v, want the missed customer as blocked", report)
+			}
+		}},
+		{"force", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/unrouted/force", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("ForceUnroutedCustomers() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "POST", planPath+"/unrouted/force", token, nil); w.Code != http.StatusConflict {
+				t.Errorf("second ForceUnroutedCustomers() status = %d, want %d", w.Code, http.StatusConflict)
+			}
+		}},
+		{"boosted priority", func(t *testing.T) {
+			if w := s.do(t, "POST", planPath+"/optimize", token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			for _, cust := range s.opt.LastRequest().Customers {
+				if cust.ID != missed.ID {
+					continue
+				}
+				if cust.Priority < 1+forcedPriorityBoost || cust.CurrentInventory != cust.MinInventory {
+					t.Errorf("forced customer sent as %+v, want boosted priority and due on day 1", cust)
+				}
+			}
+
+			pending, _ := database.GetPendingForcedCustomers(s.db)
+			if len(pending) != 0 {
+				t.Errorf("%d forced entries still pending after optimization, want 0", len(pending))
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/securitylog"
+	"LogiTrackPro/backend/internal/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrganizationRequest struct {
+	Name                     string `json:"name" binding:"required"`
+	MaxOptimizationsPerMonth *int   `json:"max_optimizations_per_month" binding:"omitempty,gte=0"`
+	MaxCustomers             *int   `json:"max_customers" binding:"omitempty,gte=0"`
+	MaxAPICallsPerDay        *int   `json:"max_api_calls_per_day" binding:"omitempty,gte=0"`
+}
+
+type UpdateUserOrganizationRequest struct {
+	OrganizationID *int64 `json:"organization_id"`
+}
+
+// UsageMiddleware meters API calls of users that belong to an organization
+// and rejects them with 429 once the daily quota is used up. It must run
+// after AuthMiddleware.
+func (h *Handler) UsageMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, err := database.GetUserByID(h.db, c.GetInt64("userID"))
+		if err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				errorResponse(c, http.StatusUnauthorized, "User not found")
+			} else {
+				errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+			}
+			c.Abort()
+			return
+		}
+		c.Set("userRole", user.Role)
+		if user.OrganizationID == nil {
+			c.Next()
+			return
+		}
+		c.Set("organizationID", *user.OrganizationID)
+		// usage is not metered while the database is read-only
+		if h.InMaintenance() {
+			c.Next()
+			return
+		}
+
+		limits, err := h.organizationLimits(*user.OrganizationID)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch organization")
+			c.Abort()
+			return
+		}
+		now := h.clock.Now()
+		period, resetsAt := usage.Period(usage.APICalls, now)
+		used, err := database.IncrementUsage(h.db, *user.OrganizationID, usage.APICalls, period, 1)
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to record usage")
+			c.Abort()
+			return
+		}
+
+		if limit := limits.APICallsPerDay; limit > 0 {
+			remaining := int64(limit) - used
+			if remaining < 0 {
+				remaining = 0
+			}
+			c.Header("X-Quota-Limit", strconv.Itoa(limit))
+			c.Header("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+			if used > int64(limit) {
+				c.Header("Retry-After", strconv.Itoa(int(resetsAt.Sub(now).Seconds())+1))
+				errorCodeResponse(c, usage.ExceededStatus(usage.APICalls), codeQuotaExceeded, fmt.Sprintf("Daily API call quota of %d exceeded", limit))
+				c.Abort()
+				return
+			}
+		}
+		c.Next()
+	}
+}
+
+// reserveUsage checks the current organization's quota for metric before
+// an action and counts the action. It responds with 402 and returns false
+// when the quota is used up. Users outside an organization are not metered.
+func (h *Handler) reserveUsage(c *gin.Context, metric string) bool {
+	orgID, ok := organizationID(c)
+	if !ok {
+		return true
+	}
+
+	limits, err := h.organizationLimits(orgID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch organization")
+		return false
+	}
+	current, err := h.metricUsage(orgID, metric, limits, h.clock.Now())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch usage")
+		return false
+	}
+	if current.Exceeded() {
+		errorCodeResponse(c, usage.ExceededStatus(metric), codeQuotaExceeded, fmt.Sprintf("Quota of %d %s exceeded, upgrade your plan or contact support", current.Limit, metric))
+		return false
+	}
+
+	if current.Period != "" {
+		if _, err := database.IncrementUsage(h.db, orgID, metric, current.Period, 1); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to record usage")
+			return false
+		}
+	}
+	return true
+}
+
+// organizationID returns the organization set by UsageMiddleware
+func organizationID(c *gin.Context) (int64, bool) {
+	id, ok := c.Get("organizationID")
+	if !ok {
+		return 0, false
+	}
+	return id.(int64), true
+}
+
+func (h *Handler) organizationLimits(orgID int64) (usage.Limits, error) {
+	org, err := database.GetOrganization(h.db, orgID)
+	if err != nil {
+		return usage.Limits{}, err
+	}
+	return usage.Resolve(org, h.defaultLimits()), nil
+}
+
+func (h *Handler) defaultLimits() usage.Limits {
+	return usage.Limits{
+		OptimizationsPerMonth: h.config.QuotaOptimizationsPerMonth,
+		Customers:             h.config.QuotaCustomers,
+		APICallsPerDay:        h.config.QuotaAPICallsPerDay,
+	}
+}
+
+// metricUsage reads an organization's current usage of metric
+func (h *Handler) metricUsage(orgID int64, metric string, limits usage.Limits, now time.Time) (usage.Metric, error) {
+	period, resetsAt := usage.Period(metric, now)
+	m := usage.Metric{Metric: metric, Limit: limits.Limit(metric), Period: period, ResetsAt: resetsAt}
+
+	var err error
+	if metric == usage.Customers {
+		m.Used, err = database.CountOrganizationCustomers(h.db, orgID)
+	} else {
+		m.Used, err = database.GetUsage(h.db, orgID, metric, period)
+	}
+	return m, err
+}
+
+// usageReport builds an organization's usage against its quotas
+func (h *Handler) usageReport(org *models.Organization) (*usage.Report, error) {
+	limits := usage.Resolve(org, h.defaultLimits())
+	report := &usage.Report{Organization: org}
+	now := h.clock.Now()
+	for _, metric := range []string{usage.Optimizations, usage.Customers, usage.APICalls} {
+		m, err := h.metricUsage(org.ID, metric, limits, now)
+		if err != nil {
+			return nil, err
+		}
+		report.Metrics = append(report.Metrics, m)
+	}
+	return report, nil
+}
+
+// GetUsage handles GET /api/v1/usage
+// Shows the current user's organization usage against its quotas.
+func (h *Handler) GetUsage(c *gin.Context) {
+	orgID, ok := organizationID(c)
+	if !ok {
+		errorCodeResponse(c, http.StatusNotFound, "ORGANIZATION_NOT_FOUND", "User does not belong to an organization")
+		return
+	}
+	h.respondUsage(c, orgID)
+}
+
+// GetOrganizationUsage handles GET /api/v1/admin/organizations/:id/usage
+func (h *Handler) GetOrganizationUsage(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+	h.respondUsage(c, id)
+}
+
+func (h *Handler) respondUsage(c *gin.Context, orgID int64) {
+	org, err := database.GetOrganization(h.db, orgID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Organization")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch organization")
+		return
+	}
+
+	report, err := h.usageReport(org)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch usage")
+		return
+	}
+	successResponse(c, report)
+}
+
+// ListOrganizations handles GET /api/v1/admin/organizations
+func (h *Handler) ListOrganizations(c *gin.Context) {
+	orgs, err := database.ListOrganizations(h.db)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch organizations")
+		return
+	}
+	if orgs == nil {
+		orgs = []models.Organization{}
+	}
+	successResponse(c, orgs)
+}
+
+// CreateOrganization handles POST /api/v1/admin/organizations
+func (h *Handler) CreateOrganization(c *gin.Context) {
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	org := &models.Organization{
+		Name:                     req.Name,
+		MaxOptimizationsPerMonth: req.MaxOptimizationsPerMonth,
+		MaxCustomers:             req.MaxCustomers,
+		MaxAPICallsPerDay:        req.MaxAPICallsPerDay,
+	}
+	if err := database.CreateOrganization(h.db, org); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create organization")
+		return
+	}
+	createdResponse(c, org)
+}
+
+// UpdateOrganization handles PUT /api/v1/admin/organizations/:id
+// Omitted quotas fall back to the server defaults.
+func (h *Handler) UpdateOrganization(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid organization ID")
+		return
+	}
+
+	var req OrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	org := &models.Organization{
+		ID:                       id,
+		Name:                     req.Name,
+		MaxOptimizationsPerMonth: req.MaxOptimizationsPerMonth,
+		MaxCustomers:             req.MaxCustomers,
+		MaxAPICallsPerDay:        req.MaxAPICallsPerDay,
+	}
+	if err := database.UpdateOrganization(h.db, org); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Organization")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update organization")
+		return
+	}
+
+	updated, err := database.GetOrganization(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch organization")
+		return
+	}
+	successResponse(c, updated)
+}
+
+// UpdateUserOrganization handles PUT /api/v1/admin/users/:id/organization
+// A null organization_id removes the user from metering.
+func (h *Handler) UpdateUserOrganization(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req UpdateUserOrganizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if req.OrganizationID != nil {
+		if _, err := database.GetOrganization(h.db, *req.OrganizationID); err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				errorResponse(c, http.StatusBadRequest, "Organization not found")
+				return
+			}
+			errorResponse(c, http.StatusInternalServerError, "Failed to fetch organization")
+			return
+		}
+	}
+
+	if err := database.UpdateUserOrganization(h.db, id, req.OrganizationID); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "User")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update user organization")
+		return
+	}
+
+	user, err := database.GetUserByID(h.db, id)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	detail := "removed from organization"
+	if user.OrganizationID != nil {
+		detail = fmt.Sprintf("moved to organization %d", *user.OrganizationID)
+	}
+	h.recordSecurityEvent(c, securitylog.OrganizationChanged, user, detail)
+	successResponse(c, user)
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/usage"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestUsageQuotas tests that organization usage is metered and that
+// exceeded quotas are rejected with 402 or 429
+func TestUsageQuotas(t *testing.T) {
+	s := newTestServer(t)
+	metered := s.api.Group("", s.h.UsageMiddleware())
+	metered.GET("/usage", s.h.GetUsage)
+	metered.POST("/customers", s.h.CreateCustomer)
+	metered.POST("/plans/:id/optimize", s.h.OptimizePlan)
+
+	one, five := 1, 5
+	org := &models.Organization{Name: "Acme", MaxOptimizationsPerMonth: &one, MaxCustomers: &one, MaxAPICallsPerDay: &five}
+	database.CreateOrganization(s.db, org)
+	token := e2eLogin(t, s.router, s.fx.User("user", func(u *models.User) { u.OrganizationID = &org.ID })).Token
+	warehouse := s.fx.Warehouse()
+	s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), 7)
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"customer quota", func(t *testing.T) {
+			customer := gin.H{"name": "Customer", "latitude": 40.7, "longitude": -74.0, "demand_rate": 10}
+			if w := s.do(t, "POST", "/api/v1/customers", token, customer); w.Code != http.StatusCreated {
+				t.Fatalf("CreateCustomer() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "POST", "/api/v1/customers", token, customer); w.Code != http.StatusPaymentRequired {
+				t.Errorf("CreateCustomer() over quota status = %d, want %d", w.Code, http.StatusPaymentRequired)
+			}
+		}},
+		{"optimization quota", func(t *testing.T) {
+			optimizePath := fmt.Sprintf("/api/v1/plans/%d/optimize", plan.ID)
+			if w := s.do(t, "POST", optimizePath, token, nil); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "POST", optimizePath, token, nil); w.Code != http.StatusPaymentRequired {
+				t.Errorf("OptimizePlan() over quota status = %d, want %d", w.Code, http.StatusPaymentRequired)
+			}
+		}},
+		{"usage report", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/usage", token, nil)
+			var resp struct {
+				Data usage.Report
+			}
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if w.Code != http.StatusOK || len(resp.Data.Metrics) != 3 {
+				t.Fatalf("GetUsage() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			used := map[string]int64{}
+			for _, m := range resp.Data.Metrics {
+				used[m.Metric] = m.Used
+			}
+			if used[usage.Optimizations] != 1 || used[usage.Customers] != 1 || used[usage.APICalls] != 5 {
+				t.Errorf("usage = %v, want 1 optimization, 1 customer and 5 API calls", used)
+			}
+		}},
+		{"API call quota", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/usage", token, nil)
+			if w.Code != http.StatusTooManyRequests || w.Header().Get("Retry-After") == "" {
+				t.Errorf("sixth API call status = %d, Retry-After %q, want %d with Retry-After", w.Code, w.Header().Get("Retry-After"), http.StatusTooManyRequests)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestVehicleHistory tests that a vehicle's history totals its routes,
+// incidents and maintenance within the period
+func TestVehicleHistory(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)))
+	s.api.GET("/vehicles/:id/history", s.h.GetVehicleHistory)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+	other := s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 5)
+	route := s.fx.Route(plan, vehicle, 1, s.fx.Customer(), s.fx.Customer())
+	s.fx.Route(plan, vehicle, 3, s.fx.Customer())
+	s.fx.Route(plan, other, 1, s.fx.Customer())
+
+	for _, incident := range []struct {
+		vehicleID  int64
+		category   string
+		occurredAt time.Time
+	}{
+		{vehicle.ID, "breakdown", time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)},
+		{vehicle.ID, "accident", time.Date(2024, 3, 10, 23, 30, 0, 0, time.UTC)},
+		{vehicle.ID, "breakdown", time.Date(2024, 2, 28, 9, 0, 0, 0, time.UTC)}, // before the period
+		{other.ID, "breakdown", time.Date(2024, 3, 4, 15, 0, 0, 0, time.UTC)},
+	} {
+		vehicleID := incident.vehicleID
+		err := database.CreateIncidentTx(s.db, &models.Incident{
+			RouteExecutionID: 1,
+			RouteID:          route.ID,
+			PlanID:           plan.ID,
+			VehicleID:        &vehicleID,
+			Category:         incident.category,
+			OccurredAt:       incident.occurredAt,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, record := range []models.MaintenanceRecord{
+		{VehicleID: vehicle.ID, Kind: "service", Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Cost: 180},
+		{VehicleID: vehicle.ID, Kind: "tyres", Date: time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC), Cost: 640},
+		{VehicleID: vehicle.ID, Kind: "repair", Date: time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), Cost: 1000}, // before the period
+		{VehicleID: other.ID, Kind: "inspection", Date: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), Cost: 90},
+	} {
+		if err := database.CreateMaintenanceRecord(s.db, &record); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("history", func(t *testing.T) {
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/vehicles/%d/history?from=2024-03-01&to=2024-03-10", vehicle.ID), token, nil)
+		var resp struct{ Data models.VehicleHistory }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("history status = %d: %s", w.Code, w.Body.String())
+		}
+		history := resp.Data
+		if history.RouteCount != 2 || history.DaysActive != 2 || history.PlannedDistanceKm != 30 {
+			t.Errorf("routes = %d on %d days over %v km, want 2 on 2 days over 30 km", history.RouteCount, history.DaysActive, history.PlannedDistanceKm)
+		}
+		if history.IncidentCount != 2 || len(history.Incidents) != 2 || history.IncidentsByCategory["breakdown"] != 1 || history.IncidentsByCategory["accident"] != 1 {
+			t.Errorf("incidents = %d %v, want a breakdown and an accident (the 10th counts in full)", history.IncidentCount, history.IncidentsByCategory)
+		}
+		if history.MaintenanceCount != 2 || history.MaintenanceCost != 820 || len(history.Maintenance) != 2 {
+			t.Errorf("maintenance = %d costing %v, want 2 costing 820", history.MaintenanceCount, history.MaintenanceCost)
+		}
+	})
+
+	t.Run("retired vehicle", func(t *testing.T) {
+		if err := database.DeleteVehicle(s.db, other.ID); err != nil {
+			t.Fatal(err)
+		}
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/vehicles/%d/history?from=2024-03-01&to=2024-03-10", other.ID), token, nil)
+		var resp struct{ Data models.VehicleHistory }
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || w.Code != http.StatusOK {
+			t.Fatalf("history of retired vehicle status = %d: %s", w.Code, w.Body.String())
+		}
+		if resp.Data.RouteCount != 1 || resp.Data.IncidentCount != 1 || resp.Data.MaintenanceCount != 1 {
+			t.Errorf("retired vehicle history = %d routes, %d incidents, %d maintenance, want 1 of each", resp.Data.RouteCount, resp.Data.IncidentCount, resp.Data.MaintenanceCount)
+		}
+	})
+
+	t.Run("unknown vehicle", func(t *testing.T) {
+		if w := s.do(t, "GET", fmt.Sprintf("/api/v1/vehicles/%d/history", vehicle.ID+1000), token, nil); w.Code != http.StatusNotFound {
+			t.Errorf("history of unknown vehicle status = %d, want 404", w.Code)
+		}
+	})
+
+	t.Run("empty history", func(t *testing.T) {
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/vehicles/%d/history?from=2023-01-01&to=2023-01-31", vehicle.ID), token, nil)
+		var resp struct{ Data map[string]interface{} }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if resp.Data["incidents"] == nil || resp.Data["maintenance"] == nil || resp.Data["incident_count"] != float64(0) {
+			t.Errorf("empty history = %s, want empty lists rather than null", w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"math"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// efficiencyDefaultDays is the range of vehicle logs analysed when no from
+// date is given
+const efficiencyDefaultDays = 90
+
+// reportsVehicleLog tells whether a completion reports odometer readings
+// or fuel for the vehicle log
+func (req CompleteRouteExecutionRequest) reportsVehicleLog() bool {
+	return req.OdometerStart != nil || req.OdometerEnd != nil || req.FuelPurchased > 0 || req.FuelCost > 0
+}
+
+// GetVehicleEfficiency handles GET /api/v1/analytics/vehicle-efficiency?from=&to=&vehicle_id=
+// Fuel efficiency and running cost per vehicle from the odometer readings
+// and fuel reported on completed route executions, by default over the
+// last 90 days, next to the static cost per km the optimizer plans with.
+// Fuel is summed over all logs in the range and divided by the odometer
+// distance of the logs with both readings, so fills need not match trips.
+func (h *Handler) GetVehicleEfficiency(c *gin.Context) {
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	vehicleID, err := parseIDQuery(c, "vehicle_id")
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+	end := h.clock.Now().Truncate(24 * time.Hour)
+	if to != nil {
+		end = *to
+	}
+	start := end.AddDate(0, 0, -efficiencyDefaultDays)
+	if from != nil {
+		start = *from
+	}
+	if end.Before(start) {
+		errorResponse(c, http.StatusBadRequest, "to must not be before from")
+		return
+	}
+
+	logs, err := database.GetVehicleLogs(h.db, start, end, vehicleID)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle logs")
+		return
+	}
+	var ids []int64
+	for i, log := range logs {
+		if i == 0 || logs[i-1].VehicleID != log.VehicleID {
+			ids = append(ids, log.VehicleID)
+		}
+	}
+	vehicles, err := database.GetVehiclesByIDs(h.db.Unscoped(), ids)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
+		return
+	}
+
+	successResponse(c, &models.VehicleEfficiencyReport{
+		From:     start.Format("2006-01-02"),
+		To:       end.Format("2006-01-02"),
+		Vehicles: buildVehicleEfficiency(logs, vehicles),
+	})
+}
+
+// buildVehicleEfficiency totals vehicle logs, which come ordered by
+// vehicle, per vehicle
+func buildVehicleEfficiency(logs []models.VehicleLog, vehicles map[int64]models.Vehicle) []models.VehicleEfficiency {
+	result := []models.VehicleEfficiency{}
+	for _, log := range logs {
+		if len(result) == 0 || result[len(result)-1].VehicleID != log.VehicleID {
+			vehicle := vehicles[log.VehicleID]
+			result = append(result, models.VehicleEfficiency{
+				VehicleID:           log.VehicleID,
+				Name:                vehicle.Name,
+				Electric:            vehicle.Electric,
+				ConfiguredCostPerKm: vehicle.CostPerKm,
+			})
+		}
+		e := &result[len(result)-1]
+		e.Logs++
+		e.FuelPurchased += log.FuelPurchased
+		e.FuelCost += log.FuelCost
+		if distance, ok := log.Distance(); ok {
+			e.DistanceKm += distance
+		}
+	}
+
+	for i := range result {
+		e := &result[i]
+		if e.DistanceKm <= 0 {
+			continue
+		}
+		consumption := math.Round(e.FuelPurchased/e.DistanceKm*100*100) / 100
+		costPerKm := math.Round(e.FuelCost/e.DistanceKm*1000) / 1000
+		variance := math.Round((costPerKm-e.ConfiguredCostPerKm)*1000) / 1000
+		e.ConsumptionPer100Km, e.CostPerKm, e.ConfiguredVariance = &consumption, &costPerKm, &variance
+	}
+	return result
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestVehicleEfficiency tests odometer and fuel reported on completion are
+// logged and turned into consumption and cost per km by vehicle
+func TestVehicleEfficiency(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)))
+	s.api.POST("/executions/:id/complete", s.h.CompleteRouteExecution)
+	s.api.GET("/analytics/vehicle-efficiency", s.h.GetVehicleEfficiency)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse, func(v *models.Vehicle) { v.CostPerKm = 0.5 })
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 2, testkit.WithStatus("executing"))
+	execute := func(day int) *models.RouteExecution {
+		execution := &models.RouteExecution{RouteID: s.fx.Route(plan, vehicle, day, s.fx.Customer()).ID, Status: "in_progress"}
+		if err := database.CreateRouteExecution(s.db, execution); err != nil {
+			t.Fatal(err)
+		}
+		return execution
+	}
+	km := func(v float64) *float64 { return &v }
+	complete := func(t *testing.T, execution *models.RouteExecution, req CompleteRouteExecutionRequest) *httptest.ResponseRecorder {
+		t.Helper()
+		return s.do(t, "POST", fmt.Sprintf("/api/v1/executions/%d/complete", execution.ID), token, req)
+	}
+
+	first, second := execute(1), execute(2)
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"odometer going back", func(t *testing.T) {
+			if w := complete(t, first, CompleteRouteExecutionRequest{OdometerStart: km(1000), OdometerEnd: km(900)}); w.Code != http.StatusBadRequest {
+				t.Errorf("odometer going back status = %d, want 400", w.Code)
+			}
+		}},
+		{"complete with fuel", func(t *testing.T) {
+			end := time.Date(2024, 3, 4, 17, 0, 0, 0, time.UTC)
+			w := complete(t, first, CompleteRouteExecutionRequest{ActualEndTime: &end, OdometerStart: km(1000), OdometerEnd: km(1200), FuelPurchased: 60, FuelCost: 90})
+			if w.Code != http.StatusOK {
+				t.Fatalf("complete status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			var resp struct{ Data models.RouteExecution }
+			json.Unmarshal(w.Body.Bytes(), &resp)
+			if log := resp.Data.VehicleLog; log == nil || log.VehicleID != vehicle.ID || log.FuelPurchased != 60 || log.Date.Format("2006-01-02") != "2024-03-04" {
+				t.Errorf("vehicle log = %+v, want 60 litres on 4 March", resp.Data.VehicleLog)
+			}
+		}},
+		{"complete without fuel", func(t *testing.T) {
+			if w := complete(t, second, CompleteRouteExecutionRequest{OdometerStart: km(1200), OdometerEnd: km(1400)}); w.Code != http.StatusOK {
+				t.Fatalf("complete status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+		}},
+		{"efficiency", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/analytics/vehicle-efficiency", token, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("efficiency status = %d, want 200: %s", w.Code, w.Body.String())
+			}
+			var report struct {
+				Data models.VehicleEfficiencyReport
+			}
+			json.Unmarshal(w.Body.Bytes(), &report)
+			if len(report.Data.Vehicles) != 1 {
+				t.Fatalf("vehicles = %+v, want one", report.Data.Vehicles)
+			}
+			e := report.Data.Vehicles[0]
+			// 60 litres for 90 over 400 km
+			if e.Logs != 2 || e.DistanceKm != 400 || *e.ConsumptionPer100Km != 15 || *e.CostPerKm != 0.225 || *e.ConfiguredVariance != -0.275 {
+				t.Errorf("efficiency = %+v, want 15 l/100km at 0.225 per km", e)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceRecordRequest is the body of POST /api/v1/vehicles/:id/maintenance
+type MaintenanceRecordRequest struct {
+	Kind        string   `json:"kind" binding:"required,oneof=service repair inspection tyres other"`
+	Date        string   `json:"date" binding:"required"` // YYYY-MM-DD
+	Description string   `json:"description" binding:"max=5000"`
+	Cost        float64  `json:"cost" binding:"gte=0"`
+	Odometer    *float64 `json:"odometer" binding:"omitempty,gte=0"`
+}
+
+// RecordVehicleMaintenance handles POST /api/v1/vehicles/:id/maintenance
+func (h *Handler) RecordVehicleMaintenance(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	var req MaintenanceRecordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+
+	if _, err := database.GetVehicle(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Vehicle")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle")
+		return
+	}
+
+	record := &models.MaintenanceRecord{
+		VehicleID:   id,
+		Kind:        req.Kind,
+		Date:        date,
+		Description: req.Description,
+		Cost:        req.Cost,
+		Odometer:    req.Odometer,
+	}
+	if userID := c.GetInt64("userID"); userID != 0 {
+		record.RecordedBy = &userID
+	}
+	if err := database.CreateMaintenanceRecord(h.db, record); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to record maintenance")
+		return
+	}
+	createdResponse(c, record)
+}
+
+// ListVehicleMaintenance handles GET /api/v1/vehicles/:id/maintenance?from=&to=
+// The vehicle's maintenance oldest first; from and to are optional dates.
+func (h *Handler) ListVehicleMaintenance(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+	from, to, err := parseDateRangeQuery(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if _, err := database.GetVehicle(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Vehicle")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle")
+		return
+	}
+
+	var since, until time.Time
+	if from != nil {
+		since = *from
+	}
+	if to != nil {
+		until = *to
+	}
+	records, err := database.ListMaintenanceRecords(h.db, id, since, until)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch maintenance")
+		return
+	}
+	successResponse(c, records)
+}
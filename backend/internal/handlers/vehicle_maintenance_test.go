@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestVehicleMaintenance tests recording and listing a vehicle's
+// maintenance
+func TestVehicleMaintenance(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)))
+	s.api.GET("/vehicles/:id/maintenance", s.h.ListVehicleMaintenance)
+	s.api.POST("/vehicles/:id/maintenance", s.h.RoleMiddleware("admin", "manager"), s.h.RecordVehicleMaintenance)
+
+	token := s.login(t, "manager")
+	driverToken := s.login(t, "driver")
+	warehouse := s.fx.Warehouse()
+	vehicle := s.fx.Vehicle(warehouse)
+	other := s.fx.Vehicle(warehouse)
+
+	record := func(t *testing.T, token string, vehicleID int64, req MaintenanceRecordRequest) int {
+		t.Helper()
+		return s.do(t, "POST", fmt.Sprintf("/api/v1/vehicles/%d/maintenance", vehicleID), token, req).Code
+	}
+	t.Run("record maintenance", func(t *testing.T) {
+		for _, req := range []MaintenanceRecordRequest{
+			{Kind: "service", Date: "2024-03-05", Description: "Oil change", Cost: 180},
+			{Kind: "tyres", Date: "2024-03-10", Cost: 640},
+			{Kind: "repair", Date: "2024-01-15", Cost: 1000}, // before the period
+		} {
+			if code := record(t, token, vehicle.ID, req); code != http.StatusCreated {
+				t.Fatalf("record %s status = %d, want 201", req.Kind, code)
+			}
+		}
+		if code := record(t, token, other.ID, MaintenanceRecordRequest{Kind: "inspection", Date: "2024-03-05", Cost: 90}); code != http.StatusCreated {
+			t.Fatalf("record for other vehicle status = %d, want 201", code)
+		}
+		if code := record(t, driverToken, vehicle.ID, MaintenanceRecordRequest{Kind: "service", Date: "2024-03-05"}); code != http.StatusForbidden {
+			t.Errorf("driver record status = %d, want 403", code)
+		}
+		if code := record(t, token, vehicle.ID, MaintenanceRecordRequest{Kind: "wash", Date: "2024-03-05"}); code != http.StatusBadRequest {
+			t.Errorf("unknown kind status = %d, want 400", code)
+		}
+		if code := record(t, token, vehicle.ID, MaintenanceRecordRequest{Kind: "service", Date: "5 March"}); code != http.StatusBadRequest {
+			t.Errorf("malformed date status = %d, want 400", code)
+		}
+		if code := record(t, token, 9999, MaintenanceRecordRequest{Kind: "service", Date: "2024-03-05"}); code != http.StatusNotFound {
+			t.Errorf("unknown vehicle status = %d, want 404", code)
+		}
+	})
+
+	t.Run("list maintenance", func(t *testing.T) {
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/vehicles/%d/maintenance?from=2024-03-01", vehicle.ID), token, nil)
+		var resp struct{ Data []models.MaintenanceRecord }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		if w.Code != http.StatusOK || len(resp.Data) != 2 || resp.Data[0].Kind != "service" || resp.Data[1].Kind != "tyres" {
+			t.Errorf("list status = %d: %s, want the service and tyres of March oldest first", w.Code, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// rangeWarnings checks routes leaving from warehouse against their
+// vehicles' range (see rangeWarning)
+func rangeWarnings(warehouse *models.Warehouse, routes []models.Route) []string {
+	var warnings []string
+	for _, r := range routes {
+		if w := rangeWarning(warehouse, r); w != "" {
+			warnings = append(warnings, w)
+		}
+	}
+	return warnings
+}
+
+// routeRangeWarnings checks a route loaded with its plan's warehouse
+func routeRangeWarnings(route *models.Route) []string {
+	if route.Plan == nil {
+		return nil
+	}
+	return rangeWarnings(route.Plan.Warehouse, []models.Route{*route})
+}
+
+// rangeWarning warns when a route's vehicle drives further than its range
+// between refuelling, or charging for an electric vehicle. The vehicle
+// leaves warehouse full and drives through the stops in sequence and back;
+// legs are measured straight-line like the planned refuel and charging
+// stops. Stops without coordinates are passed over. Vehicles without a
+// range are not checked.
+func rangeWarning(warehouse *models.Warehouse, route models.Route) string {
+	vehicle := route.Vehicle
+	if vehicle == nil || vehicle.RangeKm <= 0 || warehouse == nil {
+		return ""
+	}
+	stops := append([]models.Stop(nil), route.Stops...)
+	sort.SliceStable(stops, func(i, j int) bool { return stops[i].Sequence < stops[j].Sequence })
+	resupply, verb := optimizer.StopRefuel, "refuelling"
+	if vehicle.Electric {
+		resupply, verb = optimizer.StopCharging, "charging"
+	}
+
+	lat, lon := warehouse.Latitude, warehouse.Longitude
+	var driven, longest float64
+	drive := func(toLat, toLon float64) {
+		driven += optimizer.HaversineKm(lat, lon, toLat, toLon)
+		lat, lon = toLat, toLon
+		longest = max(longest, driven)
+	}
+	for _, s := range stops {
+		switch {
+		case s.Customer != nil:
+			drive(s.Customer.Latitude, s.Customer.Longitude)
+		case s.Place != nil:
+			drive(s.Place.Latitude, s.Place.Longitude)
+		}
+		if s.Type == resupply {
+			driven = 0
+		}
+	}
+	drive(warehouse.Latitude, warehouse.Longitude)
+
+	if longest <= vehicle.RangeKm {
+		return ""
+	}
+	return fmt.Sprintf("Route %d on day %d drives %.0f km without %s, beyond the %.0f km range of vehicle %s",
+		route.ID, route.Day, longest, verb, vehicle.RangeKm, vehicle.Name)
+}
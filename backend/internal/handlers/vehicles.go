@@ -2,28 +2,152 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/optimizer"
 
 	"github.com/gin-gonic/gin"
 )
 
 type VehicleRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Capacity    float64 `json:"capacity" binding:"required"`
-	CostPerKm   float64 `json:"cost_per_km"`
-	FixedCost   float64 `json:"fixed_cost"`
-	MaxDistance float64 `json:"max_distance"`
-	Available   bool    `json:"available"`
-	WarehouseID int64   `json:"warehouse_id"`
+	Name             string   `json:"name" binding:"required"`
+	Capacity         float64  `json:"capacity" binding:"required"`
+	CostPerKm        float64  `json:"cost_per_km"`
+	FixedCost        float64  `json:"fixed_cost"`
+	MaxDistance      float64  `json:"max_distance"`
+	RangeKm          float64  `json:"range_km" binding:"gte=0"`
+	Electric         bool     `json:"electric"`
+	ChargeMinutes    int      `json:"charge_minutes" binding:"gte=0"`
+	ConsumptionPerKm float64  `json:"consumption_per_km" binding:"gte=0"`
+	CO2PerKm         float64  `json:"co2_per_km" binding:"gte=0"`
+	Available        bool     `json:"available"`
+	WarehouseID      int64    `json:"warehouse_id"`
+	MaxWorkingHours  float64  `json:"max_working_hours" binding:"gte=0"`
+	AverageSpeed     float64  `json:"average_speed" binding:"gte=0"`
+	ShiftStart       string   `json:"shift_start"`
+	ShiftEnd         string   `json:"shift_end"`
+	AllowedTags      []string `json:"allowed_tags"`
+	MaxPayloadWeight float64  `json:"max_payload_weight" binding:"gte=0"`
+	MaxFrontAxleLoad float64  `json:"max_front_axle_load" binding:"gte=0"`
+	MaxRearAxleLoad  float64  `json:"max_rear_axle_load" binding:"gte=0"`
+	ExternalRef      string   `json:"external_ref" binding:"max=100"`
+	// Version is the version of the vehicle edited; see expectedVersion
+	Version int64 `json:"version"`
 }
 
-// ListVehicles handles GET /api/v1/vehicles
+// validateShift checks the shift times are HH:MM and the shift is long
+// enough for the vehicle's working hours
+func (r VehicleRequest) validateShift() error {
+	var start, end int
+	var err error
+	if r.ShiftStart != "" {
+		if start, err = optimizer.ParseClock(r.ShiftStart); err != nil {
+			return fmt.Errorf("shift_start: %w", err)
+		}
+	}
+	if r.ShiftEnd != "" {
+		if end, err = optimizer.ParseClock(r.ShiftEnd); err != nil {
+			return fmt.Errorf("shift_end: %w", err)
+		}
+		if r.ShiftStart != "" && end <= start {
+			return errors.New("shift_end must be after shift_start")
+		}
+	}
+	return nil
+}
+
+// VehiclePatch changes the fields of a vehicle it has. warehouse_id 0
+// clears it.
+type VehiclePatch struct {
+	Name             *string   `json:"name" binding:"omitempty,min=1"`
+	Capacity         *float64  `json:"capacity" binding:"omitempty,gt=0"`
+	CostPerKm        *float64  `json:"cost_per_km"`
+	FixedCost        *float64  `json:"fixed_cost"`
+	MaxDistance      *float64  `json:"max_distance"`
+	RangeKm          *float64  `json:"range_km" binding:"omitempty,gte=0"`
+	Electric         *bool     `json:"electric"`
+	ChargeMinutes    *int      `json:"charge_minutes" binding:"omitempty,gte=0"`
+	ConsumptionPerKm *float64  `json:"consumption_per_km" binding:"omitempty,gte=0"`
+	CO2PerKm         *float64  `json:"co2_per_km" binding:"omitempty,gte=0"`
+	Available        *bool     `json:"available"`
+	WarehouseID      *int64    `json:"warehouse_id"`
+	MaxWorkingHours  *float64  `json:"max_working_hours" binding:"omitempty,gte=0"`
+	AverageSpeed     *float64  `json:"average_speed" binding:"omitempty,gte=0"`
+	ShiftStart       *string   `json:"shift_start"`
+	ShiftEnd         *string   `json:"shift_end"`
+	AllowedTags      *[]string `json:"allowed_tags"`
+	MaxPayloadWeight *float64  `json:"max_payload_weight" binding:"omitempty,gte=0"`
+	MaxFrontAxleLoad *float64  `json:"max_front_axle_load" binding:"omitempty,gte=0"`
+	MaxRearAxleLoad  *float64  `json:"max_rear_axle_load" binding:"omitempty,gte=0"`
+	ExternalRef      *string   `json:"external_ref" binding:"omitempty,max=100"`
+	Version          int64     `json:"version"`
+}
+
+// apply applies the patch to a vehicle and returns the columns it changes
+func (p VehiclePatch) apply(v *models.Vehicle) []string {
+	var columns []string
+	patchField(&columns, "name", &v.Name, p.Name)
+	patchField(&columns, "capacity", &v.Capacity, p.Capacity)
+	patchField(&columns, "cost_per_km", &v.CostPerKm, p.CostPerKm)
+	patchField(&columns, "fixed_cost", &v.FixedCost, p.FixedCost)
+	patchField(&columns, "max_distance", &v.MaxDistance, p.MaxDistance)
+	patchField(&columns, "range_km", &v.RangeKm, p.RangeKm)
+	patchField(&columns, "electric", &v.Electric, p.Electric)
+	patchField(&columns, "charge_minutes", &v.ChargeMinutes, p.ChargeMinutes)
+	patchField(&columns, "consumption_per_km", &v.ConsumptionPerKm, p.ConsumptionPerKm)
+	patchField(&columns, "co2_per_km", &v.CO2PerKm, p.CO2PerKm)
+	patchField(&columns, "available", &v.Available, p.Available)
+	patchID(&columns, "warehouse_id", &v.WarehouseID, p.WarehouseID)
+	patchField(&columns, "max_working_hours", &v.MaxWorkingHours, p.MaxWorkingHours)
+	patchField(&columns, "average_speed", &v.AverageSpeed, p.AverageSpeed)
+	patchField(&columns, "shift_start", &v.ShiftStart, p.ShiftStart)
+	patchField(&columns, "shift_end", &v.ShiftEnd, p.ShiftEnd)
+	patchField(&columns, "allowed_tags", &v.AllowedTags, p.AllowedTags)
+	patchField(&columns, "max_payload_weight", &v.MaxPayloadWeight, p.MaxPayloadWeight)
+	patchField(&columns, "max_front_axle_load", &v.MaxFrontAxleLoad, p.MaxFrontAxleLoad)
+	patchField(&columns, "max_rear_axle_load", &v.MaxRearAxleLoad, p.MaxRearAxleLoad)
+	patchField(&columns, "external_ref", &v.ExternalRef, p.ExternalRef)
+	return columns
+}
+
+// toVehicle builds the vehicle model from a request
+func (r VehicleRequest) toVehicle(id int64) *models.Vehicle {
+	return &models.Vehicle{
+		ID:               id,
+		Name:             r.Name,
+		Capacity:         r.Capacity,
+		CostPerKm:        r.CostPerKm,
+		FixedCost:        r.FixedCost,
+		MaxDistance:      r.MaxDistance,
+		RangeKm:          r.RangeKm,
+		Electric:         r.Electric,
+		ChargeMinutes:    r.ChargeMinutes,
+		ConsumptionPerKm: r.ConsumptionPerKm,
+		CO2PerKm:         r.CO2PerKm,
+		Available:        r.Available,
+		WarehouseID:      warehouseIDPtr(r.WarehouseID),
+		MaxWorkingHours:  r.MaxWorkingHours,
+		AverageSpeed:     r.AverageSpeed,
+		ShiftStart:       r.ShiftStart,
+		ShiftEnd:         r.ShiftEnd,
+		AllowedTags:      r.AllowedTags,
+		MaxPayloadWeight: r.MaxPayloadWeight,
+		MaxFrontAxleLoad: r.MaxFrontAxleLoad,
+		MaxRearAxleLoad:  r.MaxRearAxleLoad,
+		ExternalRef:      r.ExternalRef,
+	}
+}
+
+// ListVehicles handles GET /api/v1/vehicles?tag=
+// tag is a comma-separated list of tag names; vehicles with any of them are
+// listed.
 func (h *Handler) ListVehicles(c *gin.Context) {
-	vehicles, err := database.ListVehicles(h.db)
+	vehicles, err := database.ListVehicles(h.db, parseTagQuery(c))
 	if err != nil {
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicles")
 		return
@@ -45,12 +169,13 @@ func (h *Handler) GetVehicle(c *gin.Context) {
 	vehicle, err := database.GetVehicle(h.db, id)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Vehicle not found")
+			notFoundResponse(c, "Vehicle")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle")
 		return
 	}
+	setETag(c, vehicle.Version)
 	successResponse(c, vehicle)
 }
 
@@ -58,18 +183,21 @@ func (h *Handler) GetVehicle(c *gin.Context) {
 func (h *Handler) CreateVehicle(c *gin.Context) {
 	var req VehicleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
+		return
+	}
+	if err := req.validateShift(); err != nil {
+		validationErrorResponse(c, err)
 		return
 	}
 
-	vehicle := &models.Vehicle{
-		Name:        req.Name,
-		Capacity:    req.Capacity,
-		CostPerKm:   req.CostPerKm,
-		FixedCost:   req.FixedCost,
-		MaxDistance: req.MaxDistance,
-		Available:   req.Available,
-		WarehouseID: req.WarehouseID,
+	h.createVehicle(c, req.toVehicle(0))
+}
+
+// createVehicle stores a new vehicle and responds with it
+func (h *Handler) createVehicle(c *gin.Context, vehicle *models.Vehicle) {
+	if !h.uniqueExternalRef(c, "vehicle", h.db, &models.Vehicle{}, vehicle.ID, vehicle.ExternalRef) {
+		return
 	}
 
 	if err := database.CreateVehicle(h.db, vehicle); err != nil {
@@ -89,32 +217,252 @@ func (h *Handler) UpdateVehicle(c *gin.Context) {
 
 	var req VehicleRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
 		return
 	}
+	if err := req.validateShift(); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	vehicle := req.toVehicle(id)
+	vehicle.Version = version
+	h.updateVehicle(c, vehicle)
+}
 
-	vehicle := &models.Vehicle{
-		ID:          id,
-		Name:        req.Name,
-		Capacity:    req.Capacity,
-		CostPerKm:   req.CostPerKm,
-		FixedCost:   req.FixedCost,
-		MaxDistance: req.MaxDistance,
-		Available:   req.Available,
-		WarehouseID: req.WarehouseID,
+// updateVehicle stores a vehicle's changes and responds with it
+func (h *Handler) updateVehicle(c *gin.Context, vehicle *models.Vehicle) {
+	if !h.uniqueExternalRef(c, "vehicle", h.db, &models.Vehicle{}, vehicle.ID, vehicle.ExternalRef) {
+		return
 	}
 
 	if err := database.UpdateVehicle(h.db, vehicle); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Vehicle not found")
+			notFoundResponse(c, "Vehicle")
+			return
+		}
+		if versionConflict(c, "Vehicle", err) {
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to update vehicle")
 		return
 	}
+	setETag(c, vehicle.Version)
+	successResponse(c, vehicle)
+}
+
+// PatchVehicle handles PATCH /api/v1/vehicles/:id
+// Changes only the fields in the request, unlike UpdateVehicle, which
+// replaces the vehicle. The shift is checked as the patch leaves it.
+func (h *Handler) PatchVehicle(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	var req VehiclePatch
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	vehicle, err := database.GetVehicle(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Vehicle")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle")
+		return
+	}
+	if staleVersion(c, "Vehicle", version, vehicle.Version) {
+		return
+	}
+	vehicle.Version = version
+	columns := req.apply(vehicle)
+	shift := VehicleRequest{ShiftStart: vehicle.ShiftStart, ShiftEnd: vehicle.ShiftEnd}
+	if err := shift.validateShift(); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	if !h.uniqueExternalRef(c, "vehicle", h.db, &models.Vehicle{}, vehicle.ID, vehicle.ExternalRef) {
+		return
+	}
+	if len(columns) > 0 {
+		if err := database.UpdateVehicleColumns(h.db, vehicle, append(columns, "updated_at")); err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				notFoundResponse(c, "Vehicle")
+				return
+			}
+			if versionConflict(c, "Vehicle", err) {
+				return
+			}
+			errorResponse(c, http.StatusInternalServerError, "Failed to update vehicle")
+			return
+		}
+	}
+	setETag(c, vehicle.Version)
 	successResponse(c, vehicle)
 }
 
+// GetVehicleByRef handles GET /api/v1/vehicles/by-ref/:ref
+func (h *Handler) GetVehicleByRef(c *gin.Context) {
+	h.getByExternalRef(c, "vehicle", h.db, &models.Vehicle{})
+}
+
+// UpsertVehicle handles PUT /api/v1/vehicles/by-ref/:ref
+// Updates the vehicle with the external reference, or creates it (201)
+// when there is none. The version only applies to updates.
+func (h *Handler) UpsertVehicle(c *gin.Context) {
+	var req VehicleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+	if err := req.validateShift(); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	id, ok := h.upsertTarget(c, "vehicle", h.db, &models.Vehicle{}, &req.ExternalRef)
+	if !ok {
+		return
+	}
+	if id == 0 {
+		h.createVehicle(c, req.toVehicle(0))
+		return
+	}
+	vehicle := req.toVehicle(id)
+	vehicle.Version = version
+	h.updateVehicle(c, vehicle)
+}
+
+// GetVehicleHistory handles GET /api/v1/vehicles/:id/history?from=&to=
+// The vehicle's routes, executions, incidents and maintenance over the
+// period, a year up to today unless given, with their totals.
+func (h *Handler) GetVehicleHistory(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid vehicle ID")
+		return
+	}
+
+	today := h.clock.Now().Truncate(24 * time.Hour)
+	to, err := parseDateQuery(c, "to", today)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid to date format (use YYYY-MM-DD)")
+		return
+	}
+	from, err := parseDateQuery(c, "from", to.AddDate(-1, 0, 0))
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid from date format (use YYYY-MM-DD)")
+		return
+	}
+	if to.Before(from) {
+		errorResponse(c, http.StatusBadRequest, "to date must be after from date")
+		return
+	}
+
+	// Retired vehicles keep their history
+	if _, err := database.GetVehicleWithDeleted(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Vehicle")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle")
+		return
+	}
+
+	routes, err := database.GetRoutesByVehicle(h.db, id, from, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle routes")
+		return
+	}
+	executions, err := database.GetRouteExecutionsByVehicle(h.db, id, from, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle executions")
+		return
+	}
+	incidents, err := database.GetIncidentsByVehicle(h.db, id, from, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle incidents")
+		return
+	}
+	maintenance, err := database.ListMaintenanceRecords(h.db, id, from, to)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch vehicle maintenance")
+		return
+	}
+
+	history := &models.VehicleHistory{
+		VehicleID:           id,
+		From:                from.Format("2006-01-02"),
+		To:                  to.Format("2006-01-02"),
+		RouteCount:          len(routes),
+		Routes:              routes,
+		Executions:          executions,
+		Incidents:           incidents,
+		Maintenance:         maintenance,
+		IncidentCount:       len(incidents),
+		IncidentsByCategory: make(map[string]int),
+		MaintenanceCount:    len(maintenance),
+	}
+
+	days := make(map[string]bool)
+	for _, r := range routes {
+		days[r.Date.Format("2006-01-02")] = true
+		history.PlannedDistanceKm += r.TotalDistance
+		history.PlannedCost += r.TotalCost
+		history.TotalLoad += r.TotalLoad
+	}
+	history.DaysActive = len(days)
+
+	for _, e := range executions {
+		if e.Status == "completed" {
+			history.CompletedExecutions++
+		}
+		history.ActualDistanceKm += e.ActualDistance
+		history.ActualCost += e.ActualCost
+	}
+
+	for i := range history.Incidents {
+		history.IncidentsByCategory[history.Incidents[i].Category]++
+		if !h.signIncidentPhotos(c, &history.Incidents[i]) {
+			return
+		}
+	}
+	for _, m := range maintenance {
+		history.MaintenanceCost += m.Cost
+	}
+
+	if history.Routes == nil {
+		history.Routes = []models.Route{}
+	}
+	if history.Executions == nil {
+		history.Executions = []models.RouteExecution{}
+	}
+	if history.Incidents == nil {
+		history.Incidents = []models.Incident{}
+	}
+	if history.Maintenance == nil {
+		history.Maintenance = []models.MaintenanceRecord{}
+	}
+
+	successResponse(c, history)
+}
+
 // DeleteVehicle handles DELETE /api/v1/vehicles/:id
 func (h *Handler) DeleteVehicle(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -125,7 +473,7 @@ func (h *Handler) DeleteVehicle(c *gin.Context) {
 
 	if err := database.DeleteVehicle(h.db, id); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Vehicle not found")
+			notFoundResponse(c, "Vehicle")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to delete vehicle")
@@ -134,3 +482,10 @@ func (h *Handler) DeleteVehicle(c *gin.Context) {
 	successResponse(c, gin.H{"message": "Vehicle deleted successfully"})
 }
 
+// warehouseIDPtr maps the request's zero value to an unassigned vehicle
+func warehouseIDPtr(id int64) *int64 {
+	if id == 0 {
+		return nil
+	}
+	return &id
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+)
+
+// TestCreateVehicleShiftValidation tests that malformed shifts are rejected
+func TestCreateVehicleShiftValidation(t *testing.T) {
+	s := newTestServer(t)
+
+	s.api.POST("/vehicles", s.h.CreateVehicle)
+	token := s.login(t, "user")
+
+	tests := []struct {
+		name       string
+		req        VehicleRequest
+		wantStatus int
+	}{
+		{
+			name:       "valid shift",
+			req:        VehicleRequest{Name: "Truck", Capacity: 100, ShiftStart: "06:00", ShiftEnd: "14:00", MaxWorkingHours: 8, AllowedTags: []string{"reefer"}},
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "malformed shift start",
+			req:        VehicleRequest{Name: "Truck", Capacity: 100, ShiftStart: "6am"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "shift ends before it starts",
+			req:        VehicleRequest{Name: "Truck", Capacity: 100, ShiftStart: "14:00", ShiftEnd: "06:00"},
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "negative working hours",
+			req:        VehicleRequest{Name: "Truck", Capacity: 100, MaxWorkingHours: -1},
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := s.do(t, "POST", "/api/v1/vehicles", token, tt.req)
+			if w.Code != tt.wantStatus {
+				t.Errorf("CreateVehicle() status = %d, want %d, body = %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+// TestOptimizeStoresPlannedRouteTimes tests that routes are stored with
+// planned times from the vehicle's shift and that skill mismatches are
+// rejected
+func TestOptimizeStoresPlannedRouteTimes(t *testing.T) {
+	s := newTestServer(t)
+
+	warehouse := &models.Warehouse{Name: "Depot", Latitude: 40.7, Longitude: -74.0}
+	database.CreateWarehouse(s.db, warehouse)
+	customer := &models.Customer{Name: "Customer A", Latitude: 40.7, Longitude: -74.0, DemandRate: 10}
+	database.CreateCustomer(s.db, customer)
+	vehicle := &models.Vehicle{Name: "Truck", Capacity: 100, Available: true, WarehouseID: &warehouse.ID, AverageSpeed: 20, ShiftStart: "06:30"}
+	database.CreateVehicle(s.db, vehicle)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	plan := &models.Plan{Name: "Plan", StartDate: start, EndDate: start.AddDate(0, 0, 6), Status: "draft", WarehouseID: &warehouse.ID}
+	database.CreatePlan(s.db, plan)
+	optimizePath := "/api/v1/plans/" + strconv.FormatInt(plan.ID, 10) + "/optimize"
+
+	s.api.POST("/plans/:id/optimize", s.h.OptimizePlan)
+	token := s.login(t, "user")
+
+	optimize := func(t *testing.T) *httptest.ResponseRecorder {
+		t.Helper()
+		return s.do(t, "POST", optimizePath, token, nil)
+	}
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"planned times", func(t *testing.T) {
+			if w := optimize(t); w.Code != http.StatusOK {
+				t.Fatalf("OptimizePlan() status = %d, body = %s", w.Code, w.Body.String())
+			}
+			if len(s.opt.LastRequest().Vehicles) != 1 || s.opt.LastRequest().Vehicles[0].ShiftStart != "06:30" || s.opt.LastRequest().Vehicles[0].AverageSpeed != 20 {
+				t.Errorf("optimizer vehicles = %+v, want shift and speed sent", s.opt.LastRequest().Vehicles)
+			}
+
+			routes, err := database.GetRoutesByPlan(s.db, plan.ID)
+			if err != nil || len(routes) != 1 {
+				t.Fatalf("GetRoutesByPlan() = %d routes, err = %v, want 1", len(routes), err)
+			}
+			// 10 km at 20 km/h plus one 15 minute stop
+			wantStart := start.Add(6*time.Hour + 30*time.Minute)
+			wantEnd := wantStart.Add(45 * time.Minute)
+			route := routes[0]
+			if route.PlannedStart == nil || !route.PlannedStart.Equal(wantStart) || route.PlannedEnd == nil || !route.PlannedEnd.Equal(wantEnd) {
+				t.Errorf("planned window = %v - %v, want %v - %v", route.PlannedStart, route.PlannedEnd, wantStart, wantEnd)
+			}
+		}},
+		{"skill mismatch", func(t *testing.T) {
+			customer.ServiceTags = []string{"reefer"}
+			database.UpdateCustomer(s.db, customer)
+			if w := optimize(t); w.Code != http.StatusUnprocessableEntity {
+				t.Errorf("OptimizePlan() with skill mismatch status = %d, want %d", w.Code, http.StatusUnprocessableEntity)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
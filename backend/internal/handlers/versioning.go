@@ -0,0 +1,129 @@
+package handlers
+
+import (
+	"strconv"
+
+	"LogiTrackPro/backend/internal/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIV2BasePath is the path version 2 of the API is below. Its routes are
+// served by the same handlers as version 1; only the serializers that shape
+// their responses differ.
+const APIV2BasePath = "/api/v2"
+
+// apiVersionKey is the context key of the API version a request is for
+const apiVersionKey = "apiVersion"
+
+// APIVersionMiddleware marks the requests of a route group as being for
+// version of the API, whose serializers then shape their responses, and
+// echoes it in the API-Version header
+func (h *Handler) APIVersionMiddleware(version int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(apiVersionKey, version)
+		c.Header("API-Version", strconv.Itoa(version))
+		c.Next()
+	}
+}
+
+// apiVersion is the API version a request is for, 1 unless its route group
+// says otherwise
+func apiVersion(c *gin.Context) int {
+	if version := c.GetInt(apiVersionKey); version > 0 {
+		return version
+	}
+	return 1
+}
+
+// serializer shapes the bodies of a version's success responses from what
+// the handlers respond with
+type serializer struct {
+	// page is the body of a page of a list
+	page func(c *gin.Context, data interface{}, page models.Pagination) interface{}
+}
+
+// serializers are the serializers of each API version
+var serializers = map[int]serializer{
+	1: {page: pageV1},
+	2: {page: pageV2},
+}
+
+// serializerFor is the serializer of the request's API version
+func serializerFor(c *gin.Context) serializer {
+	if s, ok := serializers[apiVersion(c)]; ok {
+		return s
+	}
+	return serializers[1]
+}
+
+// pageV1 is the v1 body of a page: the success envelope with the pagination
+// beside the data
+func pageV1(c *gin.Context, data interface{}, page models.Pagination) interface{} {
+	return gin.H{
+		"success":    true,
+		"data":       data,
+		"pagination": page,
+	}
+}
+
+// PageV2 is the v2 body of a page of a list
+type PageV2 struct {
+	Data  interface{} `json:"data"`
+	Meta  PageMeta    `json:"meta"`
+	Links PageLinks   `json:"links"`
+}
+
+// PageMeta describes a v2 page and the list it is of
+type PageMeta struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+	HasMore    bool  `json:"has_more"`
+}
+
+// PageLinks are the URLs of a v2 page and of the pages around it, keeping
+// the request's other query parameters. Prev and Next are left out on the
+// first and last pages.
+type PageLinks struct {
+	Self  string `json:"self"`
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Prev  string `json:"prev,omitempty"`
+	Next  string `json:"next,omitempty"`
+}
+
+// pageV2 is the v2 body of a page
+func pageV2(c *gin.Context, data interface{}, page models.Pagination) interface{} {
+	last := max(page.TotalPages, 1)
+	links := PageLinks{
+		Self:  pageURL(c, page.Page),
+		First: pageURL(c, 1),
+		Last:  pageURL(c, last),
+	}
+	if page.Page > 1 {
+		links.Prev = pageURL(c, min(page.Page-1, last))
+	}
+	if page.Page < page.TotalPages {
+		links.Next = pageURL(c, page.Page+1)
+	}
+	return PageV2{
+		Data: data,
+		Meta: PageMeta{
+			Page:       page.Page,
+			Limit:      page.Limit,
+			Total:      page.Total,
+			TotalPages: page.TotalPages,
+			HasMore:    page.Page < page.TotalPages,
+		},
+		Links: links,
+	}
+}
+
+// pageURL is the request's URL, relative to the host, on another page
+func pageURL(c *gin.Context, page int) string {
+	query := c.Request.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	return c.Request.URL.Path + "?" + query.Encode()
+}
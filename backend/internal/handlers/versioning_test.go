@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// TestAPIVersions tests that the same list handler answers v1 in the
+// success envelope with its pagination and v2 in the page envelope with
+// links to the pages around it
+func TestAPIVersions(t *testing.T) {
+	s := newTestServer(t)
+	s.router.Group(APIBasePath, s.h.APIVersionMiddleware(1), s.h.AuthMiddleware()).GET("/customers", s.h.ListCustomers)
+	s.router.Group(APIV2BasePath, s.h.APIVersionMiddleware(2), s.h.AuthMiddleware()).GET("/customers", s.h.ListCustomers)
+
+	token := s.login(t, "manager")
+	for i := 0; i < 3; i++ {
+		s.fx.Customer()
+	}
+
+	var nextPage string
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"v1", func(t *testing.T) {
+			w := s.do(t, "GET", "/api/v1/customers?limit=2", token, nil)
+			var v1 map[string]json.RawMessage
+			json.Unmarshal(w.Body.Bytes(), &v1)
+			if w.Code != http.StatusOK || v1["success"] == nil || v1["pagination"] == nil || w.Header().Get("API-Version") != "1" {
+				t.Fatalf("v1 status = %d: %s, want the success envelope with pagination", w.Code, w.Body.String())
+			}
+		}},
+		{"v2 first page", func(t *testing.T) {
+			var v2 struct {
+				Success *bool
+				Data    []map[string]interface{}
+				Meta    PageMeta
+				Links   PageLinks
+			}
+			w := s.do(t, "GET", "/api/v2/customers?limit=2&fields=id,name", token, nil)
+			if err := json.Unmarshal(w.Body.Bytes(), &v2); err != nil || w.Code != http.StatusOK || w.Header().Get("API-Version") != "2" {
+				t.Fatalf("v2 status = %d: %s", w.Code, w.Body.String())
+			}
+			if v2.Success != nil || len(v2.Data) != 2 || len(v2.Data[0]) != 2 {
+				t.Errorf("v2 data = %v, want 2 customers with the selected fields and no success", v2.Data)
+			}
+			if v2.Meta != (PageMeta{Page: 1, Limit: 2, Total: 3, TotalPages: 2, HasMore: true}) {
+				t.Errorf("v2 meta = %+v, want page 1 of 2", v2.Meta)
+			}
+			if v2.Links.Prev != "" || v2.Links.Next == "" || v2.Links.Last != v2.Links.Next {
+				t.Fatalf("v2 links = %+v, want a next page that is the last", v2.Links)
+			}
+			next, _ := url.Parse(v2.Links.Next)
+			if next.Path != "/api/v2/customers" || next.Query().Get("page") != "2" || next.Query().Get("limit") != "2" || next.Query().Get("fields") != "id,name" {
+				t.Errorf("next = %q, want page 2 keeping the query", v2.Links.Next)
+			}
+			nextPage = v2.Links.Next
+		}},
+		{"v2 last page", func(t *testing.T) {
+			w := s.do(t, "GET", nextPage, token, nil)
+			var last struct {
+				Data  []map[string]interface{}
+				Meta  PageMeta
+				Links PageLinks
+			}
+			json.Unmarshal(w.Body.Bytes(), &last)
+			if len(last.Data) != 1 || last.Meta.HasMore || last.Links.Next != "" || last.Links.Prev != last.Links.First {
+				t.Errorf("last page = %+v, %+v, want 1 customer with a previous page only", last.Meta, last.Links)
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// expectedVersion returns the version of the record an edit is based on:
+// the If-Match header's ("3", W/"3", 3 or a conditional GET's W/"3-…"),
+// else the request body's. 0 applies the edit to any version. It responds
+// 400 to an invalid header.
+func expectedVersion(c *gin.Context, body int64) (int64, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		return body, true
+	}
+	tag := strings.Trim(strings.TrimPrefix(header, "W/"), `"`)
+	tag, _, _ = strings.Cut(tag, "-")
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil || version < 1 {
+		errorResponse(c, http.StatusBadRequest, "If-Match must be a record version")
+		return 0, false
+	}
+	return version, true
+}
+
+// setETag sets the ETag header to a record's version, for If-Match
+func setETag(c *gin.Context, version int64) {
+	c.Header("ETag", `"`+strconv.FormatInt(version, 10)+`"`)
+}
+
+// versionConflict responds 409 when err is a version conflict on a record
+// of kind, reporting whether it did
+func versionConflict(c *gin.Context, kind string, err error) bool {
+	if !errors.Is(err, database.ErrVersionConflict) {
+		return false
+	}
+	errorCodeResponse(c, http.StatusConflict, codeVersionConflict, kind+" was changed by someone else; reload it and try again")
+	return true
+}
+
+// staleVersion responds 409 when an edit is based on another version than
+// the current one of a record of kind, reporting whether it did. Edits that
+// change nothing are checked here since they never reach the database.
+func staleVersion(c *gin.Context, kind string, expected, current int64) bool {
+	if expected == 0 || expected == current {
+		return false
+	}
+	return versionConflict(c, kind, database.ErrVersionConflict)
+}
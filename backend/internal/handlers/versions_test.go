@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ifMatch sends a JSON request with an If-Match header as the token's user
+func (s *testServer) ifMatch(t *testing.T, method, path, token, ifMatch string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("If-Match", ifMatch)
+	w := httptest.NewRecorder()
+	s.router.ServeHTTP(w, req)
+	return w
+}
+
+// TestOptimisticLocking tests that edits based on an outdated version of a
+// record are refused with 409 and edits without a version still apply
+func TestOptimisticLocking(t *testing.T) {
+	s := newTestServer(t)
+	s.api.GET("/customers/:id", s.h.GetCustomer)
+	s.api.PUT("/customers/:id", s.h.UpdateCustomer)
+	s.api.PATCH("/vehicles/:id", s.h.PatchVehicle)
+	s.api.PATCH("/warehouses/:id", s.h.PatchWarehouse)
+	s.api.PUT("/plans/:id/notes", s.h.SetPlanNotes)
+
+	token := s.login(t, "manager")
+	warehouse := s.fx.Warehouse()
+	customer := s.fx.Customer()
+	vehicle := s.fx.Vehicle(warehouse)
+	plan := s.fx.Plan(warehouse, time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC), 3)
+	customerPath := "/api/v1/customers/" + fmt.Sprint(customer.ID)
+
+	t.Run("customer", func(t *testing.T) {
+		if w := s.do(t, "GET", customerPath, token, nil); w.Header().Get("ETag") != `"1"` {
+			t.Fatalf("new customer ETag = %q, want %q", w.Header().Get("ETag"), `"1"`)
+		}
+
+		edit := CustomerRequest{Name: "First edit", Latitude: customer.Latitude, Longitude: customer.Longitude, Version: 1}
+		if w := s.do(t, "PUT", customerPath, token, edit); w.Code != http.StatusOK {
+			t.Fatalf("update at version 1 status = %d: %s", w.Code, w.Body.String())
+		}
+		edit.Name = "Second edit"
+		if w := s.do(t, "PUT", customerPath, token, edit); w.Code != http.StatusConflict {
+			t.Errorf("update at stale version 1 status = %d, want %d", w.Code, http.StatusConflict)
+		}
+		if stored, _ := database.GetCustomer(s.db, customer.ID); stored.Name != "First edit" || stored.Version != 2 {
+			t.Errorf("customer = %q at version %d, want the first edit at version 2", stored.Name, stored.Version)
+		}
+		edit.Version = 0
+		if w := s.do(t, "PUT", customerPath, token, edit); w.Code != http.StatusOK {
+			t.Errorf("update without a version status = %d, want %d", w.Code, http.StatusOK)
+		}
+	})
+
+	t.Run("vehicle", func(t *testing.T) {
+		vehiclePath := "/api/v1/vehicles/" + fmt.Sprint(vehicle.ID)
+		w := s.ifMatch(t, "PATCH", vehiclePath, token, `W/"1"`, gin.H{"capacity": 900})
+		if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+			t.Fatalf("patch vehicle at version 1 status = %d, ETag %q: %s", w.Code, w.Header().Get("ETag"), w.Body.String())
+		}
+		if w := s.ifMatch(t, "PATCH", vehiclePath, token, `"1"`, gin.H{"capacity": 800}); w.Code != http.StatusConflict {
+			t.Errorf("patch vehicle at stale version 1 status = %d, want %d", w.Code, http.StatusConflict)
+		}
+		if w := s.ifMatch(t, "PATCH", vehiclePath, token, "*", gin.H{"capacity": 800}); w.Code != http.StatusBadRequest {
+			t.Errorf("patch vehicle with If-Match * status = %d, want %d", w.Code, http.StatusBadRequest)
+		}
+	})
+
+	t.Run("warehouse", func(t *testing.T) {
+		warehousePath := "/api/v1/warehouses/" + fmt.Sprint(warehouse.ID)
+		if w := s.do(t, "PATCH", warehousePath, token, gin.H{"version": 5}); w.Code != http.StatusConflict {
+			t.Errorf("empty warehouse patch at version 5 status = %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+
+	t.Run("plan notes", func(t *testing.T) {
+		notesPath := fmt.Sprintf("/api/v1/plans/%d/notes", plan.ID)
+		if w := s.do(t, "PUT", notesPath, token, SetPlanNotesRequest{Notes: "Use gate B", Version: 1}); w.Code != http.StatusOK {
+			t.Fatalf("set notes at version 1 status = %d: %s", w.Code, w.Body.String())
+		}
+		if w := s.do(t, "PUT", notesPath, token, SetPlanNotesRequest{Notes: "Use gate C", Version: 1}); w.Code != http.StatusConflict {
+			t.Errorf("set notes at stale version 1 status = %d, want %d", w.Code, http.StatusConflict)
+		}
+	})
+}
+
+// TestInventoryChangesConflictWithStaleEdits tests that an inventory
+// adjustment makes a new version of the customer, so that a PUT based on
+// the version before it cannot write the old inventory back
+func TestInventoryChangesConflictWithStaleEdits(t *testing.T) {
+	s := newTestServer(t)
+	s.api.PUT("/customers/:id", s.h.UpdateCustomer)
+	s.api.POST("/customers/:id/inventory-adjustments", s.h.AdjustCustomerInventory)
+
+	token := s.login(t, "manager")
+	customer := s.fx.Customer()
+	path := "/api/v1/customers/" + fmt.Sprint(customer.ID)
+
+	if w := s.do(t, "POST", path+"/inventory-adjustments", token, InventoryAdjustmentRequest{Delta: -12, Reason: "count_correction"}); w.Code != http.StatusCreated {
+		t.Fatalf("adjust status = %d: %s", w.Code, w.Body.String())
+	}
+	edit := CustomerRequest{Name: customer.Name, Latitude: customer.Latitude, Longitude: customer.Longitude, CurrentInventory: customer.CurrentInventory}
+	if w := s.ifMatch(t, "PUT", path, token, `"1"`, edit); w.Code != http.StatusConflict {
+		t.Errorf("update at the version before the adjustment status = %d, want %d", w.Code, http.StatusConflict)
+	}
+	if stored, _ := database.GetCustomer(s.db, customer.ID); stored.CurrentInventory != customer.CurrentInventory-12 || stored.Version != 2 {
+		t.Errorf("customer = %v on hand at version %d, want the adjustment kept at version 2", stored.CurrentInventory, stored.Version)
+	}
+}
@@ -3,7 +3,9 @@ package handlers
 import (
 	"errors"
 	"net/http"
+	"sort"
 	"strconv"
+	"time"
 
 	"LogiTrackPro/backend/internal/database"
 	"LogiTrackPro/backend/internal/models"
@@ -17,9 +19,60 @@ type WarehouseRequest struct {
 	Latitude        float64 `json:"latitude" binding:"required"`
 	Longitude       float64 `json:"longitude" binding:"required"`
 	Capacity        float64 `json:"capacity"`
-	CurrentStock    float64 `json:"current_stock"`
+	CurrentStock    float64 `json:"current_stock"` // opening stock; afterwards stock changes through stock movements
 	HoldingCost     float64 `json:"holding_cost"`
 	ReplenishmentQty float64 `json:"replenishment_qty"`
+	MinStock        float64 `json:"min_stock"`
+	ExternalRef     string  `json:"external_ref" binding:"max=100"`
+	// Version is the version of the warehouse edited; see expectedVersion
+	Version int64 `json:"version"`
+}
+
+// WarehousePatch changes the fields of a warehouse it has. Stock only
+// changes through stock movements.
+type WarehousePatch struct {
+	Name             *string  `json:"name" binding:"omitempty,min=1"`
+	Address          *string  `json:"address"`
+	Latitude         *float64 `json:"latitude" binding:"omitempty,gte=-90,lte=90"`
+	Longitude        *float64 `json:"longitude" binding:"omitempty,gte=-180,lte=180"`
+	Capacity         *float64 `json:"capacity" binding:"omitempty,gte=0"`
+	HoldingCost      *float64 `json:"holding_cost"`
+	ReplenishmentQty *float64 `json:"replenishment_qty"`
+	MinStock         *float64 `json:"min_stock" binding:"omitempty,gte=0"`
+	ExternalRef      *string  `json:"external_ref" binding:"omitempty,max=100"`
+	Version          int64    `json:"version"`
+}
+
+// apply applies the patch to a warehouse and returns the columns it changes
+func (p WarehousePatch) apply(w *models.Warehouse) []string {
+	var columns []string
+	patchField(&columns, "name", &w.Name, p.Name)
+	patchField(&columns, "address", &w.Address, p.Address)
+	patchField(&columns, "latitude", &w.Latitude, p.Latitude)
+	patchField(&columns, "longitude", &w.Longitude, p.Longitude)
+	patchField(&columns, "capacity", &w.Capacity, p.Capacity)
+	patchField(&columns, "holding_cost", &w.HoldingCost, p.HoldingCost)
+	patchField(&columns, "replenishment_qty", &w.ReplenishmentQty, p.ReplenishmentQty)
+	patchField(&columns, "min_stock", &w.MinStock, p.MinStock)
+	patchField(&columns, "external_ref", &w.ExternalRef, p.ExternalRef)
+	return columns
+}
+
+// toWarehouse builds the warehouse model from a request
+func (r WarehouseRequest) toWarehouse(id int64) *models.Warehouse {
+	return &models.Warehouse{
+		ID:               id,
+		Name:             r.Name,
+		Address:          r.Address,
+		Latitude:         r.Latitude,
+		Longitude:        r.Longitude,
+		Capacity:         r.Capacity,
+		CurrentStock:     r.CurrentStock,
+		HoldingCost:      r.HoldingCost,
+		ReplenishmentQty: r.ReplenishmentQty,
+		MinStock:         r.MinStock,
+		ExternalRef:      r.ExternalRef,
+	}
 }
 
 // ListWarehouses handles GET /api/v1/warehouses
@@ -46,12 +99,13 @@ func (h *Handler) GetWarehouse(c *gin.Context) {
 	warehouse, err := database.GetWarehouse(h.db, id)
 	if err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Warehouse not found")
+			notFoundResponse(c, "Warehouse")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
 		return
 	}
+	setETag(c, warehouse.Version)
 	successResponse(c, warehouse)
 }
 
@@ -59,19 +113,16 @@ func (h *Handler) GetWarehouse(c *gin.Context) {
 func (h *Handler) CreateWarehouse(c *gin.Context) {
 	var req WarehouseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
 		return
 	}
+	h.createWarehouse(c, req.toWarehouse(0))
+}
 
-	warehouse := &models.Warehouse{
-		Name:            req.Name,
-		Address:         req.Address,
-		Latitude:        req.Latitude,
-		Longitude:       req.Longitude,
-		Capacity:        req.Capacity,
-		CurrentStock:    req.CurrentStock,
-		HoldingCost:     req.HoldingCost,
-		ReplenishmentQty: req.ReplenishmentQty,
+// createWarehouse stores a new warehouse and responds with it
+func (h *Handler) createWarehouse(c *gin.Context, warehouse *models.Warehouse) {
+	if !h.uniqueExternalRef(c, "warehouse", h.db, &models.Warehouse{}, warehouse.ID, warehouse.ExternalRef) {
+		return
 	}
 
 	if err := database.CreateWarehouse(h.db, warehouse); err != nil {
@@ -82,6 +133,7 @@ func (h *Handler) CreateWarehouse(c *gin.Context) {
 }
 
 // UpdateWarehouse handles PUT /api/v1/warehouses/:id
+// current_stock is left as is; receipts, adjustments and transfers change it.
 func (h *Handler) UpdateWarehouse(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
@@ -91,33 +143,125 @@ func (h *Handler) UpdateWarehouse(c *gin.Context) {
 
 	var req WarehouseRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		errorResponse(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
 		return
 	}
+	warehouse := req.toWarehouse(id)
+	warehouse.Version = version
+	h.updateWarehouse(c, warehouse)
+}
 
-	warehouse := &models.Warehouse{
-		ID:              id,
-		Name:            req.Name,
-		Address:         req.Address,
-		Latitude:        req.Latitude,
-		Longitude:       req.Longitude,
-		Capacity:        req.Capacity,
-		CurrentStock:    req.CurrentStock,
-		HoldingCost:     req.HoldingCost,
-		ReplenishmentQty: req.ReplenishmentQty,
+// updateWarehouse stores a warehouse's changes and responds with it
+func (h *Handler) updateWarehouse(c *gin.Context, warehouse *models.Warehouse) {
+	if !h.uniqueExternalRef(c, "warehouse", h.db, &models.Warehouse{}, warehouse.ID, warehouse.ExternalRef) {
+		return
 	}
 
 	if err := database.UpdateWarehouse(h.db, warehouse); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Warehouse not found")
+			notFoundResponse(c, "Warehouse")
+			return
+		}
+		if versionConflict(c, "Warehouse", err) {
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to update warehouse")
 		return
 	}
+	setETag(c, warehouse.Version)
 	successResponse(c, warehouse)
 }
 
+// PatchWarehouse handles PATCH /api/v1/warehouses/:id
+// Changes only the fields in the request, unlike UpdateWarehouse, which
+// replaces the warehouse.
+func (h *Handler) PatchWarehouse(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	var req WarehousePatch
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+
+	warehouse, err := database.GetWarehouse(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Warehouse")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return
+	}
+	if staleVersion(c, "Warehouse", version, warehouse.Version) {
+		return
+	}
+	warehouse.Version = version
+	columns := req.apply(warehouse)
+	if !h.uniqueExternalRef(c, "warehouse", h.db, &models.Warehouse{}, warehouse.ID, warehouse.ExternalRef) {
+		return
+	}
+	if len(columns) > 0 {
+		if err := database.UpdateWarehouseColumns(h.db, warehouse, append(columns, "updated_at")); err != nil {
+			if errors.Is(err, database.ErrNotFound) {
+				notFoundResponse(c, "Warehouse")
+				return
+			}
+			if versionConflict(c, "Warehouse", err) {
+				return
+			}
+			errorResponse(c, http.StatusInternalServerError, "Failed to update warehouse")
+			return
+		}
+	}
+	setETag(c, warehouse.Version)
+	successResponse(c, warehouse)
+}
+
+// GetWarehouseByRef handles GET /api/v1/warehouses/by-ref/:ref
+func (h *Handler) GetWarehouseByRef(c *gin.Context) {
+	h.getByExternalRef(c, "warehouse", h.db, &models.Warehouse{})
+}
+
+// UpsertWarehouse handles PUT /api/v1/warehouses/by-ref/:ref
+// Updates the warehouse with the external reference, or creates it (201)
+// when there is none. current_stock is only booked for a new warehouse and
+// the version only applies to updates.
+func (h *Handler) UpsertWarehouse(c *gin.Context) {
+	var req WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+	version, ok := expectedVersion(c, req.Version)
+	if !ok {
+		return
+	}
+	id, ok := h.upsertTarget(c, "warehouse", h.db, &models.Warehouse{}, &req.ExternalRef)
+	if !ok {
+		return
+	}
+	if id == 0 {
+		h.createWarehouse(c, req.toWarehouse(0))
+		return
+	}
+	warehouse := req.toWarehouse(id)
+	warehouse.Version = version
+	h.updateWarehouse(c, warehouse)
+}
+
 // DeleteWarehouse handles DELETE /api/v1/warehouses/:id
 func (h *Handler) DeleteWarehouse(c *gin.Context) {
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
@@ -128,7 +272,7 @@ func (h *Handler) DeleteWarehouse(c *gin.Context) {
 
 	if err := database.DeleteWarehouse(h.db, id); err != nil {
 		if errors.Is(err, database.ErrNotFound) {
-			errorResponse(c, http.StatusNotFound, "Warehouse not found")
+			notFoundResponse(c, "Warehouse")
 			return
 		}
 		errorResponse(c, http.StatusInternalServerError, "Failed to delete warehouse")
@@ -137,3 +281,89 @@ func (h *Handler) DeleteWarehouse(c *gin.Context) {
 	successResponse(c, gin.H{"message": "Warehouse deleted successfully"})
 }
 
+// GetWarehouseDay handles GET /api/v1/warehouses/:id/day?date=YYYY-MM-DD
+func (h *Handler) GetWarehouseDay(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid warehouse ID")
+		return
+	}
+
+	today := h.clock.Now().Truncate(24 * time.Hour)
+	date, err := parseDateQuery(c, "date", today)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid date format (use YYYY-MM-DD)")
+		return
+	}
+
+	warehouse, err := database.GetWarehouse(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Warehouse")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch warehouse")
+		return
+	}
+
+	// Stock is only known today; project forward through planned departures
+	// and daily replenishments. Past dates are reported from today's stock.
+	projectFrom := today
+	if date.Before(today) {
+		projectFrom = date
+	}
+	routes, err := database.GetRoutesByWarehouse(h.db, id, projectFrom, date)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch routes")
+		return
+	}
+
+	view := &models.WarehouseDayView{
+		WarehouseID:           id,
+		Date:                  date.Format("2006-01-02"),
+		OpeningStock:          warehouse.CurrentStock,
+		ExpectedReplenishment: warehouse.ReplenishmentQty,
+		LoadingSchedule:       []models.LoadingSlot{},
+		Routes:                []models.Route{},
+	}
+
+	daysAhead := int(date.Sub(projectFrom).Hours() / 24)
+	view.OpeningStock += warehouse.ReplenishmentQty * float64(daysAhead)
+
+	for _, r := range routes {
+		if r.Date.Before(date) {
+			view.OpeningStock -= r.TotalLoad
+			continue
+		}
+
+		view.Routes = append(view.Routes, r)
+		view.TotalOutbound += r.TotalLoad
+
+		slot := models.LoadingSlot{
+			RouteID:   r.ID,
+			PlanID:    r.PlanID,
+			VehicleID: r.VehicleID,
+			Load:      r.TotalLoad,
+			StopCount: len(r.Stops),
+		}
+		if r.Vehicle != nil {
+			slot.VehicleName = r.Vehicle.Name
+		}
+		if len(r.Stops) > 0 {
+			slot.FirstArrivalTime = r.Stops[0].ArrivalTime
+		}
+		view.LoadingSchedule = append(view.LoadingSchedule, slot)
+	}
+
+	// Vehicles whose first drop is earliest are loaded first
+	sort.SliceStable(view.LoadingSchedule, func(i, j int) bool {
+		return view.LoadingSchedule[i].FirstArrivalTime < view.LoadingSchedule[j].FirstArrivalTime
+	})
+
+	view.ProjectedClosingStock = view.OpeningStock + view.ExpectedReplenishment - view.TotalOutbound
+	if view.ProjectedClosingStock < 0 {
+		view.StockShortfall = -view.ProjectedClosingStock
+	}
+
+	successResponse(c, view)
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+// TestWarehouseDay tests that a warehouse's day view loads and projects stock
+// only for routes of plans that are going ahead
+func TestWarehouseDay(t *testing.T) {
+	s := newTestServer(t)
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 4, 12, 0, 0, 0, time.UTC)))
+	s.api.GET("/warehouses/:id/day", s.h.GetWarehouseDay)
+	token := s.login(t, "manager")
+
+	warehouse := s.fx.Warehouse(func(w *models.Warehouse) {
+		w.ReplenishmentQty = 100
+	})
+	vehicle := s.fx.Vehicle(warehouse)
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	scheduled := map[int64]bool{}
+	for _, status := range []string{
+		planstate.Draft,
+		planstate.Optimized,
+		planstate.Approved,
+		planstate.Executing,
+		planstate.Completed,
+		planstate.Cancelled,
+		planstate.Archived,
+	} {
+		plan := s.fx.Plan(warehouse, start, 3, testkit.WithStatus(status))
+		s.fx.Route(plan, vehicle, 1, s.fx.Customer())
+		route := s.fx.Route(plan, vehicle, 2, s.fx.Customer(), s.fx.Customer())
+		switch status {
+		case planstate.Optimized, planstate.Approved, planstate.Executing:
+			scheduled[route.ID] = true
+		}
+	}
+	day := func(t *testing.T, id int64, date string) (int, models.WarehouseDayView) {
+		t.Helper()
+		w := s.do(t, "GET", fmt.Sprintf("/api/v1/warehouses/%d/day?date=%s", id, date), token, nil)
+		var resp struct{ Data models.WarehouseDayView }
+		json.Unmarshal(w.Body.Bytes(), &resp)
+		return w.Code, resp.Data
+	}
+
+	t.Run("scheduled routes only", func(t *testing.T) {
+		code, view := day(t, warehouse.ID, "2024-03-05")
+		if code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", code)
+		}
+		if len(view.Routes) != len(scheduled) || len(view.LoadingSchedule) != len(scheduled) {
+			t.Fatalf("routes = %d, loading slots = %d, want %d each", len(view.Routes), len(view.LoadingSchedule), len(scheduled))
+		}
+		for _, r := range view.Routes {
+			if !scheduled[r.ID] {
+				t.Errorf("route %d of a plan that is not going ahead is departing", r.ID)
+			}
+		}
+		// 5000 in stock, one replenishment of 100 and three 10 unit routes the day before
+		if view.OpeningStock != 5070 || view.TotalOutbound != 60 || view.ProjectedClosingStock != 5110 {
+			t.Errorf("opening = %v, outbound = %v, closing = %v, want 5070, 60 and 5110", view.OpeningStock, view.TotalOutbound, view.ProjectedClosingStock)
+		}
+	})
+
+	t.Run("unknown warehouse", func(t *testing.T) {
+		if code, _ := day(t, warehouse.ID+1000, "2024-03-05"); code != http.StatusNotFound {
+			t.Errorf("status = %d, want 404", code)
+		}
+	})
+
+	t.Run("invalid date", func(t *testing.T) {
+		if code, _ := day(t, warehouse.ID, "05/03/2024"); code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", code)
+		}
+	})
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/webhooks"
+
+	"github.com/gin-gonic/gin"
+)
+
+var webhookDeliveryStatuses = []string{
+	models.WebhookPending, models.WebhookRetrying, models.WebhookSucceeded, models.WebhookFailed, models.WebhookCancelled,
+}
+
+// WebhookRequest creates or replaces a webhook subscription. A secret is
+// generated when none is given; on update, leaving it out keeps the
+// current one.
+type WebhookRequest struct {
+	URL         string   `json:"url" binding:"required,url,max=2048"`
+	Description string   `json:"description" binding:"max=255"`
+	Events      []string `json:"events" binding:"required,min=1,dive,oneof=plan.optimized execution.completed inventory.low_stock"`
+	Secret      string   `json:"secret" binding:"omitempty,min=16,max=255"`
+	Active      *bool    `json:"active"`
+}
+
+// CreatedWebhook is a new subscription with its secret, which is only
+// shown once
+type CreatedWebhook struct {
+	models.WebhookSubscription
+	Secret string `json:"secret"`
+}
+
+// publishWebhook queues an event for its subscribers. Failures are logged
+// since the change the event reports is already stored.
+func (h *Handler) publishWebhook(event string, data interface{}) {
+	if _, err := webhooks.Publish(h.db, event, data, h.clock.Now()); err != nil {
+		log.Printf("Failed to queue %s webhooks: %v", event, err)
+	}
+}
+
+// ListWebhooks handles GET /api/v1/webhooks
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	subs, err := database.ListWebhookSubscriptions(h.db)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch webhooks")
+		return
+	}
+	if subs == nil {
+		subs = []models.WebhookSubscription{}
+	}
+	successResponse(c, subs)
+}
+
+// GetWebhook handles GET /api/v1/webhooks/:id
+func (h *Handler) GetWebhook(c *gin.Context) {
+	sub, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+	successResponse(c, sub)
+}
+
+// CreateWebhook handles POST /api/v1/webhooks
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if !h.checkWebhookURL(c, req.URL) {
+		return
+	}
+	sub := &models.WebhookSubscription{
+		URL:         req.URL,
+		Description: req.Description,
+		Events:      uniqueEvents(req.Events),
+		Secret:      req.Secret,
+		Active:      req.Active == nil || *req.Active,
+	}
+	if sub.Secret == "" {
+		secret, err := newWebhookSecret()
+		if err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to generate secret")
+			return
+		}
+		sub.Secret = secret
+	}
+	userID := c.GetInt64("userID")
+	sub.CreatedBy = &userID
+	if err := database.CreateWebhookSubscription(h.db, sub); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create webhook")
+		return
+	}
+	createdResponse(c, CreatedWebhook{WebhookSubscription: *sub, Secret: sub.Secret})
+}
+
+// UpdateWebhook handles PUT /api/v1/webhooks/:id
+func (h *Handler) UpdateWebhook(c *gin.Context) {
+	sub, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+	var req WebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		validationErrorResponse(c, err)
+		return
+	}
+
+	if !h.checkWebhookURL(c, req.URL) {
+		return
+	}
+	sub.URL = req.URL
+	sub.Description = req.Description
+	sub.Events = uniqueEvents(req.Events)
+	if req.Secret != "" {
+		sub.Secret = req.Secret
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if err := database.UpdateWebhookSubscription(h.db, sub); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Webhook")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to update webhook")
+		return
+	}
+	successResponse(c, sub)
+}
+
+// DeleteWebhook handles DELETE /api/v1/webhooks/:id
+// Deliveries not yet made are cancelled; the delivery log is kept.
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return
+	}
+	if err := database.DeleteWebhookSubscription(h.db, id); err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Webhook")
+			return
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+	successResponse(c, gin.H{"message": "Webhook deleted successfully"})
+}
+
+// PingWebhook handles POST /api/v1/webhooks/:id/ping
+// Posts a ping event right away, without retries, and responds with the
+// delivery so the endpoint's answer can be checked.
+func (h *Handler) PingWebhook(c *gin.Context) {
+	sub, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+	now := h.clock.Now()
+	body, err := json.Marshal(webhooks.Envelope{
+		Event:      webhooks.Ping,
+		OccurredAt: now,
+		Data:       gin.H{"webhook_id": sub.ID},
+	})
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to encode ping")
+		return
+	}
+	delivery := &models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		Event:          webhooks.Ping,
+		Payload:        string(body),
+		Status:         models.WebhookPending,
+	}
+	if err := database.CreateWebhookDelivery(h.db, delivery); err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to create delivery")
+		return
+	}
+
+	deliverer := webhooks.NewDeliverer(h.db)
+	deliverer.SetClock(h.clock)
+	if h.config.WebhookAllowPrivateAddresses {
+		deliverer.AllowPrivateAddresses()
+	}
+	// the outcome is recorded on the delivery, errors included
+	deliverer.Attempt(c.Request.Context(), sub, delivery)
+	if delivery.Status != models.WebhookSucceeded {
+		// pings are not retried
+		delivery.Status = models.WebhookFailed
+		delivery.NextAttemptAt = nil
+		if err := database.SaveWebhookDelivery(h.db, delivery); err != nil {
+			errorResponse(c, http.StatusInternalServerError, "Failed to record delivery")
+			return
+		}
+	}
+	successResponse(c, delivery)
+}
+
+// ListWebhookDeliveries handles GET /api/v1/webhooks/:id/deliveries?status=&event=&page=&limit=
+// The delivery log, newest first, with the outcome of each delivery's
+// latest attempt. status takes a comma-separated list.
+func (h *Handler) ListWebhookDeliveries(c *gin.Context) {
+	sub, ok := h.webhook(c)
+	if !ok {
+		return
+	}
+	page, err := parsePage(c)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter := database.WebhookDeliveryFilter{SubscriptionID: sub.ID, Event: c.Query("event")}
+	if raw := c.Query("status"); raw != "" {
+		filter.Statuses = strings.Split(raw, ",")
+		for _, status := range filter.Statuses {
+			if !slices.Contains(webhookDeliveryStatuses, status) {
+				errorResponse(c, http.StatusBadRequest, "status must be one of "+strings.Join(webhookDeliveryStatuses, ", "))
+				return
+			}
+		}
+	}
+
+	deliveries, total, err := database.ListWebhookDeliveries(h.db, filter, page.Offset(), page.Limit)
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch webhook deliveries")
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	page.SetTotal(total)
+	paginatedResponse(c, deliveries, page)
+}
+
+// GetWebhookDelivery handles GET /api/v1/webhooks/:id/deliveries/:delivery_id
+func (h *Handler) GetWebhookDelivery(c *gin.Context) {
+	_, delivery, ok := h.webhookDelivery(c)
+	if !ok {
+		return
+	}
+	successResponse(c, delivery)
+}
+
+// RedeliverWebhook handles POST /api/v1/webhooks/:id/deliveries/:delivery_id/redeliver
+// Queues the delivery's payload again as a new delivery with attempts of
+// its own; the original stays in the log.
+func (h *Handler) RedeliverWebhook(c *gin.Context) {
+	sub, delivery, ok := h.webhookDelivery(c)
+	if !ok {
+		return
+	}
+	if !sub.Active {
+		errorResponse(c, http.StatusConflict, "Webhook is not active")
+		return
+	}
+	redelivery, err := webhooks.Queue(h.db, sub.ID, delivery.Event, []byte(delivery.Payload), &delivery.ID, h.clock.Now())
+	if err != nil {
+		errorResponse(c, http.StatusInternalServerError, "Failed to queue redelivery")
+		return
+	}
+	createdResponse(c, redelivery)
+}
+
+// webhook fetches the subscription of the id parameter, responding with
+// the error when it fails
+func (h *Handler) webhook(c *gin.Context) (*models.WebhookSubscription, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid webhook ID")
+		return nil, false
+	}
+	sub, err := database.GetWebhookSubscription(h.db, id)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			notFoundResponse(c, "Webhook")
+			return nil, false
+		}
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch webhook")
+		return nil, false
+	}
+	return sub, true
+}
+
+// checkWebhookURL rejects subscription URLs deliveries may not post to,
+// responding with 400
+func (h *Handler) checkWebhookURL(c *gin.Context, url string) bool {
+	if err := webhooks.CheckURL(url, h.config.WebhookAllowPrivateAddresses); err != nil {
+		errorResponse(c, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+// webhookDelivery fetches the delivery of the delivery_id parameter, which
+// must belong to the subscription of the id parameter
+func (h *Handler) webhookDelivery(c *gin.Context) (*models.WebhookSubscription, *models.WebhookDelivery, bool) {
+	sub, ok := h.webhook(c)
+	if !ok {
+		return nil, nil, false
+	}
+	id, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil {
+		errorResponse(c, http.StatusBadRequest, "Invalid delivery ID")
+		return nil, nil, false
+	}
+	delivery, err := database.GetWebhookDelivery(h.db, id)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		errorResponse(c, http.StatusInternalServerError, "Failed to fetch webhook delivery")
+		return nil, nil, false
+	}
+	if delivery == nil || delivery.SubscriptionID != sub.ID {
+		notFoundResponse(c, "Webhook delivery")
+		return nil, nil, false
+	}
+	return sub, delivery, true
+}
+
+func uniqueEvents(events []string) []string {
+	var unique []string
+	for _, e := range events {
+		if !slices.Contains(unique, e) {
+			unique = append(unique, e)
+		}
+	}
+	return unique
+}
+
+// newWebhookSecret returns 32 random bytes in hex
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
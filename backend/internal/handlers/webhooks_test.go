@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/config"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/securitylog"
+	"LogiTrackPro/backend/internal/testkit"
+	"LogiTrackPro/backend/internal/webhooks"
+)
+
+// TestWebhooks tests managing subscriptions, pinging an endpoint, the
+// delivery log and redelivering a delivery
+func TestWebhooks(t *testing.T) {
+	s := newTestServer(t, func(cfg *config.Config) { cfg.WebhookAllowPrivateAddresses = true })
+	s.h.SetClock(testkit.NewClock(time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)))
+
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		signatures = append(signatures, r.Header.Get(webhooks.SignatureHeader))
+		if r.Header.Get(webhooks.SignatureHeader) != securitylog.Sign("a-sixteen-char-secret", body) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	hooks := s.api.Group("/webhooks", s.h.RoleMiddleware("admin", "manager"))
+	hooks.GET("", s.h.ListWebhooks)
+	hooks.POST("", s.h.CreateWebhook)
+	hooks.GET("/:id", s.h.GetWebhook)
+	hooks.PUT("/:id", s.h.UpdateWebhook)
+	hooks.DELETE("/:id", s.h.DeleteWebhook)
+	hooks.POST("/:id/ping", s.h.PingWebhook)
+	hooks.GET("/:id/deliveries", s.h.ListWebhookDeliveries)
+	hooks.GET("/:id/deliveries/:delivery_id", s.h.GetWebhookDelivery)
+	hooks.POST("/:id/deliveries/:delivery_id/redeliver", s.h.RedeliverWebhook)
+
+	token := s.login(t, "manager")
+	userToken := s.login(t, "user")
+	var path string
+	var failed models.WebhookDelivery
+
+	steps := []struct {
+		name string
+		run  func(t *testing.T)
+	}{
+		{"validation", func(t *testing.T) {
+			if w := s.do(t, "GET", "/api/v1/webhooks", userToken, nil); w.Code != http.StatusForbidden {
+				t.Errorf("list as user status = %d, want 403", w.Code)
+			}
+			if w := s.do(t, "POST", "/api/v1/webhooks", token, WebhookRequest{URL: server.URL, Events: []string{"plan.created"}}); w.Code != http.StatusBadRequest {
+				t.Errorf("create with unknown event status = %d, want 400", w.Code)
+			}
+			if w := s.do(t, "POST", "/api/v1/webhooks", token, WebhookRequest{URL: "ftp://erp.example.com/hooks", Events: []string{webhooks.PlanOptimized}}); w.Code != http.StatusBadRequest {
+				t.Errorf("create with an ftp URL status = %d, want 400", w.Code)
+			}
+		}},
+		{"create", func(t *testing.T) {
+			// The secret is generated and shown once
+			w := s.do(t, "POST", "/api/v1/webhooks", token, WebhookRequest{
+				URL:    server.URL,
+				Events: []string{webhooks.PlanOptimized, webhooks.PlanOptimized, webhooks.ExecutionCompleted},
+			})
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create status = %d: %s", w.Code, w.Body.String())
+			}
+			var created struct{ Data CreatedWebhook }
+			json.Unmarshal(w.Body.Bytes(), &created)
+			if len(created.Data.Secret) != 64 || !created.Data.Active || len(created.Data.Events) != 2 {
+				t.Errorf("created = %+v, want an active subscription to two events with a generated secret", created.Data)
+			}
+			path = fmt.Sprintf("/api/v1/webhooks/%d", created.Data.ID)
+			w = s.do(t, "GET", path, token, nil)
+			if w.Code != http.StatusOK || strings.Contains(w.Body.String(), created.Data.Secret) {
+				t.Errorf("get status = %d with body %s, want the subscription without its secret", w.Code, w.Body.String())
+			}
+		}},
+		{"ping with the wrong secret", func(t *testing.T) {
+			// A ping with the wrong secret is recorded as failed
+			w := s.do(t, "POST", path+"/ping", token, nil)
+			var ping struct{ Data models.WebhookDelivery }
+			json.Unmarshal(w.Body.Bytes(), &ping)
+			if w.Code != http.StatusOK || ping.Data.Status != models.WebhookFailed || ping.Data.ResponseStatus == nil || *ping.Data.ResponseStatus != http.StatusUnauthorized {
+				t.Errorf("ping status = %d with delivery %+v, want a failed delivery answered 401", w.Code, ping.Data)
+			}
+		}},
+		{"update", func(t *testing.T) {
+			// Keeping the secret when none is given
+			w := s.do(t, "PUT", path, token, WebhookRequest{URL: server.URL, Events: []string{webhooks.PlanOptimized}, Secret: "a-sixteen-char-secret"})
+			if w.Code != http.StatusOK {
+				t.Fatalf("update status = %d: %s", w.Code, w.Body.String())
+			}
+			w = s.do(t, "PUT", path, token, WebhookRequest{URL: server.URL, Description: "ERP", Events: []string{webhooks.PlanOptimized}})
+			if w.Code != http.StatusOK {
+				t.Fatalf("update status = %d: %s", w.Code, w.Body.String())
+			}
+			w = s.do(t, "POST", path+"/ping", token, nil)
+			var ping struct{ Data models.WebhookDelivery }
+			json.Unmarshal(w.Body.Bytes(), &ping)
+			if ping.Data.Status != models.WebhookSucceeded || ping.Data.Attempts != 1 {
+				t.Errorf("ping after update = %+v, want succeeded with the updated secret", ping.Data)
+			}
+		}},
+		{"deliveries", func(t *testing.T) {
+			// Event deliveries are queued and logged
+			if _, err := webhooks.Publish(s.db, webhooks.PlanOptimized, webhooks.PlanOptimizedData{PlanID: 1}, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+			w := s.do(t, "GET", path+"/deliveries?status=pending,failed", token, nil)
+			var log struct {
+				Data       []models.WebhookDelivery
+				Pagination models.Pagination
+			}
+			json.Unmarshal(w.Body.Bytes(), &log)
+			if w.Code != http.StatusOK || log.Pagination.Total != 2 || log.Data[0].Event != webhooks.PlanOptimized {
+				t.Fatalf("deliveries status = %d with %+v, want the pending event and the failed ping, newest first", w.Code, log)
+			}
+			if w := s.do(t, "GET", path+"/deliveries?status=lost", token, nil); w.Code != http.StatusBadRequest {
+				t.Errorf("deliveries with unknown status = %d, want 400", w.Code)
+			}
+			failed = log.Data[1]
+		}},
+		{"redeliver", func(t *testing.T) {
+			w := s.do(t, "POST", fmt.Sprintf("%s/deliveries/%d/redeliver", path, failed.ID), token, nil)
+			var redelivery struct{ Data models.WebhookDelivery }
+			json.Unmarshal(w.Body.Bytes(), &redelivery)
+			if w.Code != http.StatusCreated || redelivery.Data.RedeliveryOf == nil || *redelivery.Data.RedeliveryOf != failed.ID || redelivery.Data.Payload != failed.Payload {
+				t.Errorf("redeliver status = %d with %+v, want a new delivery of the same payload", w.Code, redelivery.Data)
+			}
+			if jobs, _ := database.ListJobs(s.db, "", webhooks.JobType, 10); len(jobs) != 2 {
+				t.Errorf("queued %d deliver jobs, want the event and the redelivery", len(jobs))
+			}
+		}},
+		{"deliveries of another subscription", func(t *testing.T) {
+			// Deliveries belong to their subscription
+			w := s.do(t, "POST", "/api/v1/webhooks", token, WebhookRequest{URL: server.URL, Events: []string{webhooks.InventoryLowStock}})
+			var created struct{ Data CreatedWebhook }
+			json.Unmarshal(w.Body.Bytes(), &created)
+			if w := s.do(t, "GET", fmt.Sprintf("/api/v1/webhooks/%d/deliveries/%d", created.Data.ID, failed.ID), token, nil); w.Code != http.StatusNotFound {
+				t.Errorf("delivery of another subscription status = %d, want 404", w.Code)
+			}
+		}},
+		{"delete", func(t *testing.T) {
+			if w := s.do(t, "DELETE", path, token, nil); w.Code != http.StatusOK {
+				t.Fatalf("delete status = %d: %s", w.Code, w.Body.String())
+			}
+			if w := s.do(t, "GET", path, token, nil); w.Code != http.StatusNotFound {
+				t.Errorf("get deleted status = %d, want 404", w.Code)
+			}
+			if len(signatures) != 2 {
+				t.Errorf("endpoint received %d posts, want the two pings", len(signatures))
+			}
+		}},
+	}
+	for _, step := range steps {
+		if !t.Run(step.name, step.run) {
+			return
+		}
+	}
+}
+
+// TestWebhookPrivateAddresses tests that subscriptions to loopback and
+// private addresses are refused unless allowed
+func TestWebhookPrivateAddresses(t *testing.T) {
+	s := newTestServer(t)
+	s.api.POST("/webhooks", s.h.CreateWebhook)
+	s.api.PUT("/webhooks/:id", s.h.UpdateWebhook)
+	token := s.login(t, "admin")
+
+	for _, url := range []string{"http://127.0.0.1:8080/hooks", "http://localhost/hooks", "http://169.254.169.254/latest/meta-data", "http://[::1]/hooks"} {
+		if w := s.do(t, "POST", "/api/v1/webhooks", token, WebhookRequest{URL: url, Events: []string{webhooks.PlanOptimized}}); w.Code != http.StatusBadRequest {
+			t.Errorf("create with %s status = %d, want 400", url, w.Code)
+		}
+	}
+
+	w := s.do(t, "POST", "/api/v1/webhooks", token, WebhookRequest{URL: "https://erp.example.com/hooks", Events: []string{webhooks.PlanOptimized}})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create with a public host status = %d: %s", w.Code, w.Body.String())
+	}
+	var created struct {
+		Data CreatedWebhook
+	}
+	json.Unmarshal(w.Body.Bytes(), &created)
+	path := fmt.Sprintf("/api/v1/webhooks/%d", created.Data.ID)
+	if w := s.do(t, "PUT", path, token, WebhookRequest{URL: "http://10.0.0.5/hooks", Events: []string{webhooks.PlanOptimized}}); w.Code != http.StatusBadRequest {
+		t.Errorf("update to a private address status = %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,270 @@
+// Package hos checks the shifts drivers recorded on route executions
+// against hours-of-service rules: driving per day and per ISO week, and
+// driving without a long enough break. Driving is the working time of a
+// shift, less its breaks, spent away from stops.
+package hos
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// Rules
+const (
+	DailyDriving      = "daily_driving"
+	WeeklyDriving     = "weekly_driving"
+	ContinuousDriving = "continuous_driving"
+)
+
+// Rules are the limits shifts are checked against; 0 disables a limit
+type Rules struct {
+	MaxDailyDriving   time.Duration
+	MaxWeeklyDriving  time.Duration
+	BreakAfterDriving time.Duration // driving allowed before a break of at least BreakDuration
+	BreakDuration     time.Duration
+}
+
+// Span is a period of time within a shift
+type Span struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Shift is a driver's working time on one route execution. Stops are the
+// spans spent at stops, which are work but not driving.
+type Shift struct {
+	ExecutionID int64
+	DriverID    int64
+	Start       time.Time
+	End         time.Time
+	Breaks      []Span
+	Stops       []Span
+}
+
+// FromExecution returns the shift of a route execution: its recorded shift,
+// else its actual start and end, running until now while in progress.
+// Executions without a driver or a start have none.
+func FromExecution(e *models.RouteExecution, now time.Time) (Shift, bool) {
+	if e.Route == nil || e.Route.DriverID == nil {
+		return Shift{}, false
+	}
+	start, end := e.ShiftStart, e.ShiftEnd
+	if start == nil {
+		start = e.ActualStartTime
+	}
+	if end == nil {
+		end = e.ActualEndTime
+	}
+	if start == nil {
+		return Shift{}, false
+	}
+	shift := Shift{ExecutionID: e.ID, DriverID: *e.Route.DriverID, Start: *start, End: now}
+	if end != nil {
+		shift.End = *end
+	} else if e.Status != "in_progress" {
+		return Shift{}, false
+	}
+	if shift.End.Before(shift.Start) {
+		return Shift{}, false
+	}
+	for _, b := range e.Breaks {
+		shift.Breaks = append(shift.Breaks, Span{Start: b.Start, End: b.End})
+	}
+	for _, s := range e.StopExecutions {
+		if s.ActualArrivalTime != nil && s.ActualDepartureTime != nil {
+			shift.Stops = append(shift.Stops, Span{Start: *s.ActualArrivalTime, End: *s.ActualDepartureTime})
+		}
+	}
+	return shift, true
+}
+
+// Working is the shift's length less its breaks
+func (s Shift) Working() time.Duration {
+	return s.Within(s.Start, s.End) - s.BreakTime()
+}
+
+// BreakTime is the time taken in breaks
+func (s Shift) BreakTime() time.Duration {
+	var total time.Duration
+	for _, b := range s.Breaks {
+		total += overlap(b.Start, b.End, s.Start, s.End)
+	}
+	return total
+}
+
+// Driving is the working time spent away from stops
+func (s Shift) Driving() time.Duration {
+	return s.drivingBetween(s.Start, s.End, s.Breaks)
+}
+
+// Within is how much of the shift falls between from and to
+func (s Shift) Within(from, to time.Time) time.Duration {
+	return overlap(s.Start, s.End, from, to)
+}
+
+// drivingBetween is the driving between from and to, not counting breaks
+// and stops
+func (s Shift) drivingBetween(from, to time.Time, breaks []Span) time.Duration {
+	driving := s.Within(from, to)
+	for _, b := range breaks {
+		driving -= overlap(b.Start, b.End, from, to)
+	}
+	for _, stop := range s.Stops {
+		driving -= overlap(stop.Start, stop.End, from, to)
+	}
+	if driving < 0 {
+		return 0
+	}
+	return driving
+}
+
+// longestStint is the most driving done between two breaks of at least
+// minBreak; shorter breaks pause the driving without ending the stint
+func (s Shift) longestStint(minBreak time.Duration) time.Duration {
+	var short []Span
+	var rests []Span
+	for _, b := range s.Breaks {
+		if b.End.Sub(b.Start) >= minBreak {
+			rests = append(rests, b)
+		} else {
+			short = append(short, b)
+		}
+	}
+	sort.Slice(rests, func(i, j int) bool { return rests[i].Start.Before(rests[j].Start) })
+
+	var longest time.Duration
+	from := s.Start
+	for _, r := range rests {
+		if d := s.drivingBetween(from, r.Start, short); d > longest {
+			longest = d
+		}
+		if r.End.After(from) {
+			from = r.End
+		}
+	}
+	if d := s.drivingBetween(from, s.End, short); d > longest {
+		longest = d
+	}
+	return longest
+}
+
+// Check sums shifts by driver and day, dated by their start, and returns
+// the days in driver and date order with the rules they breach
+func Check(rules Rules, shifts []Shift) ([]models.DriverDay, []models.HoursViolation) {
+	sort.SliceStable(shifts, func(i, j int) bool {
+		if shifts[i].DriverID != shifts[j].DriverID {
+			return shifts[i].DriverID < shifts[j].DriverID
+		}
+		return shifts[i].Start.Before(shifts[j].Start)
+	})
+
+	type key struct {
+		driverID int64
+		period   string
+	}
+	days := []models.DriverDay{}
+	dayIndex := make(map[key]int)
+	weeks := make(map[key]time.Duration)
+	var weekOrder []key
+	violations := []models.HoursViolation{}
+
+	for _, s := range shifts {
+		working, driving := s.Working(), s.Driving()
+		date := s.Start.Format("2006-01-02")
+		k := key{s.DriverID, date}
+		i, ok := dayIndex[k]
+		if !ok {
+			i = len(days)
+			dayIndex[k] = i
+			days = append(days, models.DriverDay{DriverID: s.DriverID, Date: date, ExecutionIDs: []int64{}})
+		}
+		day := &days[i]
+		day.ExecutionIDs = append(day.ExecutionIDs, s.ExecutionID)
+		day.WorkingMinutes += minutes(working)
+		day.DrivingMinutes += minutes(driving)
+		day.BreakMinutes += minutes(s.BreakTime())
+
+		year, week := s.Start.ISOWeek()
+		wk := key{s.DriverID, fmt.Sprintf("%d-W%02d", year, week)}
+		if _, ok := weeks[wk]; !ok {
+			weekOrder = append(weekOrder, wk)
+		}
+		weeks[wk] += driving
+
+		if rules.BreakAfterDriving > 0 && rules.BreakDuration > 0 {
+			if stint := s.longestStint(rules.BreakDuration); stint > rules.BreakAfterDriving {
+				id := s.ExecutionID
+				violations = append(violations, models.HoursViolation{
+					DriverID:      s.DriverID,
+					Rule:          ContinuousDriving,
+					Period:        date,
+					ExecutionID:   &id,
+					LimitMinutes:  minutes(rules.BreakAfterDriving),
+					ActualMinutes: minutes(stint),
+				})
+			}
+		}
+	}
+
+	if rules.MaxDailyDriving > 0 {
+		for _, day := range days {
+			if day.DrivingMinutes > minutes(rules.MaxDailyDriving) {
+				violations = append(violations, models.HoursViolation{
+					DriverID:      day.DriverID,
+					Rule:          DailyDriving,
+					Period:        day.Date,
+					LimitMinutes:  minutes(rules.MaxDailyDriving),
+					ActualMinutes: day.DrivingMinutes,
+				})
+			}
+		}
+	}
+	if rules.MaxWeeklyDriving > 0 {
+		for _, wk := range weekOrder {
+			if weeks[wk] > rules.MaxWeeklyDriving {
+				violations = append(violations, models.HoursViolation{
+					DriverID:      wk.driverID,
+					Rule:          WeeklyDriving,
+					Period:        wk.period,
+					LimitMinutes:  minutes(rules.MaxWeeklyDriving),
+					ActualMinutes: minutes(weeks[wk]),
+				})
+			}
+		}
+	}
+
+	sort.SliceStable(violations, func(i, j int) bool {
+		if violations[i].DriverID != violations[j].DriverID {
+			return violations[i].DriverID < violations[j].DriverID
+		}
+		return violations[i].Period < violations[j].Period
+	})
+	return days, violations
+}
+
+// WeekStart returns the Monday starting the ISO week of t
+func WeekStart(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+}
+
+func overlap(aStart, aEnd, bStart, bEnd time.Time) time.Duration {
+	start, end := aStart, aEnd
+	if bStart.After(start) {
+		start = bStart
+	}
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start)
+}
+
+func minutes(d time.Duration) int {
+	return int(d.Round(time.Minute) / time.Minute)
+}
@@ -0,0 +1,101 @@
+package hos
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+var rules = Rules{
+	MaxDailyDriving:   9 * time.Hour,
+	MaxWeeklyDriving:  56 * time.Hour,
+	BreakAfterDriving: 270 * time.Minute,
+	BreakDuration:     45 * time.Minute,
+}
+
+// at returns the time on 4 March 2024 (a Monday) plus days
+func at(days, hour, minute int) time.Time {
+	return time.Date(2024, 3, 4+days, hour, minute, 0, 0, time.UTC)
+}
+
+// TestShiftTimes tests working and driving time leave out breaks and stops
+func TestShiftTimes(t *testing.T) {
+	s := Shift{
+		Start:  at(0, 8, 0),
+		End:    at(0, 17, 0),
+		Breaks: []Span{{at(0, 12, 0), at(0, 12, 45)}},
+		Stops:  []Span{{at(0, 10, 0), at(0, 10, 30)}, {at(0, 14, 0), at(0, 14, 30)}},
+	}
+	if got := s.Working(); got != 8*time.Hour+15*time.Minute {
+		t.Errorf("Working() = %v, want 8h15m", got)
+	}
+	if got := s.Driving(); got != 7*time.Hour+15*time.Minute {
+		t.Errorf("Driving() = %v, want 7h15m", got)
+	}
+	// 3h30m before the break less a stop, 4h15m after it less a stop
+	if got := s.longestStint(rules.BreakDuration); got != 3*time.Hour+45*time.Minute {
+		t.Errorf("longestStint() = %v, want 3h45m", got)
+	}
+}
+
+// TestCheck tests daily, weekly and continuous driving limits
+func TestCheck(t *testing.T) {
+	var shifts []Shift
+	// six days of 10 hours with a 45 minute break after 4 hours
+	for day := 0; day < 6; day++ {
+		shifts = append(shifts, Shift{
+			ExecutionID: int64(day + 1),
+			DriverID:    1,
+			Start:       at(day, 6, 0),
+			End:         at(day, 16, 45),
+			Breaks:      []Span{{at(day, 10, 0), at(day, 10, 45)}},
+		})
+	}
+	// another driver drives 5 hours without a break
+	shifts = append(shifts, Shift{ExecutionID: 7, DriverID: 2, Start: at(0, 8, 0), End: at(0, 13, 0)})
+
+	days, violations := Check(rules, shifts)
+	if len(days) != 7 || days[0].DrivingMinutes != 600 || days[0].BreakMinutes != 45 || days[6].DriverID != 2 {
+		t.Fatalf("days = %+v, want 6 days of 600 minutes for driver 1 then driver 2", days)
+	}
+
+	counts := make(map[string]int)
+	for _, v := range violations {
+		counts[v.Rule]++
+	}
+	// 6 days of 10 hours break the daily limit each day, 60 hours the
+	// weekly limit, and 6h45m after the break the continuous limit
+	if counts[DailyDriving] != 6 || counts[WeeklyDriving] != 1 || counts[ContinuousDriving] != 7 {
+		t.Errorf("violations = %+v, want 6 daily, 1 weekly and 7 continuous", violations)
+	}
+	last := violations[len(violations)-1]
+	if last.DriverID != 2 || last.Rule != ContinuousDriving || *last.ExecutionID != 7 || last.ActualMinutes != 300 {
+		t.Errorf("last violation = %+v, want driver 2 driving 300 minutes without a break", last)
+	}
+}
+
+// TestFromExecution tests the recorded shift is preferred over the actual
+// start and end, and open shifts run until now while in progress
+func TestFromExecution(t *testing.T) {
+	driverID := int64(3)
+	start, end, shiftStart := at(0, 8, 0), at(0, 16, 0), at(0, 7, 30)
+	e := &models.RouteExecution{
+		ID: 1, Status: "in_progress", Route: &models.Route{DriverID: &driverID},
+		ActualStartTime: &start, ShiftStart: &shiftStart,
+	}
+	now := at(0, 12, 0)
+	s, ok := FromExecution(e, now)
+	if !ok || !s.Start.Equal(shiftStart) || !s.End.Equal(now) || s.DriverID != driverID {
+		t.Errorf("FromExecution() = %+v, %v, want from the shift start until now", s, ok)
+	}
+
+	e.Status, e.ActualEndTime = "completed", &end
+	if s, _ := FromExecution(e, now); !s.End.Equal(end) {
+		t.Errorf("completed shift ends %v, want %v", s.End, end)
+	}
+	e.Route.DriverID = nil
+	if _, ok := FromExecution(e, now); ok {
+		t.Error("FromExecution() without a driver = ok, want none")
+	}
+}
@@ -0,0 +1,268 @@
+// Package jobs runs background work from a database-backed queue. Jobs are
+// retried with exponential backoff and parked as dead once their attempts
+// are used up; periodic jobs replace per-feature ticker loops. Several
+// backend instances can share one queue because jobs are claimed with a
+// conditional update.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"runtime/debug"
+	"sort"
+	"time"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Job statuses
+const (
+	Pending   = "pending"
+	Running   = "running"
+	Succeeded = "succeeded"
+	Dead      = "dead"
+	Cancelled = "cancelled"
+)
+
+const (
+	// DefaultMaxAttempts applies when a job is enqueued without a limit
+	DefaultMaxAttempts = 5
+	// DefaultTimeout bounds a single attempt
+	DefaultTimeout = 10 * time.Minute
+
+	baseBackoff = 30 * time.Second
+	maxBackoff  = time.Hour
+)
+
+// Func executes a job. A returned error fails the attempt.
+type Func func(ctx context.Context, payload json.RawMessage) error
+
+// Options adjust an enqueued job. Zero values use the defaults.
+type Options struct {
+	RunAt       time.Time
+	MaxAttempts int
+}
+
+// Enqueue stores a job of jobType with payload marshalled to JSON
+func Enqueue(db *gorm.DB, jobType string, payload interface{}, opts Options) (*models.Job, error) {
+	body := []byte("null")
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return nil, fmt.Errorf("marshal payload: %w", err)
+		}
+	}
+	if opts.RunAt.IsZero() {
+		opts.RunAt = clock.Real.Now()
+	}
+	if opts.MaxAttempts < 1 {
+		opts.MaxAttempts = DefaultMaxAttempts
+	}
+
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     string(body),
+		Status:      Pending,
+		MaxAttempts: opts.MaxAttempts,
+		RunAt:       opts.RunAt,
+	}
+	if err := database.CreateJob(db, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Backoff returns the delay before retrying after a failed attempt:
+// 30s, 1m, 2m, ... capped at an hour
+func Backoff(attempt int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay
+}
+
+// Retryable reports whether a job can be queued again by hand
+func Retryable(status string) bool {
+	return status == Dead || status == Cancelled
+}
+
+type periodic struct {
+	jobType  string
+	interval time.Duration
+	schedule func(now time.Time) time.Time
+	next     time.Time
+}
+
+// Runner claims and executes due jobs
+type Runner struct {
+	db       *gorm.DB
+	interval time.Duration
+	timeout  time.Duration
+	worker   string
+	funcs    map[string]Func
+	periodic []*periodic
+	clock    clock.Clock
+	paused   func() bool
+}
+
+// NewRunner creates a runner polling the queue every interval
+func NewRunner(db *gorm.DB, interval time.Duration) *Runner {
+	host, _ := os.Hostname()
+	return &Runner{
+		db:       db,
+		interval: interval,
+		timeout:  DefaultTimeout,
+		worker:   fmt.Sprintf("%s-%d", host, os.Getpid()),
+		funcs:    make(map[string]Func),
+		clock:    clock.Real,
+	}
+}
+
+// SetClock replaces the clock the runner reads the current time from
+func (r *Runner) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// Handle registers the function executing jobs of jobType
+func (r *Runner) Handle(jobType string, fn Func) {
+	r.funcs[jobType] = fn
+}
+
+// Every enqueues a jobType job when the runner starts and then every
+// interval, unless one is still pending or running
+func (r *Runner) Every(jobType string, interval time.Duration) {
+	r.periodic = append(r.periodic, &periodic{jobType: jobType, interval: interval})
+}
+
+// Schedule enqueues a jobType job when the runner starts and then at each
+// time next returns after a run, unless one is still pending or running.
+// Unlike Every it follows the clock, e.g. once a day at a time of day.
+func (r *Runner) Schedule(jobType string, next func(now time.Time) time.Time) {
+	r.periodic = append(r.periodic, &periodic{jobType: jobType, schedule: next})
+}
+
+// PauseWhen makes Run skip polling while paused returns true, such as
+// during database maintenance
+func (r *Runner) PauseWhen(paused func() bool) {
+	r.paused = paused
+}
+
+// Run processes jobs immediately and then every interval until ctx is done
+func (r *Runner) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if r.paused == nil || !r.paused() {
+			if _, err := r.RunOnce(ctx, r.clock.Now()); err != nil {
+				log.Printf("Job runner: %v", err)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce enqueues due periodic jobs, requeues jobs of workers that stopped
+// responding and runs due jobs until none is left. It returns the number of
+// jobs executed.
+func (r *Runner) RunOnce(ctx context.Context, now time.Time) (int, error) {
+	var errs []error
+	for _, p := range r.periodic {
+		if now.Before(p.next) {
+			continue
+		}
+		open, err := database.HasOpenJob(r.db, p.jobType)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %s: %w", p.jobType, err))
+			continue
+		}
+		if !open {
+			if _, err := Enqueue(r.db, p.jobType, nil, Options{RunAt: now}); err != nil {
+				errs = append(errs, fmt.Errorf("schedule %s: %w", p.jobType, err))
+				continue
+			}
+		}
+		if p.schedule != nil {
+			p.next = p.schedule(now)
+		} else {
+			p.next = now.Add(p.interval)
+		}
+	}
+
+	if n, err := database.RequeueStaleJobs(r.db, now.Add(-2*r.timeout)); err != nil {
+		errs = append(errs, fmt.Errorf("requeue stale jobs: %w", err))
+	} else if n > 0 {
+		log.Printf("Job runner: requeued %d stale jobs", n)
+	}
+
+	types := make([]string, 0, len(r.funcs))
+	for jobType := range r.funcs {
+		types = append(types, jobType)
+	}
+	sort.Strings(types)
+
+	executed := 0
+	for ctx.Err() == nil {
+		job, err := database.ClaimNextJob(r.db, types, r.worker, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("claim job: %w", err))
+			break
+		}
+		if job == nil {
+			break
+		}
+		if err := r.execute(ctx, job, now); err != nil {
+			errs = append(errs, err)
+		}
+		executed++
+	}
+	return executed, errors.Join(errs...)
+}
+
+// execute runs a claimed job and records its outcome
+func (r *Runner) execute(ctx context.Context, job *models.Job, now time.Time) error {
+	jobCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	err := call(jobCtx, r.funcs[job.Type], json.RawMessage(job.Payload))
+	if err == nil {
+		return database.FinishJob(r.db, job.ID, "", nil, r.clock.Now())
+	}
+
+	var retryAt *time.Time
+	if job.Attempts < job.MaxAttempts {
+		at := now.Add(Backoff(job.Attempts))
+		retryAt = &at
+		log.Printf("Job runner: %s job %d attempt %d failed, retrying at %s: %v", job.Type, job.ID, job.Attempts, at.Format(time.RFC3339), err)
+	} else {
+		log.Printf("Job runner: %s job %d failed after %d attempts: %v", job.Type, job.ID, job.Attempts, err)
+	}
+	return database.FinishJob(r.db, job.ID, err.Error(), retryAt, r.clock.Now())
+}
+
+// call runs fn, turning a panic into an error so one bad job cannot stop the
+// runner
+func call(ctx context.Context, fn Func, payload json.RawMessage) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v\n%s", p, debug.Stack())
+		}
+	}()
+	return fn(ctx, payload)
+}
@@ -0,0 +1,200 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTestDB(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to open test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Job{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return db
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{4, 4 * time.Minute},
+		{20, time.Hour},
+	}
+	for _, tt := range tests {
+		if got := Backoff(tt.attempt); got != tt.want {
+			t.Errorf("Backoff(%d) = %v, want %v", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// TestRunnerRetriesAndDeadLetters tests that failing jobs are retried with
+// backoff and parked as dead once their attempts are used up
+func TestRunnerRetriesAndDeadLetters(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	var got []string
+	r := NewRunner(db, time.Minute)
+	r.Handle("echo", func(ctx context.Context, payload json.RawMessage) error {
+		var p struct{ Message string }
+		json.Unmarshal(payload, &p)
+		got = append(got, p.Message)
+		return nil
+	})
+	r.Handle("flaky", func(ctx context.Context, payload json.RawMessage) error {
+		return errors.New("upstream unavailable")
+	})
+	r.Handle("panics", func(ctx context.Context, payload json.RawMessage) error {
+		panic("boom")
+	})
+
+	echo, _ := Enqueue(db, "echo", map[string]string{"message": "hello"}, Options{RunAt: now})
+	flaky, _ := Enqueue(db, "flaky", nil, Options{RunAt: now, MaxAttempts: 2})
+	panics, _ := Enqueue(db, "panics", nil, Options{RunAt: now, MaxAttempts: 1})
+	later, _ := Enqueue(db, "echo", map[string]string{"message": "later"}, Options{RunAt: now.Add(time.Hour)})
+	unknown, _ := Enqueue(db, "unknown", nil, Options{RunAt: now})
+
+	n, err := r.RunOnce(ctx, now)
+	if err != nil || n != 3 {
+		t.Fatalf("RunOnce() = %d, %v, want 3 jobs", n, err)
+	}
+	if len(got) != 1 || got[0] != "hello" {
+		t.Errorf("echo received %v, want [hello]", got)
+	}
+
+	status := func(id int64) *models.Job {
+		job, err := database.GetJob(db, id)
+		if err != nil {
+			t.Fatalf("GetJob(%d) error = %v", id, err)
+		}
+		return job
+	}
+	if job := status(echo.ID); job.Status != Succeeded || job.Attempts != 1 || job.FinishedAt == nil {
+		t.Errorf("echo job = %+v, want succeeded after 1 attempt", job)
+	}
+	job := status(flaky.ID)
+	if job.Status != Pending || job.Attempts != 1 || !job.RunAt.Equal(now.Add(30*time.Second)) || job.LastError != "upstream unavailable" {
+		t.Errorf("flaky job = %+v, want pending retry in 30s", job)
+	}
+	if job := status(panics.ID); job.Status != Dead {
+		t.Errorf("panicking job status = %s, want dead", job.Status)
+	}
+	if job := status(later.ID); job.Status != Pending || job.Attempts != 0 {
+		t.Errorf("future job = %+v, want untouched", job)
+	}
+	if job := status(unknown.ID); job.Status != Pending {
+		t.Errorf("job without a handler status = %s, want pending", job.Status)
+	}
+
+	// The retry is not due yet; after the backoff it fails for good
+	if n, _ := r.RunOnce(ctx, now.Add(10*time.Second)); n != 0 {
+		t.Errorf("RunOnce() before the backoff ran %d jobs, want 0", n)
+	}
+	r.RunOnce(ctx, now.Add(time.Minute))
+	if job := status(flaky.ID); job.Status != Dead || job.Attempts != 2 {
+		t.Errorf("flaky job = %+v, want dead after 2 attempts", job)
+	}
+
+	dead, _ := database.ListJobs(db, Dead, "", 10)
+	if len(dead) != 2 {
+		t.Errorf("dead-letter queue has %d jobs, want 2", len(dead))
+	}
+
+	if err := database.RetryJob(db, flaky.ID, now); err != nil {
+		t.Fatalf("RetryJob() error = %v", err)
+	}
+	if job := status(flaky.ID); job.Status != Pending || job.Attempts != 0 {
+		t.Errorf("retried job = %+v, want pending with no attempts", job)
+	}
+	if err := database.CancelJob(db, flaky.ID, now); err != nil {
+		t.Fatalf("CancelJob() error = %v", err)
+	}
+	if err := database.CancelJob(db, echo.ID, now); !errors.Is(err, database.ErrNotFound) {
+		t.Errorf("CancelJob() on a succeeded job error = %v, want ErrNotFound", err)
+	}
+}
+
+// TestRunnerPeriodic tests that periodic jobs are enqueued once per interval
+// and stale running jobs are requeued
+func TestRunnerPeriodic(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	runs := 0
+	r := NewRunner(db, time.Minute)
+	r.Handle("tick", func(ctx context.Context, payload json.RawMessage) error {
+		runs++
+		return nil
+	})
+	r.Every("tick", time.Hour)
+
+	r.RunOnce(ctx, now)
+	r.RunOnce(ctx, now.Add(30*time.Minute))
+	r.RunOnce(ctx, now.Add(time.Hour))
+	if runs != 2 {
+		t.Errorf("periodic job ran %d times in an hour, want 2", runs)
+	}
+
+	// A job claimed by a worker that crashed is picked up again
+	stale, _ := Enqueue(db, "tick", nil, Options{RunAt: now})
+	database.ClaimNextJob(db, []string{"tick"}, "crashed-worker", now)
+	r.RunOnce(ctx, now.Add(5*time.Minute))
+	if job, _ := database.GetJob(db, stale.ID); job.Status != Running {
+		t.Errorf("recently claimed job status = %s, want running", job.Status)
+	}
+	r.RunOnce(ctx, now.Add(time.Hour+30*time.Minute))
+	if job, _ := database.GetJob(db, stale.ID); job.Status != Succeeded || job.Attempts != 2 {
+		t.Errorf("stale job = %+v, want succeeded on the second attempt", job)
+	}
+}
+
+// TestRunnerSchedule tests that scheduled jobs run at start and then at the
+// times the schedule returns
+func TestRunnerSchedule(t *testing.T) {
+	db := setupTestDB(t)
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	runs := 0
+	r := NewRunner(db, time.Minute)
+	r.Handle("daily", func(ctx context.Context, payload json.RawMessage) error {
+		runs++
+		return nil
+	})
+	// every day at 18:00
+	r.Schedule("daily", func(now time.Time) time.Time {
+		at := now.Truncate(24 * time.Hour).Add(18 * time.Hour)
+		if !at.After(now) {
+			at = at.AddDate(0, 0, 1)
+		}
+		return at
+	})
+
+	r.RunOnce(ctx, now)
+	r.RunOnce(ctx, now.Add(8*time.Hour))
+	if runs != 1 {
+		t.Errorf("scheduled job ran %d times before 18:00, want 1", runs)
+	}
+	r.RunOnce(ctx, now.Add(9*time.Hour))
+	r.RunOnce(ctx, now.Add(10*time.Hour))
+	if runs != 2 {
+		t.Errorf("scheduled job ran %d times by 19:00, want 2", runs)
+	}
+}
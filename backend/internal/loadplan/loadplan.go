@@ -0,0 +1,120 @@
+// Package loadplan sequences a route's cargo for loading and checks the
+// weight on each axle, so routes that cannot legally be loaded are caught
+// before dispatch.
+//
+// The model is deliberately simple. The load bed runs from the front
+// (position 0, over the front axle) to the rear door (position 1, over the
+// rear axle). Cargo is loaded last drop first (LIFO), so the first drop is
+// at the door. Each stop's cargo takes a share of the bed length equal to
+// its share of the vehicle capacity and its weight is split between the
+// axles by the position of its centre. Axle loads are checked when leaving
+// the warehouse and after every drop.
+package loadplan
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Item is the cargo for one stop
+type Item struct {
+	StopID   int64
+	Sequence int
+	Quantity float64
+	Weight   float64 // kg
+}
+
+// Limits are a vehicle's payload limits in kg; zero leaves a limit unchecked
+type Limits struct {
+	Capacity     float64 // in quantity units, sizes the bed
+	MaxPayload   float64
+	MaxFrontAxle float64
+	MaxRearAxle  float64
+}
+
+// Placement is an item's place in the load order and on the bed
+type Placement struct {
+	Item
+	LoadOrder int     // 1 is loaded first
+	From      float64 // bed position of the front of the cargo
+	To        float64 // bed position of the back of the cargo
+}
+
+// Leg is the payload on the axles while driving to the next stop
+type Leg struct {
+	AfterSequence int // 0 when leaving the warehouse
+	Payload       float64
+	FrontAxle     float64
+	RearAxle      float64
+}
+
+// Result is the load plan of a route
+type Result struct {
+	Placements []Placement // in load order
+	Legs       []Leg
+	Violations []string
+}
+
+// tolerance absorbs floating point noise in limit checks
+const tolerance = 1e-6
+
+// Plan sequences items by their stop sequence and checks them against limits
+func Plan(items []Item, limits Limits) Result {
+	sorted := append([]Item(nil), items...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Sequence > sorted[j].Sequence })
+
+	bed := limits.Capacity
+	total := 0.0
+	for _, item := range sorted {
+		total += item.Quantity
+	}
+	if bed < total {
+		bed = total
+	}
+
+	var result Result
+	position := 0.0
+	for i, item := range sorted {
+		p := Placement{Item: item, LoadOrder: i + 1, From: position}
+		if bed > 0 {
+			position += item.Quantity / bed
+		}
+		p.To = position
+		result.Placements = append(result.Placements, p)
+	}
+
+	// Drops happen in reverse load order; the drive back is empty
+	for drop := 0; drop == 0 || drop < len(sorted); drop++ {
+		leg := Leg{}
+		if drop > 0 {
+			leg.AfterSequence = result.Placements[len(sorted)-drop].Sequence
+		}
+		for _, p := range result.Placements[:len(sorted)-drop] {
+			centre := (p.From + p.To) / 2
+			leg.Payload += p.Weight
+			leg.RearAxle += p.Weight * centre
+			leg.FrontAxle += p.Weight * (1 - centre)
+		}
+		result.Legs = append(result.Legs, leg)
+		result.Violations = append(result.Violations, checkLeg(leg, limits)...)
+	}
+	return result
+}
+
+func checkLeg(leg Leg, limits Limits) []string {
+	where := "leaving the warehouse"
+	if leg.AfterSequence > 0 {
+		where = fmt.Sprintf("after stop %d", leg.AfterSequence)
+	}
+	var violations []string
+	if limits.MaxPayload > 0 && leg.Payload > limits.MaxPayload+tolerance {
+		violations = append(violations, fmt.Sprintf("payload %.0f kg %s exceeds the limit of %.0f kg", leg.Payload, where, limits.MaxPayload))
+	}
+	if limits.MaxFrontAxle > 0 && leg.FrontAxle > limits.MaxFrontAxle+tolerance {
+		violations = append(violations, fmt.Sprintf("front axle carries %.0f kg %s, limit %.0f kg", leg.FrontAxle, where, limits.MaxFrontAxle))
+	}
+	if limits.MaxRearAxle > 0 && leg.RearAxle > limits.MaxRearAxle+tolerance {
+		violations = append(violations, fmt.Sprintf("rear axle carries %.0f kg %s, limit %.0f kg", leg.RearAxle, where, limits.MaxRearAxle))
+	}
+	return violations
+}
@@ -0,0 +1,75 @@
+package loadplan
+
+import (
+	"math"
+	"testing"
+)
+
+// TestPlan tests LIFO load order, bed positions and axle loads per leg
+func TestPlan(t *testing.T) {
+	items := []Item{
+		{StopID: 1, Sequence: 1, Quantity: 25, Weight: 1000},
+		{StopID: 2, Sequence: 2, Quantity: 25, Weight: 3000},
+		{StopID: 3, Sequence: 3, Quantity: 50, Weight: 2000},
+	}
+	result := Plan(items, Limits{Capacity: 100})
+
+	wantOrder := []int64{3, 2, 1}
+	for i, p := range result.Placements {
+		if p.StopID != wantOrder[i] || p.LoadOrder != i+1 {
+			t.Errorf("placement %d = stop %d (order %d), want stop %d", i, p.StopID, p.LoadOrder, wantOrder[i])
+		}
+	}
+	if p := result.Placements[2]; p.From != 0.75 || p.To != 1 {
+		t.Errorf("first drop placed at %.2f-%.2f, want at the door 0.75-1", p.From, p.To)
+	}
+
+	if len(result.Legs) != 3 {
+		t.Fatalf("legs = %+v, want 3", result.Legs)
+	}
+	// Centres: stop 3 at 0.25, stop 2 at 0.625, stop 1 at 0.875
+	departure := result.Legs[0]
+	if departure.Payload != 6000 || !near(departure.RearAxle, 2000*0.25+3000*0.625+1000*0.875) {
+		t.Errorf("departure leg = %+v, want 6000 kg with rear axle 3250", departure)
+	}
+	if last := result.Legs[2]; last.AfterSequence != 2 || last.Payload != 2000 || !near(last.FrontAxle, 1500) {
+		t.Errorf("last leg = %+v, want 2000 kg after stop 2 with front axle 1500", last)
+	}
+	if len(result.Violations) != 0 {
+		t.Errorf("violations = %v, want none without limits", result.Violations)
+	}
+}
+
+// TestPlanViolations tests that payload and axle limits are flagged per leg
+func TestPlanViolations(t *testing.T) {
+	items := []Item{
+		{StopID: 1, Sequence: 1, Quantity: 50, Weight: 4000},
+		{StopID: 2, Sequence: 2, Quantity: 50, Weight: 1000},
+	}
+	result := Plan(items, Limits{Capacity: 100, MaxPayload: 4500, MaxRearAxle: 3000, MaxFrontAxle: 2000})
+
+	want := []string{
+		"payload 5000 kg leaving the warehouse exceeds the limit of 4500 kg",
+		"rear axle carries 3250 kg leaving the warehouse, limit 3000 kg",
+	}
+	if len(result.Violations) != len(want) {
+		t.Fatalf("violations = %q, want %q", result.Violations, want)
+	}
+	for i := range want {
+		if result.Violations[i] != want[i] {
+			t.Errorf("violation %d = %q, want %q", i, result.Violations[i], want[i])
+		}
+	}
+}
+
+// TestPlanEmpty tests a route without cargo
+func TestPlanEmpty(t *testing.T) {
+	result := Plan(nil, Limits{Capacity: 100, MaxPayload: 1})
+	if len(result.Placements) != 0 || len(result.Legs) != 1 || len(result.Violations) != 0 {
+		t.Errorf("Plan(nil) = %+v, want one empty leg", result)
+	}
+}
+
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
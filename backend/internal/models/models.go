@@ -1,18 +1,23 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // User represents a system user
 type User struct {
-	ID        int64     `gorm:"primaryKey" json:"id"`
-	Email     string    `gorm:"uniqueIndex;not null;type:varchar(255)" json:"email"`
-	Password  string    `gorm:"column:password_hash;not null;type:varchar(255)" json:"-"`
-	Name      string    `gorm:"not null;type:varchar(255)" json:"name"`
-	Role      string    `gorm:"type:varchar(50);default:'user'" json:"role"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	Email           string     `gorm:"uniqueIndex;not null;type:varchar(255)" json:"email"`
+	Password        string     `gorm:"column:password_hash;not null;type:varchar(255)" json:"-"`
+	Name            string     `gorm:"not null;type:varchar(255)" json:"name"`
+	Role            string     `gorm:"type:varchar(50);default:'user'" json:"role"` // admin, manager, user, driver
+	OrganizationID  *int64     `gorm:"index;type:integer" json:"organization_id"`   // usage is metered per organization; nil is unmetered
+	TokensRevokedAt *time.Time `gorm:"column:tokens_revoked_at" json:"-"`           // tokens issued before this are rejected
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (User) TableName() string {
@@ -30,8 +35,12 @@ type Warehouse struct {
 	CurrentStock       float64             `gorm:"column:current_stock;type:double precision;default:0" json:"current_stock"`
 	HoldingCost        float64             `gorm:"column:holding_cost;type:double precision;default:0" json:"holding_cost"`
 	ReplenishmentQty   float64             `gorm:"column:replenishment_qty;type:double precision;default:0" json:"replenishment_qty"`
+	MinStock           float64             `gorm:"column:min_stock;type:double precision;default:0" json:"min_stock"` // low-stock alert threshold
+	ExternalRef        string              `gorm:"column:external_ref;type:varchar(100);index" json:"external_ref"`   // the warehouse's key in another system; unique when set
+	Version            int64               `gorm:"not null;default:1" json:"version"`                                 // counts edits, for optimistic locking
 	CreatedAt          time.Time           `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt          time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt          gorm.DeletedAt      `gorm:"index" json:"-"`
 	Vehicles           []Vehicle           `gorm:"foreignKey:WarehouseID" json:"vehicles,omitempty"`
 	Plans              []Plan              `gorm:"foreignKey:WarehouseID" json:"plans,omitempty"`
 	InventorySnapshots []InventorySnapshot `gorm:"foreignKey:EntityID" json:"inventory_snapshots,omitempty"`
@@ -54,11 +63,21 @@ type Customer struct {
 	MinInventory       float64                    `gorm:"column:min_inventory;type:double precision;default:0" json:"min_inventory"`
 	HoldingCost        float64                    `gorm:"column:holding_cost;type:double precision;default:0" json:"holding_cost"`
 	Priority           int                        `gorm:"type:integer;default:1" json:"priority"`
+	MinDropSize        float64                    `gorm:"column:min_drop_size;type:double precision;default:0" json:"min_drop_size"` // smallest worthwhile delivery, 0 = any
+	ProductID          *int64                     `gorm:"index;type:integer" json:"product_id"`                                      // product the inventory is planned in
+	ServiceTags        []string                   `gorm:"column:service_tags;type:text;serializer:json" json:"service_tags"`         // skills a vehicle needs to serve the customer, e.g. reefer
+	OrganizationID     *int64                     `gorm:"index;type:integer" json:"organization_id"`                                 // organization whose customer quota it counts against
+	WarehouseID        *int64                     `gorm:"index;type:integer" json:"warehouse_id"`                                    // warehouse that serves it, if fixed
+	ExternalRef        string                     `gorm:"column:external_ref;type:varchar(100);index" json:"external_ref"`           // the customer's key in another system, e.g. an ERP; unique when set
+	Version            int64                      `gorm:"not null;default:1" json:"version"`                                         // counts edits, for optimistic locking
 	CreatedAt          time.Time                  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt          time.Time                  `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt          gorm.DeletedAt             `gorm:"index" json:"-"`
 	Stops              []Stop                     `gorm:"foreignKey:CustomerID" json:"stops,omitempty"`
 	InventorySnapshots []InventorySnapshot        `gorm:"foreignKey:EntityID" json:"inventory_snapshots,omitempty"`
 	ProductInventory   []CustomerProductInventory `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE" json:"product_inventory,omitempty"`
+	Product            *Product                   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Tags               []Tag                      `gorm:"many2many:customer_tags;constraint:OnDelete:CASCADE" json:"tags,omitempty"`
 }
 
 func (Customer) TableName() string {
@@ -67,22 +86,141 @@ func (Customer) TableName() string {
 
 // Vehicle represents a delivery vehicle
 type Vehicle struct {
+	ID               int64          `gorm:"primaryKey" json:"id"`
+	Name             string         `gorm:"not null;type:varchar(255)" json:"name"`
+	Capacity         float64        `gorm:"not null;type:double precision" json:"capacity"`
+	CostPerKm        float64        `gorm:"column:cost_per_km;type:double precision;default:0" json:"cost_per_km"`
+	FixedCost        float64        `gorm:"column:fixed_cost;type:double precision;default:0" json:"fixed_cost"`
+	MaxDistance      float64        `gorm:"column:max_distance;type:double precision;default:0" json:"max_distance"`
+	RangeKm          float64        `gorm:"column:range_km;type:double precision;default:0" json:"range_km"`                     // distance on a full tank or battery, 0 = unlimited
+	Electric         bool           `gorm:"type:boolean;default:false" json:"electric"`                                          // charges at charging stations instead of refuelling
+	ChargeMinutes    int            `gorm:"column:charge_minutes;default:0" json:"charge_minutes"`                               // minutes to charge a flat battery full
+	ConsumptionPerKm float64        `gorm:"column:consumption_per_km;type:double precision;default:0" json:"consumption_per_km"` // kWh for electric vehicles
+	CO2PerKm         float64        `gorm:"column:co2_per_km;type:double precision;default:0" json:"co2_per_km"`                 // kg; 0 = none for electric vehicles, DEFAULT_CO2_PER_KM for others
+	Available        bool           `gorm:"type:boolean;default:true" json:"available"`
+	MaxWorkingHours  float64        `gorm:"column:max_working_hours;type:double precision;default:0" json:"max_working_hours"`     // 0 = unlimited
+	AverageSpeed     float64        `gorm:"column:average_speed;type:double precision;default:0" json:"average_speed"`             // km/h, 0 = optimizer default
+	ShiftStart       string         `gorm:"column:shift_start;type:varchar(5)" json:"shift_start"`                                 // HH:MM
+	ShiftEnd         string         `gorm:"column:shift_end;type:varchar(5)" json:"shift_end"`                                     // HH:MM
+	AllowedTags      []string       `gorm:"column:allowed_tags;type:text;serializer:json" json:"allowed_tags"`                     // customer service tags the vehicle can serve
+	MaxPayloadWeight float64        `gorm:"column:max_payload_weight;type:double precision;default:0" json:"max_payload_weight"`   // kg, 0 = unchecked
+	MaxFrontAxleLoad float64        `gorm:"column:max_front_axle_load;type:double precision;default:0" json:"max_front_axle_load"` // kg of payload, 0 = unchecked
+	MaxRearAxleLoad  float64        `gorm:"column:max_rear_axle_load;type:double precision;default:0" json:"max_rear_axle_load"`   // kg of payload, 0 = unchecked
+	WarehouseID      *int64         `gorm:"index;type:integer" json:"warehouse_id"`
+	ExternalRef      string         `gorm:"column:external_ref;type:varchar(100);index" json:"external_ref"` // the vehicle's key in another system, e.g. a fleet register; unique when set
+	Version          int64          `gorm:"not null;default:1" json:"version"`                               // counts edits, for optimistic locking
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+	Warehouse        *Warehouse     `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	Routes           []Route        `gorm:"foreignKey:VehicleID" json:"routes,omitempty"`
+	Tags             []Tag          `gorm:"many2many:vehicle_tags;constraint:OnDelete:CASCADE" json:"tags,omitempty"`
+}
+
+func (Vehicle) TableName() string {
+	return "vehicles"
+}
+
+// Tag labels customers and vehicles for filtering lists and selecting what
+// a plan optimizes, e.g. frozen-goods customers and reefer vehicles. Unlike
+// service tags it does not constrain which vehicle may serve a customer.
+type Tag struct {
+	ID          int64     `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(50);not null;uniqueIndex" json:"name"` // lowercase
+	Description string    `gorm:"type:varchar(255)" json:"description"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Tag) TableName() string {
+	return "tags"
+}
+
+// Driver represents a vehicle driver
+type Driver struct {
+	ID            int64      `gorm:"primaryKey" json:"id"`
+	Name          string     `gorm:"not null;type:varchar(255)" json:"name"`
+	Phone         string     `gorm:"type:varchar(50)" json:"phone"`
+	LicenseNumber string     `gorm:"column:license_number;type:varchar(100)" json:"license_number"`
+	Status        string     `gorm:"type:varchar(50);default:'active'" json:"status"` // active, on_leave, inactive
+	WarehouseID   *int64     `gorm:"index;type:integer" json:"warehouse_id"`
+	UserID        *int64     `gorm:"index;type:integer" json:"user_id"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	Warehouse     *Warehouse `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+}
+
+func (Driver) TableName() string {
+	return "drivers"
+}
+
+// Place is an address book entry for a location that is not a customer,
+// such as a fuel station, truck stop or partner depot, where routes can make
+// refuel, rest and other stops
+type Place struct {
+	ID        int64          `gorm:"primaryKey" json:"id"`
+	Name      string         `gorm:"not null;type:varchar(255)" json:"name"`
+	Kind      string         `gorm:"type:varchar(50);default:'other'" json:"kind"` // fuel_station, truck_stop, charging_station, depot, other
+	Address   string         `gorm:"type:text" json:"address"`
+	Latitude  float64        `gorm:"not null;type:double precision" json:"latitude"`
+	Longitude float64        `gorm:"not null;type:double precision" json:"longitude"`
+	Notes     string         `gorm:"type:text" json:"notes"`
+	CreatedAt time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (Place) TableName() string {
+	return "places"
+}
+
+// DriverAbsence is a period a driver is unavailable (vacation, sick leave).
+// Only approved absences affect rosters.
+type DriverAbsence struct {
 	ID          int64      `gorm:"primaryKey" json:"id"`
-	Name        string     `gorm:"not null;type:varchar(255)" json:"name"`
-	Capacity    float64    `gorm:"not null;type:double precision" json:"capacity"`
-	CostPerKm   float64    `gorm:"column:cost_per_km;type:double precision;default:0" json:"cost_per_km"`
-	FixedCost   float64    `gorm:"column:fixed_cost;type:double precision;default:0" json:"fixed_cost"`
-	MaxDistance float64    `gorm:"column:max_distance;type:double precision;default:0" json:"max_distance"`
-	Available   bool       `gorm:"type:boolean;default:true" json:"available"`
-	WarehouseID *int64     `gorm:"index;type:integer" json:"warehouse_id"`
+	DriverID    int64      `gorm:"index;not null;type:integer" json:"driver_id"`
+	Type        string     `gorm:"type:varchar(50);not null" json:"type"` // vacation, sick, other
+	StartDate   time.Time  `gorm:"column:start_date;type:date;not null" json:"start_date"`
+	EndDate     time.Time  `gorm:"column:end_date;type:date;not null" json:"end_date"`
+	Status      string     `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, approved, rejected
+	Reason      string     `gorm:"type:text" json:"reason"`
+	RequestedBy *int64     `gorm:"type:integer" json:"requested_by"`
+	ReviewedBy  *int64     `gorm:"type:integer" json:"reviewed_by"`
+	ReviewedAt  *time.Time `gorm:"type:timestamp" json:"reviewed_at"`
 	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
-	Warehouse   *Warehouse `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
-	Routes      []Route    `gorm:"foreignKey:VehicleID" json:"routes,omitempty"`
+	Driver      *Driver    `gorm:"foreignKey:DriverID;constraint:OnDelete:CASCADE" json:"driver,omitempty"`
 }
 
-func (Vehicle) TableName() string {
-	return "vehicles"
+func (DriverAbsence) TableName() string {
+	return "driver_absences"
+}
+
+// RosterEntry assigns a driver to a vehicle for one day
+type RosterEntry struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	DriverID  int64     `gorm:"not null;type:integer;uniqueIndex:idx_roster_driver_date" json:"driver_id"`
+	VehicleID int64     `gorm:"not null;type:integer;uniqueIndex:idx_roster_vehicle_date" json:"vehicle_id"`
+	Date      time.Time `gorm:"type:date;not null;uniqueIndex:idx_roster_driver_date;uniqueIndex:idx_roster_vehicle_date" json:"date"`
+	Notes     string    `gorm:"type:text" json:"notes"`
+	CreatedBy *int64    `gorm:"type:integer" json:"created_by"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Driver    *Driver   `gorm:"foreignKey:DriverID;constraint:OnDelete:CASCADE" json:"driver,omitempty"`
+	Vehicle   *Vehicle  `gorm:"foreignKey:VehicleID;constraint:OnDelete:CASCADE" json:"vehicle,omitempty"`
+}
+
+func (RosterEntry) TableName() string {
+	return "driver_rosters"
+}
+
+// RosterConflict describes why a roster assignment cannot be honoured
+type RosterConflict struct {
+	Type      string `json:"type"` // driver_double_booked, vehicle_double_booked, driver_on_leave, driver_inactive, driver_absent, vehicle_unavailable
+	Date      string `json:"date"`
+	DriverID  int64  `json:"driver_id"`
+	VehicleID int64  `json:"vehicle_id"`
+	EntryID   *int64 `json:"entry_id,omitempty"`
+	Message   string `json:"message"`
 }
 
 // Plan represents a delivery plan
@@ -91,13 +229,27 @@ type Plan struct {
 	Name               string              `gorm:"not null;type:varchar(255)" json:"name"`
 	StartDate          time.Time           `gorm:"column:start_date;type:date;not null" json:"start_date"`
 	EndDate            time.Time           `gorm:"column:end_date;type:date;not null" json:"end_date"`
-	Status             string              `gorm:"type:varchar(50);default:'draft'" json:"status"` // draft, optimizing, optimized, executed
+	Status             string              `gorm:"type:varchar(50);default:'draft'" json:"status"` // draft, optimizing, optimized, approved, executing, completed, cancelled
 	TotalCost          float64             `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
 	TotalDistance      float64             `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
 	WarehouseID        *int64              `gorm:"index;type:integer" json:"warehouse_id"`
+	CustomerIDs        []int64             `gorm:"column:customer_ids;type:text;serializer:json" json:"customer_ids"`   // empty means all customers
+	VehicleIDs         []int64             `gorm:"column:vehicle_ids;type:text;serializer:json" json:"vehicle_ids"`     // empty means all available vehicles of the warehouse
+	CustomerTags       []string            `gorm:"column:customer_tags;type:text;serializer:json" json:"customer_tags"` // only customers with any of the tags; empty means all
+	VehicleTags        []string            `gorm:"column:vehicle_tags;type:text;serializer:json" json:"vehicle_tags"`   // only vehicles with any of the tags; empty means all
+	TemplateID         *int64              `gorm:"index;type:integer" json:"template_id"`
+	Rolling            bool                `gorm:"type:boolean;default:false" json:"rolling"` // the horizon slides forward every day
+	Notes              string              `gorm:"type:text" json:"notes"`                    // shown to the drivers of the plan's routes
+	Objective          string              `gorm:"type:varchar(20)" json:"objective"`         // cost (default) or carbon, within max_cost_increase_pct
+	MaxCostIncreasePct float64             `gorm:"column:max_cost_increase_pct;type:double precision;default:0" json:"max_cost_increase_pct"`
+	ObjectiveTradeoff  *ObjectiveTradeoff  `gorm:"column:objective_tradeoff;type:text;serializer:json" json:"objective_tradeoff,omitempty"`
+	ApprovedBy         *int64              `gorm:"type:integer" json:"approved_by"`
+	ApprovedAt         *time.Time          `json:"approved_at"`
 	CreatedBy          *int64              `gorm:"index;type:integer" json:"created_by"`
+	Version            int64               `gorm:"not null;default:1" json:"version"` // counts edits, for optimistic locking
 	CreatedAt          time.Time           `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt          time.Time           `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt          gorm.DeletedAt      `gorm:"index" json:"-"`
 	Warehouse          *Warehouse          `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
 	User               *User               `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
 	Routes             []Route             `gorm:"foreignKey:PlanID;constraint:OnDelete:CASCADE" json:"routes,omitempty"`
@@ -109,40 +261,75 @@ func (Plan) TableName() string {
 	return "plans"
 }
 
+// ObjectiveTradeoff compares the routes a plan optimized for carbon was
+// given with the cheapest routes found for it. Costs are the vehicles'
+// fixed and per-km cost.
+type ObjectiveTradeoff struct {
+	BaselineCost    float64 `json:"baseline_cost"`
+	BaselineCO2Kg   float64 `json:"baseline_co2_kg"`
+	Cost            float64 `json:"cost"`
+	CO2Kg           float64 `json:"co2_kg"`
+	CostIncreasePct float64 `json:"cost_increase_pct"`
+	CO2SavedKg      float64 `json:"co2_saved_kg"`
+	CarbonWeight    float64 `json:"carbon_weight"` // of CO2 against cost in the solve kept; 0 when the cheapest routes were kept
+}
+
 // Route represents a delivery route for a specific day
 type Route struct {
-	ID            int64            `gorm:"primaryKey" json:"id"`
-	PlanID        int64            `gorm:"index;not null;type:integer" json:"plan_id"`
-	VehicleID     *int64           `gorm:"index;type:integer" json:"vehicle_id"`
-	Day           int              `gorm:"not null;type:integer" json:"day"`
-	Date          time.Time        `gorm:"type:date;not null" json:"date"`
-	TotalDistance float64          `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
-	TotalCost     float64          `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
-	TotalLoad     float64          `gorm:"column:total_load;type:double precision;default:0" json:"total_load"`
-	CreatedAt     time.Time        `gorm:"autoCreateTime" json:"created_at"`
-	Plan          *Plan            `gorm:"foreignKey:PlanID" json:"plan,omitempty"`
-	Vehicle       *Vehicle         `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
-	Stops         []Stop           `gorm:"foreignKey:RouteID;constraint:OnDelete:CASCADE" json:"stops,omitempty"`
-	Executions    []RouteExecution `gorm:"foreignKey:RouteID" json:"executions,omitempty"`
+	ID                int64     `gorm:"primaryKey" json:"id"`
+	PlanID            int64     `gorm:"index;not null;type:integer" json:"plan_id"`
+	VehicleID         *int64    `gorm:"index;type:integer" json:"vehicle_id"`
+	DriverID          *int64    `gorm:"index;type:integer" json:"driver_id"`
+	NeedsReassignment bool      `gorm:"column:needs_reassignment;type:boolean;default:false" json:"needs_reassignment"`
+	Locked            bool      `gorm:"type:boolean;default:false" json:"locked"` // pinned by a planner; re-optimization keeps it as is
+	Day               int       `gorm:"not null;type:integer" json:"day"`
+	Date              time.Time `gorm:"type:date;not null" json:"date"`
+	TotalDistance     float64   `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
+	TotalCost         float64   `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
+	TotalLoad         float64   `gorm:"column:total_load;type:double precision;default:0" json:"total_load"`
+	CostBreakdown     `gorm:"embedded"`
+	PlannedStart      *time.Time       `gorm:"column:planned_start;type:timestamp" json:"planned_start"`
+	PlannedEnd        *time.Time       `gorm:"column:planned_end;type:timestamp" json:"planned_end"`
+	CreatedAt         time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	Plan              *Plan            `gorm:"foreignKey:PlanID" json:"plan,omitempty"`
+	Vehicle           *Vehicle         `gorm:"foreignKey:VehicleID" json:"vehicle,omitempty"`
+	Driver            *Driver          `gorm:"foreignKey:DriverID" json:"driver,omitempty"`
+	Stops             []Stop           `gorm:"foreignKey:RouteID;constraint:OnDelete:CASCADE" json:"stops,omitempty"`
+	Executions        []RouteExecution `gorm:"foreignKey:RouteID" json:"executions,omitempty"`
 }
 
 func (Route) TableName() string {
 	return "routes"
 }
 
+// CostBreakdown decomposes a route's cost as computed from the optimizer
+// result it came from (see optimizer.CostRoutes). Holding cost is not part
+// of the route's total_cost.
+type CostBreakdown struct {
+	FixedCost    float64 `gorm:"column:fixed_cost;type:double precision;default:0" json:"fixed_cost"`
+	DistanceCost float64 `gorm:"column:distance_cost;type:double precision;default:0" json:"distance_cost"`
+	HoldingCost  float64 `gorm:"column:holding_cost;type:double precision;default:0" json:"holding_cost"`
+	PenaltyCost  float64 `gorm:"column:penalty_cost;type:double precision;default:0" json:"penalty_cost"`
+}
+
 // Stop represents a stop on a route
 type Stop struct {
 	ID                int64                 `gorm:"primaryKey" json:"id"`
 	RouteID           int64                 `gorm:"index;not null;type:integer" json:"route_id"`
 	CustomerID        *int64                `gorm:"index;type:integer" json:"customer_id"`
+	PlaceID           *int64                `gorm:"index;type:integer" json:"place_id"`
+	Type              string                `gorm:"type:varchar(50);default:'delivery'" json:"type"` // delivery, refuel, charging, rest_break, other
 	Sequence          int                   `gorm:"not null;type:integer" json:"sequence"`
+	DurationMinutes   int                   `gorm:"column:duration_minutes;type:integer;default:0" json:"duration_minutes"` // time spent at a place stop
 	Quantity          float64               `gorm:"type:double precision;default:0" json:"quantity"`
 	ArrivalTime       string                `gorm:"type:varchar(10)" json:"arrival_time"`
 	CreatedAt         time.Time             `gorm:"autoCreateTime" json:"created_at"`
 	Route             *Route                `gorm:"foreignKey:RouteID" json:"route,omitempty"`
 	Customer          *Customer             `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Place             *Place                `gorm:"foreignKey:PlaceID" json:"place,omitempty"`
 	StopExecutions    []StopExecution       `gorm:"foreignKey:StopID" json:"stop_executions,omitempty"`
 	ProductQuantities []StopProductQuantity `gorm:"foreignKey:StopID;constraint:OnDelete:CASCADE" json:"product_quantities,omitempty"`
+	Explanation       *StopExplanation      `gorm:"foreignKey:StopID;constraint:OnDelete:CASCADE" json:"-"`
 }
 
 func (Stop) TableName() string {
@@ -151,45 +338,109 @@ func (Stop) TableName() string {
 
 // RouteExecution represents the actual execution of a planned route
 type RouteExecution struct {
-	ID               int64           `gorm:"primaryKey" json:"id"`
-	RouteID          int64           `gorm:"index;not null;type:integer" json:"route_id"`
-	Status           string          `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, in_progress, completed, cancelled
-	PlannedDistance  float64         `gorm:"column:planned_distance;type:double precision;default:0" json:"planned_distance"`
-	ActualDistance   float64         `gorm:"column:actual_distance;type:double precision;default:0" json:"actual_distance"`
-	PlannedCost      float64         `gorm:"column:planned_cost;type:double precision;default:0" json:"planned_cost"`
-	ActualCost       float64         `gorm:"column:actual_cost;type:double precision;default:0" json:"actual_cost"`
-	PlannedLoad      float64         `gorm:"column:planned_load;type:double precision;default:0" json:"planned_load"`
-	ActualLoad       float64         `gorm:"column:actual_load;type:double precision;default:0" json:"actual_load"`
-	PlannedStartTime *time.Time      `gorm:"type:timestamp" json:"planned_start_time"`
-	ActualStartTime  *time.Time      `gorm:"type:timestamp" json:"actual_start_time"`
-	PlannedEndTime   *time.Time      `gorm:"type:timestamp" json:"planned_end_time"`
-	ActualEndTime    *time.Time      `gorm:"type:timestamp" json:"actual_end_time"`
-	DriverNotes      string          `gorm:"type:text" json:"driver_notes"`
-	DeviationReason  string          `gorm:"type:text" json:"deviation_reason"`
-	CreatedAt        time.Time       `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt        time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
-	Route            *Route          `gorm:"foreignKey:RouteID" json:"route,omitempty"`
-	StopExecutions   []StopExecution `gorm:"foreignKey:RouteExecutionID;constraint:OnDelete:CASCADE" json:"stop_executions,omitempty"`
+	ID               int64             `gorm:"primaryKey" json:"id"`
+	RouteID          int64             `gorm:"index;not null;type:integer" json:"route_id"`
+	Status           string            `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, in_progress, completed, cancelled
+	PlannedDistance  float64           `gorm:"column:planned_distance;type:double precision;default:0" json:"planned_distance"`
+	ActualDistance   float64           `gorm:"column:actual_distance;type:double precision;default:0" json:"actual_distance"`
+	PlannedCost      float64           `gorm:"column:planned_cost;type:double precision;default:0" json:"planned_cost"`
+	ActualCost       float64           `gorm:"column:actual_cost;type:double precision;default:0" json:"actual_cost"`
+	PlannedLoad      float64           `gorm:"column:planned_load;type:double precision;default:0" json:"planned_load"`
+	ActualLoad       float64           `gorm:"column:actual_load;type:double precision;default:0" json:"actual_load"`
+	PlannedStartTime *time.Time        `gorm:"type:timestamp" json:"planned_start_time"`
+	ActualStartTime  *time.Time        `gorm:"type:timestamp" json:"actual_start_time"`
+	PlannedEndTime   *time.Time        `gorm:"type:timestamp" json:"planned_end_time"`
+	ActualEndTime    *time.Time        `gorm:"type:timestamp" json:"actual_end_time"`
+	DriverNotes      string            `gorm:"type:text" json:"driver_notes"`
+	DeviationReason  string            `gorm:"type:text" json:"deviation_reason"`
+	ShiftStart       *time.Time        `gorm:"column:shift_start;type:timestamp" json:"shift_start"` // driver's working time; the actual start and end stand in when not recorded
+	ShiftEnd         *time.Time        `gorm:"column:shift_end;type:timestamp" json:"shift_end"`
+	Breaks           []ShiftBreak      `gorm:"column:breaks;type:text;serializer:json" json:"breaks"`
+	Progress         ExecutionProgress `gorm:"embedded;embeddedPrefix:progress_" json:"progress"`
+	CreatedAt        time.Time         `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time         `gorm:"autoUpdateTime" json:"updated_at"`
+	Route            *Route            `gorm:"foreignKey:RouteID" json:"route,omitempty"`
+	StopExecutions   []StopExecution   `gorm:"foreignKey:RouteExecutionID;constraint:OnDelete:CASCADE" json:"stop_executions,omitempty"`
+	VehicleLog       *VehicleLog       `gorm:"foreignKey:RouteExecutionID" json:"vehicle_log,omitempty"`
 }
 
 func (RouteExecution) TableName() string {
 	return "route_executions"
 }
 
+// ShiftBreak is a break a driver took during a shift
+type ShiftBreak struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// DriverDay is the working time a driver recorded on one day. Driving is
+// the time worked away from stops.
+type DriverDay struct {
+	DriverID       int64   `json:"driver_id"`
+	Date           string  `json:"date"`
+	ExecutionIDs   []int64 `json:"execution_ids"`
+	WorkingMinutes int     `json:"working_minutes"`
+	DrivingMinutes int     `json:"driving_minutes"`
+	BreakMinutes   int     `json:"break_minutes"`
+}
+
+// HoursViolation is a breach of an hours-of-service rule
+type HoursViolation struct {
+	DriverID      int64  `json:"driver_id"`
+	Rule          string `json:"rule"`                   // daily_driving, weekly_driving or continuous_driving
+	Period        string `json:"period"`                 // the day (2024-03-04) or ISO week (2024-W10)
+	ExecutionID   *int64 `json:"execution_id,omitempty"` // the shift driven too long without a break
+	LimitMinutes  int    `json:"limit_minutes"`
+	ActualMinutes int    `json:"actual_minutes"`
+}
+
+// DriverHoursReport is drivers' recorded working time by day and the
+// hours-of-service rules it breaches
+type DriverHoursReport struct {
+	From                    string           `json:"from"`
+	To                      string           `json:"to"`
+	MaxDailyDrivingMinutes  int              `json:"max_daily_driving_minutes"`
+	MaxWeeklyDrivingMinutes int              `json:"max_weekly_driving_minutes"`
+	BreakAfterMinutes       int              `json:"break_after_minutes"`
+	Days                    []DriverDay      `json:"days"`
+	Violations              []HoursViolation `json:"violations"`
+}
+
+// ExecutionProgress is how far a route execution has come, kept up to date
+// as the execution and its stops are reported. Elapsed time runs from the
+// actual start to the end, or to the latest stop arrival or departure while
+// the route is under way. Percentages are 0 when nothing is planned.
+type ExecutionProgress struct {
+	TotalStops       int     `gorm:"column:total_stops;type:integer;default:0" json:"total_stops"`
+	CompletedStops   int     `gorm:"column:completed_stops;type:integer;default:0" json:"completed_stops"`
+	StopsPercent     float64 `gorm:"column:stops_percent;type:double precision;default:0" json:"stops_percent"`
+	DeliveredPercent float64 `gorm:"column:delivered_percent;type:double precision;default:0" json:"delivered_percent"` // actual load of planned load
+	PlannedMinutes   int     `gorm:"column:planned_minutes;type:integer;default:0" json:"planned_minutes"`
+	ElapsedMinutes   int     `gorm:"column:elapsed_minutes;type:integer;default:0" json:"elapsed_minutes"`
+	TimePercent      float64 `gorm:"column:time_percent;type:double precision;default:0" json:"time_percent"`
+}
+
 // StopExecution represents the actual execution of a planned stop
 type StopExecution struct {
 	ID                   int64           `gorm:"primaryKey" json:"id"`
 	RouteExecutionID     int64           `gorm:"index;not null;type:integer" json:"route_execution_id"`
 	StopID               int64           `gorm:"index;not null;type:integer" json:"stop_id"`
-	Status               string          `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, in_progress, completed, skipped, failed
+	Status               string          `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, arrived, completed, skipped, failed
 	PlannedQuantity      float64         `gorm:"column:planned_quantity;type:double precision;default:0" json:"planned_quantity"`
 	ActualQuantity       float64         `gorm:"column:actual_quantity;type:double precision;default:0" json:"actual_quantity"`
 	PlannedArrivalTime   *time.Time      `gorm:"type:timestamp" json:"planned_arrival_time"`
 	ActualArrivalTime    *time.Time      `gorm:"type:timestamp" json:"actual_arrival_time"`
 	PlannedDepartureTime *time.Time      `gorm:"type:timestamp" json:"planned_departure_time"`
 	ActualDepartureTime  *time.Time      `gorm:"type:timestamp" json:"actual_departure_time"`
-	ServiceDuration      int             `gorm:"type:integer;default:0" json:"service_duration"` // minutes
+	ServiceDuration      int             `gorm:"type:integer;default:0" json:"service_duration"`                                 // minutes
+	ArrivalDetected      bool            `gorm:"column:arrival_detected;type:boolean;default:false" json:"arrival_detected"`     // arrival taken from GPS, not reported
+	DepartureDetected    bool            `gorm:"column:departure_detected;type:boolean;default:false" json:"departure_detected"` // departure taken from GPS, not reported
 	Notes                string          `gorm:"type:text" json:"notes"`
+	RecipientName        string          `gorm:"column:recipient_name;type:varchar(255)" json:"recipient_name"`
+	PODSignature         *StoredFile     `gorm:"column:pod_signature;type:text;serializer:json" json:"-"`
+	PODPhotos            []StoredFile    `gorm:"column:pod_photos;type:text;serializer:json" json:"-"`
+	PODCapturedAt        *time.Time      `gorm:"column:pod_captured_at;type:timestamp" json:"pod_captured_at"`
 	CreatedAt            time.Time       `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt            time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
 	RouteExecution       *RouteExecution `gorm:"foreignKey:RouteExecutionID" json:"route_execution,omitempty"`
@@ -200,6 +451,339 @@ func (StopExecution) TableName() string {
 	return "stop_executions"
 }
 
+// VehicleLog is the odometer readings and fuel purchased that a driver
+// reports when completing a route execution
+type VehicleLog struct {
+	ID               int64     `gorm:"primaryKey" json:"id"`
+	VehicleID        int64     `gorm:"index;not null;type:integer" json:"vehicle_id"`
+	RouteExecutionID int64     `gorm:"uniqueIndex;not null;type:integer" json:"route_execution_id"`
+	Date             time.Time `gorm:"type:date;not null;index" json:"date"`
+	OdometerStart    *float64  `gorm:"column:odometer_start;type:double precision" json:"odometer_start"`           // km
+	OdometerEnd      *float64  `gorm:"column:odometer_end;type:double precision" json:"odometer_end"`               // km
+	FuelPurchased    float64   `gorm:"column:fuel_purchased;type:double precision;default:0" json:"fuel_purchased"` // litres, kWh for electric vehicles
+	FuelCost         float64   `gorm:"column:fuel_cost;type:double precision;default:0" json:"fuel_cost"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (VehicleLog) TableName() string {
+	return "vehicle_logs"
+}
+
+// MaintenanceRecord is service or repair work done on a vehicle
+type MaintenanceRecord struct {
+	ID          int64     `gorm:"primaryKey" json:"id"`
+	VehicleID   int64     `gorm:"index;not null;type:integer" json:"vehicle_id"`
+	Kind        string    `gorm:"type:varchar(50);not null" json:"kind"` // service, repair, inspection, tyres, other
+	Date        time.Time `gorm:"type:date;not null;index" json:"date"`
+	Description string    `gorm:"type:text" json:"description"`
+	Cost        float64   `gorm:"type:double precision;default:0" json:"cost"`
+	Odometer    *float64  `gorm:"type:double precision" json:"odometer"` // km, when it was read
+	RecordedBy  *int64    `gorm:"type:integer" json:"recorded_by"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (MaintenanceRecord) TableName() string {
+	return "maintenance_records"
+}
+
+// Distance is the distance driven by the odometer readings, if both were
+// reported
+func (l VehicleLog) Distance() (float64, bool) {
+	if l.OdometerStart == nil || l.OdometerEnd == nil {
+		return 0, false
+	}
+	return *l.OdometerEnd - *l.OdometerStart, true
+}
+
+// Incident is a breakdown, accident, refused delivery or damaged goods
+// reported on a route execution, with what was done about the stops left
+// on the route. The route, plan, vehicle and driver are copied from the
+// execution for the safety team's filters.
+type Incident struct {
+	ID               int64          `gorm:"primaryKey" json:"id"`
+	RouteExecutionID int64          `gorm:"index;not null;type:integer" json:"route_execution_id"`
+	RouteID          int64          `gorm:"not null;type:integer" json:"route_id"`
+	PlanID           int64          `gorm:"index;not null;type:integer" json:"plan_id"`
+	VehicleID        *int64         `gorm:"index;type:integer" json:"vehicle_id"`
+	DriverID         *int64         `gorm:"index;type:integer" json:"driver_id"`
+	StopExecutionID  *int64         `gorm:"type:integer" json:"stop_execution_id"`
+	Category         string         `gorm:"type:varchar(50);not null;index" json:"category"` // breakdown, accident, refused_delivery, damaged_goods
+	Description      string         `gorm:"type:text" json:"description"`
+	Latitude         *float64       `gorm:"type:double precision" json:"latitude"`
+	Longitude        *float64       `gorm:"type:double precision" json:"longitude"`
+	OccurredAt       time.Time      `gorm:"type:timestamp;not null;index" json:"occurred_at"`
+	ReportedBy       *int64         `gorm:"type:integer" json:"reported_by"`
+	Effect           string         `gorm:"type:varchar(50);not null;default:'none'" json:"effect"` // none, cancel_remaining, reassign
+	ReassignRouteID  *int64         `gorm:"column:reassign_route_id;type:integer" json:"reassign_route_id"`
+	AffectedStops    []int64        `gorm:"column:affected_stops;type:text;serializer:json" json:"affected_stops"` // stop executions skipped or failed by the effect
+	PhotoFiles       []StoredFile   `gorm:"column:photos;type:text;serializer:json" json:"-"`
+	Photos           []ArtifactLink `gorm:"-" json:"photos"`
+	CreatedAt        time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (Incident) TableName() string {
+	return "incidents"
+}
+
+// DriverStopEvent is a stop update uploaded by the driver app. The
+// client-generated ID makes uploads retried after losing connectivity
+// apply once.
+type DriverStopEvent struct {
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	ClientEventID   string     `gorm:"column:client_event_id;uniqueIndex;not null;type:varchar(64)" json:"client_event_id"`
+	StopExecutionID int64      `gorm:"index;not null;type:integer" json:"stop_execution_id"`
+	UserID          int64      `gorm:"not null;type:integer" json:"user_id"`
+	Status          string     `gorm:"type:varchar(50);not null" json:"status"`
+	ActualQuantity  *float64   `gorm:"column:actual_quantity;type:double precision" json:"actual_quantity"`
+	Notes           string     `gorm:"type:text" json:"notes"`
+	OccurredAt      *time.Time `gorm:"type:timestamp" json:"occurred_at"` // device time, when sent
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (DriverStopEvent) TableName() string {
+	return "driver_stop_events"
+}
+
+// LocationPing is a GPS position reported by a vehicle during a route
+// execution. On PostgreSQL the table is partitioned by day of RecordedAt.
+type LocationPing struct {
+	ID               int64     `gorm:"primaryKey" json:"id"`
+	RouteExecutionID int64     `gorm:"not null;type:integer;uniqueIndex:idx_location_pings_track,priority:1" json:"route_execution_id"`
+	Latitude         float64   `gorm:"type:double precision;not null" json:"latitude"`
+	Longitude        float64   `gorm:"type:double precision;not null" json:"longitude"`
+	Speed            *float64  `gorm:"type:double precision" json:"speed"` // km/h, when the device reports it
+	RecordedAt       time.Time `gorm:"type:timestamp;not null;uniqueIndex:idx_location_pings_track,priority:2" json:"recorded_at"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (LocationPing) TableName() string {
+	return "location_pings"
+}
+
+// LocationIngest is the outcome of a batch of location pings: how many were
+// received and stored (repeated pings are dropped), the execution's actual
+// distance computed from its track and the stop visits it revealed
+type LocationIngest struct {
+	Received       int     `json:"received"`
+	Stored         int     `json:"stored"`
+	ActualDistance float64 `json:"actual_distance"`
+	Arrivals       int     `json:"arrivals"`   // stops marked arrived by the geofence
+	Departures     int     `json:"departures"` // stops marked departed by the geofence
+}
+
+// DriverRoute is a route as the driver app shows it, without the plan,
+// vehicle and customer records behind it
+type DriverRoute struct {
+	RouteID         int64        `json:"route_id"`
+	PlanID          int64        `json:"plan_id"`
+	Date            time.Time    `json:"date"`
+	VehicleID       *int64       `json:"vehicle_id"`
+	VehicleName     string       `json:"vehicle_name"`
+	PlannedStart    *time.Time   `json:"planned_start"`
+	PlannedEnd      *time.Time   `json:"planned_end"`
+	TotalLoad       float64      `json:"total_load"`
+	ExecutionID     *int64       `json:"execution_id"`
+	ExecutionStatus string       `json:"execution_status"`
+	Notes           []string     `json:"notes"` // plan notes and the driver's roster notes for the day
+	Stops           []DriverStop `json:"stops"`
+}
+
+// DriverStop is a stop of a DriverRoute with its execution state
+type DriverStop struct {
+	StopID          int64   `json:"stop_id"`
+	StopExecutionID *int64  `json:"stop_execution_id"`
+	Sequence        int     `json:"sequence"`
+	Type            string  `json:"type"`
+	CustomerID      *int64  `json:"customer_id"`
+	CustomerName    string  `json:"customer_name"`
+	PlaceID         *int64  `json:"place_id"`
+	PlaceName       string  `json:"place_name"`
+	Address         string  `json:"address"`
+	Latitude        float64 `json:"latitude"`
+	Longitude       float64 `json:"longitude"`
+	Quantity        float64 `json:"quantity"`
+	ArrivalTime     string  `json:"arrival_time"`
+	Status          string  `json:"status"`
+	ActualQuantity  float64 `json:"actual_quantity"`
+}
+
+// PlanEvent is a change streamed to the dispatch dashboards watching a
+// plan. Data is an ExecutionUpdate, a LocationPing or an ExecutionETA by
+// Type.
+type PlanEvent struct {
+	Type        string      `json:"type"` // execution, location, eta
+	PlanID      int64       `json:"plan_id"`
+	RouteID     int64       `json:"route_id"`
+	ExecutionID int64       `json:"execution_id"`
+	At          time.Time   `json:"at"`
+	Data        interface{} `json:"data"`
+}
+
+// ExecutionUpdate is the state of a route execution after a change
+type ExecutionUpdate struct {
+	Status          string            `json:"status"`
+	Progress        ExecutionProgress `json:"progress"`
+	ActualStartTime *time.Time        `json:"actual_start_time"`
+	ActualEndTime   *time.Time        `json:"actual_end_time"`
+	ActualDistance  float64           `json:"actual_distance"`
+	ActualLoad      float64           `json:"actual_load"`
+}
+
+// ExecutionETA is the estimated arrival at the stops of a route execution
+// still to be visited: their planned arrival shifted by the delay of the
+// latest start, arrival or departure reported
+type ExecutionETA struct {
+	DelayMinutes int       `json:"delay_minutes"`
+	Stops        []StopETA `json:"stops"`
+}
+
+// StopETA is the estimated arrival at a stop
+type StopETA struct {
+	StopExecutionID int64     `json:"stop_execution_id"`
+	StopID          int64     `json:"stop_id"`
+	ETA             time.Time `json:"eta"`
+	DelayMinutes    int       `json:"delay_minutes,omitempty"` // behind the planned arrival; negative when ahead
+	DistanceKm      float64   `json:"distance_km,omitempty"`   // left to drive to the stop
+}
+
+// RouteETAs are the arrivals expected at the stops of a route execution
+// still to be visited, recomputed from where the vehicle is
+type RouteETAs struct {
+	ExecutionID int64     `json:"execution_id"`
+	Origin      string    `json:"origin"` // stop (being served), gps or warehouse
+	Latitude    float64   `json:"latitude"`
+	Longitude   float64   `json:"longitude"`
+	DepartureAt time.Time `json:"departure_at"` // when the vehicle leaves the origin
+	Source      string    `json:"source"`       // road or straight_line
+	Stops       []StopETA `json:"stops"`
+}
+
+// ExecutionEvent is one thing that happened on a route execution. Stop
+// events carry the stop, location events and alerts where the vehicle was,
+// and messages and driver updates who posted them.
+type ExecutionEvent struct {
+	At              time.Time `json:"at"`
+	Type            string    `json:"type"`             // started, arrived, completed, failed, skipped, proof_of_delivery, driver_update, location, message, note, alert, route_completed, route_cancelled
+	Source          string    `json:"source,omitempty"` // reported or gps, for arrivals and stop outcomes
+	Alert           string    `json:"alert,omitempty"`  // late_arrival or gps_gap
+	StopExecutionID *int64    `json:"stop_execution_id,omitempty"`
+	StopID          *int64    `json:"stop_id,omitempty"`
+	UserID          *int64    `json:"user_id,omitempty"`
+	Detail          string    `json:"detail,omitempty"`
+	Latitude        *float64  `json:"latitude,omitempty"`
+	Longitude       *float64  `json:"longitude,omitempty"`
+	Speed           *float64  `json:"speed,omitempty"`
+}
+
+// ExecutionTimeline is everything recorded on a route execution, oldest
+// first
+type ExecutionTimeline struct {
+	ExecutionID int64            `json:"execution_id"`
+	RouteID     int64            `json:"route_id"`
+	Status      string           `json:"status"`
+	Alerts      int              `json:"alerts"`
+	Events      []ExecutionEvent `json:"events"`
+}
+
+// Compact payloads are returned with ?view=compact by the endpoints drivers
+// use, to save bandwidth on slow mobile connections: short keys, no nested
+// records, times as Unix seconds and empty values left out. The shapes are
+// documented in api/openapi.yaml.
+
+// CompactRoute is the compact form of a DriverRoute
+type CompactRoute struct {
+	ID          int64         `json:"id"`
+	PlanID      int64         `json:"p"`
+	Date        int64         `json:"d"`
+	Vehicle     string        `json:"v,omitempty"`
+	Start       int64         `json:"s,omitempty"`
+	End         int64         `json:"e,omitempty"`
+	Load        float64       `json:"l"`
+	ExecutionID int64         `json:"x,omitempty"`
+	Status      string        `json:"xs,omitempty"`
+	Notes       []string      `json:"n,omitempty"`
+	Stops       []CompactStop `json:"st"`
+}
+
+// CompactStop is the compact form of a DriverStop
+type CompactStop struct {
+	ID          int64   `json:"id"`
+	ExecutionID int64   `json:"x,omitempty"`
+	Sequence    int     `json:"sq"`
+	Name        string  `json:"nm,omitempty"`
+	Address     string  `json:"ad,omitempty"`
+	Latitude    float64 `json:"la"`
+	Longitude   float64 `json:"lo"`
+	Quantity    float64 `json:"q"`
+	ArrivalTime string  `json:"at,omitempty"`
+	Status      string  `json:"s"`
+	Delivered   float64 `json:"aq,omitempty"`
+}
+
+// CompactExecution is the compact form of a RouteExecution
+type CompactExecution struct {
+	ID             int64                  `json:"id"`
+	RouteID        int64                  `json:"r"`
+	Status         string                 `json:"s"`
+	Started        int64                  `json:"a,omitempty"`
+	Ended          int64                  `json:"e,omitempty"`
+	Distance       float64                `json:"km"`
+	Load           float64                `json:"l"`
+	CompletedStops int                    `json:"sd"`
+	TotalStops     int                    `json:"sn"`
+	Stops          []CompactStopExecution `json:"st"`
+}
+
+// CompactStopExecution is the compact form of a StopExecution
+type CompactStopExecution struct {
+	ID        int64   `json:"id"`
+	StopID    int64   `json:"sid"`
+	Status    string  `json:"s"`
+	Planned   float64 `json:"pq"`
+	Delivered float64 `json:"aq"`
+	Arrived   int64   `json:"a,omitempty"`
+	Departed  int64   `json:"dp,omitempty"`
+	Notes     string  `json:"n,omitempty"`
+}
+
+// RouteMessage is a message in the dispatcher-driver thread of a route
+type RouteMessage struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	RouteID   int64     `gorm:"index;not null;type:integer" json:"route_id"`
+	SenderID  int64     `gorm:"index;not null;type:integer" json:"sender_id"`
+	Body      string    `gorm:"type:text;not null" json:"body"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Sender    *User     `gorm:"foreignKey:SenderID" json:"sender,omitempty"`
+}
+
+func (RouteMessage) TableName() string {
+	return "route_messages"
+}
+
+// RouteMessageRead is a read receipt: how far a user has read a route's
+// thread
+type RouteMessageRead struct {
+	RouteID           int64     `gorm:"primaryKey;type:integer" json:"route_id"`
+	UserID            int64     `gorm:"primaryKey;type:integer" json:"user_id"`
+	LastReadMessageID int64     `gorm:"not null;type:integer" json:"last_read_message_id"`
+	ReadAt            time.Time `gorm:"type:timestamp" json:"read_at"`
+}
+
+func (RouteMessageRead) TableName() string {
+	return "route_message_reads"
+}
+
+// RouteThread is a route's messages as one user sees them. Reads hold every
+// participant's receipt; Unread counts messages from others after the
+// user's own receipt.
+type RouteThread struct {
+	RouteID  int64              `json:"route_id"`
+	Messages []RouteMessage     `json:"messages"`
+	Reads    []RouteMessageRead `json:"reads"`
+	Unread   int                `json:"unread"`
+}
+
 // InventorySnapshot represents a historical snapshot of inventory levels
 type InventorySnapshot struct {
 	ID             int64     `gorm:"primaryKey" json:"id"`
@@ -211,7 +795,7 @@ type InventorySnapshot struct {
 	DemandRate     float64   `gorm:"column:demand_rate;type:double precision;default:0" json:"demand_rate"`
 	MinInventory   float64   `gorm:"column:min_inventory;type:double precision;default:0" json:"min_inventory"`
 	MaxInventory   float64   `gorm:"column:max_inventory;type:double precision;default:0" json:"max_inventory"`
-	SnapshotReason string    `gorm:"type:varchar(50)" json:"snapshot_reason"` // daily, delivery, manual, optimization
+	SnapshotReason string    `gorm:"type:varchar(50)" json:"snapshot_reason"` // daily, delivery, adjustment, stocktake, manual, optimization
 	PlanID         *int64    `gorm:"index;type:integer" json:"plan_id"`
 	RouteID        *int64    `gorm:"index;type:integer" json:"route_id"`
 	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
@@ -223,18 +807,137 @@ func (InventorySnapshot) TableName() string {
 	return "inventory_snapshots"
 }
 
+// InventoryAdjustment is a correction of a customer's current inventory
+// with the reason for it. The inventory after it is also recorded as a
+// snapshot.
+type InventoryAdjustment struct {
+	ID         int64 `gorm:"primaryKey" json:"id"`
+	CustomerID int64 `gorm:"index;not null;type:integer" json:"customer_id"`
+	// Delta is the change, negative when inventory is taken out
+	Delta      float64   `gorm:"type:double precision;not null" json:"delta"`
+	Reason     string    `gorm:"type:varchar(50);not null" json:"reason"`
+	Notes      string    `gorm:"type:text" json:"notes"`
+	Balance    float64   `gorm:"type:double precision" json:"balance"` // current inventory after the adjustment
+	SnapshotID *int64    `gorm:"type:integer" json:"snapshot_id"`
+	CreatedBy  *int64    `gorm:"type:integer" json:"created_by"`
+	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (InventoryAdjustment) TableName() string {
+	return "inventory_adjustments"
+}
+
+// Demand estimation methods
+const (
+	DemandMovingAverage        = "moving_average"
+	DemandExponentialSmoothing = "exponential_smoothing"
+)
+
+// DemandEstimate is a customer's daily demand estimated from its history
+// over a window: the inventory it used between snapshots, taking deliveries
+// and adjustments into account. The customer's demand rate only changes
+// when an estimate is applied.
+type DemandEstimate struct {
+	ID         int64     `gorm:"primaryKey" json:"id"`
+	CustomerID int64     `gorm:"index;not null;type:integer" json:"customer_id"`
+	WindowFrom time.Time `gorm:"column:window_from;not null" json:"window_from"`
+	WindowTo   time.Time `gorm:"column:window_to;not null" json:"window_to"`
+	// ObservedDays is the number of days of the window with history
+	ObservedDays  int     `gorm:"column:observed_days;type:integer;default:0" json:"observed_days"`
+	Consumed      float64 `gorm:"type:double precision;default:0" json:"consumed"`
+	MovingAverage float64 `gorm:"column:moving_average;type:double precision;default:0" json:"moving_average"`
+	Smoothed      float64 `gorm:"type:double precision;default:0" json:"smoothed"` // exponential smoothing of the daily demand
+	Alpha         float64 `gorm:"type:double precision;default:0" json:"alpha"`
+	CurrentRate   float64 `gorm:"column:current_rate;type:double precision;default:0" json:"current_rate"` // the customer's demand rate when estimated
+	// AppliedMethod is the method whose estimate became the customer's
+	// demand rate, empty while not applied
+	AppliedMethod string     `gorm:"column:applied_method;type:varchar(30)" json:"applied_method,omitempty"`
+	AppliedBy     *int64     `gorm:"type:integer" json:"applied_by"`
+	AppliedAt     *time.Time `json:"applied_at"`
+	EstimatedAt   time.Time  `gorm:"index;not null" json:"estimated_at"`
+}
+
+func (DemandEstimate) TableName() string {
+	return "demand_estimates"
+}
+
+// Rate is the estimated daily demand by method
+func (e *DemandEstimate) Rate(method string) float64 {
+	if method == DemandMovingAverage {
+		return e.MovingAverage
+	}
+	return e.Smoothed
+}
+
+// InventoryHistoryAggregate is the inventory history of customers or
+// warehouses over a date range, bucketed by day or week
+type InventoryHistoryAggregate struct {
+	From     string               `json:"from"`
+	To       string               `json:"to"`
+	Bucket   string               `json:"bucket"` // day, week
+	Entities []InventoryAggregate `json:"entities"`
+}
+
+// InventoryAggregate is one customer's or warehouse's bucketed inventory
+// history with its totals over the range
+type InventoryAggregate struct {
+	EntityType string `json:"entity_type"`
+	EntityID   int64  `json:"entity_id"`
+	Name       string `json:"name"`
+	// Capacity is what fill rates are measured against: a customer's max
+	// inventory or a warehouse's capacity
+	Capacity float64 `json:"capacity"`
+	InventoryStats
+	Buckets []InventoryBucket `json:"buckets"`
+}
+
+// InventoryBucket is the inventory history of a day, or of the week
+// starting on the Monday of Start
+type InventoryBucket struct {
+	Start string `json:"start"`
+	InventoryStats
+}
+
+// InventoryStats summarises inventory snapshots
+type InventoryStats struct {
+	Snapshots int     `json:"snapshots"`
+	Min       float64 `json:"min"`
+	Avg       float64 `json:"avg"`
+	Max       float64 `json:"max"`
+	// DaysObserved counts the days with snapshots and DaysBelowMin those
+	// with a snapshot below the minimum
+	DaysObserved int `json:"days_observed"`
+	DaysBelowMin int `json:"days_below_min"`
+	// FillRate is the average inventory as a share of capacity, nil without
+	// a capacity
+	FillRate *float64 `json:"fill_rate"`
+}
+
+// DailySnapshots is the outcome of taking the daily inventory snapshots of
+// a date
+type DailySnapshots struct {
+	Date string `json:"date"`
+	// Created is false when the date's snapshots had already been taken
+	Created   bool `json:"created"`
+	Snapshots int  `json:"snapshots"`
+}
+
 // Product represents a product type (optional multi-product support)
 // If not used, system assumes single product
 type Product struct {
-	ID          int64     `gorm:"primaryKey" json:"id"`
-	Name        string    `gorm:"not null;type:varchar(255)" json:"name"`
-	SKU         string    `gorm:"uniqueIndex;type:varchar(100)" json:"sku"`
-	Description string    `gorm:"type:text" json:"description"`
-	Unit        string    `gorm:"type:varchar(50);default:'kg'" json:"unit"`     // kg, liters, units, etc.
-	Weight      float64   `gorm:"type:double precision;default:0" json:"weight"` // per unit
-	Volume      float64   `gorm:"type:double precision;default:0" json:"volume"` // per unit
-	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID          int64   `gorm:"primaryKey" json:"id"`
+	Name        string  `gorm:"not null;type:varchar(255)" json:"name"`
+	SKU         string  `gorm:"uniqueIndex;type:varchar(100)" json:"sku"`
+	Description string  `gorm:"type:text" json:"description"`
+	Unit        string  `gorm:"type:varchar(50);default:'kg'" json:"unit"`     // kg, liters, units, etc.
+	Weight      float64 `gorm:"type:double precision;default:0" json:"weight"` // per unit
+	Volume      float64 `gorm:"type:double precision;default:0" json:"volume"` // per unit
+	// Planned quantities are rounded to multiples of QuantityStep (0 = no rounding)
+	QuantityStep float64   `gorm:"column:quantity_step;type:double precision;default:0" json:"quantity_step"`
+	RoundingMode string    `gorm:"column:rounding_mode;type:varchar(20);default:'nearest'" json:"rounding_mode"` // nearest, up, down
+	ExternalRef  string    `gorm:"column:external_ref;type:varchar(100);index" json:"external_ref"`              // the product's key in another system; unique when set
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (Product) TableName() string {
@@ -243,19 +946,24 @@ func (Product) TableName() string {
 
 // CustomerProductInventory represents product-specific inventory for customers (optional)
 type CustomerProductInventory struct {
-	ID               int64     `gorm:"primaryKey" json:"id"`
-	CustomerID       int64     `gorm:"index;not null;type:integer" json:"customer_id"`
-	ProductID        int64     `gorm:"index;not null;type:integer" json:"product_id"`
-	CurrentInventory float64   `gorm:"column:current_inventory;type:double precision;default:0" json:"current_inventory"`
-	MaxInventory     float64   `gorm:"column:max_inventory;type:double precision;default:0" json:"max_inventory"`
-	MinInventory     float64   `gorm:"column:min_inventory;type:double precision;default:0" json:"min_inventory"`
-	DemandRate       float64   `gorm:"column:demand_rate;type:double precision;default:0" json:"demand_rate"`
-	HoldingCost      float64   `gorm:"column:holding_cost;type:double precision;default:0" json:"holding_cost"`
-	Priority         int       `gorm:"type:integer;default:1" json:"priority"`
-	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt        time.Time `gorm:"autoUpdateTime" json:"updated_at"`
-	Customer         *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
-	Product          *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	ID               int64   `gorm:"primaryKey" json:"id"`
+	CustomerID       int64   `gorm:"index;not null;type:integer" json:"customer_id"`
+	ProductID        int64   `gorm:"index;not null;type:integer" json:"product_id"`
+	CurrentInventory float64 `gorm:"column:current_inventory;type:double precision;default:0" json:"current_inventory"`
+	MaxInventory     float64 `gorm:"column:max_inventory;type:double precision;default:0" json:"max_inventory"`
+	MinInventory     float64 `gorm:"column:min_inventory;type:double precision;default:0" json:"min_inventory"`
+	DemandRate       float64 `gorm:"column:demand_rate;type:double precision;default:0" json:"demand_rate"`
+	HoldingCost      float64 `gorm:"column:holding_cost;type:double precision;default:0" json:"holding_cost"`
+	Priority         int     `gorm:"type:integer;default:1" json:"priority"`
+	// ReorderPoint is the inventory at or below which the product is
+	// reordered, 0 for its min inventory; OrderQuantity is what is ordered
+	// then, 0 to top up to its max inventory
+	ReorderPoint  float64   `gorm:"column:reorder_point;type:double precision;default:0" json:"reorder_point"`
+	OrderQuantity float64   `gorm:"column:order_quantity;type:double precision;default:0" json:"order_quantity"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Customer      *Customer `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Product       *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
 }
 
 func (CustomerProductInventory) TableName() string {
@@ -277,6 +985,475 @@ func (StopProductQuantity) TableName() string {
 	return "stop_product_quantities"
 }
 
+// WarehouseProductStock is a warehouse's stock of one product. The
+// warehouse's current stock is its total over all products, so stock not
+// booked to a product is its current stock less these quantities.
+type WarehouseProductStock struct {
+	ID          int64     `gorm:"primaryKey" json:"id"`
+	WarehouseID int64     `gorm:"not null;type:integer;uniqueIndex:idx_warehouse_product" json:"warehouse_id"`
+	ProductID   int64     `gorm:"not null;type:integer;uniqueIndex:idx_warehouse_product" json:"product_id"`
+	Quantity    float64   `gorm:"type:double precision;default:0" json:"quantity"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Product     *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (WarehouseProductStock) TableName() string {
+	return "warehouse_product_stock"
+}
+
+// Stock movement kinds
+const (
+	StockReceipt     = "receipt"
+	StockDelivery    = "delivery"
+	StockAdjustment  = "adjustment"
+	StockTransferIn  = "transfer_in"
+	StockTransferOut = "transfer_out"
+)
+
+// StockMovement is an entry of a warehouse's stock ledger. Entries are
+// never changed or removed; a mistake is corrected by a later adjustment.
+type StockMovement struct {
+	ID          int64  `gorm:"primaryKey" json:"id"`
+	WarehouseID int64  `gorm:"index;not null;type:integer" json:"warehouse_id"`
+	ProductID   *int64 `gorm:"index;type:integer" json:"product_id"`  // nil for stock not booked to a product
+	Kind        string `gorm:"type:varchar(20);not null" json:"kind"` // receipt, delivery, adjustment, transfer_in, transfer_out
+	Reason      string `gorm:"type:varchar(50)" json:"reason"`        // adjustment reason code, stocktake or opening_balance
+	// Quantity is the change in stock, negative when stock leaves
+	Quantity float64 `gorm:"type:double precision;not null" json:"quantity"`
+	// Balance is the warehouse's current stock after the movement, and
+	// ProductBalance its stock of the product
+	Balance                float64   `gorm:"type:double precision" json:"balance"`
+	ProductBalance         *float64  `gorm:"column:product_balance;type:double precision" json:"product_balance"`
+	CounterpartWarehouseID *int64    `gorm:"column:counterpart_warehouse_id;type:integer" json:"counterpart_warehouse_id"` // other side of a transfer
+	Reference              string    `gorm:"type:varchar(255)" json:"reference"`                                           // e.g. a delivery note number
+	Notes                  string    `gorm:"type:text" json:"notes"`
+	PlanID                 *int64    `gorm:"type:integer" json:"plan_id"`
+	RouteID                *int64    `gorm:"type:integer" json:"route_id"`
+	StopExecutionID        *int64    `gorm:"column:stop_execution_id;type:integer" json:"stop_execution_id"`
+	CreatedBy              *int64    `gorm:"type:integer" json:"created_by"`
+	OccurredAt             time.Time `gorm:"index;not null" json:"occurred_at"`
+	CreatedAt              time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Product                *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
+
+// Stock transfer statuses. A transfer is requested, then shipped, which
+// takes the stock out of the source warehouse, then received, which books
+// it in at the destination. Only a requested transfer can be cancelled.
+const (
+	TransferRequested = "requested"
+	TransferInTransit = "in_transit"
+	TransferReceived  = "received"
+	TransferCancelled = "cancelled"
+)
+
+// StockTransfer moves stock from one warehouse to another over time, unlike
+// a transfer movement pair which books both sides at once. It can come with
+// a route in a plan of the source warehouse that carries it.
+type StockTransfer struct {
+	ID              int64   `gorm:"primaryKey" json:"id"`
+	FromWarehouseID int64   `gorm:"index;not null;type:integer" json:"from_warehouse_id"`
+	ToWarehouseID   int64   `gorm:"index;not null;type:integer" json:"to_warehouse_id"`
+	ProductID       *int64  `gorm:"type:integer" json:"product_id"` // nil for stock not booked to a product
+	Quantity        float64 `gorm:"type:double precision;not null" json:"quantity"`
+	Status          string  `gorm:"type:varchar(20);not null;index" json:"status"`
+	Reference       string  `gorm:"type:varchar(255)" json:"reference"`
+	Notes           string  `gorm:"type:text" json:"notes"`
+	PlanID          *int64  `gorm:"type:integer" json:"plan_id"`
+	RouteID         *int64  `gorm:"type:integer" json:"route_id"`
+	// OutMovementID and InMovementID are the ledger entries booked when the
+	// transfer was shipped and received
+	OutMovementID *int64     `gorm:"column:out_movement_id;type:integer" json:"out_movement_id"`
+	InMovementID  *int64     `gorm:"column:in_movement_id;type:integer" json:"in_movement_id"`
+	RequestedBy   *int64     `gorm:"type:integer" json:"requested_by"`
+	ShippedBy     *int64     `gorm:"type:integer" json:"shipped_by"`
+	ShippedAt     *time.Time `json:"shipped_at"`
+	ReceivedBy    *int64     `gorm:"type:integer" json:"received_by"`
+	ReceivedAt    *time.Time `json:"received_at"`
+	CancelledBy   *int64     `gorm:"type:integer" json:"cancelled_by"`
+	CancelledAt   *time.Time `json:"cancelled_at"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	FromWarehouse *Warehouse `gorm:"foreignKey:FromWarehouseID" json:"from_warehouse,omitempty"`
+	ToWarehouse   *Warehouse `gorm:"foreignKey:ToWarehouseID" json:"to_warehouse,omitempty"`
+	Product       *Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (StockTransfer) TableName() string {
+	return "stock_transfers"
+}
+
+// StocktakeReason is the reason of the adjustments and snapshots a
+// stocktake records
+const StocktakeReason = "stocktake"
+
+// Stocktake reconciles the counted inventory of a customer, or the counted
+// stock of a warehouse, with the books. Each line's variance is applied as
+// an adjustment when the stocktake is recorded.
+type Stocktake struct {
+	ID         int64  `gorm:"primaryKey" json:"id"`
+	EntityType string `gorm:"type:varchar(20);not null;index:idx_stocktakes_entity" json:"entity_type"` // 'customer' or 'warehouse'
+	EntityID   int64  `gorm:"not null;type:integer;index:idx_stocktakes_entity" json:"entity_id"`
+	Reference  string `gorm:"type:varchar(255)" json:"reference"`
+	Notes      string `gorm:"type:text" json:"notes"`
+	// Variance is the sum of the lines' variances
+	Variance  float64         `gorm:"type:double precision" json:"variance"`
+	CountedBy *int64          `gorm:"type:integer" json:"counted_by"`
+	CountedAt time.Time       `gorm:"not null" json:"counted_at"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	Lines     []StocktakeLine `gorm:"foreignKey:StocktakeID" json:"lines,omitempty"`
+}
+
+func (Stocktake) TableName() string {
+	return "stocktakes"
+}
+
+// StocktakeLine is the count of one product, or of the stock not booked to
+// a product, against the book quantity when it was counted
+type StocktakeLine struct {
+	ID              int64   `gorm:"primaryKey" json:"id"`
+	StocktakeID     int64   `gorm:"index;not null;type:integer" json:"stocktake_id"`
+	ProductID       *int64  `gorm:"type:integer" json:"product_id"`
+	BookQuantity    float64 `gorm:"type:double precision;not null" json:"book_quantity"`
+	CountedQuantity float64 `gorm:"type:double precision;not null" json:"counted_quantity"`
+	// Variance is counted less book quantity
+	Variance float64 `gorm:"type:double precision;not null" json:"variance"`
+	// AdjustmentID is the customer inventory adjustment, and MovementID the
+	// warehouse stock movement, that applied the variance; nil without one
+	AdjustmentID *int64   `gorm:"type:integer" json:"adjustment_id"`
+	MovementID   *int64   `gorm:"type:integer" json:"movement_id"`
+	Product      *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (StocktakeLine) TableName() string {
+	return "stocktake_lines"
+}
+
+// WarehouseStock is a warehouse's current stock with its stock per product
+type WarehouseStock struct {
+	WarehouseID  int64                   `json:"warehouse_id"`
+	CurrentStock float64                 `json:"current_stock"`
+	Unassigned   float64                 `json:"unassigned"` // stock not booked to a product
+	Products     []WarehouseProductStock `json:"products"`
+}
+
+// StopExplanation records why the optimizer put a customer on a route's
+// day, from the inventory data it solved with
+type StopExplanation struct {
+	ID                  int64     `gorm:"primaryKey" json:"id"`
+	StopID              int64     `gorm:"uniqueIndex;not null;type:integer" json:"stop_id"`
+	Reason              string    `gorm:"type:varchar(50);not null" json:"reason"` // stockout, below_minimum, due, top_up
+	Detail              string    `gorm:"type:text" json:"detail"`
+	InventoryAtDelivery float64   `gorm:"column:inventory_at_delivery;type:double precision;default:0" json:"inventory_at_delivery"` // projected, before the drop
+	InventoryAfter      float64   `gorm:"column:inventory_after;type:double precision;default:0" json:"inventory_after"`
+	DaysToStockout      *float64  `gorm:"column:days_to_stockout;type:double precision" json:"days_to_stockout"` // nil when the customer has no demand
+	DemandRate          float64   `gorm:"column:demand_rate;type:double precision;default:0" json:"demand_rate"`
+	MinInventory        float64   `gorm:"column:min_inventory;type:double precision;default:0" json:"min_inventory"`
+	MaxInventory        float64   `gorm:"column:max_inventory;type:double precision;default:0" json:"max_inventory"`
+	Priority            int       `gorm:"type:integer;default:0" json:"priority"`
+	CreatedAt           time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (StopExplanation) TableName() string {
+	return "stop_explanations"
+}
+
+// PlanTemplate is a saved plan configuration. Templates with a recurrence
+// are instantiated as new draft plans by the scheduler every week or month.
+type PlanTemplate struct {
+	ID            int64      `gorm:"primaryKey" json:"id"`
+	Name          string     `gorm:"not null;type:varchar(255)" json:"name"`
+	WarehouseID   *int64     `gorm:"index;type:integer" json:"warehouse_id"`
+	CustomerIDs   []int64    `gorm:"column:customer_ids;type:text;serializer:json" json:"customer_ids"`
+	VehicleIDs    []int64    `gorm:"column:vehicle_ids;type:text;serializer:json" json:"vehicle_ids"`
+	CustomerTags  []string   `gorm:"column:customer_tags;type:text;serializer:json" json:"customer_tags"`
+	VehicleTags   []string   `gorm:"column:vehicle_tags;type:text;serializer:json" json:"vehicle_tags"`
+	HorizonDays   int        `gorm:"column:horizon_days;not null;type:integer" json:"horizon_days"`
+	Recurrence    string     `gorm:"type:varchar(20);default:'none'" json:"recurrence"` // none, weekly, monthly
+	NextStartDate *time.Time `gorm:"column:next_start_date;type:date;index" json:"next_start_date"`
+	LeadDays      int        `gorm:"column:lead_days;type:integer;default:0" json:"lead_days"` // create the plan this many days before it starts
+	Active        bool       `json:"active"`
+	SourcePlanID  *int64     `gorm:"type:integer" json:"source_plan_id"`
+	LastPlanID    *int64     `gorm:"type:integer" json:"last_plan_id"`
+	LastRunAt     *time.Time `json:"last_run_at"`
+	CreatedBy     *int64     `gorm:"index;type:integer" json:"created_by"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	Warehouse     *Warehouse `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+}
+
+func (PlanTemplate) TableName() string {
+	return "plan_templates"
+}
+
+// Scenario is a sandboxed what-if copy of a plan's optimization inputs.
+// Optimizing a scenario never touches the source plan's routes.
+type Scenario struct {
+	ID               int64           `gorm:"primaryKey" json:"id"`
+	PlanID           int64           `gorm:"index;not null;type:integer" json:"plan_id"`
+	Name             string          `gorm:"not null;type:varchar(255)" json:"name"`
+	Status           string          `gorm:"type:varchar(50);default:'draft'" json:"status"` // draft, optimized, failed
+	VehicleCount     *int            `gorm:"type:integer" json:"vehicle_count"`
+	DemandMultiplier float64         `gorm:"column:demand_multiplier;type:double precision;default:1" json:"demand_multiplier"`
+	CustomerIDs      []int64         `gorm:"column:customer_ids;type:text;serializer:json" json:"customer_ids"`
+	TotalCost        float64         `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
+	TotalDistance    float64         `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
+	VehiclesUsed     int             `gorm:"column:vehicles_used;type:integer;default:0" json:"vehicles_used"`
+	Message          string          `gorm:"type:text" json:"message"`
+	CreatedBy        *int64          `gorm:"index;type:integer" json:"created_by"`
+	CreatedAt        time.Time       `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time       `gorm:"autoUpdateTime" json:"updated_at"`
+	Plan             *Plan           `gorm:"foreignKey:PlanID" json:"plan,omitempty"`
+	Routes           []ScenarioRoute `gorm:"foreignKey:ScenarioID;constraint:OnDelete:CASCADE" json:"routes,omitempty"`
+}
+
+func (Scenario) TableName() string {
+	return "scenarios"
+}
+
+// ScenarioRoute is a route produced by optimizing a scenario
+type ScenarioRoute struct {
+	ID            int64          `gorm:"primaryKey" json:"id"`
+	ScenarioID    int64          `gorm:"index;not null;type:integer" json:"scenario_id"`
+	VehicleID     *int64         `gorm:"type:integer" json:"vehicle_id"`
+	Day           int            `gorm:"not null;type:integer" json:"day"`
+	Date          time.Time      `gorm:"type:date;not null" json:"date"`
+	TotalDistance float64        `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
+	TotalCost     float64        `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
+	TotalLoad     float64        `gorm:"column:total_load;type:double precision;default:0" json:"total_load"`
+	Stops         []ScenarioStop `gorm:"type:text;serializer:json" json:"stops"`
+	CreatedAt     time.Time      `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (ScenarioRoute) TableName() string {
+	return "scenario_routes"
+}
+
+// ScenarioStop is stored inline on scenario and solution routes
+type ScenarioStop struct {
+	CustomerID      int64   `json:"customer_id"` // 0 for stops at places
+	PlaceID         *int64  `json:"place_id,omitempty"`
+	Type            string  `json:"type,omitempty"` // empty for deliveries
+	Sequence        int     `json:"sequence"`
+	DurationMinutes int     `json:"duration_minutes,omitempty"`
+	Quantity        float64 `json:"quantity"`
+	ArrivalTime     string  `json:"arrival_time"`
+}
+
+// PlanSolution is a stored version of a plan's optimized routes. Every
+// optimization, re-optimization or rollback appends a new version.
+type PlanSolution struct {
+	ID            int64              `gorm:"primaryKey" json:"id"`
+	PlanID        int64              `gorm:"index;not null;type:integer;uniqueIndex:idx_plan_solution_version" json:"plan_id"`
+	Version       int                `gorm:"not null;type:integer;uniqueIndex:idx_plan_solution_version" json:"version"`
+	Source        string             `gorm:"type:varchar(50);not null" json:"source"` // optimize, reoptimize, roll, rollback, clone
+	BaseVersion   *int               `gorm:"column:base_version;type:integer" json:"base_version"`
+	IsCurrent     bool               `gorm:"column:is_current;type:boolean;default:false" json:"is_current"`
+	TotalCost     float64            `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
+	TotalDistance float64            `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
+	RouteCount    int                `gorm:"column:route_count;type:integer;default:0" json:"route_count"`
+	Parameters    SolutionParameters `gorm:"type:text;serializer:json" json:"parameters"`
+	Message       string             `gorm:"type:text" json:"message"`
+	CreatedBy     *int64             `gorm:"type:integer" json:"created_by"`
+	CreatedAt     time.Time          `gorm:"autoCreateTime" json:"created_at"`
+	Routes        []SolutionRoute    `gorm:"foreignKey:SolutionID;constraint:OnDelete:CASCADE" json:"routes,omitempty"`
+}
+
+func (PlanSolution) TableName() string {
+	return "plan_solutions"
+}
+
+// SolutionParameters records the solver inputs a solution was produced with
+type SolutionParameters struct {
+	StartDate       string  `json:"start_date,omitempty"`
+	PlanningHorizon int     `json:"planning_horizon,omitempty"`
+	FromDay         int     `json:"from_day,omitempty"`
+	VehicleIDs      []int64 `json:"vehicle_ids,omitempty"`
+	CustomerCount   int     `json:"customer_count,omitempty"`
+	LockedRoutes    int     `json:"locked_routes,omitempty"`
+	RoadDistances   bool    `json:"road_distances"`
+	// Windows lists the rolling windows a plan too large for one optimizer
+	// call was solved in; empty when it was solved in one call
+	Windows []SolutionWindow `json:"windows,omitempty"`
+}
+
+// SolutionWindow is a run of plan days solved in one optimizer call
+type SolutionWindow struct {
+	FirstDay int `json:"first_day"`
+	LastDay  int `json:"last_day"`
+}
+
+// SolutionRoute is a route as it was stored in a plan solution
+type SolutionRoute struct {
+	ID            int64     `gorm:"primaryKey" json:"id"`
+	SolutionID    int64     `gorm:"index;not null;type:integer" json:"solution_id"`
+	VehicleID     *int64    `gorm:"type:integer" json:"vehicle_id"`
+	DriverID      *int64    `gorm:"type:integer" json:"driver_id"`
+	Day           int       `gorm:"not null;type:integer" json:"day"`
+	Date          time.Time `gorm:"type:date;not null" json:"date"`
+	TotalDistance float64   `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
+	TotalCost     float64   `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
+	TotalLoad     float64   `gorm:"column:total_load;type:double precision;default:0" json:"total_load"`
+	CostBreakdown `gorm:"embedded"`
+	PlannedStart  *time.Time     `gorm:"column:planned_start;type:timestamp" json:"planned_start"`
+	PlannedEnd    *time.Time     `gorm:"column:planned_end;type:timestamp" json:"planned_end"`
+	Stops         []ScenarioStop `gorm:"type:text;serializer:json" json:"stops"`
+}
+
+func (SolutionRoute) TableName() string {
+	return "plan_solution_routes"
+}
+
+// UnroutedCustomer records a customer with demand in a plan's horizon that
+// the last optimization left without a stop. Forcing it boosts the
+// customer's priority in the next optimization, which then consumes it.
+type UnroutedCustomer struct {
+	ID           int64      `gorm:"primaryKey" json:"id"`
+	PlanID       int64      `gorm:"index;not null;type:integer" json:"plan_id"`
+	CustomerID   int64      `gorm:"index;not null;type:integer" json:"customer_id"`
+	Reason       string     `gorm:"type:varchar(50);not null" json:"reason"` // capacity, distance, blocked
+	Detail       string     `gorm:"type:text" json:"detail"`
+	Shortfall    float64    `gorm:"type:double precision;default:0" json:"shortfall"`
+	ForcedAt     *time.Time `gorm:"column:forced_at;type:timestamp" json:"forced_at"`
+	ForcedBy     *int64     `gorm:"column:forced_by;type:integer" json:"forced_by"`
+	ConsumedAt   *time.Time `gorm:"column:consumed_at;type:timestamp" json:"consumed_at"`
+	ConsumedPlan *int64     `gorm:"column:consumed_plan_id;type:integer" json:"consumed_plan_id"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	Customer     *Customer  `gorm:"foreignKey:CustomerID;constraint:OnDelete:CASCADE" json:"customer,omitempty"`
+}
+
+func (UnroutedCustomer) TableName() string {
+	return "unrouted_customers"
+}
+
+// Redelivery follows up a stop left with part or all of its quantity
+// undelivered. The remainder is appended to a later route of the same plan
+// when one has room, or queued for the next optimization, which consumes it.
+type Redelivery struct {
+	ID              int64      `gorm:"primaryKey" json:"id"`
+	PlanID          int64      `gorm:"index;not null;type:integer" json:"plan_id"`
+	CustomerID      int64      `gorm:"index;not null;type:integer" json:"customer_id"`
+	StopExecutionID int64      `gorm:"column:stop_execution_id;index;not null;type:integer" json:"stop_execution_id"`
+	Reason          string     `gorm:"type:varchar(50);not null" json:"reason"` // partial, failed
+	Quantity        float64    `gorm:"type:double precision;default:0" json:"quantity"`
+	Status          string     `gorm:"type:varchar(50);index;not null" json:"status"` // scheduled, queued, delivered, failed, consumed, cancelled
+	RouteID         *int64     `gorm:"type:integer" json:"route_id"`
+	StopID          *int64     `gorm:"index;type:integer" json:"stop_id"`
+	ConsumedPlanID  *int64     `gorm:"column:consumed_plan_id;type:integer" json:"consumed_plan_id"`
+	ResolvedAt      *time.Time `gorm:"type:timestamp" json:"resolved_at"`
+	CreatedAt       time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt       time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+	Customer        *Customer  `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+}
+
+func (Redelivery) TableName() string {
+	return "redeliveries"
+}
+
+// OptimizationRun archives the raw optimizer request and response of one
+// optimizer call for debugging
+type OptimizationRun struct {
+	ID              int64     `gorm:"primaryKey" json:"id"`
+	PlanID          *int64    `gorm:"index;type:integer" json:"plan_id"`
+	ScenarioID      *int64    `gorm:"index;type:integer" json:"scenario_id"`
+	Kind            string    `gorm:"type:varchar(50);not null" json:"kind"`   // optimize, reoptimize, scenario
+	Status          string    `gorm:"type:varchar(50);not null" json:"status"` // success, failed, infeasible, error
+	Protocol        string    `gorm:"type:varchar(20)" json:"protocol"`
+	DurationMs      int64     `gorm:"column:duration_ms;type:bigint;default:0" json:"duration_ms"`
+	PlanningHorizon int       `gorm:"column:planning_horizon;type:integer;default:0" json:"planning_horizon"`
+	CustomerCount   int       `gorm:"column:customer_count;type:integer;default:0" json:"customer_count"`
+	VehicleCount    int       `gorm:"column:vehicle_count;type:integer;default:0" json:"vehicle_count"`
+	RouteCount      int       `gorm:"column:route_count;type:integer;default:0" json:"route_count"`
+	TotalCost       float64   `gorm:"column:total_cost;type:double precision;default:0" json:"total_cost"`
+	TotalDistance   float64   `gorm:"column:total_distance;type:double precision;default:0" json:"total_distance"`
+	SolverMessage   string    `gorm:"column:solver_message;type:text" json:"solver_message"`
+	Error           string    `gorm:"type:text" json:"error,omitempty"`
+	Violations      string    `gorm:"type:text" json:"-"`
+	FirstDay        int       `gorm:"column:first_day;type:integer;default:0" json:"first_day,omitempty"` // request day a chunked call starts on; 0 for whole-horizon calls
+	Request         string    `gorm:"type:text" json:"-"`
+	Response        string    `gorm:"type:text" json:"-"`
+	CreatedBy       *int64    `gorm:"type:integer" json:"created_by"`
+	CreatedAt       time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (OptimizationRun) TableName() string {
+	return "optimization_runs"
+}
+
+// Job is a unit of background work executed by the job runner. Failed jobs
+// are retried with backoff until MaxAttempts, then parked as dead.
+type Job struct {
+	ID          int64      `gorm:"primaryKey" json:"id"`
+	Type        string     `gorm:"type:varchar(100);not null;index" json:"type"`
+	Payload     string     `gorm:"type:text" json:"payload"`                                             // JSON
+	Status      string     `gorm:"type:varchar(20);not null;index:idx_jobs_status_run_at" json:"status"` // pending, running, succeeded, dead, cancelled
+	Attempts    int        `gorm:"type:integer;default:0" json:"attempts"`
+	MaxAttempts int        `gorm:"column:max_attempts;type:integer;default:0" json:"max_attempts"`
+	RunAt       time.Time  `gorm:"column:run_at;not null;index:idx_jobs_status_run_at" json:"run_at"`
+	LockedAt    *time.Time `gorm:"column:locked_at" json:"locked_at,omitempty"`
+	LockedBy    string     `gorm:"column:locked_by;type:varchar(100)" json:"locked_by,omitempty"`
+	LastError   string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	FinishedAt  *time.Time `gorm:"column:finished_at" json:"finished_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// SecurityEvent is an entry in the security log. UserID is the account
+// concerned and ActorID the user who acted on it, such as the admin changing
+// a role; failed logins for unknown emails have neither.
+type SecurityEvent struct {
+	ID        int64     `gorm:"primaryKey" json:"id"`
+	Type      string    `gorm:"type:varchar(50);not null;index" json:"type"` // login, login_failed, password_changed, tokens_revoked, role_changed, organization_changed
+	UserID    *int64    `gorm:"index;type:integer" json:"user_id,omitempty"`
+	ActorID   *int64    `gorm:"type:integer" json:"actor_id,omitempty"`
+	Email     string    `gorm:"type:varchar(255)" json:"email,omitempty"`
+	IP        string    `gorm:"column:ip;type:varchar(64)" json:"ip,omitempty"`
+	UserAgent string    `gorm:"type:varchar(255)" json:"user_agent,omitempty"`
+	Detail    string    `gorm:"type:text" json:"detail,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (SecurityEvent) TableName() string {
+	return "security_events"
+}
+
+// Organization groups users for usage metering. Nil quotas fall back to the
+// QUOTA_* defaults; 0 means unlimited.
+type Organization struct {
+	ID                       int64     `gorm:"primaryKey" json:"id"`
+	Name                     string    `gorm:"not null;type:varchar(255)" json:"name"`
+	MaxOptimizationsPerMonth *int      `gorm:"column:max_optimizations_per_month;type:integer" json:"max_optimizations_per_month"`
+	MaxCustomers             *int      `gorm:"column:max_customers;type:integer" json:"max_customers"`
+	MaxAPICallsPerDay        *int      `gorm:"column:max_api_calls_per_day;type:integer" json:"max_api_calls_per_day"`
+	CreatedAt                time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt                time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Organization) TableName() string {
+	return "organizations"
+}
+
+// UsageCounter counts a metered action of an organization in one period
+type UsageCounter struct {
+	ID             int64     `gorm:"primaryKey" json:"id"`
+	OrganizationID int64     `gorm:"not null;uniqueIndex:idx_usage_counter" json:"organization_id"`
+	Metric         string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_usage_counter" json:"metric"` // optimizations, api_calls
+	Period         string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_usage_counter" json:"period"` // 2024-01 (monthly) or 2024-01-15 (daily)
+	Count          int64     `gorm:"type:bigint;default:0" json:"count"`
+	UpdatedAt      time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (UsageCounter) TableName() string {
+	return "usage_counters"
+}
+
 // Dashboard represents analytics dashboard data
 type Dashboard struct {
 	TotalWarehouses int     `json:"total_warehouses"`
@@ -289,3 +1466,674 @@ type Dashboard struct {
 	AvgUtilization  float64 `json:"avg_utilization"`
 	RecentPlans     []Plan  `json:"recent_plans"`
 }
+
+// OnboardingStep is one setup step and how far it has got
+type OnboardingStep struct {
+	Key       string `json:"key"`
+	Title     string `json:"title"`
+	Completed bool   `json:"completed"`
+	Current   int    `json:"current"`
+	Target    int    `json:"target"`
+}
+
+// Onboarding is the setup checklist of a new installation
+type Onboarding struct {
+	Steps          []OnboardingStep `json:"steps"`
+	CompletedSteps int              `json:"completed_steps"`
+	TotalSteps     int              `json:"total_steps"`
+	Complete       bool             `json:"complete"`
+	NextStep       string           `json:"next_step,omitempty"` // key of the first open step
+}
+
+// VehicleHistory summarizes a vehicle's usage over a period
+type VehicleHistory struct {
+	VehicleID           int64               `json:"vehicle_id"`
+	From                string              `json:"from"`
+	To                  string              `json:"to"`
+	RouteCount          int                 `json:"route_count"`
+	DaysActive          int                 `json:"days_active"`
+	PlannedDistanceKm   float64             `json:"planned_distance_km"`
+	ActualDistanceKm    float64             `json:"actual_distance_km"`
+	TotalLoad           float64             `json:"total_load"`
+	PlannedCost         float64             `json:"planned_cost"`
+	ActualCost          float64             `json:"actual_cost"`
+	CompletedExecutions int                 `json:"completed_executions"`
+	IncidentCount       int                 `json:"incident_count"`
+	IncidentsByCategory map[string]int      `json:"incidents_by_category"`
+	MaintenanceCount    int                 `json:"maintenance_count"`
+	MaintenanceCost     float64             `json:"maintenance_cost"`
+	Routes              []Route             `json:"routes"`
+	Executions          []RouteExecution    `json:"executions"`
+	Incidents           []Incident          `json:"incidents"`
+	Maintenance         []MaintenanceRecord `json:"maintenance"`
+}
+
+// WarehouseDayView is the daily operational view of a warehouse
+type WarehouseDayView struct {
+	WarehouseID           int64         `json:"warehouse_id"`
+	Date                  string        `json:"date"`
+	OpeningStock          float64       `json:"opening_stock"`
+	ExpectedReplenishment float64       `json:"expected_replenishment"`
+	TotalOutbound         float64       `json:"total_outbound"`
+	ProjectedClosingStock float64       `json:"projected_closing_stock"`
+	StockShortfall        float64       `json:"stock_shortfall"`
+	LoadingSchedule       []LoadingSlot `json:"loading_schedule"`
+	Routes                []Route       `json:"routes"`
+}
+
+// LoadingSlot is a vehicle to be loaded for a departing route
+type LoadingSlot struct {
+	RouteID          int64   `json:"route_id"`
+	PlanID           int64   `json:"plan_id"`
+	VehicleID        *int64  `json:"vehicle_id"`
+	VehicleName      string  `json:"vehicle_name"`
+	Load             float64 `json:"load"`
+	StopCount        int     `json:"stop_count"`
+	FirstArrivalTime string  `json:"first_arrival_time"`
+}
+
+// CustomerPortfolioEntry classifies a customer by volume (ABC) and visit frequency
+type CustomerPortfolioEntry struct {
+	CustomerID         int64   `json:"customer_id"`
+	Name               string  `json:"name"`
+	Visits             int     `json:"visits"`
+	VisitsPerWeek      float64 `json:"visits_per_week"`
+	Volume             float64 `json:"volume"`
+	VolumeShare        float64 `json:"volume_share"`
+	AvgDropSize        float64 `json:"avg_drop_size"`
+	VolumeClass        string  `json:"volume_class"`        // A, B, C
+	FrequencyBand      string  `json:"frequency_band"`      // high, medium, low, none
+	SuggestedFrequency string  `json:"suggested_frequency"` // increase, decrease, keep
+	Reason             string  `json:"reason,omitempty"`
+}
+
+// CustomerPortfolioReport groups customers into volume classes and frequency bands
+type CustomerPortfolioReport struct {
+	From        string                   `json:"from"`
+	To          string                   `json:"to"`
+	TotalVolume float64                  `json:"total_volume"`
+	ClassCounts map[string]int           `json:"class_counts"`
+	BandCounts  map[string]int           `json:"band_counts"`
+	Customers   []CustomerPortfolioEntry `json:"customers"`
+}
+
+// AccuracyScore compares what plans planned with what their completed
+// routes did. Each accuracy is a percentage, 100 meaning the plans were
+// carried out exactly; the score averages those with something to measure.
+type AccuracyScore struct {
+	Plans            int     `json:"plans"`
+	Routes           int     `json:"routes"`
+	Stops            int     `json:"stops"`
+	QuantityAccuracy float64 `json:"quantity_accuracy"` // delivered against planned quantities
+	TimingAccuracy   float64 `json:"timing_accuracy"`   // stops reached within the on-time window
+	CostAccuracy     float64 `json:"cost_accuracy"`     // actual against planned route costs
+	Score            float64 `json:"score"`
+}
+
+// AccuracyPeriod is the accuracy of the plans starting in one week or month
+type AccuracyPeriod struct {
+	Period string `json:"period"` // 2024-03, or 2024-W10 by week
+	AccuracyScore
+}
+
+// AccuracyGroup trends the accuracy of one warehouse's or planner's plans.
+// Change is the score of the last period less that of the first.
+type AccuracyGroup struct {
+	ID     int64            `json:"id"`
+	Name   string           `json:"name"`
+	Change float64          `json:"change"`
+	Trend  []AccuracyPeriod `json:"trend"`
+	AccuracyScore
+}
+
+// PlanAccuracy is the accuracy of one plan
+type PlanAccuracy struct {
+	PlanID      int64  `json:"plan_id"`
+	Name        string `json:"name"`
+	StartDate   string `json:"start_date"`
+	WarehouseID *int64 `json:"warehouse_id"`
+	CreatedBy   *int64 `json:"created_by"`
+	AccuracyScore
+}
+
+// PlanAccuracyReport scores past plans over a date range, overall, by
+// period and by warehouse and planner
+type PlanAccuracyReport struct {
+	From       string           `json:"from"`
+	To         string           `json:"to"`
+	Period     string           `json:"period"` // week, month
+	Overall    AccuracyScore    `json:"overall"`
+	Trend      []AccuracyPeriod `json:"trend"`
+	Warehouses []AccuracyGroup  `json:"warehouses"`
+	Planners   []AccuracyGroup  `json:"planners"`
+	Plans      []PlanAccuracy   `json:"plans"`
+}
+
+// PlanSummary is a compact management summary of a plan
+type PlanSummary struct {
+	PlanID          int64           `json:"plan_id"`
+	Name            string          `json:"name"`
+	Status          string          `json:"status"`
+	StartDate       string          `json:"start_date"`
+	EndDate         string          `json:"end_date"`
+	Days            int             `json:"days"`
+	RouteCount      int             `json:"route_count"`
+	VehiclesUsed    int             `json:"vehicles_used"`
+	StopCount       int             `json:"stop_count"`
+	CustomersServed int             `json:"customers_served"`
+	TotalDelivered  float64         `json:"total_delivered"`
+	TotalCost       float64         `json:"total_cost"`
+	TotalDistanceKm float64         `json:"total_distance_km"`
+	CostPerUnit     float64         `json:"cost_per_unit"`
+	AvgUtilization  float64         `json:"avg_utilization"`
+	Headline        string          `json:"headline"`
+	RiskFlags       []PlanRiskFlag  `json:"risk_flags"`
+	PreviousPlanID  *int64          `json:"previous_plan_id"`
+	Deviations      []PlanDeviation `json:"deviations"`
+	TotalCO2Kg      float64         `json:"total_co2_kg"`
+	// ObjectiveTradeoff is set for plans optimized for carbon
+	ObjectiveTradeoff *ObjectiveTradeoff `json:"objective_tradeoff,omitempty"`
+}
+
+// PlanRiskFlag is an issue in a plan that management should be aware of
+type PlanRiskFlag struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"` // info, warning, critical
+	Message  string `json:"message"`
+}
+
+// PlanDeviation compares a plan metric against the previous plan
+type PlanDeviation struct {
+	Metric       string  `json:"metric"`
+	Previous     float64 `json:"previous"`
+	Current      float64 `json:"current"`
+	Delta        float64 `json:"delta"`
+	DeltaPercent float64 `json:"delta_percent"`
+}
+
+// DispatchCheck is the pre-dispatch over-delivery check of a plan
+type DispatchCheck struct {
+	PlanID         int64          `json:"plan_id"`
+	StopsChecked   int            `json:"stops_checked"`
+	OverDeliveries []OverDelivery `json:"over_deliveries"`
+}
+
+// OverDelivery is a stop planning more than the customer can store on the
+// delivery date
+type OverDelivery struct {
+	StopID             int64     `json:"stop_id"`
+	RouteID            int64     `json:"route_id"`
+	CustomerID         int64     `json:"customer_id"`
+	CustomerName       string    `json:"customer_name"`
+	Day                int       `json:"day"`
+	Date               string    `json:"date"`
+	PlannedQuantity    float64   `json:"planned_quantity"`
+	ProjectedInventory float64   `json:"projected_inventory"`
+	MaxInventory       float64   `json:"max_inventory"`
+	FreeCapacity       float64   `json:"free_capacity"`
+	Excess             float64   `json:"excess"`
+	InventoryAsOf      time.Time `json:"inventory_as_of"`
+	InventorySource    string    `json:"inventory_source"` // customer or snapshot
+}
+
+// DeviationReport attributes a plan's execution deviations to likely causes
+type DeviationReport struct {
+	PlanID            int64            `json:"plan_id"`
+	PlannedAt         *time.Time       `json:"planned_at"`
+	RoutesAnalyzed    int              `json:"routes_analyzed"`
+	PlannedCost       float64          `json:"planned_cost"`
+	ActualCost        float64          `json:"actual_cost"`
+	CostDeviation     float64          `json:"cost_deviation"`
+	PlannedQuantity   float64          `json:"planned_quantity"`
+	DeliveredQuantity float64          `json:"delivered_quantity"`
+	QuantityDeviation float64          `json:"quantity_deviation"`
+	Causes            []DeviationCause `json:"causes"` // most significant first
+}
+
+// DeviationCause groups the deviations attributed to one cause
+type DeviationCause struct {
+	Cause          string          `json:"cause"` // inventory_staleness, traffic, failed_stops, manual_edit, unexplained
+	Rank           int             `json:"rank"`
+	Share          float64         `json:"share"` // larger of its share of total cost and quantity deviation
+	Occurrences    int             `json:"occurrences"`
+	CostImpact     float64         `json:"cost_impact"`
+	QuantityImpact float64         `json:"quantity_impact"`
+	Items          []DeviationItem `json:"items"`
+}
+
+// DeviationItem is a single route or stop deviation attributed to a cause
+type DeviationItem struct {
+	RouteID        int64   `json:"route_id"`
+	StopID         *int64  `json:"stop_id,omitempty"`
+	CustomerID     *int64  `json:"customer_id,omitempty"`
+	Day            int     `json:"day"`
+	CostImpact     float64 `json:"cost_impact"`
+	QuantityImpact float64 `json:"quantity_impact"`
+	Detail         string  `json:"detail"`
+}
+
+// RouteExplanation reports why each customer is on a route
+type RouteExplanation struct {
+	RouteID   int64                 `json:"route_id"`
+	PlanID    int64                 `json:"plan_id"`
+	Day       int                   `json:"day"`
+	Date      time.Time             `json:"date"`
+	VehicleID *int64                `json:"vehicle_id"`
+	Stops     []StopExplanationItem `json:"stops"`
+}
+
+// StopExplanationItem is one stop of a RouteExplanation. Explanation is nil
+// for stops the optimizer did not create, e.g. copied or restored ones.
+type StopExplanationItem struct {
+	StopID       int64            `json:"stop_id"`
+	Sequence     int              `json:"sequence"`
+	CustomerID   *int64           `json:"customer_id"`
+	CustomerName string           `json:"customer_name"`
+	Quantity     float64          `json:"quantity"`
+	Explanation  *StopExplanation `json:"explanation"`
+}
+
+// ReplayInputs is the optimizer request a plan would have had at a point in
+// time, rebuilt for back-testing solver versions against past scenarios
+type ReplayInputs struct {
+	PlanID                   int64           `json:"plan_id"`
+	AsOf                     time.Time       `json:"as_of"`
+	Base                     string          `json:"base"` // optimization_run or master_data
+	BaseRunID                *int64          `json:"base_run_id"`
+	CustomersFromSnapshots   int             `json:"customers_from_snapshots"`
+	CustomersWithoutSnapshot []int64         `json:"customers_without_snapshot"`
+	WarehouseFromSnapshot    bool            `json:"warehouse_from_snapshot"`
+	Request                  json.RawMessage `json:"request"`
+	Link                     *ArtifactLink   `json:"link,omitempty"`
+}
+
+// GeoJSONFeatureCollection is a GeoJSON (RFC 7946) feature collection
+type GeoJSONFeatureCollection struct {
+	Type     string           `json:"type"` // FeatureCollection
+	Features []GeoJSONFeature `json:"features"`
+}
+
+// GeoJSONFeature is a GeoJSON feature
+type GeoJSONFeature struct {
+	Type       string                 `json:"type"` // Feature
+	Geometry   GeoJSONGeometry        `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// GeoJSONGeometry is a Point ([longitude, latitude]) or a LineString (a list
+// of points)
+type GeoJSONGeometry struct {
+	Type        string      `json:"type"`
+	Coordinates interface{} `json:"coordinates"`
+}
+
+// PlanTimeline lays a plan's routes out as time-bounded bars, one lane per
+// vehicle, for dispatch boards
+type PlanTimeline struct {
+	PlanID              int64          `json:"plan_id"`
+	Lanes               []TimelineLane `json:"lanes"`
+	UnscheduledRouteIDs []int64        `json:"unscheduled_route_ids"` // routes without planned or arrival times
+}
+
+// TimelineLane holds a vehicle's routes in start order
+type TimelineLane struct {
+	VehicleID   *int64        `json:"vehicle_id"`
+	VehicleName string        `json:"vehicle_name"`
+	Bars        []TimelineBar `json:"bars"`
+}
+
+// TimelineBar is one route on the timeline
+type TimelineBar struct {
+	RouteID    int64          `json:"route_id"`
+	Day        int            `json:"day"`
+	Date       time.Time      `json:"date"`
+	DriverID   *int64         `json:"driver_id"`
+	DriverName string         `json:"driver_name"`
+	Start      time.Time      `json:"start"`
+	End        time.Time      `json:"end"`
+	Load       float64        `json:"load"`
+	Locked     bool           `json:"locked"`
+	Stops      []TimelineStop `json:"stops"`
+}
+
+// TimelineStop is the service window of a stop within a bar
+type TimelineStop struct {
+	StopID       int64     `json:"stop_id"`
+	Sequence     int       `json:"sequence"`
+	CustomerID   *int64    `json:"customer_id"`
+	CustomerName string    `json:"customer_name"`
+	Quantity     float64   `json:"quantity"`
+	Arrival      time.Time `json:"arrival"`
+	Departure    time.Time `json:"departure"`
+}
+
+// RouteLoadPlan is the order a route's cargo is loaded in, last drop
+// first, and the payload on each axle along the route
+type RouteLoadPlan struct {
+	RouteID              int64          `json:"route_id"`
+	VehicleID            *int64         `json:"vehicle_id"`
+	TotalWeight          float64        `json:"total_weight"` // kg
+	Legal                bool           `json:"legal"`
+	Items                []LoadPlanItem `json:"items"` // in load order
+	Legs                 []LoadPlanLeg  `json:"legs"`
+	Violations           []string       `json:"violations"`
+	MissingWeightStopIDs []int64        `json:"missing_weight_stop_ids"` // stops whose products have no weight, counted as 0 kg
+}
+
+// LoadPlanItem is one stop's cargo and where it goes on the load bed, from
+// 0 at the front to 1 at the door
+type LoadPlanItem struct {
+	LoadOrder    int     `json:"load_order"` // 1 is loaded first
+	StopID       int64   `json:"stop_id"`
+	Sequence     int     `json:"sequence"`
+	CustomerName string  `json:"customer_name"`
+	Quantity     float64 `json:"quantity"`
+	Weight       float64 `json:"weight"` // kg
+	BedFrom      float64 `json:"bed_from"`
+	BedTo        float64 `json:"bed_to"`
+}
+
+// LoadPlanLeg is the payload while driving on after a stop
+type LoadPlanLeg struct {
+	AfterSequence int     `json:"after_sequence"` // 0 when leaving the warehouse
+	Payload       float64 `json:"payload"`        // kg
+	FrontAxle     float64 `json:"front_axle"`     // kg
+	RearAxle      float64 `json:"rear_axle"`      // kg
+}
+
+// PlanLoadCheck lists the routes of a plan that cannot legally be loaded
+type PlanLoadCheck struct {
+	PlanID        int64           `json:"plan_id"`
+	RoutesChecked int             `json:"routes_checked"`
+	Illegal       []RouteLoadPlan `json:"illegal"`
+}
+
+// PlanCosts is the cost breakdown of a plan in total, per day and per route.
+// TotalCost is the sum of the components, so unlike the plan's total_cost it
+// includes holding cost.
+type PlanCosts struct {
+	PlanID int64 `json:"plan_id"`
+	CostBreakdown
+	TotalCost float64      `json:"total_cost"`
+	Days      []DayCosts   `json:"days"`
+	Routes    []RouteCosts `json:"routes"`
+}
+
+// DayCosts is the cost breakdown of a plan day
+type DayCosts struct {
+	Day  int       `json:"day"`
+	Date time.Time `json:"date"`
+	CostBreakdown
+	TotalCost float64 `json:"total_cost"`
+	Routes    int     `json:"routes"`
+}
+
+// RouteCosts is the cost breakdown of a route
+type RouteCosts struct {
+	RouteID     int64     `json:"route_id"`
+	Day         int       `json:"day"`
+	Date        time.Time `json:"date"`
+	VehicleID   *int64    `json:"vehicle_id"`
+	VehicleName string    `json:"vehicle_name"`
+	CostBreakdown
+	TotalCost float64 `json:"total_cost"`
+	EnergyKwh float64 `json:"energy_kwh,omitempty"` // used by an electric vehicle over the route's distance
+	// Derived is set for routes stored without a breakdown, whose fixed and
+	// distance cost are taken from the vehicle as it is now
+	Derived bool `json:"derived,omitempty"`
+}
+
+// Pagination describes one page of a list response
+type Pagination struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+}
+
+// SetTotal records the number of matching items
+func (p *Pagination) SetTotal(total int64) {
+	p.Total = total
+	p.TotalPages = int((total + int64(p.Limit) - 1) / int64(p.Limit))
+}
+
+// Offset is the number of items before the page
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// ArtifactLink is a signed download link to a stored file
+type ArtifactLink struct {
+	Key       string    `json:"key"`
+	URL       string    `json:"url"`
+	Size      int64     `json:"size"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// StoredFile is a file kept in artifact storage for a record
+type StoredFile struct {
+	Key         string `json:"key"`
+	Size        int64  `json:"size"`
+	ContentType string `json:"content_type"`
+}
+
+// ProofOfDelivery is what was captured at a stop, with signed links to the
+// stored signature and photos
+type ProofOfDelivery struct {
+	StopExecutionID int64          `json:"stop_execution_id"`
+	RecipientName   string         `json:"recipient_name"`
+	CapturedAt      *time.Time     `json:"captured_at"`
+	Signature       *ArtifactLink  `json:"signature"`
+	Photos          []ArtifactLink `json:"photos"`
+}
+
+// MaintenanceStatus is the state of read-only mode. Replayed and
+// ReplayFailed count the queued submissions applied when it was turned
+// off.
+type MaintenanceStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Since             *time.Time `json:"since,omitempty"`
+	Message           string     `json:"message,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds"`
+	Queued            int        `json:"queued"`
+	Replayed          int        `json:"replayed,omitempty"`
+	ReplayFailed      int        `json:"replay_failed,omitempty"`
+}
+
+// DoctorCheck is the outcome of one of the doctor's dependency checks:
+// "pass", "warn" or "fail"
+type DoctorCheck struct {
+	Name       string `json:"name"`
+	Status     string `json:"status"`
+	Detail     string `json:"detail"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// DoctorReport is the doctor's checks, with the worst of their statuses
+type DoctorReport struct {
+	Status    string        `json:"status"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Checks    []DoctorCheck `json:"checks"`
+}
+
+// VehicleEfficiency is a vehicle's fuel efficiency and running cost from
+// its logs. Consumption and cost per km are nil without odometer distance.
+type VehicleEfficiency struct {
+	VehicleID           int64    `json:"vehicle_id"`
+	Name                string   `json:"name"`
+	Electric            bool     `json:"electric"`
+	Logs                int      `json:"logs"`
+	DistanceKm          float64  `json:"distance_km"` // by odometer
+	FuelPurchased       float64  `json:"fuel_purchased"`
+	FuelCost            float64  `json:"fuel_cost"`
+	ConsumptionPer100Km *float64 `json:"consumption_per_100km"` // litres, kWh for electric vehicles
+	CostPerKm           *float64 `json:"cost_per_km"`           // fuel cost per odometer km
+	ConfiguredCostPerKm float64  `json:"configured_cost_per_km"`
+	ConfiguredVariance  *float64 `json:"configured_variance"` // cost_per_km less configured_cost_per_km
+}
+
+// VehicleEfficiencyReport is the vehicles' efficiency over a date range
+type VehicleEfficiencyReport struct {
+	From     string              `json:"from"`
+	To       string              `json:"to"`
+	Vehicles []VehicleEfficiency `json:"vehicles"`
+}
+
+// InventoryForecastDay is a customer's projected inventory on a day
+type InventoryForecastDay struct {
+	Date      string  `json:"date"`
+	Opening   float64 `json:"opening"`
+	Delivered float64 `json:"delivered"` // planned deliveries
+	Demand    float64 `json:"demand"`
+	Closing   float64 `json:"closing"`
+}
+
+// InventoryForecast projects a customer's inventory forward from its
+// current inventory, daily demand and the deliveries planned on optimized
+// routes. The dates are nil when the projection never reaches them.
+type InventoryForecast struct {
+	CustomerID       int64                  `json:"customer_id"`
+	CurrentInventory float64                `json:"current_inventory"`
+	DemandRate       float64                `json:"demand_rate"`
+	MinInventory     float64                `json:"min_inventory"`
+	MaxInventory     float64                `json:"max_inventory"`
+	StockoutDate     *string                `json:"stockout_date"`      // first day inventory runs out
+	BelowMinimumDate *string                `json:"below_minimum_date"` // first day inventory falls below min_inventory
+	Deliveries       []PlannedDelivery      `json:"deliveries"`
+	Days             []InventoryForecastDay `json:"days"`
+}
+
+// PlannedDelivery is a delivery in an inventory forecast
+type PlannedDelivery struct {
+	Date     string  `json:"date"`
+	PlanID   int64   `json:"plan_id"`
+	RouteID  int64   `json:"route_id"`
+	StopID   int64   `json:"stop_id"`
+	Quantity float64 `json:"quantity"`
+}
+
+// Alert kinds
+const (
+	AlertBelowMinimum      = "below_minimum"
+	AlertProjectedStockout = "projected_stockout"
+)
+
+// Alert severities
+const (
+	AlertWarning  = "warning"
+	AlertCritical = "critical"
+)
+
+// Alert statuses
+const (
+	AlertOpen         = "open"
+	AlertAcknowledged = "acknowledged"
+	AlertResolved     = "resolved"
+)
+
+// Alert is a low-stock condition of a customer or warehouse found by the
+// alert scan. A condition has at most one unresolved alert, which later
+// scans keep up to date and resolve once the condition clears.
+type Alert struct {
+	ID             int64      `gorm:"primaryKey" json:"id"`
+	EntityType     string     `gorm:"type:varchar(20);not null;index:idx_alert_entity" json:"entity_type"` // customer or warehouse
+	EntityID       int64      `gorm:"not null;type:integer;index:idx_alert_entity" json:"entity_id"`
+	EntityName     string     `gorm:"type:varchar(255)" json:"entity_name"`
+	Kind           string     `gorm:"type:varchar(50);not null" json:"kind"`
+	Severity       string     `gorm:"type:varchar(20);not null" json:"severity"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'open';index" json:"status"`
+	Message        string     `gorm:"type:text" json:"message"`
+	InventoryLevel float64    `gorm:"column:inventory_level;type:double precision" json:"inventory_level"`
+	Threshold      float64    `gorm:"type:double precision" json:"threshold"`              // min inventory or stock
+	StockoutDate   *time.Time `gorm:"column:stockout_date;type:date" json:"stockout_date"` // projected_stockout only
+	DetectedAt     time.Time  `gorm:"column:detected_at;type:timestamp;not null" json:"detected_at"`
+	LastSeenAt     time.Time  `gorm:"column:last_seen_at;type:timestamp;not null" json:"last_seen_at"`
+	AcknowledgedAt *time.Time `gorm:"column:acknowledged_at;type:timestamp" json:"acknowledged_at"`
+	AcknowledgedBy *int64     `gorm:"type:integer" json:"acknowledged_by"`
+	ResolvedAt     *time.Time `gorm:"column:resolved_at;type:timestamp" json:"resolved_at"`
+	ResolvedBy     *int64     `gorm:"type:integer" json:"resolved_by"` // nil when the scan resolved it
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (Alert) TableName() string {
+	return "alerts"
+}
+
+// AlertScan is the outcome of an alert scan
+type AlertScan struct {
+	Opened   int `json:"opened"`
+	Updated  int `json:"updated"`
+	Resolved int `json:"resolved"`
+}
+
+// IdempotencyKey is a POST request sent with an Idempotency-Key header and,
+// once it completed, its response, which retries with the same key get
+// instead of running the request again. Keys are per user.
+type IdempotencyKey struct {
+	ID          int64      `gorm:"primaryKey" json:"id"`
+	UserID      int64      `gorm:"not null;type:integer;uniqueIndex:idx_idempotency_user_key" json:"user_id"`
+	Key         string     `gorm:"type:varchar(255);not null;uniqueIndex:idx_idempotency_user_key" json:"key"`
+	RequestHash string     `gorm:"type:varchar(64);not null" json:"request_hash"` // SHA-256 of the method, path and body
+	Status      int        `gorm:"type:integer;not null;default:0" json:"status"` // 0 while the request runs
+	ContentType string     `gorm:"type:varchar(100)" json:"content_type"`
+	Body        []byte     `json:"-"`
+	CompletedAt *time.Time `gorm:"column:completed_at;type:timestamp" json:"completed_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
+
+// WebhookSubscription is an external endpoint posted the events it
+// subscribed to, signed with its secret
+type WebhookSubscription struct {
+	ID          int64          `gorm:"primaryKey" json:"id"`
+	URL         string         `gorm:"column:url;type:varchar(2048);not null" json:"url"`
+	Description string         `gorm:"type:varchar(255)" json:"description"`
+	Events      []string       `gorm:"type:text;serializer:json" json:"events"` // plan.optimized, execution.completed, inventory.low_stock
+	Secret      string         `gorm:"type:varchar(255);not null" json:"-"`
+	Active      bool           `gorm:"type:boolean;default:true" json:"active"`
+	CreatedBy   *int64         `gorm:"type:integer" json:"created_by"`
+	CreatedAt   time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// Webhook delivery statuses
+const (
+	WebhookPending   = "pending"
+	WebhookRetrying  = "retrying"
+	WebhookSucceeded = "succeeded"
+	WebhookFailed    = "failed"
+	WebhookCancelled = "cancelled"
+)
+
+// WebhookDelivery is an event posted, or to be posted, to a subscription,
+// with the outcome of its last attempt. Payload is the exact body sent.
+type WebhookDelivery struct {
+	ID             int64      `gorm:"primaryKey" json:"id"`
+	SubscriptionID int64      `gorm:"not null;type:integer;index:idx_webhook_delivery_subscription" json:"subscription_id"`
+	Event          string     `gorm:"type:varchar(50);not null" json:"event"`
+	Payload        string     `gorm:"type:text" json:"payload"`
+	Status         string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	Attempts       int        `gorm:"type:integer;default:0" json:"attempts"`
+	ResponseStatus *int       `gorm:"column:response_status;type:integer" json:"response_status"`
+	ResponseBody   string     `gorm:"column:response_body;type:text" json:"response_body,omitempty"` // first KB
+	LastError      string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	DurationMs     int64      `gorm:"column:duration_ms" json:"duration_ms"`
+	LastAttemptAt  *time.Time `gorm:"column:last_attempt_at;type:timestamp" json:"last_attempt_at"`
+	NextAttemptAt  *time.Time `gorm:"column:next_attempt_at;type:timestamp" json:"next_attempt_at"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at;type:timestamp" json:"delivered_at"`
+	RedeliveryOf   *int64     `gorm:"column:redelivery_of;type:integer" json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime;index:idx_webhook_delivery_subscription" json:"created_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
@@ -0,0 +1,285 @@
+// Package openapi builds an OpenAPI 3 document from a table of the API's
+// operations. Request and response bodies are described by reflection on
+// the Go types the handlers bind and respond with, so the schemas follow
+// the code; the table itself is checked against the registered routes by
+// the API's tests.
+package openapi
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Version is the OpenAPI version of the documents built
+const Version = "3.0.3"
+
+// Operation documents one endpoint. Bodies are given as example values of
+// their Go types, e.g. CustomerRequest{} or []models.Customer{}.
+type Operation struct {
+	Method string
+	// Path is the path as registered with gin below the base path, e.g.
+	// /plans/:id
+	Path    string
+	Summary string
+	// Public operations need no bearer token
+	Public bool
+	// Query lists the query parameters
+	Query []string
+	// Body is the JSON request body, if any; BodyOptional bodies may be
+	// left out
+	Body         interface{}
+	BodyOptional bool
+	// Files and Fields are the parts of a multipart/form-data request body
+	Files  []string
+	Fields []string
+	// Response is the data of a success response; nil for any object
+	Response interface{}
+	// Status is the status of a success response, 200 unless set
+	Status int
+	// Paginated responses carry a page of a list and its pagination
+	Paginated bool
+	// ContentType is the type of a response that is not the JSON envelope,
+	// such as a file download
+	ContentType string
+}
+
+// Config describes the API and the envelopes its responses come in
+type Config struct {
+	Title       string
+	Version     string
+	Description string
+	// BasePath is the path the operations are below, e.g. /api/v1
+	BasePath string
+	// ErrorBody and Pagination are example values of the error response
+	// body and of the pagination of list responses
+	ErrorBody  interface{}
+	Pagination interface{}
+	// PageProperties, when set, are example values of the properties
+	// paginated responses have beside data, in place of success and
+	// pagination
+	PageProperties map[string]interface{}
+	// ReadQuery lists the query parameters every GET operation with a
+	// JSON response takes
+	ReadQuery []string
+}
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Servers    []Server                        `json:"servers"`
+	Paths      map[string]map[string]*PathItem `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL string `json:"url"`
+}
+
+// PathItem is an operation of a path
+type PathItem struct {
+	Tags        []string              `json:"tags"`
+	Summary     string                `json:"summary"`
+	OperationID string                `json:"operationId"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required,omitempty"`
+	Schema   *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// securityScheme is the name of the bearer token scheme
+const securityScheme = "bearerAuth"
+
+// Build builds the document of the operations
+func Build(cfg Config, ops []Operation) *Document {
+	g := newGenerator()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    Info{Title: cfg.Title, Version: cfg.Version, Description: cfg.Description},
+		Servers: []Server{{URL: cfg.BasePath}},
+		Paths:   make(map[string]map[string]*PathItem),
+		Components: Components{
+			Schemas: g.schemas,
+			SecuritySchemes: map[string]SecurityScheme{
+				securityScheme: {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+			},
+		},
+	}
+	errorContent := map[string]MediaType{"application/json": {Schema: g.schema(cfg.ErrorBody)}}
+
+	for _, op := range ops {
+		path, params := convertPath(op.Path)
+		item := &PathItem{
+			Tags:        []string{tag(op.Path)},
+			Summary:     op.Summary,
+			OperationID: operationID(op.Method, op.Path),
+			Parameters:  params,
+			Responses: map[string]Response{
+				"default": {Description: "Error", Content: errorContent},
+			},
+		}
+		query := op.Query
+		if op.Method == http.MethodGet && op.ContentType == "" {
+			query = append(slices.Clone(query), cfg.ReadQuery...)
+		}
+		for _, name := range query {
+			item.Parameters = append(item.Parameters, Parameter{Name: name, In: "query", Schema: paramSchema(name)})
+		}
+		if !op.Public {
+			item.Security = []map[string][]string{{securityScheme: {}}}
+		}
+		switch {
+		case op.Body != nil:
+			item.RequestBody = &RequestBody{Required: !op.BodyOptional, Content: map[string]MediaType{
+				"application/json": {Schema: g.schema(op.Body)},
+			}}
+		case len(op.Files) > 0 || len(op.Fields) > 0:
+			form := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+			for _, name := range op.Files {
+				form.Properties[name] = &Schema{Type: "string", Format: "binary"}
+			}
+			for _, name := range op.Fields {
+				form.Properties[name] = &Schema{Type: "string"}
+			}
+			item.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+				"multipart/form-data": {Schema: form},
+			}}
+		}
+
+		status := op.Status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		success := Response{Description: http.StatusText(status)}
+		if op.ContentType != "" {
+			success.Content = map[string]MediaType{op.ContentType: {Schema: &Schema{Type: "string", Format: "binary"}}}
+		} else {
+			envelope := &Schema{
+				Type:     "object",
+				Required: []string{"success", "data"},
+				Properties: map[string]*Schema{
+					"success":  {Type: "boolean"},
+					"data":     g.schema(op.Response),
+					"warnings": {Type: "array", Items: &Schema{Type: "string"}},
+				},
+			}
+			switch {
+			case op.Paginated && cfg.PageProperties != nil:
+				envelope = &Schema{
+					Type:       "object",
+					Required:   []string{"data"},
+					Properties: map[string]*Schema{"data": g.schema(op.Response)},
+				}
+				for _, name := range slices.Sorted(maps.Keys(cfg.PageProperties)) {
+					envelope.Required = append(envelope.Required, name)
+					envelope.Properties[name] = g.schema(cfg.PageProperties[name])
+				}
+			case op.Paginated:
+				envelope.Properties["pagination"] = g.schema(cfg.Pagination)
+			}
+			success.Content = map[string]MediaType{"application/json": {Schema: envelope}}
+		}
+		item.Responses[strconv.Itoa(status)] = success
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*PathItem)
+		}
+		doc.Paths[path][strings.ToLower(op.Method)] = item
+	}
+	return doc
+}
+
+// Path is the OpenAPI form of a gin path, e.g. /plans/{id} for /plans/:id
+func Path(ginPath string) string {
+	path, _ := convertPath(ginPath)
+	return path
+}
+
+// convertPath turns a gin path into an OpenAPI one with its parameters
+func convertPath(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, s := range segments {
+		if s == "" || (s[0] != ':' && s[0] != '*') {
+			continue
+		}
+		name := s[1:]
+		params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: paramSchema(name)})
+		segments[i] = "{" + name + "}"
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// paramSchema is the schema of a path or query parameter: IDs and paging
+// are integers, the others strings
+func paramSchema(name string) *Schema {
+	if name == "id" || strings.HasSuffix(name, "_id") || name == "page" || name == "limit" || name == "version" {
+		return &Schema{Type: "integer", Format: "int64"}
+	}
+	return &Schema{Type: "string"}
+}
+
+// tag groups operations by the first segment of their path, e.g. Plan
+// templates for /plan-templates/:id
+func tag(path string) string {
+	first := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)[0]
+	first = strings.ReplaceAll(first, "-", " ")
+	if first == "" {
+		return "API"
+	}
+	return strings.ToUpper(first[:1]) + first[1:]
+}
+
+// operationID names an operation after its method and path, e.g.
+// getPlansIdSolutions
+func operationID(method, path string) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(method))
+	for _, word := range strings.FieldsFunc(path, func(r rune) bool {
+		return r == '/' || r == ':' || r == '*' || r == '-' || r == '_' || r == '.'
+	}) {
+		b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return b.String()
+}
@@ -0,0 +1,100 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+type testItem struct {
+	Quantity float64 `json:"quantity" binding:"gte=0"`
+}
+
+type testRequest struct {
+	Name     string     `json:"name" binding:"required,max=100"`
+	Kind     string     `json:"kind" binding:"omitempty,oneof=a b"`
+	Due      *time.Time `json:"due"`
+	Items    []testItem `json:"items" binding:"dive"`
+	Parent   *testRequest
+	internal string
+}
+
+type testError struct {
+	Error string `json:"error"`
+}
+
+func TestBuild(t *testing.T) {
+	doc := Build(Config{Title: "Test", BasePath: "/api/v1", ErrorBody: testError{}}, []Operation{
+		{Method: http.MethodPost, Path: "/things/:id/items/:item_id", Summary: "Add", Body: testRequest{}, Status: http.StatusCreated},
+		{Method: http.MethodGet, Path: "/things", Summary: "List", Public: true, Query: []string{"page", "q"}, Response: []testItem{}, Paginated: true},
+	})
+
+	add := doc.Paths["/things/{id}/items/{item_id}"]["post"]
+	if add == nil {
+		t.Fatalf("paths = %v, want the converted path", doc.Paths)
+	}
+	if add.OperationID != "postThingsIdItemsItemId" {
+		t.Errorf("operation ID = %q, want postThingsIdItemsItemId", add.OperationID)
+	}
+	if len(add.Parameters) != 2 || add.Parameters[1].Schema.Type != "integer" {
+		t.Errorf("parameters = %+v, want id and item_id as integers", add.Parameters)
+	}
+	if _, ok := add.Responses["201"]; !ok || len(add.Security) == 0 {
+		t.Errorf("add = %+v, want a secured 201", add)
+	}
+
+	s := doc.Components.Schemas["testRequest"]
+	if s == nil {
+		t.Fatalf("schemas = %v, want testRequest", doc.Components.Schemas)
+	}
+	if len(s.Required) != 1 || s.Required[0] != "name" {
+		t.Errorf("required = %v, want name", s.Required)
+	}
+	if s.Properties["name"].MaxLength == nil || *s.Properties["name"].MaxLength != 100 {
+		t.Errorf("name = %+v, want a max length of 100", s.Properties["name"])
+	}
+	if len(s.Properties["kind"].Enum) != 2 {
+		t.Errorf("kind = %+v, want an enum", s.Properties["kind"])
+	}
+	if due := s.Properties["due"]; due.Format != "date-time" || !due.Nullable {
+		t.Errorf("due = %+v, want a nullable date-time", due)
+	}
+	if s.Properties["Parent"].Ref != "#/components/schemas/testRequest" {
+		t.Errorf("Parent = %+v, want a reference to itself", s.Properties["Parent"])
+	}
+	if _, ok := s.Properties["internal"]; ok {
+		t.Error("unexported fields are documented")
+	}
+	if min := doc.Components.Schemas["testItem"].Properties["quantity"].Minimum; min == nil || *min != 0 {
+		t.Errorf("quantity minimum = %v, want 0", min)
+	}
+
+	list := doc.Paths["/things"]["get"]
+	if len(list.Security) != 0 || len(list.Parameters) != 2 {
+		t.Errorf("list = %+v, want a public operation with two query parameters", list)
+	}
+	envelope := list.Responses["200"].Content["application/json"].Schema
+	if envelope.Properties["pagination"] == nil || envelope.Properties["data"].Type != "array" {
+		t.Errorf("envelope = %+v, want an array of data with pagination", envelope)
+	}
+	if list.Tags[0] != "Things" {
+		t.Errorf("tags = %v, want Things", list.Tags)
+	}
+}
+
+func TestBuildPageProperties(t *testing.T) {
+	type testMeta struct {
+		Total int64 `json:"total"`
+	}
+	doc := Build(Config{Title: "Test", BasePath: "/api/v2", ErrorBody: testError{}, PageProperties: map[string]interface{}{"meta": testMeta{}}}, []Operation{
+		{Method: http.MethodGet, Path: "/things", Summary: "List", Response: []testItem{}, Paginated: true},
+	})
+
+	envelope := doc.Paths["/things"]["get"].Responses["200"].Content["application/json"].Schema
+	if envelope.Properties["success"] != nil || envelope.Properties["pagination"] != nil || envelope.Properties["meta"] == nil {
+		t.Errorf("envelope = %+v, want data and meta only", envelope)
+	}
+	if len(envelope.Required) != 2 || envelope.Required[1] != "meta" {
+		t.Errorf("required = %v, want data and meta", envelope.Required)
+	}
+}
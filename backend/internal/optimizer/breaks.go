@@ -0,0 +1,172 @@
+package optimizer
+
+import "math"
+
+// Types of the stops PlanMandatoryStops plans
+const (
+	StopRestBreak = "rest_break"
+	StopRefuel    = "refuel"
+	StopCharging  = "charging"
+)
+
+// MaxRestDetourKm is the furthest a place may take a route out of its way
+// to be used for a break or refuel stop
+const MaxRestDetourKm = 20.0
+
+// RestPlace is a place where break and refuel stops can be made
+type RestPlace struct {
+	ID        int64
+	Latitude  float64
+	Longitude float64
+	Fuel      bool // refuel stops can be made here
+	Charging  bool // electric vehicles can charge here
+}
+
+// BreakRules are the limits mandatory stops are planned against
+type BreakRules struct {
+	MaxDrivingMinutes int               // driving before a break; 0 plans no breaks
+	BreakMinutes      int               // length of a break
+	RefuelMinutes     int               // length of a refuel stop
+	RangeKm           map[int64]float64 // by vehicle ID; vehicles without a range never refuel
+	ChargeMinutes     map[int64]int     // electric vehicles by ID, with the minutes of a full charge
+	Places            []RestPlace
+}
+
+// MandatoryStop is a break or refuel stop planned on a route
+type MandatoryStop struct {
+	Type     string  // StopRestBreak, StopRefuel or StopCharging
+	Before   int     // index of the route stop it comes before; len(Stops) for the return to the warehouse
+	PlaceID  int64   // 0 when no place was near enough and the stop is made where the leg starts
+	Minutes  int     // time spent at the stop
+	DetourKm float64 // distance the stop adds to the route
+}
+
+// PlanMandatoryStops plans the break and refuel stops of every route,
+// indexed like routes. Each route is driven from the warehouse through its
+// stops and back at the vehicle's average speed. A refuel stop comes before
+// a leg that would take the distance since the last refuel past the
+// vehicle's range, and a break before a leg that would take the driving
+// time since the last break past the rules' limit; time spent serving
+// customers is not a break. Each stop is made at the place adding the
+// least detour to the leg, places with fuel only for refuel stops, and a
+// break falling due where the vehicle just refuelled is taken there.
+// Electric vehicles charge instead of refuelling, only at charging
+// stations, for the part of a full charge they used since the last one;
+// with no station near a leg they drive on and charge at the next station
+// in reach, if any. Routes with a customer missing from the request get no
+// stops.
+func PlanMandatoryStops(req *OptimizeRequest, routes []RouteResult, rules BreakRules) [][]MandatoryStop {
+	vehicles := make(map[int64]VehicleData, len(req.Vehicles))
+	for _, v := range req.Vehicles {
+		vehicles[v.ID] = v
+	}
+	customers := make(map[int64]CustomerData, len(req.Customers))
+	for _, c := range req.Customers {
+		customers[c.ID] = c
+	}
+	depot := RestPlace{Latitude: req.Warehouse.Latitude, Longitude: req.Warehouse.Longitude}
+
+	planned := make([][]MandatoryStop, len(routes))
+	for i, route := range routes {
+		rangeKm := rules.RangeKm[route.VehicleID]
+		if rules.MaxDrivingMinutes <= 0 && rangeKm <= 0 {
+			continue
+		}
+		points := make([]RestPlace, 0, len(route.Stops)+2)
+		points = append(points, depot)
+		known := true
+		for _, s := range route.Stops {
+			c, ok := customers[s.CustomerID]
+			if !ok {
+				known = false
+				break
+			}
+			points = append(points, RestPlace{Latitude: c.Latitude, Longitude: c.Longitude})
+		}
+		if !known {
+			continue
+		}
+		points = append(points, depot)
+
+		speed := vehicles[route.VehicleID].AverageSpeed
+		if speed <= 0 {
+			speed = DefaultAverageSpeed
+		}
+		chargeMinutes, electric := rules.ChargeMinutes[route.VehicleID]
+		planned[i] = planRouteStops(points, speed, rangeKm, electric, chargeMinutes, rules)
+	}
+	return planned
+}
+
+// planRouteStops plans the mandatory stops along points, the warehouse
+// first and last (see PlanMandatoryStops)
+func planRouteStops(points []RestPlace, speed, rangeKm float64, electric bool, chargeMinutes int, rules BreakRules) []MandatoryStop {
+	var stops []MandatoryStop
+	var driving, fuel float64 // minutes since the last break, km since the last refuel
+	minutes := func(km float64) float64 { return km / speed * 60 }
+	for i := 1; i < len(points); i++ {
+		from, to := points[i-1], points[i]
+		leg := distanceKm(from, to)
+		var at int64 // place the vehicle stopped at on this leg
+
+		if rangeKm > 0 && fuel+leg > rangeKm {
+			stop := MandatoryStop{Type: StopRefuel, Before: i - 1, Minutes: rules.RefuelMinutes}
+			usable := func(p RestPlace) bool { return p.Fuel }
+			if electric {
+				stop.Type = StopCharging
+				stop.Minutes = int(math.Ceil(float64(chargeMinutes) * math.Min(fuel/rangeKm, 1)))
+				usable = func(p RestPlace) bool { return p.Charging }
+			}
+			p, detour, ok := nearestRestPlace(from, to, rules.Places, usable)
+			if ok {
+				stop.PlaceID, stop.DetourKm = p.ID, detour
+				driving += minutes(distanceKm(from, p))
+				from, at = p, p.ID
+				leg = distanceKm(from, to)
+			}
+			if ok || !electric {
+				stops = append(stops, stop)
+				fuel = 0
+			}
+		}
+		if rules.MaxDrivingMinutes > 0 && driving+minutes(leg) > float64(rules.MaxDrivingMinutes) {
+			stop := MandatoryStop{Type: StopRestBreak, Before: i - 1, Minutes: rules.BreakMinutes, PlaceID: at}
+			if at == 0 {
+				if p, detour, ok := nearestRestPlace(from, to, rules.Places, nil); ok {
+					stop.PlaceID, stop.DetourKm = p.ID, detour
+					fuel += distanceKm(from, p)
+					from = p
+					leg = distanceKm(from, to)
+				}
+			}
+			stops = append(stops, stop)
+			driving = 0
+		}
+
+		driving += minutes(leg)
+		fuel += leg
+	}
+	return stops
+}
+
+// nearestRestPlace returns the place adding the least detour to the leg
+// from a to b, at most MaxRestDetourKm, and the detour. usable, when set,
+// picks the places the stop can be made at.
+func nearestRestPlace(a, b RestPlace, places []RestPlace, usable func(RestPlace) bool) (RestPlace, float64, bool) {
+	direct := distanceKm(a, b)
+	var best RestPlace
+	bestDetour, found := MaxRestDetourKm, false
+	for _, p := range places {
+		if usable != nil && !usable(p) {
+			continue
+		}
+		if detour := distanceKm(a, p) + distanceKm(p, b) - direct; detour <= bestDetour {
+			best, bestDetour, found = p, detour, true
+		}
+	}
+	return best, bestDetour, found
+}
+
+func distanceKm(a, b RestPlace) float64 {
+	return HaversineKm(a.Latitude, a.Longitude, b.Latitude, b.Longitude)
+}
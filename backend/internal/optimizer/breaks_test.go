@@ -0,0 +1,90 @@
+package optimizer
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestPlanMandatoryStops tests that breaks and refuel stops are planned
+// from driving time and range at the places nearest the route
+func TestPlanMandatoryStops(t *testing.T) {
+	req := &OptimizeRequest{
+		Vehicles: []VehicleData{{ID: 1, AverageSpeed: 60}, {ID: 2, AverageSpeed: 60}},
+		Customers: []CustomerData{
+			{ID: 1, Latitude: 2, Longitude: 0},
+			{ID: 2, Latitude: 2, Longitude: 0.5},
+			{ID: 3, Latitude: -3, Longitude: 0},
+		},
+	}
+	rules := BreakRules{
+		MaxDrivingMinutes: 270,
+		BreakMinutes:      45,
+		RefuelMinutes:     15,
+		RangeKm:           map[int64]float64{1: 260},
+		Places:            []RestPlace{{ID: 7, Latitude: 2, Longitude: 0.25, Fuel: true}},
+	}
+	routes := []RouteResult{
+		// about 222 km out, 56 km between the customers and 229 km back
+		{VehicleID: 1, Stops: []StopResult{{CustomerID: 1}, {CustomerID: 2}}},
+		// 334 km out and back, nowhere near the station
+		{VehicleID: 2, Stops: []StopResult{{CustomerID: 3}}},
+		{VehicleID: 1, Stops: []StopResult{{CustomerID: 99}}},
+	}
+
+	planned := PlanMandatoryStops(req, routes, rules)
+
+	// the tank runs dry between the customers, and the driver is due a
+	// break at the station
+	if got := planned[0]; len(got) != 2 ||
+		got[0].Type != StopRefuel || got[0].Before != 1 || got[0].PlaceID != 7 || got[0].Minutes != 15 || got[0].DetourKm > 0.01 ||
+		got[1].Type != StopRestBreak || got[1].Before != 1 || got[1].PlaceID != 7 || got[1].Minutes != 45 || got[1].DetourKm != 0 {
+		t.Errorf("route 1 stops = %+v, want a refuel and a break at the station before the second customer", got)
+	}
+	want := []MandatoryStop{
+		{Type: StopRestBreak, Before: 0, Minutes: 45},
+		{Type: StopRestBreak, Before: 1, Minutes: 45},
+	}
+	if got := planned[1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("route 2 stops = %+v, want a break without a place on each leg", got)
+	}
+	if planned[2] != nil {
+		t.Errorf("route with an unknown customer got stops %+v", planned[2])
+	}
+
+	rules.MaxDrivingMinutes = 0
+	if planned := PlanMandatoryStops(req, routes[1:2], rules); planned[0] != nil {
+		t.Errorf("stops without break rules or range = %+v, want none", planned[0])
+	}
+}
+
+// TestPlanChargingStops tests that electric vehicles charge only at
+// charging stations, for the part of the battery they used
+func TestPlanChargingStops(t *testing.T) {
+	req := &OptimizeRequest{
+		Vehicles:  []VehicleData{{ID: 1, AverageSpeed: 60}},
+		Customers: []CustomerData{{ID: 1, Latitude: 2, Longitude: 0}, {ID: 2, Latitude: 2, Longitude: 0.5}},
+	}
+	rules := BreakRules{
+		RefuelMinutes: 15,
+		RangeKm:       map[int64]float64{1: 260},
+		ChargeMinutes: map[int64]int{1: 120},
+		Places: []RestPlace{
+			{ID: 7, Latitude: 2, Longitude: 0.25, Fuel: true},
+			{ID: 8, Latitude: 1, Longitude: 0.5, Charging: true},
+		},
+	}
+	// about 222 km out, 56 km between the customers and 229 km back
+	routes := []RouteResult{{VehicleID: 1, Stops: []StopResult{{CustomerID: 1}, {CustomerID: 2}}}}
+
+	// no charger near the leg between the customers, so the battery is
+	// charged on the way back after 278 km
+	planned := PlanMandatoryStops(req, routes, rules)
+	if got := planned[0]; len(got) != 1 || got[0].Type != StopCharging || got[0].Before != 2 || got[0].PlaceID != 8 || got[0].Minutes != 120 {
+		t.Errorf("stops = %+v, want a full charge at the charger on the way back", got)
+	}
+
+	rules.Places = rules.Places[:1]
+	if planned := PlanMandatoryStops(req, routes, rules); planned[0] != nil {
+		t.Errorf("stops without a charger = %+v, want none", planned[0])
+	}
+}
@@ -0,0 +1,72 @@
+package optimizer
+
+// Objectives plans can be optimized for
+const (
+	ObjectiveCost   = "cost"
+	ObjectiveCarbon = "carbon"
+)
+
+// CarbonWeights are the weights of CO2 against cost tried, in order, when
+// optimizing for carbon: routes priced on emissions alone first, then a
+// blend closer to cost when those cost too much
+var CarbonWeights = []float64{1, 0.5}
+
+// CarbonRequest returns a copy of req the solver prices on emissions.
+// Each vehicle's cost per km becomes a blend of its own and its kg of CO2
+// per km, weighted by weight from 0 (cost only) to 1 (CO2 only), and its
+// fixed cost shrinks with the cost's share. CO2 is converted to money at
+// the fleet's average cost per kg so both halves weigh alike.
+func CarbonRequest(req *OptimizeRequest, co2PerKm map[int64]float64, weight float64) *OptimizeRequest {
+	var costSum, co2Sum float64
+	for _, v := range req.Vehicles {
+		costSum += v.CostPerKm
+		co2Sum += co2PerKm[v.ID]
+	}
+	price := 1.0
+	if costSum > 0 && co2Sum > 0 {
+		price = costSum / co2Sum
+	}
+
+	carbon := *req
+	carbon.Vehicles = make([]VehicleData, len(req.Vehicles))
+	for i, v := range req.Vehicles {
+		v.CostPerKm = (1-weight)*v.CostPerKm + weight*co2PerKm[v.ID]*price
+		v.FixedCost = (1 - weight) * v.FixedCost
+		carbon.Vehicles[i] = v
+	}
+	return &carbon
+}
+
+// Emissions returns the kg of CO2 routes emit at their vehicles' kg per km
+func Emissions(routes []RouteResult, co2PerKm map[int64]float64) float64 {
+	var kg float64
+	for _, r := range routes {
+		kg += r.TotalDistance * co2PerKm[r.VehicleID]
+	}
+	return kg
+}
+
+// OperatingCost returns the fixed and per-km cost of routes with the
+// vehicles of req, leaving out anything else the solver charged
+func OperatingCost(req *OptimizeRequest, routes []RouteResult) float64 {
+	vehicles := make(map[int64]VehicleData, len(req.Vehicles))
+	for _, v := range req.Vehicles {
+		vehicles[v.ID] = v
+	}
+	var cost float64
+	for _, r := range routes {
+		v := vehicles[r.VehicleID]
+		cost += v.FixedCost + r.TotalDistance*v.CostPerKm
+	}
+	return cost
+}
+
+// RepriceRoutes replaces the costs of a response solved from a carbon
+// request with the operating cost of its routes under req
+func RepriceRoutes(req *OptimizeRequest, resp *OptimizeResponse) {
+	resp.TotalCost = 0
+	for i := range resp.Routes {
+		resp.Routes[i].TotalCost = OperatingCost(req, resp.Routes[i:i+1])
+		resp.TotalCost += resp.Routes[i].TotalCost
+	}
+}
@@ -0,0 +1,42 @@
+package optimizer
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCarbonRequest tests that emissions are priced at the fleet's average
+// cost per kg and blended with cost by weight
+func TestCarbonRequest(t *testing.T) {
+	req := &OptimizeRequest{Vehicles: []VehicleData{
+		{ID: 1, CostPerKm: 1, FixedCost: 50},
+		{ID: 2, CostPerKm: 2},
+	}}
+	co2 := map[int64]float64{1: 1, 2: 0}
+
+	carbon := CarbonRequest(req, co2, 1)
+	if got := carbon.Vehicles; got[0].CostPerKm != 3 || got[0].FixedCost != 0 || got[1].CostPerKm != 0 {
+		t.Errorf("CarbonRequest(1) vehicles = %+v, want 3 and 0 per km without fixed cost", got)
+	}
+	blend := CarbonRequest(req, co2, 0.5)
+	if got := blend.Vehicles; got[0].CostPerKm != 2 || got[0].FixedCost != 25 || got[1].CostPerKm != 1 {
+		t.Errorf("CarbonRequest(0.5) vehicles = %+v, want 2 and 1 per km with half the fixed cost", got)
+	}
+	if req.Vehicles[0].CostPerKm != 1 {
+		t.Error("CarbonRequest() changed the original request")
+	}
+
+	routes := []RouteResult{{VehicleID: 1, TotalDistance: 10}, {VehicleID: 2, TotalDistance: 20}}
+	if kg := Emissions(routes, co2); kg != 10 {
+		t.Errorf("Emissions() = %v, want 10", kg)
+	}
+	if cost := OperatingCost(req, routes); math.Abs(cost-100) > 1e-9 {
+		t.Errorf("OperatingCost() = %v, want 100", cost)
+	}
+
+	resp := &OptimizeResponse{Routes: routes, TotalCost: 12}
+	RepriceRoutes(req, resp)
+	if resp.TotalCost != 100 || resp.Routes[0].TotalCost != 60 || resp.Routes[1].TotalCost != 40 {
+		t.Errorf("RepriceRoutes() = %v with routes %+v, want 100 split 60 and 40", resp.TotalCost, resp.Routes)
+	}
+}
@@ -0,0 +1,170 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limits bound the size of a single optimizer call. Zero leaves a limit
+// unchecked.
+type Limits struct {
+	MaxHorizonDays  int
+	MaxCustomers    int
+	MaxCustomerDays int // customers times horizon days
+}
+
+// Window is a run of days of a request solved in one optimizer call.
+// FirstDay is the 1-based day of the full request.
+type Window struct {
+	FirstDay int `json:"first_day"`
+	Days     int `json:"days"`
+}
+
+// LastDay is the last day of the full request the window covers
+func (w Window) LastDay() int {
+	return w.FirstDay + w.Days - 1
+}
+
+// Segment splits a request's horizon into the windows it is solved in. A
+// request within the limits is a single window. A longer one is split into
+// windows of equal length (the last may be shorter) when chunk is true and
+// rejected otherwise. Customer counts cannot be split over time, so more
+// customers than MaxCustomers, or than MaxCustomerDays allows for a single
+// day, are always rejected.
+func (l Limits) Segment(req *OptimizeRequest, chunk bool) ([]Window, error) {
+	horizon := req.PlanningHorizon
+	customers := len(req.Customers)
+	if l.MaxCustomers > 0 && customers > l.MaxCustomers {
+		return nil, fmt.Errorf("%d customers exceed the optimizer limit of %d", customers, l.MaxCustomers)
+	}
+
+	days, limit := horizon, ""
+	if l.MaxHorizonDays > 0 && days > l.MaxHorizonDays {
+		days = l.MaxHorizonDays
+		limit = fmt.Sprintf("a %d-day horizon exceeds the optimizer limit of %d days", horizon, l.MaxHorizonDays)
+	}
+	if l.MaxCustomerDays > 0 && customers > 0 {
+		perCall := l.MaxCustomerDays / customers
+		if perCall < 1 {
+			return nil, fmt.Errorf("%d customers exceed the optimizer limit of %d customer-days for a single day", customers, l.MaxCustomerDays)
+		}
+		if days > perCall {
+			days = perCall
+			limit = fmt.Sprintf("%d customers over %d days exceed the optimizer limit of %d customer-days", customers, horizon, l.MaxCustomerDays)
+		}
+	}
+	if days >= horizon {
+		return []Window{{FirstDay: 1, Days: horizon}}, nil
+	}
+	if !chunk {
+		return nil, fmt.Errorf("%s and chunking is disabled", limit)
+	}
+
+	windows := make([]Window, 0, (horizon+days-1)/days)
+	for first := 1; first <= horizon; first += days {
+		windows = append(windows, Window{FirstDay: first, Days: min(days, horizon-first+1)})
+	}
+	return windows, nil
+}
+
+// WindowRequest builds the request for one window of req. Inventories are
+// carried forward from the start of the horizon one day at a time: each day
+// the deliveries of earlier routes, numbered in days of req, and of locked
+// routes are added, up to the customer's maximum inventory when it has one,
+// and daily demand is taken off, with stock that ran out counted as zero.
+// Warehouse stock is reduced by the deliveries of earlier routes. Vehicle
+// availability and locked routes are limited to the window and renumbered
+// from its first day.
+func WindowRequest(req *OptimizeRequest, w Window, earlier []RouteResult) (*OptimizeRequest, error) {
+	start, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %w", err)
+	}
+
+	// delivered[day-1] holds the deliveries of each day before the window
+	delivered := make([]map[int64]float64, w.FirstDay-1)
+	for i := range delivered {
+		delivered[i] = make(map[int64]float64)
+	}
+	shipped := 0.0
+	for _, route := range earlier {
+		if route.Day < 1 || route.Day >= w.FirstDay {
+			continue
+		}
+		for _, stop := range route.Stops {
+			delivered[route.Day-1][stop.CustomerID] += stop.Quantity
+			shipped += stop.Quantity
+		}
+	}
+	for _, route := range req.LockedRoutes {
+		if route.Day < 1 || route.Day >= w.FirstDay {
+			continue
+		}
+		for _, stop := range route.Stops {
+			delivered[route.Day-1][stop.CustomerID] += stop.Quantity
+		}
+	}
+
+	out := *req
+	out.StartDate = start.AddDate(0, 0, w.FirstDay-1).Format("2006-01-02")
+	out.PlanningHorizon = w.Days
+	out.Warehouse.Stock = math.Max(req.Warehouse.Stock-shipped, 0)
+
+	out.Customers = make([]CustomerData, len(req.Customers))
+	for i, c := range req.Customers {
+		for _, day := range delivered {
+			c.CurrentInventory += day[c.ID]
+			if c.MaxInventory > 0 {
+				c.CurrentInventory = math.Min(c.CurrentInventory, c.MaxInventory)
+			}
+			c.CurrentInventory = math.Max(c.CurrentInventory-c.DemandRate, 0)
+		}
+		out.Customers[i] = c
+	}
+
+	out.Vehicles = make([]VehicleData, 0, len(req.Vehicles))
+	for _, v := range req.Vehicles {
+		if len(v.AvailableDays) > 0 {
+			var days []int
+			for _, day := range v.AvailableDays {
+				if day >= w.FirstDay && day <= w.LastDay() {
+					days = append(days, day-w.FirstDay+1)
+				}
+			}
+			if len(days) == 0 {
+				continue
+			}
+			v.AvailableDays = days
+		}
+		out.Vehicles = append(out.Vehicles, v)
+	}
+
+	out.LockedRoutes = nil
+	for _, route := range req.LockedRoutes {
+		if route.Day >= w.FirstDay && route.Day <= w.LastDay() {
+			route.Day -= w.FirstDay - 1
+			out.LockedRoutes = append(out.LockedRoutes, route)
+		}
+	}
+	return &out, nil
+}
+
+// StitchWindow appends the result of one window to the result of the full
+// request, renumbering its route days and prefixing its solver message with
+// the days it covers
+func StitchWindow(total *OptimizeResponse, w Window, part *OptimizeResponse) {
+	for _, route := range part.Routes {
+		route.Day += w.FirstDay - 1
+		total.Routes = append(total.Routes, route)
+	}
+	total.TotalCost += part.TotalCost
+	total.TotalDistance += part.TotalDistance
+	if part.Message == "" {
+		return
+	}
+	if total.Message != "" {
+		total.Message += "; "
+	}
+	total.Message += fmt.Sprintf("days %d-%d: %s", w.FirstDay, w.LastDay(), part.Message)
+}
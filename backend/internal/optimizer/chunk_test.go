@@ -0,0 +1,122 @@
+package optimizer
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestSegment tests splitting requests into windows under the limits
+func TestSegment(t *testing.T) {
+	customers := func(n int) []CustomerData { return make([]CustomerData, n) }
+	tests := []struct {
+		name    string
+		limits  Limits
+		req     *OptimizeRequest
+		chunk   bool
+		want    string
+		wantErr string
+	}{
+		{"within limits", Limits{MaxHorizonDays: 31}, &OptimizeRequest{PlanningHorizon: 7, Customers: customers(10)}, false, "[{1 7}]", ""},
+		{"unlimited", Limits{}, &OptimizeRequest{PlanningHorizon: 90, Customers: customers(5000)}, false, "[{1 90}]", ""},
+		{"horizon chunked", Limits{MaxHorizonDays: 30}, &OptimizeRequest{PlanningHorizon: 70, Customers: customers(10)}, true, "[{1 30} {31 30} {61 10}]", ""},
+		{"horizon rejected", Limits{MaxHorizonDays: 30}, &OptimizeRequest{PlanningHorizon: 70}, false, "", "70-day horizon exceeds the optimizer limit of 30 days"},
+		{"customer-days chunked", Limits{MaxHorizonDays: 31, MaxCustomerDays: 30000}, &OptimizeRequest{PlanningHorizon: 14, Customers: customers(5000)}, true, "[{1 6} {7 6} {13 2}]", ""},
+		{"customer-days rejected", Limits{MaxCustomerDays: 30000}, &OptimizeRequest{PlanningHorizon: 14, Customers: customers(5000)}, false, "", "5000 customers over 14 days exceed"},
+		{"too many customers", Limits{MaxCustomers: 100}, &OptimizeRequest{PlanningHorizon: 7, Customers: customers(101)}, true, "", "101 customers exceed the optimizer limit of 100"},
+		{"too many customers for one day", Limits{MaxCustomerDays: 100}, &OptimizeRequest{PlanningHorizon: 7, Customers: customers(101)}, true, "", "customer-days for a single day"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			windows, err := tt.limits.Segment(tt.req, tt.chunk)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Segment() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Segment() error = %v", err)
+			}
+			if got := fmt.Sprint(windows); got != tt.want {
+				t.Errorf("Segment() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWindowRequest tests carrying inventories, availability and locked
+// routes into a later window
+func TestWindowRequest(t *testing.T) {
+	req := &OptimizeRequest{
+		StartDate:       "2024-01-01",
+		PlanningHorizon: 10,
+		Warehouse:       WarehouseData{Stock: 1000},
+		Customers: []CustomerData{
+			{ID: 1, DemandRate: 10, CurrentInventory: 100, MaxInventory: 100},
+			{ID: 2, DemandRate: 30, CurrentInventory: 50},
+		},
+		Vehicles: []VehicleData{
+			{ID: 1},
+			{ID: 2, AvailableDays: []int{2, 6, 7}},
+			{ID: 3, AvailableDays: []int{1, 2}},
+		},
+		LockedRoutes: []RouteResult{
+			{Day: 0, VehicleID: 9, Stops: []StopResult{{CustomerID: 1, Quantity: 1000}}}, // context before the horizon
+			{Day: 3, VehicleID: 9, Stops: []StopResult{{CustomerID: 1, Quantity: 10}}},
+			{Day: 7, VehicleID: 9},
+		},
+	}
+	earlier := []RouteResult{
+		{Day: 2, Stops: []StopResult{{CustomerID: 1, Quantity: 40}}},
+		{Day: 5, Stops: []StopResult{{CustomerID: 2, Quantity: 60}}},
+		{Day: 6, Stops: []StopResult{{CustomerID: 2, Quantity: 500}}},
+	}
+
+	got, err := WindowRequest(req, Window{FirstDay: 6, Days: 5}, earlier)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.StartDate != "2024-01-06" || got.PlanningHorizon != 5 {
+		t.Errorf("window = %s for %d days, want 2024-01-06 for 5", got.StartDate, got.PlanningHorizon)
+	}
+	if got.Warehouse.Stock != 900 {
+		t.Errorf("warehouse stock = %v, want 900", got.Warehouse.Stock)
+	}
+	// Customer 1 is filled to its maximum of 100 on day 2 and gets 10 more
+	// on day 3: 90, 90, 90, 80, 70. Customer 2 runs out on day 2 and the 60
+	// delivered on day 5 still count: 20, 0, 0, 0, 30.
+	if got.Customers[0].CurrentInventory != 70 || got.Customers[1].CurrentInventory != 30 {
+		t.Errorf("inventories = %v and %v, want 70 and 30", got.Customers[0].CurrentInventory, got.Customers[1].CurrentInventory)
+	}
+	if len(got.Vehicles) != 2 || len(got.Vehicles[0].AvailableDays) != 0 || fmt.Sprint(got.Vehicles[1].AvailableDays) != "[1 2]" {
+		t.Errorf("vehicles = %+v, want vehicle 1 every day and vehicle 2 on days 1 and 2", got.Vehicles)
+	}
+	if len(got.LockedRoutes) != 1 || got.LockedRoutes[0].Day != 2 {
+		t.Errorf("locked routes = %+v, want the day 7 route on day 2", got.LockedRoutes)
+	}
+	if req.Customers[0].CurrentInventory != 100 || len(req.Vehicles[1].AvailableDays) != 3 {
+		t.Error("WindowRequest() modified the full request")
+	}
+}
+
+// TestStitchWindow tests renumbering and totalling window results
+func TestStitchWindow(t *testing.T) {
+	total := &OptimizeResponse{Success: true}
+	StitchWindow(total, Window{FirstDay: 1, Days: 4}, &OptimizeResponse{TotalCost: 100, TotalDistance: 10, Message: "ok", Routes: []RouteResult{{Day: 2}}})
+	StitchWindow(total, Window{FirstDay: 5, Days: 4}, &OptimizeResponse{TotalCost: 50, TotalDistance: 5, Message: "ok", Routes: []RouteResult{{Day: 1}, {Day: 4}}})
+
+	var days []int
+	for _, r := range total.Routes {
+		days = append(days, r.Day)
+	}
+	if fmt.Sprint(days) != "[2 5 8]" {
+		t.Errorf("route days = %v, want [2 5 8]", days)
+	}
+	if total.TotalCost != 150 || total.TotalDistance != 15 {
+		t.Errorf("totals = %v / %v, want 150 / 15", total.TotalCost, total.TotalDistance)
+	}
+	if total.Message != "days 1-4: ok; days 5-8: ok" {
+		t.Errorf("message = %q", total.Message)
+	}
+}
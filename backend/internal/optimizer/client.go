@@ -11,6 +11,8 @@ import (
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	// grpc is set when the client talks gRPC instead of JSON over HTTP
+	grpc *grpcTransport
 }
 
 func NewClient(baseURL string) *Client {
@@ -22,6 +24,44 @@ func NewClient(baseURL string) *Client {
 	}
 }
 
+// NewGRPCClient creates a client that calls the optimizer over gRPC at addr (host:port)
+func NewGRPCClient(addr string) (*Client, error) {
+	transport, err := newGRPCTransport(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{baseURL: addr, grpc: transport}, nil
+}
+
+// New creates a client for the configured protocol ("http" or "grpc")
+func New(protocol, httpURL, grpcAddr string) (*Client, error) {
+	switch protocol {
+	case "", "http":
+		return NewClient(httpURL), nil
+	case "grpc":
+		return NewGRPCClient(grpcAddr)
+	default:
+		return nil, fmt.Errorf("unknown optimizer protocol %q", protocol)
+	}
+}
+
+// Protocol returns the transport the client uses ("http" or "grpc")
+func (c *Client) Protocol() string {
+	if c.grpc != nil {
+		return "grpc"
+	}
+	return "http"
+}
+
+// Progress reports how far a running optimization has got
+type Progress struct {
+	Day       int     `json:"day"`
+	TotalDays int     `json:"total_days"`
+	Routes    int     `json:"routes"`
+	Cost      float64 `json:"cost"`
+	Distance  float64 `json:"distance"`
+}
+
 // OptimizeRequest represents the request to the optimizer service
 type OptimizeRequest struct {
 	Warehouse  WarehouseData   `json:"warehouse"`
@@ -29,6 +69,20 @@ type OptimizeRequest struct {
 	Vehicles   []VehicleData   `json:"vehicles"`
 	PlanningHorizon int        `json:"planning_horizon"`
 	StartDate  string          `json:"start_date"`
+	DistanceMatrix *DistanceMatrix `json:"distance_matrix,omitempty"`
+	// LockedRoutes are already-committed routes the solver must not change.
+	// Their deliveries on days within the horizon count towards their
+	// customers' inventories, and those customers are not visited again on
+	// those days; routes on days before the horizon are sent for context.
+	LockedRoutes []RouteResult `json:"locked_routes,omitempty"`
+}
+
+// DistanceMatrix carries road distances (km) and durations (minutes) between
+// locations. LocationIDs gives the row/column order; the warehouse is ID 0.
+type DistanceMatrix struct {
+	LocationIDs []int64     `json:"location_ids"`
+	Distances   [][]float64 `json:"distances"`
+	Durations   [][]float64 `json:"durations"`
 }
 
 type WarehouseData struct {
@@ -47,6 +101,33 @@ type CustomerData struct {
 	CurrentInventory float64 `json:"current_inventory"`
 	MinInventory     float64 `json:"min_inventory"`
 	Priority         int     `json:"priority"`
+	// ServiceTags are skills a vehicle must have to serve the customer
+	ServiceTags []string `json:"service_tags,omitempty"`
+	// MinDropSize is the smallest quantity worth delivering; smaller
+	// deliveries are skipped
+	MinDropSize float64 `json:"min_drop_size,omitempty"`
+	// HoldingCost per unit and day of stock on hand
+	HoldingCost float64 `json:"holding_cost,omitempty"`
+	// Products break the customer down by product when it keeps several;
+	// the fields above then sum their policies
+	Products []ProductData `json:"products,omitempty"`
+}
+
+// ProductData is a customer's inventory of one product with its reorder
+// policy
+type ProductData struct {
+	ProductID        int64   `json:"product_id"`
+	DemandRate       float64 `json:"demand_rate"`
+	CurrentInventory float64 `json:"current_inventory"`
+	MaxInventory     float64 `json:"max_inventory"`
+	ReorderPoint     float64 `json:"reorder_point"`
+	OrderQuantity    float64 `json:"order_quantity"`
+	// Deliverable is what the product takes on a delivery on the first day:
+	// its order quantity once at its reorder point, else nothing
+	Deliverable float64 `json:"deliverable"`
+	// DaysToReorder is when the product reaches its reorder point, nil
+	// without demand
+	DaysToReorder *float64 `json:"days_to_reorder,omitempty"`
 }
 
 type VehicleData struct {
@@ -55,6 +136,19 @@ type VehicleData struct {
 	CostPerKm   float64 `json:"cost_per_km"`
 	FixedCost   float64 `json:"fixed_cost"`
 	MaxDistance float64 `json:"max_distance"`
+	// AvailableDays lists the 1-based days the vehicle has a rostered driver;
+	// empty means the vehicle is available every day
+	AvailableDays []int `json:"available_days,omitempty"`
+	// MaxWorkingHours caps a route's duration; 0 means unlimited
+	MaxWorkingHours float64 `json:"max_working_hours,omitempty"`
+	// AverageSpeed in km/h; 0 uses DefaultAverageSpeed
+	AverageSpeed float64 `json:"average_speed,omitempty"`
+	// ShiftStart and ShiftEnd bound the route as HH:MM; empty uses
+	// DefaultShiftStart and no end limit
+	ShiftStart string `json:"shift_start,omitempty"`
+	ShiftEnd   string `json:"shift_end,omitempty"`
+	// AllowedTags are the customer service tags the vehicle can serve
+	AllowedTags []string `json:"allowed_tags,omitempty"`
 }
 
 // OptimizeResponse represents the response from the optimizer service
@@ -73,7 +167,11 @@ type RouteResult struct {
 	TotalDistance float64      `json:"total_distance"`
 	TotalCost     float64      `json:"total_cost"`
 	TotalLoad     float64      `json:"total_load"`
-	Stops         []StopResult `json:"stops"`
+	// StartTime and EndTime are the planned departure from and return to
+	// the warehouse as HH:MM
+	StartTime string       `json:"start_time,omitempty"`
+	EndTime   string       `json:"end_time,omitempty"`
+	Stops     []StopResult `json:"stops"`
 }
 
 type StopResult struct {
@@ -85,6 +183,10 @@ type StopResult struct {
 
 // HealthCheck checks if the optimizer service is available
 func (c *Client) HealthCheck() error {
+	if c.grpc != nil {
+		return c.grpc.healthCheck()
+	}
+
 	resp, err := c.httpClient.Get(c.baseURL + "/health")
 	if err != nil {
 		return fmt.Errorf("optimizer service unavailable: %w", err)
@@ -99,6 +201,17 @@ func (c *Client) HealthCheck() error {
 
 // Optimize sends the optimization request to the Python service
 func (c *Client) Optimize(req *OptimizeRequest) (*OptimizeResponse, error) {
+	return c.OptimizeWithProgress(req, nil)
+}
+
+// OptimizeWithProgress runs an optimization and calls onProgress as the
+// solver reports intermediate solutions. Only the gRPC transport streams
+// progress; over HTTP onProgress is never called.
+func (c *Client) OptimizeWithProgress(req *OptimizeRequest, onProgress func(Progress)) (*OptimizeResponse, error) {
+	if c.grpc != nil {
+		return c.grpc.optimize(req, onProgress)
+	}
+
 	jsonData, err := json.Marshal(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
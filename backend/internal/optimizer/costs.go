@@ -0,0 +1,56 @@
+package optimizer
+
+import "math"
+
+// RouteCosts decomposes the cost of a route
+type RouteCosts struct {
+	Fixed    float64 // the vehicle's fixed cost
+	Distance float64 // distance times the vehicle's cost per km
+	Holding  float64 // holding the delivered stock at the customers
+	Penalty  float64 // what the solver charged on top of fixed and distance cost
+}
+
+// CostRoutes decomposes the cost of every route, indexed like routes.
+// Fixed and distance cost come from the route's vehicle in the request and
+// penalty is the rest of the route's total cost. Holding cost is not part
+// of the solver's total: it is the customers' holding cost per unit and day
+// for each delivered quantity until it is consumed at the demand rate, at
+// most to the end of the horizon.
+func CostRoutes(req *OptimizeRequest, routes []RouteResult) []RouteCosts {
+	vehicles := make(map[int64]VehicleData, len(req.Vehicles))
+	for _, v := range req.Vehicles {
+		vehicles[v.ID] = v
+	}
+	customers := make(map[int64]CustomerData, len(req.Customers))
+	for _, c := range req.Customers {
+		customers[c.ID] = c
+	}
+
+	costs := make([]RouteCosts, len(routes))
+	for i, route := range routes {
+		c := &costs[i]
+		if v, ok := vehicles[route.VehicleID]; ok {
+			c.Fixed = v.FixedCost
+			c.Distance = route.TotalDistance * v.CostPerKm
+		}
+		// Rounding in the solver's total must not turn into a penalty
+		if penalty := route.TotalCost - c.Fixed - c.Distance; penalty > 0.01 {
+			c.Penalty = penalty
+		}
+
+		remaining := float64(req.PlanningHorizon - route.Day + 1)
+		for _, stop := range route.Stops {
+			customer, ok := customers[stop.CustomerID]
+			if !ok || customer.HoldingCost <= 0 || stop.Quantity <= 0 {
+				continue
+			}
+			days := remaining
+			if customer.DemandRate > 0 {
+				days = math.Min(stop.Quantity/customer.DemandRate, remaining)
+			}
+			// Stock consumed evenly is held half the time on average
+			c.Holding += customer.HoldingCost * stop.Quantity * math.Max(days, 0) / 2
+		}
+	}
+	return costs
+}
@@ -0,0 +1,39 @@
+package optimizer
+
+import (
+	"math"
+	"testing"
+)
+
+// TestCostRoutes tests the decomposition of route costs
+func TestCostRoutes(t *testing.T) {
+	req := &OptimizeRequest{
+		PlanningHorizon: 5,
+		Vehicles:        []VehicleData{{ID: 1, FixedCost: 100, CostPerKm: 2}},
+		Customers: []CustomerData{
+			{ID: 1, DemandRate: 10, HoldingCost: 0.5},
+			{ID: 2, HoldingCost: 1},
+			{ID: 3, DemandRate: 10},
+		},
+	}
+	routes := []RouteResult{
+		{Day: 1, VehicleID: 1, TotalDistance: 50, TotalCost: 200, Stops: []StopResult{{CustomerID: 1, Quantity: 20}, {CustomerID: 3, Quantity: 40}}},
+		{Day: 4, VehicleID: 1, TotalDistance: 10, TotalCost: 150, Stops: []StopResult{{CustomerID: 1, Quantity: 100}, {CustomerID: 2, Quantity: 4}}},
+		{Day: 2, VehicleID: 9, TotalDistance: 10, TotalCost: 30},
+	}
+
+	costs := CostRoutes(req, routes)
+
+	// 20 units last 2 days: 0.5 * 20 * 2 / 2
+	if got := costs[0]; got.Fixed != 100 || got.Distance != 100 || got.Penalty != 0 || got.Holding != 10 {
+		t.Errorf("day 1 costs = %+v, want fixed 100, distance 100, holding 10", got)
+	}
+	// 100 units would last 10 days but the horizon ends after 2; customer 2
+	// has no demand and holds 4 units for both days
+	if got := costs[1]; got.Penalty != 30 || math.Abs(got.Holding-(0.5*100*2/2+1*4*2/2)) > 1e-9 {
+		t.Errorf("day 4 costs = %+v, want penalty 30 and holding 54", got)
+	}
+	if got := costs[2]; got.Fixed != 0 || got.Distance != 0 || got.Penalty != 30 {
+		t.Errorf("unknown vehicle costs = %+v, want the whole cost as penalty", got)
+	}
+}
@@ -0,0 +1,98 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Stop reasons
+const (
+	ReasonStockout     = "stockout"
+	ReasonBelowMinimum = "below_minimum"
+	ReasonDue          = "due"
+	ReasonTopUp        = "top_up"
+)
+
+// StopReason explains a stop from the inputs the solver saw: the customer's
+// projected inventory when the delivery arrives and how urgent it was
+type StopReason struct {
+	Reason              string
+	Detail              string
+	InventoryAtDelivery float64
+	InventoryAfter      float64
+	DaysToStockout      *float64 // from delivery, nil without demand
+	DemandRate          float64
+	MinInventory        float64
+	MaxInventory        float64
+	Priority            int
+}
+
+// ExplainStops explains every stop of routes, indexed like routes and their
+// stops. Inventory is projected from the request: starting inventory, less
+// daily demand up to the delivery day, plus deliveries on earlier days.
+// Stops for customers missing from the request have no reason (nil).
+func ExplainStops(req *OptimizeRequest, routes []RouteResult) [][]*StopReason {
+	customers := make(map[int64]CustomerData, len(req.Customers))
+	for _, c := range req.Customers {
+		customers[c.ID] = c
+	}
+	delivered := make(map[int64]map[int]float64)
+	for _, route := range routes {
+		for _, stop := range route.Stops {
+			if delivered[stop.CustomerID] == nil {
+				delivered[stop.CustomerID] = make(map[int]float64)
+			}
+			delivered[stop.CustomerID][route.Day] += stop.Quantity
+		}
+	}
+
+	reasons := make([][]*StopReason, len(routes))
+	for i, route := range routes {
+		reasons[i] = make([]*StopReason, len(route.Stops))
+		for j, stop := range route.Stops {
+			c, ok := customers[stop.CustomerID]
+			if !ok {
+				continue
+			}
+			before := c.CurrentInventory - c.DemandRate*float64(route.Day-1)
+			for day, quantity := range delivered[c.ID] {
+				if day < route.Day {
+					before += quantity
+				}
+			}
+			reasons[i][j] = explainStop(c, before, stop.Quantity)
+		}
+	}
+	return reasons
+}
+
+func explainStop(c CustomerData, before, quantity float64) *StopReason {
+	r := &StopReason{
+		InventoryAtDelivery: before,
+		InventoryAfter:      before + quantity,
+		DemandRate:          c.DemandRate,
+		MinInventory:        c.MinInventory,
+		MaxInventory:        c.MaxInventory,
+		Priority:            c.Priority,
+	}
+	if c.DemandRate > 0 {
+		days := math.Max(before, 0) / c.DemandRate
+		r.DaysToStockout = &days
+	}
+
+	switch {
+	case before <= 0:
+		r.Reason = ReasonStockout
+		r.Detail = fmt.Sprintf("projected to run out of stock (%.1f) before the delivery", before)
+	case before < c.MinInventory:
+		r.Reason = ReasonBelowMinimum
+		r.Detail = fmt.Sprintf("projected inventory %.1f is below the minimum %.1f", before, c.MinInventory)
+	case before-c.DemandRate < c.MinInventory:
+		r.Reason = ReasonDue
+		r.Detail = fmt.Sprintf("projected inventory %.1f would fall below the minimum %.1f by the end of the day", before, c.MinInventory)
+	default:
+		r.Reason = ReasonTopUp
+		r.Detail = fmt.Sprintf("delivered ahead of need with %.1f on hand; topped up while a vehicle was nearby", before)
+	}
+	return r
+}
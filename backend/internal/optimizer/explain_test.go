@@ -0,0 +1,47 @@
+package optimizer
+
+import "testing"
+
+// TestExplainStops tests inventory projection and reason classification
+func TestExplainStops(t *testing.T) {
+	req := &OptimizeRequest{
+		PlanningHorizon: 7,
+		Customers: []CustomerData{
+			{ID: 1, DemandRate: 10, MinInventory: 20, MaxInventory: 100, CurrentInventory: 25, Priority: 3},
+			{ID: 2, DemandRate: 10, MinInventory: 20, MaxInventory: 100, CurrentInventory: 80},
+			{ID: 3, MinInventory: 0, MaxInventory: 50, CurrentInventory: 0},
+		},
+	}
+	routes := []RouteResult{
+		{Day: 1, Stops: []StopResult{{CustomerID: 1, Quantity: 60}, {CustomerID: 3, Quantity: 50}}},
+		{Day: 4, Stops: []StopResult{{CustomerID: 1, Quantity: 30}, {CustomerID: 2, Quantity: 10}, {CustomerID: 99, Quantity: 5}}},
+	}
+
+	reasons := ExplainStops(req, routes)
+
+	first := reasons[0][0]
+	if first.Reason != ReasonDue || first.InventoryAtDelivery != 25 || first.InventoryAfter != 85 || first.Priority != 3 {
+		t.Errorf("customer 1 on day 1 = %+v, want due at 25 rising to 85", first)
+	}
+	if first.DaysToStockout == nil || *first.DaysToStockout != 2.5 {
+		t.Errorf("customer 1 days to stockout = %v, want 2.5", first.DaysToStockout)
+	}
+	if got := reasons[0][1]; got.Reason != ReasonStockout || got.DaysToStockout != nil {
+		t.Errorf("customer 3 = %+v, want stockout without days to stockout", got)
+	}
+	// 25 - 3 days of demand + 60 delivered on day 1
+	if got := reasons[1][0]; got.InventoryAtDelivery != 55 || got.Reason != ReasonTopUp {
+		t.Errorf("customer 1 on day 4 = %+v, want a top-up at 55", got)
+	}
+	if got := reasons[1][1]; got.InventoryAtDelivery != 50 || got.Reason != ReasonTopUp {
+		t.Errorf("customer 2 on day 4 = %+v, want a top-up at 50", got)
+	}
+	if reasons[1][2] != nil {
+		t.Errorf("unknown customer = %+v, want nil", reasons[1][2])
+	}
+
+	req.Customers[1].CurrentInventory = 40
+	if got := ExplainStops(req, routes)[1][1]; got.Reason != ReasonBelowMinimum {
+		t.Errorf("customer 2 at 10 on day 4 = %+v, want below_minimum", got)
+	}
+}
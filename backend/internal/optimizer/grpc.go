@@ -0,0 +1,215 @@
+package optimizer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"LogiTrackPro/backend/internal/optimizer/optimizerpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// maxGRPCMessageSize allows large distance matrices in a single request
+const maxGRPCMessageSize = 64 << 20
+
+// grpcTransport calls the optimizer service over gRPC
+type grpcTransport struct {
+	conn    *grpc.ClientConn
+	client  optimizerpb.OptimizerClient
+	timeout time.Duration
+}
+
+func newGRPCTransport(addr string, opts ...grpc.DialOption) (*grpcTransport, error) {
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(maxGRPCMessageSize),
+			grpc.MaxCallSendMsgSize(maxGRPCMessageSize),
+		),
+	}, opts...)
+
+	conn, err := grpc.NewClient(addr, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client: %w", err)
+	}
+	return &grpcTransport{
+		conn:    conn,
+		client:  optimizerpb.NewOptimizerClient(conn),
+		timeout: 5 * time.Minute, // Optimization can take time
+	}, nil
+}
+
+func (t *grpcTransport) healthCheck() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	resp, err := t.client.Health(ctx, &optimizerpb.HealthRequest{})
+	if err != nil {
+		return fmt.Errorf("optimizer service unavailable: %w", err)
+	}
+	if resp.GetStatus() != "healthy" {
+		return fmt.Errorf("optimizer service reported status %q", resp.GetStatus())
+	}
+	return nil
+}
+
+// optimize streams the optimization, forwarding progress events until the
+// final result arrives
+func (t *grpcTransport) optimize(req *OptimizeRequest, onProgress func(Progress)) (*OptimizeResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+	defer cancel()
+
+	stream, err := t.client.OptimizeStream(ctx, toProtoRequest(req))
+	if err != nil {
+		return nil, fmt.Errorf("failed to call optimizer: %w", err)
+	}
+
+	for {
+		event, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("optimizer stream ended without a result")
+		}
+		if err != nil {
+			return nil, fmt.Errorf("optimizer stream failed: %w", err)
+		}
+
+		if p := event.GetProgress(); p != nil {
+			if onProgress != nil {
+				onProgress(Progress{
+					Day:       int(p.GetDay()),
+					TotalDays: int(p.GetTotalDays()),
+					Routes:    int(p.GetRoutes()),
+					Cost:      p.GetCost(),
+					Distance:  p.GetDistance(),
+				})
+			}
+			continue
+		}
+		if result := event.GetResult(); result != nil {
+			return fromProtoResponse(result), nil
+		}
+	}
+}
+
+func toProtoRequest(req *OptimizeRequest) *optimizerpb.OptimizeRequest {
+	pb := &optimizerpb.OptimizeRequest{
+		Warehouse: &optimizerpb.Warehouse{
+			Id:        req.Warehouse.ID,
+			Latitude:  req.Warehouse.Latitude,
+			Longitude: req.Warehouse.Longitude,
+			Stock:     req.Warehouse.Stock,
+		},
+		PlanningHorizon: int32(req.PlanningHorizon),
+		StartDate:       req.StartDate,
+	}
+	for _, c := range req.Customers {
+		pb.Customers = append(pb.Customers, &optimizerpb.Customer{
+			Id:               c.ID,
+			Latitude:         c.Latitude,
+			Longitude:        c.Longitude,
+			DemandRate:       c.DemandRate,
+			MaxInventory:     c.MaxInventory,
+			CurrentInventory: c.CurrentInventory,
+			MinInventory:     c.MinInventory,
+			Priority:         int32(c.Priority),
+			ServiceTags:      c.ServiceTags,
+			MinDropSize:      c.MinDropSize,
+		})
+	}
+	for _, v := range req.Vehicles {
+		vehicle := &optimizerpb.Vehicle{
+			Id:              v.ID,
+			Capacity:        v.Capacity,
+			CostPerKm:       v.CostPerKm,
+			FixedCost:       v.FixedCost,
+			MaxDistance:     v.MaxDistance,
+			MaxWorkingHours: v.MaxWorkingHours,
+			AverageSpeed:    v.AverageSpeed,
+			ShiftStart:      v.ShiftStart,
+			ShiftEnd:        v.ShiftEnd,
+			AllowedTags:     v.AllowedTags,
+		}
+		for _, d := range v.AvailableDays {
+			vehicle.AvailableDays = append(vehicle.AvailableDays, int32(d))
+		}
+		pb.Vehicles = append(pb.Vehicles, vehicle)
+	}
+	if m := req.DistanceMatrix; m != nil {
+		pb.DistanceMatrix = &optimizerpb.DistanceMatrix{
+			LocationIds: m.LocationIDs,
+			Distances:   toProtoRows(m.Distances),
+			Durations:   toProtoRows(m.Durations),
+		}
+	}
+	for _, r := range req.LockedRoutes {
+		pb.LockedRoutes = append(pb.LockedRoutes, toProtoRoute(r))
+	}
+	return pb
+}
+
+func toProtoRows(rows [][]float64) []*optimizerpb.MatrixRow {
+	result := make([]*optimizerpb.MatrixRow, len(rows))
+	for i, row := range rows {
+		result[i] = &optimizerpb.MatrixRow{Values: row}
+	}
+	return result
+}
+
+func toProtoRoute(r RouteResult) *optimizerpb.Route {
+	route := &optimizerpb.Route{
+		Day:           int32(r.Day),
+		Date:          r.Date,
+		VehicleId:     r.VehicleID,
+		TotalDistance: r.TotalDistance,
+		TotalCost:     r.TotalCost,
+		TotalLoad:     r.TotalLoad,
+		StartTime:     r.StartTime,
+		EndTime:       r.EndTime,
+	}
+	for _, s := range r.Stops {
+		route.Stops = append(route.Stops, &optimizerpb.Stop{
+			CustomerId:  s.CustomerID,
+			Sequence:    int32(s.Sequence),
+			Quantity:    s.Quantity,
+			ArrivalTime: s.ArrivalTime,
+		})
+	}
+	return route
+}
+
+func fromProtoResponse(pb *optimizerpb.OptimizeResponse) *OptimizeResponse {
+	resp := &OptimizeResponse{
+		Success:       pb.GetSuccess(),
+		Message:       pb.GetMessage(),
+		TotalCost:     pb.GetTotalCost(),
+		TotalDistance: pb.GetTotalDistance(),
+		Routes:        make([]RouteResult, 0, len(pb.GetRoutes())),
+	}
+	for _, r := range pb.GetRoutes() {
+		route := RouteResult{
+			Day:           int(r.GetDay()),
+			Date:          r.GetDate(),
+			VehicleID:     r.GetVehicleId(),
+			TotalDistance: r.GetTotalDistance(),
+			TotalCost:     r.GetTotalCost(),
+			TotalLoad:     r.GetTotalLoad(),
+			StartTime:     r.GetStartTime(),
+			EndTime:       r.GetEndTime(),
+			Stops:         make([]StopResult, 0, len(r.GetStops())),
+		}
+		for _, s := range r.GetStops() {
+			route.Stops = append(route.Stops, StopResult{
+				CustomerID:  s.GetCustomerId(),
+				Sequence:    int(s.GetSequence()),
+				Quantity:    s.GetQuantity(),
+				ArrivalTime: s.GetArrivalTime(),
+			})
+		}
+		resp.Routes = append(resp.Routes, route)
+	}
+	return resp
+}
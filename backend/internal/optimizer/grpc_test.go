@@ -0,0 +1,126 @@
+package optimizer
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"LogiTrackPro/backend/internal/optimizer/optimizerpb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeOptimizerServer streams one progress event per day followed by a single route
+type fakeOptimizerServer struct {
+	optimizerpb.UnimplementedOptimizerServer
+	fail bool
+}
+
+func (s *fakeOptimizerServer) Health(context.Context, *optimizerpb.HealthRequest) (*optimizerpb.HealthResponse, error) {
+	return &optimizerpb.HealthResponse{Status: "healthy"}, nil
+}
+
+func (s *fakeOptimizerServer) OptimizeStream(req *optimizerpb.OptimizeRequest, stream optimizerpb.Optimizer_OptimizeStreamServer) error {
+	if s.fail {
+		return status.Error(codes.Internal, "solver crashed")
+	}
+	for day := int32(1); day <= req.PlanningHorizon; day++ {
+		stream.Send(&optimizerpb.OptimizeEvent{Event: &optimizerpb.OptimizeEvent_Progress{
+			Progress: &optimizerpb.Progress{Day: day, TotalDays: req.PlanningHorizon, Routes: day, Cost: float64(day) * 10},
+		}})
+	}
+	return stream.Send(&optimizerpb.OptimizeEvent{Event: &optimizerpb.OptimizeEvent_Result{
+		Result: &optimizerpb.OptimizeResponse{
+			Success:   true,
+			Message:   "ok",
+			TotalCost: 30,
+			Routes: []*optimizerpb.Route{{
+				Day:       1,
+				Date:      req.StartDate,
+				VehicleId: req.Vehicles[0].Id,
+				Stops:     []*optimizerpb.Stop{{CustomerId: req.Customers[0].Id, Sequence: 1, Quantity: 50}},
+			}},
+		},
+	}})
+}
+
+func newBufconnClient(t *testing.T, srv optimizerpb.OptimizerServer) *Client {
+	lis := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	optimizerpb.RegisterOptimizerServer(server, srv)
+	go server.Serve(lis)
+	t.Cleanup(server.Stop)
+
+	transport, err := newGRPCTransport("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("newGRPCTransport() error = %v", err)
+	}
+	t.Cleanup(func() { transport.conn.Close() })
+	return &Client{grpc: transport}
+}
+
+// TestGRPCOptimize tests progress streaming and response conversion over gRPC
+func TestGRPCOptimize(t *testing.T) {
+	client := newBufconnClient(t, &fakeOptimizerServer{})
+
+	if err := client.HealthCheck(); err != nil {
+		t.Fatalf("HealthCheck() error = %v", err)
+	}
+
+	req := &OptimizeRequest{
+		Warehouse:       WarehouseData{ID: 1, Latitude: 40.7, Longitude: -74.0, Stock: 1000},
+		Customers:       []CustomerData{{ID: 7, DemandRate: 10, MaxInventory: 100}},
+		Vehicles:        []VehicleData{{ID: 3, Capacity: 200, AvailableDays: []int{1, 2}}},
+		PlanningHorizon: 3,
+		StartDate:       "2024-01-01",
+		DistanceMatrix:  &DistanceMatrix{LocationIDs: []int64{0, 7}, Distances: [][]float64{{0, 5}, {5, 0}}},
+	}
+
+	var progress []Progress
+	resp, err := client.OptimizeWithProgress(req, func(p Progress) {
+		progress = append(progress, p)
+	})
+	if err != nil {
+		t.Fatalf("OptimizeWithProgress() error = %v", err)
+	}
+	if len(progress) != 3 || progress[2].Day != 3 || progress[2].TotalDays != 3 || progress[2].Cost != 30 {
+		t.Errorf("progress = %+v, want 3 events ending at day 3", progress)
+	}
+	if !resp.Success || len(resp.Routes) != 1 {
+		t.Fatalf("response = %+v, want one successful route", resp)
+	}
+	route := resp.Routes[0]
+	if route.VehicleID != 3 || route.Date != "2024-01-01" || route.Stops[0].CustomerID != 7 || route.Stops[0].Quantity != 50 {
+		t.Errorf("route = %+v, conversion mismatch", route)
+	}
+}
+
+// TestGRPCOptimizeError tests that server errors are surfaced
+func TestGRPCOptimizeError(t *testing.T) {
+	client := newBufconnClient(t, &fakeOptimizerServer{fail: true})
+
+	_, err := client.Optimize(&OptimizeRequest{PlanningHorizon: 1, StartDate: "2024-01-01"})
+	if err == nil {
+		t.Fatal("Optimize() error = nil, want error")
+	}
+}
+
+// TestNew tests optimizer client selection by protocol
+func TestNew(t *testing.T) {
+	if c, err := New("", "http://localhost:8000", ""); err != nil || c.grpc != nil {
+		t.Errorf("New(\"\") = %+v, %v, want HTTP client", c, err)
+	}
+	if c, err := New("grpc", "", "localhost:50051"); err != nil || c.grpc == nil {
+		t.Errorf("New(grpc) = %+v, %v, want gRPC client", c, err)
+	}
+	if _, err := New("soap", "", ""); err == nil {
+		t.Error("New(soap) should fail for unknown protocol")
+	}
+}
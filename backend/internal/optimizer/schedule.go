@@ -0,0 +1,101 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Defaults the optimizer service uses when a vehicle leaves them unset
+const (
+	DefaultAverageSpeed = 50.0 // km/h
+	DefaultShiftStart   = "08:00"
+	ServiceMinutes      = 15 // per stop
+)
+
+// ParseClock parses an HH:MM time of day into minutes after midnight
+func ParseClock(s string) (int, error) {
+	var hours, minutes int
+	if _, err := fmt.Sscanf(s, "%d:%d", &hours, &minutes); err != nil || len(s) != 5 {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	if hours < 0 || hours > 23 || minutes < 0 || minutes > 59 {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return hours*60 + minutes, nil
+}
+
+// FormatClock formats minutes after midnight as HH:MM, wrapping past midnight
+func FormatClock(minutes int) string {
+	minutes = ((minutes % 1440) + 1440) % 1440
+	return fmt.Sprintf("%02d:%02d", minutes/60, minutes%60)
+}
+
+// EstimateRouteMinutes estimates how long a route takes from its distance,
+// the vehicle's average speed and the per-stop service time
+func EstimateRouteMinutes(route RouteResult, vehicle VehicleData) int {
+	speed := vehicle.AverageSpeed
+	if speed <= 0 {
+		speed = DefaultAverageSpeed
+	}
+	return int(math.Ceil(route.TotalDistance/speed*60)) + ServiceMinutes*len(route.Stops)
+}
+
+// FillRouteTimes sets StartTime and EndTime on routes the optimizer returned
+// without them, starting at the vehicle's shift start and estimating the
+// duration with EstimateRouteMinutes
+func FillRouteTimes(req *OptimizeRequest, resp *OptimizeResponse) {
+	vehicles := make(map[int64]VehicleData, len(req.Vehicles))
+	for _, v := range req.Vehicles {
+		vehicles[v.ID] = v
+	}
+
+	for i := range resp.Routes {
+		route := &resp.Routes[i]
+		vehicle := vehicles[route.VehicleID]
+		if route.StartTime == "" {
+			route.StartTime = vehicle.ShiftStart
+			if route.StartTime == "" {
+				route.StartTime = DefaultShiftStart
+			}
+		}
+		if route.EndTime == "" {
+			start, err := ParseClock(route.StartTime)
+			if err != nil {
+				continue
+			}
+			route.EndTime = FormatClock(start + EstimateRouteMinutes(*route, vehicle))
+		}
+	}
+}
+
+// RouteWindow returns a route's start and end in minutes after midnight of
+// the route date. An end time earlier than the start is taken as the next day.
+func RouteWindow(route RouteResult) (start, end int, err error) {
+	if start, err = ParseClock(route.StartTime); err != nil {
+		return 0, 0, err
+	}
+	if end, err = ParseClock(route.EndTime); err != nil {
+		return 0, 0, err
+	}
+	if end < start {
+		end += 1440
+	}
+	return start, end, nil
+}
+
+// CanServe reports whether a vehicle has every service tag a customer needs
+func CanServe(vehicle VehicleData, customer CustomerData) bool {
+	for _, tag := range customer.ServiceTags {
+		allowed := false
+		for _, t := range vehicle.AllowedTags {
+			if t == tag {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return false
+		}
+	}
+	return true
+}
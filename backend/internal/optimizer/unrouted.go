@@ -0,0 +1,132 @@
+package optimizer
+
+import (
+	"fmt"
+	"math"
+)
+
+// Unrouted reasons
+const (
+	UnroutedCapacity = "capacity"
+	UnroutedDistance = "distance"
+	UnroutedBlocked  = "blocked"
+)
+
+// saturatedLoad is the share of capacity above which a route counts as full
+const saturatedLoad = 0.95
+
+// Unrouted is a customer with demand in the horizon that received no stop
+type Unrouted struct {
+	CustomerID int64   `json:"customer_id"`
+	Reason     string  `json:"reason"`
+	Detail     string  `json:"detail"`
+	Shortfall  float64 `json:"shortfall"`
+}
+
+// FindUnrouted lists customers that will drop below minimum inventory within
+// the horizon but were given no stops, with the most likely reason:
+//   - distance: no vehicle can make the round trip within its distance limit
+//   - capacity: the delivery needed exceeds every vehicle, or every route
+//     that ran was already full
+//   - blocked: a vehicle could have served the customer but the solver did
+//     not, e.g. no vehicle was available on the days it was needed
+func FindUnrouted(req *OptimizeRequest, resp *OptimizeResponse) []Unrouted {
+	served := make(map[int64]bool)
+	for _, routes := range [][]RouteResult{resp.Routes, req.LockedRoutes} {
+		for _, route := range routes {
+			for _, stop := range route.Stops {
+				served[stop.CustomerID] = true
+			}
+		}
+	}
+
+	maxCapacity := 0.0
+	for _, v := range req.Vehicles {
+		maxCapacity = math.Max(maxCapacity, v.Capacity)
+	}
+
+	capacities := make(map[int64]float64, len(req.Vehicles))
+	for _, v := range req.Vehicles {
+		capacities[v.ID] = v.Capacity
+	}
+	saturated := len(resp.Routes) > 0
+	for _, route := range resp.Routes {
+		if capacity := capacities[route.VehicleID]; capacity > 0 && route.TotalLoad < capacity*saturatedLoad {
+			saturated = false
+			break
+		}
+	}
+
+	var unrouted []Unrouted
+	for _, c := range req.Customers {
+		if served[c.ID] {
+			continue
+		}
+		projected := c.CurrentInventory - c.DemandRate*float64(req.PlanningHorizon)
+		if projected >= c.MinInventory {
+			continue
+		}
+
+		u := Unrouted{CustomerID: c.ID, Shortfall: c.MinInventory - projected}
+		roundTrip := 2 * warehouseDistance(req, c)
+		needed := c.MaxInventory - math.Max(c.MinInventory, 0)
+
+		switch {
+		case !reachable(req.Vehicles, roundTrip):
+			u.Reason = UnroutedDistance
+			u.Detail = fmt.Sprintf("round trip of %.1f km exceeds every vehicle's distance limit", roundTrip)
+		case needed > maxCapacity:
+			u.Reason = UnroutedCapacity
+			u.Detail = fmt.Sprintf("delivery of %.1f exceeds the largest vehicle capacity %.1f", needed, maxCapacity)
+		case saturated:
+			u.Reason = UnroutedCapacity
+			u.Detail = "all routes were loaded to capacity"
+		default:
+			u.Reason = UnroutedBlocked
+			u.Detail = "no available vehicle was assigned to the customer"
+		}
+		unrouted = append(unrouted, u)
+	}
+	return unrouted
+}
+
+// reachable reports whether any vehicle can drive the given distance
+func reachable(vehicles []VehicleData, distance float64) bool {
+	for _, v := range vehicles {
+		if v.MaxDistance <= 0 || distance <= v.MaxDistance+capacityTolerance {
+			return true
+		}
+	}
+	return false
+}
+
+// warehouseDistance returns the km from the warehouse to a customer, using
+// the road distance matrix when present
+func warehouseDistance(req *OptimizeRequest, c CustomerData) float64 {
+	if m := req.DistanceMatrix; m != nil {
+		depot, customer := -1, -1
+		for i, id := range m.LocationIDs {
+			switch id {
+			case 0:
+				depot = i
+			case c.ID:
+				customer = i
+			}
+		}
+		if depot >= 0 && customer >= 0 && depot < len(m.Distances) && customer < len(m.Distances[depot]) {
+			return m.Distances[depot][customer]
+		}
+	}
+	return HaversineKm(req.Warehouse.Latitude, req.Warehouse.Longitude, c.Latitude, c.Longitude)
+}
+
+// HaversineKm is the straight-line distance in km, the fallback the
+// optimizer service also uses
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	dLat := (lat2 - lat1) * math.Pi / 180
+	dLon := (lon2 - lon1) * math.Pi / 180
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*math.Pi/180)*math.Cos(lat2*math.Pi/180)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
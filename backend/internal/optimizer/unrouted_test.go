@@ -0,0 +1,99 @@
+package optimizer
+
+import "testing"
+
+// TestFindUnrouted tests unrouted customer detection and reason classification
+func TestFindUnrouted(t *testing.T) {
+	base := func() *OptimizeRequest {
+		return &OptimizeRequest{
+			Warehouse:       WarehouseData{ID: 1, Latitude: 40.7, Longitude: -74.0},
+			PlanningHorizon: 7,
+			StartDate:       "2024-01-01",
+			Customers: []CustomerData{
+				{ID: 10, Latitude: 40.7, Longitude: -74.0, DemandRate: 10, MaxInventory: 100, CurrentInventory: 50},
+			},
+			Vehicles: []VehicleData{{ID: 1, Capacity: 200, MaxDistance: 100}},
+		}
+	}
+
+	tests := []struct {
+		name       string
+		modify     func(*OptimizeRequest, *OptimizeResponse)
+		wantReason string
+	}{
+		{
+			name: "served customer",
+			modify: func(req *OptimizeRequest, resp *OptimizeResponse) {
+				resp.Routes = []RouteResult{{Day: 1, VehicleID: 1, Stops: []StopResult{{CustomerID: 10, Quantity: 50}}}}
+			},
+		},
+		{
+			name: "no demand in horizon",
+			modify: func(req *OptimizeRequest, resp *OptimizeResponse) {
+				req.Customers[0].CurrentInventory = 500
+			},
+		},
+		{
+			name: "served by locked route",
+			modify: func(req *OptimizeRequest, resp *OptimizeResponse) {
+				req.LockedRoutes = []RouteResult{{Day: 1, VehicleID: 1, Stops: []StopResult{{CustomerID: 10, Quantity: 50}}}}
+			},
+		},
+		{
+			name: "out of reach",
+			modify: func(req *OptimizeRequest, resp *OptimizeResponse) {
+				req.Customers[0].Latitude = 42.0 // ~145 km away
+			},
+			wantReason: UnroutedDistance,
+		},
+		{
+			name: "out of reach by road matrix",
+			modify: func(req *OptimizeRequest, resp *OptimizeResponse) {
+				req.DistanceMatrix = &DistanceMatrix{LocationIDs: []int64{0, 10}, Distances: [][]float64{{0, 60}, {60, 0}}}
+			},
+			wantReason: UnroutedDistance,
+		},
+		{
+			name: "delivery larger than any vehicle",
+			modify: func(req *OptimizeRequest, resp *OptimizeResponse) {
+				req.Customers[0].MaxInventory = 500
+			},
+			wantReason: UnroutedCapacity,
+		},
+		{
+			name: "fleet saturated",
+			modify: func(req *OptimizeRequest, resp *OptimizeResponse) {
+				req.Customers = append(req.Customers, CustomerData{ID: 11, DemandRate: 1, CurrentInventory: 100})
+				resp.Routes = []RouteResult{{Day: 1, VehicleID: 1, TotalLoad: 199, Stops: []StopResult{{CustomerID: 11, Quantity: 199}}}}
+			},
+			wantReason: UnroutedCapacity,
+		},
+		{
+			name:       "blocked",
+			modify:     func(req *OptimizeRequest, resp *OptimizeResponse) {},
+			wantReason: UnroutedBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := base()
+			resp := &OptimizeResponse{Success: true}
+			tt.modify(req, resp)
+
+			got := FindUnrouted(req, resp)
+			if tt.wantReason == "" {
+				if len(got) != 0 {
+					t.Errorf("FindUnrouted() = %+v, want none", got)
+				}
+				return
+			}
+			if len(got) != 1 || got[0].CustomerID != 10 || got[0].Reason != tt.wantReason {
+				t.Fatalf("FindUnrouted() = %+v, want customer 10 with reason %s", got, tt.wantReason)
+			}
+			if got[0].Shortfall <= 0 {
+				t.Errorf("Shortfall = %v, want positive", got[0].Shortfall)
+			}
+		})
+	}
+}
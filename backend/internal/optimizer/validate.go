@@ -0,0 +1,244 @@
+package optimizer
+
+import (
+	"fmt"
+	"time"
+)
+
+// capacityTolerance absorbs floating point noise from the solver
+const capacityTolerance = 1e-6
+
+// Violation describes a single constraint an optimizer result breaks
+type Violation struct {
+	Rule       string `json:"rule"`
+	Day        int    `json:"day,omitempty"`
+	VehicleID  int64  `json:"vehicle_id,omitempty"`
+	CustomerID int64  `json:"customer_id,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Violation rules
+const (
+	RuleUnknownVehicle   = "unknown_vehicle"
+	RuleUnknownCustomer  = "unknown_customer"
+	RuleCapacity         = "capacity_exceeded"
+	RuleMaxDistance      = "max_distance_exceeded"
+	RuleDuplicateVisit   = "duplicate_visit"
+	RuleDuplicateVehicle = "vehicle_reused"
+	RuleNegativeQuantity = "negative_quantity"
+	RuleOutsideHorizon   = "outside_horizon"
+	RuleNotRostered      = "vehicle_not_rostered"
+	RuleSkillMismatch    = "skill_mismatch"
+	RuleWorkingHours     = "working_hours_exceeded"
+	RuleOutsideShift     = "outside_shift"
+	RuleInvalidTime      = "invalid_time"
+	RuleBelowMinDrop     = "below_min_drop"
+)
+
+// ValidateResponse checks an optimizer result against the request it was
+// produced for. Solver bugs or stale inputs can yield infeasible routes, so
+// the result must be validated before it is persisted.
+func ValidateResponse(req *OptimizeRequest, resp *OptimizeResponse) []Violation {
+	var violations []Violation
+	add := func(v Violation) {
+		violations = append(violations, v)
+	}
+
+	vehicles := make(map[int64]VehicleData, len(req.Vehicles))
+	for _, v := range req.Vehicles {
+		vehicles[v.ID] = v
+	}
+	customers := make(map[int64]CustomerData, len(req.Customers))
+	for _, c := range req.Customers {
+		customers[c.ID] = c
+	}
+
+	startDate, startErr := time.Parse("2006-01-02", req.StartDate)
+
+	type dayKey struct {
+		day int
+		id  int64
+	}
+	vehicleDays := make(map[dayKey]bool)
+	customerDays := make(map[dayKey]bool)
+	for _, route := range req.LockedRoutes {
+		for _, stop := range route.Stops {
+			customerDays[dayKey{route.Day, stop.CustomerID}] = true
+		}
+	}
+
+	for _, route := range resp.Routes {
+		if route.Day < 1 || route.Day > req.PlanningHorizon {
+			add(Violation{
+				Rule:      RuleOutsideHorizon,
+				Day:       route.Day,
+				VehicleID: route.VehicleID,
+				Message:   fmt.Sprintf("day %d is outside the %d-day planning horizon", route.Day, req.PlanningHorizon),
+			})
+		} else if startErr == nil {
+			want := startDate.AddDate(0, 0, route.Day-1).Format("2006-01-02")
+			if route.Date != want {
+				add(Violation{
+					Rule:      RuleOutsideHorizon,
+					Day:       route.Day,
+					VehicleID: route.VehicleID,
+					Message:   fmt.Sprintf("route date %q does not match day %d (%s)", route.Date, route.Day, want),
+				})
+			}
+		}
+
+		vehicle, known := vehicles[route.VehicleID]
+		if !known {
+			add(Violation{
+				Rule:      RuleUnknownVehicle,
+				Day:       route.Day,
+				VehicleID: route.VehicleID,
+				Message:   fmt.Sprintf("vehicle %d was not part of the request", route.VehicleID),
+			})
+		}
+
+		if known && len(vehicle.AvailableDays) > 0 && !containsDay(vehicle.AvailableDays, route.Day) {
+			add(Violation{
+				Rule:      RuleNotRostered,
+				Day:       route.Day,
+				VehicleID: route.VehicleID,
+				Message:   fmt.Sprintf("vehicle %d has no rostered driver on day %d", route.VehicleID, route.Day),
+			})
+		}
+
+		key := dayKey{route.Day, route.VehicleID}
+		if vehicleDays[key] {
+			add(Violation{
+				Rule:      RuleDuplicateVehicle,
+				Day:       route.Day,
+				VehicleID: route.VehicleID,
+				Message:   fmt.Sprintf("vehicle %d is assigned more than one route on day %d", route.VehicleID, route.Day),
+			})
+		}
+		vehicleDays[key] = true
+
+		load := 0.0
+		for _, stop := range route.Stops {
+			customer, knownCustomer := customers[stop.CustomerID]
+			if !knownCustomer {
+				add(Violation{
+					Rule:       RuleUnknownCustomer,
+					Day:        route.Day,
+					VehicleID:  route.VehicleID,
+					CustomerID: stop.CustomerID,
+					Message:    fmt.Sprintf("customer %d was not part of the request", stop.CustomerID),
+				})
+			}
+			if knownCustomer && stop.Quantity > 0 && stop.Quantity < customer.MinDropSize-capacityTolerance {
+				add(Violation{
+					Rule:       RuleBelowMinDrop,
+					Day:        route.Day,
+					VehicleID:  route.VehicleID,
+					CustomerID: stop.CustomerID,
+					Message:    fmt.Sprintf("delivery of %.2f to customer %d is below its minimum drop size %.2f", stop.Quantity, stop.CustomerID, customer.MinDropSize),
+				})
+			}
+			if known && knownCustomer && !CanServe(vehicle, customer) {
+				add(Violation{
+					Rule:       RuleSkillMismatch,
+					Day:        route.Day,
+					VehicleID:  route.VehicleID,
+					CustomerID: stop.CustomerID,
+					Message:    fmt.Sprintf("vehicle %d lacks service tags %v required by customer %d", route.VehicleID, customer.ServiceTags, stop.CustomerID),
+				})
+			}
+			if stop.Quantity < 0 {
+				add(Violation{
+					Rule:       RuleNegativeQuantity,
+					Day:        route.Day,
+					VehicleID:  route.VehicleID,
+					CustomerID: stop.CustomerID,
+					Message:    fmt.Sprintf("negative delivery quantity %.2f", stop.Quantity),
+				})
+			}
+
+			visit := dayKey{route.Day, stop.CustomerID}
+			if customerDays[visit] {
+				add(Violation{
+					Rule:       RuleDuplicateVisit,
+					Day:        route.Day,
+					VehicleID:  route.VehicleID,
+					CustomerID: stop.CustomerID,
+					Message:    fmt.Sprintf("customer %d is visited more than once on day %d", stop.CustomerID, route.Day),
+				})
+			}
+			customerDays[visit] = true
+
+			load += stop.Quantity
+		}
+
+		if !known {
+			continue
+		}
+		if load > vehicle.Capacity+capacityTolerance {
+			add(Violation{
+				Rule:      RuleCapacity,
+				Day:       route.Day,
+				VehicleID: route.VehicleID,
+				Message:   fmt.Sprintf("route load %.2f exceeds vehicle capacity %.2f", load, vehicle.Capacity),
+			})
+		}
+		if vehicle.MaxDistance > 0 && route.TotalDistance > vehicle.MaxDistance+capacityTolerance {
+			add(Violation{
+				Rule:      RuleMaxDistance,
+				Day:       route.Day,
+				VehicleID: route.VehicleID,
+				Message:   fmt.Sprintf("route distance %.2f km exceeds vehicle limit %.2f km", route.TotalDistance, vehicle.MaxDistance),
+			})
+		}
+		for _, v := range validateRouteTimes(route, vehicle) {
+			add(v)
+		}
+	}
+
+	return violations
+}
+
+// validateRouteTimes checks a route's planned window against the vehicle's
+// working hours and shift. Routes without times are not checked.
+func validateRouteTimes(route RouteResult, vehicle VehicleData) []Violation {
+	if route.StartTime == "" && route.EndTime == "" {
+		return nil
+	}
+	violation := func(rule, message string) Violation {
+		return Violation{Rule: rule, Day: route.Day, VehicleID: route.VehicleID, Message: message}
+	}
+
+	start, end, err := RouteWindow(route)
+	if err != nil {
+		return []Violation{violation(RuleInvalidTime, err.Error())}
+	}
+
+	var violations []Violation
+	if hours := float64(end-start) / 60; vehicle.MaxWorkingHours > 0 && hours > vehicle.MaxWorkingHours+capacityTolerance {
+		violations = append(violations, violation(RuleWorkingHours,
+			fmt.Sprintf("route takes %.2f h, vehicle limit is %.2f h", hours, vehicle.MaxWorkingHours)))
+	}
+	if vehicle.ShiftStart != "" {
+		if shiftStart, err := ParseClock(vehicle.ShiftStart); err == nil && start < shiftStart {
+			violations = append(violations, violation(RuleOutsideShift,
+				fmt.Sprintf("route starts at %s before the shift starts at %s", route.StartTime, vehicle.ShiftStart)))
+		}
+	}
+	if vehicle.ShiftEnd != "" {
+		if shiftEnd, err := ParseClock(vehicle.ShiftEnd); err == nil && end > shiftEnd {
+			violations = append(violations, violation(RuleOutsideShift,
+				fmt.Sprintf("route ends at %s after the shift ends at %s", route.EndTime, vehicle.ShiftEnd)))
+		}
+	}
+	return violations
+}
+
+func containsDay(days []int, day int) bool {
+	for _, d := range days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package optimizer
+
+import "testing"
+
+// TestValidateResponse tests that infeasible optimizer results are reported
+func TestValidateResponse(t *testing.T) {
+	req := &OptimizeRequest{
+		Customers: []CustomerData{{ID: 1}, {ID: 2}, {ID: 3, ServiceTags: []string{"reefer"}, MinDropSize: 20}},
+		Vehicles: []VehicleData{
+			{ID: 10, Capacity: 100, MaxDistance: 50},
+			{ID: 20, Capacity: 100, MaxWorkingHours: 8, ShiftStart: "06:00", ShiftEnd: "16:00", AllowedTags: []string{"reefer"}},
+		},
+		PlanningHorizon: 3,
+		StartDate:       "2024-01-01",
+		LockedRoutes:    []RouteResult{{Day: 3, Date: "2024-01-03", VehicleID: 30, Stops: []StopResult{{CustomerID: 2, Quantity: 10}}}},
+	}
+	route := func(day int, date string, distance float64, stops ...StopResult) RouteResult {
+		return RouteResult{Day: day, Date: date, VehicleID: 10, TotalDistance: distance, Stops: stops}
+	}
+	timed := func(start, end string, stops ...StopResult) RouteResult {
+		return RouteResult{Day: 1, Date: "2024-01-01", VehicleID: 20, StartTime: start, EndTime: end, Stops: stops}
+	}
+
+	tests := []struct {
+		name     string
+		routes   []RouteResult
+		wantRule string
+	}{
+		{
+			name:   "feasible",
+			routes: []RouteResult{route(1, "2024-01-01", 40, StopResult{CustomerID: 1, Quantity: 60}, StopResult{CustomerID: 2, Quantity: 40})},
+		},
+		{
+			name:     "over capacity",
+			routes:   []RouteResult{route(1, "2024-01-01", 40, StopResult{CustomerID: 1, Quantity: 80}, StopResult{CustomerID: 2, Quantity: 40})},
+			wantRule: RuleCapacity,
+		},
+		{
+			name:     "over max distance",
+			routes:   []RouteResult{route(2, "2024-01-02", 75, StopResult{CustomerID: 1, Quantity: 10})},
+			wantRule: RuleMaxDistance,
+		},
+		{
+			name:     "customer visited twice in a day",
+			routes:   []RouteResult{route(1, "2024-01-01", 40, StopResult{CustomerID: 1, Quantity: 10}, StopResult{CustomerID: 1, Quantity: 10})},
+			wantRule: RuleDuplicateVisit,
+		},
+		{
+			name:     "customer visited again on a locked day",
+			routes:   []RouteResult{route(3, "2024-01-03", 40, StopResult{CustomerID: 2, Quantity: 10})},
+			wantRule: RuleDuplicateVisit,
+		},
+		{
+			name:     "negative quantity",
+			routes:   []RouteResult{route(1, "2024-01-01", 40, StopResult{CustomerID: 2, Quantity: -5})},
+			wantRule: RuleNegativeQuantity,
+		},
+		{
+			name:     "day past horizon",
+			routes:   []RouteResult{route(4, "2024-01-04", 40, StopResult{CustomerID: 2, Quantity: 5})},
+			wantRule: RuleOutsideHorizon,
+		},
+		{
+			name:     "date does not match day",
+			routes:   []RouteResult{route(2, "2024-02-02", 40, StopResult{CustomerID: 2, Quantity: 5})},
+			wantRule: RuleOutsideHorizon,
+		},
+		{
+			name:     "unknown customer",
+			routes:   []RouteResult{route(1, "2024-01-01", 40, StopResult{CustomerID: 99, Quantity: 5})},
+			wantRule: RuleUnknownCustomer,
+		},
+		{
+			name:   "tagged customer on equipped vehicle within shift",
+			routes: []RouteResult{timed("07:00", "14:30", StopResult{CustomerID: 3, Quantity: 25})},
+		},
+		{
+			name:     "delivery below minimum drop size",
+			routes:   []RouteResult{timed("07:00", "14:30", StopResult{CustomerID: 3, Quantity: 5})},
+			wantRule: RuleBelowMinDrop,
+		},
+		{
+			name:     "tagged customer on unequipped vehicle",
+			routes:   []RouteResult{route(1, "2024-01-01", 40, StopResult{CustomerID: 3, Quantity: 25})},
+			wantRule: RuleSkillMismatch,
+		},
+		{
+			name:     "route longer than working hours",
+			routes:   []RouteResult{timed("06:00", "15:00", StopResult{CustomerID: 1, Quantity: 5})},
+			wantRule: RuleWorkingHours,
+		},
+		{
+			name:     "route starts before shift",
+			routes:   []RouteResult{timed("05:30", "10:00", StopResult{CustomerID: 1, Quantity: 5})},
+			wantRule: RuleOutsideShift,
+		},
+		{
+			name:     "malformed route time",
+			routes:   []RouteResult{timed("7am", "10:00", StopResult{CustomerID: 1, Quantity: 5})},
+			wantRule: RuleInvalidTime,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			violations := ValidateResponse(req, &OptimizeResponse{Success: true, Routes: tt.routes})
+			if tt.wantRule == "" {
+				if len(violations) != 0 {
+					t.Errorf("ValidateResponse() = %+v, want no violations", violations)
+				}
+				return
+			}
+			if len(violations) != 1 || violations[0].Rule != tt.wantRule {
+				t.Errorf("ValidateResponse() = %+v, want one %s violation", violations, tt.wantRule)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package optimizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// CompatibleMajor is the major version of the optimizer service this
+// client speaks to. Minor versions only add optional fields.
+const CompatibleMajor = 1
+
+// Version returns the version the optimizer service reports on its health
+// endpoint, or "" when it does not report one. The gRPC health check
+// carries no version.
+func (c *Client) Version() (string, error) {
+	if c.grpc != nil {
+		return "", c.grpc.healthCheck()
+	}
+
+	resp, err := c.httpClient.Get(c.baseURL + "/health")
+	if err != nil {
+		return "", fmt.Errorf("optimizer service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("optimizer service returned status %d", resp.StatusCode)
+	}
+	var health struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return "", nil
+	}
+	return health.Version, nil
+}
+
+// Compatible reports whether an optimizer service version has the major
+// version this client speaks to
+func Compatible(version string) bool {
+	major, _, _ := strings.Cut(strings.TrimPrefix(version, "v"), ".")
+	n, err := strconv.Atoi(major)
+	return err == nil && n == CompatibleMajor
+}
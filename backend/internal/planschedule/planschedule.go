@@ -0,0 +1,146 @@
+// Package planschedule instantiates recurring plan templates: every week or
+// month a new draft plan is created from the template's configuration.
+package planschedule
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"gorm.io/gorm"
+)
+
+// Recurrences
+const (
+	None    = "none"
+	Weekly  = "weekly"
+	Monthly = "monthly"
+)
+
+// MaxMonthlyDay is the last day of the month a monthly template may start
+// on, so that every month has the same start day
+const MaxMonthlyDay = 28
+
+// Next returns the start date after start for a recurrence. ok is false for
+// templates that do not recur.
+func Next(recurrence string, start time.Time) (next time.Time, ok bool) {
+	switch recurrence {
+	case Weekly:
+		return start.AddDate(0, 0, 7), true
+	case Monthly:
+		return start.AddDate(0, 1, 0), true
+	}
+	return time.Time{}, false
+}
+
+// Due reports whether the template's next plan should be created at now:
+// the template is active, recurs and its next start is within its lead days
+func Due(t models.PlanTemplate, now time.Time) bool {
+	if !t.Active || t.NextStartDate == nil {
+		return false
+	}
+	if _, ok := Next(t.Recurrence, *t.NextStartDate); !ok {
+		return false
+	}
+	today := now.UTC().Truncate(24 * time.Hour)
+	return !t.NextStartDate.AddDate(0, 0, -t.LeadDays).After(today)
+}
+
+// NewPlan builds the draft plan a template produces for a start date
+func NewPlan(t models.PlanTemplate, start time.Time, createdBy *int64) *models.Plan {
+	templateID := t.ID
+	return &models.Plan{
+		Name:         fmt.Sprintf("%s %s", t.Name, start.Format("2006-01-02")),
+		StartDate:    start,
+		EndDate:      start.AddDate(0, 0, t.HorizonDays-1),
+		Status:       planstate.Draft,
+		WarehouseID:  t.WarehouseID,
+		CustomerIDs:  t.CustomerIDs,
+		VehicleIDs:   t.VehicleIDs,
+		CustomerTags: t.CustomerTags,
+		VehicleTags:  t.VehicleTags,
+		TemplateID:   &templateID,
+		CreatedBy:    createdBy,
+	}
+}
+
+// JobType is the periodic job that runs the scheduler
+const JobType = "plan_templates.schedule"
+
+// Scheduler creates the plans of due templates
+type Scheduler struct {
+	db    *gorm.DB
+	clock clock.Clock
+}
+
+func New(db *gorm.DB) *Scheduler {
+	return &Scheduler{db: db, clock: clock.Real}
+}
+
+// SetClock replaces the clock RunJob reads the current time from
+func (s *Scheduler) SetClock(c clock.Clock) {
+	s.clock = c
+}
+
+// RunJob runs the scheduler as a background job
+func (s *Scheduler) RunJob(ctx context.Context, _ json.RawMessage) error {
+	plans, err := s.RunOnce(s.clock.Now())
+	for _, p := range plans {
+		log.Printf("Plan template scheduler: created plan %d %q", p.ID, p.Name)
+	}
+	return err
+}
+
+// RunOnce creates a plan for every due template and advances its schedule.
+// Occurrences that already started are skipped rather than back-filled, so a
+// scheduler that was down for a while catches up with a single plan.
+func (s *Scheduler) RunOnce(now time.Time) ([]models.Plan, error) {
+	templates, err := database.ListScheduledPlanTemplates(s.db)
+	if err != nil {
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	created := []models.Plan{}
+	var errs []error
+	for _, t := range templates {
+		for Due(t, now) {
+			start := *t.NextStartDate
+			next, _ := Next(t.Recurrence, start)
+
+			var plan *models.Plan
+			err := s.db.Transaction(func(tx *gorm.DB) error {
+				var planID *int64
+				if !start.Before(today) {
+					plan = NewPlan(t, start, t.CreatedBy)
+					if err := database.CreatePlan(tx, plan); err != nil {
+						return err
+					}
+					planID = &plan.ID
+				}
+				return database.AdvancePlanTemplateTx(tx, t.ID, start, next, planID, now)
+			})
+			if errors.Is(err, database.ErrNotFound) {
+				// Another scheduler advanced this template first
+				break
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("template %d: %w", t.ID, err))
+				break
+			}
+			if plan != nil {
+				created = append(created, *plan)
+			}
+			t.NextStartDate = &next
+		}
+	}
+	return created, errors.Join(errs...)
+}
@@ -0,0 +1,128 @@
+package planschedule
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		recurrence string
+		start      string
+		want       string
+		ok         bool
+	}{
+		{Weekly, "2024-01-29", "2024-02-05", true},
+		{Monthly, "2024-01-15", "2024-02-15", true},
+		{Monthly, "2024-12-28", "2025-01-28", true},
+		{None, "2024-01-01", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := Next(tt.recurrence, date(tt.start))
+		if ok != tt.ok || (ok && !got.Equal(date(tt.want))) {
+			t.Errorf("Next(%s, %s) = %v, %v, want %s, %v", tt.recurrence, tt.start, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDue(t *testing.T) {
+	next := date("2024-01-08")
+	template := models.PlanTemplate{Active: true, Recurrence: Weekly, NextStartDate: &next, LeadDays: 2}
+
+	if Due(template, date("2024-01-05").Add(23*time.Hour)) {
+		t.Error("Due() three days before start with two lead days = true, want false")
+	}
+	if !Due(template, date("2024-01-06").Add(time.Hour)) {
+		t.Error("Due() two days before start = false, want true")
+	}
+
+	inactive := template
+	inactive.Active = false
+	if Due(inactive, date("2024-01-08")) {
+		t.Error("Due() for inactive template = true, want false")
+	}
+	once := template
+	once.Recurrence = None
+	if Due(once, date("2024-01-08")) {
+		t.Error("Due() for non-recurring template = true, want false")
+	}
+}
+
+// TestRunOnce tests that due templates create one plan per occurrence, that
+// missed occurrences are skipped and that a second run creates nothing
+func TestRunOnce(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Plan{}, &models.PlanTemplate{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	warehouseID := int64(3)
+	weeklyStart := date("2024-01-08")
+	weekly := &models.PlanTemplate{
+		Name: "Weekly north", WarehouseID: &warehouseID, CustomerIDs: []int64{1, 2}, VehicleIDs: []int64{7},
+		HorizonDays: 5, Recurrence: Weekly, NextStartDate: &weeklyStart, LeadDays: 3, Active: true,
+	}
+	// Two occurrences already started while the scheduler was down
+	monthlyStart := date("2023-11-20")
+	monthly := &models.PlanTemplate{
+		Name: "Monthly south", WarehouseID: &warehouseID, HorizonDays: 7, Recurrence: Monthly, NextStartDate: &monthlyStart, Active: true,
+	}
+	paused := &models.PlanTemplate{
+		Name: "Paused", WarehouseID: &warehouseID, HorizonDays: 7, Recurrence: Weekly, NextStartDate: &weeklyStart,
+	}
+	for _, tpl := range []*models.PlanTemplate{weekly, monthly, paused} {
+		if err := database.CreatePlanTemplate(db, tpl); err != nil {
+			t.Fatalf("CreatePlanTemplate() error = %v", err)
+		}
+	}
+
+	s := New(db)
+	now := date("2024-01-05").Add(9 * time.Hour)
+	plans, err := s.RunOnce(now)
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if len(plans) != 1 {
+		t.Fatalf("RunOnce() created %d plans, want 1: %+v", len(plans), plans)
+	}
+	p := plans[0]
+	if p.Name != "Weekly north 2024-01-08" || p.Status != "draft" || !p.StartDate.Equal(weeklyStart) || !p.EndDate.Equal(date("2024-01-12")) {
+		t.Errorf("plan = %+v, want draft Weekly north 2024-01-08 to 2024-01-12", p)
+	}
+	if p.TemplateID == nil || *p.TemplateID != weekly.ID || len(p.CustomerIDs) != 2 || len(p.VehicleIDs) != 1 {
+		t.Errorf("plan = %+v, want the template's customers, vehicles and ID", p)
+	}
+
+	stored, _ := database.GetPlanTemplate(db, weekly.ID)
+	if !stored.NextStartDate.Equal(date("2024-01-15")) || stored.LastPlanID == nil || *stored.LastPlanID != p.ID {
+		t.Errorf("weekly template next %v, last plan %v, want 2024-01-15 and plan %d", stored.NextStartDate, stored.LastPlanID, p.ID)
+	}
+	stored, _ = database.GetPlanTemplate(db, monthly.ID)
+	if !stored.NextStartDate.Equal(date("2024-01-20")) || stored.LastPlanID != nil {
+		t.Errorf("monthly template next %v, last plan %v, want 2024-01-20 and no plan", stored.NextStartDate, stored.LastPlanID)
+	}
+
+	plans, err = s.RunOnce(now.Add(time.Hour))
+	if err != nil || len(plans) != 0 {
+		t.Errorf("second RunOnce() = %d plans, %v, want none", len(plans), err)
+	}
+	var count int64
+	db.Model(&models.Plan{}).Count(&count)
+	if count != 1 {
+		t.Errorf("stored %d plans, want 1", count)
+	}
+}
@@ -0,0 +1,91 @@
+// Package planstate defines the plan lifecycle and the status changes it
+// allows:
+//
+//	draft → optimizing → optimized → approved → executing → completed → archived
+//
+// A failed optimization returns the plan to the status it had, draft or
+// optimized, an optimized plan can be optimized again, and any plan that has
+// not finished can be cancelled. A plan left optimizing by an optimization
+// that never finished is reset to optimized or draft.
+// Archived plans are hidden from plan lists unless asked for.
+package planstate
+
+import "fmt"
+
+// Plan statuses
+const (
+	Draft      = "draft"
+	Optimizing = "optimizing"
+	Optimized  = "optimized"
+	Approved   = "approved"
+	Executing  = "executing"
+	Completed  = "completed"
+	Cancelled  = "cancelled"
+	Archived   = "archived"
+
+	// Executed is the status finished plans had before approvals existed. It
+	// is treated like Completed.
+	Executed = "executed"
+)
+
+var transitions = map[string][]string{
+	Draft:      {Optimizing, Optimized, Cancelled},
+	Optimizing: {Optimized, Draft, Cancelled},
+	Optimized:  {Optimizing, Optimized, Approved, Cancelled},
+	Approved:   {Executing, Cancelled},
+	Executing:  {Completed, Cancelled},
+	Completed:  {Archived},
+	Executed:   {Archived},
+}
+
+// TransitionError reports a status change the lifecycle does not allow
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("plan status cannot change from %s to %s", e.From, e.To)
+}
+
+// Allowed returns the statuses a plan in status from can move to
+func Allowed(from string) []string {
+	return transitions[from]
+}
+
+// Check returns a *TransitionError unless a plan may move from one status to
+// the other. Optimized to Optimized is allowed: re-optimizing and rolling
+// back replace the routes of an optimized plan.
+func Check(from, to string) error {
+	for _, s := range transitions[from] {
+		if s == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// Released reports whether a plan has been approved for drivers to see
+func Released(status string) bool {
+	switch status {
+	case Approved, Executing, Completed, Executed, Archived:
+		return true
+	}
+	return false
+}
+
+// ReleasedStatuses lists the statuses for which Released is true
+func ReleasedStatuses() []string {
+	return []string{Approved, Executing, Completed, Executed, Archived}
+}
+
+// ScheduledStatuses lists the statuses of plans whose routes are expected to
+// leave the warehouse
+func ScheduledStatuses() []string {
+	return []string{Optimized, Approved, Executing}
+}
+
+// ActiveStatuses lists the statuses of plans still being planned or carried out
+func ActiveStatuses() []string {
+	return []string{Draft, Optimizing, Optimized, Approved, Executing}
+}
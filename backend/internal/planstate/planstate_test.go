@@ -0,0 +1,58 @@
+package planstate
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{Draft, Optimizing, true},
+		{Optimizing, Optimized, true},
+		{Optimizing, Draft, true},
+		{Optimizing, Cancelled, true},
+		{Optimized, Optimizing, true},
+		{Optimized, Optimized, true},
+		{Optimized, Approved, true},
+		{Approved, Executing, true},
+		{Executing, Completed, true},
+		{Executing, Cancelled, true},
+		{Draft, Approved, false},
+		{Approved, Optimizing, false},
+		{Approved, Approved, false},
+		{Optimized, Executing, false},
+		{Completed, Cancelled, false},
+		{Cancelled, Draft, false},
+		{Executed, Optimizing, false},
+		{Completed, Archived, true},
+		{Executed, Archived, true},
+		{Cancelled, Archived, false},
+		{Archived, Completed, false},
+	}
+	for _, tt := range tests {
+		err := Check(tt.from, tt.to)
+		if (err == nil) != tt.ok {
+			t.Errorf("Check(%s, %s) = %v, want ok %v", tt.from, tt.to, err, tt.ok)
+		}
+		var te *TransitionError
+		if err != nil && (!errors.As(err, &te) || te.From != tt.from || te.To != tt.to) {
+			t.Errorf("Check(%s, %s) error = %#v, want *TransitionError", tt.from, tt.to, err)
+		}
+	}
+}
+
+func TestReleased(t *testing.T) {
+	for _, s := range []string{Approved, Executing, Completed, Executed, Archived} {
+		if !Released(s) {
+			t.Errorf("Released(%s) = false, want true", s)
+		}
+	}
+	for _, s := range []string{Draft, Optimizing, Optimized, Cancelled} {
+		if Released(s) {
+			t.Errorf("Released(%s) = true, want false", s)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+// Package push sends notifications to users' devices through a push
+// gateway. The backend does not track devices: the gateway is posted the
+// user ID and fans out to whatever devices it knows for that user. Every
+// notification is sent by its own background job, so an unavailable
+// gateway never holds up the request that caused it and failed posts are
+// retried by the job queue.
+package push
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/securitylog"
+)
+
+// JobType is the job that posts one notification to the gateway
+const JobType = "push.send"
+
+// Notification is the body posted to the gateway and the payload of a
+// send job
+type Notification struct {
+	UserID int64             `json:"user_id"`
+	Title  string            `json:"title"`
+	Body   string            `json:"body"`
+	Data   map[string]string `json:"data,omitempty"`
+}
+
+// Sender posts notifications to a push gateway. Bodies are signed like
+// security event webhooks when a secret is configured.
+type Sender struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewSender(url, secret string) *Sender {
+	return &Sender{url: url, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// RunJob sends the notification of a send job
+func (s *Sender) RunJob(ctx context.Context, payload json.RawMessage) error {
+	var n Notification
+	if err := json.Unmarshal(payload, &n); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	return s.Send(ctx, n)
+}
+
+// Send posts a notification as JSON. Any status other than 2xx is an error.
+func (s *Sender) Send(ctx context.Context, n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.secret != "" {
+		req.Header.Set(securitylog.SignatureHeader, securitylog.Sign(s.secret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("push to user %d: %w", n.UserID, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("push to user %d: gateway returned %s", n.UserID, resp.Status)
+	}
+	return nil
+}
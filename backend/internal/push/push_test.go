@@ -0,0 +1,40 @@
+package push
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"LogiTrackPro/backend/internal/securitylog"
+)
+
+func TestSender(t *testing.T) {
+	var received Notification
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(securitylog.SignatureHeader); got != securitylog.Sign("secret", body) {
+			t.Errorf("signature %q does not match the body", got)
+		}
+		json.Unmarshal(body, &received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	s := NewSender(server.URL, "secret")
+	payload, _ := json.Marshal(Notification{UserID: 7, Title: "Route 3", Body: "Gate code is 1234", Data: map[string]string{"route_id": "3"}})
+	if err := s.RunJob(context.Background(), payload); err != nil {
+		t.Fatalf("RunJob() error = %v", err)
+	}
+	if received.UserID != 7 || received.Body != "Gate code is 1234" || received.Data["route_id"] != "3" {
+		t.Errorf("gateway received %+v", received)
+	}
+
+	status = http.StatusBadGateway
+	if err := s.RunJob(context.Background(), payload); err == nil {
+		t.Error("RunJob() succeeded on a 502, want an error so the job is retried")
+	}
+}
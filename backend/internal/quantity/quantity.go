@@ -0,0 +1,97 @@
+// Package quantity applies per-product rounding rules to delivery quantities
+// so plans are expressed in units that can actually be loaded, e.g. whole
+// pallets or liters to two decimals.
+package quantity
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Rounding modes
+const (
+	RoundNearest = "nearest"
+	RoundUp      = "up"
+	RoundDown    = "down"
+)
+
+// tolerance absorbs floating point noise when comparing against step multiples
+const tolerance = 1e-9
+
+// Rule rounds quantities to multiples of Step. A zero Step disables rounding.
+type Rule struct {
+	Step float64 `json:"step"`
+	Mode string  `json:"mode"`
+	Unit string  `json:"unit,omitempty"`
+}
+
+// Enabled reports whether the rule rounds anything
+func (r Rule) Enabled() bool {
+	return r.Step > 0
+}
+
+// ValidMode reports whether mode is a known rounding mode. Empty means nearest.
+func ValidMode(mode string) bool {
+	switch mode {
+	case "", RoundNearest, RoundUp, RoundDown:
+		return true
+	}
+	return false
+}
+
+// Round rounds q to a multiple of the rule's step using its mode
+func Round(q float64, r Rule) float64 {
+	if !r.Enabled() {
+		return q
+	}
+	units := q / r.Step
+	switch r.Mode {
+	case RoundUp:
+		units = math.Ceil(units - tolerance)
+	case RoundDown:
+		units = math.Floor(units + tolerance)
+	default:
+		units = math.Round(units)
+	}
+	return clean(units*r.Step, r.Step)
+}
+
+// Validate checks that a manually entered quantity is a non-negative
+// multiple of the rule's step
+func Validate(q float64, r Rule) error {
+	if q < 0 {
+		return fmt.Errorf("quantity %s must not be negative", format(q))
+	}
+	if !r.Enabled() {
+		return nil
+	}
+	units := q / r.Step
+	if math.Abs(units-math.Round(units)) > 1e-6 {
+		return fmt.Errorf("quantity %s is not a multiple of %s%s (nearest allowed: %s)",
+			format(q), format(r.Step), unitSuffix(r.Unit), format(Round(q, Rule{Step: r.Step})))
+	}
+	return nil
+}
+
+// clean strips floating point noise by rounding to the step's decimal places
+func clean(q, step float64) float64 {
+	decimals := 0
+	if s := strconv.FormatFloat(step, 'f', -1, 64); strings.Contains(s, ".") {
+		decimals = len(s) - strings.Index(s, ".") - 1
+	}
+	scale := math.Pow(10, float64(decimals))
+	return math.Round(q*scale) / scale
+}
+
+func format(q float64) string {
+	return strconv.FormatFloat(q, 'f', -1, 64)
+}
+
+func unitSuffix(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return " " + unit
+}
@@ -0,0 +1,53 @@
+package quantity
+
+import "testing"
+
+// TestRound tests rounding to steps in each mode
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name string
+		q    float64
+		rule Rule
+		want float64
+	}{
+		{"disabled", 3.4127, Rule{}, 3.4127},
+		{"whole pallets nearest", 3.4127, Rule{Step: 1}, 3},
+		{"whole pallets up", 3.4127, Rule{Step: 1, Mode: RoundUp}, 4},
+		{"whole pallets down", 3.9, Rule{Step: 1, Mode: RoundDown}, 3},
+		{"exact multiple up", 4, Rule{Step: 1, Mode: RoundUp}, 4},
+		{"noise below multiple up", 2.9999999999, Rule{Step: 1, Mode: RoundUp}, 3},
+		{"noise below multiple down", 2.9999999999, Rule{Step: 1, Mode: RoundDown}, 3},
+		{"half pallets", 3.3, Rule{Step: 0.5}, 3.5},
+		{"liters to two decimals", 10.4567, Rule{Step: 0.01}, 10.46},
+		{"decimal step without noise", 0.7, Rule{Step: 0.1, Mode: RoundUp}, 0.7},
+		{"crates of 12", 50, Rule{Step: 12}, 48},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Round(tt.q, tt.rule); got != tt.want {
+				t.Errorf("Round(%v, %+v) = %v, want %v", tt.q, tt.rule, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestValidate tests manual quantity validation
+func TestValidate(t *testing.T) {
+	pallets := Rule{Step: 1, Unit: "pallets"}
+	if err := Validate(3, pallets); err != nil {
+		t.Errorf("Validate(3) error = %v", err)
+	}
+	if err := Validate(3.4127, pallets); err == nil {
+		t.Error("Validate(3.4127) should fail for whole pallets")
+	}
+	if err := Validate(-1, Rule{}); err == nil {
+		t.Error("Validate(-1) should fail")
+	}
+	if err := Validate(0.3, Rule{Step: 0.1}); err != nil {
+		t.Errorf("Validate(0.3, step 0.1) error = %v", err)
+	}
+	if err := Validate(3.4127, Rule{}); err != nil {
+		t.Errorf("Validate() without rule error = %v", err)
+	}
+}
@@ -0,0 +1,143 @@
+// Package ratelimit limits request rates with token buckets, kept in memory
+// for a single instance or in Redis when several instances share the limits.
+// A bucket holds up to Burst tokens and refills at Rate tokens a second; each
+// request takes one token and is refused when none is left.
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Limit is the rate and burst of a bucket. A zero Rate is no limit.
+type Limit struct {
+	Rate  float64 // tokens per second
+	Burst int
+}
+
+// PerMinute is a limit of n requests a minute in bursts of up to burst; a
+// burst of 0 allows the whole minute's requests at once
+func PerMinute(n, burst int) Limit {
+	if n <= 0 {
+		return Limit{}
+	}
+	if burst <= 0 {
+		burst = n
+	}
+	return Limit{Rate: float64(n) / 60, Burst: burst}
+}
+
+// Enabled reports whether the limit limits anything
+func (l Limit) Enabled() bool {
+	return l.Rate > 0 && l.Burst > 0
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed   bool
+	Limit     int // the burst
+	Remaining int // whole tokens left
+	// RetryAfter is how long until a token is available, when refused
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps buckets by key
+type Store interface {
+	// Take takes a token from the bucket of key, creating it full
+	Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error)
+}
+
+// refill is the tokens of a bucket that had tokens at updated, at now
+func (l Limit) refill(tokens float64, updated, now time.Time) float64 {
+	if elapsed := now.Sub(updated).Seconds(); elapsed > 0 {
+		tokens = math.Min(float64(l.Burst), tokens+elapsed*l.Rate)
+	}
+	return tokens
+}
+
+// take takes a token from a bucket of tokens, returning what is left
+func (l Limit) take(tokens float64) (float64, bool) {
+	if tokens >= 1 {
+		return tokens - 1, true
+	}
+	return tokens, false
+}
+
+// result describes a bucket left with tokens
+func (l Limit) result(tokens float64, allowed bool) Result {
+	r := Result{
+		Allowed:   allowed,
+		Limit:     l.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     seconds((float64(l.Burst) - tokens) / l.Rate),
+	}
+	if !allowed {
+		r.RetryAfter = seconds((1 - tokens) / l.Rate)
+	}
+	return r
+}
+
+func seconds(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
+
+// sweepInterval is how often Memory drops buckets that have refilled
+const sweepInterval = time.Minute
+
+// Memory keeps buckets in the process. Buckets that have refilled are
+// dropped, since a full bucket is the same as none.
+type Memory struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	sweptAt time.Time
+}
+
+type bucket struct {
+	limit   Limit
+	tokens  float64
+	updated time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{buckets: make(map[string]*bucket)}
+}
+
+func (m *Memory) Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	b.limit = l
+	b.tokens = l.refill(b.tokens, b.updated, now)
+	if now.After(b.updated) {
+		b.updated = now
+	}
+	var allowed bool
+	b.tokens, allowed = l.take(b.tokens)
+	return l.result(b.tokens, allowed), nil
+}
+
+// sweep drops full buckets, at most once per sweepInterval
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.sweptAt) < sweepInterval {
+		return
+	}
+	m.sweptAt = now
+	for key, b := range m.buckets {
+		if b.limit.refill(b.tokens, b.updated, now) >= float64(b.limit.Burst) {
+			delete(m.buckets, key)
+		}
+	}
+}
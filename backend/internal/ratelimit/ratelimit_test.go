@@ -0,0 +1,128 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMemoryTokenBucket(t *testing.T) {
+	m := NewMemory()
+	ctx := context.Background()
+	l := PerMinute(60, 2) // one token a second, two at once
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	for i, want := range []bool{true, true, false} {
+		r, _ := m.Take(ctx, "a", l, now)
+		if r.Allowed != want {
+			t.Fatalf("take %d allowed = %v, want %v", i, r.Allowed, want)
+		}
+	}
+	r, _ := m.Take(ctx, "a", l, now)
+	if r.Limit != 2 || r.Remaining != 0 || r.RetryAfter != time.Second || r.Reset != 2*time.Second {
+		t.Errorf("empty bucket = %+v, want a token in 1s and full in 2s", r)
+	}
+	if r, _ := m.Take(ctx, "b", l, now); !r.Allowed || r.Remaining != 1 {
+		t.Errorf("other key = %+v, want its own full bucket", r)
+	}
+
+	// Half a second refills half a token, not enough for a request
+	if r, _ := m.Take(ctx, "a", l, now.Add(500*time.Millisecond)); r.Allowed || r.RetryAfter != 500*time.Millisecond {
+		t.Errorf("after 0.5s = %+v, want refused for another 0.5s", r)
+	}
+	if r, _ := m.Take(ctx, "a", l, now.Add(time.Second)); !r.Allowed || r.Remaining != 0 {
+		t.Errorf("after 1s = %+v, want one request allowed", r)
+	}
+
+	// Refilled buckets are swept
+	m.Take(ctx, "c", l, now.Add(time.Hour))
+	if len(m.buckets) != 1 {
+		t.Errorf("buckets after an hour = %d, want only the new one", len(m.buckets))
+	}
+}
+
+func TestPerMinute(t *testing.T) {
+	if l := PerMinute(0, 10); l.Enabled() {
+		t.Errorf("PerMinute(0) = %+v, want disabled", l)
+	}
+	if l := PerMinute(120, 0); l.Rate != 2 || l.Burst != 120 {
+		t.Errorf("PerMinute(120, 0) = %+v, want 2/s in bursts of 120", l)
+	}
+}
+
+// fakeRedis serves one connection, answering each command with the next
+// reply and recording the commands
+func fakeRedis(t *testing.T, replies ...string) (string, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	commands := make(chan []string, len(replies))
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		rd := bufio.NewReader(conn)
+		for _, reply := range replies {
+			cmd, err := readReply(rd)
+			if err != nil {
+				return
+			}
+			var args []string
+			for _, arg := range cmd.([]interface{}) {
+				args = append(args, arg.(string))
+			}
+			commands <- args
+			conn.Write([]byte(reply))
+		}
+	}()
+	return ln.Addr().String(), commands
+}
+
+func TestRedisTake(t *testing.T) {
+	addr, commands := fakeRedis(t, "+OK\r\n", "+OK\r\n", "*2\r\n:0\r\n$4\r\n0.25\r\n")
+	r, err := NewRedis(fmt.Sprintf("redis://:secret@%s/2", addr))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	now := time.UnixMilli(1709542800000)
+	res, err := r.Take(context.Background(), "user:7", PerMinute(30, 5), now)
+	if err != nil {
+		t.Fatalf("Take: %v", err)
+	}
+	if res.Allowed || res.Limit != 5 || res.Remaining != 0 || res.RetryAfter != 1500*time.Millisecond {
+		t.Errorf("result = %+v, want refused with a token in 1.5s", res)
+	}
+
+	if auth := <-commands; strings.Join(auth, " ") != "AUTH secret" {
+		t.Errorf("first command = %v, want AUTH", auth)
+	}
+	if sel := <-commands; strings.Join(sel, " ") != "SELECT 2" {
+		t.Errorf("second command = %v, want SELECT 2", sel)
+	}
+	eval := <-commands
+	if len(eval) != 7 || eval[0] != "EVAL" || eval[3] != "logitrackpro:ratelimit:user:7" || eval[4] != "0.5" || eval[5] != "5" || eval[6] != "1709542800000" {
+		t.Errorf("EVAL = %v, want the key, rate, burst and time", eval)
+	}
+}
+
+func TestRedisErrorReply(t *testing.T) {
+	addr, _ := fakeRedis(t, "-NOSCRIPT no scripting\r\n")
+	r, _ := NewRedis("redis://" + addr)
+	if _, err := r.Take(context.Background(), "ip:10.0.0.1", PerMinute(60, 0), time.Now()); err == nil || !strings.Contains(err.Error(), "NOSCRIPT") {
+		t.Errorf("Take error = %v, want the error reply", err)
+	}
+	if _, err := NewRedis("http://localhost"); err == nil {
+		t.Errorf("NewRedis accepted an http URL")
+	}
+}
@@ -0,0 +1,247 @@
+package ratelimit
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// keyPrefix namespaces the limiter's keys in a shared Redis
+const keyPrefix = "logitrackpro:ratelimit:"
+
+// defaultRedisTimeout bounds a Redis round trip when the context does not
+const defaultRedisTimeout = 2 * time.Second
+
+// maxIdleConns is how many connections Redis keeps for reuse
+const maxIdleConns = 8
+
+// takeScript refills and takes from a bucket atomically. Buckets are
+// hashes of tokens and the millisecond they were updated at, expiring once
+// they would be full again. Tokens are returned as a string since Redis
+// truncates Lua numbers to integers.
+const takeScript = `
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call('HMGET', KEYS[1], 'tokens', 'updated')
+local tokens = tonumber(bucket[1])
+local updated = tonumber(bucket[2])
+if tokens == nil or updated == nil then
+	tokens = burst
+	updated = now
+end
+if now > updated then
+	tokens = math.min(burst, tokens + (now - updated) / 1000 * rate)
+	updated = now
+end
+local allowed = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'updated', tostring(updated))
+redis.call('PEXPIRE', KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return {allowed, tostring(tokens)}
+`
+
+// Redis keeps buckets in Redis so that every instance of the API draws
+// from the same ones. It speaks just enough of the Redis protocol to run
+// the bucket script; instances' clocks are assumed to be in sync.
+type Redis struct {
+	addr     string
+	username string
+	password string
+	db       int
+	idle     chan net.Conn
+}
+
+// NewRedis connects lazily to the Redis of a redis:// URL, e.g.
+// redis://:password@localhost:6379/0
+func NewRedis(rawURL string) (*Redis, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse redis URL: %w", err)
+	}
+	if u.Scheme != "redis" {
+		return nil, fmt.Errorf("unsupported redis URL scheme %q", u.Scheme)
+	}
+	r := &Redis{addr: u.Host, idle: make(chan net.Conn, maxIdleConns)}
+	if u.Port() == "" {
+		r.addr = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	if u.User != nil {
+		r.username = u.User.Username()
+		r.password, _ = u.User.Password()
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		if r.db, err = strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis database %q", db)
+		}
+	}
+	return r, nil
+}
+
+func (r *Redis) Take(ctx context.Context, key string, l Limit, now time.Time) (Result, error) {
+	reply, err := r.do(ctx, "EVAL", takeScript, "1", keyPrefix+key,
+		strconv.FormatFloat(l.Rate, 'f', -1, 64),
+		strconv.Itoa(l.Burst),
+		strconv.FormatInt(now.UnixMilli(), 10))
+	if err != nil {
+		return Result{}, err
+	}
+	values, ok := reply.([]interface{})
+	if !ok || len(values) != 2 {
+		return Result{}, fmt.Errorf("unexpected redis reply %v", reply)
+	}
+	allowed, _ := values[0].(int64)
+	raw, _ := values[1].(string)
+	tokens, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return Result{}, fmt.Errorf("unexpected redis reply %v", reply)
+	}
+	return l.result(tokens, allowed == 1), nil
+}
+
+// Close closes the idle connections
+func (r *Redis) Close() error {
+	for {
+		select {
+		case conn := <-r.idle:
+			conn.Close()
+		default:
+			return nil
+		}
+	}
+}
+
+// redisError is an error reply
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+// do sends a command and reads its reply on an idle or new connection.
+// Connections are dropped after any error rather than risk reading a stale
+// reply on them.
+func (r *Redis) do(ctx context.Context, args ...string) (interface{}, error) {
+	conn, err := r.conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	reply, err := roundTrip(ctx, conn, args...)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	select {
+	case r.idle <- conn:
+	default:
+		conn.Close()
+	}
+	return reply, nil
+}
+
+// conn takes an idle connection or dials, authenticates and selects the
+// database on a new one
+func (r *Redis) conn(ctx context.Context) (net.Conn, error) {
+	select {
+	case conn := <-r.idle:
+		return conn, nil
+	default:
+	}
+	dialer := net.Dialer{Timeout: defaultRedisTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", r.addr)
+	if err != nil {
+		return nil, fmt.Errorf("dial redis: %w", err)
+	}
+	var setup [][]string
+	if r.password != "" {
+		if r.username != "" {
+			setup = append(setup, []string{"AUTH", r.username, r.password})
+		} else {
+			setup = append(setup, []string{"AUTH", r.password})
+		}
+	}
+	if r.db != 0 {
+		setup = append(setup, []string{"SELECT", strconv.Itoa(r.db)})
+	}
+	for _, args := range setup {
+		if _, err := roundTrip(ctx, conn, args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// roundTrip writes a command as an array of bulk strings and reads the reply
+func roundTrip(ctx context.Context, conn net.Conn, args ...string) (interface{}, error) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultRedisTimeout)
+	}
+	conn.SetDeadline(deadline)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if _, err := conn.Write([]byte(b.String())); err != nil {
+		return nil, fmt.Errorf("write redis command: %w", err)
+	}
+	// replies are read whole, so nothing is left buffered between commands
+	return readReply(bufio.NewReader(conn))
+}
+
+// readReply reads a reply: a string, an int64, nil, a slice of replies or
+// a redisError
+func readReply(rd *bufio.Reader) (interface{}, error) {
+	line, err := rd.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("read redis reply: %w", err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if line == "" {
+		return nil, errors.New("read redis reply: empty line")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(rd, buf); err != nil {
+			return nil, fmt.Errorf("read redis reply: %w", err)
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		values := make([]interface{}, n)
+		for i := range values {
+			if values[i], err = readReply(rd); err != nil {
+				return nil, err
+			}
+		}
+		return values, nil
+	}
+	return nil, fmt.Errorf("read redis reply: unexpected %q", line)
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type gormCustomers struct {
+	db *gorm.DB
+}
+
+func (r gormCustomers) List() ([]models.Customer, error) {
+	return database.ListCustomers(r.db)
+}
+
+func (r gormCustomers) Page(f database.CustomerFilter, offset, limit int) ([]models.Customer, int64, error) {
+	return database.ListCustomersPage(r.db, f, offset, limit)
+}
+
+func (r gormCustomers) Get(id int64) (*models.Customer, error) {
+	return database.GetCustomer(r.db, id)
+}
+
+func (r gormCustomers) Create(c *models.Customer) error {
+	return database.CreateCustomer(r.db, c)
+}
+
+func (r gormCustomers) Update(c *models.Customer) error {
+	return database.UpdateCustomer(r.db, c)
+}
+
+func (r gormCustomers) Patch(c *models.Customer, columns []string) error {
+	return database.UpdateCustomerColumns(r.db, c, columns)
+}
+
+func (r gormCustomers) Delete(id int64) error {
+	return database.DeleteCustomer(r.db, id)
+}
+
+type gormPlans struct {
+	db *gorm.DB
+}
+
+func (r gormPlans) List(f database.PlanFilter, offset, limit int) ([]models.Plan, int64, error) {
+	return database.ListPlansPage(r.db, f, offset, limit)
+}
+
+func (r gormPlans) Get(id int64) (*models.Plan, error) {
+	return database.GetPlan(r.db, id)
+}
+
+func (r gormPlans) GetWithDeleted(id int64) (*models.Plan, error) {
+	return database.GetPlanWithDeleted(r.db, id)
+}
+
+func (r gormPlans) Create(p *models.Plan) error {
+	return database.CreatePlan(r.db, p)
+}
+
+func (r gormPlans) Transition(id int64, from, to string, extra map[string]interface{}) error {
+	return database.TransitionPlanStatus(r.db, id, from, to, extra)
+}
+
+func (r gormPlans) Delete(id int64) error {
+	return database.DeletePlan(r.db, id)
+}
+
+func (r gormPlans) Purge(id int64) error {
+	return database.PurgePlan(r.db, id)
+}
+
+type gormExecutions struct {
+	db *gorm.DB
+}
+
+func (r gormExecutions) Create(e *models.RouteExecution) error {
+	return database.CreateRouteExecution(r.db, e)
+}
+
+func (r gormExecutions) Get(id int64) (*models.RouteExecution, error) {
+	return database.GetRouteExecution(r.db, id)
+}
+
+func (r gormExecutions) ListByRoute(routeID int64) ([]models.RouteExecution, error) {
+	return database.GetRouteExecutionsByRoute(r.db, routeID)
+}
+
+func (r gormExecutions) Update(e *models.RouteExecution) error {
+	return database.UpdateRouteExecution(r.db, e)
+}
+
+func (r gormExecutions) Complete(id int64, actualDistance, actualCost, actualLoad float64, endTime time.Time) error {
+	return database.CompleteRouteExecution(r.db, id, actualDistance, actualCost, actualLoad, endTime)
+}
+
+func (r gormExecutions) Stats(planID int64) (map[string]interface{}, error) {
+	return database.GetExecutionStats(r.db, planID)
+}
@@ -0,0 +1,79 @@
+// Package repository defines the storage handlers depend on, one interface
+// per aggregate, so handlers can be unit tested against in-memory fakes and
+// storage backends can be swapped. The GORM implementations delegate to the
+// database package.
+//
+// Implementations return ErrNotFound for missing records. Operations that
+// need a transaction across aggregates still go through the database
+// package.
+package repository
+
+import (
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned for missing records
+var ErrNotFound = database.ErrNotFound
+
+// Customers stores customers
+type Customers interface {
+	List() ([]models.Customer, error)
+	// Page returns one page of the customers matching f and the number of
+	// matching customers
+	Page(f database.CustomerFilter, offset, limit int) ([]models.Customer, int64, error)
+	Get(id int64) (*models.Customer, error)
+	Create(c *models.Customer) error
+	Update(c *models.Customer) error
+	// Patch writes the given columns of a customer, zero values included
+	Patch(c *models.Customer, columns []string) error
+	Delete(id int64) error
+}
+
+// Plans stores plans
+type Plans interface {
+	// List returns one page of the plans matching f and the number of
+	// matching plans
+	List(f database.PlanFilter, offset, limit int) ([]models.Plan, int64, error)
+	Get(id int64) (*models.Plan, error)
+	// GetWithDeleted also finds soft-deleted plans
+	GetWithDeleted(id int64) (*models.Plan, error)
+	Create(p *models.Plan) error
+	// Transition moves a plan from one status to another, also setting the
+	// extra columns, and returns ErrNotFound when it is not in from
+	Transition(id int64, from, to string, extra map[string]interface{}) error
+	// Delete soft-deletes a plan
+	Delete(id int64) error
+	// Purge removes a plan with everything recorded for it
+	Purge(id int64) error
+}
+
+// Executions stores route executions
+type Executions interface {
+	Create(e *models.RouteExecution) error
+	Get(id int64) (*models.RouteExecution, error)
+	ListByRoute(routeID int64) ([]models.RouteExecution, error)
+	Update(e *models.RouteExecution) error
+	Complete(id int64, actualDistance, actualCost, actualLoad float64, endTime time.Time) error
+	Stats(planID int64) (map[string]interface{}, error)
+}
+
+// Repositories bundles the repositories a handler uses
+type Repositories struct {
+	Customers  Customers
+	Plans      Plans
+	Executions Executions
+}
+
+// NewGorm returns repositories storing in db
+func NewGorm(db *gorm.DB) Repositories {
+	return Repositories{
+		Customers:  gormCustomers{db},
+		Plans:      gormPlans{db},
+		Executions: gormExecutions{db},
+	}
+}
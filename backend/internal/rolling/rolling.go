@@ -0,0 +1,123 @@
+// Package rolling slides the horizon of rolling plans: every night the days
+// that have passed fall off the front of the plan, the same number of days
+// is appended at the end and the open window is re-optimized.
+package rolling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"gorm.io/gorm"
+)
+
+// JobType is the periodic job that rolls plans
+const JobType = "plans.roll"
+
+// FrozenDays are the days at the front of a rolled plan that are kept as
+// planned: day 1 is today, whose routes are already being dispatched
+const FrozenDays = 1
+
+// Statuses are the plan statuses whose horizon rolls. Only optimized plans
+// are re-optimized; approved and executing plans keep their routes and get
+// empty days appended for a planner to fill.
+var Statuses = []string{planstate.Optimized, planstate.Approved, planstate.Executing}
+
+// Shift returns the number of days a plan's horizon moves at now: the days
+// between its start date and today
+func Shift(plan models.Plan, now time.Time) int {
+	today := now.UTC().Truncate(24 * time.Hour)
+	start := plan.StartDate.UTC().Truncate(24 * time.Hour)
+	if !start.Before(today) {
+		return 0
+	}
+	return int(today.Sub(start).Hours() / 24)
+}
+
+// ReoptimizeFunc re-solves a plan from fromDay to its end
+type ReoptimizeFunc func(plan *models.Plan, fromDay int) error
+
+// Rolled reports a plan moved by the roller
+type Rolled struct {
+	PlanID      int64
+	Shift       int
+	Reoptimized bool
+}
+
+// Roller rolls the horizon of rolling plans
+type Roller struct {
+	db         *gorm.DB
+	reoptimize ReoptimizeFunc
+	clock      clock.Clock
+}
+
+func New(db *gorm.DB, reoptimize ReoptimizeFunc) *Roller {
+	return &Roller{db: db, reoptimize: reoptimize, clock: clock.Real}
+}
+
+// SetClock replaces the clock RunJob reads today from
+func (r *Roller) SetClock(c clock.Clock) {
+	r.clock = c
+}
+
+// RunJob runs the roller as a background job
+func (r *Roller) RunJob(ctx context.Context, _ json.RawMessage) error {
+	rolled, err := r.RunOnce(r.clock.Now())
+	for _, p := range rolled {
+		log.Printf("Plan roller: moved plan %d by %d day(s), re-optimized: %t", p.PlanID, p.Shift, p.Reoptimized)
+	}
+	return err
+}
+
+// RunOnce moves every rolling plan that starts before today so that it
+// starts today and keeps its length, then re-optimizes the days after the
+// frozen ones. A failed re-optimization leaves the plan rolled with its
+// previous routes and is reported in the error.
+func (r *Roller) RunOnce(now time.Time) ([]Rolled, error) {
+	plans, err := database.ListRollingPlans(r.db, Statuses)
+	if err != nil {
+		return nil, fmt.Errorf("list plans: %w", err)
+	}
+
+	rolled := []Rolled{}
+	var errs []error
+	for _, plan := range plans {
+		shift := Shift(plan, now)
+		if shift == 0 {
+			continue
+		}
+		err := r.db.Transaction(func(tx *gorm.DB) error {
+			return database.RollPlanTx(tx, plan.ID, plan.StartDate, plan.EndDate, shift)
+		})
+		if errors.Is(err, database.ErrNotFound) {
+			// Another roller moved this plan first
+			continue
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plan %d: %w", plan.ID, err))
+			continue
+		}
+		plan.StartDate = plan.StartDate.AddDate(0, 0, shift)
+		plan.EndDate = plan.EndDate.AddDate(0, 0, shift)
+
+		result := Rolled{PlanID: plan.ID, Shift: shift}
+		horizon := int(plan.EndDate.Sub(plan.StartDate).Hours()/24) + 1
+		if plan.Status == planstate.Optimized && FrozenDays < horizon {
+			if err := r.reoptimize(&plan, FrozenDays+1); err != nil {
+				errs = append(errs, fmt.Errorf("plan %d: re-optimize: %w", plan.ID, err))
+			} else {
+				result.Reoptimized = true
+			}
+		}
+		rolled = append(rolled, result)
+	}
+	return rolled, errors.Join(errs...)
+}
@@ -0,0 +1,140 @@
+package rolling
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestShift(t *testing.T) {
+	plan := models.Plan{StartDate: date("2024-01-08"), EndDate: date("2024-01-14")}
+	tests := []struct {
+		now  time.Time
+		want int
+	}{
+		{date("2024-01-07").Add(23 * time.Hour), 0},
+		{date("2024-01-08").Add(12 * time.Hour), 0},
+		{date("2024-01-09").Add(time.Minute), 1},
+		{date("2024-01-11"), 3},
+	}
+	for _, tt := range tests {
+		if got := Shift(plan, tt.now); got != tt.want {
+			t.Errorf("Shift(%v) = %d, want %d", tt.now, got, tt.want)
+		}
+	}
+}
+
+// TestRunOnce tests that rolling plans move to today, that routes are
+// renumbered or dropped and that only optimized plans are re-optimized
+func TestRunOnce(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Plan{}, &models.Route{}, &models.Stop{}, &models.RouteExecution{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+
+	start, end := date("2024-01-08"), date("2024-01-14")
+	optimized := &models.Plan{Name: "Rolling", StartDate: start, EndDate: end, Status: planstate.Optimized, Rolling: true}
+	approved := &models.Plan{Name: "Approved", StartDate: start, EndDate: end, Status: planstate.Approved, Rolling: true}
+	fixed := &models.Plan{Name: "Fixed", StartDate: start, EndDate: end, Status: planstate.Optimized}
+	for _, p := range []*models.Plan{optimized, approved, fixed} {
+		if err := database.CreatePlan(db, p); err != nil {
+			t.Fatalf("CreatePlan() error = %v", err)
+		}
+	}
+	routes := map[int]*models.Route{}
+	for _, day := range []int{1, 2, 3} {
+		r := &models.Route{PlanID: optimized.ID, Day: day, Date: start.AddDate(0, 0, day-1)}
+		if err := database.CreateRoute(db, r); err != nil {
+			t.Fatalf("CreateRoute() error = %v", err)
+		}
+		routes[day] = r
+	}
+	// Day 1 was driven, day 2 never started
+	if err := db.Create(&models.RouteExecution{RouteID: routes[1].ID, Status: "completed"}).Error; err != nil {
+		t.Fatalf("create execution: %v", err)
+	}
+
+	var reoptimized []int64
+	roller := New(db, func(plan *models.Plan, fromDay int) error {
+		if fromDay != 2 || !plan.StartDate.Equal(date("2024-01-10")) {
+			t.Errorf("reoptimize(%v, %d), want the rolled plan from day 2", plan.StartDate, fromDay)
+		}
+		reoptimized = append(reoptimized, plan.ID)
+		return nil
+	})
+
+	now := date("2024-01-10").Add(time.Hour)
+	rolled, err := roller.RunOnce(now)
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if len(rolled) != 2 || rolled[0].Shift != 2 || !rolled[0].Reoptimized || rolled[1].Reoptimized {
+		t.Fatalf("RunOnce() = %+v, want both rolling plans moved by 2 and only the optimized one re-optimized", rolled)
+	}
+	if len(reoptimized) != 1 || reoptimized[0] != optimized.ID {
+		t.Errorf("re-optimized plans %v, want [%d]", reoptimized, optimized.ID)
+	}
+
+	stored, _ := database.GetPlan(db, optimized.ID)
+	if !stored.StartDate.Equal(date("2024-01-10")) || !stored.EndDate.Equal(date("2024-01-16")) {
+		t.Errorf("plan runs %v to %v, want 2024-01-10 to 2024-01-16", stored.StartDate, stored.EndDate)
+	}
+	stored, _ = database.GetPlan(db, fixed.ID)
+	if !stored.StartDate.Equal(start) {
+		t.Errorf("non-rolling plan starts %v, want %v", stored.StartDate, start)
+	}
+
+	var days []int
+	db.Model(&models.Route{}).Where("plan_id = ?", optimized.ID).Order("day").Pluck("day", &days)
+	// The executed day 1 is kept as history at day -1, day 2 is dropped and
+	// day 3 is today
+	if len(days) != 2 || days[0] != -1 || days[1] != 1 {
+		t.Errorf("route days = %v, want [-1 1]", days)
+	}
+
+	rolled, err = roller.RunOnce(now.Add(time.Hour))
+	if err != nil || len(rolled) != 0 {
+		t.Errorf("second RunOnce() = %+v, %v, want nothing to roll", rolled, err)
+	}
+}
+
+// TestRunOnceReoptimizeError tests that a failed re-optimization is reported
+// and the plan stays rolled
+func TestRunOnceReoptimizeError(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.Plan{}, &models.Route{}, &models.RouteExecution{}); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	plan := &models.Plan{Name: "Rolling", StartDate: date("2024-01-08"), EndDate: date("2024-01-10"), Status: planstate.Optimized, Rolling: true}
+	if err := database.CreatePlan(db, plan); err != nil {
+		t.Fatalf("CreatePlan() error = %v", err)
+	}
+
+	roller := New(db, func(*models.Plan, int) error { return errors.New("no vehicles") })
+	rolled, err := roller.RunOnce(date("2024-01-09"))
+	if err == nil || len(rolled) != 1 || rolled[0].Reoptimized {
+		t.Fatalf("RunOnce() = %+v, %v, want the plan rolled with an error", rolled, err)
+	}
+	stored, _ := database.GetPlan(db, plan.ID)
+	if !stored.StartDate.Equal(date("2024-01-09")) {
+		t.Errorf("plan starts %v, want 2024-01-09", stored.StartDate)
+	}
+}
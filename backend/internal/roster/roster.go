@@ -0,0 +1,171 @@
+// Package roster checks driver-to-vehicle day assignments and turns them
+// into per-vehicle availability for the optimizer.
+package roster
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// Conflict types
+const (
+	DriverDoubleBooked  = "driver_double_booked"
+	VehicleDoubleBooked = "vehicle_double_booked"
+	DriverOnLeave       = "driver_on_leave"
+	DriverInactive      = "driver_inactive"
+	VehicleUnavailable  = "vehicle_unavailable"
+	DriverAbsent        = "driver_absent"
+)
+
+// Resources holds the drivers, vehicles and approved absences referenced by
+// the roster entries being checked, keyed by ID
+type Resources struct {
+	Drivers  map[int64]models.Driver
+	Vehicles map[int64]models.Vehicle
+	Absences map[int64][]models.DriverAbsence // by driver ID
+}
+
+// AbsenceOn returns the driver's approved absence covering date, if any
+func (r Resources) AbsenceOn(driverID int64, date time.Time) *models.DriverAbsence {
+	for i := range r.Absences[driverID] {
+		a := &r.Absences[driverID][i]
+		if !date.Before(a.StartDate) && !date.After(a.EndDate) {
+			return a
+		}
+	}
+	return nil
+}
+
+type dayKey struct {
+	id   int64
+	date string
+}
+
+// DetectConflicts checks candidate entries against each other and against
+// existing entries. A driver or vehicle missing from res is not reported
+// here, callers validate references separately.
+func DetectConflicts(candidates, existing []models.RosterEntry, res Resources) []models.RosterConflict {
+	conflicts := []models.RosterConflict{}
+
+	driverDays := make(map[dayKey]*int64)
+	vehicleDays := make(map[dayKey]*int64)
+	for i := range existing {
+		e := &existing[i]
+		date := e.Date.Format("2006-01-02")
+		driverDays[dayKey{e.DriverID, date}] = &e.ID
+		vehicleDays[dayKey{e.VehicleID, date}] = &e.ID
+	}
+
+	for _, c := range candidates {
+		date := c.Date.Format("2006-01-02")
+		conflict := func(kind string, entryID *int64, message string) {
+			conflicts = append(conflicts, models.RosterConflict{
+				Type:      kind,
+				Date:      date,
+				DriverID:  c.DriverID,
+				VehicleID: c.VehicleID,
+				EntryID:   entryID,
+				Message:   message,
+			})
+		}
+
+		if entryID, taken := driverDays[dayKey{c.DriverID, date}]; taken {
+			conflict(DriverDoubleBooked, entryID, fmt.Sprintf("driver %d is already rostered on %s", c.DriverID, date))
+		}
+		if entryID, taken := vehicleDays[dayKey{c.VehicleID, date}]; taken {
+			conflict(VehicleDoubleBooked, entryID, fmt.Sprintf("vehicle %d already has a driver on %s", c.VehicleID, date))
+		}
+		conflicts = append(conflicts, entryConflicts(c, res)...)
+
+		var entryID *int64
+		if c.ID != 0 {
+			id := c.ID
+			entryID = &id
+		}
+		driverDays[dayKey{c.DriverID, date}] = entryID
+		vehicleDays[dayKey{c.VehicleID, date}] = entryID
+	}
+
+	return conflicts
+}
+
+// CheckEntries re-validates stored entries against current driver and vehicle
+// status and absences, e.g. after a driver was put on leave
+func CheckEntries(entries []models.RosterEntry, res Resources) []models.RosterConflict {
+	conflicts := []models.RosterConflict{}
+	for _, e := range entries {
+		conflicts = append(conflicts, entryConflicts(e, res)...)
+	}
+	return conflicts
+}
+
+// Usable returns the entries that can be honoured: the driver is active and
+// not absent, and the vehicle is available
+func Usable(entries []models.RosterEntry, res Resources) []models.RosterEntry {
+	usable := make([]models.RosterEntry, 0, len(entries))
+	for _, e := range entries {
+		if len(entryConflicts(e, res)) == 0 {
+			usable = append(usable, e)
+		}
+	}
+	return usable
+}
+
+func entryConflicts(e models.RosterEntry, res Resources) []models.RosterConflict {
+	var conflicts []models.RosterConflict
+	date := e.Date.Format("2006-01-02")
+	var entryID *int64
+	if e.ID != 0 {
+		id := e.ID
+		entryID = &id
+	}
+	add := func(kind, message string) {
+		conflicts = append(conflicts, models.RosterConflict{
+			Type:      kind,
+			Date:      date,
+			DriverID:  e.DriverID,
+			VehicleID: e.VehicleID,
+			EntryID:   entryID,
+			Message:   message,
+		})
+	}
+
+	if d, ok := res.Drivers[e.DriverID]; ok {
+		switch d.Status {
+		case "on_leave":
+			add(DriverOnLeave, fmt.Sprintf("driver %s is on leave", d.Name))
+		case "inactive":
+			add(DriverInactive, fmt.Sprintf("driver %s is inactive", d.Name))
+		}
+	}
+	if a := res.AbsenceOn(e.DriverID, e.Date); a != nil {
+		add(DriverAbsent, fmt.Sprintf("driver %d is absent (%s) from %s to %s", e.DriverID, a.Type,
+			a.StartDate.Format("2006-01-02"), a.EndDate.Format("2006-01-02")))
+	}
+	if v, ok := res.Vehicles[e.VehicleID]; ok && !v.Available {
+		add(VehicleUnavailable, fmt.Sprintf("vehicle %s is not available", v.Name))
+	}
+	return conflicts
+}
+
+// VehicleDays maps each vehicle to the 1-based planning days it is rostered
+// on, counting from start. Entries with conflicts should be filtered out
+// before calling.
+func VehicleDays(entries []models.RosterEntry, start time.Time) map[int64][]int {
+	days := make(map[int64][]int)
+	for _, e := range entries {
+		day := int(math.Round(e.Date.Sub(start).Hours()/24)) + 1
+		if day < 1 {
+			continue
+		}
+		days[e.VehicleID] = append(days[e.VehicleID], day)
+	}
+	for id := range days {
+		sort.Ints(days[id])
+	}
+	return days
+}
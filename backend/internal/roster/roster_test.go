@@ -0,0 +1,76 @@
+package roster
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+var day1 = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// TestDetectConflicts tests double-booking and driver/vehicle status checks
+func TestDetectConflicts(t *testing.T) {
+	drivers := map[int64]models.Driver{
+		1: {ID: 1, Name: "Ann", Status: "active"},
+		2: {ID: 2, Name: "Bob", Status: "on_leave"},
+		3: {ID: 3, Name: "Cy", Status: "active"},
+	}
+	vehicles := map[int64]models.Vehicle{
+		10: {ID: 10, Name: "Truck 10", Available: true},
+		11: {ID: 11, Name: "Truck 11", Available: false},
+		12: {ID: 12, Name: "Truck 12", Available: true},
+	}
+	absences := map[int64][]models.DriverAbsence{
+		3: {{DriverID: 3, Type: "vacation", StartDate: day1.AddDate(0, 0, 5), EndDate: day1.AddDate(0, 0, 7)}},
+	}
+	res := Resources{Drivers: drivers, Vehicles: vehicles, Absences: absences}
+	existing := []models.RosterEntry{{ID: 100, DriverID: 1, VehicleID: 10, Date: day1}}
+
+	tests := []struct {
+		name      string
+		candidate []models.RosterEntry
+		want      []string
+	}{
+		{"free slot", []models.RosterEntry{{DriverID: 3, VehicleID: 12, Date: day1}}, nil},
+		{"driver double booked", []models.RosterEntry{{DriverID: 1, VehicleID: 12, Date: day1}}, []string{DriverDoubleBooked}},
+		{"vehicle double booked", []models.RosterEntry{{DriverID: 3, VehicleID: 10, Date: day1}}, []string{VehicleDoubleBooked}},
+		{"same driver next day", []models.RosterEntry{{DriverID: 1, VehicleID: 10, Date: day1.AddDate(0, 0, 1)}}, nil},
+		{"driver on leave", []models.RosterEntry{{DriverID: 2, VehicleID: 12, Date: day1}}, []string{DriverOnLeave}},
+		{"vehicle unavailable", []models.RosterEntry{{DriverID: 3, VehicleID: 11, Date: day1}}, []string{VehicleUnavailable}},
+		{"driver absent", []models.RosterEntry{{DriverID: 3, VehicleID: 12, Date: day1.AddDate(0, 0, 7)}}, []string{DriverAbsent}},
+		{"day after absence", []models.RosterEntry{{DriverID: 3, VehicleID: 12, Date: day1.AddDate(0, 0, 8)}}, nil},
+		{"clash within request", []models.RosterEntry{
+			{DriverID: 3, VehicleID: 12, Date: day1.AddDate(0, 0, 2)},
+			{DriverID: 3, VehicleID: 10, Date: day1.AddDate(0, 0, 2)},
+		}, []string{DriverDoubleBooked}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, c := range DetectConflicts(tt.candidate, existing, res) {
+				got = append(got, c.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DetectConflicts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestVehicleDays tests conversion of roster dates to planning days
+func TestVehicleDays(t *testing.T) {
+	entries := []models.RosterEntry{
+		{VehicleID: 10, Date: day1.AddDate(0, 0, 2)},
+		{VehicleID: 10, Date: day1},
+		{VehicleID: 11, Date: day1.AddDate(0, 0, 1)},
+		{VehicleID: 12, Date: day1.AddDate(0, 0, -1)},
+	}
+	got := VehicleDays(entries, day1)
+	want := map[int64][]int{10: {1, 3}, 11: {2}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("VehicleDays() = %v, want %v", got, want)
+	}
+}
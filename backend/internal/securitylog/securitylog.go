@@ -0,0 +1,112 @@
+// Package securitylog defines the security event types and forwards stored
+// events to a SIEM webhook. Each event is forwarded by its own background
+// job, so a slow or unavailable SIEM never holds up a login and failed
+// posts are retried by the job queue.
+package securitylog
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Event types
+const (
+	Login               = "login"
+	LoginFailed         = "login_failed"
+	PasswordChanged     = "password_changed"
+	TokensRevoked       = "tokens_revoked"
+	RoleChanged         = "role_changed"
+	OrganizationChanged = "organization_changed"
+)
+
+// Types lists every event type
+var Types = []string{Login, LoginFailed, PasswordChanged, TokensRevoked, RoleChanged, OrganizationChanged}
+
+// ForwardJobType is the job that posts one event to the webhook
+const ForwardJobType = "security_events.forward"
+
+// SignatureHeader carries the hex HMAC-SHA256 of the body when a secret is
+// configured
+const SignatureHeader = "X-LogiTrack-Signature"
+
+// ForwardPayload is the payload of a forward job
+type ForwardPayload struct {
+	EventID int64 `json:"event_id"`
+}
+
+// Forwarder posts security events to a webhook
+type Forwarder struct {
+	db     *gorm.DB
+	url    string
+	secret string
+	client *http.Client
+}
+
+func NewForwarder(db *gorm.DB, url, secret string) *Forwarder {
+	return &Forwarder{db: db, url: url, secret: secret, client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+// RunJob forwards the event of a forward job. Events deleted since they
+// were queued are skipped.
+func (f *Forwarder) RunJob(ctx context.Context, payload json.RawMessage) error {
+	var p ForwardPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	event, err := database.GetSecurityEvent(f.db, p.EventID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	return f.Forward(ctx, event)
+}
+
+// Forward posts an event as JSON. Any status other than 2xx is an error.
+func (f *Forwarder) Forward(ctx context.Context, event *models.SecurityEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if f.secret != "" {
+		req.Header.Set(SignatureHeader, Sign(f.secret, body))
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("post event %d: %w", event.ID, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("post event %d: webhook returned %s", event.ID, resp.Status)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
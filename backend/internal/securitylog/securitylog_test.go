@@ -0,0 +1,54 @@
+package securitylog
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+func TestForwarder(t *testing.T) {
+	db := testkit.DB(t)
+	event := &models.SecurityEvent{Type: LoginFailed, Email: "someone@example.com", Detail: "wrong password"}
+	if err := database.CreateSecurityEvent(db, event); err != nil {
+		t.Fatal(err)
+	}
+
+	var received models.SecurityEvent
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(SignatureHeader); got != Sign("secret", body) {
+			t.Errorf("signature %q does not match the body", got)
+		}
+		json.Unmarshal(body, &received)
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	f := NewForwarder(db, server.URL, "secret")
+	payload, _ := json.Marshal(ForwardPayload{EventID: event.ID})
+	if err := f.RunJob(context.Background(), payload); err != nil {
+		t.Fatalf("RunJob() error = %v", err)
+	}
+	if received.ID != event.ID || received.Type != LoginFailed || received.Email != event.Email {
+		t.Errorf("webhook received %+v", received)
+	}
+
+	status = http.StatusServiceUnavailable
+	if err := f.RunJob(context.Background(), payload); err == nil {
+		t.Error("RunJob() succeeded on a 503, want an error so the job is retried")
+	}
+
+	missing, _ := json.Marshal(ForwardPayload{EventID: event.ID + 1})
+	if err := f.RunJob(context.Background(), missing); err != nil {
+		t.Errorf("RunJob() for a deleted event error = %v, want nil", err)
+	}
+
+}
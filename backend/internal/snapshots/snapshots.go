@@ -0,0 +1,132 @@
+// Package snapshots takes the daily inventory snapshots of all customers and
+// warehouses at a configured time of day, which the inventory history and
+// analytics read from.
+package snapshots
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"LogiTrackPro/backend/internal/alerts"
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+
+	"gorm.io/gorm"
+)
+
+// Reason is the snapshot reason of the daily snapshots
+const Reason = "daily"
+
+// Schedule is a time of day in a timezone
+type Schedule struct {
+	hour, minute int
+	location     *time.Location
+}
+
+// ParseSchedule parses a time of day as HH:MM and an IANA timezone name
+func ParseSchedule(at, timezone string) (*Schedule, error) {
+	t, err := time.Parse("15:04", at)
+	if err != nil {
+		return nil, fmt.Errorf("invalid time of day %q (use HH:MM)", at)
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", timezone, err)
+	}
+	return &Schedule{hour: t.Hour(), minute: t.Minute(), location: location}, nil
+}
+
+// at returns the scheduled time on the local day of now
+func (s *Schedule) at(now time.Time) time.Time {
+	local := now.In(s.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), s.hour, s.minute, 0, 0, s.location)
+}
+
+// Next returns the first scheduled time after now
+func (s *Schedule) Next(now time.Time) time.Time {
+	next := s.at(now)
+	if !next.After(now) {
+		local := now.In(s.location)
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, s.hour, s.minute, 0, 0, s.location)
+	}
+	return next
+}
+
+// Due reports whether the scheduled time of now's local day has passed
+func (s *Schedule) Due(now time.Time) bool {
+	return !now.Before(s.at(now))
+}
+
+// Date returns now's local calendar date, as the UTC midnight snapshot
+// dates are stored at
+func (s *Schedule) Date(now time.Time) time.Time {
+	local := now.In(s.location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Take creates the daily snapshots of a date taken at now, unless that date
+// already has them, and queues a low-stock alert scan of all customers and
+// warehouses
+func Take(db *gorm.DB, date time.Time, now time.Time) (*models.DailySnapshots, error) {
+	result := &models.DailySnapshots{Date: date.Format("2006-01-02")}
+	taken, err := database.HasDailyInventorySnapshots(db, date)
+	if err != nil {
+		return nil, fmt.Errorf("check snapshots: %w", err)
+	}
+	if taken {
+		return result, nil
+	}
+	result.Snapshots, err = database.CreateDailyInventorySnapshots(db, date, Reason, now)
+	if err != nil {
+		return nil, fmt.Errorf("create snapshots: %w", err)
+	}
+	result.Created = true
+	if _, err := jobs.Enqueue(db, alerts.JobType, alerts.Scope{}, jobs.Options{RunAt: now}); err != nil {
+		log.Printf("Failed to queue alert scan after daily snapshots: %v", err)
+	}
+	return result, nil
+}
+
+// JobType is the scheduled job that takes the snapshots
+const JobType = "inventory_snapshots.daily"
+
+// Taker takes the daily snapshots on a schedule
+type Taker struct {
+	db       *gorm.DB
+	schedule *Schedule
+	clock    clock.Clock
+}
+
+func New(db *gorm.DB, schedule *Schedule) *Taker {
+	return &Taker{db: db, schedule: schedule, clock: clock.Real}
+}
+
+// SetClock replaces the clock RunJob reads the current time from
+func (t *Taker) SetClock(c clock.Clock) {
+	t.clock = c
+}
+
+// RunJob runs the taker as a background job
+func (t *Taker) RunJob(ctx context.Context, _ json.RawMessage) error {
+	result, err := t.RunOnce(t.clock.Now())
+	if result != nil && result.Created {
+		log.Printf("Inventory snapshots: took %d daily snapshots for %s", result.Snapshots, result.Date)
+	}
+	return err
+}
+
+// RunOnce takes today's snapshots once the scheduled time has passed. A
+// taker started after the scheduled time catches up on today, but days it
+// was down for are not back-filled. The result is nil when the snapshots
+// are not due yet.
+func (t *Taker) RunOnce(now time.Time) (*models.DailySnapshots, error) {
+	if !t.schedule.Due(now) {
+		return nil, nil
+	}
+	return Take(t.db, t.schedule.Date(now), now)
+}
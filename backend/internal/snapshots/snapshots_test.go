@@ -0,0 +1,84 @@
+package snapshots
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+func TestScheduleNext(t *testing.T) {
+	s, err := ParseSchedule("23:55", "Europe/Berlin")
+	if err != nil {
+		t.Fatalf("ParseSchedule() error = %v", err)
+	}
+	tests := []struct {
+		now  time.Time
+		want time.Time
+		due  bool
+		date string
+	}{
+		// 10:00 in Berlin
+		{time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC), time.Date(2024, 3, 4, 22, 55, 0, 0, time.UTC), false, "2024-03-04"},
+		// exactly at the scheduled time
+		{time.Date(2024, 3, 4, 22, 55, 0, 0, time.UTC), time.Date(2024, 3, 5, 22, 55, 0, 0, time.UTC), true, "2024-03-04"},
+		// already the next day in Berlin
+		{time.Date(2024, 3, 4, 23, 30, 0, 0, time.UTC), time.Date(2024, 3, 5, 22, 55, 0, 0, time.UTC), false, "2024-03-05"},
+		// clocks go forward on 31 March
+		{time.Date(2024, 3, 30, 23, 0, 0, 0, time.UTC), time.Date(2024, 3, 31, 21, 55, 0, 0, time.UTC), false, "2024-03-31"},
+	}
+	for _, tt := range tests {
+		if got := s.Next(tt.now); !got.Equal(tt.want) {
+			t.Errorf("Next(%v) = %v, want %v", tt.now, got.UTC(), tt.want)
+		}
+		if got := s.Due(tt.now); got != tt.due {
+			t.Errorf("Due(%v) = %v, want %v", tt.now, got, tt.due)
+		}
+		if got := s.Date(tt.now).Format("2006-01-02"); got != tt.date {
+			t.Errorf("Date(%v) = %s, want %s", tt.now, got, tt.date)
+		}
+	}
+
+	for _, bad := range [][2]string{{"25:00", "UTC"}, {"noon", "UTC"}, {"12:00", "Mars/Olympus"}} {
+		if _, err := ParseSchedule(bad[0], bad[1]); err == nil {
+			t.Errorf("ParseSchedule(%q, %q) error = nil, want an error", bad[0], bad[1])
+		}
+	}
+}
+
+// TestRunOnce tests that the snapshots are taken once the scheduled time has
+// passed and only once per day
+func TestRunOnce(t *testing.T) {
+	db := testkit.DB(t)
+	fx := testkit.NewFixtures(t, db)
+	fx.Warehouse()
+	fx.Customer()
+	fx.Customer()
+
+	schedule, _ := ParseSchedule("23:55", "UTC")
+	taker := New(db, schedule)
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	if result, err := taker.RunOnce(day.Add(12 * time.Hour)); err != nil || result != nil {
+		t.Errorf("RunOnce() before the scheduled time = %+v, %v, want nothing", result, err)
+	}
+	result, err := taker.RunOnce(day.Add(23*time.Hour + 56*time.Minute))
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	if !result.Created || result.Snapshots != 3 || result.Date != "2024-03-04" {
+		t.Errorf("RunOnce() = %+v, want 3 snapshots for 2024-03-04", result)
+	}
+	// e.g. a restart later that evening
+	result, err = taker.RunOnce(day.Add(23*time.Hour + 59*time.Minute))
+	if err != nil || result.Created {
+		t.Errorf("second RunOnce() = %+v, %v, want the day skipped", result, err)
+	}
+
+	var count int64
+	db.Model(&models.InventorySnapshot{}).Where("snapshot_reason = ?", Reason).Count(&count)
+	if count != 3 {
+		t.Errorf("daily snapshots = %d, want 3", count)
+	}
+}
@@ -0,0 +1,236 @@
+// Package spreadsheet reads uploaded tables: a CSV file, or the first sheet
+// of an Excel workbook. It is the reading side of the export package and
+// understands the workbooks Excel and LibreOffice write as well as its own.
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+
+	"LogiTrackPro/backend/internal/export"
+)
+
+// maxPartBytes bounds a decompressed workbook part, so a small upload
+// cannot expand without limit
+const maxPartBytes = 64 << 20
+
+// Row is a row of a table with its number as a spreadsheet shows it,
+// counting from 1. Rows that are empty in a workbook are left out.
+type Row struct {
+	Number int
+	Cells  []string
+}
+
+// Read reads the rows of a table in format (export.CSV or export.XLSX)
+func Read(format string, data []byte) ([]Row, error) {
+	switch format {
+	case export.CSV:
+		return readCSV(data)
+	case export.XLSX:
+		return readXLSX(data)
+	}
+	return nil, fmt.Errorf("unknown spreadsheet format %q (use csv or xlsx)", format)
+}
+
+// FormatOf returns the format of a file by its name's extension
+func FormatOf(filename string) (string, bool) {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv":
+		return export.CSV, true
+	case ".xlsx":
+		return export.XLSX, true
+	}
+	return "", false
+}
+
+func readCSV(data []byte) ([]Row, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	var rows []Row
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := r.FieldPos(0)
+		rows = append(rows, Row{Number: line, Cells: record})
+	}
+}
+
+type xlsxWorkbook struct {
+	Sheets []struct {
+		RelID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// xlsxText is a shared or inline string: plain, or made of formatted runs
+type xlsxText struct {
+	Text string `xml:"t"`
+	Runs []struct {
+		Text string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t xlsxText) String() string {
+	s := t.Text
+	for _, r := range t.Runs {
+		s += r.Text
+	}
+	return s
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxText `xml:"si"`
+}
+
+type xlsxWorksheet struct {
+	Rows []struct {
+		Number int `xml:"r,attr"`
+		Cells  []struct {
+			Ref    string   `xml:"r,attr"`
+			Type   string   `xml:"t,attr"`
+			Value  string   `xml:"v"`
+			Inline xlsxText `xml:"is"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+func readXLSX(data []byte) ([]Row, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not an xlsx workbook: %w", err)
+	}
+	parts := make(map[string]*zip.File)
+	for _, f := range zr.File {
+		parts[strings.TrimPrefix(f.Name, "/")] = f
+	}
+
+	sheetPath, err := firstSheetPath(parts)
+	if err != nil {
+		return nil, err
+	}
+	var shared xlsxSharedStrings
+	if _, ok := parts["xl/sharedStrings.xml"]; ok {
+		if err := decodePart(parts, "xl/sharedStrings.xml", &shared); err != nil {
+			return nil, err
+		}
+	}
+	var sheet xlsxWorksheet
+	if err := decodePart(parts, sheetPath, &sheet); err != nil {
+		return nil, err
+	}
+
+	var rows []Row
+	for i, r := range sheet.Rows {
+		row := Row{Number: r.Number}
+		if row.Number == 0 {
+			row.Number = i + 1
+		}
+		empty := true
+		for j, c := range r.Cells {
+			col := j
+			if c.Ref != "" {
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			var value string
+			switch c.Type {
+			case "s":
+				k, err := strconv.Atoi(c.Value)
+				if err != nil || k < 0 || k >= len(shared.Items) {
+					return nil, fmt.Errorf("cell %s refers to a missing shared string", c.Ref)
+				}
+				value = shared.Items[k].String()
+			case "inlineStr":
+				value = c.Inline.String()
+			default:
+				value = c.Value
+			}
+			if value == "" {
+				continue
+			}
+			for len(row.Cells) <= col {
+				row.Cells = append(row.Cells, "")
+			}
+			row.Cells[col], empty = value, false
+		}
+		if !empty {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// firstSheetPath finds the part of the workbook's first sheet
+func firstSheetPath(parts map[string]*zip.File) (string, error) {
+	var workbook xlsxWorkbook
+	if err := decodePart(parts, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	if len(workbook.Sheets) == 0 {
+		return "", errors.New("workbook has no sheets")
+	}
+	var rels xlsxRelationships
+	if err := decodePart(parts, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+	for _, r := range rels.Relationships {
+		if r.ID != workbook.Sheets[0].RelID {
+			continue
+		}
+		if strings.HasPrefix(r.Target, "/") {
+			return strings.TrimPrefix(r.Target, "/"), nil
+		}
+		return path.Join("xl", r.Target), nil
+	}
+	return "", errors.New("workbook does not say where its first sheet is")
+}
+
+// decodePart unmarshals a workbook part
+func decodePart(parts map[string]*zip.File, name string, v interface{}) error {
+	f, ok := parts[name]
+	if !ok {
+		return fmt.Errorf("workbook has no %s", name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, maxPartBytes)).Decode(v); err != nil {
+		return fmt.Errorf("read %s: %w", name, err)
+	}
+	return nil
+}
+
+// columnIndex returns the zero-based column of a cell reference like "AB12"
+func columnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref) && ref[i] >= 'A' && ref[i] <= 'Z'; i++ {
+		col = col*26 + int(ref[i]-'A'+1)
+	}
+	if i == 0 || col > 16384 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
@@ -0,0 +1,82 @@
+package spreadsheet
+
+import (
+	"archive/zip"
+	"bytes"
+	"reflect"
+	"testing"
+
+	"LogiTrackPro/backend/internal/export"
+)
+
+// TestReadExport tests reading back what the export package writes
+func TestReadExport(t *testing.T) {
+	for _, format := range []string{export.CSV, export.XLSX} {
+		var buf bytes.Buffer
+		w, _ := export.New(format, &buf)
+		w.Sheet("Customers", "name", "latitude")
+		w.Row("North <A&B>", 52.5)
+		w.Row("South", nil)
+		if err := w.Close(); err != nil {
+			t.Fatalf("%s: Close() error = %v", format, err)
+		}
+
+		rows, err := Read(format, buf.Bytes())
+		if err != nil {
+			t.Fatalf("%s: Read() error = %v", format, err)
+		}
+		if format == export.CSV {
+			// the CSV writer puts the sheet name first
+			rows = rows[1:]
+		}
+		want := [][]string{{"name", "latitude"}, {"North <A&B>", "52.5"}, {"South"}}
+		if len(rows) != len(want) {
+			t.Fatalf("%s: rows = %+v, want %v", format, rows, want)
+		}
+		for i, r := range rows {
+			cells := r.Cells
+			if format == export.CSV && len(cells) == 2 && cells[1] == "" {
+				cells = cells[:1]
+			}
+			if !reflect.DeepEqual(cells, want[i]) {
+				t.Errorf("%s: row %d = %q, want %q", format, r.Number, cells, want[i])
+			}
+		}
+	}
+}
+
+// TestReadSharedStrings tests a workbook as Excel writes it: shared
+// strings, rich text, skipped cells and rows, and a first sheet that is not
+// sheet1.xml
+func TestReadSharedStrings(t *testing.T) {
+	parts := map[string]string{
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+			`<sheets><sheet name="Import" sheetId="1" r:id="rId7"/><sheet name="Other" sheetId="2" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Target="worksheets/sheet1.xml"/><Relationship Id="rId7" Target="/xl/worksheets/sheet2.xml"/></Relationships>`,
+		"xl/sharedStrings.xml": `<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+			`<si><t>name</t></si><si><t>ref</t></si><si><r><t>Acme </t></r><r><t>Fuels</t></r></si></sst>`,
+		"xl/worksheets/sheet2.xml": `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>` +
+			`<row r="1"><c r="A1" t="s"><v>0</v></c><c r="C1" t="s"><v>1</v></c></row>` +
+			`<row r="2"><c r="A2"/></row>` +
+			`<row r="4"><c r="A4" t="s"><v>2</v></c><c r="C4"><v>17</v></c></row>` +
+			`</sheetData></worksheet>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData><row r="1"><c r="A1"><v>1</v></c></row></sheetData></worksheet>`,
+	}
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, body := range parts {
+		f, _ := zw.Create(name)
+		f.Write([]byte(body))
+	}
+	zw.Close()
+
+	rows, err := Read(export.XLSX, buf.Bytes())
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	want := []Row{{1, []string{"name", "", "ref"}}, {4, []string{"Acme Fuels", "", "17"}}}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %+v, want %+v", rows, want)
+	}
+}
@@ -0,0 +1,19 @@
+package storage
+
+const defaultGCSEndpoint = "https://storage.googleapis.com"
+
+// NewGCS stores artifacts in a Google Cloud Storage bucket through its
+// S3-compatible XML API, authenticated with HMAC interoperability keys
+func NewGCS(bucket, accessKey, secretKey, endpoint string) (*S3, error) {
+	if endpoint == "" {
+		endpoint = defaultGCSEndpoint
+	}
+	return NewS3(S3Config{
+		Endpoint:  endpoint,
+		Region:    "auto",
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		PathStyle: true,
+	})
+}
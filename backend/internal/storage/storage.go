@@ -0,0 +1,151 @@
+// Package storage keeps binary artifacts (exports, proofs of delivery,
+// attachments, backups) in local disk, S3 or Google Cloud Storage behind one
+// interface, with signed download URLs and age-based cleanup.
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+)
+
+// Supported drivers for STORAGE_DRIVER
+const (
+	DriverLocal = "local"
+	DriverS3    = "s3"
+	DriverGCS   = "gcs"
+)
+
+// Key prefixes used by features that produce files
+const (
+	ExportsPrefix   = "exports/"
+	PODPrefix       = "pod/"
+	IncidentsPrefix = "incidents/"
+)
+
+var (
+	ErrNotFound   = errors.New("object not found")
+	ErrInvalidKey = errors.New("invalid object key")
+)
+
+// Object describes a stored artifact
+type Object struct {
+	Key     string
+	Size    int64
+	ModTime time.Time
+}
+
+// Storage stores artifacts by slash-separated key
+type Storage interface {
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get returns ErrNotFound for missing keys
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete does not fail for missing keys
+	Delete(ctx context.Context, key string) error
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// SignedURL returns a URL that downloads the object without other
+	// credentials until it expires
+	SignedURL(ctx context.Context, key string, expiry time.Duration) (string, error)
+}
+
+// Config selects and configures a driver
+type Config struct {
+	Driver string
+
+	// local: files live under LocalDir and signed URLs point at PublicURL,
+	// signed with SigningSecret
+	LocalDir      string
+	PublicURL     string
+	SigningSecret string
+
+	// s3 and gcs (HMAC interoperability keys)
+	Bucket    string
+	Region    string
+	Endpoint  string // S3-compatible endpoint, e.g. MinIO; empty for AWS or GCS
+	AccessKey string
+	SecretKey string
+}
+
+// New builds the storage for a driver name; an empty name means local
+func New(cfg Config) (Storage, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "", DriverLocal:
+		return NewLocal(cfg.LocalDir, cfg.PublicURL, []byte(cfg.SigningSecret))
+	case DriverS3:
+		return NewS3(S3Config{
+			Endpoint:  cfg.Endpoint,
+			Region:    cfg.Region,
+			Bucket:    cfg.Bucket,
+			AccessKey: cfg.AccessKey,
+			SecretKey: cfg.SecretKey,
+			PathStyle: cfg.Endpoint != "",
+		})
+	case DriverGCS:
+		return NewGCS(cfg.Bucket, cfg.AccessKey, cfg.SecretKey, cfg.Endpoint)
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+	}
+}
+
+// cleanKey normalizes a key and rejects keys that could escape the bucket or
+// storage directory
+func cleanKey(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	cleaned := path.Clean(key)
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", ErrInvalidKey
+	}
+	return cleaned, nil
+}
+
+// Rule deletes objects under Prefix once they are older than MaxAge
+type Rule struct {
+	Prefix string
+	MaxAge time.Duration
+}
+
+// Cleanup applies lifecycle rules and returns the number of deleted objects
+func Cleanup(ctx context.Context, s Storage, rules []Rule, now time.Time) (int, error) {
+	deleted := 0
+	for _, rule := range rules {
+		if rule.MaxAge <= 0 {
+			continue
+		}
+		objects, err := s.List(ctx, rule.Prefix)
+		if err != nil {
+			return deleted, fmt.Errorf("list %s: %w", rule.Prefix, err)
+		}
+		for _, o := range objects {
+			if now.Sub(o.ModTime) <= rule.MaxAge {
+				continue
+			}
+			if err := s.Delete(ctx, o.Key); err != nil {
+				return deleted, fmt.Errorf("delete %s: %w", o.Key, err)
+			}
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// CleanupJobType is the periodic job that applies lifecycle rules
+const CleanupJobType = "storage.cleanup"
+
+// CleanupJob returns a background job applying rules to s
+func CleanupJob(s Storage, rules []Rule) func(ctx context.Context, payload json.RawMessage) error {
+	return func(ctx context.Context, _ json.RawMessage) error {
+		deleted, err := Cleanup(ctx, s, rules, time.Now())
+		if deleted > 0 {
+			log.Printf("Storage cleanup: deleted %d expired objects", deleted)
+		}
+		return err
+	}
+}
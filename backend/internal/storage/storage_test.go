@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+func TestCleanKey(t *testing.T) {
+	valid := map[string]string{
+		"exports/run.json":      "exports/run.json",
+		"exports//a/./run.json": "exports/a/run.json",
+	}
+	for key, want := range valid {
+		if got, err := cleanKey(key); err != nil || got != want {
+			t.Errorf("cleanKey(%q) = %q, %v, want %q", key, got, err, want)
+		}
+	}
+	for _, key := range []string{"", "/etc/passwd", "../secret", "exports/../../secret", "a\\b", "."} {
+		if _, err := cleanKey(key); err != ErrInvalidKey {
+			t.Errorf("cleanKey(%q) error = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
+
+// TestLocalStorage tests the local driver round trip, signed URLs and
+// lifecycle cleanup
+func TestLocalStorage(t *testing.T) {
+	s, err := NewLocal(t.TempDir(), "https://api.example.com/api/v1/files", []byte("secret"))
+	if err != nil {
+		t.Fatalf("NewLocal() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if err := s.Put(ctx, "exports/runs/1.json", strings.NewReader("one"), 3, "application/json"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := s.Put(ctx, "pods/stop-7.jpg", strings.NewReader("photo"), 5, "image/jpeg"); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	r, err := s.Get(ctx, "exports/runs/1.json")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	body, _ := io.ReadAll(r)
+	r.Close()
+	if string(body) != "one" {
+		t.Errorf("Get() = %q, want one", body)
+	}
+	if _, err := s.Get(ctx, "exports/missing.json"); err != ErrNotFound {
+		t.Errorf("Get() of missing key error = %v, want ErrNotFound", err)
+	}
+	if err := s.Put(ctx, "../escape", strings.NewReader("x"), 1, ""); err != ErrInvalidKey {
+		t.Errorf("Put() outside the root error = %v, want ErrInvalidKey", err)
+	}
+
+	signed, err := s.SignedURL(ctx, "exports/runs/1.json", time.Minute)
+	if err != nil {
+		t.Fatalf("SignedURL() error = %v", err)
+	}
+	u, _ := url.Parse(signed)
+	if u.Host != "api.example.com" || u.Path != "/api/v1/files/exports/runs/1.json" {
+		t.Errorf("SignedURL() = %s", signed)
+	}
+	expires, signature := u.Query().Get("expires"), u.Query().Get("signature")
+	if err := s.VerifySignature("exports/runs/1.json", expires, signature, time.Now()); err != nil {
+		t.Errorf("VerifySignature() error = %v", err)
+	}
+	if err := s.VerifySignature("pods/stop-7.jpg", expires, signature, time.Now()); err != ErrInvalidSignature {
+		t.Errorf("VerifySignature() for another key error = %v, want ErrInvalidSignature", err)
+	}
+	if err := s.VerifySignature("exports/runs/1.json", expires, signature, time.Now().Add(2*time.Minute)); err != ErrInvalidSignature {
+		t.Errorf("VerifySignature() after expiry error = %v, want ErrInvalidSignature", err)
+	}
+
+	// Links expire by the storage clock, the one VerifySignature is given
+	frozen := testkit.NewClock(time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC))
+	s.SetClock(frozen)
+	signed, _ = s.SignedURL(ctx, "exports/runs/1.json", time.Minute)
+	u, _ = url.Parse(signed)
+	expires, signature = u.Query().Get("expires"), u.Query().Get("signature")
+	if err := s.VerifySignature("exports/runs/1.json", expires, signature, frozen.Now()); err != nil {
+		t.Errorf("VerifySignature() on the storage clock error = %v", err)
+	}
+	if err := s.VerifySignature("exports/runs/1.json", expires, signature, time.Now()); err != ErrInvalidSignature {
+		t.Errorf("VerifySignature() of a link signed in 2024 error = %v, want ErrInvalidSignature", err)
+	}
+
+	// Age the export past the retention period
+	p, _ := s.Path("exports/runs/1.json")
+	old := time.Now().Add(-48 * time.Hour)
+	os.Chtimes(p, old, old)
+	deleted, err := Cleanup(ctx, s, []Rule{{Prefix: ExportsPrefix, MaxAge: 24 * time.Hour}}, time.Now())
+	if err != nil || deleted != 1 {
+		t.Fatalf("Cleanup() = %d, %v, want 1 deleted", deleted, err)
+	}
+	objects, _ := s.List(ctx, "")
+	if len(objects) != 1 || objects[0].Key != "pods/stop-7.jpg" {
+		t.Errorf("objects after cleanup = %+v, want only pods/stop-7.jpg", objects)
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{Driver: "s3", Bucket: "b"}); err == nil {
+		t.Error("New(s3) without credentials succeeded, want error")
+	}
+	if _, err := New(Config{Driver: "ftp"}); err == nil {
+		t.Error("New(ftp) succeeded, want error")
+	}
+	s, err := New(Config{Driver: "gcs", Bucket: "b", AccessKey: "k", SecretKey: "s"})
+	if err != nil {
+		t.Fatalf("New(gcs) error = %v", err)
+	}
+	signed, _ := s.SignedURL(context.Background(), "a.json", time.Minute)
+	if !strings.HasPrefix(signed, "https://storage.googleapis.com/b/a.json?") || !strings.Contains(signed, "%2Fauto%2Fs3%2Faws4_request") {
+		t.Errorf("gcs SignedURL() = %s", signed)
+	}
+}
@@ -0,0 +1,158 @@
+package testkit
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/planstate"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// Password is the password of every user created by Fixtures.User
+const Password = "password123"
+
+// Fixtures builds and stores test records with working defaults. Every
+// builder takes optional functions that adjust the record before it is
+// stored; any error fails the test.
+type Fixtures struct {
+	t  testing.TB
+	db *gorm.DB
+	n  int
+}
+
+func NewFixtures(t testing.TB, db *gorm.DB) *Fixtures {
+	return &Fixtures{t: t, db: db}
+}
+
+// create stores a record built by the caller after applying mods
+func create[T any](f *Fixtures, record *T, mods []func(*T)) *T {
+	f.t.Helper()
+	for _, mod := range mods {
+		mod(record)
+	}
+	if err := f.db.Create(record).Error; err != nil {
+		f.t.Fatalf("create %T: %v", record, err)
+	}
+	return record
+}
+
+func (f *Fixtures) next() int {
+	f.n++
+	return f.n
+}
+
+// User creates a user with a role and Password
+func (f *Fixtures) User(role string, mods ...func(*models.User)) *models.User {
+	f.t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.MinCost)
+	if err != nil {
+		f.t.Fatalf("hash password: %v", err)
+	}
+	n := f.next()
+	return create(f, &models.User{
+		Email:    fmt.Sprintf("%s%d@example.com", role, n),
+		Password: string(hash),
+		Name:     fmt.Sprintf("User %d", n),
+		Role:     role,
+	}, mods)
+}
+
+// Warehouse creates a warehouse in New York with plenty of stock
+func (f *Fixtures) Warehouse(mods ...func(*models.Warehouse)) *models.Warehouse {
+	f.t.Helper()
+	return create(f, &models.Warehouse{
+		Name:         fmt.Sprintf("Warehouse %d", f.next()),
+		Latitude:     40.7128,
+		Longitude:    -74.0060,
+		Capacity:     10000,
+		CurrentStock: 5000,
+	}, mods)
+}
+
+// Customer creates a customer near the default warehouse that needs a
+// delivery within a few days
+func (f *Fixtures) Customer(mods ...func(*models.Customer)) *models.Customer {
+	f.t.Helper()
+	n := f.next()
+	return create(f, &models.Customer{
+		Name:             fmt.Sprintf("Customer %d", n),
+		Latitude:         40.7 + float64(n)/100,
+		Longitude:        -74.0,
+		DemandRate:       10,
+		MaxInventory:     100,
+		CurrentInventory: 50,
+		MinInventory:     20,
+		Priority:         1,
+	}, mods)
+}
+
+// Vehicle creates an available vehicle at a warehouse
+func (f *Fixtures) Vehicle(warehouse *models.Warehouse, mods ...func(*models.Vehicle)) *models.Vehicle {
+	f.t.Helper()
+	return create(f, &models.Vehicle{
+		Name:        fmt.Sprintf("Vehicle %d", f.next()),
+		Capacity:    100,
+		CostPerKm:   1,
+		Available:   true,
+		WarehouseID: &warehouse.ID,
+	}, mods)
+}
+
+// Driver creates an active driver at a warehouse
+func (f *Fixtures) Driver(warehouse *models.Warehouse, mods ...func(*models.Driver)) *models.Driver {
+	f.t.Helper()
+	return create(f, &models.Driver{
+		Name:        fmt.Sprintf("Driver %d", f.next()),
+		Status:      "active",
+		WarehouseID: &warehouse.ID,
+	}, mods)
+}
+
+// Roster puts a driver on a vehicle for a day
+func (f *Fixtures) Roster(driver *models.Driver, vehicle *models.Vehicle, date time.Time) *models.RosterEntry {
+	f.t.Helper()
+	return create(f, &models.RosterEntry{DriverID: driver.ID, VehicleID: vehicle.ID, Date: date}, nil)
+}
+
+// Plan creates a draft plan for a warehouse covering days days from start
+func (f *Fixtures) Plan(warehouse *models.Warehouse, start time.Time, days int, mods ...func(*models.Plan)) *models.Plan {
+	f.t.Helper()
+	return create(f, &models.Plan{
+		Name:        fmt.Sprintf("Plan %d", f.next()),
+		StartDate:   start,
+		EndDate:     start.AddDate(0, 0, days-1),
+		Status:      planstate.Draft,
+		WarehouseID: &warehouse.ID,
+	}, mods)
+}
+
+// Route creates a route of a plan on a day with one 10 unit stop per
+// customer in order
+func (f *Fixtures) Route(plan *models.Plan, vehicle *models.Vehicle, day int, customers ...*models.Customer) *models.Route {
+	f.t.Helper()
+	route := &models.Route{
+		PlanID:        plan.ID,
+		VehicleID:     &vehicle.ID,
+		Day:           day,
+		Date:          plan.StartDate.AddDate(0, 0, day-1),
+		TotalDistance: 10 * float64(len(customers)),
+		TotalCost:     100 * float64(len(customers)),
+		TotalLoad:     10 * float64(len(customers)),
+	}
+	for i, c := range customers {
+		customerID := c.ID
+		route.Stops = append(route.Stops, models.Stop{CustomerID: &customerID, Sequence: i + 1, Quantity: 10})
+	}
+	return create(f, route, nil)
+}
+
+// WithStatus sets a plan's status
+func WithStatus(status string) func(*models.Plan) {
+	return func(p *models.Plan) {
+		p.Status = status
+	}
+}
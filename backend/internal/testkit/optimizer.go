@@ -0,0 +1,156 @@
+package testkit
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// Script produces the optimizer's answer to one request. A nil response
+// makes the server fail with 500.
+type Script func(req *optimizer.OptimizeRequest) *optimizer.OptimizeResponse
+
+// Optimizer is a fake optimizer service. Queued scripts answer requests in
+// order; once they are used up every request is answered by the fallback,
+// which defaults to OneStopPerVehicle.
+type Optimizer struct {
+	URL string
+
+	mu       sync.Mutex
+	script   []Script
+	fallback Script
+	requests []optimizer.OptimizeRequest
+}
+
+// NewOptimizer starts a fake optimizer that is closed when the test ends
+func NewOptimizer(t testing.TB) *Optimizer {
+	t.Helper()
+	o := &Optimizer{fallback: OneStopPerVehicle}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"healthy","version":"1.0.0"}`))
+	})
+	mux.HandleFunc("/optimize", o.serveOptimize)
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	o.URL = server.URL
+	return o
+}
+
+// Client returns an HTTP optimizer client for the fake
+func (o *Optimizer) Client() *optimizer.Client {
+	return optimizer.NewClient(o.URL)
+}
+
+// Then queues scripts to answer the next requests
+func (o *Optimizer) Then(scripts ...Script) *Optimizer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.script = append(o.script, scripts...)
+	return o
+}
+
+// Otherwise replaces the fallback script
+func (o *Optimizer) Otherwise(s Script) *Optimizer {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.fallback = s
+	return o
+}
+
+// Requests returns the requests received so far
+func (o *Optimizer) Requests() []optimizer.OptimizeRequest {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]optimizer.OptimizeRequest(nil), o.requests...)
+}
+
+// LastRequest returns the latest request, or nil before the first one
+func (o *Optimizer) LastRequest() *optimizer.OptimizeRequest {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.requests) == 0 {
+		return nil
+	}
+	req := o.requests[len(o.requests)-1]
+	return &req
+}
+
+func (o *Optimizer) serveOptimize(w http.ResponseWriter, r *http.Request) {
+	var req optimizer.OptimizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	o.mu.Lock()
+	o.requests = append(o.requests, req)
+	script := o.fallback
+	if len(o.script) > 0 {
+		script, o.script = o.script[0], o.script[1:]
+	}
+	o.mu.Unlock()
+
+	resp := script(&req)
+	if resp == nil {
+		http.Error(w, "scripted failure", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// Respond answers with resp as is
+func Respond(resp optimizer.OptimizeResponse) Script {
+	return func(*optimizer.OptimizeRequest) *optimizer.OptimizeResponse {
+		return &resp
+	}
+}
+
+// Fail answers with an HTTP error
+func Fail() Script {
+	return func(*optimizer.OptimizeRequest) *optimizer.OptimizeResponse {
+		return nil
+	}
+}
+
+// Infeasible answers that the solver found no solution
+func Infeasible(message string) Script {
+	return Respond(optimizer.OptimizeResponse{Success: false, Message: message})
+}
+
+// OneStopPerVehicle sends every vehicle out on its first available day
+// with one stop of 5 units arriving at 08:30, at customers taken round robin.
+// Each route costs 100 and is 10 km long.
+func OneStopPerVehicle(req *optimizer.OptimizeRequest) *optimizer.OptimizeResponse {
+	resp := &optimizer.OptimizeResponse{Success: true, Message: "ok"}
+	if len(req.Customers) == 0 {
+		return resp
+	}
+	start, _ := time.Parse("2006-01-02", req.StartDate)
+	for i, v := range req.Vehicles {
+		day := 1
+		if len(v.AvailableDays) > 0 {
+			day = v.AvailableDays[0]
+		}
+		resp.Routes = append(resp.Routes, optimizer.RouteResult{
+			Day:           day,
+			Date:          start.AddDate(0, 0, day-1).Format("2006-01-02"),
+			VehicleID:     v.ID,
+			TotalDistance: 10,
+			TotalCost:     100,
+			TotalLoad:     5,
+			Stops: []optimizer.StopResult{
+				{CustomerID: req.Customers[i%len(req.Customers)].ID, Sequence: 1, Quantity: 5, ArrivalTime: "08:30"},
+			},
+		})
+		resp.TotalCost += 100
+		resp.TotalDistance += 10
+	}
+	return resp
+}
@@ -0,0 +1,27 @@
+// Package testkit is the harness for end-to-end tests: an in-memory
+// database with the full schema, a fake optimizer server with scripted
+// responses, a controllable clock and fixture builders. It is only imported
+// from tests.
+package testkit
+
+import (
+	"testing"
+
+	"LogiTrackPro/backend/internal/database"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+// DB opens an in-memory SQLite database migrated like production
+func DB(t testing.TB) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := database.RunMigrations(db); err != nil {
+		t.Fatalf("Failed to migrate test database: %v", err)
+	}
+	return db
+}
@@ -0,0 +1,47 @@
+package testkit
+
+import (
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/optimizer"
+)
+
+// TestOptimizerScript tests that scripts answer in order before the fallback
+func TestOptimizerScript(t *testing.T) {
+	opt := NewOptimizer(t).Then(Fail(), Infeasible("no vehicles"))
+	client := opt.Client()
+	req := &optimizer.OptimizeRequest{
+		StartDate: "2024-01-01",
+		Customers: []optimizer.CustomerData{{ID: 7}},
+		Vehicles:  []optimizer.VehicleData{{ID: 3, AvailableDays: []int{2}}},
+	}
+
+	if _, err := client.Optimize(req); err == nil {
+		t.Error("first Optimize() error = nil, want the scripted failure")
+	}
+	if resp, err := client.Optimize(req); err != nil || resp.Success || resp.Message != "no vehicles" {
+		t.Errorf("second Optimize() = %+v, %v, want infeasible", resp, err)
+	}
+	resp, err := client.Optimize(req)
+	if err != nil || len(resp.Routes) != 1 {
+		t.Fatalf("third Optimize() = %+v, %v, want one route", resp, err)
+	}
+	if r := resp.Routes[0]; r.Day != 2 || r.Date != "2024-01-02" || r.Stops[0].CustomerID != 7 {
+		t.Errorf("route = %+v, want customer 7 on day 2", r)
+	}
+	if n := len(opt.Requests()); n != 3 {
+		t.Errorf("recorded %d requests, want 3", n)
+	}
+}
+
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	c := NewClock(start)
+	if !c.Now().Equal(start) {
+		t.Errorf("Now() = %v, want %v", c.Now(), start)
+	}
+	if got := c.Advance(time.Hour); !got.Equal(start.Add(time.Hour)) || !c.Now().Equal(got) {
+		t.Errorf("Advance() = %v, Now() = %v, want 09:00", got, c.Now())
+	}
+}
@@ -0,0 +1,102 @@
+// Package usage defines the metered actions of an organization, the periods
+// they are counted in and how quotas are resolved.
+package usage
+
+import (
+	"net/http"
+	"time"
+
+	"LogiTrackPro/backend/internal/models"
+)
+
+// Metrics
+const (
+	Optimizations = "optimizations" // optimizer runs per calendar month
+	Customers     = "customers"     // customers stored
+	APICalls      = "api_calls"     // authenticated API requests per day
+)
+
+// Limits are quotas per metric; 0 means unlimited
+type Limits struct {
+	OptimizationsPerMonth int
+	Customers             int
+	APICallsPerDay        int
+}
+
+// Resolve applies an organization's quota overrides to the defaults
+func Resolve(org *models.Organization, defaults Limits) Limits {
+	limits := defaults
+	if org == nil {
+		return limits
+	}
+	if org.MaxOptimizationsPerMonth != nil {
+		limits.OptimizationsPerMonth = *org.MaxOptimizationsPerMonth
+	}
+	if org.MaxCustomers != nil {
+		limits.Customers = *org.MaxCustomers
+	}
+	if org.MaxAPICallsPerDay != nil {
+		limits.APICallsPerDay = *org.MaxAPICallsPerDay
+	}
+	return limits
+}
+
+// Limit returns the quota for metric
+func (l Limits) Limit(metric string) int {
+	switch metric {
+	case Optimizations:
+		return l.OptimizationsPerMonth
+	case Customers:
+		return l.Customers
+	case APICalls:
+		return l.APICallsPerDay
+	}
+	return 0
+}
+
+// Period returns the counter period of metric at now, and when it resets.
+// Customers are a stored total and have no period.
+func Period(metric string, now time.Time) (period string, resetsAt *time.Time) {
+	now = now.UTC()
+	switch metric {
+	case Optimizations:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		reset := start.AddDate(0, 1, 0)
+		return start.Format("2006-01"), &reset
+	case APICalls:
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		reset := start.AddDate(0, 0, 1)
+		return start.Format("2006-01-02"), &reset
+	}
+	return "", nil
+}
+
+// ExceededStatus is the HTTP status for an exceeded quota: 429 for the
+// request rate, which recovers by itself, and 402 for plan limits, which
+// need a bigger plan
+func ExceededStatus(metric string) int {
+	if metric == APICalls {
+		return http.StatusTooManyRequests
+	}
+	return http.StatusPaymentRequired
+}
+
+// Metric is the usage of one metric
+type Metric struct {
+	Metric   string     `json:"metric"`
+	Used     int64      `json:"used"`
+	Limit    int        `json:"limit"` // 0 = unlimited
+	Period   string     `json:"period,omitempty"`
+	ResetsAt *time.Time `json:"resets_at,omitempty"`
+}
+
+// Exceeded reports whether one more use would exceed the limit
+func (m Metric) Exceeded() bool {
+	return m.Limit > 0 && m.Used >= int64(m.Limit)
+}
+
+// Report is an organization's usage against its quotas
+type Report struct {
+	Organization *models.Organization `json:"organization"`
+	Metrics      []Metric             `json:"metrics"`
+}
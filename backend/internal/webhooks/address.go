@@ -0,0 +1,67 @@
+package webhooks
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrBlockedAddress is returned for endpoints on loopback, private,
+// link-local and other non-public addresses, which subscriptions must not
+// reach unless private addresses are allowed
+var ErrBlockedAddress = errors.New("webhook endpoint address is not public")
+
+// CheckURL reports whether a subscription URL may be posted to: it must be
+// http or https and, unless allowPrivate, its host must not be localhost
+// or a non-public IP. Host names are checked again on every connection,
+// once resolved.
+func CheckURL(raw string, allowPrivate bool) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid webhook URL: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("webhook URL must be http or https")
+	}
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("webhook URL has no host")
+	}
+	if allowPrivate {
+		return nil
+	}
+	if host = strings.ToLower(strings.TrimSuffix(host, ".")); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrBlockedAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && !publicIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
+
+// publicIP reports whether ip is a public unicast address
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsUnspecified() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast()
+}
+
+// dialControl refuses connections to non-public addresses after DNS
+// resolution, so a host name cannot point a subscription at the internal
+// network
+func (d *Deliverer) dialControl(network, address string, _ syscall.RawConn) error {
+	if d.allowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+		return ErrBlockedAddress
+	}
+	return nil
+}
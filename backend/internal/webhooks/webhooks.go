@@ -0,0 +1,253 @@
+// Package webhooks posts events to the endpoints of external systems that
+// subscribed to them. Publishing stores a delivery per subscription and
+// queues a job posting it, so a slow or unavailable endpoint never holds up
+// the request that caused the event; failed posts are retried by the job
+// queue with its backoff, and every attempt is recorded on the delivery for
+// debugging.
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"LogiTrackPro/backend/internal/clock"
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/jobs"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/securitylog"
+
+	"gorm.io/gorm"
+)
+
+// Event types
+const (
+	PlanOptimized      = "plan.optimized"
+	ExecutionCompleted = "execution.completed"
+	InventoryLowStock  = "inventory.low_stock"
+	// Ping is sent on request to test a subscription
+	Ping = "ping"
+)
+
+// Events lists the event types subscriptions can subscribe to
+var Events = []string{PlanOptimized, ExecutionCompleted, InventoryLowStock}
+
+// JobType is the job that posts one delivery
+const JobType = "webhooks.deliver"
+
+// MaxAttempts is how often a delivery is attempted: with the job queue's
+// backoff the last retry is about an hour after the event
+const MaxAttempts = 8
+
+// Headers of a delivery. The signature is the hex HMAC-SHA256 of the body
+// keyed with the subscription's secret, as for security event webhooks.
+const (
+	SignatureHeader = securitylog.SignatureHeader
+	EventHeader     = "X-LogiTrack-Event"
+	DeliveryHeader  = "X-LogiTrack-Delivery"
+)
+
+// maxResponseBody is how much of an endpoint's response a delivery keeps
+const maxResponseBody = 1024
+
+// Envelope is the body posted for an event
+type Envelope struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// PlanOptimizedData is the data of a plan.optimized event
+type PlanOptimizedData struct {
+	PlanID        int64   `json:"plan_id"`
+	Name          string  `json:"name"`
+	Source        string  `json:"source"` // optimize, reoptimize or roll
+	TotalCost     float64 `json:"total_cost"`
+	TotalDistance float64 `json:"total_distance"`
+}
+
+// DeliverPayload is the payload of a deliver job
+type DeliverPayload struct {
+	DeliveryID int64 `json:"delivery_id"`
+}
+
+// Publish queues an event for every active subscription to it, returning
+// the deliveries created
+func Publish(db *gorm.DB, event string, data interface{}, now time.Time) ([]models.WebhookDelivery, error) {
+	subs, err := database.ListWebhookSubscriptionsForEvent(db, event)
+	if err != nil || len(subs) == 0 {
+		return nil, err
+	}
+	body, err := json.Marshal(Envelope{Event: event, OccurredAt: now, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("encode %s event: %w", event, err)
+	}
+	deliveries := make([]models.WebhookDelivery, 0, len(subs))
+	for _, s := range subs {
+		d, err := Queue(db, s.ID, event, body, nil, now)
+		if err != nil {
+			return deliveries, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, nil
+}
+
+// Queue stores a delivery of body to a subscription and queues its job.
+// redeliveryOf is the delivery it repeats, if any.
+func Queue(db *gorm.DB, subscriptionID int64, event string, body []byte, redeliveryOf *int64, now time.Time) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{
+		SubscriptionID: subscriptionID,
+		Event:          event,
+		Payload:        string(body),
+		Status:         models.WebhookPending,
+		NextAttemptAt:  &now,
+		RedeliveryOf:   redeliveryOf,
+	}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := database.CreateWebhookDelivery(tx, d); err != nil {
+			return err
+		}
+		_, err := jobs.Enqueue(tx, JobType, DeliverPayload{DeliveryID: d.ID}, jobs.Options{RunAt: now, MaxAttempts: MaxAttempts})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("queue %s delivery: %w", event, err)
+	}
+	return d, nil
+}
+
+// Deliverer posts deliveries to their subscriptions. It only connects to
+// public addresses and does not follow redirects, which count as failed
+// attempts.
+type Deliverer struct {
+	db           *gorm.DB
+	client       *http.Client
+	clock        clock.Clock
+	allowPrivate bool
+}
+
+func NewDeliverer(db *gorm.DB) *Deliverer {
+	d := &Deliverer{db: db, clock: clock.Real}
+	dialer := &net.Dialer{Timeout: 5 * time.Second, Control: d.dialControl}
+	d.client = &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	return d
+}
+
+// AllowPrivateAddresses lets deliveries reach loopback and private
+// addresses, for receivers on the same network
+func (d *Deliverer) AllowPrivateAddresses() {
+	d.allowPrivate = true
+}
+
+// SetClock replaces the clock attempts are timed with
+func (d *Deliverer) SetClock(c clock.Clock) {
+	d.clock = c
+}
+
+// RunJob attempts the delivery of a deliver job. A failed attempt fails the
+// job, so the queue retries it. Deliveries to subscriptions deleted or
+// deactivated since are cancelled.
+func (d *Deliverer) RunJob(ctx context.Context, payload json.RawMessage) error {
+	var p DeliverPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("decode payload: %w", err)
+	}
+	delivery, err := database.GetWebhookDelivery(d.db, p.DeliveryID)
+	if err != nil {
+		if errors.Is(err, database.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if delivery.Status == models.WebhookSucceeded || delivery.Status == models.WebhookCancelled {
+		return nil
+	}
+	sub, err := database.GetWebhookSubscription(d.db, delivery.SubscriptionID)
+	if err != nil && !errors.Is(err, database.ErrNotFound) {
+		return err
+	}
+	if sub == nil || !sub.Active {
+		delivery.Status = models.WebhookCancelled
+		delivery.NextAttemptAt = nil
+		return database.SaveWebhookDelivery(d.db, delivery)
+	}
+	return d.Attempt(ctx, sub, delivery)
+}
+
+// Attempt posts a delivery once and records the outcome on it. Any status
+// other than 2xx is an error; once MaxAttempts are used up the delivery is
+// failed.
+func (d *Deliverer) Attempt(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) error {
+	started := d.clock.Now()
+	status, body, postErr := d.post(ctx, sub, delivery)
+
+	delivery.Attempts++
+	delivery.LastAttemptAt = &started
+	delivery.DurationMs = d.clock.Now().Sub(started).Milliseconds()
+	delivery.ResponseBody = body
+	delivery.ResponseStatus = nil
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if postErr == nil {
+		delivery.Status = models.WebhookSucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &started
+		delivery.NextAttemptAt = nil
+	} else {
+		delivery.LastError = postErr.Error()
+		if delivery.Attempts >= MaxAttempts {
+			delivery.Status = models.WebhookFailed
+			delivery.NextAttemptAt = nil
+		} else {
+			delivery.Status = models.WebhookRetrying
+			next := started.Add(jobs.Backoff(delivery.Attempts))
+			delivery.NextAttemptAt = &next
+		}
+	}
+	if err := database.SaveWebhookDelivery(d.db, delivery); err != nil {
+		return err
+	}
+	return postErr
+}
+
+// post sends a delivery, returning the response status and the start of
+// the response body
+func (d *Deliverer) post(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) (int, string, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "LogiTrackPro-Webhooks")
+	req.Header.Set(EventHeader, delivery.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(SignatureHeader, securitylog.Sign(sub.Secret, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", fmt.Errorf("post delivery %d: %w", delivery.ID, err)
+	}
+	defer resp.Body.Close()
+	excerpt, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, string(excerpt), fmt.Errorf("post delivery %d: endpoint returned %s", delivery.ID, resp.Status)
+	}
+	return resp.StatusCode, string(excerpt), nil
+}
@@ -0,0 +1,214 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"LogiTrackPro/backend/internal/database"
+	"LogiTrackPro/backend/internal/models"
+	"LogiTrackPro/backend/internal/securitylog"
+	"LogiTrackPro/backend/internal/testkit"
+)
+
+func TestPublishAndDeliver(t *testing.T) {
+	db := testkit.DB(t)
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+
+	var received []Envelope
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if got := r.Header.Get(SignatureHeader); got != securitylog.Sign("subscriber-secret", body) {
+			t.Errorf("signature %q does not match the body", got)
+		}
+		if r.Header.Get(EventHeader) == "" || r.Header.Get(DeliveryHeader) == "" {
+			t.Errorf("headers = %v, want the event and delivery", r.Header)
+		}
+		var e Envelope
+		json.Unmarshal(body, &e)
+		received = append(received, e)
+		w.WriteHeader(status)
+		io.WriteString(w, "thanks")
+	}))
+	defer server.Close()
+
+	sub := &models.WebhookSubscription{URL: server.URL, Events: []string{PlanOptimized}, Secret: "subscriber-secret", Active: true}
+	other := &models.WebhookSubscription{URL: server.URL, Events: []string{ExecutionCompleted}, Secret: "other-secret", Active: true}
+	inactive := &models.WebhookSubscription{URL: server.URL, Events: []string{PlanOptimized}, Secret: "inactive-secret"}
+	for _, s := range []*models.WebhookSubscription{sub, other, inactive} {
+		if err := database.CreateWebhookSubscription(db, s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Model(inactive).Update("active", false)
+
+	deliveries, err := Publish(db, PlanOptimized, PlanOptimizedData{PlanID: 7, Source: "optimize"}, now)
+	if err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if len(deliveries) != 1 || deliveries[0].SubscriptionID != sub.ID || deliveries[0].Status != models.WebhookPending {
+		t.Fatalf("deliveries = %+v, want one pending delivery to the active subscriber", deliveries)
+	}
+	queued, _ := database.ListJobs(db, "", JobType, 10)
+	if len(queued) != 1 || queued[0].MaxAttempts != MaxAttempts {
+		t.Fatalf("jobs = %+v, want one deliver job of %d attempts", queued, MaxAttempts)
+	}
+
+	d := NewDeliverer(db)
+	d.AllowPrivateAddresses()
+	d.SetClock(testkit.NewClock(now))
+	payload, _ := json.Marshal(DeliverPayload{DeliveryID: deliveries[0].ID})
+
+	// A failed attempt is recorded and fails the job, so the queue retries
+	status = http.StatusBadGateway
+	if err := d.RunJob(context.Background(), payload); err == nil {
+		t.Fatal("RunJob() succeeded on a 502, want an error so the job is retried")
+	}
+	delivery, _ := database.GetWebhookDelivery(db, deliveries[0].ID)
+	if delivery.Status != models.WebhookRetrying || delivery.Attempts != 1 || delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusBadGateway {
+		t.Errorf("after a failure delivery = %+v, want retrying after one 502", delivery)
+	}
+	if delivery.NextAttemptAt == nil || !delivery.NextAttemptAt.Equal(now.Add(30*time.Second)) {
+		t.Errorf("next attempt at %v, want after the queue's first backoff", delivery.NextAttemptAt)
+	}
+
+	status = http.StatusOK
+	if err := d.RunJob(context.Background(), payload); err != nil {
+		t.Fatalf("RunJob() error = %v", err)
+	}
+	delivery, _ = database.GetWebhookDelivery(db, deliveries[0].ID)
+	if delivery.Status != models.WebhookSucceeded || delivery.Attempts != 2 || delivery.LastError != "" || delivery.ResponseBody != "thanks" {
+		t.Errorf("after a success delivery = %+v, want succeeded on the second attempt", delivery)
+	}
+	if len(received) != 2 || received[1].Event != PlanOptimized {
+		t.Fatalf("endpoint received %+v, want the plan.optimized event twice", received)
+	}
+	if data, _ := received[1].Data.(map[string]interface{}); data["plan_id"] != float64(7) {
+		t.Errorf("data = %v, want plan 7", received[1].Data)
+	}
+
+	// Succeeded deliveries are not posted again
+	if err := d.RunJob(context.Background(), payload); err != nil || len(received) != 2 {
+		t.Errorf("RunJob() of a succeeded delivery error = %v with %d posts, want none", err, len(received))
+	}
+}
+
+func TestDeliveryFailsAfterMaxAttempts(t *testing.T) {
+	db := testkit.DB(t)
+	now := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	sub := &models.WebhookSubscription{URL: server.URL, Events: []string{ExecutionCompleted}, Secret: "secret", Active: true}
+	database.CreateWebhookSubscription(db, sub)
+	delivery, err := Queue(db, sub.ID, ExecutionCompleted, []byte(`{}`), nil, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	delivery.Attempts = MaxAttempts - 1
+	database.SaveWebhookDelivery(db, delivery)
+
+	d := NewDeliverer(db)
+	d.AllowPrivateAddresses()
+	if err := d.Attempt(context.Background(), sub, delivery); err == nil {
+		t.Fatal("Attempt() succeeded on a 500")
+	}
+	if delivery.Status != models.WebhookFailed || delivery.NextAttemptAt != nil {
+		t.Errorf("delivery = %+v, want failed without a next attempt", delivery)
+	}
+}
+
+func TestDeliveryToDeletedSubscriptionIsCancelled(t *testing.T) {
+	db := testkit.DB(t)
+	sub := &models.WebhookSubscription{URL: "http://127.0.0.1:1/hook", Events: []string{InventoryLowStock}, Secret: "secret", Active: true}
+	database.CreateWebhookSubscription(db, sub)
+	delivery, err := Queue(db, sub.ID, InventoryLowStock, []byte(`{}`), nil, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	database.DeleteWebhookSubscription(db, sub.ID)
+
+	payload := []byte(`{"delivery_id":` + strconv.FormatInt(delivery.ID, 10) + `}`)
+	if err := NewDeliverer(db).RunJob(context.Background(), payload); err != nil {
+		t.Fatalf("RunJob() error = %v, want nil", err)
+	}
+	delivery, _ = database.GetWebhookDelivery(db, delivery.ID)
+	if delivery.Status != models.WebhookCancelled || delivery.Attempts != 0 {
+		t.Errorf("delivery = %+v, want cancelled without an attempt", delivery)
+	}
+}
+
+func TestCheckURL(t *testing.T) {
+	tests := []struct {
+		url          string
+		allowPrivate bool
+		wantErr      bool
+	}{
+		{"https://erp.example.com/hooks", false, false},
+		{"http://203.0.113.10:8080/hooks", false, false},
+		{"ftp://erp.example.com/hooks", false, true},
+		{"https:///hooks", false, true},
+		{"http://localhost:8080/hooks", false, true},
+		{"http://127.0.0.1/hooks", false, true},
+		{"http://10.0.0.5/hooks", false, true},
+		{"http://169.254.169.254/latest/meta-data", false, true},
+		{"http://[::1]/hooks", false, true},
+		{"http://[fd00::1]/hooks", false, true},
+		{"http://0.0.0.0/hooks", false, true},
+		{"http://10.0.0.5/hooks", true, false},
+		{"ftp://10.0.0.5/hooks", true, true},
+	}
+	for _, tt := range tests {
+		if err := CheckURL(tt.url, tt.allowPrivate); (err != nil) != tt.wantErr {
+			t.Errorf("CheckURL(%q, %v) error = %v, want error %v", tt.url, tt.allowPrivate, err, tt.wantErr)
+		}
+	}
+}
+
+// TestDeliveryAddressPolicy tests that deliveries only reach private
+// addresses when allowed and never follow redirects
+func TestDeliveryAddressPolicy(t *testing.T) {
+	db := testkit.DB(t)
+	posted := 0
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posted++
+	}))
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	attempt := func(t *testing.T, d *Deliverer, url string) (*models.WebhookDelivery, error) {
+		t.Helper()
+		sub := &models.WebhookSubscription{URL: url, Events: []string{PlanOptimized}, Secret: "secret", Active: true}
+		database.CreateWebhookSubscription(db, sub)
+		delivery, err := Queue(db, sub.ID, PlanOptimized, []byte(`{}`), nil, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return delivery, d.Attempt(context.Background(), sub, delivery)
+	}
+
+	t.Run("private address", func(t *testing.T) {
+		if _, err := attempt(t, NewDeliverer(db), target.URL); !errors.Is(err, ErrBlockedAddress) || posted != 0 {
+			t.Errorf("Attempt() to loopback error = %v with %d posts, want ErrBlockedAddress", err, posted)
+		}
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		d := NewDeliverer(db)
+		d.AllowPrivateAddresses()
+		delivery, err := attempt(t, d, redirect.URL)
+		if err == nil || posted != 0 || delivery.ResponseStatus == nil || *delivery.ResponseStatus != http.StatusTemporaryRedirect {
+			t.Errorf("Attempt() to a redirect error = %v, delivery = %+v, want a failed 307 without following it", err, delivery)
+		}
+	})
+}
@@ -0,0 +1,225 @@
+"""
+LogiTrackPro Optimizer gRPC Server
+Serves the same solver as main.py over gRPC, streaming progress per planned day.
+Generate the stubs first:
+    python -m grpc_tools.protoc -Iproto --python_out=. --grpc_python_out=. proto/optimizer.proto
+"""
+
+from concurrent import futures
+import logging
+import queue
+import threading
+
+import grpc
+
+import optimizer_pb2
+import optimizer_pb2_grpc
+from main import (
+    CustomerData,
+    DistanceMatrix,
+    OptimizeResponse,
+    RouteResult,
+    StopResult,
+    VehicleData,
+    WarehouseData,
+)
+from solver import IRPSolver
+
+logger = logging.getLogger(__name__)
+
+
+def _to_models(request):
+    """Convert a protobuf OptimizeRequest into the pydantic models the solver uses"""
+    warehouse = WarehouseData(
+        id=request.warehouse.id,
+        latitude=request.warehouse.latitude,
+        longitude=request.warehouse.longitude,
+        stock=request.warehouse.stock,
+    )
+    customers = [
+        CustomerData(
+            id=c.id,
+            latitude=c.latitude,
+            longitude=c.longitude,
+            demand_rate=c.demand_rate,
+            max_inventory=c.max_inventory,
+            current_inventory=c.current_inventory,
+            min_inventory=c.min_inventory,
+            priority=c.priority,
+            service_tags=list(c.service_tags),
+            min_drop_size=c.min_drop_size,
+        )
+        for c in request.customers
+    ]
+    vehicles = [
+        VehicleData(
+            id=v.id,
+            capacity=v.capacity,
+            cost_per_km=v.cost_per_km,
+            fixed_cost=v.fixed_cost,
+            max_distance=v.max_distance,
+            available_days=list(v.available_days) or None,
+            max_working_hours=v.max_working_hours,
+            average_speed=v.average_speed,
+            shift_start=v.shift_start,
+            shift_end=v.shift_end,
+            allowed_tags=list(v.allowed_tags),
+        )
+        for v in request.vehicles
+    ]
+    matrix = None
+    if request.HasField("distance_matrix"):
+        matrix = DistanceMatrix(
+            location_ids=list(request.distance_matrix.location_ids),
+            distances=[list(row.values) for row in request.distance_matrix.distances],
+            durations=[list(row.values) for row in request.distance_matrix.durations],
+        )
+    locked_routes = [
+        RouteResult(
+            day=r.day,
+            date=r.date,
+            vehicle_id=r.vehicle_id,
+            total_distance=r.total_distance,
+            total_cost=r.total_cost,
+            total_load=r.total_load,
+            stops=[
+                StopResult(
+                    customer_id=s.customer_id,
+                    sequence=s.sequence,
+                    quantity=s.quantity,
+                    arrival_time=s.arrival_time,
+                )
+                for s in r.stops
+            ],
+        )
+        for r in request.locked_routes
+    ]
+    return warehouse, customers, vehicles, matrix, locked_routes
+
+
+def _to_proto(result: OptimizeResponse):
+    """Convert a solver result into a protobuf OptimizeResponse"""
+    return optimizer_pb2.OptimizeResponse(
+        success=result.success,
+        message=result.message,
+        total_cost=result.total_cost,
+        total_distance=result.total_distance,
+        routes=[
+            optimizer_pb2.Route(
+                day=r.day,
+                date=r.date,
+                vehicle_id=r.vehicle_id,
+                total_distance=r.total_distance,
+                total_cost=r.total_cost,
+                total_load=r.total_load,
+                start_time=r.start_time,
+                end_time=r.end_time,
+                stops=[
+                    optimizer_pb2.Stop(
+                        customer_id=s.customer_id,
+                        sequence=s.sequence,
+                        quantity=s.quantity,
+                        arrival_time=s.arrival_time,
+                    )
+                    for s in r.stops
+                ],
+            )
+            for r in result.routes
+        ],
+    )
+
+
+def _failure(message: str):
+    return optimizer_pb2.OptimizeResponse(success=False, message=message)
+
+
+class OptimizerServicer(optimizer_pb2_grpc.OptimizerServicer):
+    def Health(self, request, context):
+        return optimizer_pb2.HealthResponse(status="healthy")
+
+    def Optimize(self, request, context):
+        result = None
+        for event in self.OptimizeStream(request, context):
+            if event.HasField("result"):
+                result = event.result
+        return result
+
+    def OptimizeStream(self, request, context):
+        logger.info(f"Received gRPC optimization request: {len(request.customers)} customers, "
+                    f"{len(request.vehicles)} vehicles, {request.planning_horizon} days")
+
+        if not request.customers:
+            yield optimizer_pb2.OptimizeEvent(result=_failure("No customers provided"))
+            return
+        if not request.vehicles:
+            yield optimizer_pb2.OptimizeEvent(result=_failure("No vehicles provided"))
+            return
+
+        warehouse, customers, vehicles, matrix, locked_routes = _to_models(request)
+        solver = IRPSolver(
+            warehouse=warehouse,
+            customers=customers,
+            vehicles=vehicles,
+            planning_horizon=request.planning_horizon,
+            start_date=request.start_date,
+            distance_matrix=matrix,
+            locked_routes=locked_routes,
+        )
+
+        # The solver runs in a worker thread and hands progress to this
+        # generator through a queue; None marks the end of the run.
+        events = queue.Queue()
+        outcome = {}
+
+        def on_progress(day, total_days, routes, cost, distance):
+            events.put(optimizer_pb2.Progress(
+                day=day, total_days=total_days, routes=routes,
+                cost=round(cost, 2), distance=round(distance, 2),
+            ))
+
+        def run():
+            try:
+                outcome["result"] = solver.solve(progress_callback=on_progress)
+            except Exception as e:
+                outcome["error"] = e
+            finally:
+                events.put(None)
+
+        threading.Thread(target=run, daemon=True).start()
+
+        while True:
+            progress = events.get()
+            if progress is None:
+                break
+            yield optimizer_pb2.OptimizeEvent(progress=progress)
+
+        if "error" in outcome:
+            logger.error(f"Optimization failed: {outcome['error']}")
+            context.abort(grpc.StatusCode.INTERNAL, str(outcome["error"]))
+
+        result = outcome["result"]
+        logger.info(f"Optimization complete: {result.total_cost:.2f} cost, "
+                    f"{result.total_distance:.2f} km, {len(result.routes)} routes")
+        yield optimizer_pb2.OptimizeEvent(result=_to_proto(result))
+
+
+def serve(port: int):
+    """Start the gRPC server in the background and return it"""
+    max_message = 64 * 1024 * 1024
+    server = grpc.server(
+        futures.ThreadPoolExecutor(max_workers=4),
+        options=[
+            ("grpc.max_send_message_length", max_message),
+            ("grpc.max_receive_message_length", max_message),
+        ],
+    )
+    optimizer_pb2_grpc.add_OptimizerServicer_to_server(OptimizerServicer(), server)
+    server.add_insecure_port(f"[::]:{port}")
+    server.start()
+    logger.info(f"gRPC optimizer listening on port {port}")
+    return server
+
+
+if __name__ == "__main__":
+    logging.basicConfig(level=logging.INFO)
+    serve(50051).wait_for_termination()
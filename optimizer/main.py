@@ -9,6 +9,7 @@ from pydantic import BaseModel
 from typing import List, Optional
 from datetime import datetime, timedelta
 import logging
+import os
 
 from solver import IRPSolver
 
@@ -49,6 +50,8 @@ class CustomerData(BaseModel):
     current_inventory: float
     min_inventory: float
     priority: int = 1
+    service_tags: List[str] = []  # vehicle skills the customer requires
+    min_drop_size: float = 0  # smallest worthwhile delivery, 0 = any
 
 
 class VehicleData(BaseModel):
@@ -57,14 +60,18 @@ class VehicleData(BaseModel):
     cost_per_km: float
     fixed_cost: float
     max_distance: float
+    available_days: Optional[List[int]] = None  # 1-based days with a rostered driver
+    max_working_hours: float = 0  # 0 = no limit
+    average_speed: float = 0  # km/h, 0 = default 50
+    shift_start: str = ""  # HH:MM, empty = 08:00
+    shift_end: str = ""  # HH:MM, empty = no limit
+    allowed_tags: List[str] = []
 
 
-class OptimizeRequest(BaseModel):
-    warehouse: WarehouseData
-    customers: List[CustomerData]
-    vehicles: List[VehicleData]
-    planning_horizon: int
-    start_date: str
+class DistanceMatrix(BaseModel):
+    location_ids: List[int]
+    distances: List[List[float]]  # km
+    durations: List[List[float]]  # minutes
 
 
 class StopResult(BaseModel):
@@ -82,6 +89,20 @@ class RouteResult(BaseModel):
     total_cost: float
     total_load: float
     stops: List[StopResult]
+    start_time: str = ""  # HH:MM
+    end_time: str = ""  # HH:MM
+
+
+class OptimizeRequest(BaseModel):
+    warehouse: WarehouseData
+    customers: List[CustomerData]
+    vehicles: List[VehicleData]
+    planning_horizon: int
+    start_date: str
+    distance_matrix: Optional[DistanceMatrix] = None
+    # Committed routes: their deliveries count towards inventories and their
+    # customers are not visited again on their days
+    locked_routes: List[RouteResult] = []
 
 
 class OptimizeResponse(BaseModel):
@@ -92,12 +113,24 @@ class OptimizeResponse(BaseModel):
     routes: List[RouteResult]
 
 
+@app.on_event("startup")
+def start_grpc_server():
+    """Serve the gRPC API alongside HTTP when OPTIMIZER_GRPC_PORT is set"""
+    port = os.getenv("OPTIMIZER_GRPC_PORT")
+    if not port:
+        return
+    # Imported here because grpc_server depends on the models in this module
+    from grpc_server import serve
+    app.state.grpc_server = serve(int(port))
+
+
 @app.get("/health")
 async def health_check():
     """Health check endpoint"""
     return {
         "status": "healthy",
         "service": "LogiTrackPro Optimizer",
+        "version": app.version,
         "timestamp": datetime.now().isoformat()
     }
 
@@ -137,7 +170,9 @@ async def optimize(request: OptimizeRequest):
             customers=request.customers,
             vehicles=request.vehicles,
             planning_horizon=request.planning_horizon,
-            start_date=request.start_date
+            start_date=request.start_date,
+            distance_matrix=request.distance_matrix,
+            locked_routes=request.locked_routes
         )
         
         # Run optimization
@@ -11,6 +11,11 @@ from dataclasses import dataclass
 from ortools.constraint_solver import routing_enums_pb2
 from ortools.constraint_solver import pywrapcp
 
+DEFAULT_SPEED = 50  # km/h
+DEFAULT_SHIFT_START = "08:00"
+SERVICE_MINUTES = 15  # per stop
+DROP_PENALTY = 10_000_000_000  # cost of leaving a customer unserved
+
 
 @dataclass
 class StopResult:
@@ -29,6 +34,8 @@ class RouteResult:
     total_cost: float
     total_load: float
     stops: List[StopResult]
+    start_time: str = ""
+    end_time: str = ""
 
 
 @dataclass
@@ -46,24 +53,38 @@ class IRPSolver:
     
     The algorithm:
     1. For each day in the planning horizon:
-       a. Determine which customers need delivery (inventory projection)
+       a. Determine which customers need delivery (inventory projection),
+          counting the deliveries of locked routes that day
        b. Solve VRP using OR-Tools for that day
        c. Update inventory levels
     """
     
-    def __init__(self, warehouse, customers, vehicles, planning_horizon, start_date):
+    def __init__(self, warehouse, customers, vehicles, planning_horizon, start_date,
+                 distance_matrix=None, locked_routes=None):
         self.warehouse = warehouse
         self.customers = {c.id: c for c in customers}
-        self.vehicles = {v.id: v for v in vehicles}
+        self.all_vehicles = {v.id: v for v in vehicles}
+        self.vehicles = dict(self.all_vehicles)
         self.planning_horizon = planning_horizon
         self.start_date = datetime.strptime(start_date, "%Y-%m-%d")
         
         # Build distance matrix
         self.locations = self._build_locations()
-        self.distance_matrix = self._compute_distance_matrix()
+        self.distance_matrix = self._compute_distance_matrix(distance_matrix)
+        self.duration_matrix = self._compute_duration_matrix(distance_matrix)
         
         # Track customer inventory levels
         self.inventory = {c.id: c.current_inventory for c in customers}
+        
+        # Deliveries of locked routes by 1-based day; routes outside the
+        # horizon are context only
+        self.locked_deliveries: Dict[int, Dict[int, float]] = {}
+        for route in locked_routes or []:
+            if not 1 <= route.day <= planning_horizon:
+                continue
+            day_deliveries = self.locked_deliveries.setdefault(route.day, {})
+            for stop in route.stops:
+                day_deliveries[stop.customer_id] = day_deliveries.get(stop.customer_id, 0) + stop.quantity
     
     def _build_locations(self) -> Dict[int, Tuple[float, float]]:
         """Build location dictionary with warehouse as ID 0"""
@@ -72,18 +93,27 @@ class IRPSolver:
             locations[cid] = (customer.latitude, customer.longitude)
         return locations
     
-    def _compute_distance_matrix(self) -> List[List[int]]:
+    def _compute_distance_matrix(self, road_matrix=None) -> List[List[int]]:
         """
         Compute distance matrix as integers (OR-Tools requires integers).
         Returns matrix where [i][j] is distance from location i to j in meters.
+        Road distances supplied by the backend take precedence over haversine.
         """
         ids = sorted(self.locations.keys())
         n = len(ids)
         matrix = [[0] * n for _ in range(n)]
         
+        road_index = {}
+        if road_matrix is not None:
+            road_index = {loc_id: idx for idx, loc_id in enumerate(road_matrix.location_ids)}
+        
         for i, id_i in enumerate(ids):
             for j, id_j in enumerate(ids):
                 if i != j:
+                    if id_i in road_index and id_j in road_index:
+                        dist_km = road_matrix.distances[road_index[id_i]][road_index[id_j]]
+                        matrix[i][j] = int(dist_km * 1000)
+                        continue
                     # Calculate haversine distance in meters
                     dist_km = self._haversine(
                         self.locations[id_i][0], self.locations[id_i][1],
@@ -94,6 +124,28 @@ class IRPSolver:
         
         return matrix
     
+    def _compute_duration_matrix(self, road_matrix=None) -> List[List[Optional[float]]]:
+        """
+        Driving times in minutes between locations from the road matrix
+        supplied by the backend, ordered like the distance matrix. Pairs the
+        road matrix does not cover are None and are driven at vehicle speed.
+        """
+        ids = sorted(self.locations.keys())
+        n = len(ids)
+        matrix: List[List[Optional[float]]] = [[None] * n for _ in range(n)]
+        if road_matrix is None or not road_matrix.durations:
+            return matrix
+        
+        road_index = {loc_id: idx for idx, loc_id in enumerate(road_matrix.location_ids)}
+        for i, id_i in enumerate(ids):
+            for j, id_j in enumerate(ids):
+                if i == j:
+                    matrix[i][j] = 0.0
+                elif id_i in road_index and id_j in road_index:
+                    matrix[i][j] = road_matrix.durations[road_index[id_i]][road_index[id_j]]
+        
+        return matrix
+    
     @staticmethod
     def _haversine(lat1: float, lon1: float, lat2: float, lon2: float) -> float:
         """Calculate haversine distance in kilometers"""
@@ -108,8 +160,91 @@ class IRPSolver:
         
         return R * c
     
-    def solve(self) -> OptimizeResponse:
-        """Main solving method"""
+    @staticmethod
+    def _clock_minutes(value: str, default: Optional[int] = None) -> Optional[int]:
+        """Minutes after midnight for an HH:MM time, or default when unset"""
+        if not value:
+            return default
+        hours, minutes = value.split(":")
+        return int(hours) * 60 + int(minutes)
+    
+    @staticmethod
+    def _speed(vehicle) -> float:
+        """Average speed in km/h, falling back to the default"""
+        speed = getattr(vehicle, 'average_speed', 0)
+        return speed if speed and speed > 0 else DEFAULT_SPEED
+    
+    def _shift_start(self, vehicle) -> int:
+        """Minutes after midnight the vehicle's shift starts"""
+        return self._clock_minutes(getattr(vehicle, 'shift_start', ""),
+                                   self._clock_minutes(DEFAULT_SHIFT_START))
+    
+    def _time_budget(self, vehicle) -> Optional[int]:
+        """
+        Minutes a vehicle may spend on a route, limited by its working hours
+        and the end of its shift. None means unlimited.
+        """
+        budgets = []
+        if getattr(vehicle, 'max_working_hours', 0) > 0:
+            budgets.append(int(vehicle.max_working_hours * 60))
+        shift_end = self._clock_minutes(getattr(vehicle, 'shift_end', ""))
+        if shift_end is not None:
+            budgets.append(max(0, shift_end - self._shift_start(vehicle)))
+        return min(budgets) if budgets else None
+    
+    def _can_serve(self, vehicle, cid: int) -> bool:
+        """Whether a vehicle has every service tag the customer requires"""
+        required = getattr(self.customers[cid], 'service_tags', None) or []
+        allowed = set(getattr(vehicle, 'allowed_tags', None) or [])
+        return all(tag in allowed for tag in required)
+    
+    def _min_drop(self, cid: int) -> float:
+        """Smallest quantity worth delivering to a customer"""
+        return getattr(self.customers[cid], 'min_drop_size', 0) or 0
+    
+    def _travel_minutes(self, vehicle, from_id: int, to_id: int) -> float:
+        """
+        Driving time between two locations: the road matrix duration when
+        there is one, otherwise the distance at the vehicle's speed
+        """
+        all_ids = sorted(self.locations.keys())
+        i, j = all_ids.index(from_id), all_ids.index(to_id)
+        minutes = self.duration_matrix[i][j]
+        if minutes is not None:
+            return minutes
+        dist_km = self.distance_matrix[i][j] / 1000.0
+        return dist_km / self._speed(vehicle) * 60
+    
+    def _schedule_stops(self, vehicle, date: datetime, route_customers: List[int],
+                        route_deliveries: Dict[int, float]) -> Tuple[List[StopResult], str, str]:
+        """
+        Arrival times for a route starting at the vehicle's shift start.
+        Returns the stops and the route's HH:MM start and end times.
+        """
+        start = datetime.combine(date.date(), datetime.min.time()) + \
+            timedelta(minutes=self._shift_start(vehicle))
+        current_time = start
+        stops = []
+        prev_loc = 0  # warehouse
+        for seq, cid in enumerate(route_customers, 1):
+            current_time += timedelta(minutes=self._travel_minutes(vehicle, prev_loc, cid))
+            stops.append(StopResult(
+                customer_id=cid,
+                sequence=seq,
+                quantity=round(route_deliveries[cid], 2),
+                arrival_time=current_time.strftime("%H:%M")
+            ))
+            current_time += timedelta(minutes=SERVICE_MINUTES)
+            prev_loc = cid
+        current_time += timedelta(minutes=self._travel_minutes(vehicle, prev_loc, 0))
+        return stops, start.strftime("%H:%M"), current_time.strftime("%H:%M")
+    
+    def solve(self, progress_callback=None) -> OptimizeResponse:
+        """Main solving method
+
+        progress_callback, when given, is called after each planned day with
+        (day, total_days, routes, cost, distance) for the solution so far.
+        """
         all_routes = []
         total_cost = 0
         total_distance = 0
@@ -117,12 +252,27 @@ class IRPSolver:
         for day in range(self.planning_horizon):
             current_date = self.start_date + timedelta(days=day)
             
+            # Only vehicles with a rostered driver can be used today
+            self.vehicles = self._vehicles_for_day(day + 1)
+            
+            # Customers on today's locked routes get those deliveries and
+            # no other visit
+            locked = self.locked_deliveries.get(day + 1, {})
+            for cid, quantity in locked.items():
+                if cid in self.inventory:
+                    self.inventory[cid] += quantity
+            
             # Determine customers needing delivery
-            customers_to_visit = self._get_customers_needing_delivery(day)
+            customers_to_visit = [
+                cid for cid in self._get_customers_needing_delivery(day)
+                if cid not in locked
+            ]
             
-            if not customers_to_visit:
+            if not customers_to_visit or not self.vehicles:
                 # Update inventory for next day (consume demand)
                 self._update_inventory()
+                if progress_callback:
+                    progress_callback(day + 1, self.planning_horizon, len(all_routes), total_cost, total_distance)
                 continue
             
             # Solve VRP for this day using OR-Tools
@@ -139,6 +289,9 @@ class IRPSolver:
             
             # Update inventory levels
             self._update_inventory()
+            
+            if progress_callback:
+                progress_callback(day + 1, self.planning_horizon, len(all_routes), total_cost, total_distance)
         
         return OptimizeResponse(
             success=True,
@@ -148,6 +301,13 @@ class IRPSolver:
             routes=all_routes
         )
     
+    def _vehicles_for_day(self, day: int) -> Dict[int, object]:
+        """Vehicles available on a 1-based day; no roster means every day"""
+        return {
+            vid: v for vid, v in self.all_vehicles.items()
+            if not getattr(v, 'available_days', None) or day in v.available_days
+        }
+    
     def _get_customers_needing_delivery(self, day: int) -> List[int]:
         """
         Determine which customers need delivery based on inventory projections.
@@ -179,6 +339,21 @@ class IRPSolver:
         """
         Solve Vehicle Routing Problem for a single day using OR-Tools.
         """
+        # Customers that need a skill no available vehicle has are left for
+        # the backend to report as unrouted
+        customers_to_visit = [
+            cid for cid in customers_to_visit
+            if any(self._can_serve(v, cid) for v in self.vehicles.values())
+        ]
+        
+        # Skip visits whose delivery would fall below the minimum drop size,
+        # either because the tank is nearly full or no truck can carry it
+        largest_capacity = max(v.capacity for v in self.vehicles.values())
+        customers_to_visit = [
+            cid for cid in customers_to_visit
+            if min(self.customers[cid].max_inventory - self.inventory[cid], largest_capacity)
+            >= self._min_drop(cid)
+        ]
         if not customers_to_visit:
             return []
         
@@ -271,6 +446,50 @@ class IRPSolver:
         distance_dimension = routing.GetDimensionOrDie(dimension_name)
         distance_dimension.SetGlobalSpanCostCoefficient(100)
         
+        # Add time constraint: road driving times, or driving at each
+        # vehicle's speed where there are none, plus service time, bounded by
+        # its working hours and shift end
+        vehicle_ids = list(self.vehicles.keys())
+        time_callback_indices = []
+        time_budgets = []
+        for vehicle_id in vehicle_ids:
+            vehicle = self.vehicles[vehicle_id]
+            
+            def time_callback(from_index, to_index, vehicle=vehicle):
+                from_node = manager.IndexToNode(from_index)
+                to_node = manager.IndexToNode(to_index)
+                minutes = self._travel_minutes(
+                    vehicle, index_to_customer_id[from_node], index_to_customer_id[to_node]
+                )
+                if from_node != 0:
+                    minutes += SERVICE_MINUTES
+                return int(math.ceil(minutes))
+            
+            time_callback_indices.append(routing.RegisterTransitCallback(time_callback))
+            budget = self._time_budget(vehicle)
+            time_budgets.append(budget if budget is not None else 24 * 60)
+        
+        routing.AddDimensionWithVehicleTransitAndCapacity(
+            time_callback_indices,
+            0,  # no waiting
+            time_budgets,
+            True,  # start cumul to zero
+            'Time'
+        )
+        
+        # Restrict customers to vehicles with the skills they need and let
+        # the solver drop customers no route can fit rather than fail
+        for node in range(1, num_locations):
+            cid = index_to_customer_id[node]
+            index = manager.NodeToIndex(node)
+            allowed = [
+                vehicle_index for vehicle_index, vehicle_id in enumerate(vehicle_ids)
+                if self._can_serve(self.vehicles[vehicle_id], cid)
+            ]
+            if len(allowed) < num_vehicles:
+                routing.SetAllowedVehiclesForIndex(allowed, index)
+            routing.AddDisjunction([index], DROP_PENALTY)
+        
         # Set max distance per vehicle if specified
         for vehicle_index in range(num_vehicles):
             vehicle_id = list(self.vehicles.keys())[vehicle_index]
@@ -337,30 +556,9 @@ class IRPSolver:
                 total_load = sum(route_deliveries.values())
                 
                 # Create stops with arrival times
-                stops = []
-                current_time = datetime.combine(date.date(), datetime.min.time().replace(hour=8))
-                avg_speed = 50  # km/h
-                
-                prev_loc = 0  # warehouse
-                for seq, cid in enumerate(route_customers, 1):
-                    # Calculate travel time
-                    all_ids = sorted(self.locations.keys())
-                    prev_idx = all_ids.index(prev_loc)
-                    curr_idx = all_ids.index(cid)
-                    dist_km = self.distance_matrix[prev_idx][curr_idx] / 1000.0
-                    travel_time = timedelta(hours=dist_km / avg_speed)
-                    current_time += travel_time
-                    
-                    stops.append(StopResult(
-                        customer_id=cid,
-                        sequence=seq,
-                        quantity=round(route_deliveries[cid], 2),
-                        arrival_time=current_time.strftime("%H:%M")
-                    ))
-                    
-                    # Add service time (15 min per stop)
-                    current_time += timedelta(minutes=15)
-                    prev_loc = cid
+                stops, start_time, end_time = self._schedule_stops(
+                    vehicle, date, route_customers, route_deliveries
+                )
                 
                 # Add return to warehouse distance
                 if route_customers:
@@ -378,7 +576,9 @@ class IRPSolver:
                     total_distance=round(route_distance_km, 2),
                     total_cost=round(route_cost, 2),
                     total_load=round(total_load, 2),
-                    stops=stops
+                    stops=stops,
+                    start_time=start_time,
+                    end_time=end_time
                 ))
         
         return routes
@@ -403,6 +603,8 @@ class IRPSolver:
             route_deliveries = {}
             current_location = 0  # warehouse
             remaining_capacity = vehicle.capacity
+            budget = self._time_budget(vehicle)
+            elapsed = 0.0  # minutes since the shift start
             
             while unassigned and remaining_capacity > 0:
                 # Find nearest unassigned customer
@@ -420,9 +622,18 @@ class IRPSolver:
                         customer.max_inventory
                     )
                     
-                    if delivery_qty <= 0:
+                    if delivery_qty <= 0 or not self._can_serve(vehicle, cid):
+                        continue
+                    if delivery_qty < self._min_drop(cid):
                         continue
                     
+                    # Visiting must leave time to get back to the warehouse
+                    if budget is not None:
+                        finish = (elapsed + self._travel_minutes(vehicle, current_location, cid)
+                                  + SERVICE_MINUTES + self._travel_minutes(vehicle, cid, 0))
+                        if finish > budget:
+                            continue
+                    
                     cid_idx = all_ids.index(cid)
                     dist = self.distance_matrix[current_idx][cid_idx]
                     
@@ -442,6 +653,7 @@ class IRPSolver:
                 route_customers.append(best_customer)
                 route_deliveries[best_customer] = delivery_qty
                 remaining_capacity -= delivery_qty
+                elapsed += self._travel_minutes(vehicle, current_location, best_customer) + SERVICE_MINUTES
                 current_location = best_customer
                 unassigned.discard(best_customer)
             
@@ -467,27 +679,9 @@ class IRPSolver:
                 total_load = sum(route_deliveries.values())
                 
                 # Create stops
-                stops = []
-                current_time = datetime.combine(date.date(), datetime.min.time().replace(hour=8))
-                avg_speed = 50  # km/h
-                
-                prev_loc = 0
-                for seq, cid in enumerate(route_customers, 1):
-                    prev_idx = all_ids.index(prev_loc)
-                    curr_idx = all_ids.index(cid)
-                    dist_km = self.distance_matrix[prev_idx][curr_idx] / 1000.0
-                    travel_time = timedelta(hours=dist_km / avg_speed)
-                    current_time += travel_time
-                    
-                    stops.append(StopResult(
-                        customer_id=cid,
-                        sequence=seq,
-                        quantity=round(route_deliveries[cid], 2),
-                        arrival_time=current_time.strftime("%H:%M")
-                    ))
-                    
-                    current_time += timedelta(minutes=15)
-                    prev_loc = cid
+                stops, start_time, end_time = self._schedule_stops(
+                    vehicle, date, route_customers, route_deliveries
+                )
                 
                 routes.append(RouteResult(
                     day=day + 1,
@@ -496,7 +690,9 @@ class IRPSolver:
                     total_distance=round(route_distance_km, 2),
                     total_cost=round(route_cost, 2),
                     total_load=round(total_load, 2),
-                    stops=stops
+                    stops=stops,
+                    start_time=start_time,
+                    end_time=end_time
                 ))
             
             vehicle_index += 1
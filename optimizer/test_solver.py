@@ -6,7 +6,7 @@ Tests the core algorithmic component including inventory projection, VRP solving
 import pytest
 from datetime import datetime, timedelta
 from unittest.mock import Mock, patch
-from solver import IRPSolver, OptimizeResponse
+from solver import IRPSolver, OptimizeResponse, RouteResult, StopResult
 
 
 class MockWarehouse:
@@ -29,6 +29,13 @@ class MockCustomer:
         self.priority = priority
 
 
+class MockDistanceMatrix:
+    def __init__(self, location_ids, distances, durations):
+        self.location_ids = location_ids
+        self.distances = distances
+        self.durations = durations
+
+
 class MockVehicle:
     def __init__(self, id, capacity=5000, cost_per_km=1.0, fixed_cost=100.0, max_distance=0):
         self.id = id
@@ -118,6 +125,30 @@ class TestDistanceMatrix:
         for row in matrix:
             for dist in row:
                 assert isinstance(dist, int)
+    
+    def test_travel_minutes_from_road_durations(self, sample_warehouse, sample_customers):
+        """Road durations are used for driving time instead of distance at speed"""
+        road = MockDistanceMatrix(
+            location_ids=[0, 1],
+            distances=[[0, 10], [10, 0]],
+            durations=[[0, 45], [40, 0]],
+        )
+        solver = IRPSolver(sample_warehouse, sample_customers, [], 1, "2024-01-01", distance_matrix=road)
+        vehicle = MockVehicle(id=1)
+        
+        assert solver._travel_minutes(vehicle, 0, 1) == 45
+        assert solver._travel_minutes(vehicle, 1, 0) == 40
+        # Customer 2 is not in the road matrix and is driven at speed
+        assert solver._travel_minutes(vehicle, 0, 2) == pytest.approx(
+            solver.distance_matrix[0][2] / 1000.0 / IRPSolver._speed(vehicle) * 60)
+    
+    def test_travel_minutes_without_durations(self, sample_warehouse, sample_customers):
+        """Road distances without durations are driven at the vehicle's speed"""
+        road = MockDistanceMatrix(location_ids=[0, 1], distances=[[0, 10], [10, 0]], durations=[])
+        solver = IRPSolver(sample_warehouse, sample_customers, [], 1, "2024-01-01", distance_matrix=road)
+        vehicle = MockVehicle(id=1)
+        
+        assert solver._travel_minutes(vehicle, 0, 1) == pytest.approx(10 / IRPSolver._speed(vehicle) * 60)
 
 
 class TestCustomerSelection:
@@ -336,6 +367,45 @@ class TestEndToEndSolver:
         assert result.success == True
 
 
+class TestLockedRoutes:
+    """Tests for deliveries already committed on locked routes"""
+    
+    @staticmethod
+    def _locked(day, customer_id, quantity):
+        return RouteResult(day=day, date="", vehicle_id=9, total_distance=0, total_cost=0,
+                           total_load=quantity, stops=[StopResult(customer_id, 1, quantity, "08:00")])
+    
+    def test_locked_customer_not_visited_on_locked_day(self, sample_warehouse, sample_vehicles):
+        """A customer on a locked route is not visited again that day"""
+        customer = MockCustomer(id=1, lat=40.7580, lon=-73.9855, current_inv=50, min_inv=100, demand_rate=50)
+        solver = IRPSolver(sample_warehouse, [customer], sample_vehicles, 1, "2024-01-01",
+                           locked_routes=[self._locked(1, 1, 10)])
+        result = solver.solve()
+        
+        assert all(stop.customer_id != 1 for r in result.routes if r.day == 1 for stop in r.stops)
+    
+    def test_locked_customer_served_on_later_days(self, sample_warehouse, sample_vehicles):
+        """A customer locked on one day can still get deliveries on later days"""
+        customer = MockCustomer(id=1, lat=40.7580, lon=-73.9855, current_inv=50, min_inv=100, demand_rate=50)
+        solver = IRPSolver(sample_warehouse, [customer], sample_vehicles, 3, "2024-01-01",
+                           locked_routes=[self._locked(1, 1, 10)])
+        result = solver.solve()
+        
+        assert any(stop.customer_id == 1 for r in result.routes if r.day > 1 for stop in r.stops)
+    
+    def test_locked_deliveries_count_towards_inventory(self, sample_warehouse, sample_vehicles):
+        """Locked deliveries are added to the inventory projection"""
+        customer = MockCustomer(id=1, lat=40.7580, lon=-73.9855, current_inv=150, min_inv=100, demand_rate=50)
+        solver = IRPSolver(sample_warehouse, [customer], sample_vehicles, 2, "2024-01-01",
+                           locked_routes=[self._locked(1, 1, 800), self._locked(0, 1, 5000)])
+        result = solver.solve()
+        
+        # 150 + 800 - 50 covers day 2, so no delivery is needed; the day 0
+        # route before the horizon is not counted
+        assert result.routes == []
+        assert solver.inventory[1] == 850
+
+
 class TestEdgeCases:
     """Edge case and error scenario tests"""
     